@@ -0,0 +1,281 @@
+package infraaerospike
+
+import (
+	"net"
+	"strconv"
+	"time"
+
+	as "github.com/aerospike/aerospike-client-go/v7"
+	"github.com/aerospike/aerospike-client-go/v7/types"
+	"github.com/pkg/errors"
+	infraconfig "github.com/pushwoosh/infra/config"
+)
+
+// Client is an aerospike cluster client with policies from config and metrics
+type Client struct {
+	name   string
+	cfg    *ConnectionConfig
+	client *as.Client
+
+	readPolicy       *as.BasePolicy
+	writePolicy      *as.WritePolicy
+	batchPolicy      *as.BatchPolicy
+	batchWritePolicy *as.BatchPolicy
+}
+
+// NewClient connects to the cluster. Use Container to hold named clients
+func NewClient(name string, cfg *ConnectionConfig) (*Client, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	policy, err := clientPolicy(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	hosts, err := parseHosts(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	initMetrics()
+
+	client, asErr := as.NewClientWithPolicyAndHost(policy, hosts...)
+	if asErr != nil {
+		return nil, errors.Wrap(asErr, "cannot connect to aerospike")
+	}
+
+	c := &Client{
+		name:             name,
+		cfg:              cfg,
+		client:           client,
+		readPolicy:       as.NewPolicy(),
+		writePolicy:      as.NewWritePolicy(0, cfg.Write.Expiration),
+		batchPolicy:      as.NewBatchPolicy(),
+		batchWritePolicy: as.NewWriteBatchPolicy(),
+	}
+
+	cfg.Read.apply(c.readPolicy)
+	cfg.Write.apply(&c.writePolicy.BasePolicy)
+	c.writePolicy.SendKey = cfg.Write.SendKey
+	for _, p := range []*as.BatchPolicy{c.batchPolicy, c.batchWritePolicy} {
+		cfg.Batch.apply(&p.BasePolicy)
+		if cfg.Batch.ConcurrentNodes != nil {
+			p.ConcurrentNodes = *cfg.Batch.ConcurrentNodes
+		}
+	}
+
+	return c, nil
+}
+
+func clientPolicy(cfg *ConnectionConfig) (*as.ClientPolicy, error) {
+	policy := as.NewClientPolicy()
+	policy.User = cfg.User
+	policy.ClusterName = cfg.ClusterName
+	policy.MinConnectionsPerNode = cfg.MinConnectionsPerNode
+
+	if cfg.Password != "" {
+		password, err := infraconfig.ResolveSecret(cfg.Password)
+		if err != nil {
+			return nil, errors.Wrap(err, "password")
+		}
+		policy.Password = password
+	}
+
+	if cfg.ConnectTimeout != 0 {
+		policy.Timeout = cfg.ConnectTimeout
+		policy.LoginTimeout = cfg.ConnectTimeout
+	}
+	if cfg.IdleTimeout != 0 {
+		policy.IdleTimeout = cfg.IdleTimeout
+	}
+	if cfg.ConnectionQueueSize != 0 {
+		policy.ConnectionQueueSize = cfg.ConnectionQueueSize
+	}
+
+	if cfg.TLS != nil {
+		loader := cfg.TLS.Loader()
+		if err := loader.Reload(); err != nil {
+			return nil, errors.Wrap(err, "tls")
+		}
+		policy.TlsConfig = loader.ClientConfig()
+	}
+
+	return policy, nil
+}
+
+func parseHosts(cfg *ConnectionConfig) ([]*as.Host, error) {
+	hosts := make([]*as.Host, 0, len(cfg.Hosts))
+	for _, addr := range cfg.Hosts {
+		host, port := addr, defaultPort
+		if h, p, err := net.SplitHostPort(addr); err == nil {
+			host = h
+			if port, err = strconv.Atoi(p); err != nil {
+				return nil, errors.Errorf("invalid port of host %s", addr)
+			}
+		}
+
+		h := as.NewHost(host, port)
+		if cfg.TLS != nil {
+			h.TLSName = cfg.TLS.ServerName
+		}
+		hosts = append(hosts, h)
+	}
+	return hosts, nil
+}
+
+func (c *PolicyConfig) apply(p *as.BasePolicy) {
+	if c.TotalTimeout != 0 {
+		p.TotalTimeout = c.TotalTimeout
+	}
+	if c.SocketTimeout != 0 {
+		p.SocketTimeout = c.SocketTimeout
+	}
+	if c.MaxRetries != nil {
+		p.MaxRetries = *c.MaxRetries
+	}
+}
+
+// Aerospike returns underlying client
+func (c *Client) Aerospike() *as.Client {
+	return c.client
+}
+
+// ReadPolicy returns a copy of the configured read policy to be adjusted for a call
+func (c *Client) ReadPolicy() *as.BasePolicy {
+	p := *c.readPolicy
+	return &p
+}
+
+// WritePolicy returns a copy of the configured write policy to be adjusted for a call
+func (c *Client) WritePolicy() *as.WritePolicy {
+	p := *c.writePolicy
+	return &p
+}
+
+// BatchPolicy returns a copy of the configured batch policy to be adjusted for a call
+func (c *Client) BatchPolicy() *as.BatchPolicy {
+	p := *c.batchPolicy
+	return &p
+}
+
+// Key creates a key in the configured namespace
+func (c *Client) Key(set string, value interface{}) (*as.Key, error) {
+	key, err := as.NewKey(c.cfg.Namespace, set, value)
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Get reads a record. Use IsNotFound to check whether the record doesn't exist
+func (c *Client) Get(key *as.Key, bins ...string) (record *as.Record, err error) {
+	defer func(start time.Time) { c.observe("get", start, err) }(time.Now())
+
+	record, asErr := c.client.Get(c.readPolicy, key, bins...)
+	if asErr != nil {
+		return nil, asErr
+	}
+	return record, nil
+}
+
+// Put writes bins of a record
+func (c *Client) Put(key *as.Key, bins as.BinMap) (err error) {
+	defer func(start time.Time) { c.observe("put", start, err) }(time.Now())
+
+	if asErr := c.client.Put(c.writePolicy, key, bins); asErr != nil {
+		return asErr
+	}
+	return nil
+}
+
+// Delete deletes a record and returns whether it existed
+func (c *Client) Delete(key *as.Key) (existed bool, err error) {
+	defer func(start time.Time) { c.observe("delete", start, err) }(time.Now())
+
+	existed, asErr := c.client.Delete(c.writePolicy, key)
+	if asErr != nil {
+		return false, asErr
+	}
+	return existed, nil
+}
+
+// BatchGet reads records of keys at once. Records of missing keys are nil
+func (c *Client) BatchGet(keys []*as.Key, bins ...string) (records []*as.Record, err error) {
+	defer func(start time.Time) { c.observe("batch_get", start, err) }(time.Now())
+
+	records, asErr := c.client.BatchGet(c.batchPolicy, keys, bins...)
+	if asErr != nil {
+		return records, asErr
+	}
+	return records, nil
+}
+
+// BatchPut writes bins[i] to keys[i] at once. Records are written independently,
+// the error of the first failed record is returned, the others may be written
+func (c *Client) BatchPut(keys []*as.Key, bins []as.BinMap) (err error) {
+	if len(keys) != len(bins) {
+		return errors.New("keys and bins should have the same length")
+	}
+
+	defer func(start time.Time) { c.observe("batch_put", start, err) }(time.Now())
+
+	policy := as.NewBatchWritePolicy()
+	policy.Expiration = c.writePolicy.Expiration
+	policy.SendKey = c.writePolicy.SendKey
+
+	records := make([]as.BatchRecordIfc, 0, len(keys))
+	for i, key := range keys {
+		ops := make([]*as.Operation, 0, len(bins[i]))
+		for name, value := range bins[i] {
+			ops = append(ops, as.PutOp(as.NewBin(name, value)))
+		}
+		records = append(records, as.NewBatchWrite(policy, key, ops...))
+	}
+
+	if asErr := c.client.BatchOperate(c.batchWritePolicy, records); asErr != nil {
+		return asErr
+	}
+
+	failed := 0
+	for _, r := range records {
+		if br := r.BatchRec(); br.Err != nil {
+			if err == nil {
+				err = br.Err
+			}
+			failed++
+		}
+	}
+	if err != nil {
+		return errors.Wrapf(err, "%d of %d records are not written", failed, len(records))
+	}
+
+	return nil
+}
+
+// Ping checks that the client is connected to at least one active node
+func (c *Client) Ping() error {
+	if !c.client.IsConnected() {
+		return errors.New("aerospike client is not connected")
+	}
+
+	for _, node := range c.client.GetNodes() {
+		if node.IsActive() {
+			return nil
+		}
+	}
+
+	return errors.New("no active aerospike nodes")
+}
+
+// Close closes connections to all nodes
+func (c *Client) Close() {
+	c.client.Close()
+}
+
+// IsNotFound returns true if the error means the record doesn't exist
+func IsNotFound(err error) bool {
+	var asErr as.Error
+	return errors.As(err, &asErr) && asErr.Matches(types.KEY_NOT_FOUND_ERROR)
+}
@@ -0,0 +1,143 @@
+package infraaerospike
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	infratls "github.com/pushwoosh/infra/tls"
+)
+
+const defaultPort = 3000
+
+type ConnectionsConfig map[string]*ConnectionConfig
+
+type ConnectionConfig struct {
+	// Seed hosts "host:port", port is 3000 if omitted
+	Hosts []string `mapstructure:"hosts"`
+
+	// Namespace used by Client.Key
+	Namespace string `mapstructure:"namespace"`
+
+	// optional
+	User string `mapstructure:"user"`
+
+	// Password or a secret reference, e.g. "${vault:secret/aerospike#password}". optional
+	Password string `mapstructure:"password"`
+
+	// Expected cluster name, nodes of other clusters are ignored. optional
+	ClusterName string `mapstructure:"cluster_name"`
+
+	// Timeout of the initial cluster connection and node logins. optional, default: 30s
+	ConnectTimeout time.Duration `mapstructure:"connect_timeout"`
+
+	// Idle connections are closed after this timeout, it should be less than proto-fd-idle-ms of the server. optional
+	IdleTimeout time.Duration `mapstructure:"idle_timeout"`
+
+	// Max connections per node. optional, default: 100
+	ConnectionQueueSize int `mapstructure:"connection_queue_size"`
+
+	// Connections opened per node on start and kept open. optional
+	MinConnectionsPerNode int `mapstructure:"min_connections_per_node"`
+
+	// Policy of single record reads. optional
+	Read PolicyConfig `mapstructure:"read"`
+
+	// Policy of single record writes. optional
+	Write WritePolicyConfig `mapstructure:"write"`
+
+	// Policy of batch reads and writes. optional
+	Batch BatchPolicyConfig `mapstructure:"batch"`
+
+	// TLS options, ServerName is the TLS name of the nodes. TLS is disabled if empty
+	TLS *infratls.Config `mapstructure:"tls"`
+}
+
+type PolicyConfig struct {
+	// Timeout of the whole command including retries. optional, default: 1s
+	TotalTimeout time.Duration `mapstructure:"total_timeout"`
+
+	// Timeout of one attempt. optional, default: 30s
+	SocketTimeout time.Duration `mapstructure:"socket_timeout"`
+
+	// Retries of a failed attempt. optional, default: 2 for reads, 0 for writes as they may be not idempotent
+	MaxRetries *int `mapstructure:"max_retries"`
+}
+
+type WritePolicyConfig struct {
+	PolicyConfig `mapstructure:",squash"`
+
+	// Record TTL in seconds. optional, default: namespace default TTL
+	Expiration uint32 `mapstructure:"expiration"`
+
+	// Store the user key with the record. optional
+	SendKey bool `mapstructure:"send_key"`
+}
+
+type BatchPolicyConfig struct {
+	PolicyConfig `mapstructure:",squash"`
+
+	// Nodes queried in parallel, 0 means all. optional, default: 1
+	ConcurrentNodes *int `mapstructure:"concurrent_nodes"`
+}
+
+func (c *ConnectionsConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	for name, conf := range *c {
+		if err := conf.Validate(); err != nil {
+			return errors.Wrap(err, name)
+		}
+	}
+
+	return nil
+}
+
+func (c *ConnectionConfig) Validate() error {
+	if c == nil {
+		return errors.New("empty connection config")
+	}
+
+	if len(c.Hosts) == 0 {
+		return errors.New("hosts are mandatory")
+	}
+
+	if c.ConnectTimeout < 0 || c.IdleTimeout < 0 {
+		return errors.New("timeouts should be greater than or equal to 0")
+	}
+
+	if c.ConnectionQueueSize < 0 || c.MinConnectionsPerNode < 0 {
+		return errors.New("connection counts should be greater than or equal to 0")
+	}
+
+	for name, p := range map[string]PolicyConfig{"read": c.Read, "write": c.Write.PolicyConfig, "batch": c.Batch.PolicyConfig} {
+		if err := p.Validate(); err != nil {
+			return errors.Wrap(err, name)
+		}
+	}
+
+	if c.TLS != nil {
+		if err := c.TLS.Validate(); err != nil {
+			return errors.Wrap(err, "tls")
+		}
+		// nodes are dialed by IP addresses, certificates are verified for the TLS name only
+		if c.TLS.ServerName == "" && !c.TLS.InsecureSkipVerify {
+			return errors.New("tls: server_name is mandatory")
+		}
+	}
+
+	return nil
+}
+
+func (c *PolicyConfig) Validate() error {
+	if c.TotalTimeout < 0 || c.SocketTimeout < 0 {
+		return errors.New("timeouts should be greater than or equal to 0")
+	}
+
+	if c.MaxRetries != nil && *c.MaxRetries < 0 {
+		return errors.New("max_retries should be greater than or equal to 0")
+	}
+
+	return nil
+}
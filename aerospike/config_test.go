@@ -0,0 +1,42 @@
+package infraaerospike
+
+import (
+	"testing"
+
+	as "github.com/aerospike/aerospike-client-go/v7"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectionConfig(t *testing.T) {
+	var cfg *ConnectionConfig
+	require.Error(t, cfg.Validate())
+
+	cfg = &ConnectionConfig{}
+	require.ErrorContains(t, cfg.Validate(), "hosts")
+
+	retries := -1
+	cfg = &ConnectionConfig{Hosts: []string{"localhost"}, Write: WritePolicyConfig{PolicyConfig: PolicyConfig{MaxRetries: &retries}}}
+	require.ErrorContains(t, cfg.Validate(), "write")
+
+	cfg = &ConnectionConfig{Hosts: []string{"localhost", "10.0.0.1:3100", "[::1]:3200"}}
+	require.NoError(t, cfg.Validate())
+
+	hosts, err := parseHosts(cfg)
+	require.NoError(t, err)
+	require.Len(t, hosts, 3)
+	require.Equal(t, "localhost:3000", hosts[0].String())
+	require.Equal(t, "10.0.0.1:3100", hosts[1].String())
+	require.Equal(t, 3200, hosts[2].Port)
+
+	_, err = parseHosts(&ConnectionConfig{Hosts: []string{"localhost:port"}})
+	require.Error(t, err)
+}
+
+func TestIsNotFound(t *testing.T) {
+	err := errors.Wrap(as.ErrKeyNotFound, "get")
+	require.True(t, IsNotFound(err))
+	require.False(t, IsNotFound(errors.New("timeout")))
+	require.False(t, IsNotFound(as.ErrTimeout))
+	require.False(t, IsNotFound(nil))
+}
@@ -0,0 +1,121 @@
+package infraaerospike
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	infraoperator "github.com/pushwoosh/infra/operator"
+)
+
+// Container is a simple container for holding named aerospike clients
+type Container struct {
+	mu         *sync.RWMutex
+	cfg        map[string]ConnectionConfig
+	pool       map[string]*Client
+	collectors map[string]*nodesCollector
+}
+
+var (
+	_ infraoperator.Stopper = (*Container)(nil)
+	_ infraoperator.Checker = (*Container)(nil)
+)
+
+func NewContainer() *Container {
+	return &Container{
+		mu:         &sync.RWMutex{},
+		cfg:        make(map[string]ConnectionConfig),
+		pool:       make(map[string]*Client),
+		collectors: make(map[string]*nodesCollector),
+	}
+}
+
+// Connect creates a new named client and checks that cluster nodes are active
+func (cont *Container) Connect(name string, cfg *ConnectionConfig) error {
+	client, err := NewClient(name, cfg)
+	if err != nil {
+		return err
+	}
+
+	if err = client.Ping(); err != nil {
+		client.Close()
+		return errors.Wrap(err, "cannot connect to aerospike")
+	}
+
+	// replace existing client with the same name
+	cont.Remove(name)
+
+	collector := newNodesCollector(name, client.client)
+	prometheus.MustRegister(collector)
+
+	cont.mu.Lock()
+	defer cont.mu.Unlock()
+
+	cont.pool[name] = client
+	cont.cfg[name] = *cfg
+	cont.collectors[name] = collector
+
+	return nil
+}
+
+// Get gets client from a container
+func (cont *Container) Get(name string) *Client {
+	cont.mu.RLock()
+	defer cont.mu.RUnlock()
+
+	return cont.pool[name]
+}
+
+// Remove closes named client and removes it from the container
+func (cont *Container) Remove(name string) {
+	cont.mu.Lock()
+	client := cont.pool[name]
+	collector := cont.collectors[name]
+	delete(cont.pool, name)
+	delete(cont.cfg, name)
+	delete(cont.collectors, name)
+	cont.mu.Unlock()
+
+	if collector != nil {
+		prometheus.Unregister(collector)
+	}
+
+	if client != nil {
+		client.Close()
+	}
+}
+
+// Check checks that all clients in the container have active nodes
+func (cont *Container) Check(_ context.Context) error {
+	cont.mu.RLock()
+	defer cont.mu.RUnlock()
+
+	for name, client := range cont.pool {
+		if err := client.Ping(); err != nil {
+			return errors.Wrap(err, name)
+		}
+	}
+
+	return nil
+}
+
+// Stop closes all clients in the container
+func (cont *Container) Stop(_ context.Context) error {
+	cont.Close()
+	return nil
+}
+
+// Close closes all clients in the container
+func (cont *Container) Close() {
+	cont.mu.RLock()
+	names := make([]string, 0, len(cont.pool))
+	for name := range cont.pool {
+		names = append(names, name)
+	}
+	cont.mu.RUnlock()
+
+	for _, name := range names {
+		cont.Remove(name)
+	}
+}
@@ -0,0 +1,119 @@
+package infraaerospike
+
+import (
+	"sync"
+	"time"
+
+	as "github.com/aerospike/aerospike-client-go/v7"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	RequestsCounter          *prometheus.CounterVec
+	CommandDurationHistogram *prometheus.HistogramVec
+}
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.RequestsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "aerospike_requests_total",
+			Help: "The total number of aerospike commands by result: success, miss or error",
+		}, []string{"connection", "command", "result"})
+
+		metrics.CommandDurationHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "aerospike_command_duration",
+			Help:    "The aerospike command duration",
+			Buckets: []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1},
+		}, []string{"connection", "command"})
+
+		prometheus.MustRegister(
+			metrics.RequestsCounter,
+			metrics.CommandDurationHistogram,
+		)
+	})
+}
+
+func (c *Client) observe(command string, start time.Time, err error) {
+	result := "success"
+	switch {
+	case IsNotFound(err):
+		result = "miss"
+	case err != nil:
+		result = "error"
+	}
+
+	metrics.RequestsCounter.WithLabelValues(c.name, command, result).Inc()
+	metrics.CommandDurationHistogram.WithLabelValues(c.name, command).Observe(time.Since(start).Seconds())
+}
+
+// nodesCollector exports cluster node health to prometheus
+type nodesCollector struct {
+	client *as.Client
+
+	nodes           *prometheus.Desc
+	nodeActive      *prometheus.Desc
+	openConnections *prometheus.Desc
+	failedConns     *prometheus.Desc
+	failedTends     *prometheus.Desc
+}
+
+func newNodesCollector(name string, client *as.Client) *nodesCollector {
+	labels := prometheus.Labels{"connection": name}
+	desc := func(metric, help string, nodeLabels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName("aerospike", "cluster", metric), help, nodeLabels, labels)
+	}
+
+	return &nodesCollector{
+		client: client,
+
+		nodes:           desc("nodes", "The number of active cluster nodes."),
+		nodeActive:      desc("node_active", "1 if the node is active, 0 otherwise.", "node", "host"),
+		openConnections: desc("node_open_connections", "The number of open connections to the node.", "host"),
+		failedConns:     desc("node_failed_connections_total", "The number of failed connection attempts to the node.", "host"),
+		failedTends:     desc("node_failed_tends_total", "The number of failed cluster tends of the node.", "host"),
+	}
+}
+
+func (c *nodesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.nodes
+	ch <- c.nodeActive
+	ch <- c.openConnections
+	ch <- c.failedConns
+	ch <- c.failedTends
+}
+
+func (c *nodesCollector) Collect(ch chan<- prometheus.Metric) {
+	active := 0
+	for _, node := range c.client.GetNodes() {
+		value := 0.0
+		if node.IsActive() {
+			value = 1
+			active++
+		}
+		ch <- prometheus.MustNewConstMetric(c.nodeActive, prometheus.GaugeValue, value, node.GetName(), node.GetHost().String())
+	}
+	ch <- prometheus.MustNewConstMetric(c.nodes, prometheus.GaugeValue, float64(active))
+
+	stats, err := c.client.Stats()
+	if err != nil {
+		return
+	}
+
+	for host, s := range stats {
+		nodeStats, ok := s.(map[string]interface{})
+		if !ok || host == "cluster-aggregated-stats" {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.openConnections, prometheus.GaugeValue, number(nodeStats["open-connections"]), host)
+		ch <- prometheus.MustNewConstMetric(c.failedConns, prometheus.CounterValue, number(nodeStats["connections-failed"]), host)
+		ch <- prometheus.MustNewConstMetric(c.failedTends, prometheus.CounterValue, number(nodeStats["tends-failed"]), host)
+	}
+}
+
+// number converts a json decoded stats value
+func number(v interface{}) float64 {
+	f, _ := v.(float64)
+	return f
+}
@@ -0,0 +1,53 @@
+//go:build integration
+
+package infraanalytics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	infrabatch "github.com/pushwoosh/infra/batch"
+	infraclickhouse "github.com/pushwoosh/infra/clickhouse"
+	infratestcontainers "github.com/pushwoosh/infra/test/containers"
+)
+
+func TestRecorder(t *testing.T) {
+	cfg := infratestcontainers.ClickHouse(t)
+
+	cont := infraclickhouse.NewContainer()
+	if err := cont.Connect("test", cfg); err != nil {
+		t.Fatal(err)
+	}
+	db := cont.Get("test")
+
+	ctx := context.Background()
+	recorder, err := NewRecorder[*pushOpen](db, &Config{
+		Table:        "push_opens",
+		ManageSchema: true,
+		Batch:        &infrabatch.Config{MaxItems: 100},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = recorder.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10; i++ {
+		if err = recorder.Record(ctx, &pushOpen{Time: time.Now(), AppCode: "ABCDE", UserID: int64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = recorder.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var count int
+	if err = db.QueryRowContext(ctx, "SELECT count() FROM push_opens").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 10 {
+		t.Fatalf("expected 10 events, got %d", count)
+	}
+}
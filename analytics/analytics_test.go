@@ -0,0 +1,42 @@
+package infraanalytics
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type pushOpen struct {
+	Time     time.Time `ch:"ts"`
+	AppCode  string    `ch:"app_code,LowCardinality(String)"`
+	UserID   int64
+	Tags     []string
+	internal string
+	Ignored  string `ch:"-"`
+}
+
+func TestSchema(t *testing.T) {
+	columns, err := columnsOf(reflect.TypeOf(&pushOpen{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := createTableQuery(&Config{Table: "analytics.push_opens", TTL: "ts + INTERVAL 90 DAY"}, columns)
+	expected := "CREATE TABLE IF NOT EXISTS analytics.push_opens (\n" +
+		"\t`ts` DateTime64(3),\n" +
+		"\t`app_code` LowCardinality(String),\n" +
+		"\t`user_id` Int64,\n" +
+		"\t`tags` Array(String)\n" +
+		") ENGINE = MergeTree\n" +
+		"PARTITION BY toYYYYMM(ts)\n" +
+		"ORDER BY (ts)\n" +
+		"TTL ts + INTERVAL 90 DAY"
+	if query != expected {
+		t.Fatalf("unexpected query:\n%s", query)
+	}
+
+	if query = insertQuery("push_opens", columns); !strings.HasPrefix(query, "INSERT INTO push_opens (`ts`, `app_code`") {
+		t.Fatalf("unexpected query %s", query)
+	}
+}
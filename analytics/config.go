@@ -0,0 +1,62 @@
+package infraanalytics
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/pkg/errors"
+	infrabatch "github.com/pushwoosh/infra/batch"
+)
+
+var identifier = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*(\.[a-zA-Z_][a-zA-Z0-9_]*)?$`)
+
+type Config struct {
+	// Table is a name of the events table, optionally with a database, e.g. "analytics.push_opens"
+	Table string `mapstructure:"table"`
+
+	// ManageSchema creates the table and adds missing columns on Start
+	ManageSchema bool `mapstructure:"manage_schema"`
+
+	// Sorting key columns of a created table. optional, default: the first time column
+	OrderBy []string `mapstructure:"order_by"`
+
+	// Partition expression of a created table. optional, default: toYYYYMM of the first time column
+	PartitionBy string `mapstructure:"partition_by"`
+
+	// TTL expression of a created table, e.g. "ts + INTERVAL 90 DAY". optional
+	TTL string `mapstructure:"ttl"`
+
+	// Buffering and batching of events. optional, default: batches of 10000 events or 5s
+	Batch *infrabatch.Config `mapstructure:"batch"`
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if !identifier.MatchString(c.Table) {
+		return errors.Errorf("invalid table name %q", c.Table)
+	}
+
+	for _, column := range c.OrderBy {
+		if !identifier.MatchString(column) {
+			return errors.Errorf("invalid order_by column %q", column)
+		}
+	}
+
+	if c.Batch != nil {
+		if err := c.Batch.Validate(); err != nil {
+			return errors.Wrap(err, "batch")
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) GetBatch() *infrabatch.Config {
+	if c.Batch == nil {
+		return &infrabatch.Config{MaxItems: 10000, MaxAge: 5 * time.Second}
+	}
+	return c.Batch
+}
@@ -0,0 +1,135 @@
+package infraanalytics
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+
+	"github.com/pkg/errors"
+	infrabatch "github.com/pushwoosh/infra/batch"
+	infraclickhouse "github.com/pushwoosh/infra/clickhouse"
+	infraoperator "github.com/pushwoosh/infra/operator"
+)
+
+// Recorder buffers events in memory and writes them to a ClickHouse table in batches.
+// Table columns are mapped from exported fields of the event struct, see Config.ManageSchema:
+//
+//	type PushOpen struct {
+//		Time     time.Time `ch:"ts"`
+//		AppCode  string    `ch:"app_code,LowCardinality(String)"`
+//		Platform string
+//	}
+//
+//	recorder, err := infraanalytics.NewRecorder[*PushOpen](chContainer.Get("analytics"), cfg)
+//	app.Add("push_opens", recorder)
+//	...
+//	err = recorder.Record(ctx, &PushOpen{Time: time.Now(), AppCode: code, Platform: "ios"})
+type Recorder[T any] struct {
+	db      *sql.DB
+	cfg     *Config
+	columns []*column
+	query   string
+	batcher *infrabatch.Batcher[[]any]
+}
+
+var (
+	_ infraoperator.Starter = (*Recorder[any])(nil)
+	_ infraoperator.Stopper = (*Recorder[any])(nil)
+)
+
+func NewRecorder[T any](db *sql.DB, cfg *Config) (*Recorder[T], error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	columns, err := columnsOf(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Recorder[T]{
+		db:      db,
+		cfg:     cfg,
+		columns: columns,
+		query:   insertQuery(cfg.Table, columns),
+	}
+
+	r.batcher, err = infrabatch.New("analytics_"+cfg.Table, cfg.GetBatch(), r.flush)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Record adds the event to the buffer. It blocks while the buffer is full, ctx bounds the wait
+func (r *Recorder[T]) Record(ctx context.Context, event T) error {
+	row, err := r.row(event)
+	if err != nil {
+		return err
+	}
+
+	return r.batcher.Add(ctx, row)
+}
+
+// TryRecord adds the event to the buffer without waiting. It returns infrabatch.ErrFull while the buffer is full
+func (r *Recorder[T]) TryRecord(event T) error {
+	row, err := r.row(event)
+	if err != nil {
+		return err
+	}
+
+	return r.batcher.TryAdd(row)
+}
+
+func (r *Recorder[T]) row(event T) ([]any, error) {
+	v := reflect.ValueOf(event)
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil, errors.New("nil event")
+		}
+		v = v.Elem()
+	}
+
+	row := make([]any, len(r.columns))
+	for i, c := range r.columns {
+		row[i] = c.value(v.FieldByIndex(c.index))
+	}
+
+	return row, nil
+}
+
+// Start creates the table and adds missing columns if ManageSchema is set and starts flushing
+func (r *Recorder[T]) Start(ctx context.Context) error {
+	if r.cfg.ManageSchema {
+		if err := r.EnsureTable(ctx); err != nil {
+			return err
+		}
+	}
+
+	return r.batcher.Start(ctx)
+}
+
+// Stop flushes buffered events
+func (r *Recorder[T]) Stop(ctx context.Context) error {
+	return r.batcher.Stop(ctx)
+}
+
+// EnsureTable creates the table if it doesn't exist and adds columns of new event fields
+func (r *Recorder[T]) EnsureTable(ctx context.Context) error {
+	if _, err := r.db.ExecContext(ctx, createTableQuery(r.cfg, r.columns)); err != nil {
+		return errors.Wrapf(err, "unable to create table %s", r.cfg.Table)
+	}
+
+	for _, c := range r.columns {
+		if _, err := r.db.ExecContext(ctx, addColumnQuery(r.cfg.Table, c)); err != nil {
+			return errors.Wrapf(err, "unable to add column %s to %s", c.name, r.cfg.Table)
+		}
+	}
+
+	return nil
+}
+
+func (r *Recorder[T]) flush(ctx context.Context, rows [][]any) error {
+	return infraclickhouse.Insert(ctx, r.db, r.query, rows)
+}
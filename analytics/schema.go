@@ -0,0 +1,176 @@
+package infraanalytics
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// column is a table column mapped to an event struct field
+type column struct {
+	name  string
+	typ   string
+	index []int
+	value func(v reflect.Value) any
+}
+
+// columnsOf maps exported fields of the event struct to columns. Column names are taken from `ch` tags
+// or converted to snake case, the type may be set after the name: `ch:"platform,LowCardinality(String)"`.
+// Fields tagged `ch:"-"` are skipped
+func columnsOf(t reflect.Type) ([]*column, error) {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, errors.Errorf("event should be a struct, got %s", t)
+	}
+
+	var columns []*column
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+
+		tag := field.Tag.Get("ch")
+		if tag == "-" {
+			continue
+		}
+
+		name, typ, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = snakeCase(field.Name)
+		}
+
+		chType, value, err := mapType(field.Type)
+		if err != nil {
+			return nil, errors.Wrapf(err, "field %s", field.Name)
+		}
+		if typ == "" {
+			typ = chType
+		}
+
+		columns = append(columns, &column{name: name, typ: typ, index: field.Index, value: value})
+	}
+
+	if len(columns) == 0 {
+		return nil, errors.Errorf("event %s has no columns", t)
+	}
+
+	return columns, nil
+}
+
+// mapType returns a ClickHouse type of the go type and a function converting field values to driver values
+func mapType(t reflect.Type) (string, func(v reflect.Value) any, error) {
+	if t == timeType {
+		return "DateTime64(3)", func(v reflect.Value) any { return v.Interface() }, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "String", func(v reflect.Value) any { return v.String() }, nil
+	case reflect.Bool:
+		return "Bool", func(v reflect.Value) any { return v.Bool() }, nil
+	case reflect.Int8:
+		return "Int8", func(v reflect.Value) any { return int8(v.Int()) }, nil
+	case reflect.Int16:
+		return "Int16", func(v reflect.Value) any { return int16(v.Int()) }, nil
+	case reflect.Int32:
+		return "Int32", func(v reflect.Value) any { return int32(v.Int()) }, nil
+	case reflect.Int, reflect.Int64:
+		return "Int64", func(v reflect.Value) any { return v.Int() }, nil
+	case reflect.Uint8:
+		return "UInt8", func(v reflect.Value) any { return uint8(v.Uint()) }, nil
+	case reflect.Uint16:
+		return "UInt16", func(v reflect.Value) any { return uint16(v.Uint()) }, nil
+	case reflect.Uint32:
+		return "UInt32", func(v reflect.Value) any { return uint32(v.Uint()) }, nil
+	case reflect.Uint, reflect.Uint64:
+		return "UInt64", func(v reflect.Value) any { return v.Uint() }, nil
+	case reflect.Float32:
+		return "Float32", func(v reflect.Value) any { return float32(v.Float()) }, nil
+	case reflect.Float64:
+		return "Float64", func(v reflect.Value) any { return v.Float() }, nil
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.String {
+			return "Array(String)", func(v reflect.Value) any { return v.Convert(reflect.TypeOf([]string(nil))).Interface() }, nil
+		}
+	case reflect.Map:
+		if t.Key().Kind() == reflect.String && t.Elem().Kind() == reflect.String {
+			return "Map(String, String)", func(v reflect.Value) any {
+				return v.Convert(reflect.TypeOf(map[string]string(nil))).Interface()
+			}, nil
+		}
+	}
+
+	return "", nil, errors.Errorf("unsupported type %s", t)
+}
+
+// createTableQuery returns a MergeTree table definition for the columns
+func createTableQuery(cfg *Config, columns []*column) string {
+	var timeColumn string
+	definitions := make([]string, len(columns))
+	for i, c := range columns {
+		definitions[i] = fmt.Sprintf("`%s` %s", c.name, c.typ)
+		if timeColumn == "" && strings.HasPrefix(c.typ, "DateTime") {
+			timeColumn = c.name
+		}
+	}
+
+	orderBy := "tuple()"
+	switch {
+	case len(cfg.OrderBy) > 0:
+		orderBy = "(" + strings.Join(cfg.OrderBy, ", ") + ")"
+	case timeColumn != "":
+		orderBy = "(" + timeColumn + ")"
+	}
+
+	partitionBy := cfg.PartitionBy
+	if partitionBy == "" && timeColumn != "" {
+		partitionBy = "toYYYYMM(" + timeColumn + ")"
+	}
+
+	query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n\t%s\n) ENGINE = MergeTree", cfg.Table, strings.Join(definitions, ",\n\t"))
+	if partitionBy != "" {
+		query += "\nPARTITION BY " + partitionBy
+	}
+	query += "\nORDER BY " + orderBy
+	if cfg.TTL != "" {
+		query += "\nTTL " + cfg.TTL
+	}
+
+	return query
+}
+
+func addColumnQuery(table string, c *column) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS `%s` %s", table, c.name, c.typ)
+}
+
+func insertQuery(table string, columns []*column) string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = "`" + c.name + "`"
+	}
+
+	return fmt.Sprintf("INSERT INTO %s (%s)", table, strings.Join(names, ", "))
+}
+
+func snakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
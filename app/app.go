@@ -0,0 +1,259 @@
+package infraapp
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultStartTimeout = 30 * time.Second
+	DefaultStopTimeout  = 30 * time.Second
+)
+
+// ErrStop is returned by a Runner to stop the application gracefully, Run returns nil then
+var ErrStop = errors.New("application stop requested")
+
+// Runner is a component that works until ctx is canceled, e.g. a consumer loop.
+// Returned error other than context.Canceled stops the whole application, see ErrStop.
+type Runner interface {
+	Run(ctx context.Context) error
+}
+
+// App runs components: starts them in order of registration, waits for a stop signal
+// or a component failure and stops them in reverse order.
+// A component may implement any of infraoperator.Starter, infraoperator.Stopper and Runner:
+//
+//	app := infraapp.New()
+//	app.Add("postgres", pgContainer)
+//	app.Add("consumer", consumer, infraapp.WithStopTimeout(time.Minute))
+//	app.Add("http", httpServer)
+//	err := app.Run(ctx) // blocks until ctx is canceled or something fails
+type App struct {
+	startTimeout time.Duration
+	stopTimeout  time.Duration
+
+	mu         sync.Mutex
+	components []*component
+	running    bool
+	failErr    error // guarded by mu, Fail may be called concurrently with the end of Run
+
+	failOnce sync.Once
+	failed   chan struct{}
+}
+
+type component struct {
+	name         string
+	impl         interface{}
+	startTimeout time.Duration
+	stopTimeout  time.Duration
+}
+
+// New creates an empty application
+func New(opts ...Option) *App {
+	a := &App{
+		startTimeout: DefaultStartTimeout,
+		stopTimeout:  DefaultStopTimeout,
+		failed:       make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt.apply(a)
+	}
+
+	return a
+}
+
+// Add registers a component. Components can't be added after Run is called.
+func (a *App) Add(name string, impl interface{}, opts ...ComponentOption) {
+	c := &component{
+		name:         name,
+		impl:         impl,
+		startTimeout: a.startTimeout,
+		stopTimeout:  a.stopTimeout,
+	}
+	for _, opt := range opts {
+		opt.apply(c)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.running {
+		panic("infraapp: component is added to a running application")
+	}
+
+	a.components = append(a.components, c)
+}
+
+// Fail stops the application with err. Can be called by components at any time.
+func (a *App) Fail(err error) {
+	a.failOnce.Do(func() {
+		a.mu.Lock()
+		a.failErr = err
+		a.mu.Unlock()
+		close(a.failed)
+	})
+}
+
+// Stop stops the application gracefully as if ctx of Run was canceled. Can be called by components at any time.
+func (a *App) Stop() {
+	a.failOnce.Do(func() {
+		close(a.failed)
+	})
+}
+
+// Run starts all components and blocks until ctx is canceled or a component fails.
+// Then all started components are stopped in reverse order, including the one whose Start timed out.
+// Returns the error that caused the stop, or nil if ctx was canceled.
+func (a *App) Run(ctx context.Context) error {
+	a.mu.Lock()
+	if a.running {
+		a.mu.Unlock()
+		return errors.New("application is already running")
+	}
+	a.running = true
+	components := append([]*component{}, a.components...)
+	a.mu.Unlock()
+
+	runCtx, cancelRun := context.WithCancel(context.Background())
+	runWg := sync.WaitGroup{}
+
+	started := make([]*component, 0, len(components))
+	var startErr error
+	for _, c := range components {
+		if err := a.start(ctx, c); err != nil {
+			startErr = errors.Wrapf(err, "start %s", c.name)
+			if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+				// Start may still be running and acquire resources, so the component is stopped as well
+				started = append(started, c)
+			}
+			break
+		}
+		started = append(started, c)
+
+		if runner, ok := c.impl.(Runner); ok {
+			runWg.Add(1)
+			go func(c *component) {
+				defer runWg.Done()
+				a.run(runCtx, c, runner)
+			}(c)
+		}
+	}
+
+	if startErr == nil {
+		infralog.Info("application started")
+
+		select {
+		case <-ctx.Done():
+		case <-a.failed:
+		}
+	} else {
+		a.Fail(startErr)
+	}
+
+	infralog.Info("application is stopping")
+
+	cancelRun()
+	runWg.Wait()
+
+	stopErr := a.stop(started)
+
+	a.mu.Lock()
+	failErr := a.failErr
+	a.mu.Unlock()
+
+	if failErr != nil {
+		return failErr
+	}
+	return stopErr
+}
+
+func (a *App) start(ctx context.Context, c *component) error {
+	starter, ok := c.impl.(infraoperator.Starter)
+	if !ok {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.startTimeout)
+	defer cancel()
+
+	start := time.Now()
+	if err := runWithContext(ctx, starter.Start); err != nil {
+		return err
+	}
+
+	infralog.Debug("component started", zap.String("component", c.name), zap.Duration("duration", time.Since(start)))
+	return nil
+}
+
+func (a *App) run(ctx context.Context, c *component, runner Runner) {
+	err := runner.Run(ctx)
+	if ctx.Err() != nil {
+		// application is stopping
+		return
+	}
+
+	if errors.Is(err, ErrStop) {
+		infralog.Info("component requested stop", zap.String("component", c.name))
+		a.Stop()
+		return
+	}
+
+	if err == nil {
+		err = errors.New("exited unexpectedly")
+	}
+	infralog.Error("component failed", zap.String("component", c.name), zap.Error(err))
+	a.Fail(errors.Wrapf(err, "run %s", c.name))
+}
+
+// stop stops components in reverse order. Returns the first error.
+func (a *App) stop(components []*component) error {
+	var firstErr error
+
+	for i := len(components) - 1; i >= 0; i-- {
+		c := components[i]
+
+		stopper, ok := c.impl.(infraoperator.Stopper)
+		if !ok {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.stopTimeout)
+		err := runWithContext(ctx, stopper.Stop)
+		cancel()
+
+		if err != nil {
+			infralog.Error("component stop error", zap.String("component", c.name), zap.Error(err))
+			if firstErr == nil {
+				firstErr = errors.Wrapf(err, "stop %s", c.name)
+			}
+			continue
+		}
+
+		infralog.Debug("component stopped", zap.String("component", c.name))
+	}
+
+	return firstErr
+}
+
+// runWithContext returns when fn returns or ctx is done, whichever happens first.
+// It protects the application from components that ignore context.
+func runWithContext(ctx context.Context, fn func(ctx context.Context) error) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- fn(ctx)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "timed out")
+	}
+}
@@ -0,0 +1,137 @@
+package infraapp
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testComponent struct {
+	name     string
+	log      *[]string
+	mu       *sync.Mutex
+	startErr error
+	runErr   error
+	// startBlock makes Start ignore ctx until it's closed
+	startBlock chan struct{}
+}
+
+func (c *testComponent) record(event string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	*c.log = append(*c.log, event+" "+c.name)
+}
+
+func (c *testComponent) Start(_ context.Context) error {
+	c.record("start")
+	if c.startBlock != nil {
+		<-c.startBlock
+	}
+	return c.startErr
+}
+
+func (c *testComponent) Stop(_ context.Context) error {
+	c.record("stop")
+	return nil
+}
+
+type testRunner struct {
+	testComponent
+}
+
+func (c *testRunner) Run(ctx context.Context) error {
+	if c.runErr != nil {
+		return c.runErr
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestApp_Run(t *testing.T) {
+	var log []string
+	mu := &sync.Mutex{}
+
+	app := New()
+	app.Add("a", &testComponent{name: "a", log: &log, mu: mu})
+	app.Add("b", &testComponent{name: "b", log: &log, mu: mu})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := app.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"start a", "start b", "stop b", "stop a"}
+	if !reflect.DeepEqual(log, expected) {
+		t.Errorf("expected %v, got %v", expected, log)
+	}
+}
+
+func TestApp_StartFailure(t *testing.T) {
+	var log []string
+	mu := &sync.Mutex{}
+
+	app := New()
+	app.Add("a", &testComponent{name: "a", log: &log, mu: mu})
+	app.Add("b", &testComponent{name: "b", log: &log, mu: mu, startErr: errors.New("boom")})
+	app.Add("c", &testComponent{name: "c", log: &log, mu: mu})
+
+	if err := app.Run(context.Background()); err == nil {
+		t.Fatal("expected start error")
+	}
+
+	expected := []string{"start a", "start b", "stop a"}
+	if !reflect.DeepEqual(log, expected) {
+		t.Errorf("expected %v, got %v", expected, log)
+	}
+}
+
+func TestApp_StartTimeout(t *testing.T) {
+	var log []string
+	mu := &sync.Mutex{}
+
+	block := make(chan struct{})
+	defer close(block)
+
+	app := New()
+	app.Add("a", &testComponent{name: "a", log: &log, mu: mu})
+	app.Add("b", &testComponent{name: "b", log: &log, mu: mu, startBlock: block}, WithStartTimeout(10*time.Millisecond))
+	app.Add("c", &testComponent{name: "c", log: &log, mu: mu})
+
+	err := app.Run(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected start timeout, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	expected := []string{"start a", "start b", "stop b", "stop a"}
+	if !reflect.DeepEqual(log, expected) {
+		t.Errorf("expected %v, got %v", expected, log)
+	}
+}
+
+func TestApp_RunnerFailure(t *testing.T) {
+	var log []string
+	mu := &sync.Mutex{}
+
+	runErr := errors.New("consumer is broken")
+
+	app := New()
+	app.Add("a", &testComponent{name: "a", log: &log, mu: mu})
+	app.Add("consumer", &testRunner{testComponent{name: "consumer", log: &log, mu: mu, runErr: runErr}})
+
+	err := app.Run(context.Background())
+	if !errors.Is(err, runErr) {
+		t.Fatalf("expected runner error, got %v", err)
+	}
+
+	expected := []string{"start a", "start consumer", "stop consumer", "stop a"}
+	if !reflect.DeepEqual(log, expected) {
+		t.Errorf("expected %v, got %v", expected, log)
+	}
+}
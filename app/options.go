@@ -0,0 +1,48 @@
+package infraapp
+
+import "time"
+
+type Option interface {
+	apply(a *App)
+}
+
+type optionWithDefaultTimeouts struct {
+	start time.Duration
+	stop  time.Duration
+}
+
+func (o optionWithDefaultTimeouts) apply(a *App) {
+	a.startTimeout = o.start
+	a.stopTimeout = o.stop
+}
+
+// WithDefaultTimeouts sets start and stop timeouts of components that don't set their own
+func WithDefaultTimeouts(start, stop time.Duration) Option {
+	return optionWithDefaultTimeouts{start: start, stop: stop}
+}
+
+type ComponentOption interface {
+	apply(c *component)
+}
+
+type optionWithStartTimeout time.Duration
+
+func (o optionWithStartTimeout) apply(c *component) {
+	c.startTimeout = time.Duration(o)
+}
+
+// WithStartTimeout sets component start timeout
+func WithStartTimeout(timeout time.Duration) ComponentOption {
+	return optionWithStartTimeout(timeout)
+}
+
+type optionWithStopTimeout time.Duration
+
+func (o optionWithStopTimeout) apply(c *component) {
+	c.stopTimeout = time.Duration(o)
+}
+
+// WithStopTimeout sets component stop timeout
+func WithStopTimeout(timeout time.Duration) ComponentOption {
+	return optionWithStopTimeout(timeout)
+}
@@ -0,0 +1,68 @@
+//go:build integration
+
+package infraaudit
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	infraclickhouse "github.com/pushwoosh/infra/clickhouse"
+	infralog "github.com/pushwoosh/infra/log"
+	infraspool "github.com/pushwoosh/infra/spool"
+	infratestcontainers "github.com/pushwoosh/infra/test/containers"
+)
+
+func TestWriter(t *testing.T) {
+	cfg := infratestcontainers.ClickHouse(t)
+
+	cont := infraclickhouse.NewContainer()
+	if err := cont.Connect("test", cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	writer, err := NewWriter(cont.Get("test"), &Config{ManageSchema: true, Spool: &infraspool.Config{Dir: t.TempDir()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = writer.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Stop(ctx)
+
+	infralog.RegisterAuditHandler(writer.Record)
+
+	err = infralog.Audit(ctx, &infralog.AuditEvent{
+		Actor:  "user:42",
+		Action: "application.update",
+		Object: "application:ABCDE",
+		Before: map[string]string{"name": "old"},
+		After:  map[string]string{"name": "new"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// events are inserted in background
+	var records []*Record
+	for deadline := time.Now().Add(5 * time.Second); len(records) == 0 && time.Now().Before(deadline); {
+		time.Sleep(50 * time.Millisecond)
+		if records, err = writer.Query(ctx, &Filter{Actor: "user:42"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(records) != 1 || string(records[0].After) != `{"name":"new"}` {
+		t.Fatalf("unexpected records %+v", records)
+	}
+
+	var out bytes.Buffer
+	if err = writer.Export(ctx, nil, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `"action":"application.update"`) {
+		t.Fatalf("unexpected export %s", out.String())
+	}
+}
@@ -0,0 +1,34 @@
+package infraaudit
+
+import (
+	"testing"
+	"time"
+
+	infraspool "github.com/pushwoosh/infra/spool"
+)
+
+func TestFilter(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	where, args := (&Filter{From: from, Actor: "user:42"}).where()
+
+	if where != " WHERE ts >= ? AND actor = ?" || len(args) != 2 || args[1] != "user:42" {
+		t.Fatalf("unexpected condition %q %v", where, args)
+	}
+
+	if where, _ = (&Filter{}).where(); where != "" {
+		t.Fatalf("expected no condition, got %q", where)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	cfg := &Config{Spool: &infraspool.Config{Dir: t.TempDir()}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Record promises the event is on disk when it returns
+	cfg.Spool.SyncInterval = time.Second
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected an error for spool sync_interval")
+	}
+}
@@ -0,0 +1,74 @@
+package infraaudit
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/pkg/errors"
+	infraspool "github.com/pushwoosh/infra/spool"
+)
+
+const DefaultBatchSize = 1000
+
+var tableName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*(\.[a-zA-Z_][a-zA-Z0-9_]*)?$`)
+
+type Config struct {
+	// Table is a name of the audit table, optionally with a database. optional, default: audit_log
+	Table string `mapstructure:"table"`
+
+	// ManageSchema creates the table on Start
+	ManageSchema bool `mapstructure:"manage_schema"`
+
+	// TTL of audit events in a created table, e.g. 3 years for compliance. optional, events are kept forever by default
+	TTL time.Duration `mapstructure:"ttl"`
+
+	// Spool keeps recorded events on disk until they are inserted, events left by a crash are inserted after restart.
+	// Every event is synced before Record returns, sync_interval must be 0
+	Spool *infraspool.Config `mapstructure:"spool"`
+
+	// Max number of events inserted at once. optional, default: 1000
+	BatchSize int `mapstructure:"batch_size"`
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if c.Table != "" && !tableName.MatchString(c.Table) {
+		return errors.Errorf("invalid table name %q", c.Table)
+	}
+
+	if c.TTL < 0 {
+		return errors.New("ttl should be greater than or equal to 0")
+	}
+
+	if c.BatchSize < 0 {
+		return errors.New("batch_size should be greater than or equal to 0")
+	}
+
+	if err := c.Spool.Validate(); err != nil {
+		return errors.Wrap(err, "spool")
+	}
+
+	if c.Spool.SyncInterval > 0 {
+		// Record must return after the event is synced to disk
+		return errors.New("spool.sync_interval should be 0")
+	}
+
+	return nil
+}
+
+func (c *Config) GetTable() string {
+	if c.Table == "" {
+		return "audit_log"
+	}
+	return c.Table
+}
+
+func (c *Config) GetBatchSize() int {
+	if c.BatchSize <= 0 {
+		return DefaultBatchSize
+	}
+	return c.BatchSize
+}
@@ -0,0 +1,135 @@
+package infraaudit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Record is a persisted audit event
+type Record struct {
+	Time      time.Time       `json:"time"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	Object    string          `json:"object"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+	Tenant    string          `json:"tenant,omitempty"`
+	Service   string          `json:"service,omitempty"`
+}
+
+// Filter selects audit records. Empty fields are not filtered by
+type Filter struct {
+	From   time.Time
+	To     time.Time
+	Actor  string
+	Action string
+	Object string
+	Tenant string
+
+	// Max number of records. optional, all matching records by default
+	Limit int
+}
+
+func (f *Filter) where() (string, []any) {
+	var (
+		conditions []string
+		args       []any
+	)
+
+	add := func(condition string, arg any) {
+		conditions = append(conditions, condition)
+		args = append(args, arg)
+	}
+
+	if !f.From.IsZero() {
+		add("ts >= ?", f.From)
+	}
+	if !f.To.IsZero() {
+		add("ts < ?", f.To)
+	}
+	if f.Actor != "" {
+		add("actor = ?", f.Actor)
+	}
+	if f.Action != "" {
+		add("action = ?", f.Action)
+	}
+	if f.Object != "" {
+		add("object = ?", f.Object)
+	}
+	if f.Tenant != "" {
+		add("tenant = ?", f.Tenant)
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// Query returns records matching the filter ordered by time
+func (w *Writer) Query(ctx context.Context, filter *Filter) ([]*Record, error) {
+	var records []*Record
+	err := w.each(ctx, filter, func(r *Record) error {
+		records = append(records, r)
+		return nil
+	})
+
+	return records, err
+}
+
+// Export writes records matching the filter as JSON lines ordered by time, e.g. for compliance exports.
+// Records are streamed, so the export isn't limited by memory
+func (w *Writer) Export(ctx context.Context, filter *Filter, out io.Writer) error {
+	encoder := json.NewEncoder(out)
+
+	return w.each(ctx, filter, func(r *Record) error {
+		return encoder.Encode(r)
+	})
+}
+
+func (w *Writer) each(ctx context.Context, filter *Filter, fn func(r *Record) error) error {
+	if filter == nil {
+		filter = &Filter{}
+	}
+
+	where, args := filter.where()
+	query := fmt.Sprintf("SELECT %s FROM %s%s ORDER BY ts", columns, w.cfg.GetTable(), where)
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+
+	rows, err := w.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "unable to query audit records")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			r             Record
+			before, after string
+		)
+		if err = rows.Scan(&r.Time, &r.Actor, &r.Action, &r.Object, &before, &after, &r.RequestID, &r.Tenant, &r.Service); err != nil {
+			return errors.Wrap(err, "unable to scan audit record")
+		}
+		if before != "" {
+			r.Before = json.RawMessage(before)
+		}
+		if after != "" {
+			r.After = json.RawMessage(after)
+		}
+
+		if err = fn(&r); err != nil {
+			return err
+		}
+	}
+
+	return errors.Wrap(rows.Err(), "unable to read audit records")
+}
@@ -0,0 +1,248 @@
+package infraaudit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	infraclickhouse "github.com/pushwoosh/infra/clickhouse"
+	infraclock "github.com/pushwoosh/infra/clock"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	infrarequestid "github.com/pushwoosh/infra/requestid"
+	infraretry "github.com/pushwoosh/infra/retry"
+	infraspool "github.com/pushwoosh/infra/spool"
+	infratenancy "github.com/pushwoosh/infra/tenancy"
+	infraversion "github.com/pushwoosh/infra/version"
+	"go.uber.org/zap"
+)
+
+const columns = "ts, actor, action, object, before, after, request_id, tenant, service"
+
+var insertBackoff = infraretry.Exponential{
+	Initial: 100 * time.Millisecond,
+	Max:     30 * time.Second,
+	Jitter:  0.2,
+}
+
+// spooledEvent is an event kept in the spool
+type spooledEvent struct {
+	Time      time.Time `json:"ts"`
+	Actor     string    `json:"actor"`
+	Action    string    `json:"action"`
+	Object    string    `json:"object"`
+	Before    string    `json:"before"`
+	After     string    `json:"after"`
+	RequestID string    `json:"request_id"`
+	Tenant    string    `json:"tenant"`
+}
+
+// Writer persists audit events to a ClickHouse table. Record returns after the event is synced to the spool
+// on disk, so an action is never reported as done while its audit event may be lost. Spooled events are
+// inserted in background in order and at least once: a batch inserted right before a crash is inserted again.
+// Register the writer as the handler of infralog.Audit to persist events of all packages:
+//
+//	writer, err := infraaudit.NewWriter(chContainer.Get("audit"), cfg)
+//	app.Add("audit", writer)
+//	infralog.RegisterAuditHandler(writer.Record)
+type Writer struct {
+	db      *sql.DB
+	cfg     *Config
+	service string
+	queue   *infraspool.Queue
+
+	mu       sync.Mutex
+	started  bool
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+var (
+	_ infraoperator.Starter = (*Writer)(nil)
+	_ infraoperator.Stopper = (*Writer)(nil)
+)
+
+// NewWriter opens the spool, events left by the previous run are inserted after Start
+func NewWriter(db *sql.DB, cfg *Config) (*Writer, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	queue, err := infraspool.Open("audit", cfg.Spool)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Writer{
+		db:      db,
+		cfg:     cfg,
+		service: serviceName(),
+		queue:   queue,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}, nil
+}
+
+// Record spools the event. The request id and the tenant are taken from ctx
+func (w *Writer) Record(ctx context.Context, event *infralog.AuditEvent) error {
+	before, err := encodeState(event.Before)
+	if err != nil {
+		return errors.Wrap(err, "before")
+	}
+
+	after, err := encodeState(event.After)
+	if err != nil {
+		return errors.Wrap(err, "after")
+	}
+
+	payload, err := json.Marshal(&spooledEvent{
+		Time:      event.Time,
+		Actor:     event.Actor,
+		Action:    event.Action,
+		Object:    event.Object,
+		Before:    before,
+		After:     after,
+		RequestID: infrarequestid.FromContext(ctx),
+		Tenant:    infratenancy.FromContext(ctx),
+	})
+	if err != nil {
+		return errors.Wrap(err, "unable to encode audit event")
+	}
+
+	if err = w.queue.Append(payload); err != nil {
+		return errors.Wrap(err, "unable to spool audit event")
+	}
+
+	return nil
+}
+
+// Spooled returns the number of events not inserted yet
+func (w *Writer) Spooled() int {
+	return w.queue.Len()
+}
+
+// Start creates the table if ManageSchema is set and starts writing
+func (w *Writer) Start(ctx context.Context) error {
+	if w.cfg.ManageSchema {
+		if err := w.EnsureTable(ctx); err != nil {
+			return err
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.started {
+		w.started = true
+		go w.run()
+	}
+	return nil
+}
+
+// Stop stops inserting and closes the spool. Events not inserted yet stay on disk for the next run
+func (w *Writer) Stop(ctx context.Context) error {
+	w.mu.Lock()
+	started := w.started
+	w.mu.Unlock()
+
+	if started {
+		w.stopOnce.Do(func() { close(w.stop) })
+
+		select {
+		case <-w.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return w.queue.Close()
+}
+
+// EnsureTable creates the audit table if it doesn't exist
+func (w *Writer) EnsureTable(ctx context.Context) error {
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	ts DateTime64(3),
+	actor String,
+	action LowCardinality(String),
+	object String,
+	before String,
+	after String,
+	request_id String,
+	tenant String,
+	service LowCardinality(String)
+) ENGINE = MergeTree
+PARTITION BY toYYYYMM(ts)
+ORDER BY (ts, action)`, w.cfg.GetTable())
+	if w.cfg.TTL > 0 {
+		query += fmt.Sprintf("\nTTL toDateTime(ts) + INTERVAL %d SECOND", int64(w.cfg.TTL.Seconds()))
+	}
+
+	_, err := w.db.ExecContext(ctx, query)
+	return errors.Wrapf(err, "unable to create table %s", w.cfg.GetTable())
+}
+
+func (w *Writer) run() {
+	defer close(w.done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-w.stop:
+			cancel()
+		case <-w.done:
+		}
+	}()
+
+	w.queue.Replay(ctx, w.cfg.GetBatchSize(), insertBackoff, infraclock.Real, w.insert)
+}
+
+func (w *Writer) insert(ctx context.Context, payloads [][]byte) error {
+	rows := make([][]any, 0, len(payloads))
+	for _, payload := range payloads {
+		var r spooledEvent
+		if err := json.Unmarshal(payload, &r); err != nil {
+			// records are checksummed, a payload written by Record is always decodable
+			infralog.ErrorCtx(ctx, "audit: dropping undecodable event", zap.Error(err))
+			continue
+		}
+		rows = append(rows, []any{r.Time, r.Actor, r.Action, r.Object, r.Before, r.After, r.RequestID, r.Tenant, w.service})
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s)", w.cfg.GetTable(), columns)
+	return infraclickhouse.Insert(ctx, w.db, query, rows)
+}
+
+// serviceName is the service of audit events, see infralog.SetGlobalFieldsFromEnvironment
+func serviceName() string {
+	if service := os.Getenv(infralog.EnvServiceName); service != "" {
+		return service
+	}
+	if info := infraversion.Get(); info.Path != "" {
+		return path.Base(info.Path)
+	}
+	return ""
+}
+
+func encodeState(state any) (string, error) {
+	if state == nil {
+		return "", nil
+	}
+
+	if raw, ok := state.(json.RawMessage); ok {
+		return string(raw), nil
+	}
+
+	data, err := json.Marshal(state)
+	return string(data), err
+}
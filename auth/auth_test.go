@@ -0,0 +1,171 @@
+package infraauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	infratenancy "github.com/pushwoosh/infra/tenancy"
+	"github.com/stretchr/testify/require"
+)
+
+type testIssuer struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mu   sync.Mutex
+	keys map[string]*rsa.PrivateKey
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	iss := &testIssuer{t: t, keys: make(map[string]*rsa.PrivateKey)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   iss.server.URL,
+			"jwks_uri": iss.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, _ *http.Request) {
+		iss.mu.Lock()
+		defer iss.mu.Unlock()
+
+		set := jose.JSONWebKeySet{}
+		for kid, key := range iss.keys {
+			set.Keys = append(set.Keys, jose.JSONWebKey{Key: &key.PublicKey, KeyID: kid, Algorithm: "RS256", Use: "sig"})
+		}
+		_ = json.NewEncoder(w).Encode(set)
+	})
+
+	iss.server = httptest.NewServer(mux)
+	t.Cleanup(iss.server.Close)
+
+	iss.rotate("k1")
+	return iss
+}
+
+func (iss *testIssuer) rotate(kid string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(iss.t, err)
+
+	iss.mu.Lock()
+	iss.keys[kid] = key
+	iss.mu.Unlock()
+}
+
+func (iss *testIssuer) token(kid string, claims jwt.Claims, extra map[string]interface{}) string {
+	iss.mu.Lock()
+	key := iss.keys[kid]
+	iss.mu.Unlock()
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key}, (&jose.SignerOptions{}).WithHeader("kid", kid))
+	require.NoError(iss.t, err)
+
+	if claims.Issuer == "" {
+		claims.Issuer = iss.server.URL
+	}
+	token, err := jwt.Signed(signer).Claims(claims).Claims(extra).CompactSerialize()
+	require.NoError(iss.t, err)
+	return token
+}
+
+func TestValidator(t *testing.T) {
+	ctx := context.Background()
+	iss := newTestIssuer(t)
+
+	v, err := NewValidator(ctx, &Config{
+		Issuer:             iss.server.URL,
+		Audience:           []string{"billing"},
+		MinRefreshInterval: time.Nanosecond,
+	})
+	require.NoError(t, err)
+
+	valid := jwt.Claims{Subject: "user-1", Audience: jwt.Audience{"billing"}, Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+
+	claims, err := v.Validate(ctx, iss.token("k1", valid, map[string]interface{}{"scope": "billing:read billing:write"}))
+	require.NoError(t, err)
+	require.Equal(t, "user-1", claims.Subject)
+	require.True(t, claims.HasScope("billing:write"))
+
+	expired := valid
+	expired.Expiry = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+	_, err = v.Validate(ctx, iss.token("k1", expired, nil))
+	require.ErrorIs(t, err, ErrExpiredToken)
+
+	noExpiry := valid
+	noExpiry.Expiry = nil
+	_, err = v.Validate(ctx, iss.token("k1", noExpiry, nil))
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	other := valid
+	other.Audience = jwt.Audience{"push"}
+	_, err = v.Validate(ctx, iss.token("k1", other, nil))
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = v.Validate(ctx, "garbage")
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	// a new key is fetched on unknown key id
+	iss.rotate("k2")
+	_, err = v.Validate(ctx, iss.token("k2", valid, nil))
+	require.NoError(t, err)
+}
+
+func TestHTTP(t *testing.T) {
+	iss := newTestIssuer(t)
+
+	v, err := NewValidator(context.Background(), &Config{Issuer: iss.server.URL, Audience: []string{"billing"}})
+	require.NoError(t, err)
+
+	handler := HTTP(v)(RequireScopes("billing:write")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(FromContext(r.Context()).Subject))
+	})))
+
+	do := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	claims := jwt.Claims{Subject: "user-1", Audience: jwt.Audience{"billing"}, Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+
+	require.Equal(t, http.StatusUnauthorized, do("").Code)
+	require.Equal(t, http.StatusForbidden, do(iss.token("k1", claims, map[string]interface{}{"scope": "billing:read"})).Code)
+
+	rec := do(iss.token("k1", claims, map[string]interface{}{"scope": []string{"billing:write"}}))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "user-1", rec.Body.String())
+}
+
+func TestHTTPTenant(t *testing.T) {
+	handler := HTTPTenant("tenant")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(infratenancy.FromContext(r.Context())))
+	}))
+
+	do := func(claims *Claims) string {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(infratenancy.Header, "forged")
+		if claims != nil {
+			req = req.WithContext(NewContext(req.Context(), claims))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Body.String()
+	}
+
+	require.Equal(t, "", do(nil))
+	require.Equal(t, "acme", do(&Claims{Raw: map[string]interface{}{"tenant": "acme"}}))
+}
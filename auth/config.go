@@ -0,0 +1,97 @@
+package infraauth
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultRefreshInterval    = time.Hour
+	defaultMinRefreshInterval = time.Minute
+	defaultLeeway             = time.Minute
+	defaultScopeClaim         = "scope"
+)
+
+var defaultAlgorithms = []string{"RS256", "ES256"}
+
+type Config struct {
+	// Issuer of tokens. Keys are discovered from its /.well-known/openid-configuration unless JWKSURL is set
+	Issuer string `mapstructure:"issuer"`
+
+	// JWKS endpoint. optional, discovered by issuer if empty
+	JWKSURL string `mapstructure:"jwks_url"`
+
+	// Token must have one of the audiences, so tokens of other services of the issuer are not accepted
+	Audience []string `mapstructure:"audience"`
+
+	// Accepted signing algorithms. optional, default: RS256, ES256
+	Algorithms []string `mapstructure:"algorithms"`
+
+	// How often keys are refreshed. optional, default: 1h
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+
+	// Keys are refreshed on unknown key id, but not more often than that. optional, default: 1m
+	MinRefreshInterval time.Duration `mapstructure:"min_refresh_interval"`
+
+	// Allowed clock skew for exp, nbf and iat. optional, default: 1m
+	Leeway time.Duration `mapstructure:"leeway"`
+
+	// Claim holding scopes: a space separated string or a list. optional, default: scope
+	ScopeClaim string `mapstructure:"scope_claim"`
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if c.Issuer == "" {
+		return errors.New("issuer is mandatory")
+	}
+
+	if len(c.Audience) == 0 {
+		return errors.New("audience is mandatory")
+	}
+
+	if c.RefreshInterval < 0 || c.MinRefreshInterval < 0 || c.Leeway < 0 {
+		return errors.New("intervals should not be negative")
+	}
+
+	return nil
+}
+
+func (c *Config) GetAlgorithms() []string {
+	if len(c.Algorithms) == 0 {
+		return defaultAlgorithms
+	}
+	return c.Algorithms
+}
+
+func (c *Config) GetRefreshInterval() time.Duration {
+	if c.RefreshInterval == 0 {
+		return defaultRefreshInterval
+	}
+	return c.RefreshInterval
+}
+
+func (c *Config) GetMinRefreshInterval() time.Duration {
+	if c.MinRefreshInterval == 0 {
+		return defaultMinRefreshInterval
+	}
+	return c.MinRefreshInterval
+}
+
+func (c *Config) GetLeeway() time.Duration {
+	if c.Leeway == 0 {
+		return defaultLeeway
+	}
+	return c.Leeway
+}
+
+func (c *Config) GetScopeClaim() string {
+	if c.ScopeClaim == "" {
+		return defaultScopeClaim
+	}
+	return c.ScopeClaim
+}
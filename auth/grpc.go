@@ -0,0 +1,69 @@
+package infraauth
+
+import (
+	"context"
+
+	grpc_auth "github.com/grpc-ecosystem/go-grpc-middleware/auth"
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCAuthFunc authenticates calls with a bearer token in "authorization" metadata, see infragrpcserver.WithAuth.
+// Claims are put into the context.
+func GRPCAuthFunc(v *Validator) grpc_auth.AuthFunc {
+	return func(ctx context.Context) (context.Context, error) {
+		token, err := grpc_auth.AuthFromMD(ctx, "bearer")
+		if err != nil {
+			metrics.TokensCounter.WithLabelValues("missing").Inc()
+			return nil, err
+		}
+
+		claims, err := v.Validate(ctx, token)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		return infralog.WithField(NewContext(ctx, claims), zap.String("subject", claims.Subject)), nil
+	}
+}
+
+// UnaryServerScopesInterceptor checks scopes required by methods, e.g. {"/billing.Billing/Charge": {"billing:write"}}.
+// Methods missing in the map need no scopes. It must be chained after authentication.
+func UnaryServerScopesInterceptor(methodScopes map[string][]string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := checkMethodScopes(ctx, methodScopes[info.FullMethod]); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerScopesInterceptor is UnaryServerScopesInterceptor for streams
+func StreamServerScopesInterceptor(methodScopes map[string][]string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := checkMethodScopes(ss.Context(), methodScopes[info.FullMethod]); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func checkMethodScopes(ctx context.Context, scopes []string) error {
+	if len(scopes) == 0 {
+		return nil
+	}
+
+	err := CheckScopes(ctx, scopes...)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrNoToken):
+		return status.Error(codes.Unauthenticated, err.Error())
+	default:
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+}
@@ -0,0 +1,83 @@
+package infraauth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	infratenancy "github.com/pushwoosh/infra/tenancy"
+	"go.uber.org/zap"
+)
+
+// HTTP authenticates requests with a bearer token and puts its claims into the request context.
+// Requests without a valid token get 401.
+func HTTP(v *Validator) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := bearer(r.Header.Get("Authorization"))
+			if !ok {
+				metrics.TokensCounter.WithLabelValues("missing").Inc()
+				unauthorized(w, `Bearer`)
+				return
+			}
+
+			claims, err := v.Validate(r.Context(), token)
+			if err != nil {
+				infralog.Debug("invalid token", zap.Error(err))
+				unauthorized(w, `Bearer error="invalid_token"`)
+				return
+			}
+
+			ctx := infralog.WithField(NewContext(r.Context(), claims), zap.String("subject", claims.Subject))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequireScopes responds 403 to requests whose token lacks any of scopes. It must be used after HTTP.
+func RequireScopes(scopes ...string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			err := CheckScopes(r.Context(), scopes...)
+			switch {
+			case err == nil:
+				next.ServeHTTP(w, r)
+			case errors.Is(err, ErrNoToken):
+				unauthorized(w, `Bearer`)
+			default:
+				w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+strings.Join(scopes, " ")+`"`)
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			}
+		})
+	}
+}
+
+// HTTPTenant stores the claim of the token as the tenant of the request, see infratenancy.NewContext.
+// It must be used after HTTP. Requests whose token lacks the claim are served without a tenant.
+func HTTPTenant(claim string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if claims := FromContext(r.Context()); claims != nil {
+				if tenant := claims.String(claim); tenant != "" {
+					r = r.WithContext(infratenancy.NewContext(r.Context(), tenant))
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func unauthorized(w http.ResponseWriter, challenge string) {
+	w.Header().Set("WWW-Authenticate", challenge)
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+func bearer(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "bearer") || token == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
@@ -0,0 +1,162 @@
+package infraauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	"go.uber.org/zap"
+)
+
+// keySet caches keys of a JWKS endpoint. Keys are refreshed when they get older than the refresh interval
+// or when a token has an unknown key id, so rotated keys are picked up without restarts.
+// Validations with cached keys don't wait for a refresh, concurrent refreshes are merged into one request.
+type keySet struct {
+	url    string
+	client *http.Client
+	cfg    *Config
+
+	mu         sync.Mutex
+	keys       *jose.JSONWebKeySet
+	fetchedAt  time.Time
+	refreshing chan struct{} // closed when the running refresh completes, nil if there is none
+	refreshErr error
+}
+
+// discoverJWKS returns jwks_uri of the issuer OpenID configuration
+func discoverJWKS(ctx context.Context, client *http.Client, issuer string) (string, error) {
+	var doc struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := getJSON(ctx, client, strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", &doc); err != nil {
+		return "", errors.Wrap(err, "unable to get openid configuration")
+	}
+
+	if doc.Issuer != issuer {
+		return "", errors.Errorf("openid configuration issuer %q doesn't match %q", doc.Issuer, issuer)
+	}
+	if doc.JWKSURI == "" {
+		return "", errors.New("openid configuration has no jwks_uri")
+	}
+
+	return doc.JWKSURI, nil
+}
+
+// key returns keys with the key id, refreshing the set if needed
+func (s *keySet) key(ctx context.Context, kid string) ([]jose.JSONWebKey, error) {
+	keys, fetchedAt := s.current()
+
+	if keys == nil || time.Since(fetchedAt) > s.cfg.GetRefreshInterval() {
+		if err := s.refresh(ctx); err != nil && keys == nil {
+			return nil, err
+		}
+		keys, fetchedAt = s.current()
+	}
+
+	found := keys.Key(kid)
+	if len(found) == 0 && time.Since(fetchedAt) > s.cfg.GetMinRefreshInterval() {
+		if err := s.refresh(ctx); err != nil {
+			return nil, err
+		}
+		keys, _ = s.current()
+		found = keys.Key(kid)
+	}
+
+	return found, nil
+}
+
+func (s *keySet) current() (*jose.JSONWebKeySet, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.keys, s.fetchedAt
+}
+
+// refresh fetches keys, callers wait for a refresh that is already running instead of starting another one
+func (s *keySet) refresh(ctx context.Context) error {
+	for {
+		s.mu.Lock()
+		running := s.refreshing
+		if running == nil {
+			break
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-running:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		s.mu.Lock()
+		err := s.refreshErr
+		s.mu.Unlock()
+
+		// the refresh was canceled by its caller, another one is started
+		if !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+			return err
+		}
+	}
+
+	done := make(chan struct{})
+	s.refreshing = done
+	s.mu.Unlock()
+
+	keys := &jose.JSONWebKeySet{}
+	err := getJSON(ctx, s.client, s.url, keys)
+
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	metrics.RefreshesCounter.WithLabelValues(result).Inc()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.refreshing = nil
+	s.refreshErr = err
+	close(done)
+
+	if err != nil {
+		if ctx.Err() != nil {
+			// the caller is gone, it says nothing about the endpoint
+			return err
+		}
+
+		// a failed refresh is not retried until the min interval passes, the cached keys are kept
+		s.fetchedAt = time.Now()
+		infralog.Error("unable to refresh jwks", zap.String("url", s.url), zap.Error(err))
+		return errors.Wrap(err, "unable to get jwks")
+	}
+
+	s.keys = keys
+	s.fetchedAt = time.Now()
+	return nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, dst interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(dst)
+}
@@ -0,0 +1,33 @@
+package infraauth
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	TokensCounter    *prometheus.CounterVec
+	RefreshesCounter *prometheus.CounterVec
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.TokensCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "auth_tokens_total",
+			Help: "Number of validated tokens by result: valid, missing, invalid, expired or forbidden",
+		}, []string{"result"})
+
+		metrics.RefreshesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "auth_jwks_refreshes_total",
+			Help: "Number of JWKS refreshes by result: success or error",
+		}, []string{"result"})
+
+		prometheus.MustRegister(
+			metrics.TokensCounter,
+			metrics.RefreshesCounter,
+		)
+	})
+}
@@ -0,0 +1,237 @@
+package infraauth
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrNoToken      = errors.New("no token")
+	ErrInvalidToken = errors.New("invalid token")
+	ErrExpiredToken = errors.New("token is expired")
+	ErrForbidden    = errors.New("insufficient scope")
+)
+
+// Claims are claims of a validated token
+type Claims struct {
+	Subject  string
+	Issuer   string
+	Audience []string
+	Expiry   time.Time
+	IssuedAt time.Time
+	Scopes   []string
+	Raw      map[string]interface{}
+}
+
+// HasScope returns true if the token has the scope
+func (c *Claims) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes, scope)
+}
+
+// String returns a string claim, empty if it's missing or not a string
+func (c *Claims) String(name string) string {
+	s, _ := c.Raw[name].(string)
+	return s
+}
+
+type Option interface {
+	apply(v *Validator)
+}
+
+type optionHTTPClient struct {
+	client *http.Client
+}
+
+func (opt optionHTTPClient) apply(v *Validator) {
+	v.client = opt.client
+}
+
+// WithHTTPClient sets a client used to get OpenID configuration and keys, e.g. created with infrahttp.NewClient
+func WithHTTPClient(client *http.Client) Option {
+	return optionHTTPClient{client: client}
+}
+
+// Validator validates JWT access tokens of an OIDC issuer:
+//
+//	validator, err := infraauth.NewValidator(ctx, cfg.Auth)
+//	srv := infrahttp.NewServer(cfg.HTTP, mux, infrahttp.WithMiddlewares(infraauth.HTTP(validator)))
+//	grpcSrv := infragrpcserver.NewServer(cfg.GRPC, infragrpcserver.WithAuth(infraauth.GRPCAuthFunc(validator)))
+//	...
+//	claims := infraauth.FromContext(ctx)
+//
+// Signing keys are fetched from JWKS of the issuer and refreshed periodically and on unknown key ids.
+type Validator struct {
+	cfg    *Config
+	client *http.Client
+	keys   *keySet
+}
+
+// NewValidator discovers the JWKS endpoint and fetches keys
+func NewValidator(ctx context.Context, cfg *Config, opts ...Option) (*Validator, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	initMetrics()
+
+	v := &Validator{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+
+	for _, opt := range opts {
+		opt.apply(v)
+	}
+
+	url := cfg.JWKSURL
+	if url == "" {
+		var err error
+		if url, err = discoverJWKS(ctx, v.client, cfg.Issuer); err != nil {
+			return nil, err
+		}
+	}
+
+	v.keys = &keySet{url: url, client: v.client, cfg: cfg}
+	if err := v.keys.refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// Validate verifies the token signature and its issuer, audience and lifetime
+func (v *Validator) Validate(ctx context.Context, token string) (*Claims, error) {
+	claims, err := v.validate(ctx, token)
+
+	switch {
+	case err == nil:
+		metrics.TokensCounter.WithLabelValues("valid").Inc()
+	case errors.Is(err, ErrExpiredToken):
+		metrics.TokensCounter.WithLabelValues("expired").Inc()
+	default:
+		metrics.TokensCounter.WithLabelValues("invalid").Inc()
+	}
+
+	return claims, err
+}
+
+func (v *Validator) validate(ctx context.Context, token string) (*Claims, error) {
+	parsed, err := jwt.ParseSigned(token)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidToken, err.Error())
+	}
+	if len(parsed.Headers) != 1 {
+		return nil, errors.Wrap(ErrInvalidToken, "multiple signatures")
+	}
+
+	header := parsed.Headers[0]
+	if !slices.Contains(v.cfg.GetAlgorithms(), header.Algorithm) {
+		return nil, errors.Wrapf(ErrInvalidToken, "algorithm %s is not allowed", header.Algorithm)
+	}
+
+	keys, err := v.keys.key(ctx, header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		std jwt.Claims
+		raw map[string]interface{}
+	)
+	verified := false
+	for _, key := range keys {
+		if err = parsed.Claims(key.Key, &std, &raw); err == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, errors.Wrap(ErrInvalidToken, "signature is not verified")
+	}
+
+	// ValidateWithLeeway skips a missing exp, tokens must expire
+	if std.Expiry == nil {
+		return nil, errors.Wrap(ErrInvalidToken, "token has no expiry")
+	}
+
+	err = std.ValidateWithLeeway(jwt.Expected{Issuer: v.cfg.Issuer, Time: time.Now()}, v.cfg.GetLeeway())
+	if errors.Is(err, jwt.ErrExpired) {
+		return nil, ErrExpiredToken
+	}
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidToken, err.Error())
+	}
+
+	if !slices.ContainsFunc(v.cfg.Audience, std.Audience.Contains) {
+		return nil, errors.Wrap(ErrInvalidToken, "audience is not allowed")
+	}
+
+	claims := &Claims{
+		Subject:  std.Subject,
+		Issuer:   std.Issuer,
+		Audience: std.Audience,
+		Expiry:   std.Expiry.Time(),
+		Scopes:   scopes(raw[v.cfg.GetScopeClaim()]),
+		Raw:      raw,
+	}
+	if std.IssuedAt != nil {
+		claims.IssuedAt = std.IssuedAt.Time()
+	}
+
+	return claims, nil
+}
+
+// scopes parses a space separated string or a list of scopes
+func scopes(claim interface{}) []string {
+	switch v := claim.(type) {
+	case string:
+		return strings.Fields(v)
+	case []interface{}:
+		res := make([]string, 0, len(v))
+		for _, s := range v {
+			if s, ok := s.(string); ok {
+				res = append(res, s)
+			}
+		}
+		return res
+	default:
+		return nil
+	}
+}
+
+// CheckScopes returns ErrForbidden if the token of ctx lacks any of scopes
+func CheckScopes(ctx context.Context, scopes ...string) error {
+	claims := FromContext(ctx)
+	if claims == nil {
+		return ErrNoToken
+	}
+
+	for _, scope := range scopes {
+		if !claims.HasScope(scope) {
+			metrics.TokensCounter.WithLabelValues("forbidden").Inc()
+			return errors.Wrap(ErrForbidden, scope)
+		}
+	}
+	return nil
+}
+
+type claimsCtxKeyType string
+
+const claimsCtxKey claimsCtxKeyType = "auth_claims"
+
+// NewContext returns a context with claims
+func NewContext(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsCtxKey, claims)
+}
+
+// FromContext returns claims of the authenticated request, nil if there are none
+func FromContext(ctx context.Context) *Claims {
+	claims, _ := ctx.Value(claimsCtxKey).(*Claims)
+	return claims
+}
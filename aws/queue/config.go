@@ -0,0 +1,88 @@
+package infraawsqueue
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type ConnectionsConfig map[string]*ConnectionConfig
+
+type ConnectionConfig struct {
+	// AWS region
+	Region string `mapstructure:"region"`
+
+	// Custom endpoint, e.g. localstack address. Optional
+	Endpoint string `mapstructure:"endpoint"`
+
+	// Static credentials. Default AWS credentials chain is used if empty
+	AccessKeyID     string `mapstructure:"access_key_id"`
+	SecretAccessKey string `mapstructure:"secret_access_key"`
+}
+
+type ConsumerConfig struct {
+	ConnectionName    string
+	QueueURL          string
+	MaxMessages       int           // optional, messages per receive call, up to 10
+	WaitTime          time.Duration // optional, long polling wait time, up to 20 seconds
+	VisibilityTimeout time.Duration // optional, queue default is used if zero
+	DeleteInterval    time.Duration // optional, max delay before acknowledged messages are deleted
+}
+
+type PublisherConfig struct {
+	ConnectionName string
+}
+
+func (c *ConnectionsConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	for name, conf := range *c {
+		if err := conf.Validate(); err != nil {
+			return errors.Wrap(err, name)
+		}
+	}
+
+	return nil
+}
+
+func (c *ConnectionConfig) Validate() error {
+	if c == nil {
+		return errors.New("empty connection config")
+	}
+
+	if c.Region == "" {
+		return errors.New("region is mandatory")
+	}
+
+	if (c.AccessKeyID == "") != (c.SecretAccessKey == "") {
+		return errors.New("access_key_id and secret_access_key must be set together")
+	}
+
+	return nil
+}
+
+func (c *ConsumerConfig) Validate() error {
+	if c == nil {
+		return errors.New("config is required")
+	}
+
+	if c.QueueURL == "" {
+		return errors.New("queue url is mandatory")
+	}
+
+	if c.MaxMessages <= 0 || c.MaxMessages > maxBatchSize {
+		c.MaxMessages = maxBatchSize
+	}
+
+	if c.WaitTime <= 0 || c.WaitTime > maxWaitTime {
+		c.WaitTime = maxWaitTime
+	}
+
+	if c.DeleteInterval <= 0 {
+		c.DeleteInterval = defaultDeleteInterval
+	}
+
+	return nil
+}
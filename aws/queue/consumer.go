@@ -0,0 +1,360 @@
+package infraawsqueue
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	"go.uber.org/zap"
+)
+
+const (
+	maxBatchSize          = 10
+	maxWaitTime           = 20 * time.Second
+	defaultDeleteInterval = time.Second
+	receiveErrorDelay     = time.Second
+)
+
+type Consumer struct {
+	client *sqs.Client
+	cfg    *ConsumerConfig
+
+	visibilityTimeout time.Duration
+	maxReceiveCount   int
+
+	ch              chan *Message
+	deletes         chan string
+	ctx             context.Context
+	cancel          context.CancelFunc
+	abandon         chan struct{}
+	abandonOnce     sync.Once
+	closed          chan struct{}
+	itemsInProgress sync.WaitGroup
+
+	mu       sync.Mutex
+	inFlight map[string]*Message
+	isClosed bool
+
+	deletesMu     sync.RWMutex
+	deletesClosed bool
+}
+
+func newConsumer(client *sqs.Client, cfg *ConsumerConfig) (*Consumer, error) {
+	attrs, err := client.GetQueueAttributes(context.Background(), &sqs.GetQueueAttributesInput{
+		QueueUrl: aws.String(cfg.QueueURL),
+		AttributeNames: []types.QueueAttributeName{
+			types.QueueAttributeNameVisibilityTimeout,
+			types.QueueAttributeNameRedrivePolicy,
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get queue attributes")
+	}
+
+	visibilityTimeout := cfg.VisibilityTimeout
+	if visibilityTimeout <= 0 {
+		seconds, _ := strconv.Atoi(attrs.Attributes[string(types.QueueAttributeNameVisibilityTimeout)])
+		visibilityTimeout = time.Duration(seconds) * time.Second
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Consumer{
+		client:            client,
+		cfg:               cfg,
+		visibilityTimeout: visibilityTimeout,
+		maxReceiveCount:   parseMaxReceiveCount(attrs.Attributes[string(types.QueueAttributeNameRedrivePolicy)]),
+		ch:                make(chan *Message),
+		deletes:           make(chan string, maxBatchSize),
+		ctx:               ctx,
+		cancel:            cancel,
+		abandon:           make(chan struct{}),
+		closed:            make(chan struct{}),
+		inFlight:          make(map[string]*Message),
+	}, nil
+}
+
+func (c *Consumer) Consume() chan *Message {
+	return c.ch
+}
+
+// Close stops receiving messages and waits until received messages are acknowledged, up to the visibility
+// timeout of the queue. See Shutdown
+func (c *Consumer) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), max(c.visibilityTimeout, maxWaitTime))
+	defer cancel()
+
+	return c.Shutdown(ctx)
+}
+
+// Shutdown stops receiving messages and waits until received messages are acknowledged or ctx is done.
+// Visibility timeouts of messages not acknowledged by then are not extended anymore, so they are received
+// again after it unless they are acknowledged before
+func (c *Consumer) Shutdown(ctx context.Context) error {
+	c.mu.Lock()
+	if !c.isClosed {
+		c.isClosed = true
+		c.cancel()
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-c.closed:
+		return nil
+	case <-ctx.Done():
+	}
+
+	c.abandonOnce.Do(func() { close(c.abandon) })
+	<-c.closed
+	return errors.Wrap(ctx.Err(), "messages are not acknowledged")
+}
+
+func (c *Consumer) start() {
+	deleterDone := make(chan struct{})
+	go func() {
+		c.deleteLoop()
+		close(deleterDone)
+	}()
+
+	extenderDone := make(chan struct{})
+	go func() {
+		c.extendLoop(extenderDone)
+	}()
+
+	for c.ctx.Err() == nil {
+		out, err := c.client.ReceiveMessage(c.ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:              aws.String(c.cfg.QueueURL),
+			MaxNumberOfMessages:   int32(c.cfg.MaxMessages),
+			WaitTimeSeconds:       int32(c.cfg.WaitTime.Seconds()),
+			VisibilityTimeout:     int32(c.cfg.VisibilityTimeout.Seconds()),
+			AttributeNames:        []types.QueueAttributeName{types.QueueAttributeNameAll},
+			MessageAttributeNames: []string{"All"},
+		})
+		if err != nil {
+			if c.ctx.Err() != nil {
+				break
+			}
+
+			infralog.Error("sqs consumer: receive message", zap.String("queue", c.cfg.QueueURL), zap.Error(err))
+			time.Sleep(receiveErrorDelay) // time to wait to not make infinite "for" loop
+			continue
+		}
+
+		for i := range out.Messages {
+			msg := newMessage(out.Messages[i], c)
+			c.track(msg)
+
+			select {
+			case c.ch <- msg:
+			case <-c.ctx.Done():
+				// consumer is closing: return undelivered message to the queue
+				_ = msg.Nack()
+			}
+		}
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		c.itemsInProgress.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-c.abandon:
+	}
+
+	close(extenderDone)
+
+	// messages acknowledged after that are deleted one by one
+	c.deletesMu.Lock()
+	c.deletesClosed = true
+	close(c.deletes)
+	c.deletesMu.Unlock()
+	<-deleterDone
+	close(c.ch)
+	close(c.closed)
+}
+
+func (c *Consumer) track(msg *Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.itemsInProgress.Add(1)
+	c.inFlight[msg.receiptHandle()] = msg
+}
+
+func (c *Consumer) untrack(msg *Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.inFlight[msg.receiptHandle()]; ok {
+		delete(c.inFlight, msg.receiptHandle())
+		c.itemsInProgress.Done()
+	}
+}
+
+func (c *Consumer) ack(msg *Message) {
+	defer c.untrack(msg)
+
+	c.deletesMu.RLock()
+	defer c.deletesMu.RUnlock()
+
+	if !c.deletesClosed {
+		c.deletes <- msg.receiptHandle()
+		return
+	}
+
+	_, err := c.client.DeleteMessage(context.Background(), &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(c.cfg.QueueURL),
+		ReceiptHandle: aws.String(msg.receiptHandle()),
+	})
+	if err != nil {
+		infralog.Error("sqs consumer: delete message", zap.String("queue", c.cfg.QueueURL), zap.Error(err))
+	}
+}
+
+func (c *Consumer) nack(msg *Message) error {
+	defer c.untrack(msg)
+
+	_, err := c.client.ChangeMessageVisibility(context.Background(), &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String(c.cfg.QueueURL),
+		ReceiptHandle:     aws.String(msg.receiptHandle()),
+		VisibilityTimeout: 0,
+	})
+	if err != nil {
+		return errors.Wrap(err, "unable to change message visibility")
+	}
+
+	return nil
+}
+
+// deleteLoop deletes acknowledged messages in batches
+func (c *Consumer) deleteLoop() {
+	ticker := time.NewTicker(c.cfg.DeleteInterval)
+	defer ticker.Stop()
+
+	batch := make([]string, 0, maxBatchSize)
+	for {
+		select {
+		case handle, ok := <-c.deletes:
+			if !ok {
+				c.deleteBatch(batch)
+				return
+			}
+
+			batch = append(batch, handle)
+			if len(batch) == maxBatchSize {
+				c.deleteBatch(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			c.deleteBatch(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+func (c *Consumer) deleteBatch(handles []string) {
+	if len(handles) == 0 {
+		return
+	}
+
+	entries := make([]types.DeleteMessageBatchRequestEntry, len(handles))
+	for i := range handles {
+		entries[i] = types.DeleteMessageBatchRequestEntry{
+			Id:            aws.String(strconv.Itoa(i)),
+			ReceiptHandle: aws.String(handles[i]),
+		}
+	}
+
+	out, err := c.client.DeleteMessageBatch(context.Background(), &sqs.DeleteMessageBatchInput{
+		QueueUrl: aws.String(c.cfg.QueueURL),
+		Entries:  entries,
+	})
+	if err != nil {
+		infralog.Error("sqs consumer: delete messages", zap.String("queue", c.cfg.QueueURL), zap.Error(err))
+		return
+	}
+
+	for _, failed := range out.Failed {
+		infralog.Error("sqs consumer: delete message",
+			zap.String("queue", c.cfg.QueueURL),
+			zap.String("code", aws.ToString(failed.Code)),
+			zap.String("reason", aws.ToString(failed.Message)))
+	}
+}
+
+// extendLoop extends visibility timeout of messages that are still in progress
+func (c *Consumer) extendLoop(done chan struct{}) {
+	if c.visibilityTimeout <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(c.visibilityTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			c.mu.Lock()
+			handles := make([]string, 0, len(c.inFlight))
+			for handle := range c.inFlight {
+				handles = append(handles, handle)
+			}
+			c.mu.Unlock()
+
+			for len(handles) > 0 {
+				n := min(len(handles), maxBatchSize)
+				c.extendBatch(handles[:n])
+				handles = handles[n:]
+			}
+		}
+	}
+}
+
+func (c *Consumer) extendBatch(handles []string) {
+	entries := make([]types.ChangeMessageVisibilityBatchRequestEntry, len(handles))
+	for i := range handles {
+		entries[i] = types.ChangeMessageVisibilityBatchRequestEntry{
+			Id:                aws.String(strconv.Itoa(i)),
+			ReceiptHandle:     aws.String(handles[i]),
+			VisibilityTimeout: int32(c.visibilityTimeout.Seconds()),
+		}
+	}
+
+	_, err := c.client.ChangeMessageVisibilityBatch(context.Background(), &sqs.ChangeMessageVisibilityBatchInput{
+		QueueUrl: aws.String(c.cfg.QueueURL),
+		Entries:  entries,
+	})
+	if err != nil {
+		infralog.Error("sqs consumer: extend visibility timeout", zap.String("queue", c.cfg.QueueURL), zap.Error(err))
+	}
+}
+
+// parseMaxReceiveCount extracts maxReceiveCount from queue redrive policy.
+// Returns 0 if queue has no dead-letter queue.
+func parseMaxReceiveCount(redrivePolicy string) int {
+	if redrivePolicy == "" {
+		return 0
+	}
+
+	var policy struct {
+		MaxReceiveCount json.Number `json:"maxReceiveCount"`
+	}
+	if err := json.Unmarshal([]byte(redrivePolicy), &policy); err != nil {
+		return 0
+	}
+
+	count, _ := policy.MaxReceiveCount.Int64()
+	return int(count)
+}
@@ -0,0 +1,111 @@
+package infraawsqueue
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/pkg/errors"
+)
+
+// Container is a simple container for holding named AWS connections.
+type Container struct {
+	mu  *sync.RWMutex
+	cfg map[string]*ConnectionConfig
+	sqs map[string]*sqs.Client
+	sns map[string]*sns.Client
+}
+
+func NewContainer() *Container {
+	return &Container{
+		mu:  &sync.RWMutex{},
+		cfg: make(map[string]*ConnectionConfig),
+		sqs: make(map[string]*sqs.Client),
+		sns: make(map[string]*sns.Client),
+	}
+}
+
+// AddConnection adds a named connection to a container.
+// It's possible to create consumer or publisher on created connection later using CreateConsumer or CreatePublisher.
+func (cont *Container) AddConnection(name string, cfg *ConnectionConfig) error {
+	opts := []func(*awsconfig.LoadOptions) error{
+		awsconfig.WithRegion(cfg.Region),
+	}
+
+	if cfg.AccessKeyID != "" {
+		opts = append(opts, awsconfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, "")))
+	}
+
+	awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), opts...)
+	if err != nil {
+		return errors.Wrap(err, "unable to load AWS config")
+	}
+
+	sqsClient := sqs.NewFromConfig(awsCfg, func(o *sqs.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+	})
+
+	snsClient := sns.NewFromConfig(awsCfg, func(o *sns.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+	})
+
+	cont.mu.Lock()
+	defer cont.mu.Unlock()
+
+	cont.cfg[name] = cfg
+	cont.sqs[name] = sqsClient
+	cont.sns[name] = snsClient
+
+	return nil
+}
+
+// CreateConsumer creates a new SQS consumer by a connection name and starts receiving messages
+func (cont *Container) CreateConsumer(consumerCfg *ConsumerConfig) (*Consumer, error) {
+	if err := consumerCfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	cont.mu.RLock()
+	client, ok := cont.sqs[consumerCfg.ConnectionName]
+	cont.mu.RUnlock()
+	if !ok {
+		return nil, errors.Errorf("invalid connection name: %s", consumerCfg.ConnectionName)
+	}
+
+	consumer, err := newConsumer(client, consumerCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	go consumer.start()
+	return consumer, nil
+}
+
+// CreatePublisher creates a new SQS/SNS publisher by a connection name
+func (cont *Container) CreatePublisher(publisherCfg *PublisherConfig) (*Publisher, error) {
+	if publisherCfg == nil {
+		return nil, errors.New("config is required")
+	}
+
+	cont.mu.RLock()
+	defer cont.mu.RUnlock()
+
+	sqsClient, ok := cont.sqs[publisherCfg.ConnectionName]
+	if !ok {
+		return nil, errors.Errorf("invalid connection name: %s", publisherCfg.ConnectionName)
+	}
+
+	return &Publisher{
+		sqs: sqsClient,
+		sns: cont.sns[publisherCfg.ConnectionName],
+	}, nil
+}
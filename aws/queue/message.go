@@ -0,0 +1,82 @@
+package infraawsqueue
+
+import (
+	"strconv"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// Message is a message received from SQS queue.
+// The interface is the same as infrarabbit.Message, so services can switch brokers.
+type Message struct {
+	msg          types.Message
+	consumer     *Consumer
+	receiveCount int
+	once         atomic.Bool
+}
+
+func newMessage(msg types.Message, consumer *Consumer) *Message {
+	receiveCount, _ := strconv.Atoi(msg.Attributes[string(types.MessageSystemAttributeNameApproximateReceiveCount)])
+
+	return &Message{
+		msg:          msg,
+		consumer:     consumer,
+		receiveCount: receiveCount,
+	}
+}
+
+// Ack marks the message as processed. Messages are deleted from the queue in batches.
+func (m *Message) Ack() error {
+	if m.once.Swap(true) {
+		return nil
+	}
+
+	m.consumer.ack(m)
+	return nil
+}
+
+// Nack returns the message to the queue immediately
+func (m *Message) Nack() error {
+	if m.once.Swap(true) {
+		return nil
+	}
+
+	return m.consumer.nack(m)
+}
+
+func (m *Message) IsRedelivered() bool {
+	return m.receiveCount > 1
+}
+
+func (m *Message) Body() []byte {
+	return []byte(aws.ToString(m.msg.Body))
+}
+
+func (m *Message) ID() string {
+	return aws.ToString(m.msg.MessageId)
+}
+
+// Attribute returns string value of a message attribute
+func (m *Message) Attribute(name string) string {
+	if attr, ok := m.msg.MessageAttributes[name]; ok {
+		return aws.ToString(attr.StringValue)
+	}
+	return ""
+}
+
+// ReceiveCount returns the number of times the message has been received
+func (m *Message) ReceiveCount() int {
+	return m.receiveCount
+}
+
+// IsLastAttempt returns true if the message will be moved to the dead-letter queue
+// after it's not acknowledged this time. Always false for queues without redrive policy.
+func (m *Message) IsLastAttempt() bool {
+	return m.consumer.maxReceiveCount > 0 && m.receiveCount >= m.consumer.maxReceiveCount
+}
+
+func (m *Message) receiptHandle() string {
+	return aws.ToString(m.msg.ReceiptHandle)
+}
@@ -0,0 +1,114 @@
+package infraawsqueue
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/pkg/errors"
+)
+
+// Publisher sends messages to SQS queues or SNS topics
+type Publisher struct {
+	sqs *sqs.Client
+	sns *sns.Client
+}
+
+type PublisherMessage struct {
+	// SQS queue URL or SNS topic ARN
+	Target string
+
+	Body       []byte
+	Attributes map[string]string
+
+	// FIFO queues and topics only
+	GroupID         string
+	DeduplicationID string
+}
+
+// Publish sends a message to SNS topic if target is a topic ARN or to SQS queue otherwise
+func (p *Publisher) Publish(ctx context.Context, msg *PublisherMessage) error {
+	if msg == nil {
+		return errors.New("message is nil")
+	}
+
+	if msg.Target == "" {
+		return errors.New("message target is empty")
+	}
+
+	if isTopicARN(msg.Target) {
+		return p.publishSNS(ctx, msg)
+	}
+
+	return p.publishSQS(ctx, msg)
+}
+
+func (p *Publisher) publishSQS(ctx context.Context, msg *PublisherMessage) error {
+	input := &sqs.SendMessageInput{
+		QueueUrl:    aws.String(msg.Target),
+		MessageBody: aws.String(string(msg.Body)),
+	}
+
+	if len(msg.Attributes) > 0 {
+		input.MessageAttributes = make(map[string]sqstypes.MessageAttributeValue, len(msg.Attributes))
+		for k, v := range msg.Attributes {
+			input.MessageAttributes[k] = sqstypes.MessageAttributeValue{
+				DataType:    aws.String("String"),
+				StringValue: aws.String(v),
+			}
+		}
+	}
+
+	if msg.GroupID != "" {
+		input.MessageGroupId = aws.String(msg.GroupID)
+	}
+	if msg.DeduplicationID != "" {
+		input.MessageDeduplicationId = aws.String(msg.DeduplicationID)
+	}
+
+	if _, err := p.sqs.SendMessage(ctx, input); err != nil {
+		return errors.Wrap(err, "unable to send SQS message")
+	}
+
+	return nil
+}
+
+func (p *Publisher) publishSNS(ctx context.Context, msg *PublisherMessage) error {
+	input := &sns.PublishInput{
+		TopicArn: aws.String(msg.Target),
+		Message:  aws.String(string(msg.Body)),
+	}
+
+	if len(msg.Attributes) > 0 {
+		input.MessageAttributes = make(map[string]snstypes.MessageAttributeValue, len(msg.Attributes))
+		for k, v := range msg.Attributes {
+			input.MessageAttributes[k] = snstypes.MessageAttributeValue{
+				DataType:    aws.String("String"),
+				StringValue: aws.String(v),
+			}
+		}
+	}
+
+	if msg.GroupID != "" {
+		input.MessageGroupId = aws.String(msg.GroupID)
+	}
+	if msg.DeduplicationID != "" {
+		input.MessageDeduplicationId = aws.String(msg.DeduplicationID)
+	}
+
+	if _, err := p.sns.Publish(ctx, input); err != nil {
+		return errors.Wrap(err, "unable to publish SNS message")
+	}
+
+	return nil
+}
+
+// isTopicARN reports whether target is an SNS topic ARN of any partition, e.g. aws-cn or aws-us-gov
+func isTopicARN(target string) bool {
+	parsed, err := arn.Parse(target)
+	return err == nil && parsed.Service == "sns"
+}
@@ -0,0 +1,19 @@
+package infraawsqueue
+
+import "testing"
+
+func TestIsTopicARN(t *testing.T) {
+	cases := map[string]bool{
+		"arn:aws:sns:us-east-1:123456789012:events":        true,
+		"arn:aws-cn:sns:cn-north-1:123456789012:events":    true,
+		"arn:aws-us-gov:sns:us-gov-west-1:123456789012:ev": true,
+		"arn:aws:sqs:us-east-1:123456789012:events":        false,
+		"https://sqs.us-east-1.amazonaws.com/123/events":   false,
+	}
+
+	for target, expected := range cases {
+		if isTopicARN(target) != expected {
+			t.Errorf("isTopicARN(%q) != %v", target, expected)
+		}
+	}
+}
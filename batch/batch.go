@@ -0,0 +1,303 @@
+package infrabatch
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	infraclock "github.com/pushwoosh/infra/clock"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	"go.uber.org/zap"
+)
+
+// ErrStopped is returned by Add after Stop
+var ErrStopped = errors.New("batcher is stopped")
+
+// ErrFull is returned by TryAdd while MaxPending items are waiting for flush
+var ErrFull = errors.New("batcher is full")
+
+// FlushFunc writes a batch, e.g. with a single insert or bulk request. The items slice is not reused
+type FlushFunc[T any] func(ctx context.Context, items []T) error
+
+// Batcher accumulates items and flushes them in batches by the number of items, size in bytes and age.
+// Batches are flushed in order by a single goroutine, failed flushes are retried with backoff and dropped
+// after MaxRetries. Add blocks when MaxPending items are waiting for flush, so memory is bounded
+// when the flush function is slower than producers:
+//
+//	batcher, err := infrabatch.New("events", cfg, func(ctx context.Context, events []*Event) error {
+//		return insertEvents(ctx, events)
+//	})
+//	...
+//	err = batcher.Add(ctx, event)
+type Batcher[T any] struct {
+	name  string
+	cfg   *Config
+	flush FlushFunc[T]
+	clock infraclock.Clock
+
+	size        func(item T) int
+	shouldRetry func(err error) bool
+	onError     func(ctx context.Context, items []T, err error)
+
+	// slots bounds the number of pending items
+	slots chan struct{}
+	queue chan *batch[T]
+
+	mu      sync.Mutex
+	items   []T
+	bytes   int
+	last    *batch[T]
+	stopped bool
+
+	runMu   sync.Mutex
+	started bool
+	cancel  context.CancelFunc
+	done    chan struct{}
+	flushed chan struct{}
+}
+
+type batch[T any] struct {
+	items []T
+	err   error
+	done  chan struct{}
+}
+
+var (
+	_ infraoperator.Starter = (*Batcher[any])(nil)
+	_ infraoperator.Stopper = (*Batcher[any])(nil)
+)
+
+// New creates a batcher. Call Start to begin flushing
+func New[T any](name string, cfg *Config, flush FlushFunc[T], opts ...Option[T]) (*Batcher[T], error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	initMetrics()
+
+	b := &Batcher[T]{
+		name:        name,
+		cfg:         cfg,
+		flush:       flush,
+		clock:       infraclock.Real,
+		shouldRetry: func(error) bool { return true },
+		onError: func(ctx context.Context, items []T, err error) {
+			infralog.ErrorCtx(ctx, "batch flush failed, batch is dropped",
+				zap.String("name", name), zap.Int("items", len(items)), zap.Error(err))
+		},
+		slots:   make(chan struct{}, cfg.GetMaxPending()),
+		queue:   make(chan *batch[T], cfg.GetMaxPending()),
+		flushed: make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt.apply(b)
+	}
+
+	if cfg.MaxBytes > 0 && b.size == nil {
+		return nil, errors.New("max_bytes requires WithSizeFunc option")
+	}
+
+	return b, nil
+}
+
+// Add adds the item to the current batch. It blocks while MaxPending items are waiting for flush
+func (b *Batcher[T]) Add(ctx context.Context, item T) error {
+	select {
+	case b.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return b.add(item)
+}
+
+// TryAdd adds the item to the current batch without waiting. It returns ErrFull while MaxPending items
+// are waiting for flush
+func (b *Batcher[T]) TryAdd(item T) error {
+	select {
+	case b.slots <- struct{}{}:
+	default:
+		return ErrFull
+	}
+
+	return b.add(item)
+}
+
+// add adds the item holding a slot
+func (b *Batcher[T]) add(item T) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.stopped {
+		<-b.slots
+		return ErrStopped
+	}
+
+	metrics.PendingItemsGauge.WithLabelValues(b.name).Inc()
+
+	b.items = append(b.items, item)
+	if b.size != nil {
+		b.bytes += b.size(item)
+	}
+
+	switch {
+	case b.cfg.MaxItems > 0 && len(b.items) >= b.cfg.MaxItems:
+		b.enqueue("items")
+	case b.cfg.MaxBytes > 0 && b.bytes >= b.cfg.MaxBytes:
+		b.enqueue("bytes")
+	}
+
+	return nil
+}
+
+// Flush flushes the current batch and waits until it and all previous batches are flushed.
+// It returns the error of the last batch
+func (b *Batcher[T]) Flush(ctx context.Context) error {
+	b.mu.Lock()
+	if len(b.items) > 0 && !b.stopped {
+		b.enqueue("manual")
+	}
+	last := b.last
+	b.mu.Unlock()
+
+	if last == nil {
+		return nil
+	}
+
+	select {
+	case <-last.done:
+		return last.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Start starts flushing of batches
+func (b *Batcher[T]) Start(_ context.Context) error {
+	b.runMu.Lock()
+	defer b.runMu.Unlock()
+
+	if b.started {
+		return errors.New("batcher is already started")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b.started = true
+	b.cancel = cancel
+	b.done = make(chan struct{})
+
+	go b.process()
+	go b.run(ctx)
+
+	return nil
+}
+
+// Stop flushes remaining items and waits until all batches are flushed or ctx is done
+func (b *Batcher[T]) Stop(ctx context.Context) error {
+	b.runMu.Lock()
+	started := b.started
+	if b.cancel != nil {
+		b.cancel()
+		<-b.done
+		b.cancel = nil
+	}
+	b.runMu.Unlock()
+
+	b.mu.Lock()
+	first := !b.stopped
+	if first {
+		if len(b.items) > 0 {
+			b.enqueue("stop")
+		}
+		b.stopped = true
+		close(b.queue)
+	}
+	b.mu.Unlock()
+
+	if !started && first {
+		// nothing processes the queue of a batcher that was never started
+		go b.process()
+	}
+
+	select {
+	case <-b.flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// enqueue moves the current batch to the flush queue. It never blocks as the queue
+// has room for MaxPending batches. b.mu must be held
+func (b *Batcher[T]) enqueue(reason string) {
+	metrics.FlushesCounter.WithLabelValues(b.name, reason).Inc()
+
+	b.last = &batch[T]{items: b.items, done: make(chan struct{})}
+	b.queue <- b.last
+	b.items, b.bytes = nil, 0
+}
+
+// run flushes batches by age
+func (b *Batcher[T]) run(ctx context.Context) {
+	defer close(b.done)
+
+	ticker := b.clock.NewTicker(b.cfg.GetMaxAge())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			b.mu.Lock()
+			if len(b.items) > 0 && !b.stopped {
+				b.enqueue("age")
+			}
+			b.mu.Unlock()
+		}
+	}
+}
+
+// process flushes queued batches in order until the queue is closed
+func (b *Batcher[T]) process() {
+	defer close(b.flushed)
+
+	for batch := range b.queue {
+		batch.err = b.flushWithRetries(batch.items)
+		close(batch.done)
+
+		for range batch.items {
+			<-b.slots
+		}
+		metrics.PendingItemsGauge.WithLabelValues(b.name).Sub(float64(len(batch.items)))
+	}
+}
+
+func (b *Batcher[T]) flushWithRetries(items []T) error {
+	// in-flight batches are not interrupted by Stop
+	ctx := context.Background()
+	backoff := b.cfg.GetRetryBackoff()
+
+	for attempt := 0; ; attempt++ {
+		start := b.clock.Now()
+		err := b.flush(ctx, items)
+		metrics.FlushDuration.WithLabelValues(b.name).Observe(b.clock.Now().Sub(start).Seconds())
+
+		if err == nil {
+			metrics.ItemsCounter.WithLabelValues(b.name, "success").Add(float64(len(items)))
+			return nil
+		}
+
+		if attempt >= b.cfg.MaxRetries || !b.shouldRetry(err) {
+			metrics.ItemsCounter.WithLabelValues(b.name, "dropped").Add(float64(len(items)))
+			b.onError(ctx, items, err)
+			return err
+		}
+
+		metrics.RetriesCounter.WithLabelValues(b.name).Inc()
+		_ = b.clock.Sleep(ctx, backoff)
+		backoff *= 2
+	}
+}
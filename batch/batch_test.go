@@ -0,0 +1,129 @@
+package infrabatch
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	infraclock "github.com/pushwoosh/infra/clock"
+)
+
+type recorder struct {
+	mu      sync.Mutex
+	batches [][]int
+	fail    int
+}
+
+func (r *recorder) flush(_ context.Context, items []int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.fail > 0 {
+		r.fail--
+		return errors.New("unavailable")
+	}
+
+	r.batches = append(r.batches, items)
+	return nil
+}
+
+func (r *recorder) get() [][]int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.batches
+}
+
+func TestBatcher(t *testing.T) {
+	ctx := context.Background()
+	clock := infraclock.NewFake(time.Now())
+	r := &recorder{fail: 1}
+
+	b, err := New("test", &Config{MaxItems: 3, MaxAge: time.Second, MaxRetries: 1}, r.flush,
+		WithClock[int](clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = b.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i <= 4; i++ {
+		if err = b.Add(ctx, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the first flush fails and is retried after backoff
+	clock.BlockUntil(2)
+	clock.Advance(100 * time.Millisecond)
+
+	// the ticker flushes the rest by age
+	clock.Advance(time.Second)
+	if err = b.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = b.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	batches := r.get()
+	if len(batches) != 2 || len(batches[0]) != 3 || len(batches[1]) != 1 || batches[1][0] != 4 {
+		t.Fatalf("unexpected batches %v", batches)
+	}
+
+	if err = b.Add(ctx, 5); !errors.Is(err, ErrStopped) {
+		t.Fatalf("expected ErrStopped, got %v", err)
+	}
+}
+
+func TestBatcherMaxPending(t *testing.T) {
+	r := &recorder{}
+	b, err := New("test_pending", &Config{MaxItems: 2, MaxPending: 2}, r.flush)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// not started, so the pending batch is not flushed and Add blocks
+	_ = b.Add(ctx, 1)
+	_ = b.Add(ctx, 2)
+	if err = b.Add(ctx, 3); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+	if err = b.TryAdd(3); !errors.Is(err, ErrFull) {
+		t.Fatalf("expected ErrFull, got %v", err)
+	}
+
+	if err = b.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if batches := r.get(); len(batches) != 1 || len(batches[0]) != 2 {
+		t.Fatalf("unexpected batches %v", batches)
+	}
+}
+
+func TestBatcherStopNotStarted(t *testing.T) {
+	unblock := make(chan struct{})
+	defer close(unblock)
+
+	b, err := New("test_stop", &Config{MaxItems: 10}, func(context.Context, []int) error {
+		<-unblock
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = b.Add(context.Background(), 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err = b.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+}
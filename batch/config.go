@@ -0,0 +1,89 @@
+package infrabatch
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type Config struct {
+	// Max number of items in a batch. optional
+	MaxItems int `mapstructure:"max_items"`
+
+	// Max size of a batch in bytes, items are measured with the function of WithSizeFunc. optional
+	MaxBytes int `mapstructure:"max_bytes"`
+
+	// Max time an item waits in the batch before flush. optional, default: 1s
+	MaxAge time.Duration `mapstructure:"max_age"`
+
+	// Max number of items added but not flushed yet, Add blocks when it's reached.
+	// optional, default: 10 batches of MaxItems or 10000
+	MaxPending int `mapstructure:"max_pending"`
+
+	// Number of flush retries before the batch is dropped. optional, default: 0
+	MaxRetries int `mapstructure:"max_retries"`
+
+	// Delay before the first retry, doubled on every next one. optional, default: 100ms
+	RetryBackoff time.Duration `mapstructure:"retry_backoff"`
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if c.MaxItems < 0 {
+		return errors.New("max_items should be greater than or equal to 0")
+	}
+
+	if c.MaxBytes < 0 {
+		return errors.New("max_bytes should be greater than or equal to 0")
+	}
+
+	if c.MaxAge < 0 {
+		return errors.New("max_age should be greater than or equal to 0")
+	}
+
+	if c.MaxPending < 0 {
+		return errors.New("max_pending should be greater than or equal to 0")
+	}
+
+	if c.MaxPending > 0 && c.MaxPending < c.MaxItems {
+		return errors.New("max_pending should be greater than or equal to max_items")
+	}
+
+	if c.MaxRetries < 0 {
+		return errors.New("max_retries should be greater than or equal to 0")
+	}
+
+	if c.RetryBackoff < 0 {
+		return errors.New("retry_backoff should be greater than or equal to 0")
+	}
+
+	return nil
+}
+
+func (c *Config) GetMaxAge() time.Duration {
+	if c.MaxAge == 0 {
+		return time.Second
+	}
+	return c.MaxAge
+}
+
+func (c *Config) GetMaxPending() int {
+	switch {
+	case c.MaxPending > 0:
+		return c.MaxPending
+	case c.MaxItems > 0:
+		return 10 * c.MaxItems
+	default:
+		return 10000
+	}
+}
+
+func (c *Config) GetRetryBackoff() time.Duration {
+	if c.RetryBackoff == 0 {
+		return 100 * time.Millisecond
+	}
+	return c.RetryBackoff
+}
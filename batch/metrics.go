@@ -0,0 +1,55 @@
+package infrabatch
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	FlushesCounter    *prometheus.CounterVec
+	ItemsCounter      *prometheus.CounterVec
+	RetriesCounter    *prometheus.CounterVec
+	FlushDuration     *prometheus.HistogramVec
+	PendingItemsGauge *prometheus.GaugeVec
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.FlushesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "batch_flushes_total",
+			Help: "Number of batch flushes by reason: items, bytes, age or manual",
+		}, []string{"name", "reason"})
+
+		metrics.ItemsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "batch_items_total",
+			Help: "Number of flushed items by result: success or dropped",
+		}, []string{"name", "result"})
+
+		metrics.RetriesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "batch_flush_retries_total",
+			Help: "Number of retried flushes",
+		}, []string{"name"})
+
+		metrics.FlushDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "batch_flush_duration_seconds",
+			Help:    "Duration of flush function calls",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"name"})
+
+		metrics.PendingItemsGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "batch_pending_items",
+			Help: "Number of added items that are not flushed yet",
+		}, []string{"name"})
+
+		prometheus.MustRegister(
+			metrics.FlushesCounter,
+			metrics.ItemsCounter,
+			metrics.RetriesCounter,
+			metrics.FlushDuration,
+			metrics.PendingItemsGauge,
+		)
+	})
+}
@@ -0,0 +1,58 @@
+package infrabatch
+
+import (
+	"context"
+
+	infraclock "github.com/pushwoosh/infra/clock"
+)
+
+type Option[T any] interface {
+	apply(b *Batcher[T])
+}
+
+type optionSizeFunc[T any] func(item T) int
+
+func (opt optionSizeFunc[T]) apply(b *Batcher[T]) {
+	b.size = opt
+}
+
+// WithSizeFunc sets a function measuring items in bytes for Config.MaxBytes
+func WithSizeFunc[T any](fn func(item T) int) Option[T] {
+	return optionSizeFunc[T](fn)
+}
+
+type optionShouldRetry[T any] func(err error) bool
+
+func (opt optionShouldRetry[T]) apply(b *Batcher[T]) {
+	b.shouldRetry = opt
+}
+
+// WithShouldRetry sets a function deciding if a failed flush is retried. By default all errors are retried
+func WithShouldRetry[T any](fn func(err error) bool) Option[T] {
+	return optionShouldRetry[T](fn)
+}
+
+type optionOnError[T any] func(ctx context.Context, items []T, err error)
+
+func (opt optionOnError[T]) apply(b *Batcher[T]) {
+	b.onError = opt
+}
+
+// WithOnError sets a callback called with batches dropped after failed retries, e.g. to save them elsewhere.
+// By default the error is logged
+func WithOnError[T any](fn func(ctx context.Context, items []T, err error)) Option[T] {
+	return optionOnError[T](fn)
+}
+
+type optionClock[T any] struct {
+	clock infraclock.Clock
+}
+
+func (opt optionClock[T]) apply(b *Batcher[T]) {
+	b.clock = opt.clock
+}
+
+// WithClock sets a clock of age flushes and retry backoff, e.g. a fake one in tests
+func WithClock[T any](clock infraclock.Clock) Option[T] {
+	return optionClock[T]{clock: clock}
+}
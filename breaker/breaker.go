@@ -0,0 +1,300 @@
+package infrabreaker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	"go.uber.org/zap"
+)
+
+// ErrOpen is returned without calling the protected function when the breaker is open
+var ErrOpen = errors.New("circuit breaker is open")
+
+type State int
+
+const (
+	StateClosed State = iota
+	StateHalfOpen
+	StateOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	case StateOpen:
+		return "open"
+	}
+	return "unknown"
+}
+
+// Breaker is a circuit breaker. It counts failed and slow calls in a sliding window of last calls
+// and opens when either rate exceeds its threshold. After OpenTimeout it lets HalfOpenCalls trial
+// calls through and closes again if their rates are below thresholds.
+//
+//	b, err := infrabreaker.New("billing", infrabreaker.DefaultConfig())
+//	err = b.Execute(ctx, func(ctx context.Context) error {
+//		return callBilling(ctx)
+//	})
+type Breaker struct {
+	name      string
+	cfg       *Config
+	isFailure func(err error) bool
+	onChange  func(name string, from, to State)
+
+	mu         sync.Mutex
+	state      State
+	generation uint64
+	openedAt   time.Time
+	window     *window
+	inFlight   int
+}
+
+func New(name string, cfg *Config, opts ...Option) (*Breaker, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	initMetrics()
+
+	b := &Breaker{
+		name:      name,
+		cfg:       cfg,
+		isFailure: defaultIsFailure,
+		window:    newWindow(cfg.WindowSize),
+	}
+
+	for _, opt := range opts {
+		opt.apply(b)
+	}
+
+	metrics.StateGauge.WithLabelValues(name).Set(float64(StateClosed))
+
+	return b, nil
+}
+
+func defaultIsFailure(err error) bool {
+	return err != nil && !errors.Is(err, context.Canceled)
+}
+
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// State returns current breaker state
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refreshState(time.Now())
+	return b.state
+}
+
+// Execute calls fn if the breaker allows it and records the result
+func (b *Breaker) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	done, err := b.Allow()
+	if err != nil {
+		return err
+	}
+
+	defer recordPanic(done)
+
+	err = fn(ctx)
+	done(err)
+
+	return err
+}
+
+// Execute calls fn returning a value if the breaker allows it and records the result
+func Execute[T any](ctx context.Context, b *Breaker, fn func(ctx context.Context) (T, error)) (T, error) {
+	done, err := b.Allow()
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	defer recordPanic(done)
+
+	res, err := fn(ctx)
+	done(err)
+
+	return res, err
+}
+
+// recordPanic records a panic of the protected function as a failure, so its half-open slot is released
+func recordPanic(done func(err error)) {
+	if r := recover(); r != nil {
+		done(errors.Errorf("panic: %v", r))
+		panic(r)
+	}
+}
+
+// Allow checks if a call is permitted. If so, done must be called with the call result.
+// Returns ErrOpen if the call is rejected.
+func (b *Breaker) Allow() (done func(err error), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.refreshState(now)
+
+	switch b.state {
+	case StateOpen:
+		metrics.CallsCounter.WithLabelValues(b.name, "rejected").Inc()
+		return nil, ErrOpen
+	case StateHalfOpen:
+		if b.inFlight+b.window.count >= b.cfg.HalfOpenCalls {
+			metrics.CallsCounter.WithLabelValues(b.name, "rejected").Inc()
+			return nil, ErrOpen
+		}
+	}
+
+	b.inFlight++
+	generation := b.generation
+
+	return func(err error) {
+		b.record(generation, time.Since(now), err)
+	}, nil
+}
+
+func (b *Breaker) record(generation uint64, duration time.Duration, err error) {
+	failed := b.isFailure(err)
+	slow := b.cfg.SlowCallDuration > 0 && duration > b.cfg.SlowCallDuration
+
+	switch {
+	case failed:
+		metrics.CallsCounter.WithLabelValues(b.name, "failure").Inc()
+	case slow:
+		metrics.CallsCounter.WithLabelValues(b.name, "slow").Inc()
+	default:
+		metrics.CallsCounter.WithLabelValues(b.name, "success").Inc()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// the call was started before the last state change
+	if generation != b.generation {
+		return
+	}
+
+	b.inFlight--
+	b.window.add(failed, slow)
+
+	switch b.state {
+	case StateClosed:
+		if b.window.count >= b.cfg.MinCalls && b.exceeded() {
+			b.setState(StateOpen, time.Now())
+		}
+	case StateHalfOpen:
+		if b.window.count >= b.cfg.HalfOpenCalls {
+			if b.exceeded() {
+				b.setState(StateOpen, time.Now())
+			} else {
+				b.setState(StateClosed, time.Now())
+			}
+		}
+	}
+}
+
+func (b *Breaker) exceeded() bool {
+	failureRate, slowRate := b.window.rates()
+	if failureRate >= b.cfg.FailureRateThreshold {
+		return true
+	}
+
+	return b.cfg.SlowCallDuration > 0 && b.cfg.SlowCallRateThreshold > 0 && slowRate >= b.cfg.SlowCallRateThreshold
+}
+
+// refreshState moves open breaker to half-open after timeout
+func (b *Breaker) refreshState(now time.Time) {
+	if b.state == StateOpen && now.Sub(b.openedAt) >= b.cfg.OpenTimeout {
+		b.setState(StateHalfOpen, now)
+	}
+}
+
+func (b *Breaker) setState(state State, now time.Time) {
+	from := b.state
+
+	b.state = state
+	b.generation++
+	b.inFlight = 0
+	b.window.reset()
+	if state == StateOpen {
+		b.openedAt = now
+	}
+
+	metrics.StateGauge.WithLabelValues(b.name).Set(float64(state))
+	metrics.TransitionsCounter.WithLabelValues(b.name, state.String()).Inc()
+	infralog.Info("circuit breaker state changed",
+		zap.String("breaker", b.name),
+		zap.String("from", from.String()),
+		zap.String("to", state.String()))
+
+	if b.onChange != nil {
+		go b.onChange(b.name, from, state)
+	}
+}
+
+// window is a ring buffer of call outcomes
+type window struct {
+	failed []bool
+	slow   []bool
+	pos    int
+	count  int
+
+	failures  int
+	slowCalls int
+}
+
+func newWindow(size int) *window {
+	return &window{
+		failed: make([]bool, size),
+		slow:   make([]bool, size),
+	}
+}
+
+func (w *window) add(failed, slow bool) {
+	if w.count == len(w.failed) {
+		if w.failed[w.pos] {
+			w.failures--
+		}
+		if w.slow[w.pos] {
+			w.slowCalls--
+		}
+	} else {
+		w.count++
+	}
+
+	w.failed[w.pos] = failed
+	w.slow[w.pos] = slow
+	if failed {
+		w.failures++
+	}
+	if slow {
+		w.slowCalls++
+	}
+
+	w.pos = (w.pos + 1) % len(w.failed)
+}
+
+func (w *window) rates() (failureRate, slowRate float64) {
+	if w.count == 0 {
+		return 0, 0
+	}
+
+	return float64(w.failures) * 100 / float64(w.count), float64(w.slowCalls) * 100 / float64(w.count)
+}
+
+func (w *window) reset() {
+	for i := range w.failed {
+		w.failed[i] = false
+		w.slow[i] = false
+	}
+	w.pos, w.count, w.failures, w.slowCalls = 0, 0, 0, 0
+}
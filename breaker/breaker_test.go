@@ -0,0 +1,126 @@
+package infrabreaker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	infraid "github.com/pushwoosh/infra/id"
+	infrarabbit "github.com/pushwoosh/infra/rabbit"
+)
+
+func TestBreaker(t *testing.T) {
+	b, err := New("test", &Config{
+		WindowSize:           10,
+		MinCalls:             4,
+		FailureRateThreshold: 50,
+		OpenTimeout:          50 * time.Millisecond,
+		HalfOpenCalls:        2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	fail := func(context.Context) error { return errors.New("failed") }
+	ok := func(context.Context) error { return nil }
+
+	_ = b.Execute(ctx, ok)
+	_ = b.Execute(ctx, ok)
+	_ = b.Execute(ctx, fail)
+	if b.State() != StateClosed {
+		t.Fatal("breaker must stay closed until min calls")
+	}
+
+	_ = b.Execute(ctx, fail)
+	if b.State() != StateOpen {
+		t.Fatal("breaker must open at failure rate threshold")
+	}
+
+	if err = b.Execute(ctx, ok); !errors.Is(err, ErrOpen) {
+		t.Fatalf("expected ErrOpen, got %v", err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if b.State() != StateHalfOpen {
+		t.Fatal("breaker must be half-open after timeout")
+	}
+
+	_ = b.Execute(ctx, ok)
+	_ = b.Execute(ctx, ok)
+	if b.State() != StateClosed {
+		t.Fatal("breaker must close after successful trial calls")
+	}
+}
+
+func TestBreaker_panic(t *testing.T) {
+	b, err := New("test_panic", &Config{
+		WindowSize:           2,
+		MinCalls:             1,
+		FailureRateThreshold: 50,
+		OpenTimeout:          time.Minute,
+		HalfOpenCalls:        1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("panic must be propagated")
+			}
+		}()
+		_ = b.Execute(context.Background(), func(context.Context) error { panic("boom") })
+	}()
+
+	if b.State() != StateOpen {
+		t.Fatal("panic must be recorded as a failure")
+	}
+}
+
+type publisherFunc func(ctx context.Context, msg *infrarabbit.ProducerMessage) error
+
+func (f publisherFunc) Produce(ctx context.Context, msg *infrarabbit.ProducerMessage) error {
+	return f(ctx, msg)
+}
+
+func TestWrapProducer_compose(t *testing.T) {
+	b, err := New("test_producer", &Config{
+		WindowSize:           2,
+		MinCalls:             1,
+		FailureRateThreshold: 50,
+		OpenTimeout:          time.Minute,
+		HalfOpenCalls:        1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var published []*infrarabbit.ProducerMessage
+	brokerDown := false
+	broker := publisherFunc(func(_ context.Context, msg *infrarabbit.ProducerMessage) error {
+		if brokerDown {
+			return errors.New("connection refused")
+		}
+		published = append(published, msg)
+		return nil
+	})
+
+	producer := WrapProducer(b, infraid.WrapProducer(broker))
+
+	ctx := context.Background()
+	if err = producer.Produce(ctx, &infrarabbit.ProducerMessage{Body: []byte("ok")}); err != nil {
+		t.Fatal(err)
+	}
+	if len(published) != 1 || published[0].MessageID == "" {
+		t.Fatalf("expected a message with id, got %v", published)
+	}
+
+	brokerDown = true
+	_ = producer.Produce(ctx, &infrarabbit.ProducerMessage{Body: []byte("failed")})
+	if err = producer.Produce(ctx, &infrarabbit.ProducerMessage{Body: []byte("rejected")}); !errors.Is(err, ErrOpen) {
+		t.Fatalf("expected ErrOpen, got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package infrabreaker
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type Config struct {
+	// WindowSize is the number of last calls used to calculate failure and slow call rates
+	WindowSize int `mapstructure:"window_size"`
+
+	// MinCalls is the minimal number of calls in the window before the breaker can open
+	MinCalls int `mapstructure:"min_calls"`
+
+	// FailureRateThreshold is a percentage of failed calls that opens the breaker
+	FailureRateThreshold float64 `mapstructure:"failure_rate_threshold"`
+
+	// SlowCallDuration is a duration after which a call is considered slow. optional, set 0 to disable
+	SlowCallDuration time.Duration `mapstructure:"slow_call_duration"`
+
+	// SlowCallRateThreshold is a percentage of slow calls that opens the breaker. optional
+	SlowCallRateThreshold float64 `mapstructure:"slow_call_rate_threshold"`
+
+	// OpenTimeout is the time the breaker stays open before letting trial calls through
+	OpenTimeout time.Duration `mapstructure:"open_timeout"`
+
+	// HalfOpenCalls is the number of trial calls in half-open state
+	HalfOpenCalls int `mapstructure:"half_open_calls"`
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		WindowSize:           100,
+		MinCalls:             20,
+		FailureRateThreshold: 50,
+		OpenTimeout:          30 * time.Second,
+		HalfOpenCalls:        5,
+	}
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if c.WindowSize <= 0 {
+		return errors.New("window_size should be greater than zero")
+	}
+
+	if c.MinCalls <= 0 || c.MinCalls > c.WindowSize {
+		return errors.New("min_calls should be greater than zero and not greater than window_size")
+	}
+
+	if c.FailureRateThreshold <= 0 || c.FailureRateThreshold > 100 {
+		return errors.New("failure_rate_threshold should be in (0, 100]")
+	}
+
+	if c.SlowCallDuration < 0 || c.SlowCallRateThreshold < 0 || c.SlowCallRateThreshold > 100 {
+		return errors.New("invalid slow call settings")
+	}
+
+	if c.OpenTimeout <= 0 {
+		return errors.New("open_timeout should be greater than zero")
+	}
+
+	// trial calls are counted in the window, a half-open breaker never decides if they don't fit
+	if c.HalfOpenCalls <= 0 || c.HalfOpenCalls > c.WindowSize {
+		return errors.New("half_open_calls should be greater than zero and not greater than window_size")
+	}
+
+	return nil
+}
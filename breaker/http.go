@@ -0,0 +1,47 @@
+package infrabreaker
+
+import (
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// Transport protects HTTP calls with the breaker. Responses with 5xx status codes are failures.
+//
+//	client.Transport = infrabreaker.Transport(b, client.Transport)
+func Transport(b *Breaker, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &transport{
+		breaker: b,
+		next:    next,
+	}
+}
+
+type transport struct {
+	breaker *Breaker
+	next    http.RoundTripper
+}
+
+var errServerError = errors.New("server error")
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	done, err := t.breaker.Allow()
+	if err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err == nil && resp.StatusCode >= http.StatusInternalServerError {
+		done(errServerError)
+	} else {
+		done(err)
+	}
+
+	return resp, err
+}
@@ -0,0 +1,40 @@
+package infrabreaker
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	StateGauge         *prometheus.GaugeVec
+	CallsCounter       *prometheus.CounterVec
+	TransitionsCounter *prometheus.CounterVec
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.StateGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "circuit_breaker_state",
+			Help: "Circuit breaker state: 0 - closed, 1 - half-open, 2 - open",
+		}, []string{"name"})
+
+		metrics.CallsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "circuit_breaker_calls_total",
+			Help: "Number of calls by result: success, failure, slow or rejected",
+		}, []string{"name", "result"})
+
+		metrics.TransitionsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "circuit_breaker_transitions_total",
+			Help: "Number of state transitions by target state",
+		}, []string{"name", "state"})
+
+		prometheus.MustRegister(
+			metrics.StateGauge,
+			metrics.CallsCounter,
+			metrics.TransitionsCounter,
+		)
+	})
+}
@@ -0,0 +1,28 @@
+package infrabreaker
+
+type Option interface {
+	apply(b *Breaker)
+}
+
+type optionFailureClassifier func(err error) bool
+
+func (opt optionFailureClassifier) apply(b *Breaker) {
+	b.isFailure = opt
+}
+
+// WithFailureClassifier sets a function deciding if a call result is a failure.
+// By default any error except context.Canceled is a failure.
+func WithFailureClassifier(isFailure func(err error) bool) Option {
+	return optionFailureClassifier(isFailure)
+}
+
+type optionOnStateChange func(name string, from, to State)
+
+func (opt optionOnStateChange) apply(b *Breaker) {
+	b.onChange = opt
+}
+
+// WithOnStateChange sets a callback called in a separate goroutine on state transitions
+func WithOnStateChange(fn func(name string, from, to State)) Option {
+	return optionOnStateChange(fn)
+}
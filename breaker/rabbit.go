@@ -0,0 +1,28 @@
+package infrabreaker
+
+import (
+	"context"
+
+	infrarabbit "github.com/pushwoosh/infra/rabbit"
+)
+
+// Producer protects rabbitmq publishing with the breaker
+type Producer struct {
+	publisher infrarabbit.Publisher
+	breaker   *Breaker
+}
+
+var _ infrarabbit.Publisher = (*Producer)(nil)
+
+func WrapProducer(b *Breaker, p infrarabbit.Publisher) *Producer {
+	return &Producer{
+		publisher: p,
+		breaker:   b,
+	}
+}
+
+func (p *Producer) Produce(ctx context.Context, msg *infrarabbit.ProducerMessage) error {
+	return p.breaker.Execute(ctx, func(ctx context.Context) error {
+		return p.publisher.Produce(ctx, msg)
+	})
+}
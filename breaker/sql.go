@@ -0,0 +1,113 @@
+package infrabreaker
+
+import (
+	"context"
+	"database/sql"
+)
+
+// DB protects queries of a database/sql connection pool like ClickHouse container connections:
+//
+//	db := infrabreaker.WrapDB(b, chContainer.Get("events"))
+//	rows, err := db.QueryContext(ctx, query, args...)
+//
+// The pool isn't exposed, so every query goes through the breaker.
+type DB struct {
+	db      *sql.DB
+	breaker *Breaker
+}
+
+func WrapDB(b *Breaker, db *sql.DB) *DB {
+	return &DB{
+		db:      db,
+		breaker: b,
+	}
+}
+
+func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return Execute(ctx, db.breaker, func(ctx context.Context) (sql.Result, error) {
+		return db.db.ExecContext(ctx, query, args...)
+	})
+}
+
+func (db *DB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return db.ExecContext(context.Background(), query, args...)
+}
+
+func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return Execute(ctx, db.breaker, func(ctx context.Context) (*sql.Rows, error) {
+		return db.db.QueryContext(ctx, query, args...)
+	})
+}
+
+func (db *DB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return db.QueryContext(context.Background(), query, args...)
+}
+
+// QueryRowContext records the query error, sql.ErrNoRows of Scan is not a failure
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *Row {
+	row, err := Execute(ctx, db.breaker, func(ctx context.Context) (*sql.Row, error) {
+		row := db.db.QueryRowContext(ctx, query, args...)
+		return row, row.Err()
+	})
+
+	return &Row{row: row, err: err}
+}
+
+func (db *DB) QueryRow(query string, args ...interface{}) *Row {
+	return db.QueryRowContext(context.Background(), query, args...)
+}
+
+// PrepareContext prepares a statement. Only the preparation is protected, executions of the statement are not.
+func (db *DB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return Execute(ctx, db.breaker, func(ctx context.Context) (*sql.Stmt, error) {
+		return db.db.PrepareContext(ctx, query)
+	})
+}
+
+func (db *DB) Prepare(query string) (*sql.Stmt, error) {
+	return db.PrepareContext(context.Background(), query)
+}
+
+// BeginTx starts a transaction. Only the start is protected, statements within the transaction are not.
+func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return Execute(ctx, db.breaker, func(ctx context.Context) (*sql.Tx, error) {
+		return db.db.BeginTx(ctx, opts)
+	})
+}
+
+func (db *DB) Begin() (*sql.Tx, error) {
+	return db.BeginTx(context.Background(), nil)
+}
+
+func (db *DB) PingContext(ctx context.Context) error {
+	return db.breaker.Execute(ctx, db.db.PingContext)
+}
+
+func (db *DB) Ping() error {
+	return db.PingContext(context.Background())
+}
+
+func (db *DB) Stats() sql.DBStats {
+	return db.db.Stats()
+}
+
+func (db *DB) Close() error {
+	return db.db.Close()
+}
+
+// Row is a result of QueryRowContext, Scan returns ErrOpen if the query was rejected
+type Row struct {
+	row *sql.Row
+	err error
+}
+
+func (r *Row) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.row.Scan(dest...)
+}
+
+func (r *Row) Err() error {
+	return r.err
+}
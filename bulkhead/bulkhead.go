@@ -0,0 +1,133 @@
+package infrabulkhead
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	infraconcurrency "github.com/pushwoosh/infra/concurrency"
+)
+
+// ErrRejected is returned without calling the protected function when there is no free slot in time
+var ErrRejected = errors.New("bulkhead is full")
+
+// Bulkhead limits the number of concurrent calls to a dependency, so a slow dependency can't take
+// every goroutine of the service. Calls over MaxConcurrent wait for a free slot up to QueueTimeout,
+// calls over MaxQueue are rejected at once:
+//
+//	b, err := infrabulkhead.New("geo", &infrabulkhead.Config{MaxConcurrent: 20, MaxQueue: 100, QueueTimeout: time.Second})
+//	err = b.Execute(ctx, func(ctx context.Context) error {
+//		return callGeo(ctx)
+//	})
+//
+// Slots are a semaphore named bulkhead_<name>, its concurrency_* metrics report the wait time and calls in flight.
+type Bulkhead struct {
+	name  string
+	cfg   *Config
+	slots *infraconcurrency.Semaphore
+
+	queued atomic.Int64
+}
+
+func New(name string, cfg *Config) (*Bulkhead, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	initMetrics()
+
+	return &Bulkhead{
+		name:  name,
+		cfg:   cfg,
+		slots: infraconcurrency.NewSemaphore("bulkhead_"+name, int64(cfg.MaxConcurrent)),
+	}, nil
+}
+
+func (b *Bulkhead) Name() string {
+	return b.name
+}
+
+// InFlight returns the number of calls being executed
+func (b *Bulkhead) InFlight() int {
+	return int(b.slots.InUse())
+}
+
+// Execute calls fn when a slot is free
+func (b *Bulkhead) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	release, err := b.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return fn(ctx)
+}
+
+// Execute calls fn returning a value when a slot is free
+func Execute[T any](ctx context.Context, b *Bulkhead, fn func(ctx context.Context) (T, error)) (T, error) {
+	release, err := b.Acquire(ctx)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	defer release()
+
+	return fn(ctx)
+}
+
+// Handler limits concurrent calls of a message handler of any broker
+func Handler[T any](b *Bulkhead, fn func(ctx context.Context, msg T) error) func(ctx context.Context, msg T) error {
+	return func(ctx context.Context, msg T) error {
+		return b.Execute(ctx, func(ctx context.Context) error {
+			return fn(ctx, msg)
+		})
+	}
+}
+
+// Acquire takes a slot. If so, release must be called when the call is finished.
+// Returns ErrRejected if the queue is full or QueueTimeout passed, and ctx error if ctx is done while waiting.
+func (b *Bulkhead) Acquire(ctx context.Context) (release func(), err error) {
+	if b.slots.TryAcquire(1) {
+		return b.acquired(), nil
+	}
+
+	if b.queued.Add(1) > int64(b.cfg.MaxQueue) {
+		b.queued.Add(-1)
+		metrics.RejectedCounter.WithLabelValues(b.name, "queue_full").Inc()
+		return nil, errors.Wrap(ErrRejected, "queue is full")
+	}
+	metrics.QueuedGauge.WithLabelValues(b.name).Inc()
+
+	defer func() {
+		b.queued.Add(-1)
+		metrics.QueuedGauge.WithLabelValues(b.name).Dec()
+	}()
+
+	waitCtx := ctx
+	if b.cfg.QueueTimeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, b.cfg.QueueTimeout)
+		defer cancel()
+	}
+
+	if err = b.slots.Acquire(waitCtx, 1); err != nil {
+		if ctx.Err() != nil {
+			metrics.RejectedCounter.WithLabelValues(b.name, "canceled").Inc()
+			return nil, ctx.Err()
+		}
+		metrics.RejectedCounter.WithLabelValues(b.name, "timeout").Inc()
+		return nil, errors.Wrapf(ErrRejected, "no free slot in %s", b.cfg.QueueTimeout)
+	}
+
+	return b.acquired(), nil
+}
+
+func (b *Bulkhead) acquired() func() {
+	var released atomic.Bool
+	return func() {
+		if released.Swap(true) {
+			return
+		}
+		b.slots.Release(1)
+	}
+}
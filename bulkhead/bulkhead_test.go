@@ -0,0 +1,73 @@
+package infrabulkhead
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestBulkhead(t *testing.T) {
+	b, err := New("test", &Config{MaxConcurrent: 2, MaxQueue: 1, QueueTimeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	release1, err := b.Acquire(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release2, err := b.Acquire(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the only queue place is taken by a waiting call, the next one is rejected at once
+	waited := make(chan error, 1)
+	go func() {
+		waited <- b.Execute(ctx, func(context.Context) error { return nil })
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	if err = b.Execute(ctx, func(context.Context) error { return nil }); !errors.Is(err, ErrRejected) {
+		t.Fatalf("expected ErrRejected on full queue, got %v", err)
+	}
+
+	release1()
+	release1()
+	if err = <-waited; err != nil {
+		t.Fatalf("queued call must get the released slot, got %v", err)
+	}
+
+	if b.InFlight() != 1 {
+		t.Fatalf("expected 1 call in flight, got %d", b.InFlight())
+	}
+
+	release3, err := b.Acquire(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	if _, err = Execute(ctx, b, func(context.Context) (int, error) { return 1, nil }); !errors.Is(err, ErrRejected) {
+		t.Fatalf("expected ErrRejected on queue timeout, got %v", err)
+	}
+	if time.Since(start) < 50*time.Millisecond {
+		t.Fatal("call must wait for queue timeout")
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err = b.Execute(cancelled, func(context.Context) error { return nil }); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	release2()
+	release3()
+	if b.InFlight() != 0 {
+		t.Fatalf("expected no calls in flight, got %d", b.InFlight())
+	}
+}
@@ -0,0 +1,38 @@
+package infrabulkhead
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type Config struct {
+	// MaxConcurrent is the number of calls executed at the same time
+	MaxConcurrent int `mapstructure:"max_concurrent"`
+
+	// MaxQueue is the number of calls waiting for a free slot, the rest are rejected. optional, default: 0 - no waiting
+	MaxQueue int `mapstructure:"max_queue"`
+
+	// QueueTimeout is how long a call waits for a free slot before it's rejected. optional, default: until ctx is done
+	QueueTimeout time.Duration `mapstructure:"queue_timeout"`
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if c.MaxConcurrent <= 0 {
+		return errors.New("max_concurrent should be greater than zero")
+	}
+
+	if c.MaxQueue < 0 {
+		return errors.New("max_queue should not be negative")
+	}
+
+	if c.QueueTimeout < 0 {
+		return errors.New("queue_timeout should not be negative")
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package infrabulkhead
+
+import (
+	"io"
+	"net/http"
+)
+
+// Transport limits concurrent HTTP calls. The slot is held until the response body is closed.
+//
+//	client.Transport = infrabulkhead.Transport(b, client.Transport)
+func Transport(b *Bulkhead, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &transport{
+		bulkhead: b,
+		next:     next,
+	}
+}
+
+type transport struct {
+	bulkhead *Bulkhead
+	next     http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	release, err := t.bulkhead.Acquire(req.Context())
+	if err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	resp.Body = &body{ReadCloser: resp.Body, release: release}
+
+	return resp, nil
+}
+
+type body struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *body) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
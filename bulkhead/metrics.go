@@ -0,0 +1,33 @@
+package infrabulkhead
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	QueuedGauge     *prometheus.GaugeVec
+	RejectedCounter *prometheus.CounterVec
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.QueuedGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "bulkhead_queued",
+			Help: "Number of calls waiting for a free slot",
+		}, []string{"name"})
+
+		metrics.RejectedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "bulkhead_rejected_total",
+			Help: "Number of rejected calls by reason: queue_full, timeout or canceled",
+		}, []string{"name", "reason"})
+
+		prometheus.MustRegister(
+			metrics.QueuedGauge,
+			metrics.RejectedCounter,
+		)
+	})
+}
@@ -0,0 +1,20 @@
+package infrabulkhead
+
+import (
+	"context"
+
+	infrarabbit "github.com/pushwoosh/infra/rabbit"
+)
+
+// Middleware limits concurrent handling of rabbitmq messages. Rejected messages are requeued by the router:
+//
+//	router.Use(infrabulkhead.Middleware(b))
+func Middleware(b *Bulkhead) infrarabbit.Middleware {
+	return func(next infrarabbit.HandlerFunc) infrarabbit.HandlerFunc {
+		return func(ctx context.Context, msg *infrarabbit.Message) error {
+			return b.Execute(ctx, func(ctx context.Context) error {
+				return next(ctx, msg)
+			})
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package infrabulkhead
+
+import (
+	"context"
+	"database/sql"
+)
+
+// DB limits concurrent queries of a database/sql connection pool like ClickHouse container connections:
+//
+//	db := infrabulkhead.WrapDB(b, chContainer.Get("events"))
+//	rows, err := db.QueryContext(ctx, query, args...)
+//
+// The pool isn't exposed, so every query goes through the bulkhead. The slot of QueryContext is held until
+// rows are closed, of QueryRowContext until the row is scanned and of BeginTx until the transaction ends,
+// so reading rows and transactions stay bounded too.
+type DB struct {
+	db       *sql.DB
+	bulkhead *Bulkhead
+}
+
+func WrapDB(b *Bulkhead, db *sql.DB) *DB {
+	return &DB{
+		db:       db,
+		bulkhead: b,
+	}
+}
+
+func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return Execute(ctx, db.bulkhead, func(ctx context.Context) (sql.Result, error) {
+		return db.db.ExecContext(ctx, query, args...)
+	})
+}
+
+func (db *DB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return db.ExecContext(context.Background(), query, args...)
+}
+
+// QueryContext returns Rows, they must be closed to free the slot
+func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*Rows, error) {
+	release, err := db.bulkhead.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	return &Rows{Rows: rows, release: release}, nil
+}
+
+func (db *DB) Query(query string, args ...interface{}) (*Rows, error) {
+	return db.QueryContext(context.Background(), query, args...)
+}
+
+// QueryRowContext returns Row, the slot is freed by Scan
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *Row {
+	release, err := db.bulkhead.Acquire(ctx)
+	if err != nil {
+		return &Row{err: err}
+	}
+
+	return &Row{row: db.db.QueryRowContext(ctx, query, args...), release: release}
+}
+
+func (db *DB) QueryRow(query string, args ...interface{}) *Row {
+	return db.QueryRowContext(context.Background(), query, args...)
+}
+
+// PrepareContext prepares a statement. Only the preparation is limited, executions of the statement are not.
+func (db *DB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return Execute(ctx, db.bulkhead, func(ctx context.Context) (*sql.Stmt, error) {
+		return db.db.PrepareContext(ctx, query)
+	})
+}
+
+func (db *DB) Prepare(query string) (*sql.Stmt, error) {
+	return db.PrepareContext(context.Background(), query)
+}
+
+// BeginTx starts a transaction, it must be committed or rolled back to free the slot
+func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	release, err := db.bulkhead.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.db.BeginTx(ctx, opts)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	return &Tx{Tx: tx, release: release}, nil
+}
+
+func (db *DB) Begin() (*Tx, error) {
+	return db.BeginTx(context.Background(), nil)
+}
+
+func (db *DB) PingContext(ctx context.Context) error {
+	return db.bulkhead.Execute(ctx, db.db.PingContext)
+}
+
+func (db *DB) Ping() error {
+	return db.PingContext(context.Background())
+}
+
+func (db *DB) Stats() sql.DBStats {
+	return db.db.Stats()
+}
+
+func (db *DB) Close() error {
+	return db.db.Close()
+}
+
+// Rows frees the bulkhead slot on Close
+type Rows struct {
+	*sql.Rows
+	release func()
+}
+
+func (r *Rows) Close() error {
+	defer r.release()
+	return r.Rows.Close()
+}
+
+// Row is a result of QueryRowContext, Scan returns ErrRejected if there was no free slot
+type Row struct {
+	row     *sql.Row
+	err     error
+	release func()
+}
+
+func (r *Row) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	defer r.release()
+
+	return r.row.Scan(dest...)
+}
+
+func (r *Row) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.row.Err()
+}
+
+// Tx frees the bulkhead slot on Commit or Rollback
+type Tx struct {
+	*sql.Tx
+	release func()
+}
+
+func (tx *Tx) Commit() error {
+	defer tx.release()
+	return tx.Tx.Commit()
+}
+
+func (tx *Tx) Rollback() error {
+	defer tx.release()
+	return tx.Tx.Rollback()
+}
@@ -0,0 +1,85 @@
+package infrabulkhead
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+type fakeConnector struct{}
+
+func (fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn{}, nil }
+func (fakeConnector) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (fakeConn) Close() error                        { return nil }
+func (fakeConn) Begin() (driver.Tx, error)           { return fakeConn{}, nil }
+func (fakeConn) Commit() error                       { return nil }
+func (fakeConn) Rollback() error                     { return nil }
+
+func (fakeConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+
+func (fakeConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return &fakeRows{}, nil
+}
+
+type fakeRows struct {
+	read bool
+}
+
+func (r *fakeRows) Columns() []string { return []string{"n"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.read {
+		return io.EOF
+	}
+	r.read = true
+	dest[0] = int64(1)
+	return nil
+}
+
+func TestDB(t *testing.T) {
+	b, err := New("test_sql", &Config{MaxConcurrent: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool := sql.OpenDB(fakeConnector{})
+	defer pool.Close()
+
+	db := WrapDB(b, pool)
+	ctx := context.Background()
+
+	row := db.QueryRowContext(ctx, "SELECT 1")
+	if _, err = db.ExecContext(ctx, "SELECT 1"); !errors.Is(err, ErrRejected) {
+		t.Fatalf("expected the slot to be held until the row is scanned, got %v", err)
+	}
+	var n int
+	if err = row.Scan(&n); err != nil || n != 1 {
+		t.Fatalf("unexpected scan result %d, %v", n, err)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.QueryRowContext(ctx, "SELECT 1").Scan(&n); !errors.Is(err, ErrRejected) {
+		t.Fatalf("expected the slot to be held until the transaction ends, got %v", err)
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if b.InFlight() != 0 {
+		t.Fatalf("expected all slots to be free, got %d in flight", b.InFlight())
+	}
+}
@@ -0,0 +1,36 @@
+package infracache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Cache stores values of type T by string keys
+type Cache[T any] interface {
+	// Get returns a cached value. ok is false if there is no value
+	Get(ctx context.Context, key string) (value T, ok bool, err error)
+	// Set stores a value for ttl. Zero ttl means the cache default
+	Set(ctx context.Context, key string, value T, ttl time.Duration) error
+	// Delete removes a value
+	Delete(ctx context.Context, key string) error
+}
+
+// Codec serializes values for remote caches
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// JSONCodec is a default codec
+var JSONCodec Codec = jsonCodec{}
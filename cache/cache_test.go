@@ -0,0 +1,64 @@
+package infracache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewMemory[int]("test", &MemoryConfig{Size: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = c.Set(ctx, "a", 1, 0)
+	_ = c.Set(ctx, "b", 2, 0)
+	_, _, _ = c.Get(ctx, "a")
+	_ = c.Set(ctx, "c", 3, 0)
+
+	if _, ok, _ := c.Get(ctx, "b"); ok {
+		t.Error("least recently used entry must be evicted")
+	}
+	if v, ok, _ := c.Get(ctx, "a"); !ok || v != 1 {
+		t.Error("recently used entry must stay")
+	}
+
+	_ = c.Set(ctx, "ttl", 4, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if _, ok, _ := c.Get(ctx, "ttl"); ok {
+		t.Error("expired entry must not be returned")
+	}
+}
+
+func TestLoader(t *testing.T) {
+	ctx := context.Background()
+	c, _ := NewMemory[string]("test_loader", &MemoryConfig{Size: 10})
+	loader := NewLoader[string]("test_loader", c)
+
+	var loads atomic.Int32
+	load := func(context.Context) (string, error) {
+		loads.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		return "value", nil
+	}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := loader.Get(ctx, "key", 0, load); err != nil || v != "value" {
+				t.Errorf("unexpected result: %s, %v", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if loads.Load() != 1 {
+		t.Errorf("expected a single load, got %d", loads.Load())
+	}
+}
@@ -0,0 +1,70 @@
+package infracache
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	infradebug "github.com/pushwoosh/infra/debug"
+)
+
+const defaultDebugLimit = 100
+
+type debugEntry struct {
+	Key     string      `json:"key"`
+	Value   interface{} `json:"value,omitempty"`
+	Expires *time.Time  `json:"expires,omitempty"`
+}
+
+type debugContents struct {
+	Name    string        `json:"name"`
+	Len     int           `json:"len"`
+	Size    int           `json:"size"`
+	Entries []*debugEntry `json:"entries"`
+}
+
+// DebugHandler dumps cache entries from the most recently used, register it with infradebug.Register.
+// Query parameters: limit - max number of entries, default: 100; prefix - key prefix;
+// values=true - include values, they are not shown by default as they may hold personal data.
+func (m *Memory[T]) DebugHandler() http.Handler {
+	return infradebug.JSON(func(req *http.Request) (interface{}, error) {
+		query := req.URL.Query()
+
+		limit := defaultDebugLimit
+		if v, err := strconv.Atoi(query.Get("limit")); err == nil && v > 0 {
+			limit = v
+		}
+		prefix := query.Get("prefix")
+		values := query.Get("values") == "true"
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		contents := &debugContents{
+			Name:    m.name,
+			Len:     m.lru.Len(),
+			Size:    m.cfg.Size,
+			Entries: make([]*debugEntry, 0),
+		}
+
+		for el := m.lru.Front(); el != nil && len(contents.Entries) < limit; el = el.Next() {
+			entry := el.Value.(*memoryEntry[T])
+			if !strings.HasPrefix(entry.key, prefix) {
+				continue
+			}
+
+			e := &debugEntry{Key: entry.key}
+			if values {
+				e.Value = entry.value
+			}
+			if !entry.expires.IsZero() {
+				expires := entry.expires
+				e.Expires = &expires
+			}
+			contents.Entries = append(contents.Entries, e)
+		}
+
+		return contents, nil
+	})
+}
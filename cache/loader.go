@@ -0,0 +1,71 @@
+package infracache
+
+import (
+	"context"
+	"time"
+
+	infraconcurrency "github.com/pushwoosh/infra/concurrency"
+	infralog "github.com/pushwoosh/infra/log"
+	"go.uber.org/zap"
+)
+
+// Loader reads values through a cache. Concurrent misses of the same key call load only once,
+// so an expired hot key doesn't cause a stampede:
+//
+//	loader := infracache.NewLoader[*Tenant]("tenants", tenants)
+//	tenant, err := loader.Get(ctx, code, 0, func(ctx context.Context) (*Tenant, error) {
+//		return repo.Tenant(ctx, code)
+//	})
+type Loader[T any] struct {
+	name  string
+	cache Cache[T]
+	group infraconcurrency.KeyedSingleflight[T]
+}
+
+func NewLoader[T any](name string, cache Cache[T]) *Loader[T] {
+	initMetrics()
+
+	return &Loader[T]{
+		name:  name,
+		cache: cache,
+	}
+}
+
+// Get returns a cached value or loads and caches it for ttl.
+// Cache errors are logged and don't fail the call.
+func (l *Loader[T]) Get(ctx context.Context, key string, ttl time.Duration, load func(ctx context.Context) (T, error)) (T, error) {
+	value, ok, err := l.cache.Get(ctx, key)
+	if err != nil {
+		infralog.ErrorCtx(ctx, "cache get failed", zap.String("cache", l.name), zap.String("key", key), zap.Error(err))
+	}
+	if ok {
+		return value, nil
+	}
+
+	// the load is shared by all waiting callers and isn't canceled by the first one
+	value, _, err = l.group.Do(ctx, key, func(loadCtx context.Context) (T, error) {
+		value, err := load(loadCtx)
+		if err != nil {
+			metrics.LoadsCounter.WithLabelValues(l.name, "error").Inc()
+			return value, err
+		}
+		metrics.LoadsCounter.WithLabelValues(l.name, "success").Inc()
+
+		if err = l.cache.Set(loadCtx, key, value, ttl); err != nil {
+			infralog.ErrorCtx(ctx, "cache set failed", zap.String("cache", l.name), zap.String("key", key), zap.Error(err))
+		}
+
+		return value, nil
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return value, nil
+}
+
+// Forget makes the next Get of the key load the value even if a load is in progress
+func (l *Loader[T]) Forget(key string) {
+	l.group.Forget(key)
+}
@@ -0,0 +1,156 @@
+package infracache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type MemoryConfig struct {
+	// Size is max number of entries, the least recently used ones are evicted
+	Size int `mapstructure:"size"`
+	// TTL is a default time to live. optional, entries don't expire by default
+	TTL time.Duration `mapstructure:"ttl"`
+}
+
+func (c *MemoryConfig) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if c.Size <= 0 {
+		return errors.New("size should be greater than zero")
+	}
+
+	if c.TTL < 0 {
+		return errors.New("ttl should be greater than or equal to 0")
+	}
+
+	return nil
+}
+
+// Memory is an in-process LRU cache with TTL
+type Memory[T any] struct {
+	name string
+	cfg  *MemoryConfig
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+type memoryEntry[T any] struct {
+	key     string
+	value   T
+	expires time.Time
+}
+
+var _ Cache[struct{}] = (*Memory[struct{}])(nil)
+
+// NewMemory creates LRU cache, name is used in metrics
+func NewMemory[T any](name string, cfg *MemoryConfig) (*Memory[T], error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	initMetrics()
+
+	return &Memory[T]{
+		name:    name,
+		cfg:     cfg,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+	}, nil
+}
+
+func (m *Memory[T]) Get(_ context.Context, key string) (T, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var zero T
+
+	el, ok := m.entries[key]
+	if !ok {
+		observe(m.name, tierMemory, false)
+		return zero, false, nil
+	}
+
+	entry := el.Value.(*memoryEntry[T])
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		m.remove(el)
+		observe(m.name, tierMemory, false)
+		return zero, false, nil
+	}
+
+	m.lru.MoveToFront(el)
+	observe(m.name, tierMemory, true)
+
+	return entry.value, true, nil
+}
+
+func (m *Memory[T]) Set(_ context.Context, key string, value T, ttl time.Duration) error {
+	if ttl == 0 {
+		ttl = m.cfg.TTL
+	}
+
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if el, ok := m.entries[key]; ok {
+		entry := el.Value.(*memoryEntry[T])
+		entry.value = value
+		entry.expires = expires
+		m.lru.MoveToFront(el)
+		return nil
+	}
+
+	m.entries[key] = m.lru.PushFront(&memoryEntry[T]{key: key, value: value, expires: expires})
+
+	for m.lru.Len() > m.cfg.Size {
+		m.remove(m.lru.Back())
+		metrics.EvictionsCounter.WithLabelValues(m.name).Inc()
+	}
+
+	return nil
+}
+
+func (m *Memory[T]) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if el, ok := m.entries[key]; ok {
+		m.remove(el)
+	}
+
+	return nil
+}
+
+// Len returns number of entries including expired ones not evicted yet
+func (m *Memory[T]) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.lru.Len()
+}
+
+// Purge removes all entries
+func (m *Memory[T]) Purge() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lru.Init()
+	m.entries = make(map[string]*list.Element)
+}
+
+func (m *Memory[T]) remove(el *list.Element) {
+	m.lru.Remove(el)
+	delete(m.entries, el.Value.(*memoryEntry[T]).key)
+}
@@ -0,0 +1,60 @@
+package infracache
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	tierMemory = "memory"
+	tierRedis  = "redis"
+)
+
+var metrics struct {
+	RequestsCounter      *prometheus.CounterVec
+	EvictionsCounter     *prometheus.CounterVec
+	InvalidationsCounter *prometheus.CounterVec
+	LoadsCounter         *prometheus.CounterVec
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.RequestsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cache_requests_total",
+			Help: "Number of cache reads by tier and result: hit or miss",
+		}, []string{"cache", "tier", "result"})
+
+		metrics.EvictionsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cache_evictions_total",
+			Help: "Number of entries evicted from memory cache due to size limit",
+		}, []string{"cache"})
+
+		metrics.InvalidationsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cache_invalidations_total",
+			Help: "Number of local entries invalidated by other replicas",
+		}, []string{"cache"})
+
+		metrics.LoadsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cache_loads_total",
+			Help: "Number of values loaded on cache miss by result",
+		}, []string{"cache", "result"})
+
+		prometheus.MustRegister(
+			metrics.RequestsCounter,
+			metrics.EvictionsCounter,
+			metrics.InvalidationsCounter,
+			metrics.LoadsCounter,
+		)
+	})
+}
+
+func observe(cache, tier string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	metrics.RequestsCounter.WithLabelValues(cache, tier, result).Inc()
+}
@@ -0,0 +1,79 @@
+package infracache
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis is a cache stored in redis, values are serialized with a codec
+type Redis[T any] struct {
+	name   string
+	client redis.UniversalClient
+	prefix string
+	ttl    time.Duration
+	codec  Codec
+}
+
+var _ Cache[struct{}] = (*Redis[struct{}])(nil)
+
+// NewRedis creates a cache storing values in keys "<prefix>:<key>" with default ttl.
+// JSONCodec is used if codec is nil.
+func NewRedis[T any](name string, client redis.UniversalClient, prefix string, ttl time.Duration, codec Codec) *Redis[T] {
+	if codec == nil {
+		codec = JSONCodec
+	}
+
+	initMetrics()
+
+	return &Redis[T]{
+		name:   name,
+		client: client,
+		prefix: prefix,
+		ttl:    ttl,
+		codec:  codec,
+	}
+}
+
+func (r *Redis[T]) Get(ctx context.Context, key string) (T, bool, error) {
+	var value T
+
+	data, err := r.client.Get(ctx, r.key(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		observe(r.name, tierRedis, false)
+		return value, false, nil
+	}
+	if err != nil {
+		return value, false, err
+	}
+
+	if err = r.codec.Unmarshal(data, &value); err != nil {
+		return value, false, errors.Wrapf(err, "unable to decode cached value %s", key)
+	}
+
+	observe(r.name, tierRedis, true)
+	return value, true, nil
+}
+
+func (r *Redis[T]) Set(ctx context.Context, key string, value T, ttl time.Duration) error {
+	if ttl == 0 {
+		ttl = r.ttl
+	}
+
+	data, err := r.codec.Marshal(value)
+	if err != nil {
+		return errors.Wrapf(err, "unable to encode cached value %s", key)
+	}
+
+	return r.client.Set(ctx, r.key(key), data, ttl).Err()
+}
+
+func (r *Redis[T]) Delete(ctx context.Context, key string) error {
+	return r.client.Del(ctx, r.key(key)).Err()
+}
+
+func (r *Redis[T]) key(key string) string {
+	return r.prefix + ":" + key
+}
@@ -0,0 +1,161 @@
+package infracache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// Tiered is a two-tier cache: a local memory cache in front of a shared remote cache.
+// Set and Delete publish invalidation messages to a redis channel, so other replicas drop their local copies.
+// Invalidations may be lost on redis reconnects, so keep local TTL short.
+//
+//	local, _ := infracache.NewMemory[*Tenant]("tenants", &infracache.MemoryConfig{Size: 10000, TTL: time.Minute})
+//	remote := infracache.NewRedis[*Tenant]("tenants", redisClient, "tenants", time.Hour, nil)
+//	tenants := infracache.NewTiered[*Tenant]("tenants", local, remote, redisClient, "tenants:invalidate")
+//	op.AddService(ctx, tenants)
+type Tiered[T any] struct {
+	name     string
+	local    *Memory[T]
+	remote   Cache[T]
+	client   redis.UniversalClient
+	channel  string
+	instance string
+
+	mu     sync.Mutex
+	pubsub *redis.PubSub
+	done   chan struct{}
+}
+
+var (
+	_ Cache[struct{}]       = (*Tiered[struct{}])(nil)
+	_ infraoperator.Starter = (*Tiered[struct{}])(nil)
+	_ infraoperator.Stopper = (*Tiered[struct{}])(nil)
+)
+
+const invalidationSeparator = "|"
+
+func NewTiered[T any](name string, local *Memory[T], remote Cache[T], client redis.UniversalClient, channel string) *Tiered[T] {
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+
+	return &Tiered[T]{
+		name:     name,
+		local:    local,
+		remote:   remote,
+		client:   client,
+		channel:  channel,
+		instance: hex.EncodeToString(id),
+	}
+}
+
+func (t *Tiered[T]) Get(ctx context.Context, key string) (T, bool, error) {
+	if value, ok, _ := t.local.Get(ctx, key); ok {
+		return value, true, nil
+	}
+
+	value, ok, err := t.remote.Get(ctx, key)
+	if err != nil || !ok {
+		return value, ok, err
+	}
+
+	_ = t.local.Set(ctx, key, value, 0)
+	return value, true, nil
+}
+
+func (t *Tiered[T]) Set(ctx context.Context, key string, value T, ttl time.Duration) error {
+	if err := t.remote.Set(ctx, key, value, ttl); err != nil {
+		return err
+	}
+
+	// local ttl is never longer than remote one
+	localTTL := ttl
+	if localTTL == 0 || (t.local.cfg.TTL > 0 && t.local.cfg.TTL < localTTL) {
+		localTTL = t.local.cfg.TTL
+	}
+	_ = t.local.Set(ctx, key, value, localTTL)
+
+	return t.invalidate(ctx, key)
+}
+
+func (t *Tiered[T]) Delete(ctx context.Context, key string) error {
+	_ = t.local.Delete(ctx, key)
+
+	if err := t.remote.Delete(ctx, key); err != nil {
+		return err
+	}
+
+	return t.invalidate(ctx, key)
+}
+
+func (t *Tiered[T]) invalidate(ctx context.Context, key string) error {
+	err := t.client.Publish(ctx, t.channel, t.instance+invalidationSeparator+key).Err()
+	return errors.Wrap(err, "unable to publish cache invalidation")
+}
+
+// Start subscribes to invalidation messages
+func (t *Tiered[T]) Start(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.pubsub != nil {
+		return errors.New("cache is already started")
+	}
+
+	pubsub := t.client.Subscribe(ctx, t.channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return errors.Wrap(err, "unable to subscribe to cache invalidations")
+	}
+
+	t.pubsub = pubsub
+	t.done = make(chan struct{})
+
+	go t.listen(pubsub.Channel())
+
+	return nil
+}
+
+// Stop unsubscribes from invalidation messages
+func (t *Tiered[T]) Stop(_ context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.pubsub == nil {
+		return nil
+	}
+
+	err := t.pubsub.Close()
+	<-t.done
+	t.pubsub = nil
+
+	return err
+}
+
+func (t *Tiered[T]) listen(messages <-chan *redis.Message) {
+	defer close(t.done)
+
+	for msg := range messages {
+		instance, key, ok := strings.Cut(msg.Payload, invalidationSeparator)
+		if !ok {
+			infralog.Error("invalid cache invalidation message", zap.String("cache", t.name), zap.String("payload", msg.Payload))
+			continue
+		}
+
+		if instance == t.instance {
+			continue
+		}
+
+		_ = t.local.Delete(context.Background(), key)
+		metrics.InvalidationsCounter.WithLabelValues(t.name).Inc()
+	}
+}
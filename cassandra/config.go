@@ -0,0 +1,272 @@
+package infracassandra
+
+import (
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/pkg/errors"
+	infratls "github.com/pushwoosh/infra/tls"
+)
+
+const (
+	defaultConsistency    = "local_quorum"
+	defaultTimeout        = 2 * time.Second
+	defaultConnectTimeout = 5 * time.Second
+	defaultNumRetries     = 3
+
+	RetryPolicySimple      = "simple"
+	RetryPolicyExponential = "exponential"
+	RetryPolicyDowngrading = "downgrading"
+
+	HostPolicyTokenAware = "token_aware"
+	HostPolicyRoundRobin = "round_robin"
+	HostPolicyDCAware    = "dc_aware"
+)
+
+type ConnectionsConfig map[string]*ConnectionConfig
+
+type ConnectionConfig struct {
+	// Contact points "host" or "host:port", other nodes are discovered
+	Hosts []string `mapstructure:"hosts"`
+
+	// Default keyspace of queries. optional
+	Keyspace string `mapstructure:"keyspace"`
+
+	// optional
+	Username string `mapstructure:"username"`
+
+	// Password or a secret reference, e.g. "${vault:secret/cassandra#password}". optional
+	Password string `mapstructure:"password"`
+
+	// Default consistency: any, one, two, three, quorum, all, local_quorum, each_quorum or local_one.
+	// optional, default: local_quorum
+	Consistency string `mapstructure:"consistency"`
+
+	// Consistency of the paxos phase of lightweight transactions: serial or local_serial. optional, default: serial
+	SerialConsistency string `mapstructure:"serial_consistency"`
+
+	// Query timeout. optional, default: 2s
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// Timeout of establishing a connection. optional, default: 5s
+	ConnectTimeout time.Duration `mapstructure:"connect_timeout"`
+
+	// Connections per host. optional, default: 2
+	NumConns int `mapstructure:"num_conns"`
+
+	// Rows fetched per page. optional, default: 5000
+	PageSize int `mapstructure:"page_size"`
+
+	// Native protocol version. optional, discovered if empty
+	ProtoVersion int `mapstructure:"proto_version"`
+
+	// Retries of failed idempotent queries. optional
+	Retry RetryConfig `mapstructure:"retry"`
+
+	// Node selection of queries. optional
+	HostSelection HostSelectionConfig `mapstructure:"host_selection"`
+
+	// Queries longer than the threshold are logged. optional, disabled if 0
+	SlowLogThreshold time.Duration `mapstructure:"slow_log_threshold"`
+
+	// TLS options, certificates are reloaded on change. TLS is disabled if empty
+	TLS *infratls.Config `mapstructure:"tls"`
+}
+
+type RetryConfig struct {
+	// simple, exponential or downgrading. optional, default: simple
+	Policy string `mapstructure:"policy"`
+
+	// optional, default: 3
+	NumRetries int `mapstructure:"num_retries"`
+
+	// Backoff of exponential policy. optional, default: 100ms
+	MinBackoff time.Duration `mapstructure:"min_backoff"`
+
+	// Backoff of exponential policy. optional, default: 10s
+	MaxBackoff time.Duration `mapstructure:"max_backoff"`
+
+	// Consistencies tried one by one by downgrading policy, e.g. [local_quorum, local_one]
+	DowngradeConsistencies []string `mapstructure:"downgrade_consistencies"`
+}
+
+type HostSelectionConfig struct {
+	// token_aware, round_robin or dc_aware. optional, default: token_aware
+	Policy string `mapstructure:"policy"`
+
+	// Local datacenter of dc_aware policy, token_aware policy falls back to it if set. optional
+	LocalDC string `mapstructure:"local_dc"`
+
+	// Spread queries among replicas of a token instead of the primary one. optional
+	ShuffleReplicas bool `mapstructure:"shuffle_replicas"`
+}
+
+func (c *ConnectionsConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	for name, conf := range *c {
+		if err := conf.Validate(); err != nil {
+			return errors.Wrap(err, name)
+		}
+	}
+
+	return nil
+}
+
+func (c *ConnectionConfig) Validate() error {
+	if c == nil {
+		return errors.New("empty connection config")
+	}
+
+	if len(c.Hosts) == 0 {
+		return errors.New("hosts are mandatory")
+	}
+
+	if _, err := c.consistency(); err != nil {
+		return err
+	}
+
+	if _, err := c.serialConsistency(); err != nil {
+		return err
+	}
+
+	if c.Timeout < 0 || c.ConnectTimeout < 0 {
+		return errors.New("timeouts should be greater than or equal to 0")
+	}
+
+	if c.NumConns < 0 || c.PageSize < 0 {
+		return errors.New("num_conns and page_size should be greater than or equal to 0")
+	}
+
+	if err := c.Retry.Validate(); err != nil {
+		return errors.Wrap(err, "retry")
+	}
+
+	if err := c.HostSelection.Validate(); err != nil {
+		return errors.Wrap(err, "host_selection")
+	}
+
+	if c.TLS != nil {
+		if err := c.TLS.Validate(); err != nil {
+			return errors.Wrap(err, "tls")
+		}
+	}
+
+	return nil
+}
+
+func (c *RetryConfig) Validate() error {
+	switch c.Policy {
+	case "", RetryPolicySimple, RetryPolicyExponential:
+	case RetryPolicyDowngrading:
+		if len(c.DowngradeConsistencies) == 0 {
+			return errors.New("downgrade_consistencies are mandatory for downgrading policy")
+		}
+		for _, s := range c.DowngradeConsistencies {
+			if _, err := gocql.ParseConsistencyWrapper(s); err != nil {
+				return errors.Wrapf(err, "downgrade consistency %s", s)
+			}
+		}
+	default:
+		return errors.Errorf("unknown policy %s", c.Policy)
+	}
+
+	if c.NumRetries < 0 || c.MinBackoff < 0 || c.MaxBackoff < 0 {
+		return errors.New("num_retries and backoffs should be greater than or equal to 0")
+	}
+
+	return nil
+}
+
+func (c *HostSelectionConfig) Validate() error {
+	switch c.Policy {
+	case "", HostPolicyTokenAware, HostPolicyRoundRobin:
+	case HostPolicyDCAware:
+		if c.LocalDC == "" {
+			return errors.New("local_dc is mandatory for dc_aware policy")
+		}
+	default:
+		return errors.Errorf("unknown policy %s", c.Policy)
+	}
+
+	return nil
+}
+
+func (c *ConnectionConfig) consistency() (gocql.Consistency, error) {
+	s := c.Consistency
+	if s == "" {
+		s = defaultConsistency
+	}
+
+	consistency, err := gocql.ParseConsistencyWrapper(s)
+	if err != nil {
+		return 0, errors.Wrapf(err, "consistency %s", s)
+	}
+	return consistency, nil
+}
+
+func (c *ConnectionConfig) serialConsistency() (gocql.SerialConsistency, error) {
+	switch c.SerialConsistency {
+	case "", "serial":
+		return gocql.Serial, nil
+	case "local_serial":
+		return gocql.LocalSerial, nil
+	default:
+		return 0, errors.Errorf("unknown serial_consistency %s", c.SerialConsistency)
+	}
+}
+
+func (c *ConnectionConfig) GetTimeout() time.Duration {
+	if c.Timeout == 0 {
+		return defaultTimeout
+	}
+	return c.Timeout
+}
+
+func (c *ConnectionConfig) GetConnectTimeout() time.Duration {
+	if c.ConnectTimeout == 0 {
+		return defaultConnectTimeout
+	}
+	return c.ConnectTimeout
+}
+
+func (c *RetryConfig) retryPolicy() gocql.RetryPolicy {
+	numRetries := c.NumRetries
+	if numRetries == 0 {
+		numRetries = defaultNumRetries
+	}
+
+	switch c.Policy {
+	case RetryPolicyExponential:
+		return &gocql.ExponentialBackoffRetryPolicy{NumRetries: numRetries, Min: c.MinBackoff, Max: c.MaxBackoff}
+	case RetryPolicyDowngrading:
+		levels := make([]gocql.Consistency, 0, len(c.DowngradeConsistencies))
+		for _, s := range c.DowngradeConsistencies {
+			levels = append(levels, gocql.ParseConsistency(s))
+		}
+		return &gocql.DowngradingConsistencyRetryPolicy{ConsistencyLevelsToTry: levels}
+	default:
+		return &gocql.SimpleRetryPolicy{NumRetries: numRetries}
+	}
+}
+
+func (c *HostSelectionConfig) hostSelectionPolicy() gocql.HostSelectionPolicy {
+	fallback := gocql.RoundRobinHostPolicy()
+	if c.LocalDC != "" {
+		fallback = gocql.DCAwareRoundRobinPolicy(c.LocalDC)
+	}
+
+	switch c.Policy {
+	case HostPolicyRoundRobin:
+		return gocql.RoundRobinHostPolicy()
+	case HostPolicyDCAware:
+		return fallback
+	default:
+		if c.ShuffleReplicas {
+			return gocql.TokenAwareHostPolicy(fallback, gocql.ShuffleReplicas())
+		}
+		return gocql.TokenAwareHostPolicy(fallback)
+	}
+}
@@ -0,0 +1,48 @@
+package infracassandra
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectionConfig(t *testing.T) {
+	var cfg *ConnectionConfig
+	require.Error(t, cfg.Validate())
+
+	require.ErrorContains(t, (&ConnectionConfig{}).Validate(), "hosts")
+	require.ErrorContains(t, (&ConnectionConfig{Hosts: []string{"db"}, Consistency: "most"}).Validate(), "consistency")
+	require.ErrorContains(t, (&ConnectionConfig{Hosts: []string{"db"}, Retry: RetryConfig{Policy: RetryPolicyDowngrading}}).Validate(), "retry")
+	require.ErrorContains(t, (&ConnectionConfig{Hosts: []string{"db"}, HostSelection: HostSelectionConfig{Policy: HostPolicyDCAware}}).Validate(), "host_selection")
+
+	cfg = &ConnectionConfig{
+		Hosts:             []string{"db1", "db2:9043"},
+		Keyspace:          "events",
+		Consistency:       "local_one",
+		SerialConsistency: "local_serial",
+		Retry:             RetryConfig{Policy: RetryPolicyExponential, NumRetries: 5},
+		HostSelection:     HostSelectionConfig{LocalDC: "dc1", ShuffleReplicas: true},
+	}
+	require.NoError(t, cfg.Validate())
+
+	cluster, err := newClusterConfig("test", cfg)
+	require.NoError(t, err)
+	require.Equal(t, []string{"db1", "db2:9043"}, cluster.Hosts)
+	require.Equal(t, "events", cluster.Keyspace)
+	require.Equal(t, gocql.LocalOne, cluster.Consistency)
+	require.Equal(t, gocql.LocalSerial, cluster.SerialConsistency)
+	require.Equal(t, 2*time.Second, cluster.Timeout)
+	require.Equal(t, &gocql.ExponentialBackoffRetryPolicy{NumRetries: 5}, cluster.RetryPolicy)
+	require.NotNil(t, cluster.PoolConfig.HostSelectionPolicy)
+	require.Nil(t, cluster.Authenticator)
+}
+
+func TestStatementContext(t *testing.T) {
+	ctx := context.Background()
+	require.Equal(t, "unnamed", statementFromContext(ctx))
+	require.Equal(t, "unnamed", statementFromContext(withStatement(ctx, "")))
+	require.Equal(t, "get_user", statementFromContext(withStatement(ctx, "get_user")))
+}
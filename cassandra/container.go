@@ -0,0 +1,108 @@
+package infracassandra
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	infraoperator "github.com/pushwoosh/infra/operator"
+)
+
+// Container is a simple container for holding named cassandra sessions
+type Container struct {
+	mu   *sync.RWMutex
+	cfg  map[string]ConnectionConfig
+	pool map[string]*Session
+}
+
+var (
+	_ infraoperator.Stopper = (*Container)(nil)
+	_ infraoperator.Checker = (*Container)(nil)
+)
+
+func NewContainer() *Container {
+	return &Container{
+		mu:   &sync.RWMutex{},
+		cfg:  make(map[string]ConnectionConfig),
+		pool: make(map[string]*Session),
+	}
+}
+
+// Connect creates a new named session and checks it with a query
+func (cont *Container) Connect(name string, cfg *ConnectionConfig) error {
+	session, err := NewSession(name, cfg)
+	if err != nil {
+		return err
+	}
+
+	if err = session.Ping(context.Background()); err != nil {
+		session.Close()
+		return errors.Wrap(err, "cannot connect to cassandra")
+	}
+
+	// replace existing session with the same name
+	cont.Remove(name)
+
+	cont.mu.Lock()
+	defer cont.mu.Unlock()
+
+	cont.pool[name] = session
+	cont.cfg[name] = *cfg
+
+	return nil
+}
+
+// Get gets session from a container
+func (cont *Container) Get(name string) *Session {
+	cont.mu.RLock()
+	defer cont.mu.RUnlock()
+
+	return cont.pool[name]
+}
+
+// Remove closes named session and removes it from the container
+func (cont *Container) Remove(name string) {
+	cont.mu.Lock()
+	session := cont.pool[name]
+	delete(cont.pool, name)
+	delete(cont.cfg, name)
+	cont.mu.Unlock()
+
+	if session != nil {
+		session.Close()
+	}
+}
+
+// Check runs a query in all sessions of the container
+func (cont *Container) Check(ctx context.Context) error {
+	cont.mu.RLock()
+	defer cont.mu.RUnlock()
+
+	for name, session := range cont.pool {
+		if err := session.Ping(ctx); err != nil {
+			return errors.Wrap(err, name)
+		}
+	}
+
+	return nil
+}
+
+// Stop closes all sessions in the container
+func (cont *Container) Stop(_ context.Context) error {
+	cont.Close()
+	return nil
+}
+
+// Close closes all sessions in the container
+func (cont *Container) Close() {
+	cont.mu.RLock()
+	names := make([]string, 0, len(cont.pool))
+	for name := range cont.pool {
+		names = append(names, name)
+	}
+	cont.mu.RUnlock()
+
+	for _, name := range names {
+		cont.Remove(name)
+	}
+}
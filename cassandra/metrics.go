@@ -0,0 +1,106 @@
+package infracassandra
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/prometheus/client_golang/prometheus"
+	infralog "github.com/pushwoosh/infra/log"
+	infratracing "github.com/pushwoosh/infra/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+var metrics struct {
+	QueriesCounter         *prometheus.CounterVec
+	QueryDurationHistogram *prometheus.HistogramVec
+}
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.QueriesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cassandra_queries_total",
+			Help: "The total number of cassandra query attempts by result: success, not_found or error. Retries are counted separately",
+		}, []string{"connection", "statement", "result"})
+
+		metrics.QueryDurationHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "cassandra_query_duration",
+			Help:    "The cassandra query attempt duration",
+			Buckets: []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5},
+		}, []string{"connection", "statement"})
+
+		prometheus.MustRegister(
+			metrics.QueriesCounter,
+			metrics.QueryDurationHistogram,
+		)
+	})
+}
+
+// observer collects metrics, spans and slow logs of every query and batch attempt
+type observer struct {
+	connection    string
+	slowThreshold time.Duration
+}
+
+var (
+	_ gocql.QueryObserver = (*observer)(nil)
+	_ gocql.BatchObserver = (*observer)(nil)
+)
+
+func (o *observer) ObserveQuery(ctx context.Context, q gocql.ObservedQuery) {
+	o.observe(ctx, q.Keyspace, q.Statement, q.Host, q.Start, q.End, q.Attempt, q.Err)
+}
+
+func (o *observer) ObserveBatch(ctx context.Context, b gocql.ObservedBatch) {
+	statement := ""
+	if len(b.Statements) > 0 {
+		statement = b.Statements[0]
+	}
+	o.observe(ctx, b.Keyspace, statement, b.Host, b.Start, b.End, 0, b.Err)
+}
+
+func (o *observer) observe(ctx context.Context, keyspace, cql string, host *gocql.HostInfo, start, end time.Time, attempt int, err error) {
+	name := statementFromContext(ctx)
+	duration := end.Sub(start)
+
+	result := "success"
+	switch {
+	case err == gocql.ErrNotFound:
+		result = "not_found"
+	case err != nil:
+		result = "error"
+	}
+
+	metrics.QueriesCounter.WithLabelValues(o.connection, name, result).Inc()
+	metrics.QueryDurationHistogram.WithLabelValues(o.connection, name).Observe(duration.Seconds())
+
+	_, span := infratracing.Tracer("cassandra").Start(ctx, "cassandra "+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(start),
+		trace.WithAttributes(
+			attribute.String("db.system", "cassandra"),
+			attribute.String("db.name", keyspace),
+			attribute.String("db.statement", cql),
+			attribute.Int("db.cassandra.attempt", attempt),
+		))
+	if host != nil {
+		span.SetAttributes(attribute.String("net.peer.name", host.ConnectAddress().String()))
+	}
+	if result == "error" {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End(trace.WithTimestamp(end))
+
+	if o.slowThreshold > 0 && duration > o.slowThreshold {
+		infralog.WarnCtx(ctx, "cassandra slow query",
+			zap.String("connection", o.connection),
+			zap.String("statement", name),
+			zap.Duration("duration", duration))
+	}
+}
@@ -0,0 +1,199 @@
+package infracassandra
+
+import (
+	"context"
+	"net"
+
+	"github.com/gocql/gocql"
+	"github.com/pkg/errors"
+	infraconfig "github.com/pushwoosh/infra/config"
+	infratls "github.com/pushwoosh/infra/tls"
+)
+
+// ErrNotFound is returned by Scan if the query returns no rows
+var ErrNotFound = gocql.ErrNotFound
+
+// Statement is a named CQL statement. gocql prepares statements on first use and caches them per host,
+// so define statements once and reuse them:
+//
+//	var getUser = infracassandra.Statement{
+//		Name:       "get_user",
+//		CQL:        "SELECT name, email FROM users WHERE id = ?",
+//		Idempotent: true,
+//	}
+//	...
+//	err := session.Scan(ctx, getUser, []interface{}{id}, &name, &email)
+type Statement struct {
+	// Name is a metric label and a span name
+	Name string
+	CQL  string
+
+	// Idempotent statements are retried by the retry policy and may be speculatively executed
+	Idempotent bool
+}
+
+// Session is a gocql session with policies from config, metrics and tracing
+type Session struct {
+	name    string
+	session *gocql.Session
+}
+
+// NewSession connects to the cluster. Use Container to hold named sessions
+func NewSession(name string, cfg *ConnectionConfig) (*Session, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	cluster, err := newClusterConfig(name, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	initMetrics()
+
+	session, err := cluster.CreateSession()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot connect to cassandra")
+	}
+
+	return &Session{
+		name:    name,
+		session: session,
+	}, nil
+}
+
+func newClusterConfig(name string, cfg *ConnectionConfig) (*gocql.ClusterConfig, error) {
+	cluster := gocql.NewCluster(cfg.Hosts...)
+	cluster.Keyspace = cfg.Keyspace
+	cluster.Timeout = cfg.GetTimeout()
+	cluster.ConnectTimeout = cfg.GetConnectTimeout()
+	cluster.RetryPolicy = cfg.Retry.retryPolicy()
+	cluster.PoolConfig.HostSelectionPolicy = cfg.HostSelection.hostSelectionPolicy()
+
+	// validated in Validate
+	cluster.Consistency, _ = cfg.consistency()
+	cluster.SerialConsistency, _ = cfg.serialConsistency()
+
+	if cfg.NumConns != 0 {
+		cluster.NumConns = cfg.NumConns
+	}
+	if cfg.PageSize != 0 {
+		cluster.PageSize = cfg.PageSize
+	}
+	if cfg.ProtoVersion != 0 {
+		cluster.ProtoVersion = cfg.ProtoVersion
+	}
+
+	if cfg.Username != "" {
+		password, err := infraconfig.ResolveSecret(cfg.Password)
+		if err != nil {
+			return nil, errors.Wrap(err, "password")
+		}
+		cluster.Authenticator = gocql.PasswordAuthenticator{
+			Username: cfg.Username,
+			Password: password,
+		}
+	}
+
+	if cfg.TLS != nil {
+		loader := cfg.TLS.Loader()
+		if err := loader.Reload(); err != nil {
+			return nil, errors.Wrap(err, "tls")
+		}
+		cluster.HostDialer = &tlsHostDialer{
+			dialer: &net.Dialer{Timeout: cluster.ConnectTimeout},
+			loader: loader,
+		}
+	}
+
+	obs := &observer{connection: name, slowThreshold: cfg.SlowLogThreshold}
+	cluster.QueryObserver = obs
+	cluster.BatchObserver = obs
+
+	return cluster, nil
+}
+
+// tlsHostDialer verifies certificates of nodes for their connect addresses, unless ServerName is configured.
+// gocql dials discovered nodes by IP addresses which are not sent as SNI, so the default dialer can't verify them.
+type tlsHostDialer struct {
+	dialer *net.Dialer
+	loader *infratls.Loader
+}
+
+func (d *tlsHostDialer) DialHost(ctx context.Context, host *gocql.HostInfo) (*gocql.DialedHost, error) {
+	conn, err := d.dialer.DialContext(ctx, "tcp", host.ConnectAddressAndPort())
+	if err != nil {
+		return nil, err
+	}
+	return gocql.WrapTLS(ctx, conn, host.ConnectAddressAndPort(), d.loader.ClientConfigForHost(host.ConnectAddress().String()))
+}
+
+// Session returns underlying gocql session
+func (s *Session) Session() *gocql.Session {
+	return s.session
+}
+
+// Query creates a query of the statement bound to ctx
+func (s *Session) Query(ctx context.Context, stmt Statement, values ...interface{}) *gocql.Query {
+	return s.session.Query(stmt.CQL, values...).
+		WithContext(withStatement(ctx, stmt.Name)).
+		Idempotent(stmt.Idempotent)
+}
+
+// Exec executes the statement without returning rows
+func (s *Session) Exec(ctx context.Context, stmt Statement, values ...interface{}) error {
+	return s.Query(ctx, stmt, values...).Exec()
+}
+
+// Scan executes the statement and scans the first row into dest. ErrNotFound is returned if there are no rows
+func (s *Session) Scan(ctx context.Context, stmt Statement, values []interface{}, dest ...interface{}) error {
+	return s.Query(ctx, stmt, values...).Scan(dest...)
+}
+
+// Iter executes the statement and returns an iterator over its rows. The iterator must be closed
+func (s *Session) Iter(ctx context.Context, stmt Statement, values ...interface{}) *gocql.Iter {
+	return s.Query(ctx, stmt, values...).Iter()
+}
+
+// Batch creates a batch bound to ctx. name is a metric label
+func (s *Session) Batch(ctx context.Context, name string, typ gocql.BatchType) *gocql.Batch {
+	return s.session.NewBatch(typ).WithContext(withStatement(ctx, name))
+}
+
+// Ping checks that the session has connected hosts
+func (s *Session) Ping(ctx context.Context) error {
+	if s.session.Closed() {
+		return errors.New("session is closed")
+	}
+
+	return s.Query(ctx, pingStatement).Exec()
+}
+
+var pingStatement = Statement{
+	Name:       "ping",
+	CQL:        "SELECT release_version FROM system.local",
+	Idempotent: true,
+}
+
+// Close closes all connections of the session
+func (s *Session) Close() {
+	s.session.Close()
+}
+
+type statementCtxKeyType string
+
+const statementCtxKey statementCtxKeyType = "cassandra_statement"
+
+func withStatement(ctx context.Context, name string) context.Context {
+	if name == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, statementCtxKey, name)
+}
+
+func statementFromContext(ctx context.Context) string {
+	if name, ok := ctx.Value(statementCtxKey).(string); ok {
+		return name
+	}
+	return "unnamed"
+}
@@ -0,0 +1,95 @@
+package infrachaos
+
+import (
+	"context"
+	"math/rand"
+
+	"github.com/pkg/errors"
+	infraflags "github.com/pushwoosh/infra/flags"
+	infraretry "github.com/pushwoosh/infra/retry"
+)
+
+// ErrInjected is returned by calls failed on purpose
+var ErrInjected = errors.New("chaos: injected fault")
+
+// Injector injects latency, errors and dropped messages into calls at configured rates.
+// Use it in staging to check how services behave when their dependencies misbehave:
+//
+//	injector, err := infrachaos.New(cfg.Chaos)
+//	producer := infrachaos.WrapProducer(injector, rabbitContainer.GetProducer("events"))
+//	router.Use(infrachaos.Middleware(injector))
+//	db := infrachaos.WrapDB(injector, chContainer.Get("events"))
+//	client.Transport = infrachaos.Transport(injector, client.Transport)
+//
+// Nothing is injected unless Enabled is set or the feature flag from config is on for the tenant from context.
+type Injector struct {
+	cfg   *Config
+	flags *infraflags.Flags
+	flag  *infraflags.BoolFlag
+}
+
+func New(cfg *Config, opts ...Option) (*Injector, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	initMetrics()
+
+	i := &Injector{cfg: cfg}
+
+	for _, opt := range opts {
+		opt.apply(i)
+	}
+
+	if cfg.Flag != "" {
+		if i.flags == nil {
+			i.flags = infraflags.Default()
+		}
+		i.flag = i.flags.Bool(cfg.Flag, false)
+	}
+
+	return i, nil
+}
+
+// Enabled reports if faults are injected into calls with the context
+func (i *Injector) Enabled(ctx context.Context) bool {
+	if i.cfg.Enabled {
+		return true
+	}
+	return i.flag != nil && i.flag.Enabled(ctx)
+}
+
+// Inject applies faults configured for the target to a call: it sleeps on latency, returns ErrInjected
+// on error and returns true if the message should be dropped. Latency is cut short when ctx is done.
+func (i *Injector) Inject(ctx context.Context, target string) (bool, error) {
+	fault := i.cfg.Faults[target]
+	if fault == nil || !i.Enabled(ctx) {
+		return false, nil
+	}
+
+	if hit(fault.LatencyRate) {
+		latency := fault.GetLatency()
+		metrics.FaultsCounter.WithLabelValues(target, "latency").Inc()
+		metrics.LatencyCounter.WithLabelValues(target).Add(latency.Seconds())
+
+		if err := infraretry.Sleep(ctx, latency); err != nil {
+			return false, err
+		}
+	}
+
+	if hit(fault.ErrorRate) {
+		metrics.FaultsCounter.WithLabelValues(target, "error").Inc()
+		return false, ErrInjected
+	}
+
+	if hit(fault.DropRate) {
+		metrics.FaultsCounter.WithLabelValues(target, "drop").Inc()
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func hit(rate float64) bool {
+	return rate > 0 && rand.Float64() < rate
+}
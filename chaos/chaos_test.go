@@ -0,0 +1,78 @@
+package infrachaos
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestInject(t *testing.T) {
+	ctx := context.Background()
+
+	i, err := New(&Config{
+		Enabled: true,
+		Faults: map[string]*FaultConfig{
+			TargetRabbitPublish: {DropRate: 1},
+			TargetHTTPClient:    {LatencyRate: 1, Latency: 20 * time.Millisecond, ErrorRate: 1},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if drop, err := i.Inject(ctx, TargetRabbitPublish); err != nil || !drop {
+		t.Fatalf("expected drop, got %v, %v", drop, err)
+	}
+
+	if drop, err := i.Inject(ctx, TargetClickHouseQuery); err != nil || drop {
+		t.Fatalf("expected no fault for unconfigured target, got %v, %v", drop, err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost", nil)
+	start := time.Now()
+	if _, err = Transport(i, nil).RoundTrip(req); !errors.Is(err, ErrInjected) {
+		t.Fatalf("expected ErrInjected, got %v", err)
+	}
+	if time.Since(start) < 20*time.Millisecond {
+		t.Fatal("latency must be injected before error")
+	}
+
+	i.cfg.Enabled = false
+	if drop, err := i.Inject(ctx, TargetRabbitPublish); err != nil || drop {
+		t.Fatalf("expected no fault while disabled, got %v, %v", drop, err)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	cfg := &Config{Faults: map[string]*FaultConfig{TargetHTTPClient: {DropRate: 0.5}}}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("drop_rate must be rejected for http target")
+	}
+
+	cfg = &Config{Faults: map[string]*FaultConfig{"kafka": {ErrorRate: 0.5}}}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("unknown target must be rejected")
+	}
+}
+
+func TestDB(t *testing.T) {
+	i, err := New(&Config{Enabled: true, Faults: map[string]*FaultConfig{TargetClickHouseQuery: {ErrorRate: 1}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the pool isn't reached when a fault is injected
+	db := WrapDB(i, nil)
+	ctx := context.Background()
+
+	var n int
+	if err = db.QueryRowContext(ctx, "SELECT 1").Scan(&n); !errors.Is(err, ErrInjected) {
+		t.Fatalf("expected ErrInjected from QueryRowContext, got %v", err)
+	}
+	if _, err = db.BeginTx(ctx, nil); !errors.Is(err, ErrInjected) {
+		t.Fatalf("expected ErrInjected from BeginTx, got %v", err)
+	}
+}
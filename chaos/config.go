@@ -0,0 +1,106 @@
+package infrachaos
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Fault targets
+const (
+	TargetRabbitPublish   = "rabbit_publish"
+	TargetRabbitConsume   = "rabbit_consume"
+	TargetClickHouseQuery = "clickhouse_query"
+	TargetHTTPClient      = "http_client"
+)
+
+const defaultLatency = 100 * time.Millisecond
+
+var targets = map[string]bool{
+	TargetRabbitPublish:   true,
+	TargetRabbitConsume:   true,
+	TargetClickHouseQuery: true,
+	TargetHTTPClient:      true,
+}
+
+// dropTargets are targets handling messages, other targets can't drop calls silently
+var dropTargets = map[string]bool{
+	TargetRabbitPublish: true,
+	TargetRabbitConsume: true,
+}
+
+type Config struct {
+	// Enabled turns injection on. optional, default: false
+	Enabled bool `mapstructure:"enabled"`
+
+	// Flag is a bool feature flag turning injection on at runtime while Enabled is false. optional
+	Flag string `mapstructure:"flag"`
+
+	// Faults by target: rabbit_publish, rabbit_consume, clickhouse_query or http_client
+	Faults map[string]*FaultConfig `mapstructure:"faults"`
+}
+
+type FaultConfig struct {
+	// Fraction of calls delayed by Latency, from 0 to 1. optional
+	LatencyRate float64 `mapstructure:"latency_rate"`
+
+	// optional, default: 100ms
+	Latency time.Duration `mapstructure:"latency"`
+
+	// Fraction of calls failed with ErrInjected, from 0 to 1. optional
+	ErrorRate float64 `mapstructure:"error_rate"`
+
+	// Fraction of messages dropped without an error, from 0 to 1. Only rabbit targets drop messages. optional
+	DropRate float64 `mapstructure:"drop_rate"`
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	for target, fault := range c.Faults {
+		if !targets[target] {
+			return errors.Errorf("unknown target %q", target)
+		}
+		if err := fault.Validate(); err != nil {
+			return errors.Wrap(err, target)
+		}
+		if fault.DropRate > 0 && !dropTargets[target] {
+			return errors.Wrap(errors.New("drop_rate is supported by rabbit targets only"), target)
+		}
+	}
+
+	return nil
+}
+
+func (c *FaultConfig) Validate() error {
+	if c == nil {
+		return errors.New("empty fault config")
+	}
+
+	if c.LatencyRate < 0 || c.LatencyRate > 1 {
+		return errors.New("latency_rate should be between 0 and 1")
+	}
+
+	if c.ErrorRate < 0 || c.ErrorRate > 1 {
+		return errors.New("error_rate should be between 0 and 1")
+	}
+
+	if c.DropRate < 0 || c.DropRate > 1 {
+		return errors.New("drop_rate should be between 0 and 1")
+	}
+
+	if c.Latency < 0 {
+		return errors.New("latency should not be negative")
+	}
+
+	return nil
+}
+
+func (c *FaultConfig) GetLatency() time.Duration {
+	if c.Latency == 0 {
+		return defaultLatency
+	}
+	return c.Latency
+}
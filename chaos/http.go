@@ -0,0 +1,37 @@
+package infrachaos
+
+import (
+	"net/http"
+)
+
+// Transport injects faults into HTTP calls:
+//
+//	client.Transport = infrachaos.Transport(injector, client.Transport)
+//
+// Put it under infrahttp.WrapTransport to have injected faults measured and retried like real ones.
+func Transport(i *Injector, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &transport{
+		injector: i,
+		next:     next,
+	}
+}
+
+type transport struct {
+	injector *Injector
+	next     http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if _, err := t.injector.Inject(req.Context(), TargetHTTPClient); err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, err
+	}
+
+	return t.next.RoundTrip(req)
+}
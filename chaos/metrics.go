@@ -0,0 +1,33 @@
+package infrachaos
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	FaultsCounter  *prometheus.CounterVec
+	LatencyCounter *prometheus.CounterVec
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.FaultsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "chaos_faults_injected_total",
+			Help: "Number of injected faults by target and fault: latency, error or drop",
+		}, []string{"target", "fault"})
+
+		metrics.LatencyCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "chaos_injected_latency_seconds_total",
+			Help: "Total latency injected into calls of the target",
+		}, []string{"target"})
+
+		prometheus.MustRegister(
+			metrics.FaultsCounter,
+			metrics.LatencyCounter,
+		)
+	})
+}
@@ -0,0 +1,22 @@
+package infrachaos
+
+import (
+	infraflags "github.com/pushwoosh/infra/flags"
+)
+
+type Option interface {
+	apply(i *Injector)
+}
+
+type optionFlags struct {
+	flags *infraflags.Flags
+}
+
+func (opt optionFlags) apply(i *Injector) {
+	i.flags = opt.flags
+}
+
+// WithFlags sets flags evaluating Config.Flag. infraflags.Default() is used by default
+func WithFlags(flags *infraflags.Flags) Option {
+	return optionFlags{flags: flags}
+}
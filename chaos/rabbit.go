@@ -0,0 +1,48 @@
+package infrachaos
+
+import (
+	"context"
+
+	infrarabbit "github.com/pushwoosh/infra/rabbit"
+)
+
+// Producer injects faults into rabbitmq publishing. Dropped messages are reported as published
+type Producer struct {
+	publisher infrarabbit.Publisher
+	injector  *Injector
+}
+
+var _ infrarabbit.Publisher = (*Producer)(nil)
+
+func WrapProducer(i *Injector, p infrarabbit.Publisher) *Producer {
+	return &Producer{
+		publisher: p,
+		injector:  i,
+	}
+}
+
+func (p *Producer) Produce(ctx context.Context, msg *infrarabbit.ProducerMessage) error {
+	drop, err := p.injector.Inject(ctx, TargetRabbitPublish)
+	if err != nil || drop {
+		return err
+	}
+
+	return p.publisher.Produce(ctx, msg)
+}
+
+// Middleware injects faults into handling of rabbitmq messages. Failed messages are requeued by the router,
+// dropped messages are acked without calling the handler:
+//
+//	router.Use(infrachaos.Middleware(injector))
+func Middleware(i *Injector) infrarabbit.Middleware {
+	return func(next infrarabbit.HandlerFunc) infrarabbit.HandlerFunc {
+		return func(ctx context.Context, msg *infrarabbit.Message) error {
+			drop, err := i.Inject(ctx, TargetRabbitConsume)
+			if err != nil || drop {
+				return err
+			}
+
+			return next(ctx, msg)
+		}
+	}
+}
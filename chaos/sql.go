@@ -0,0 +1,126 @@
+package infrachaos
+
+import (
+	"context"
+	"database/sql"
+)
+
+// DB injects faults into queries of a database/sql connection pool like ClickHouse container connections:
+//
+//	db := infrachaos.WrapDB(injector, chContainer.Get("events"))
+//	rows, err := db.QueryContext(ctx, query, args...)
+//
+// The pool isn't exposed, so every query gets faults injected.
+type DB struct {
+	db       *sql.DB
+	injector *Injector
+}
+
+func WrapDB(i *Injector, db *sql.DB) *DB {
+	return &DB{
+		db:       db,
+		injector: i,
+	}
+}
+
+func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if _, err := db.injector.Inject(ctx, TargetClickHouseQuery); err != nil {
+		return nil, err
+	}
+
+	return db.db.ExecContext(ctx, query, args...)
+}
+
+func (db *DB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return db.ExecContext(context.Background(), query, args...)
+}
+
+func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if _, err := db.injector.Inject(ctx, TargetClickHouseQuery); err != nil {
+		return nil, err
+	}
+
+	return db.db.QueryContext(ctx, query, args...)
+}
+
+func (db *DB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return db.QueryContext(context.Background(), query, args...)
+}
+
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *Row {
+	if _, err := db.injector.Inject(ctx, TargetClickHouseQuery); err != nil {
+		return &Row{err: err}
+	}
+
+	return &Row{row: db.db.QueryRowContext(ctx, query, args...)}
+}
+
+func (db *DB) QueryRow(query string, args ...interface{}) *Row {
+	return db.QueryRowContext(context.Background(), query, args...)
+}
+
+// PrepareContext prepares a statement. Faults are injected into the preparation, not into executions of the statement.
+func (db *DB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	if _, err := db.injector.Inject(ctx, TargetClickHouseQuery); err != nil {
+		return nil, err
+	}
+
+	return db.db.PrepareContext(ctx, query)
+}
+
+func (db *DB) Prepare(query string) (*sql.Stmt, error) {
+	return db.PrepareContext(context.Background(), query)
+}
+
+// BeginTx starts a transaction. Faults are injected into the start, not into statements within the transaction.
+func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	if _, err := db.injector.Inject(ctx, TargetClickHouseQuery); err != nil {
+		return nil, err
+	}
+
+	return db.db.BeginTx(ctx, opts)
+}
+
+func (db *DB) Begin() (*sql.Tx, error) {
+	return db.BeginTx(context.Background(), nil)
+}
+
+func (db *DB) PingContext(ctx context.Context) error {
+	if _, err := db.injector.Inject(ctx, TargetClickHouseQuery); err != nil {
+		return err
+	}
+
+	return db.db.PingContext(ctx)
+}
+
+func (db *DB) Ping() error {
+	return db.PingContext(context.Background())
+}
+
+func (db *DB) Stats() sql.DBStats {
+	return db.db.Stats()
+}
+
+func (db *DB) Close() error {
+	return db.db.Close()
+}
+
+// Row is a result of QueryRowContext, Scan returns ErrInjected if a fault was injected
+type Row struct {
+	row *sql.Row
+	err error
+}
+
+func (r *Row) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.row.Scan(dest...)
+}
+
+func (r *Row) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.row.Err()
+}
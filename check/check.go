@@ -0,0 +1,220 @@
+package infracheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	infraconfig "github.com/pushwoosh/infra/config"
+)
+
+const (
+	// Flag is the command line flag of self-check mode: --selfcheck prints a text report, --selfcheck=json a JSON one
+	Flag = "selfcheck"
+
+	FormatText = "text"
+	FormatJSON = "json"
+
+	defaultTimeout = 5 * time.Second
+)
+
+// Func checks a dependency, e.g. connects to it. It should return when ctx is done
+type Func func(ctx context.Context) error
+
+// Result is a result of a single check
+type Result struct {
+	Kind     string        `json:"kind"`
+	Name     string        `json:"name"`
+	OK       bool          `json:"ok"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Report is a result of all checks
+type Report struct {
+	OK       bool          `json:"ok"`
+	Duration time.Duration `json:"duration"`
+	Results  []Result      `json:"results"`
+}
+
+type check struct {
+	kind string
+	name string
+	fn   Func
+}
+
+// Runner checks configured dependencies of a service, so a broken config or an unreachable dependency
+// is found by CI smoke tests or an init container before the service is rolled out:
+//
+//	if format, ok := infracheck.Requested(os.Args[1:]); ok {
+//		checks := infracheck.New(infracheck.WithTimeout(3 * time.Second))
+//		if err := checks.LoadConfig(*configPath, cfg, infraconfig.WithEnvPrefix("APP")); err == nil {
+//			checks.Add("rabbit", "main", infracheck.Rabbit(cfg.Rabbit))
+//			checks.Add("clickhouse", "events", infracheck.ClickHouse(cfg.ClickHouse))
+//			checks.Add("redis", "cache", infracheck.Redis(cfg.Redis))
+//		}
+//		os.Exit(checks.Main(ctx, os.Stdout, format))
+//	}
+type Runner struct {
+	timeout time.Duration
+	checks  []check
+	loaded  []Result
+}
+
+func New(opts ...Option) *Runner {
+	r := &Runner{timeout: defaultTimeout}
+	for _, opt := range opts {
+		opt.apply(r)
+	}
+	return r
+}
+
+// Add adds a check of a dependency of the kind, e.g. "rabbit", with the name
+func (r *Runner) Add(kind, name string, fn Func) {
+	r.checks = append(r.checks, check{kind: kind, name: name, fn: fn})
+}
+
+// LoadConfig loads the config with infraconfig.Load, the result is included in the report.
+// Dependencies should be added only if it succeeds
+func (r *Runner) LoadConfig(path string, dst interface{}, opts ...infraconfig.Option) error {
+	start := time.Now()
+	err := infraconfig.Load(path, dst, opts...)
+
+	name := path
+	if name == "" {
+		name = "env"
+	}
+	r.loaded = append(r.loaded, result("config", name, time.Since(start), err))
+
+	return err
+}
+
+// Run runs all checks in parallel, each with the timeout
+func (r *Runner) Run(ctx context.Context) Report {
+	start := time.Now()
+
+	results := make([]Result, len(r.checks))
+	var wg sync.WaitGroup
+	for i, c := range r.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			checkStart := time.Now()
+			err := r.call(ctx, c.fn)
+			results[i] = result(c.kind, c.name, time.Since(checkStart), err)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{
+		OK:       true,
+		Duration: time.Since(start),
+		Results:  append(append([]Result{}, r.loaded...), results...),
+	}
+	for _, res := range report.Results {
+		report.OK = report.OK && res.OK
+	}
+
+	return report
+}
+
+// Main runs checks, writes the report to out in the format and returns the exit code: 0 if all checks passed
+func (r *Runner) Main(ctx context.Context, out io.Writer, format string) int {
+	report := r.Run(ctx)
+
+	var err error
+	if format == FormatJSON {
+		err = report.WriteJSON(out)
+	} else {
+		err = report.WriteText(out)
+	}
+
+	if err != nil || !report.OK {
+		return 1
+	}
+	return 0
+}
+
+// call runs fn with the timeout. Some clients don't respect ctx on connect, so call doesn't wait for fn
+// after the timeout. It's fine for a process that exits after checks
+func (r *Runner) call(ctx context.Context, fn Func) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- errors.Errorf("panic: %v", p)
+			}
+		}()
+		done <- fn(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "check timed out")
+	}
+}
+
+func result(kind, name string, duration time.Duration, err error) Result {
+	res := Result{Kind: kind, Name: name, OK: err == nil, Duration: duration}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// WriteText writes the report as a table
+func (r Report) WriteText(out io.Writer) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "KIND\tNAME\tSTATUS\tDURATION\tERROR")
+	for _, res := range r.Results {
+		status := "ok"
+		if !res.OK {
+			status = "FAIL"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", res.Kind, res.Name, status, res.Duration.Round(time.Millisecond), res.Error)
+	}
+
+	status := "passed"
+	if !r.OK {
+		status = "failed"
+	}
+	_, _ = fmt.Fprintf(w, "\nself-check %s in %s\n", status, r.Duration.Round(time.Millisecond))
+
+	return w.Flush()
+}
+
+// WriteJSON writes the report as JSON, durations are in nanoseconds
+func (r Report) WriteJSON(out io.Writer) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// Requested reports whether args contain the self-check flag and returns the report format
+func Requested(args []string) (string, bool) {
+	for _, arg := range args {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != Flag {
+			continue
+		}
+
+		if hasValue && value == FormatJSON {
+			return FormatJSON, true
+		}
+		return FormatText, true
+	}
+
+	return "", false
+}
@@ -0,0 +1,65 @@
+package infracheck
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestRunner(t *testing.T) {
+	ctx := context.Background()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	r := New(WithTimeout(50 * time.Millisecond))
+	r.Add("http", "api", HTTP(srv.URL))
+	r.Add("tcp", "api", TCP(srv.Listener.Addr().String()))
+
+	var out bytes.Buffer
+	if code := r.Main(ctx, &out, FormatText); code != 0 {
+		t.Fatalf("expected success, got %d:\n%s", code, out.String())
+	}
+
+	if err := r.LoadConfig("missing.yaml", &struct{}{}); err == nil {
+		t.Fatal("expected config error")
+	}
+	r.Add("custom", "broken", func(context.Context) error { return errors.New("broken") })
+	r.Add("custom", "hanging", func(context.Context) error { select {} })
+
+	report := r.Run(ctx)
+	if report.OK || len(report.Results) != 5 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if res := report.Results[0]; res.Kind != "config" || res.OK {
+		t.Fatalf("expected failed config first, got %+v", res)
+	}
+	if res := report.Results[4]; res.OK || !strings.Contains(res.Error, "timed out") {
+		t.Fatalf("expected timeout, got %+v", res)
+	}
+
+	out.Reset()
+	if code := r.Main(ctx, &out, FormatJSON); code != 1 || !strings.Contains(out.String(), `"error": "broken"`) {
+		t.Fatalf("unexpected output %d:\n%s", code, out.String())
+	}
+}
+
+func TestRequested(t *testing.T) {
+	for args, expected := range map[string]string{
+		"":                           "",
+		"--config app.yaml":          "",
+		"--selfcheck":                FormatText,
+		"-config x --selfcheck=json": FormatJSON,
+		"-selfcheck=text":            FormatText,
+	} {
+		format, ok := Requested(strings.Fields(args))
+		if format != expected || ok != (expected != "") {
+			t.Fatalf("%q: unexpected %q, %v", args, format, ok)
+		}
+	}
+}
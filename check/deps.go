@@ -0,0 +1,117 @@
+package infracheck
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	infraclickhouse "github.com/pushwoosh/infra/clickhouse"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	infrapostgres "github.com/pushwoosh/infra/postgres"
+	infrarabbit "github.com/pushwoosh/infra/rabbit"
+	infraredis "github.com/pushwoosh/infra/redis"
+)
+
+// connection name of containers, connections are closed after checks
+const connectionName = "selfcheck"
+
+// Checker checks a dependency that is already created, e.g. a container with connections
+func Checker(c infraoperator.Checker) Func {
+	return c.Check
+}
+
+// Rabbit opens a connection to RabbitMQ
+func Rabbit(cfg *infrarabbit.ConnectionConfig) Func {
+	return func(context.Context) error {
+		cont := infrarabbit.NewContainer()
+		if err := cont.AddConnection(connectionName, cfg); err != nil {
+			return err
+		}
+
+		conn, err := cont.Dial(connectionName)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
+
+// ClickHouse connects to ClickHouse and runs a ping
+func ClickHouse(cfg *infraclickhouse.ConnectionConfig) Func {
+	return func(ctx context.Context) error {
+		cont := infraclickhouse.NewContainer()
+		if err := cont.Connect(connectionName, cfg); err != nil {
+			return err
+		}
+		defer prometheus.Unregister(cont.GetCollector(connectionName))
+
+		return ping(ctx, cont.Get(connectionName))
+	}
+}
+
+// Postgres connects to Postgres and runs a ping
+func Postgres(cfg *infrapostgres.ConnectionConfig) Func {
+	return func(ctx context.Context) error {
+		cont := infrapostgres.NewContainer()
+		if err := cont.Connect(connectionName, cfg); err != nil {
+			return err
+		}
+		defer prometheus.Unregister(cont.GetCollector(connectionName))
+
+		return ping(ctx, cont.Get(connectionName))
+	}
+}
+
+// Redis connects to Redis and runs a ping
+func Redis(cfg *infraredis.ConnectionConfig) Func {
+	return func(ctx context.Context) error {
+		cont := infraredis.NewContainer()
+		defer cont.Close()
+
+		if err := cont.Connect(connectionName, cfg); err != nil {
+			return err
+		}
+		return cont.Check(ctx)
+	}
+}
+
+// TCP connects to the address, e.g. of a dependency without a client in infra
+func TCP(address string) Func {
+	return func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
+
+// HTTP sends a GET request to the url, it fails on statuses other than 2xx
+func HTTP(url string) Func {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return errors.Errorf("unexpected status %s", resp.Status)
+		}
+		return nil
+	}
+}
+
+func ping(ctx context.Context, db *sql.DB) error {
+	defer db.Close()
+	return db.PingContext(ctx)
+}
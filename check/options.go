@@ -0,0 +1,20 @@
+package infracheck
+
+import (
+	"time"
+)
+
+type Option interface {
+	apply(r *Runner)
+}
+
+type optionTimeout time.Duration
+
+func (opt optionTimeout) apply(r *Runner) {
+	r.timeout = time.Duration(opt)
+}
+
+// WithTimeout sets a timeout of each check, default: 5s
+func WithTimeout(timeout time.Duration) Option {
+	return optionTimeout(timeout)
+}
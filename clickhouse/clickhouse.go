@@ -4,7 +4,6 @@ import (
 	"database/sql"
 	"sync"
 
-	_ "github.com/ClickHouse/clickhouse-go/v2"
 	"github.com/dlmiddlecote/sqlstats"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
@@ -27,17 +26,23 @@ func NewContainer() *Container {
 	}
 }
 
-// Connect creates a new named clickhouse connection
+// Connect creates a new named clickhouse connection.
+// Credentials are resolved for every new physical connection, so rotated secrets are picked up without Connect.
 func (cont *Container) Connect(name string, cfg *ConnectionConfig) error {
-	dsn := cfg.GetConnectionDSN()
-	conn, err := sql.Open("clickhouse", dsn)
-	if err != nil {
-		return errors.Wrapf(err, "sql.Open")
+	if _, err := cfg.ResolveConnectionDSN(); err != nil {
+		return err
 	}
+	if cfg.TLS != nil {
+		if err := cfg.TLS.Loader().Reload(); err != nil {
+			return errors.Wrap(err, "tls")
+		}
+	}
+	conn := sql.OpenDB(&connector{cfg: *cfg})
 
-	err = conn.Ping()
-	if err != nil {
-		return errors.Wrapf(err, "conn.Ping")
+	if !cfg.Lazy {
+		if err := conn.Ping(); err != nil {
+			return errors.Wrapf(err, "conn.Ping")
+		}
 	}
 
 	conn.SetMaxOpenConns(cfg.MaxConnections)
@@ -5,8 +5,18 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	infraconfig "github.com/pushwoosh/infra/config"
+	infraproxy "github.com/pushwoosh/infra/proxy"
+	infratls "github.com/pushwoosh/infra/tls"
 )
 
+func init() {
+	// services start in dev without their dependencies running
+	infraconfig.RegisterProfileDefaults(infraconfig.ProfileDev, func(c *ConnectionConfig) {
+		c.Lazy = true
+	})
+}
+
 type ConnectionsConfig map[string]*ConnectionConfig
 
 type ConnectionConfig struct {
@@ -27,10 +37,22 @@ type ConnectionConfig struct {
 
 	// Connection idle time. Connections that idle more than that period will be closed
 	MaxConnectionIdleTime time.Duration `mapstructure:"max_connection_idle_time"`
+
+	// TLS options, certificates are reloaded on change. TLS is disabled if empty
+	TLS *infratls.Config `mapstructure:"tls"`
+
+	// Proxy of connections. optional, default: the global proxy
+	Proxy *infraproxy.Config `mapstructure:"proxy"`
+
+	// Lazy connects on the first use instead of Connect, so a service starts without the database running.
+	// optional, default: true in the dev profile
+	Lazy bool `mapstructure:"lazy"`
 }
 
 type Credentials struct {
 	Database string `mapstructure:"database"`
+
+	// Username and Password may be secret references, see infraconfig.ResolveSecret
 	Username string `mapstructure:"username"`
 	Password string `mapstructure:"password"`
 }
@@ -46,6 +68,21 @@ func (c *ConnectionConfig) GetConnectionDSN() string {
 	)
 }
 
+// ResolveConnectionDSN returns the connection DSN with resolved secret references of credentials
+func (c *ConnectionConfig) ResolveConnectionDSN() (string, error) {
+	resolved := *c
+
+	var err error
+	if resolved.Credentials.Username, err = infraconfig.ResolveSecret(c.Credentials.Username); err != nil {
+		return "", errors.Wrap(err, "username")
+	}
+	if resolved.Credentials.Password, err = infraconfig.ResolveSecret(c.Credentials.Password); err != nil {
+		return "", errors.Wrap(err, "password")
+	}
+
+	return resolved.GetConnectionDSN(), nil
+}
+
 func (c *ConnectionsConfig) Validate() error {
 	if c == nil {
 		return nil
@@ -73,6 +110,18 @@ func (c *ConnectionConfig) Validate() error {
 		return errors.Wrap(err, "credentials")
 	}
 
+	if c.TLS != nil {
+		if err := c.TLS.Validate(); err != nil {
+			return errors.Wrap(err, "tls")
+		}
+	}
+
+	if c.Proxy != nil {
+		if err := c.Proxy.Validate(); err != nil {
+			return errors.Wrap(err, "proxy")
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,86 @@
+package infraclickhouse
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql/driver"
+	"net"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/pkg/errors"
+	infraproxy "github.com/pushwoosh/infra/proxy"
+)
+
+// connector resolves the DSN for every new connection
+type connector struct {
+	cfg ConnectionConfig
+}
+
+var _ driver.Connector = (*connector)(nil)
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	opts, err := c.options()
+	if err != nil {
+		return nil, err
+	}
+
+	return clickhouse.Connector(opts).Connect(ctx)
+}
+
+func (c *connector) Driver() driver.Driver {
+	opts, err := c.options()
+	if err != nil {
+		opts = &clickhouse.Options{}
+	}
+
+	return clickhouse.Connector(opts).Driver()
+}
+
+func (c *connector) options() (*clickhouse.Options, error) {
+	dsn, err := c.cfg.ResolveConnectionDSN()
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := clickhouse.ParseDSN(dsn)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid dsn")
+	}
+
+	if c.cfg.TLS != nil {
+		opts.TLS = c.cfg.TLS.Loader().ClientConfig()
+	}
+
+	if proxy := infraproxy.ForClient(c.cfg.Proxy); proxy != nil {
+		dial, err := proxy.Dialer((&net.Dialer{Timeout: opts.DialTimeout}).DialContext)
+		if err != nil {
+			return nil, errors.Wrap(err, "proxy")
+		}
+		opts.DialContext = proxyDialContext(dial, opts)
+	}
+
+	return opts, nil
+}
+
+// proxyDialContext dials through the proxy. The driver doesn't wrap connections of a custom dial function
+// of the native protocol with TLS, so it's done here
+func proxyDialContext(dial infraproxy.DialFunc, opts *clickhouse.Options) func(ctx context.Context, addr string) (net.Conn, error) {
+	return func(ctx context.Context, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, "tcp", addr)
+		if err != nil || opts.TLS == nil || opts.Protocol == clickhouse.HTTP {
+			return conn, err
+		}
+
+		tlsCfg := opts.TLS.Clone()
+		if tlsCfg.ServerName == "" {
+			tlsCfg.ServerName, _, _ = net.SplitHostPort(addr)
+		}
+
+		tlsConn := tls.Client(conn, tlsCfg)
+		if err = tlsConn.HandshakeContext(ctx); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		return tlsConn, nil
+	}
+}
@@ -0,0 +1,55 @@
+package infraclickhouse
+
+import (
+	"net/http"
+	"time"
+
+	infradebug "github.com/pushwoosh/infra/debug"
+)
+
+type debugConnection struct {
+	Address  string `json:"address"`
+	Database string `json:"database"`
+	TLS      bool   `json:"tls"`
+
+	MaxOpenConnections int           `json:"max_open_connections"`
+	OpenConnections    int           `json:"open_connections"`
+	InUse              int           `json:"in_use"`
+	Idle               int           `json:"idle"`
+	WaitCount          int64         `json:"wait_count"`
+	WaitDuration       time.Duration `json:"wait_duration"`
+	MaxIdleClosed      int64         `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64         `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64         `json:"max_lifetime_closed"`
+}
+
+// DebugHandler dumps pool stats of the container connections by name, register it with infradebug.Register
+func (cont *Container) DebugHandler() http.Handler {
+	return infradebug.JSON(func(*http.Request) (interface{}, error) {
+		cont.mu.RLock()
+		defer cont.mu.RUnlock()
+
+		conns := make(map[string]*debugConnection, len(cont.conns))
+		for name, conn := range cont.conns {
+			cfg := cont.cfg[name]
+			stats := conn.Stats()
+
+			conns[name] = &debugConnection{
+				Address:            cfg.Address,
+				Database:           cfg.Credentials.Database,
+				TLS:                cfg.TLS != nil,
+				MaxOpenConnections: stats.MaxOpenConnections,
+				OpenConnections:    stats.OpenConnections,
+				InUse:              stats.InUse,
+				Idle:               stats.Idle,
+				WaitCount:          stats.WaitCount,
+				WaitDuration:       stats.WaitDuration,
+				MaxIdleClosed:      stats.MaxIdleClosed,
+				MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+				MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+			}
+		}
+
+		return conns, nil
+	})
+}
@@ -0,0 +1,65 @@
+package infraclickhouse
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/pkg/errors"
+	infratracing "github.com/pushwoosh/infra/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Insert sends rows with a single batch insert. query is an insert statement without values,
+// e.g. "INSERT INTO events (ts, user_id, name)", rows are values of the listed columns.
+// The insert is traced with a client span, its context is sent to the server
+func Insert(ctx context.Context, db *sql.DB, query string, rows [][]any) (err error) {
+	ctx, span := infratracing.Tracer("clickhouse").Start(ctx, "clickhouse insert",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "clickhouse"),
+			attribute.String("db.operation", "INSERT"),
+			attribute.String("db.statement", query),
+			attribute.Int("db.clickhouse.rows", len(rows)),
+		))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+	ctx = clickhouse.Context(ctx, clickhouse.WithSpan(span.SpanContext()))
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "unable to begin batch")
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return errors.Wrap(err, "unable to prepare batch")
+	}
+	defer stmt.Close()
+
+	for _, row := range rows {
+		if _, err = stmt.ExecContext(ctx, row...); err != nil {
+			return errors.Wrap(err, "unable to append to batch")
+		}
+	}
+
+	return errors.Wrap(tx.Commit(), "unable to send batch")
+}
+
+// WithSettings returns ctx with ClickHouse settings of queries run with it,
+// e.g. insert_deduplication_token of an Insert retried after an unknown result
+func WithSettings(ctx context.Context, settings map[string]any) context.Context {
+	return clickhouse.Context(ctx, clickhouse.WithSettings(settings))
+}
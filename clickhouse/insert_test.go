@@ -0,0 +1,80 @@
+package infraclickhouse
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	infratesttracing "github.com/pushwoosh/infra/test/tracing"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// batchConnector opens connections whose batch statements fail on the row failRow, if it's set
+type batchConnector struct {
+	failRow int
+	rows    int
+}
+
+func (c *batchConnector) Connect(context.Context) (driver.Conn, error) { return &batchConn{c}, nil }
+func (c *batchConnector) Driver() driver.Driver                        { return nil }
+
+type batchConn struct {
+	connector *batchConnector
+}
+
+func (c *batchConn) Prepare(string) (driver.Stmt, error) { return &batchStmt{c.connector}, nil }
+func (c *batchConn) Close() error                        { return nil }
+func (c *batchConn) Begin() (driver.Tx, error)           { return batchTx{}, nil }
+
+type batchTx struct{}
+
+func (batchTx) Commit() error   { return nil }
+func (batchTx) Rollback() error { return nil }
+
+type batchStmt struct {
+	connector *batchConnector
+}
+
+func (s *batchStmt) Close() error  { return nil }
+func (s *batchStmt) NumInput() int { return -1 }
+func (s *batchStmt) Query([]driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s *batchStmt) Exec([]driver.Value) (driver.Result, error) {
+	s.connector.rows++
+	if s.connector.rows == s.connector.failRow {
+		return nil, errors.New("code: 60, table doesn't exist")
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func TestInsertTracing(t *testing.T) {
+	recorder := infratesttracing.Record(t)
+
+	connector := &batchConnector{}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	query := "INSERT INTO events (ts, name)"
+	if err := Insert(context.Background(), db, query, [][]any{{1, "a"}, {2, "b"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	span := infratesttracing.Span(t, recorder, "clickhouse insert")
+	infratesttracing.RequireAttribute(t, span, "db.statement", query)
+	infratesttracing.RequireAttribute(t, span, "db.clickhouse.rows", int64(2))
+	if span.Status().Code == codes.Error {
+		t.Fatalf("unexpected error status %v", span.Status())
+	}
+
+	connector.rows, connector.failRow = 0, 1
+	if err := Insert(context.Background(), db, query, [][]any{{3, "c"}}); err == nil {
+		t.Fatal("expected insert error")
+	}
+	if spans := recorder.Ended(); spans[len(spans)-1].Status().Code != codes.Error {
+		t.Fatal("failed insert must be an error span")
+	}
+}
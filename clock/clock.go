@@ -0,0 +1,76 @@
+package infraclock
+
+import (
+	"context"
+	"time"
+)
+
+// Clock is a source of time. Infra components use it instead of the time package,
+// so time dependent code can be tested with Fake.
+type Clock interface {
+	Now() time.Time
+
+	NewTicker(d time.Duration) Ticker
+
+	// After returns a channel receiving the current time after d
+	After(d time.Duration) <-chan time.Time
+
+	// Sleep pauses for d. It returns ctx error if ctx is done earlier. It returns immediately if d <= 0
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// Ticker is a time.Ticker of a Clock
+type Ticker interface {
+	C() <-chan time.Time
+	Reset(d time.Duration)
+	Stop()
+}
+
+// Real is the clock of the time package
+var Real Clock = realClock{}
+
+// OrReal returns c or Real if c is nil, e.g. for optional clocks of components
+func OrReal(c Clock) Clock {
+	if c == nil {
+		return Real
+	}
+	return c
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{Ticker: time.NewTicker(d)}
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (realClock) Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type realTicker struct {
+	*time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
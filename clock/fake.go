@@ -0,0 +1,220 @@
+package infraclock
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fake is a Clock controlled by a test. Time moves only with Advance and Set, firing due timers and tickers:
+//
+//	clock := infraclock.NewFake(time.Now())
+//	go component.Run(clock)
+//	clock.BlockUntil(1) // wait until the component sleeps or waits for a ticker
+//	clock.Advance(time.Minute)
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*waiter
+
+	// changed is closed and replaced when waiters change, BlockUntil waits on it
+	changed chan struct{}
+}
+
+type waiter struct {
+	at     time.Time
+	period time.Duration // tickers only
+	ch     chan time.Time
+}
+
+var _ Clock = (*Fake)(nil)
+
+func NewFake(now time.Time) *Fake {
+	return &Fake{
+		now:     now,
+		changed: make(chan struct{}),
+	}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w := &waiter{at: f.now.Add(d), ch: make(chan time.Time, 1)}
+	if d <= 0 {
+		w.ch <- f.now
+		return w.ch
+	}
+
+	f.add(w)
+	return w.ch
+}
+
+func (f *Fake) Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	ch := f.After(d)
+	select {
+	case <-ctx.Done():
+		f.remove(ch)
+		return ctx.Err()
+	case <-ch:
+		return nil
+	}
+}
+
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w := &waiter{at: f.now.Add(d), period: d, ch: make(chan time.Time, 1)}
+	f.add(w)
+
+	return &fakeTicker{clock: f, w: w}
+}
+
+// Advance moves the time forward and fires timers and tickers due by the new time
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.setLocked(f.now.Add(d))
+}
+
+// Set moves the time to t and fires timers and tickers due by t. Moving backwards fires nothing
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.setLocked(t)
+}
+
+// Waiters returns the number of pending sleeps, After channels and tickers
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.waiters)
+}
+
+// BlockUntil waits until there are at least n pending sleeps, After channels and tickers.
+// It's used to advance the time only after the tested code started waiting.
+func (f *Fake) BlockUntil(n int) {
+	for {
+		f.mu.Lock()
+		if len(f.waiters) >= n {
+			f.mu.Unlock()
+			return
+		}
+		changed := f.changed
+		f.mu.Unlock()
+
+		<-changed
+	}
+}
+
+func (f *Fake) setLocked(t time.Time) {
+	f.now = t
+
+	sort.SliceStable(f.waiters, func(i, j int) bool {
+		return f.waiters[i].at.Before(f.waiters[j].at)
+	})
+
+	kept := f.waiters[:0]
+	for _, w := range f.waiters {
+		if w.at.After(t) {
+			kept = append(kept, w)
+			continue
+		}
+
+		// like time.Ticker, a slow receiver misses ticks instead of getting them all
+		select {
+		case w.ch <- t:
+		default:
+		}
+
+		if w.period > 0 {
+			for !w.at.After(t) {
+				w.at = w.at.Add(w.period)
+			}
+			kept = append(kept, w)
+		}
+	}
+
+	for i := len(kept); i < len(f.waiters); i++ {
+		f.waiters[i] = nil
+	}
+	if len(kept) != len(f.waiters) {
+		f.waiters = kept
+		f.notify()
+	}
+}
+
+func (f *Fake) add(w *waiter) {
+	f.waiters = append(f.waiters, w)
+	f.notify()
+}
+
+func (f *Fake) remove(ch <-chan time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i, w := range f.waiters {
+		if w.ch == ch {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			f.notify()
+			return
+		}
+	}
+}
+
+func (f *Fake) notify() {
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+type fakeTicker struct {
+	clock *Fake
+	w     *waiter
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.w.ch
+}
+
+func (t *fakeTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("non-positive interval for Ticker.Reset")
+	}
+
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	t.w.period = d
+	t.w.at = t.clock.now.Add(d)
+
+	for _, w := range t.clock.waiters {
+		if w == t.w {
+			return
+		}
+	}
+	t.clock.add(t.w)
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.remove(t.w.ch)
+}
@@ -0,0 +1,67 @@
+package infraclock
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFake(start)
+
+	slept := make(chan error, 1)
+	go func() {
+		slept <- clock.Sleep(context.Background(), time.Minute)
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(30 * time.Second)
+	select {
+	case <-slept:
+		t.Fatal("sleep must not finish before its duration")
+	default:
+	}
+
+	clock.Advance(30 * time.Second)
+	if err := <-slept; err != nil {
+		t.Fatal(err)
+	}
+	if !clock.Now().Equal(start.Add(time.Minute)) {
+		t.Fatalf("unexpected now %s", clock.Now())
+	}
+
+	ticker := clock.NewTicker(10 * time.Second)
+	clock.Advance(25 * time.Second)
+	if tick := <-ticker.C(); !tick.Equal(start.Add(85 * time.Second)) {
+		t.Fatalf("unexpected tick %s", tick)
+	}
+	select {
+	case <-ticker.C():
+		t.Fatal("missed ticks must be dropped")
+	default:
+	}
+
+	clock.Advance(5 * time.Second)
+	<-ticker.C()
+
+	ticker.Stop()
+	if clock.Waiters() != 0 {
+		t.Fatalf("stopped ticker must be removed, got %d waiters", clock.Waiters())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := clock.Sleep(ctx, time.Hour); err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if clock.Waiters() != 0 {
+		t.Fatal("cancelled sleep must be removed")
+	}
+
+	select {
+	case <-clock.After(0):
+	default:
+		t.Fatal("After with zero duration must fire at once")
+	}
+}
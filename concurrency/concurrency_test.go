@@ -0,0 +1,131 @@
+package infraconcurrency
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSemaphore(t *testing.T) {
+	sem := NewSemaphore("test", 10)
+	ctx := context.Background()
+
+	if err := sem.Acquire(ctx, 8); err != nil {
+		t.Fatal(err)
+	}
+	if sem.TryAcquire(3) {
+		t.Fatal("weight over the size must not be acquired")
+	}
+	if err := sem.Acquire(ctx, 11); err == nil {
+		t.Fatal("weight greater than the size must be rejected")
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := sem.Acquire(timeoutCtx, 5); err == nil {
+		t.Fatal("expected ctx error")
+	}
+
+	sem.Release(8)
+	if !sem.TryAcquire(10) {
+		t.Fatal("expected the whole semaphore to be free")
+	}
+}
+
+func TestKeyedLimiter(t *testing.T) {
+	limiter := NewKeyedLimiter("test", 2)
+	ctx := context.Background()
+
+	release1, _ := limiter.Acquire(ctx, "a")
+	release2, _ := limiter.Acquire(ctx, "a")
+	if _, ok := limiter.TryAcquire("a"); ok {
+		t.Fatal("key a must be full")
+	}
+
+	releaseB, ok := limiter.TryAcquire("b")
+	if !ok {
+		t.Fatal("key b must be free")
+	}
+	releaseB()
+
+	release1()
+	release1() // release is idempotent
+	if _, ok = limiter.TryAcquire("a"); !ok {
+		t.Fatal("key a must have a free slot")
+	}
+
+	release2()
+}
+
+func TestKeyedMutex(t *testing.T) {
+	mu := NewKeyedMutex("test")
+	ctx := context.Background()
+
+	var inside, maxInside atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			unlock, err := mu.Lock(ctx, "key")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer unlock()
+
+			n := inside.Add(1)
+			if n > maxInside.Load() {
+				maxInside.Store(n)
+			}
+			time.Sleep(time.Millisecond)
+			inside.Add(-1)
+		}()
+	}
+	wg.Wait()
+
+	if maxInside.Load() != 1 {
+		t.Fatalf("expected exclusive access, got %d holders", maxInside.Load())
+	}
+	if mu.limiter.Keys() != 0 {
+		t.Fatalf("expected released keys to be removed, got %d", mu.limiter.Keys())
+	}
+}
+
+func TestKeyedSingleflight(t *testing.T) {
+	var sf KeyedSingleflight[int]
+	var calls atomic.Int32
+	started := make(chan struct{})
+	finish := make(chan struct{})
+
+	go func() {
+		_, _, _ = sf.Do(context.Background(), "key", func(context.Context) (int, error) {
+			calls.Add(1)
+			close(started)
+			<-finish
+			return 42, nil
+		})
+	}()
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := sf.Do(ctx, "key", nil); err == nil {
+		t.Fatal("expected ctx error of the canceled waiter")
+	}
+
+	done := make(chan int)
+	go func() {
+		value, _, _ := sf.Do(context.Background(), "key", nil)
+		done <- value
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(finish)
+
+	if value := <-done; value != 42 || calls.Load() != 1 {
+		t.Fatalf("expected the shared result, got %d and %d calls", value, calls.Load())
+	}
+}
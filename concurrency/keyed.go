@@ -0,0 +1,138 @@
+package infraconcurrency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type keyedEntry struct {
+	slots chan struct{}
+	refs  int
+}
+
+// KeyedLimiter limits the number of concurrent calls per key, e.g. per tenant or per queue.
+// Keys are tracked only while they are held or waited for, so the number of keys is unbounded:
+//
+//	limiter := infraconcurrency.NewKeyedLimiter("tenant exports", 2)
+//	release, err := limiter.Acquire(ctx, tenant)
+//	if err != nil { ... }
+//	defer release()
+type KeyedLimiter struct {
+	name  string
+	limit int
+
+	mu      sync.Mutex
+	entries map[string]*keyedEntry
+}
+
+// NewKeyedLimiter creates a limiter allowing limit concurrent holders of every key, name is used as a metrics label
+func NewKeyedLimiter(name string, limit int) *KeyedLimiter {
+	initMetrics()
+
+	return &KeyedLimiter{
+		name:    name,
+		limit:   max(limit, 1),
+		entries: make(map[string]*keyedEntry),
+	}
+}
+
+// Acquire blocks until the key has a free slot or ctx is done. release must be called once the work is done
+func (l *KeyedLimiter) Acquire(ctx context.Context, key string) (release func(), err error) {
+	e := l.ref(key)
+
+	start := time.Now()
+	select {
+	case e.slots <- struct{}{}:
+	case <-ctx.Done():
+		l.unref(key, e)
+		return nil, ctx.Err()
+	}
+	metrics.WaitDuration.WithLabelValues(l.name).Observe(time.Since(start).Seconds())
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			<-e.slots
+			l.unref(key, e)
+		})
+	}, nil
+}
+
+// TryAcquire takes a slot of the key without blocking, ok is false if there is no free slot
+func (l *KeyedLimiter) TryAcquire(key string) (release func(), ok bool) {
+	e := l.ref(key)
+
+	select {
+	case e.slots <- struct{}{}:
+	default:
+		l.unref(key, e)
+		return nil, false
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			<-e.slots
+			l.unref(key, e)
+		})
+	}, true
+}
+
+// Keys returns the number of keys held or waited for
+func (l *KeyedLimiter) Keys() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.entries)
+}
+
+func (l *KeyedLimiter) ref(key string) *keyedEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.entries[key]
+	if !ok {
+		e = &keyedEntry{slots: make(chan struct{}, l.limit)}
+		l.entries[key] = e
+		metrics.KeysGauge.WithLabelValues(l.name).Set(float64(len(l.entries)))
+	}
+	e.refs++
+
+	return e
+}
+
+func (l *KeyedLimiter) unref(key string, e *keyedEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e.refs--
+	if e.refs == 0 {
+		delete(l.entries, key)
+		metrics.KeysGauge.WithLabelValues(l.name).Set(float64(len(l.entries)))
+	}
+}
+
+// KeyedMutex is a mutex per key, it's a KeyedLimiter with the limit of 1:
+//
+//	mu := infraconcurrency.NewKeyedMutex("device updates")
+//	unlock, err := mu.Lock(ctx, deviceID)
+//	if err != nil { ... }
+//	defer unlock()
+type KeyedMutex struct {
+	limiter *KeyedLimiter
+}
+
+func NewKeyedMutex(name string) *KeyedMutex {
+	return &KeyedMutex{limiter: NewKeyedLimiter(name, 1)}
+}
+
+// Lock blocks until the key is unlocked or ctx is done
+func (m *KeyedMutex) Lock(ctx context.Context, key string) (unlock func(), err error) {
+	return m.limiter.Acquire(ctx, key)
+}
+
+// TryLock locks the key if it isn't locked
+func (m *KeyedMutex) TryLock(key string) (unlock func(), ok bool) {
+	return m.limiter.TryAcquire(key)
+}
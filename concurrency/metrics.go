@@ -0,0 +1,41 @@
+package infraconcurrency
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	WaitDuration *prometheus.HistogramVec
+	InUseGauge   *prometheus.GaugeVec
+	KeysGauge    *prometheus.GaugeVec
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.WaitDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "concurrency_acquire_wait_seconds",
+			Help:    "Time spent waiting for a semaphore, a keyed mutex or a keyed limiter",
+			Buckets: []float64{0.0001, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10},
+		}, []string{"name"})
+
+		metrics.InUseGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "concurrency_semaphore_in_use",
+			Help: "Acquired weight of a semaphore",
+		}, []string{"name"})
+
+		metrics.KeysGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "concurrency_active_keys",
+			Help: "Number of keys held or waited for by a keyed mutex or a keyed limiter",
+		}, []string{"name"})
+
+		prometheus.MustRegister(
+			metrics.WaitDuration,
+			metrics.InUseGauge,
+			metrics.KeysGauge,
+		)
+	})
+}
@@ -0,0 +1,75 @@
+package infraconcurrency
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sync/semaphore"
+)
+
+// Semaphore is a weighted semaphore with acquisition wait metrics. Waiters are served in FIFO order,
+// so a heavy acquisition isn't starved by light ones:
+//
+//	sem := infraconcurrency.NewSemaphore("exports", 64<<20) // 64MiB of buffers
+//	if err := sem.Acquire(ctx, int64(len(buf))); err != nil { ... }
+//	defer sem.Release(int64(len(buf)))
+type Semaphore struct {
+	name string
+	size int64
+	sem  *semaphore.Weighted
+	used atomic.Int64
+}
+
+// NewSemaphore creates a semaphore of the size, name is used as a metrics label
+func NewSemaphore(name string, size int64) *Semaphore {
+	initMetrics()
+
+	return &Semaphore{
+		name: name,
+		size: size,
+		sem:  semaphore.NewWeighted(size),
+	}
+}
+
+// Acquire takes weight n blocking until it's available or ctx is done.
+// Returns an error at once if n is greater than the semaphore size
+func (s *Semaphore) Acquire(ctx context.Context, n int64) error {
+	if n > s.size {
+		return errors.Errorf("weight %d is greater than semaphore size %d", n, s.size)
+	}
+
+	start := time.Now()
+	if err := s.sem.Acquire(ctx, n); err != nil {
+		return err
+	}
+	metrics.WaitDuration.WithLabelValues(s.name).Observe(time.Since(start).Seconds())
+	s.used.Add(n)
+	metrics.InUseGauge.WithLabelValues(s.name).Add(float64(n))
+
+	return nil
+}
+
+// TryAcquire takes weight n without blocking, returns false if it's not available
+func (s *Semaphore) TryAcquire(n int64) bool {
+	if !s.sem.TryAcquire(n) {
+		return false
+	}
+	s.used.Add(n)
+	metrics.InUseGauge.WithLabelValues(s.name).Add(float64(n))
+
+	return true
+}
+
+// Release returns weight n taken by Acquire or TryAcquire
+func (s *Semaphore) Release(n int64) {
+	s.used.Add(-n)
+	s.sem.Release(n)
+	metrics.InUseGauge.WithLabelValues(s.name).Sub(float64(n))
+}
+
+// InUse returns the acquired weight
+func (s *Semaphore) InUse() int64 {
+	return s.used.Load()
+}
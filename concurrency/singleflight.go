@@ -0,0 +1,37 @@
+package infraconcurrency
+
+import (
+	"context"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// KeyedSingleflight deduplicates concurrent calls with the same key: the first caller runs fn,
+// the others wait for its result. fn runs with a context that isn't canceled when the first caller gives up,
+// while every caller stops waiting when its own ctx is done
+type KeyedSingleflight[T any] struct {
+	group singleflight.Group
+}
+
+// Do runs fn once for concurrent calls of the key. shared is true if the result is given to several callers
+func (s *KeyedSingleflight[T]) Do(ctx context.Context, key string, fn func(ctx context.Context) (T, error)) (value T, shared bool, err error) {
+	ch := s.group.DoChan(key, func() (interface{}, error) {
+		return fn(context.WithoutCancel(ctx))
+	})
+
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return value, res.Shared, res.Err
+		}
+		value, _ = res.Val.(T)
+		return value, res.Shared, nil
+	case <-ctx.Done():
+		return value, false, ctx.Err()
+	}
+}
+
+// Forget makes the next Do of the key run fn even if a call is in progress
+func (s *KeyedSingleflight[T]) Forget(key string) {
+	s.group.Forget(key)
+}
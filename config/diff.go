@@ -0,0 +1,89 @@
+package infraconfig
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Diff returns sorted paths of config fields that differ between a and b.
+// Paths are built of mapstructure tag names, e.g. "rabbit.main.prefetch_count".
+// Nested structs and maps are compared field by field, other values are compared as a whole.
+func Diff(a, b interface{}) []string {
+	var paths []string
+	diffValues(reflect.ValueOf(a), reflect.ValueOf(b), "", &paths)
+	sort.Strings(paths)
+	return paths
+}
+
+// Changed reports whether path or any of its nested fields is in the diff
+func Changed(diff []string, path string) bool {
+	for _, p := range diff {
+		if p == path || strings.HasPrefix(p, path+".") || strings.HasPrefix(path, p+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func diffValues(a, b reflect.Value, path string, paths *[]string) {
+	a, b = indirect(a), indirect(b)
+
+	if !a.IsValid() || !b.IsValid() {
+		if a.IsValid() != b.IsValid() {
+			*paths = append(*paths, path)
+		}
+		return
+	}
+
+	if a.Type() != b.Type() {
+		*paths = append(*paths, path)
+		return
+	}
+
+	switch a.Kind() {
+	case reflect.Struct:
+		t := a.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			name := field.Name
+			if tag, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ","); tag != "" {
+				name = tag
+			}
+
+			diffValues(a.Field(i), b.Field(i), joinPath(path, name), paths)
+		}
+	case reflect.Map:
+		keys := make(map[interface{}]reflect.Value)
+		for _, k := range a.MapKeys() {
+			keys[k.Interface()] = k
+		}
+		for _, k := range b.MapKeys() {
+			keys[k.Interface()] = k
+		}
+
+		for _, k := range keys {
+			diffValues(a.MapIndex(k), b.MapIndex(k), joinPath(path, fmt.Sprint(k.Interface())), paths)
+		}
+	default:
+		if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+			*paths = append(*paths, path)
+		}
+	}
+}
+
+// indirect dereferences pointers and interfaces, nil pointer becomes invalid value
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
@@ -0,0 +1,200 @@
+package infraconfig
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvSeparator separates nested keys in environment variable names:
+//
+//	APP__POSTGRES__MAIN__MAX_CONNECTIONS=10
+//
+// overrides "postgres.main.max_connections" when loaded with WithEnvPrefix("APP").
+const EnvSeparator = "__"
+
+// Validator is implemented by config structs that validate themselves
+type Validator interface {
+	Validate() error
+}
+
+// Load reads config file and decodes it into dst.
+// dst must be a pointer to a struct, values already set in dst are used as defaults:
+//
+//	cfg := &AppConfig{Postgres: infrapostgres.ConnectionsConfig{}, HTTP: infrahttp.DefaultConfig()}
+//	err := infraconfig.Load("config.yaml", cfg, infraconfig.WithEnvPrefix("APP"))
+//
+// File format is chosen by extension: ".json" is parsed as JSON, anything else as YAML.
+// Empty path means there is no file and only environment is used.
+// After decoding, fields tagged with `required:"true"` are checked and dst.Validate() is called if implemented.
+//
+// With a profile, layers are applied in order: profile defaults of infra packages, the base file,
+// the profile file next to it if it exists ("config.prod.yaml" for "config.yaml") and environment:
+//
+//	err := infraconfig.Load("config.yaml", cfg, infraconfig.WithEnvPrefix("APP"), infraconfig.WithProfileFromEnv())
+func Load(path string, dst interface{}, opts ...Option) error {
+	o := newOptions(opts)
+
+	raw := make(map[string]interface{})
+	if path != "" {
+		var err error
+		if raw, err = readFile(path); err != nil {
+			return err
+		}
+
+		if o.profile != "" {
+			profileRaw, err := readFile(profileFile(path, o.profile))
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			merge(raw, profileRaw)
+		}
+	}
+
+	return decode(raw, dst, o)
+}
+
+// LoadBytes decodes config from data of the given format ("yaml" or "json") into dst.
+// See Load for details.
+func LoadBytes(format string, data []byte, dst interface{}, opts ...Option) error {
+	raw, err := parse("."+format, data)
+	if err != nil {
+		return errors.Wrap(err, "unable to parse config")
+	}
+
+	return decode(raw, dst, newOptions(opts))
+}
+
+func readFile(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read config file")
+	}
+
+	raw, err := parse(path, data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to parse config file %s", path)
+	}
+
+	return raw, nil
+}
+
+// merge puts values of src into dst, nested maps are merged recursively
+func merge(dst, src map[string]interface{}) {
+	for key, value := range src {
+		srcMap, ok := value.(map[string]interface{})
+		dstMap, dstOk := dst[key].(map[string]interface{})
+		if ok && dstOk {
+			merge(dstMap, srcMap)
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+func parse(path string, data []byte) (map[string]interface{}, error) {
+	raw := make(map[string]interface{})
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+	default:
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+	}
+
+	return raw, nil
+}
+
+func decode(raw map[string]interface{}, dst interface{}, o *options) error {
+	if o.envPrefix != "" {
+		applyEnv(raw, o.envPrefix, os.Environ())
+	}
+
+	if err := resolveSecrets(raw, o.secretResolvers); err != nil {
+		return err
+	}
+
+	hooks := append([]mapstructure.DecodeHookFunc{
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+		StringToByteSizeHookFunc(),
+	}, o.hooks...)
+
+	if o.profile != "" {
+		applyProfileDefaults(o.profile, dst)
+		hooks = append(hooks, profileDefaultsHookFunc(o.profile))
+	}
+
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.ComposeDecodeHookFunc(hooks...),
+		ErrorUnused:      o.strict,
+		WeaklyTypedInput: true,
+		Result:           dst,
+	})
+	if err != nil {
+		return errors.Wrap(err, "mapstructure.NewDecoder")
+	}
+
+	if err = decoder.Decode(raw); err != nil {
+		return errors.Wrap(err, "unable to decode config")
+	}
+
+	if err = checkRequired(dst); err != nil {
+		return err
+	}
+
+	if o.profile != "" {
+		if err = checkProfile(o.profile, dst); err != nil {
+			return errors.Wrap(err, "invalid config")
+		}
+	}
+
+	if v, ok := dst.(Validator); ok {
+		if err = v.Validate(); err != nil {
+			return errors.Wrap(err, "invalid config")
+		}
+	}
+
+	return nil
+}
+
+// applyEnv puts environment variables with the prefix into raw config.
+// Keys are lowercased, so environment can override only lowercase config keys.
+func applyEnv(raw map[string]interface{}, prefix string, environ []string) {
+	prefix += EnvSeparator
+
+	for _, kv := range environ {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(key, prefix) {
+			continue
+		}
+
+		path := strings.Split(strings.ToLower(strings.TrimPrefix(key, prefix)), EnvSeparator)
+		setPath(raw, path, value)
+	}
+}
+
+func setPath(m map[string]interface{}, path []string, value interface{}) {
+	for i, key := range path {
+		if i == len(path)-1 {
+			m[key] = value
+			return
+		}
+
+		next, ok := m[key].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			m[key] = next
+		}
+		m = next
+	}
+}
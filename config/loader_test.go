@@ -0,0 +1,200 @@
+package infraconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type testConnectionConfig struct {
+	Host           string        `mapstructure:"host" required:"true"`
+	MaxConnections int           `mapstructure:"max_connections"`
+	Timeout        time.Duration `mapstructure:"timeout"`
+	BufferSize     ByteSize      `mapstructure:"buffer_size"`
+	Password       string        `mapstructure:"password"`
+}
+
+type testConfig struct {
+	Name        string                           `mapstructure:"name"`
+	Connections map[string]*testConnectionConfig `mapstructure:"connections"`
+}
+
+const testYAML = `
+name: app
+connections:
+  main:
+    host: localhost
+    max_connections: 5
+    timeout: 3s
+    buffer_size: 4KB
+    password: ${env:INFRACONFIG_TEST_PASSWORD}
+`
+
+func TestLoadBytes(t *testing.T) {
+	t.Setenv("INFRACONFIG_TEST_PASSWORD", "secret")
+	t.Setenv("APP__CONNECTIONS__MAIN__MAX_CONNECTIONS", "10")
+	t.Setenv("APP__NAME", "overridden")
+
+	cfg := &testConfig{}
+	if err := LoadBytes("yaml", []byte(testYAML), cfg, WithEnvPrefix("APP")); err != nil {
+		t.Fatal(err)
+	}
+
+	main := cfg.Connections["main"]
+	if cfg.Name != "overridden" {
+		t.Errorf("expected name to be overridden, got %q", cfg.Name)
+	}
+	if main.MaxConnections != 10 {
+		t.Errorf("expected max_connections 10, got %d", main.MaxConnections)
+	}
+	if main.Timeout != 3*time.Second {
+		t.Errorf("expected timeout 3s, got %s", main.Timeout)
+	}
+	if main.BufferSize != 4*Kilobyte {
+		t.Errorf("expected buffer_size 4096, got %d", main.BufferSize)
+	}
+	if main.Password != "secret" {
+		t.Errorf("expected resolved password, got %q", main.Password)
+	}
+}
+
+func TestLoadBytes_required(t *testing.T) {
+	cfg := &testConfig{}
+	err := LoadBytes("json", []byte(`{"connections": {"main": {"max_connections": 1}}}`), cfg)
+	if err == nil || err.Error() != "connections.main.host is required" {
+		t.Errorf("expected required error, got %v", err)
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := map[string]ByteSize{
+		"100":    100,
+		"1KB":    Kilobyte,
+		"1.5 MB": Megabyte + Megabyte/2,
+		"2GiB":   2 * Gigabyte,
+	}
+
+	for in, expected := range tests {
+		got, err := ParseByteSize(in)
+		if err != nil {
+			t.Errorf("%s: %v", in, err)
+			continue
+		}
+		if got != expected {
+			t.Errorf("%s: expected %d, got %d", in, expected, got)
+		}
+	}
+
+	if _, err := ParseByteSize("1XB"); err == nil {
+		t.Error("expected error for invalid unit")
+	}
+}
+
+func TestResolveSecret(t *testing.T) {
+	t.Setenv("INFRACONFIG_TEST_PASSWORD", "secret")
+	RegisterSecretResolver("test", func(ref string) (string, error) {
+		return "resolved " + ref, nil
+	})
+
+	tests := map[string]string{
+		"plain":                            "plain",
+		"${env:INFRACONFIG_TEST_PASSWORD}": "secret",
+		"${test:kv/path#key}":              "resolved kv/path#key",
+		"$${test:kv/path#key}":             "${test:kv/path#key}",
+		"amqp://host:5672":                 "amqp://host:5672",
+	}
+
+	for in, expected := range tests {
+		got, err := ResolveSecret(in)
+		if err != nil {
+			t.Errorf("%s: %v", in, err)
+			continue
+		}
+		if got != expected {
+			t.Errorf("%s: expected %q, got %q", in, expected, got)
+		}
+	}
+
+	if _, err := ResolveSecret("${env:INFRACONFIG_TEST_UNSET}"); err == nil {
+		t.Error("expected error for unset variable")
+	}
+	if _, err := ResolveSecret("${unknown:ref}"); err == nil {
+		t.Error("expected error for unknown scheme")
+	}
+}
+
+func TestLoad_profile(t *testing.T) {
+	RegisterProfileDefaults("test", func(c *testConnectionConfig) {
+		c.MaxConnections = 20
+		c.Timeout = time.Second
+	})
+	RegisterProfileCheck("test", func(c *testConnectionConfig) error {
+		if c.Host == "localhost" {
+			return errors.New("localhost is not allowed")
+		}
+		return nil
+	})
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte(testYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("INFRACONFIG_TEST_PASSWORD", "secret")
+
+	// the base file is checked by the profile
+	if err := Load(path, &testConfig{}, WithProfile("test")); err == nil || !strings.Contains(err.Error(), "localhost") {
+		t.Fatalf("expected profile check error, got %v", err)
+	}
+
+	profileYAML := "connections:\n  main:\n    host: db.internal\n  replica:\n    host: replica.internal\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.test.yaml"), []byte(profileYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(ProfileEnv, "test")
+	t.Setenv("APP__CONNECTIONS__REPLICA__MAX_CONNECTIONS", "3")
+
+	cfg := &testConfig{}
+	if err := Load(path, cfg, WithEnvPrefix("APP"), WithProfileFromEnv()); err != nil {
+		t.Fatal(err)
+	}
+
+	main, replica := cfg.Connections["main"], cfg.Connections["replica"]
+	if main.Host != "db.internal" || main.MaxConnections != 5 || main.Timeout != 3*time.Second {
+		t.Errorf("expected profile file over base file, got %+v", main)
+	}
+	if replica.MaxConnections != 3 || replica.Timeout != time.Second {
+		t.Errorf("expected profile defaults under environment, got %+v", replica)
+	}
+}
+
+func TestRegisterProfileDefaults_concurrent(t *testing.T) {
+	type section struct {
+		Calls int
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			RegisterProfileDefaults("concurrent", func(c *section) { c.Calls++ })
+		}()
+		go func() {
+			defer wg.Done()
+			applyProfileDefaults("concurrent", &struct{ Section section }{})
+		}()
+	}
+	wg.Wait()
+
+	cfg := &struct{ Section section }{}
+	applyProfileDefaults("concurrent", cfg)
+	if cfg.Section.Calls != 4 {
+		t.Fatalf("expected 4 defaults applied, got %d", cfg.Section.Calls)
+	}
+}
@@ -0,0 +1,94 @@
+package infraconfig
+
+import (
+	"github.com/mitchellh/mapstructure"
+)
+
+type options struct {
+	envPrefix       string
+	profile         string
+	strict          bool
+	hooks           []mapstructure.DecodeHookFunc
+	secretResolvers map[string]SecretResolver
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		secretResolvers: registeredSecretResolvers(),
+	}
+
+	for _, opt := range opts {
+		opt.apply(o)
+	}
+
+	return o
+}
+
+type Option interface {
+	apply(o *options)
+}
+
+type optionWithEnvPrefix string
+
+func (opt optionWithEnvPrefix) apply(o *options) {
+	o.envPrefix = string(opt)
+}
+
+// WithEnvPrefix enables environment overrides. See EnvSeparator for naming rules.
+func WithEnvPrefix(prefix string) Option {
+	return optionWithEnvPrefix(prefix)
+}
+
+type optionStrict struct{}
+
+func (opt optionStrict) apply(o *options) {
+	o.strict = true
+}
+
+// Strict makes Load fail when config contains keys that don't match any field
+func Strict() Option {
+	return optionStrict{}
+}
+
+type optionWithDecodeHook struct {
+	hook mapstructure.DecodeHookFunc
+}
+
+func (opt optionWithDecodeHook) apply(o *options) {
+	o.hooks = append(o.hooks, opt.hook)
+}
+
+// WithDecodeHook adds custom mapstructure decode hook, e.g. infragrpcclient.StringToCodeHookFunc()
+func WithDecodeHook(hook mapstructure.DecodeHookFunc) Option {
+	return optionWithDecodeHook{hook: hook}
+}
+
+type optionWithSecretResolver struct {
+	scheme   string
+	resolver SecretResolver
+}
+
+func (opt optionWithSecretResolver) apply(o *options) {
+	o.secretResolvers[opt.scheme] = opt.resolver
+}
+
+// WithSecretResolver registers resolver for "${scheme:ref}" values of one Load, see RegisterSecretResolver
+func WithSecretResolver(scheme string, resolver SecretResolver) Option {
+	return optionWithSecretResolver{scheme: scheme, resolver: resolver}
+}
+
+type optionWithProfile string
+
+func (opt optionWithProfile) apply(o *options) {
+	o.profile = string(opt)
+}
+
+// WithProfile enables profile defaults, checks and the profile file, see Load
+func WithProfile(profile string) Option {
+	return optionWithProfile(profile)
+}
+
+// WithProfileFromEnv is WithProfile with the profile selected by ProfileEnv. Nothing is enabled if it's not set
+func WithProfileFromEnv() Option {
+	return optionWithProfile(CurrentProfile())
+}
@@ -0,0 +1,203 @@
+package infraconfig
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+)
+
+// ProfileEnv is the environment variable selecting a profile, see WithProfileFromEnv
+const ProfileEnv = "APP_ENV"
+
+const (
+	ProfileDev   = "dev"
+	ProfileStage = "stage"
+	ProfileProd  = "prod"
+)
+
+type profileHooks struct {
+	defaults []func(v interface{})
+	checks   []func(v interface{}) error
+}
+
+var profiles = struct {
+	mu    sync.RWMutex
+	hooks map[string]map[reflect.Type]*profileHooks
+}{
+	hooks: make(map[string]map[reflect.Type]*profileHooks),
+}
+
+func init() {
+	RegisterProfileDefaults(ProfileDev, func(c *infralog.Config) {
+		c.Environment = infralog.EnvironmentDevelopment
+		c.Level = "debug"
+	})
+}
+
+// CurrentProfile returns the profile selected by ProfileEnv, empty if it's not set
+func CurrentProfile() string {
+	return os.Getenv(ProfileEnv)
+}
+
+// RegisterProfileDefaults registers defaults of config sections of type T in the profile. Infra packages
+// register their own defaults, e.g. lazy connections in dev. Defaults are applied to every T found in
+// the config before decoding, so values from files and environment override them
+func RegisterProfileDefaults[T any](profile string, fn func(*T)) {
+	profiles.mu.Lock()
+	defer profiles.mu.Unlock()
+
+	hooks := profileHooksOf[T](profile)
+	hooks.defaults = append(hooks.defaults, func(v interface{}) { fn(v.(*T)) })
+}
+
+// RegisterProfileCheck registers a check of config sections of type T in the profile,
+// e.g. insecure TLS is rejected in prod. Checks are run after decoding, before Validate
+func RegisterProfileCheck[T any](profile string, fn func(*T) error) {
+	profiles.mu.Lock()
+	defer profiles.mu.Unlock()
+
+	hooks := profileHooksOf[T](profile)
+	hooks.checks = append(hooks.checks, func(v interface{}) error { return fn(v.(*T)) })
+}
+
+// profileHooksOf returns hooks of T in the profile, profiles.mu must be held
+func profileHooksOf[T any](profile string) *profileHooks {
+	types, ok := profiles.hooks[profile]
+	if !ok {
+		types = make(map[reflect.Type]*profileHooks)
+		profiles.hooks[profile] = types
+	}
+
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	hooks, ok := types[t]
+	if !ok {
+		hooks = &profileHooks{}
+		types[t] = hooks
+	}
+
+	return hooks
+}
+
+// lookupProfileHooks returns a copy of hooks of t in the profile, so they are run without holding profiles.mu
+func lookupProfileHooks(profile string, t reflect.Type) *profileHooks {
+	profiles.mu.RLock()
+	defer profiles.mu.RUnlock()
+
+	hooks, ok := profiles.hooks[profile][t]
+	if !ok {
+		return nil
+	}
+
+	return &profileHooks{
+		defaults: slices.Clone(hooks.defaults),
+		checks:   slices.Clone(hooks.checks),
+	}
+}
+
+// profileFile returns the path of the profile file next to the base one: config.yaml -> config.prod.yaml
+func profileFile(path, profile string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + profile + ext
+}
+
+// applyProfileDefaults sets profile defaults of sections already present in dst
+func applyProfileDefaults(profile string, dst interface{}) {
+	_ = walkSections(reflect.ValueOf(dst), func(section reflect.Value) error {
+		if hooks := lookupProfileHooks(profile, section.Type().Elem()); hooks != nil {
+			for _, fn := range hooks.defaults {
+				fn(section.Interface())
+			}
+		}
+		return nil
+	})
+}
+
+// profileDefaultsHookFunc sets profile defaults of sections created while decoding, e.g. values of maps
+func profileDefaultsHookFunc(profile string) mapstructure.DecodeHookFuncValue {
+	return func(from reflect.Value, to reflect.Value) (interface{}, error) {
+		if to.Kind() == reflect.Struct && to.CanAddr() {
+			if hooks := lookupProfileHooks(profile, to.Type()); hooks != nil {
+				for _, fn := range hooks.defaults {
+					fn(to.Addr().Interface())
+				}
+			}
+		}
+		return from.Interface(), nil
+	}
+}
+
+func checkProfile(profile string, dst interface{}) error {
+	return walkSections(reflect.ValueOf(dst), func(section reflect.Value) error {
+		hooks := lookupProfileHooks(profile, section.Type().Elem())
+		if hooks == nil {
+			return nil
+		}
+
+		for _, fn := range hooks.checks {
+			if err := fn(section.Interface()); err != nil {
+				return errors.Wrapf(err, "%s profile", profile)
+			}
+		}
+		return nil
+	})
+}
+
+// walkSections calls fn with pointers to all structs reachable from v through exported fields,
+// pointers, maps and slices
+func walkSections(v reflect.Value, fn func(section reflect.Value) error) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return walkSections(v.Elem(), fn)
+	case reflect.Struct:
+		if v.CanAddr() {
+			if err := fn(v.Addr()); err != nil {
+				return err
+			}
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				if err := walkSections(v.Field(i), fn); err != nil {
+					return err
+				}
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := walkSections(v.Index(i), fn); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			value := iter.Value()
+			if value.Kind() != reflect.Struct {
+				if err := walkSections(value, fn); err != nil {
+					return err
+				}
+				continue
+			}
+
+			// struct values of maps aren't addressable, they are walked as copies
+			elem := reflect.New(value.Type()).Elem()
+			elem.Set(value)
+			if err := walkSections(elem, fn); err != nil {
+				return err
+			}
+			v.SetMapIndex(iter.Key(), elem)
+		}
+	default:
+	}
+
+	return nil
+}
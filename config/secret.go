@@ -0,0 +1,141 @@
+package infraconfig
+
+import (
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// SecretResolver returns secret value by reference
+type SecretResolver func(ref string) (string, error)
+
+var secretResolvers = struct {
+	mu sync.RWMutex
+	m  map[string]SecretResolver
+}{
+	m: map[string]SecretResolver{
+		"env":  EnvSecretResolver,
+		"file": FileSecretResolver,
+	},
+}
+
+// RegisterSecretResolver registers resolver for "${scheme:ref}" values of every loaded config and of ResolveSecret:
+//
+//	infraconfig.RegisterSecretResolver("vault", secrets.Resolver())
+//
+// env and file are registered by default. WithSecretResolver registers a resolver for one Load only.
+func RegisterSecretResolver(scheme string, resolver SecretResolver) {
+	secretResolvers.mu.Lock()
+	defer secretResolvers.mu.Unlock()
+
+	secretResolvers.m[scheme] = resolver
+}
+
+// registeredSecretResolvers returns a copy of registered resolvers
+func registeredSecretResolvers() map[string]SecretResolver {
+	secretResolvers.mu.RLock()
+	defer secretResolvers.mu.RUnlock()
+
+	resolvers := make(map[string]SecretResolver, len(secretResolvers.m))
+	for scheme, resolver := range secretResolvers.m {
+		resolvers[scheme] = resolver
+	}
+
+	return resolvers
+}
+
+// ResolveSecret resolves a secret reference like ${env:NAME}, ${file:/path} or ${vault:kv/path#key}
+// with registered resolvers. Plain values are returned as is.
+//
+// Load resolves references once. Connection containers and clients resolve credentials with ResolveSecret
+// on every connect, so rotated secrets are picked up on reconnect when the reference reaches them unresolved:
+// "$${vault:kv/path#key}" is loaded as "${vault:kv/path#key}".
+func ResolveSecret(s string) (string, error) {
+	return resolveSecret(s, registeredSecretResolvers())
+}
+
+// EnvSecretResolver resolves "${env:NAME}" to the value of environment variable NAME
+func EnvSecretResolver(ref string) (string, error) {
+	value, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", errors.Errorf("environment variable %s is not set", ref)
+	}
+	return value, nil
+}
+
+// FileSecretResolver resolves "${file:/path}" to the file content without trailing newlines
+func FileSecretResolver(ref string) (string, error) {
+	data, err := os.ReadFile(ref)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// resolveSecrets replaces string values of form "${scheme:ref}" with resolved secrets
+func resolveSecrets(raw interface{}, resolvers map[string]SecretResolver) error {
+	switch v := raw.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if s, ok := value.(string); ok {
+				resolved, err := resolveSecret(s, resolvers)
+				if err != nil {
+					return errors.Wrap(err, key)
+				}
+				v[key] = resolved
+				continue
+			}
+
+			if err := resolveSecrets(value, resolvers); err != nil {
+				return errors.Wrap(err, key)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			if s, ok := value.(string); ok {
+				resolved, err := resolveSecret(s, resolvers)
+				if err != nil {
+					return err
+				}
+				v[i] = resolved
+				continue
+			}
+
+			if err := resolveSecrets(value, resolvers); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func resolveSecret(s string, resolvers map[string]SecretResolver) (string, error) {
+	// escaped reference is kept for ResolveSecret
+	if strings.HasPrefix(s, "$${") && strings.HasSuffix(s, "}") {
+		return s[1:], nil
+	}
+
+	if !strings.HasPrefix(s, "${") || !strings.HasSuffix(s, "}") {
+		return s, nil
+	}
+
+	scheme, ref, ok := strings.Cut(s[2:len(s)-1], ":")
+	if !ok {
+		return s, nil
+	}
+
+	resolver, ok := resolvers[scheme]
+	if !ok {
+		return "", errors.Errorf("unknown secret scheme: %s", scheme)
+	}
+
+	value, err := resolver(ref)
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to resolve secret %s", s)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,137 @@
+package infraconfig
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+// ByteSize is a size in bytes that can be set in config as a number or with a unit suffix: "512KB", "10MiB", "1G"
+type ByteSize int64
+
+const (
+	Byte     ByteSize = 1
+	Kilobyte          = 1024 * Byte
+	Megabyte          = 1024 * Kilobyte
+	Gigabyte          = 1024 * Megabyte
+	Terabyte          = 1024 * Gigabyte
+)
+
+var byteSizeUnits = map[string]ByteSize{
+	"":    Byte,
+	"B":   Byte,
+	"K":   Kilobyte,
+	"KB":  Kilobyte,
+	"KIB": Kilobyte,
+	"M":   Megabyte,
+	"MB":  Megabyte,
+	"MIB": Megabyte,
+	"G":   Gigabyte,
+	"GB":  Gigabyte,
+	"GIB": Gigabyte,
+	"T":   Terabyte,
+	"TB":  Terabyte,
+	"TIB": Terabyte,
+}
+
+// ParseByteSize parses size string. Units are binary: 1KB = 1024 bytes.
+func ParseByteSize(s string) (ByteSize, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+
+	number, unit := s[:i], strings.ToUpper(strings.TrimSpace(s[i:]))
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, errors.Errorf("invalid size: %q", s)
+	}
+
+	multiplier, ok := byteSizeUnits[unit]
+	if !ok {
+		return 0, errors.Errorf("invalid size unit: %q", s)
+	}
+
+	return ByteSize(value * float64(multiplier)), nil
+}
+
+// StringToByteSizeHookFunc returns a mapstructure hook that converts strings to ByteSize
+func StringToByteSizeHookFunc() mapstructure.DecodeHookFuncType {
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if f.Kind() != reflect.String || t != reflect.TypeOf(ByteSize(0)) {
+			return data, nil
+		}
+
+		return ParseByteSize(data.(string))
+	}
+}
+
+// checkRequired checks that fields tagged with `required:"true"` have non-zero values.
+// Nested structs are checked recursively.
+func checkRequired(v interface{}) error {
+	return checkRequiredValue(reflect.ValueOf(v), "")
+}
+
+func checkRequiredValue(v reflect.Value, path string) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			name := field.Name
+			if tag, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ","); tag != "" {
+				name = tag
+			}
+			name = joinPath(path, name)
+
+			if field.Tag.Get("required") == "true" && v.Field(i).IsZero() {
+				return errors.Errorf("%s is required", name)
+			}
+
+			if err := checkRequiredValue(v.Field(i), name); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := checkRequiredValue(iter.Value(), joinPath(path, fmt.Sprint(iter.Key().Interface()))); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := checkRequiredValue(v.Index(i), path+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,191 @@
+package infraconfig
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	"go.uber.org/zap"
+)
+
+// watchDebounce merges bursts of file events (editors and k8s configmap updates produce several)
+const watchDebounce = 200 * time.Millisecond
+
+// ChangeHandler is called with old and new config after successful reload
+type ChangeHandler[T any] func(old, new *T, diff []string)
+
+// Watcher holds the current config and reloads it on file changes or by Reload call.
+// Reload is usually bound to SIGHUP:
+//
+//	w, err := infraconfig.NewWatcher(path, newConfig, infraconfig.WithEnvPrefix("APP"))
+//	w.OnChange("log.level", func(_, cfg *AppConfig, _ []string) { level.SetLevel(cfg.Log.Level) })
+//	signals := infrasystem.NewDefaultSignals(op, infrasystem.WithReloadCallback(w.ReloadAndLog))
+//	op.AddService(ctx, w) // watch the file
+//
+// Config that fails to load or validate is rejected and the current one is kept.
+type Watcher[T any] struct {
+	path      string
+	newConfig func() *T
+	opts      []Option
+
+	current atomic.Pointer[T]
+
+	mu       sync.Mutex
+	handlers []watcherHandler[T]
+	reloadMu sync.Mutex
+
+	fsWatcher *fsnotify.Watcher
+	done      chan struct{}
+}
+
+type watcherHandler[T any] struct {
+	path string
+	fn   ChangeHandler[T]
+}
+
+var (
+	_ infraoperator.Starter = (*Watcher[struct{}])(nil)
+	_ infraoperator.Stopper = (*Watcher[struct{}])(nil)
+)
+
+// NewWatcher loads config from path. newConfig must return a new config filled with defaults.
+func NewWatcher[T any](path string, newConfig func() *T, opts ...Option) (*Watcher[T], error) {
+	w := &Watcher[T]{
+		path:      path,
+		newConfig: newConfig,
+		opts:      opts,
+	}
+
+	cfg := newConfig()
+	if err := Load(path, cfg, opts...); err != nil {
+		return nil, err
+	}
+	w.current.Store(cfg)
+
+	return w, nil
+}
+
+// Current returns the current config. Returned value must not be modified.
+func (w *Watcher[T]) Current() *T {
+	return w.current.Load()
+}
+
+// OnChange registers a handler that is called when config at path changes.
+// Empty path means any change.
+func (w *Watcher[T]) OnChange(path string, fn ChangeHandler[T]) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.handlers = append(w.handlers, watcherHandler[T]{path: path, fn: fn})
+}
+
+// Reload loads config and notifies handlers about changes
+func (w *Watcher[T]) Reload() error {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+
+	cfg := w.newConfig()
+	if err := Load(w.path, cfg, w.opts...); err != nil {
+		return err
+	}
+
+	old := w.current.Load()
+	diff := Diff(old, cfg)
+	if len(diff) == 0 {
+		return nil
+	}
+
+	w.current.Store(cfg)
+	infralog.Info("config reloaded", zap.String("path", w.path), zap.Strings("changed", diff))
+
+	w.mu.Lock()
+	handlers := append([]watcherHandler[T]{}, w.handlers...)
+	w.mu.Unlock()
+
+	for _, h := range handlers {
+		if h.path == "" || Changed(diff, h.path) {
+			h.fn(old, cfg, diff)
+		}
+	}
+
+	return nil
+}
+
+// ReloadAndLog reloads config and logs the error if any. Suitable for signal handlers.
+func (w *Watcher[T]) ReloadAndLog() {
+	if err := w.Reload(); err != nil {
+		infralog.Error("config reload failed", zap.String("path", w.path), zap.Error(err))
+	}
+}
+
+// Start starts watching config file changes
+func (w *Watcher[T]) Start(_ context.Context) error {
+	if w.path == "" {
+		return nil
+	}
+
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.Wrap(err, "fsnotify.NewWatcher")
+	}
+
+	// watch the directory because the file may be replaced, e.g. k8s configmap updates swap a symlink
+	if err = fsWatcher.Add(filepath.Dir(w.path)); err != nil {
+		_ = fsWatcher.Close()
+		return errors.Wrap(err, "unable to watch config directory")
+	}
+
+	w.fsWatcher = fsWatcher
+	w.done = make(chan struct{})
+	go w.watch()
+
+	return nil
+}
+
+// Stop stops watching config file changes
+func (w *Watcher[T]) Stop(_ context.Context) error {
+	if w.fsWatcher == nil {
+		return nil
+	}
+
+	err := w.fsWatcher.Close()
+	<-w.done
+	w.fsWatcher = nil
+
+	return err
+}
+
+func (w *Watcher[T]) watch() {
+	defer close(w.done)
+
+	timer := time.NewTimer(0)
+	<-timer.C
+
+	for {
+		select {
+		case event, ok := <-w.fsWatcher.Events:
+			if !ok {
+				timer.Stop()
+				return
+			}
+
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Remove) != 0 {
+				timer.Reset(watchDebounce)
+			}
+		case err, ok := <-w.fsWatcher.Errors:
+			if !ok {
+				timer.Stop()
+				return
+			}
+			infralog.Error("config watcher error", zap.String("path", w.path), zap.Error(err))
+		case <-timer.C:
+			w.ReloadAndLog()
+		}
+	}
+}
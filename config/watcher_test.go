@@ -0,0 +1,73 @@
+package infraconfig
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	a := &testConfig{Name: "app", Connections: map[string]*testConnectionConfig{
+		"main": {Host: "localhost", MaxConnections: 1},
+		"old":  {Host: "old"},
+	}}
+	b := &testConfig{Name: "app", Connections: map[string]*testConnectionConfig{
+		"main": {Host: "localhost", MaxConnections: 2},
+		"new":  {Host: "new"},
+	}}
+
+	expected := []string{"connections.main.max_connections", "connections.new", "connections.old"}
+	if diff := Diff(a, b); !reflect.DeepEqual(diff, expected) {
+		t.Errorf("expected %v, got %v", expected, diff)
+	}
+
+	if !Changed(expected, "connections.main") || Changed(expected, "name") {
+		t.Error("unexpected Changed result")
+	}
+}
+
+func TestWatcher_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("name: first\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := NewWatcher(path, func() *testConfig { return &testConfig{} })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var nameChanges, connectionChanges int
+	w.OnChange("name", func(old, new *testConfig, _ []string) {
+		if old.Name != "first" || new.Name != "second" {
+			t.Errorf("unexpected change: %q -> %q", old.Name, new.Name)
+		}
+		nameChanges++
+	})
+	w.OnChange("connections", func(_, _ *testConfig, _ []string) {
+		connectionChanges++
+	})
+
+	if err = os.WriteFile(path, []byte("name: second\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	// invalid config must be rejected
+	if err = os.WriteFile(path, []byte("connections: {main: {max_connections: 1}}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Reload(); err == nil {
+		t.Error("expected reload error")
+	}
+
+	if w.Current().Name != "second" {
+		t.Errorf("expected current name \"second\", got %q", w.Current().Name)
+	}
+	if nameChanges != 1 || connectionChanges != 0 {
+		t.Errorf("unexpected handler calls: name=%d, connections=%d", nameChanges, connectionChanges)
+	}
+}
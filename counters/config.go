@@ -0,0 +1,143 @@
+package infracounters
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var tableName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*(\.[a-zA-Z_][a-zA-Z0-9_]*)?$`)
+
+type Config struct {
+	// Number of redis keys a bucket of a counter is spread over, so hot counters don't load one node of a cluster.
+	// optional, default: 8
+	Shards int `mapstructure:"shards"`
+
+	// Size of time buckets counts are kept in, it's the resolution of windowed counts. optional, default: 1m
+	Window time.Duration `mapstructure:"window"`
+
+	// How long buckets are kept in redis. optional, default: 48h
+	Retention time.Duration `mapstructure:"retention"`
+
+	// How often local increments are flushed to redis. optional, default: 1s
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+
+	// Max number of members kept per bucket for top-K queries. Members with the smallest counts are evicted,
+	// so top-K is approximate for counters with more members. optional, default: 1000
+	TopKSize int `mapstructure:"top_k_size"`
+
+	// DisableTopK skips top-K tracking, it costs a sorted set update per flushed member
+	DisableTopK bool `mapstructure:"disable_top_k"`
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if c.Shards < 0 || c.TopKSize < 0 {
+		return errors.New("shards and top_k_size should not be negative")
+	}
+
+	if c.Window < 0 || c.Retention < 0 || c.FlushInterval < 0 {
+		return errors.New("window, retention and flush_interval should not be negative")
+	}
+
+	if c.Window > 0 && c.Window%time.Second != 0 {
+		return errors.New("window should be a multiple of a second")
+	}
+
+	if c.GetRetention() < c.GetWindow() {
+		return errors.New("retention should be greater than or equal to window")
+	}
+
+	return nil
+}
+
+func (c *Config) GetShards() int {
+	if c.Shards == 0 {
+		return 8
+	}
+	return c.Shards
+}
+
+func (c *Config) GetWindow() time.Duration {
+	if c.Window == 0 {
+		return time.Minute
+	}
+	return c.Window
+}
+
+func (c *Config) GetRetention() time.Duration {
+	if c.Retention == 0 {
+		return 48 * time.Hour
+	}
+	return c.Retention
+}
+
+func (c *Config) GetFlushInterval() time.Duration {
+	if c.FlushInterval == 0 {
+		return time.Second
+	}
+	return c.FlushInterval
+}
+
+func (c *Config) GetTopKSize() int {
+	if c.TopKSize == 0 {
+		return 1000
+	}
+	return c.TopKSize
+}
+
+type ExportConfig struct {
+	// Table of exported counts, optionally with a database. optional, default: counters
+	Table string `mapstructure:"table"`
+
+	// ManageSchema creates the table on Start
+	ManageSchema bool `mapstructure:"manage_schema"`
+
+	// How often closed buckets are exported. optional, default: 30s
+	Interval time.Duration `mapstructure:"interval"`
+
+	// A bucket is exported that long after it's closed, so late flushes of other instances are included.
+	// It should be greater than the flush interval. optional, default: 1m
+	Grace time.Duration `mapstructure:"grace"`
+}
+
+func (c *ExportConfig) Validate() error {
+	if c == nil {
+		return errors.New("empty export config")
+	}
+
+	if c.Table != "" && !tableName.MatchString(c.Table) {
+		return errors.Errorf("invalid table name %q", c.Table)
+	}
+
+	if c.Interval < 0 || c.Grace < 0 {
+		return errors.New("interval and grace should not be negative")
+	}
+
+	return nil
+}
+
+func (c *ExportConfig) GetTable() string {
+	if c.Table == "" {
+		return "counters"
+	}
+	return c.Table
+}
+
+func (c *ExportConfig) GetInterval() time.Duration {
+	if c.Interval == 0 {
+		return 30 * time.Second
+	}
+	return c.Interval
+}
+
+func (c *ExportConfig) GetGrace() time.Duration {
+	if c.Grace == 0 {
+		return time.Minute
+	}
+	return c.Grace
+}
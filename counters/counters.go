@@ -0,0 +1,388 @@
+package infracounters
+
+import (
+	"context"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	infraclock "github.com/pushwoosh/infra/clock"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// Entry is a member of a counter with its count
+type Entry struct {
+	Member string
+	Count  int64
+}
+
+// Counters are distributed counters on redis for quotas and usage metering. Increments are summed in memory
+// and flushed periodically, so Incr is cheap on hot paths:
+//
+//	calls, err := infracounters.New(redisContainer.Get("counters"), "usage", cfg)
+//	app.Add("counters", calls)
+//
+//	calls.Incr("api_calls", tenantID, 1)
+//
+//	used, err := calls.Last(ctx, "api_calls", tenantID, 24*time.Hour)
+//	top, err := calls.TopK(ctx, "api_calls", from, to, 10)
+//
+// Counts are kept in time buckets of Window size, so counts of any window aligned to buckets can be read.
+// A bucket of a counter is spread over Shards hash keys "<prefix>:<name>:<bucket>:<shard>", each flush
+// increments a random one of them. Reads sum the shards and don't include increments that aren't flushed yet.
+type Counters struct {
+	client redis.UniversalClient
+	prefix string
+	cfg    *Config
+	clock  infraclock.Clock
+
+	mu      sync.Mutex
+	pending map[pendingKey]int64
+
+	runMu  sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+type pendingKey struct {
+	name   string
+	member string
+	bucket int64
+}
+
+var (
+	_ infraoperator.Starter = (*Counters)(nil)
+	_ infraoperator.Stopper = (*Counters)(nil)
+)
+
+func New(client redis.UniversalClient, prefix string, cfg *Config, opts ...Option) (*Counters, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	if prefix == "" {
+		return nil, errors.New("empty prefix")
+	}
+
+	initMetrics()
+
+	c := &Counters{
+		client:  client,
+		prefix:  prefix,
+		cfg:     cfg,
+		pending: make(map[pendingKey]int64),
+	}
+
+	for _, opt := range opts {
+		opt.apply(c)
+	}
+	c.clock = infraclock.OrReal(c.clock)
+
+	return c, nil
+}
+
+// Incr adds n to the member of the counter name in the current bucket. It doesn't block on redis
+func (c *Counters) Incr(name, member string, n int64) {
+	if n == 0 {
+		return
+	}
+
+	key := pendingKey{name: name, member: member, bucket: c.bucket(c.clock.Now())}
+
+	c.mu.Lock()
+	c.pending[key] += n
+	c.mu.Unlock()
+}
+
+// Start starts flushing increments in background
+func (c *Counters) Start(_ context.Context) error {
+	c.runMu.Lock()
+	defer c.runMu.Unlock()
+
+	if c.cancel != nil {
+		return errors.New("counters are already started")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	c.done = make(chan struct{})
+
+	go c.run(ctx)
+
+	return nil
+}
+
+// Stop stops background flushing and flushes the rest of increments
+func (c *Counters) Stop(ctx context.Context) error {
+	c.runMu.Lock()
+	defer c.runMu.Unlock()
+
+	if c.cancel != nil {
+		c.cancel()
+		<-c.done
+		c.cancel = nil
+	}
+
+	return c.Flush(ctx)
+}
+
+func (c *Counters) run(ctx context.Context) {
+	defer close(c.done)
+
+	ticker := c.clock.NewTicker(c.cfg.GetFlushInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			if err := c.Flush(ctx); err != nil && ctx.Err() == nil {
+				infralog.Error("unable to flush counters", zap.String("prefix", c.prefix), zap.Error(err))
+			}
+		}
+	}
+}
+
+// Flush writes pending increments to redis. Increments that failed are kept for the next flush
+func (c *Counters) Flush(ctx context.Context) error {
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = make(map[pendingKey]int64)
+	c.mu.Unlock()
+
+	metrics.PendingGauge.WithLabelValues(c.prefix).Set(float64(len(pending)))
+	if len(pending) == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.FlushDuration.Observe(time.Since(start).Seconds())
+	}()
+
+	ttl := c.cfg.GetRetention() + c.cfg.GetWindow()
+	shards := make(map[string]string)
+	names := make(map[string]bool)
+	cmds := make(map[pendingKey]*redis.IntCmd, len(pending))
+
+	pipe := c.client.Pipeline()
+	for key, n := range pending {
+		bucketKey := c.bucketKey(key.name, key.bucket)
+
+		// all members of a bucket go to one shard per flush, so a flush touches a key per bucket
+		shardKey, ok := shards[bucketKey]
+		if !ok {
+			shardKey = bucketKey + ":" + strconv.Itoa(rand.IntN(c.cfg.GetShards()))
+			shards[bucketKey] = shardKey
+		}
+
+		cmds[key] = pipe.HIncrBy(ctx, shardKey, key.member, n)
+		names[key.name] = true
+
+		if !c.cfg.DisableTopK {
+			pipe.ZIncrBy(ctx, bucketKey+":top", float64(n), key.member)
+		}
+	}
+
+	for bucketKey, shardKey := range shards {
+		pipe.Expire(ctx, shardKey, ttl)
+
+		if !c.cfg.DisableTopK {
+			// evict the smallest members, only the top of a bucket is kept
+			pipe.ZRemRangeByRank(ctx, bucketKey+":top", 0, -int64(c.cfg.GetTopKSize())-1)
+			pipe.Expire(ctx, bucketKey+":top", ttl)
+		}
+	}
+	for name := range names {
+		pipe.SAdd(ctx, c.prefix+":names", name)
+	}
+
+	_, err := pipe.Exec(ctx)
+	if err == nil {
+		metrics.FlushesCounter.WithLabelValues(c.prefix, "ok").Inc()
+		return nil
+	}
+
+	metrics.FlushesCounter.WithLabelValues(c.prefix, "error").Inc()
+
+	// increments are returned back only if they weren't applied, top-K errors are ignored as it's approximate
+	c.mu.Lock()
+	for key, cmd := range cmds {
+		if cmd.Err() != nil {
+			c.pending[key] += pending[key]
+		}
+	}
+	c.mu.Unlock()
+
+	return errors.Wrap(err, "unable to flush counters")
+}
+
+// Count returns the count of the member of the counter name in buckets overlapping [from, to)
+func (c *Counters) Count(ctx context.Context, name, member string, from, to time.Time) (int64, error) {
+	buckets := c.buckets(from, to)
+	if len(buckets) == 0 {
+		return 0, nil
+	}
+
+	shards := c.cfg.GetShards()
+	cmds := make([]*redis.StringCmd, 0, len(buckets)*shards)
+
+	pipe := c.client.Pipeline()
+	for _, bucket := range buckets {
+		bucketKey := c.bucketKey(name, bucket)
+		for shard := 0; shard < shards; shard++ {
+			cmds = append(cmds, pipe.HGet(ctx, bucketKey+":"+strconv.Itoa(shard), member))
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return 0, errors.Wrap(err, "unable to read counter")
+	}
+
+	var total int64
+	for _, cmd := range cmds {
+		n, err := cmd.Int64()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return 0, errors.Wrap(err, "unable to parse counter")
+		}
+		total += n
+	}
+
+	return total, nil
+}
+
+// Last returns the count of the member of the counter name for the last d, rounded up to buckets
+func (c *Counters) Last(ctx context.Context, name, member string, d time.Duration) (int64, error) {
+	now := c.clock.Now()
+	return c.Count(ctx, name, member, now.Add(-d), now.Add(time.Nanosecond))
+}
+
+// Counts returns counts of all members of the counter name in buckets overlapping [from, to)
+func (c *Counters) Counts(ctx context.Context, name string, from, to time.Time) (map[string]int64, error) {
+	counts := make(map[string]int64)
+	for _, bucket := range c.buckets(from, to) {
+		bucketCounts, err := c.bucketCounts(ctx, name, bucket)
+		if err != nil {
+			return nil, err
+		}
+		for member, n := range bucketCounts {
+			counts[member] += n
+		}
+	}
+	return counts, nil
+}
+
+// TopK returns up to k members of the counter name with the largest counts in buckets overlapping [from, to).
+// Counts are approximate if buckets had more than TopKSize members
+func (c *Counters) TopK(ctx context.Context, name string, from, to time.Time, k int) ([]Entry, error) {
+	if c.cfg.DisableTopK {
+		return nil, errors.New("top-K is disabled")
+	}
+
+	buckets := c.buckets(from, to)
+	cmds := make([]*redis.ZSliceCmd, 0, len(buckets))
+
+	pipe := c.client.Pipeline()
+	for _, bucket := range buckets {
+		cmds = append(cmds, pipe.ZRevRangeWithScores(ctx, c.bucketKey(name, bucket)+":top", 0, -1))
+	}
+	if len(cmds) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, errors.Wrap(err, "unable to read top-K")
+		}
+	}
+
+	counts := make(map[string]int64)
+	for _, cmd := range cmds {
+		for _, z := range cmd.Val() {
+			member, _ := z.Member.(string)
+			counts[member] += int64(z.Score)
+		}
+	}
+
+	return topK(counts, k), nil
+}
+
+// Names returns names of counters flushed to redis
+func (c *Counters) Names(ctx context.Context) ([]string, error) {
+	names, err := c.client.SMembers(ctx, c.prefix+":names").Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read counter names")
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (c *Counters) bucketCounts(ctx context.Context, name string, bucket int64) (map[string]int64, error) {
+	shards := c.cfg.GetShards()
+	bucketKey := c.bucketKey(name, bucket)
+	cmds := make([]*redis.MapStringStringCmd, 0, shards)
+
+	pipe := c.client.Pipeline()
+	for shard := 0; shard < shards; shard++ {
+		cmds = append(cmds, pipe.HGetAll(ctx, bucketKey+":"+strconv.Itoa(shard)))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, errors.Wrap(err, "unable to read counter")
+	}
+
+	counts := make(map[string]int64)
+	for _, cmd := range cmds {
+		for member, value := range cmd.Val() {
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, errors.Wrapf(err, "unable to parse counter %s", member)
+			}
+			counts[member] += n
+		}
+	}
+
+	return counts, nil
+}
+
+func (c *Counters) bucket(t time.Time) int64 {
+	return t.Truncate(c.cfg.GetWindow()).Unix()
+}
+
+// buckets returns starts of buckets overlapping [from, to) within the retention
+func (c *Counters) buckets(from, to time.Time) []int64 {
+	if oldest := c.clock.Now().Add(-c.cfg.GetRetention()); from.Before(oldest) {
+		from = oldest
+	}
+
+	window := int64(c.cfg.GetWindow() / time.Second)
+	var buckets []int64
+	for bucket := c.bucket(from); time.Unix(bucket, 0).Before(to); bucket += window {
+		buckets = append(buckets, bucket)
+	}
+	return buckets
+}
+
+func (c *Counters) bucketKey(name string, bucket int64) string {
+	return c.prefix + ":" + name + ":" + strconv.FormatInt(bucket, 10)
+}
+
+func topK(counts map[string]int64, k int) []Entry {
+	entries := make([]Entry, 0, len(counts))
+	for member, n := range counts {
+		entries = append(entries, Entry{Member: member, Count: n})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Count != entries[j].Count {
+			return entries[i].Count > entries[j].Count
+		}
+		return entries[i].Member < entries[j].Member
+	})
+
+	if k > 0 && len(entries) > k {
+		entries = entries[:k]
+	}
+	return entries
+}
@@ -0,0 +1,57 @@
+//go:build integration
+
+package infracounters
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	infraclock "github.com/pushwoosh/infra/clock"
+	infraredis "github.com/pushwoosh/infra/redis"
+	infratestcontainers "github.com/pushwoosh/infra/test/containers"
+)
+
+func TestCounters(t *testing.T) {
+	ctx := context.Background()
+	cfg := infratestcontainers.Redis(t)
+
+	cont := infraredis.NewContainer()
+	defer cont.Close()
+	if err := cont.Connect("counters", cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	clock := infraclock.NewFake(time.Now())
+	c, err := New(cont.Get("counters"), "test-"+time.Now().Format("150405.000"), &Config{Shards: 4, TopKSize: 2}, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10; i++ {
+		c.Incr("calls", "tenant-a", 1)
+		c.Incr("calls", "tenant-b", 2)
+		if err = c.Flush(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c.Incr("calls", "tenant-c", 1)
+	if err = c.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := c.Last(ctx, "calls", "tenant-b", time.Hour); err != nil || n != 20 {
+		t.Fatalf("expected 20, got %d, %v", n, err)
+	}
+
+	// tenant-c is evicted from the top of 2
+	top, err := c.TopK(ctx, "calls", clock.Now().Add(-time.Hour), clock.Now().Add(time.Minute), 10)
+	if err != nil || len(top) != 2 || top[0] != (Entry{Member: "tenant-b", Count: 20}) {
+		t.Fatalf("unexpected top %+v, %v", top, err)
+	}
+
+	counts, err := c.Counts(ctx, "calls", clock.Now().Add(-time.Hour), clock.Now().Add(time.Minute))
+	if err != nil || counts["tenant-a"] != 10 || counts["tenant-c"] != 1 {
+		t.Fatalf("unexpected counts %v, %v", counts, err)
+	}
+}
@@ -0,0 +1,31 @@
+package infracounters
+
+import (
+	"testing"
+	"time"
+
+	infraclock "github.com/pushwoosh/infra/clock"
+)
+
+func TestBuckets(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	c, err := New(nil, "usage", &Config{Retention: time.Hour}, WithClock(infraclock.NewFake(now)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buckets := c.buckets(now.Add(-150*time.Second), now.Add(time.Second))
+	if len(buckets) != 4 || buckets[0] != now.Add(-3*time.Minute).Unix() || buckets[3] != now.Unix() {
+		t.Fatalf("unexpected buckets %v", buckets)
+	}
+
+	// buckets older than the retention are skipped
+	if buckets = c.buckets(now.Add(-24*time.Hour), now); len(buckets) != 60 {
+		t.Fatalf("expected 60 buckets, got %d", len(buckets))
+	}
+
+	top := topK(map[string]int64{"a": 1, "b": 5, "c": 5, "d": 3}, 3)
+	if len(top) != 3 || top[0].Member != "b" || top[1].Member != "c" || top[2].Member != "d" {
+		t.Fatalf("unexpected top %+v", top)
+	}
+}
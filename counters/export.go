@@ -0,0 +1,207 @@
+package infracounters
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	infraclickhouse "github.com/pushwoosh/infra/clickhouse"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// Exporter copies counts of closed buckets to ClickHouse for long-term storage and reports. Each bucket
+// is exported once as rows (ts, name, member, count), so several instances may run exporters:
+// a bucket is claimed in redis before it's exported.
+type Exporter struct {
+	counters *Counters
+	db       *sql.DB
+	cfg      *ExportConfig
+
+	runMu  sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+var (
+	_ infraoperator.Starter = (*Exporter)(nil)
+	_ infraoperator.Stopper = (*Exporter)(nil)
+)
+
+func NewExporter(counters *Counters, db *sql.DB, cfg *ExportConfig) (*Exporter, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &Exporter{
+		counters: counters,
+		db:       db,
+		cfg:      cfg,
+	}, nil
+}
+
+// Start creates the table if ManageSchema is set and starts exporting in background
+func (e *Exporter) Start(ctx context.Context) error {
+	e.runMu.Lock()
+	defer e.runMu.Unlock()
+
+	if e.cancel != nil {
+		return errors.New("exporter is already started")
+	}
+
+	if e.cfg.ManageSchema {
+		if err := e.EnsureTable(ctx); err != nil {
+			return err
+		}
+	}
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	e.cancel = cancel
+	e.done = make(chan struct{})
+
+	go e.run(runCtx)
+
+	return nil
+}
+
+// Stop stops exporting and waits for the current export
+func (e *Exporter) Stop(_ context.Context) error {
+	e.runMu.Lock()
+	defer e.runMu.Unlock()
+
+	if e.cancel == nil {
+		return nil
+	}
+
+	e.cancel()
+	<-e.done
+	e.cancel = nil
+
+	return nil
+}
+
+// EnsureTable creates the table of exported counts if it doesn't exist
+func (e *Exporter) EnsureTable(ctx context.Context) error {
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	ts DateTime,
+	name LowCardinality(String),
+	member String,
+	count Int64
+) ENGINE = SummingMergeTree(count)
+ORDER BY (name, member, ts)`, e.cfg.GetTable())
+
+	if _, err := e.db.ExecContext(ctx, query); err != nil {
+		return errors.Wrapf(err, "unable to create table %s", e.cfg.GetTable())
+	}
+
+	return nil
+}
+
+func (e *Exporter) run(ctx context.Context) {
+	defer close(e.done)
+
+	ticker := e.counters.clock.NewTicker(e.cfg.GetInterval())
+	defer ticker.Stop()
+
+	for {
+		if _, err := e.Export(ctx); err != nil && ctx.Err() == nil {
+			infralog.Error("unable to export counters", zap.String("prefix", e.counters.prefix), zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
+
+// Export exports closed buckets of all counters that weren't exported yet and returns the number of rows
+func (e *Exporter) Export(ctx context.Context) (int, error) {
+	names, err := e.counters.Names(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int
+	for _, name := range names {
+		n, err := e.exportCounter(ctx, name)
+		total += n
+		if err != nil {
+			return total, errors.Wrapf(err, "counter %s", name)
+		}
+	}
+
+	return total, nil
+}
+
+func (e *Exporter) exportCounter(ctx context.Context, name string) (int, error) {
+	c := e.counters
+	window := int64(c.cfg.GetWindow() / time.Second)
+	checkpointKey := c.prefix + ":" + name + ":exported"
+
+	from := c.bucket(c.clock.Now().Add(-c.cfg.GetRetention()))
+	last, err := c.client.Get(ctx, checkpointKey).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return 0, errors.Wrap(err, "unable to read checkpoint")
+	}
+	if err == nil && last+window > from {
+		from = last + window
+	}
+
+	// a bucket is closed when it ended more than the grace ago
+	to := c.bucket(c.clock.Now().Add(-e.cfg.GetGrace())) - window
+
+	var total int
+	for bucket := from; bucket <= to; bucket += window {
+		claimKey := c.bucketKey(name, bucket) + ":export"
+		claimed, err := c.client.SetNX(ctx, claimKey, 1, c.cfg.GetRetention()+c.cfg.GetWindow()).Result()
+		if err != nil {
+			return total, errors.Wrap(err, "unable to claim bucket")
+		}
+
+		if claimed {
+			n, err := e.exportBucket(ctx, name, bucket)
+			if err != nil {
+				// let it be exported by the next run
+				_ = c.client.Del(context.WithoutCancel(ctx), claimKey).Err()
+				return total, err
+			}
+			total += n
+		}
+
+		if err = c.client.Set(ctx, checkpointKey, strconv.FormatInt(bucket, 10), 0).Err(); err != nil {
+			return total, errors.Wrap(err, "unable to save checkpoint")
+		}
+	}
+
+	return total, nil
+}
+
+func (e *Exporter) exportBucket(ctx context.Context, name string, bucket int64) (int, error) {
+	counts, err := e.counters.bucketCounts(ctx, name, bucket)
+	if err != nil || len(counts) == 0 {
+		return 0, err
+	}
+
+	ts := time.Unix(bucket, 0).UTC()
+	rows := make([][]any, 0, len(counts))
+	for member, n := range counts {
+		rows = append(rows, []any{ts, name, member, n})
+	}
+
+	query := "INSERT INTO " + e.cfg.GetTable() + " (ts, name, member, count)"
+	if err = infraclickhouse.Insert(ctx, e.db, query, rows); err != nil {
+		metrics.ExportedCounter.WithLabelValues(e.counters.prefix, "error").Add(float64(len(rows)))
+		return 0, errors.Wrap(err, "unable to insert counts")
+	}
+
+	metrics.ExportedCounter.WithLabelValues(e.counters.prefix, "ok").Add(float64(len(rows)))
+	return len(rows), nil
+}
@@ -0,0 +1,47 @@
+package infracounters
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	FlushesCounter  *prometheus.CounterVec
+	FlushDuration   prometheus.Histogram
+	PendingGauge    *prometheus.GaugeVec
+	ExportedCounter *prometheus.CounterVec
+}
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.FlushesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "counters_flushes_total",
+			Help: "The total number of flushes of local increments to redis",
+		}, []string{"prefix", "result"})
+
+		metrics.FlushDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "counters_flush_duration_seconds",
+			Help:    "The flush of local increments duration",
+			Buckets: []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1},
+		})
+
+		metrics.PendingGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "counters_pending_members",
+			Help: "The number of counter members with increments waiting for the flush",
+		}, []string{"prefix"})
+
+		metrics.ExportedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "counters_exported_rows_total",
+			Help: "The total number of bucket counts exported to ClickHouse",
+		}, []string{"prefix", "result"})
+
+		prometheus.MustRegister(
+			metrics.FlushesCounter,
+			metrics.FlushDuration,
+			metrics.PendingGauge,
+			metrics.ExportedCounter,
+		)
+	})
+}
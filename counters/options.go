@@ -0,0 +1,22 @@
+package infracounters
+
+import (
+	infraclock "github.com/pushwoosh/infra/clock"
+)
+
+type Option interface {
+	apply(c *Counters)
+}
+
+type optionClock struct {
+	clock infraclock.Clock
+}
+
+func (opt optionClock) apply(c *Counters) {
+	c.clock = opt.clock
+}
+
+// WithClock sets a clock of buckets and flushes, e.g. a fake one in tests
+func WithClock(clock infraclock.Clock) Option {
+	return optionClock{clock: clock}
+}
@@ -0,0 +1,281 @@
+package infracron
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	infrarecovery "github.com/pushwoosh/infra/recovery"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+// parser accepts standard 5-field expressions, optional seconds field and descriptors like "@hourly" or "@every 10s"
+var parser = cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// Scheduler runs jobs by cron expressions:
+//
+//	s := infracron.New()
+//	err := s.Add("cleanup", "*/5 * * * *", cleanup, infracron.WithTimeout(time.Minute))
+//	err = s.Add("report", "@every 30s", report, infracron.WithLock(locker, time.Minute))
+//	op.AddService(ctx, s)
+type Scheduler struct {
+	mu      sync.Mutex
+	jobs    map[string]*job
+	started bool
+
+	stop      chan struct{}      // closed to stop scheduling
+	runCtx    context.Context    // context of job runs
+	cancelRun context.CancelFunc // interrupts running jobs
+	loopsWg   sync.WaitGroup
+	runsWg    sync.WaitGroup
+}
+
+type job struct {
+	name     string
+	schedule cron.Schedule
+	fn       JobFunc
+
+	timeout time.Duration
+	overlap OverlapPolicy
+	locker  Locker
+	lockTTL time.Duration
+
+	mu         sync.Mutex
+	running    bool
+	queued     bool
+	queuedTick time.Time
+}
+
+var (
+	_ infraoperator.Starter = (*Scheduler)(nil)
+	_ infraoperator.Stopper = (*Scheduler)(nil)
+)
+
+func New() *Scheduler {
+	initMetrics()
+
+	return &Scheduler{
+		jobs: make(map[string]*job),
+	}
+}
+
+// Add registers a job. Jobs added after Start are scheduled immediately.
+func (s *Scheduler) Add(name, spec string, fn JobFunc, opts ...JobOption) error {
+	schedule, err := parser.Parse(spec)
+	if err != nil {
+		return errors.Wrapf(err, "invalid schedule of job %s", name)
+	}
+
+	j := &job{
+		name:     name,
+		schedule: schedule,
+		fn:       fn,
+	}
+	for _, opt := range opts {
+		opt.apply(j)
+	}
+
+	if j.locker != nil && j.lockTTL <= 0 {
+		return errors.Errorf("lock ttl of job %s must be greater than zero", name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.jobs[name]; ok {
+		return errors.Errorf("job %s already exists", name)
+	}
+	s.jobs[name] = j
+
+	if s.started {
+		s.startLoop(j)
+	}
+
+	return nil
+}
+
+// Start starts scheduling jobs
+func (s *Scheduler) Start(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return errors.New("scheduler is already started")
+	}
+
+	s.started = true
+	s.stop = make(chan struct{})
+	s.runCtx, s.cancelRun = context.WithCancel(context.Background())
+
+	for _, j := range s.jobs {
+		s.startLoop(j)
+	}
+
+	return nil
+}
+
+// Stop stops scheduling and waits for running jobs.
+// Jobs' context is canceled when ctx expires.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.started {
+		s.mu.Unlock()
+		return nil
+	}
+	s.started = false
+	close(s.stop)
+	cancelRun := s.cancelRun
+	s.mu.Unlock()
+
+	s.loopsWg.Wait()
+
+	finished := make(chan struct{})
+	go func() {
+		s.runsWg.Wait()
+		close(finished)
+	}()
+
+	defer cancelRun()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		cancelRun()
+		<-finished
+		return errors.Wrap(ctx.Err(), "running jobs are interrupted")
+	}
+}
+
+func (s *Scheduler) startLoop(j *job) {
+	s.loopsWg.Add(1)
+	go s.loop(j, s.stop, s.runCtx)
+}
+
+func (s *Scheduler) loop(j *job, stop chan struct{}, runCtx context.Context) {
+	defer s.loopsWg.Done()
+
+	for {
+		tick := nextTick(j.schedule, time.Now())
+		timer := time.NewTimer(time.Until(tick))
+
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+			s.trigger(j, tick, stop, runCtx)
+		}
+	}
+}
+
+// nextTick returns the next scheduled time. "@every" schedules are aligned to multiples of the interval,
+// so replicas agree on ticks and the lock key
+func nextTick(schedule cron.Schedule, now time.Time) time.Time {
+	if every, ok := schedule.(cron.ConstantDelaySchedule); ok {
+		return now.Truncate(every.Delay).Add(every.Delay)
+	}
+	return schedule.Next(now)
+}
+
+func (s *Scheduler) trigger(j *job, tick time.Time, stop chan struct{}, runCtx context.Context) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.running {
+		if j.overlap == OverlapQueue {
+			j.queued = true
+			j.queuedTick = tick
+			return
+		}
+
+		metrics.RunsCounter.WithLabelValues(j.name, statusSkipped).Inc()
+		infralog.Warn("cron job is skipped: previous run is in progress", zap.String("job", j.name))
+		return
+	}
+
+	j.running = true
+	s.runsWg.Add(1)
+	go func() {
+		defer s.runsWg.Done()
+
+		for {
+			s.run(runCtx, j, tick)
+
+			j.mu.Lock()
+			select {
+			case <-stop:
+				j.queued = false
+			default:
+			}
+			if !j.queued {
+				j.running = false
+				j.mu.Unlock()
+				return
+			}
+			j.queued = false
+			tick = j.queuedTick
+			j.mu.Unlock()
+		}
+	}()
+}
+
+// run runs the job scheduled at tick
+func (s *Scheduler) run(ctx context.Context, j *job, tick time.Time) {
+	if j.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.timeout)
+		defer cancel()
+	}
+
+	if j.locker != nil {
+		unlock, acquired, err := j.locker.TryLock(ctx, lockKey(j.name, tick), j.lockTTL)
+		if err != nil {
+			metrics.RunsCounter.WithLabelValues(j.name, statusFailed).Inc()
+			infralog.Error("cron job lock error", zap.String("job", j.name), zap.Error(err))
+			return
+		}
+		if !acquired {
+			metrics.RunsCounter.WithLabelValues(j.name, statusLocked).Inc()
+			return
+		}
+
+		// the lock of the tick is held for at least ttl, so a replica with a lagging clock doesn't run the tick again
+		locked := time.Now()
+		defer func() {
+			time.AfterFunc(j.lockTTL-time.Since(locked), unlock)
+		}()
+	}
+
+	start := time.Now()
+	err := execute(ctx, j)
+	metrics.DurationHistogram.WithLabelValues(j.name).Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		metrics.RunsCounter.WithLabelValues(j.name, statusFailed).Inc()
+		infralog.Error("cron job failed", zap.String("job", j.name), zap.Duration("duration", time.Since(start)), zap.Error(err))
+		return
+	}
+
+	metrics.RunsCounter.WithLabelValues(j.name, statusSuccess).Inc()
+	metrics.LastSuccessGauge.WithLabelValues(j.name).SetToCurrentTime()
+}
+
+func lockKey(name string, tick time.Time) string {
+	return "cron:" + name + ":" + tick.UTC().Format(time.RFC3339)
+}
+
+func execute(ctx context.Context, j *job) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			infrarecovery.Report(ctx, "cron", rec, zap.String("job", j.name))
+			err = errors.Errorf("panic: %v", rec)
+		}
+	}()
+
+	return j.fn(ctx)
+}
@@ -0,0 +1,173 @@
+package infracron
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const rareSchedule = "0 0 1 1 *"
+
+func TestScheduler_overlap(t *testing.T) {
+	tests := []struct {
+		policy   OverlapPolicy
+		expected int32
+	}{
+		{OverlapSkip, 1},
+		{OverlapQueue, 2},
+	}
+
+	for _, tt := range tests {
+		s := New()
+
+		var runs atomic.Int32
+		release := make(chan struct{})
+		err := s.Add("job", rareSchedule, func(ctx context.Context) error {
+			runs.Add(1)
+			<-release
+			return nil
+		}, WithOverlapPolicy(tt.policy))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err = s.Start(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		j := s.jobs["job"]
+		s.trigger(j, time.Now(), s.stop, s.runCtx)
+		s.trigger(j, time.Now(), s.stop, s.runCtx)
+		s.trigger(j, time.Now(), s.stop, s.runCtx)
+		close(release)
+
+		// let the queued run start before stop discards it
+		deadline := time.Now().Add(time.Second)
+		for runs.Load() < tt.expected && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+
+		if err = s.Stop(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		if runs.Load() != tt.expected {
+			t.Errorf("policy %d: expected %d runs, got %d", tt.policy, tt.expected, runs.Load())
+		}
+	}
+}
+
+// testLocker is a lock server shared by replicas
+type testLocker struct {
+	mu   sync.Mutex
+	held map[string]bool
+}
+
+func (l *testLocker) TryLock(_ context.Context, key string, _ time.Duration) (func(), bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.held[key] {
+		return nil, false, nil
+	}
+	if l.held == nil {
+		l.held = make(map[string]bool)
+	}
+	l.held[key] = true
+
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.held, key)
+	}, true, nil
+}
+
+func (l *testLocker) isHeld(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.held[key]
+}
+
+func TestScheduler_lock(t *testing.T) {
+	locker := &testLocker{}
+
+	// two replicas whose clocks differ by 3s
+	var runs atomic.Int32
+	replicas := make([]*Scheduler, 2)
+	for i := range replicas {
+		replicas[i] = New()
+		if err := replicas[i].Add("locked", "*/10 * * * * *", func(ctx context.Context) error {
+			runs.Add(1)
+			return nil
+		}, WithLock(locker, time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Date(2024, 1, 1, 12, 0, 1, 0, time.UTC)
+	for i, s := range replicas {
+		j := s.jobs["locked"]
+		tick := nextTick(j.schedule, now.Add(time.Duration(i)*3*time.Second))
+		if !tick.Equal(now.Add(9 * time.Second)) {
+			t.Fatalf("replica %d: unexpected tick %s", i, tick)
+		}
+
+		// the lagging replica runs the tick after the first one has finished
+		s.run(context.Background(), j, tick)
+	}
+
+	if runs.Load() != 1 {
+		t.Fatalf("the tick must run once, got %d runs", runs.Load())
+	}
+
+	replicas[1].run(context.Background(), replicas[1].jobs["locked"], now.Add(19*time.Second))
+	if runs.Load() != 2 {
+		t.Fatal("the next tick must run")
+	}
+}
+
+func TestScheduler_lockReleased(t *testing.T) {
+	locker := &testLocker{}
+	s := New()
+	if err := s.Add("released", "@every 1s", func(ctx context.Context) error { return nil },
+		WithLock(locker, 50*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+
+	tick := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	s.run(context.Background(), s.jobs["released"], tick)
+
+	key := lockKey("released", tick)
+	if !locker.isHeld(key) {
+		t.Fatal("the lock must be held for ttl after the run")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for locker.isHeld(key) {
+		if time.Now().After(deadline) {
+			t.Fatal("the lock was not released")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNextTick_every(t *testing.T) {
+	schedule, err := parser.Parse("@every 10s")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, 1, 1, 12, 0, 1, 0, time.UTC)
+	if a, b := nextTick(schedule, now), nextTick(schedule, now.Add(3*time.Second)); !a.Equal(b) {
+		t.Fatalf("skewed replicas must agree on the tick: %s, %s", a, b)
+	}
+}
+
+func TestScheduler_invalidSchedule(t *testing.T) {
+	if err := New().Add("invalid", "* * *", func(ctx context.Context) error { return nil }); err == nil {
+		t.Error("expected schedule parse error")
+	}
+}
@@ -0,0 +1,68 @@
+package infracron
+
+import (
+	"context"
+	"time"
+)
+
+// JobFunc is a scheduled job. ctx is canceled on job timeout or scheduler stop.
+type JobFunc func(ctx context.Context) error
+
+// OverlapPolicy defines what happens when the job is due while its previous run is still in progress
+type OverlapPolicy int
+
+const (
+	// OverlapSkip skips the run
+	OverlapSkip OverlapPolicy = iota
+	// OverlapQueue runs the job right after the current run finishes. At most one run is queued.
+	OverlapQueue
+)
+
+// Locker guards a job so only one replica runs it
+type Locker interface {
+	// TryLock acquires the lock without waiting. acquired is false when the lock is held by someone else.
+	TryLock(ctx context.Context, key string, ttl time.Duration) (unlock func(), acquired bool, err error)
+}
+
+type JobOption interface {
+	apply(j *job)
+}
+
+type optionWithTimeout time.Duration
+
+func (o optionWithTimeout) apply(j *job) {
+	j.timeout = time.Duration(o)
+}
+
+// WithTimeout limits job run duration
+func WithTimeout(timeout time.Duration) JobOption {
+	return optionWithTimeout(timeout)
+}
+
+type optionWithOverlapPolicy OverlapPolicy
+
+func (o optionWithOverlapPolicy) apply(j *job) {
+	j.overlap = OverlapPolicy(o)
+}
+
+// WithOverlapPolicy sets overlap policy. Default is OverlapSkip.
+func WithOverlapPolicy(policy OverlapPolicy) JobOption {
+	return optionWithOverlapPolicy(policy)
+}
+
+type optionWithLock struct {
+	locker Locker
+	ttl    time.Duration
+}
+
+func (o optionWithLock) apply(j *job) {
+	j.locker = o.locker
+	j.lockTTL = o.ttl
+}
+
+// WithLock makes every tick of the job run only on the replica that acquired "cron:<job name>:<tick time>" lock.
+// The lock is released after the run, but not earlier than ttl after it was acquired, so ttl must be greater
+// than the clock skew of replicas.
+func WithLock(locker Locker, ttl time.Duration) JobOption {
+	return optionWithLock{locker: locker, ttl: ttl}
+}
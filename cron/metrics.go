@@ -0,0 +1,48 @@
+package infracron
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	statusSuccess = "success"
+	statusFailed  = "failed"
+	statusSkipped = "skipped"
+	statusLocked  = "locked"
+)
+
+var metrics struct {
+	RunsCounter       *prometheus.CounterVec
+	DurationHistogram *prometheus.HistogramVec
+	LastSuccessGauge  *prometheus.GaugeVec
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.RunsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cron_job_runs_total",
+			Help: "Number of cron job runs by status: success, failed, skipped (overlap) or locked (run by another replica)",
+		}, []string{"job", "status"})
+
+		metrics.DurationHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "cron_job_duration_seconds",
+			Help:    "Cron job run duration",
+			Buckets: []float64{0.01, 0.1, 0.5, 1, 5, 10, 30, 60, 300, 600, 1800, 3600},
+		}, []string{"job"})
+
+		metrics.LastSuccessGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cron_job_last_success_timestamp_seconds",
+			Help: "Unix time of the last successful run",
+		}, []string{"job"})
+
+		prometheus.MustRegister(
+			metrics.RunsCounter,
+			metrics.DurationHistogram,
+			metrics.LastSuccessGauge,
+		)
+	})
+}
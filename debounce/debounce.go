@@ -0,0 +1,208 @@
+package infradebounce
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	infraclock "github.com/pushwoosh/infra/clock"
+	infrarecovery "github.com/pushwoosh/infra/recovery"
+)
+
+type mode int
+
+const (
+	modeDebounce mode = iota
+	modeThrottle
+	modeCoalesce
+)
+
+// Func is a rate limited function. Triggers are collapsed into calls of fn with the merged payload,
+// fn is called from a background goroutine, never concurrently with itself.
+// Stop calls fn with the pending payload if any and stops the goroutine
+type Func[T any] struct {
+	mode     mode
+	delay    time.Duration
+	maxWait  time.Duration
+	fn       func(ctx context.Context, v T)
+	merge    func(acc, v T) T
+	clock    infraclock.Clock
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wake     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+
+	mu          sync.Mutex
+	stopped     bool
+	pending     bool
+	acc         T
+	first       time.Time
+	last        time.Time
+	nextAllowed time.Time
+}
+
+// Debounce calls fn once triggers stop for wait, e.g. to reload config after a burst of file events:
+//
+//	reload := infradebounce.Debounce(time.Second, func(ctx context.Context, _ struct{}) {
+//		reloadConfig(ctx)
+//	})
+//	defer reload.Stop(ctx)
+//	watcher.OnChange(func() { reload.Trigger(struct{}{}) })
+func Debounce[T any](wait time.Duration, fn func(ctx context.Context, v T), opts ...Option[T]) *Func[T] {
+	return newFunc(modeDebounce, wait, fn, opts)
+}
+
+// Throttle calls fn at most once per interval. The first trigger calls fn at once,
+// triggers within the interval are merged into one call at its end
+func Throttle[T any](interval time.Duration, fn func(ctx context.Context, v T), opts ...Option[T]) *Func[T] {
+	return newFunc(modeThrottle, interval, fn, opts)
+}
+
+// Coalesce collects triggers for window since the first one and calls fn with the merged payload,
+// e.g. to fan in cache invalidations:
+//
+//	invalidate := infradebounce.Coalesce(100*time.Millisecond, func(ctx context.Context, keys []string) {
+//		cache.Delete(ctx, keys...)
+//	}, infradebounce.WithMerge(func(acc, keys []string) []string { return append(acc, keys...) }))
+func Coalesce[T any](window time.Duration, fn func(ctx context.Context, v T), opts ...Option[T]) *Func[T] {
+	return newFunc(modeCoalesce, window, fn, opts)
+}
+
+func newFunc[T any](m mode, delay time.Duration, fn func(ctx context.Context, v T), opts []Option[T]) *Func[T] {
+	f := &Func[T]{
+		mode:  m,
+		delay: delay,
+		fn:    fn,
+		merge: func(_, v T) T { return v },
+		clock: infraclock.Real,
+		wake:  make(chan struct{}, 1),
+		done:  make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt.apply(f)
+	}
+	f.ctx, f.cancel = context.WithCancel(context.Background())
+
+	go f.run()
+
+	return f
+}
+
+// Trigger schedules a call with the payload. It never blocks, triggers after Stop are ignored
+func (f *Func[T]) Trigger(v T) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.stopped {
+		return
+	}
+
+	now := f.clock.Now()
+	if f.pending {
+		f.acc = f.merge(f.acc, v)
+	} else {
+		var zero T
+		f.acc = f.merge(zero, v)
+		f.pending = true
+		f.first = now
+	}
+	f.last = now
+
+	select {
+	case f.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Stop calls fn with the pending payload and waits for fn to return or ctx to be done.
+// ctx of fn is canceled if ctx is done first
+func (f *Func[T]) Stop(ctx context.Context) error {
+	f.stopOnce.Do(func() {
+		f.mu.Lock()
+		f.stopped = true
+		f.mu.Unlock()
+
+		select {
+		case f.wake <- struct{}{}:
+		default:
+		}
+	})
+
+	select {
+	case <-f.done:
+		return nil
+	case <-ctx.Done():
+		f.cancel()
+		return ctx.Err()
+	}
+}
+
+func (f *Func[T]) run() {
+	defer close(f.done)
+	defer f.cancel()
+
+	for {
+		f.mu.Lock()
+		stopped, pending := f.stopped, f.pending
+		var wait time.Duration
+		if pending {
+			wait = f.deadline().Sub(f.clock.Now())
+		}
+		f.mu.Unlock()
+
+		if stopped {
+			if pending {
+				f.call()
+			}
+			return
+		}
+
+		if pending && wait <= 0 {
+			f.call()
+			continue
+		}
+
+		var timer <-chan time.Time
+		if pending {
+			timer = f.clock.After(wait)
+		}
+
+		select {
+		case <-f.wake:
+		case <-timer:
+		}
+	}
+}
+
+// deadline returns the time of the pending call, f.mu must be held
+func (f *Func[T]) deadline() time.Time {
+	switch f.mode {
+	case modeThrottle:
+		return f.nextAllowed
+	case modeCoalesce:
+		return f.first.Add(f.delay)
+	default:
+		deadline := f.last.Add(f.delay)
+		if f.maxWait > 0 && f.first.Add(f.maxWait).Before(deadline) {
+			deadline = f.first.Add(f.maxWait)
+		}
+		return deadline
+	}
+}
+
+// call takes the pending payload and calls fn recovering its panic
+func (f *Func[T]) call() {
+	f.mu.Lock()
+	v := f.acc
+	var zero T
+	f.acc = zero
+	f.pending = false
+	f.nextAllowed = f.clock.Now().Add(f.delay)
+	f.mu.Unlock()
+
+	_ = infrarecovery.Do(f.ctx, "debounce", func(ctx context.Context) error {
+		f.fn(ctx, v)
+		return nil
+	})
+}
@@ -0,0 +1,104 @@
+package infradebounce
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	infraclock "github.com/pushwoosh/infra/clock"
+)
+
+func sum(acc, v int) int {
+	return acc + v
+}
+
+func collect(calls chan int) func(context.Context, int) {
+	return func(_ context.Context, v int) {
+		calls <- v
+	}
+}
+
+func expectCall(t *testing.T, calls chan int, expected int) {
+	t.Helper()
+
+	select {
+	case v := <-calls:
+		if v != expected {
+			t.Fatalf("expected call with %d, got %d", expected, v)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected call with %d", expected)
+	}
+}
+
+func expectNoCall(t *testing.T, calls chan int) {
+	t.Helper()
+
+	select {
+	case v := <-calls:
+		t.Fatalf("unexpected call with %d", v)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestDebounce(t *testing.T) {
+	clock := infraclock.NewFake(time.Now())
+	calls := make(chan int, 10)
+	f := Debounce(time.Second, collect(calls), WithMerge(sum), WithMaxWait[int](3*time.Second), WithClock[int](clock))
+	defer f.Stop(context.Background())
+
+	f.Trigger(1)
+	clock.Advance(500 * time.Millisecond)
+	f.Trigger(2)
+	clock.Advance(600 * time.Millisecond)
+	expectNoCall(t, calls)
+
+	clock.Advance(400 * time.Millisecond)
+	expectCall(t, calls, 3)
+
+	// continuous triggers are flushed after max wait
+	for i := 0; i < 4; i++ {
+		f.Trigger(1)
+		clock.Advance(900 * time.Millisecond)
+	}
+	expectCall(t, calls, 4)
+}
+
+func TestThrottle(t *testing.T) {
+	clock := infraclock.NewFake(time.Now())
+	calls := make(chan int, 10)
+	f := Throttle(time.Second, collect(calls), WithClock[int](clock))
+	defer f.Stop(context.Background())
+
+	f.Trigger(1)
+	expectCall(t, calls, 1)
+
+	f.Trigger(2)
+	f.Trigger(3)
+	expectNoCall(t, calls)
+
+	clock.Advance(time.Second)
+	expectCall(t, calls, 3)
+}
+
+func TestCoalesce(t *testing.T) {
+	clock := infraclock.NewFake(time.Now())
+	calls := make(chan int, 10)
+	f := Coalesce(time.Second, collect(calls), WithMerge(sum), WithClock[int](clock))
+
+	f.Trigger(1)
+	clock.Advance(500 * time.Millisecond)
+	f.Trigger(2)
+	clock.Advance(500 * time.Millisecond)
+	expectCall(t, calls, 3)
+
+	// stop flushes the pending payload and ignores later triggers
+	f.Trigger(4)
+	if err := f.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	expectCall(t, calls, 4)
+
+	f.Trigger(5)
+	expectNoCall(t, calls)
+}
@@ -0,0 +1,47 @@
+package infradebounce
+
+import (
+	"time"
+
+	infraclock "github.com/pushwoosh/infra/clock"
+)
+
+type Option[T any] interface {
+	apply(f *Func[T])
+}
+
+type optionMerge[T any] func(acc, v T) T
+
+func (opt optionMerge[T]) apply(f *Func[T]) {
+	f.merge = opt
+}
+
+// WithMerge sets a function merging payloads of a burst, e.g. a union of invalidated keys.
+// optional, default: the last payload wins
+func WithMerge[T any](merge func(acc, v T) T) Option[T] {
+	return optionMerge[T](merge)
+}
+
+type optionMaxWait[T any] time.Duration
+
+func (opt optionMaxWait[T]) apply(f *Func[T]) {
+	f.maxWait = time.Duration(opt)
+}
+
+// WithMaxWait limits the delay of a debounced call under continuous triggers. optional, default: no limit
+func WithMaxWait[T any](d time.Duration) Option[T] {
+	return optionMaxWait[T](d)
+}
+
+type optionClock[T any] struct {
+	clock infraclock.Clock
+}
+
+func (opt optionClock[T]) apply(f *Func[T]) {
+	f.clock = opt.clock
+}
+
+// WithClock sets a clock of delays, e.g. a fake one in tests
+func WithClock[T any](clock infraclock.Clock) Option[T] {
+	return optionClock[T]{clock: clock}
+}
@@ -0,0 +1,22 @@
+package infradebug
+
+import (
+	"github.com/pkg/errors"
+)
+
+type Config struct {
+	// Enabled serves /debug/infra/ endpoints on the observability server
+	Enabled bool `mapstructure:"enabled"`
+
+	// Token is a bearer token required by endpoints. It may be a secret reference, see infraconfig.ResolveSecret.
+	// optional, endpoints are open if empty
+	Token string `mapstructure:"token"`
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	return nil
+}
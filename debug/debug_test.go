@@ -0,0 +1,44 @@
+package infradebug
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	r.Register("state", "test state", JSON(func(req *http.Request) (interface{}, error) {
+		return map[string]string{"path": req.URL.Path}, nil
+	}))
+
+	h, err := r.Handler(&Config{Enabled: true, Token: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, Prefix+"state/sub", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without token, got %d", rec.Code)
+	}
+
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if body := rec.Body.String(); body != "{\n  \"path\": \"/sub\"\n}\n" {
+		t.Fatalf("unexpected body %q", body)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, Prefix+"unknown", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,164 @@
+package infradebug
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	infraconfig "github.com/pushwoosh/infra/config"
+)
+
+// Prefix is a path the registry handler is served on by the observability server
+const Prefix = "/debug/infra/"
+
+// Registry holds debug handlers of subsystems, every handler is served on Prefix + name:
+//
+//	infradebug.Register("rabbit", "rabbit connections", rabbitContainer.DebugHandler())
+//	infradebug.Register("flags", "feature flag definitions", flags.DebugHandler())
+//
+// GET Prefix lists registered handlers with descriptions.
+type Registry struct {
+	mu       sync.RWMutex
+	handlers map[string]*entry
+}
+
+type entry struct {
+	description string
+	handler     http.Handler
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		handlers: make(map[string]*entry),
+	}
+}
+
+var defaultRegistry = NewRegistry()
+
+// Default returns the process wide registry served by the observability server
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// Register adds a handler to the default registry, see Registry.Register
+func Register(name, description string, handler http.Handler) {
+	defaultRegistry.Register(name, description, handler)
+}
+
+// Register adds a handler served on Prefix + name and its subpaths. A handler with the same name is replaced.
+// name should not contain slashes.
+func (r *Registry) Register(name, description string, handler http.Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.handlers[name] = &entry{description: description, handler: handler}
+}
+
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.handlers, name)
+}
+
+// Names returns sorted names of registered handlers
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.handlers))
+	for name := range r.handlers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// Handler serves registered handlers on Prefix. Handlers registered later are served too.
+func (r *Registry) Handler(cfg *Config) (http.Handler, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	token, err := infraconfig.ResolveSecret(cfg.Token)
+	if err != nil {
+		return nil, errors.Wrap(err, "token")
+	}
+
+	return &handler{registry: r, token: token}, nil
+}
+
+type handler struct {
+	registry *Registry
+	token    string
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if h.token != "" && !h.authorized(req) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	path := strings.TrimPrefix(req.URL.Path, Prefix)
+	if path == "" {
+		h.index(w)
+		return
+	}
+
+	name, _, _ := strings.Cut(path, "/")
+
+	h.registry.mu.RLock()
+	e := h.registry.handlers[name]
+	h.registry.mu.RUnlock()
+
+	if e == nil {
+		http.NotFound(w, req)
+		return
+	}
+
+	http.StripPrefix(Prefix+name, e.handler).ServeHTTP(w, req)
+}
+
+func (h *handler) authorized(req *http.Request) bool {
+	token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) == 1
+}
+
+func (h *handler) index(w http.ResponseWriter) {
+	h.registry.mu.RLock()
+	index := make(map[string]string, len(h.registry.handlers))
+	for name, e := range h.registry.handlers {
+		index[Prefix+name] = e.description
+	}
+	h.registry.mu.RUnlock()
+
+	writeJSON(w, http.StatusOK, index)
+}
+
+// JSON is a handler responding with the value returned by fn encoded to JSON, or 500 on error
+func JSON(fn func(req *http.Request) (interface{}, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		v, err := fn(req)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, v)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
@@ -0,0 +1,34 @@
+package infradedupe
+
+// bloom is a bloom filter of a fixed number of bits
+type bloom struct {
+	bits []uint64
+	m    uint64
+	k    int
+}
+
+func newBloom(capacity int, fpRate float64) *bloom {
+	m, k := bloomSize(capacity, fpRate)
+	return &bloom{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+	}
+}
+
+func (b *bloom) contains(h keyHash) bool {
+	for _, pos := range bloomPositions(h, b.m, b.k) {
+		if b.bits[pos/64]&(1<<(pos%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// add never fails, bloom filters don't fill up but their false positive rate grows
+func (b *bloom) add(h keyHash) bool {
+	for _, pos := range bloomPositions(h, b.m, b.k) {
+		b.bits[pos/64] |= 1 << (pos % 64)
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package infradedupe
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	KindBloom  = "bloom"
+	KindCuckoo = "cuckoo"
+)
+
+type Config struct {
+	// Kind of filters: bloom or cuckoo. Cuckoo filters are smaller for low false positive rates
+	// but may fill up before the rotation, then they are rotated early. Redis filters are bloom only.
+	// optional, default: bloom
+	Kind string `mapstructure:"kind"`
+
+	// Expected number of keys added during a rotation period. optional, default: 1000000
+	Capacity int `mapstructure:"capacity"`
+
+	// Acceptable rate of new keys reported as seen at full capacity. optional, default: 0.001
+	FalsePositiveRate float64 `mapstructure:"false_positive_rate"`
+
+	// Keys are remembered for at least Rotation and at most 2 * Rotation. optional, default: 1h
+	Rotation time.Duration `mapstructure:"rotation"`
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	switch c.Kind {
+	case "", KindBloom:
+	case KindCuckoo:
+		// fingerprints are up to 16 bits
+		if c.FalsePositiveRate != 0 && c.FalsePositiveRate < 0.0002 {
+			return errors.New("false_positive_rate of cuckoo filters should be at least 0.0002")
+		}
+	default:
+		return errors.Errorf("unknown kind %q", c.Kind)
+	}
+
+	if c.Capacity < 0 {
+		return errors.New("capacity should not be negative")
+	}
+
+	if c.FalsePositiveRate < 0 || c.FalsePositiveRate >= 1 {
+		return errors.New("false_positive_rate should be in [0, 1)")
+	}
+
+	if c.Rotation < 0 {
+		return errors.New("rotation should not be negative")
+	}
+
+	return nil
+}
+
+func (c *Config) GetKind() string {
+	if c.Kind == "" {
+		return KindBloom
+	}
+	return c.Kind
+}
+
+func (c *Config) GetCapacity() int {
+	if c.Capacity == 0 {
+		return 1000000
+	}
+	return c.Capacity
+}
+
+func (c *Config) GetFalsePositiveRate() float64 {
+	if c.FalsePositiveRate == 0 {
+		return 0.001
+	}
+	return c.FalsePositiveRate
+}
+
+func (c *Config) GetRotation() time.Duration {
+	if c.Rotation == 0 {
+		return time.Hour
+	}
+	return c.Rotation
+}
@@ -0,0 +1,118 @@
+package infradedupe
+
+import (
+	"math"
+	"math/bits"
+	"math/rand/v2"
+)
+
+const (
+	cuckooBucketSize = 4
+	cuckooMaxKicks   = 500
+)
+
+// cuckoo is a cuckoo filter with buckets of 4 fingerprints of up to 16 bits, a zero fingerprint is an empty slot.
+// A fingerprint that can't be relocated is kept as the victim, then the filter is full.
+type cuckoo struct {
+	buckets [][cuckooBucketSize]uint16
+	mask    uint64
+	fpMask  uint16
+
+	victim      uint16
+	victimIndex uint64
+}
+
+func newCuckoo(capacity int, fpRate float64) *cuckoo {
+	// 95% load factor is reachable with 4-way buckets
+	n := uint64(math.Ceil(float64(capacity) / cuckooBucketSize / 0.95))
+	if n < 1 {
+		n = 1
+	}
+	n = 1 << bits.Len64(n-1)
+
+	fpBits := int(math.Ceil(math.Log2(2 * cuckooBucketSize / fpRate)))
+	fpBits = min(max(fpBits, 4), 16)
+
+	return &cuckoo{
+		buckets: make([][cuckooBucketSize]uint16, n),
+		mask:    n - 1,
+		fpMask:  uint16(1<<fpBits - 1),
+	}
+}
+
+func (c *cuckoo) fingerprint(h keyHash) uint16 {
+	fp := uint16(h.h2>>32) & c.fpMask
+	if fp == 0 {
+		fp = 1
+	}
+	return fp
+}
+
+// altIndex is the other bucket of a fingerprint, it's computed from the fingerprint only, so it's symmetric
+func (c *cuckoo) altIndex(i uint64, fp uint16) uint64 {
+	return (i ^ (uint64(fp) * 0x5bd1e995)) & c.mask
+}
+
+func (c *cuckoo) contains(h keyHash) bool {
+	fp := c.fingerprint(h)
+	i1 := h.h1 & c.mask
+	i2 := c.altIndex(i1, fp)
+
+	if c.victim == fp && (c.victimIndex == i1 || c.victimIndex == i2) {
+		return true
+	}
+	return c.bucketHas(i1, fp) || c.bucketHas(i2, fp)
+}
+
+// add returns false if the filter is full
+func (c *cuckoo) add(h keyHash) bool {
+	if c.victim != 0 {
+		return false
+	}
+
+	fp := c.fingerprint(h)
+	i1 := h.h1 & c.mask
+	i2 := c.altIndex(i1, fp)
+
+	if c.insert(i1, fp) || c.insert(i2, fp) {
+		return true
+	}
+
+	i := i1
+	if rand.IntN(2) == 1 {
+		i = i2
+	}
+
+	// relocate existing fingerprints to their alternate buckets
+	for kick := 0; kick < cuckooMaxKicks; kick++ {
+		slot := rand.IntN(cuckooBucketSize)
+		fp, c.buckets[i][slot] = c.buckets[i][slot], fp
+
+		i = c.altIndex(i, fp)
+		if c.insert(i, fp) {
+			return true
+		}
+	}
+
+	c.victim, c.victimIndex = fp, i
+	return true
+}
+
+func (c *cuckoo) bucketHas(i uint64, fp uint16) bool {
+	for _, slot := range c.buckets[i] {
+		if slot == fp {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *cuckoo) insert(i uint64, fp uint16) bool {
+	for slot := range c.buckets[i] {
+		if c.buckets[i][slot] == 0 {
+			c.buckets[i][slot] = fp
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+//go:build integration
+
+package infradedupe
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	infraclock "github.com/pushwoosh/infra/clock"
+	infraredis "github.com/pushwoosh/infra/redis"
+	infratestcontainers "github.com/pushwoosh/infra/test/containers"
+)
+
+func TestRedis(t *testing.T) {
+	ctx := context.Background()
+	cfg := infratestcontainers.Redis(t)
+
+	cont := infraredis.NewContainer()
+	defer cont.Close()
+	if err := cont.Connect("dedupe", cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	clock := infraclock.NewFake(time.Now())
+	f, err := NewRedis(cont.Get("dedupe"), "test-"+time.Now().Format("150405.000"), &Config{Capacity: 1000}, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if seen, err := f.Seen(ctx, "a"); err != nil || seen {
+		t.Fatalf("expected new key, got %v, %v", seen, err)
+	}
+	if seen, err := f.Seen(ctx, "a"); err != nil || !seen {
+		t.Fatalf("expected seen key, got %v, %v", seen, err)
+	}
+
+	clock.Advance(time.Hour)
+	if seen, _ := f.Contains(ctx, "a"); !seen {
+		t.Fatal("expected key in the previous generation")
+	}
+	clock.Advance(time.Hour)
+	if seen, _ := f.Contains(ctx, "a"); seen {
+		t.Fatal("expected key to be forgotten")
+	}
+}
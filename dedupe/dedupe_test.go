@@ -0,0 +1,74 @@
+package infradedupe
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	infraclock "github.com/pushwoosh/infra/clock"
+)
+
+func TestLocal(t *testing.T) {
+	for _, kind := range []string{KindBloom, KindCuckoo} {
+		t.Run(kind, func(t *testing.T) {
+			ctx := context.Background()
+			clock := infraclock.NewFake(time.Now())
+			cfg := &Config{Kind: kind, Capacity: 10000, FalsePositiveRate: 0.01, Rotation: time.Hour}
+
+			f, err := NewLocal("test-"+kind, cfg, WithClock(clock))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i := 0; i < 10000; i++ {
+				if err = f.Add(ctx, "key-"+strconv.Itoa(i)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var falsePositives int
+			for i := 0; i < 10000; i++ {
+				if seen, _ := f.Contains(ctx, "key-"+strconv.Itoa(i)); !seen {
+					t.Fatalf("key-%d is not found", i)
+				}
+				if seen, _ := f.Contains(ctx, "other-"+strconv.Itoa(i)); seen {
+					falsePositives++
+				}
+			}
+			if falsePositives > 300 {
+				t.Fatalf("too many false positives: %d", falsePositives)
+			}
+
+			// keys are in the previous generation after a rotation and forgotten after two
+			clock.Advance(time.Hour)
+			if seen, _ := f.Contains(ctx, "key-1"); !seen {
+				t.Fatal("key-1 is not found after rotation")
+			}
+			clock.Advance(time.Hour)
+			if seen, _ := f.Contains(ctx, "key-1"); seen {
+				t.Fatal("key-1 is found after two rotations")
+			}
+		})
+	}
+}
+
+func TestCuckooFull(t *testing.T) {
+	ctx := context.Background()
+	f, err := NewLocal("test-full", &Config{Kind: KindCuckoo, Capacity: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the filter is rotated early, keys of the full generation are kept
+	for i := 0; i < 300; i++ {
+		if err = f.Add(ctx, "key-"+strconv.Itoa(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 200; i < 300; i++ {
+		if seen, _ := f.Contains(ctx, "key-"+strconv.Itoa(i)); !seen {
+			t.Fatalf("key-%d is not found", i)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package infradedupe
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"time"
+)
+
+// Filter answers "seen before?" questions with little memory. Answers are probabilistic: a new key may be
+// reported as seen with the configured false positive rate, but a key added within the rotation period
+// is never reported as new.
+type Filter interface {
+	// Contains reports whether the key was probably added
+	Contains(ctx context.Context, key string) (bool, error)
+
+	// Add adds the key
+	Add(ctx context.Context, key string) error
+
+	// Seen adds the key and reports whether it was probably added before
+	Seen(ctx context.Context, key string) (bool, error)
+}
+
+// keyHash is a pair of independent hashes of a key, positions in filters are derived from them
+type keyHash struct {
+	h1, h2 uint64
+}
+
+func hashKey(key string) keyHash {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	sum := h.Sum64()
+
+	// fnv mixes short similar keys poorly, so both hashes are finalized
+	return keyHash{
+		h1: mix64(sum),
+		h2: mix64(sum^0x9e3779b97f4a7c15) | 1,
+	}
+}
+
+// mix64 is the splitmix64 finalizer
+func mix64(x uint64) uint64 {
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// bloomSize returns the number of bits and hash functions of a bloom filter for n keys
+// with false positive rate p
+func bloomSize(n int, p float64) (uint64, int) {
+	m := math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2))
+	k := int(math.Round(m / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return uint64(m), k
+}
+
+// bloomPositions returns k bit positions of the key in a filter of m bits with double hashing
+func bloomPositions(h keyHash, m uint64, k int) []uint64 {
+	positions := make([]uint64, k)
+	for i := range positions {
+		positions[i] = (h.h1 + uint64(i)*h.h2) % m
+	}
+	return positions
+}
+
+// generation returns the number of the rotation period of t, filters of all instances rotate at the same time
+func generation(t time.Time, rotation time.Duration) int64 {
+	return t.UnixNano() / int64(rotation)
+}
@@ -0,0 +1,136 @@
+package infradedupe
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	infraclock "github.com/pushwoosh/infra/clock"
+)
+
+// set is a single generation of a rotating filter
+type set interface {
+	contains(h keyHash) bool
+	// add returns false if the set is full
+	add(h keyHash) bool
+}
+
+// Local is a rotating filter in process memory. Keys are added to the current generation and checked
+// in the current and the previous ones, so they are remembered for one to two rotation periods
+type Local struct {
+	name  string
+	cfg   *Config
+	clock infraclock.Clock
+
+	mu       sync.Mutex
+	gen      int64
+	current  set
+	previous set
+}
+
+var _ Filter = (*Local)(nil)
+
+// NewLocal creates a local filter, name is used in metrics
+func NewLocal(name string, cfg *Config, opts ...Option) (*Local, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	initMetrics()
+
+	o := newOptions(opts)
+
+	l := &Local{
+		name:  name,
+		cfg:   cfg,
+		clock: o.clock,
+		gen:   generation(o.clock.Now(), cfg.GetRotation()),
+	}
+	l.current = l.newSet()
+
+	return l, nil
+}
+
+func (l *Local) Contains(_ context.Context, key string) (bool, error) {
+	h := hashKey(key)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rotate()
+	seen := l.contains(h)
+
+	observeCheck(l.name, seen, nil)
+	return seen, nil
+}
+
+func (l *Local) Add(_ context.Context, key string) error {
+	h := hashKey(key)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rotate()
+	return l.add(h)
+}
+
+func (l *Local) Seen(_ context.Context, key string) (bool, error) {
+	h := hashKey(key)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rotate()
+	seen := l.contains(h)
+	if !seen {
+		if err := l.add(h); err != nil {
+			observeCheck(l.name, false, err)
+			return false, err
+		}
+	}
+
+	observeCheck(l.name, seen, nil)
+	return seen, nil
+}
+
+func (l *Local) contains(h keyHash) bool {
+	return l.current.contains(h) || (l.previous != nil && l.previous.contains(h))
+}
+
+func (l *Local) add(h keyHash) error {
+	if l.current.add(h) {
+		return nil
+	}
+
+	// the current generation is full before the period ends
+	metrics.RotationsCounter.WithLabelValues(l.name, "full").Inc()
+	l.previous, l.current = l.current, l.newSet()
+
+	if !l.current.add(h) {
+		return errors.New("unable to add key to empty filter")
+	}
+	return nil
+}
+
+func (l *Local) rotate() {
+	gen := generation(l.clock.Now(), l.cfg.GetRotation())
+	if gen == l.gen {
+		return
+	}
+
+	metrics.RotationsCounter.WithLabelValues(l.name, "period").Inc()
+	if gen == l.gen+1 {
+		l.previous = l.current
+	} else {
+		l.previous = nil
+	}
+	l.current = l.newSet()
+	l.gen = gen
+}
+
+func (l *Local) newSet() set {
+	if l.cfg.GetKind() == KindCuckoo {
+		return newCuckoo(l.cfg.GetCapacity(), l.cfg.GetFalsePositiveRate())
+	}
+	return newBloom(l.cfg.GetCapacity(), l.cfg.GetFalsePositiveRate())
+}
@@ -0,0 +1,43 @@
+package infradedupe
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	ChecksCounter    *prometheus.CounterVec
+	RotationsCounter *prometheus.CounterVec
+}
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.ChecksCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "dedupe_checks_total",
+			Help: "The total number of dedupe checks by result: new, duplicate or error",
+		}, []string{"filter", "result"})
+
+		metrics.RotationsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "dedupe_rotations_total",
+			Help: "The total number of local filter rotations, full ones are rotated before the period ends",
+		}, []string{"filter", "reason"})
+
+		prometheus.MustRegister(
+			metrics.ChecksCounter,
+			metrics.RotationsCounter,
+		)
+	})
+}
+
+func observeCheck(filter string, seen bool, err error) {
+	result := "new"
+	switch {
+	case err != nil:
+		result = "error"
+	case seen:
+		result = "duplicate"
+	}
+	metrics.ChecksCounter.WithLabelValues(filter, result).Inc()
+}
@@ -0,0 +1,35 @@
+package infradedupe
+
+import (
+	infraclock "github.com/pushwoosh/infra/clock"
+)
+
+type Option interface {
+	apply(o *options)
+}
+
+type options struct {
+	clock infraclock.Clock
+}
+
+type optionClock struct {
+	clock infraclock.Clock
+}
+
+func (opt optionClock) apply(o *options) {
+	o.clock = opt.clock
+}
+
+// WithClock sets a clock of rotations, e.g. a fake one in tests
+func WithClock(clock infraclock.Clock) Option {
+	return optionClock{clock: clock}
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt.apply(&o)
+	}
+	o.clock = infraclock.OrReal(o.clock)
+	return o
+}
@@ -0,0 +1,58 @@
+package infradedupe
+
+import (
+	"context"
+
+	infralog "github.com/pushwoosh/infra/log"
+	infrarabbit "github.com/pushwoosh/infra/rabbit"
+	"go.uber.org/zap"
+)
+
+// KeyFunc returns a dedupe key of a message. Messages with empty key are not deduplicated
+type KeyFunc func(msg *infrarabbit.Message) string
+
+// MessageIDKey uses message id as dedupe key
+func MessageIDKey(msg *infrarabbit.Message) string {
+	return msg.MessageID()
+}
+
+// Middleware drops messages that were probably processed before, a key is added after the message is
+// processed successfully, so failed messages are redelivered. It's meant for high-volume topics where
+// exact stores like infraidempotency are too expensive:
+//
+//	router.Use(infradedupe.Middleware(filter, infradedupe.MessageIDKey))
+//
+// Unique messages are dropped with the false positive rate of the filter, and messages redelivered while
+// processing are processed twice. Messages are processed if the filter fails.
+func Middleware(filter Filter, keyFunc KeyFunc) infrarabbit.Middleware {
+	return func(next infrarabbit.HandlerFunc) infrarabbit.HandlerFunc {
+		return func(ctx context.Context, msg *infrarabbit.Message) error {
+			key := keyFunc(msg)
+			if key == "" {
+				return next(ctx, msg)
+			}
+
+			seen, err := filter.Contains(ctx, key)
+			if err != nil {
+				infralog.WarnCtx(ctx, "dedupe: unable to check message",
+					zap.String("routing_key", msg.RoutingKey()),
+					zap.Error(err))
+			}
+			if seen {
+				return nil
+			}
+
+			if err = next(ctx, msg); err != nil {
+				return err
+			}
+
+			if err = filter.Add(ctx, key); err != nil {
+				infralog.WarnCtx(ctx, "dedupe: unable to add message",
+					zap.String("routing_key", msg.RoutingKey()),
+					zap.Error(err))
+			}
+
+			return nil
+		}
+	}
+}
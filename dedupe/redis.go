@@ -0,0 +1,131 @@
+package infradedupe
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	infraclock "github.com/pushwoosh/infra/clock"
+	"github.com/redis/go-redis/v9"
+)
+
+// bloomScript checks and sets bits of a key in the current and the previous generations.
+// KEYS[1] - current generation bitmap
+// KEYS[2] - previous generation bitmap
+// ARGV[1] - mode: contains, add or seen
+// ARGV[2] - ttl of the current generation in milliseconds
+// ARGV[3..] - bit positions
+// Returns 1 if the key was probably added
+var bloomScript = redis.NewScript(`
+local function has(key)
+	for i = 3, #ARGV do
+		if redis.call("GETBIT", key, ARGV[i]) == 0 then
+			return false
+		end
+	end
+	return true
+end
+
+local seen = 0
+if ARGV[1] ~= "add" and (has(KEYS[1]) or has(KEYS[2])) then
+	seen = 1
+end
+
+if ARGV[1] ~= "contains" and seen == 0 then
+	for i = 3, #ARGV do
+		redis.call("SETBIT", KEYS[1], ARGV[i], 1)
+	end
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+
+return seen
+`)
+
+// Redis is a rotating bloom filter shared by instances, generations are bitmaps "{<prefix>}:<generation>".
+// A filter of capacity 1M with false positive rate 0.001 takes 1.8MB per generation
+type Redis struct {
+	client redis.UniversalClient
+	prefix string
+	cfg    *Config
+	clock  infraclock.Clock
+
+	m uint64
+	k int
+}
+
+var _ Filter = (*Redis)(nil)
+
+// NewRedis creates a redis filter, prefix is used in metrics as the filter name
+func NewRedis(client redis.UniversalClient, prefix string, cfg *Config, opts ...Option) (*Redis, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	if cfg.GetKind() != KindBloom {
+		return nil, errors.Errorf("redis filters don't support %s kind", cfg.GetKind())
+	}
+
+	if prefix == "" {
+		return nil, errors.New("empty prefix")
+	}
+
+	initMetrics()
+
+	o := newOptions(opts)
+	m, k := bloomSize(cfg.GetCapacity(), cfg.GetFalsePositiveRate())
+
+	return &Redis{
+		client: client,
+		prefix: prefix,
+		cfg:    cfg,
+		clock:  o.clock,
+		m:      m,
+		k:      k,
+	}, nil
+}
+
+func (r *Redis) Contains(ctx context.Context, key string) (bool, error) {
+	seen, err := r.run(ctx, "contains", key)
+	observeCheck(r.prefix, seen, err)
+	return seen, err
+}
+
+func (r *Redis) Add(ctx context.Context, key string) error {
+	_, err := r.run(ctx, "add", key)
+	return err
+}
+
+func (r *Redis) Seen(ctx context.Context, key string) (bool, error) {
+	seen, err := r.run(ctx, "seen", key)
+	observeCheck(r.prefix, seen, err)
+	return seen, err
+}
+
+func (r *Redis) run(ctx context.Context, mode, key string) (bool, error) {
+	rotation := r.cfg.GetRotation()
+	gen := generation(r.clock.Now(), rotation)
+
+	// keys share a hash tag to be in one slot of a cluster
+	keys := []string{
+		"{" + r.prefix + "}:" + strconv.FormatInt(gen, 10),
+		"{" + r.prefix + "}:" + strconv.FormatInt(gen-1, 10),
+	}
+
+	// the current generation is read as the previous one during the next period
+	ttl := 2*rotation + time.Minute
+
+	positions := bloomPositions(hashKey(key), r.m, r.k)
+	args := make([]any, 0, len(positions)+2)
+	args = append(args, mode, ttl.Milliseconds())
+	for _, pos := range positions {
+		args = append(args, pos)
+	}
+
+	seen, err := bloomScript.Run(ctx, r.client, keys, args...).Int()
+	if err != nil {
+		return false, errors.Wrap(err, "unable to check filter")
+	}
+
+	return seen == 1, nil
+}
@@ -0,0 +1,95 @@
+package infradiscovery
+
+import (
+	"context"
+	"time"
+
+	consul "github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+)
+
+const defaultConsulWaitTime = 30 * time.Second
+
+type ConsulConfig struct {
+	// Consul agent address. optional, CONSUL_HTTP_ADDR environment variable or "127.0.0.1:8500" is used by default
+	Address string `mapstructure:"address"`
+	// ACL token. optional
+	Token string `mapstructure:"token"`
+	// Datacenter. optional, agent datacenter is used by default
+	Datacenter string `mapstructure:"datacenter"`
+	// Tag filters instances by tag. optional
+	Tag string `mapstructure:"tag"`
+	// WaitTime is a max duration of blocking query. optional
+	WaitTime time.Duration `mapstructure:"wait_time"`
+}
+
+// ConsulResolver resolves services to instances passing health checks
+type ConsulResolver struct {
+	client *consul.Client
+	cfg    *ConsulConfig
+}
+
+var (
+	_ Resolver         = (*ConsulResolver)(nil)
+	_ blockingResolver = (*ConsulResolver)(nil)
+)
+
+func NewConsulResolver(cfg *ConsulConfig) (*ConsulResolver, error) {
+	consulCfg := consul.DefaultConfig()
+	if cfg.Address != "" {
+		consulCfg.Address = cfg.Address
+	}
+	if cfg.Token != "" {
+		consulCfg.Token = cfg.Token
+	}
+	if cfg.Datacenter != "" {
+		consulCfg.Datacenter = cfg.Datacenter
+	}
+
+	client, err := consul.NewClient(consulCfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "consul.NewClient")
+	}
+
+	return &ConsulResolver{
+		client: client,
+		cfg:    cfg,
+	}, nil
+}
+
+func (r *ConsulResolver) Resolve(ctx context.Context, service string) ([]Instance, error) {
+	instances, _, err := r.resolveBlocking(ctx, service, 0)
+	return instances, err
+}
+
+func (r *ConsulResolver) resolveBlocking(ctx context.Context, service string, index uint64) ([]Instance, uint64, error) {
+	waitTime := r.cfg.WaitTime
+	if waitTime <= 0 {
+		waitTime = defaultConsulWaitTime
+	}
+
+	opts := (&consul.QueryOptions{WaitIndex: index, WaitTime: waitTime}).WithContext(ctx)
+	entries, meta, err := r.client.Health().Service(service, r.cfg.Tag, true, opts)
+	if err != nil {
+		return nil, index, errors.Wrapf(err, "unable to resolve %s", service)
+	}
+
+	instances := make([]Instance, 0, len(entries))
+	for _, entry := range entries {
+		address := entry.Service.Address
+		if address == "" {
+			address = entry.Node.Address
+		}
+
+		instances = append(instances, Instance{
+			ID:      entry.Service.ID,
+			Address: address,
+			Port:    entry.Service.Port,
+			Tags:    entry.Service.Tags,
+			Meta:    entry.Service.Meta,
+		})
+	}
+	sortInstances(instances)
+
+	return instances, meta.LastIndex, nil
+}
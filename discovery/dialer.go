@@ -0,0 +1,39 @@
+package infradiscovery
+
+import (
+	"context"
+	"net"
+)
+
+// Dialer connects to instances picked from the watch ignoring the dialed address, e.g. for RabbitMQ connections.
+// Instances are tried in round-robin order until one accepts the connection:
+//
+//	cfg.Dialer = infradiscovery.Dialer(watch, &net.Dialer{Timeout: 5 * time.Second})
+//	err := container.AddConnection("default", cfg)
+func Dialer(watch *Watch, dialer *net.Dialer) func(ctx context.Context, network, address string) (net.Conn, error) {
+	if dialer == nil {
+		dialer = &net.Dialer{}
+	}
+
+	return func(ctx context.Context, network, _ string) (net.Conn, error) {
+		var lastErr error
+		for range max(len(watch.Instances()), 1) {
+			instance, err := watch.Pick()
+			if err != nil {
+				return nil, err
+			}
+
+			conn, err := dialer.DialContext(ctx, network, instance.HostPort())
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+
+			if ctx.Err() != nil {
+				break
+			}
+		}
+
+		return nil, lastErr
+	}
+}
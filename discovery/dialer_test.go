@@ -0,0 +1,52 @@
+package infradiscovery
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestDialer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	down, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	downAddr := down.Addr().(*net.TCPAddr)
+	_ = down.Close()
+
+	up := ln.Addr().(*net.TCPAddr)
+	r := &staticResolver{}
+	r.set(Instance{Address: "127.0.0.1", Port: downAddr.Port}, Instance{Address: "127.0.0.1", Port: up.Port})
+
+	watch := NewWatch(r, "rabbitmq", Callbacks{})
+	dial := Dialer(watch, nil)
+
+	if _, err = dial(context.Background(), "tcp", "rabbitmq:5672"); !errors.Is(err, ErrNoInstances) {
+		t.Fatalf("expected ErrNoInstances before start, got %v", err)
+	}
+
+	if err = watch.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = watch.Stop(context.Background()) }()
+
+	// the instance that is down is skipped on every attempt
+	for range 2 {
+		conn, err := dial(context.Background(), "tcp", "rabbitmq:5672")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if port := conn.RemoteAddr().(*net.TCPAddr).Port; port != up.Port {
+			t.Fatalf("expected %d, got %d", up.Port, port)
+		}
+		_ = conn.Close()
+	}
+}
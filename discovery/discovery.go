@@ -0,0 +1,46 @@
+package infradiscovery
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strconv"
+)
+
+// Instance is a single instance of a service
+type Instance struct {
+	ID      string
+	Address string
+	Port    int
+	Tags    []string
+	Meta    map[string]string
+}
+
+// HostPort returns "host:port" of the instance
+func (i Instance) HostPort() string {
+	return net.JoinHostPort(i.Address, strconv.Itoa(i.Port))
+}
+
+// Resolver resolves service name to its healthy instances
+type Resolver interface {
+	Resolve(ctx context.Context, service string) ([]Instance, error)
+}
+
+// blockingResolver is implemented by resolvers supporting long polling like consul blocking queries.
+// It returns when instances change after index or wait time passes.
+type blockingResolver interface {
+	resolveBlocking(ctx context.Context, service string, index uint64) ([]Instance, uint64, error)
+}
+
+func sortInstances(instances []Instance) {
+	sort.Slice(instances, func(i, j int) bool {
+		return instances[i].HostPort() < instances[j].HostPort()
+	})
+}
+
+func key(i Instance) string {
+	if i.ID != "" {
+		return i.ID
+	}
+	return i.HostPort()
+}
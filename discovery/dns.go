@@ -0,0 +1,44 @@
+package infradiscovery
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DNSResolver resolves services with DNS SRV records.
+// Service is a full record name, e.g. "_grpc._tcp.billing.default.svc.cluster.local".
+type DNSResolver struct {
+	resolver *net.Resolver
+}
+
+var _ Resolver = (*DNSResolver)(nil)
+
+// NewDNSResolver creates SRV resolver, net.DefaultResolver is used if resolver is nil
+func NewDNSResolver(resolver *net.Resolver) *DNSResolver {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	return &DNSResolver{resolver: resolver}
+}
+
+func (r *DNSResolver) Resolve(ctx context.Context, service string) ([]Instance, error) {
+	_, records, err := r.resolver.LookupSRV(ctx, "", "", service)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to resolve %s", service)
+	}
+
+	instances := make([]Instance, 0, len(records))
+	for _, srv := range records {
+		instances = append(instances, Instance{
+			Address: strings.TrimSuffix(srv.Target, "."),
+			Port:    int(srv.Port),
+		})
+	}
+	sortInstances(instances)
+
+	return instances, nil
+}
@@ -0,0 +1,151 @@
+package infradiscovery
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	infraretry "github.com/pushwoosh/infra/retry"
+	"google.golang.org/grpc/resolver"
+)
+
+// grpcResolveBackoff delays resolves after failures, ResolveNow calls don't bypass it
+var grpcResolveBackoff = infraretry.Exponential{
+	Initial:    watchRetryDelay,
+	Max:        time.Minute,
+	Multiplier: 2,
+	Jitter:     0.2,
+}
+
+// GRPCResolverBuilder builds grpc name resolvers backed by a Resolver:
+//
+//	cfg.Address = "consul:///billing"
+//	conn, err := infragrpcclient.Dial("billing", cfg,
+//		grpc.WithResolvers(infradiscovery.NewGRPCResolverBuilder("consul", consulResolver)))
+type GRPCResolverBuilder struct {
+	scheme   string
+	resolver Resolver
+	opts     []WatchOption
+}
+
+var _ resolver.Builder = (*GRPCResolverBuilder)(nil)
+
+func NewGRPCResolverBuilder(scheme string, r Resolver, opts ...WatchOption) *GRPCResolverBuilder {
+	return &GRPCResolverBuilder{
+		scheme:   scheme,
+		resolver: r,
+		opts:     opts,
+	}
+}
+
+func (b *GRPCResolverBuilder) Scheme() string {
+	return b.scheme
+}
+
+func (b *GRPCResolverBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	r := &grpcResolver{
+		watch:      NewWatch(b.resolver, target.Endpoint(), Callbacks{}, b.opts...),
+		cc:         cc,
+		resolveNow: make(chan struct{}, 1),
+		cancel:     cancel,
+		done:       make(chan struct{}),
+	}
+
+	// do not block dial, grpc waits for the first state update
+	go r.run(ctx)
+
+	return r, nil
+}
+
+// grpcResolver resolves instances in background and reports them to grpc.
+// Failed and empty resolves are reported as errors, so grpc fails calls instead of waiting for addresses.
+type grpcResolver struct {
+	watch      *Watch
+	cc         resolver.ClientConn
+	resolveNow chan struct{}
+	cancel     context.CancelFunc
+	done       chan struct{}
+}
+
+func (r *grpcResolver) run(ctx context.Context) {
+	defer close(r.done)
+
+	_, blocking := r.watch.resolver.(blockingResolver)
+
+	var (
+		index    uint64
+		failures int
+	)
+
+	for {
+		newIndex, err := r.watch.resolve(ctx, index)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil && len(r.watch.Instances()) == 0 {
+			err = errors.Wrap(ErrNoInstances, r.watch.service)
+		}
+
+		if err != nil {
+			failures++
+			index = 0
+			r.cc.ReportError(err)
+
+			// ResolveNow during the backoff is postponed till it ends
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(grpcResolveBackoff.Delay(failures)):
+			}
+			continue
+		}
+
+		failures = 0
+		// consul recommends to reset index if it goes backwards
+		if newIndex < index {
+			newIndex = 0
+		}
+		index = newIndex
+		r.update()
+
+		// blocking queries return on changes themselves
+		if blocking {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.resolveNow:
+		case <-time.After(r.watch.interval):
+		}
+	}
+}
+
+func (r *grpcResolver) update() {
+	instances := r.watch.Instances()
+
+	addresses := make([]resolver.Address, 0, len(instances))
+	for _, i := range instances {
+		addresses = append(addresses, resolver.Address{Addr: i.HostPort()})
+	}
+
+	if err := r.cc.UpdateState(resolver.State{Addresses: addresses}); err != nil {
+		r.cc.ReportError(err)
+	}
+}
+
+// ResolveNow is called by grpc when connections fail, it triggers a resolve without waiting for the interval
+func (r *grpcResolver) ResolveNow(resolver.ResolveNowOptions) {
+	select {
+	case r.resolveNow <- struct{}{}:
+	default:
+	}
+}
+
+func (r *grpcResolver) Close() {
+	r.cancel()
+	<-r.done
+}
@@ -0,0 +1,43 @@
+package infradiscovery
+
+import "net/http"
+
+// Transport sends requests to instances picked from the watch, replacing request URL host.
+// Put it under infrahttp retries, so retried requests go to other instances:
+//
+//	transport := infrahttp.WrapTransport("billing", infradiscovery.Transport(watch, http.DefaultTransport), cfg.Retry)
+//	client := &http.Client{Transport: transport}
+//	resp, err := client.Get("http://billing/v1/balance")
+func Transport(watch *Watch, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &transport{
+		watch: watch,
+		next:  next,
+	}
+}
+
+type transport struct {
+	watch *Watch
+	next  http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	instance, err := t.watch.Pick()
+	if err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, err
+	}
+
+	out := req.Clone(req.Context())
+	out.URL.Host = instance.HostPort()
+	if out.Host == "" {
+		out.Host = req.URL.Host
+	}
+
+	return t.next.RoundTrip(out)
+}
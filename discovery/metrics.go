@@ -0,0 +1,33 @@
+package infradiscovery
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	InstancesGauge       *prometheus.GaugeVec
+	ResolveErrorsCounter *prometheus.CounterVec
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.InstancesGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "discovery_instances",
+			Help: "Number of discovered service instances",
+		}, []string{"service"})
+
+		metrics.ResolveErrorsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "discovery_resolve_errors_total",
+			Help: "Number of failed service resolves",
+		}, []string{"service"})
+
+		prometheus.MustRegister(
+			metrics.InstancesGauge,
+			metrics.ResolveErrorsCounter,
+		)
+	})
+}
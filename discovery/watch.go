@@ -0,0 +1,240 @@
+package infradiscovery
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultWatchInterval = 10 * time.Second
+	watchRetryDelay      = time.Second
+)
+
+var ErrNoInstances = errors.New("no service instances available")
+
+// Callbacks are called on instance list changes. All callbacks are optional.
+type Callbacks struct {
+	// OnAdded is called for every new instance
+	OnAdded func(instance Instance)
+	// OnRemoved is called for every disappeared instance
+	OnRemoved func(instance Instance)
+	// OnChange is called with a full list of instances after any change
+	OnChange func(instances []Instance)
+}
+
+type WatchOption interface {
+	apply(w *Watch)
+}
+
+type watchOptionInterval time.Duration
+
+func (opt watchOptionInterval) apply(w *Watch) {
+	w.interval = time.Duration(opt)
+}
+
+// WithInterval sets polling interval for resolvers without blocking queries support
+func WithInterval(interval time.Duration) WatchOption {
+	return watchOptionInterval(interval)
+}
+
+// Watch keeps instance list of a service up to date:
+//
+//	watch := infradiscovery.NewWatch(consulResolver, "billing", infradiscovery.Callbacks{})
+//	if err := watch.Start(ctx); err != nil { ... }
+//	instance, err := watch.Pick()
+type Watch struct {
+	resolver  Resolver
+	service   string
+	callbacks Callbacks
+	interval  time.Duration
+
+	mu        sync.RWMutex
+	instances []Instance
+	next      atomic.Uint64
+
+	runMu  sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+var (
+	_ infraoperator.Starter = (*Watch)(nil)
+	_ infraoperator.Stopper = (*Watch)(nil)
+)
+
+func NewWatch(resolver Resolver, service string, callbacks Callbacks, opts ...WatchOption) *Watch {
+	initMetrics()
+
+	w := &Watch{
+		resolver:  resolver,
+		service:   service,
+		callbacks: callbacks,
+		interval:  DefaultWatchInterval,
+	}
+
+	for _, opt := range opts {
+		opt.apply(w)
+	}
+
+	return w
+}
+
+// Instances returns current instances
+func (w *Watch) Instances() []Instance {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.instances
+}
+
+// Pick returns the next instance in round-robin order
+func (w *Watch) Pick() (Instance, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if len(w.instances) == 0 {
+		return Instance{}, errors.Wrap(ErrNoInstances, w.service)
+	}
+
+	return w.instances[w.next.Add(1)%uint64(len(w.instances))], nil
+}
+
+// Start resolves instances and keeps watching for changes in background
+func (w *Watch) Start(ctx context.Context) error {
+	w.runMu.Lock()
+	defer w.runMu.Unlock()
+
+	if w.cancel != nil {
+		return errors.New("watch is already started")
+	}
+
+	index, err := w.resolve(ctx, 0)
+	if err != nil {
+		return err
+	}
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.done = make(chan struct{})
+
+	go w.run(runCtx, index)
+
+	return nil
+}
+
+// Stop stops watching
+func (w *Watch) Stop(_ context.Context) error {
+	w.runMu.Lock()
+	defer w.runMu.Unlock()
+
+	if w.cancel == nil {
+		return nil
+	}
+
+	w.cancel()
+	<-w.done
+	w.cancel = nil
+
+	return nil
+}
+
+func (w *Watch) run(ctx context.Context, index uint64) {
+	defer close(w.done)
+
+	_, blocking := w.resolver.(blockingResolver)
+
+	for {
+		delay := w.interval
+		if blocking {
+			delay = 0
+		}
+
+		newIndex, err := w.resolve(ctx, index)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			infralog.Error("service discovery error", zap.String("service", w.service), zap.Error(err))
+			delay = max(delay, watchRetryDelay)
+		}
+		// consul recommends to reset index if it goes backwards
+		if newIndex < index {
+			newIndex = 0
+		}
+		index = newIndex
+
+		if delay > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+		}
+	}
+}
+
+func (w *Watch) resolve(ctx context.Context, index uint64) (uint64, error) {
+	var (
+		instances []Instance
+		err       error
+	)
+
+	if br, ok := w.resolver.(blockingResolver); ok {
+		instances, index, err = br.resolveBlocking(ctx, w.service, index)
+	} else {
+		instances, err = w.resolver.Resolve(ctx, w.service)
+	}
+
+	if err != nil {
+		metrics.ResolveErrorsCounter.WithLabelValues(w.service).Inc()
+		return index, err
+	}
+
+	w.update(instances)
+	return index, nil
+}
+
+func (w *Watch) update(instances []Instance) {
+	w.mu.Lock()
+	old := make(map[string]Instance, len(w.instances))
+	for _, i := range w.instances {
+		old[key(i)] = i
+	}
+	w.instances = instances
+	w.mu.Unlock()
+
+	metrics.InstancesGauge.WithLabelValues(w.service).Set(float64(len(instances)))
+
+	changed := len(old) != len(instances)
+	for _, i := range instances {
+		if _, ok := old[key(i)]; ok {
+			delete(old, key(i))
+			continue
+		}
+
+		changed = true
+		infralog.Info("service instance added", zap.String("service", w.service), zap.String("address", i.HostPort()))
+		if w.callbacks.OnAdded != nil {
+			w.callbacks.OnAdded(i)
+		}
+	}
+
+	for _, i := range old {
+		changed = true
+		infralog.Info("service instance removed", zap.String("service", w.service), zap.String("address", i.HostPort()))
+		if w.callbacks.OnRemoved != nil {
+			w.callbacks.OnRemoved(i)
+		}
+	}
+
+	if changed && w.callbacks.OnChange != nil {
+		w.callbacks.OnChange(instances)
+	}
+}
@@ -0,0 +1,134 @@
+package infradiscovery
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/resolver"
+)
+
+type staticResolver struct {
+	mu        sync.Mutex
+	instances []Instance
+}
+
+func (r *staticResolver) set(instances ...Instance) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.instances = instances
+}
+
+func (r *staticResolver) Resolve(context.Context, string) ([]Instance, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.instances, nil
+}
+
+func TestWatch(t *testing.T) {
+	a := Instance{Address: "10.0.0.1", Port: 80}
+	b := Instance{Address: "10.0.0.2", Port: 80}
+
+	r := &staticResolver{}
+	r.set(a)
+
+	added := make(chan Instance, 2)
+	removed := make(chan Instance, 2)
+	watch := NewWatch(r, "billing", Callbacks{
+		OnAdded:   func(i Instance) { added <- i },
+		OnRemoved: func(i Instance) { removed <- i },
+	}, WithInterval(10*time.Millisecond))
+
+	if err := watch.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = watch.Stop(context.Background()) }()
+
+	if i := <-added; i.HostPort() != a.HostPort() {
+		t.Fatalf("unexpected instance added: %v", i)
+	}
+
+	r.set(b)
+
+	select {
+	case i := <-added:
+		if i.HostPort() != "10.0.0.2:80" {
+			t.Fatalf("unexpected instance added: %v", i)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("instance was not added")
+	}
+	if i := <-removed; i.HostPort() != "10.0.0.1:80" {
+		t.Fatalf("unexpected instance removed: %v", i)
+	}
+
+	picked, err := watch.Pick()
+	if err != nil || picked.HostPort() != "10.0.0.2:80" {
+		t.Fatalf("unexpected pick: %v, %v", picked, err)
+	}
+}
+
+type testClientConn struct {
+	resolver.ClientConn
+
+	states chan resolver.State
+	errs   chan error
+}
+
+func (c *testClientConn) UpdateState(s resolver.State) error {
+	c.states <- s
+	return nil
+}
+
+func (c *testClientConn) ReportError(err error) {
+	c.errs <- err
+}
+
+func TestGRPCResolver(t *testing.T) {
+	r := &staticResolver{}
+	cc := &testClientConn{states: make(chan resolver.State, 10), errs: make(chan error, 10)}
+
+	builder := NewGRPCResolverBuilder("test", r, WithInterval(time.Hour))
+	res, err := builder.Build(resolver.Target{URL: url.URL{Path: "/billing"}}, cc, resolver.BuildOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Close()
+
+	select {
+	case err = <-cc.errs:
+		if !errors.Is(err, ErrNoInstances) {
+			t.Fatalf("expected ErrNoInstances, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("empty resolve was not reported")
+	}
+
+	// resolved again after the backoff
+	r.set(Instance{Address: "10.0.0.1", Port: 80})
+	select {
+	case s := <-cc.states:
+		if len(s.Addresses) != 1 || s.Addresses[0].Addr != "10.0.0.1:80" {
+			t.Fatalf("unexpected state: %v", s)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("state was not updated")
+	}
+
+	// ResolveNow doesn't wait for the interval
+	r.set(Instance{Address: "10.0.0.2", Port: 80})
+	res.ResolveNow(resolver.ResolveNowOptions{})
+	select {
+	case s := <-cc.states:
+		if len(s.Addresses) != 1 || s.Addresses[0].Addr != "10.0.0.2:80" {
+			t.Fatalf("unexpected state: %v", s)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("ResolveNow didn't resolve")
+	}
+}
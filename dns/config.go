@@ -0,0 +1,91 @@
+package infradns
+
+import (
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultTimeout     = 2 * time.Second
+	DefaultMinTTL      = time.Second
+	DefaultMaxTTL      = 5 * time.Minute
+	DefaultNegativeTTL = 5 * time.Second
+	DefaultStaleTTL    = time.Minute
+)
+
+type Config struct {
+	// Servers are DNS servers as host:port. optional, default: nameservers of /etc/resolv.conf
+	Servers []string `mapstructure:"servers"`
+
+	// Timeout of a single query. optional, default: 2s
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// Record TTLs are clamped to [MinTTL, MaxTTL]. optional, default: 1s and 5m
+	MinTTL time.Duration `mapstructure:"min_ttl"`
+	MaxTTL time.Duration `mapstructure:"max_ttl"`
+
+	// NegativeTTL is how long failed lookups are cached. optional, default: 5s
+	NegativeTTL time.Duration `mapstructure:"negative_ttl"`
+
+	// StaleTTL is how long expired addresses are served while DNS servers fail,
+	// so a DNS outage doesn't break connections to known hosts. optional, default: 1m
+	StaleTTL time.Duration `mapstructure:"stale_ttl"`
+
+	// DisableStale fails lookups as soon as cached addresses expire and DNS servers fail
+	DisableStale bool `mapstructure:"disable_stale"`
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	for _, server := range c.Servers {
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			return errors.Wrapf(err, "invalid server %q", server)
+		}
+	}
+
+	if c.MaxTTL > 0 && c.GetMinTTL() > c.MaxTTL {
+		return errors.New("min_ttl is greater than max_ttl")
+	}
+
+	return nil
+}
+
+func (c *Config) GetTimeout() time.Duration {
+	if c.Timeout <= 0 {
+		return DefaultTimeout
+	}
+	return c.Timeout
+}
+
+func (c *Config) GetMinTTL() time.Duration {
+	if c.MinTTL <= 0 {
+		return DefaultMinTTL
+	}
+	return c.MinTTL
+}
+
+func (c *Config) GetMaxTTL() time.Duration {
+	if c.MaxTTL <= 0 {
+		return DefaultMaxTTL
+	}
+	return c.MaxTTL
+}
+
+func (c *Config) GetNegativeTTL() time.Duration {
+	if c.NegativeTTL <= 0 {
+		return DefaultNegativeTTL
+	}
+	return c.NegativeTTL
+}
+
+func (c *Config) GetStaleTTL() time.Duration {
+	if c.StaleTTL <= 0 {
+		return DefaultStaleTTL
+	}
+	return c.StaleTTL
+}
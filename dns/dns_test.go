@@ -0,0 +1,99 @@
+package infradns
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/pkg/errors"
+	infraclock "github.com/pushwoosh/infra/clock"
+)
+
+// startServer runs a DNS server answering A queries of known.test. with 127.0.0.1 and TTL 3600
+func startServer(t *testing.T, failing *atomic.Bool, queries *atomic.Int32) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := &dns.Server{PacketConn: conn, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		queries.Add(1)
+
+		resp := new(dns.Msg)
+		resp.SetReply(req)
+
+		q := req.Question[0]
+		switch {
+		case failing.Load():
+			resp.Rcode = dns.RcodeServerFailure
+		case q.Name != "known.test.":
+			resp.Rcode = dns.RcodeNameError
+		case q.Qtype == dns.TypeA:
+			rr, _ := dns.NewRR("known.test. 3600 IN A 127.0.0.1")
+			resp.Answer = append(resp.Answer, rr)
+		}
+		_ = w.WriteMsg(resp)
+	})}
+	go func() {
+		_ = server.ActivateAndServe()
+	}()
+	t.Cleanup(func() {
+		_ = server.Shutdown()
+	})
+
+	return conn.LocalAddr().String()
+}
+
+func TestResolver(t *testing.T) {
+	var failing atomic.Bool
+	var queries atomic.Int32
+	addr := startServer(t, &failing, &queries)
+
+	ctx := context.Background()
+	clock := infraclock.NewFake(time.Now())
+	r, err := New(&Config{Servers: []string{addr}, MaxTTL: time.Minute}, WithClock(clock), WithHostsFile(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.clientConf = nil
+
+	addrs, err := r.LookupHost(ctx, "known.test")
+	if err != nil || len(addrs) != 1 || addrs[0] != "127.0.0.1" {
+		t.Fatalf("unexpected lookup result %v, %v", addrs, err)
+	}
+
+	_, _ = r.LookupHost(ctx, "known.test")
+	if n := queries.Load(); n != 2 {
+		t.Fatalf("expected A and AAAA queries of the first lookup only, got %d", n)
+	}
+
+	// TTL of 3600 is clamped to a minute, the server fails and the stale address is served
+	failing.Store(true)
+	clock.Advance(time.Minute)
+	if addrs, err = r.LookupHost(ctx, "known.test"); err != nil || len(addrs) != 1 {
+		t.Fatalf("expected stale address, got %v, %v", addrs, err)
+	}
+	if n := queries.Load(); n != 4 {
+		t.Fatalf("expected the expired host to be queried, got %d queries", n)
+	}
+
+	clock.Advance(2 * time.Minute)
+	if _, err = r.LookupHost(ctx, "known.test"); err == nil {
+		t.Fatal("expected an error after the stale period")
+	}
+
+	failing.Store(false)
+	_, err = r.LookupHost(ctx, "unknown.test")
+	var dnsErr *net.DNSError
+	if !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+
+	n := queries.Load()
+	if _, err = r.LookupHost(ctx, "unknown.test"); err == nil || queries.Load() != n {
+		t.Fatalf("expected cached negative result, got %v", err)
+	}
+}
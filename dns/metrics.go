@@ -0,0 +1,41 @@
+package infradns
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	LookupsCounter         *prometheus.CounterVec
+	QueryDurationHistogram *prometheus.HistogramVec
+	CachedHostsGauge       prometheus.Gauge
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.LookupsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "dns_lookups_total",
+			Help: "Host lookups by result: hit, miss, stale, negative or error",
+		}, []string{"result"})
+
+		metrics.QueryDurationHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "dns_query_duration_seconds",
+			Help:    "Duration of queries to DNS servers",
+			Buckets: []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5},
+		}, []string{"type", "status"})
+
+		metrics.CachedHostsGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "dns_cached_hosts",
+			Help: "Number of hosts in the resolver cache",
+		})
+
+		prometheus.MustRegister(
+			metrics.LookupsCounter,
+			metrics.QueryDurationHistogram,
+			metrics.CachedHostsGauge,
+		)
+	})
+}
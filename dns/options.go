@@ -0,0 +1,33 @@
+package infradns
+
+import (
+	infraclock "github.com/pushwoosh/infra/clock"
+)
+
+type Option interface {
+	apply(r *Resolver)
+}
+
+type optionClock struct {
+	clock infraclock.Clock
+}
+
+func (opt optionClock) apply(r *Resolver) {
+	r.clock = opt.clock
+}
+
+// WithClock sets a clock of cache expiration, e.g. a fake one in tests
+func WithClock(clock infraclock.Clock) Option {
+	return optionClock{clock: clock}
+}
+
+type optionHostsFile string
+
+func (opt optionHostsFile) apply(r *Resolver) {
+	r.hostsFile = string(opt)
+}
+
+// WithHostsFile sets a path of the hosts file. optional, default: /etc/hosts
+func WithHostsFile(path string) Option {
+	return optionHostsFile(path)
+}
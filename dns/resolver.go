@@ -0,0 +1,383 @@
+package infradns
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/pkg/errors"
+	infraclock "github.com/pushwoosh/infra/clock"
+	infraconcurrency "github.com/pushwoosh/infra/concurrency"
+	infradiscovery "github.com/pushwoosh/infra/discovery"
+	infralog "github.com/pushwoosh/infra/log"
+	"go.uber.org/zap"
+)
+
+const (
+	resolvConfPath   = "/etc/resolv.conf"
+	defaultHostsFile = "/etc/hosts"
+)
+
+// errNotFound is returned by a query of a name without addresses
+var errNotFound = errors.New("no such host")
+
+// DialFunc dials an address, it's the signature of net.Dialer.DialContext
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+type entry struct {
+	addrs   []string
+	err     error
+	expires time.Time
+	// addrs may be served after expiration until stale while DNS servers fail
+	stale time.Time
+}
+
+// Resolver resolves host names to addresses and caches them for the record TTL clamped by the config.
+// Failed lookups are cached for NegativeTTL, and while DNS servers fail expired addresses are served
+// for StaleTTL, so DNS flaps don't turn into reconnect storms.
+// Concurrent lookups of a host are deduplicated. Names from /etc/hosts are resolved without queries.
+//
+// The resolver plugs into infra clients:
+//
+//	resolver, err := infradns.New(cfg.DNS)
+//	httpClient, err := infrahttp.NewClient("billing", cfg.Billing, infrahttp.WithResolver(resolver))
+//	rabbitContainer := infrarabbit.NewContainer(infrarabbit.WithResolver(resolver))
+//	grpcConn, err := infragrpcclient.Dial("billing", cfg.BillingGRPC, // with cfg.Address "cdns:///billing:9090"
+//		grpc.WithResolvers(infradiscovery.NewGRPCResolverBuilder("cdns", resolver)))
+type Resolver struct {
+	cfg       *Config
+	clock     infraclock.Clock
+	hostsFile string
+
+	servers    []string
+	clientConf *dns.ClientConfig
+	udp        *dns.Client
+	tcp        *dns.Client
+	hosts      map[string][]string
+
+	mu    sync.Mutex
+	cache map[string]*entry
+	group infraconcurrency.KeyedSingleflight[[]string]
+}
+
+var _ infradiscovery.Resolver = (*Resolver)(nil)
+
+func New(cfg *Config, opts ...Option) (*Resolver, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	initMetrics()
+
+	r := &Resolver{
+		cfg:       cfg,
+		clock:     infraclock.Real,
+		hostsFile: defaultHostsFile,
+		udp:       &dns.Client{Net: "udp", Timeout: cfg.GetTimeout()},
+		tcp:       &dns.Client{Net: "tcp", Timeout: cfg.GetTimeout()},
+		cache:     make(map[string]*entry),
+	}
+
+	for _, opt := range opts {
+		opt.apply(r)
+	}
+
+	// search domains and ndots are taken from resolv.conf even if servers are configured
+	if clientConf, err := dns.ClientConfigFromFile(resolvConfPath); err == nil {
+		r.clientConf = clientConf
+		for _, server := range clientConf.Servers {
+			r.servers = append(r.servers, net.JoinHostPort(server, clientConf.Port))
+		}
+	}
+	if len(cfg.Servers) > 0 {
+		r.servers = cfg.Servers
+	}
+	if len(r.servers) == 0 {
+		return nil, errors.Errorf("no DNS servers configured and %s is not readable", resolvConfPath)
+	}
+
+	hosts, err := readHosts(r.hostsFile)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, "hosts file")
+	}
+	r.hosts = hosts
+
+	return r, nil
+}
+
+// LookupHost returns IPv4 and IPv6 addresses of the host, IPv4 first
+func (r *Resolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if net.ParseIP(host) != nil {
+		return []string{host}, nil
+	}
+
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if addrs, ok := r.hosts[host]; ok {
+		return addrs, nil
+	}
+
+	r.mu.Lock()
+	e := r.cache[host]
+	r.mu.Unlock()
+
+	if e != nil && r.clock.Now().Before(e.expires) {
+		if e.err != nil {
+			metrics.LookupsCounter.WithLabelValues("negative").Inc()
+			return nil, e.err
+		}
+		metrics.LookupsCounter.WithLabelValues("hit").Inc()
+		return e.addrs, nil
+	}
+
+	// the lookup is shared by concurrent callers, so it isn't bound by ctx of one of them
+	addrs, _, err := r.group.Do(ctx, host, func(ctx context.Context) ([]string, error) {
+		return r.refresh(ctx, host, e)
+	})
+	return addrs, err
+}
+
+// refresh queries DNS servers and updates the cache entry of the host, prev is the expired entry if any
+func (r *Resolver) refresh(ctx context.Context, host string, prev *entry) ([]string, error) {
+	addrs, ttl, err := r.query(host)
+	now := r.clock.Now()
+
+	switch {
+	case err == nil:
+		ttl = min(max(ttl, r.cfg.GetMinTTL()), r.cfg.GetMaxTTL())
+		r.store(host, &entry{addrs: addrs, expires: now.Add(ttl), stale: now.Add(ttl + r.cfg.GetStaleTTL())})
+		metrics.LookupsCounter.WithLabelValues("miss").Inc()
+		return addrs, nil
+
+	case prev != nil && prev.err == nil && !r.cfg.DisableStale && now.Before(prev.stale):
+		infralog.WarnCtx(ctx, "dns: serving stale addresses", zap.String("host", host), zap.Error(err))
+		r.store(host, &entry{addrs: prev.addrs, expires: now.Add(r.cfg.GetNegativeTTL()), stale: prev.stale})
+		metrics.LookupsCounter.WithLabelValues("stale").Inc()
+		return prev.addrs, nil
+
+	default:
+		err = &net.DNSError{
+			Err:        err.Error(),
+			Name:       host,
+			IsNotFound: errors.Is(err, errNotFound),
+		}
+		r.store(host, &entry{err: err, expires: now.Add(r.cfg.GetNegativeTTL())})
+		metrics.LookupsCounter.WithLabelValues("error").Inc()
+		return nil, err
+	}
+}
+
+func (r *Resolver) store(host string, e *entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cache[host] = e
+	metrics.CachedHostsGauge.Set(float64(len(r.cache)))
+}
+
+// DialContext returns a dial function connecting to resolved addresses of the host one by one
+// until a connection succeeds. A default dialer is used if dialer is nil
+//
+//	transport.DialContext = resolver.DialContext(&net.Dialer{Timeout: 5 * time.Second})
+func (r *Resolver) DialContext(dialer *net.Dialer) DialFunc {
+	if dialer == nil {
+		dialer = &net.Dialer{}
+	}
+
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+
+		addrs, err := r.LookupHost(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+
+		var lastErr error
+		for _, addr := range addrs {
+			if !matchNetwork(network, addr) {
+				continue
+			}
+
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(addr, port))
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+		}
+
+		if lastErr == nil {
+			lastErr = errors.Errorf("no %s addresses of %s", network, host)
+		}
+		return nil, lastErr
+	}
+}
+
+// Resolve implements infradiscovery.Resolver, service is "host:port"
+func (r *Resolver) Resolve(ctx context.Context, service string) ([]infradiscovery.Instance, error) {
+	host, portStr, err := net.SplitHostPort(service)
+	if err != nil {
+		return nil, err
+	}
+	port, err := net.LookupPort("tcp", portStr)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := r.LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	instances := make([]infradiscovery.Instance, 0, len(addrs))
+	for _, addr := range addrs {
+		instances = append(instances, infradiscovery.Instance{Address: addr, Port: port})
+	}
+	sort.Slice(instances, func(i, j int) bool {
+		return instances[i].Address < instances[j].Address
+	})
+
+	return instances, nil
+}
+
+// query resolves the host trying names of the search list and returns addresses with the minimal TTL
+func (r *Resolver) query(host string) ([]string, time.Duration, error) {
+	names := []string{dns.Fqdn(host)}
+	if r.clientConf != nil {
+		names = r.clientConf.NameList(host)
+	}
+
+	var lastErr error
+	for _, name := range names {
+		addrs, ttl, err := r.queryName(name)
+		if err == nil {
+			return addrs, ttl, nil
+		}
+		lastErr = err
+		if !errors.Is(err, errNotFound) {
+			break
+		}
+	}
+
+	return nil, 0, lastErr
+}
+
+func (r *Resolver) queryName(name string) ([]string, time.Duration, error) {
+	addrs4, ttl4, err4 := r.exchange(name, dns.TypeA)
+	addrs6, ttl6, err6 := r.exchange(name, dns.TypeAAAA)
+
+	// a failure of one of queries is tolerated if the other one has addresses
+	switch {
+	case len(addrs4) > 0 && len(addrs6) > 0:
+		return append(addrs4, addrs6...), min(ttl4, ttl6), nil
+	case len(addrs4) > 0:
+		return addrs4, ttl4, nil
+	case len(addrs6) > 0:
+		return addrs6, ttl6, nil
+	case err4 != nil && !errors.Is(err4, errNotFound):
+		return nil, 0, err4
+	case err6 != nil && !errors.Is(err6, errNotFound):
+		return nil, 0, err6
+	default:
+		return nil, 0, errNotFound
+	}
+}
+
+// exchange sends the query to servers in order until one of them answers
+func (r *Resolver) exchange(name string, qtype uint16) ([]string, time.Duration, error) {
+	msg := new(dns.Msg)
+	msg.SetQuestion(name, qtype)
+	typ := dns.TypeToString[qtype]
+
+	var lastErr error
+	for _, server := range r.servers {
+		start := time.Now()
+		resp, _, err := r.udp.Exchange(msg, server)
+		if err == nil && resp.Truncated {
+			resp, _, err = r.tcp.Exchange(msg, server)
+		}
+		if err == nil && resp.Rcode != dns.RcodeSuccess && resp.Rcode != dns.RcodeNameError {
+			err = errors.Errorf("%s answered %s", server, dns.RcodeToString[resp.Rcode])
+		}
+		if err != nil {
+			metrics.QueryDurationHistogram.WithLabelValues(typ, "error").Observe(time.Since(start).Seconds())
+			lastErr = err
+			continue
+		}
+		metrics.QueryDurationHistogram.WithLabelValues(typ, "ok").Observe(time.Since(start).Seconds())
+
+		if resp.Rcode == dns.RcodeNameError {
+			return nil, 0, errNotFound
+		}
+
+		var addrs []string
+		var ttl uint32
+		for _, rr := range resp.Answer {
+			switch rr := rr.(type) {
+			case *dns.A:
+				addrs = append(addrs, rr.A.String())
+			case *dns.AAAA:
+				addrs = append(addrs, rr.AAAA.String())
+			default:
+				continue
+			}
+			if ttl == 0 || rr.Header().Ttl < ttl {
+				ttl = rr.Header().Ttl
+			}
+		}
+		if len(addrs) == 0 {
+			return nil, 0, errNotFound
+		}
+
+		return addrs, time.Duration(ttl) * time.Second, nil
+	}
+
+	return nil, 0, lastErr
+}
+
+// matchNetwork reports if the address can be dialed with tcp4/udp4 or tcp6/udp6 network
+func matchNetwork(network, addr string) bool {
+	isIPv4 := net.ParseIP(addr).To4() != nil
+
+	switch {
+	case strings.HasSuffix(network, "4"):
+		return isIPv4
+	case strings.HasSuffix(network, "6"):
+		return !isIPv4
+	default:
+		return true
+	}
+}
+
+// readHosts parses a hosts file into lowercase names and their addresses
+func readHosts(path string) (map[string][]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hosts := make(map[string][]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) < 2 || net.ParseIP(fields[0]) == nil {
+			continue
+		}
+
+		for _, name := range fields[1:] {
+			name = strings.ToLower(strings.TrimSuffix(name, "."))
+			hosts[name] = append(hosts[name], fields[0])
+		}
+	}
+
+	return hosts, scanner.Err()
+}
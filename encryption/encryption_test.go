@@ -0,0 +1,108 @@
+package infraencryption
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func newKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, keySize))
+}
+
+func TestEncryptor(t *testing.T) {
+	ctx := context.Background()
+
+	provider, err := NewStaticProvider(&StaticConfig{
+		CurrentKey: "v1",
+		Keys:       map[string]string{"v1": newKey(1)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enc, err := NewEncryptor(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := []byte("user@example.com")
+	envelope, err := enc.Encrypt(ctx, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if envelope.KeyID != "v1" || bytes.Contains(envelope.Ciphertext, payload) {
+		t.Fatalf("unexpected envelope %+v", envelope)
+	}
+
+	decrypted, err := enc.Decrypt(ctx, envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decrypted, payload) {
+		t.Fatalf("expected %q, got %q", payload, decrypted)
+	}
+
+	envelope.Ciphertext[len(envelope.Ciphertext)-1] ^= 0xff
+	if _, err = enc.Decrypt(ctx, envelope); !errors.Is(err, ErrInvalidCiphertext) {
+		t.Fatalf("expected ErrInvalidCiphertext, got %v", err)
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	ctx := context.Background()
+
+	old, err := NewStaticProvider(&StaticConfig{
+		CurrentKey: "v1",
+		Keys:       map[string]string{"v1": newKey(1)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldEnc, err := NewEncryptor(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	envelope, err := oldEnc.Encrypt(ctx, []byte("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated, err := NewStaticProvider(&StaticConfig{
+		CurrentKey: "v2",
+		Keys:       map[string]string{"v1": newKey(1), "v2": newKey(2)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := NewEncryptor(rotated)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = enc.Decrypt(ctx, envelope); err != nil {
+		t.Fatalf("payload encrypted with old key should be decrypted: %v", err)
+	}
+
+	fresh, err := enc.Encrypt(ctx, []byte("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fresh.KeyID != "v2" {
+		t.Fatalf("expected v2, got %s", fresh.KeyID)
+	}
+}
+
+func TestVaultProviderKeyID(t *testing.T) {
+	provider := NewVaultProvider(nil, "transit", "user-pii", "user-pii-old")
+
+	for _, keyID := range []string{"billing", "user-pii/../../sys/raw", "", "user-pii-old/x"} {
+		if _, err := provider.DecryptDataKey(context.Background(), keyID, []byte("vault:v1:x")); !errors.Is(err, ErrUnknownKey) {
+			t.Errorf("%q: expected ErrUnknownKey, got %v", keyID, err)
+		}
+	}
+}
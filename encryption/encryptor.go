@@ -0,0 +1,146 @@
+package infraencryption
+
+import (
+	"context"
+	"encoding/base64"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	infracache "github.com/pushwoosh/infra/cache"
+)
+
+const (
+	defaultDataKeyTTL = 5 * time.Minute
+	defaultCacheSize  = 1000
+)
+
+// ErrInvalidCiphertext is returned for payloads that are corrupted or encrypted with another key
+var ErrInvalidCiphertext = errors.New("invalid ciphertext")
+
+// ErrUnknownKey is returned by key providers for master keys they don't have or don't allow
+var ErrUnknownKey = errors.New("unknown key")
+
+// Envelope is an encrypted payload with its encrypted data key
+type Envelope struct {
+	// KeyID is an id of the master key encrypting the data key
+	KeyID string
+
+	// DataKey is the encrypted data key
+	DataKey []byte
+
+	// Ciphertext is AES-GCM encrypted payload with the nonce prepended
+	Ciphertext []byte
+}
+
+// Encryptor encrypts payloads with envelope encryption: payloads are encrypted with AES-256-GCM data keys,
+// data keys are encrypted with master keys of the key provider and stored with the payload:
+//
+//	provider := infraencryption.NewVaultProvider(secretsClient, "transit", "user-pii")
+//	enc := infraencryption.NewEncryptor(provider)
+//	envelope, err := enc.Encrypt(ctx, payload)
+//	...
+//	payload, err = enc.Decrypt(ctx, envelope)
+//
+// A data key is reused for DataKeyTTL, decrypted data keys are cached, so the provider is not called
+// for every payload.
+type Encryptor struct {
+	provider   KeyProvider
+	dataKeyTTL time.Duration
+	cacheSize  int
+
+	mu        sync.Mutex
+	current   *DataKey
+	currentAt time.Time
+
+	decrypted *infracache.Memory[[]byte]
+}
+
+func NewEncryptor(provider KeyProvider, opts ...Option) (*Encryptor, error) {
+	initMetrics()
+
+	e := &Encryptor{
+		provider:   provider,
+		dataKeyTTL: defaultDataKeyTTL,
+		cacheSize:  defaultCacheSize,
+	}
+
+	for _, opt := range opts {
+		opt.apply(e)
+	}
+
+	decrypted, err := infracache.NewMemory[[]byte]("encryption_data_keys", &infracache.MemoryConfig{Size: e.cacheSize})
+	if err != nil {
+		return nil, errors.Wrap(err, "data keys cache")
+	}
+	e.decrypted = decrypted
+
+	return e, nil
+}
+
+// Encrypt encrypts the payload with the current data key
+func (e *Encryptor) Encrypt(ctx context.Context, plaintext []byte) (envelope *Envelope, err error) {
+	defer func() {
+		observe("encrypt", err)
+	}()
+
+	key, err := e.dataKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := seal(key.Plaintext, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Envelope{
+		KeyID:      key.KeyID,
+		DataKey:    key.Encrypted,
+		Ciphertext: ciphertext,
+	}, nil
+}
+
+// Decrypt decrypts the payload of the envelope
+func (e *Encryptor) Decrypt(ctx context.Context, envelope *Envelope) (plaintext []byte, err error) {
+	defer func() {
+		observe("decrypt", err)
+	}()
+
+	if envelope == nil || envelope.KeyID == "" || len(envelope.DataKey) == 0 {
+		return nil, ErrInvalidCiphertext
+	}
+
+	cacheKey := envelope.KeyID + ":" + base64.StdEncoding.EncodeToString(envelope.DataKey)
+	key, ok, _ := e.decrypted.Get(ctx, cacheKey)
+	if !ok {
+		metrics.DataKeysCounter.WithLabelValues("decrypt").Inc()
+		if key, err = e.provider.DecryptDataKey(ctx, envelope.KeyID, envelope.DataKey); err != nil {
+			return nil, errors.Wrap(err, "unable to decrypt data key")
+		}
+		_ = e.decrypted.Set(ctx, cacheKey, key, 0)
+	}
+
+	return open(key, envelope.Ciphertext)
+}
+
+// dataKey returns the current data key or generates a new one when it's expired
+func (e *Encryptor) dataKey(ctx context.Context) (*DataKey, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.current != nil && time.Since(e.currentAt) < e.dataKeyTTL {
+		return e.current, nil
+	}
+
+	metrics.DataKeysCounter.WithLabelValues("generate").Inc()
+	key, err := e.provider.GenerateDataKey(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to generate data key")
+	}
+
+	e.current = key
+	e.currentAt = time.Now()
+
+	return key, nil
+}
@@ -0,0 +1,41 @@
+package infraencryption
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	OperationsCounter *prometheus.CounterVec
+	DataKeysCounter   *prometheus.CounterVec
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.OperationsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "encryption_operations_total",
+			Help: "Number of payload encryptions and decryptions by result: success or error",
+		}, []string{"operation", "result"})
+
+		metrics.DataKeysCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "encryption_data_key_requests_total",
+			Help: "Number of data key requests to the key provider by operation: generate or decrypt",
+		}, []string{"operation"})
+
+		prometheus.MustRegister(
+			metrics.OperationsCounter,
+			metrics.DataKeysCounter,
+		)
+	})
+}
+
+func observe(operation string, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	metrics.OperationsCounter.WithLabelValues(operation, result).Inc()
+}
@@ -0,0 +1,31 @@
+package infraencryption
+
+import (
+	"time"
+)
+
+type Option interface {
+	apply(e *Encryptor)
+}
+
+type optionDataKeyTTL time.Duration
+
+func (opt optionDataKeyTTL) apply(e *Encryptor) {
+	e.dataKeyTTL = time.Duration(opt)
+}
+
+// WithDataKeyTTL sets how long a data key encrypts payloads before a new one is generated. Default is 5m
+func WithDataKeyTTL(ttl time.Duration) Option {
+	return optionDataKeyTTL(ttl)
+}
+
+type optionCacheSize int
+
+func (opt optionCacheSize) apply(e *Encryptor) {
+	e.cacheSize = int(opt)
+}
+
+// WithCacheSize sets the number of decrypted data keys kept in memory. Default is 1000
+func WithCacheSize(size int) Option {
+	return optionCacheSize(size)
+}
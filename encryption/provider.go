@@ -0,0 +1,22 @@
+package infraencryption
+
+import (
+	"context"
+)
+
+// DataKey is a key encrypting payloads. Plaintext is used in memory only,
+// Encrypted is stored next to the payload and decrypted by the provider with the key KeyID.
+type DataKey struct {
+	KeyID     string
+	Plaintext []byte
+	Encrypted []byte
+}
+
+// KeyProvider issues and decrypts data keys with master keys it holds, e.g. in Vault transit engine or KMS
+type KeyProvider interface {
+	// GenerateDataKey returns a new 256-bit data key encrypted with the current master key
+	GenerateDataKey(ctx context.Context) (*DataKey, error)
+
+	// DecryptDataKey decrypts a data key encrypted with the master key keyID
+	DecryptDataKey(ctx context.Context, keyID string, encrypted []byte) ([]byte, error)
+}
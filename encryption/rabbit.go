@@ -0,0 +1,119 @@
+package infraencryption
+
+import (
+	"context"
+	"encoding/base64"
+	"maps"
+
+	"github.com/pkg/errors"
+	infrarabbit "github.com/pushwoosh/infra/rabbit"
+)
+
+const (
+	// HeaderKeyID is a message header with the id of the master key
+	HeaderKeyID = "x-encryption-key-id"
+
+	// HeaderDataKey is a message header with the base64 encoded encrypted data key
+	HeaderDataKey = "x-encryption-data-key"
+)
+
+// Producer encrypts bodies of published messages, the key id and the encrypted data key are sent in headers
+type Producer struct {
+	publisher infrarabbit.Publisher
+	encryptor *Encryptor
+}
+
+var _ infrarabbit.Publisher = (*Producer)(nil)
+
+func WrapProducer(e *Encryptor, p infrarabbit.Publisher) *Producer {
+	return &Producer{
+		publisher: p,
+		encryptor: e,
+	}
+}
+
+func (p *Producer) Produce(ctx context.Context, msg *infrarabbit.ProducerMessage) error {
+	envelope, err := p.encryptor.Encrypt(ctx, msg.Body)
+	if err != nil {
+		return errors.Wrap(err, "unable to encrypt message")
+	}
+
+	encrypted := *msg
+	encrypted.Body = envelope.Ciphertext
+	encrypted.Headers = maps.Clone(msg.Headers)
+	if encrypted.Headers == nil {
+		encrypted.Headers = make(map[string]interface{}, 2)
+	}
+	encrypted.Headers[HeaderKeyID] = envelope.KeyID
+	encrypted.Headers[HeaderDataKey] = base64.StdEncoding.EncodeToString(envelope.DataKey)
+
+	return p.publisher.Produce(ctx, &encrypted)
+}
+
+// MiddlewareOption configures Middleware
+type MiddlewareOption interface {
+	apply(m *middleware)
+}
+
+type middleware struct {
+	requireEncrypted bool
+}
+
+type optionRequireEncrypted struct{}
+
+func (optionRequireEncrypted) apply(m *middleware) {
+	m.requireEncrypted = true
+}
+
+// RequireEncrypted makes Middleware drop messages without encryption headers,
+// enable it once all producers encrypt messages
+func RequireEncrypted() MiddlewareOption {
+	return optionRequireEncrypted{}
+}
+
+// Middleware decrypts bodies of messages published by the encrypting Producer. Messages without encryption
+// headers are passed as is unless RequireEncrypted is set, so consumers can be migrated before producers:
+//
+//	router.Use(infraencryption.Middleware(encryptor, infraencryption.RequireEncrypted()))
+//
+// Messages that can't be decrypted: corrupted, encrypted with an unknown key or unencrypted with RequireEncrypted,
+// are dropped by the router as malformed. Errors of the key provider requeue messages.
+func Middleware(e *Encryptor, opts ...MiddlewareOption) infrarabbit.Middleware {
+	m := &middleware{}
+	for _, opt := range opts {
+		opt.apply(m)
+	}
+
+	return func(next infrarabbit.HandlerFunc) infrarabbit.HandlerFunc {
+		return func(ctx context.Context, msg *infrarabbit.Message) error {
+			keyID, _ := msg.Headers()[HeaderKeyID].(string)
+			if keyID == "" {
+				if m.requireEncrypted {
+					return errors.Wrap(infrarabbit.ErrMalformed, "message is not encrypted")
+				}
+				return next(ctx, msg)
+			}
+
+			dataKey, _ := msg.Headers()[HeaderDataKey].(string)
+			decodedKey, err := base64.StdEncoding.DecodeString(dataKey)
+			if err != nil {
+				return errors.Wrap(infrarabbit.ErrMalformed, "invalid data key header")
+			}
+
+			body, err := e.Decrypt(ctx, &Envelope{
+				KeyID:      keyID,
+				DataKey:    decodedKey,
+				Ciphertext: msg.Body(),
+			})
+			if errors.Is(err, ErrInvalidCiphertext) || errors.Is(err, ErrUnknownKey) {
+				return errors.Wrapf(infrarabbit.ErrMalformed, "unable to decrypt message: %s", err)
+			}
+			if err != nil {
+				return errors.Wrap(err, "unable to decrypt message")
+			}
+
+			msg.SetBody(body)
+			return next(ctx, msg)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package infraencryption
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+
+	"github.com/pkg/errors"
+	infraconfig "github.com/pushwoosh/infra/config"
+)
+
+// StaticConfig holds master keys in config, for services without Vault or KMS
+type StaticConfig struct {
+	// CurrentKey is an id of the key encrypting new data keys
+	CurrentKey string `mapstructure:"current_key"`
+
+	// Keys are base64 encoded 256-bit master keys by id. Old keys are kept to decrypt existing payloads.
+	// Values may be secret references, see infraconfig.ResolveSecret
+	Keys map[string]string `mapstructure:"keys"`
+}
+
+func (c *StaticConfig) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if c.CurrentKey == "" {
+		return errors.New("current_key is mandatory")
+	}
+
+	if _, ok := c.Keys[c.CurrentKey]; !ok {
+		return errors.Errorf("current key %q is not in keys", c.CurrentKey)
+	}
+
+	return nil
+}
+
+// StaticProvider encrypts data keys with AES-GCM master keys from config
+type StaticProvider struct {
+	current string
+	keys    map[string][]byte
+}
+
+var _ KeyProvider = (*StaticProvider)(nil)
+
+func NewStaticProvider(cfg *StaticConfig) (*StaticProvider, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	p := &StaticProvider{
+		current: cfg.CurrentKey,
+		keys:    make(map[string][]byte, len(cfg.Keys)),
+	}
+
+	for id, ref := range cfg.Keys {
+		encoded, err := infraconfig.ResolveSecret(ref)
+		if err != nil {
+			return nil, errors.Wrapf(err, "key %s", id)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, errors.Wrapf(err, "key %s: invalid base64", id)
+		}
+		if len(key) != keySize {
+			return nil, errors.Errorf("key %s: should be %d bytes", id, keySize)
+		}
+
+		p.keys[id] = key
+	}
+
+	return p, nil
+}
+
+func (p *StaticProvider) GenerateDataKey(_ context.Context) (*DataKey, error) {
+	plaintext := make([]byte, keySize)
+	if _, err := rand.Read(plaintext); err != nil {
+		return nil, errors.Wrap(err, "unable to generate data key")
+	}
+
+	encrypted, err := seal(p.keys[p.current], plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DataKey{
+		KeyID:     p.current,
+		Plaintext: plaintext,
+		Encrypted: encrypted,
+	}, nil
+}
+
+func (p *StaticProvider) DecryptDataKey(_ context.Context, keyID string, encrypted []byte) ([]byte, error) {
+	key, ok := p.keys[keyID]
+	if !ok {
+		return nil, errors.Wrapf(ErrUnknownKey, "%q", keyID)
+	}
+
+	return open(key, encrypted)
+}
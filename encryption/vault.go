@@ -0,0 +1,99 @@
+package infraencryption
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"regexp"
+
+	vault "github.com/hashicorp/vault/api"
+	"github.com/pkg/errors"
+	infrasecrets "github.com/pushwoosh/infra/secrets"
+)
+
+// transitKeyName is a valid name of a transit key, key ids of messages are checked before they get into a Vault path
+var transitKeyName = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// VaultProvider issues and decrypts data keys with a key of Vault transit secrets engine.
+// Master keys never leave Vault, rotating the transit key doesn't require any changes in services.
+// Data keys are decrypted only with the key and the allowed keys, key ids of payloads are not trusted.
+type VaultProvider struct {
+	client  *infrasecrets.Client
+	mount   string
+	key     string
+	allowed map[string]struct{}
+}
+
+var _ KeyProvider = (*VaultProvider)(nil)
+
+// NewVaultProvider creates a provider using the transit key named key of the engine mounted at mount, e.g. "transit".
+// allowedKeys are other transit keys data keys may be decrypted with, e.g. a key payloads are migrated from.
+func NewVaultProvider(client *infrasecrets.Client, mount, key string, allowedKeys ...string) *VaultProvider {
+	allowed := make(map[string]struct{}, len(allowedKeys)+1)
+	allowed[key] = struct{}{}
+	for _, k := range allowedKeys {
+		allowed[k] = struct{}{}
+	}
+
+	return &VaultProvider{
+		client:  client,
+		mount:   mount,
+		key:     key,
+		allowed: allowed,
+	}
+}
+
+func (p *VaultProvider) GenerateDataKey(ctx context.Context) (*DataKey, error) {
+	secret, err := p.client.API().Logical().WriteWithContext(ctx, p.mount+"/datakey/plaintext/"+p.key, map[string]interface{}{
+		"bits": keySize * 8,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to generate data key")
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("empty data key response")
+	}
+
+	encoded, _ := secret.Data["plaintext"].(string)
+	ciphertext, _ := secret.Data["ciphertext"].(string)
+
+	plaintext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(plaintext) != keySize || ciphertext == "" {
+		return nil, errors.New("invalid data key response")
+	}
+
+	return &DataKey{
+		KeyID:     p.key,
+		Plaintext: plaintext,
+		Encrypted: []byte(ciphertext),
+	}, nil
+}
+
+func (p *VaultProvider) DecryptDataKey(ctx context.Context, keyID string, encrypted []byte) ([]byte, error) {
+	if _, ok := p.allowed[keyID]; !ok || !transitKeyName.MatchString(keyID) {
+		return nil, errors.Wrapf(ErrUnknownKey, "%q", keyID)
+	}
+
+	secret, err := p.client.API().Logical().WriteWithContext(ctx, p.mount+"/decrypt/"+keyID, map[string]interface{}{
+		"ciphertext": string(encrypted),
+	})
+	if err != nil {
+		var respErr *vault.ResponseError
+		if errors.As(err, &respErr) && respErr.StatusCode == http.StatusBadRequest {
+			// Vault rejects ciphertexts that are corrupted or encrypted with another key
+			return nil, errors.Wrap(ErrInvalidCiphertext, err.Error())
+		}
+		return nil, errors.Wrap(err, "unable to decrypt data key")
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("empty decrypt response")
+	}
+
+	encoded, _ := secret.Data["plaintext"].(string)
+	plaintext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(plaintext) != keySize {
+		return nil, errors.New("invalid decrypt response")
+	}
+
+	return plaintext, nil
+}
@@ -0,0 +1,49 @@
+package infraerrors
+
+import (
+	"context"
+	"maps"
+)
+
+type infoCtxKeyType string
+
+const infoCtxKey infoCtxKeyType = "error_info"
+
+// info is immutable, every With* call copies it
+type info struct {
+	user   *User
+	tenant string
+	tags   map[string]string
+}
+
+func infoFromContext(ctx context.Context) info {
+	if i, ok := ctx.Value(infoCtxKey).(*info); ok {
+		return *i
+	}
+	return info{}
+}
+
+// WithUser attaches the user to errors captured with the context
+func WithUser(ctx context.Context, user User) context.Context {
+	i := infoFromContext(ctx)
+	i.user = &user
+	return context.WithValue(ctx, infoCtxKey, &i)
+}
+
+// WithTenant attaches the tenant to errors captured with the context
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	i := infoFromContext(ctx)
+	i.tenant = tenant
+	return context.WithValue(ctx, infoCtxKey, &i)
+}
+
+// WithTag attaches a tag to errors captured with the context
+func WithTag(ctx context.Context, key, value string) context.Context {
+	i := infoFromContext(ctx)
+	i.tags = maps.Clone(i.tags)
+	if i.tags == nil {
+		i.tags = make(map[string]string)
+	}
+	i.tags[key] = value
+	return context.WithValue(ctx, infoCtxKey, &i)
+}
@@ -0,0 +1,23 @@
+package infraerrors
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	CapturedCounter *prometheus.CounterVec
+}
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.CapturedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "errors_captured_total",
+			Help: "The total number of errors and panics sent to the error tracker",
+		}, []string{"kind"})
+
+		prometheus.MustRegister(metrics.CapturedCounter)
+	})
+}
@@ -0,0 +1,147 @@
+package infraerrors
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	infralog "github.com/pushwoosh/infra/log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Event is an error report
+type Event struct {
+	Err error
+
+	// Panic is true for recovered panics, Stack is the stack of the panicking goroutine then
+	Panic bool
+	Stack []byte
+
+	// Fields are log fields from the context and the capture call
+	Fields map[string]interface{}
+
+	// Tags are indexed by error trackers, keep their cardinality low
+	Tags map[string]string
+
+	User   *User
+	Tenant string
+}
+
+// User is a user affected by the error
+type User struct {
+	ID        string
+	Email     string
+	IPAddress string
+}
+
+// Reporter sends error events to an error tracker
+type Reporter interface {
+	Capture(ctx context.Context, event *Event)
+
+	// Flush waits until buffered events are sent. It returns false on timeout
+	Flush(timeout time.Duration) bool
+}
+
+// NopReporter drops all events. It's used until a reporter is set with SetReporter
+type NopReporter struct{}
+
+var _ Reporter = NopReporter{}
+
+func (NopReporter) Capture(context.Context, *Event) {}
+
+func (NopReporter) Flush(time.Duration) bool { return true }
+
+var (
+	reporterMu sync.RWMutex
+	reporter   Reporter = NopReporter{}
+)
+
+// SetReporter sets the process wide reporter used by Capture, CapturePanic and infra recovery middlewares
+func SetReporter(r Reporter) {
+	if r == nil {
+		r = NopReporter{}
+	}
+
+	initMetrics()
+
+	reporterMu.Lock()
+	defer reporterMu.Unlock()
+
+	reporter = r
+}
+
+// GetReporter returns the process wide reporter
+func GetReporter() Reporter {
+	reporterMu.RLock()
+	defer reporterMu.RUnlock()
+
+	return reporter
+}
+
+// Capture reports an error with log fields from the context and fields, and user, tenant and tags from the context
+func Capture(ctx context.Context, err error, fields ...zap.Field) {
+	if err == nil {
+		return
+	}
+
+	capture(ctx, &Event{Err: err}, fields)
+}
+
+// CapturePanic reports a recovered panic value. It must be called from the deferred function that recovered it
+// to have the panicking stack:
+//
+//	defer func() {
+//		if rec := recover(); rec != nil {
+//			infraerrors.CapturePanic(ctx, rec)
+//		}
+//	}()
+func CapturePanic(ctx context.Context, rec interface{}, fields ...zap.Field) {
+	err, ok := rec.(error)
+	if !ok {
+		err = fmt.Errorf("%v", rec)
+	}
+
+	capture(ctx, &Event{Err: err, Panic: true, Stack: debug.Stack()}, fields)
+}
+
+// Flush waits until buffered events of the process wide reporter are sent, e.g. before exit
+func Flush(timeout time.Duration) bool {
+	return GetReporter().Flush(timeout)
+}
+
+func capture(ctx context.Context, event *Event, fields []zap.Field) {
+	r := GetReporter()
+	if _, ok := r.(NopReporter); ok {
+		return
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range infralog.FieldsFromContext(ctx) {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	if len(enc.Fields) > 0 {
+		event.Fields = enc.Fields
+	}
+
+	if info, ok := ctx.Value(infoCtxKey).(*info); ok {
+		event.User = info.user
+		event.Tenant = info.tenant
+		event.Tags = info.tags
+	}
+
+	metrics.CapturedCounter.WithLabelValues(kind(event)).Inc()
+	r.Capture(ctx, event)
+}
+
+func kind(event *Event) string {
+	if event.Panic {
+		return "panic"
+	}
+	return "error"
+}
@@ -0,0 +1,64 @@
+package infraerrors
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	infralog "github.com/pushwoosh/infra/log"
+	"go.uber.org/zap"
+)
+
+type fakeReporter struct {
+	events []*Event
+}
+
+func (r *fakeReporter) Capture(_ context.Context, event *Event) {
+	r.events = append(r.events, event)
+}
+
+func (r *fakeReporter) Flush(time.Duration) bool { return true }
+
+func TestCapture(t *testing.T) {
+	reporter := &fakeReporter{}
+	SetReporter(reporter)
+	defer SetReporter(nil)
+
+	ctx := infralog.WithField(context.Background(), zap.String("request_id", "r1"))
+	ctx = WithUser(ctx, User{ID: "u1"})
+	ctx = WithTenant(ctx, "acme")
+	ctx = WithTag(ctx, "component", "billing")
+
+	Capture(ctx, errors.New("boom"), zap.Int("attempt", 2))
+	Capture(ctx, nil)
+
+	func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				CapturePanic(context.Background(), rec)
+			}
+		}()
+		panic("oops")
+	}()
+
+	if len(reporter.events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(reporter.events))
+	}
+
+	event := reporter.events[0]
+	if event.Err.Error() != "boom" || event.Panic {
+		t.Errorf("unexpected event %+v", event)
+	}
+	if event.Fields["request_id"] != "r1" || event.Fields["attempt"] != int64(2) {
+		t.Errorf("unexpected fields %v", event.Fields)
+	}
+	if event.User == nil || event.User.ID != "u1" || event.Tenant != "acme" || event.Tags["component"] != "billing" {
+		t.Errorf("unexpected context info %+v", event)
+	}
+
+	event = reporter.events[1]
+	if !event.Panic || event.Err.Error() != "oops" || len(event.Stack) == 0 {
+		t.Errorf("unexpected panic event %+v", event)
+	}
+}
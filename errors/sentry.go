@@ -0,0 +1,130 @@
+package infraerrors
+
+import (
+	"context"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/pkg/errors"
+	infraoperator "github.com/pushwoosh/infra/operator"
+)
+
+const defaultFlushTimeout = 5 * time.Second
+
+type SentryConfig struct {
+	DSN string `mapstructure:"dsn"`
+
+	// optional
+	Environment string `mapstructure:"environment"`
+
+	// Release version. optional, detected from SENTRY_RELEASE and build info by sentry
+	Release string `mapstructure:"release"`
+
+	// Fraction of reported events, from 0 to 1. optional, default: 1
+	SampleRate float64 `mapstructure:"sample_rate"`
+
+	// How long Stop waits for buffered events. optional, default: 5s
+	FlushTimeout time.Duration `mapstructure:"flush_timeout"`
+}
+
+func (c *SentryConfig) Validate() error {
+	if c == nil {
+		return errors.New("empty sentry config")
+	}
+
+	if c.DSN == "" {
+		return errors.New("dsn is mandatory")
+	}
+
+	if c.SampleRate < 0 || c.SampleRate > 1 {
+		return errors.New("sample_rate should be between 0 and 1")
+	}
+
+	return nil
+}
+
+// SentryReporter sends events to Sentry asynchronously
+type SentryReporter struct {
+	cfg *SentryConfig
+	hub *sentry.Hub
+}
+
+var (
+	_ Reporter              = (*SentryReporter)(nil)
+	_ infraoperator.Stopper = (*SentryReporter)(nil)
+)
+
+func NewSentryReporter(cfg *SentryConfig) (*SentryReporter, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	sampleRate := cfg.SampleRate
+	if sampleRate == 0 {
+		sampleRate = 1
+	}
+
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:              cfg.DSN,
+		Environment:      cfg.Environment,
+		Release:          cfg.Release,
+		SampleRate:       sampleRate,
+		AttachStacktrace: true,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create sentry client")
+	}
+
+	return &SentryReporter{
+		cfg: cfg,
+		hub: sentry.NewHub(client, sentry.NewScope()),
+	}, nil
+}
+
+func (r *SentryReporter) Capture(ctx context.Context, event *Event) {
+	// every event gets its own hub, scopes are not safe for concurrent use
+	hub := r.hub.Clone()
+
+	hub.ConfigureScope(func(scope *sentry.Scope) {
+		if event.User != nil {
+			scope.SetUser(sentry.User{
+				ID:        event.User.ID,
+				Email:     event.User.Email,
+				IPAddress: event.User.IPAddress,
+			})
+		}
+		if event.Tenant != "" {
+			scope.SetTag("tenant", event.Tenant)
+		}
+		scope.SetTags(event.Tags)
+		if len(event.Fields) > 0 {
+			scope.SetContext("fields", event.Fields)
+		}
+	})
+
+	if event.Panic {
+		hub.Scope().SetLevel(sentry.LevelFatal)
+		hub.RecoverWithContext(ctx, event.Err)
+		return
+	}
+
+	hub.CaptureException(event.Err)
+}
+
+func (r *SentryReporter) Flush(timeout time.Duration) bool {
+	return r.hub.Flush(timeout)
+}
+
+// Stop flushes buffered events
+func (r *SentryReporter) Stop(_ context.Context) error {
+	timeout := r.cfg.FlushTimeout
+	if timeout == 0 {
+		timeout = defaultFlushTimeout
+	}
+
+	if !r.hub.Flush(timeout) {
+		return errors.New("unable to flush sentry events in time")
+	}
+
+	return nil
+}
@@ -0,0 +1,299 @@
+package infraes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+	infrabatch "github.com/pushwoosh/infra/batch"
+	infraclock "github.com/pushwoosh/infra/clock"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	"go.uber.org/zap"
+)
+
+// Bulk actions
+const (
+	ActionIndex  = "index"
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// BulkItem is a single bulk operation
+type BulkItem struct {
+	// Action is one of ActionIndex (default), ActionCreate, ActionUpdate, ActionDelete
+	Action string
+	Index  string
+	ID     string
+
+	// Document is a document source. For ActionUpdate it's an update body, e.g. {"doc": {...}}.
+	// It's compacted to a single line if it's []byte or json.RawMessage, otherwise it's encoded to JSON.
+	// Ignored for ActionDelete
+	Document any
+}
+
+// BulkItemResult is a result of a single bulk operation
+type BulkItemResult struct {
+	Index  string `json:"_index"`
+	ID     string `json:"_id"`
+	Status int    `json:"status"`
+	Error  *struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"error,omitempty"`
+}
+
+type BulkOption interface {
+	apply(b *BulkIndexer)
+}
+
+type optionOnError func(ctx context.Context, items []BulkItem, err error)
+
+func (opt optionOnError) apply(b *BulkIndexer) {
+	b.onError = opt
+}
+
+// WithOnError sets a callback called when a whole bulk request fails.
+// By default the error is logged.
+func WithOnError(fn func(ctx context.Context, items []BulkItem, err error)) BulkOption {
+	return optionOnError(fn)
+}
+
+type optionOnFailure func(ctx context.Context, item BulkItem, result BulkItemResult)
+
+func (opt optionOnFailure) apply(b *BulkIndexer) {
+	b.onFailure = opt
+}
+
+// WithOnFailure sets a callback called for every rejected item of a successful bulk request.
+// By default failures are logged.
+func WithOnFailure(fn func(ctx context.Context, item BulkItem, result BulkItemResult)) BulkOption {
+	return optionOnFailure(fn)
+}
+
+type optionClock struct {
+	clock infraclock.Clock
+}
+
+func (opt optionClock) apply(b *BulkIndexer) {
+	b.clock = opt.clock
+}
+
+// WithClock sets a clock of periodic flushes, e.g. a fake one in tests
+func WithClock(clock infraclock.Clock) BulkOption {
+	return optionClock{clock: clock}
+}
+
+// BulkIndexer accumulates operations and sends them with _bulk requests when batch size or items count
+// thresholds are reached and periodically. It's an infrabatch.Batcher: batches are sent in order
+// in background after Start, Add blocks while 10 batches are waiting to be sent.
+type BulkIndexer struct {
+	client  *Client
+	clock   infraclock.Clock
+	batcher *infrabatch.Batcher[bulkEntry]
+
+	onError   func(ctx context.Context, items []BulkItem, err error)
+	onFailure func(ctx context.Context, item BulkItem, result BulkItemResult)
+}
+
+// bulkEntry is an operation with its encoded bulk lines
+type bulkEntry struct {
+	item BulkItem
+	line []byte
+}
+
+var (
+	_ infraoperator.Starter = (*BulkIndexer)(nil)
+	_ infraoperator.Stopper = (*BulkIndexer)(nil)
+)
+
+// NewBulkIndexer creates a bulk indexer. Call Start to begin sending batches
+func NewBulkIndexer(client *Client, cfg *BulkIndexerConfig, opts ...BulkOption) (*BulkIndexer, error) {
+	if cfg == nil {
+		cfg = DefaultBulkIndexerConfig()
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	b := &BulkIndexer{
+		client: client,
+		clock:  infraclock.Real,
+		onError: func(ctx context.Context, items []BulkItem, err error) {
+			infralog.ErrorCtx(ctx, "elasticsearch bulk request failed",
+				zap.String("connection", client.name), zap.Int("items", len(items)), zap.Error(err))
+		},
+		onFailure: func(ctx context.Context, item BulkItem, result BulkItemResult) {
+			fields := []zap.Field{
+				zap.String("connection", client.name),
+				zap.String("action", item.Action),
+				zap.String("index", result.Index),
+				zap.String("id", result.ID),
+				zap.Int("status", result.Status),
+			}
+			if result.Error != nil {
+				fields = append(fields, zap.String("type", result.Error.Type), zap.String("reason", result.Error.Reason))
+			}
+			infralog.ErrorCtx(ctx, "elasticsearch bulk item failed", fields...)
+		},
+	}
+
+	for _, opt := range opts {
+		opt.apply(b)
+	}
+
+	batchCfg := &infrabatch.Config{
+		MaxItems: cfg.FlushItems,
+		MaxBytes: cfg.FlushBytes,
+		MaxAge:   cfg.FlushInterval,
+	}
+	batcher, err := infrabatch.New("es_bulk_"+client.name, batchCfg, b.send,
+		infrabatch.WithSizeFunc(func(entry bulkEntry) int { return len(entry.line) }),
+		infrabatch.WithClock[bulkEntry](b.clock),
+		infrabatch.WithOnError(func(ctx context.Context, entries []bulkEntry, err error) {
+			b.onError(ctx, bulkItems(entries), err)
+		}))
+	if err != nil {
+		return nil, err
+	}
+	b.batcher = batcher
+
+	return b, nil
+}
+
+// Add adds an operation to the current batch. It blocks while 10 batches are waiting to be sent
+func (b *BulkIndexer) Add(ctx context.Context, item BulkItem) error {
+	if item.Action == "" {
+		item.Action = ActionIndex
+	}
+
+	line, err := encodeBulkItem(item)
+	if err != nil {
+		return err
+	}
+
+	return b.batcher.Add(ctx, bulkEntry{item: item, line: line})
+}
+
+// Flush sends the current batch and waits until it and all previous batches are sent.
+// It returns the request error of the last batch, the error is passed to the error callback too
+func (b *BulkIndexer) Flush(ctx context.Context) error {
+	return b.batcher.Flush(ctx)
+}
+
+// Start starts sending batches
+func (b *BulkIndexer) Start(ctx context.Context) error {
+	return b.batcher.Start(ctx)
+}
+
+// Stop sends remaining items and waits until all batches are sent or ctx is done
+func (b *BulkIndexer) Stop(ctx context.Context) error {
+	return b.batcher.Stop(ctx)
+}
+
+// send sends a batch with a _bulk request, rejected items are passed to the failure callback
+func (b *BulkIndexer) send(ctx context.Context, entries []bulkEntry) error {
+	start := b.clock.Now()
+	defer func() {
+		metrics.BulkFlushDuration.WithLabelValues(b.client.name).Observe(b.clock.Now().Sub(start).Seconds())
+	}()
+
+	var body bytes.Buffer
+	for _, entry := range entries {
+		body.Write(entry.line)
+	}
+
+	var resp struct {
+		Errors bool                        `json:"errors"`
+		Items  []map[string]BulkItemResult `json:"items"`
+	}
+
+	err := b.client.perform(ctx, "bulk", http.MethodPost, "/_bulk", "application/x-ndjson", body.Bytes(), &resp)
+	if err == nil && len(resp.Items) != len(entries) {
+		err = errors.Errorf("unexpected bulk response: %d items sent, %d received", len(entries), len(resp.Items))
+	}
+	if err != nil {
+		metrics.BulkItemsCounter.WithLabelValues(b.client.name, "error").Add(float64(len(entries)))
+		return err
+	}
+
+	var failed int
+	for i, entry := range entries {
+		result := resp.Items[i][entry.item.Action]
+		if result.Status >= http.StatusMultipleChoices {
+			failed++
+			b.onFailure(ctx, entry.item, result)
+		}
+	}
+
+	metrics.BulkItemsCounter.WithLabelValues(b.client.name, "success").Add(float64(len(entries) - failed))
+	metrics.BulkItemsCounter.WithLabelValues(b.client.name, "failure").Add(float64(failed))
+
+	return nil
+}
+
+func bulkItems(entries []bulkEntry) []BulkItem {
+	items := make([]BulkItem, len(entries))
+	for i := range entries {
+		items[i] = entries[i].item
+	}
+	return items
+}
+
+func encodeBulkItem(item BulkItem) ([]byte, error) {
+	meta := map[string]map[string]string{
+		item.Action: {"_index": item.Index},
+	}
+	if item.ID != "" {
+		meta[item.Action]["_id"] = item.ID
+	}
+
+	switch item.Action {
+	case ActionIndex, ActionCreate, ActionUpdate, ActionDelete:
+	default:
+		return nil, errors.Errorf("unknown bulk action: %s", item.Action)
+	}
+
+	if item.Index == "" {
+		return nil, errors.New("index is mandatory")
+	}
+
+	if item.ID == "" && item.Action != ActionIndex && item.Action != ActionCreate {
+		return nil, errors.Errorf("id is mandatory for %s action", item.Action)
+	}
+
+	line, err := json.Marshal(meta)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to encode bulk item")
+	}
+	line = append(line, '\n')
+
+	if item.Action == ActionDelete {
+		return line, nil
+	}
+
+	var doc []byte
+	switch raw := item.Document.(type) {
+	case []byte:
+		doc = raw
+	case json.RawMessage:
+		doc = raw
+	default:
+		if doc, err = json.Marshal(item.Document); err != nil {
+			return nil, errors.Wrap(err, "unable to encode document")
+		}
+		return append(append(line, doc...), '\n'), nil
+	}
+
+	// a newline inside the document would split it into two bulk lines
+	buf := bytes.NewBuffer(line)
+	if err = json.Compact(buf, doc); err != nil {
+		return nil, errors.Wrap(err, "invalid document")
+	}
+
+	return append(buf.Bytes(), '\n'), nil
+}
@@ -0,0 +1,120 @@
+package infraes
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBulkIndexerFlush(t *testing.T) {
+	var lines []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_bulk" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"errors": true,
+			"items": []any{
+				map[string]any{"index": map[string]any{"_index": "events", "_id": "1", "status": 201}},
+				map[string]any{"delete": map[string]any{"_index": "events", "_id": "2", "status": 404,
+					"error": map[string]any{"type": "not_found", "reason": "missing"}}},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	client, err := NewClient("test", &ConnectionConfig{Addresses: []string{srv.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var failures []BulkItemResult
+	indexer, err := NewBulkIndexer(client, &BulkIndexerConfig{FlushItems: 2, FlushInterval: 1 << 62},
+		WithOnFailure(func(_ context.Context, _ BulkItem, result BulkItemResult) {
+			failures = append(failures, result)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if err = indexer.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = indexer.Stop(ctx) }()
+
+	if err = indexer.Add(ctx, BulkItem{Index: "events", ID: "1", Document: map[string]int{"a": 1}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 0 {
+		t.Fatal("batch must not be flushed before threshold")
+	}
+
+	if err = indexer.Add(ctx, BulkItem{Action: ActionDelete, Index: "events", ID: "2"}); err != nil {
+		t.Fatal(err)
+	}
+	// the full batch is already sent, Flush waits for it
+	if err = indexer.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		`{"index":{"_id":"1","_index":"events"}}`,
+		`{"a":1}`,
+		`{"delete":{"_id":"2","_index":"events"}}`,
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d lines, got %v", len(expected), lines)
+	}
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Fatalf("line %d: expected %s, got %s", i, expected[i], lines[i])
+		}
+	}
+
+	if len(failures) != 1 || failures[0].ID != "2" || failures[0].Error.Type != "not_found" {
+		t.Fatalf("unexpected failures: %+v", failures)
+	}
+}
+
+func TestClientGetNotFound(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"_index":"events","_id":"1","found":false}`))
+	}))
+	defer srv.Close()
+
+	client, err := NewClient("test", &ConnectionConfig{Addresses: []string{srv.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc map[string]any
+	found, err := client.Get(context.Background(), "events", "1", &doc)
+	if err != nil || found {
+		t.Fatalf("expected missing document, got %v, %v", found, err)
+	}
+}
+
+func TestEncodeBulkItemRawDocument(t *testing.T) {
+	line, err := encodeBulkItem(BulkItem{Action: ActionIndex, Index: "events", Document: []byte("{\n  \"a\": 1\n}\n")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(line) != "{\"index\":{\"_index\":\"events\"}}\n{\"a\":1}\n" {
+		t.Fatalf("unexpected line %q", line)
+	}
+
+	if _, err = encodeBulkItem(BulkItem{Index: "events", Action: ActionIndex, Document: []byte("{\"a\":")}); err == nil {
+		t.Fatal("expected error for invalid document")
+	}
+}
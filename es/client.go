@@ -0,0 +1,247 @@
+package infraes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Client is a thin Elasticsearch/OpenSearch REST client.
+// It uses only API that is common for both engines.
+type Client struct {
+	name    string
+	cfg     *ConnectionConfig
+	http    *http.Client
+	counter atomic.Uint64
+}
+
+// NewClient creates a client. Use Container to hold named clients
+func NewClient(name string, cfg *ConnectionConfig) (*Client, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	initMetrics()
+
+	return &Client{
+		name: name,
+		cfg:  cfg,
+		http: &http.Client{Timeout: cfg.GetTimeout()},
+	}, nil
+}
+
+// Error is a non-successful response of the cluster
+type Error struct {
+	StatusCode int
+	Type       string
+	Reason     string
+}
+
+func (e *Error) Error() string {
+	if e.Type == "" {
+		return fmt.Sprintf("elasticsearch: status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("elasticsearch: status %d: %s: %s", e.StatusCode, e.Type, e.Reason)
+}
+
+// IsNotFound checks if err is a 404 response
+func IsNotFound(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.StatusCode == http.StatusNotFound
+}
+
+// Perform sends a request to the cluster.
+// body is sent as is if it's []byte, otherwise it's encoded to JSON. nil body sends no body.
+// Response is decoded into out if it's not nil.
+// operation is used as metrics label, e.g. "search".
+func (c *Client) Perform(ctx context.Context, operation, method, path string, body, out any) error {
+	return c.perform(ctx, operation, method, path, "application/json", body, out)
+}
+
+func (c *Client) perform(ctx context.Context, operation, method, path, contentType string, body, out any) (err error) {
+	var payload []byte
+	switch b := body.(type) {
+	case nil:
+	case []byte:
+		payload = b
+	default:
+		if payload, err = json.Marshal(body); err != nil {
+			return errors.Wrap(err, "unable to encode request body")
+		}
+	}
+
+	start := time.Now()
+	defer func() {
+		c.observe(ctx, operation, method, path, time.Since(start), err)
+	}()
+
+	var resp *http.Response
+	for attempt := 0; ; attempt++ {
+		resp, err = c.do(ctx, method, path, contentType, payload)
+		if attempt >= c.cfg.MaxRetries || ctx.Err() != nil || !shouldRetry(resp, err) {
+			break
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+	}
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return decodeError(resp)
+	}
+
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	return errors.Wrap(json.NewDecoder(resp.Body).Decode(out), "unable to decode response")
+}
+
+func (c *Client) do(ctx context.Context, method, path, contentType string, payload []byte) (*http.Response, error) {
+	address := c.cfg.Addresses[int(c.counter.Add(1)-1)%len(c.cfg.Addresses)]
+
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(address, "/")+path, body)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create request")
+	}
+
+	if payload != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	switch {
+	case c.cfg.APIKey != "":
+		req.Header.Set("Authorization", "ApiKey "+c.cfg.APIKey)
+	case c.cfg.Username != "":
+		req.SetBasicAuth(c.cfg.Username, c.cfg.Password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "request to %s failed", address)
+	}
+
+	return resp, nil
+}
+
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+func decodeError(resp *http.Response) error {
+	var body struct {
+		Error json.RawMessage `json:"error"`
+	}
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body)
+
+	e := &Error{StatusCode: resp.StatusCode}
+
+	var cause struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal(body.Error, &cause); err == nil {
+		e.Type = cause.Type
+		e.Reason = cause.Reason
+	} else {
+		// some errors are plain strings
+		_ = json.Unmarshal(body.Error, &e.Reason)
+	}
+
+	return e
+}
+
+// Index creates or replaces a document. Id is generated by the cluster if it's empty
+func (c *Client) Index(ctx context.Context, index, id string, doc any) error {
+	if id == "" {
+		return c.Perform(ctx, "index", http.MethodPost, "/"+url.PathEscape(index)+"/_doc", doc, nil)
+	}
+	return c.Perform(ctx, "index", http.MethodPut, docPath(index, id), doc, nil)
+}
+
+// Get fetches document source into out. It returns false if the document doesn't exist
+func (c *Client) Get(ctx context.Context, index, id string, out any) (bool, error) {
+	var resp struct {
+		Found  bool            `json:"found"`
+		Source json.RawMessage `json:"_source"`
+	}
+
+	err := c.Perform(ctx, "get", http.MethodGet, docPath(index, id), nil, &resp)
+	if IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if !resp.Found {
+		return false, nil
+	}
+
+	return true, errors.Wrap(json.Unmarshal(resp.Source, out), "unable to decode document")
+}
+
+// Delete deletes a document. Missing document is not an error
+func (c *Client) Delete(ctx context.Context, index, id string) error {
+	err := c.Perform(ctx, "delete", http.MethodDelete, docPath(index, id), nil, nil)
+	if IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// Search executes a search request and decodes the whole response into out
+func (c *Client) Search(ctx context.Context, index string, query, out any) error {
+	return c.Perform(ctx, "search", http.MethodPost, "/"+url.PathEscape(index)+"/_search", query, out)
+}
+
+// Ping checks that cluster is reachable and its health status is not red
+func (c *Client) Ping(ctx context.Context) error {
+	var health struct {
+		Status string `json:"status"`
+	}
+
+	if err := c.Perform(ctx, "health", http.MethodGet, "/_cluster/health", nil, &health); err != nil {
+		return err
+	}
+
+	if health.Status == "red" {
+		return errors.New("cluster health status is red")
+	}
+
+	return nil
+}
+
+func docPath(index, id string) string {
+	return "/" + url.PathEscape(index) + "/_doc/" + url.PathEscape(id)
+}
@@ -0,0 +1,109 @@
+package infraes
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type ConnectionsConfig map[string]*ConnectionConfig
+
+type ConnectionConfig struct {
+	// Cluster node addresses, e.g. "http://es-1:9200". Requests are balanced over nodes with round-robin
+	Addresses []string `mapstructure:"addresses"`
+
+	// Basic auth credentials. optional
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+
+	// APIKey is a base64 encoded Elasticsearch API key. optional, takes precedence over basic auth
+	APIKey string `mapstructure:"api_key"`
+
+	// Request timeout. Default: DefaultTimeout
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// MaxRetries is the number of attempts on other nodes on network errors and 502, 503, 504 responses
+	MaxRetries int `mapstructure:"max_retries"`
+
+	// Requests that were executed longer than that time are logged. Zero disables slow log
+	SlowLogThreshold time.Duration `mapstructure:"slow_log_threshold"`
+}
+
+const DefaultTimeout = 30 * time.Second
+
+func (c *ConnectionsConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	for name, conf := range *c {
+		if err := conf.Validate(); err != nil {
+			return errors.Wrap(err, name)
+		}
+	}
+
+	return nil
+}
+
+func (c *ConnectionConfig) Validate() error {
+	if c == nil {
+		return errors.New("empty connection config")
+	}
+
+	if len(c.Addresses) == 0 {
+		return errors.New("addresses are mandatory")
+	}
+
+	if c.Timeout < 0 {
+		return errors.New("timeout should be greater than or equal to 0")
+	}
+
+	if c.MaxRetries < 0 {
+		return errors.New("max_retries should be greater than or equal to 0")
+	}
+
+	return nil
+}
+
+func (c *ConnectionConfig) GetTimeout() time.Duration {
+	if c.Timeout <= 0 {
+		return DefaultTimeout
+	}
+
+	return c.Timeout
+}
+
+// BulkIndexerConfig holds bulk indexer flush thresholds. Batch is flushed when any of them is reached
+type BulkIndexerConfig struct {
+	// Max size of a bulk request body in bytes. Default: 5MB
+	FlushBytes int `mapstructure:"flush_bytes"`
+
+	// Max number of items in a bulk request. optional
+	FlushItems int `mapstructure:"flush_items"`
+
+	// Interval of periodic flush. Default: 30s
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+}
+
+func DefaultBulkIndexerConfig() *BulkIndexerConfig {
+	return &BulkIndexerConfig{
+		FlushBytes:    5 << 20,
+		FlushInterval: 30 * time.Second,
+	}
+}
+
+func (c *BulkIndexerConfig) Validate() error {
+	if c == nil {
+		return errors.New("empty bulk indexer config")
+	}
+
+	if c.FlushBytes <= 0 && c.FlushItems <= 0 {
+		return errors.New("flush_bytes or flush_items should be greater than zero")
+	}
+
+	if c.FlushInterval <= 0 {
+		return errors.New("flush_interval should be greater than zero")
+	}
+
+	return nil
+}
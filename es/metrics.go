@@ -0,0 +1,64 @@
+package infraes
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	infralog "github.com/pushwoosh/infra/log"
+	"go.uber.org/zap"
+)
+
+var metrics struct {
+	RequestDurationHistogram *prometheus.HistogramVec
+	BulkItemsCounter         *prometheus.CounterVec
+	BulkFlushDuration        *prometheus.HistogramVec
+}
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.RequestDurationHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "elasticsearch_request_duration",
+			Help:    "The elasticsearch request duration",
+			Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		}, []string{"connection", "operation", "status"})
+
+		metrics.BulkItemsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "elasticsearch_bulk_items_total",
+			Help: "The total number of items sent with bulk indexer",
+		}, []string{"connection", "status"})
+
+		metrics.BulkFlushDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "elasticsearch_bulk_flush_duration",
+			Help:    "The bulk indexer flush duration",
+			Buckets: []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		}, []string{"connection"})
+
+		prometheus.MustRegister(
+			metrics.RequestDurationHistogram,
+			metrics.BulkItemsCounter,
+			metrics.BulkFlushDuration,
+		)
+	})
+}
+
+func (c *Client) observe(ctx context.Context, operation, method, path string, duration time.Duration, err error) {
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+
+	metrics.RequestDurationHistogram.WithLabelValues(c.name, operation, status).Observe(duration.Seconds())
+
+	if c.cfg.SlowLogThreshold > 0 && duration > c.cfg.SlowLogThreshold {
+		infralog.WarnCtx(ctx, "elasticsearch slow request",
+			zap.String("connection", c.name),
+			zap.String("operation", operation),
+			zap.String("method", method),
+			zap.String("path", path),
+			zap.Duration("duration", duration),
+		)
+	}
+}
@@ -0,0 +1,112 @@
+package infraetcd
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type ConnectionsConfig map[string]*ConnectionConfig
+
+type ConnectionConfig struct {
+	// Cluster endpoints, e.g. "https://etcd-1:2379"
+	Endpoints []string `mapstructure:"endpoints"`
+
+	// Credentials. optional
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+
+	// TLS options. TLS is disabled if empty
+	TLS *TLSConfig `mapstructure:"tls"`
+
+	// Dial timeout. Default: DefaultDialTimeout
+	DialTimeout time.Duration `mapstructure:"dial_timeout"`
+
+	// Keepalive options
+	Keepalive *KeepaliveConfig `mapstructure:"keepalive"`
+
+	// Interval of endpoints update with cluster members. optional, disabled if zero
+	AutoSyncInterval time.Duration `mapstructure:"auto_sync_interval"`
+}
+
+type TLSConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+
+	// PEM encoded CA certificate file. System pool is used if empty
+	CAFile string `mapstructure:"ca_file"`
+
+	// PEM encoded client certificate and key files for mutual TLS
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+
+	// Server name used to verify the hostname. Default is the host from the endpoint
+	ServerName string `mapstructure:"server_name"`
+
+	// Disables server certificate verification
+	InsecureSkipVerify bool `mapstructure:"insecure_skip_verify"`
+}
+
+// KeepaliveConfig holds grpc keepalive options
+type KeepaliveConfig struct {
+	Time                time.Duration `mapstructure:"time"`
+	Timeout             time.Duration `mapstructure:"timeout"`
+	PermitWithoutStream bool          `mapstructure:"permit_without_stream"`
+}
+
+const DefaultDialTimeout = 5 * time.Second
+
+func (c *ConnectionsConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	for name, conf := range *c {
+		if err := conf.Validate(); err != nil {
+			return errors.Wrap(err, name)
+		}
+	}
+
+	return nil
+}
+
+func (c *ConnectionConfig) Validate() error {
+	if c == nil {
+		return errors.New("empty connection config")
+	}
+
+	if len(c.Endpoints) == 0 {
+		return errors.New("endpoints are mandatory")
+	}
+
+	if (c.Username == "") != (c.Password == "") {
+		return errors.New("username and password must be set together")
+	}
+
+	if c.TLS != nil {
+		if err := c.TLS.Validate(); err != nil {
+			return errors.Wrap(err, "tls")
+		}
+	}
+
+	return nil
+}
+
+func (c *ConnectionConfig) GetDialTimeout() time.Duration {
+	if c.DialTimeout <= 0 {
+		return DefaultDialTimeout
+	}
+
+	return c.DialTimeout
+}
+
+func (c *TLSConfig) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return errors.New("cert_file and key_file must be set together")
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package infraetcd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectionConfig(t *testing.T) {
+	var cfg *ConnectionConfig
+	require.Error(t, cfg.Validate())
+
+	cfg = &ConnectionConfig{Endpoints: []string{"https://etcd-1:2379"}}
+	require.NoError(t, cfg.Validate())
+	require.Equal(t, DefaultDialTimeout, cfg.GetDialTimeout())
+
+	cfg.DialTimeout = time.Second
+	require.Equal(t, time.Second, cfg.GetDialTimeout())
+
+	for name, invalid := range map[string]*ConnectionConfig{
+		"endpoints":   {},
+		"credentials": {Endpoints: []string{"etcd:2379"}, Username: "app"},
+		"tls":         {Endpoints: []string{"etcd:2379"}, TLS: &TLSConfig{Enabled: true, KeyFile: "client.key"}},
+	} {
+		require.Error(t, invalid.Validate(), name)
+	}
+
+	connections := ConnectionsConfig{"main": cfg, "config": {}}
+	require.ErrorContains(t, connections.Validate(), "config")
+}
+
+func TestTLSConfig(t *testing.T) {
+	tlsCfg, err := (&TLSConfig{Enabled: true, ServerName: "etcd.internal"}).tlsConfig()
+	require.NoError(t, err)
+	require.Equal(t, "etcd.internal", tlsCfg.ServerName)
+	require.Nil(t, tlsCfg.RootCAs)
+
+	_, err = (&TLSConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")}).tlsConfig()
+	require.ErrorContains(t, err, "CA file")
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, []byte("not a certificate"), 0o600))
+	_, err = (&TLSConfig{CAFile: caFile}).tlsConfig()
+	require.ErrorContains(t, err, "no certificates")
+
+	_, err = (&TLSConfig{CertFile: "missing.pem", KeyFile: "missing.key"}).tlsConfig()
+	require.ErrorContains(t, err, "client certificate")
+}
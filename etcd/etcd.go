@@ -0,0 +1,104 @@
+package infraetcd
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+
+	"github.com/pkg/errors"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// Client is an etcd v3 client with metrics and watch helpers
+type Client struct {
+	*clientv3.Client
+
+	name string
+}
+
+// NewClient creates a client. Use Container to hold named clients
+func NewClient(name string, cfg *ConnectionConfig) (*Client, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	initMetrics()
+
+	etcdCfg := clientv3.Config{
+		Endpoints:        cfg.Endpoints,
+		Username:         cfg.Username,
+		Password:         cfg.Password,
+		DialTimeout:      cfg.GetDialTimeout(),
+		AutoSyncInterval: cfg.AutoSyncInterval,
+		DialOptions: []grpc.DialOption{
+			grpc.WithChainUnaryInterceptor(metricsInterceptor(name)),
+		},
+		// client errors are returned to the caller and measured, internal logs are too noisy
+		Logger: zap.NewNop(),
+	}
+
+	if cfg.Keepalive != nil {
+		etcdCfg.DialKeepAliveTime = cfg.Keepalive.Time
+		etcdCfg.DialKeepAliveTimeout = cfg.Keepalive.Timeout
+		etcdCfg.PermitWithoutStream = cfg.Keepalive.PermitWithoutStream
+	}
+
+	if cfg.TLS != nil && cfg.TLS.Enabled {
+		tlsCfg, err := cfg.TLS.tlsConfig()
+		if err != nil {
+			return nil, errors.Wrap(err, "tls")
+		}
+		etcdCfg.TLS = tlsCfg
+	}
+
+	client, err := clientv3.New(etcdCfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create etcd client")
+	}
+
+	return &Client{Client: client, name: name}, nil
+}
+
+// Ping checks that the cluster has a leader and serves reads
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.Get(clientv3.WithRequireLeader(ctx), "health", clientv3.WithCountOnly())
+	if errors.Is(err, rpctypes.ErrPermissionDenied) {
+		// the cluster works, user just has no access to the key
+		return nil
+	}
+
+	return err
+}
+
+func (c *TLSConfig) tlsConfig() (*tls.Config, error) {
+	ret := &tls.Config{
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify, //nolint:gosec
+	}
+
+	if c.CAFile != "" {
+		ca, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read CA file")
+		}
+
+		ret.RootCAs = x509.NewCertPool()
+		if !ret.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, errors.New("no certificates found in CA file")
+		}
+	}
+
+	if c.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to load client certificate")
+		}
+		ret.Certificates = []tls.Certificate{cert}
+	}
+
+	return ret, nil
+}
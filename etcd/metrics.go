@@ -0,0 +1,56 @@
+package infraetcd
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+var metrics struct {
+	RequestDurationHistogram *prometheus.HistogramVec
+	WatchEventsCounter       *prometheus.CounterVec
+	WatchRestartsCounter     *prometheus.CounterVec
+}
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.RequestDurationHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "etcd_request_duration",
+			Help:    "The etcd request duration",
+			Buckets: []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+		}, []string{"connection", "method", "code"})
+
+		metrics.WatchEventsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "etcd_watch_events_total",
+			Help: "The total number of received watch events",
+		}, []string{"connection", "key"})
+
+		metrics.WatchRestartsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "etcd_watch_restarts_total",
+			Help: "The total number of watch restarts by reason: compacted or error",
+		}, []string{"connection", "key", "reason"})
+
+		prometheus.MustRegister(
+			metrics.RequestDurationHistogram,
+			metrics.WatchEventsCounter,
+			metrics.WatchRestartsCounter,
+		)
+	})
+}
+
+// metricsInterceptor measures unary requests duration
+func metricsInterceptor(name string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+
+		metrics.RequestDurationHistogram.WithLabelValues(name, method, status.Code(err).String()).Observe(time.Since(start).Seconds())
+
+		return err
+	}
+}
@@ -0,0 +1,202 @@
+package infraetcd
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	infraretry "github.com/pushwoosh/infra/retry"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+)
+
+const watchRetryDelay = time.Second
+
+// WatchHandler receives key state and changes. All callbacks are called from a single goroutine
+type WatchHandler struct {
+	// OnSnapshot is called with all current key-values on start and
+	// after re-watch when missed revisions were compacted, so the state must be replaced
+	OnSnapshot func(kvs []*mvccpb.KeyValue)
+
+	// OnEvents is called with every batch of changes
+	OnEvents func(events []*clientv3.Event)
+}
+
+type WatchOption interface {
+	apply(w *Watcher)
+}
+
+type watchOptionPrefix bool
+
+func (opt watchOptionPrefix) apply(w *Watcher) {
+	w.prefix = bool(opt)
+}
+
+// WithPrefix watches all keys with the prefix
+func WithPrefix() WatchOption {
+	return watchOptionPrefix(true)
+}
+
+// Watcher watches a key or a prefix and restarts the watch on errors and compaction:
+//
+//	w := client.NewWatcher("/config/", infraetcd.WatchHandler{OnSnapshot: ..., OnEvents: ...}, infraetcd.WithPrefix())
+//	if err := w.Start(ctx); err != nil { ... }
+type Watcher struct {
+	client  *Client
+	key     string
+	prefix  bool
+	handler WatchHandler
+
+	runMu  sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+var (
+	_ infraoperator.Starter = (*Watcher)(nil)
+	_ infraoperator.Stopper = (*Watcher)(nil)
+)
+
+func (c *Client) NewWatcher(key string, handler WatchHandler, opts ...WatchOption) *Watcher {
+	w := &Watcher{
+		client:  c,
+		key:     key,
+		handler: handler,
+	}
+
+	for _, opt := range opts {
+		opt.apply(w)
+	}
+
+	return w
+}
+
+// Start loads the initial snapshot and keeps watching in background
+func (w *Watcher) Start(ctx context.Context) error {
+	w.runMu.Lock()
+	defer w.runMu.Unlock()
+
+	if w.cancel != nil {
+		return errors.New("watcher is already started")
+	}
+
+	revision, err := w.snapshot(ctx)
+	if err != nil {
+		return err
+	}
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.done = make(chan struct{})
+
+	go w.run(runCtx, revision)
+
+	return nil
+}
+
+// Stop stops watching
+func (w *Watcher) Stop(_ context.Context) error {
+	w.runMu.Lock()
+	defer w.runMu.Unlock()
+
+	if w.cancel == nil {
+		return nil
+	}
+
+	w.cancel()
+	<-w.done
+	w.cancel = nil
+
+	return nil
+}
+
+func (w *Watcher) options() []clientv3.OpOption {
+	if w.prefix {
+		return []clientv3.OpOption{clientv3.WithPrefix()}
+	}
+	return nil
+}
+
+// snapshot passes current state to the handler and returns its revision
+func (w *Watcher) snapshot(ctx context.Context) (int64, error) {
+	resp, err := w.client.Get(ctx, w.key, w.options()...)
+	if err != nil {
+		return 0, errors.Wrapf(err, "unable to get %s", w.key)
+	}
+
+	if w.handler.OnSnapshot != nil {
+		w.handler.OnSnapshot(resp.Kvs)
+	}
+
+	return resp.Header.Revision, nil
+}
+
+func (w *Watcher) run(ctx context.Context, revision int64) {
+	defer close(w.done)
+
+	for ctx.Err() == nil {
+		var reason string
+		revision, reason = w.watch(ctx, revision)
+		if ctx.Err() != nil {
+			return
+		}
+
+		metrics.WatchRestartsCounter.WithLabelValues(w.client.name, w.key, reason).Inc()
+
+		if reason == "compacted" {
+			for ctx.Err() == nil {
+				rev, err := w.snapshot(ctx)
+				if err == nil {
+					revision = rev
+					break
+				}
+
+				infralog.Error("etcd watch snapshot failed", zap.String("connection", w.client.name), zap.String("key", w.key), zap.Error(err))
+				_ = infraretry.Sleep(ctx, watchRetryDelay)
+			}
+			continue
+		}
+
+		_ = infraretry.Sleep(ctx, watchRetryDelay)
+	}
+}
+
+// watch watches changes after revision until an error.
+// It returns the last seen revision and a restart reason.
+func (w *Watcher) watch(ctx context.Context, revision int64) (int64, string) {
+	watchCtx, cancel := context.WithCancel(clientv3.WithRequireLeader(ctx))
+	defer cancel()
+
+	opts := append(w.options(), clientv3.WithRev(revision+1))
+	for resp := range w.client.Watch(watchCtx, w.key, opts...) {
+		if resp.CompactRevision != 0 {
+			infralog.Warn("etcd watch revision compacted, reloading",
+				zap.String("connection", w.client.name),
+				zap.String("key", w.key),
+				zap.Int64("revision", revision),
+				zap.Int64("compact_revision", resp.CompactRevision),
+			)
+			return revision, "compacted"
+		}
+
+		if err := resp.Err(); err != nil {
+			infralog.Error("etcd watch failed", zap.String("connection", w.client.name), zap.String("key", w.key), zap.Error(err))
+			return revision, "error"
+		}
+
+		if len(resp.Events) > 0 {
+			metrics.WatchEventsCounter.WithLabelValues(w.client.name, w.key).Add(float64(len(resp.Events)))
+			if w.handler.OnEvents != nil {
+				w.handler.OnEvents(resp.Events)
+			}
+		}
+
+		revision = resp.Header.Revision
+	}
+
+	return revision, "error"
+}
@@ -0,0 +1,105 @@
+package infraetcd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// testKV returns the next response on every Get
+type testKV struct {
+	clientv3.KV
+	responses chan *clientv3.GetResponse
+}
+
+func (kv *testKV) Get(_ context.Context, _ string, _ ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	return <-kv.responses, nil
+}
+
+// testWatcher passes the watch revision and returns a channel controlled by the test
+type testWatcher struct {
+	clientv3.Watcher
+	watches chan testWatch
+}
+
+type testWatch struct {
+	rev       int64
+	prefix    bool
+	responses chan clientv3.WatchResponse
+}
+
+func (w *testWatcher) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	op := clientv3.OpGet(key, opts...)
+	responses := make(chan clientv3.WatchResponse)
+	w.watches <- testWatch{rev: op.Rev(), prefix: len(op.RangeBytes()) > 0, responses: responses}
+
+	ret := make(chan clientv3.WatchResponse)
+	go func() {
+		defer close(ret)
+		for {
+			select {
+			case resp := <-responses:
+				ret <- resp
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ret
+}
+
+func getResponse(revision int64, kvs ...*mvccpb.KeyValue) *clientv3.GetResponse {
+	return &clientv3.GetResponse{Header: &pb.ResponseHeader{Revision: revision}, Kvs: kvs}
+}
+
+func TestWatcher(t *testing.T) {
+	initMetrics()
+
+	kv := &testKV{responses: make(chan *clientv3.GetResponse, 2)}
+	watcher := &testWatcher{watches: make(chan testWatch, 1)}
+	client := &Client{Client: &clientv3.Client{KV: kv, Watcher: watcher}, name: "test"}
+
+	snapshots := make(chan []*mvccpb.KeyValue, 2)
+	events := make(chan []*clientv3.Event, 1)
+	w := client.NewWatcher("/config/", WatchHandler{
+		OnSnapshot: func(kvs []*mvccpb.KeyValue) { snapshots <- kvs },
+		OnEvents:   func(e []*clientv3.Event) { events <- e },
+	}, WithPrefix())
+
+	ctx := context.Background()
+	kv.responses <- getResponse(10, &mvccpb.KeyValue{Key: []byte("/config/a"), Value: []byte("1")})
+	require.NoError(t, w.Start(ctx))
+	require.Error(t, w.Start(ctx))
+	require.Len(t, <-snapshots, 1)
+
+	// changes are watched after the snapshot revision
+	watch := <-watcher.watches
+	require.Equal(t, int64(11), watch.rev)
+	require.True(t, watch.prefix)
+
+	watch.responses <- clientv3.WatchResponse{
+		Header: pb.ResponseHeader{Revision: 12},
+		Events: []*clientv3.Event{{Type: clientv3.EventTypePut, Kv: &mvccpb.KeyValue{Key: []byte("/config/b")}}},
+	}
+	require.Len(t, <-events, 1)
+
+	// compacted revisions are replaced with a new snapshot
+	kv.responses <- getResponse(20)
+	watch.responses <- clientv3.WatchResponse{Header: pb.ResponseHeader{Revision: 15}, CompactRevision: 15}
+	require.Empty(t, <-snapshots)
+
+	select {
+	case watch = <-watcher.watches:
+		require.Equal(t, int64(21), watch.rev)
+	case <-time.After(time.Second * 5):
+		t.Fatal("watch is not restarted")
+	}
+
+	require.NoError(t, w.Stop(ctx))
+	require.NoError(t, w.Stop(ctx))
+}
@@ -0,0 +1,152 @@
+package infraeventbus
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.uber.org/zap"
+)
+
+// Envelope is a broker independent event format
+type Envelope struct {
+	ID   string    `json:"id"`
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+
+	// Key is a partitioning key. Events with the same key are delivered in order by brokers that support it
+	Key string `json:"key,omitempty"`
+
+	// Trace is a propagated trace context, e.g. "traceparent"
+	Trace map[string]string `json:"trace,omitempty"`
+
+	Data json.RawMessage `json:"data"`
+}
+
+// Handler processes an event. Returned error means the event should be delivered again
+type Handler func(ctx context.Context, env *Envelope) error
+
+// Publisher sends envelopes to a topic
+type Publisher interface {
+	Publish(ctx context.Context, topic string, env *Envelope) error
+}
+
+// Subscriber delivers envelopes of a topic to a handler until it's closed
+type Subscriber interface {
+	Subscribe(topic string, handler Handler) error
+	Close() error
+}
+
+// Topic binds a topic name with an event type:
+//
+//	var UserCreated = infraeventbus.NewTopic[UserCreatedEvent]("users", "user.created")
+//
+//	err := infraeventbus.Publish(ctx, publisher, UserCreated, UserCreatedEvent{ID: 1})
+//	err := infraeventbus.Subscribe(subscriber, UserCreated, func(ctx context.Context, e UserCreatedEvent, env *infraeventbus.Envelope) error { ... })
+type Topic[T any] struct {
+	Name string
+	Type string
+}
+
+func NewTopic[T any](name, eventType string) Topic[T] {
+	return Topic[T]{Name: name, Type: eventType}
+}
+
+type PublishOption interface {
+	apply(env *Envelope)
+}
+
+type optionKey string
+
+func (opt optionKey) apply(env *Envelope) {
+	env.Key = string(opt)
+}
+
+// WithKey sets event partitioning key
+func WithKey(key string) PublishOption {
+	return optionKey(key)
+}
+
+type optionID string
+
+func (opt optionID) apply(env *Envelope) {
+	env.ID = string(opt)
+}
+
+// WithID sets event id instead of a generated one, e.g. to make retried publishing idempotent
+func WithID(id string) PublishOption {
+	return optionID(id)
+}
+
+// Publish wraps event into an envelope with trace context from ctx and publishes it
+func Publish[T any](ctx context.Context, p Publisher, topic Topic[T], event T, opts ...PublishOption) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrapf(err, "unable to encode %s event", topic.Type)
+	}
+
+	env := &Envelope{
+		ID:   uuid.NewString(),
+		Type: topic.Type,
+		Time: time.Now().UTC(),
+		Data: data,
+	}
+
+	for _, opt := range opts {
+		opt.apply(env)
+	}
+
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) > 0 {
+		env.Trace = carrier
+	}
+
+	return p.Publish(ctx, topic.Name, env)
+}
+
+// Subscribe decodes events of the topic type and passes them to handler.
+// Events of other types in the same topic are skipped.
+func Subscribe[T any](s Subscriber, topic Topic[T], handler func(ctx context.Context, event T, env *Envelope) error) error {
+	initMetrics()
+
+	return s.Subscribe(topic.Name, func(ctx context.Context, env *Envelope) error {
+		if env.Type != topic.Type {
+			return nil
+		}
+
+		var event T
+		if err := json.Unmarshal(env.Data, &event); err != nil {
+			// redelivery won't help
+			metrics.DecodeErrorsCounter.WithLabelValues(topic.Name).Inc()
+			infralog.ErrorCtx(ctx, "unable to decode event", zap.String("topic", topic.Name), zap.String("id", env.ID), zap.Error(err))
+			return nil
+		}
+
+		return handler(ctx, event, env)
+	})
+}
+
+func encodeEnvelope(env *Envelope) ([]byte, error) {
+	body, err := json.Marshal(env)
+	return body, errors.Wrap(err, "unable to encode envelope")
+}
+
+// decodeEnvelope decodes an envelope and returns a context with the propagated trace
+func decodeEnvelope(ctx context.Context, body []byte) (context.Context, *Envelope, error) {
+	var env Envelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		return ctx, nil, errors.Wrap(err, "unable to decode envelope")
+	}
+
+	if len(env.Trace) > 0 {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(env.Trace))
+	}
+
+	return ctx, &env, nil
+}
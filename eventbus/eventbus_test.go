@@ -0,0 +1,79 @@
+package infraeventbus
+
+import (
+	"context"
+	"testing"
+)
+
+// memoryBus delivers published envelopes synchronously
+type memoryBus struct {
+	handlers map[string][]Handler
+}
+
+func (b *memoryBus) Publish(ctx context.Context, topic string, env *Envelope) error {
+	body, err := encodeEnvelope(env)
+	if err != nil {
+		return err
+	}
+
+	for _, h := range b.handlers[topic] {
+		ctx, decoded, err := decodeEnvelope(ctx, body)
+		if err != nil {
+			return err
+		}
+		if err = h(ctx, decoded); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *memoryBus) Subscribe(topic string, handler Handler) error {
+	b.handlers[topic] = append(b.handlers[topic], handler)
+	return nil
+}
+
+func (b *memoryBus) Close() error { return nil }
+
+type userCreated struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type userDeleted struct {
+	ID int `json:"id"`
+}
+
+func TestTypedTopics(t *testing.T) {
+	bus := &memoryBus{handlers: map[string][]Handler{}}
+	created := NewTopic[userCreated]("users", "user.created")
+	deleted := NewTopic[userDeleted]("users", "user.deleted")
+
+	var (
+		got      []userCreated
+		envelope *Envelope
+	)
+	err := Subscribe(bus, created, func(_ context.Context, e userCreated, env *Envelope) error {
+		got = append(got, e)
+		envelope = env
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if err = Publish(ctx, bus, created, userCreated{ID: 1, Name: "john"}, WithKey("1")); err != nil {
+		t.Fatal(err)
+	}
+	if err = Publish(ctx, bus, deleted, userDeleted{ID: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 1 || got[0].Name != "john" {
+		t.Fatalf("unexpected events: %v", got)
+	}
+	if envelope.ID == "" || envelope.Type != "user.created" || envelope.Key != "1" || envelope.Time.IsZero() {
+		t.Fatalf("unexpected envelope: %+v", envelope)
+	}
+}
@@ -0,0 +1,119 @@
+package infraeventbus
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	infrakafka "github.com/pushwoosh/infra/kafka"
+	infralog "github.com/pushwoosh/infra/log"
+	"go.uber.org/zap"
+)
+
+const backendKafka = "kafka"
+
+// KafkaPublisher publishes envelopes to a kafka topic with envelope key as message key.
+// The producer is asynchronous, delivery errors are reported to its OnDelivery callback.
+type KafkaPublisher struct {
+	producer *infrakafka.Producer
+}
+
+var _ Publisher = (*KafkaPublisher)(nil)
+
+func NewKafkaPublisher(producer *infrakafka.Producer) *KafkaPublisher {
+	initMetrics()
+
+	return &KafkaPublisher{producer: producer}
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, topic string, env *Envelope) error {
+	body, err := encodeEnvelope(env)
+	if err != nil {
+		return err
+	}
+
+	msg := &infrakafka.ProducerMessage{
+		Topic:   topic,
+		Body:    body,
+		Headers: map[string]string{"type": env.Type, "id": env.ID},
+	}
+	if env.Key != "" {
+		msg.Key = []byte(env.Key)
+	}
+
+	err = p.producer.Produce(ctx, msg)
+	metrics.PublishedCounter.WithLabelValues(backendKafka, topic, status(err)).Inc()
+
+	return err
+}
+
+// KafkaSubscriber creates a consumer group member per topic.
+// Failed events are retried according to consumer config, malformed ones are skipped.
+type KafkaSubscriber struct {
+	container  *infrakafka.Container
+	connection string
+	groupID    string
+
+	mu        sync.Mutex
+	consumers []*infrakafka.Consumer
+}
+
+var _ Subscriber = (*KafkaSubscriber)(nil)
+
+func NewKafkaSubscriber(container *infrakafka.Container, connection, groupID string) *KafkaSubscriber {
+	initMetrics()
+
+	return &KafkaSubscriber{
+		container:  container,
+		connection: connection,
+		groupID:    groupID,
+	}
+}
+
+func (s *KafkaSubscriber) Subscribe(topic string, handler Handler) error {
+	consumer, err := s.container.CreateGroupConsumer(&infrakafka.ConsumerConfig{
+		ConnectionName: s.connection,
+		GroupID:        s.groupID,
+		Topics:         []string{topic},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "unable to subscribe to %s", topic)
+	}
+
+	s.mu.Lock()
+	s.consumers = append(s.consumers, consumer)
+	s.mu.Unlock()
+
+	consumer.Consume(func(ctx context.Context, msg *infrakafka.Message) error {
+		ctx, env, err := decodeEnvelope(ctx, msg.Body())
+		if err != nil {
+			metrics.DecodeErrorsCounter.WithLabelValues(topic).Inc()
+			infralog.Error("unable to decode event", zap.String("topic", topic), zap.Error(err))
+			return nil
+		}
+
+		err = handler(ctx, env)
+		metrics.HandledCounter.WithLabelValues(backendKafka, topic, status(err)).Inc()
+
+		return err
+	})
+
+	return nil
+}
+
+// Close stops all consumers and waits for events in progress
+func (s *KafkaSubscriber) Close() error {
+	s.mu.Lock()
+	consumers := s.consumers
+	s.consumers = nil
+	s.mu.Unlock()
+
+	var firstErr error
+	for _, consumer := range consumers {
+		if err := consumer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
@@ -0,0 +1,47 @@
+package infraeventbus
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	inframetrics "github.com/pushwoosh/infra/metrics"
+)
+
+var metrics struct {
+	PublishedCounter    *inframetrics.CounterVec
+	HandledCounter      *inframetrics.CounterVec
+	DecodeErrorsCounter *inframetrics.CounterVec
+}
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.PublishedCounter = inframetrics.NewCounterVec(prometheus.CounterOpts{
+			Name: "eventbus_published_total",
+			Help: "The total number of published events",
+		}, []string{"backend", "topic", "status"})
+
+		metrics.HandledCounter = inframetrics.NewCounterVec(prometheus.CounterOpts{
+			Name: "eventbus_handled_total",
+			Help: "The total number of handled events",
+		}, []string{"backend", "topic", "status"})
+
+		metrics.DecodeErrorsCounter = inframetrics.NewCounterVec(prometheus.CounterOpts{
+			Name: "eventbus_decode_errors_total",
+			Help: "The total number of skipped events that could not be decoded",
+		}, []string{"topic"})
+
+		prometheus.MustRegister(
+			metrics.PublishedCounter,
+			metrics.HandledCounter,
+			metrics.DecodeErrorsCounter,
+		)
+	})
+}
+
+func status(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
@@ -0,0 +1,140 @@
+package infraeventbus
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	infrarabbit "github.com/pushwoosh/infra/rabbit"
+	"go.uber.org/zap"
+)
+
+const backendRabbit = "rabbit"
+
+// RabbitPublisher publishes envelopes to an exchange with topic name as routing key
+type RabbitPublisher struct {
+	producer *infrarabbit.Producer
+	exchange string
+}
+
+var _ Publisher = (*RabbitPublisher)(nil)
+
+func NewRabbitPublisher(producer *infrarabbit.Producer, exchange string) *RabbitPublisher {
+	initMetrics()
+
+	return &RabbitPublisher{producer: producer, exchange: exchange}
+}
+
+func (p *RabbitPublisher) Publish(ctx context.Context, topic string, env *Envelope) error {
+	body, err := encodeEnvelope(env)
+	if err != nil {
+		return err
+	}
+
+	err = p.producer.Produce(ctx, &infrarabbit.ProducerMessage{
+		Body:       body,
+		Exchange:   p.exchange,
+		RoutingKey: topic,
+		MessageID:  env.ID,
+		Headers:    map[string]interface{}{"type": env.Type},
+	})
+	metrics.PublishedCounter.WithLabelValues(backendRabbit, topic, status(err)).Inc()
+
+	return err
+}
+
+// RabbitSubscriber consumes a queue per topic. Queues must be bound to the exchange
+// with topic name as routing key, e.g. with producer bindings.
+// Handled events are acked, failed ones are requeued, malformed ones are dropped.
+type RabbitSubscriber struct {
+	container  *infrarabbit.Container
+	connection string
+	queue      func(topic string) string
+
+	mu        sync.Mutex
+	consumers []*infrarabbit.Consumer
+	wg        sync.WaitGroup
+}
+
+var _ Subscriber = (*RabbitSubscriber)(nil)
+
+// NewRabbitSubscriber creates a subscriber. queue maps topic to a queue name, topic name is used if it's nil
+func NewRabbitSubscriber(container *infrarabbit.Container, connection string, queue func(topic string) string) *RabbitSubscriber {
+	initMetrics()
+
+	if queue == nil {
+		queue = func(topic string) string { return topic }
+	}
+
+	return &RabbitSubscriber{
+		container:  container,
+		connection: connection,
+		queue:      queue,
+	}
+}
+
+func (s *RabbitSubscriber) Subscribe(topic string, handler Handler) error {
+	consumer, err := s.container.CreateConsumer(&infrarabbit.ConsumerConfig{
+		ConnectionName: s.connection,
+		Queue:          s.queue(topic),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "unable to subscribe to %s", topic)
+	}
+
+	s.mu.Lock()
+	s.consumers = append(s.consumers, consumer)
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		for msg := range consumer.Consume() {
+			s.handle(topic, msg, handler)
+		}
+	}()
+
+	return nil
+}
+
+func (s *RabbitSubscriber) handle(topic string, msg *infrarabbit.Message, handler Handler) {
+	ctx, env, err := decodeEnvelope(context.Background(), msg.Body())
+	if err != nil {
+		metrics.DecodeErrorsCounter.WithLabelValues(topic).Inc()
+		infralog.Error("unable to decode event", zap.String("topic", topic), zap.Error(err))
+		_ = msg.Ack()
+		return
+	}
+
+	err = handler(ctx, env)
+	metrics.HandledCounter.WithLabelValues(backendRabbit, topic, status(err)).Inc()
+
+	if err != nil {
+		infralog.ErrorCtx(ctx, "unable to handle event", zap.String("topic", topic), zap.String("id", env.ID), zap.Error(err))
+		_ = msg.Nack()
+		return
+	}
+
+	_ = msg.Ack()
+}
+
+// Close stops all consumers and waits for events in progress
+func (s *RabbitSubscriber) Close() error {
+	s.mu.Lock()
+	consumers := s.consumers
+	s.consumers = nil
+	s.mu.Unlock()
+
+	var firstErr error
+	for _, consumer := range consumers {
+		if err := consumer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	s.wg.Wait()
+
+	return firstErr
+}
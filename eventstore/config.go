@@ -0,0 +1,127 @@
+package infraeventstore
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var tableName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*(\.[a-zA-Z_][a-zA-Z0-9_]*)?$`)
+
+type Config struct {
+	// Table of events, optionally with a database. optional, default: events
+	Table string `mapstructure:"table"`
+
+	// Table of snapshots. optional, default: <table>_snapshots
+	SnapshotTable string `mapstructure:"snapshot_table"`
+
+	// Table of subscription checkpoints. optional, default: <table>_checkpoints
+	CheckpointTable string `mapstructure:"checkpoint_table"`
+
+	// ManageSchema creates the tables on Start
+	ManageSchema bool `mapstructure:"manage_schema"`
+
+	// LockTTL is a ttl of stream locks taken by Append with a distributed locker. optional, default: 10s
+	LockTTL time.Duration `mapstructure:"lock_ttl"`
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	for _, table := range []string{c.Table, c.SnapshotTable, c.CheckpointTable} {
+		if table != "" && !tableName.MatchString(table) {
+			return errors.Errorf("invalid table name %q", table)
+		}
+	}
+
+	if c.LockTTL < 0 {
+		return errors.New("lock_ttl should be greater than or equal to 0")
+	}
+
+	return nil
+}
+
+func (c *Config) GetTable() string {
+	if c.Table == "" {
+		return "events"
+	}
+	return c.Table
+}
+
+func (c *Config) GetSnapshotTable() string {
+	if c.SnapshotTable == "" {
+		return c.GetTable() + "_snapshots"
+	}
+	return c.SnapshotTable
+}
+
+func (c *Config) GetCheckpointTable() string {
+	if c.CheckpointTable == "" {
+		return c.GetTable() + "_checkpoints"
+	}
+	return c.CheckpointTable
+}
+
+func (c *Config) GetLockTTL() time.Duration {
+	if c.LockTTL == 0 {
+		return 10 * time.Second
+	}
+	return c.LockTTL
+}
+
+type SubscriptionConfig struct {
+	// How often new events are polled when the subscription caught up. optional, default: 1s
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+
+	// Max number of events read at once. optional, default: 1000
+	BatchSize int `mapstructure:"batch_size"`
+
+	// Events younger than that are not read yet: inserts of concurrent writers may become visible
+	// out of time order, so the delay should cover the longest insert and clock skew of writers.
+	// optional, default: 5s
+	SettleDelay time.Duration `mapstructure:"settle_delay"`
+}
+
+func (c *SubscriptionConfig) Validate() error {
+	if c == nil {
+		return errors.New("empty subscription config")
+	}
+
+	if c.PollInterval < 0 {
+		return errors.New("poll_interval should be greater than or equal to 0")
+	}
+
+	if c.BatchSize < 0 {
+		return errors.New("batch_size should be greater than or equal to 0")
+	}
+
+	if c.SettleDelay < 0 {
+		return errors.New("settle_delay should be greater than or equal to 0")
+	}
+
+	return nil
+}
+
+func (c *SubscriptionConfig) GetPollInterval() time.Duration {
+	if c.PollInterval == 0 {
+		return time.Second
+	}
+	return c.PollInterval
+}
+
+func (c *SubscriptionConfig) GetBatchSize() int {
+	if c.BatchSize == 0 {
+		return 1000
+	}
+	return c.BatchSize
+}
+
+func (c *SubscriptionConfig) GetSettleDelay() time.Duration {
+	if c.SettleDelay == 0 {
+		return 5 * time.Second
+	}
+	return c.SettleDelay
+}
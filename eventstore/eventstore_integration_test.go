@@ -0,0 +1,89 @@
+//go:build integration
+
+package infraeventstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	infraclickhouse "github.com/pushwoosh/infra/clickhouse"
+	infraclock "github.com/pushwoosh/infra/clock"
+	infratestcontainers "github.com/pushwoosh/infra/test/containers"
+)
+
+func TestStore(t *testing.T) {
+	cfg := infratestcontainers.ClickHouse(t)
+
+	cont := infraclickhouse.NewContainer()
+	if err := cont.Connect("test", cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	clock := infraclock.NewFake(time.Now())
+	store, err := New(cont.Get("test"), &Config{ManageSchema: true}, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = store.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	version, err := store.Append(ctx, "order-1", NoStream,
+		&Event{Type: "order.created", Payload: []byte(`{"total":10}`)},
+		&Event{Type: "order.paid"})
+	if err != nil || version != 2 {
+		t.Fatalf("unexpected append result %d %v", version, err)
+	}
+
+	if _, err = store.Append(ctx, "order-1", 1, &Event{Type: "order.canceled"}); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("expected version conflict, got %v", err)
+	}
+
+	if err = store.SaveSnapshot(ctx, &Snapshot{StreamID: "order-1", Version: 1, State: []byte(`{"total":10}`)}); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, events, err := store.Load(ctx, "order-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot == nil || snapshot.Version != 1 || len(events) != 1 || events[0].Type != "order.paid" {
+		t.Fatalf("unexpected stream %+v %+v", snapshot, events)
+	}
+
+	handled := make(chan *Event, 10)
+	sub, err := store.Subscribe("test", func(_ context.Context, event *Event) error {
+		handled <- event
+		return nil
+	}, &SubscriptionConfig{PollInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// events are read once they are older than the settle delay
+	clock.Advance(10 * time.Second)
+	if err = sub.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []uint64{1, 2} {
+		select {
+		case event := <-handled:
+			if event.Version != expected {
+				t.Fatalf("expected version %d, got %d", expected, event.Version)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatal("expected event to be handled")
+		}
+	}
+	if err = sub.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	position, err := store.Checkpoint(ctx, "test")
+	if err != nil || position.StreamID != "order-1" || position.Version != 2 {
+		t.Fatalf("unexpected checkpoint %+v %v", position, err)
+	}
+}
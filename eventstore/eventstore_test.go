@@ -0,0 +1,17 @@
+package infraeventstore
+
+import (
+	"testing"
+)
+
+func TestWithContextMetadata(t *testing.T) {
+	metadata := withContextMetadata(nil, "req-1", "")
+	if len(metadata) != 1 || metadata["request_id"] != "req-1" {
+		t.Fatalf("unexpected metadata %v", metadata)
+	}
+
+	metadata = withContextMetadata(map[string]string{"tenant": "own"}, "", "ctx")
+	if metadata["tenant"] != "own" {
+		t.Fatalf("expected explicit tenant to be kept, got %v", metadata)
+	}
+}
@@ -0,0 +1,46 @@
+package infraeventstore
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	AppendedCounter  *prometheus.CounterVec
+	ConflictsCounter *prometheus.CounterVec
+	HandledCounter   *prometheus.CounterVec
+	LagGauge         *prometheus.GaugeVec
+}
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.AppendedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "eventstore_appended_total",
+			Help: "The total number of appended events",
+		}, []string{"table"})
+
+		metrics.ConflictsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "eventstore_conflicts_total",
+			Help: "The total number of appends rejected by the expected version",
+		}, []string{"table"})
+
+		metrics.HandledCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "eventstore_subscription_handled_total",
+			Help: "The total number of events handled by subscriptions",
+		}, []string{"subscription", "result"})
+
+		metrics.LagGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "eventstore_subscription_lag_seconds",
+			Help: "Age of the last event handled by the subscription",
+		}, []string{"subscription"})
+
+		prometheus.MustRegister(
+			metrics.AppendedCounter,
+			metrics.ConflictsCounter,
+			metrics.HandledCounter,
+			metrics.LagGauge,
+		)
+	})
+}
@@ -0,0 +1,37 @@
+package infraeventstore
+
+import (
+	infraclock "github.com/pushwoosh/infra/clock"
+	infralock "github.com/pushwoosh/infra/lock"
+)
+
+type Option interface {
+	apply(s *Store)
+}
+
+type optionLocker struct {
+	locker infralock.Locker
+}
+
+func (opt optionLocker) apply(s *Store) {
+	s.locker = opt.locker
+}
+
+// WithLocker sets a distributed locker of streams. It's required when streams are appended
+// by several instances, otherwise the expected version is checked within the process only
+func WithLocker(locker infralock.Locker) Option {
+	return optionLocker{locker: locker}
+}
+
+type optionClock struct {
+	clock infraclock.Clock
+}
+
+func (opt optionClock) apply(s *Store) {
+	s.clock = opt.clock
+}
+
+// WithClock sets a clock of event times, e.g. a fake one in tests
+func WithClock(clock infraclock.Clock) Option {
+	return optionClock{clock: clock}
+}
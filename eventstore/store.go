@@ -0,0 +1,344 @@
+package infraeventstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	infraclickhouse "github.com/pushwoosh/infra/clickhouse"
+	infraclock "github.com/pushwoosh/infra/clock"
+	infraconcurrency "github.com/pushwoosh/infra/concurrency"
+	infralock "github.com/pushwoosh/infra/lock"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	infrarequestid "github.com/pushwoosh/infra/requestid"
+	infratenancy "github.com/pushwoosh/infra/tenancy"
+)
+
+const (
+	eventColumns    = "stream_id, version, type, payload, metadata, ts"
+	snapshotColumns = "stream_id, version, state, ts"
+
+	// AnyVersion disables the expected version check of Append
+	AnyVersion int64 = -1
+	// NoStream is the expected version of a stream without events
+	NoStream int64 = 0
+)
+
+// ErrVersionConflict is returned by Append when the stream version isn't the expected one
+var ErrVersionConflict = errors.New("stream version conflict")
+
+// Event is a stored event. Versions of a stream start from 1 and have no gaps
+type Event struct {
+	StreamID string            `json:"stream_id"`
+	Version  uint64            `json:"version"`
+	Type     string            `json:"type"`
+	Payload  []byte            `json:"payload"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Time     time.Time         `json:"time"`
+}
+
+// Snapshot is a state of a stream built from its events up to Version
+type Snapshot struct {
+	StreamID string    `json:"stream_id"`
+	Version  uint64    `json:"version"`
+	State    []byte    `json:"state"`
+	Time     time.Time `json:"time"`
+}
+
+// Store is an append-only event store on ClickHouse. Events are appended to streams with optimistic concurrency:
+// an append fails with ErrVersionConflict if the stream was changed since it was read:
+//
+//	store, err := infraeventstore.New(chContainer.Get("events"), cfg, infraeventstore.WithLocker(locker))
+//	app.Add("eventstore", store)
+//
+//	snapshot, events, err := store.Load(ctx, "order-42")
+//	order := replay(snapshot, events)
+//	_, err = store.Append(ctx, "order-42", int64(order.Version), &infraeventstore.Event{Type: "order.paid", Payload: payload})
+//	if errors.Is(err, infraeventstore.ErrVersionConflict) {
+//		// reload the stream and retry the command
+//	}
+//
+// ClickHouse has no transactions, so appends of a stream are serialized by a lock: a process mutex
+// and a distributed lock if the store is created WithLocker.
+type Store struct {
+	db     *sql.DB
+	cfg    *Config
+	locker infralock.Locker
+	local  *infraconcurrency.KeyedMutex
+	clock  infraclock.Clock
+}
+
+var _ infraoperator.Starter = (*Store)(nil)
+
+func New(db *sql.DB, cfg *Config, opts ...Option) (*Store, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	initMetrics()
+
+	s := &Store{
+		db:    db,
+		cfg:   cfg,
+		local: infraconcurrency.NewKeyedMutex("eventstore_" + cfg.GetTable()),
+		clock: infraclock.Real,
+	}
+
+	for _, opt := range opts {
+		opt.apply(s)
+	}
+
+	return s, nil
+}
+
+// Start creates the tables if ManageSchema is set
+func (s *Store) Start(ctx context.Context) error {
+	if s.cfg.ManageSchema {
+		return s.EnsureTables(ctx)
+	}
+	return nil
+}
+
+// EnsureTables creates tables of events, snapshots and checkpoints if they don't exist
+func (s *Store) EnsureTables(ctx context.Context) error {
+	queries := map[string]string{
+		s.cfg.GetTable(): `CREATE TABLE IF NOT EXISTS %s (
+	stream_id String,
+	version UInt64,
+	type LowCardinality(String),
+	payload String,
+	metadata Map(String, String),
+	ts DateTime64(6),
+	INDEX ts_idx ts TYPE minmax GRANULARITY 1
+) ENGINE = MergeTree
+ORDER BY (stream_id, version)
+SETTINGS non_replicated_deduplication_window = 1000`,
+		s.cfg.GetSnapshotTable(): `CREATE TABLE IF NOT EXISTS %s (
+	stream_id String,
+	version UInt64,
+	state String,
+	ts DateTime64(6)
+) ENGINE = ReplacingMergeTree(version)
+ORDER BY stream_id`,
+		s.cfg.GetCheckpointTable(): `CREATE TABLE IF NOT EXISTS %s (
+	name String,
+	ts DateTime64(6),
+	stream_id String,
+	version UInt64,
+	updated_at DateTime64(6)
+) ENGINE = ReplacingMergeTree(updated_at)
+ORDER BY name`,
+	}
+
+	for table, query := range queries {
+		if _, err := s.db.ExecContext(ctx, fmt.Sprintf(query, table)); err != nil {
+			return errors.Wrapf(err, "unable to create table %s", table)
+		}
+	}
+
+	return nil
+}
+
+// Append appends events to the stream and returns the new stream version. expectedVersion is the version
+// the stream should have before the append: NoStream for a new stream or AnyVersion to skip the check.
+// StreamID, Version and Time of events are set by Append, the request id and the tenant of ctx
+// are added to the metadata
+func (s *Store) Append(ctx context.Context, streamID string, expectedVersion int64, events ...*Event) (uint64, error) {
+	if streamID == "" {
+		return 0, errors.New("empty stream id")
+	}
+	if len(events) == 0 {
+		return s.Version(ctx, streamID)
+	}
+
+	unlock, lost, err := s.lock(ctx, streamID)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	current, err := s.Version(ctx, streamID)
+	if err != nil {
+		return 0, err
+	}
+	if expectedVersion != AnyVersion && uint64(expectedVersion) != current {
+		metrics.ConflictsCounter.WithLabelValues(s.cfg.GetTable()).Inc()
+		return current, errors.Wrapf(ErrVersionConflict, "stream %s is at version %d, expected %d", streamID, current, expectedVersion)
+	}
+
+	requestID, tenant := infrarequestid.FromContext(ctx), infratenancy.FromContext(ctx)
+	now := s.clock.Now()
+	rows := make([][]any, len(events))
+	for i, event := range events {
+		event.StreamID = streamID
+		event.Version = current + uint64(i) + 1
+		event.Time = now
+		event.Metadata = withContextMetadata(event.Metadata, requestID, tenant)
+
+		metadata := event.Metadata
+		if metadata == nil {
+			metadata = map[string]string{}
+		}
+		rows[i] = []any{event.StreamID, event.Version, event.Type, string(event.Payload), metadata, event.Time}
+	}
+
+	// the stream may be appended by someone else once the lock is lost
+	select {
+	case <-lost:
+		return current, errors.Wrapf(infralock.ErrLockLost, "unable to append to stream %s", streamID)
+	default:
+	}
+
+	// a retried or concurrent insert of the same versions is dropped by ClickHouse
+	insertCtx := infraclickhouse.WithSettings(ctx, map[string]any{
+		"insert_deduplication_token": streamID + ":" + strconv.FormatUint(current+1, 10),
+	})
+	query := fmt.Sprintf("INSERT INTO %s (%s)", s.cfg.GetTable(), eventColumns)
+	if err = infraclickhouse.Insert(insertCtx, s.db, query, rows); err != nil {
+		return current, errors.Wrapf(err, "unable to append to stream %s", streamID)
+	}
+
+	metrics.AppendedCounter.WithLabelValues(s.cfg.GetTable()).Add(float64(len(events)))
+
+	return current + uint64(len(events)), nil
+}
+
+// Version returns the version of the stream, 0 if it has no events
+func (s *Store) Version(ctx context.Context, streamID string) (uint64, error) {
+	var version uint64
+	query := fmt.Sprintf("SELECT max(version) FROM %s WHERE stream_id = ?", s.cfg.GetTable())
+	if err := s.db.QueryRowContext(ctx, query, streamID).Scan(&version); err != nil {
+		return 0, errors.Wrapf(err, "unable to get version of stream %s", streamID)
+	}
+	return version, nil
+}
+
+// Read returns events of the stream after the version ordered by version
+func (s *Store) Read(ctx context.Context, streamID string, afterVersion uint64) ([]*Event, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE stream_id = ? AND version > ? ORDER BY version", eventColumns, s.cfg.GetTable())
+
+	events, err := s.query(ctx, query, streamID, afterVersion)
+	return events, errors.Wrapf(err, "unable to read stream %s", streamID)
+}
+
+// Load returns the latest snapshot of the stream, nil if there is none, and events after it
+func (s *Store) Load(ctx context.Context, streamID string) (*Snapshot, []*Event, error) {
+	snapshot, err := s.LoadSnapshot(ctx, streamID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var version uint64
+	if snapshot != nil {
+		version = snapshot.Version
+	}
+
+	events, err := s.Read(ctx, streamID, version)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return snapshot, events, nil
+}
+
+// SaveSnapshot saves a state of the stream, so Load reads only events after it. Snapshots are an optimization:
+// a lost or stale snapshot only makes Load read more events
+func (s *Store) SaveSnapshot(ctx context.Context, snapshot *Snapshot) error {
+	if snapshot.Time.IsZero() {
+		snapshot.Time = s.clock.Now()
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s)", s.cfg.GetSnapshotTable(), snapshotColumns)
+	err := infraclickhouse.Insert(ctx, s.db, query, [][]any{
+		{snapshot.StreamID, snapshot.Version, string(snapshot.State), snapshot.Time},
+	})
+	return errors.Wrapf(err, "unable to save snapshot of stream %s", snapshot.StreamID)
+}
+
+// LoadSnapshot returns the latest snapshot of the stream, nil if there is none
+func (s *Store) LoadSnapshot(ctx context.Context, streamID string) (*Snapshot, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE stream_id = ? ORDER BY version DESC LIMIT 1", snapshotColumns, s.cfg.GetSnapshotTable())
+
+	var (
+		snapshot Snapshot
+		state    string
+	)
+	err := s.db.QueryRowContext(ctx, query, streamID).Scan(&snapshot.StreamID, &snapshot.Version, &state, &snapshot.Time)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to load snapshot of stream %s", streamID)
+	}
+	snapshot.State = []byte(state)
+
+	return &snapshot, nil
+}
+
+// lock returns unlock and a channel closed when the distributed lock is lost, it's never closed without a locker
+func (s *Store) lock(ctx context.Context, streamID string) (func(), <-chan struct{}, error) {
+	unlockLocal, err := s.local.Lock(ctx, streamID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if s.locker == nil {
+		return unlockLocal, nil, nil
+	}
+
+	lock, err := s.locker.Acquire(ctx, "eventstore:"+s.cfg.GetTable()+":"+streamID, s.cfg.GetLockTTL())
+	if err != nil {
+		unlockLocal()
+		return nil, nil, errors.Wrapf(err, "unable to lock stream %s", streamID)
+	}
+
+	return func() {
+		_ = lock.Release(context.WithoutCancel(ctx))
+		unlockLocal()
+	}, lock.Lost(), nil
+}
+
+func (s *Store) query(ctx context.Context, query string, args ...any) ([]*Event, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*Event
+	for rows.Next() {
+		var (
+			event   Event
+			payload string
+		)
+		if err = rows.Scan(&event.StreamID, &event.Version, &event.Type, &payload, &event.Metadata, &event.Time); err != nil {
+			return nil, err
+		}
+		event.Payload = []byte(payload)
+		events = append(events, &event)
+	}
+
+	return events, rows.Err()
+}
+
+func withContextMetadata(metadata map[string]string, requestID, tenant string) map[string]string {
+	add := func(key, value string) {
+		if value == "" {
+			return
+		}
+		if _, ok := metadata[key]; ok {
+			return
+		}
+		if metadata == nil {
+			metadata = make(map[string]string, 2)
+		}
+		metadata[key] = value
+	}
+
+	add("request_id", requestID)
+	add("tenant", tenant)
+
+	return metadata
+}
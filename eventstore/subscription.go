@@ -0,0 +1,249 @@
+package infraeventstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	infraclickhouse "github.com/pushwoosh/infra/clickhouse"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	infrarabbit "github.com/pushwoosh/infra/rabbit"
+	infraretry "github.com/pushwoosh/infra/retry"
+	"go.uber.org/zap"
+)
+
+var handleBackoff = infraretry.Exponential{
+	Initial: time.Second,
+	Max:     time.Minute,
+	Jitter:  0.2,
+}
+
+// Handler handles an event of a subscription
+type Handler func(ctx context.Context, event *Event) error
+
+// Position is a position in the feed of all streams. Events are ordered by time, stream and version
+type Position struct {
+	Time     time.Time
+	StreamID string
+	Version  uint64
+}
+
+// Subscription is a feed of events of all streams ordered by time. Every event is passed to the handler
+// at least once: a failed event is retried until it's handled, the position is saved after each batch,
+// so events handled before a crash are handled again.
+type Subscription struct {
+	name    string
+	store   *Store
+	handler Handler
+	cfg     *SubscriptionConfig
+
+	runMu  sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+var (
+	_ infraoperator.Starter = (*Subscription)(nil)
+	_ infraoperator.Stopper = (*Subscription)(nil)
+)
+
+// Subscribe creates a subscription starting from its saved position or the beginning of the feed:
+//
+//	sub, err := store.Subscribe("orders-to-rabbit", infraeventstore.RabbitHandler(producer, "orders"), nil)
+//	app.Add("orders feed", sub)
+func (s *Store) Subscribe(name string, handler Handler, cfg *SubscriptionConfig) (*Subscription, error) {
+	if cfg == nil {
+		cfg = &SubscriptionConfig{}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		return nil, errors.New("empty subscription name")
+	}
+
+	return &Subscription{
+		name:    name,
+		store:   s,
+		handler: handler,
+		cfg:     cfg,
+	}, nil
+}
+
+// Start starts handling events in background
+func (sub *Subscription) Start(_ context.Context) error {
+	sub.runMu.Lock()
+	defer sub.runMu.Unlock()
+
+	if sub.cancel != nil {
+		return errors.New("subscription is already started")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sub.cancel = cancel
+	sub.done = make(chan struct{})
+
+	go sub.run(ctx)
+
+	return nil
+}
+
+// Stop stops handling and waits for the current event
+func (sub *Subscription) Stop(_ context.Context) error {
+	sub.runMu.Lock()
+	defer sub.runMu.Unlock()
+
+	if sub.cancel == nil {
+		return nil
+	}
+
+	sub.cancel()
+	<-sub.done
+	sub.cancel = nil
+
+	return nil
+}
+
+func (sub *Subscription) run(ctx context.Context) {
+	defer close(sub.done)
+
+	var (
+		position Position
+		err      error
+	)
+	for attempt := 1; ; attempt++ {
+		if position, err = sub.store.Checkpoint(ctx, sub.name); err == nil {
+			break
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		infralog.Error("unable to load subscription checkpoint", zap.String("subscription", sub.name), zap.Error(err))
+		_ = infraretry.Sleep(ctx, handleBackoff.Delay(attempt))
+	}
+
+	for ctx.Err() == nil {
+		events, err := sub.store.feed(ctx, position, sub.store.clock.Now().Add(-sub.cfg.GetSettleDelay()), sub.cfg.GetBatchSize())
+		if err != nil {
+			if ctx.Err() == nil {
+				infralog.Error("unable to read events", zap.String("subscription", sub.name), zap.Error(err))
+			}
+			_ = infraretry.Sleep(ctx, sub.cfg.GetPollInterval())
+			continue
+		}
+
+		handled := sub.handle(ctx, events)
+		if handled > 0 {
+			last := events[handled-1]
+			position = Position{Time: last.Time, StreamID: last.StreamID, Version: last.Version}
+			metrics.LagGauge.WithLabelValues(sub.name).Set(sub.store.clock.Now().Sub(last.Time).Seconds())
+
+			// the position must be saved even if the subscription is stopping, otherwise events are handled again
+			if err = sub.store.SaveCheckpoint(context.WithoutCancel(ctx), sub.name, position); err != nil {
+				infralog.Error("unable to save subscription checkpoint", zap.String("subscription", sub.name), zap.Error(err))
+			}
+		}
+
+		// full batch means there are more events waiting
+		if err == nil && len(events) == sub.cfg.GetBatchSize() && handled == len(events) {
+			continue
+		}
+
+		_ = infraretry.Sleep(ctx, sub.cfg.GetPollInterval())
+	}
+}
+
+// handle passes events to the handler in order and returns the number of handled ones.
+// A failed event is retried until it's handled or ctx is done
+func (sub *Subscription) handle(ctx context.Context, events []*Event) int {
+	for i, event := range events {
+		for attempt := 1; ; attempt++ {
+			err := sub.handler(ctx, event)
+			if err == nil {
+				metrics.HandledCounter.WithLabelValues(sub.name, "ok").Inc()
+				break
+			}
+			if ctx.Err() != nil {
+				return i
+			}
+
+			metrics.HandledCounter.WithLabelValues(sub.name, "error").Inc()
+			infralog.Error("unable to handle event",
+				zap.String("subscription", sub.name),
+				zap.String("stream_id", event.StreamID),
+				zap.Uint64("version", event.Version),
+				zap.Int("attempt", attempt),
+				zap.Error(err))
+			if infraretry.Sleep(ctx, handleBackoff.Delay(attempt)) != nil {
+				return i
+			}
+		}
+	}
+
+	return len(events)
+}
+
+// Checkpoint returns the saved position of the subscription, zero position if there is none
+func (s *Store) Checkpoint(ctx context.Context, name string) (Position, error) {
+	query := fmt.Sprintf("SELECT ts, stream_id, version FROM %s WHERE name = ? ORDER BY updated_at DESC LIMIT 1", s.cfg.GetCheckpointTable())
+
+	var position Position
+	err := s.db.QueryRowContext(ctx, query, name).Scan(&position.Time, &position.StreamID, &position.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Position{}, nil
+	}
+	return position, errors.Wrapf(err, "unable to load checkpoint %s", name)
+}
+
+// SaveCheckpoint saves the position of the subscription
+func (s *Store) SaveCheckpoint(ctx context.Context, name string, position Position) error {
+	query := fmt.Sprintf("INSERT INTO %s (name, ts, stream_id, version, updated_at)", s.cfg.GetCheckpointTable())
+	err := infraclickhouse.Insert(ctx, s.db, query, [][]any{
+		{name, position.Time, position.StreamID, position.Version, s.clock.Now()},
+	})
+	return errors.Wrapf(err, "unable to save checkpoint %s", name)
+}
+
+// feed returns events after the position older than before
+func (s *Store) feed(ctx context.Context, after Position, before time.Time, limit int) ([]*Event, error) {
+	where, args := "ts < ?", []any{before}
+	if !after.Time.IsZero() {
+		where += " AND (ts, stream_id, version) > (?, ?, ?)"
+		args = append(args, after.Time, after.StreamID, after.Version)
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY ts, stream_id, version LIMIT %d",
+		eventColumns, s.cfg.GetTable(), where, limit)
+
+	return s.query(ctx, query, args...)
+}
+
+// RabbitHandler publishes events to the exchange with the event type as a routing key.
+// The message id is <stream id>:<version>, so consumers can deduplicate redelivered events.
+// The publisher should confirm messages, e.g. an infrarabbit.Producer with Confirm enabled
+// or an infraspool.Producer, otherwise events may be lost
+func RabbitHandler(publisher infrarabbit.Publisher, exchange string) Handler {
+	return func(ctx context.Context, event *Event) error {
+		headers := make(map[string]interface{}, len(event.Metadata)+2)
+		for k, v := range event.Metadata {
+			headers[k] = v
+		}
+		headers["stream_id"] = event.StreamID
+		headers["stream_version"] = int64(event.Version)
+
+		return publisher.Produce(ctx, &infrarabbit.ProducerMessage{
+			Body:       event.Payload,
+			Exchange:   exchange,
+			RoutingKey: event.Type,
+			MessageID:  event.StreamID + ":" + strconv.FormatUint(event.Version, 10),
+			Headers:    headers,
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package infrafallback
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	infrabreaker "github.com/pushwoosh/infra/breaker"
+	infralog "github.com/pushwoosh/infra/log"
+	"go.uber.org/zap"
+)
+
+// ErrNoValue is returned by fallbacks that have nothing to return, e.g. LastGood before the first success
+var ErrNoValue = errors.New("no fallback value")
+
+// Func is a call returning a value
+type Func[T any] func(ctx context.Context) (T, error)
+
+// Policy decides when the fallback is used
+type Policy struct {
+	// Breaker protects the primary: while it's open the fallback is called at once. optional
+	Breaker *infrabreaker.Breaker
+
+	// Timeout of the primary call, the fallback is called when it's exceeded. optional
+	Timeout time.Duration
+
+	// ShouldFallback reports if the fallback is used for the primary error.
+	// optional, default: all errors except cancellation of the caller's ctx
+	ShouldFallback func(err error) bool
+}
+
+// Error is returned when both the primary and the fallback fail
+type Error struct {
+	Primary  error
+	Fallback error
+}
+
+func (e *Error) Error() string {
+	return "primary: " + e.Primary.Error() + ", fallback: " + e.Fallback.Error()
+}
+
+func (e *Error) Unwrap() []error {
+	return []error{e.Primary, e.Fallback}
+}
+
+// WithFallback returns a call of primary that switches to fallback when primary fails or its circuit is open,
+// e.g. to a stale value, a default or a secondary datastore:
+//
+//	getRates := infrafallback.WithFallback("rates", fetchRates, lastRates.Fallback, &infrafallback.Policy{
+//		Breaker: ratesBreaker,
+//		Timeout: time.Second,
+//	})
+//	rates, err := getRates(ctx)
+//
+// The primary error is logged, fallback usage is counted in metrics by name
+func WithFallback[T any](name string, primary, fallback Func[T], policy *Policy) Func[T] {
+	initMetrics()
+
+	if policy == nil {
+		policy = &Policy{}
+	}
+
+	return func(ctx context.Context) (T, error) {
+		return call(ctx, name, primary, fallback, policy, policy.Timeout)
+	}
+}
+
+// Do calls primary and switches to fallback according to the policy, see WithFallback
+func Do[T any](ctx context.Context, name string, primary, fallback Func[T], policy *Policy) (T, error) {
+	return WithFallback(name, primary, fallback, policy)(ctx)
+}
+
+// Value returns a fallback returning the default value
+func Value[T any](v T) Func[T] {
+	return func(context.Context) (T, error) {
+		return v, nil
+	}
+}
+
+// call calls primary with the timeout and fallback if primary fails. Results that are read after the call,
+// like rows or response bodies, must be called without a timeout, its ctx is canceled on return
+func call[T any](ctx context.Context, name string, primary, fallback Func[T], policy *Policy, timeout time.Duration) (T, error) {
+	res, err := callPrimary(ctx, primary, policy.Breaker, timeout)
+	if err == nil {
+		metrics.CallsCounter.WithLabelValues(name, "primary").Inc()
+		return res, nil
+	}
+
+	if ctx.Err() != nil || !shouldFallback(policy, err) {
+		metrics.CallsCounter.WithLabelValues(name, "failed").Inc()
+		return res, err
+	}
+
+	metrics.FallbacksCounter.WithLabelValues(name, reason(err)).Inc()
+	if !errors.Is(err, infrabreaker.ErrOpen) {
+		infralog.WarnCtx(ctx, "fallback: primary failed", zap.String("name", name), zap.Error(err))
+	}
+
+	res, fallbackErr := fallback(ctx)
+	if fallbackErr != nil {
+		metrics.CallsCounter.WithLabelValues(name, "failed").Inc()
+		return res, &Error{Primary: err, Fallback: fallbackErr}
+	}
+
+	metrics.CallsCounter.WithLabelValues(name, "fallback").Inc()
+	return res, nil
+}
+
+func callPrimary[T any](ctx context.Context, primary Func[T], breaker *infrabreaker.Breaker, timeout time.Duration) (T, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if breaker != nil {
+		return infrabreaker.Execute(ctx, breaker, primary)
+	}
+	return primary(ctx)
+}
+
+func shouldFallback(policy *Policy, err error) bool {
+	if policy.ShouldFallback != nil {
+		return policy.ShouldFallback(err)
+	}
+	return true
+}
+
+func reason(err error) string {
+	switch {
+	case errors.Is(err, infrabreaker.ErrOpen):
+		return "open"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	default:
+		return "error"
+	}
+}
+
+// LastGood keeps the last successful result of the primary to serve it as a stale fallback:
+//
+//	lastRates := infrafallback.NewLastGood[Rates](time.Hour)
+//	getRates := infrafallback.WithFallback("rates", lastRates.Record(fetchRates), lastRates.Fallback, policy)
+type LastGood[T any] struct {
+	maxAge time.Duration
+
+	mu      sync.RWMutex
+	value   T
+	updated time.Time
+}
+
+// NewLastGood creates a stale value holder, values older than maxAge aren't served. Zero maxAge is unlimited
+func NewLastGood[T any](maxAge time.Duration) *LastGood[T] {
+	return &LastGood[T]{maxAge: maxAge}
+}
+
+// Record wraps the primary, so its successful results are kept
+func (l *LastGood[T]) Record(primary Func[T]) Func[T] {
+	return func(ctx context.Context) (T, error) {
+		res, err := primary(ctx)
+		if err == nil {
+			l.Set(res)
+		}
+		return res, err
+	}
+}
+
+// Set keeps the value
+func (l *LastGood[T]) Set(v T) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.value = v
+	l.updated = time.Now()
+}
+
+// Fallback returns the kept value or ErrNoValue if there is none or it's older than maxAge
+func (l *LastGood[T]) Fallback(context.Context) (T, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.updated.IsZero() || (l.maxAge > 0 && time.Since(l.updated) > l.maxAge) {
+		var zero T
+		return zero, ErrNoValue
+	}
+	return l.value, nil
+}
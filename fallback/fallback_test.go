@@ -0,0 +1,88 @@
+package infrafallback
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	infrabreaker "github.com/pushwoosh/infra/breaker"
+)
+
+func TestWithFallback(t *testing.T) {
+	ctx := context.Background()
+	b, err := infrabreaker.New("fallback-test", &infrabreaker.Config{
+		WindowSize:           2,
+		MinCalls:             2,
+		FailureRateThreshold: 100,
+		OpenTimeout:          time.Minute,
+		HalfOpenCalls:        1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var calls int
+	failing := false
+	lastGood := NewLastGood[string](0)
+	get := WithFallback("test", lastGood.Record(func(context.Context) (string, error) {
+		calls++
+		if failing {
+			return "", errors.New("unavailable")
+		}
+		return "fresh", nil
+	}), lastGood.Fallback, &Policy{Breaker: b})
+
+	if v, err := get(ctx); err != nil || v != "fresh" {
+		t.Fatalf("unexpected result %q %v", v, err)
+	}
+
+	failing = true
+	for i := 0; i < 3; i++ {
+		if v, err := get(ctx); err != nil || v != "fresh" {
+			t.Fatalf("expected stale value, got %q %v", v, err)
+		}
+	}
+
+	// the circuit is open after two failures, so the primary isn't called anymore
+	if calls != 3 {
+		t.Fatalf("expected 3 primary calls, got %d", calls)
+	}
+
+	_, err = Do(ctx, "test", func(context.Context) (int, error) { return 0, errors.New("primary") },
+		func(context.Context) (int, error) { return 0, ErrNoValue }, nil)
+	var fallbackErr *Error
+	if !errors.As(err, &fallbackErr) || !errors.Is(err, ErrNoValue) {
+		t.Fatalf("expected both errors, got %v", err)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: Transport("test", nil, Static(http.StatusOK, "application/json", []byte(`[]`)), nil)}
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "[]" {
+		t.Fatalf("expected fallback response, got %d %s", resp.StatusCode, body)
+	}
+
+	// the primary response is returned when the fallback fails
+	client.Transport = Transport("test", nil, Rewrite("http://127.0.0.1:1", nil), nil)
+	if resp, err = client.Get(srv.URL); err != nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected primary response, got %v %v", resp, err)
+	}
+	resp.Body.Close()
+}
@@ -0,0 +1,139 @@
+package infrafallback
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// ServerError is a primary response with a 5xx status code, it's a failure for the fallback policy
+type ServerError struct {
+	StatusCode int
+}
+
+func (e *ServerError) Error() string {
+	return http.StatusText(e.StatusCode)
+}
+
+// Transport sends requests with next and switches to fallback on errors, 5xx responses or open circuit:
+//
+//	client.Transport = infrafallback.Transport("geo", client.Transport,
+//		infrafallback.Rewrite("http://geo.backup.svc", client.Transport), policy)
+//
+// Requests with a body fall back only if it can be sent again, see http.Request.GetBody.
+// Policy.Timeout isn't applied, use timeouts of the client.
+func Transport(name string, next, fallback http.RoundTripper, policy *Policy) http.RoundTripper {
+	initMetrics()
+
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if policy == nil {
+		policy = &Policy{}
+	}
+
+	return &transport{
+		name:     name,
+		next:     next,
+		fallback: fallback,
+		policy:   policy,
+	}
+}
+
+type transport struct {
+	name     string
+	next     http.RoundTripper
+	fallback http.RoundTripper
+	policy   *Policy
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return t.next.RoundTrip(req)
+	}
+
+	var serverResp *http.Response
+	resp, err := call(req.Context(), t.name,
+		func(context.Context) (*http.Response, error) {
+			resp, err := t.next.RoundTrip(req)
+			if err == nil && resp.StatusCode >= http.StatusInternalServerError {
+				serverResp = resp
+				return nil, &ServerError{StatusCode: resp.StatusCode}
+			}
+			return resp, err
+		},
+		func(context.Context) (*http.Response, error) {
+			retry := req.Clone(req.Context())
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				retry.Body = body
+			}
+			return t.fallback.RoundTrip(retry)
+		},
+		t.policy, 0)
+
+	if serverResp != nil {
+		if err != nil {
+			// the fallback failed or wasn't used, the client gets the primary response as is
+			return serverResp, nil
+		}
+		_, _ = io.Copy(io.Discard, serverResp.Body)
+		_ = serverResp.Body.Close()
+	}
+
+	return resp, err
+}
+
+// Rewrite returns a round tripper sending requests to another base URL, e.g. a secondary region of the service
+func Rewrite(baseURL string, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	base, err := url.Parse(baseURL)
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid fallback url")
+		}
+
+		req = req.Clone(req.Context())
+		req.URL.Scheme = base.Scheme
+		req.URL.Host = base.Host
+		req.Host = ""
+		if base.Path != "" && base.Path != "/" {
+			req.URL.Path = base.JoinPath(req.URL.Path).Path
+		}
+
+		return next.RoundTrip(req)
+	})
+}
+
+// Static returns a round tripper responding with the status and the body, e.g. an empty list
+func Static(status int, contentType string, body []byte) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			Status:        http.StatusText(status),
+			StatusCode:    status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": {contentType}},
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	})
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
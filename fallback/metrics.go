@@ -0,0 +1,32 @@
+package infrafallback
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	CallsCounter     *prometheus.CounterVec
+	FallbacksCounter *prometheus.CounterVec
+}
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.CallsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "fallback_calls_total",
+			Help: "The total number of calls by result: primary, fallback or failed when both failed",
+		}, []string{"name", "result"})
+
+		metrics.FallbacksCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "fallback_used_total",
+			Help: "The total number of fallback calls by reason: error, timeout or open circuit",
+		}, []string{"name", "reason"})
+
+		prometheus.MustRegister(
+			metrics.CallsCounter,
+			metrics.FallbacksCounter,
+		)
+	})
+}
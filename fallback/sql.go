@@ -0,0 +1,71 @@
+package infrafallback
+
+import (
+	"context"
+	"database/sql"
+)
+
+// DB reads from the primary connection pool and switches to the secondary one when the primary fails
+// or its circuit is open, e.g. to a ClickHouse replica in another region:
+//
+//	db := infrafallback.WrapDB("events", chContainer.Get("events"), chContainer.Get("events_replica"), policy)
+//	counts, err := infrafallback.Query(ctx, db, scanCounts, query, args...)
+//
+// Writes aren't wrapped, they go to the primary only.
+type DB struct {
+	*sql.DB
+	name      string
+	secondary *sql.DB
+	policy    *Policy
+}
+
+func WrapDB(name string, primary, secondary *sql.DB, policy *Policy) *DB {
+	initMetrics()
+
+	if policy == nil {
+		policy = &Policy{}
+	}
+
+	return &DB{
+		DB:        primary,
+		name:      name,
+		secondary: secondary,
+		policy:    policy,
+	}
+}
+
+// QueryContext falls back when the query fails to start. Errors while reading rows are returned as is,
+// use Query to fall back on them too. Policy.Timeout isn't applied, rows would be canceled with it
+func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return call(ctx, db.name,
+		func(ctx context.Context) (*sql.Rows, error) {
+			return db.DB.QueryContext(ctx, query, args...)
+		},
+		func(ctx context.Context) (*sql.Rows, error) {
+			return db.secondary.QueryContext(ctx, query, args...)
+		},
+		db.policy, 0)
+}
+
+// Query runs the query and reads rows with scan on the primary, then on the secondary if the primary fails.
+// Policy.Timeout bounds the query with reading rows
+func Query[T any](ctx context.Context, db *DB, scan func(rows *sql.Rows) (T, error), query string, args ...interface{}) (T, error) {
+	read := func(conn *sql.DB) Func[T] {
+		return func(ctx context.Context) (T, error) {
+			rows, err := conn.QueryContext(ctx, query, args...)
+			if err != nil {
+				var zero T
+				return zero, err
+			}
+			defer rows.Close()
+
+			res, err := scan(rows)
+			if err != nil {
+				return res, err
+			}
+			return res, rows.Err()
+		}
+	}
+
+	return call(ctx, db.name, read(db.DB), read(db.secondary), db.policy, db.policy.Timeout)
+}
@@ -0,0 +1,16 @@
+package infraflags
+
+import "context"
+
+type tenantKey struct{}
+
+// WithTenant returns a context with tenant used for flag targeting
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns tenant stored by WithTenant
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
@@ -0,0 +1,48 @@
+package infraflags
+
+import (
+	"net/http"
+
+	infradebug "github.com/pushwoosh/infra/debug"
+)
+
+type debugRule struct {
+	Tenants []string    `json:"tenants"`
+	Value   interface{} `json:"value"`
+}
+
+type debugDefinition struct {
+	Value interface{}  `json:"value"`
+	Rules []*debugRule `json:"rules,omitempty"`
+}
+
+type debugState struct {
+	Providers   []string                    `json:"providers"`
+	Definitions map[string]*debugDefinition `json:"definitions"`
+}
+
+// DebugHandler dumps current flag definitions and providers, register it with infradebug.Register
+func (f *Flags) DebugHandler() http.Handler {
+	return infradebug.JSON(func(*http.Request) (interface{}, error) {
+		state := &debugState{
+			Providers:   make([]string, 0, len(f.providers)),
+			Definitions: make(map[string]*debugDefinition),
+		}
+		for _, p := range f.providers {
+			state.Providers = append(state.Providers, p.Name())
+		}
+
+		f.mu.RLock()
+		defer f.mu.RUnlock()
+
+		for name, def := range f.defs {
+			d := &debugDefinition{Value: def.Value}
+			for _, rule := range def.Rules {
+				d.Rules = append(d.Rules, &debugRule{Tenants: rule.Tenants, Value: rule.Value})
+			}
+			state.Definitions[name] = d
+		}
+
+		return state, nil
+	})
+}
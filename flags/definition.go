@@ -0,0 +1,106 @@
+package infraflags
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Definition is a flag state provided by a Provider:
+//
+//	flags:
+//	  rabbit_new_ack_mode:
+//	    value: false
+//	    rules:
+//	      - tenants: [ "ABCDE-12345" ]
+//	        value: true
+//	  new_pipeline:
+//	    value: 25 # percentage rollout
+type Definition struct {
+	// Value is a flag value for all tenants not matched by rules.
+	// For percentage flags it is a percentage of tenants from 0 to 100.
+	Value interface{} `mapstructure:"value"`
+	// Rules override value for specific tenants. The first matched rule wins.
+	Rules []*Rule `mapstructure:"rules"` // optional
+}
+
+// Rule is a per-tenant targeting rule
+type Rule struct {
+	Tenants []string    `mapstructure:"tenants"`
+	Value   interface{} `mapstructure:"value"`
+}
+
+// evaluate returns the value for a tenant and the evaluation source for metrics
+func (d *Definition) evaluate(tenant string) (interface{}, string) {
+	if tenant != "" {
+		for _, rule := range d.Rules {
+			for _, t := range rule.Tenants {
+				if t == tenant {
+					return rule.Value, sourceRule
+				}
+			}
+		}
+	}
+
+	return d.Value, sourceValue
+}
+
+func toBool(v interface{}) (bool, bool) {
+	switch v := v.(type) {
+	case bool:
+		return v, true
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		return b, err == nil
+	case int:
+		return v != 0, true
+	case float64:
+		return v != 0, true
+	}
+	return false, false
+}
+
+func toInt(v interface{}) (int, bool) {
+	switch v := v.(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	case string:
+		i, err := strconv.Atoi(strings.TrimSpace(v))
+		return i, err == nil
+	}
+	return 0, false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case bool:
+		if v {
+			return 100, true
+		}
+		return 0, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(v), "%"), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func toString(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	}
+	return fmt.Sprint(v), true
+}
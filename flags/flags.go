@@ -0,0 +1,250 @@
+package infraflags
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	"go.uber.org/zap"
+)
+
+const DefaultRefreshInterval = 30 * time.Second
+
+// ChangeHandler is called when a flag definition changes. old or new is nil if the flag was added or removed.
+type ChangeHandler func(name string, old, new *Definition)
+
+// Flags holds flag definitions loaded from providers:
+//
+//	flags := infraflags.New(infraflags.DefaultRefreshInterval,
+//		infraflags.NewFileProvider("flags.yaml"),
+//		infraflags.NewEnvProvider("FLAGS_"),
+//	)
+//	newAckMode := flags.Bool("rabbit_new_ack_mode", false)
+//	op.AddService(ctx, flags)
+//	...
+//	if newAckMode.Enabled(infraflags.WithTenant(ctx, appCode)) { ... }
+type Flags struct {
+	providers []Provider
+	interval  time.Duration
+
+	mu       sync.RWMutex
+	defs     map[string]*Definition
+	handlers []ChangeHandler
+
+	runMu  sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+var (
+	_ infraoperator.Starter = (*Flags)(nil)
+	_ infraoperator.Stopper = (*Flags)(nil)
+)
+
+// New creates flags with providers. Definitions are reloaded every refreshInterval after Start.
+func New(refreshInterval time.Duration, providers ...Provider) *Flags {
+	initMetrics()
+
+	if refreshInterval <= 0 {
+		refreshInterval = DefaultRefreshInterval
+	}
+
+	return &Flags{
+		providers: providers,
+		interval:  refreshInterval,
+		defs:      make(map[string]*Definition),
+	}
+}
+
+var (
+	defaultMu    sync.RWMutex
+	defaultFlags *Flags
+)
+
+// Default returns flags used by infra packages to gate new behaviour.
+// Without SetDefault all flags have their default values.
+func Default() *Flags {
+	defaultMu.RLock()
+	f := defaultFlags
+	defaultMu.RUnlock()
+	if f != nil {
+		return f
+	}
+
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultFlags == nil {
+		defaultFlags = New(DefaultRefreshInterval)
+	}
+	return defaultFlags
+}
+
+// SetDefault replaces flags returned by Default
+func SetDefault(f *Flags) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	defaultFlags = f
+}
+
+// OnChange registers a handler for definition changes
+func (f *Flags) OnChange(handler ChangeHandler) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.handlers = append(f.handlers, handler)
+}
+
+// Definition returns current flag definition or nil
+func (f *Flags) Definition(name string) *Definition {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.defs[name]
+}
+
+// Refresh loads definitions from all providers.
+// If a provider fails, its previous definitions are not removed and the error is returned.
+func (f *Flags) Refresh(ctx context.Context) error {
+	f.mu.RLock()
+	merged := make(map[string]*Definition, len(f.defs))
+	for name, def := range f.defs {
+		merged[name] = def
+	}
+	f.mu.RUnlock()
+
+	var loadErr error
+	loaded := make(map[string]*Definition)
+	for _, p := range f.providers {
+		defs, err := p.Load(ctx)
+		if err != nil {
+			metrics.RefreshErrorsCounter.WithLabelValues(p.Name()).Inc()
+			if loadErr == nil {
+				loadErr = errors.Wrap(err, p.Name())
+			}
+			continue
+		}
+
+		for name, def := range defs {
+			loaded[name] = def
+		}
+	}
+
+	// definitions disappear only when all providers are available
+	if loadErr == nil {
+		merged = loaded
+	} else {
+		for name, def := range loaded {
+			merged[name] = def
+		}
+	}
+
+	f.apply(merged)
+
+	return loadErr
+}
+
+func (f *Flags) apply(defs map[string]*Definition) {
+	type change struct {
+		name     string
+		old, new *Definition
+	}
+
+	f.mu.Lock()
+	var changes []change
+	for name, def := range defs {
+		if old := f.defs[name]; !reflect.DeepEqual(old, def) {
+			changes = append(changes, change{name: name, old: old, new: def})
+		}
+	}
+	for name, old := range f.defs {
+		if _, ok := defs[name]; !ok {
+			changes = append(changes, change{name: name, old: old})
+		}
+	}
+	f.defs = defs
+	handlers := f.handlers
+	f.mu.Unlock()
+
+	for _, c := range changes {
+		metrics.ChangesCounter.WithLabelValues(c.name).Inc()
+		infralog.Info("feature flag changed", zap.String("flag", c.name))
+
+		for _, h := range handlers {
+			h(c.name, c.old, c.new)
+		}
+	}
+}
+
+// Start loads definitions and refreshes them periodically in background
+func (f *Flags) Start(ctx context.Context) error {
+	f.runMu.Lock()
+	defer f.runMu.Unlock()
+
+	if f.cancel != nil {
+		return errors.New("flags are already started")
+	}
+
+	if err := f.Refresh(ctx); err != nil {
+		return errors.Wrap(err, "unable to load feature flags")
+	}
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	f.cancel = cancel
+	f.done = make(chan struct{})
+
+	go f.run(runCtx)
+
+	return nil
+}
+
+// Stop stops refreshing definitions
+func (f *Flags) Stop(_ context.Context) error {
+	f.runMu.Lock()
+	defer f.runMu.Unlock()
+
+	if f.cancel == nil {
+		return nil
+	}
+
+	f.cancel()
+	<-f.done
+	f.cancel = nil
+
+	return nil
+}
+
+func (f *Flags) run(ctx context.Context) {
+	defer close(f.done)
+
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := f.Refresh(ctx); err != nil && ctx.Err() == nil {
+				infralog.Error("unable to refresh feature flags", zap.Error(err))
+			}
+		}
+	}
+}
+
+// evaluate returns raw flag value for a tenant from context
+func (f *Flags) evaluate(ctx context.Context, name string) (interface{}, string) {
+	f.mu.RLock()
+	def := f.defs[name]
+	f.mu.RUnlock()
+
+	if def == nil {
+		return nil, sourceDefault
+	}
+
+	return def.evaluate(TenantFromContext(ctx))
+}
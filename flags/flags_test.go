@@ -0,0 +1,74 @@
+package infraflags
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFlags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.yaml")
+	err := os.WriteFile(path, []byte(`
+flags:
+  new_ack_mode:
+    value: false
+    rules:
+      - tenants: [ "AAAAA-00000" ]
+        value: true
+  batch_size:
+    value: 100
+  rollout:
+    value: 50
+`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TESTFLAGS_BATCH_SIZE", "200")
+
+	flags := New(DefaultRefreshInterval, NewFileProvider(path), NewEnvProvider("TESTFLAGS_"))
+
+	var changed []string
+	flags.OnChange(func(name string, _, _ *Definition) {
+		changed = append(changed, name)
+	})
+
+	if err = flags.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 3 {
+		t.Fatalf("expected 3 changes, got %v", changed)
+	}
+
+	ctx := context.Background()
+	tenantCtx := WithTenant(ctx, "AAAAA-00000")
+
+	newAckMode := flags.Bool("new_ack_mode", true)
+	if newAckMode.Enabled(ctx) || !newAckMode.Enabled(tenantCtx) {
+		t.Error("tenant rule is not applied")
+	}
+
+	if v := flags.Int("batch_size", 1).Value(ctx); v != 200 {
+		t.Errorf("expected env override 200, got %d", v)
+	}
+
+	if v := flags.String("missing", "default").Value(ctx); v != "default" {
+		t.Errorf("expected default value, got %s", v)
+	}
+
+	rollout := flags.Percentage("rollout", 0)
+	enabled := 0
+	for _, tenant := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l", "m", "n", "o", "p"} {
+		tenantCtx := WithTenant(ctx, tenant)
+		first := rollout.Enabled(tenantCtx)
+		if first != rollout.Enabled(tenantCtx) {
+			t.Fatal("rollout is not stable")
+		}
+		if first {
+			enabled++
+		}
+	}
+	if enabled == 0 || enabled == 16 {
+		t.Errorf("unexpected rollout distribution: %d of 16", enabled)
+	}
+}
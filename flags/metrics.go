@@ -0,0 +1,44 @@
+package infraflags
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	EvaluationsCounter   *prometheus.CounterVec
+	RefreshErrorsCounter *prometheus.CounterVec
+	ChangesCounter       *prometheus.CounterVec
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.EvaluationsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "feature_flag_evaluations_total",
+			Help: "Number of flag evaluations by source of the value: default, value or rule",
+		}, []string{"flag", "source"})
+
+		metrics.RefreshErrorsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "feature_flag_refresh_errors_total",
+			Help: "Number of failed flag provider loads",
+		}, []string{"provider"})
+
+		metrics.ChangesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "feature_flag_changes_total",
+			Help: "Number of flag definition changes",
+		}, []string{"flag"})
+
+		prometheus.MustRegister(
+			metrics.EvaluationsCounter,
+			metrics.RefreshErrorsCounter,
+			metrics.ChangesCounter,
+		)
+	})
+}
+
+func observe(flag, source string) {
+	metrics.EvaluationsCounter.WithLabelValues(flag, source).Inc()
+}
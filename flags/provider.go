@@ -0,0 +1,125 @@
+package infraflags
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	infraconfig "github.com/pushwoosh/infra/config"
+)
+
+// Provider loads flag definitions. Definitions of later providers override earlier ones.
+type Provider interface {
+	Name() string
+	Load(ctx context.Context) (map[string]*Definition, error)
+}
+
+type document struct {
+	Flags map[string]*Definition `mapstructure:"flags"`
+}
+
+// FileProvider reads definitions from a YAML or JSON file with "flags" root key
+type FileProvider struct {
+	path string
+}
+
+func NewFileProvider(path string) *FileProvider {
+	return &FileProvider{path: path}
+}
+
+func (p *FileProvider) Name() string {
+	return "file"
+}
+
+func (p *FileProvider) Load(_ context.Context) (map[string]*Definition, error) {
+	doc := &document{}
+	if err := infraconfig.Load(p.path, doc); err != nil {
+		return nil, err
+	}
+	return doc.Flags, nil
+}
+
+// EnvProvider reads flag values from environment variables with the prefix:
+//
+//	FLAGS_RABBIT_NEW_ACK_MODE=true
+//
+// sets "rabbit_new_ack_mode" flag with NewEnvProvider("FLAGS_"). Env flags have no targeting rules.
+type EnvProvider struct {
+	prefix string
+}
+
+func NewEnvProvider(prefix string) *EnvProvider {
+	return &EnvProvider{prefix: prefix}
+}
+
+func (p *EnvProvider) Name() string {
+	return "env"
+}
+
+func (p *EnvProvider) Load(_ context.Context) (map[string]*Definition, error) {
+	res := make(map[string]*Definition)
+
+	for _, kv := range os.Environ() {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(key, p.prefix) {
+			continue
+		}
+
+		res[strings.ToLower(strings.TrimPrefix(key, p.prefix))] = &Definition{Value: value}
+	}
+
+	return res, nil
+}
+
+// HTTPProvider fetches definitions in JSON format (same structure as in FileProvider) from a remote service
+type HTTPProvider struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPProvider creates remote provider, http.DefaultClient is used if client is nil
+func NewHTTPProvider(url string, client *http.Client) *HTTPProvider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &HTTPProvider{
+		url:    url,
+		client: client,
+	}
+}
+
+func (p *HTTPProvider) Name() string {
+	return "http"
+}
+
+func (p *HTTPProvider) Load(ctx context.Context) (map[string]*Definition, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &document{}
+	if err = infraconfig.LoadBytes("json", data, doc); err != nil {
+		return nil, err
+	}
+	return doc.Flags, nil
+}
@@ -0,0 +1,150 @@
+package infraflags
+
+import (
+	"context"
+	"hash/fnv"
+)
+
+const (
+	sourceDefault = "default"
+	sourceValue   = "value"
+	sourceRule    = "rule"
+)
+
+// BoolFlag is an on/off flag
+type BoolFlag struct {
+	flags *Flags
+	name  string
+	def   bool
+}
+
+// Bool defines a bool flag. def is used when the flag is not provided or has invalid value.
+func (f *Flags) Bool(name string, def bool) *BoolFlag {
+	return &BoolFlag{flags: f, name: name, def: def}
+}
+
+func (b *BoolFlag) Name() string {
+	return b.name
+}
+
+// Enabled evaluates the flag for a tenant from context
+func (b *BoolFlag) Enabled(ctx context.Context) bool {
+	raw, source := b.flags.evaluate(ctx, b.name)
+	if v, ok := toBool(raw); ok && source != sourceDefault {
+		observe(b.name, source)
+		return v
+	}
+
+	observe(b.name, sourceDefault)
+	return b.def
+}
+
+// IntFlag is a flag with an integer value
+type IntFlag struct {
+	flags *Flags
+	name  string
+	def   int
+}
+
+// Int defines an int flag. def is used when the flag is not provided or has invalid value.
+func (f *Flags) Int(name string, def int) *IntFlag {
+	return &IntFlag{flags: f, name: name, def: def}
+}
+
+func (i *IntFlag) Name() string {
+	return i.name
+}
+
+// Value evaluates the flag for a tenant from context
+func (i *IntFlag) Value(ctx context.Context) int {
+	raw, source := i.flags.evaluate(ctx, i.name)
+	if v, ok := toInt(raw); ok && source != sourceDefault {
+		observe(i.name, source)
+		return v
+	}
+
+	observe(i.name, sourceDefault)
+	return i.def
+}
+
+// StringFlag is a flag with a string value
+type StringFlag struct {
+	flags *Flags
+	name  string
+	def   string
+}
+
+// String defines a string flag. def is used when the flag is not provided.
+func (f *Flags) String(name string, def string) *StringFlag {
+	return &StringFlag{flags: f, name: name, def: def}
+}
+
+func (s *StringFlag) Name() string {
+	return s.name
+}
+
+// Value evaluates the flag for a tenant from context
+func (s *StringFlag) Value(ctx context.Context) string {
+	raw, source := s.flags.evaluate(ctx, s.name)
+	if v, ok := toString(raw); ok && source != sourceDefault {
+		observe(s.name, source)
+		return v
+	}
+
+	observe(s.name, sourceDefault)
+	return s.def
+}
+
+// PercentageFlag is enabled for a stable share of tenants.
+// Tenants are bucketed by hash of flag name and tenant, so increasing the percentage keeps already enabled tenants.
+type PercentageFlag struct {
+	flags *Flags
+	name  string
+	def   float64
+}
+
+// Percentage defines a percentage rollout flag, def is a percentage from 0 to 100.
+// Rule values can be either percentages or bools.
+func (f *Flags) Percentage(name string, def float64) *PercentageFlag {
+	return &PercentageFlag{flags: f, name: name, def: def}
+}
+
+func (p *PercentageFlag) Name() string {
+	return p.name
+}
+
+// Enabled evaluates the flag for a tenant from context.
+// Without a tenant the flag is enabled only at 100%.
+func (p *PercentageFlag) Enabled(ctx context.Context) bool {
+	percentage := p.def
+	raw, source := p.flags.evaluate(ctx, p.name)
+	if v, ok := toFloat(raw); ok && source != sourceDefault {
+		percentage = v
+	} else {
+		source = sourceDefault
+	}
+	observe(p.name, source)
+
+	if percentage >= 100 {
+		return true
+	}
+	if percentage <= 0 {
+		return false
+	}
+
+	tenant := TenantFromContext(ctx)
+	if tenant == "" {
+		return false
+	}
+
+	return bucket(p.name, tenant) < percentage
+}
+
+// bucket maps a tenant to [0, 100)
+func bucket(name, tenant string) float64 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(tenant))
+	return float64(h.Sum32()%10000) / 100
+}
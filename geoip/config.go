@@ -0,0 +1,115 @@
+package infrageoip
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultReloadInterval   = time.Minute
+	defaultDownloadInterval = 24 * time.Hour
+	defaultDownloadTimeout  = 5 * time.Minute
+	defaultDownloadURL      = "https://download.maxmind.com/geoip/databases/{edition_id}/download?suffix=tar.gz"
+)
+
+type Config struct {
+	// Path of the mmdb file. It's reloaded without a restart when the file is replaced
+	Path string `mapstructure:"path"`
+
+	// How often the file is checked for changes. optional, default: 1m
+	ReloadInterval time.Duration `mapstructure:"reload_interval"`
+
+	// Download keeps the file up to date from MaxMind. optional, the file is managed externally by default
+	Download *DownloadConfig `mapstructure:"download"`
+}
+
+type DownloadConfig struct {
+	// EditionID of the database, e.g. GeoLite2-Country or GeoLite2-ASN
+	EditionID string `mapstructure:"edition_id"`
+
+	// AccountID and LicenseKey of the MaxMind account, the key may be a secret reference, see infraconfig.ResolveSecret
+	AccountID  string `mapstructure:"account_id"`
+	LicenseKey string `mapstructure:"license_key"`
+
+	// URL of the database, {edition_id} is replaced with EditionID. A mirror may serve a plain mmdb file
+	// or a tar.gz archive. optional, default: MaxMind download API
+	URL string `mapstructure:"url"`
+
+	// How often the database is updated. MaxMind updates GeoLite2 twice a week. optional, default: 24h
+	Interval time.Duration `mapstructure:"interval"`
+
+	// Timeout of a download. optional, default: 5m
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if c.Path == "" {
+		return errors.New("empty path")
+	}
+
+	if c.ReloadInterval < 0 {
+		return errors.New("reload_interval should not be negative")
+	}
+
+	if c.Download != nil {
+		if err := c.Download.Validate(); err != nil {
+			return errors.Wrap(err, "download")
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) GetReloadInterval() time.Duration {
+	if c.ReloadInterval == 0 {
+		return defaultReloadInterval
+	}
+	return c.ReloadInterval
+}
+
+func (c *DownloadConfig) Validate() error {
+	if c.EditionID == "" && c.URL == "" {
+		return errors.New("edition_id or url is required")
+	}
+
+	if c.URL != "" {
+		if _, err := url.Parse(c.URL); err != nil {
+			return errors.Wrap(err, "invalid url")
+		}
+	}
+
+	if c.Interval < 0 || c.Timeout < 0 {
+		return errors.New("interval and timeout should not be negative")
+	}
+
+	return nil
+}
+
+func (c *DownloadConfig) GetURL() string {
+	u := c.URL
+	if u == "" {
+		u = defaultDownloadURL
+	}
+	return strings.ReplaceAll(u, "{edition_id}", url.PathEscape(c.EditionID))
+}
+
+func (c *DownloadConfig) GetInterval() time.Duration {
+	if c.Interval == 0 {
+		return defaultDownloadInterval
+	}
+	return c.Interval
+}
+
+func (c *DownloadConfig) GetTimeout() time.Duration {
+	if c.Timeout == 0 {
+		return defaultDownloadTimeout
+	}
+	return c.Timeout
+}
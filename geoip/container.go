@@ -0,0 +1,103 @@
+package infrageoip
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	infraoperator "github.com/pushwoosh/infra/operator"
+)
+
+// Container is a simple container for holding named GeoIP databases
+type Container struct {
+	mu  *sync.RWMutex
+	cfg map[string]Config
+	dbs map[string]*DB
+}
+
+var (
+	_ infraoperator.Stopper = (*Container)(nil)
+	_ infraoperator.Checker = (*Container)(nil)
+)
+
+func NewContainer() *Container {
+	return &Container{
+		mu:  &sync.RWMutex{},
+		cfg: make(map[string]Config),
+		dbs: make(map[string]*DB),
+	}
+}
+
+// Open loads a new named database, see Open
+func (cont *Container) Open(name string, cfg *Config) error {
+	db, err := Open(name, cfg)
+	if err != nil {
+		return errors.Wrapf(err, "geoip %s", name)
+	}
+
+	// replace existing database with the same name
+	cont.Remove(name)
+
+	cont.mu.Lock()
+	defer cont.mu.Unlock()
+
+	cont.dbs[name] = db
+	cont.cfg[name] = *cfg
+
+	return nil
+}
+
+// Get gets database from a container
+func (cont *Container) Get(name string) *DB {
+	cont.mu.RLock()
+	defer cont.mu.RUnlock()
+
+	return cont.dbs[name]
+}
+
+// Remove stops updates of named database and removes it from the container
+func (cont *Container) Remove(name string) {
+	cont.mu.Lock()
+	db := cont.dbs[name]
+	delete(cont.dbs, name)
+	delete(cont.cfg, name)
+	cont.mu.Unlock()
+
+	if db != nil {
+		db.Close()
+	}
+}
+
+// Check checks that files of all databases are readable, so a broken update is noticed
+func (cont *Container) Check(_ context.Context) error {
+	cont.mu.RLock()
+	defer cont.mu.RUnlock()
+
+	for name, db := range cont.dbs {
+		if err := db.Reload(); err != nil {
+			return errors.Wrap(err, name)
+		}
+	}
+
+	return nil
+}
+
+// Stop stops updates of all databases in the container
+func (cont *Container) Stop(_ context.Context) error {
+	cont.Close()
+	return nil
+}
+
+// Close stops updates of all databases in the container
+func (cont *Container) Close() {
+	cont.mu.RLock()
+	names := make([]string, 0, len(cont.dbs))
+	for name := range cont.dbs {
+		names = append(names, name)
+	}
+	cont.mu.RUnlock()
+
+	for _, name := range names {
+		cont.Remove(name)
+	}
+}
@@ -0,0 +1,235 @@
+package infrageoip
+
+import (
+	"context"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	infraretry "github.com/pushwoosh/infra/retry"
+	"go.uber.org/zap"
+)
+
+// Country is a country of an IP address from Country, City or Enterprise databases
+type Country struct {
+	ISOCode string
+	Name    string
+}
+
+// ASN is an autonomous system of an IP address from ASN databases
+type ASN struct {
+	Number       uint   `maxminddb:"autonomous_system_number"`
+	Organization string `maxminddb:"autonomous_system_organization"`
+}
+
+type countryRecord struct {
+	Country struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+}
+
+type file struct {
+	modTime time.Time
+	size    int64
+}
+
+// DB is a MaxMind database. The file is read into memory and swapped atomically when it changes on disk
+// or is downloaded, lookups are never blocked by a reload:
+//
+//	cont := infrageoip.NewContainer()
+//	err := cont.Open("country", &infrageoip.Config{Path: "/data/GeoLite2-Country.mmdb"})
+//	app.Add("geoip", cont)
+//
+//	country, err := cont.Get("country").Country(net.ParseIP(ip))
+type DB struct {
+	name   string
+	cfg    *Config
+	reader atomic.Pointer[maxminddb.Reader]
+
+	mu   sync.Mutex
+	file file
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Open loads the database and starts watching the file and downloading updates in background.
+// A missing file is downloaded first if Download is configured
+func Open(name string, cfg *Config) (*DB, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	initMetrics()
+
+	db := &DB{
+		name: name,
+		cfg:  cfg,
+		done: make(chan struct{}),
+	}
+
+	if _, err := os.Stat(cfg.Path); errors.Is(err, os.ErrNotExist) && cfg.Download != nil {
+		if _, err = db.Download(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
+	if db.reader.Load() == nil {
+		if err := db.Reload(); err != nil {
+			return nil, err
+		}
+	}
+
+	var ctx context.Context
+	ctx, db.cancel = context.WithCancel(context.Background())
+	go db.run(ctx)
+
+	return db, nil
+}
+
+// Close stops background updates
+func (db *DB) Close() {
+	db.cancel()
+	<-db.done
+}
+
+// Reload reads the file if it has changed since the last load
+func (db *DB) Reload() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	stat, err := os.Stat(db.cfg.Path)
+	if err != nil {
+		return errors.Wrap(err, "unable to stat geoip database")
+	}
+	current := file{modTime: stat.ModTime(), size: stat.Size()}
+	if current == db.file && db.reader.Load() != nil {
+		return nil
+	}
+
+	if err = db.load(); err != nil {
+		metrics.ReloadsCounter.WithLabelValues(db.name, "error").Inc()
+		return err
+	}
+	db.file = current
+	metrics.ReloadsCounter.WithLabelValues(db.name, "success").Inc()
+
+	return nil
+}
+
+// load reads the file and swaps the reader, db.mu must be held
+func (db *DB) load() error {
+	data, err := os.ReadFile(db.cfg.Path)
+	if err != nil {
+		return errors.Wrap(err, "unable to read geoip database")
+	}
+
+	reader, err := maxminddb.FromBytes(data)
+	if err != nil {
+		return errors.Wrapf(err, "invalid geoip database %s", db.cfg.Path)
+	}
+
+	db.swap(reader)
+	return nil
+}
+
+// swap replaces the reader, db.mu must be held
+func (db *DB) swap(reader *maxminddb.Reader) {
+	// the previous reader isn't closed: it holds no resources besides memory and may serve lookups right now
+	db.reader.Store(reader)
+	metrics.BuildGauge.WithLabelValues(db.name).Set(float64(reader.Metadata.BuildEpoch))
+
+	infralog.Info("geoip database loaded",
+		zap.String("db", db.name),
+		zap.String("type", reader.Metadata.DatabaseType),
+		zap.Time("build", time.Unix(int64(reader.Metadata.BuildEpoch), 0)))
+}
+
+// Metadata returns metadata of the loaded database
+func (db *DB) Metadata() maxminddb.Metadata {
+	return db.reader.Load().Metadata
+}
+
+// Lookup decodes the record of the ip into result, see maxminddb.Reader.Lookup. Returns false if there is no record
+func (db *DB) Lookup(ip net.IP, result any) (bool, error) {
+	start := time.Now()
+	_, found, err := db.reader.Load().LookupNetwork(ip, result)
+
+	status := "hit"
+	switch {
+	case err != nil:
+		status = "error"
+	case !found:
+		status = "miss"
+	}
+	metrics.LookupDuration.WithLabelValues(db.name, status).Observe(time.Since(start).Seconds())
+
+	return found, errors.Wrapf(err, "unable to lookup %s", ip)
+}
+
+// Country returns the country of the ip with the English name, nil if it's unknown
+func (db *DB) Country(ip net.IP) (*Country, error) {
+	var record countryRecord
+	found, err := db.Lookup(ip, &record)
+	if err != nil || !found || record.Country.ISOCode == "" {
+		return nil, err
+	}
+
+	return &Country{ISOCode: record.Country.ISOCode, Name: record.Country.Names["en"]}, nil
+}
+
+// ASN returns the autonomous system of the ip, nil if it's unknown
+func (db *DB) ASN(ip net.IP) (*ASN, error) {
+	var asn ASN
+	found, err := db.Lookup(ip, &asn)
+	if err != nil || !found || asn.Number == 0 {
+		return nil, err
+	}
+
+	return &asn, nil
+}
+
+func (db *DB) run(ctx context.Context) {
+	defer close(db.done)
+
+	reload := time.NewTicker(db.cfg.GetReloadInterval())
+	defer reload.Stop()
+
+	var download <-chan time.Time
+	if db.cfg.Download != nil {
+		ticker := time.NewTicker(db.cfg.Download.GetInterval())
+		defer ticker.Stop()
+		download = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-reload.C:
+			if err := db.Reload(); err != nil {
+				infralog.Error("unable to reload geoip database", zap.String("db", db.name), zap.Error(err))
+			}
+		case <-download:
+			db.downloadWithRetries(ctx)
+		}
+	}
+}
+
+func (db *DB) downloadWithRetries(ctx context.Context) {
+	retrier := infraretry.New("geoip.download", infraretry.WithMaxAttempts(5))
+
+	err := retrier.Do(ctx, func(ctx context.Context) error {
+		_, err := db.Download(ctx)
+		return err
+	})
+	if err != nil && ctx.Err() == nil {
+		infralog.Error("unable to download geoip database", zap.String("db", db.name), zap.Error(err))
+	}
+}
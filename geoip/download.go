@@ -0,0 +1,168 @@
+package infrageoip
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+	"github.com/pkg/errors"
+	infraconfig "github.com/pushwoosh/infra/config"
+	infralog "github.com/pushwoosh/infra/log"
+	infraretry "github.com/pushwoosh/infra/retry"
+	"go.uber.org/zap"
+)
+
+// maxDatabaseSize limits a downloaded database, the largest MaxMind databases are a few hundred megabytes
+const maxDatabaseSize = 1 << 30
+
+// Download downloads the database if it was updated since the loaded one, replaces the file and loads it.
+// Returns false if the database is up to date
+func (db *DB) Download(ctx context.Context) (bool, error) {
+	cfg := db.cfg.Download
+	if cfg == nil {
+		return false, errors.New("download isn't configured")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.GetTimeout())
+	defer cancel()
+
+	updated, err := db.download(ctx, cfg)
+	switch {
+	case err != nil:
+		metrics.DownloadsCounter.WithLabelValues(db.name, "error").Inc()
+	case updated:
+		metrics.DownloadsCounter.WithLabelValues(db.name, "updated").Inc()
+	default:
+		metrics.DownloadsCounter.WithLabelValues(db.name, "not_modified").Inc()
+	}
+
+	return updated, err
+}
+
+func (db *DB) download(ctx context.Context, cfg *DownloadConfig) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cfg.GetURL(), nil)
+	if err != nil {
+		return false, infraretry.Permanent(err)
+	}
+
+	if cfg.AccountID != "" || cfg.LicenseKey != "" {
+		key, err := infraconfig.ResolveSecret(cfg.LicenseKey)
+		if err != nil {
+			return false, infraretry.Permanent(errors.Wrap(err, "license_key"))
+		}
+		req.SetBasicAuth(cfg.AccountID, key)
+	}
+
+	db.mu.Lock()
+	loaded := db.file
+	db.mu.Unlock()
+	if !loaded.modTime.IsZero() {
+		req.Header.Set("If-Modified-Since", loaded.modTime.UTC().Format(http.TimeFormat))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, errors.Wrap(err, "unable to download geoip database")
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		return false, nil
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return false, infraretry.Permanent(errors.Errorf("geoip download responded %d, check account_id and license_key", resp.StatusCode))
+	case resp.StatusCode != http.StatusOK:
+		return false, errors.Errorf("geoip download responded %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDatabaseSize))
+	if err != nil {
+		return false, errors.Wrap(err, "unable to download geoip database")
+	}
+
+	if data, err = extract(data); err != nil {
+		return false, infraretry.Permanent(err)
+	}
+
+	reader, err := maxminddb.FromBytes(data)
+	if err != nil {
+		return false, infraretry.Permanent(errors.Wrap(err, "downloaded invalid geoip database"))
+	}
+
+	modTime := time.Now()
+	if lastModified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		modTime = lastModified
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if err = writeFile(db.cfg.Path, data, modTime); err != nil {
+		return false, err
+	}
+	db.swap(reader)
+	if stat, err := os.Stat(db.cfg.Path); err == nil {
+		db.file = file{modTime: stat.ModTime(), size: stat.Size()}
+	}
+
+	infralog.Info("geoip database downloaded", zap.String("db", db.name), zap.Int("size", len(data)))
+
+	return true, nil
+}
+
+// extract returns the mmdb file of a tar.gz archive or the data itself if it isn't gzipped
+func extract(data []byte) ([]byte, error) {
+	if len(data) < 2 || data[0] != 0x1f || data[1] != 0x8b {
+		return data, nil
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read geoip archive")
+	}
+
+	archive := tar.NewReader(gz)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil, errors.New("no mmdb file in geoip archive")
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read geoip archive")
+		}
+
+		if header.Typeflag == tar.TypeReg && strings.HasSuffix(header.Name, ".mmdb") {
+			return io.ReadAll(io.LimitReader(archive, maxDatabaseSize))
+		}
+	}
+}
+
+// writeFile replaces the file atomically, so other processes watching it never read a partial database
+func writeFile(path string, data []byte, modTime time.Time) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return errors.Wrap(err, "unable to create geoip database file")
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return errors.Wrap(err, "unable to write geoip database file")
+	}
+	if err = tmp.Close(); err != nil {
+		return errors.Wrap(err, "unable to write geoip database file")
+	}
+	if err = os.Chtimes(tmp.Name(), modTime, modTime); err != nil {
+		return errors.Wrap(err, "unable to write geoip database file")
+	}
+
+	return errors.Wrap(os.Rename(tmp.Name(), path), "unable to replace geoip database file")
+}
@@ -0,0 +1,172 @@
+package infrageoip
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+// encode encodes a value in the MaxMind DB data format, maps are map[string]any
+func encode(v any) []byte {
+	// sizes up to 284 are enough for tests
+	control := func(typ byte, size int) []byte {
+		var extra []byte
+		if size >= 29 {
+			extra = []byte{byte(size - 29)}
+			size = 29
+		}
+		return append([]byte{typ<<5 | byte(size)}, extra...)
+	}
+
+	switch v := v.(type) {
+	case string:
+		return append(control(2, len(v)), v...)
+	case uint32:
+		var buf [4]byte
+		binary.BigEndian.PutUint32(buf[:], v)
+		value := bytes.TrimLeft(buf[:], "\x00")
+		return append(control(6, len(value)), value...)
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		out := control(7, len(v))
+		for _, k := range keys {
+			out = append(out, encode(k)...)
+			out = append(out, encode(v[k])...)
+		}
+		return out
+	}
+	panic("unsupported type")
+}
+
+// buildDB returns an IPv4 database with a single search tree node:
+// 0.0.0.0/1 is mapped to the low record and 128.0.0.0/1 to the high one
+func buildDB(low, high map[string]any) []byte {
+	lowData, highData := encode(low), encode(high)
+
+	const nodeCount = 1
+	lowPointer := nodeCount + 16
+	highPointer := lowPointer + len(lowData)
+
+	var out []byte
+	out = append(out, byte(lowPointer>>16), byte(lowPointer>>8), byte(lowPointer))
+	out = append(out, byte(highPointer>>16), byte(highPointer>>8), byte(highPointer))
+	out = append(out, make([]byte, 16)...)
+	out = append(out, lowData...)
+	out = append(out, highData...)
+	out = append(out, "\xab\xcd\xefMaxMind.com"...)
+	out = append(out, encode(map[string]any{
+		"binary_format_major_version": uint32(2),
+		"binary_format_minor_version": uint32(0),
+		"build_epoch":                 uint32(1700000000),
+		"database_type":               "Test-Country-ASN",
+		"ip_version":                  uint32(4),
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint32(24),
+	})...)
+
+	return out
+}
+
+func record(iso, name string, asn uint32, org string) map[string]any {
+	return map[string]any{
+		"country":                        map[string]any{"iso_code": iso, "names": map[string]any{"en": name}},
+		"autonomous_system_number":       asn,
+		"autonomous_system_organization": org,
+	}
+}
+
+func TestDB(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.mmdb")
+	if err := os.WriteFile(path, buildDB(record("US", "United States", 15169, "Google"), record("DE", "Germany", 3320, "DTAG")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cont := NewContainer()
+	defer cont.Close()
+	if err := cont.Open("test", &Config{Path: path}); err != nil {
+		t.Fatal(err)
+	}
+	db := cont.Get("test")
+
+	country, err := db.Country(net.ParseIP("8.8.8.8"))
+	if err != nil || country == nil || country.ISOCode != "US" || country.Name != "United States" {
+		t.Fatalf("unexpected country %+v %v", country, err)
+	}
+
+	asn, err := db.ASN(net.ParseIP("193.0.0.1"))
+	if err != nil || asn == nil || asn.Number != 3320 || asn.Organization != "DTAG" {
+		t.Fatalf("unexpected asn %+v %v", asn, err)
+	}
+
+	// the replaced file is picked up by a reload
+	if err = os.WriteFile(path, buildDB(record("FR", "France", 1, "Orange SA"), record("DE", "Germany", 3320, "DTAG")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err = db.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if country, _ = db.Country(net.ParseIP("8.8.8.8")); country == nil || country.ISOCode != "FR" {
+		t.Fatalf("expected reloaded database, got %+v", country)
+	}
+}
+
+func TestDownload(t *testing.T) {
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	data := buildDB(record("US", "United States", 15169, "Google"), map[string]any{})
+	_ = tw.WriteHeader(&tar.Header{Name: "GeoLite2-Country_20240101/GeoLite2-Country.mmdb", Mode: 0o644, Size: int64(len(data)), Typeflag: tar.TypeReg})
+	_, _ = tw.Write(data)
+	_ = tw.Close()
+	_ = gz.Close()
+
+	lastModified := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, key, _ := r.BasicAuth(); user != "42" || key != "secret" || r.URL.Path != "/GeoLite2-Country" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		http.ServeContent(w, r, "db.tar.gz", lastModified, bytes.NewReader(archive.Bytes()))
+	}))
+	defer srv.Close()
+
+	db, err := Open("test", &Config{
+		Path: filepath.Join(t.TempDir(), "country.mmdb"),
+		Download: &DownloadConfig{
+			EditionID:  "GeoLite2-Country",
+			AccountID:  "42",
+			LicenseKey: "secret",
+			URL:        srv.URL + "/{edition_id}",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if country, _ := db.Country(net.ParseIP("1.1.1.1")); country == nil || country.ISOCode != "US" {
+		t.Fatalf("unexpected country %+v", country)
+	}
+	if country, _ := db.Country(net.ParseIP("200.1.1.1")); country != nil {
+		t.Fatalf("expected unknown country, got %+v", country)
+	}
+
+	if updated, err := db.Download(context.Background()); err != nil || updated {
+		t.Fatalf("expected database to be up to date, got %v %v", updated, err)
+	}
+}
@@ -0,0 +1,47 @@
+package infrageoip
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	LookupDuration   *prometheus.HistogramVec
+	ReloadsCounter   *prometheus.CounterVec
+	DownloadsCounter *prometheus.CounterVec
+	BuildGauge       *prometheus.GaugeVec
+}
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.LookupDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "geoip_lookup_duration_seconds",
+			Help:    "The GeoIP lookup duration by result: hit, miss or error",
+			Buckets: []float64{0.000001, 0.0000025, 0.000005, 0.00001, 0.000025, 0.00005, 0.0001, 0.00025, 0.001},
+		}, []string{"db", "result"})
+
+		metrics.ReloadsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "geoip_reloads_total",
+			Help: "The total number of GeoIP database reloads",
+		}, []string{"db", "result"})
+
+		metrics.DownloadsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "geoip_downloads_total",
+			Help: "The total number of GeoIP database downloads by result: updated, not_modified or error",
+		}, []string{"db", "result"})
+
+		metrics.BuildGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "geoip_database_build_timestamp_seconds",
+			Help: "Build time of the loaded GeoIP database",
+		}, []string{"db"})
+
+		prometheus.MustRegister(
+			metrics.LookupDuration,
+			metrics.ReloadsCounter,
+			metrics.DownloadsCounter,
+			metrics.BuildGauge,
+		)
+	})
+}
@@ -0,0 +1,55 @@
+package infragrpcgateway
+
+import (
+	"github.com/pkg/errors"
+	infragrpcserver "github.com/pushwoosh/infra/grpc/grpcserver"
+	infrahttp "github.com/pushwoosh/infra/http"
+)
+
+type Config struct {
+	// GRPC is the gRPC server config
+	GRPC *infragrpcserver.GrpcConfig `mapstructure:"grpc"`
+
+	// HTTP is the REST server config, its standard middlewares are applied to REST requests
+	HTTP *infrahttp.Config `mapstructure:"http"`
+
+	// Path the OpenAPI spec is served on if it's set with WithOpenAPI. optional, default: /openapi.json
+	OpenAPIPath string `mapstructure:"openapi_path"`
+}
+
+func DefaultConfig() *Config {
+	httpCfg := infrahttp.DefaultConfig()
+	httpCfg.Middlewares = infrahttp.DefaultMiddlewaresConfig()
+
+	return &Config{
+		GRPC: infragrpcserver.DefaultGrpcConfig(),
+		HTTP: httpCfg,
+	}
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if c.GRPC == nil || c.HTTP == nil {
+		return errors.New("grpc and http are mandatory")
+	}
+
+	if err := c.GRPC.Validate(); err != nil {
+		return errors.Wrap(err, "grpc")
+	}
+
+	if err := c.HTTP.Validate(); err != nil {
+		return errors.Wrap(err, "http")
+	}
+
+	return nil
+}
+
+func (c *Config) GetOpenAPIPath() string {
+	if c.OpenAPIPath == "" {
+		return "/openapi.json"
+	}
+	return c.OpenAPIPath
+}
@@ -0,0 +1,232 @@
+package infragrpcgateway
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/textproto"
+	"sync"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/pkg/errors"
+	infragrpcserver "github.com/pushwoosh/infra/grpc/grpcserver"
+	infrahttp "github.com/pushwoosh/infra/http"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	infrarequestid "github.com/pushwoosh/infra/requestid"
+	infratenancy "github.com/pushwoosh/infra/tenancy"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// HandlerFunc registers REST handlers of a service, it's the signature of generated Register<Service>Handler
+type HandlerFunc func(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error
+
+// Gateway serves gRPC services and their REST transcoding by grpc-gateway with one lifecycle:
+//
+//	gw, err := infragrpcgateway.New(cfg, infragrpcgateway.WithOpenAPI(openapiSpec))
+//	err = infragrpcgateway.Register(gw, pb.RegisterUsersServer, usersService, pb.RegisterUsersHandler)
+//	app.Add("gateway", gw)
+//
+// REST requests are passed to the gRPC server in process without network, so they go through the gRPC
+// interceptor chain: authentication, capacity limiter, metrics and logging. The gRPC peer of a REST call is
+// the REST client: its address and TLS state, so client IP limits and mutual TLS checks apply. The REST server applies
+// infrahttp middlewares of Config.HTTP, request ids and tenants are propagated to gRPC calls, errors
+// are sent as problem details, see ProblemErrorHandler.
+type Gateway struct {
+	cfg *Config
+
+	grpc    *infragrpcserver.Server
+	http    *infrahttp.Server
+	mux     *runtime.ServeMux
+	httpMux *http.ServeMux
+	conn    *grpc.ClientConn
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	started bool
+}
+
+var (
+	_ infraoperator.Starter = (*Gateway)(nil)
+	_ infraoperator.Stopper = (*Gateway)(nil)
+)
+
+func New(cfg *Config, opts ...Option) (*Gateway, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	o := &options{name: "gateway"}
+	for _, opt := range opts {
+		opt.apply(o)
+	}
+
+	grpcSrv := infragrpcserver.NewServer(cfg.GRPC, append([]infragrpcserver.ServerOption{
+		infragrpcserver.WithName(o.name),
+	}, o.grpcOptions...)...)
+
+	conn, err := grpcSrv.ClientConn(
+		grpc.WithChainUnaryInterceptor(infrarequestid.UnaryClientInterceptor(), infratenancy.UnaryClientInterceptor()),
+		grpc.WithChainStreamInterceptor(infrarequestid.StreamClientInterceptor(), infratenancy.StreamClientInterceptor()),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	muxOptions := append([]runtime.ServeMuxOption{
+		infragrpcserver.MuxOptionCustomMarshaler(),
+		runtime.WithErrorHandler(ProblemErrorHandler),
+		runtime.WithIncomingHeaderMatcher(headerMatcher),
+	}, o.muxOptions...)
+
+	g := &Gateway{
+		cfg:     cfg,
+		grpc:    grpcSrv,
+		mux:     runtime.NewServeMux(muxOptions...),
+		httpMux: http.NewServeMux(),
+		conn:    conn,
+	}
+	g.ctx, g.cancel = context.WithCancel(context.Background())
+
+	g.httpMux.Handle("/", peerHandler(g.mux))
+	if o.openAPI != nil {
+		g.httpMux.Handle(cfg.GetOpenAPIPath(), openAPIHandler(o.openAPI))
+	}
+
+	g.http = infrahttp.NewServer(cfg.HTTP, g.httpMux, append([]infrahttp.ServerOption{
+		infrahttp.WithName(o.name),
+	}, o.httpOptions...)...)
+
+	return g, nil
+}
+
+// Register registers the gRPC service implementation and its REST handlers in one call:
+//
+//	err := infragrpcgateway.Register(gw, pb.RegisterUsersServer, usersService, pb.RegisterUsersHandler)
+func Register[T any](g *Gateway, registerServer func(grpc.ServiceRegistrar, T), impl T, registerHandler HandlerFunc) error {
+	registerServer(g.Registrar(), impl)
+	return g.RegisterHandler(registerHandler)
+}
+
+// Registrar returns the registrar of gRPC services. Services must be registered before Start
+func (g *Gateway) Registrar() grpc.ServiceRegistrar {
+	return g.grpc.Registrar()
+}
+
+// RegisterHandler registers REST handlers of a gRPC service registered with Registrar
+func (g *Gateway) RegisterHandler(fn HandlerFunc) error {
+	return errors.Wrap(fn(g.ctx, g.mux, g.conn), "unable to register handler")
+}
+
+// Handle registers an additional http handler on the REST server, e.g. a webhook endpoint
+func (g *Gateway) Handle(pattern string, handler http.Handler) {
+	g.httpMux.Handle(pattern, handler)
+}
+
+// Mux returns grpc-gateway mux, e.g. to add custom routes with HandlePath
+func (g *Gateway) Mux() *runtime.ServeMux {
+	return g.mux
+}
+
+// GRPC returns the gRPC server, e.g. to change health statuses
+func (g *Gateway) GRPC() *infragrpcserver.Server {
+	return g.grpc
+}
+
+// Start starts the gRPC server and then the REST server
+func (g *Gateway) Start(ctx context.Context) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.started {
+		return errors.New("gateway is already started")
+	}
+
+	if err := g.grpc.Start(ctx); err != nil {
+		return errors.Wrap(err, "grpc")
+	}
+
+	if err := g.http.Start(ctx); err != nil {
+		_ = g.grpc.Stop(ctx)
+		return errors.Wrap(err, "http")
+	}
+
+	g.started = true
+	return nil
+}
+
+// Stop stops the REST server first, so in-flight REST requests complete their gRPC calls, and then the gRPC server
+func (g *Gateway) Stop(ctx context.Context) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.started {
+		return nil
+	}
+	g.started = false
+
+	err := errors.Wrap(g.http.Stop(ctx), "http")
+
+	g.cancel()
+	_ = g.conn.Close()
+
+	if grpcErr := g.grpc.Stop(ctx); err == nil {
+		err = errors.Wrap(grpcErr, "grpc")
+	}
+
+	return err
+}
+
+// headerMatcher forwards Authorization as is, so gRPC auth functions see the same metadata
+// for gRPC and REST calls. Other headers are matched by runtime.DefaultHeaderMatcher
+func headerMatcher(key string) (string, bool) {
+	if textproto.CanonicalMIMEHeaderKey(key) == "Authorization" {
+		return "authorization", true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// peerHandler passes the client of a REST request to the gRPC server as the peer of calls
+func peerHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := &peer.Peer{Addr: remoteAddr(r.RemoteAddr)}
+		if r.TLS != nil {
+			p.AuthInfo = credentials.TLSInfo{
+				State:          *r.TLS,
+				CommonAuthInfo: credentials.CommonAuthInfo{SecurityLevel: credentials.PrivacyAndIntegrity},
+			}
+		}
+
+		next.ServeHTTP(w, r.WithContext(infragrpcserver.ContextWithPeer(r.Context(), p)))
+	})
+}
+
+func remoteAddr(addr string) net.Addr {
+	if addrPort, err := netip.ParseAddrPort(addr); err == nil {
+		return net.TCPAddrFromAddrPort(addrPort)
+	}
+	return stringAddr(addr)
+}
+
+// stringAddr is a remote address that isn't ip:port, e.g. of a unix socket
+type stringAddr string
+
+func (a stringAddr) Network() string { return "unknown" }
+func (a stringAddr) String() string  { return string(a) }
+
+func openAPIHandler(spec []byte) http.Handler {
+	contentType := "application/yaml"
+	if trimmed := bytes.TrimSpace(spec); len(trimmed) > 0 && trimmed[0] == '{' {
+		contentType = "application/json"
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		_, _ = w.Write(spec)
+	})
+}
@@ -0,0 +1,172 @@
+package infragrpcgateway
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	infragrpcserver "github.com/pushwoosh/infra/grpc/grpcserver"
+	infrahttp "github.com/pushwoosh/infra/http"
+	infratls "github.com/pushwoosh/infra/tls"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+)
+
+func registerHealthHandler(_ context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error {
+	client := healthpb.NewHealthClient(conn)
+
+	return mux.HandlePath(http.MethodGet, "/health/{service}", func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+		_, outbound := runtime.MarshalerForRequest(mux, r)
+
+		resp, err := client.Check(r.Context(), &healthpb.HealthCheckRequest{Service: params["service"]})
+		if err != nil {
+			runtime.HTTPError(r.Context(), mux, outbound, w, r, err)
+			return
+		}
+		runtime.ForwardResponseMessage(r.Context(), mux, outbound, w, r, resp)
+	})
+}
+
+func TestGateway(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// REST calls must work with mutual TLS of the gRPC server
+	cert, key := selfSigned(t)
+
+	cfg := DefaultConfig()
+	cfg.GRPC.Listen = "127.0.0.1:0"
+	cfg.GRPC.HealthEnabled = true
+	cfg.GRPC.TLS = &infratls.Config{Cert: cert, Key: key, CA: cert, RequireClientCert: true}
+	cfg.HTTP.Listen = "127.0.0.1:0"
+
+	var peerAddr atomic.Value
+	capturePeer := func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if p, ok := peer.FromContext(ctx); ok {
+			peerAddr.Store(p.Addr.String())
+		}
+		return handler(ctx, req)
+	}
+
+	gw, err := New(cfg,
+		WithHTTPOptions(infrahttp.WithListener(listener), infrahttp.WithoutMetrics()),
+		WithGRPCOptions(infragrpcserver.WithUnaryInterceptors(capturePeer)),
+		WithOpenAPI([]byte(`{"openapi":"3.0.0"}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.RegisterHandler(registerHealthHandler); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if err := gw.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = gw.Stop(ctx) }()
+
+	gw.GRPC().SetServingStatus("users", true)
+	base := "http://" + listener.Addr().String()
+
+	t.Run("ok", func(t *testing.T) {
+		resp, body := get(t, base+"/health/users")
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status %d: %s", resp.StatusCode, body)
+		}
+
+		// the gRPC peer is the REST client, not the in-process connection
+		host, _, _ := net.SplitHostPort(fmt.Sprint(peerAddr.Load()))
+		if host != "127.0.0.1" {
+			t.Fatalf("unexpected peer %v", peerAddr.Load())
+		}
+	})
+
+	t.Run("problem", func(t *testing.T) {
+		resp, body := get(t, base+"/health/unknown")
+		if resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("status %d: %s", resp.StatusCode, body)
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != ProblemContentType {
+			t.Fatalf("content type %q", ct)
+		}
+
+		var problem Problem
+		if err := json.Unmarshal(body, &problem); err != nil {
+			t.Fatal(err)
+		}
+		if problem.Status != http.StatusNotFound || problem.Code != "NotFound" {
+			t.Fatalf("unexpected problem %+v", problem)
+		}
+	})
+
+	t.Run("openapi", func(t *testing.T) {
+		resp, _ := get(t, base+"/openapi.json")
+		if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+			t.Fatalf("content type %q", ct)
+		}
+	})
+}
+
+// selfSigned returns PEM encoded self-signed certificate and key for localhost
+func selfSigned(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
+
+func get(t *testing.T, url string) (*http.Response, []byte) {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, body
+}
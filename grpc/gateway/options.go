@@ -0,0 +1,74 @@
+package infragrpcgateway
+
+import (
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	infragrpcserver "github.com/pushwoosh/infra/grpc/grpcserver"
+	infrahttp "github.com/pushwoosh/infra/http"
+)
+
+type options struct {
+	name        string
+	muxOptions  []runtime.ServeMuxOption
+	grpcOptions []infragrpcserver.ServerOption
+	httpOptions []infrahttp.ServerOption
+	openAPI     []byte
+}
+
+type Option interface {
+	apply(o *options)
+}
+
+type optionWithName string
+
+func (opt optionWithName) apply(o *options) {
+	o.name = string(opt)
+}
+
+// WithName sets the name of both servers used in logs and metrics labels, default: "gateway"
+func WithName(name string) Option {
+	return optionWithName(name)
+}
+
+type optionWithMuxOptions []runtime.ServeMuxOption
+
+func (opt optionWithMuxOptions) apply(o *options) {
+	o.muxOptions = append(o.muxOptions, opt...)
+}
+
+// WithMuxOptions adds grpc-gateway mux options after the default ones, so they may override them
+func WithMuxOptions(opts ...runtime.ServeMuxOption) Option {
+	return optionWithMuxOptions(opts)
+}
+
+type optionWithGRPCOptions []infragrpcserver.ServerOption
+
+func (opt optionWithGRPCOptions) apply(o *options) {
+	o.grpcOptions = append(o.grpcOptions, opt...)
+}
+
+// WithGRPCOptions sets options of the gRPC server, e.g. infragrpcserver.WithAuth
+func WithGRPCOptions(opts ...infragrpcserver.ServerOption) Option {
+	return optionWithGRPCOptions(opts)
+}
+
+type optionWithHTTPOptions []infrahttp.ServerOption
+
+func (opt optionWithHTTPOptions) apply(o *options) {
+	o.httpOptions = append(o.httpOptions, opt...)
+}
+
+// WithHTTPOptions sets options of the REST server, e.g. infrahttp.WithMiddlewares
+func WithHTTPOptions(opts ...infrahttp.ServerOption) Option {
+	return optionWithHTTPOptions(opts)
+}
+
+type optionWithOpenAPI []byte
+
+func (opt optionWithOpenAPI) apply(o *options) {
+	o.openAPI = opt
+}
+
+// WithOpenAPI serves the OpenAPI spec, e.g. an embedded protoc-gen-openapiv2 output, on Config.OpenAPIPath
+func WithOpenAPI(spec []byte) Option {
+	return optionWithOpenAPI(spec)
+}
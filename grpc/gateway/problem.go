@@ -0,0 +1,72 @@
+package infragrpcgateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/pkg/errors"
+	infrarequestid "github.com/pushwoosh/infra/requestid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// ProblemContentType is the content type of error responses
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 9457 problem details response of a failed call
+type Problem struct {
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Status    int               `json:"status"`
+	Detail    string            `json:"detail,omitempty"`
+	Instance  string            `json:"instance,omitempty"`
+	Code      string            `json:"code"`
+	RequestID string            `json:"request_id,omitempty"`
+	Details   []json.RawMessage `json:"details,omitempty"`
+}
+
+// ProblemErrorHandler writes gRPC errors as problem details. HTTP statuses follow grpc-gateway mapping
+// except ResourceExhausted of the capacity limiter, it's 503. Status details, e.g. errdetails.BadRequest,
+// are sent in details
+func ProblemErrorHandler(ctx context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	httpStatus := 0
+
+	var statusErr *runtime.HTTPStatusError
+	if errors.As(err, &statusErr) {
+		httpStatus = statusErr.HTTPStatus
+		err = statusErr.Err
+	}
+
+	st := status.Convert(err)
+	if httpStatus == 0 {
+		httpStatus = runtime.HTTPStatusFromCode(st.Code())
+		if st.Code() == codes.ResourceExhausted {
+			httpStatus = http.StatusServiceUnavailable
+		}
+	}
+
+	problem := Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(httpStatus),
+		Status:    httpStatus,
+		Detail:    st.Message(),
+		Instance:  r.URL.Path,
+		Code:      st.Code().String(),
+		RequestID: infrarequestid.FromContext(ctx),
+	}
+
+	for _, detail := range st.Proto().GetDetails() {
+		if data, err := protojson.Marshal(detail); err == nil {
+			problem.Details = append(problem.Details, data)
+		}
+	}
+
+	w.Header().Del("Trailer")
+	w.Header().Del("Transfer-Encoding")
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(httpStatus)
+	_ = json.NewEncoder(w).Encode(problem)
+}
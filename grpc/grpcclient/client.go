@@ -8,9 +8,12 @@ import (
 	grpc_retry "github.com/grpc-ecosystem/go-grpc-middleware/retry"
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
 	"github.com/pkg/errors"
+	infrarequestid "github.com/pushwoosh/infra/requestid"
+	infratenancy "github.com/pushwoosh/infra/tenancy"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/balancer/roundrobin"
 	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/keepalive"
 )
@@ -48,67 +51,58 @@ func NewContainer() *Container {
 
 // Connect creates a new named GRPC connection
 func (cont *Container) Connect(name string, cfg *ConnectionConfig) error {
-	unaryInterceptors := []grpc.UnaryClientInterceptor{
-		grpc_prometheus.UnaryClientInterceptor,
+	conn, err := Dial(name, cfg)
+	if err != nil {
+		return err
 	}
 
-	if cfg.Retry == nil {
-		cfg.Retry = &RetryConfig{
-			LinearBackoff: &LinearBackoffConfig{
-				Delay:       defaultRetryDelay,
-				MaxAttempts: defaultRetryMaxAttempts,
-				Jitter:      defaultRetryJitter,
-			},
-		}
-	}
+	cont.mu.Lock()
+	defer cont.mu.Unlock()
 
-	codes := grpc_retry.DefaultRetriableCodes
-	if cfg.Retry.Codes != nil {
-		codes = cfg.Retry.Codes
+	cont.pool[name] = conn
+	cont.cfg[name] = *cfg
+
+	return nil
+}
+
+// Dial creates a new GRPC connection with options built from config.
+// target is a logical name of the remote service that is used in metrics labels.
+// opts are appended to the options built from config, e.g. grpc.WithResolvers.
+func Dial(target string, cfg *ConnectionConfig, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	initMetrics()
+
+	unaryInterceptors := []grpc.UnaryClientInterceptor{
+		infrarequestid.UnaryClientInterceptor(),
+		infratenancy.UnaryClientInterceptor(),
+		grpc_prometheus.UnaryClientInterceptor,
+		unaryClientMetricsInterceptor(target),
+	}
+	streamInterceptors := []grpc.StreamClientInterceptor{
+		infrarequestid.StreamClientInterceptor(),
+		infratenancy.StreamClientInterceptor(),
+		grpc_prometheus.StreamClientInterceptor,
+		streamClientMetricsInterceptor(target),
 	}
 
-	if cfg.Retry.ExponentialBackoff != nil {
-		backoff := cfg.Retry.ExponentialBackoff
-		if backoff.Jitter <= 0 || backoff.Jitter > 1 {
-			backoff.Jitter = defaultRetryJitter
-		}
-		unaryInterceptors = append(unaryInterceptors,
-			grpc_retry.UnaryClientInterceptor(
-				grpc_retry.WithBackoff(grpc_retry.BackoffExponentialWithJitter(backoff.BaseDelay, backoff.Jitter)),
-				grpc_retry.WithMax(uint(backoff.MaxAttempts)),
-				grpc_retry.WithCodes(codes...),
-			),
-		)
-	} else if cfg.Retry.LinearBackoff != nil {
-		backoff := cfg.Retry.LinearBackoff
-		if backoff.Jitter <= 0 || backoff.Jitter > 1 {
-			backoff.Jitter = defaultRetryJitter
-		}
-		unaryInterceptors = append(unaryInterceptors,
-			grpc_retry.UnaryClientInterceptor(
-				grpc_retry.WithBackoff(grpc_retry.BackoffLinearWithJitter(backoff.Delay, backoff.Jitter)),
-				grpc_retry.WithMax(uint(backoff.MaxAttempts)),
-				grpc_retry.WithCodes(codes...),
-			),
-		)
-	} else if cfg.Retry.Codes != nil {
-		unaryInterceptors = append(unaryInterceptors,
-			grpc_retry.UnaryClientInterceptor(
-				grpc_retry.WithCodes(codes...),
-			),
-		)
-	} else {
-		panic("invalid retryer config")
+	// retries are made by grpc itself when service policy is set
+	if cfg.Policy == nil {
+		unaryInterceptors = append(unaryInterceptors, retryInterceptor(cfg))
 	}
 
 	options := []grpc.DialOption{
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
 		grpc.WithChainUnaryInterceptor(unaryInterceptors...),
+		grpc.WithChainStreamInterceptor(streamInterceptors...),
 	}
 
 	if cfg.TLS == nil || !cfg.TLS.Enabled {
 		options = append(options, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	} else {
-		panic("TLS is not supported yet")
+		loader := cfg.TLS.Loader()
+		if err := loader.Reload(); err != nil {
+			return nil, errors.Wrap(err, "tls")
+		}
+		options = append(options, grpc.WithTransportCredentials(credentials.NewTLS(loader.ClientConfig())))
 	}
 
 	// setup keepalive options. see keepalive.ClientParameters for details
@@ -120,10 +114,12 @@ func (cont *Container) Connect(name string, cfg *ConnectionConfig) error {
 		}))
 	}
 
-	// setup load balancing options
-	options = append(options, grpc.WithDefaultServiceConfig(
-		`{"loadBalancingPolicy":"`+roundrobin.Name+`"}`),
-	)
+	// setup load balancing and retry policy
+	serviceConfig, err := cfg.serviceConfig()
+	if err != nil {
+		return nil, err
+	}
+	options = append(options, grpc.WithDefaultServiceConfig(serviceConfig))
 
 	if cfg.MaxGrpcSendMsgSizeMB > 0 {
 		options = append(options, grpc.WithDefaultCallOptions(grpc.MaxCallSendMsgSize(cfg.MaxGrpcSendMsgSizeMB*1024*1024)))
@@ -133,9 +129,11 @@ func (cont *Container) Connect(name string, cfg *ConnectionConfig) error {
 		options = append(options, grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(cfg.MaxGrpcRecvMsgSizeMB*1024*1024)))
 	}
 
+	options = append(options, opts...)
+
 	conn, err := grpc.NewClient(cfg.Address, options...)
 	if err != nil {
-		return errors.Wrapf(err, "can't create grpc connection to \"%s\"", cfg.Address)
+		return nil, errors.Wrapf(err, "can't create grpc connection to \"%s\"", cfg.Address)
 	}
 
 	// try to connect immediately if lazy connection is disabled
@@ -148,17 +146,58 @@ func (cont *Container) Connect(name string, cfg *ConnectionConfig) error {
 		conn.WaitForStateChange(ctx, connectivity.Idle) // do not check result, we are not interested in that change
 		stateChanged := conn.WaitForStateChange(ctx, connectivity.Connecting)
 		if !stateChanged || conn.GetState() != connectivity.Ready {
-			return errors.Wrap(ErrFailedToConnect, name)
+			_ = conn.Close()
+			return nil, errors.Wrap(ErrFailedToConnect, target)
 		}
 	}
 
-	cont.mu.Lock()
-	defer cont.mu.Unlock()
+	return conn, nil
+}
 
-	cont.pool[name] = conn
-	cont.cfg[name] = *cfg
+// retryInterceptor creates retrying interceptor from retry config
+func retryInterceptor(cfg *ConnectionConfig) grpc.UnaryClientInterceptor {
+	if cfg.Retry == nil {
+		cfg.Retry = &RetryConfig{
+			LinearBackoff: &LinearBackoffConfig{
+				Delay:       defaultRetryDelay,
+				MaxAttempts: defaultRetryMaxAttempts,
+				Jitter:      defaultRetryJitter,
+			},
+		}
+	}
 
-	return nil
+	codes := grpc_retry.DefaultRetriableCodes
+	if cfg.Retry.Codes != nil {
+		codes = cfg.Retry.Codes
+	}
+
+	if cfg.Retry.ExponentialBackoff != nil {
+		backoff := cfg.Retry.ExponentialBackoff
+		if backoff.Jitter <= 0 || backoff.Jitter > 1 {
+			backoff.Jitter = defaultRetryJitter
+		}
+		return grpc_retry.UnaryClientInterceptor(
+			grpc_retry.WithBackoff(grpc_retry.BackoffExponentialWithJitter(backoff.BaseDelay, backoff.Jitter)),
+			grpc_retry.WithMax(uint(backoff.MaxAttempts)),
+			grpc_retry.WithCodes(codes...),
+		)
+	} else if cfg.Retry.LinearBackoff != nil {
+		backoff := cfg.Retry.LinearBackoff
+		if backoff.Jitter <= 0 || backoff.Jitter > 1 {
+			backoff.Jitter = defaultRetryJitter
+		}
+		return grpc_retry.UnaryClientInterceptor(
+			grpc_retry.WithBackoff(grpc_retry.BackoffLinearWithJitter(backoff.Delay, backoff.Jitter)),
+			grpc_retry.WithMax(uint(backoff.MaxAttempts)),
+			grpc_retry.WithCodes(codes...),
+		)
+	} else if cfg.Retry.Codes != nil {
+		return grpc_retry.UnaryClientInterceptor(
+			grpc_retry.WithCodes(codes...),
+		)
+	}
+
+	panic("invalid retryer config")
 }
 
 // Get gets connection from a container
@@ -5,36 +5,55 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	infraconfig "github.com/pushwoosh/infra/config"
+	infratls "github.com/pushwoosh/infra/tls"
 	"google.golang.org/grpc/codes"
 )
 
+func init() {
+	// services start in dev without their dependencies running
+	infraconfig.RegisterProfileDefaults(infraconfig.ProfileDev, func(c *ConnectionConfig) {
+		c.Lazy = true
+	})
+}
+
 // ConnectionConfig holds GRPC client configuration
 type ConnectionConfig struct {
 	// GRPC Service address.
-	// See https://github.com/grpc/grpc/blob/master/doc/naming.md for format. TLDR: "host:port" is okay
+	// See https://github.com/grpc/grpc/blob/master/doc/naming.md for format. TLDR: "host:port" is okay.
+	// Use "dns:///host:port" to balance over all resolved addresses.
 	Address string `mapstructure:"address"`
 
+	// LoadBalancing is a load balancing policy: "round_robin" (default) or "pick_first"
+	LoadBalancing string `mapstructure:"load_balancing"`
+
 	// Keepalive options
 	Keepalive *KeepaliveConfig
 
 	// Retry policy config
 	Retry *RetryConfig `mapstructure:"retry"`
 
+	// Policy is grpc native retry or hedging policy. Interceptor based Retry is disabled when it's set
+	Policy *PolicyConfig `mapstructure:"policy"`
+
 	// Max outgoing grpc request size in megabytes
 	MaxGrpcSendMsgSizeMB int `mapstructure:"max_grpc_send_msg_size_mb"`
 
 	// Max incoming grpc request size in megabytes
 	MaxGrpcRecvMsgSizeMB int `mapstructure:"max_grpc_recv_msg_size_mb"`
 
-	// TLSConfig: unused
+	// TLS options. TLS is disabled if empty
 	TLS *TLSConfig `mapstructure:"tls"`
 
 	// Lazy defines if the connection should be established immediately
 	Lazy bool `mapstructure:"lazy"`
 }
 
+// TLSConfig enables transport security with CA, client certificate for mutual TLS and server checks of infratls
 type TLSConfig struct {
-	Enabled bool
+	Enabled bool `mapstructure:"enabled"`
+
+	infratls.Config `mapstructure:",squash"`
 }
 
 type ConnectionsConfig map[string]*ConnectionConfig
@@ -98,6 +117,22 @@ func (c *ConnectionConfig) Validate() error {
 		return errors.New("Address is mandatory")
 	}
 
+	if c.LoadBalancing != "" && c.LoadBalancing != LoadBalancingRoundRobin && c.LoadBalancing != LoadBalancingPickFirst {
+		return errors.Errorf("unknown load balancing policy: %s", c.LoadBalancing)
+	}
+
+	if c.Policy != nil {
+		if err := c.Policy.Validate(); err != nil {
+			return errors.Wrap(err, "policy")
+		}
+	}
+
+	if c.TLS != nil && c.TLS.Enabled {
+		if err := c.TLS.Config.Validate(); err != nil {
+			return errors.Wrap(err, "tls")
+		}
+	}
+
 	if c.Retry == nil {
 		c.Retry = NewDefaultRetryConfig()
 	}
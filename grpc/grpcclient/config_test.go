@@ -0,0 +1,36 @@
+package infragrpcclient
+
+import (
+	"testing"
+	"time"
+
+	infratls "github.com/pushwoosh/infra/tls"
+)
+
+func TestConnectionConfig(t *testing.T) {
+	cfg := &ConnectionConfig{Address: "localhost:9091"}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Retry == nil || cfg.Retry.LinearBackoff == nil {
+		t.Fatal("expected the default retry config")
+	}
+
+	for name, invalid := range map[string]*ConnectionConfig{
+		"address":        {},
+		"load balancing": {Address: "localhost:9091", LoadBalancing: "random"},
+		"policy":         {Address: "localhost:9091", Policy: &PolicyConfig{Hedging: &HedgingPolicyConfig{}}},
+		"tls":            {Address: "localhost:9091", TLS: &TLSConfig{Enabled: true, Config: infratls.Config{CertFile: "client.pem"}}},
+		"retry":          {Address: "localhost:9091", Retry: &RetryConfig{LinearBackoff: &LinearBackoffConfig{}}},
+		"exponential":    {Address: "localhost:9091", Retry: &RetryConfig{ExponentialBackoff: &ExponentialBackoffConfig{BaseDelay: time.Second}}},
+	} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+
+	connections := ConnectionsConfig{"main": {Address: "localhost:9091"}, "billing": {}}
+	if err := connections.Validate(); err == nil {
+		t.Fatal("expected validation error")
+	}
+}
@@ -0,0 +1,63 @@
+package infragrpcclient
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	inframetrics "github.com/pushwoosh/infra/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+var metrics struct {
+	RequestsCounter          *inframetrics.CounterVec
+	RequestDurationHistogram *inframetrics.HistogramVec
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.RequestsCounter = inframetrics.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_client_target_requests_total",
+			Help: "Total number of RPCs completed by the client, labeled by logical target",
+		}, []string{"target", "grpc_method", "grpc_code"})
+
+		metrics.RequestDurationHistogram = inframetrics.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "grpc_client_target_request_duration_seconds",
+			Help:    "Duration of RPCs completed by the client, labeled by logical target",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"target", "grpc_method"})
+
+		prometheus.MustRegister(
+			metrics.RequestsCounter,
+			metrics.RequestDurationHistogram,
+		)
+	})
+}
+
+func unaryClientMetricsInterceptor(target string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		observe(target, method, start, err)
+		return err
+	}
+}
+
+// streamClientMetricsInterceptor measures stream establishment only
+func streamClientMetricsInterceptor(target string) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		start := time.Now()
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		observe(target, method, start, err)
+		return stream, err
+	}
+}
+
+func observe(target, method string, start time.Time, err error) {
+	metrics.RequestsCounter.WithLabelValues(target, method, status.Code(err).String()).Inc()
+	metrics.RequestDurationHistogram.WithLabelValues(target, method).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,161 @@
+package infragrpcclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/balancer/roundrobin"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	LoadBalancingRoundRobin = roundrobin.Name
+	LoadBalancingPickFirst  = "pick_first"
+)
+
+// PolicyConfig configures grpc native retry or hedging policy applied to all methods.
+// Only one of Retry and Hedging may be set.
+// See https://github.com/grpc/proposal/blob/master/A6-client-retries.md for details.
+type PolicyConfig struct {
+	Retry   *RetryPolicyConfig   `mapstructure:"retry"`
+	Hedging *HedgingPolicyConfig `mapstructure:"hedging"`
+}
+
+type RetryPolicyConfig struct {
+	// MaxAttempts includes the original request, must be greater than 1
+	MaxAttempts       int           `mapstructure:"max_attempts"`
+	InitialBackoff    time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff        time.Duration `mapstructure:"max_backoff"`
+	BackoffMultiplier float64       `mapstructure:"backoff_multiplier"`
+	// Codes are retryable status codes. optional, default is UNAVAILABLE
+	Codes []codes.Code `mapstructure:"codes"`
+}
+
+type HedgingPolicyConfig struct {
+	// MaxAttempts includes the original request, must be greater than 1
+	MaxAttempts  int           `mapstructure:"max_attempts"`
+	HedgingDelay time.Duration `mapstructure:"hedging_delay"`
+	// NonFatalCodes are status codes that don't cancel other hedged requests. optional
+	NonFatalCodes []codes.Code `mapstructure:"non_fatal_codes"`
+}
+
+func (c *PolicyConfig) Validate() error {
+	if c.Retry != nil && c.Hedging != nil {
+		return errors.New("retry and hedging policies are mutually exclusive")
+	}
+
+	if c.Retry != nil {
+		if err := c.Retry.Validate(); err != nil {
+			return errors.Wrap(err, "retry")
+		}
+	}
+
+	if c.Hedging != nil {
+		if err := c.Hedging.Validate(); err != nil {
+			return errors.Wrap(err, "hedging")
+		}
+	}
+
+	return nil
+}
+
+func (c *RetryPolicyConfig) Validate() error {
+	if c.MaxAttempts < 2 {
+		return errors.New("max_attempts should be greater than 1")
+	}
+
+	if c.InitialBackoff <= 0 || c.MaxBackoff <= 0 {
+		return errors.New("backoff should be greater than zero")
+	}
+
+	if c.BackoffMultiplier <= 0 {
+		return errors.New("backoff_multiplier should be greater than zero")
+	}
+
+	return nil
+}
+
+func (c *HedgingPolicyConfig) Validate() error {
+	if c.MaxAttempts < 2 {
+		return errors.New("max_attempts should be greater than 1")
+	}
+
+	if c.HedgingDelay < 0 {
+		return errors.New("hedging_delay should be greater than or equal to zero")
+	}
+
+	return nil
+}
+
+// serviceConfig builds grpc service config JSON. See https://github.com/grpc/grpc/blob/master/doc/service_config.md
+func (c *ConnectionConfig) serviceConfig() (string, error) {
+	type methodConfig struct {
+		Name          []map[string]string `json:"name"`
+		RetryPolicy   map[string]any      `json:"retryPolicy,omitempty"`
+		HedgingPolicy map[string]any      `json:"hedgingPolicy,omitempty"`
+	}
+
+	lb := c.LoadBalancing
+	if lb == "" {
+		lb = LoadBalancingRoundRobin
+	}
+
+	sc := map[string]any{"loadBalancingPolicy": lb}
+
+	if c.Policy != nil {
+		// empty name matches all methods of all services
+		mc := methodConfig{Name: []map[string]string{{}}}
+
+		if p := c.Policy.Retry; p != nil {
+			retryCodes := p.Codes
+			if len(retryCodes) == 0 {
+				retryCodes = []codes.Code{codes.Unavailable}
+			}
+
+			mc.RetryPolicy = map[string]any{
+				"maxAttempts":          p.MaxAttempts,
+				"initialBackoff":       durationJSON(p.InitialBackoff),
+				"maxBackoff":           durationJSON(p.MaxBackoff),
+				"backoffMultiplier":    p.BackoffMultiplier,
+				"retryableStatusCodes": codeNames(retryCodes),
+			}
+		}
+
+		if p := c.Policy.Hedging; p != nil {
+			mc.HedgingPolicy = map[string]any{
+				"maxAttempts":         p.MaxAttempts,
+				"hedgingDelay":        durationJSON(p.HedgingDelay),
+				"nonFatalStatusCodes": codeNames(p.NonFatalCodes),
+			}
+		}
+
+		sc["methodConfig"] = []methodConfig{mc}
+	}
+
+	data, err := json.Marshal(sc)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to build service config")
+	}
+
+	return string(data), nil
+}
+
+// durationJSON formats duration as protobuf JSON duration
+func durationJSON(d time.Duration) string {
+	return fmt.Sprintf("%.9fs", d.Seconds())
+}
+
+func codeNames(list []codes.Code) []string {
+	names := make([]string, 0, len(list))
+	for _, code := range list {
+		for name, c := range strToCode {
+			if c == code {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,97 @@
+package infragrpcclient
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestPolicyConfig(t *testing.T) {
+	retry := &RetryPolicyConfig{MaxAttempts: 3, InitialBackoff: time.Millisecond * 100, MaxBackoff: time.Second, BackoffMultiplier: 2}
+	hedging := &HedgingPolicyConfig{MaxAttempts: 2, HedgingDelay: time.Millisecond * 50}
+
+	for _, valid := range []*PolicyConfig{{}, {Retry: retry}, {Hedging: hedging}} {
+		if err := valid.Validate(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	for name, invalid := range map[string]*PolicyConfig{
+		"both":             {Retry: retry, Hedging: hedging},
+		"retry attempts":   {Retry: &RetryPolicyConfig{MaxAttempts: 1, InitialBackoff: time.Second, MaxBackoff: time.Second, BackoffMultiplier: 1}},
+		"retry backoff":    {Retry: &RetryPolicyConfig{MaxAttempts: 2, MaxBackoff: time.Second, BackoffMultiplier: 1}},
+		"retry multiplier": {Retry: &RetryPolicyConfig{MaxAttempts: 2, InitialBackoff: time.Second, MaxBackoff: time.Second}},
+		"hedging attempts": {Hedging: &HedgingPolicyConfig{MaxAttempts: 1}},
+		"hedging delay":    {Hedging: &HedgingPolicyConfig{MaxAttempts: 2, HedgingDelay: -time.Second}},
+	} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+}
+
+func TestServiceConfig(t *testing.T) {
+	for name, tt := range map[string]struct {
+		cfg      *ConnectionConfig
+		expected string
+	}{
+		"default": {
+			cfg:      &ConnectionConfig{},
+			expected: `{"loadBalancingPolicy":"round_robin"}`,
+		},
+		"retry": {
+			cfg: &ConnectionConfig{
+				LoadBalancing: LoadBalancingPickFirst,
+				Policy: &PolicyConfig{Retry: &RetryPolicyConfig{
+					MaxAttempts:       3,
+					InitialBackoff:    time.Millisecond * 100,
+					MaxBackoff:        time.Second,
+					BackoffMultiplier: 1.5,
+				}},
+			},
+			expected: `{"loadBalancingPolicy":"pick_first","methodConfig":[{"name":[{}],"retryPolicy":{
+				"maxAttempts":3,"initialBackoff":"0.100000000s","maxBackoff":"1.000000000s",
+				"backoffMultiplier":1.5,"retryableStatusCodes":["UNAVAILABLE"]}}]}`,
+		},
+		"hedging": {
+			cfg: &ConnectionConfig{
+				Policy: &PolicyConfig{Hedging: &HedgingPolicyConfig{
+					MaxAttempts:   2,
+					HedgingDelay:  time.Millisecond * 50,
+					NonFatalCodes: []codes.Code{codes.Unavailable, codes.ResourceExhausted},
+				}},
+			},
+			expected: `{"loadBalancingPolicy":"round_robin","methodConfig":[{"name":[{}],"hedgingPolicy":{
+				"maxAttempts":2,"hedgingDelay":"0.050000000s","nonFatalStatusCodes":["UNAVAILABLE","RESOURCE_EXHAUSTED"]}}]}`,
+		},
+	} {
+		sc, err := tt.cfg.serviceConfig()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		var actual, expected any
+		_ = json.Unmarshal([]byte(sc), &actual)
+		if err = json.Unmarshal([]byte(tt.expected), &expected); err != nil {
+			t.Fatal(err)
+		}
+		actualJSON, _ := json.Marshal(actual)
+		expectedJSON, _ := json.Marshal(expected)
+		if string(actualJSON) != string(expectedJSON) {
+			t.Errorf("%s: expected %s, got %s", name, expectedJSON, actualJSON)
+		}
+
+		// the service config must be accepted by grpc
+		conn, err := grpc.NewClient("localhost:1",
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithDefaultServiceConfig(sc))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		_ = conn.Close()
+	}
+}
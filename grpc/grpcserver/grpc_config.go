@@ -1,16 +1,45 @@
 package infragrpcserver
 
-import "github.com/pkg/errors"
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	infratls "github.com/pushwoosh/infra/tls"
+)
 
 const GrpcCapacityUnlimited = 0
 const DefaultGrpcListenAddress = ":9091"
 const DefaultGrpcCapacity = GrpcCapacityUnlimited
+const DefaultGrpcShutdownTimeout = 30 * time.Second
 
 type GrpcConfig struct {
 	Listen            string         `mapstructure:"listen"`
 	Capacity          int            `mapstructure:"capacity"`
 	ReflectionEnabled bool           `mapstructure:"reflection_enabled"`
 	GrpcWeb           *GrpcWebConfig `mapstructure:"grpc_web"`
+
+	// fields below are used by Server only
+
+	HealthEnabled    bool                 `mapstructure:"health_enabled"`
+	LogRequests      bool                 `mapstructure:"log_requests"`
+	MaxRecvMsgSizeMB int                  `mapstructure:"max_recv_msg_size_mb"` // optional
+	MaxSendMsgSizeMB int                  `mapstructure:"max_send_msg_size_mb"` // optional
+	ShutdownTimeout  time.Duration        `mapstructure:"shutdown_timeout"`     // optional
+	Keepalive        *GrpcKeepaliveConfig `mapstructure:"keepalive"`            // optional
+
+	// TLS enables transport security, certificates are reloaded on change. optional
+	TLS *infratls.Config `mapstructure:"tls"`
+}
+
+// GrpcKeepaliveConfig is a server keepalive config. See keepalive.ServerParameters and keepalive.EnforcementPolicy for details.
+type GrpcKeepaliveConfig struct {
+	MaxConnectionIdle     time.Duration `mapstructure:"max_connection_idle"`
+	MaxConnectionAge      time.Duration `mapstructure:"max_connection_age"`
+	MaxConnectionAgeGrace time.Duration `mapstructure:"max_connection_age_grace"`
+	Time                  time.Duration `mapstructure:"time"`
+	Timeout               time.Duration `mapstructure:"timeout"`
+	MinTime               time.Duration `mapstructure:"min_time"`
+	PermitWithoutStream   bool          `mapstructure:"permit_without_stream"`
 }
 
 type GrpcWebConfig struct {
@@ -34,6 +63,8 @@ func DefaultGrpcConfig() *GrpcConfig {
 		Listen:            DefaultGrpcListenAddress,
 		Capacity:          DefaultGrpcCapacity,
 		ReflectionEnabled: true,
+		HealthEnabled:     true,
+		ShutdownTimeout:   DefaultGrpcShutdownTimeout,
 	}
 }
 
@@ -46,9 +77,23 @@ func (c *GrpcConfig) Validate() error {
 		return errors.New("listen address is mandatory")
 	}
 
+	if c.MaxRecvMsgSizeMB < 0 || c.MaxSendMsgSizeMB < 0 {
+		return errors.New("message size limits must be greater or equal to zero")
+	}
+
+	if c.ShutdownTimeout < 0 {
+		return errors.New("shutdown timeout must be greater or equal to zero")
+	}
+
 	if err := c.GrpcWeb.Validate(); err != nil {
 		return err
 	}
 
+	if c.TLS != nil {
+		if err := c.TLS.Validate(); err != nil {
+			return errors.Wrap(err, "tls")
+		}
+	}
+
 	return nil
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
@@ -16,8 +17,15 @@ import (
 	"google.golang.org/protobuf/encoding/protojson"
 )
 
+// GatewayStartupFunc registers handlers of the gateway mux forwarding to endpoint.
+//
+// Deprecated: use infragrpcgateway, see Gateway.
 type GatewayStartupFunc func(gw *Gateway, endpoint string, dialOptions []grpc.DialOption) http.Handler
 
+// Gateway is a grpc-gateway server forwarding REST requests to a gRPC server over network.
+//
+// Deprecated: use infragrpcgateway. It serves gRPC and REST with one lifecycle, passes REST calls to the gRPC server
+// in process with the original client as the peer and applies standard http middlewares.
 type Gateway struct {
 	cfg *GrpcGatewayConfig
 
@@ -31,6 +39,8 @@ type Gateway struct {
 }
 
 // NewGateway creates a new Grpc Gateway server
+//
+// Deprecated: use infragrpcgateway.New.
 func NewGateway(cfg *GrpcGatewayConfig, startupFunc GatewayStartupFunc, gwMuxOptions ...runtime.ServeMuxOption) *Gateway {
 	runningCtx, runningCtxCancel := context.WithCancel(context.Background())
 
@@ -69,8 +79,9 @@ func (s *Gateway) Context() context.Context {
 // Start starts listening Grpc Gateway server and blocks until it exit.
 func (s *Gateway) Start(_ context.Context) error {
 	s.srv = &http.Server{
-		Addr:    s.cfg.Listen,
-		Handler: s.mux,
+		Addr:     s.cfg.Listen,
+		Handler:  s.mux,
+		ErrorLog: infralog.NewStdLogger(zapcore.WarnLevel, "grpc-gateway"),
 	}
 
 	handler := s.startupFunc(s, s.cfg.ForwardTo, s.grpcDialOptions)
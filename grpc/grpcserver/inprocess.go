@@ -0,0 +1,142 @@
+package infragrpcserver
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"sync"
+
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// inProcessPeerKey is a metadata key with the id of the peer of an in-process call, see ContextWithPeer
+const inProcessPeerKey = "x-infra-in-process-peer"
+
+type inProcessPeerCtxKey struct{}
+
+// ContextWithPeer sets the peer of calls made over ClientConn with the context, e.g. the client of a REST request.
+// The server sees it in peer.FromContext instead of the in-process connection, so client IP rate limits
+// and mutual TLS checks of the gRPC server apply to the original client.
+func ContextWithPeer(ctx context.Context, p *peer.Peer) context.Context {
+	return context.WithValue(ctx, inProcessPeerCtxKey{}, p)
+}
+
+// inProcessPeers passes peers of in-process calls from the client side to the server side.
+// Calls carry only a random id in metadata, so a peer can't be forged by a client sending metadata.
+type inProcessPeers struct {
+	m sync.Map
+}
+
+func (p *inProcessPeers) store(ctx context.Context) (context.Context, func()) {
+	pr, ok := ctx.Value(inProcessPeerCtxKey{}).(*peer.Peer)
+	if !ok {
+		return ctx, func() {}
+	}
+
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	id := hex.EncodeToString(b[:])
+
+	p.m.Store(id, pr)
+	return metadata.AppendToOutgoingContext(ctx, inProcessPeerKey, id), func() { p.m.Delete(id) }
+}
+
+func (p *inProcessPeers) load(ctx context.Context) context.Context {
+	values := metadata.ValueFromIncomingContext(ctx, inProcessPeerKey)
+	if len(values) == 0 {
+		return ctx
+	}
+
+	pr, ok := p.m.LoadAndDelete(values[0])
+	if !ok {
+		return ctx
+	}
+	return peer.NewContext(ctx, pr.(*peer.Peer))
+}
+
+func (p *inProcessPeers) unaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, release := p.store(ctx)
+		defer release()
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func (p *inProcessPeers) streamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, release := p.store(ctx)
+		context.AfterFunc(ctx, release)
+
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
+
+func (p *inProcessPeers) unaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(p.load(ctx), req)
+	}
+}
+
+func (p *inProcessPeers) streamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		wrapped := grpc_middleware.WrapServerStream(ss)
+		wrapped.WrappedContext = p.load(ss.Context())
+		return handler(srv, wrapped)
+	}
+}
+
+// pipeListener is a listener of in-process connections
+type pipeListener struct {
+	conns     chan net.Conn
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newPipeListener() *pipeListener {
+	return &pipeListener{
+		conns: make(chan net.Conn),
+		done:  make(chan struct{}),
+	}
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *pipeListener) Close() error {
+	l.closeOnce.Do(func() { close(l.done) })
+	return nil
+}
+
+func (l *pipeListener) Addr() net.Addr {
+	return pipeAddr{}
+}
+
+func (l *pipeListener) DialContext(ctx context.Context) (net.Conn, error) {
+	server, client := net.Pipe()
+
+	select {
+	case l.conns <- server:
+		return client, nil
+	case <-l.done:
+		return nil, errors.New("in-process listener is closed")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+type pipeAddr struct{}
+
+func (pipeAddr) Network() string { return "pipe" }
+func (pipeAddr) String() string  { return "in-process" }
@@ -0,0 +1,18 @@
+package inframiddleware
+
+import (
+	infrarecovery "github.com/pushwoosh/infra/recovery"
+	"google.golang.org/grpc"
+)
+
+// UnaryServerRecoveryInterceptor returns a grpc server unary interceptor
+// that recovers handler panics, logs them, reports them to the error tracker and returns codes.Internal error.
+func UnaryServerRecoveryInterceptor() grpc.UnaryServerInterceptor {
+	return infrarecovery.UnaryServerInterceptor()
+}
+
+// StreamServerRecoveryInterceptor returns a grpc server stream interceptor
+// that recovers handler panics, logs them, reports them to the error tracker and returns codes.Internal error.
+func StreamServerRecoveryInterceptor() grpc.StreamServerInterceptor {
+	return infrarecovery.StreamServerInterceptor()
+}
@@ -0,0 +1,42 @@
+package inframiddleware
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// testServerStream is a server stream with a context only
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestRecoveryInterceptors(t *testing.T) {
+	unary := UnaryServerRecoveryInterceptor()
+	_, err := unary(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/test.Service/Unary"},
+		func(context.Context, interface{}) (interface{}, error) { panic("boom") })
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("expected internal error, got %v", err)
+	}
+
+	resp, err := unary(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/test.Service/Unary"},
+		func(context.Context, interface{}) (interface{}, error) { return "ok", nil })
+	if err != nil || resp != "ok" {
+		t.Fatalf("expected the handler response, got %v %v", resp, err)
+	}
+
+	stream := StreamServerRecoveryInterceptor()
+	err = stream(nil, testServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/test.Service/Stream"},
+		func(interface{}, grpc.ServerStream) error { panic("boom") })
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("expected internal error, got %v", err)
+	}
+}
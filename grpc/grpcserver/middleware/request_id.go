@@ -0,0 +1,18 @@
+package inframiddleware
+
+import (
+	infrarequestid "github.com/pushwoosh/infra/requestid"
+	"google.golang.org/grpc"
+)
+
+// UnaryServerRequestIDInterceptor returns a grpc server unary interceptor
+// that takes the request id from x-request-id metadata or generates a new one and stores it in the context.
+func UnaryServerRequestIDInterceptor() grpc.UnaryServerInterceptor {
+	return infrarequestid.UnaryServerInterceptor()
+}
+
+// StreamServerRequestIDInterceptor returns a grpc server stream interceptor
+// that takes the request id from x-request-id metadata or generates a new one and stores it in the context.
+func StreamServerRequestIDInterceptor() grpc.StreamServerInterceptor {
+	return infrarequestid.StreamServerInterceptor()
+}
@@ -0,0 +1,47 @@
+package inframiddleware
+
+import (
+	"context"
+	"time"
+
+	infralog "github.com/pushwoosh/infra/log"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerRequestLogInterceptor returns a grpc server unary interceptor
+// that logs every handled request with debug level.
+func UnaryServerRequestLogInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logRequest(ctx, info.FullMethod, "unary", start, err)
+		return resp, err
+	}
+}
+
+// StreamServerRequestLogInterceptor returns a grpc server stream interceptor
+// that logs every handled stream with debug level.
+func StreamServerRequestLogInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logRequest(ss.Context(), info.FullMethod, "stream", start, err)
+		return err
+	}
+}
+
+func logRequest(ctx context.Context, method, grpcType string, start time.Time, err error) {
+	fields := []zap.Field{
+		zap.String("grpc_method", method),
+		zap.String("grpc_type", grpcType),
+		zap.String("grpc_code", status.Code(err).String()),
+		zap.Duration("duration", time.Since(start)),
+	}
+	if err != nil {
+		fields = append(fields, zap.Error(err))
+	}
+
+	infralog.DebugCtx(ctx, "grpc request", fields...)
+}
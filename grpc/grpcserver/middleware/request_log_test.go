@@ -0,0 +1,69 @@
+package inframiddleware
+
+import (
+	"context"
+	"testing"
+
+	infralog "github.com/pushwoosh/infra/log"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// requests are collected from "grpc request" entries, handlers can't be unregistered
+var requests []*infralog.LogEntry
+
+func init() {
+	infralog.RegisterLogHandler(func(entry *infralog.LogEntry) {
+		if entry.Message == "grpc request" {
+			requests = append(requests, entry)
+		}
+	})
+}
+
+func requestFields(entry *infralog.LogEntry) map[string]string {
+	fields := map[string]string{}
+	for _, field := range entry.Fields {
+		if field.Type == zapcore.StringType {
+			fields[field.Key] = field.String
+		}
+	}
+	return fields
+}
+
+func TestRequestLogInterceptors(t *testing.T) {
+	requests = nil
+
+	unary := UnaryServerRequestLogInterceptor()
+	_, _ = unary(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/test.Service/Unary"},
+		func(context.Context, interface{}) (interface{}, error) { return "ok", nil })
+
+	stream := StreamServerRequestLogInterceptor()
+	err := stream(nil, testServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/test.Service/Stream"},
+		func(interface{}, grpc.ServerStream) error { return status.Error(codes.NotFound, "not found") })
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected the handler error, got %v", err)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(requests))
+	}
+	for i, expected := range []map[string]string{
+		{"grpc_method": "/test.Service/Unary", "grpc_type": "unary", "grpc_code": "OK"},
+		{"grpc_method": "/test.Service/Stream", "grpc_type": "stream", "grpc_code": "NotFound"},
+	} {
+		if requests[i].Level != zapcore.DebugLevel {
+			t.Errorf("expected debug level, got %s", requests[i].Level)
+		}
+		fields := requestFields(requests[i])
+		for k, v := range expected {
+			if fields[k] != v {
+				t.Errorf("expected %s=%s, got %s", k, v, fields[k])
+			}
+		}
+	}
+	if requests[1].Error() == nil {
+		t.Error("expected the error logged")
+	}
+}
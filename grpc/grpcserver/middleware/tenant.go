@@ -0,0 +1,18 @@
+package inframiddleware
+
+import (
+	infratenancy "github.com/pushwoosh/infra/tenancy"
+	"google.golang.org/grpc"
+)
+
+// UnaryServerTenantInterceptor returns a grpc server unary interceptor
+// that takes the tenant id from x-tenant-id metadata and stores it in the context.
+func UnaryServerTenantInterceptor() grpc.UnaryServerInterceptor {
+	return infratenancy.UnaryServerInterceptor()
+}
+
+// StreamServerTenantInterceptor returns a grpc server stream interceptor
+// that takes the tenant id from x-tenant-id metadata and stores it in the context.
+func StreamServerTenantInterceptor() grpc.StreamServerInterceptor {
+	return infratenancy.StreamServerInterceptor()
+}
@@ -0,0 +1,295 @@
+package infragrpcserver
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	grpc_auth "github.com/grpc-ecosystem/go-grpc-middleware/auth"
+	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
+	"github.com/pkg/errors"
+	inframiddleware "github.com/pushwoosh/infra/grpc/grpcserver/middleware"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/reflection"
+)
+
+// AuthFunc authenticates a request. Returned context is passed to the handler,
+// returned error is sent to the client, so it should be a grpc status error.
+type AuthFunc = grpc_auth.AuthFunc
+
+// Server is a gRPC server with the standard interceptor chain:
+// recovery, capacity limiter, metrics, request logging and authentication.
+// Tracing is done by OpenTelemetry stats handler.
+// Health and reflection services are registered according to config.
+type Server struct {
+	cfg    *GrpcConfig
+	name   string
+	listen ListenFunc
+
+	srv    *grpc.Server
+	health *health.Server
+
+	// inProcessSrv serves connections of ClientConn, it has the same services and interceptors,
+	// but no transport credentials, see ClientConn
+	inProcessSrv   *grpc.Server
+	inProcess      *pipeListener
+	inProcessPeers *inProcessPeers
+
+	mu      sync.Mutex
+	started bool
+	serveWg sync.WaitGroup
+}
+
+var (
+	_ infraoperator.Starter = (*Server)(nil)
+	_ infraoperator.Stopper = (*Server)(nil)
+)
+
+// NewServer creates a new gRPC server. Register services with Registrar before Start.
+func NewServer(cfg *GrpcConfig, opts ...ServerOption) *Server {
+	o := &serverOptions{name: "grpc", listenFunc: net.Listen}
+	for _, opt := range opts {
+		opt.apply(o)
+	}
+
+	unary := []grpc.UnaryServerInterceptor{
+		inframiddleware.UnaryServerRequestIDInterceptor(),
+		inframiddleware.UnaryServerTenantInterceptor(),
+		inframiddleware.UnaryServerRecoveryInterceptor(),
+		inframiddleware.UnaryServerCapacityLimiterInterceptor(o.name, cfg.Capacity),
+		grpc_prometheus.UnaryServerInterceptor,
+	}
+	stream := []grpc.StreamServerInterceptor{
+		inframiddleware.StreamServerRequestIDInterceptor(),
+		inframiddleware.StreamServerTenantInterceptor(),
+		inframiddleware.StreamServerRecoveryInterceptor(),
+		inframiddleware.StreamServerCapacityLimiterInterceptor(o.name, cfg.Capacity),
+		grpc_prometheus.StreamServerInterceptor,
+	}
+
+	if cfg.LogRequests {
+		unary = append(unary, inframiddleware.UnaryServerRequestLogInterceptor())
+		stream = append(stream, inframiddleware.StreamServerRequestLogInterceptor())
+	}
+
+	if o.authFunc != nil {
+		unary = append(unary, grpc_auth.UnaryServerInterceptor(o.authFunc))
+		stream = append(stream, grpc_auth.StreamServerInterceptor(o.authFunc))
+	}
+
+	unary = append(unary, o.unary...)
+	stream = append(stream, o.stream...)
+
+	srvOpts := []grpc.ServerOption{
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	}
+
+	if cfg.MaxRecvMsgSizeMB > 0 {
+		srvOpts = append(srvOpts, grpc.MaxRecvMsgSize(cfg.MaxRecvMsgSizeMB*1024*1024))
+	}
+	if cfg.MaxSendMsgSizeMB > 0 {
+		srvOpts = append(srvOpts, grpc.MaxSendMsgSize(cfg.MaxSendMsgSizeMB*1024*1024))
+	}
+
+	if cfg.Keepalive != nil {
+		srvOpts = append(srvOpts,
+			grpc.KeepaliveParams(keepalive.ServerParameters{
+				MaxConnectionIdle:     cfg.Keepalive.MaxConnectionIdle,
+				MaxConnectionAge:      cfg.Keepalive.MaxConnectionAge,
+				MaxConnectionAgeGrace: cfg.Keepalive.MaxConnectionAgeGrace,
+				Time:                  cfg.Keepalive.Time,
+				Timeout:               cfg.Keepalive.Timeout,
+			}),
+			grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+				MinTime:             cfg.Keepalive.MinTime,
+				PermitWithoutStream: cfg.Keepalive.PermitWithoutStream,
+			}),
+		)
+	}
+
+	peers := &inProcessPeers{}
+	inProcessOpts := append([]grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(peers.unaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(peers.streamServerInterceptor()),
+	}, srvOpts...)
+
+	if cfg.TLS != nil {
+		srvOpts = append(srvOpts, grpc.Creds(credentials.NewTLS(cfg.TLS.Loader().ServerConfig())))
+	}
+
+	srvOpts = append(srvOpts, o.srvOpts...)
+	inProcessOpts = append(inProcessOpts, o.srvOpts...)
+
+	s := &Server{
+		cfg:            cfg,
+		name:           o.name,
+		listen:         o.listenFunc,
+		srv:            grpc.NewServer(srvOpts...),
+		inProcessSrv:   grpc.NewServer(inProcessOpts...),
+		inProcessPeers: peers,
+	}
+
+	if cfg.HealthEnabled {
+		s.health = health.NewServer()
+		healthpb.RegisterHealthServer(s.Registrar(), s.health)
+	}
+
+	if cfg.ReflectionEnabled {
+		reflection.Register(s.srv)
+	}
+
+	return s
+}
+
+// Registrar returns service registrar. All services must be registered before Start.
+func (s *Server) Registrar() grpc.ServiceRegistrar {
+	return registrar{s.srv, s.inProcessSrv}
+}
+
+// registrar registers services on the network and the in-process servers
+type registrar []*grpc.Server
+
+func (r registrar) RegisterService(desc *grpc.ServiceDesc, impl interface{}) {
+	for _, srv := range r {
+		srv.RegisterService(desc, impl)
+	}
+}
+
+// SetServingStatus sets health status of a service. Empty service name means the whole server.
+// Does nothing if health service is disabled.
+func (s *Server) SetServingStatus(service string, serving bool) {
+	if s.health == nil {
+		return
+	}
+
+	st := healthpb.HealthCheckResponse_NOT_SERVING
+	if serving {
+		st = healthpb.HealthCheckResponse_SERVING
+	}
+	s.health.SetServingStatus(service, st)
+}
+
+// Start starts serving in background
+func (s *Server) Start(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return errors.New("server is already started")
+	}
+
+	if s.cfg.TLS != nil {
+		if err := s.cfg.TLS.Loader().Reload(); err != nil {
+			return errors.Wrap(err, "tls")
+		}
+	}
+
+	listener, err := s.listen("tcp", s.cfg.Listen)
+	if err != nil {
+		return errors.Wrap(err, "net.Listen")
+	}
+
+	grpc_prometheus.Register(s.srv)
+	s.started = true
+
+	infralog.Debug("serving gRPC", zap.String("server", s.name), zap.String("address", s.cfg.Listen))
+	s.serve(s.srv, listener)
+	if s.inProcess != nil {
+		s.serve(s.inProcessSrv, s.inProcess)
+	}
+
+	return nil
+}
+
+func (s *Server) serve(srv *grpc.Server, listener net.Listener) {
+	s.serveWg.Add(1)
+	go func() {
+		defer s.serveWg.Done()
+
+		if err := srv.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			infralog.Fatal("grpc server error", zap.String("server", s.name), zap.Error(err))
+		}
+	}()
+}
+
+// ClientConn returns a connection to the server that doesn't use network, e.g. for a REST gateway
+// in the same process. Calls go through the server interceptor chain, they succeed after Start.
+// The connection is served without transport credentials, use ContextWithPeer to pass the original client
+// to the server. Options of WithServerOptions apply to in-process connections too, so they must not set credentials.
+// The caller is responsible for closing the connection.
+func (s *Server) ClientConn(opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	s.mu.Lock()
+	if s.inProcess == nil {
+		s.inProcess = newPipeListener()
+		if s.started {
+			s.serve(s.inProcessSrv, s.inProcess)
+		}
+	}
+	listener := s.inProcess
+	s.mu.Unlock()
+
+	opts = append([]grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(s.inProcessPeers.unaryClientInterceptor()),
+		grpc.WithChainStreamInterceptor(s.inProcessPeers.streamClientInterceptor()),
+	}, opts...)
+
+	conn, err := grpc.NewClient("passthrough:///"+s.name, opts...)
+	return conn, errors.Wrap(err, "grpc.NewClient")
+}
+
+// Stop marks the server as not serving and stops it gracefully.
+// Active RPCs are canceled when shutdown timeout or ctx expires.
+func (s *Server) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.started {
+		return nil
+	}
+
+	if s.health != nil {
+		s.health.Shutdown()
+	}
+
+	if s.cfg.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.ShutdownTimeout)
+		defer cancel()
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		s.srv.GracefulStop()
+		s.inProcessSrv.GracefulStop()
+		close(stopped)
+	}()
+
+	var err error
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.srv.Stop()
+		s.inProcessSrv.Stop()
+		err = ctx.Err()
+	}
+
+	s.serveWg.Wait()
+	s.started = false
+
+	return err
+}
@@ -0,0 +1,91 @@
+package infragrpcserver
+
+import (
+	"net"
+
+	"google.golang.org/grpc"
+)
+
+type serverOptions struct {
+	name       string
+	authFunc   AuthFunc
+	unary      []grpc.UnaryServerInterceptor
+	stream     []grpc.StreamServerInterceptor
+	srvOpts    []grpc.ServerOption
+	listenFunc ListenFunc
+}
+
+type ServerOption interface {
+	apply(o *serverOptions)
+}
+
+type optionWithName string
+
+func (o optionWithName) apply(opts *serverOptions) {
+	opts.name = string(o)
+}
+
+// WithName sets server name that is used in logs and metrics labels. Default is "grpc".
+func WithName(name string) ServerOption {
+	return optionWithName(name)
+}
+
+type optionWithAuth AuthFunc
+
+func (o optionWithAuth) apply(opts *serverOptions) {
+	opts.authFunc = AuthFunc(o)
+}
+
+// WithAuth enables authentication of every request with fn.
+// Services may implement grpc_auth.ServiceAuthFuncOverride to override it.
+func WithAuth(fn AuthFunc) ServerOption {
+	return optionWithAuth(fn)
+}
+
+type optionWithUnaryInterceptors []grpc.UnaryServerInterceptor
+
+func (o optionWithUnaryInterceptors) apply(opts *serverOptions) {
+	opts.unary = append(opts.unary, o...)
+}
+
+// WithUnaryInterceptors adds unary interceptors after the standard chain
+func WithUnaryInterceptors(interceptors ...grpc.UnaryServerInterceptor) ServerOption {
+	return optionWithUnaryInterceptors(interceptors)
+}
+
+type optionWithStreamInterceptors []grpc.StreamServerInterceptor
+
+func (o optionWithStreamInterceptors) apply(opts *serverOptions) {
+	opts.stream = append(opts.stream, o...)
+}
+
+// WithStreamInterceptors adds stream interceptors after the standard chain
+func WithStreamInterceptors(interceptors ...grpc.StreamServerInterceptor) ServerOption {
+	return optionWithStreamInterceptors(interceptors)
+}
+
+type optionWithServerOptions []grpc.ServerOption
+
+func (o optionWithServerOptions) apply(opts *serverOptions) {
+	opts.srvOpts = append(opts.srvOpts, o...)
+}
+
+// WithServerOptions adds raw grpc server options, e.g. credentials
+func WithServerOptions(srvOpts ...grpc.ServerOption) ServerOption {
+	return optionWithServerOptions(srvOpts)
+}
+
+// ListenFunc opens a listener, net.Listen by default
+type ListenFunc func(network, address string) (net.Listener, error)
+
+type optionWithListenFunc ListenFunc
+
+func (o optionWithListenFunc) apply(opts *serverOptions) {
+	opts.listenFunc = ListenFunc(o)
+}
+
+// WithListenFunc sets a function opening the listen address, e.g. infrahandoff.Handoff.Listen
+// to inherit the listener of the previous process on restart
+func WithListenFunc(fn ListenFunc) ServerOption {
+	return optionWithListenFunc(fn)
+}
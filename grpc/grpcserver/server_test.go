@@ -0,0 +1,131 @@
+package infragrpcserver
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func TestGrpcConfig(t *testing.T) {
+	if err := DefaultGrpcConfig().Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, invalid := range map[string]*GrpcConfig{
+		"capacity":         {Listen: ":9091", Capacity: -1},
+		"listen":           {},
+		"message size":     {Listen: ":9091", MaxRecvMsgSizeMB: -1},
+		"shutdown timeout": {Listen: ":9091", ShutdownTimeout: -time.Second},
+		"grpc web":         {Listen: ":9091", GrpcWeb: &GrpcWebConfig{}},
+	} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+}
+
+func TestServer(t *testing.T) {
+	var address string
+	listenFunc := func(network, addr string) (net.Listener, error) {
+		l, err := net.Listen(network, addr)
+		if err == nil {
+			address = l.Addr().String()
+		}
+		return l, err
+	}
+
+	cfg := DefaultGrpcConfig()
+	cfg.Listen = "127.0.0.1:0"
+	cfg.LogRequests = true
+	srv := NewServer(cfg, WithName("test_grpc"), WithListenFunc(listenFunc))
+
+	ctx := context.Background()
+	if err := srv.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.Start(ctx); err == nil {
+		t.Fatal("expected error on the second start")
+	}
+
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	check := func() healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.GetStatus()
+	}
+
+	if st := check(); st != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("expected serving, got %s", st)
+	}
+	srv.SetServingStatus("", false)
+	if st := check(); st != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("expected not serving, got %s", st)
+	}
+
+	if err = srv.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err = srv.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.Check(ctx, &healthpb.HealthCheckRequest{}); err == nil {
+		t.Fatal("expected the server stopped")
+	}
+}
+
+func TestServerInterceptors(t *testing.T) {
+	panicking := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if info.FullMethod == healthpb.Health_Check_FullMethodName {
+			panic("boom")
+		}
+		return handler(ctx, req)
+	}
+	auth := func(ctx context.Context) (context.Context, error) {
+		return nil, status.Error(codes.Unauthenticated, "no token")
+	}
+
+	for _, tt := range []struct {
+		opt      ServerOption
+		expected codes.Code
+	}{
+		{opt: WithUnaryInterceptors(panicking), expected: codes.Internal},
+		{opt: WithAuth(auth), expected: codes.Unauthenticated},
+	} {
+		cfg := DefaultGrpcConfig()
+		cfg.Listen = "127.0.0.1:0"
+		srv := NewServer(cfg, tt.opt)
+
+		conn, err := srv.ClientConn()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = srv.Start(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+		if status.Code(err) != tt.expected {
+			t.Errorf("expected %s, got %v", tt.expected, err)
+		}
+
+		_ = conn.Close()
+		if err = srv.Stop(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
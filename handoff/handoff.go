@@ -0,0 +1,328 @@
+package infrahandoff
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	infraapp "github.com/pushwoosh/infra/app"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	"go.uber.org/zap"
+)
+
+// envState passes inherited file descriptors to the new process
+const envState = "INFRA_HANDOFF"
+
+const defaultReadyTimeout = time.Minute
+
+// ErrUpgraded is returned by Upgrade if the process has handed its listeners over already
+var ErrUpgraded = errors.New("listeners are handed over already")
+
+// state is passed to the new process: file descriptors of listeners by "network:address" and of the ready pipe
+type state struct {
+	Listeners map[string]uintptr `json:"listeners"`
+	Ready     uintptr            `json:"ready"`
+}
+
+// Handoff restarts the process without dropping connections on bare VMs. The new process is started
+// with listening sockets of the current one, when it's ready the current process stops gracefully,
+// finishing requests in progress while the new one accepts new connections:
+//
+//	h, err := infrahandoff.New()
+//	httpServer := infrahttp.NewServer(cfg.HTTP, mux, infrahttp.WithListenFunc(h.Listen))
+//	grpcServer := infragrpcserver.NewServer(cfg.GRPC, infragrpcserver.WithListenFunc(h.Listen))
+//	app.Add("http", httpServer)
+//	app.Add("grpc", grpcServer)
+//	app.Add("handoff", h) // the last one, so the parent is notified when everything is started
+//	err = app.Run(ctx)
+//
+// On SIGHUP the binary is executed again with the same arguments. Listeners are inherited by address,
+// the ones not requested by the new process are closed. If the new process fails to start, the current
+// one keeps serving.
+type Handoff struct {
+	signal       os.Signal
+	readyTimeout time.Duration
+
+	mu        sync.Mutex
+	inherited map[string]*os.File
+	listeners map[string]net.Listener
+	ready     *os.File
+	upgraded  bool
+}
+
+var (
+	_ infraoperator.Starter = (*Handoff)(nil)
+	_ infraapp.Runner       = (*Handoff)(nil)
+)
+
+// New creates a handoff and takes listeners inherited from the parent process if any
+func New(opts ...Option) (*Handoff, error) {
+	initMetrics()
+
+	h := &Handoff{
+		signal:       syscall.SIGHUP,
+		readyTimeout: defaultReadyTimeout,
+		inherited:    make(map[string]*os.File),
+		listeners:    make(map[string]net.Listener),
+	}
+
+	for _, opt := range opts {
+		opt.apply(h)
+	}
+
+	raw := os.Getenv(envState)
+	if raw == "" {
+		return h, nil
+	}
+	// children of this process must not take the descriptors
+	_ = os.Unsetenv(envState)
+
+	st := &state{}
+	if err := json.Unmarshal([]byte(raw), st); err != nil {
+		return nil, errors.Wrap(err, "invalid handoff state")
+	}
+
+	for key, fd := range st.Listeners {
+		h.inherited[key] = os.NewFile(fd, key)
+	}
+	h.ready = os.NewFile(st.Ready, "handoff-ready")
+
+	metrics.InheritedListeners.Set(float64(len(h.inherited)))
+	infralog.Info("listeners inherited from the parent process", zap.Int("listeners", len(h.inherited)))
+
+	return h, nil
+}
+
+// HasParent reports if the process is started by Upgrade of another one
+func (h *Handoff) HasParent() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.ready != nil
+}
+
+// Listen returns a listener inherited from the parent process for the address or opens a new one.
+// It's compatible with net.Listen.
+func (h *Handoff) Listen(network, address string) (net.Listener, error) {
+	key := network + ":" + address
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if f, ok := h.inherited[key]; ok {
+		delete(h.inherited, key)
+
+		listener, err := net.FileListener(f)
+		_ = f.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to inherit listener %s", key)
+		}
+
+		h.listeners[key] = listener
+		return listener, nil
+	}
+
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	h.listeners[key] = listener
+	return listener, nil
+}
+
+// Start notifies the parent process that this one is ready, so the parent stops.
+// Inherited listeners not requested by Listen are closed.
+func (h *Handoff) Start(_ context.Context) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for key, f := range h.inherited {
+		_ = f.Close()
+		delete(h.inherited, key)
+	}
+
+	if h.ready == nil {
+		return nil
+	}
+
+	_, err := h.ready.Write([]byte{1})
+	_ = h.ready.Close()
+	h.ready = nil
+	if err != nil {
+		return errors.Wrap(err, "unable to notify the parent process")
+	}
+
+	return nil
+}
+
+// Run restarts the process on the signal. After a successful restart it returns infraapp.ErrStop,
+// so the application stops gracefully.
+func (h *Handoff) Run(ctx context.Context) error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, h.signal)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-signals:
+		}
+
+		infralog.Info("restarting with listener handoff")
+		if err := h.Upgrade(ctx); err != nil {
+			infralog.Error("unable to restart with listener handoff", zap.Error(err))
+			continue
+		}
+
+		return infraapp.ErrStop
+	}
+}
+
+// Upgrade starts the new process with listeners of this one and waits until it's ready.
+// The caller is responsible for stopping the current process after that.
+func (h *Handoff) Upgrade(ctx context.Context) (err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.upgraded {
+		return ErrUpgraded
+	}
+
+	defer func() {
+		result := "success"
+		if err != nil {
+			result = "failure"
+		}
+		metrics.UpgradesCounter.WithLabelValues(result).Inc()
+	}()
+
+	executable, err := os.Executable()
+	if err != nil {
+		return errors.Wrap(err, "unable to find executable")
+	}
+
+	keys := make([]string, 0, len(h.listeners))
+	for key := range h.listeners {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	// descriptors of ExtraFiles are 3, 4, ... in the new process
+	st := &state{Listeners: make(map[string]uintptr, len(keys))}
+	files := make([]*os.File, 0, len(keys)+1)
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+
+	for _, key := range keys {
+		f, err := listenerFile(h.listeners[key])
+		if err != nil {
+			return errors.Wrapf(err, "unable to get file of listener %s", key)
+		}
+		st.Listeners[key] = uintptr(3 + len(files))
+		files = append(files, f)
+	}
+
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return errors.Wrap(err, "unable to create ready pipe")
+	}
+	defer func() {
+		_ = readyR.Close()
+	}()
+	st.Ready = uintptr(3 + len(files))
+	files = append(files, readyW)
+
+	raw, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = append(environ(), envState+"="+string(raw))
+
+	if err = cmd.Start(); err != nil {
+		return errors.Wrap(err, "unable to start the new process")
+	}
+	// only the new process must hold the write end, so its exit closes the pipe
+	_ = readyW.Close()
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+
+	ready := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 1)
+		if _, err := io.ReadFull(readyR, buf); err != nil {
+			ready <- errors.New("the new process exited before it was ready")
+			return
+		}
+		ready <- nil
+	}()
+
+	timer := time.NewTimer(h.readyTimeout)
+	defer timer.Stop()
+
+	select {
+	case err = <-ready:
+	case err = <-exited:
+		err = errors.Wrap(err, "the new process exited before it was ready")
+	case <-timer.C:
+		err = errors.New("the new process is not ready in time")
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	if err != nil {
+		_ = cmd.Process.Kill()
+		return err
+	}
+
+	// closing listeners by the stopping servers must not remove unix sockets of the new process
+	for _, listener := range h.listeners {
+		if l, ok := listener.(*net.UnixListener); ok {
+			l.SetUnlinkOnClose(false)
+		}
+	}
+
+	h.upgraded = true
+	infralog.Info("listeners are handed over to the new process", zap.Int("pid", cmd.Process.Pid))
+
+	return nil
+}
+
+func listenerFile(listener net.Listener) (*os.File, error) {
+	l, ok := listener.(interface{ File() (*os.File, error) })
+	if !ok {
+		return nil, errors.Errorf("unsupported listener type %T", listener)
+	}
+	return l.File()
+}
+
+func environ() []string {
+	env := os.Environ()
+	return slices.DeleteFunc(env, func(v string) bool {
+		return strings.HasPrefix(v, envState+"=")
+	})
+}
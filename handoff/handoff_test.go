@@ -0,0 +1,75 @@
+//go:build unix
+
+package infrahandoff
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestInheritedListener(t *testing.T) {
+	parent, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer parent.Close()
+
+	f, err := parent.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer readyR.Close()
+
+	// descriptors are owned by the handoff, the test closes its copies
+	listenerFd, _ := syscall.Dup(int(f.Fd()))
+	readyFd, _ := syscall.Dup(int(readyW.Fd()))
+	_ = f.Close()
+	_ = readyW.Close()
+
+	address := parent.Addr().String()
+	raw, _ := json.Marshal(&state{
+		Listeners: map[string]uintptr{"tcp:" + address: uintptr(listenerFd)},
+		Ready:     uintptr(readyFd),
+	})
+	t.Setenv(envState, string(raw))
+
+	h, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !h.HasParent() {
+		t.Fatal("handoff must have a parent")
+	}
+
+	listener, err := h.Listen("tcp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	if listener.Addr().String() != address {
+		t.Fatalf("expected inherited listener on %s, got %s", address, listener.Addr())
+	}
+
+	if err = h.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if h.HasParent() {
+		t.Fatal("parent must be notified once")
+	}
+
+	buf := make([]byte, 1)
+	if _, err = io.ReadFull(readyR, buf); err != nil {
+		t.Fatalf("parent is not notified: %s", err)
+	}
+}
@@ -0,0 +1,33 @@
+package infrahandoff
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	UpgradesCounter    *prometheus.CounterVec
+	InheritedListeners prometheus.Gauge
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.UpgradesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "handoff_upgrades_total",
+			Help: "Number of restarts with listener handoff by result: success or failure",
+		}, []string{"result"})
+
+		metrics.InheritedListeners = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "handoff_inherited_listeners",
+			Help: "Number of listeners inherited from the previous process",
+		})
+
+		prometheus.MustRegister(
+			metrics.UpgradesCounter,
+			metrics.InheritedListeners,
+		)
+	})
+}
@@ -0,0 +1,34 @@
+package infrahandoff
+
+import (
+	"os"
+	"time"
+)
+
+type Option interface {
+	apply(h *Handoff)
+}
+
+type optionSignal struct {
+	signal os.Signal
+}
+
+func (opt optionSignal) apply(h *Handoff) {
+	h.signal = opt.signal
+}
+
+// WithSignal sets a signal starting the restart in Run. Default is SIGHUP
+func WithSignal(signal os.Signal) Option {
+	return optionSignal{signal: signal}
+}
+
+type optionReadyTimeout time.Duration
+
+func (opt optionReadyTimeout) apply(h *Handoff) {
+	h.readyTimeout = time.Duration(opt)
+}
+
+// WithReadyTimeout sets how long to wait for the new process to become ready. Default is 1m
+func WithReadyTimeout(timeout time.Duration) Option {
+	return optionReadyTimeout(timeout)
+}
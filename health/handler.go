@@ -0,0 +1,42 @@
+package infrahealth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	infralog "github.com/pushwoosh/infra/log"
+	"go.uber.org/zap"
+)
+
+// LivenessHandler returns /healthz handler
+func (r *Registry) LivenessHandler() http.Handler {
+	return reportHandler(r.Liveness)
+}
+
+// ReadinessHandler returns /readyz handler.
+// Responds with 503 when any critical check fails, details are written as JSON.
+func (r *Registry) ReadinessHandler() http.Handler {
+	return reportHandler(r.Readiness)
+}
+
+func reportHandler(run func(ctx context.Context) *Report) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		report := run(req.Context())
+
+		status := http.StatusOK
+		if report.Status == StatusFail {
+			status = http.StatusServiceUnavailable
+
+			for _, name := range report.names() {
+				if result := report.Checks[name]; result.Status == StatusFail {
+					infralog.Error("Health check error", zap.String("check", name), zap.String("error", result.Error))
+				}
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
@@ -0,0 +1,45 @@
+package infrahealth
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	CheckStatusGauge       *prometheus.GaugeVec
+	CheckDurationHistogram *prometheus.HistogramVec
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.CheckStatusGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "health_check_status",
+			Help: "Result of the last health check run: 1 if healthy, 0 otherwise",
+		}, []string{"check"})
+
+		metrics.CheckDurationHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "health_check_duration_seconds",
+			Help:    "Duration of health checks",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"check"})
+
+		prometheus.MustRegister(
+			metrics.CheckStatusGauge,
+			metrics.CheckDurationHistogram,
+		)
+	})
+}
+
+func observe(name string, result *CheckResult) {
+	initMetrics()
+
+	value := 0.0
+	if result.Status == StatusOK {
+		value = 1
+	}
+	metrics.CheckStatusGauge.WithLabelValues(name).Set(value)
+	metrics.CheckDurationHistogram.WithLabelValues(name).Observe(result.Duration.Seconds())
+}
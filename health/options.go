@@ -0,0 +1,41 @@
+package infrahealth
+
+import "time"
+
+type CheckOption interface {
+	apply(c *check)
+}
+
+type optionWithTimeout time.Duration
+
+func (o optionWithTimeout) apply(c *check) {
+	c.timeout = time.Duration(o)
+}
+
+// WithTimeout sets check timeout. Default is DefaultCheckTimeout.
+func WithTimeout(timeout time.Duration) CheckOption {
+	return optionWithTimeout(timeout)
+}
+
+type optionNonCritical struct{}
+
+func (o optionNonCritical) apply(c *check) {
+	c.critical = false
+}
+
+// NonCritical makes check failure report service as degraded instead of not ready
+func NonCritical() CheckOption {
+	return optionNonCritical{}
+}
+
+type optionLiveness struct{}
+
+func (o optionLiveness) apply(c *check) {
+	c.liveness = true
+}
+
+// Liveness includes check into liveness probe.
+// Use it only for checks of the process itself, e.g. deadlock detection.
+func Liveness() CheckOption {
+	return optionLiveness{}
+}
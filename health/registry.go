@@ -0,0 +1,223 @@
+package infrahealth
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	infraoperator "github.com/pushwoosh/infra/operator"
+)
+
+const DefaultCheckTimeout = 5 * time.Second
+
+// CheckFunc returns nil if the checked subsystem is healthy
+type CheckFunc func(ctx context.Context) error
+
+// Registry holds named health checks.
+// Readiness runs all checks, only critical failures make the service not ready.
+// Liveness runs only checks registered with Liveness option:
+// dependencies must not fail liveness, otherwise k8s restarts pods because of a broken database.
+type Registry struct {
+	mu     sync.RWMutex
+	checks map[string]*check
+
+	notReady atomic.Bool
+}
+
+type check struct {
+	name     string
+	fn       CheckFunc
+	timeout  time.Duration
+	critical bool
+	liveness bool
+}
+
+var _ infraoperator.Checker = (*Registry)(nil)
+
+func NewRegistry() *Registry {
+	return &Registry{
+		checks: make(map[string]*check),
+	}
+}
+
+// Register adds a named check. Check with the same name is replaced.
+func (r *Registry) Register(name string, fn CheckFunc, opts ...CheckOption) {
+	c := &check{
+		name:     name,
+		fn:       fn,
+		timeout:  DefaultCheckTimeout,
+		critical: true,
+	}
+	for _, opt := range opts {
+		opt.apply(c)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checks[name] = c
+}
+
+// RegisterChecker adds a named check of a container or a service implementing infraoperator.Checker
+func (r *Registry) RegisterChecker(name string, checker infraoperator.Checker, opts ...CheckOption) {
+	r.Register(name, checker.Check, opts...)
+}
+
+// Unregister removes a named check
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.checks, name)
+}
+
+// SetReady overrides readiness. Service is reported as not ready while it's false regardless of checks.
+// Usually it's set to false at the beginning of a graceful shutdown.
+func (r *Registry) SetReady(ready bool) {
+	r.notReady.Store(!ready)
+}
+
+// Readiness runs all checks concurrently
+func (r *Registry) Readiness(ctx context.Context) *Report {
+	report := r.run(ctx, func(c *check) bool { return true })
+	if r.notReady.Load() {
+		report.Status = StatusFail
+	}
+	return report
+}
+
+// Liveness runs liveness checks concurrently
+func (r *Registry) Liveness(ctx context.Context) *Report {
+	return r.run(ctx, func(c *check) bool { return c.liveness })
+}
+
+// Check implements infraoperator.Checker. Returns error if any critical check fails.
+func (r *Registry) Check(ctx context.Context) error {
+	report := r.Readiness(ctx)
+	if report.Status != StatusFail {
+		return nil
+	}
+
+	for _, name := range report.names() {
+		result := report.Checks[name]
+		if result.Status == StatusFail && result.Critical {
+			return errors.Errorf("%s: %s", name, result.Error)
+		}
+	}
+
+	return errors.New("service is not ready")
+}
+
+func (r *Registry) run(ctx context.Context, filter func(c *check) bool) *Report {
+	r.mu.RLock()
+	checks := make([]*check, 0, len(r.checks))
+	for _, c := range r.checks {
+		if filter(c) {
+			checks = append(checks, c)
+		}
+	}
+	r.mu.RUnlock()
+
+	results := make([]*CheckResult, len(checks))
+	wg := sync.WaitGroup{}
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c *check) {
+			defer wg.Done()
+			results[i] = c.run(ctx)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := &Report{
+		Status: StatusOK,
+		Checks: make(map[string]*CheckResult, len(checks)),
+	}
+	for i, c := range checks {
+		result := results[i]
+		report.Checks[c.name] = result
+
+		if result.Status == StatusOK {
+			continue
+		}
+		if result.Critical {
+			report.Status = StatusFail
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+
+	return report
+}
+
+func (c *check) run(ctx context.Context) *CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+
+	// check may ignore context, so it's run in a separate goroutine to respect the timeout
+	errCh := make(chan error, 1)
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				errCh <- errors.Errorf("check panic: %v", rec)
+			}
+		}()
+		errCh <- c.fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = errors.Wrap(ctx.Err(), "check timed out")
+	}
+
+	result := &CheckResult{
+		Status:   StatusOK,
+		Critical: c.critical,
+		Duration: time.Since(start),
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+
+	observe(c.name, result)
+
+	return result
+}
+
+// Report is a result of running checks
+type Report struct {
+	Status Status                  `json:"status"`
+	Checks map[string]*CheckResult `json:"checks,omitempty"`
+}
+
+type CheckResult struct {
+	Status   Status        `json:"status"`
+	Critical bool          `json:"critical"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+func (r *Report) names() []string {
+	names := make([]string, 0, len(r.Checks))
+	for name := range r.Checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type Status string
+
+const (
+	StatusOK       Status = "ok"
+	StatusDegraded Status = "degraded"
+	StatusFail     Status = "fail"
+)
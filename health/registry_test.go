@@ -0,0 +1,50 @@
+package infrahealth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRegistry_Readiness(t *testing.T) {
+	r := NewRegistry()
+	r.Register("ok", func(ctx context.Context) error { return nil })
+	r.Register("optional", func(ctx context.Context) error { return errors.New("down") }, NonCritical())
+
+	if report := r.Readiness(context.Background()); report.Status != StatusDegraded {
+		t.Errorf("expected degraded status, got %s", report.Status)
+	}
+
+	r.Register("slow", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}, WithTimeout(10*time.Millisecond))
+
+	report := r.Readiness(context.Background())
+	if report.Status != StatusFail {
+		t.Errorf("expected fail status, got %s", report.Status)
+	}
+	if report.Checks["slow"].Status != StatusFail {
+		t.Error("expected slow check to time out")
+	}
+
+	if err := r.Check(context.Background()); err == nil {
+		t.Error("expected check error")
+	}
+}
+
+func TestRegistry_Liveness(t *testing.T) {
+	r := NewRegistry()
+	r.Register("database", func(ctx context.Context) error { return errors.New("down") })
+
+	if report := r.Liveness(context.Background()); report.Status != StatusOK {
+		t.Errorf("expected liveness to ignore dependencies, got %s", report.Status)
+	}
+
+	r.SetReady(false)
+	r.Unregister("database")
+	if report := r.Readiness(context.Background()); report.Status != StatusFail {
+		t.Errorf("expected not ready status, got %s", report.Status)
+	}
+}
@@ -0,0 +1,108 @@
+package infrahttpaccesslog
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	infrahttp "github.com/pushwoosh/infra/http"
+	infralog "github.com/pushwoosh/infra/log"
+	infrarequestid "github.com/pushwoosh/infra/requestid"
+	infratenancy "github.com/pushwoosh/infra/tenancy"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Record is an access log record of a handled request
+type Record struct {
+	Time       time.Time     `ch:"ts"`
+	Method     string        `ch:"method,LowCardinality(String)"`
+	Route      string        `ch:"route,LowCardinality(String)"`
+	Path       string        `ch:"path"`
+	Status     uint16        `ch:"status"`
+	Bytes      int64         `ch:"bytes"`
+	Latency    time.Duration `ch:"latency_ns"`
+	RemoteAddr string        `ch:"remote_addr"`
+	UserAgent  string        `ch:"user_agent"`
+	RequestID  string        `ch:"request_id"`
+	Tenant     string        `ch:"tenant,LowCardinality(String)"`
+	TraceID    string        `ch:"trace_id"`
+}
+
+// Middleware writes a record of every sampled request to the sink:
+//
+//	srv := infrahttp.NewServer(cfg.HTTP, mux, infrahttp.WithMiddlewares(
+//		infrahttpaccesslog.Middleware(cfg.AccessLog, infrahttpaccesslog.LogSink{}),
+//	))
+//
+// The route is the pattern of http.ServeMux that matched the request, or the path if there is none.
+// Sink errors are logged with warn level and don't affect the response, records dropped by a full sink are counted.
+func Middleware(cfg *Config, sink Sink) infrahttp.Middleware {
+	initMetrics()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := infrahttp.WrapResponseWriter(w)
+
+			next.ServeHTTP(rw, r)
+
+			latency := time.Since(start)
+			route := r.Pattern
+			if route == "" {
+				route = r.URL.Path
+			}
+
+			if !sampled(cfg, route, rw.Status(), latency) {
+				metrics.RecordsCounter.WithLabelValues("sampled_out").Inc()
+				return
+			}
+
+			// the record outlives the request, e.g. in the buffer of ClickHouseSink
+			ctx := context.WithoutCancel(r.Context())
+			rec := &Record{
+				Time:       start,
+				Method:     r.Method,
+				Route:      route,
+				Path:       r.URL.Path,
+				Status:     uint16(rw.Status()),
+				Bytes:      int64(rw.Bytes()),
+				Latency:    latency,
+				RemoteAddr: r.RemoteAddr,
+				UserAgent:  r.UserAgent(),
+				RequestID:  infrarequestid.FromContext(ctx),
+				Tenant:     infratenancy.FromContext(ctx),
+			}
+			if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+				rec.TraceID = sc.TraceID().String()
+			}
+
+			err := sink.Write(ctx, rec)
+			if errors.Is(err, ErrDropped) {
+				metrics.RecordsCounter.WithLabelValues("dropped").Inc()
+				return
+			}
+			if err != nil {
+				metrics.RecordsCounter.WithLabelValues("failed").Inc()
+				infralog.WarnCtx(ctx, "http access log: write record", zap.Error(err))
+				return
+			}
+			metrics.RecordsCounter.WithLabelValues("written").Inc()
+		})
+	}
+}
+
+// sampled decides if a request is logged. Errors and slow requests are always logged
+func sampled(cfg *Config, route string, status int, latency time.Duration) bool {
+	if status >= http.StatusInternalServerError && !cfg.SampleErrors {
+		return true
+	}
+	if cfg.SlowThreshold > 0 && latency >= cfg.SlowThreshold {
+		return true
+	}
+
+	rate := cfg.sampleRate(route)
+	return rate >= 1 || rate > 0 && rand.Float64() < rate
+}
@@ -0,0 +1,92 @@
+package infrahttpaccesslog
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	infraanalytics "github.com/pushwoosh/infra/analytics"
+)
+
+type sinkFunc func(ctx context.Context, rec *Record) error
+
+func (f sinkFunc) Write(ctx context.Context, rec *Record) error {
+	return f(ctx, rec)
+}
+
+func TestMiddleware(t *testing.T) {
+	zero := 0.0
+	cfg := &Config{SampleRate: &zero, Routes: map[string]float64{"GET /users/{id}": 1}}
+
+	var records []*Record
+	sink := sinkFunc(func(_ context.Context, rec *Record) error {
+		records = append(records, rec)
+		return nil
+	})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("user"))
+	})
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, _ *http.Request) {})
+	mux.HandleFunc("GET /fail", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	handler := Middleware(cfg, sink)(mux)
+
+	for _, path := range []string{"/users/42", "/health", "/fail"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("expected the sampled route and the error to be logged, got %d records", len(records))
+	}
+
+	rec := records[0]
+	if rec.Route != "GET /users/{id}" || rec.Path != "/users/42" || rec.Status != http.StatusOK || rec.Bytes != 4 {
+		t.Fatalf("unexpected record %+v", rec)
+	}
+	if records[1].Status != http.StatusBadGateway {
+		t.Fatalf("unexpected record %+v", records[1])
+	}
+}
+
+func TestMiddlewareDetachedContext(t *testing.T) {
+	one := 1.0
+	written := make(chan error, 1)
+	sink := sinkFunc(func(ctx context.Context, rec *Record) error {
+		written <- ctx.Err()
+		return ErrDropped
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	handler := Middleware(&Config{SampleRate: &one}, sink)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		// the client is gone
+		cancel()
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+
+	if err := <-written; err != nil {
+		t.Fatalf("the record must be written with a detached context, got %v", err)
+	}
+}
+
+func TestSampled(t *testing.T) {
+	zero := 0.0
+	cfg := &Config{SampleRate: &zero, SlowThreshold: time.Second}
+
+	if !sampled(cfg, "/", http.StatusOK, 2*time.Second) {
+		t.Fatal("slow request must be logged")
+	}
+	if sampled(cfg, "/", http.StatusOK, time.Millisecond) {
+		t.Fatal("request must be sampled out")
+	}
+}
+
+func TestClickHouseSinkSchema(t *testing.T) {
+	if _, err := NewClickHouseSink(nil, &infraanalytics.Config{Table: "http_access_log"}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,54 @@
+package infrahttpaccesslog
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type Config struct {
+	// SampleRate is a share of logged requests, from 0 to 1. optional, default: 1
+	SampleRate *float64 `mapstructure:"sample_rate"`
+
+	// Routes overrides SampleRate for route patterns, e.g. {"GET /health": 0, "POST /v1/events": 0.01}. optional
+	Routes map[string]float64 `mapstructure:"routes"`
+
+	// Requests slower than that are logged regardless of sampling. optional
+	SlowThreshold time.Duration `mapstructure:"slow_threshold"`
+
+	// Responses with 5xx status are logged regardless of sampling unless SampleErrors is set. optional
+	SampleErrors bool `mapstructure:"sample_errors"`
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if c.SampleRate != nil && (*c.SampleRate < 0 || *c.SampleRate > 1) {
+		return errors.Errorf("sample_rate must be between 0 and 1, got %v", *c.SampleRate)
+	}
+
+	for route, rate := range c.Routes {
+		if rate < 0 || rate > 1 {
+			return errors.Errorf("sample rate of route %q must be between 0 and 1, got %v", route, rate)
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) GetSampleRate() float64 {
+	if c.SampleRate == nil {
+		return 1
+	}
+	return *c.SampleRate
+}
+
+// sampleRate returns the sample rate of a route
+func (c *Config) sampleRate(route string) float64 {
+	if rate, ok := c.Routes[route]; ok {
+		return rate
+	}
+	return c.GetSampleRate()
+}
@@ -0,0 +1,26 @@
+package infrahttpaccesslog
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	RecordsCounter *prometheus.CounterVec
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.RecordsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_access_log_records_total",
+			Help: "Access log records by result: written, sampled_out, dropped or failed",
+		}, []string{"result"})
+
+		prometheus.MustRegister(
+			metrics.RecordsCounter,
+		)
+	})
+}
@@ -0,0 +1,83 @@
+package infrahttpaccesslog
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+	infraanalytics "github.com/pushwoosh/infra/analytics"
+	infrabatch "github.com/pushwoosh/infra/batch"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	"go.uber.org/zap"
+)
+
+// ErrDropped is returned by Sink.Write when the record is dropped because the sink is full
+var ErrDropped = errors.New("access log record is dropped")
+
+// Sink writes access log records. Write is called in the handler, so it must not block
+type Sink interface {
+	Write(ctx context.Context, rec *Record) error
+}
+
+// LogSink writes records to infralog with info level
+type LogSink struct{}
+
+func (LogSink) Write(ctx context.Context, rec *Record) error {
+	infralog.InfoCtx(ctx, "http access",
+		zap.String("method", rec.Method),
+		zap.String("route", rec.Route),
+		zap.String("path", rec.Path),
+		zap.Uint16("status", rec.Status),
+		zap.Int64("bytes", rec.Bytes),
+		zap.Duration("latency", rec.Latency),
+		zap.String("remote_addr", rec.RemoteAddr),
+		zap.String("user_agent", rec.UserAgent),
+		zap.String("trace_id", rec.TraceID))
+	return nil
+}
+
+// ClickHouseSink writes records to a ClickHouse table in batches. It must be started before use:
+//
+//	sink, err := infrahttpaccesslog.NewClickHouseSink(chContainer.Get("logs"), &infraanalytics.Config{
+//		Table:        "http_access_log",
+//		ManageSchema: true,
+//		TTL:          "ts + INTERVAL 30 DAY",
+//	})
+//	app.Add("access log", sink)
+type ClickHouseSink struct {
+	recorder *infraanalytics.Recorder[*Record]
+}
+
+var (
+	_ infraoperator.Starter = (*ClickHouseSink)(nil)
+	_ infraoperator.Stopper = (*ClickHouseSink)(nil)
+)
+
+func NewClickHouseSink(db *sql.DB, cfg *infraanalytics.Config) (*ClickHouseSink, error) {
+	recorder, err := infraanalytics.NewRecorder[*Record](db, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ClickHouseSink{recorder: recorder}, nil
+}
+
+// Write adds the record to the buffer without waiting. It returns ErrDropped while the buffer is full
+func (s *ClickHouseSink) Write(_ context.Context, rec *Record) error {
+	err := s.recorder.TryRecord(rec)
+	if errors.Is(err, infrabatch.ErrFull) {
+		return ErrDropped
+	}
+	return err
+}
+
+// Start creates the table if ManageSchema is set and starts flushing
+func (s *ClickHouseSink) Start(ctx context.Context) error {
+	return s.recorder.Start(ctx)
+}
+
+// Stop flushes buffered records
+func (s *ClickHouseSink) Stop(ctx context.Context) error {
+	return s.recorder.Stop(ctx)
+}
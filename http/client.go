@@ -0,0 +1,215 @@
+package infrahttp
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	infraproxy "github.com/pushwoosh/infra/proxy"
+	infrarequestid "github.com/pushwoosh/infra/requestid"
+	infraretry "github.com/pushwoosh/infra/retry"
+	infratenancy "github.com/pushwoosh/infra/tenancy"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// NewClient creates an http client with configured connection pool, timeouts and retries.
+// Every request is measured with prometheus metrics labeled by target and traced with a client span,
+// and trace context and request id are propagated to the server.
+// target is a logical name of the remote service, e.g. "billing-api".
+func NewClient(target string, cfg *ClientConfig, opts ...ClientOption) (*http.Client, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	options := &clientOptions{}
+	for _, opt := range opts {
+		opt.apply(options)
+	}
+
+	initClientMetrics()
+
+	dialer := &net.Dialer{
+		Timeout:   cfg.DialTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: cfg.ResponseHeaderTimeout,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+	}
+	if options.resolver != nil {
+		transport.DialContext = options.resolver.DialContext(dialer)
+	}
+
+	if proxy := infraproxy.ForClient(cfg.Proxy); proxy != nil {
+		proxyFunc, err := proxy.ProxyFunc()
+		if err != nil {
+			return nil, errors.Wrap(err, "proxy")
+		}
+		transport.Proxy = proxyFunc
+	} else if cfg.Proxy != nil {
+		// direct connections are requested explicitly
+		transport.Proxy = nil
+	}
+
+	if cfg.TLS != nil {
+		loader := cfg.TLS.Loader()
+		if err := loader.Reload(); err != nil {
+			return nil, errors.Wrap(err, "tls")
+		}
+		transport.TLSClientConfig = loader.ClientConfig()
+	}
+
+	return &http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: WrapTransport(target, transport, cfg.Retry),
+	}, nil
+}
+
+// WrapTransport wraps an existing round tripper with metrics, a client span, trace and request id propagation
+// and optional retries
+func WrapTransport(target string, next http.RoundTripper, retry *ClientRetryConfig) http.RoundTripper {
+	initClientMetrics()
+
+	var rt http.RoundTripper = &metricsTransport{target: target, next: next}
+	if retry != nil {
+		rt = &retryTransport{target: target, cfg: retry, next: rt}
+	}
+
+	return &tracingTransport{target: target, next: &propagationTransport{next: rt}}
+}
+
+// propagationTransport injects trace context, request id and tenant id into request headers
+type propagationTransport struct {
+	next http.RoundTripper
+}
+
+func (t *propagationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	otel.GetTextMapPropagator().Inject(req.Context(), propagation.HeaderCarrier(req.Header))
+	if id := infrarequestid.FromContext(req.Context()); id != "" && req.Header.Get(infrarequestid.Header) == "" {
+		req.Header.Set(infrarequestid.Header, id)
+	}
+	if tenant := infratenancy.FromContext(req.Context()); tenant != "" && req.Header.Get(infratenancy.Header) == "" {
+		req.Header.Set(infratenancy.Header, tenant)
+	}
+	return t.next.RoundTrip(req)
+}
+
+// metricsTransport measures every attempt of a request
+type metricsTransport struct {
+	target string
+	next   http.RoundTripper
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+
+	clientMetrics.RequestsCounter.WithLabelValues(t.target, req.Method, code).Inc()
+	clientMetrics.RequestDurationHistogram.WithLabelValues(t.target, req.Method, code).Observe(time.Since(start).Seconds())
+
+	return resp, err
+}
+
+// retryTransport repeats idempotent requests on network errors and configured response codes
+type retryTransport struct {
+	target string
+	cfg    *ClientRetryConfig
+	next   http.RoundTripper
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isRetryable(req) {
+		return t.next.RoundTrip(req)
+	}
+
+	delay := t.cfg.Delay
+	if delay == 0 {
+		delay = defaultClientRetryDelay
+	}
+	maxDelay := t.cfg.MaxDelay
+	if maxDelay == 0 {
+		maxDelay = defaultClientRetryMaxDelay
+	}
+	jitter := t.cfg.Jitter
+	if jitter == 0 {
+		jitter = defaultClientRetryJitter
+	}
+
+	backoff := infraretry.Exponential{Initial: delay, Max: maxDelay, Jitter: jitter}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+		if attempt >= t.cfg.MaxAttempts || !t.shouldRetry(resp, err) || req.Context().Err() != nil {
+			return resp, err
+		}
+
+		if resp != nil {
+			// drain body to reuse connection
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			_ = resp.Body.Close()
+		}
+
+		if err = rewindBody(req); err != nil {
+			return nil, err
+		}
+
+		clientMetrics.RetriesCounter.WithLabelValues(t.target, req.Method).Inc()
+
+		if err = infraretry.Sleep(req.Context(), backoff.Delay(attempt)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (t *retryTransport) shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return slices.Contains(t.cfg.statusCodes(), resp.StatusCode)
+}
+
+func isRetryable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		// body can't be sent twice
+		return false
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+func rewindBody(req *http.Request) error {
+	if req.GetBody == nil {
+		return nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return errors.Wrap(err, "unable to rewind request body")
+	}
+	req.Body = body
+
+	return nil
+}
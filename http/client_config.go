@@ -0,0 +1,124 @@
+package infrahttp
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	infraproxy "github.com/pushwoosh/infra/proxy"
+	infratls "github.com/pushwoosh/infra/tls"
+)
+
+const (
+	DefaultClientTimeout             = 30 * time.Second
+	DefaultClientDialTimeout         = 5 * time.Second
+	DefaultClientTLSHandshakeTimeout = 5 * time.Second
+	DefaultClientIdleConnTimeout     = 90 * time.Second
+	DefaultClientMaxIdleConnsPerHost = 16
+
+	defaultClientRetryDelay    = 100 * time.Millisecond
+	defaultClientRetryMaxDelay = 5 * time.Second
+	defaultClientRetryJitter   = 0.2
+)
+
+type ClientConfig struct {
+	// Timeout limits the whole request including retries
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	DialTimeout           time.Duration `mapstructure:"dial_timeout"`            // optional
+	TLSHandshakeTimeout   time.Duration `mapstructure:"tls_handshake_timeout"`   // optional
+	ResponseHeaderTimeout time.Duration `mapstructure:"response_header_timeout"` // optional
+	IdleConnTimeout       time.Duration `mapstructure:"idle_conn_timeout"`       // optional
+
+	MaxIdleConnsPerHost int `mapstructure:"max_idle_conns_per_host"` // optional
+	MaxConnsPerHost     int `mapstructure:"max_conns_per_host"`      // optional, 0 means no limit
+
+	// Retry enables retries of idempotent requests. optional
+	Retry *ClientRetryConfig `mapstructure:"retry"`
+
+	// TLS sets CA, client certificate for mutual TLS and server checks of https requests. optional
+	TLS *infratls.Config `mapstructure:"tls"`
+
+	// Proxy of requests. optional, default: the global proxy or HTTP_PROXY/HTTPS_PROXY/NO_PROXY from environment
+	Proxy *infraproxy.Config `mapstructure:"proxy"`
+}
+
+// ClientRetryConfig configures retries with exponential backoff.
+// Only requests with idempotent methods or with Idempotency-Key header are retried.
+type ClientRetryConfig struct {
+	MaxAttempts int           `mapstructure:"max_attempts"`
+	Delay       time.Duration `mapstructure:"delay"`     // optional, initial delay
+	MaxDelay    time.Duration `mapstructure:"max_delay"` // optional
+	Jitter      float64       `mapstructure:"jitter"`    // optional, 0 < jitter <= 1
+	// StatusCodes are response codes that are retried. optional, default is 502, 503 and 504
+	StatusCodes []int `mapstructure:"status_codes"`
+}
+
+func DefaultClientConfig() *ClientConfig {
+	return &ClientConfig{
+		Timeout:             DefaultClientTimeout,
+		DialTimeout:         DefaultClientDialTimeout,
+		TLSHandshakeTimeout: DefaultClientTLSHandshakeTimeout,
+		IdleConnTimeout:     DefaultClientIdleConnTimeout,
+		MaxIdleConnsPerHost: DefaultClientMaxIdleConnsPerHost,
+	}
+}
+
+func (c *ClientConfig) Validate() error {
+	if c.Timeout < 0 || c.DialTimeout < 0 || c.TLSHandshakeTimeout < 0 || c.ResponseHeaderTimeout < 0 || c.IdleConnTimeout < 0 {
+		return errors.New("timeouts must be greater or equal to zero")
+	}
+
+	if c.MaxIdleConnsPerHost < 0 || c.MaxConnsPerHost < 0 {
+		return errors.New("connection limits must be greater or equal to zero")
+	}
+
+	if c.Retry != nil {
+		if err := c.Retry.Validate(); err != nil {
+			return errors.Wrap(err, "retry")
+		}
+	}
+
+	if c.TLS != nil {
+		if err := c.TLS.Validate(); err != nil {
+			return errors.Wrap(err, "tls")
+		}
+	}
+
+	if c.Proxy != nil {
+		if err := c.Proxy.Validate(); err != nil {
+			return errors.Wrap(err, "proxy")
+		}
+	}
+
+	return nil
+}
+
+func (c *ClientRetryConfig) Validate() error {
+	if c.MaxAttempts < 1 {
+		return errors.New("max attempts must be greater than zero")
+	}
+
+	if c.Delay < 0 || c.MaxDelay < 0 {
+		return errors.New("delays must be greater or equal to zero")
+	}
+
+	if c.Jitter < 0 || c.Jitter > 1 {
+		return errors.New("jitter must be in range [0, 1]")
+	}
+
+	for _, code := range c.StatusCodes {
+		if code < 100 || code > 599 {
+			return errors.Errorf("invalid status code: %d", code)
+		}
+	}
+
+	return nil
+}
+
+func (c *ClientRetryConfig) statusCodes() []int {
+	if len(c.StatusCodes) > 0 {
+		return c.StatusCodes
+	}
+	return []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+}
@@ -0,0 +1,26 @@
+package infrahttp
+
+import (
+	infradns "github.com/pushwoosh/infra/dns"
+)
+
+type ClientOption interface {
+	apply(o *clientOptions)
+}
+
+type clientOptions struct {
+	resolver *infradns.Resolver
+}
+
+type optionWithResolver struct {
+	resolver *infradns.Resolver
+}
+
+func (o optionWithResolver) apply(opts *clientOptions) {
+	opts.resolver = o.resolver
+}
+
+// WithResolver resolves hosts with the caching resolver instead of the system one
+func WithResolver(resolver *infradns.Resolver) ClientOption {
+	return optionWithResolver{resolver: resolver}
+}
@@ -0,0 +1,124 @@
+package infrahttp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	infrarequestid "github.com/pushwoosh/infra/requestid"
+	infratenancy "github.com/pushwoosh/infra/tenancy"
+)
+
+func TestClientConfig(t *testing.T) {
+	if err := DefaultClientConfig().Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, invalid := range map[string]*ClientConfig{
+		"timeout":      {Timeout: -time.Second},
+		"conns":        {MaxConnsPerHost: -1},
+		"attempts":     {Retry: &ClientRetryConfig{}},
+		"delay":        {Retry: &ClientRetryConfig{MaxAttempts: 2, Delay: -time.Second}},
+		"jitter":       {Retry: &ClientRetryConfig{MaxAttempts: 2, Jitter: 1.5}},
+		"status codes": {Retry: &ClientRetryConfig{MaxAttempts: 2, StatusCodes: []int{600}}},
+	} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+}
+
+func TestClientRetry(t *testing.T) {
+	var attempts atomic.Int32
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if attempts.Add(1)%3 != 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	cfg := DefaultClientConfig()
+	cfg.Retry = &ClientRetryConfig{MaxAttempts: 3, Delay: time.Millisecond}
+	client, err := NewClient("retry-test", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	do := func(method, body string, header http.Header) int {
+		req, err := http.NewRequest(method, srv.URL, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// idempotent requests are retried with the body rewound
+	if code := do(http.MethodPut, "payload", nil); code != http.StatusOK || attempts.Load() != 3 {
+		t.Fatalf("expected success after 3 attempts, got %d after %d", code, attempts.Load())
+	}
+	if bodies[0] != "payload" || bodies[2] != "payload" {
+		t.Fatalf("expected the body sent on every attempt, got %q", bodies)
+	}
+
+	// non-idempotent requests are sent once
+	attempts.Store(0)
+	if code := do(http.MethodPost, "payload", nil); code != http.StatusServiceUnavailable || attempts.Load() != 1 {
+		t.Fatalf("expected a single attempt, got %d after %d", code, attempts.Load())
+	}
+
+	attempts.Store(0)
+	header := http.Header{"Idempotency-Key": []string{"key"}}
+	if code := do(http.MethodPost, "payload", header); code != http.StatusOK || attempts.Load() != 3 {
+		t.Fatalf("expected success after 3 attempts, got %d after %d", code, attempts.Load())
+	}
+}
+
+func TestClientPropagation(t *testing.T) {
+	var headers http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	client, err := NewClient("propagation-test", DefaultClientConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := infrarequestid.NewContext(context.Background(), "req-1")
+	ctx = infratenancy.NewContext(ctx, "acme")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	if headers.Get(infrarequestid.Header) != "req-1" || headers.Get(infratenancy.Header) != "acme" {
+		t.Fatalf("expected request and tenant id propagated, got %v", headers)
+	}
+	if req.Header.Get(infrarequestid.Header) != "" {
+		t.Fatal("the original request must not be modified")
+	}
+}
@@ -0,0 +1,263 @@
+package infrahttp
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// CompressionMiddleware compresses responses with gzip or deflate if the client accepts it.
+// Responses are compressed if they have a configured content type and are larger than MinSize,
+// responses with Content-Encoding set by handlers are sent as is
+func CompressionMiddleware(cfg *CompressionConfig) Middleware {
+	level := cfg.GetLevel()
+	gzipPool := &sync.Pool{New: func() interface{} {
+		w, _ := gzip.NewWriterLevel(io.Discard, level)
+		return w
+	}}
+	flatePool := &sync.Pool{New: func() interface{} {
+		w, _ := flate.NewWriter(io.Discard, level)
+		return w
+	}}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			encoding := acceptedEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead || r.Header.Get("Upgrade") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{
+				ResponseWriter: w,
+				cfg:            cfg,
+				encoding:       encoding,
+				gzipPool:       gzipPool,
+				flatePool:      flatePool,
+				status:         http.StatusOK,
+			}
+			defer cw.close()
+
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// acceptedEncoding returns gzip or deflate if it's accepted, gzip is preferred
+func acceptedEncoding(header string) string {
+	var deflate bool
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.ReplaceAll(params, " ", "") == "q=0" {
+			continue
+		}
+
+		switch strings.ToLower(name) {
+		case "gzip", "*":
+			return "gzip"
+		case "deflate":
+			deflate = true
+		}
+	}
+
+	if deflate {
+		return "deflate"
+	}
+	return ""
+}
+
+// compressWriter buffers the beginning of a response to decide whether it's worth compressing
+type compressWriter struct {
+	http.ResponseWriter
+	cfg       *CompressionConfig
+	encoding  string
+	gzipPool  *sync.Pool
+	flatePool *sync.Pool
+
+	status      int
+	wroteHeader bool
+	decided     bool
+	buf         []byte
+	compressor  io.WriteCloser
+	hijacked    bool
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.status = status
+	w.wroteHeader = true
+
+	// informational responses are sent right away
+	if status < http.StatusOK {
+		w.wroteHeader = false
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+
+	if status == http.StatusNoContent || status == http.StatusNotModified {
+		w.decide(false)
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+
+	if !w.decided {
+		if !w.compressible() {
+			w.decide(false)
+		} else {
+			w.buf = append(w.buf, b...)
+			if len(w.buf) < w.cfg.GetMinSize() {
+				return len(b), nil
+			}
+
+			w.decide(true)
+			buf := w.buf
+			w.buf = nil
+			if _, err := w.compressor.Write(buf); err != nil {
+				return 0, err
+			}
+			return len(b), nil
+		}
+	}
+
+	if w.compressor != nil {
+		return w.compressor.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *compressWriter) compressible() bool {
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	contentType := h.Get("Content-Type")
+	if contentType == "" {
+		// it's sniffed from the beginning of the body by net/http otherwise
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, pattern := range w.cfg.GetContentTypes() {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(mediaType, prefix) || pattern == mediaType {
+			return true
+		}
+	}
+	return false
+}
+
+// decide writes the header with or without compression
+func (w *compressWriter) decide(compress bool) {
+	w.decided = true
+
+	if compress {
+		h := w.Header()
+		if h.Get("Content-Type") == "" {
+			h.Set("Content-Type", http.DetectContentType(w.buf))
+		}
+		h.Set("Content-Encoding", w.encoding)
+		h.Del("Content-Length")
+		h.Del("Accept-Ranges")
+
+		if w.encoding == "gzip" {
+			gw := w.gzipPool.Get().(*gzip.Writer)
+			gw.Reset(w.ResponseWriter)
+			w.compressor = gw
+		} else {
+			fw := w.flatePool.Get().(*flate.Writer)
+			fw.Reset(w.ResponseWriter)
+			w.compressor = fw
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+func (w *compressWriter) close() {
+	if w.hijacked {
+		return
+	}
+
+	if !w.decided {
+		// the response is smaller than MinSize or has no body
+		buf := w.buf
+		w.buf = nil
+		if !w.wroteHeader && len(buf) == 0 {
+			return
+		}
+		w.decide(false)
+		if len(buf) > 0 {
+			_, _ = w.ResponseWriter.Write(buf)
+		}
+		return
+	}
+
+	if w.compressor == nil {
+		return
+	}
+
+	_ = w.compressor.Close()
+	switch c := w.compressor.(type) {
+	case *gzip.Writer:
+		w.gzipPool.Put(c)
+	case *flate.Writer:
+		w.flatePool.Put(c)
+	}
+	w.compressor = nil
+}
+
+func (w *compressWriter) Flush() {
+	if !w.decided && w.wroteHeader {
+		// a streamed response is compressed regardless of its size
+		w.decide(w.compressible())
+		buf := w.buf
+		w.buf = nil
+		if len(buf) > 0 {
+			_, _ = w.Write(buf)
+		}
+	}
+
+	if gw, ok := w.compressor.(*gzip.Writer); ok {
+		_ = gw.Flush()
+	} else if fw, ok := w.compressor.(*flate.Writer); ok {
+		_ = fw.Flush()
+	}
+
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	conn, buf, err := h.Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+	return conn, buf, err
+}
+
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
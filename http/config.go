@@ -1,16 +1,52 @@
 package infrahttp
 
 import (
+	"time"
+
 	"github.com/pkg/errors"
+	infratls "github.com/pushwoosh/infra/tls"
+)
+
+const (
+	DefaultReadHeaderTimeout = 10 * time.Second
+	DefaultReadTimeout       = 30 * time.Second
+	DefaultWriteTimeout      = 30 * time.Second
+	DefaultIdleTimeout       = 120 * time.Second
+	DefaultShutdownTimeout   = 30 * time.Second
 )
 
 type Config struct {
+	// Listen is an address to listen to. "unix:" prefix means unix socket path.
 	Listen string `mapstructure:"listen"`
+	// AdditionalListen is a list of extra addresses served by the same handler. optional
+	AdditionalListen []string `mapstructure:"additional_listen"`
+
+	ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout"` // optional, default: DefaultReadHeaderTimeout
+	ReadTimeout       time.Duration `mapstructure:"read_timeout"`        // optional, default: DefaultReadTimeout
+	WriteTimeout      time.Duration `mapstructure:"write_timeout"`       // optional
+	IdleTimeout       time.Duration `mapstructure:"idle_timeout"`        // optional, default: DefaultIdleTimeout
+	ShutdownTimeout   time.Duration `mapstructure:"shutdown_timeout"`    // optional
+	MaxHeaderBytes    int           `mapstructure:"max_header_bytes"`    // optional
+
+	// LogRequests enables logging of every handled request with debug level
+	LogRequests bool `mapstructure:"log_requests"`
+
+	// TLS serves https on all addresses, certificates are reloaded on change. optional
+	TLS *infratls.Config `mapstructure:"tls"`
+
+	// Middlewares are standard middlewares applied to all requests, see StandardMiddlewares. optional
+	Middlewares *MiddlewaresConfig `mapstructure:"middlewares"`
 }
 
 func DefaultConfig() *Config {
 	return &Config{
 		Listen: ":8081",
+
+		ReadHeaderTimeout: DefaultReadHeaderTimeout,
+		ReadTimeout:       DefaultReadTimeout,
+		WriteTimeout:      DefaultWriteTimeout,
+		IdleTimeout:       DefaultIdleTimeout,
+		ShutdownTimeout:   DefaultShutdownTimeout,
 	}
 }
 
@@ -19,5 +55,56 @@ func (c *Config) Validate() error {
 		return errors.New("listen address is mandatory")
 	}
 
+	for _, addr := range c.AdditionalListen {
+		if addr == "" {
+			return errors.New("additional listen address must not be empty")
+		}
+	}
+
+	if c.ReadHeaderTimeout < 0 || c.ReadTimeout < 0 || c.WriteTimeout < 0 || c.IdleTimeout < 0 || c.ShutdownTimeout < 0 {
+		return errors.New("timeouts must be greater or equal to zero")
+	}
+
+	if c.MaxHeaderBytes < 0 {
+		return errors.New("max header bytes must be greater or equal to zero")
+	}
+
+	if c.TLS != nil {
+		if err := c.TLS.Validate(); err != nil {
+			return errors.Wrap(err, "tls")
+		}
+	}
+
+	if c.Middlewares != nil {
+		if err := c.Middlewares.Validate(); err != nil {
+			return errors.Wrap(err, "middlewares")
+		}
+	}
+
 	return nil
 }
+
+func (c *Config) addresses() []string {
+	return append([]string{c.Listen}, c.AdditionalListen...)
+}
+
+func (c *Config) GetReadHeaderTimeout() time.Duration {
+	if c.ReadHeaderTimeout <= 0 {
+		return DefaultReadHeaderTimeout
+	}
+	return c.ReadHeaderTimeout
+}
+
+func (c *Config) GetReadTimeout() time.Duration {
+	if c.ReadTimeout <= 0 {
+		return DefaultReadTimeout
+	}
+	return c.ReadTimeout
+}
+
+func (c *Config) GetIdleTimeout() time.Duration {
+	if c.IdleTimeout <= 0 {
+		return DefaultIdleTimeout
+	}
+	return c.IdleTimeout
+}
@@ -0,0 +1,91 @@
+package infrahttp
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// BodyLimitMiddleware limits sizes of request bodies. Requests with larger Content-Length are rejected
+// with 413, reads of larger bodies without Content-Length fail with *http.MaxBytesError
+func BodyLimitMiddleware(cfg *BodyLimitConfig) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit, ok := routeValue(cfg.Routes, r)
+			if !ok {
+				limit = cfg.GetMaxBytes()
+			}
+
+			if limit > 0 {
+				if r.ContentLength > int64(limit) {
+					http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+					return
+				}
+				r.Body = http.MaxBytesReader(w, r.Body, int64(limit))
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// TimeoutMiddleware sets a deadline to the request context. Handlers are expected to stop on the context,
+// if a handler returns after the deadline without a response, 503 is sent. Unlike http.TimeoutHandler
+// responses aren't buffered, so streaming and websockets work with zero route timeouts or long ones
+func TimeoutMiddleware(cfg *TimeoutConfig) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout, ok := routeValue(cfg.Routes, r)
+			if !ok {
+				timeout = cfg.GetDefault()
+			}
+
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			rw := WrapResponseWriter(w)
+			next.ServeHTTP(rw, r.WithContext(ctx))
+
+			if !rw.wroteHeader && !rw.hijacked && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				http.Error(rw, "request timeout", http.StatusServiceUnavailable)
+			}
+		})
+	}
+}
+
+// routeValue returns the value of the longest route matching the request. Routes are path prefixes
+// optionally preceded by a method: "/v1/export" or "POST /v1/export", method routes win on equal prefixes
+func routeValue[T any](routes map[string]T, r *http.Request) (T, bool) {
+	var (
+		value    T
+		found    bool
+		bestLen  = -1
+		bestMeth bool
+	)
+
+	for route, v := range routes {
+		method, prefix, hasMethod := strings.Cut(route, " ")
+		if !hasMethod {
+			prefix = route
+		} else if method != r.Method {
+			continue
+		}
+
+		if !strings.HasPrefix(r.URL.Path, prefix) {
+			continue
+		}
+
+		if len(prefix) > bestLen || (len(prefix) == bestLen && hasMethod && !bestMeth) {
+			value, found, bestLen, bestMeth = v, true, len(prefix), hasMethod
+		}
+	}
+
+	return value, found
+}
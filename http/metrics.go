@@ -0,0 +1,76 @@
+package infrahttp
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	inframetrics "github.com/pushwoosh/infra/metrics"
+)
+
+var metrics struct {
+	RequestsCounter          *prometheus.CounterVec
+	RequestDurationHistogram *prometheus.HistogramVec
+	InflightRequestsGauge    *prometheus.GaugeVec
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.RequestsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_server_requests_total",
+			Help: "Total number of handled http requests",
+		}, []string{"server", "method", "code"})
+
+		metrics.RequestDurationHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_server_request_duration_seconds",
+			Help:    "Duration of handled http requests",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"server", "method", "code"})
+
+		metrics.InflightRequestsGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "http_server_inflight_requests",
+			Help: "Number of http requests being handled",
+		}, []string{"server"})
+
+		prometheus.MustRegister(
+			metrics.RequestsCounter,
+			metrics.RequestDurationHistogram,
+			metrics.InflightRequestsGauge,
+		)
+	})
+}
+
+var clientMetrics struct {
+	RequestsCounter          *inframetrics.CounterVec
+	RequestDurationHistogram *inframetrics.HistogramVec
+	RetriesCounter           *inframetrics.CounterVec
+}
+
+var clientMetricsOnce sync.Once
+
+func initClientMetrics() {
+	clientMetricsOnce.Do(func() {
+		clientMetrics.RequestsCounter = inframetrics.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_client_requests_total",
+			Help: "Total number of outgoing http requests. Every retry attempt is counted separately",
+		}, []string{"target", "method", "code"})
+
+		clientMetrics.RequestDurationHistogram = inframetrics.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_client_request_duration_seconds",
+			Help:    "Duration of outgoing http requests",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"target", "method", "code"})
+
+		clientMetrics.RetriesCounter = inframetrics.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_client_retries_total",
+			Help: "Total number of retried outgoing http requests",
+		}, []string{"target", "method"})
+
+		prometheus.MustRegister(
+			clientMetrics.RequestsCounter,
+			clientMetrics.RequestDurationHistogram,
+			clientMetrics.RetriesCounter,
+		)
+	})
+}
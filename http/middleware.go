@@ -0,0 +1,188 @@
+package infrahttp
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	infrarecovery "github.com/pushwoosh/infra/recovery"
+	infrarequestid "github.com/pushwoosh/infra/requestid"
+	infratenancy "github.com/pushwoosh/infra/tenancy"
+	"go.uber.org/zap"
+)
+
+// Middleware wraps http handler with additional behavior
+type Middleware func(next http.Handler) http.Handler
+
+// Chain applies middlewares to a handler. The first middleware is the outermost one.
+func Chain(handler http.Handler, middlewares ...Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// StandardMiddlewares returns configured standard middlewares in the order they should be applied:
+// security headers, CORS, body limit, timeout and compression. Servers apply them with Config.Middlewares:
+//
+//	cfg.Middlewares = infrahttp.DefaultMiddlewaresConfig()
+//	cfg.Middlewares.CORS = &infrahttp.CORSConfig{AllowedOrigins: []string{"https://*.example.com"}}
+func StandardMiddlewares(cfg *MiddlewaresConfig) []Middleware {
+	var middlewares []Middleware
+	if cfg.SecurityHeaders != nil {
+		middlewares = append(middlewares, SecurityHeadersMiddleware(cfg.SecurityHeaders))
+	}
+	if cfg.CORS != nil {
+		middlewares = append(middlewares, CORSMiddleware(cfg.CORS))
+	}
+	if cfg.BodyLimit != nil {
+		middlewares = append(middlewares, BodyLimitMiddleware(cfg.BodyLimit))
+	}
+	if cfg.Timeout != nil {
+		middlewares = append(middlewares, TimeoutMiddleware(cfg.Timeout))
+	}
+	if cfg.Compression != nil {
+		middlewares = append(middlewares, CompressionMiddleware(cfg.Compression))
+	}
+	return middlewares
+}
+
+// RecoveryMiddleware recovers handler panics, logs them with a stack trace, reports them to the error tracker
+// and responds with 500
+func RecoveryMiddleware() Middleware {
+	return infrarecovery.HTTP
+}
+
+// RequestIDMiddleware takes the request id from X-Request-ID header or generates a new one and stores it
+// in the request context, so it's logged with the context and propagated to outgoing calls
+func RequestIDMiddleware() Middleware {
+	return infrarequestid.HTTP
+}
+
+// TenantMiddleware takes the tenant id from X-Tenant-ID header and stores it in the request context,
+// so it labels logs, traces and error reports, selects feature flags and is propagated to outgoing calls.
+// Any caller can set the header, so it's only for servers behind a trust boundary, see WithTenantHeader
+func TenantMiddleware() Middleware {
+	return infratenancy.HTTP
+}
+
+// LoggingMiddleware logs every handled request with debug level
+func LoggingMiddleware() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := WrapResponseWriter(w)
+
+			next.ServeHTTP(rw, r)
+
+			infralog.DebugCtx(r.Context(), "http request",
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+				zap.String("remote_addr", r.RemoteAddr),
+				zap.Int("status", rw.status),
+				zap.Int("bytes", rw.bytes),
+				zap.Duration("duration", time.Since(start)))
+		})
+	}
+}
+
+// MetricsMiddleware collects request count and duration metrics.
+// server is used as a metric label to distinguish several servers in one process.
+func MetricsMiddleware(server string) Middleware {
+	initMetrics()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := WrapResponseWriter(w)
+
+			metrics.InflightRequestsGauge.WithLabelValues(server).Inc()
+			defer metrics.InflightRequestsGauge.WithLabelValues(server).Dec()
+
+			next.ServeHTTP(rw, r)
+
+			status := strconv.Itoa(rw.status)
+			metrics.RequestsCounter.WithLabelValues(server, r.Method, status).Inc()
+			if rw.hijacked {
+				// duration of a hijacked request is the lifetime of the connection, e.g. a websocket
+				return
+			}
+			metrics.RequestDurationHistogram.WithLabelValues(server, r.Method, status).Observe(time.Since(start).Seconds())
+		})
+	}
+}
+
+// ResponseWriter remembers response status and size for middlewares
+type ResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+	hijacked    bool
+}
+
+// WrapResponseWriter returns w if it's already wrapped by an outer middleware, so the response is counted once.
+// Flush, Hijack and Unwrap are passed to w
+func WrapResponseWriter(w http.ResponseWriter) *ResponseWriter {
+	if rw, ok := w.(*ResponseWriter); ok {
+		return rw
+	}
+	return &ResponseWriter{ResponseWriter: w, status: http.StatusOK}
+}
+
+// Status returns the response status, http.StatusSwitchingProtocols if the connection is hijacked
+func (w *ResponseWriter) Status() int {
+	return w.status
+}
+
+// Bytes returns the number of written body bytes
+func (w *ResponseWriter) Bytes() int {
+	return w.bytes
+}
+
+// Hijacked reports if the connection is hijacked, e.g. by a websocket
+func (w *ResponseWriter) Hijacked() bool {
+	return w.hijacked
+}
+
+func (w *ResponseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *ResponseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+func (w *ResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *ResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	conn, buf, err := h.Hijack()
+	if err == nil {
+		w.status = http.StatusSwitchingProtocols
+		w.hijacked = true
+	}
+	return conn, buf, err
+}
+
+func (w *ResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
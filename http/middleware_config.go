@@ -0,0 +1,268 @@
+package infrahttp
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	infraconfig "github.com/pushwoosh/infra/config"
+)
+
+// MiddlewaresConfig configures the standard middlewares, see StandardMiddlewares.
+// A middleware is disabled if its config is empty
+type MiddlewaresConfig struct {
+	SecurityHeaders *SecurityHeadersConfig `mapstructure:"security_headers"`
+	CORS            *CORSConfig            `mapstructure:"cors"`
+	BodyLimit       *BodyLimitConfig       `mapstructure:"body_limit"`
+	Timeout         *TimeoutConfig         `mapstructure:"timeout"`
+	Compression     *CompressionConfig     `mapstructure:"compression"`
+}
+
+// DefaultMiddlewaresConfig enables all standard middlewares except CORS, which needs allowed origins
+func DefaultMiddlewaresConfig() *MiddlewaresConfig {
+	return &MiddlewaresConfig{
+		SecurityHeaders: &SecurityHeadersConfig{},
+		BodyLimit:       &BodyLimitConfig{},
+		Timeout:         &TimeoutConfig{},
+		Compression:     &CompressionConfig{},
+	}
+}
+
+func (c *MiddlewaresConfig) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if c.CORS != nil {
+		if err := c.CORS.Validate(); err != nil {
+			return errors.Wrap(err, "cors")
+		}
+	}
+
+	if c.BodyLimit != nil {
+		if err := c.BodyLimit.Validate(); err != nil {
+			return errors.Wrap(err, "body_limit")
+		}
+	}
+
+	if c.Timeout != nil {
+		if err := c.Timeout.Validate(); err != nil {
+			return errors.Wrap(err, "timeout")
+		}
+	}
+
+	if c.Compression != nil {
+		if err := c.Compression.Validate(); err != nil {
+			return errors.Wrap(err, "compression")
+		}
+	}
+
+	return nil
+}
+
+type SecurityHeadersConfig struct {
+	// Strict-Transport-Security max age, sent on https requests only, negative disables it. optional, default: 1 year
+	HSTSMaxAge time.Duration `mapstructure:"hsts_max_age"`
+
+	// HSTSIncludeSubdomains adds includeSubDomains to Strict-Transport-Security
+	HSTSIncludeSubdomains bool `mapstructure:"hsts_include_subdomains"`
+
+	// X-Frame-Options value, "-" disables it. optional, default: DENY
+	FrameOptions string `mapstructure:"frame_options"`
+
+	// Referrer-Policy value, "-" disables it. optional, default: strict-origin-when-cross-origin
+	ReferrerPolicy string `mapstructure:"referrer_policy"`
+
+	// Content-Security-Policy value. optional
+	ContentSecurityPolicy string `mapstructure:"content_security_policy"`
+}
+
+func (c *SecurityHeadersConfig) GetHSTSMaxAge() time.Duration {
+	if c.HSTSMaxAge == 0 {
+		return 365 * 24 * time.Hour
+	}
+	return c.HSTSMaxAge
+}
+
+func (c *SecurityHeadersConfig) GetFrameOptions() string {
+	if c.FrameOptions == "" {
+		return "DENY"
+	}
+	return c.FrameOptions
+}
+
+func (c *SecurityHeadersConfig) GetReferrerPolicy() string {
+	if c.ReferrerPolicy == "" {
+		return "strict-origin-when-cross-origin"
+	}
+	return c.ReferrerPolicy
+}
+
+type CORSConfig struct {
+	// Origins allowed to make cross-origin requests: exact origins, "*" or subdomain wildcards like "https://*.example.com"
+	AllowedOrigins []string `mapstructure:"allowed_origins"`
+
+	// optional, default: GET, POST, PUT, PATCH, DELETE, HEAD
+	AllowedMethods []string `mapstructure:"allowed_methods"`
+
+	// optional, default: Content-Type, Authorization, X-Request-ID, X-Tenant-ID
+	AllowedHeaders []string `mapstructure:"allowed_headers"`
+
+	// Response headers available to scripts. optional
+	ExposedHeaders []string `mapstructure:"exposed_headers"`
+
+	// AllowCredentials allows cookies and authorization headers, it can't be used with "*" origin
+	AllowCredentials bool `mapstructure:"allow_credentials"`
+
+	// How long preflight responses are cached. optional, default: 10m
+	MaxAge time.Duration `mapstructure:"max_age"`
+}
+
+func (c *CORSConfig) Validate() error {
+	if len(c.AllowedOrigins) == 0 {
+		return errors.New("allowed_origins are mandatory")
+	}
+
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" && c.AllowCredentials {
+			return errors.New("allow_credentials can't be used with any origin")
+		}
+		if origin != "*" && strings.Count(origin, "*") > 1 {
+			return errors.Errorf("invalid origin %q", origin)
+		}
+	}
+
+	if c.MaxAge < 0 {
+		return errors.New("max_age must be greater or equal to zero")
+	}
+
+	return nil
+}
+
+func (c *CORSConfig) GetAllowedMethods() []string {
+	if len(c.AllowedMethods) == 0 {
+		return []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodHead}
+	}
+	return c.AllowedMethods
+}
+
+func (c *CORSConfig) GetAllowedHeaders() []string {
+	if len(c.AllowedHeaders) == 0 {
+		return []string{"Content-Type", "Authorization", "X-Request-ID", "X-Tenant-ID"}
+	}
+	return c.AllowedHeaders
+}
+
+func (c *CORSConfig) GetMaxAge() time.Duration {
+	if c.MaxAge == 0 {
+		return 10 * time.Minute
+	}
+	return c.MaxAge
+}
+
+type BodyLimitConfig struct {
+	// Max size of request bodies, e.g. 10MB. optional, default: 10MB
+	MaxBytes infraconfig.ByteSize `mapstructure:"max_bytes"`
+
+	// Routes overrides MaxBytes for path prefixes, optionally with a method, e.g. {"POST /v1/upload": "1GB"}.
+	// Zero disables the limit. The longest matching prefix wins. optional
+	Routes map[string]infraconfig.ByteSize `mapstructure:"routes"`
+}
+
+func (c *BodyLimitConfig) Validate() error {
+	if c.MaxBytes < 0 {
+		return errors.New("max_bytes must be greater or equal to zero")
+	}
+
+	for route, limit := range c.Routes {
+		if limit < 0 {
+			return errors.Errorf("limit of route %q must be greater or equal to zero", route)
+		}
+	}
+
+	return nil
+}
+
+func (c *BodyLimitConfig) GetMaxBytes() infraconfig.ByteSize {
+	if c.MaxBytes == 0 {
+		return 10 * infraconfig.Megabyte
+	}
+	return c.MaxBytes
+}
+
+type TimeoutConfig struct {
+	// Request handling timeout. optional, default: 30s
+	Default time.Duration `mapstructure:"default"`
+
+	// Routes overrides Default for path prefixes, optionally with a method, e.g. {"GET /v1/stream": "0s"}.
+	// Zero disables the timeout. The longest matching prefix wins. optional
+	Routes map[string]time.Duration `mapstructure:"routes"`
+}
+
+func (c *TimeoutConfig) Validate() error {
+	if c.Default < 0 {
+		return errors.New("default must be greater or equal to zero")
+	}
+
+	for route, timeout := range c.Routes {
+		if timeout < 0 {
+			return errors.Errorf("timeout of route %q must be greater or equal to zero", route)
+		}
+	}
+
+	return nil
+}
+
+func (c *TimeoutConfig) GetDefault() time.Duration {
+	if c.Default == 0 {
+		return 30 * time.Second
+	}
+	return c.Default
+}
+
+type CompressionConfig struct {
+	// gzip/deflate compression level from 1 to 9. optional, default: 6
+	Level int `mapstructure:"level"`
+
+	// Responses smaller than that aren't compressed. optional, default: 1KB
+	MinSize infraconfig.ByteSize `mapstructure:"min_size"`
+
+	// Compressed content types, "text/*" matches all text types.
+	// optional, default: text/*, application/json, application/javascript, application/xml, image/svg+xml
+	ContentTypes []string `mapstructure:"content_types"`
+}
+
+func (c *CompressionConfig) Validate() error {
+	if c.Level != 0 && (c.Level < gzip.BestSpeed || c.Level > gzip.BestCompression) {
+		return errors.Errorf("level must be between 1 and 9, got %d", c.Level)
+	}
+
+	if c.MinSize < 0 {
+		return errors.New("min_size must be greater or equal to zero")
+	}
+
+	return nil
+}
+
+func (c *CompressionConfig) GetLevel() int {
+	if c.Level == 0 {
+		return 6
+	}
+	return c.Level
+}
+
+func (c *CompressionConfig) GetMinSize() int {
+	if c.MinSize == 0 {
+		return int(infraconfig.Kilobyte)
+	}
+	return int(c.MinSize)
+}
+
+func (c *CompressionConfig) GetContentTypes() []string {
+	if len(c.ContentTypes) == 0 {
+		return []string{"text/*", "application/json", "application/javascript", "application/xml", "image/svg+xml"}
+	}
+	return c.ContentTypes
+}
@@ -0,0 +1,129 @@
+package infrahttp
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	infratenancy "github.com/pushwoosh/infra/tenancy"
+)
+
+func TestStandardMiddlewares(t *testing.T) {
+	cfg := DefaultMiddlewaresConfig()
+	cfg.CORS = &CORSConfig{AllowedOrigins: []string{"https://*.example.com"}}
+	cfg.Timeout.Routes = map[string]time.Duration{"/slow": 10 * time.Millisecond, "POST /slow": 0}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	body := strings.Repeat("hello world ", 200)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/text", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = io.WriteString(w, body)
+	})
+	mux.HandleFunc("/small", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"ok":true}`)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	mux.HandleFunc("/upload", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		}
+	})
+	handler := Chain(mux, StandardMiddlewares(cfg)...)
+
+	serve := func(r *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		return rec
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/text", nil)
+	r.Header.Set("Accept-Encoding", "br, gzip")
+	rec := serve(r)
+	if rec.Header().Get("Content-Encoding") != "gzip" || rec.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Fatalf("unexpected headers %v", rec.Header())
+	}
+	gr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded, _ := io.ReadAll(gr); string(decoded) != body {
+		t.Fatal("unexpected decoded body")
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/small", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	if rec = serve(r); rec.Header().Get("Content-Encoding") != "" || rec.Body.String() != `{"ok":true}` {
+		t.Fatalf("expected small response as is, got %v %q", rec.Header(), rec.Body.String())
+	}
+
+	r = httptest.NewRequest(http.MethodOptions, "/text", nil)
+	r.Header.Set("Origin", "https://app.example.com")
+	r.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	if rec = serve(r); rec.Code != http.StatusNoContent || rec.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" {
+		t.Fatalf("unexpected preflight response %d %v", rec.Code, rec.Header())
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/text", nil)
+	r.Header.Set("Origin", "https://example.org")
+	if rec = serve(r); rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatal("expected no CORS headers for unknown origin")
+	}
+
+	if rec = serve(httptest.NewRequest(http.MethodGet, "/slow", nil)); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected timeout, got %d", rec.Code)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(strings.Repeat("x", 11<<20)))
+	if rec = serve(r); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected too large body, got %d", rec.Code)
+	}
+}
+
+func TestRouteValue(t *testing.T) {
+	routes := map[string]int{"/v1": 1, "/v1/export": 2, "POST /v1/export": 3}
+
+	for target, expected := range map[string]int{
+		"GET /v1/users":   1,
+		"GET /v1/export":  2,
+		"POST /v1/export": 3,
+		"GET /v2":         0,
+	} {
+		method, path, _ := strings.Cut(target, " ")
+		v, _ := routeValue(routes, httptest.NewRequest(method, path, nil))
+		if v != expected {
+			t.Errorf("%s: expected %d, got %d", target, expected, v)
+		}
+	}
+}
+
+func TestServerTenantHeader(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, infratenancy.FromContext(r.Context()))
+	})
+
+	for _, tt := range []struct {
+		opts     []ServerOption
+		expected string
+	}{
+		{expected: ""},
+		{opts: []ServerOption{WithTenantHeader()}, expected: "acme"},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set(infratenancy.Header, "acme")
+		rec := httptest.NewRecorder()
+		NewServer(&Config{}, handler, append(tt.opts, WithoutMetrics())...).Handler().ServeHTTP(rec, r)
+
+		if rec.Body.String() != tt.expected {
+			t.Errorf("expected tenant %q, got %q", tt.expected, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package infrahttp
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// SecurityHeadersMiddleware sets X-Content-Type-Options, X-Frame-Options, Referrer-Policy,
+// Content-Security-Policy and Strict-Transport-Security on https requests
+func SecurityHeadersMiddleware(cfg *SecurityHeadersConfig) Middleware {
+	hsts := ""
+	if maxAge := cfg.GetHSTSMaxAge(); maxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(int(maxAge.Seconds()))
+		if cfg.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("X-Content-Type-Options", "nosniff")
+
+			if v := cfg.GetFrameOptions(); v != "-" {
+				h.Set("X-Frame-Options", v)
+			}
+			if v := cfg.GetReferrerPolicy(); v != "-" {
+				h.Set("Referrer-Policy", v)
+			}
+			if cfg.ContentSecurityPolicy != "" {
+				h.Set("Content-Security-Policy", cfg.ContentSecurityPolicy)
+			}
+			if hsts != "" && (r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https") {
+				h.Set("Strict-Transport-Security", hsts)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -2,12 +2,17 @@ package infrahttp
 
 import (
 	"context"
+	"crypto/tls"
 	"net"
 	"net/http"
+	"strings"
+	"sync"
 
 	"github.com/pkg/errors"
 	"github.com/pushwoosh/infra/log"
+	"github.com/pushwoosh/infra/operator"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 type HTTP struct {
@@ -31,8 +36,9 @@ func (srv *HTTP) Start(ctx context.Context) error {
 	}
 
 	srv.server = &http.Server{
-		Addr:    srv.cfg.Listen,
-		Handler: srv.handler,
+		Addr:     srv.cfg.Listen,
+		Handler:  srv.handler,
+		ErrorLog: infralog.NewStdLogger(zapcore.WarnLevel, "http"),
 	}
 
 	go func() {
@@ -49,3 +55,164 @@ func (srv *HTTP) Start(ctx context.Context) error {
 func (srv *HTTP) Stop(ctx context.Context) error {
 	return srv.server.Shutdown(ctx)
 }
+
+// Server is an http server with configured timeouts, panic recovery, request metrics and graceful shutdown.
+// It implements operator's Starter and Stopper interfaces, so it can be added to an operator:
+//
+//	srv := infrahttp.NewServer(cfg, mux)
+//	if err := op.AddService(ctx, srv); err != nil { ... }
+type Server struct {
+	cfg     *Config
+	name    string
+	handler http.Handler
+
+	middlewares    []Middleware
+	listeners      []net.Listener
+	listenFunc     ListenFunc
+	disableMetrics bool
+	tenantHeader   bool
+
+	mu     sync.Mutex
+	server *http.Server
+	wg     sync.WaitGroup
+}
+
+var (
+	_ infraoperator.Starter = (*Server)(nil)
+	_ infraoperator.Stopper = (*Server)(nil)
+)
+
+// NewServer creates a new http server. Call Start to start serving.
+func NewServer(cfg *Config, handler http.Handler, opts ...ServerOption) *Server {
+	s := &Server{
+		cfg:        cfg,
+		name:       "http",
+		handler:    handler,
+		listenFunc: net.Listen,
+	}
+
+	for _, opt := range opts {
+		opt.apply(s)
+	}
+
+	return s
+}
+
+// Handler returns the server handler wrapped with all middlewares
+func (s *Server) Handler() http.Handler {
+	middlewares := []Middleware{TracingMiddleware(s.name), RequestIDMiddleware()}
+	if s.tenantHeader {
+		middlewares = append(middlewares, TenantMiddleware())
+	}
+	middlewares = append(middlewares, RecoveryMiddleware())
+	if !s.disableMetrics {
+		middlewares = append(middlewares, MetricsMiddleware(s.name))
+	}
+	if s.cfg.LogRequests {
+		middlewares = append(middlewares, LoggingMiddleware())
+	}
+	if s.cfg.Middlewares != nil {
+		middlewares = append(middlewares, StandardMiddlewares(s.cfg.Middlewares)...)
+	}
+	middlewares = append(middlewares, s.middlewares...)
+
+	return Chain(s.handler, middlewares...)
+}
+
+// Start opens configured listeners and serves them in background
+func (s *Server) Start(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.server != nil {
+		return errors.New("server is already started")
+	}
+
+	var tlsConfig *tls.Config
+	if s.cfg.TLS != nil {
+		loader := s.cfg.TLS.Loader()
+		if err := loader.Reload(); err != nil {
+			return errors.Wrap(err, "tls")
+		}
+		tlsConfig = loader.ServerConfig()
+	}
+
+	listeners := make([]net.Listener, 0, len(s.cfg.AdditionalListen)+1+len(s.listeners))
+	closeAll := func() {
+		for _, l := range listeners {
+			_ = l.Close()
+		}
+	}
+
+	for _, addr := range s.cfg.addresses() {
+		listener, err := listen(s.listenFunc, addr)
+		if err != nil {
+			closeAll()
+			return errors.Wrapf(err, "listen %s", addr)
+		}
+		listeners = append(listeners, listener)
+	}
+	listeners = append(listeners, s.listeners...)
+
+	s.server = &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: s.cfg.GetReadHeaderTimeout(),
+		ReadTimeout:       s.cfg.GetReadTimeout(),
+		WriteTimeout:      s.cfg.WriteTimeout,
+		IdleTimeout:       s.cfg.GetIdleTimeout(),
+		MaxHeaderBytes:    s.cfg.MaxHeaderBytes,
+		ErrorLog:          infralog.NewStdLogger(zapcore.WarnLevel, s.name),
+		TLSConfig:         tlsConfig,
+	}
+
+	for _, listener := range listeners {
+		s.wg.Add(1)
+		go func(listener net.Listener) {
+			defer s.wg.Done()
+
+			infralog.Debug("serving http", zap.String("server", s.name), zap.String("address", listener.Addr().String()))
+			serve := s.server.Serve
+			if tlsConfig != nil {
+				serve = func(l net.Listener) error { return s.server.ServeTLS(l, "", "") }
+			}
+			if err := serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				infralog.Fatal("http server error", zap.String("server", s.name), zap.Error(err))
+			}
+		}(listener)
+	}
+
+	return nil
+}
+
+// Stop gracefully shuts down the server waiting for active requests.
+// Connections are closed forcibly when shutdown timeout or ctx expires.
+func (s *Server) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.server == nil {
+		return nil
+	}
+
+	if s.cfg.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.ShutdownTimeout)
+		defer cancel()
+	}
+
+	err := s.server.Shutdown(ctx)
+	if err != nil {
+		_ = s.server.Close()
+	}
+	s.wg.Wait()
+	s.server = nil
+
+	return err
+}
+
+func listen(listenFunc ListenFunc, addr string) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		return listenFunc("unix", path)
+	}
+	return listenFunc("tcp", addr)
+}
@@ -0,0 +1,83 @@
+package infrahttp
+
+import (
+	"net"
+)
+
+type ServerOption interface {
+	apply(s *Server)
+}
+
+type optionWithName string
+
+func (o optionWithName) apply(s *Server) {
+	s.name = string(o)
+}
+
+// WithName sets server name that is used in logs and metrics. Default is "http".
+func WithName(name string) ServerOption {
+	return optionWithName(name)
+}
+
+type optionWithMiddlewares []Middleware
+
+func (o optionWithMiddlewares) apply(s *Server) {
+	s.middlewares = append(s.middlewares, o...)
+}
+
+// WithMiddlewares adds middlewares that are applied after the builtin ones
+func WithMiddlewares(middlewares ...Middleware) ServerOption {
+	return optionWithMiddlewares(middlewares)
+}
+
+type optionWithListener struct {
+	listener net.Listener
+}
+
+func (o optionWithListener) apply(s *Server) {
+	s.listeners = append(s.listeners, o.listener)
+}
+
+// WithListener makes server serve an already opened listener in addition to configured addresses
+func WithListener(listener net.Listener) ServerOption {
+	return optionWithListener{listener: listener}
+}
+
+type optionWithoutMetrics struct{}
+
+func (o optionWithoutMetrics) apply(s *Server) {
+	s.disableMetrics = true
+}
+
+// WithoutMetrics disables builtin prometheus request metrics
+func WithoutMetrics() ServerOption {
+	return optionWithoutMetrics{}
+}
+
+type optionWithTenantHeader struct{}
+
+func (o optionWithTenantHeader) apply(s *Server) {
+	s.tenantHeader = true
+}
+
+// WithTenantHeader trusts X-Tenant-ID header of requests, see TenantMiddleware.
+// Only for internal servers whose callers are trusted, public servers take the tenant from the authenticated
+// principal, e.g. with infraauth.HTTPTenant
+func WithTenantHeader() ServerOption {
+	return optionWithTenantHeader{}
+}
+
+// ListenFunc opens a listener, net.Listen by default
+type ListenFunc func(network, address string) (net.Listener, error)
+
+type optionWithListenFunc ListenFunc
+
+func (o optionWithListenFunc) apply(s *Server) {
+	s.listenFunc = ListenFunc(o)
+}
+
+// WithListenFunc sets a function opening configured addresses, e.g. infrahandoff.Handoff.Listen
+// to inherit listeners of the previous process on restart
+func WithListenFunc(fn ListenFunc) ServerOption {
+	return optionWithListenFunc(fn)
+}
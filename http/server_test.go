@@ -0,0 +1,168 @@
+package infrahttp
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, invalid := range map[string]*Config{
+		"listen":            {},
+		"additional listen": {Listen: ":8081", AdditionalListen: []string{""}},
+		"timeout":           {Listen: ":8081", ReadTimeout: -time.Second},
+		"max header bytes":  {Listen: ":8081", MaxHeaderBytes: -1},
+	} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+
+	cfg = &Config{Listen: ":8081"}
+	if cfg.GetReadHeaderTimeout() != DefaultReadHeaderTimeout ||
+		cfg.GetReadTimeout() != DefaultReadTimeout ||
+		cfg.GetIdleTimeout() != DefaultIdleTimeout {
+		t.Fatal("expected default timeouts")
+	}
+}
+
+func TestChain(t *testing.T) {
+	var calls []string
+	middleware := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { calls = append(calls, "handler") })
+
+	Chain(handler, middleware("outer"), middleware("inner")).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if len(calls) != 3 || calls[0] != "outer" || calls[1] != "inner" || calls[2] != "handler" {
+		t.Fatalf("expected the first middleware outermost, got %v", calls)
+	}
+}
+
+func TestResponseWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rw := WrapResponseWriter(rec)
+	if WrapResponseWriter(rw) != rw {
+		t.Fatal("expected a wrapped writer reused")
+	}
+
+	rw.WriteHeader(http.StatusCreated)
+	rw.WriteHeader(http.StatusInternalServerError)
+	_, _ = rw.Write([]byte("hello"))
+	rw.Flush()
+
+	if rw.Status() != http.StatusCreated || rw.Bytes() != 5 || rw.Hijacked() {
+		t.Fatalf("unexpected status %d, bytes %d", rw.Status(), rw.Bytes())
+	}
+	if !rec.Flushed {
+		t.Fatal("expected flush passed to the underlying writer")
+	}
+	if _, _, err := rw.Hijack(); err == nil {
+		t.Fatal("expected hijack error for a writer without hijacking")
+	}
+}
+
+func TestServer(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) { panic("boom") })
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		_, _ = io.WriteString(w, "done")
+	})
+
+	var addresses []string
+	listenFunc := func(network, address string) (net.Listener, error) {
+		l, err := net.Listen(network, address)
+		if err == nil {
+			addresses = append(addresses, l.Addr().String())
+		}
+		return l, err
+	}
+
+	socket := filepath.Join(t.TempDir(), "http.sock")
+	cfg := &Config{Listen: "127.0.0.1:0", AdditionalListen: []string{"unix:" + socket}}
+	srv := NewServer(cfg, mux, WithName("test_server"), WithListenFunc(listenFunc))
+
+	ctx := context.Background()
+	if err := srv.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.Start(ctx); err == nil {
+		t.Fatal("expected error on the second start")
+	}
+	if len(addresses) != 2 || addresses[1] != socket {
+		t.Fatalf("expected tcp and unix listeners, got %v", addresses)
+	}
+
+	resp, err := http.Get("http://" + addresses[0] + "/panic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected recovered panic, got %d", resp.StatusCode)
+	}
+
+	counter := metrics.RequestsCounter.WithLabelValues("test_server", http.MethodGet, "404")
+	before := testutil.ToFloat64(counter)
+	if resp, err = http.Get("http://" + addresses[0] + "/missing"); err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if n := testutil.ToFloat64(counter) - before; n != 1 {
+		t.Fatalf("expected the request counted, got %v", n)
+	}
+
+	// stop waits for requests in progress
+	done := make(chan string)
+	go func() {
+		resp, err := http.Get("http://" + addresses[0] + "/slow")
+		if err != nil {
+			done <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		done <- string(body)
+	}()
+	<-started
+
+	stopped := make(chan error)
+	go func() { stopped <- srv.Stop(ctx) }()
+	time.Sleep(time.Millisecond * 50)
+	close(release)
+
+	if body := <-done; body != "done" {
+		t.Fatalf("expected the request completed, got %q", body)
+	}
+	if err = <-stopped; err != nil {
+		t.Fatal(err)
+	}
+	if err = srv.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = http.Get("http://" + addresses[0] + "/slow"); err == nil {
+		t.Fatal("expected the listener closed")
+	}
+}
@@ -0,0 +1,54 @@
+package infrahttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	infratesttracing "github.com/pushwoosh/infra/test/tracing"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	recorder := infratesttracing.Record(t)
+
+	handler := NewServer(&Config{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !trace.SpanContextFromContext(r.Context()).IsValid() {
+			t.Error("expected a span in the handler context")
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}), WithName("api"), WithoutMetrics()).Handler()
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	client := &http.Client{Transport: WrapTransport("billing", http.DefaultTransport, nil)}
+	resp, err := client.Get(srv.URL + "/v1/balance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	spans := map[trace.SpanKind]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.SpanKind()] = span
+	}
+
+	server, clientSpan := spans[trace.SpanKindServer], spans[trace.SpanKindClient]
+	if server == nil || clientSpan == nil {
+		t.Fatalf("expected server and client spans, got %v", spans)
+	}
+	if server.Name() != "HTTP GET" || server.Status().Code != codes.Error {
+		t.Fatalf("unexpected server span %s: %v", server.Name(), server.Status())
+	}
+	infratesttracing.RequireAttribute(t, server, "http.server_name", "api")
+	infratesttracing.RequireAttribute(t, server, "http.target", "/v1/balance")
+	infratesttracing.RequireAttribute(t, server, "http.status_code", int64(http.StatusBadGateway))
+	infratesttracing.RequireAttribute(t, clientSpan, "peer.service", "billing")
+
+	if server.Parent().SpanID() != clientSpan.SpanContext().SpanID() {
+		t.Fatal("expected the server span to continue the client trace")
+	}
+}
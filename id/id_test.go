@@ -0,0 +1,142 @@
+package infraid
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	infraclock "github.com/pushwoosh/infra/clock"
+	infralock "github.com/pushwoosh/infra/lock"
+)
+
+func TestSnowflake(t *testing.T) {
+	now := Epoch.Add(time.Hour)
+	clock := infraclock.NewFake(now)
+
+	gen, err := NewSnowflake(42, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := gen.Next()
+	ts, node, seq := ParseSnowflake(first)
+	if !ts.Equal(now) || node != 42 || seq != 0 {
+		t.Fatalf("unexpected parts %s %d %d", ts, node, seq)
+	}
+
+	prev := first
+	for i := 0; i < maxSequence+10; i++ {
+		id := gen.Next()
+		if id <= prev {
+			t.Fatalf("ids must grow, got %d after %d", id, prev)
+		}
+		prev = id
+	}
+	if ts, _, _ = ParseSnowflake(prev); !ts.After(now) {
+		t.Fatal("sequence overflow must move to the next millisecond")
+	}
+
+	clock.Set(now.Add(-time.Second))
+	if id := gen.Next(); id <= prev {
+		t.Fatal("ids must grow when the clock goes backwards")
+	}
+
+	if _, err = NewSnowflake(MaxNode + 1); err == nil {
+		t.Fatal("expected error for invalid node id")
+	}
+}
+
+func TestUUID(t *testing.T) {
+	before := time.Now().Truncate(time.Millisecond)
+	id := NewUUID()
+
+	created, err := UUIDTime(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Before(before) || created.After(time.Now()) {
+		t.Fatalf("unexpected uuid time %s", created)
+	}
+
+	if next := NewUUID(); next <= id {
+		t.Fatalf("uuids must be sorted by creation time, got %s after %s", next, id)
+	}
+}
+
+type testLock struct {
+	key  string
+	lost chan struct{}
+}
+
+func (l *testLock) Key() string                   { return l.key }
+func (l *testLock) Token() int64                  { return 0 }
+func (l *testLock) Lost() <-chan struct{}         { return l.lost }
+func (l *testLock) Release(context.Context) error { return nil }
+
+type testLocker struct {
+	mu    sync.Mutex
+	locks []*testLock
+	busy  map[string]bool
+}
+
+func (l *testLocker) Acquire(ctx context.Context, key string, ttl time.Duration) (infralock.Lock, error) {
+	return l.TryAcquire(ctx, key, ttl)
+}
+
+func (l *testLocker) TryAcquire(_ context.Context, key string, _ time.Duration) (infralock.Lock, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.busy[key] {
+		return nil, infralock.ErrNotAcquired
+	}
+
+	lock := &testLock{key: key, lost: make(chan struct{})}
+	l.locks = append(l.locks, lock)
+
+	return lock, nil
+}
+
+func TestLockNodeAllocator(t *testing.T) {
+	locker := &testLocker{busy: map[string]bool{"snowflake:0": true}}
+	nodes := NewLockNodeAllocator(locker, "snowflake", 30*time.Millisecond)
+
+	gen, err := nodes.Snowflake(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = nodes.Stop(context.Background()) }()
+
+	if _, node, _ := ParseSnowflake(gen.Next()); node != 1 {
+		t.Fatalf("expected the first free node, got %d", node)
+	}
+
+	locker.mu.Lock()
+	close(locker.locks[0].lost)
+	locker.mu.Unlock()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		_, err = gen.NextID()
+		if errors.Is(err, ErrNodeLost) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("ids are issued after the lease is lost")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// the node id is leased again
+	for {
+		if _, err = gen.NextID(); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("node id is not leased again")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,193 @@
+package infraid
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	infralock "github.com/pushwoosh/infra/lock"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	infraretry "github.com/pushwoosh/infra/retry"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const defaultLeaseTTL = 30 * time.Second
+
+// ErrNodeLost is returned by Snowflake.NextID while the node id lease is lost, see LockNodeAllocator
+var ErrNodeLost = errors.New("snowflake node id lease is lost")
+
+// LockNodeAllocator leases a free node id with a distributed lock "<prefix>:<node>", so any number of replicas
+// get unique node ids without coordination in deployment. The lock is renewed until Stop releases it:
+//
+//	nodes, err := infraid.NewRedisNodeAllocator(redisContainer.Get("main"), "sender:snowflake", 0)
+//	gen, err := nodes.Snowflake(ctx)
+//	app.Add(nodes)
+//
+// When the lock is lost another process may take the node id, so generators created with Snowflake stop
+// issuing ids until a node id is leased again.
+type LockNodeAllocator struct {
+	locker infralock.Locker
+	prefix string
+	ttl    time.Duration
+
+	mu     sync.Mutex
+	lock   infralock.Lock // nil while the lease is lost
+	node   int64
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+var (
+	_ NodeAllocator         = (*LockNodeAllocator)(nil)
+	_ infraoperator.Stopper = (*LockNodeAllocator)(nil)
+)
+
+// NewLockNodeAllocator creates an allocator of node ids locked with locker. ttl is 30s by default
+func NewLockNodeAllocator(locker infralock.Locker, prefix string, ttl time.Duration) *LockNodeAllocator {
+	if ttl <= 0 {
+		ttl = defaultLeaseTTL
+	}
+
+	return &LockNodeAllocator{
+		locker: locker,
+		prefix: prefix,
+		ttl:    ttl,
+	}
+}
+
+// NewRedisNodeAllocator creates an allocator of node ids locked in redis, see infralock.NewRedisLocker
+func NewRedisNodeAllocator(client redis.UniversalClient, prefix string, ttl time.Duration) (*LockNodeAllocator, error) {
+	locker, err := infralock.NewRedisLocker(client)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewLockNodeAllocator(locker, prefix, ttl), nil
+}
+
+// Node leases the first free node id. Subsequent calls return the leased id or ErrNodeLost while the lease is lost
+func (a *LockNodeAllocator) Node(ctx context.Context) (int64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.done != nil {
+		if a.lock == nil {
+			return 0, ErrNodeLost
+		}
+		return a.node, nil
+	}
+
+	if err := a.acquire(ctx); err != nil {
+		return 0, err
+	}
+
+	watchCtx, cancel := context.WithCancel(context.Background())
+	a.cancel = cancel
+	a.done = make(chan struct{})
+	go a.watch(watchCtx, a.lock)
+
+	return a.node, nil
+}
+
+// Snowflake leases a node id and creates a generator that stops issuing ids while the lease is lost
+func (a *LockNodeAllocator) Snowflake(ctx context.Context, opts ...Option) (*Snowflake, error) {
+	node, err := a.Node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSnowflake(node, append(opts, optionLease{lease: a})...)
+}
+
+// leasedNode returns the node id while it's leased
+func (a *LockNodeAllocator) leasedNode() (int64, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.node, a.lock != nil
+}
+
+// acquire locks the first free node id. Must be called with mu held
+func (a *LockNodeAllocator) acquire(ctx context.Context) error {
+	for node := int64(0); node <= MaxNode; node++ {
+		lock, err := a.locker.TryAcquire(ctx, a.prefix+":"+strconv.FormatInt(node, 10), a.ttl)
+		if errors.Is(err, infralock.ErrNotAcquired) {
+			continue
+		}
+		if err != nil {
+			return errors.Wrap(err, "unable to lease node id")
+		}
+
+		a.lock, a.node = lock, node
+		return nil
+	}
+
+	return errors.New("all node ids are leased")
+}
+
+// watch leases a node id again when the lease is lost
+func (a *LockNodeAllocator) watch(ctx context.Context, lock infralock.Lock) {
+	defer close(a.done)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-lock.Lost():
+		}
+
+		infralog.Error("snowflake node id lease is lost, ids are not issued until a node id is leased again",
+			zap.String("key", lock.Key()))
+
+		a.mu.Lock()
+		a.lock = nil
+		a.mu.Unlock()
+		_ = lock.Release(ctx)
+
+		for {
+			if err := infraretry.Sleep(ctx, a.ttl/3); err != nil {
+				return
+			}
+
+			a.mu.Lock()
+			err := a.acquire(ctx)
+			lock = a.lock
+			a.mu.Unlock()
+
+			if err == nil {
+				infralog.Info("snowflake node id is leased again", zap.String("key", lock.Key()))
+				break
+			}
+			infralog.Error("unable to lease snowflake node id", zap.Error(err))
+		}
+	}
+}
+
+// Stop releases the leased node id
+func (a *LockNodeAllocator) Stop(ctx context.Context) error {
+	a.mu.Lock()
+	cancel, done := a.cancel, a.done
+	a.mu.Unlock()
+
+	if done == nil {
+		return nil
+	}
+
+	cancel()
+	<-done
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	lock := a.lock
+	a.lock, a.cancel, a.done = nil, nil, nil
+	if lock == nil {
+		return nil
+	}
+
+	return errors.Wrap(lock.Release(ctx), "unable to release node id")
+}
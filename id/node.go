@@ -0,0 +1,80 @@
+package infraid
+
+import (
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// NodeAllocator assigns a snowflake node id to the current process.
+// Two running generators with the same node id produce duplicate ids.
+type NodeAllocator interface {
+	// Node returns a node id between 0 and MaxNode
+	Node(ctx context.Context) (int64, error)
+}
+
+// StaticNode is a node id set explicitly, e.g. from config
+type StaticNode int64
+
+func (n StaticNode) Node(_ context.Context) (int64, error) {
+	if n < 0 || n > MaxNode {
+		return 0, errors.Errorf("node id should be between 0 and %d", MaxNode)
+	}
+	return int64(n), nil
+}
+
+type nodeFunc func(ctx context.Context) (int64, error)
+
+func (fn nodeFunc) Node(ctx context.Context) (int64, error) {
+	return fn(ctx)
+}
+
+// HostnameNode takes the node id from the ordinal suffix of the hostname, e.g. 3 for "sender-3".
+// It's unique for pods of a kubernetes StatefulSet.
+func HostnameNode() NodeAllocator {
+	return nodeFunc(func(ctx context.Context) (int64, error) {
+		host, err := os.Hostname()
+		if err != nil {
+			return 0, errors.Wrap(err, "unable to get hostname")
+		}
+
+		i := strings.LastIndexByte(host, '-')
+		if i < 0 {
+			return 0, errors.Errorf("no ordinal in hostname %s", host)
+		}
+
+		node, err := strconv.ParseInt(host[i+1:], 10, 64)
+		if err != nil {
+			return 0, errors.Errorf("no ordinal in hostname %s", host)
+		}
+
+		return StaticNode(node).Node(ctx)
+	})
+}
+
+// IPNode takes the node id from the lower 10 bits of the first private IPv4 address.
+// It's unique as long as all generators are within one /22 network, e.g. pods of one kubernetes node pool.
+func IPNode() NodeAllocator {
+	return nodeFunc(func(_ context.Context) (int64, error) {
+		addrs, err := net.InterfaceAddrs()
+		if err != nil {
+			return 0, errors.Wrap(err, "unable to list network addresses")
+		}
+
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			if ip := ipNet.IP.To4(); ip != nil && ip.IsPrivate() {
+				return (int64(ip[2])<<8 | int64(ip[3])) & MaxNode, nil
+			}
+		}
+
+		return 0, errors.New("no private IPv4 address found")
+	})
+}
@@ -0,0 +1,75 @@
+package infraid
+
+import (
+	"context"
+
+	infrarabbit "github.com/pushwoosh/infra/rabbit"
+	infrarequestid "github.com/pushwoosh/infra/requestid"
+)
+
+type ctxKeyType string
+
+const correlationCtxKey ctxKeyType = "correlation_id"
+
+// WithCorrelationID stores the correlation id in the context, messages published with the context get it
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationCtxKey, id)
+}
+
+// CorrelationIDFromContext returns the correlation id of the context. It's empty if not set
+func CorrelationIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(correlationCtxKey).(string)
+	return id
+}
+
+// Stamp sets a UUIDv7 message id if it's empty, and a correlation id if it's empty:
+// the one of the context, the request id of the context or the message id, in that order.
+func Stamp(ctx context.Context, msg *infrarabbit.ProducerMessage) {
+	if msg.MessageID == "" {
+		msg.MessageID = NewUUID()
+	}
+
+	if msg.CorrelationID != "" {
+		return
+	}
+
+	switch {
+	case CorrelationIDFromContext(ctx) != "":
+		msg.CorrelationID = CorrelationIDFromContext(ctx)
+	case infrarequestid.FromContext(ctx) != "":
+		msg.CorrelationID = infrarequestid.FromContext(ctx)
+	default:
+		msg.CorrelationID = msg.MessageID
+	}
+}
+
+// Producer stamps ids on every published message
+type Producer struct {
+	publisher infrarabbit.Publisher
+}
+
+var _ infrarabbit.Publisher = (*Producer)(nil)
+
+func WrapProducer(p infrarabbit.Publisher) *Producer {
+	return &Producer{publisher: p}
+}
+
+func (p *Producer) Produce(ctx context.Context, msg *infrarabbit.ProducerMessage) error {
+	Stamp(ctx, msg)
+	return p.publisher.Produce(ctx, msg)
+}
+
+// Middleware stores the correlation id of a consumed message in the handler context,
+// so messages published by the handler continue the chain:
+//
+//	router.Use(infraid.Middleware())
+func Middleware() infrarabbit.Middleware {
+	return func(next infrarabbit.HandlerFunc) infrarabbit.HandlerFunc {
+		return func(ctx context.Context, msg *infrarabbit.Message) error {
+			if id := msg.CorrelationID(); id != "" {
+				ctx = WithCorrelationID(ctx, id)
+			}
+			return next(ctx, msg)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package infraid
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	infraclock "github.com/pushwoosh/infra/clock"
+)
+
+const (
+	nodeBits     = 10
+	sequenceBits = 12
+
+	// MaxNode is the maximal snowflake node id
+	MaxNode = 1<<nodeBits - 1
+
+	maxSequence = 1<<sequenceBits - 1
+)
+
+// Epoch is the start of snowflake timestamps. 41 bits of milliseconds last until 2089
+var Epoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type Option interface {
+	apply(s *Snowflake)
+}
+
+type optionClock struct {
+	clock infraclock.Clock
+}
+
+func (opt optionClock) apply(s *Snowflake) {
+	s.clock = opt.clock
+}
+
+// WithClock sets a clock of the generator, e.g. a fake one in tests
+func WithClock(clock infraclock.Clock) Option {
+	return optionClock{clock: clock}
+}
+
+type optionLease struct {
+	lease interface{ leasedNode() (int64, bool) }
+}
+
+func (opt optionLease) apply(s *Snowflake) {
+	s.lease = opt.lease
+}
+
+// Snowflake generates 63-bit time sorted ids: 41 bits of milliseconds since Epoch, 10 bits of node id
+// and 12 bits of sequence. Ids are unique as long as every running generator has its own node id,
+// see NodeAllocator.
+//
+//	node, err := infraid.HostnameNode().Node(ctx)
+//	gen, err := infraid.NewSnowflake(node)
+//	id := gen.Next()
+//
+// Generation never blocks: if the clock goes backwards or more than 4096 ids are generated within
+// a millisecond, the generator keeps counting from its last timestamp.
+type Snowflake struct {
+	node  int64
+	clock infraclock.Clock
+	lease interface{ leasedNode() (int64, bool) }
+
+	mu       sync.Mutex
+	lastMs   int64
+	sequence int64
+}
+
+func NewSnowflake(node int64, opts ...Option) (*Snowflake, error) {
+	if node < 0 || node > MaxNode {
+		return nil, errors.Errorf("node id should be between 0 and %d", MaxNode)
+	}
+
+	s := &Snowflake{
+		node:  node,
+		clock: infraclock.Real,
+	}
+
+	for _, opt := range opts {
+		opt.apply(s)
+	}
+
+	return s, nil
+}
+
+// Next returns a new id. It panics with ErrNodeLost if the generator is created by LockNodeAllocator.Snowflake
+// and the lease is lost, use NextID to handle it
+func (s *Snowflake) Next() int64 {
+	id, err := s.NextID()
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// NextID returns a new id or ErrNodeLost while the node id lease is lost
+func (s *Snowflake) NextID() (int64, error) {
+	node := s.node
+	if s.lease != nil {
+		var leased bool
+		if node, leased = s.lease.leasedNode(); !leased {
+			return 0, ErrNodeLost
+		}
+	}
+
+	ms := s.clock.Now().Sub(Epoch).Milliseconds()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ms > s.lastMs {
+		s.lastMs = ms
+		s.sequence = 0
+	} else {
+		s.sequence++
+		if s.sequence > maxSequence {
+			s.lastMs++
+			s.sequence = 0
+		}
+	}
+
+	return s.lastMs<<(nodeBits+sequenceBits) | node<<sequenceBits | s.sequence, nil
+}
+
+// NextString returns a new id in decimal form
+func (s *Snowflake) NextString() string {
+	return strconv.FormatInt(s.Next(), 10)
+}
+
+// ParseSnowflake returns creation time, node id and sequence of a snowflake id
+func ParseSnowflake(id int64) (t time.Time, node int64, sequence int64) {
+	ms := id >> (nodeBits + sequenceBits)
+	node = id >> sequenceBits & MaxNode
+	sequence = id & maxSequence
+
+	return Epoch.Add(time.Duration(ms) * time.Millisecond), node, sequence
+}
@@ -0,0 +1,29 @@
+package infraid
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// NewUUID returns a new UUIDv7 string. UUIDv7 starts with a millisecond timestamp,
+// so ids are sorted by creation time and are friendly to database indexes.
+func NewUUID() string {
+	return uuid.Must(uuid.NewV7()).String()
+}
+
+// UUIDTime returns the creation time of a UUIDv7
+func UUIDTime(id string) (time.Time, error) {
+	u, err := uuid.Parse(id)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "invalid uuid")
+	}
+
+	if u.Version() != 7 {
+		return time.Time{}, errors.Errorf("unexpected uuid version %d", u.Version())
+	}
+
+	sec, nsec := u.Time().UnixTime()
+	return time.Unix(sec, nsec), nil
+}
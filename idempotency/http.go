@@ -0,0 +1,115 @@
+package infraidempotency
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	"go.uber.org/zap"
+)
+
+// HeaderIdempotencyKey is a request header with a client generated key
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+type httpResult struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// HTTPMiddleware replays saved responses to retried requests with the same Idempotency-Key header.
+// Requests without the header are passed as is. Concurrent requests with the same key get 409 Conflict.
+// Only responses with status codes below 500 are saved, so server errors may be retried.
+func HTTPMiddleware(store Store, cfg *Config) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(HeaderIdempotencyKey)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key = r.Method + " " + r.URL.Path + " " + key
+
+			rec := &responseRecorder{header: make(http.Header), status: http.StatusOK}
+			saved, duplicate, err := Do(r.Context(), store, cfg, key, func(_ context.Context) ([]byte, error) {
+				next.ServeHTTP(rec, r)
+				if rec.status >= http.StatusInternalServerError {
+					return nil, errServerError
+				}
+				return json.Marshal(httpResult{Status: rec.status, Header: rec.header, Body: rec.body.Bytes()})
+			})
+
+			switch {
+			case errors.Is(err, ErrInProgress):
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			case errors.Is(err, errServerError):
+				rec.writeTo(w)
+				return
+			case err != nil && !rec.written:
+				infralog.ErrorCtx(r.Context(), "idempotency store error", zap.String("key", key), zap.Error(err))
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			case err != nil:
+				// the request is processed, only the result was not saved
+				infralog.ErrorCtx(r.Context(), "idempotency store error", zap.String("key", key), zap.Error(err))
+			}
+
+			if !duplicate {
+				rec.writeTo(w)
+				return
+			}
+
+			var result httpResult
+			if err = json.Unmarshal(saved, &result); err != nil {
+				infralog.ErrorCtx(r.Context(), "invalid saved idempotent response", zap.String("key", key), zap.Error(err))
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+
+			for k, v := range result.Header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(result.Status)
+			_, _ = w.Write(result.Body)
+		})
+	}
+}
+
+var errServerError = errors.New("server error")
+
+// responseRecorder buffers the response until it's saved
+type responseRecorder struct {
+	header  http.Header
+	status  int
+	body    bytes.Buffer
+	written bool
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.written {
+		r.status = status
+		r.written = true
+	}
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	r.written = true
+	return r.body.Write(p)
+}
+
+func (r *responseRecorder) writeTo(w http.ResponseWriter) {
+	for k, v := range r.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(r.status)
+	_, _ = w.Write(r.body.Bytes())
+}
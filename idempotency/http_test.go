@@ -0,0 +1,97 @@
+package infraidempotency
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memoryStore struct {
+	mu      sync.Mutex
+	records map[string]Record
+}
+
+func (s *memoryStore) CheckAndLock(_ context.Context, key string, _ time.Duration) (Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if record, ok := s.records[key]; ok {
+		return record, nil
+	}
+	s.records[key] = Record{Status: StatusInProgress, Token: key}
+	return Record{Status: StatusNew, Token: key}, nil
+}
+
+func (s *memoryStore) MarkProcessed(_ context.Context, key, token string, result []byte, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if record := s.records[key]; record.Status != StatusInProgress || record.Token != token {
+		return ErrLockLost
+	}
+	s.records[key] = Record{Status: StatusProcessed, Result: result}
+	return nil
+}
+
+func (s *memoryStore) Unlock(_ context.Context, key, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.records[key].Token == token {
+		delete(s.records, key)
+	}
+	return nil
+}
+
+func TestHTTPMiddlewareReplaysResponse(t *testing.T) {
+	var calls int
+	handler := HTTPMiddleware(&memoryStore{records: map[string]Record{}}, DefaultConfig())(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Header().Set("X-Payment", "42")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte("created"))
+		}))
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/payments", nil)
+		req.Header.Set(HeaderIdempotencyKey, "abc")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusCreated || rec.Body.String() != "created" || rec.Header().Get("X-Payment") != "42" {
+			t.Fatalf("attempt %d: unexpected response %d %q %v", i, rec.Code, rec.Body.String(), rec.Header())
+		}
+	}
+
+	if calls != 1 {
+		t.Fatalf("expected handler to be called once, got %d", calls)
+	}
+}
+
+func TestHTTPMiddlewareRetriesServerErrors(t *testing.T) {
+	var calls int
+	handler := HTTPMiddleware(&memoryStore{records: map[string]Record{}}, DefaultConfig())(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/payments", nil)
+		req.Header.Set(HeaderIdempotencyKey, "abc")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Fatalf("unexpected status %d", rec.Code)
+		}
+	}
+
+	if calls != 2 {
+		t.Fatalf("expected handler to be called twice, got %d", calls)
+	}
+}
@@ -0,0 +1,125 @@
+package infraidempotency
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrInProgress is returned when the same key is being processed by someone else
+var ErrInProgress = errors.New("request with the same idempotency key is in progress")
+
+// ErrLockLost is returned by MarkProcessed when the lock expired and was taken by someone else,
+// the result is not saved then
+var ErrLockLost = errors.New("idempotency key lock is lost")
+
+type Status int
+
+const (
+	// StatusNew means the key is seen for the first time and it's locked by the caller
+	StatusNew Status = iota
+	// StatusInProgress means the key is locked by someone else
+	StatusInProgress
+	// StatusProcessed means the key was processed, Record.Result holds the saved result
+	StatusProcessed
+)
+
+type Record struct {
+	Status Status
+	Result []byte
+	// Token identifies the lock taken by the caller for StatusNew, pass it to MarkProcessed or Unlock
+	Token string
+}
+
+// Store keeps idempotency keys
+type Store interface {
+	// CheckAndLock locks the key for ttl if it's not known yet,
+	// otherwise it returns the key status and the saved result
+	CheckAndLock(ctx context.Context, key string, ttl time.Duration) (Record, error)
+
+	// MarkProcessed saves the result of a key locked with token and keeps it for ttl.
+	// ErrLockLost is returned if the lock expired and was taken by someone else, i.e. its token is different
+	MarkProcessed(ctx context.Context, key, token string, result []byte, ttl time.Duration) error
+
+	// Unlock removes the lock of an unprocessed key, so it can be processed again.
+	// The lock is kept if it expired and was taken by someone else, i.e. its token is different
+	Unlock(ctx context.Context, key, token string) error
+}
+
+type Config struct {
+	// LockTTL is the max processing time. The key is unlocked after that to allow retries
+	LockTTL time.Duration `mapstructure:"lock_ttl"`
+
+	// TTL is how long processed keys are remembered
+	TTL time.Duration `mapstructure:"ttl"`
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		LockTTL: time.Minute,
+		TTL:     24 * time.Hour,
+	}
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty idempotency config")
+	}
+
+	if c.LockTTL <= 0 || c.TTL <= 0 {
+		return errors.New("lock_ttl and ttl should be greater than zero")
+	}
+
+	return nil
+}
+
+// Do calls fn once per key and saves its result. Duplicates get the saved result without calling fn,
+// the second returned value is true for them.
+// ErrInProgress is returned if the key is being processed concurrently.
+// The key is unlocked if fn fails, so the next attempt calls fn again.
+func Do(ctx context.Context, store Store, cfg *Config, key string, fn func(ctx context.Context) ([]byte, error)) ([]byte, bool, error) {
+	initMetrics()
+
+	record, err := store.CheckAndLock(ctx, key, cfg.LockTTL)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch record.Status {
+	case StatusProcessed:
+		metrics.KeysCounter.WithLabelValues("duplicate").Inc()
+		return record.Result, true, nil
+	case StatusInProgress:
+		metrics.KeysCounter.WithLabelValues("in_progress").Inc()
+		return nil, false, ErrInProgress
+	}
+
+	metrics.KeysCounter.WithLabelValues("new").Inc()
+
+	result, err := fn(ctx)
+	if err != nil {
+		if unlockErr := store.Unlock(context.WithoutCancel(ctx), key, record.Token); unlockErr != nil {
+			return nil, false, errors.Wrapf(err, "unable to unlock key: %s", unlockErr)
+		}
+		return nil, false, err
+	}
+
+	if err = store.MarkProcessed(context.WithoutCancel(ctx), key, record.Token, result, cfg.TTL); err != nil {
+		return result, false, errors.Wrap(err, "unable to mark key as processed")
+	}
+
+	return result, false, nil
+}
+
+// newToken returns a random lock token
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "unable to generate lock token")
+	}
+
+	return hex.EncodeToString(b), nil
+}
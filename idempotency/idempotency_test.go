@@ -0,0 +1,27 @@
+package infraidempotency
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestDoLockLost(t *testing.T) {
+	store := &memoryStore{records: map[string]Record{}}
+
+	result, _, err := Do(context.Background(), store, DefaultConfig(), "key", func(context.Context) ([]byte, error) {
+		// the lock expired while processing and was taken by another caller
+		store.records["key"] = Record{Status: StatusInProgress, Token: "other"}
+		return []byte("result"), nil
+	})
+	if !errors.Is(err, ErrLockLost) {
+		t.Fatalf("expected ErrLockLost, got %v", err)
+	}
+	if string(result) != "result" {
+		t.Fatalf("expected the result returned, got %q", result)
+	}
+	if record := store.records["key"]; record.Status != StatusInProgress || record.Token != "other" {
+		t.Fatalf("expected the lock of the other caller kept, got %+v", record)
+	}
+}
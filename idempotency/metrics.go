@@ -0,0 +1,23 @@
+package infraidempotency
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	KeysCounter *prometheus.CounterVec
+}
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.KeysCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "idempotency_keys_total",
+			Help: "The total number of checked idempotency keys by result: new, duplicate or in_progress",
+		}, []string{"result"})
+
+		prometheus.MustRegister(metrics.KeysCounter)
+	})
+}
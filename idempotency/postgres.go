@@ -0,0 +1,120 @@
+package infraidempotency
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	postgresLocked    = "locked"
+	postgresProcessed = "processed"
+)
+
+// PostgresStore keeps keys in a postgres table. Expired keys are deleted with Cleanup
+type PostgresStore struct {
+	db    *sql.DB
+	table string
+}
+
+var _ Store = (*PostgresStore)(nil)
+
+func NewPostgresStore(db *sql.DB, table string) *PostgresStore {
+	return &PostgresStore{db: db, table: table}
+}
+
+// Schema returns DDL of the keys table
+func (s *PostgresStore) Schema() string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s (
+	key        TEXT PRIMARY KEY,
+	status     TEXT NOT NULL,
+	token      TEXT,
+	result     BYTEA,
+	expires_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS %[1]s_expires_at_idx ON %[1]s (expires_at);`, s.table)
+}
+
+// Migrate creates the keys table if it doesn't exist
+func (s *PostgresStore) Migrate(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, s.Schema())
+	return errors.Wrap(err, "unable to create idempotency table")
+}
+
+func (s *PostgresStore) CheckAndLock(ctx context.Context, key string, ttl time.Duration) (Record, error) {
+	token, err := newToken()
+	if err != nil {
+		return Record{}, err
+	}
+
+	// expired keys are taken over as new ones
+	query := fmt.Sprintf(`INSERT INTO %[1]s (key, status, token, expires_at) VALUES ($1, $2, $3, now() + make_interval(secs => $4))
+		ON CONFLICT (key) DO UPDATE SET status = EXCLUDED.status, token = EXCLUDED.token, result = NULL, expires_at = EXCLUDED.expires_at
+		WHERE %[1]s.expires_at < now()
+		RETURNING key`, s.table)
+
+	var locked string
+	err = s.db.QueryRowContext(ctx, query, key, postgresLocked, token, ttl.Seconds()).Scan(&locked)
+	if err == nil {
+		return Record{Status: StatusNew, Token: token}, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return Record{}, errors.Wrap(err, "unable to lock key")
+	}
+
+	var (
+		status string
+		result []byte
+	)
+	err = s.db.QueryRowContext(ctx, fmt.Sprintf(`SELECT status, result FROM %s WHERE key = $1`, s.table), key).Scan(&status, &result)
+	if errors.Is(err, sql.ErrNoRows) {
+		// the key has just been unlocked, let the caller retry later
+		return Record{Status: StatusInProgress}, nil
+	}
+	if err != nil {
+		return Record{}, errors.Wrap(err, "unable to get key")
+	}
+
+	if status == postgresProcessed {
+		return Record{Status: StatusProcessed, Result: result}, nil
+	}
+
+	return Record{Status: StatusInProgress}, nil
+}
+
+func (s *PostgresStore) MarkProcessed(ctx context.Context, key, token string, result []byte, ttl time.Duration) error {
+	query := fmt.Sprintf(`UPDATE %s SET status = $2, result = $3, expires_at = now() + make_interval(secs => $4)
+		WHERE key = $1 AND status = $5 AND token = $6`, s.table)
+	res, err := s.db.ExecContext(ctx, query, key, postgresProcessed, result, ttl.Seconds(), postgresLocked, token)
+	if err != nil {
+		return errors.Wrap(err, "unable to save result")
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "unable to save result")
+	}
+	if n == 0 {
+		return ErrLockLost
+	}
+	return nil
+}
+
+func (s *PostgresStore) Unlock(ctx context.Context, key, token string) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE key = $1 AND status = $2 AND token = $3`, s.table)
+	_, err := s.db.ExecContext(ctx, query, key, postgresLocked, token)
+	return errors.Wrap(err, "unable to unlock key")
+}
+
+// Cleanup deletes expired keys and returns their number
+func (s *PostgresStore) Cleanup(ctx context.Context) (int64, error) {
+	res, err := s.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE expires_at < now()`, s.table))
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to cleanup idempotency keys")
+	}
+
+	return res.RowsAffected()
+}
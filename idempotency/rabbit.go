@@ -0,0 +1,44 @@
+package infraidempotency
+
+import (
+	"context"
+
+	infrarabbit "github.com/pushwoosh/infra/rabbit"
+)
+
+// RabbitHandler processes a message. The caller acks the message on nil error and nacks it otherwise
+type RabbitHandler func(ctx context.Context, msg *infrarabbit.Message) error
+
+// RabbitKeyFunc returns an idempotency key of a message. Messages with empty key are not deduplicated
+type RabbitKeyFunc func(msg *infrarabbit.Message) string
+
+// MessageIDKey uses message id as idempotency key
+func MessageIDKey(msg *infrarabbit.Message) string {
+	return msg.MessageID()
+}
+
+// RabbitMiddleware skips already processed messages. Messages that are being processed
+// by another consumer return ErrInProgress, so they are requeued:
+//
+//	handler := infraidempotency.RabbitMiddleware(store, cfg, infraidempotency.MessageIDKey, process)
+//	for msg := range consumer.Consume() {
+//		if err := handler(ctx, msg); err != nil {
+//			_ = msg.Nack()
+//			continue
+//		}
+//		_ = msg.Ack()
+//	}
+func RabbitMiddleware(store Store, cfg *Config, keyFunc RabbitKeyFunc, next RabbitHandler) RabbitHandler {
+	return func(ctx context.Context, msg *infrarabbit.Message) error {
+		key := keyFunc(msg)
+		if key == "" {
+			return next(ctx, msg)
+		}
+
+		_, _, err := Do(ctx, store, cfg, key, func(ctx context.Context) ([]byte, error) {
+			return nil, next(ctx, msg)
+		})
+
+		return err
+	}
+}
@@ -0,0 +1,76 @@
+package infraidempotency
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	infraredis "github.com/pushwoosh/infra/redis"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisLocked    = "L"
+	redisProcessed = "P"
+)
+
+// RedisStore keeps keys in redis with native expiration
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+var _ Store = (*RedisStore)(nil)
+
+func NewRedisStore(client redis.UniversalClient, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+func (s *RedisStore) CheckAndLock(ctx context.Context, key string, ttl time.Duration) (Record, error) {
+	key = s.prefix + key
+
+	token, err := newToken()
+	if err != nil {
+		return Record{}, err
+	}
+
+	ok, err := s.client.SetNX(ctx, key, redisLocked+token, ttl).Result()
+	if err != nil {
+		return Record{}, errors.Wrap(err, "unable to lock key")
+	}
+	if ok {
+		return Record{Status: StatusNew, Token: token}, nil
+	}
+
+	value, err := s.client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		// the lock has just expired, let the caller retry later
+		return Record{Status: StatusInProgress}, nil
+	}
+	if err != nil {
+		return Record{}, errors.Wrap(err, "unable to get key")
+	}
+
+	if strings.HasPrefix(value, redisLocked) {
+		return Record{Status: StatusInProgress}, nil
+	}
+
+	return Record{Status: StatusProcessed, Result: []byte(value[len(redisProcessed):])}, nil
+}
+
+func (s *RedisStore) MarkProcessed(ctx context.Context, key, token string, result []byte, ttl time.Duration) error {
+	ok, err := infraredis.SetIfEqual(ctx, s.client, s.prefix+key, redisLocked+token, redisProcessed+string(result), ttl)
+	if err != nil {
+		return errors.Wrap(err, "unable to save result")
+	}
+	if !ok {
+		return ErrLockLost
+	}
+	return nil
+}
+
+func (s *RedisStore) Unlock(ctx context.Context, key, token string) error {
+	_, err := infraredis.DeleteIfEqual(ctx, s.client, s.prefix+key, redisLocked+token)
+	return errors.Wrap(err, "unable to unlock key")
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/pushwoosh/infra/log"
 	"github.com/pushwoosh/infra/operator"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 // Info server is an internal http server that is used by k8s and prometheus to
@@ -71,8 +72,9 @@ func (s *Info) Start(_ context.Context) error {
 	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
 
 	s.srv = &http.Server{
-		Addr:    s.cfg.Listen,
-		Handler: mux,
+		Addr:     s.cfg.Listen,
+		Handler:  mux,
+		ErrorLog: infralog.NewStdLogger(zapcore.WarnLevel, "infoserver"),
 	}
 
 	go func() {
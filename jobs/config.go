@@ -0,0 +1,176 @@
+package infrajobs
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+	infrarabbit "github.com/pushwoosh/infra/rabbit"
+)
+
+const (
+	defaultExchange    = "jobs"
+	defaultConcurrency = 1
+	defaultMaxRetries  = 3
+)
+
+var defaultDelayLevels = []time.Duration{
+	time.Second,
+	5 * time.Second,
+	30 * time.Second,
+	time.Minute,
+	5 * time.Minute,
+	30 * time.Minute,
+	time.Hour,
+}
+
+type Config struct {
+	// Work queue name. Delay and dead queues are named after it: "<queue>.delay.<level>" and "<queue>.dead"
+	Queue string `mapstructure:"queue"`
+
+	// Direct exchange all job queues are bound to. optional, default: jobs
+	Exchange string `mapstructure:"exchange"`
+
+	// Max job priority, priorities are disabled if zero. optional
+	// Changing it requires recreating the work queue
+	MaxPriority uint8 `mapstructure:"max_priority"`
+
+	// Number of jobs processed in parallel by a worker. optional, default: 1
+	Concurrency int `mapstructure:"concurrency"`
+
+	// Number of retries of a failed job before it's moved to the dead queue. optional, default: 3
+	MaxRetries int `mapstructure:"max_retries"`
+
+	// Max job processing duration, the job context is cancelled after it. optional
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// Available job delays. A delay queue with fixed TTL is created for every level,
+	// requested delays and retry backoffs are rounded up to the nearest level.
+	// optional, default: 1s, 5s, 30s, 1m, 5m, 30m, 1h
+	DelayLevels []time.Duration `mapstructure:"delay_levels"`
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty jobs config")
+	}
+
+	if c.Queue == "" {
+		return errors.New("queue is mandatory")
+	}
+
+	if c.Concurrency < 0 {
+		return errors.New("concurrency should be greater than or equal to zero")
+	}
+
+	if c.MaxRetries < 0 {
+		return errors.New("max_retries should be greater than or equal to zero")
+	}
+
+	for _, level := range c.DelayLevels {
+		if level < time.Millisecond {
+			return errors.Errorf("invalid delay level %s: should be at least 1ms", level)
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) GetExchange() string {
+	if c.Exchange == "" {
+		return defaultExchange
+	}
+	return c.Exchange
+}
+
+func (c *Config) GetConcurrency() int {
+	if c.Concurrency == 0 {
+		return defaultConcurrency
+	}
+	return c.Concurrency
+}
+
+func (c *Config) GetMaxRetries() int {
+	if c.MaxRetries == 0 {
+		return defaultMaxRetries
+	}
+	return c.MaxRetries
+}
+
+// GetDelayLevels returns sorted delay levels without duplicates
+func (c *Config) GetDelayLevels() []time.Duration {
+	if len(c.DelayLevels) == 0 {
+		return defaultDelayLevels
+	}
+
+	levels := slices.Clone(c.DelayLevels)
+	slices.Sort(levels)
+	return slices.Compact(levels)
+}
+
+func (c *Config) deadQueue() string {
+	return c.Queue + ".dead"
+}
+
+func (c *Config) delayQueue(level time.Duration) string {
+	return fmt.Sprintf("%s.delay.%s", c.Queue, level)
+}
+
+// delayLevel rounds delay up to the nearest level. The longest level is used for longer delays
+func (c *Config) delayLevel(delay time.Duration) time.Duration {
+	levels := c.GetDelayLevels()
+	for _, level := range levels {
+		if level >= delay {
+			return level
+		}
+	}
+	return levels[len(levels)-1]
+}
+
+// bindings declares the work queue, a delay queue per level dead-lettering back to the work queue,
+// and the dead queue. Queue arguments must match the ones used by rabbit consumer.
+func (c *Config) bindings() []*infrarabbit.BindConfig {
+	exchange := c.GetExchange()
+
+	workArgs := map[string]interface{}{}
+	if c.MaxPriority > 0 {
+		workArgs[infrarabbit.PriorityProperty] = int(c.MaxPriority)
+	}
+
+	bindings := []*infrarabbit.BindConfig{
+		{
+			Exchange:        exchange,
+			RoutingKey:      c.Queue,
+			Queue:           c.Queue,
+			ExchangeKind:    infrarabbit.KindDirect,
+			ExchangeDurable: true,
+			QueueArgs:       workArgs,
+		},
+		{
+			Exchange:        exchange,
+			RoutingKey:      c.deadQueue(),
+			Queue:           c.deadQueue(),
+			ExchangeKind:    infrarabbit.KindDirect,
+			ExchangeDurable: true,
+		},
+	}
+
+	for _, level := range c.GetDelayLevels() {
+		queue := c.delayQueue(level)
+		bindings = append(bindings, &infrarabbit.BindConfig{
+			Exchange:        exchange,
+			RoutingKey:      queue,
+			Queue:           queue,
+			ExchangeKind:    infrarabbit.KindDirect,
+			ExchangeDurable: true,
+			QueueArgs: map[string]interface{}{
+				"x-message-ttl":             level.Milliseconds(),
+				"x-dead-letter-exchange":    exchange,
+				"x-dead-letter-routing-key": c.Queue,
+			},
+		})
+	}
+
+	return bindings
+}
@@ -0,0 +1,64 @@
+package infrajobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConfig_delayLevel(t *testing.T) {
+	cfg := &Config{
+		Queue:       "emails",
+		DelayLevels: []time.Duration{time.Minute, time.Second, 10 * time.Second, time.Second},
+	}
+
+	tests := []struct {
+		delay time.Duration
+		want  time.Duration
+	}{
+		{time.Millisecond, time.Second},
+		{time.Second, time.Second},
+		{2 * time.Second, 10 * time.Second},
+		{time.Minute, time.Minute},
+		{time.Hour, time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := cfg.delayLevel(tt.delay); got != tt.want {
+			t.Errorf("delayLevel(%s) = %s, want %s", tt.delay, got, tt.want)
+		}
+	}
+}
+
+func TestConfig_bindings(t *testing.T) {
+	cfg := &Config{
+		Queue:       "emails",
+		MaxPriority: 5,
+		DelayLevels: []time.Duration{time.Second, time.Minute},
+	}
+
+	bindings := cfg.bindings()
+	if len(bindings) != 4 {
+		t.Fatalf("expected 4 bindings, got %d", len(bindings))
+	}
+
+	queues := map[string]map[string]interface{}{}
+	for _, b := range bindings {
+		if b.Exchange != defaultExchange || b.RoutingKey != b.Queue {
+			t.Errorf("unexpected binding %s -> %s", b.RoutingKey, b.Queue)
+		}
+		queues[b.Queue] = b.QueueArgs
+	}
+
+	if queues["emails"]["x-max-priority"] != 5 {
+		t.Errorf("expected priority on work queue, got %v", queues["emails"])
+	}
+
+	delay := queues["emails.delay.1m0s"]
+	if delay["x-message-ttl"] != int64(60000) || delay["x-dead-letter-routing-key"] != "emails" {
+		t.Errorf("unexpected delay queue args %v", delay)
+	}
+
+	if _, ok := queues["emails.dead"]; !ok {
+		t.Error("expected dead queue")
+	}
+}
@@ -0,0 +1,222 @@
+package infrajobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	infrarabbit "github.com/pushwoosh/infra/rabbit"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// DeadJob is a job moved to the dead queue
+type DeadJob struct {
+	Job
+	Error    string
+	FailedAt time.Time
+}
+
+// Counts are numbers of ready messages in job queues
+type Counts struct {
+	Pending int
+	Delayed int
+	Dead    int
+}
+
+// Inspector gives access to job queues state and dead jobs.
+// Every call opens a dedicated connection, so it's meant for admin endpoints and tools, not for hot paths.
+type Inspector struct {
+	container  *infrarabbit.Container
+	connection string
+	cfg        *Config
+}
+
+func NewInspector(container *infrarabbit.Container, connection string, cfg *Config) (*Inspector, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &Inspector{
+		container:  container,
+		connection: connection,
+		cfg:        cfg,
+	}, nil
+}
+
+// Counts returns queue lengths. Jobs held unacked by workers are not counted
+func (i *Inspector) Counts(_ context.Context) (Counts, error) {
+	var counts Counts
+
+	err := i.withChannel(func(ch *amqp.Channel) error {
+		pending, err := ch.QueueDeclarePassive(i.cfg.Queue, false, false, false, false, nil)
+		if err != nil {
+			return errors.Wrap(err, "unable to inspect work queue")
+		}
+		counts.Pending = pending.Messages
+
+		dead, err := ch.QueueDeclarePassive(i.cfg.deadQueue(), false, false, false, false, nil)
+		if err != nil {
+			return errors.Wrap(err, "unable to inspect dead queue")
+		}
+		counts.Dead = dead.Messages
+
+		for _, level := range i.cfg.GetDelayLevels() {
+			delayed, err := ch.QueueDeclarePassive(i.cfg.delayQueue(level), false, false, false, false, nil)
+			if err != nil {
+				return errors.Wrapf(err, "unable to inspect delay queue %s", level)
+			}
+			counts.Delayed += delayed.Messages
+		}
+
+		return nil
+	})
+
+	return counts, err
+}
+
+// DeadJobs returns up to limit oldest dead jobs without removing them from the dead queue
+func (i *Inspector) DeadJobs(_ context.Context, limit int) ([]*DeadJob, error) {
+	var jobs []*DeadJob
+
+	err := i.withChannel(func(ch *amqp.Channel) error {
+		// fetched messages stay unacked and are returned to the queue when the channel is closed
+		for len(jobs) < limit {
+			msg, ok, err := ch.Get(i.cfg.deadQueue(), false)
+			if err != nil {
+				return errors.Wrap(err, "unable to get dead job")
+			}
+			if !ok {
+				return nil
+			}
+
+			jobs = append(jobs, decodeDeadJob(&msg))
+		}
+		return nil
+	})
+
+	return jobs, err
+}
+
+// Requeue moves a dead job back to the work queue with a fresh attempts counter.
+// It returns false if there is no dead job with the id.
+func (i *Inspector) Requeue(_ context.Context, id string) (bool, error) {
+	var found bool
+
+	err := i.withChannel(func(ch *amqp.Channel) error {
+		for {
+			msg, ok, err := ch.Get(i.cfg.deadQueue(), false)
+			if err != nil {
+				return errors.Wrap(err, "unable to get dead job")
+			}
+			if !ok {
+				return nil
+			}
+
+			if msg.MessageId != id {
+				continue
+			}
+
+			if err = i.requeue(ch, &msg); err != nil {
+				return err
+			}
+			found = true
+			return nil
+		}
+	})
+
+	return found, err
+}
+
+// RequeueAll moves all dead jobs back to the work queue and returns their number
+func (i *Inspector) RequeueAll(_ context.Context) (int, error) {
+	var n int
+
+	err := i.withChannel(func(ch *amqp.Channel) error {
+		for {
+			msg, ok, err := ch.Get(i.cfg.deadQueue(), false)
+			if err != nil {
+				return errors.Wrap(err, "unable to get dead job")
+			}
+			if !ok {
+				return nil
+			}
+
+			if err = i.requeue(ch, &msg); err != nil {
+				return err
+			}
+			n++
+		}
+	})
+
+	return n, err
+}
+
+// Purge deletes all dead jobs and returns their number
+func (i *Inspector) Purge(_ context.Context) (int, error) {
+	var n int
+
+	err := i.withChannel(func(ch *amqp.Channel) error {
+		var err error
+		n, err = ch.QueuePurge(i.cfg.deadQueue(), false)
+		return errors.Wrap(err, "unable to purge dead queue")
+	})
+
+	return n, err
+}
+
+func (i *Inspector) requeue(ch *amqp.Channel, msg *amqp.Delivery) error {
+	headers := amqp.Table{}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers[headerAttempt] = int64(1)
+	delete(headers, headerError)
+	delete(headers, headerFailedAt)
+
+	err := ch.Publish(i.cfg.GetExchange(), i.cfg.Queue, false, false, amqp.Publishing{
+		Body:      msg.Body,
+		Priority:  msg.Priority,
+		Timestamp: time.Now(),
+		MessageId: msg.MessageId,
+		Headers:   headers,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "unable to requeue job %s", msg.MessageId)
+	}
+
+	if err = msg.Ack(false); err != nil {
+		return errors.Wrapf(err, "unable to remove job %s from dead queue", msg.MessageId)
+	}
+
+	return nil
+}
+
+func (i *Inspector) withChannel(fn func(ch *amqp.Channel) error) error {
+	conn, err := i.container.Dial(i.connection)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return errors.Wrap(err, "unable to create rabbitmq channel")
+	}
+
+	return fn(ch)
+}
+
+func decodeDeadJob(msg *amqp.Delivery) *DeadJob {
+	dead := &DeadJob{
+		Job:   *decodeJob(msg.MessageId, msg.Priority, msg.Headers, msg.Body),
+		Error: headerString(msg.Headers, headerError),
+	}
+
+	if ms := headerInt(msg.Headers, headerFailedAt); ms > 0 {
+		dead.FailedAt = time.UnixMilli(ms).UTC()
+	}
+
+	return dead
+}
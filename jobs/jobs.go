@@ -0,0 +1,304 @@
+package infrajobs
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	infrarabbit "github.com/pushwoosh/infra/rabbit"
+)
+
+// job metadata is kept in message headers, message body is the encoded payload
+const (
+	headerType       = "x-job-type"
+	headerAttempt    = "x-job-attempt"
+	headerMaxRetries = "x-job-max-retries"
+	headerUnique     = "x-job-unique"
+	headerEnqueuedAt = "x-job-enqueued-at"
+	headerError      = "x-job-error"
+	headerFailedAt   = "x-job-failed-at"
+)
+
+// ErrDuplicate is returned by Enqueue when a job with the same unique key is already enqueued or running
+var ErrDuplicate = errors.New("duplicate job")
+
+// Job is a delivered job passed to handlers and middlewares
+type Job struct {
+	ID         string
+	Type       string
+	Attempt    int // starts from 1
+	MaxRetries int
+	Priority   uint8
+	EnqueuedAt time.Time
+	Payload    []byte
+
+	uniqueKey string
+}
+
+// JobType binds a job name with a payload type:
+//
+//	var SendEmail = infrajobs.NewJobType[SendEmailPayload]("send_email")
+//
+//	id, err := infrajobs.Enqueue(ctx, client, SendEmail, SendEmailPayload{To: "a@b.c"}, infrajobs.WithDelay(time.Minute))
+//	infrajobs.Handle(worker, SendEmail, func(ctx context.Context, job *infrajobs.Job, p SendEmailPayload) error { ... })
+type JobType[T any] struct {
+	Name string
+}
+
+func NewJobType[T any](name string) JobType[T] {
+	return JobType[T]{Name: name}
+}
+
+type enqueueOptions struct {
+	id         string
+	delay      time.Duration
+	maxRetries int
+	priority   uint8
+	uniqueKey  string
+	uniqueTTL  time.Duration
+}
+
+type EnqueueOption interface {
+	apply(opts *enqueueOptions)
+}
+
+type optionDelay time.Duration
+
+func (opt optionDelay) apply(opts *enqueueOptions) {
+	opts.delay = time.Duration(opt)
+}
+
+// WithDelay postpones the job. The delay is rounded up to the nearest configured delay level
+func WithDelay(delay time.Duration) EnqueueOption {
+	return optionDelay(delay)
+}
+
+type optionMaxRetries int
+
+func (opt optionMaxRetries) apply(opts *enqueueOptions) {
+	opts.maxRetries = int(opt)
+}
+
+// WithMaxRetries overrides the number of retries from config. Zero disables retries
+func WithMaxRetries(n int) EnqueueOption {
+	return optionMaxRetries(n)
+}
+
+type optionPriority uint8
+
+func (opt optionPriority) apply(opts *enqueueOptions) {
+	opts.priority = uint8(opt)
+}
+
+// WithPriority sets job priority. It has effect only if config MaxPriority is set
+func WithPriority(priority uint8) EnqueueOption {
+	return optionPriority(priority)
+}
+
+type optionUnique struct {
+	key string
+	ttl time.Duration
+}
+
+func (opt optionUnique) apply(opts *enqueueOptions) {
+	opts.uniqueKey = opt.key
+	opts.uniqueTTL = opt.ttl
+}
+
+// WithUnique rejects the job with ErrDuplicate while another job with the same key is enqueued or running.
+// The key is released when the job succeeds or is moved to the dead queue, ttl limits how long it's held
+// if a worker never finishes the job. Requires a unique store, see WithUniqueStore.
+func WithUnique(key string, ttl time.Duration) EnqueueOption {
+	return optionUnique{key: key, ttl: ttl}
+}
+
+type optionID string
+
+func (opt optionID) apply(opts *enqueueOptions) {
+	opts.id = string(opt)
+}
+
+// WithID sets job id instead of a generated one
+func WithID(id string) EnqueueOption {
+	return optionID(id)
+}
+
+// Client enqueues jobs to a work queue. Job queues are declared on creation
+type Client struct {
+	cfg      *Config
+	producer *infrarabbit.Producer
+	unique   UniqueStore
+}
+
+type ClientOption interface {
+	applyClient(c *Client)
+}
+
+func NewClient(container *infrarabbit.Container, connection string, cfg *Config, opts ...ClientOption) (*Client, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	initMetrics()
+
+	producer, err := container.CreateProducer(&infrarabbit.ProducerConfig{
+		ConnectionName: connection,
+		Bindings:       cfg.bindings(),
+		Confirm:        true,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create jobs producer")
+	}
+
+	c := &Client{
+		cfg:      cfg,
+		producer: producer,
+	}
+
+	for _, opt := range opts {
+		opt.applyClient(c)
+	}
+
+	return c, nil
+}
+
+// Enqueue encodes payload and publishes a job. It returns job id
+func Enqueue[T any](ctx context.Context, c *Client, jobType JobType[T], payload T, opts ...EnqueueOption) (string, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to encode %s job", jobType.Name)
+	}
+
+	return c.Enqueue(ctx, jobType.Name, body, opts...)
+}
+
+// Enqueue publishes a job with an already encoded payload. It returns job id
+func (c *Client) Enqueue(ctx context.Context, jobType string, payload []byte, opts ...EnqueueOption) (string, error) {
+	options := enqueueOptions{
+		id:         uuid.NewString(),
+		maxRetries: c.cfg.GetMaxRetries(),
+	}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+
+	if options.uniqueKey != "" {
+		if c.unique == nil {
+			return "", errors.New("unique store is not configured")
+		}
+
+		ok, err := c.unique.Acquire(ctx, options.uniqueKey, options.id, options.uniqueTTL)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			metrics.EnqueuedCounter.WithLabelValues(c.cfg.Queue, jobType, statusDuplicate).Inc()
+			return "", errors.Wrapf(ErrDuplicate, "%s job with key %s", jobType, options.uniqueKey)
+		}
+	}
+
+	job := &Job{
+		ID:         options.id,
+		Type:       jobType,
+		Attempt:    1,
+		MaxRetries: options.maxRetries,
+		Priority:   options.priority,
+		EnqueuedAt: time.Now().UTC(),
+		Payload:    payload,
+		uniqueKey:  options.uniqueKey,
+	}
+
+	routingKey := c.cfg.Queue
+	if options.delay > 0 {
+		routingKey = c.cfg.delayQueue(c.cfg.delayLevel(options.delay))
+	}
+
+	err := publish(ctx, c.producer, c.cfg.GetExchange(), routingKey, job, nil)
+	metrics.EnqueuedCounter.WithLabelValues(c.cfg.Queue, jobType, status(err)).Inc()
+
+	if err != nil && options.uniqueKey != "" {
+		_ = c.unique.Release(context.WithoutCancel(ctx), options.uniqueKey, options.id)
+	}
+
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to enqueue %s job", jobType)
+	}
+
+	return job.ID, nil
+}
+
+// Close closes the underlying producer
+func (c *Client) Close() error {
+	return c.producer.Close()
+}
+
+func publish(
+	ctx context.Context,
+	producer *infrarabbit.Producer,
+	exchange string,
+	routingKey string,
+	job *Job,
+	extra map[string]interface{},
+) error {
+	headers := map[string]interface{}{
+		headerType:       job.Type,
+		headerAttempt:    int64(job.Attempt),
+		headerMaxRetries: int64(job.MaxRetries),
+		headerEnqueuedAt: job.EnqueuedAt.UnixMilli(),
+	}
+	if job.uniqueKey != "" {
+		headers[headerUnique] = job.uniqueKey
+	}
+	for k, v := range extra {
+		headers[k] = v
+	}
+
+	return producer.Produce(ctx, &infrarabbit.ProducerMessage{
+		Body:       job.Payload,
+		Exchange:   exchange,
+		RoutingKey: routingKey,
+		Priority:   job.Priority,
+		MessageID:  job.ID,
+		Headers:    headers,
+	})
+}
+
+func decodeJob(id string, priority uint8, headers map[string]interface{}, body []byte) *Job {
+	job := &Job{
+		ID:         id,
+		Type:       headerString(headers, headerType),
+		Attempt:    int(headerInt(headers, headerAttempt)),
+		MaxRetries: int(headerInt(headers, headerMaxRetries)),
+		Priority:   priority,
+		Payload:    body,
+		uniqueKey:  headerString(headers, headerUnique),
+	}
+
+	if ms := headerInt(headers, headerEnqueuedAt); ms > 0 {
+		job.EnqueuedAt = time.UnixMilli(ms).UTC()
+	}
+	if job.Attempt < 1 {
+		job.Attempt = 1
+	}
+
+	return job
+}
+
+func headerString(headers map[string]interface{}, key string) string {
+	s, _ := headers[key].(string)
+	return s
+}
+
+func headerInt(headers map[string]interface{}, key string) int64 {
+	switch v := headers[key].(type) {
+	case int64:
+		return v
+	case int32:
+		return int64(v)
+	case int:
+		return int64(v)
+	}
+	return 0
+}
@@ -0,0 +1,71 @@
+package infrajobs
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	inframetrics "github.com/pushwoosh/infra/metrics"
+)
+
+const (
+	statusOK        = "ok"
+	statusError     = "error"
+	statusDuplicate = "duplicate"
+	statusRetry     = "retry"
+	statusDead      = "dead"
+)
+
+var metrics struct {
+	EnqueuedCounter   *inframetrics.CounterVec
+	ProcessedCounter  *inframetrics.CounterVec
+	DurationHistogram *inframetrics.HistogramVec
+	InProgressGauge   *inframetrics.GaugeVec
+	LatencyHistogram  *inframetrics.HistogramVec
+}
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.EnqueuedCounter = inframetrics.NewCounterVec(prometheus.CounterOpts{
+			Name: "jobs_enqueued_total",
+			Help: "The total number of enqueued jobs",
+		}, []string{"queue", "type", "status"})
+
+		metrics.ProcessedCounter = inframetrics.NewCounterVec(prometheus.CounterOpts{
+			Name: "jobs_processed_total",
+			Help: "The total number of processed jobs by result: ok, retry or dead",
+		}, []string{"queue", "type", "status"})
+
+		metrics.DurationHistogram = inframetrics.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "jobs_duration",
+			Help:    "The job processing duration",
+			Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		}, []string{"queue", "type"})
+
+		metrics.InProgressGauge = inframetrics.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "jobs_in_progress",
+			Help: "The number of jobs being processed",
+		}, []string{"queue"})
+
+		metrics.LatencyHistogram = inframetrics.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "jobs_latency",
+			Help:    "Time from enqueueing to the first processing attempt",
+			Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300, 900, 3600},
+		}, []string{"queue", "type"})
+
+		prometheus.MustRegister(
+			metrics.EnqueuedCounter,
+			metrics.ProcessedCounter,
+			metrics.DurationHistogram,
+			metrics.InProgressGauge,
+			metrics.LatencyHistogram,
+		)
+	})
+}
+
+func status(err error) string {
+	if err != nil {
+		return statusError
+	}
+	return statusOK
+}
@@ -0,0 +1,66 @@
+package infrajobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	infraredis "github.com/pushwoosh/infra/redis"
+	"github.com/redis/go-redis/v9"
+)
+
+// UniqueStore holds unique job keys. A key is owned by the job that acquired it
+type UniqueStore interface {
+	// Acquire takes the key for owner, it returns false if the key is held by another owner
+	Acquire(ctx context.Context, key, owner string, ttl time.Duration) (bool, error)
+
+	// Release frees the key if it's still held by owner
+	Release(ctx context.Context, key, owner string) error
+}
+
+type optionUniqueStore struct {
+	store UniqueStore
+}
+
+func (opt optionUniqueStore) applyClient(c *Client) {
+	c.unique = opt.store
+}
+
+func (opt optionUniqueStore) applyWorker(w *Worker) {
+	w.unique = opt.store
+}
+
+// WithUniqueStore enables unique jobs. Client and worker of a queue must use the same store
+func WithUniqueStore(store UniqueStore) interface {
+	ClientOption
+	WorkerOption
+} {
+	return optionUniqueStore{store: store}
+}
+
+// RedisUniqueStore keeps unique keys in redis with native expiration
+type RedisUniqueStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+var _ UniqueStore = (*RedisUniqueStore)(nil)
+
+func NewRedisUniqueStore(client redis.UniversalClient, prefix string) *RedisUniqueStore {
+	return &RedisUniqueStore{client: client, prefix: prefix}
+}
+
+func (s *RedisUniqueStore) Acquire(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	ok, err := s.client.SetNX(ctx, s.prefix+key, owner, ttl).Result()
+	if err != nil {
+		return false, errors.Wrap(err, "unable to acquire unique key")
+	}
+	return ok, nil
+}
+
+func (s *RedisUniqueStore) Release(ctx context.Context, key, owner string) error {
+	if _, err := infraredis.DeleteIfEqual(ctx, s.client, s.prefix+key, owner); err != nil {
+		return errors.Wrap(err, "unable to release unique key")
+	}
+	return nil
+}
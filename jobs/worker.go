@@ -0,0 +1,290 @@
+package infrajobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	infrarabbit "github.com/pushwoosh/infra/rabbit"
+	infrarecovery "github.com/pushwoosh/infra/recovery"
+	infraretry "github.com/pushwoosh/infra/retry"
+	"go.uber.org/zap"
+)
+
+// HandlerFunc processes a job. Failed jobs are retried with backoff and moved to the dead queue
+// when retries are exhausted or the error is wrapped with infraretry.Permanent.
+type HandlerFunc func(ctx context.Context, job *Job) error
+
+// Middleware wraps a job handler, e.g. with logging or tracing
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// Handle registers a typed handler for a job type. Payload decoding errors are permanent
+func Handle[T any](w *Worker, jobType JobType[T], fn func(ctx context.Context, job *Job, payload T) error) {
+	w.Handle(jobType.Name, func(ctx context.Context, job *Job) error {
+		var payload T
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return infraretry.Permanent(errors.Wrapf(err, "unable to decode %s job", job.Type))
+		}
+		return fn(ctx, job, payload)
+	})
+}
+
+type WorkerOption interface {
+	applyWorker(w *Worker)
+}
+
+// Worker consumes the work queue and runs handlers with configured concurrency.
+// A job is acked only after it's finished, retried or moved to the dead queue, so jobs are processed at least once.
+type Worker struct {
+	container  *infrarabbit.Container
+	connection string
+	cfg        *Config
+	unique     UniqueStore
+	backoff    infraretry.Exponential
+
+	mu          sync.RWMutex
+	handlers    map[string]HandlerFunc
+	middlewares []Middleware
+
+	runMu    sync.Mutex
+	producer *infrarabbit.Producer
+	consumer *infrarabbit.Consumer
+	wg       sync.WaitGroup
+}
+
+var (
+	_ infraoperator.Starter = (*Worker)(nil)
+	_ infraoperator.Stopper = (*Worker)(nil)
+)
+
+func NewWorker(container *infrarabbit.Container, connection string, cfg *Config, opts ...WorkerOption) (*Worker, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	initMetrics()
+
+	levels := cfg.GetDelayLevels()
+	w := &Worker{
+		container:  container,
+		connection: connection,
+		cfg:        cfg,
+		backoff:    infraretry.Exponential{Initial: levels[0], Max: levels[len(levels)-1]},
+		handlers:   make(map[string]HandlerFunc),
+	}
+
+	for _, opt := range opts {
+		opt.applyWorker(w)
+	}
+
+	return w, nil
+}
+
+// Use adds middlewares. Middlewares added first are called first
+func (w *Worker) Use(middlewares ...Middleware) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.middlewares = append(w.middlewares, middlewares...)
+}
+
+// Handle registers a handler for a job type. Jobs of unknown types are moved to the dead queue
+func (w *Worker) Handle(jobType string, handler HandlerFunc) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.handlers[jobType] = handler
+}
+
+// Start starts consuming jobs in background
+func (w *Worker) Start(_ context.Context) error {
+	w.runMu.Lock()
+	defer w.runMu.Unlock()
+
+	if w.consumer != nil {
+		return errors.New("worker is already started")
+	}
+
+	producer, err := w.container.CreateProducer(&infrarabbit.ProducerConfig{
+		ConnectionName: w.connection,
+		Bindings:       w.cfg.bindings(),
+		Confirm:        true,
+	})
+	if err != nil {
+		return errors.Wrap(err, "unable to create jobs producer")
+	}
+
+	concurrency := w.cfg.GetConcurrency()
+	consumer, err := w.container.CreateConsumer(&infrarabbit.ConsumerConfig{
+		ConnectionName: w.connection,
+		Queue:          w.cfg.Queue,
+		QueuePriority:  w.cfg.MaxPriority,
+		PrefetchCount:  concurrency,
+	})
+	if err != nil {
+		_ = producer.Close()
+		return errors.Wrap(err, "unable to create jobs consumer")
+	}
+
+	w.producer = producer
+	w.consumer = consumer
+
+	for i := 0; i < concurrency; i++ {
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+
+			for msg := range consumer.Consume() {
+				w.process(msg)
+			}
+		}()
+	}
+
+	return nil
+}
+
+// Stop stops consuming and waits for jobs in progress
+func (w *Worker) Stop(_ context.Context) error {
+	w.runMu.Lock()
+	defer w.runMu.Unlock()
+
+	if w.consumer == nil {
+		return nil
+	}
+
+	err := w.consumer.Close()
+	w.wg.Wait()
+
+	if closeErr := w.producer.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+
+	w.consumer = nil
+	w.producer = nil
+
+	return err
+}
+
+func (w *Worker) process(msg *infrarabbit.Message) {
+	job := decodeJob(msg.MessageID(), msg.Priority(), msg.Headers(), msg.Body())
+	queue := w.cfg.Queue
+
+	if job.Attempt == 1 && !job.EnqueuedAt.IsZero() {
+		metrics.LatencyHistogram.WithLabelValues(queue, job.Type).Observe(time.Since(job.EnqueuedAt).Seconds())
+	}
+
+	metrics.InProgressGauge.WithLabelValues(queue).Inc()
+	start := time.Now()
+	err := w.run(job)
+	metrics.DurationHistogram.WithLabelValues(queue, job.Type).Observe(time.Since(start).Seconds())
+	metrics.InProgressGauge.WithLabelValues(queue).Dec()
+
+	// the job outcome must be saved even if the worker is stopping
+	ctx := context.Background()
+
+	if err == nil {
+		metrics.ProcessedCounter.WithLabelValues(queue, job.Type, statusOK).Inc()
+		w.release(ctx, job)
+		_ = msg.Ack()
+		return
+	}
+
+	if !infraretry.IsPermanent(err) && job.Attempt <= job.MaxRetries {
+		if retryErr := w.retry(ctx, job); retryErr != nil {
+			infralog.Error("unable to retry job", zap.String("queue", queue), zap.String("id", job.ID), zap.Error(retryErr))
+			_ = msg.Nack()
+			return
+		}
+
+		infralog.Warn("job failed, retrying",
+			zap.String("queue", queue),
+			zap.String("type", job.Type),
+			zap.String("id", job.ID),
+			zap.Int("attempt", job.Attempt),
+			zap.Error(err))
+		metrics.ProcessedCounter.WithLabelValues(queue, job.Type, statusRetry).Inc()
+		_ = msg.Ack()
+		return
+	}
+
+	if deadErr := w.bury(ctx, job, err); deadErr != nil {
+		infralog.Error("unable to move job to dead queue", zap.String("queue", queue), zap.String("id", job.ID), zap.Error(deadErr))
+		_ = msg.Nack()
+		return
+	}
+
+	infralog.Error("job failed, moved to dead queue",
+		zap.String("queue", queue),
+		zap.String("type", job.Type),
+		zap.String("id", job.ID),
+		zap.Int("attempt", job.Attempt),
+		zap.Error(err))
+	metrics.ProcessedCounter.WithLabelValues(queue, job.Type, statusDead).Inc()
+	w.release(ctx, job)
+	_ = msg.Ack()
+}
+
+func (w *Worker) run(job *Job) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			infrarecovery.Report(context.Background(), "jobs", rec,
+				zap.String("queue", w.cfg.Queue),
+				zap.String("type", job.Type),
+				zap.String("id", job.ID))
+			err = errors.Errorf("job panic: %v", rec)
+		}
+	}()
+
+	w.mu.RLock()
+	handler, ok := w.handlers[job.Type]
+	middlewares := w.middlewares
+	w.mu.RUnlock()
+
+	if !ok {
+		return infraretry.Permanent(errors.Errorf("no handler for %s job", job.Type))
+	}
+
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+
+	ctx := context.Background()
+	if w.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, w.cfg.Timeout)
+		defer cancel()
+	}
+
+	return handler(ctx, job)
+}
+
+func (w *Worker) retry(ctx context.Context, job *Job) error {
+	delay := w.cfg.delayLevel(w.backoff.Delay(job.Attempt))
+
+	next := *job
+	next.Attempt++
+
+	return publish(ctx, w.producer, w.cfg.GetExchange(), w.cfg.delayQueue(delay), &next, nil)
+}
+
+func (w *Worker) bury(ctx context.Context, job *Job, cause error) error {
+	return publish(ctx, w.producer, w.cfg.GetExchange(), w.cfg.deadQueue(), job, map[string]interface{}{
+		headerError:    fmt.Sprintf("%.1024s", cause.Error()),
+		headerFailedAt: time.Now().UnixMilli(),
+	})
+}
+
+func (w *Worker) release(ctx context.Context, job *Job) {
+	if job.uniqueKey == "" || w.unique == nil {
+		return
+	}
+
+	if err := w.unique.Release(ctx, job.uniqueKey, job.ID); err != nil {
+		infralog.Error("unable to release unique job key", zap.String("queue", w.cfg.Queue), zap.String("id", job.ID), zap.Error(err))
+	}
+}
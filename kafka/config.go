@@ -1,7 +1,11 @@
 package infrakafka
 
 import (
+	"strings"
+	"time"
+
 	"github.com/pkg/errors"
+	"github.com/segmentio/kafka-go"
 )
 
 type ConnectionsConfig map[string]*ConnectionConfig
@@ -33,6 +37,11 @@ func (c *ConnectionsConfig) Validate() error {
 	return nil
 }
 
+// Brokers returns a list of broker addresses
+func (c *ConnectionConfig) Brokers() []string {
+	return strings.Split(c.Address, ",")
+}
+
 func (c *ConnectionConfig) Validate() error {
 	if c == nil {
 		return errors.New("empty connection config")
@@ -52,3 +61,65 @@ func (c *ConnectionConfig) Validate() error {
 
 	return nil
 }
+
+type CommitStrategy string
+
+const (
+	// CommitStrategyMessage commits offset after every successfully handled message
+	CommitStrategyMessage CommitStrategy = "message"
+
+	// CommitStrategyInterval commits offsets periodically and when partitions are revoked
+	CommitStrategyInterval CommitStrategy = "interval"
+)
+
+type ConsumerConfig struct {
+	ConnectionName string
+	GroupID        string
+	Topics         []string
+	CommitStrategy CommitStrategy                       // optional, default CommitStrategyInterval
+	CommitInterval time.Duration                        // optional
+	RetryDelay     time.Duration                        // optional, delay before failed message is handled again
+	SkipFailed     bool                                 // optional, commit failed messages instead of retrying them
+	OnAssigned     func(assignments map[string][]int32) // optional, called when partitions are assigned
+	OnRevoked      func(assignments map[string][]int32) // optional, called when all revoked partitions are processed
+}
+
+type ProducerConfig struct {
+	ConnectionName string
+	Topic          string                                    // optional, default topic for messages without topic
+	BatchSize      int                                       // optional
+	BatchTimeout   time.Duration                             // optional
+	OnDelivery     func(messages []kafka.Message, err error) // optional, delivery report
+}
+
+func (c *ConsumerConfig) Validate() error {
+	if c == nil {
+		return errors.New("empty consumer config")
+	}
+
+	if c.GroupID == "" {
+		return errors.New("group id is mandatory")
+	}
+
+	if len(c.Topics) == 0 {
+		return errors.New("at least one topic is required")
+	}
+
+	if c.CommitStrategy == "" {
+		c.CommitStrategy = CommitStrategyInterval
+	}
+
+	if c.CommitStrategy != CommitStrategyMessage && c.CommitStrategy != CommitStrategyInterval {
+		return errors.Errorf("invalid commit strategy \"%s\"", c.CommitStrategy)
+	}
+
+	if c.CommitInterval <= 0 {
+		c.CommitInterval = defaultCommitInterval
+	}
+
+	if c.RetryDelay <= 0 {
+		c.RetryDelay = defaultRetryDelay
+	}
+
+	return nil
+}
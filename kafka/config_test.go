@@ -0,0 +1,46 @@
+package infrakafka
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectionConfig(t *testing.T) {
+	var cfg *ConnectionConfig
+	require.Error(t, cfg.Validate())
+
+	cfg = &ConnectionConfig{}
+	require.ErrorContains(t, cfg.Validate(), "address")
+
+	cfg = &ConnectionConfig{Address: "kafka-1:9092,kafka-2:9092"}
+	require.NoError(t, cfg.Validate())
+	require.Equal(t, StartOffsetFirst, cfg.StartOffset)
+	require.Equal(t, []string{"kafka-1:9092", "kafka-2:9092"}, cfg.Brokers())
+
+	cfg.StartOffset = "newest"
+	require.ErrorContains(t, cfg.Validate(), "start_offset")
+
+	connections := ConnectionsConfig{"main": {Address: "kafka:9092"}, "events": {}}
+	require.ErrorContains(t, connections.Validate(), "events")
+}
+
+func TestConsumerConfig(t *testing.T) {
+	var cfg *ConsumerConfig
+	require.Error(t, cfg.Validate())
+
+	cfg = &ConsumerConfig{Topics: []string{"events"}}
+	require.ErrorContains(t, cfg.Validate(), "group id")
+
+	cfg = &ConsumerConfig{GroupID: "sender"}
+	require.ErrorContains(t, cfg.Validate(), "topic")
+
+	cfg = &ConsumerConfig{GroupID: "sender", Topics: []string{"events"}}
+	require.NoError(t, cfg.Validate())
+	require.Equal(t, CommitStrategyInterval, cfg.CommitStrategy)
+	require.Equal(t, defaultCommitInterval, cfg.CommitInterval)
+	require.Equal(t, defaultRetryDelay, cfg.RetryDelay)
+
+	cfg.CommitStrategy = "batch"
+	require.ErrorContains(t, cfg.Validate(), "commit strategy")
+}
@@ -0,0 +1,326 @@
+package infrakafka
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	inframessaging "github.com/pushwoosh/infra/messaging"
+	infraretry "github.com/pushwoosh/infra/retry"
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultCommitInterval = 5 * time.Second
+	defaultRetryDelay     = time.Second
+	nextGenerationDelay   = time.Second
+)
+
+// fetchBackoff delays fetches after errors, e.g. while the partition leader is unavailable
+var fetchBackoff = infraretry.Exponential{
+	Initial: 100 * time.Millisecond,
+	Max:     10 * time.Second,
+	Jitter:  0.2,
+}
+
+// Handler processes a consumed message.
+// Returned error means the message is not processed and should be handled again.
+type Handler = inframessaging.Handler[*Message]
+
+// Consumer is a consumer group member that passes messages of assigned partitions to a handler.
+// Every partition is processed in a separate goroutine, messages within a partition are processed in order.
+type Consumer struct {
+	brokers []string
+	cfg     *ConsumerConfig
+	group   *kafka.ConsumerGroup
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	closed chan struct{}
+}
+
+// CreateGroupConsumer creates a new consumer group member by a connection name.
+// Call Consume to start processing messages.
+func (cont *Container) CreateGroupConsumer(consumerCfg *ConsumerConfig) (*Consumer, error) {
+	if err := consumerCfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	cont.mu.RLock()
+	cfg, ok := cont.cfg[consumerCfg.ConnectionName]
+	cont.mu.RUnlock()
+	if !ok {
+		return nil, errors.Errorf("invalid connection name: \"%s\"", consumerCfg.ConnectionName)
+	}
+
+	var offset int64
+	if cfg.StartOffset == StartOffsetFirst {
+		offset = kafka.FirstOffset
+	} else {
+		offset = kafka.LastOffset
+	}
+
+	brokers := cfg.Brokers()
+	group, err := kafka.NewConsumerGroup(kafka.ConsumerGroupConfig{
+		ID:          consumerCfg.GroupID,
+		Brokers:     brokers,
+		Topics:      consumerCfg.Topics,
+		StartOffset: offset,
+		Logger:      kafka.LoggerFunc(getLogFunc()),
+		ErrorLogger: kafka.LoggerFunc(getLogErrorFunc()),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "kafka.NewConsumerGroup")
+	}
+
+	initMetrics()
+
+	return &Consumer{
+		brokers: brokers,
+		cfg:     consumerCfg,
+		group:   group,
+	}, nil
+}
+
+// Consume starts processing messages in background
+func (c *Consumer) Consume(handler Handler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	c.closed = make(chan struct{})
+
+	go c.run(ctx, handler)
+}
+
+// Close stops processing, waits for messages in progress and leaves the consumer group
+func (c *Consumer) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cancel != nil {
+		c.cancel()
+		<-c.closed
+	}
+
+	return c.group.Close()
+}
+
+func (c *Consumer) run(ctx context.Context, handler Handler) {
+	defer close(c.closed)
+
+	for {
+		gen, err := c.group.Next(ctx)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, kafka.ErrGroupClosed) {
+				return
+			}
+
+			infralog.Error("kafka consumer group error", zap.String("group", c.cfg.GroupID), zap.Error(err))
+			time.Sleep(nextGenerationDelay)
+			continue
+		}
+
+		c.startGeneration(ctx, gen, handler)
+	}
+}
+
+func (c *Consumer) startGeneration(ctx context.Context, gen *kafka.Generation, handler Handler) {
+	assignments := make(map[string][]int32, len(gen.Assignments))
+	for topic, partitions := range gen.Assignments {
+		for _, p := range partitions {
+			assignments[topic] = append(assignments[topic], int32(p.ID))
+		}
+	}
+
+	if c.cfg.OnAssigned != nil {
+		c.cfg.OnAssigned(assignments)
+	}
+
+	offsets := newOffsetTracker(gen)
+	partitionsWg := sync.WaitGroup{}
+
+	for topic, partitions := range gen.Assignments {
+		for _, p := range partitions {
+			topic, p := topic, p
+			partitionsWg.Add(1)
+			gen.Start(func(genCtx context.Context) {
+				defer partitionsWg.Done()
+				c.consumePartition(ctx, genCtx, topic, p, offsets, handler)
+			})
+		}
+	}
+
+	// commit offsets periodically and call revoke hook when generation ends
+	gen.Start(func(genCtx context.Context) {
+		ticker := time.NewTicker(c.cfg.CommitInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				offsets.commit()
+			case <-genCtx.Done():
+				partitionsWg.Wait()
+				offsets.commit()
+
+				if c.cfg.OnRevoked != nil {
+					c.cfg.OnRevoked(assignments)
+				}
+				return
+			}
+		}
+	})
+}
+
+func (c *Consumer) consumePartition(
+	ctx context.Context,
+	genCtx context.Context,
+	topic string,
+	assignment kafka.PartitionAssignment,
+	offsets *offsetTracker,
+	handler Handler,
+) {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     c.brokers,
+		Topic:       topic,
+		Partition:   assignment.ID,
+		Logger:      kafka.LoggerFunc(getLogFunc()),
+		ErrorLogger: kafka.LoggerFunc(getLogErrorFunc()),
+	})
+	defer func() { _ = reader.Close() }()
+
+	if err := reader.SetOffset(assignment.Offset); err != nil {
+		infralog.Error("kafka consumer: unable to set offset", zap.String("topic", topic), zap.Error(err))
+		return
+	}
+
+	// stop reading when either generation ends or consumer is closed
+	readCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-genCtx.Done():
+			cancel()
+		case <-readCtx.Done():
+		}
+	}()
+
+	lag := metrics.ConsumerLagGauge.WithLabelValues(c.cfg.GroupID, topic, strconv.Itoa(assignment.ID))
+
+	failures := 0
+	for {
+		msg, err := reader.FetchMessage(readCtx)
+		if err != nil {
+			if readCtx.Err() != nil {
+				return
+			}
+
+			failures++
+			infralog.Error("kafka consumer: fetch message",
+				zap.String("topic", topic),
+				zap.Int("failures", failures),
+				zap.Error(err))
+
+			select {
+			case <-readCtx.Done():
+				return
+			case <-time.After(fetchBackoff.Delay(failures)):
+			}
+			continue
+		}
+		failures = 0
+
+		if !c.handle(readCtx, &msg, handler) {
+			return
+		}
+
+		lag.Set(float64(msg.HighWaterMark - msg.Offset - 1))
+
+		offsets.mark(topic, msg.Partition, msg.Offset+1)
+		if c.cfg.CommitStrategy == CommitStrategyMessage {
+			offsets.commit()
+		}
+	}
+}
+
+// handle runs handler until it succeeds. Returns false if consuming was interrupted.
+func (c *Consumer) handle(ctx context.Context, msg *kafka.Message, handler Handler) bool {
+	for {
+		start := time.Now()
+		err := handler(extractContext(ctx, msg), &Message{msg: msg})
+		metrics.HandleDurationHistogram.WithLabelValues(c.cfg.GroupID, msg.Topic).Observe(time.Since(start).Seconds())
+
+		if err == nil {
+			metrics.ConsumedMessagesCounter.WithLabelValues(c.cfg.GroupID, msg.Topic, "success").Inc()
+			return true
+		}
+
+		metrics.ConsumedMessagesCounter.WithLabelValues(c.cfg.GroupID, msg.Topic, "error").Inc()
+		infralog.Error("kafka consumer: handle message",
+			zap.String("group", c.cfg.GroupID),
+			zap.String("topic", msg.Topic),
+			zap.Int("partition", msg.Partition),
+			zap.Int64("offset", msg.Offset),
+			zap.Error(err))
+
+		if c.cfg.SkipFailed {
+			return true
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(c.cfg.RetryDelay):
+		}
+	}
+}
+
+// offsetTracker collects handled offsets of a generation and commits them
+type offsetTracker struct {
+	mu      sync.Mutex
+	gen     *kafka.Generation
+	pending map[string]map[int]int64
+}
+
+func newOffsetTracker(gen *kafka.Generation) *offsetTracker {
+	return &offsetTracker{
+		gen:     gen,
+		pending: make(map[string]map[int]int64),
+	}
+}
+
+func (t *offsetTracker) mark(topic string, partition int, offset int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.pending[topic] == nil {
+		t.pending[topic] = make(map[int]int64)
+	}
+	t.pending[topic][partition] = offset
+}
+
+func (t *offsetTracker) commit() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.pending) == 0 {
+		return
+	}
+
+	if err := t.gen.CommitOffsets(t.pending); err != nil {
+		infralog.Error("kafka consumer: commit offsets", zap.String("group", t.gen.GroupID), zap.Error(err))
+		return
+	}
+
+	t.pending = make(map[string]map[int]int64)
+}
@@ -0,0 +1,59 @@
+package infrakafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsumerHandle(t *testing.T) {
+	initMetrics()
+
+	c := &Consumer{cfg: &ConsumerConfig{GroupID: "sender", RetryDelay: time.Millisecond}}
+	msg := &kafka.Message{Topic: "events", Value: []byte("body")}
+
+	// failed messages are handled again until success
+	calls := 0
+	ok := c.handle(context.Background(), msg, func(_ context.Context, m *Message) error {
+		require.Equal(t, []byte("body"), m.Body())
+		calls++
+		if calls < 3 {
+			return errors.New("temporary")
+		}
+		return nil
+	})
+	require.True(t, ok)
+	require.Equal(t, 3, calls)
+
+	// retries stop when consuming is interrupted
+	ctx, cancel := context.WithCancel(context.Background())
+	ok = c.handle(ctx, msg, func(context.Context, *Message) error {
+		cancel()
+		return errors.New("temporary")
+	})
+	require.False(t, ok)
+
+	// failed messages are skipped
+	c.cfg.SkipFailed = true
+	calls = 0
+	ok = c.handle(context.Background(), msg, func(context.Context, *Message) error {
+		calls++
+		return errors.New("permanent")
+	})
+	require.True(t, ok)
+	require.Equal(t, 1, calls)
+}
+
+func TestCreateGroupConsumer(t *testing.T) {
+	cont := NewContainer()
+
+	_, err := cont.CreateGroupConsumer(&ConsumerConfig{Topics: []string{"events"}})
+	require.ErrorContains(t, err, "group id")
+
+	_, err = cont.CreateGroupConsumer(&ConsumerConfig{ConnectionName: "missing", GroupID: "sender", Topics: []string{"events"}})
+	require.ErrorContains(t, err, "missing")
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	infrakafka "github.com/pushwoosh/infra/kafka"
+)
+
+func main() {
+	container := infrakafka.NewContainer()
+	err := container.AddConnection("name", &infrakafka.ConnectionConfig{
+		Address:     "kafka-host:9092",
+		StartOffset: infrakafka.StartOffsetFirst,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	producer, err := container.CreateBatchProducer(&infrakafka.ProducerConfig{
+		ConnectionName: "name",
+		Topic:          "test-topic",
+	})
+	if err != nil {
+		panic(err)
+	}
+	defer func() { _ = producer.Close() }()
+
+	if err = producer.Produce(context.Background(), &infrakafka.ProducerMessage{
+		Key:  []byte("key"),
+		Body: []byte("hello"),
+	}); err != nil {
+		panic(err)
+	}
+
+	consumer, err := container.CreateGroupConsumer(&infrakafka.ConsumerConfig{
+		ConnectionName: "name",
+		GroupID:        "test-group",
+		Topics:         []string{"test-topic"},
+		CommitStrategy: infrakafka.CommitStrategyInterval,
+		OnAssigned: func(assignments map[string][]int32) {
+			fmt.Printf("assigned: %v\n", assignments)
+		},
+		OnRevoked: func(assignments map[string][]int32) {
+			fmt.Printf("revoked: %v\n", assignments)
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	consumer.Consume(func(ctx context.Context, msg *infrakafka.Message) error {
+		fmt.Printf("consumed from %s/%d at %d: %s\n", msg.Topic(), msg.Partition(), msg.Offset(), msg.Body())
+		return nil
+	})
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	<-interrupt
+
+	_ = consumer.Close()
+}
@@ -0,0 +1,58 @@
+package infrakafka
+
+import (
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Message is a message received by a group consumer
+type Message struct {
+	msg *kafka.Message
+}
+
+func (m *Message) Topic() string {
+	return m.msg.Topic
+}
+
+func (m *Message) Partition() int {
+	return m.msg.Partition
+}
+
+func (m *Message) Offset() int64 {
+	return m.msg.Offset
+}
+
+func (m *Message) Key() []byte {
+	return m.msg.Key
+}
+
+func (m *Message) Body() []byte {
+	return m.msg.Value
+}
+
+func (m *Message) Time() time.Time {
+	return m.msg.Time
+}
+
+// Header returns the value of the first header with the given key
+func (m *Message) Header(key string) []byte {
+	for i := range m.msg.Headers {
+		if m.msg.Headers[i].Key == key {
+			return m.msg.Headers[i].Value
+		}
+	}
+	return nil
+}
+
+// Raw returns underlying kafka-go message
+func (m *Message) Raw() *kafka.Message {
+	return m.msg
+}
+
+type ProducerMessage struct {
+	Topic   string // optional if producer has default topic
+	Key     []byte
+	Body    []byte
+	Headers map[string]string
+}
@@ -0,0 +1,36 @@
+package infrakafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessage(t *testing.T) {
+	now := time.Now()
+	raw := &kafka.Message{
+		Topic:     "events",
+		Partition: 3,
+		Offset:    42,
+		Key:       []byte("key"),
+		Value:     []byte("body"),
+		Time:      now,
+		Headers: []kafka.Header{
+			{Key: "type", Value: []byte("first")},
+			{Key: "type", Value: []byte("second")},
+		},
+	}
+
+	msg := &Message{msg: raw}
+	require.Equal(t, "events", msg.Topic())
+	require.Equal(t, 3, msg.Partition())
+	require.Equal(t, int64(42), msg.Offset())
+	require.Equal(t, []byte("key"), msg.Key())
+	require.Equal(t, []byte("body"), msg.Body())
+	require.Equal(t, now, msg.Time())
+	require.Equal(t, []byte("first"), msg.Header("type"))
+	require.Nil(t, msg.Header("missing"))
+	require.Same(t, raw, msg.Raw())
+}
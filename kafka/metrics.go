@@ -0,0 +1,48 @@
+package infrakafka
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	inframetrics "github.com/pushwoosh/infra/metrics"
+)
+
+var metrics struct {
+	ConsumedMessagesCounter *inframetrics.CounterVec
+	HandleDurationHistogram *inframetrics.HistogramVec
+	ConsumerLagGauge        *inframetrics.GaugeVec
+	ProducedMessagesCounter *inframetrics.CounterVec
+}
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.ConsumedMessagesCounter = inframetrics.NewCounterVec(prometheus.CounterOpts{
+			Name: "kafka_consumer_messages_counter",
+			Help: "The total number of handled messages",
+		}, []string{"group", "topic", "status"})
+
+		metrics.HandleDurationHistogram = inframetrics.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "kafka_consumer_handle_duration",
+			Help:    "The message handler duration",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"group", "topic"})
+
+		metrics.ConsumerLagGauge = inframetrics.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "kafka_consumer_lag",
+			Help: "The number of messages in partition after the last handled one",
+		}, []string{"group", "topic", "partition"})
+
+		metrics.ProducedMessagesCounter = inframetrics.NewCounterVec(prometheus.CounterOpts{
+			Name: "kafka_producer_messages_counter",
+			Help: "The total number of produced messages",
+		}, []string{"topic", "status"})
+
+		prometheus.MustRegister(
+			metrics.ConsumedMessagesCounter,
+			metrics.HandleDurationHistogram,
+			metrics.ConsumerLagGauge,
+			metrics.ProducedMessagesCounter,
+		)
+	})
+}
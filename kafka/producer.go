@@ -0,0 +1,120 @@
+package infrakafka
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/kafka-go"
+)
+
+// Producer is an asynchronous batching producer.
+// Delivery results are reported to ProducerConfig.OnDelivery.
+type Producer struct {
+	cfg    *ProducerConfig
+	writer *kafka.Writer
+
+	mu       sync.RWMutex
+	isClosed bool
+}
+
+// CreateBatchProducer creates a new batching kafka producer by a connection name
+func (cont *Container) CreateBatchProducer(producerCfg *ProducerConfig) (*Producer, error) {
+	if producerCfg == nil {
+		return nil, errors.New("config is required")
+	}
+
+	cont.mu.RLock()
+	cfg, ok := cont.cfg[producerCfg.ConnectionName]
+	cont.mu.RUnlock()
+	if !ok {
+		return nil, errors.Errorf("invalid connection name: \"%s\"", producerCfg.ConnectionName)
+	}
+
+	initMetrics()
+
+	p := &Producer{cfg: producerCfg}
+	p.writer = &kafka.Writer{
+		Addr:                   kafka.TCP(cfg.Brokers()...),
+		Balancer:               &kafka.Hash{},
+		AllowAutoTopicCreation: true,
+		Async:                  true,
+		BatchSize:              producerCfg.BatchSize,
+		BatchTimeout:           producerCfg.BatchTimeout,
+		MaxAttempts:            1000,
+		Completion:             p.completion,
+		Logger:                 kafka.LoggerFunc(getLogFunc()),
+		ErrorLogger:            kafka.LoggerFunc(getLogErrorFunc()),
+	}
+
+	return p, nil
+}
+
+// Produce enqueues messages for sending. Trace context from ctx is put into message headers.
+func (p *Producer) Produce(ctx context.Context, msgs ...*ProducerMessage) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.isClosed {
+		return errors.New("kafka producer is closed")
+	}
+
+	kafkaMsgs := make([]kafka.Message, 0, len(msgs))
+	for _, msg := range msgs {
+		if msg == nil {
+			return errors.New("message is nil")
+		}
+
+		topic := msg.Topic
+		if topic == "" {
+			topic = p.cfg.Topic
+		}
+		if topic == "" {
+			return errors.New("message topic is empty")
+		}
+
+		kafkaMsg := kafka.Message{
+			Topic: topic,
+			Key:   msg.Key,
+			Value: msg.Body,
+			Time:  time.Now(),
+		}
+		for k, v := range msg.Headers {
+			kafkaMsg.Headers = append(kafkaMsg.Headers, kafka.Header{Key: k, Value: []byte(v)})
+		}
+		injectContext(ctx, &kafkaMsg)
+
+		kafkaMsgs = append(kafkaMsgs, kafkaMsg)
+	}
+
+	return p.writer.WriteMessages(ctx, kafkaMsgs...)
+}
+
+// Close flushes pending messages and closes the producer
+func (p *Producer) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.isClosed {
+		return nil
+	}
+	p.isClosed = true
+
+	return p.writer.Close()
+}
+
+func (p *Producer) completion(messages []kafka.Message, err error) {
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+
+	for i := range messages {
+		metrics.ProducedMessagesCounter.WithLabelValues(messages[i].Topic, status).Inc()
+	}
+
+	if p.cfg.OnDelivery != nil {
+		p.cfg.OnDelivery(messages, err)
+	}
+}
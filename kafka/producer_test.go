@@ -0,0 +1,48 @@
+package infrakafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProduceInvalid(t *testing.T) {
+	p := &Producer{cfg: &ProducerConfig{}}
+	ctx := context.Background()
+
+	require.ErrorContains(t, p.Produce(ctx, nil), "nil")
+	require.ErrorContains(t, p.Produce(ctx, &ProducerMessage{Body: []byte("body")}), "topic")
+
+	p.isClosed = true
+	require.ErrorContains(t, p.Produce(ctx, &ProducerMessage{Topic: "events"}), "closed")
+	require.NoError(t, p.Close())
+}
+
+func TestProducerCompletion(t *testing.T) {
+	initMetrics()
+
+	var delivered []kafka.Message
+	var deliveryErr error
+	p := &Producer{cfg: &ProducerConfig{OnDelivery: func(messages []kafka.Message, err error) {
+		delivered, deliveryErr = messages, err
+	}}}
+
+	messages := []kafka.Message{{Topic: "events"}, {Topic: "events"}}
+	p.completion(messages, errors.New("leader not available"))
+
+	require.Len(t, delivered, 2)
+	require.EqualError(t, deliveryErr, "leader not available")
+}
+
+func TestCreateBatchProducer(t *testing.T) {
+	cont := NewContainer()
+
+	_, err := cont.CreateBatchProducer(nil)
+	require.Error(t, err)
+
+	_, err = cont.CreateBatchProducer(&ProducerConfig{ConnectionName: "missing"})
+	require.ErrorContains(t, err, "missing")
+}
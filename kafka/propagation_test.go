@@ -0,0 +1,40 @@
+package infrakafka
+
+import (
+	"context"
+	"testing"
+
+	infratesttracing "github.com/pushwoosh/infra/test/tracing"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestHeadersCarrier(t *testing.T) {
+	headers := []kafka.Header{{Key: "type", Value: []byte("event")}}
+	carrier := headersCarrier{headers: &headers}
+
+	carrier.Set("traceparent", "first")
+	carrier.Set("traceparent", "second")
+
+	require.Equal(t, "second", carrier.Get("traceparent"))
+	require.Equal(t, "event", carrier.Get("type"))
+	require.Empty(t, carrier.Get("missing"))
+	require.Equal(t, []string{"type", "traceparent"}, carrier.Keys())
+}
+
+func TestPropagation(t *testing.T) {
+	infratesttracing.Record(t)
+
+	ctx, span := otel.Tracer("test").Start(context.Background(), "produce")
+	defer span.End()
+
+	msg := &kafka.Message{}
+	injectContext(ctx, msg)
+
+	extracted := trace.SpanContextFromContext(extractContext(context.Background(), msg))
+	require.True(t, extracted.IsRemote())
+	require.Equal(t, span.SpanContext().TraceID(), extracted.TraceID())
+	require.Equal(t, span.SpanContext().SpanID(), extracted.SpanID())
+}
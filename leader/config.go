@@ -0,0 +1,57 @@
+package infraleader
+
+import (
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultLeaseDuration = 15 * time.Second
+	DefaultRetryPeriod   = 2 * time.Second
+)
+
+type Config struct {
+	// Identity is a unique id of the candidate. optional, hostname (pod name in k8s) is used by default
+	Identity string `mapstructure:"identity"`
+	// LeaseDuration is the time other candidates wait before taking over leadership of a dead leader
+	LeaseDuration time.Duration `mapstructure:"lease_duration"`
+	// RetryPeriod is the interval of leadership acquisition and renewal attempts
+	RetryPeriod time.Duration `mapstructure:"retry_period"`
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		LeaseDuration: DefaultLeaseDuration,
+		RetryPeriod:   DefaultRetryPeriod,
+	}
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if c.LeaseDuration <= 0 || c.RetryPeriod <= 0 {
+		return errors.New("lease duration and retry period must be greater than zero")
+	}
+
+	if c.RetryPeriod >= c.LeaseDuration {
+		return errors.New("retry period must be less than lease duration")
+	}
+
+	return nil
+}
+
+func (c *Config) identity() (string, error) {
+	if c.Identity != "" {
+		return c.Identity, nil
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", errors.Wrap(err, "unable to get hostname for leader identity")
+	}
+	return hostname, nil
+}
@@ -0,0 +1,255 @@
+package infraleader
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+	// k8s MicroTime format
+	microTimeLayout = "2006-01-02T15:04:05.000000Z07:00"
+)
+
+// KubernetesBackend keeps the lease in a coordination.k8s.io/v1 Lease object.
+// It talks to the API server directly with in-cluster service account credentials,
+// the account must be allowed to get, create and update leases in the namespace.
+type KubernetesBackend struct {
+	client    *http.Client
+	host      string
+	tokenPath string
+	namespace string
+	name      string
+}
+
+var _ Backend = (*KubernetesBackend)(nil)
+
+type lease struct {
+	APIVersion string        `json:"apiVersion"`
+	Kind       string        `json:"kind"`
+	Metadata   leaseMetadata `json:"metadata"`
+	Spec       leaseSpec     `json:"spec"`
+}
+
+type leaseMetadata struct {
+	Name            string `json:"name"`
+	Namespace       string `json:"namespace"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+type leaseSpec struct {
+	HolderIdentity       *string `json:"holderIdentity,omitempty"`
+	LeaseDurationSeconds *int32  `json:"leaseDurationSeconds,omitempty"`
+	AcquireTime          *string `json:"acquireTime,omitempty"`
+	RenewTime            *string `json:"renewTime,omitempty"`
+	LeaseTransitions     *int32  `json:"leaseTransitions,omitempty"`
+}
+
+// NewKubernetesBackend creates a backend for a Lease with the given name.
+// The pod namespace is used if namespace is empty.
+func NewKubernetesBackend(namespace, name string) (*KubernetesBackend, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, errors.New("not running in kubernetes cluster")
+	}
+
+	caData, err := os.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read cluster ca")
+	}
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(caData) {
+		return nil, errors.New("invalid cluster ca")
+	}
+
+	if namespace == "" {
+		ns, err := os.ReadFile(serviceAccountDir + "/namespace")
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read pod namespace")
+		}
+		namespace = strings.TrimSpace(string(ns))
+	}
+
+	return &KubernetesBackend{
+		client: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{RootCAs: certPool, MinVersion: tls.VersionTLS12},
+			},
+		},
+		host:      "https://" + net.JoinHostPort(host, port),
+		tokenPath: serviceAccountDir + "/token",
+		namespace: namespace,
+		name:      name,
+	}, nil
+}
+
+func (b *KubernetesBackend) TryAcquireOrRenew(ctx context.Context, identity string, leaseDuration time.Duration) (bool, error) {
+	now := time.Now()
+	nowStr := now.UTC().Format(microTimeLayout)
+	durationSeconds := int32((leaseDuration + time.Second - 1) / time.Second)
+
+	current, err := b.get(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if current == nil {
+		transitions := int32(0)
+		return b.write(ctx, http.MethodPost, &lease{
+			APIVersion: "coordination.k8s.io/v1",
+			Kind:       "Lease",
+			Metadata:   leaseMetadata{Name: b.name, Namespace: b.namespace},
+			Spec: leaseSpec{
+				HolderIdentity:       &identity,
+				LeaseDurationSeconds: &durationSeconds,
+				AcquireTime:          &nowStr,
+				RenewTime:            &nowStr,
+				LeaseTransitions:     &transitions,
+			},
+		})
+	}
+
+	spec := &current.Spec
+	holder := ""
+	if spec.HolderIdentity != nil {
+		holder = *spec.HolderIdentity
+	}
+
+	if holder != identity {
+		if holder != "" && !spec.expired(now) {
+			return false, nil
+		}
+
+		transitions := int32(1)
+		if spec.LeaseTransitions != nil {
+			transitions = *spec.LeaseTransitions + 1
+		}
+		spec.HolderIdentity = &identity
+		spec.AcquireTime = &nowStr
+		spec.LeaseTransitions = &transitions
+	}
+
+	spec.LeaseDurationSeconds = &durationSeconds
+	spec.RenewTime = &nowStr
+
+	return b.write(ctx, http.MethodPut, current)
+}
+
+func (b *KubernetesBackend) Release(ctx context.Context, identity string) error {
+	current, err := b.get(ctx)
+	if err != nil || current == nil {
+		return err
+	}
+
+	if current.Spec.HolderIdentity == nil || *current.Spec.HolderIdentity != identity {
+		return nil
+	}
+
+	empty := ""
+	current.Spec.HolderIdentity = &empty
+	_, err = b.write(ctx, http.MethodPut, current)
+	return err
+}
+
+func (s *leaseSpec) expired(now time.Time) bool {
+	if s.RenewTime == nil || s.LeaseDurationSeconds == nil {
+		return true
+	}
+
+	renewTime, err := time.Parse(microTimeLayout, *s.RenewTime)
+	if err != nil {
+		return true
+	}
+
+	return renewTime.Add(time.Duration(*s.LeaseDurationSeconds) * time.Second).Before(now)
+}
+
+func (b *KubernetesBackend) url(withName bool) string {
+	url := fmt.Sprintf("%s/apis/coordination.k8s.io/v1/namespaces/%s/leases", b.host, b.namespace)
+	if withName {
+		url += "/" + b.name
+	}
+	return url
+}
+
+// get returns nil if the lease does not exist
+func (b *KubernetesBackend) get(ctx context.Context) (*lease, error) {
+	resp, err := b.do(ctx, http.MethodGet, b.url(true), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, statusError(resp)
+	}
+
+	res := &lease{}
+	if err := json.NewDecoder(resp.Body).Decode(res); err != nil {
+		return nil, errors.Wrap(err, "unable to decode lease")
+	}
+	return res, nil
+}
+
+// write creates or updates the lease. Returns false if somebody else has modified it concurrently.
+func (b *KubernetesBackend) write(ctx context.Context, method string, l *lease) (bool, error) {
+	body, err := json.Marshal(l)
+	if err != nil {
+		return false, errors.Wrap(err, "unable to encode lease")
+	}
+
+	resp, err := b.do(ctx, method, b.url(method == http.MethodPut), body)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		return true, nil
+	case http.StatusConflict:
+		return false, nil
+	default:
+		return false, statusError(resp)
+	}
+}
+
+func (b *KubernetesBackend) do(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	token, err := os.ReadFile(b.tokenPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read service account token")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return b.client.Do(req)
+}
+
+func statusError(resp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return errors.Errorf("kubernetes api: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+}
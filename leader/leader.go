@@ -0,0 +1,204 @@
+package infraleader
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	"go.uber.org/zap"
+)
+
+// Backend stores leadership lease
+type Backend interface {
+	// TryAcquireOrRenew makes identity the leader or extends its leadership for leaseDuration.
+	// Returns false if another candidate holds an unexpired lease.
+	TryAcquireOrRenew(ctx context.Context, identity string, leaseDuration time.Duration) (bool, error)
+	// Release gives up leadership if identity holds it
+	Release(ctx context.Context, identity string) error
+}
+
+// Callbacks are called on leadership changes. All callbacks are optional.
+type Callbacks struct {
+	// OnStartedLeading is called in a separate goroutine when the candidate becomes the leader.
+	// ctx is canceled when leadership is lost.
+	OnStartedLeading func(ctx context.Context)
+	// OnStoppedLeading is called when the leader loses leadership or stops
+	OnStoppedLeading func()
+}
+
+// Elector takes part in leader election:
+//
+//	backend := infraleader.NewRedisBackend(redisClient, "leader:billing")
+//	elector, err := infraleader.New(backend, infraleader.DefaultConfig(), infraleader.Callbacks{})
+//	op.AddService(ctx, elector)
+//	...
+//	if elector.IsLeader() { ... }
+type Elector struct {
+	backend   Backend
+	cfg       *Config
+	identity  string
+	callbacks Callbacks
+
+	leader atomic.Bool
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+var (
+	_ infraoperator.Starter = (*Elector)(nil)
+	_ infraoperator.Stopper = (*Elector)(nil)
+)
+
+func New(backend Backend, cfg *Config, callbacks Callbacks) (*Elector, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	identity, err := cfg.identity()
+	if err != nil {
+		return nil, err
+	}
+
+	initMetrics()
+
+	return &Elector{
+		backend:   backend,
+		cfg:       cfg,
+		identity:  identity,
+		callbacks: callbacks,
+	}, nil
+}
+
+// IsLeader reports whether the candidate is the leader now
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Identity returns candidate identity
+func (e *Elector) Identity() string {
+	return e.identity
+}
+
+// OnlyLeader wraps fn, so it does nothing on followers. Useful for cron jobs.
+func (e *Elector) OnlyLeader(fn func(ctx context.Context) error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if !e.IsLeader() {
+			return nil
+		}
+		return fn(ctx)
+	}
+}
+
+// Start starts participating in election in background
+func (e *Elector) Start(_ context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.cancel != nil {
+		return errors.New("elector is already started")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	e.cancel = cancel
+	e.done = make(chan struct{})
+
+	go e.run(ctx)
+
+	return nil
+}
+
+// Stop stops participating in election and releases leadership
+func (e *Elector) Stop(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.cancel == nil {
+		return nil
+	}
+
+	e.cancel()
+	<-e.done
+	e.cancel = nil
+
+	if err := e.backend.Release(ctx, e.identity); err != nil {
+		return errors.Wrap(err, "unable to release leadership")
+	}
+
+	return nil
+}
+
+func (e *Elector) run(ctx context.Context) {
+	defer close(e.done)
+
+	var (
+		leadCancel context.CancelFunc
+		lastRenew  time.Time
+	)
+
+	stopLeading := func() {
+		if leadCancel == nil {
+			return
+		}
+
+		leadCancel()
+		leadCancel = nil
+		e.leader.Store(false)
+		metrics.IsLeaderGauge.Set(0)
+		infralog.Info("leadership lost", zap.String("identity", e.identity))
+
+		if e.callbacks.OnStoppedLeading != nil {
+			e.callbacks.OnStoppedLeading()
+		}
+	}
+	defer stopLeading()
+
+	ticker := time.NewTicker(e.cfg.RetryPeriod)
+	defer ticker.Stop()
+
+	for {
+		attemptCtx, cancel := context.WithTimeout(ctx, e.cfg.RetryPeriod)
+		ok, err := e.backend.TryAcquireOrRenew(attemptCtx, e.identity, e.cfg.LeaseDuration)
+		cancel()
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		switch {
+		case err != nil:
+			infralog.Error("leader election error", zap.String("identity", e.identity), zap.Error(err))
+			// keep leadership while the lease is surely not expired
+			if leadCancel != nil && time.Since(lastRenew) > e.cfg.LeaseDuration-e.cfg.RetryPeriod {
+				stopLeading()
+			}
+		case ok:
+			lastRenew = time.Now()
+			if leadCancel == nil {
+				leadCtx, cancel := context.WithCancel(ctx)
+				leadCancel = cancel
+				e.leader.Store(true)
+				metrics.IsLeaderGauge.Set(1)
+				metrics.TransitionsCounter.Inc()
+				infralog.Info("became the leader", zap.String("identity", e.identity))
+
+				if e.callbacks.OnStartedLeading != nil {
+					go e.callbacks.OnStartedLeading(leadCtx)
+				}
+			}
+		default:
+			stopLeading()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package infraleader
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memoryBackend struct {
+	mu      sync.Mutex
+	holder  string
+	expires time.Time
+}
+
+func (b *memoryBackend) TryAcquireOrRenew(_ context.Context, identity string, leaseDuration time.Duration) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.holder != "" && b.holder != identity && time.Now().Before(b.expires) {
+		return false, nil
+	}
+	b.holder = identity
+	b.expires = time.Now().Add(leaseDuration)
+	return true, nil
+}
+
+func (b *memoryBackend) Release(_ context.Context, identity string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.holder == identity {
+		b.holder = ""
+	}
+	return nil
+}
+
+func TestElectorFailover(t *testing.T) {
+	backend := &memoryBackend{}
+	cfg := func(identity string) *Config {
+		return &Config{Identity: identity, LeaseDuration: 200 * time.Millisecond, RetryPeriod: 20 * time.Millisecond}
+	}
+
+	started := make(chan string, 2)
+	stopped := make(chan struct{}, 2)
+	callbacks := func(identity string) Callbacks {
+		return Callbacks{
+			OnStartedLeading: func(context.Context) { started <- identity },
+			OnStoppedLeading: func() { stopped <- struct{}{} },
+		}
+	}
+
+	first, err := New(backend, cfg("first"), callbacks("first"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := New(backend, cfg("second"), callbacks("second"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = first.Start(context.Background())
+	if got := <-started; got != "first" {
+		t.Fatalf("expected first to lead, got %s", got)
+	}
+
+	_ = second.Start(context.Background())
+	time.Sleep(50 * time.Millisecond)
+	if !first.IsLeader() || second.IsLeader() {
+		t.Fatal("expected only first to be the leader")
+	}
+
+	if err := first.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	<-stopped
+
+	select {
+	case got := <-started:
+		if got != "second" {
+			t.Fatalf("expected second to lead, got %s", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("second did not become the leader")
+	}
+
+	_ = second.Stop(context.Background())
+	if second.IsLeader() {
+		t.Fatal("stopped elector must not be the leader")
+	}
+}
@@ -0,0 +1,33 @@
+package infraleader
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	IsLeaderGauge      prometheus.Gauge
+	TransitionsCounter prometheus.Counter
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.IsLeaderGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "leader_election_is_leader",
+			Help: "1 if the instance is the leader, 0 otherwise",
+		})
+
+		metrics.TransitionsCounter = prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "leader_election_transitions_total",
+			Help: "Number of times the instance became the leader",
+		})
+
+		prometheus.MustRegister(
+			metrics.IsLeaderGauge,
+			metrics.TransitionsCounter,
+		)
+	})
+}
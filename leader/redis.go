@@ -0,0 +1,49 @@
+package infraleader
+
+import (
+	"context"
+	"time"
+
+	infraredis "github.com/pushwoosh/infra/redis"
+	"github.com/redis/go-redis/v9"
+)
+
+var renewScript = redis.NewScript(`
+local holder = redis.call("GET", KEYS[1])
+if holder == false then
+	redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+	return 1
+end
+if holder == ARGV[1] then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	return 1
+end
+return 0`)
+
+// RedisBackend keeps the lease in a redis key with expiration
+type RedisBackend struct {
+	client redis.UniversalClient
+	key    string
+}
+
+var _ Backend = (*RedisBackend)(nil)
+
+func NewRedisBackend(client redis.UniversalClient, key string) *RedisBackend {
+	return &RedisBackend{
+		client: client,
+		key:    key,
+	}
+}
+
+func (b *RedisBackend) TryAcquireOrRenew(ctx context.Context, identity string, leaseDuration time.Duration) (bool, error) {
+	res, err := renewScript.Run(ctx, b.client, []string{b.key}, identity, leaseDuration.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return res == 1, nil
+}
+
+func (b *RedisBackend) Release(ctx context.Context, identity string) error {
+	_, err := infraredis.DeleteIfEqual(ctx, b.client, b.key, identity)
+	return err
+}
@@ -0,0 +1,33 @@
+package infralock
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	"go.uber.org/zap"
+)
+
+// TryLocker adapts Locker to interfaces that need a simple try-lock function, e.g. infracron.Locker:
+//
+//	s.Add("report", "@hourly", report, infracron.WithLock(infralock.TryLocker{Locker: locker}, time.Hour))
+type TryLocker struct {
+	Locker Locker
+}
+
+func (l TryLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	lock, err := l.Locker.TryAcquire(ctx, key, ttl)
+	if errors.Is(err, ErrNotAcquired) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return func() {
+		if err := lock.Release(context.Background()); err != nil {
+			infralog.Error("unable to release lock", zap.String("key", key), zap.Error(err))
+		}
+	}, true, nil
+}
@@ -0,0 +1,203 @@
+package infralock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNotAcquired = errors.New("lock is held by another owner")
+	ErrLockLost    = errors.New("lock is lost")
+)
+
+const defaultRetryDelay = 100 * time.Millisecond
+
+// Locker acquires distributed locks
+type Locker interface {
+	// Acquire waits until the lock is acquired or ctx is done
+	Acquire(ctx context.Context, key string, ttl time.Duration) (Lock, error)
+	// TryAcquire acquires the lock without waiting. Returns ErrNotAcquired if the lock is held by another owner.
+	TryAcquire(ctx context.Context, key string, ttl time.Duration) (Lock, error)
+}
+
+// Lock is an acquired lock. It's renewed automatically every ttl/3 until released.
+type Lock interface {
+	Key() string
+	// Token is a fencing token: it increases with every acquisition of the key.
+	// Pass it to the protected resource to reject writes of previous owners.
+	Token() int64
+	// Lost is closed when the lock can't be renewed and may be acquired by someone else.
+	// Work protected by the lock must be stopped.
+	Lost() <-chan struct{}
+	// Release releases the lock and stops renewal
+	Release(ctx context.Context) error
+}
+
+// backend implements storage specific lock operations
+type backend interface {
+	name() string
+	tryAcquire(ctx context.Context, key, owner string, ttl time.Duration) (token int64, acquired bool, err error)
+	refresh(ctx context.Context, key, owner string, ttl time.Duration) (bool, error)
+	release(ctx context.Context, key, owner string) error
+}
+
+// locker implements Locker on top of a backend
+type locker struct {
+	backend    backend
+	retryDelay time.Duration
+}
+
+var _ Locker = (*locker)(nil)
+
+func newLocker(b backend) *locker {
+	initMetrics()
+
+	return &locker{
+		backend:    b,
+		retryDelay: defaultRetryDelay,
+	}
+}
+
+func (l *locker) Acquire(ctx context.Context, key string, ttl time.Duration) (Lock, error) {
+	for {
+		lock, err := l.TryAcquire(ctx, key, ttl)
+		if !errors.Is(err, ErrNotAcquired) {
+			return lock, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(l.retryDelay):
+		}
+	}
+}
+
+func (l *locker) TryAcquire(ctx context.Context, key string, ttl time.Duration) (Lock, error) {
+	if ttl <= 0 {
+		return nil, errors.New("lock ttl must be greater than zero")
+	}
+
+	owner, err := newOwnerID()
+	if err != nil {
+		return nil, err
+	}
+
+	token, acquired, err := l.backend.tryAcquire(ctx, key, owner, ttl)
+	if err != nil {
+		metrics.AcquireCounter.WithLabelValues(l.backend.name(), "error").Inc()
+		return nil, errors.Wrapf(err, "unable to acquire lock %s", key)
+	}
+	if !acquired {
+		metrics.AcquireCounter.WithLabelValues(l.backend.name(), "busy").Inc()
+		return nil, ErrNotAcquired
+	}
+
+	metrics.AcquireCounter.WithLabelValues(l.backend.name(), "acquired").Inc()
+
+	renewCtx, cancel := context.WithCancel(context.Background())
+	lk := &lock{
+		backend:  l.backend,
+		key:      key,
+		owner:    owner,
+		token:    token,
+		ttl:      ttl,
+		acquired: time.Now(),
+		lost:     make(chan struct{}),
+		cancel:   cancel,
+		renewed:  make(chan struct{}),
+	}
+	go lk.renew(renewCtx)
+
+	return lk, nil
+}
+
+type lock struct {
+	backend  backend
+	key      string
+	owner    string
+	token    int64
+	ttl      time.Duration
+	acquired time.Time
+
+	lost     chan struct{}
+	lostOnce sync.Once
+	cancel   context.CancelFunc
+	renewed  chan struct{} // closed when renewal goroutine exits
+
+	releaseOnce sync.Once
+	releaseErr  error
+}
+
+func (l *lock) Key() string {
+	return l.key
+}
+
+func (l *lock) Token() int64 {
+	return l.token
+}
+
+func (l *lock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+func (l *lock) Release(ctx context.Context) error {
+	l.releaseOnce.Do(func() {
+		l.cancel()
+		<-l.renewed
+
+		metrics.HoldDurationHistogram.WithLabelValues(l.backend.name()).Observe(time.Since(l.acquired).Seconds())
+
+		if err := l.backend.release(ctx, l.key, l.owner); err != nil {
+			l.releaseErr = errors.Wrapf(err, "unable to release lock %s", l.key)
+		}
+	})
+
+	return l.releaseErr
+}
+
+func (l *lock) renew(ctx context.Context) {
+	defer close(l.renewed)
+
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ok, err := l.backend.refresh(ctx, l.key, l.owner, l.ttl)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil && ok {
+			continue
+		}
+
+		if err == nil {
+			err = ErrLockLost
+		}
+		infralog.Error("lock renewal failed", zap.String("key", l.key), zap.String("backend", l.backend.name()), zap.Error(err))
+		metrics.LostCounter.WithLabelValues(l.backend.name()).Inc()
+		l.lostOnce.Do(func() { close(l.lost) })
+		return
+	}
+}
+
+func newOwnerID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "unable to generate lock owner id")
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,103 @@
+package infralock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryBackend is an in-process backend for tests
+type memoryBackend struct {
+	mu     sync.Mutex
+	owners map[string]string
+	token  int64
+}
+
+func newMemoryLocker() (*locker, *memoryBackend) {
+	b := &memoryBackend{owners: make(map[string]string)}
+	l := newLocker(b)
+	l.retryDelay = time.Millisecond
+	return l, b
+}
+
+func (b *memoryBackend) name() string { return "memory" }
+
+func (b *memoryBackend) tryAcquire(_ context.Context, key, owner string, _ time.Duration) (int64, bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.owners[key]; ok {
+		return 0, false, nil
+	}
+	b.owners[key] = owner
+	b.token++
+	return b.token, true, nil
+}
+
+func (b *memoryBackend) refresh(_ context.Context, key, owner string, _ time.Duration) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.owners[key] == owner, nil
+}
+
+func (b *memoryBackend) release(_ context.Context, key, owner string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.owners[key] == owner {
+		delete(b.owners, key)
+	}
+	return nil
+}
+
+func TestLocker(t *testing.T) {
+	l, _ := newMemoryLocker()
+	ctx := context.Background()
+
+	first, err := l.TryAcquire(ctx, "key", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = l.TryAcquire(ctx, "key", time.Second); !errors.Is(err, ErrNotAcquired) {
+		t.Fatalf("expected ErrNotAcquired, got %v", err)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_ = first.Release(ctx)
+	}()
+
+	second, err := l.Acquire(ctx, "key", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = second.Release(ctx) }()
+
+	if second.Token() <= first.Token() {
+		t.Errorf("expected fencing token to increase: %d -> %d", first.Token(), second.Token())
+	}
+}
+
+func TestLocker_lost(t *testing.T) {
+	l, b := newMemoryLocker()
+
+	lock, err := l.TryAcquire(context.Background(), "key", 30*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// somebody else took the key
+	b.mu.Lock()
+	b.owners["key"] = "another owner"
+	b.mu.Unlock()
+
+	select {
+	case <-lock.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("expected lock to be lost")
+	}
+}
@@ -0,0 +1,41 @@
+package infralock
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	AcquireCounter        *prometheus.CounterVec
+	LostCounter           *prometheus.CounterVec
+	HoldDurationHistogram *prometheus.HistogramVec
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.AcquireCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "distributed_lock_acquire_total",
+			Help: "Number of lock acquisition attempts by result: acquired, busy or error",
+		}, []string{"backend", "result"})
+
+		metrics.LostCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "distributed_lock_lost_total",
+			Help: "Number of locks lost because of failed renewal",
+		}, []string{"backend"})
+
+		metrics.HoldDurationHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "distributed_lock_hold_duration_seconds",
+			Help:    "Time between lock acquisition and release",
+			Buckets: []float64{0.01, 0.1, 0.5, 1, 5, 10, 30, 60, 300, 600, 1800, 3600},
+		}, []string{"backend"})
+
+		prometheus.MustRegister(
+			metrics.AcquireCounter,
+			metrics.LostCounter,
+			metrics.HoldDurationHistogram,
+		)
+	})
+}
@@ -0,0 +1,115 @@
+package infralock
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// postgresBackend uses session level advisory locks.
+// Every held lock keeps a dedicated connection, the lock is released by postgres if the connection dies,
+// so ttl is not used and renewal only checks that the connection is alive.
+type postgresBackend struct {
+	db *sql.DB
+
+	mu    sync.Mutex
+	conns map[string]*sql.Conn // owner -> connection holding the lock
+}
+
+const fencingSequence = "infralock_fencing_seq"
+
+// NewPostgresLocker creates a locker backed by postgres advisory locks.
+// Creates a sequence for fencing tokens if it doesn't exist.
+func NewPostgresLocker(ctx context.Context, db *sql.DB) (Locker, error) {
+	if _, err := db.ExecContext(ctx, "CREATE SEQUENCE IF NOT EXISTS "+fencingSequence); err != nil {
+		return nil, errors.Wrap(err, "unable to create fencing sequence")
+	}
+
+	return newLocker(&postgresBackend{
+		db:    db,
+		conns: make(map[string]*sql.Conn),
+	}), nil
+}
+
+func (b *postgresBackend) name() string {
+	return "postgres"
+}
+
+func (b *postgresBackend) tryAcquire(ctx context.Context, key, owner string, _ time.Duration) (int64, bool, error) {
+	conn, err := b.db.Conn(ctx)
+	if err != nil {
+		return 0, false, errors.Wrap(err, "db.Conn")
+	}
+
+	var acquired bool
+	if err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock(hashtextextended($1, 0))", key).Scan(&acquired); err != nil {
+		_ = conn.Close()
+		return 0, false, errors.Wrap(err, "pg_try_advisory_lock")
+	}
+	if !acquired {
+		_ = conn.Close()
+		return 0, false, nil
+	}
+
+	var token int64
+	if err = conn.QueryRowContext(ctx, "SELECT nextval($1)", fencingSequence).Scan(&token); err != nil {
+		_ = unlock(context.WithoutCancel(ctx), conn, key)
+		return 0, false, errors.Wrap(err, "nextval")
+	}
+
+	b.mu.Lock()
+	b.conns[owner] = conn
+	b.mu.Unlock()
+
+	return token, true, nil
+}
+
+func (b *postgresBackend) refresh(ctx context.Context, _, owner string, _ time.Duration) (bool, error) {
+	conn := b.conn(owner)
+	if conn == nil {
+		return false, nil
+	}
+
+	if err := conn.PingContext(ctx); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (b *postgresBackend) release(ctx context.Context, key, owner string) error {
+	b.mu.Lock()
+	conn := b.conns[owner]
+	delete(b.conns, owner)
+	b.mu.Unlock()
+
+	if conn == nil {
+		return nil
+	}
+
+	return unlock(ctx, conn, key)
+}
+
+// unlock releases the lock and returns the connection to the pool. If the unlock fails, the session may
+// still hold the lock, so the physical connection is discarded instead: postgres releases the lock
+// when the session ends
+func unlock(ctx context.Context, conn *sql.Conn, key string) error {
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock(hashtextextended($1, 0))", key); err != nil {
+		_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		_ = conn.Close()
+		return errors.Wrap(err, "pg_advisory_unlock")
+	}
+
+	return conn.Close()
+}
+
+func (b *postgresBackend) conn(owner string) *sql.Conn {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.conns[owner]
+}
@@ -0,0 +1,64 @@
+package infralock
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+// unlockConnector opens connections failing pg_advisory_unlock if fail is set
+type unlockConnector struct {
+	fail bool
+}
+
+func (c *unlockConnector) Connect(context.Context) (driver.Conn, error) { return unlockConn{c}, nil }
+func (c *unlockConnector) Driver() driver.Driver                        { return nil }
+
+type unlockConn struct {
+	connector *unlockConnector
+}
+
+func (unlockConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (unlockConn) Close() error                        { return nil }
+func (unlockConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c unlockConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	if c.connector.fail {
+		return nil, errors.New("connection reset")
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func TestUnlock(t *testing.T) {
+	connector := &unlockConnector{}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	ctx := context.Background()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = unlock(ctx, conn, "key"); err != nil {
+		t.Fatal(err)
+	}
+	if stats := db.Stats(); stats.Idle != 1 {
+		t.Fatalf("expected the connection returned to the pool, got %+v", stats)
+	}
+
+	// the session may still hold the lock, it must not be reused
+	connector.fail = true
+	conn, err = db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = unlock(ctx, conn, "key"); err == nil {
+		t.Fatal("expected unlock error")
+	}
+	if stats := db.Stats(); stats.OpenConnections != 0 {
+		t.Fatalf("expected the connection discarded, got %+v", stats)
+	}
+}
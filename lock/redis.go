@@ -0,0 +1,157 @@
+package infralock
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	infraredis "github.com/pushwoosh/infra/redis"
+	"github.com/redis/go-redis/v9"
+)
+
+const fencingSuffix = ":fencing"
+
+var refreshScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// redisBackend locks a key on independent redis instances, the lock is acquired when the majority agrees.
+// It's the Redlock algorithm, with a single client it degrades to a simple SET NX lock.
+// Fencing tokens come from a counter on the first instance only: counters of different instances
+// diverge when instances miss acquisitions, so the maximum of them may go backwards. The lock
+// can't be acquired while the first instance is unavailable.
+type redisBackend struct {
+	clients []redis.UniversalClient
+	quorum  int
+}
+
+// NewRedisLocker creates a locker backed by redis.
+// Pass several independent instances (not replicas of one master) for Redlock quorum,
+// the first one also keeps fencing counters and must be durable (AOF with fsync).
+func NewRedisLocker(clients ...redis.UniversalClient) (Locker, error) {
+	if len(clients) == 0 {
+		return nil, errors.New("at least one redis client is required")
+	}
+
+	return newLocker(&redisBackend{
+		clients: clients,
+		quorum:  len(clients)/2 + 1,
+	}), nil
+}
+
+func (b *redisBackend) name() string {
+	return "redis"
+}
+
+func (b *redisBackend) tryAcquire(ctx context.Context, key, owner string, ttl time.Duration) (int64, bool, error) {
+	start := time.Now()
+
+	var (
+		mu       sync.Mutex
+		acquired int
+		failed   int
+		lastErr  error
+	)
+
+	b.each(func(c redis.UniversalClient) {
+		ok, err := c.SetNX(ctx, key, owner, ttl).Result()
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			lastErr = err
+			failed++
+			return
+		}
+		if ok {
+			acquired++
+		}
+	})
+
+	// the lock is valid only if it was acquired by majority before it expired
+	if acquired >= b.quorum && time.Since(start) < ttl {
+		// fencing counter is never expired, so tokens keep increasing
+		token, err := b.clients[0].Incr(ctx, key+fencingSuffix).Result()
+		if err == nil {
+			return token, true, nil
+		}
+		lastErr = errors.Wrap(err, "unable to get fencing token")
+		failed = len(b.clients)
+	}
+
+	// release partially acquired lock
+	_ = b.release(context.WithoutCancel(ctx), key, owner)
+
+	// not enough instances answered to tell that the lock is busy
+	if len(b.clients)-failed < b.quorum {
+		return 0, false, lastErr
+	}
+
+	return 0, false, nil
+}
+
+func (b *redisBackend) refresh(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	var (
+		mu        sync.Mutex
+		refreshed int
+		lastErr   error
+	)
+
+	b.each(func(c redis.UniversalClient) {
+		res, err := refreshScript.Run(ctx, c, []string{key}, owner, ttl.Milliseconds()).Int()
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			lastErr = err
+			return
+		}
+		if res == 1 {
+			refreshed++
+		}
+	})
+
+	if refreshed >= b.quorum {
+		return true, nil
+	}
+
+	return false, lastErr
+}
+
+func (b *redisBackend) release(ctx context.Context, key, owner string) error {
+	var (
+		mu      sync.Mutex
+		lastErr error
+	)
+
+	b.each(func(c redis.UniversalClient) {
+		if _, err := infraredis.DeleteIfEqual(ctx, c, key, owner); err != nil {
+			mu.Lock()
+			lastErr = err
+			mu.Unlock()
+		}
+	})
+
+	return lastErr
+}
+
+// each runs fn for every client concurrently
+func (b *redisBackend) each(fn func(c redis.UniversalClient)) {
+	if len(b.clients) == 1 {
+		fn(b.clients[0])
+		return
+	}
+
+	wg := sync.WaitGroup{}
+	for _, c := range b.clients {
+		wg.Add(1)
+		go func(c redis.UniversalClient) {
+			defer wg.Done()
+			fn(c)
+		}(c)
+	}
+	wg.Wait()
+}
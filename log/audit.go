@@ -0,0 +1,75 @@
+package infralog
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// AuditEvent is a record of an action on a protected object, e.g. a changed application setting
+type AuditEvent struct {
+	// Time of the action. optional, the current time by default
+	Time time.Time
+
+	// Actor is who performed the action, e.g. "user:42" or "service:billing"
+	Actor string
+
+	// Action is what was done, e.g. "application.update"
+	Action string
+
+	// Object is what the action was performed on, e.g. "application:ABCDE-12345"
+	Object string
+
+	// Before and After are states of the object, they are encoded to JSON. optional
+	Before any
+	After  any
+}
+
+var audit struct {
+	mu       sync.RWMutex
+	handlers []func(ctx context.Context, event *AuditEvent) error
+}
+
+// RegisterAuditHandler adds a handler persisting audit events, e.g. infraaudit.Writer
+func RegisterAuditHandler(handler func(ctx context.Context, event *AuditEvent) error) {
+	audit.mu.Lock()
+	defer audit.mu.Unlock()
+
+	audit.handlers = append(audit.handlers, handler)
+}
+
+// Audit logs the audit event and passes it to audit handlers. It returns the first handler error,
+// so the caller may fail the action when the event can't be persisted:
+//
+//	err := infralog.Audit(ctx, &infralog.AuditEvent{
+//		Actor:  "user:42",
+//		Action: "application.update",
+//		Object: "application:" + app.Code,
+//		Before: before,
+//		After:  app,
+//	})
+func Audit(ctx context.Context, event *AuditEvent) error {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	InfoCtx(ctx, "audit",
+		zap.String("audit_actor", event.Actor),
+		zap.String("audit_action", event.Action),
+		zap.String("audit_object", event.Object))
+
+	audit.mu.RLock()
+	handlers := audit.handlers
+	audit.mu.RUnlock()
+
+	var firstErr error
+	for _, handler := range handlers {
+		if err := handler(ctx, event); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
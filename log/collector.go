@@ -85,6 +85,11 @@ func fieldsFromContext(ctx context.Context) []zap.Field {
 	return nil
 }
 
+// FieldsFromContext returns fields added to the context with WithField and WithFields
+func FieldsFromContext(ctx context.Context) []zap.Field {
+	return fieldsFromContext(ctx)
+}
+
 func WithField(ctx context.Context, field zap.Field) context.Context {
 	ctxFields, ok := ctx.Value(fieldsCtxKey).(*logFields)
 	if !ok || ctxFields == nil {
@@ -112,6 +117,7 @@ func RegisterLogHandler(handler func(entry *LogEntry)) {
 }
 
 func handleEntry(entry *LogEntry) {
+	entry.Fields = withGlobalFields(entry.Fields)
 	for _, handler := range handlers {
 		handler(entry)
 	}
@@ -1,6 +1,8 @@
 package infralog
 
 import (
+	"time"
+
 	"github.com/pkg/errors"
 	"go.uber.org/zap/zapcore"
 )
@@ -10,6 +12,13 @@ type Config struct {
 	Environment       string
 	Level             string
 	DisableStacktrace bool
+
+	// Time given to functions registered with OnFatal to finish before the process exits.
+	// Default is DefaultFatalShutdownTimeout
+	FatalShutdownTimeout time.Duration
+
+	// Outputs is a list of additional network sinks (GELF, Logstash) that receive every log entry
+	Outputs []*OutputConfig
 }
 
 const (
@@ -57,9 +66,23 @@ func (c *Config) Validate() error {
 		return errors.Errorf("invalid environment \"%s\". expected \"developemnt\" or \"production\"", c.Environment)
 	}
 
+	for i, output := range c.Outputs {
+		if err := output.Validate(); err != nil {
+			return errors.Wrapf(err, "outputs[%d]", i)
+		}
+	}
+
 	return nil
 }
 
+func (c *Config) GetFatalShutdownTimeout() time.Duration {
+	if c.FatalShutdownTimeout <= 0 {
+		return DefaultFatalShutdownTimeout
+	}
+
+	return c.FatalShutdownTimeout
+}
+
 func (c *Config) GetLogLevel() zapcore.Level {
 	return logLevels[c.Level]
 }
@@ -0,0 +1,119 @@
+package infralog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const DefaultFatalShutdownTimeout = time.Second * 30
+
+var (
+	fatalHandlersMu sync.Mutex
+	fatalHandlers   []func(ctx context.Context)
+	fatalInProgress atomic.Bool
+)
+
+// OnFatal registers a shutdown function that is called before the process exits on Fatal log entry.
+// Functions are called in reverse order of registration, like deferred calls.
+func OnFatal(fn func(ctx context.Context)) {
+	fatalHandlersMu.Lock()
+	defer fatalHandlersMu.Unlock()
+
+	fatalHandlers = append(fatalHandlers, fn)
+}
+
+// fatalHook runs registered shutdown functions and exits the process.
+// It replaces the default zap behavior that calls os.Exit right after writing the entry.
+type fatalHook struct {
+	timeout time.Duration
+	sync    func() error
+}
+
+func (h *fatalHook) OnWrite(_ *zapcore.CheckedEntry, _ []zapcore.Field) {
+	// fatal entry logged during shutdown: there is nothing more we can do
+	if fatalInProgress.Swap(true) {
+		_ = h.sync()
+		os.Exit(1)
+	}
+
+	runFatalHandlers(h.timeout)
+
+	_ = h.sync()
+	os.Exit(1)
+}
+
+func runFatalHandlers(timeout time.Duration) {
+	fatalHandlersMu.Lock()
+	handlers := append([]func(context.Context){}, fatalHandlers...)
+	fatalHandlersMu.Unlock()
+
+	if len(handlers) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := len(handlers) - 1; i >= 0; i-- {
+			runFatalHandler(ctx, handlers[i])
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		_, _ = fmt.Fprintln(os.Stderr, "infralog: fatal shutdown timeout")
+	}
+}
+
+func runFatalHandler(ctx context.Context, fn func(ctx context.Context)) {
+	defer func() {
+		if r := recover(); r != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "infralog: panic in fatal handler: %v\n", r)
+		}
+	}()
+
+	fn(ctx)
+}
+
+// Recover logs a recovered panic with its stack trace and the given fields.
+// Must be called directly with defer:
+//
+//	defer infralog.Recover(zap.String("worker", name))
+func Recover(fields ...zap.Field) {
+	if r := recover(); r != nil {
+		Error("panic recovered", append(panicFields(r), fields...)...)
+	}
+}
+
+// RecoverCtx is the same as Recover, but also adds fields from the context
+func RecoverCtx(ctx context.Context, fields ...zap.Field) {
+	if r := recover(); r != nil {
+		ErrorCtx(ctx, "panic recovered", append(panicFields(r), fields...)...)
+	}
+}
+
+func panicFields(r interface{}) []zap.Field {
+	var err error
+	switch v := r.(type) {
+	case error:
+		err = v
+	default:
+		err = fmt.Errorf("%v", v)
+	}
+
+	return []zap.Field{
+		zap.Error(err),
+		zap.StackSkip("panic_stack", 2),
+	}
+}
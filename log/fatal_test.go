@@ -0,0 +1,70 @@
+package infralog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestRunFatalHandlers(t *testing.T) {
+	fatalHandlersMu.Lock()
+	saved := fatalHandlers
+	fatalHandlers = nil
+	fatalHandlersMu.Unlock()
+	t.Cleanup(func() {
+		fatalHandlersMu.Lock()
+		fatalHandlers = saved
+		fatalHandlersMu.Unlock()
+	})
+
+	var calls []string
+	OnFatal(func(context.Context) { calls = append(calls, "first") })
+	OnFatal(func(context.Context) { panic("broken handler") })
+	OnFatal(func(context.Context) { calls = append(calls, "last") })
+
+	runFatalHandlers(time.Second)
+	if len(calls) != 2 || calls[0] != "last" || calls[1] != "first" {
+		t.Fatalf("expected handlers in reverse order despite a panic, got %v", calls)
+	}
+
+	// a stuck handler doesn't keep the process from exiting
+	block := make(chan struct{})
+	defer close(block)
+	OnFatal(func(context.Context) { <-block })
+
+	start := time.Now()
+	runFatalHandlers(50 * time.Millisecond)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected the shutdown timeout, waited %v", elapsed)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	saved := handlers
+	t.Cleanup(func() { handlers = saved })
+
+	var entries []*LogEntry
+	handlers = []func(entry *LogEntry){func(entry *LogEntry) { entries = append(entries, entry) }}
+
+	func() {
+		defer Recover(zap.String("worker", "sender"))
+		panic("nil map")
+	}()
+
+	if len(entries) != 1 {
+		t.Fatalf("expected the panic logged, got %d entries", len(entries))
+	}
+	if err := entries[0].Error(); err == nil || err.Error() != "nil map" {
+		t.Fatalf("expected the panic value as error, got %v", err)
+	}
+
+	fields := map[string]bool{}
+	for _, f := range entries[0].Fields {
+		fields[f.Key] = true
+	}
+	if !fields["panic_stack"] || !fields["worker"] {
+		t.Fatalf("expected stack and caller fields, got %v", fields)
+	}
+}
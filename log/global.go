@@ -0,0 +1,121 @@
+package infralog
+
+import (
+	"os"
+	"path"
+	"sync"
+
+	infraversion "github.com/pushwoosh/infra/version"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Environment variables used to populate global fields automatically.
+const (
+	EnvServiceName    = "SERVICE_NAME"
+	EnvServiceVersion = "SERVICE_VERSION"
+	EnvEnvironment    = "ENVIRONMENT"
+	EnvRegion         = "REGION"
+	EnvInstance       = "HOSTNAME"
+)
+
+var (
+	globalFieldsMu  sync.RWMutex
+	globalFields    []zap.Field
+	globalFieldsSet bool
+)
+
+// SetGlobalFields sets service metadata that is attached to every log entry.
+// Empty values are omitted.
+func SetGlobalFields(service, version, env, region, instance string) {
+	var fields []zap.Field
+	for _, f := range []struct{ key, value string }{
+		{"service", service},
+		{"version", version},
+		{"env", env},
+		{"region", region},
+		{"instance", instance},
+	} {
+		if f.value != "" {
+			fields = append(fields, zap.String(f.key, f.value))
+		}
+	}
+
+	globalFieldsMu.Lock()
+	defer globalFieldsMu.Unlock()
+
+	globalFields = fields
+	globalFieldsSet = true
+}
+
+// SetGlobalFieldsFromEnvironment populates global fields from environment variables.
+// Service name and version fall back to the binary's build info, see infraversion.Get, when the variables are not set.
+func SetGlobalFieldsFromEnvironment() {
+	service := os.Getenv(EnvServiceName)
+	version := os.Getenv(EnvServiceVersion)
+
+	info := infraversion.Get()
+	if service == "" && info.Path != "" {
+		service = path.Base(info.Path)
+	}
+	if version == "" {
+		version = firstNonEmpty(info.Version, info.Commit)
+	}
+
+	instance := os.Getenv(EnvInstance)
+	if instance == "" {
+		instance, _ = os.Hostname()
+	}
+
+	SetGlobalFields(service, version, os.Getenv(EnvEnvironment), os.Getenv(EnvRegion), instance)
+}
+
+// GlobalFields returns a copy of the fields attached to every log entry.
+func GlobalFields() []zap.Field {
+	globalFieldsMu.RLock()
+	defer globalFieldsMu.RUnlock()
+
+	return append([]zap.Field(nil), globalFields...)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func withGlobalFields(fields []zap.Field) []zap.Field {
+	globalFieldsMu.RLock()
+	defer globalFieldsMu.RUnlock()
+
+	if len(globalFields) == 0 {
+		return fields
+	}
+
+	ret := make([]zap.Field, 0, len(globalFields)+len(fields))
+	ret = append(ret, globalFields...)
+	return append(ret, fields...)
+}
+
+// globalFieldsCore attaches global fields to entries written directly through the zap logger
+type globalFieldsCore struct {
+	zapcore.Core
+}
+
+func (c *globalFieldsCore) With(fields []zapcore.Field) zapcore.Core {
+	return &globalFieldsCore{Core: c.Core.With(fields)}
+}
+
+func (c *globalFieldsCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *globalFieldsCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(entry, withGlobalFields(fields))
+}
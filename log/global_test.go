@@ -0,0 +1,76 @@
+package infralog
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// restoreGlobalFields resets global fields changed by a test
+func restoreGlobalFields(t *testing.T) {
+	globalFieldsMu.RLock()
+	fields, set := globalFields, globalFieldsSet
+	globalFieldsMu.RUnlock()
+
+	t.Cleanup(func() {
+		globalFieldsMu.Lock()
+		defer globalFieldsMu.Unlock()
+
+		globalFields, globalFieldsSet = fields, set
+	})
+}
+
+func TestGlobalFields(t *testing.T) {
+	restoreGlobalFields(t)
+
+	SetGlobalFields("sender", "v1.2.3", "prod", "", "sender-0")
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(&globalFieldsCore{Core: core}).With(zap.String("component", "queue"))
+	logger.Info("started", zap.Int("workers", 4))
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+
+	fields := entries[0].ContextMap()
+	expected := map[string]interface{}{
+		"service":   "sender",
+		"version":   "v1.2.3",
+		"env":       "prod",
+		"instance":  "sender-0",
+		"component": "queue",
+		"workers":   int64(4),
+	}
+	for k, v := range expected {
+		if fields[k] != v {
+			t.Errorf("expected %s=%v, got %v", k, v, fields[k])
+		}
+	}
+	if _, ok := fields["region"]; ok {
+		t.Error("empty region must be omitted")
+	}
+}
+
+func TestSetGlobalFieldsFromEnvironment(t *testing.T) {
+	restoreGlobalFields(t)
+
+	t.Setenv(EnvServiceName, "billing")
+	t.Setenv(EnvServiceVersion, "")
+	t.Setenv(EnvEnvironment, "stage")
+	t.Setenv(EnvRegion, "eu-west-1")
+	t.Setenv(EnvInstance, "billing-1")
+
+	SetGlobalFieldsFromEnvironment()
+
+	fields := map[string]string{}
+	for _, f := range GlobalFields() {
+		fields[f.Key] = f.String
+	}
+	if fields["service"] != "billing" || fields["env"] != "stage" || fields["region"] != "eu-west-1" || fields["instance"] != "billing-1" {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+}
@@ -0,0 +1,91 @@
+package infragrpclog
+
+import (
+	"fmt"
+
+	infralog "github.com/pushwoosh/infra/log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc/grpclog"
+)
+
+// Logger is a grpclog.LoggerV2 implementation that routes grpc internal logs to infralog.
+type Logger struct {
+	level     zapcore.Level
+	verbosity int
+	fields    []zap.Field
+}
+
+var _ grpclog.LoggerV2 = (*Logger)(nil)
+
+// NewLogger creates a new grpc logger.
+// level is the minimal level of entries passed to infralog,
+// verbosity is the grpc verbosity level (see GRPC_GO_LOG_VERBOSITY_LEVEL).
+func NewLogger(level zapcore.Level, verbosity int, component string) *Logger {
+	var fields []zap.Field
+	if component != "" {
+		fields = append(fields, zap.String(infralog.ComponentField, component))
+	}
+
+	return &Logger{
+		level:     level,
+		verbosity: verbosity,
+		fields:    fields,
+	}
+}
+
+// Setup replaces grpc global logger with infralog bridge.
+// Must be called before any grpc activity, see grpclog.SetLoggerV2.
+func Setup(level zapcore.Level, verbosity int, component string) {
+	grpclog.SetLoggerV2(NewLogger(level, verbosity, component))
+}
+
+func (l *Logger) log(level zapcore.Level, msg string) {
+	if level < l.level {
+		return
+	}
+
+	switch level {
+	case zapcore.InfoLevel:
+		infralog.Info(msg, l.fields...)
+	case zapcore.WarnLevel:
+		infralog.Warn(msg, l.fields...)
+	case zapcore.ErrorLevel:
+		infralog.Error(msg, l.fields...)
+	case zapcore.FatalLevel:
+		infralog.Fatal(msg, l.fields...)
+	}
+}
+
+func (l *Logger) Info(args ...interface{})   { l.log(zapcore.InfoLevel, fmt.Sprint(args...)) }
+func (l *Logger) Infoln(args ...interface{}) { l.log(zapcore.InfoLevel, sprintln(args...)) }
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.log(zapcore.InfoLevel, fmt.Sprintf(format, args...))
+}
+
+func (l *Logger) Warning(args ...interface{})   { l.log(zapcore.WarnLevel, fmt.Sprint(args...)) }
+func (l *Logger) Warningln(args ...interface{}) { l.log(zapcore.WarnLevel, sprintln(args...)) }
+func (l *Logger) Warningf(format string, args ...interface{}) {
+	l.log(zapcore.WarnLevel, fmt.Sprintf(format, args...))
+}
+
+func (l *Logger) Error(args ...interface{})   { l.log(zapcore.ErrorLevel, fmt.Sprint(args...)) }
+func (l *Logger) Errorln(args ...interface{}) { l.log(zapcore.ErrorLevel, sprintln(args...)) }
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.log(zapcore.ErrorLevel, fmt.Sprintf(format, args...))
+}
+
+func (l *Logger) Fatal(args ...interface{})   { l.log(zapcore.FatalLevel, fmt.Sprint(args...)) }
+func (l *Logger) Fatalln(args ...interface{}) { l.log(zapcore.FatalLevel, sprintln(args...)) }
+func (l *Logger) Fatalf(format string, args ...interface{}) {
+	l.log(zapcore.FatalLevel, fmt.Sprintf(format, args...))
+}
+
+func (l *Logger) V(level int) bool {
+	return level <= l.verbosity
+}
+
+func sprintln(args ...interface{}) string {
+	s := fmt.Sprintln(args...)
+	return s[:len(s)-1]
+}
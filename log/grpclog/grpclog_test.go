@@ -0,0 +1,59 @@
+package infragrpclog
+
+import (
+	"testing"
+
+	infralog "github.com/pushwoosh/infra/log"
+	"go.uber.org/zap/zapcore"
+)
+
+// entries are collected from the grpclog component only, handlers can't be unregistered
+var entries []*infralog.LogEntry
+
+func init() {
+	infralog.RegisterLogHandler(func(entry *infralog.LogEntry) {
+		for _, field := range entry.Fields {
+			if field.Key == infralog.ComponentField && field.String == "grpclog" {
+				entries = append(entries, entry)
+			}
+		}
+	})
+}
+
+func TestLogger(t *testing.T) {
+	entries = nil
+
+	l := NewLogger(zapcore.WarnLevel, 2, "grpclog")
+	l.Info("dropped")
+	l.Infof("dropped %d", 1)
+	l.Warning("transport ", "closing")
+	l.Warningln("server", "stopped")
+	l.Errorf("dial %s", "failed")
+
+	expected := []struct {
+		level zapcore.Level
+		msg   string
+	}{
+		{zapcore.WarnLevel, "transport closing"},
+		{zapcore.WarnLevel, "server stopped"},
+		{zapcore.ErrorLevel, "dial failed"},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d entries, got %d", len(expected), len(entries))
+	}
+	for i, e := range expected {
+		if entries[i].Level != e.level || entries[i].Message != e.msg {
+			t.Errorf("expected %s %q, got %s %q", e.level, e.msg, entries[i].Level, entries[i].Message)
+		}
+	}
+}
+
+func TestLoggerV(t *testing.T) {
+	l := NewLogger(zapcore.InfoLevel, 2, "")
+	if !l.V(0) || !l.V(2) {
+		t.Error("expected levels up to the verbosity enabled")
+	}
+	if l.V(3) {
+		t.Error("expected levels above the verbosity disabled")
+	}
+}
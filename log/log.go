@@ -15,6 +15,10 @@ func Setup(cfg *Config) *zap.Logger {
 	}
 	setup = true
 
+	if err := cfg.Validate(); err != nil {
+		panic(errors.Wrap(err, "infralog: invalid config"))
+	}
+
 	// build logger
 	var loggerConfig zap.Config
 	if cfg.Environment == EnvironmentDevelopment {
@@ -32,7 +36,37 @@ func Setup(cfg *Config) *zap.Logger {
 		panic(errors.Wrap(err, "Unable to create Logger"))
 	}
 
-	// setup default logging handler that simply passes all logs to the zap logger
+	// attach network outputs
+	if len(cfg.Outputs) > 0 {
+		outputCores := make([]zapcore.Core, 0, len(cfg.Outputs))
+		for _, output := range cfg.Outputs {
+			core, err := newOutputCore(output, loggerConfig.Level)
+			if err != nil {
+				panic(errors.Wrapf(err, "infralog: output %s", output.Address))
+			}
+			outputCores = append(outputCores, core)
+		}
+
+		l = l.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewTee(append([]zapcore.Core{core}, outputCores...)...)
+		}))
+	}
+
+	// run shutdown sequence registered with OnFatal before exiting on fatal entries
+	hook := &fatalHook{timeout: cfg.GetFatalShutdownTimeout()}
+	l = l.WithOptions(zap.WithFatalHook(hook))
+	hook.sync = l.Sync
+
+	// populate service metadata automatically unless it was set explicitly
+	globalFieldsMu.RLock()
+	isGlobalFieldsSet := globalFieldsSet
+	globalFieldsMu.RUnlock()
+	if !isGlobalFieldsSet {
+		SetGlobalFieldsFromEnvironment()
+	}
+
+	// setup default logging handler that simply passes all logs to the zap logger.
+	// global fields are already attached to the entry by handleEntry
 	RegisterLogHandler(func(entry *LogEntry) {
 		switch entry.Level {
 		case zapcore.DebugLevel:
@@ -48,5 +82,8 @@ func Setup(cfg *Config) *zap.Logger {
 		}
 	})
 
-	return l
+	// the returned logger is used directly, so global fields are attached by the core
+	return l.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &globalFieldsCore{Core: core}
+	}))
 }
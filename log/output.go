@@ -0,0 +1,410 @@
+package infralog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap/zapcore"
+)
+
+const outputSyncTimeout = time.Second * 5
+
+// encodeFunc converts log entry into a network message
+type encodeFunc func(entry zapcore.Entry, fields map[string]interface{}) ([]byte, error)
+
+// outputCore is a zap core that sends encoded entries to a remote log collector
+type outputCore struct {
+	zapcore.LevelEnabler
+
+	fields []zapcore.Field
+	encode encodeFunc
+	sender *outputSender
+}
+
+var (
+	outputsMu sync.Mutex
+	outputs   []*outputSender
+)
+
+// CloseOutputs sends entries queued for network outputs and stops them, call it before the process exits:
+//
+//	logger := infralog.Setup(cfg)
+//	defer infralog.CloseOutputs(context.Background())
+//
+// Entries not sent before ctx is done stay in the spool file if it's set and are dropped otherwise.
+// Entries logged after CloseOutputs are not sent to network outputs
+func CloseOutputs(ctx context.Context) error {
+	outputsMu.Lock()
+	closing := append([]*outputSender(nil), outputs...)
+	outputsMu.Unlock()
+
+	var closeErr error
+	for _, sender := range closing {
+		if err := sender.close(ctx); err != nil && closeErr == nil {
+			closeErr = err
+		}
+	}
+
+	return closeErr
+}
+
+func newOutputCore(cfg *OutputConfig, level zapcore.LevelEnabler) (*outputCore, error) {
+	if cfg.Level != "" {
+		level = logLevels[cfg.Level]
+	}
+
+	var (
+		encode    encodeFunc
+		delimiter []byte
+	)
+
+	switch cfg.Format {
+	case OutputFormatGELF:
+		encode = encodeGELF
+		delimiter = []byte{0}
+	case OutputFormatLogstash:
+		encode = encodeLogstash
+		delimiter = []byte{'\n'}
+	}
+
+	sender := &outputSender{
+		network:           cfg.GetProtocol(),
+		address:           cfg.Address,
+		reconnectInterval: cfg.GetReconnectInterval(),
+		queue:             make(chan []byte, cfg.GetBufferSize()),
+		wake:              make(chan struct{}, 1),
+		stop:              make(chan struct{}),
+		done:              make(chan struct{}),
+	}
+
+	if sender.network == OutputProtocolTCP {
+		sender.delimiter = delimiter
+	} else {
+		sender.chunked = cfg.Format == OutputFormatGELF
+	}
+
+	if cfg.SpoolFile != "" {
+		spool, err := openOutputSpool(cfg.SpoolFile, cfg.GetSpoolMaxBytes())
+		if err != nil {
+			return nil, err
+		}
+		sender.spool = spool
+	}
+
+	outputsMu.Lock()
+	outputs = append(outputs, sender)
+	outputsMu.Unlock()
+
+	go sender.run()
+
+	return &outputCore{
+		LevelEnabler: level,
+		encode:       encode,
+		sender:       sender,
+	}, nil
+}
+
+func (c *outputCore) With(fields []zapcore.Field) zapcore.Core {
+	return &outputCore{
+		LevelEnabler: c.LevelEnabler,
+		fields:       append(append([]zapcore.Field(nil), c.fields...), fields...),
+		encode:       c.encode,
+		sender:       c.sender,
+	}
+}
+
+func (c *outputCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *outputCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for i := range c.fields {
+		c.fields[i].AddTo(enc)
+	}
+	for i := range fields {
+		fields[i].AddTo(enc)
+	}
+
+	msg, err := c.encode(entry, enc.Fields)
+	if err != nil {
+		return err
+	}
+
+	c.sender.send(msg)
+
+	// make sure that fatal entries are delivered before the process exits
+	if entry.Level > zapcore.ErrorLevel {
+		return c.Sync()
+	}
+
+	return nil
+}
+
+func (c *outputCore) Sync() error {
+	return c.sender.flush(outputSyncTimeout)
+}
+
+// outputSender delivers messages in background.
+// While the remote side is unavailable messages are kept in the queue and then in the spool file if it's set.
+type outputSender struct {
+	network           string
+	address           string
+	delimiter         []byte
+	chunked           bool
+	reconnectInterval time.Duration
+
+	queue    chan []byte
+	spool    *outputSpool
+	wake     chan struct{}
+	inFlight atomic.Int64
+	dropped  atomic.Int64
+
+	closed    atomic.Bool
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+	unsent    []byte // message being sent when the sender was stopped
+
+	conn net.Conn
+	mu   sync.Mutex
+}
+
+func (s *outputSender) send(msg []byte) {
+	if s.closed.Load() {
+		return
+	}
+
+	s.inFlight.Add(1)
+
+	// spooled messages are older than new ones, so new ones are spooled too until the spool is sent
+	if s.spool != nil && s.spool.pending() {
+		s.spoolMessage(msg)
+		return
+	}
+
+	for {
+		select {
+		case s.queue <- msg:
+			return
+		default:
+		}
+
+		if s.spool != nil {
+			s.spoolMessage(msg)
+			return
+		}
+
+		// buffer is full: drop the oldest message to make room for the new one
+		select {
+		case <-s.queue:
+			s.inFlight.Add(-1)
+			if s.dropped.Add(1) == 1 {
+				_, _ = fmt.Fprintf(os.Stderr, "infralog: output %s buffer is full, dropping entries\n", s.address)
+			}
+		default:
+		}
+	}
+}
+
+func (s *outputSender) spoolMessage(msg []byte) {
+	if err := s.spool.append(msg); err != nil {
+		s.inFlight.Add(-1)
+		if s.dropped.Add(1) == 1 {
+			_, _ = fmt.Fprintf(os.Stderr, "infralog: output %s unable to spool entries, dropping them: %s\n", s.address, err)
+		}
+		return
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// flush waits until all queued messages are sent or timeout expires
+func (s *outputSender) flush(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return s.flushCtx(ctx)
+}
+
+func (s *outputSender) flushCtx(ctx context.Context) error {
+	for s.inFlight.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("infralog: output %s flush timeout", s.address)
+		case <-s.done:
+			return nil
+		case <-time.After(time.Millisecond * 10):
+		}
+	}
+
+	return nil
+}
+
+// close sends queued messages until ctx is done and stops the sender
+func (s *outputSender) close(ctx context.Context) error {
+	s.closeOnce.Do(func() {
+		outputsMu.Lock()
+		for i, sender := range outputs {
+			if sender == s {
+				outputs = append(outputs[:i], outputs[i+1:]...)
+				break
+			}
+		}
+		outputsMu.Unlock()
+
+		s.closed.Store(true)
+		s.closeErr = s.flushCtx(ctx)
+
+		close(s.stop)
+		<-s.done
+		s.closeConn()
+
+		if s.spool == nil {
+			return
+		}
+
+		// messages not sent yet are sent after a restart
+		if s.unsent != nil {
+			_ = s.spool.append(s.unsent)
+		}
+		for len(s.queue) > 0 {
+			if err := s.spool.append(<-s.queue); err != nil {
+				break
+			}
+		}
+		if err := s.spool.close(); err != nil && s.closeErr == nil {
+			s.closeErr = err
+		}
+	})
+
+	return s.closeErr
+}
+
+func (s *outputSender) run() {
+	defer close(s.done)
+
+	for {
+		msg, spooled, ok := s.next()
+		if !ok {
+			return
+		}
+
+		for {
+			if err := s.write(msg); err == nil {
+				break
+			}
+
+			s.closeConn()
+			select {
+			case <-time.After(s.reconnectInterval):
+			case <-s.stop:
+				if !spooled {
+					s.unsent = msg
+				}
+				return
+			}
+		}
+
+		if spooled {
+			if err := s.spool.ack(); err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "infralog: output %s: %s\n", s.address, err)
+			}
+		}
+
+		s.inFlight.Add(-1)
+		if dropped := s.dropped.Swap(0); dropped > 0 {
+			_, _ = fmt.Fprintf(os.Stderr, "infralog: output %s dropped %d entries\n", s.address, dropped)
+		}
+	}
+}
+
+// next returns the next message to send: queued ones first, then spooled ones. Returns false when stopped
+func (s *outputSender) next() (msg []byte, spooled bool, ok bool) {
+	for {
+		select {
+		case msg = <-s.queue:
+			return msg, false, true
+		case <-s.stop:
+			return nil, false, false
+		default:
+		}
+
+		if s.spool != nil {
+			msg, err := s.spool.peek()
+			if err == nil {
+				return msg, true, true
+			}
+			if !errors.Is(err, io.EOF) {
+				_, _ = fmt.Fprintf(os.Stderr, "infralog: output %s dropping spooled entries: %s\n", s.address, err)
+				_ = s.spool.reset()
+			}
+		}
+
+		select {
+		case msg = <-s.queue:
+			return msg, false, true
+		case <-s.wake:
+		case <-s.stop:
+			return nil, false, false
+		}
+	}
+}
+
+func (s *outputSender) write(msg []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		conn, err := net.DialTimeout(s.network, s.address, s.reconnectInterval)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+
+	if s.chunked {
+		chunks := chunkGELF(msg)
+		if chunks == nil {
+			// retrying won't help, the message is dropped
+			_, _ = fmt.Fprintf(os.Stderr, "infralog: output %s dropped entry of %d bytes exceeding GELF chunk limit\n", s.address, len(msg))
+			return nil
+		}
+		for _, chunk := range chunks {
+			if _, err := s.conn.Write(chunk); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if _, err := s.conn.Write(append(msg, s.delimiter...)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *outputSender) closeConn() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+}
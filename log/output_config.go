@@ -0,0 +1,119 @@
+package infralog
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	OutputFormatGELF     = "gelf"
+	OutputFormatLogstash = "logstash"
+
+	OutputProtocolUDP = "udp"
+	OutputProtocolTCP = "tcp"
+
+	DefaultOutputBufferSize        = 10000
+	DefaultOutputReconnectInterval = time.Second * 5
+	DefaultOutputSpoolMaxBytes     = 1 << 30
+)
+
+// OutputConfig is a network log output configuration
+type OutputConfig struct {
+	// Output format: "gelf" or "logstash"
+	Format string `mapstructure:"format"`
+
+	// Transport protocol: "udp" or "tcp". Logstash supports tcp only
+	// optional, default: "tcp"
+	Protocol string `mapstructure:"protocol"`
+
+	// Remote address. "host:port"
+	Address string `mapstructure:"address"`
+
+	// Minimal level of entries sent to the output. Default is the logger level
+	Level string `mapstructure:"level"`
+
+	// Number of entries kept in memory while the remote side is unavailable.
+	// Oldest entries are dropped when the buffer is full unless SpoolFile is set
+	// optional, default: DefaultOutputBufferSize
+	BufferSize int `mapstructure:"buffer_size"`
+
+	// File the entries are appended to when the buffer is full, they are sent after the remote side is back
+	// and after a restart. Entries sent right before a crash may be sent again after it
+	// optional, entries are dropped by default
+	SpoolFile string `mapstructure:"spool_file"`
+
+	// Max size of entries in SpoolFile, new entries are dropped when it's reached
+	// optional, default: DefaultOutputSpoolMaxBytes
+	SpoolMaxBytes int64 `mapstructure:"spool_max_bytes"`
+
+	// Delay between reconnection attempts
+	// optional, default: DefaultOutputReconnectInterval
+	ReconnectInterval time.Duration `mapstructure:"reconnect_interval"`
+}
+
+func (c *OutputConfig) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if c.Format != OutputFormatGELF && c.Format != OutputFormatLogstash {
+		return errors.Errorf("invalid format \"%s\". expected \"gelf\" or \"logstash\"", c.Format)
+	}
+
+	if protocol := c.GetProtocol(); protocol != OutputProtocolUDP && protocol != OutputProtocolTCP {
+		return errors.Errorf("invalid protocol \"%s\". expected \"udp\" or \"tcp\"", protocol)
+	}
+
+	if c.Format == OutputFormatLogstash && c.GetProtocol() != OutputProtocolTCP {
+		return errors.New("logstash output supports tcp protocol only")
+	}
+
+	if c.Address == "" {
+		return errors.New("address is mandatory")
+	}
+
+	if c.SpoolMaxBytes < 0 {
+		return errors.New("spool_max_bytes should be greater than or equal to 0")
+	}
+
+	if c.Level != "" {
+		if _, ok := logLevels[c.Level]; !ok {
+			return errors.Errorf("invalid log level \"%s\"", c.Level)
+		}
+	}
+
+	return nil
+}
+
+func (c *OutputConfig) GetProtocol() string {
+	if c.Protocol == "" {
+		return OutputProtocolTCP
+	}
+
+	return c.Protocol
+}
+
+func (c *OutputConfig) GetBufferSize() int {
+	if c.BufferSize <= 0 {
+		return DefaultOutputBufferSize
+	}
+
+	return c.BufferSize
+}
+
+func (c *OutputConfig) GetReconnectInterval() time.Duration {
+	if c.ReconnectInterval <= 0 {
+		return DefaultOutputReconnectInterval
+	}
+
+	return c.ReconnectInterval
+}
+
+func (c *OutputConfig) GetSpoolMaxBytes() int64 {
+	if c.SpoolMaxBytes <= 0 {
+		return DefaultOutputSpoolMaxBytes
+	}
+
+	return c.SpoolMaxBytes
+}
@@ -0,0 +1,111 @@
+package infralog
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"os"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	gelfVersion = "1.1"
+
+	// https://go2docs.graylog.org/current/getting_in_log_data/gelf.html#GELFviaUDP
+	gelfChunkSize       = 8192
+	gelfChunkHeaderSize = 12
+	gelfChunkMaxCount   = 128
+)
+
+var gelfChunkMagic = []byte{0x1e, 0x0f}
+
+var outputHostname, _ = os.Hostname()
+
+// syslog severity levels used by GELF
+var gelfLevels = map[zapcore.Level]int{
+	zapcore.DebugLevel:  7,
+	zapcore.InfoLevel:   6,
+	zapcore.WarnLevel:   4,
+	zapcore.ErrorLevel:  3,
+	zapcore.DPanicLevel: 2,
+	zapcore.PanicLevel:  2,
+	zapcore.FatalLevel:  2,
+}
+
+func encodeGELF(entry zapcore.Entry, fields map[string]interface{}) ([]byte, error) {
+	msg := make(map[string]interface{}, len(fields)+6)
+	for k, v := range fields {
+		// "_id" is reserved by GELF
+		if k == "id" {
+			k = "id_"
+		}
+		msg["_"+k] = v
+	}
+
+	msg["version"] = gelfVersion
+	msg["host"] = outputHostname
+	msg["short_message"] = entry.Message
+	msg["timestamp"] = float64(entry.Time.UnixNano()) / float64(time.Second)
+	msg["level"] = gelfLevels[entry.Level]
+	msg["_level_name"] = entry.Level.String()
+	if entry.Stack != "" {
+		msg["full_message"] = entry.Message + "\n" + entry.Stack
+	}
+
+	return json.Marshal(msg)
+}
+
+func encodeLogstash(entry zapcore.Entry, fields map[string]interface{}) ([]byte, error) {
+	msg := make(map[string]interface{}, len(fields)+5)
+	for k, v := range fields {
+		msg[k] = v
+	}
+
+	msg["@timestamp"] = entry.Time.UTC().Format(time.RFC3339Nano)
+	msg["@version"] = "1"
+	msg["host"] = outputHostname
+	msg["message"] = entry.Message
+	msg["level"] = entry.Level.String()
+	if entry.Stack != "" {
+		msg["stacktrace"] = entry.Stack
+	}
+
+	return json.Marshal(msg)
+}
+
+// chunkGELF splits message into GELF UDP chunks.
+// It returns nil for messages that do not fit into the maximum chunk count:
+// Graylog discards incomplete chunk sequences, so a truncated message would never be delivered.
+func chunkGELF(msg []byte) [][]byte {
+	if len(msg) <= gelfChunkSize {
+		return [][]byte{msg}
+	}
+
+	const payloadSize = gelfChunkSize - gelfChunkHeaderSize
+
+	count := (len(msg) + payloadSize - 1) / payloadSize
+	if count > gelfChunkMaxCount {
+		return nil
+	}
+
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+
+	chunks := make([][]byte, 0, count)
+	for i := 0; i < count; i++ {
+		end := (i + 1) * payloadSize
+		if end > len(msg) {
+			end = len(msg)
+		}
+
+		chunk := make([]byte, 0, gelfChunkHeaderSize+end-i*payloadSize)
+		chunk = append(chunk, gelfChunkMagic...)
+		chunk = append(chunk, id...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, msg[i*payloadSize:end]...)
+		chunks = append(chunks, chunk)
+	}
+
+	return chunks
+}
@@ -0,0 +1,50 @@
+package infralog
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_chunkGELF_small(t *testing.T) {
+	msg := []byte("{}")
+	chunks := chunkGELF(msg)
+	if len(chunks) != 1 || !bytes.Equal(chunks[0], msg) {
+		t.Error("expected message to be sent without chunking")
+	}
+}
+
+func Test_chunkGELF_large(t *testing.T) {
+	msg := bytes.Repeat([]byte("a"), gelfChunkSize*2)
+	chunks := chunkGELF(msg)
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d", len(chunks))
+	}
+
+	var payload []byte
+	for i, chunk := range chunks {
+		if len(chunk) > gelfChunkSize {
+			t.Errorf("chunk %d is too large: %d", i, len(chunk))
+		}
+		if !bytes.Equal(chunk[:2], gelfChunkMagic) {
+			t.Errorf("chunk %d: invalid magic bytes", i)
+		}
+		if !bytes.Equal(chunk[2:10], chunks[0][2:10]) {
+			t.Errorf("chunk %d: message id mismatch", i)
+		}
+		if int(chunk[10]) != i || int(chunk[11]) != len(chunks) {
+			t.Errorf("chunk %d: invalid sequence header", i)
+		}
+		payload = append(payload, chunk[gelfChunkHeaderSize:]...)
+	}
+
+	if !bytes.Equal(payload, msg) {
+		t.Error("reassembled payload does not match the message")
+	}
+}
+
+func Test_chunkGELF_tooLarge(t *testing.T) {
+	msg := bytes.Repeat([]byte("a"), (gelfChunkSize-gelfChunkHeaderSize)*gelfChunkMaxCount+1)
+	if chunks := chunkGELF(msg); chunks != nil {
+		t.Fatalf("expected message exceeding %d chunks to be rejected, got %d chunks", gelfChunkMaxCount, len(chunks))
+	}
+}
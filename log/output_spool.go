@@ -0,0 +1,155 @@
+package infralog
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// record header: payload length
+const spoolHeaderSize = 4
+
+var errOutputSpoolFull = errors.New("spool file is full")
+
+// outputSpool is a file of entries not sent yet. Records are read from the start and the file is truncated
+// when all of them are sent, a record read but not acked before a crash is read again after a restart.
+type outputSpool struct {
+	mu       sync.Mutex
+	file     *os.File
+	maxBytes int64
+	size     int64
+	readOff  int64
+	peeked   int64
+}
+
+func openOutputSpool(path string, maxBytes int64) (*outputSpool, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o640)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open spool file")
+	}
+
+	s := &outputSpool{
+		file:     file,
+		maxBytes: maxBytes,
+	}
+
+	if err = s.load(); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// load finds the end of the last complete record, a torn record left by a crash is truncated
+func (s *outputSpool) load() error {
+	info, err := s.file.Stat()
+	if err != nil {
+		return errors.Wrap(err, "unable to stat spool file")
+	}
+
+	header := make([]byte, spoolHeaderSize)
+	for s.size+spoolHeaderSize <= info.Size() {
+		if _, err = s.file.ReadAt(header, s.size); err != nil {
+			return errors.Wrap(err, "unable to read spool file")
+		}
+		next := s.size + spoolHeaderSize + int64(binary.BigEndian.Uint32(header))
+		if next > info.Size() {
+			break
+		}
+		s.size = next
+	}
+
+	if s.size < info.Size() {
+		return errors.Wrap(s.file.Truncate(s.size), "unable to truncate spool file")
+	}
+	return nil
+}
+
+func (s *outputSpool) append(msg []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	size := int64(spoolHeaderSize + len(msg))
+	if s.size-s.readOff+size > s.maxBytes {
+		return errOutputSpoolFull
+	}
+
+	buf := make([]byte, size)
+	binary.BigEndian.PutUint32(buf, uint32(len(msg)))
+	copy(buf[spoolHeaderSize:], msg)
+
+	if _, err := s.file.WriteAt(buf, s.size); err != nil {
+		// drop a partial write, otherwise the next records would follow garbage
+		_ = s.file.Truncate(s.size)
+		return errors.Wrap(err, "unable to write spool file")
+	}
+	s.size += size
+
+	return nil
+}
+
+// pending returns true if there are records not acked yet
+func (s *outputSpool) pending() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.readOff < s.size
+}
+
+// peek returns the first record not acked yet, io.EOF if there is none
+func (s *outputSpool) peek() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.readOff >= s.size {
+		return nil, io.EOF
+	}
+
+	header := make([]byte, spoolHeaderSize)
+	if _, err := s.file.ReadAt(header, s.readOff); err != nil {
+		return nil, errors.Wrap(err, "unable to read spool file")
+	}
+
+	msg := make([]byte, binary.BigEndian.Uint32(header))
+	if _, err := s.file.ReadAt(msg, s.readOff+spoolHeaderSize); err != nil {
+		return nil, errors.Wrap(err, "unable to read spool file")
+	}
+	s.peeked = int64(spoolHeaderSize + len(msg))
+
+	return msg, nil
+}
+
+// ack removes the record returned by the last peek, the file is truncated when all records are acked
+func (s *outputSpool) ack() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.readOff += s.peeked
+	s.peeked = 0
+	if s.readOff < s.size {
+		return nil
+	}
+
+	s.readOff, s.size = 0, 0
+	return errors.Wrap(s.file.Truncate(0), "unable to truncate spool file")
+}
+
+// reset drops all records, e.g. when they can't be read
+func (s *outputSpool) reset() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.readOff, s.size, s.peeked = 0, 0, 0
+	return errors.Wrap(s.file.Truncate(0), "unable to truncate spool file")
+}
+
+func (s *outputSpool) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close()
+}
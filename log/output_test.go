@@ -0,0 +1,98 @@
+package infralog
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestOutputSpool(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := ln.Addr().String()
+	_ = ln.Close()
+
+	cfg := &OutputConfig{
+		Format:            OutputFormatLogstash,
+		Address:           address,
+		BufferSize:        1,
+		ReconnectInterval: time.Millisecond * 10,
+		SpoolFile:         filepath.Join(t.TempDir(), "output.spool"),
+	}
+
+	// the collector is down: entries are spooled and kept on close
+	core, err := newOutputCore(cfg, zapcore.InfoLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range []string{"one", "two", "three"} {
+		core.sender.send([]byte(msg))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	if err = core.sender.close(ctx); err == nil {
+		t.Fatal("expected flush timeout")
+	}
+	core.sender.send([]byte("after close"))
+
+	ln, err = net.Listen("tcp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	// the collector is back: spooled entries are sent after a restart
+	core, err = newOutputCore(cfg, zapcore.InfoLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = core.sender.close(context.Background()) }()
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+
+	received := map[string]bool{}
+	scanner := bufio.NewScanner(conn)
+	for len(received) < 3 && scanner.Scan() {
+		received[scanner.Text()] = true
+	}
+	if !received["one"] || !received["two"] || !received["three"] {
+		t.Fatalf("expected all entries, got %v", received)
+	}
+
+	if err = core.sender.flush(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if core.sender.spool.pending() {
+		t.Fatal("expected sent entries removed from the spool")
+	}
+}
+
+func TestCloseOutputs(t *testing.T) {
+	core, err := newOutputCore(&OutputConfig{Format: OutputFormatLogstash, Address: "127.0.0.1:1"}, zapcore.InfoLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = CloseOutputs(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-core.sender.done:
+	default:
+		t.Fatal("expected the sender stopped")
+	}
+}
@@ -0,0 +1,69 @@
+package infralog
+
+import (
+	"log"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ComponentField is a field name that is used by bridges to mark third-party log entries
+const ComponentField = "component"
+
+// Writer is an io.Writer that converts each written line into a log entry with the given level.
+// Useful for libraries that accept io.Writer or *log.Logger for logging.
+type Writer struct {
+	level  zapcore.Level
+	fields []zap.Field
+}
+
+// NewWriter creates a new Writer.
+// component is put into "component" field of every entry, empty value is omitted.
+func NewWriter(level zapcore.Level, component string, fields ...zap.Field) *Writer {
+	if component != "" {
+		fields = append([]zap.Field{zap.String(ComponentField, component)}, fields...)
+	}
+
+	return &Writer{
+		level:  level,
+		fields: fields,
+	}
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		handleEntry(&LogEntry{Level: w.level, Message: line, Fields: append([]zap.Field(nil), w.fields...)})
+	}
+
+	return len(p), nil
+}
+
+// NewStdLogger creates a standard library logger that writes to infralog.
+// Can be used as http.Server.ErrorLog:
+//
+//	srv := &http.Server{ErrorLog: infralog.NewStdLogger(zapcore.WarnLevel, "http")}
+func NewStdLogger(level zapcore.Level, component string) *log.Logger {
+	return log.New(NewWriter(level, component), "", 0)
+}
+
+// RedirectStdLog redirects output of the standard library "log" package to infralog.
+// Returns function that restores previous output and flags.
+func RedirectStdLog(level zapcore.Level, component string) func() {
+	prevFlags := log.Flags()
+	prevPrefix := log.Prefix()
+	prevWriter := log.Writer()
+
+	log.SetFlags(0)
+	log.SetPrefix("")
+	log.SetOutput(NewWriter(level, component))
+
+	return func() {
+		log.SetFlags(prevFlags)
+		log.SetPrefix(prevPrefix)
+		log.SetOutput(prevWriter)
+	}
+}
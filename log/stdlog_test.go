@@ -0,0 +1,80 @@
+package infralog
+
+import (
+	"log"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// captureEntries replaces log handlers with one collecting entries until the test ends
+func captureEntries(t *testing.T) *[]*LogEntry {
+	saved := handlers
+	t.Cleanup(func() { handlers = saved })
+
+	var entries []*LogEntry
+	handlers = []func(entry *LogEntry){func(entry *LogEntry) { entries = append(entries, entry) }}
+
+	return &entries
+}
+
+func TestWriter(t *testing.T) {
+	entries := captureEntries(t)
+
+	w := NewWriter(zapcore.WarnLevel, "http")
+	n, err := w.Write([]byte("first\n\nsecond\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len("first\n\nsecond\n") {
+		t.Fatalf("expected all bytes written, got %d", n)
+	}
+
+	if len(*entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(*entries))
+	}
+	for i, msg := range []string{"first", "second"} {
+		entry := (*entries)[i]
+		if entry.Message != msg || entry.Level != zapcore.WarnLevel {
+			t.Errorf("expected warn %q, got %s %q", msg, entry.Level, entry.Message)
+		}
+		if len(entry.Fields) == 0 || entry.Fields[0].Key != ComponentField || entry.Fields[0].String != "http" {
+			t.Errorf("expected component field, got %v", entry.Fields)
+		}
+	}
+}
+
+func TestWriterWithoutComponent(t *testing.T) {
+	entries := captureEntries(t)
+
+	_, _ = NewWriter(zapcore.InfoLevel, "").Write([]byte("message"))
+
+	if len(*entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(*entries))
+	}
+	for _, field := range (*entries)[0].Fields {
+		if field.Key == ComponentField {
+			t.Fatal("empty component must be omitted")
+		}
+	}
+}
+
+func TestRedirectStdLog(t *testing.T) {
+	entries := captureEntries(t)
+
+	prevWriter, prevFlags := log.Writer(), log.Flags()
+
+	restore := RedirectStdLog(zapcore.ErrorLevel, "stdlog")
+	log.Printf("connection %s", "refused")
+	restore()
+
+	if len(*entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(*entries))
+	}
+	if entry := (*entries)[0]; entry.Message != "connection refused" || entry.Level != zapcore.ErrorLevel {
+		t.Fatalf("expected error without flags, got %s %q", entry.Level, entry.Message)
+	}
+	if log.Writer() != prevWriter || log.Flags() != prevFlags {
+		t.Fatal("expected previous output and flags restored")
+	}
+}
@@ -0,0 +1,168 @@
+package inframail
+
+import (
+	"context"
+	"net/mail"
+	"net/textproto"
+	"time"
+
+	"github.com/pkg/errors"
+	infraratelimit "github.com/pushwoosh/infra/ratelimit"
+	infraretry "github.com/pushwoosh/infra/retry"
+)
+
+// Client sends messages through a pool of SMTP connections.
+// Temporary failures (network errors and 4xx replies) are retried with backoff, 5xx replies are not.
+type Client struct {
+	name    string
+	cfg     *ConnectionConfig
+	pool    *pool
+	limiter infraratelimit.Limiter
+	retrier *infraretry.Retrier
+}
+
+func NewClient(name string, cfg *ConnectionConfig) (*Client, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	initMetrics()
+
+	p, err := newPool(name, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		name: name,
+		cfg:  cfg,
+		pool: p,
+		retrier: infraretry.New("mail."+name,
+			infraretry.WithMaxAttempts(cfg.GetMaxAttempts()),
+			infraretry.WithBackoff(cfg.GetBackoff()),
+			infraretry.WithRetryable(isTemporary),
+		),
+	}
+
+	if cfg.RateLimit != nil {
+		if c.limiter, err = infraratelimit.NewTokenBucket(*cfg.RateLimit); err != nil {
+			return nil, errors.Wrap(err, "rate_limit")
+		}
+	}
+
+	return c, nil
+}
+
+// Send sends a message. It blocks while the rate limit is exceeded or all connections are busy
+func (c *Client) Send(ctx context.Context, msg *Message) (err error) {
+	defer c.observe(time.Now(), &err)
+
+	from := msg.From
+	if from == "" {
+		from = c.cfg.From
+	}
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return errors.Wrapf(err, "invalid sender %q", from)
+	}
+
+	recipients, err := msg.recipients()
+	if err != nil {
+		return err
+	}
+
+	data, err := msg.build(sender, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return c.retrier.Do(ctx, func(ctx context.Context) error {
+		if c.limiter != nil {
+			if err := infraratelimit.Wait(ctx, c.limiter, c.name); err != nil {
+				return err
+			}
+		}
+		return c.send(ctx, sender.Address, recipients, data)
+	})
+}
+
+// SendTemplate renders the message template name into msg and sends it
+func (c *Client) SendTemplate(ctx context.Context, templates *Templates, name string, data interface{}, msg *Message) error {
+	if err := templates.Render(msg, name, data); err != nil {
+		return err
+	}
+	return c.Send(ctx, msg)
+}
+
+func (c *Client) send(ctx context.Context, from string, recipients []string, data []byte) error {
+	conn, err := c.pool.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = transmit(conn, from, recipients, data)
+
+	// the session is still usable after a rejection reply, but not after network errors
+	var protoErr *textproto.Error
+	c.pool.put(conn, err != nil && !errors.As(err, &protoErr))
+
+	return err
+}
+
+func transmit(conn *conn, from string, recipients []string, data []byte) error {
+	if err := conn.client.Mail(from); err != nil {
+		return errors.Wrap(err, "MAIL FROM")
+	}
+
+	for _, rcpt := range recipients {
+		if err := conn.client.Rcpt(rcpt); err != nil {
+			return errors.Wrapf(err, "RCPT TO %s", rcpt)
+		}
+	}
+
+	w, err := conn.client.Data()
+	if err != nil {
+		return errors.Wrap(err, "DATA")
+	}
+	if _, err = w.Write(data); err != nil {
+		return errors.Wrap(err, "unable to write message")
+	}
+	if err = w.Close(); err != nil {
+		return errors.Wrap(err, "message is not accepted")
+	}
+
+	return nil
+}
+
+// Ping checks that a connection to the server can be established
+func (c *Client) Ping(ctx context.Context) error {
+	conn, err := c.pool.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = conn.client.Noop()
+	c.pool.put(conn, err != nil)
+
+	return err
+}
+
+// Close closes idle connections. Connections in use are closed when they are returned
+func (c *Client) Close() error {
+	c.pool.close()
+	return nil
+}
+
+// isTemporary reports whether sending may succeed later: network errors and 4xx SMTP replies
+func isTemporary(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return protoErr.Code >= 400 && protoErr.Code < 500
+	}
+
+	return !infraretry.IsPermanent(err)
+}
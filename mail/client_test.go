@@ -0,0 +1,194 @@
+package inframail
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	infraretry "github.com/pushwoosh/infra/retry"
+)
+
+// fakeSMTP is a minimal SMTP server. rcptReplies are returned to RCPT commands in order, then 250
+type fakeSMTP struct {
+	ln          net.Listener
+	mu          sync.Mutex
+	rcptReplies []string
+	messages    []string
+}
+
+func newFakeSMTP(t *testing.T, rcptReplies ...string) *fakeSMTP {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &fakeSMTP{ln: ln, rcptReplies: rcptReplies}
+	go s.serve()
+	t.Cleanup(func() { _ = ln.Close() })
+
+	return s
+}
+
+func (s *fakeSMTP) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTP) handle(conn net.Conn) {
+	defer conn.Close()
+
+	tp := textproto.NewConn(conn)
+	_ = tp.PrintfLine("220 fake ESMTP")
+
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch cmd {
+		case "EHLO":
+			_ = tp.PrintfLine("250-fake")
+			_ = tp.PrintfLine("250 8BITMIME")
+		case "RCPT":
+			s.mu.Lock()
+			reply := "250 ok"
+			if len(s.rcptReplies) > 0 {
+				reply, s.rcptReplies = s.rcptReplies[0], s.rcptReplies[1:]
+			}
+			s.mu.Unlock()
+			_ = tp.PrintfLine("%s", reply)
+		case "DATA":
+			_ = tp.PrintfLine("354 go ahead")
+			data, _ := tp.ReadDotBytes()
+			s.mu.Lock()
+			s.messages = append(s.messages, string(data))
+			s.mu.Unlock()
+			_ = tp.PrintfLine("250 queued")
+		case "QUIT":
+			_ = tp.PrintfLine("221 bye")
+			return
+		default:
+			_ = tp.PrintfLine("250 ok")
+		}
+	}
+}
+
+func (s *fakeSMTP) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.messages...)
+}
+
+func newTestClient(t *testing.T, server *fakeSMTP) *Client {
+	client, err := NewClient("test", &ConnectionConfig{
+		Address:  server.ln.Addr().String(),
+		From:     "Service <noreply@example.com>",
+		Security: SecurityNone,
+		Backoff:  &infraretry.Exponential{Initial: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	return client
+}
+
+func TestClient_Send(t *testing.T) {
+	server := newFakeSMTP(t, "451 try again later")
+	client := newTestClient(t, server)
+
+	err := client.Send(context.Background(), &Message{
+		To:      []string{"User <user@example.com>"},
+		Bcc:     []string{"audit@example.com"},
+		Subject: "Привет",
+		Text:    "hello",
+		HTML:    "<b>hello</b>",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := server.received()
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	}
+
+	msg := messages[0]
+	for _, want := range []string{
+		"From: \"Service\" <noreply@example.com>",
+		"To: \"User\" <user@example.com>",
+		"Subject: =?utf-8?q?",
+		"multipart/alternative",
+		"<b>hello</b>",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message doesn't contain %q:\n%s", want, msg)
+		}
+	}
+	if strings.Contains(msg, "audit@example.com") {
+		t.Error("bcc recipient is visible in headers")
+	}
+}
+
+func TestClient_Send_rejected(t *testing.T) {
+	server := newFakeSMTP(t, "550 no such user", "250 ok")
+	client := newTestClient(t, server)
+
+	err := client.Send(context.Background(), &Message{
+		To:   []string{"user@example.com"},
+		Text: "hello",
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	if n := len(server.received()); n != 0 {
+		t.Fatalf("rejected message must not be retried, got %d messages", n)
+	}
+}
+
+func TestTemplates_Render(t *testing.T) {
+	fsys := fstest.MapFS{
+		"templates/welcome.subject.tmpl": {Data: []byte("Welcome,\n{{.Name}}!")},
+		"templates/welcome.txt.tmpl":     {Data: []byte("Hi {{.Name}}")},
+		"templates/welcome.html.tmpl":    {Data: []byte("<p>Hi {{.Name}}</p>")},
+		"templates/readme.md":            {Data: []byte("not a template")},
+	}
+
+	templates, err := ParseFS(fsys, "templates/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := &Message{}
+	if err = templates.Render(msg, "welcome", map[string]string{"Name": "<Bob>"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if msg.Subject != "Welcome, <Bob>!" {
+		t.Errorf("unexpected subject %q", msg.Subject)
+	}
+	if msg.Text != "Hi <Bob>" {
+		t.Errorf("unexpected text %q", msg.Text)
+	}
+	if msg.HTML != "<p>Hi &lt;Bob&gt;</p>" {
+		t.Errorf("unexpected html %q", msg.HTML)
+	}
+
+	if err = templates.Render(msg, "unknown", nil); err == nil {
+		t.Error("expected error for unknown template")
+	}
+}
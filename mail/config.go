@@ -0,0 +1,198 @@
+package inframail
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	infraratelimit "github.com/pushwoosh/infra/ratelimit"
+	infraretry "github.com/pushwoosh/infra/retry"
+)
+
+const (
+	SecurityStartTLS = "starttls"
+	SecurityTLS      = "tls"
+	SecurityNone     = "none"
+)
+
+const (
+	defaultPoolSize    = 2
+	defaultDialTimeout = 10 * time.Second
+	defaultTimeout     = 30 * time.Second
+	defaultIdleTimeout = time.Minute
+	defaultMaxAttempts = 3
+)
+
+var defaultBackoff = infraretry.Exponential{
+	Initial: time.Second,
+	Max:     30 * time.Second,
+	Jitter:  0.2,
+}
+
+type ConnectionsConfig map[string]*ConnectionConfig
+
+type ConnectionConfig struct {
+	// SMTP server address. "host:port"
+	Address string `mapstructure:"address"`
+
+	// Credentials for PLAIN auth. Auth is disabled if username is empty
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+
+	// Default sender address used if a message has no From. optional
+	From string `mapstructure:"from"`
+
+	// Connection security: starttls, tls (implicit TLS, usually port 465) or none. optional, default: starttls
+	Security string `mapstructure:"security"`
+
+	// TLS options for starttls and tls security. optional
+	TLS *TLSConfig `mapstructure:"tls"`
+
+	// Maximum number of open connections. optional, default: 2
+	PoolSize int `mapstructure:"pool_size"`
+
+	// optional, default: 10s
+	DialTimeout time.Duration `mapstructure:"dial_timeout"`
+
+	// Timeout of sending a single message. optional, default: 30s
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// Idle connections are closed after that period. optional, default: 1m
+	IdleTimeout time.Duration `mapstructure:"idle_timeout"`
+
+	// Limits sent messages rate, e.g. to fit provider quotas. optional, unlimited if empty
+	RateLimit *infraratelimit.Limit `mapstructure:"rate_limit"`
+
+	// Number of attempts to send a message on temporary errors. optional, default: 3
+	MaxAttempts int `mapstructure:"max_attempts"`
+
+	// Delays between attempts. optional, default: 1s, 2s, 4s ... 30s with 20% jitter
+	Backoff *infraretry.Exponential `mapstructure:"backoff"`
+}
+
+type TLSConfig struct {
+	// PEM encoded CA certificate file. System pool is used if empty
+	CAFile string `mapstructure:"ca_file"`
+
+	// PEM encoded client certificate and key files for mutual TLS
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+
+	// Server name used to verify the hostname. Default is the host from the address
+	ServerName string `mapstructure:"server_name"`
+
+	// Disables server certificate verification
+	InsecureSkipVerify bool `mapstructure:"insecure_skip_verify"`
+}
+
+func (c *ConnectionsConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	for name, conf := range *c {
+		if err := conf.Validate(); err != nil {
+			return errors.Wrap(err, name)
+		}
+	}
+
+	return nil
+}
+
+func (c *ConnectionConfig) Validate() error {
+	if c == nil {
+		return errors.New("empty connection config")
+	}
+
+	if c.Address == "" {
+		return errors.New("address is mandatory")
+	}
+
+	switch c.Security {
+	case "", SecurityStartTLS, SecurityTLS, SecurityNone:
+	default:
+		return errors.Errorf("unknown security %q", c.Security)
+	}
+
+	if c.TLS != nil {
+		if err := c.TLS.Validate(); err != nil {
+			return errors.Wrap(err, "tls")
+		}
+	}
+
+	if c.PoolSize < 0 {
+		return errors.New("pool_size should be greater than or equal to 0")
+	}
+
+	if c.MaxAttempts < 0 {
+		return errors.New("max_attempts should be greater than or equal to 0")
+	}
+
+	if c.RateLimit != nil {
+		if err := c.RateLimit.Validate(); err != nil {
+			return errors.Wrap(err, "rate_limit")
+		}
+	}
+
+	return nil
+}
+
+func (c *TLSConfig) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return errors.New("cert_file and key_file must be set together")
+	}
+
+	return nil
+}
+
+func (c *ConnectionConfig) GetSecurity() string {
+	if c.Security == "" {
+		return SecurityStartTLS
+	}
+	return c.Security
+}
+
+func (c *ConnectionConfig) GetPoolSize() int {
+	if c.PoolSize == 0 {
+		return defaultPoolSize
+	}
+	return c.PoolSize
+}
+
+func (c *ConnectionConfig) GetDialTimeout() time.Duration {
+	if c.DialTimeout == 0 {
+		return defaultDialTimeout
+	}
+	return c.DialTimeout
+}
+
+func (c *ConnectionConfig) GetTimeout() time.Duration {
+	if c.Timeout == 0 {
+		return defaultTimeout
+	}
+	return c.Timeout
+}
+
+func (c *ConnectionConfig) GetIdleTimeout() time.Duration {
+	if c.IdleTimeout == 0 {
+		return defaultIdleTimeout
+	}
+	return c.IdleTimeout
+}
+
+func (c *ConnectionConfig) GetMaxAttempts() int {
+	if c.MaxAttempts == 0 {
+		return defaultMaxAttempts
+	}
+	return c.MaxAttempts
+}
+
+func (c *ConnectionConfig) GetBackoff() infraretry.Exponential {
+	if c.Backoff == nil {
+		return defaultBackoff
+	}
+	return *c.Backoff
+}
@@ -0,0 +1,108 @@
+package inframail
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	infraoperator "github.com/pushwoosh/infra/operator"
+)
+
+// Container is a simple container for holding named mail clients
+type Container struct {
+	mu   *sync.RWMutex
+	cfg  map[string]ConnectionConfig
+	pool map[string]*Client
+}
+
+var (
+	_ infraoperator.Stopper = (*Container)(nil)
+	_ infraoperator.Checker = (*Container)(nil)
+)
+
+func NewContainer() *Container {
+	return &Container{
+		mu:   &sync.RWMutex{},
+		cfg:  make(map[string]ConnectionConfig),
+		pool: make(map[string]*Client),
+	}
+}
+
+// Connect creates a new named client and checks the SMTP server connection
+func (cont *Container) Connect(name string, cfg *ConnectionConfig) error {
+	client, err := NewClient(name, cfg)
+	if err != nil {
+		return err
+	}
+
+	if err = client.Ping(context.Background()); err != nil {
+		_ = client.Close()
+		return errors.Wrap(err, "cannot connect to SMTP server")
+	}
+
+	// replace existing client with the same name
+	cont.Remove(name)
+
+	cont.mu.Lock()
+	defer cont.mu.Unlock()
+
+	cont.pool[name] = client
+	cont.cfg[name] = *cfg
+
+	return nil
+}
+
+// Get gets client from a container
+func (cont *Container) Get(name string) *Client {
+	cont.mu.RLock()
+	defer cont.mu.RUnlock()
+
+	return cont.pool[name]
+}
+
+// Remove closes named client and removes it from the container
+func (cont *Container) Remove(name string) {
+	cont.mu.Lock()
+	client := cont.pool[name]
+	delete(cont.pool, name)
+	delete(cont.cfg, name)
+	cont.mu.Unlock()
+
+	if client != nil {
+		_ = client.Close()
+	}
+}
+
+// Check checks SMTP servers of all clients in the container
+func (cont *Container) Check(ctx context.Context) error {
+	cont.mu.RLock()
+	defer cont.mu.RUnlock()
+
+	for name, client := range cont.pool {
+		if err := client.Ping(ctx); err != nil {
+			return errors.Wrap(err, name)
+		}
+	}
+
+	return nil
+}
+
+// Stop closes all clients in the container
+func (cont *Container) Stop(_ context.Context) error {
+	cont.Close()
+	return nil
+}
+
+// Close closes all clients in the container
+func (cont *Container) Close() {
+	cont.mu.RLock()
+	names := make([]string, 0, len(cont.pool))
+	for name := range cont.pool {
+		names = append(names, name)
+	}
+	cont.mu.RUnlock()
+
+	for _, name := range names {
+		cont.Remove(name)
+	}
+}
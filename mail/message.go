@@ -0,0 +1,246 @@
+package inframail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// Message is an email message. At least one of Text and HTML is required
+type Message struct {
+	// Sender address, e.g. "Service <noreply@example.com>". Connection From is used if empty
+	From    string
+	To      []string
+	Cc      []string
+	Bcc     []string
+	ReplyTo string
+	Subject string
+
+	Text string
+	HTML string
+
+	// Additional headers, e.g. "List-Unsubscribe"
+	Headers map[string]string
+
+	Attachments []*Attachment
+}
+
+// Attachment is a file attached to a message.
+// Inline attachments can be referenced from HTML by filename: <img src="cid:logo.png">
+type Attachment struct {
+	Filename    string
+	ContentType string // optional, detected by filename extension
+	Data        []byte
+	Inline      bool
+}
+
+// recipients returns all envelope recipients including Bcc
+func (m *Message) recipients() ([]string, error) {
+	var ret []string
+	for _, list := range [][]string{m.To, m.Cc, m.Bcc} {
+		for _, rcpt := range list {
+			addr, err := mail.ParseAddress(rcpt)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid recipient %q", rcpt)
+			}
+			ret = append(ret, addr.Address)
+		}
+	}
+
+	if len(ret) == 0 {
+		return nil, errors.New("no recipients")
+	}
+
+	return ret, nil
+}
+
+// build encodes the message in MIME format. Bcc recipients are not included in headers
+func (m *Message) build(from *mail.Address, now time.Time) ([]byte, error) {
+	if m.Text == "" && m.HTML == "" {
+		return nil, errors.New("message has no body")
+	}
+
+	buf := &bytes.Buffer{}
+
+	headers := textproto.MIMEHeader{}
+	headers.Set("From", from.String())
+	if err := setAddressList(headers, "To", m.To); err != nil {
+		return nil, err
+	}
+	if err := setAddressList(headers, "Cc", m.Cc); err != nil {
+		return nil, err
+	}
+	if m.ReplyTo != "" {
+		if err := setAddressList(headers, "Reply-To", []string{m.ReplyTo}); err != nil {
+			return nil, err
+		}
+	}
+	headers.Set("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	headers.Set("Date", now.Format(time.RFC1123Z))
+	headers.Set("Message-ID", fmt.Sprintf("<%s@%s>", uuid.NewString(), domain(from.Address)))
+	headers.Set("MIME-Version", "1.0")
+	for k, v := range m.Headers {
+		headers.Set(k, mime.QEncoding.Encode("utf-8", v))
+	}
+
+	bodyHeaders, body := renderBody(m)
+
+	if len(m.Attachments) == 0 {
+		for k, v := range bodyHeaders {
+			headers[k] = v
+		}
+		writeHeaders(buf, headers)
+		buf.Write(body)
+		return buf.Bytes(), nil
+	}
+
+	mixed := multipart.NewWriter(buf)
+	headers.Set("Content-Type", "multipart/mixed; boundary="+mixed.Boundary())
+	writeHeaders(buf, headers)
+
+	part, err := mixed.CreatePart(bodyHeaders)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create body part")
+	}
+	_, _ = part.Write(body)
+
+	for _, a := range m.Attachments {
+		if err = writeAttachment(mixed, a); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = mixed.Close(); err != nil {
+		return nil, errors.Wrap(err, "unable to close multipart message")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// renderBody returns headers and content of text, html or alternative body
+func renderBody(m *Message) (textproto.MIMEHeader, []byte) {
+	switch {
+	case m.HTML == "":
+		return renderText("text/plain", m.Text)
+	case m.Text == "":
+		return renderText("text/html", m.HTML)
+	}
+
+	buf := &bytes.Buffer{}
+	alt := multipart.NewWriter(buf)
+	for _, text := range []struct{ contentType, content string }{
+		{"text/plain", m.Text},
+		{"text/html", m.HTML},
+	} {
+		headers, body := renderText(text.contentType, text.content)
+		part, _ := alt.CreatePart(headers)
+		_, _ = part.Write(body)
+	}
+	_ = alt.Close()
+
+	headers := textproto.MIMEHeader{}
+	headers.Set("Content-Type", "multipart/alternative; boundary="+alt.Boundary())
+
+	return headers, buf.Bytes()
+}
+
+func renderText(contentType string, text string) (textproto.MIMEHeader, []byte) {
+	headers := textproto.MIMEHeader{}
+	headers.Set("Content-Type", contentType+"; charset=utf-8")
+	headers.Set("Content-Transfer-Encoding", "quoted-printable")
+
+	buf := &bytes.Buffer{}
+	qp := quotedprintable.NewWriter(buf)
+	_, _ = qp.Write([]byte(text))
+	_ = qp.Close()
+
+	return headers, buf.Bytes()
+}
+
+func writeAttachment(mw *multipart.Writer, a *Attachment) error {
+	contentType := a.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(a.Filename))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	disposition := "attachment"
+	headers := textproto.MIMEHeader{}
+	if a.Inline {
+		disposition = "inline"
+		headers.Set("Content-ID", "<"+a.Filename+">")
+	}
+	headers.Set("Content-Type", contentType)
+	headers.Set("Content-Transfer-Encoding", "base64")
+	headers.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": a.Filename}))
+
+	part, err := mw.CreatePart(headers)
+	if err != nil {
+		return errors.Wrapf(err, "unable to create attachment %s", a.Filename)
+	}
+
+	// base64 lines must not be longer than 76 characters
+	encoded := base64.StdEncoding.EncodeToString(a.Data)
+	for len(encoded) > 76 {
+		_, _ = io.WriteString(part, encoded[:76]+"\r\n")
+		encoded = encoded[76:]
+	}
+	_, _ = io.WriteString(part, encoded+"\r\n")
+
+	return nil
+}
+
+func writeHeaders(w io.Writer, headers textproto.MIMEHeader) {
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		for _, v := range headers[k] {
+			_, _ = fmt.Fprintf(w, "%s: %s\r\n", k, v)
+		}
+	}
+	_, _ = io.WriteString(w, "\r\n")
+}
+
+func setAddressList(headers textproto.MIMEHeader, key string, list []string) error {
+	if len(list) == 0 {
+		return nil
+	}
+
+	formatted := make([]string, 0, len(list))
+	for _, s := range list {
+		addr, err := mail.ParseAddress(s)
+		if err != nil {
+			return errors.Wrapf(err, "invalid %s address %q", key, s)
+		}
+		formatted = append(formatted, addr.String())
+	}
+	headers.Set(key, strings.Join(formatted, ", "))
+
+	return nil
+}
+
+func domain(address string) string {
+	if i := strings.LastIndexByte(address, '@'); i >= 0 {
+		return address[i+1:]
+	}
+	return "localhost"
+}
@@ -0,0 +1,55 @@
+package inframail
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	SentCounter      *prometheus.CounterVec
+	SendDuration     *prometheus.HistogramVec
+	ConnectionsGauge *prometheus.GaugeVec
+}
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.SentCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mail_sent_total",
+			Help: "The total number of sent messages by result: ok, rejected or error",
+		}, []string{"connection", "status"})
+
+		metrics.SendDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "mail_send_duration",
+			Help:    "The message sending duration including retries and rate limit waiting",
+			Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		}, []string{"connection"})
+
+		metrics.ConnectionsGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "mail_pool_connections",
+			Help: "The number of open SMTP connections",
+		}, []string{"connection"})
+
+		prometheus.MustRegister(
+			metrics.SentCounter,
+			metrics.SendDuration,
+			metrics.ConnectionsGauge,
+		)
+	})
+}
+
+func (c *Client) observe(start time.Time, err *error) {
+	status := "ok"
+	switch {
+	case *err == nil:
+	case !isTemporary(*err):
+		status = "rejected"
+	default:
+		status = "error"
+	}
+
+	metrics.SentCounter.WithLabelValues(c.name, status).Inc()
+	metrics.SendDuration.WithLabelValues(c.name).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,252 @@
+package inframail
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/smtp"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// conn is a pooled SMTP connection
+type conn struct {
+	raw      net.Conn
+	client   *smtp.Client
+	lastUsed time.Time
+}
+
+func (c *conn) close() {
+	// QUIT may hang on a broken connection
+	_ = c.raw.SetDeadline(time.Now().Add(time.Second))
+	_ = c.client.Quit()
+	_ = c.raw.Close()
+}
+
+// pool keeps up to PoolSize open connections and reuses idle ones
+type pool struct {
+	name   string
+	cfg    *ConnectionConfig
+	host   string
+	tlsCfg *tls.Config
+
+	sem    chan struct{}
+	mu     sync.Mutex
+	idle   []*conn
+	closed bool
+}
+
+func newPool(name string, cfg *ConnectionConfig) (*pool, error) {
+	host, _, err := net.SplitHostPort(cfg.Address)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid address")
+	}
+
+	p := &pool{
+		name: name,
+		cfg:  cfg,
+		host: host,
+		sem:  make(chan struct{}, cfg.GetPoolSize()),
+	}
+
+	if cfg.GetSecurity() != SecurityNone {
+		if p.tlsCfg, err = tlsConfig(cfg.TLS, host); err != nil {
+			return nil, errors.Wrap(err, "tls")
+		}
+	}
+
+	return p, nil
+}
+
+// get returns an idle connection or dials a new one. The connection must be returned with put.
+// Its deadline is set from ctx, limited by Timeout, so a stuck server doesn't hang the caller
+func (p *pool) get(ctx context.Context) (*conn, error) {
+	select {
+	case p.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	for {
+		c := p.popIdle()
+		if c == nil {
+			break
+		}
+
+		_ = c.raw.SetDeadline(p.deadline(ctx, p.cfg.GetTimeout()))
+
+		// connection may be closed by the server while idle
+		if time.Since(c.lastUsed) < p.cfg.GetIdleTimeout() && c.client.Reset() == nil {
+			return c, nil
+		}
+		p.discard(c)
+	}
+
+	c, err := p.dial(ctx)
+	if err != nil {
+		<-p.sem
+		return nil, err
+	}
+	metrics.ConnectionsGauge.WithLabelValues(p.name).Inc()
+
+	_ = c.raw.SetDeadline(p.deadline(ctx, p.cfg.GetTimeout()))
+
+	return c, nil
+}
+
+// put returns a connection to the pool. Broken connections are closed
+func (p *pool) put(c *conn, broken bool) {
+	defer func() { <-p.sem }()
+
+	p.mu.Lock()
+	if broken || p.closed {
+		p.mu.Unlock()
+		p.discard(c)
+		return
+	}
+
+	_ = c.raw.SetDeadline(time.Time{})
+	c.lastUsed = time.Now()
+	p.idle = append(p.idle, c)
+	p.mu.Unlock()
+}
+
+// deadline returns the ctx deadline if it comes before timeout
+func (p *pool) deadline(ctx context.Context, timeout time.Duration) time.Time {
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	return deadline
+}
+
+func (p *pool) popIdle() *conn {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.idle) == 0 {
+		return nil
+	}
+
+	// the most recently used connection is the most likely alive
+	c := p.idle[len(p.idle)-1]
+	p.idle = p.idle[:len(p.idle)-1]
+
+	return c
+}
+
+func (p *pool) discard(c *conn) {
+	c.close()
+	metrics.ConnectionsGauge.WithLabelValues(p.name).Dec()
+}
+
+func (p *pool) dial(ctx context.Context) (*conn, error) {
+	dialer := &net.Dialer{Timeout: p.cfg.GetDialTimeout()}
+
+	var raw net.Conn
+	var err error
+	if p.cfg.GetSecurity() == SecurityTLS {
+		raw, err = (&tls.Dialer{NetDialer: dialer, Config: p.tlsCfg}).DialContext(ctx, "tcp", p.cfg.Address)
+	} else {
+		raw, err = dialer.DialContext(ctx, "tcp", p.cfg.Address)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to connect to SMTP server")
+	}
+
+	// handshake must not take longer than dialing
+	_ = raw.SetDeadline(p.deadline(ctx, p.cfg.GetDialTimeout()))
+
+	client, err := smtp.NewClient(raw, p.host)
+	if err != nil {
+		_ = raw.Close()
+		return nil, errors.Wrap(err, "unable to start SMTP session")
+	}
+
+	c := &conn{raw: raw, client: client}
+
+	if err = p.handshake(client); err != nil {
+		c.close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (p *pool) handshake(client *smtp.Client) error {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "localhost"
+	}
+	if err = client.Hello(hostname); err != nil {
+		return errors.Wrap(err, "EHLO")
+	}
+
+	if p.cfg.GetSecurity() == SecurityStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("server doesn't support STARTTLS")
+		}
+		if err = client.StartTLS(p.tlsCfg); err != nil {
+			return errors.Wrap(err, "STARTTLS")
+		}
+	}
+
+	if p.cfg.Username != "" {
+		if err = client.Auth(smtp.PlainAuth("", p.cfg.Username, p.cfg.Password, p.host)); err != nil {
+			return errors.Wrap(err, "AUTH")
+		}
+	}
+
+	return nil
+}
+
+func (p *pool) close() {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.closed = true
+	p.mu.Unlock()
+
+	for _, c := range idle {
+		p.discard(c)
+	}
+}
+
+func tlsConfig(c *TLSConfig, host string) (*tls.Config, error) {
+	ret := &tls.Config{ServerName: host}
+	if c == nil {
+		return ret, nil
+	}
+
+	ret.InsecureSkipVerify = c.InsecureSkipVerify //nolint:gosec
+	if c.ServerName != "" {
+		ret.ServerName = c.ServerName
+	}
+
+	if c.CAFile != "" {
+		ca, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read CA file")
+		}
+
+		ret.RootCAs = x509.NewCertPool()
+		if !ret.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, errors.New("no certificates found in CA file")
+		}
+	}
+
+	if c.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to load client certificate")
+		}
+		ret.Certificates = []tls.Certificate{cert}
+	}
+
+	return ret, nil
+}
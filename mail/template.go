@@ -0,0 +1,139 @@
+package inframail
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	suffixSubject = ".subject.tmpl"
+	suffixText    = ".txt.tmpl"
+	suffixHTML    = ".html.tmpl"
+)
+
+// Templates is a set of message templates. A message template named "welcome" consists of files
+// "welcome.subject.tmpl", "welcome.txt.tmpl" and "welcome.html.tmpl", each of them is optional.
+// HTML templates are parsed with html/template, so data is escaped.
+//
+//	//go:embed templates/*.tmpl
+//	var templatesFS embed.FS
+//
+//	templates, err := inframail.ParseFS(templatesFS, "templates/*.tmpl")
+//	err = client.SendTemplate(ctx, templates, "welcome", user, &inframail.Message{To: []string{user.Email}})
+type Templates struct {
+	text  *texttemplate.Template
+	html  *htmltemplate.Template
+	names map[string]struct{}
+}
+
+// ParseFS parses templates matching patterns from fsys, e.g. an embedded FS
+func ParseFS(fsys fs.FS, patterns ...string) (*Templates, error) {
+	t := &Templates{
+		text:  texttemplate.New(""),
+		html:  htmltemplate.New(""),
+		names: make(map[string]struct{}),
+	}
+
+	for _, pattern := range patterns {
+		files, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid pattern %s", pattern)
+		}
+
+		for _, file := range files {
+			if err = t.parseFile(fsys, file); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(t.names) == 0 {
+		return nil, errors.New("no templates found")
+	}
+
+	return t, nil
+}
+
+func (t *Templates) parseFile(fsys fs.FS, file string) error {
+	base := path.Base(file)
+
+	var name string
+	for _, suffix := range []string{suffixSubject, suffixText, suffixHTML} {
+		if strings.HasSuffix(base, suffix) {
+			name = strings.TrimSuffix(base, suffix)
+			break
+		}
+	}
+	if name == "" {
+		// not a message template
+		return nil
+	}
+
+	content, err := fs.ReadFile(fsys, file)
+	if err != nil {
+		return errors.Wrapf(err, "unable to read %s", file)
+	}
+
+	if strings.HasSuffix(base, suffixHTML) {
+		_, err = t.html.New(base).Parse(string(content))
+	} else {
+		_, err = t.text.New(base).Parse(string(content))
+	}
+	if err != nil {
+		return errors.Wrapf(err, "unable to parse %s", file)
+	}
+
+	t.names[name] = struct{}{}
+
+	return nil
+}
+
+// Render executes templates of the message template name and sets message subject, text and html.
+// Fields without a template are left as is.
+func (t *Templates) Render(msg *Message, name string, data interface{}) error {
+	if _, ok := t.names[name]; !ok {
+		return errors.Errorf("template %s not found", name)
+	}
+
+	if tmpl := t.text.Lookup(name + suffixSubject); tmpl != nil {
+		subject, err := execute(tmpl.Execute, data)
+		if err != nil {
+			return errors.Wrapf(err, "unable to render %s subject", name)
+		}
+		// subject must be a single line
+		msg.Subject = strings.Join(strings.Fields(subject), " ")
+	}
+
+	if tmpl := t.text.Lookup(name + suffixText); tmpl != nil {
+		text, err := execute(tmpl.Execute, data)
+		if err != nil {
+			return errors.Wrapf(err, "unable to render %s text", name)
+		}
+		msg.Text = text
+	}
+
+	if tmpl := t.html.Lookup(name + suffixHTML); tmpl != nil {
+		html, err := execute(tmpl.Execute, data)
+		if err != nil {
+			return errors.Wrapf(err, "unable to render %s html", name)
+		}
+		msg.HTML = html
+	}
+
+	return nil
+}
+
+func execute(fn func(w io.Writer, data interface{}) error, data interface{}) (string, error) {
+	buf := &bytes.Buffer{}
+	if err := fn(buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,56 @@
+package inframaintenance
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultCheckInterval = 5 * time.Second
+	defaultRetryAfter    = time.Minute
+)
+
+type Config struct {
+	// How often sources are polled. optional, default: 5s
+	CheckInterval time.Duration `mapstructure:"check_interval"`
+
+	// Retry-After of 503 responses. optional, default: 1m
+	RetryAfter time.Duration `mapstructure:"retry_after"`
+
+	// File enables maintenance while it exists. optional
+	File string `mapstructure:"file"`
+
+	// Env enables maintenance while the variable is "1", "true" or "on". optional
+	Env string `mapstructure:"env"`
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if c.CheckInterval < 0 {
+		return errors.New("check_interval should not be negative")
+	}
+
+	if c.RetryAfter < 0 {
+		return errors.New("retry_after should not be negative")
+	}
+
+	return nil
+}
+
+func (c *Config) GetCheckInterval() time.Duration {
+	if c.CheckInterval == 0 {
+		return defaultCheckInterval
+	}
+	return c.CheckInterval
+}
+
+func (c *Config) GetRetryAfter() time.Duration {
+	if c.RetryAfter == 0 {
+		return defaultRetryAfter
+	}
+	return c.RetryAfter
+}
@@ -0,0 +1,273 @@
+package inframaintenance
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	infrahealth "github.com/pushwoosh/infra/health"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	"go.uber.org/zap"
+)
+
+// ErrMaintenance is returned by the readiness check while maintenance is enabled
+var ErrMaintenance = errors.New("service is in maintenance mode")
+
+// Pauser is a component stopped during maintenance, e.g. *infrarabbit.Consumer
+type Pauser interface {
+	Pause()
+	Resume()
+}
+
+// Mode is a maintenance switch for draining a service. While it's enabled readiness fails,
+// registered consumers are paused and the http middleware responds with 503:
+//
+//	mode, err := inframaintenance.New(cfg, inframaintenance.NewRedisSource(redisClient, "sender:maintenance"))
+//	mode.RegisterHealth(healthRegistry)
+//	mode.RegisterPausers(consumer1, consumer2)
+//	server := infrahttp.NewServer(..., infrahttp.WithMiddlewares(mode.HTTP))
+//	app.Add(mode)
+//
+// Sources are polled every CheckInterval, maintenance is enabled while any of them is enabled.
+// A source that fails to answer keeps its last state.
+type Mode struct {
+	cfg     *Config
+	sources []Source
+
+	// refreshMu guards last source states
+	refreshMu sync.Mutex
+	last      []bool
+	manual    atomic.Bool
+
+	mu       sync.Mutex
+	enabled  bool
+	pausers  []Pauser
+	handlers []func(enabled bool)
+
+	state atomic.Bool
+
+	runMu  sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+var (
+	_ infraoperator.Starter = (*Mode)(nil)
+	_ infraoperator.Stopper = (*Mode)(nil)
+)
+
+// New creates a maintenance switch with sources from config and extra sources
+func New(cfg *Config, sources ...Source) (*Mode, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	initMetrics()
+
+	if cfg.File != "" {
+		sources = append(sources, NewFileSource(cfg.File))
+	}
+	if cfg.Env != "" {
+		sources = append(sources, NewEnvSource(cfg.Env))
+	}
+
+	return &Mode{
+		cfg:     cfg,
+		sources: sources,
+		last:    make([]bool, len(sources)),
+	}, nil
+}
+
+// Enabled returns true while maintenance is enabled
+func (m *Mode) Enabled() bool {
+	return m.state.Load()
+}
+
+// Enable turns maintenance on regardless of sources until Disable, e.g. from an admin endpoint
+func (m *Mode) Enable() {
+	m.manual.Store(true)
+	m.apply(true)
+}
+
+// Disable cancels Enable. Maintenance stays on if a source is enabled
+func (m *Mode) Disable() {
+	m.manual.Store(false)
+
+	m.refreshMu.Lock()
+	enabled := m.fromSources()
+	m.refreshMu.Unlock()
+
+	m.apply(enabled)
+}
+
+// RegisterPausers adds components paused during maintenance. They are paused at once if it's enabled
+func (m *Mode) RegisterPausers(pausers ...Pauser) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pausers = append(m.pausers, pausers...)
+	if m.enabled {
+		for _, p := range pausers {
+			p.Pause()
+		}
+	}
+}
+
+// OnChange adds a handler called on every switch after pausers are paused or resumed
+func (m *Mode) OnChange(handler func(enabled bool)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.handlers = append(m.handlers, handler)
+}
+
+// RegisterHealth adds a critical readiness check failing during maintenance, so the service is taken out of balancing
+func (m *Mode) RegisterHealth(registry *infrahealth.Registry) {
+	registry.Register("maintenance", func(_ context.Context) error {
+		if m.Enabled() {
+			return ErrMaintenance
+		}
+		return nil
+	})
+}
+
+// HTTP is a middleware responding with 503 and Retry-After header during maintenance
+func (m *Mode) HTTP(next http.Handler) http.Handler {
+	retryAfter := strconv.Itoa(int(m.cfg.GetRetryAfter().Seconds()))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !m.Enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		metrics.RejectedCounter.Inc()
+		w.Header().Set("Retry-After", retryAfter)
+		http.Error(w, ErrMaintenance.Error(), http.StatusServiceUnavailable)
+	})
+}
+
+// Refresh polls sources and switches maintenance. It returns the first source error
+func (m *Mode) Refresh(ctx context.Context) error {
+	m.refreshMu.Lock()
+
+	var firstErr error
+	for i, source := range m.sources {
+		enabled, err := source.Enabled(ctx)
+		if err != nil {
+			metrics.SourceErrorsCounter.WithLabelValues(source.Name()).Inc()
+			if firstErr == nil {
+				firstErr = errors.Wrapf(err, "unable to check %s source", source.Name())
+			}
+			continue
+		}
+		m.last[i] = enabled
+	}
+
+	enabled := m.fromSources()
+	m.refreshMu.Unlock()
+
+	m.apply(enabled || m.manual.Load())
+
+	return firstErr
+}
+
+func (m *Mode) fromSources() bool {
+	for _, enabled := range m.last {
+		if enabled {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Mode) apply(enabled bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.enabled == enabled {
+		return
+	}
+
+	m.enabled = enabled
+	m.state.Store(enabled)
+
+	if enabled {
+		metrics.EnabledGauge.Set(1)
+		infralog.Warn("maintenance mode enabled", zap.Int("pausers", len(m.pausers)))
+		for _, p := range m.pausers {
+			p.Pause()
+		}
+	} else {
+		metrics.EnabledGauge.Set(0)
+		infralog.Info("maintenance mode disabled", zap.Int("pausers", len(m.pausers)))
+		for _, p := range m.pausers {
+			p.Resume()
+		}
+	}
+
+	for _, handler := range m.handlers {
+		handler(enabled)
+	}
+}
+
+// Start checks sources and polls them in background
+func (m *Mode) Start(ctx context.Context) error {
+	m.runMu.Lock()
+	defer m.runMu.Unlock()
+
+	if m.cancel != nil {
+		return errors.New("maintenance mode is already started")
+	}
+
+	if err := m.Refresh(ctx); err != nil {
+		infralog.Error("unable to check maintenance mode", zap.Error(err))
+	}
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.done = make(chan struct{})
+
+	go m.run(runCtx)
+
+	return nil
+}
+
+// Stop stops polling sources. The current state is kept
+func (m *Mode) Stop(_ context.Context) error {
+	m.runMu.Lock()
+	defer m.runMu.Unlock()
+
+	if m.cancel == nil {
+		return nil
+	}
+
+	m.cancel()
+	<-m.done
+	m.cancel = nil
+
+	return nil
+}
+
+func (m *Mode) run(ctx context.Context) {
+	defer close(m.done)
+
+	ticker := time.NewTicker(m.cfg.GetCheckInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.Refresh(ctx); err != nil && ctx.Err() == nil {
+				infralog.Error("unable to check maintenance mode", zap.Error(err))
+			}
+		}
+	}
+}
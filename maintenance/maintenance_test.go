@@ -0,0 +1,81 @@
+package inframaintenance
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	infrahealth "github.com/pushwoosh/infra/health"
+)
+
+type pauser struct {
+	paused bool
+}
+
+func (p *pauser) Pause()  { p.paused = true }
+func (p *pauser) Resume() { p.paused = false }
+
+func TestMode(t *testing.T) {
+	ctx := context.Background()
+	file := filepath.Join(t.TempDir(), "maintenance")
+
+	mode, err := New(&Config{File: file, RetryAfter: 30 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registry := infrahealth.NewRegistry()
+	mode.RegisterHealth(registry)
+
+	consumer := &pauser{}
+	mode.RegisterPausers(consumer)
+
+	handler := mode.HTTP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serve := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		return rec
+	}
+
+	if err = mode.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if mode.Enabled() || consumer.paused || registry.Check(ctx) != nil || serve().Code != http.StatusOK {
+		t.Fatal("maintenance must be disabled without the file")
+	}
+
+	if err = os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err = mode.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if !mode.Enabled() || !consumer.paused || registry.Check(ctx) == nil {
+		t.Fatal("maintenance must be enabled by the file")
+	}
+	rec := serve()
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "30" {
+		t.Fatalf("unexpected response %d, Retry-After %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	if err = os.Remove(file); err != nil {
+		t.Fatal(err)
+	}
+	mode.Enable()
+	if err = mode.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !mode.Enabled() {
+		t.Fatal("manual switch must keep maintenance enabled")
+	}
+
+	mode.Disable()
+	if mode.Enabled() || consumer.paused {
+		t.Fatal("maintenance must be disabled")
+	}
+}
@@ -0,0 +1,40 @@
+package inframaintenance
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	EnabledGauge        prometheus.Gauge
+	SourceErrorsCounter *prometheus.CounterVec
+	RejectedCounter     prometheus.Counter
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.EnabledGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "maintenance_enabled",
+			Help: "1 if maintenance mode is enabled",
+		})
+
+		metrics.SourceErrorsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "maintenance_source_errors_total",
+			Help: "Number of failed maintenance source checks",
+		}, []string{"source"})
+
+		metrics.RejectedCounter = prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "maintenance_rejected_requests_total",
+			Help: "Number of http requests rejected with 503 during maintenance",
+		})
+
+		prometheus.MustRegister(
+			metrics.EnabledGauge,
+			metrics.SourceErrorsCounter,
+			metrics.RejectedCounter,
+		)
+	})
+}
@@ -0,0 +1,93 @@
+package inframaintenance
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+// Source tells if maintenance is enabled. Maintenance is on while any source is enabled
+type Source interface {
+	Name() string
+	Enabled(ctx context.Context) (bool, error)
+}
+
+// FileSource is enabled while the file exists, e.g. after `touch /tmp/maintenance` in a pod
+type FileSource struct {
+	path string
+}
+
+func NewFileSource(path string) *FileSource {
+	return &FileSource{path: path}
+}
+
+func (s *FileSource) Name() string {
+	return "file"
+}
+
+func (s *FileSource) Enabled(_ context.Context) (bool, error) {
+	_, err := os.Stat(s.path)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, os.ErrNotExist):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// EnvSource is enabled while the environment variable is "1", "true" or "on"
+type EnvSource struct {
+	name string
+}
+
+func NewEnvSource(name string) *EnvSource {
+	return &EnvSource{name: name}
+}
+
+func (s *EnvSource) Name() string {
+	return "env"
+}
+
+func (s *EnvSource) Enabled(_ context.Context) (bool, error) {
+	return isOn(os.Getenv(s.name)), nil
+}
+
+// RedisSource is enabled while the key is "1", "true" or "on". One key switches all replicas of a service:
+//
+//	redis-cli SET sender:maintenance 1
+type RedisSource struct {
+	client redis.UniversalClient
+	key    string
+}
+
+func NewRedisSource(client redis.UniversalClient, key string) *RedisSource {
+	return &RedisSource{client: client, key: key}
+}
+
+func (s *RedisSource) Name() string {
+	return "redis"
+}
+
+func (s *RedisSource) Enabled(ctx context.Context) (bool, error) {
+	value, err := s.client.Get(ctx, s.key).Result()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return isOn(value), nil
+}
+
+func isOn(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "1", "true", "on":
+		return true
+	}
+	return false
+}
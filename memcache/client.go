@@ -0,0 +1,149 @@
+package inframemcache
+
+import (
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	infradiscovery "github.com/pushwoosh/infra/discovery"
+	infralog "github.com/pushwoosh/infra/log"
+	"go.uber.org/zap"
+)
+
+// Client is a memcache client with consistent hashing and metrics
+type Client struct {
+	name   string
+	ring   *Ring
+	client *memcache.Client
+}
+
+// NewClient creates a client. Use Container to hold named clients
+func NewClient(name string, cfg *ConnectionConfig) (*Client, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	initMetrics()
+
+	ring := &Ring{}
+	if err := ring.SetServers(cfg.Servers...); err != nil {
+		return nil, err
+	}
+
+	client := memcache.NewFromSelector(ring)
+	client.Timeout = cfg.Timeout
+	client.MaxIdleConns = cfg.MaxIdleConns
+
+	return &Client{
+		name:   name,
+		ring:   ring,
+		client: client,
+	}, nil
+}
+
+// Memcache returns underlying client
+func (c *Client) Memcache() *memcache.Client {
+	return c.client
+}
+
+// SetServers replaces server list
+func (c *Client) SetServers(servers ...string) error {
+	return c.ring.SetServers(servers...)
+}
+
+// Watch creates a discovery watch that keeps server list up to date.
+// Empty instance lists are ignored to keep the cache available during discovery outages.
+// The watch must be started by the caller.
+func (c *Client) Watch(resolver infradiscovery.Resolver, service string, opts ...infradiscovery.WatchOption) *infradiscovery.Watch {
+	return infradiscovery.NewWatch(resolver, service, infradiscovery.Callbacks{
+		OnChange: func(instances []infradiscovery.Instance) {
+			if len(instances) == 0 {
+				infralog.Warn("memcache discovery returned no servers", zap.String("connection", c.name))
+				return
+			}
+
+			servers := make([]string, 0, len(instances))
+			for _, instance := range instances {
+				servers = append(servers, instance.HostPort())
+			}
+
+			if err := c.ring.SetServers(servers...); err != nil {
+				infralog.Error("unable to update memcache servers", zap.String("connection", c.name), zap.Error(err))
+			}
+		},
+	}, opts...)
+}
+
+// Get gets an item. memcache.ErrCacheMiss is returned if the item doesn't exist
+func (c *Client) Get(key string) (item *memcache.Item, err error) {
+	defer func(start time.Time) { c.observe("get", start, err) }(time.Now())
+	return c.client.Get(key)
+}
+
+// GetMulti gets several items at once. Missing items are not present in the result
+func (c *Client) GetMulti(keys []string) (map[string]*memcache.Item, error) {
+	start := time.Now()
+	items, err := c.client.GetMulti(keys)
+
+	metrics.CommandDurationHistogram.WithLabelValues(c.name, "get_multi").Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.RequestsCounter.WithLabelValues(c.name, "get_multi", "error").Inc()
+		return items, err
+	}
+
+	metrics.RequestsCounter.WithLabelValues(c.name, "get_multi", "hit").Add(float64(len(items)))
+	metrics.RequestsCounter.WithLabelValues(c.name, "get_multi", "miss").Add(float64(len(keys) - len(items)))
+
+	return items, nil
+}
+
+// Set writes an item unconditionally
+func (c *Client) Set(item *memcache.Item) (err error) {
+	defer func(start time.Time) { c.observe("set", start, err) }(time.Now())
+	return c.client.Set(item)
+}
+
+// Add writes an item only if it doesn't exist. memcache.ErrNotStored is returned otherwise
+func (c *Client) Add(item *memcache.Item) (err error) {
+	defer func(start time.Time) { c.observe("add", start, err) }(time.Now())
+	return c.client.Add(item)
+}
+
+// CompareAndSwap writes an item fetched with Get if it wasn't modified since
+func (c *Client) CompareAndSwap(item *memcache.Item) (err error) {
+	defer func(start time.Time) { c.observe("cas", start, err) }(time.Now())
+	return c.client.CompareAndSwap(item)
+}
+
+// Delete deletes an item. memcache.ErrCacheMiss is returned if the item doesn't exist
+func (c *Client) Delete(key string) (err error) {
+	defer func(start time.Time) { c.observe("delete", start, err) }(time.Now())
+	return c.client.Delete(key)
+}
+
+// Touch updates item expiration
+func (c *Client) Touch(key string, seconds int32) (err error) {
+	defer func(start time.Time) { c.observe("touch", start, err) }(time.Now())
+	return c.client.Touch(key, seconds)
+}
+
+// Increment atomically increments a numeric item
+func (c *Client) Increment(key string, delta uint64) (value uint64, err error) {
+	defer func(start time.Time) { c.observe("incr", start, err) }(time.Now())
+	return c.client.Increment(key, delta)
+}
+
+// Decrement atomically decrements a numeric item. The value is not decremented below zero
+func (c *Client) Decrement(key string, delta uint64) (value uint64, err error) {
+	defer func(start time.Time) { c.observe("decr", start, err) }(time.Now())
+	return c.client.Decrement(key, delta)
+}
+
+// Ping checks all servers
+func (c *Client) Ping() error {
+	return c.client.Ping()
+}
+
+// Close closes idle connections
+func (c *Client) Close() error {
+	return c.client.Close()
+}
@@ -0,0 +1,50 @@
+package inframemcache
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type ConnectionsConfig map[string]*ConnectionConfig
+
+type ConnectionConfig struct {
+	// Server addresses "host:port". optional if the list is provided by discovery with Client.Watch
+	Servers []string `mapstructure:"servers"`
+
+	// Socket read/write timeout. Default: 500ms
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// Maximum number of idle connections per server. Default: 2
+	MaxIdleConns int `mapstructure:"max_idle_conns"`
+}
+
+func (c *ConnectionsConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	for name, conf := range *c {
+		if err := conf.Validate(); err != nil {
+			return errors.Wrap(err, name)
+		}
+	}
+
+	return nil
+}
+
+func (c *ConnectionConfig) Validate() error {
+	if c == nil {
+		return errors.New("empty connection config")
+	}
+
+	if c.Timeout < 0 {
+		return errors.New("timeout should be greater than or equal to 0")
+	}
+
+	if c.MaxIdleConns < 0 {
+		return errors.New("max_idle_conns should be greater than or equal to 0")
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package inframemcache
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	RequestsCounter          *prometheus.CounterVec
+	CommandDurationHistogram *prometheus.HistogramVec
+}
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.RequestsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "memcache_requests_total",
+			Help: "The total number of memcache commands by result: hit, miss, success, conflict or error",
+		}, []string{"connection", "command", "result"})
+
+		metrics.CommandDurationHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "memcache_command_duration",
+			Help:    "The memcache command duration",
+			Buckets: []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1},
+		}, []string{"connection", "command"})
+
+		prometheus.MustRegister(
+			metrics.RequestsCounter,
+			metrics.CommandDurationHistogram,
+		)
+	})
+}
+
+func (c *Client) observe(command string, start time.Time, err error) {
+	result := "success"
+	switch {
+	case err == nil && (command == "get" || command == "get_multi"):
+		result = "hit"
+	case errors.Is(err, memcache.ErrCacheMiss):
+		result = "miss"
+	case errors.Is(err, memcache.ErrNotStored), errors.Is(err, memcache.ErrCASConflict):
+		result = "conflict"
+	case err != nil:
+		result = "error"
+	}
+
+	metrics.RequestsCounter.WithLabelValues(c.name, command, result).Inc()
+	metrics.CommandDurationHistogram.WithLabelValues(c.name, command).Observe(time.Since(start).Seconds())
+}
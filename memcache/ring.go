@@ -0,0 +1,126 @@
+package inframemcache
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/pkg/errors"
+)
+
+// pointsPerServer is the number of virtual nodes of every server on the ring
+const pointsPerServer = 160
+
+// Ring is a ketama compatible consistent hashing server selector.
+// Adding or removing a server remaps only keys of that server.
+type Ring struct {
+	mu     sync.RWMutex
+	addrs  []net.Addr
+	points []ringPoint
+}
+
+type ringPoint struct {
+	hash uint32
+	addr net.Addr
+}
+
+var _ memcache.ServerSelector = (*Ring)(nil)
+
+// SetServers replaces server list of the ring
+func (r *Ring) SetServers(servers ...string) error {
+	addrs := make([]net.Addr, 0, len(servers))
+	points := make([]ringPoint, 0, len(servers)*pointsPerServer)
+
+	for _, server := range servers {
+		var (
+			addr net.Addr
+			err  error
+		)
+		if strings.Contains(server, "/") {
+			addr, err = net.ResolveUnixAddr("unix", server)
+		} else {
+			addr, err = net.ResolveTCPAddr("tcp", server)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "unable to resolve %s", server)
+		}
+
+		addrs = append(addrs, addr)
+
+		// every md5 digest gives 4 points
+		for i := 0; i < pointsPerServer/4; i++ {
+			digest := md5.Sum([]byte(server + "-" + strconv.Itoa(i)))
+			for j := 0; j < 4; j++ {
+				points = append(points, ringPoint{
+					hash: binary.LittleEndian.Uint32(digest[j*4:]),
+					addr: addr,
+				})
+			}
+		}
+	}
+
+	slices.SortFunc(points, func(a, b ringPoint) int {
+		switch {
+		case a.hash < b.hash:
+			return -1
+		case a.hash > b.hash:
+			return 1
+		}
+		return 0
+	})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.addrs = addrs
+	r.points = points
+
+	return nil
+}
+
+// PickServer returns the server owning the key
+func (r *Ring) PickServer(key string) (net.Addr, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.points) == 0 {
+		return nil, memcache.ErrNoServers
+	}
+
+	digest := md5.Sum([]byte(key))
+	hash := binary.LittleEndian.Uint32(digest[:4])
+
+	i, _ := slices.BinarySearchFunc(r.points, hash, func(p ringPoint, h uint32) int {
+		switch {
+		case p.hash < h:
+			return -1
+		case p.hash > h:
+			return 1
+		}
+		return 0
+	})
+	if i == len(r.points) {
+		i = 0
+	}
+
+	return r.points[i].addr, nil
+}
+
+// Each calls f for every server
+func (r *Ring) Each(f func(net.Addr) error) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, addr := range r.addrs {
+		if err := f(addr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package inframemcache
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestRingRemapsOnlyRemovedServerKeys(t *testing.T) {
+	servers := []string{"127.0.0.1:11211", "127.0.0.2:11211", "127.0.0.3:11211"}
+
+	ring := &Ring{}
+	if err := ring.SetServers(servers...); err != nil {
+		t.Fatal(err)
+	}
+
+	before := make(map[string]string)
+	counts := make(map[string]int)
+	for i := 0; i < 3000; i++ {
+		key := "key" + strconv.Itoa(i)
+		addr, err := ring.PickServer(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		before[key] = addr.String()
+		counts[addr.String()]++
+	}
+
+	for _, server := range servers {
+		if counts[server] < 600 {
+			t.Fatalf("uneven distribution: %v", counts)
+		}
+	}
+
+	if err := ring.SetServers(servers[:2]...); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, server := range before {
+		addr, _ := ring.PickServer(key)
+		if server != servers[2] && addr.String() != server {
+			t.Fatalf("key %s moved from %s to %s", key, server, addr)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+// Package inframessaging has handler types shared by consumers of infra messaging packages,
+// so a middleware is written once for every broker:
+//
+//	func Logging[M any](next inframessaging.Handler[M]) inframessaging.Handler[M] { ... }
+//
+//	router.Use(Logging[*infrarabbit.Message])
+//	subscriber.Consume(handler, Logging[*infrapubsub.Message])
+package inframessaging
+
+import "context"
+
+// Handler processes a consumed message. Returned error means the message is not processed,
+// what happens to it then depends on the consumer, e.g. it's requeued or negatively acknowledged
+type Handler[M any] func(ctx context.Context, msg M) error
+
+// Middleware wraps a handler with additional behavior, e.g. logging or idempotency checks
+type Middleware[M any] func(next Handler[M]) Handler[M]
+
+// Chain applies middlewares to a handler. The first middleware is the outermost one
+func Chain[M any](handler Handler[M], middlewares ...Middleware[M]) Handler[M] {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
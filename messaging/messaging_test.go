@@ -0,0 +1,30 @@
+package inframessaging
+
+import (
+	"context"
+	"testing"
+)
+
+func TestChain(t *testing.T) {
+	var calls []string
+	middleware := func(name string) Middleware[string] {
+		return func(next Handler[string]) Handler[string] {
+			return func(ctx context.Context, msg string) error {
+				calls = append(calls, name)
+				return next(ctx, msg)
+			}
+		}
+	}
+
+	handler := Chain(func(_ context.Context, msg string) error {
+		calls = append(calls, msg)
+		return nil
+	}, middleware("outer"), middleware("inner"))
+
+	if err := handler(context.Background(), "handler"); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 3 || calls[0] != "outer" || calls[1] != "inner" || calls[2] != "handler" {
+		t.Fatalf("unexpected order %v", calls)
+	}
+}
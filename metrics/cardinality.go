@@ -0,0 +1,386 @@
+package inframetrics
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	infralog "github.com/pushwoosh/infra/log"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultMaxLabelValues is the default limit of distinct values of every label of a guarded vector
+	DefaultMaxLabelValues = 500
+
+	// DefaultLabelTTL is the default time after which a label value not seen is evicted when the limit is reached
+	DefaultLabelTTL = time.Hour
+
+	// OverflowLabelValue replaces label values seen after the limit is reached
+	OverflowLabelValue = "other"
+
+	// evictionInterval limits how often known values are scanned for expired ones
+	evictionInterval = time.Second
+)
+
+// discardedGauge receives values of gauges over limits
+var discardedGauge = prometheus.NewGauge(prometheus.GaugeOpts{Name: "discarded"})
+
+var cardinalityMetrics struct {
+	CappedCounter *prometheus.CounterVec
+}
+
+var cardinalityMetricsOnce sync.Once
+
+func initCardinalityMetrics() {
+	cardinalityMetricsOnce.Do(func() {
+		cardinalityMetrics.CappedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "metrics_capped_label_values_total",
+			Help: "Number of observations with a label value replaced by \"other\" after the label reached its limit",
+		}, []string{"metric", "label"})
+
+		prometheus.MustRegister(
+			cardinalityMetrics.CappedCounter,
+		)
+	})
+}
+
+type GuardOption interface {
+	apply(g *Guard)
+}
+
+type optionLabelLimit struct {
+	label string
+	limit int
+}
+
+func (opt optionLabelLimit) apply(g *Guard) {
+	for i, label := range g.labels {
+		if label == opt.label {
+			g.limits[i] = opt.limit
+		}
+	}
+}
+
+// WithLabelLimit sets the limit of distinct values of the label. Zero disables the limit
+func WithLabelLimit(label string, limit int) GuardOption {
+	return optionLabelLimit{label: label, limit: limit}
+}
+
+type optionLabelTTL time.Duration
+
+func (opt optionLabelTTL) apply(g *Guard) {
+	g.ttl = time.Duration(opt)
+}
+
+// WithLabelTTL sets the time after which values not seen are evicted to make room for new ones
+// when a label reaches its limit. Series with evicted values are deleted. Zero disables eviction
+func WithLabelTTL(ttl time.Duration) GuardOption {
+	return optionLabelTTL(ttl)
+}
+
+// Guard caps the number of distinct values of metric labels. Values seen after the limit
+// are replaced by OverflowLabelValue, the first overflow of a label is logged
+// and every capped observation is counted in metrics_capped_label_values_total.
+// Values not seen for the label TTL are evicted when the limit is reached, so labels
+// with changing values like hosts don't stay capped forever
+type Guard struct {
+	metric  string
+	labels  []string
+	limits  []int
+	ttl     time.Duration
+	onEvict func(label, value string)
+
+	mu        sync.RWMutex
+	known     []map[string]*atomic.Int64 // unix nanoseconds of the last observation
+	evictedAt []time.Time
+	capped    []bool
+}
+
+func NewGuard(metric string, labels []string, opts ...GuardOption) *Guard {
+	initCardinalityMetrics()
+
+	g := &Guard{
+		metric:    metric,
+		labels:    labels,
+		limits:    make([]int, len(labels)),
+		ttl:       DefaultLabelTTL,
+		known:     make([]map[string]*atomic.Int64, len(labels)),
+		evictedAt: make([]time.Time, len(labels)),
+		capped:    make([]bool, len(labels)),
+	}
+
+	for i := range labels {
+		g.limits[i] = DefaultMaxLabelValues
+		g.known[i] = make(map[string]*atomic.Int64)
+	}
+
+	for _, opt := range opts {
+		opt.apply(g)
+	}
+
+	return g
+}
+
+// Values returns label values with the ones over limits replaced
+func (g *Guard) Values(values []string) []string {
+	replaced, _ := g.values(values)
+	return replaced
+}
+
+func (g *Guard) values(values []string) ([]string, bool) {
+	var replaced []string
+	for i, value := range values {
+		if i >= len(g.limits) || g.allow(i, value) {
+			continue
+		}
+
+		if replaced == nil {
+			replaced = append([]string(nil), values...)
+		}
+		replaced[i] = OverflowLabelValue
+	}
+
+	if replaced == nil {
+		return values, false
+	}
+	return replaced, true
+}
+
+// Labels returns labels with the values over limits replaced
+func (g *Guard) Labels(labels prometheus.Labels) prometheus.Labels {
+	replaced, _ := g.labelValues(labels)
+	return replaced
+}
+
+func (g *Guard) labelValues(labels prometheus.Labels) (prometheus.Labels, bool) {
+	var replaced prometheus.Labels
+	for i, label := range g.labels {
+		value, ok := labels[label]
+		if !ok || g.allow(i, value) {
+			continue
+		}
+
+		if replaced == nil {
+			replaced = make(prometheus.Labels, len(labels))
+			for k, v := range labels {
+				replaced[k] = v
+			}
+		}
+		replaced[label] = OverflowLabelValue
+	}
+
+	if replaced == nil {
+		return labels, false
+	}
+	return replaced, true
+}
+
+// knownValues reports whether all values are within limits, without adding new ones
+func (g *Guard) knownValues(values []string) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	for i, value := range values {
+		if i >= len(g.limits) || g.limits[i] <= 0 {
+			continue
+		}
+		if _, ok := g.known[i][value]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// knownLabels reports whether all label values are within limits, without adding new ones
+func (g *Guard) knownLabels(labels prometheus.Labels) bool {
+	values := make([]string, len(g.labels))
+	for i, label := range g.labels {
+		values[i] = labels[label]
+	}
+	return g.knownValues(values)
+}
+
+func (g *Guard) allow(i int, value string) bool {
+	if g.limits[i] <= 0 {
+		return true
+	}
+
+	now := time.Now()
+
+	g.mu.RLock()
+	seen, ok := g.known[i][value]
+	if ok {
+		seen.Store(now.UnixNano())
+	}
+	g.mu.RUnlock()
+	if ok {
+		return true
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, ok = g.known[i][value]; ok {
+		return true
+	}
+
+	if len(g.known[i]) >= g.limits[i] {
+		g.evict(i, now)
+	}
+
+	if len(g.known[i]) < g.limits[i] {
+		seen = &atomic.Int64{}
+		seen.Store(now.UnixNano())
+		g.known[i][value] = seen
+		return true
+	}
+
+	if !g.capped[i] {
+		g.capped[i] = true
+		infralog.Warn("metric label reached its cardinality limit, new values are reported as \"other\"",
+			zap.String("metric", g.metric),
+			zap.String("label", g.labels[i]),
+			zap.Int("limit", g.limits[i]))
+	}
+	cardinalityMetrics.CappedCounter.WithLabelValues(g.metric, g.labels[i]).Inc()
+
+	return false
+}
+
+// evict forgets values of the label not seen for the TTL and deletes their series
+func (g *Guard) evict(i int, now time.Time) {
+	if g.ttl <= 0 || now.Sub(g.evictedAt[i]) < evictionInterval {
+		return
+	}
+	g.evictedAt[i] = now
+
+	expired := now.Add(-g.ttl).UnixNano()
+	for value, seen := range g.known[i] {
+		if seen.Load() >= expired {
+			continue
+		}
+
+		delete(g.known[i], value)
+		if g.onEvict != nil {
+			g.onEvict(g.labels[i], value)
+		}
+	}
+}
+
+// CounterVec is a prometheus.CounterVec with label cardinality guard
+type CounterVec struct {
+	*prometheus.CounterVec
+	guard *Guard
+}
+
+// NewCounterVec creates a counter vector capping label values, see Guard
+func NewCounterVec(opts prometheus.CounterOpts, labels []string, guardOpts ...GuardOption) *CounterVec {
+	v := &CounterVec{
+		CounterVec: prometheus.NewCounterVec(opts, labels),
+		guard:      NewGuard(prometheus.BuildFQName(opts.Namespace, opts.Subsystem, opts.Name), labels, guardOpts...),
+	}
+	v.guard.onEvict = deleteEvicted(v.CounterVec.MetricVec)
+	return v
+}
+
+func (v *CounterVec) WithLabelValues(values ...string) prometheus.Counter {
+	return v.CounterVec.WithLabelValues(v.guard.Values(values)...)
+}
+
+func (v *CounterVec) With(labels prometheus.Labels) prometheus.Counter {
+	return v.CounterVec.With(v.guard.Labels(labels))
+}
+
+// DeleteLabelValues deletes the series of values. Values over limits are reported as "other", nothing is deleted for them
+func (v *CounterVec) DeleteLabelValues(values ...string) bool {
+	return v.guard.knownValues(values) && v.CounterVec.DeleteLabelValues(values...)
+}
+
+// Delete deletes the series of labels. Values over limits are reported as "other", nothing is deleted for them
+func (v *CounterVec) Delete(labels prometheus.Labels) bool {
+	return v.guard.knownLabels(labels) && v.CounterVec.Delete(labels)
+}
+
+// GaugeVec is a prometheus.GaugeVec with label cardinality guard.
+// Values of different gauges can't be merged, so gauges with values over limits are dropped instead of
+// being reported as "other": their changes are discarded and capped observations are counted as usual
+type GaugeVec struct {
+	*prometheus.GaugeVec
+	guard *Guard
+}
+
+// NewGaugeVec creates a gauge vector dropping series with label values over limits, see Guard
+func NewGaugeVec(opts prometheus.GaugeOpts, labels []string, guardOpts ...GuardOption) *GaugeVec {
+	v := &GaugeVec{
+		GaugeVec: prometheus.NewGaugeVec(opts, labels),
+		guard:    NewGuard(prometheus.BuildFQName(opts.Namespace, opts.Subsystem, opts.Name), labels, guardOpts...),
+	}
+	v.guard.onEvict = deleteEvicted(v.GaugeVec.MetricVec)
+	return v
+}
+
+func (v *GaugeVec) WithLabelValues(values ...string) prometheus.Gauge {
+	if _, capped := v.guard.values(values); capped {
+		return discardedGauge
+	}
+	return v.GaugeVec.WithLabelValues(values...)
+}
+
+func (v *GaugeVec) With(labels prometheus.Labels) prometheus.Gauge {
+	if _, capped := v.guard.labelValues(labels); capped {
+		return discardedGauge
+	}
+	return v.GaugeVec.With(labels)
+}
+
+// DeleteLabelValues deletes the series of values, gauges over limits don't exist
+func (v *GaugeVec) DeleteLabelValues(values ...string) bool {
+	return v.guard.knownValues(values) && v.GaugeVec.DeleteLabelValues(values...)
+}
+
+// Delete deletes the series of labels, gauges over limits don't exist
+func (v *GaugeVec) Delete(labels prometheus.Labels) bool {
+	return v.guard.knownLabels(labels) && v.GaugeVec.Delete(labels)
+}
+
+// HistogramVec is a prometheus.HistogramVec with label cardinality guard
+type HistogramVec struct {
+	*prometheus.HistogramVec
+	guard *Guard
+}
+
+// NewHistogramVec creates a histogram vector capping label values, see Guard
+func NewHistogramVec(opts prometheus.HistogramOpts, labels []string, guardOpts ...GuardOption) *HistogramVec {
+	v := &HistogramVec{
+		HistogramVec: prometheus.NewHistogramVec(opts, labels),
+		guard:        NewGuard(prometheus.BuildFQName(opts.Namespace, opts.Subsystem, opts.Name), labels, guardOpts...),
+	}
+	v.guard.onEvict = deleteEvicted(v.HistogramVec.MetricVec)
+	return v
+}
+
+func (v *HistogramVec) WithLabelValues(values ...string) prometheus.Observer {
+	return v.HistogramVec.WithLabelValues(v.guard.Values(values)...)
+}
+
+func (v *HistogramVec) With(labels prometheus.Labels) prometheus.Observer {
+	return v.HistogramVec.With(v.guard.Labels(labels))
+}
+
+// DeleteLabelValues deletes the series of values. Values over limits are reported as "other", nothing is deleted for them
+func (v *HistogramVec) DeleteLabelValues(values ...string) bool {
+	return v.guard.knownValues(values) && v.HistogramVec.DeleteLabelValues(values...)
+}
+
+// Delete deletes the series of labels. Values over limits are reported as "other", nothing is deleted for them
+func (v *HistogramVec) Delete(labels prometheus.Labels) bool {
+	return v.guard.knownLabels(labels) && v.HistogramVec.Delete(labels)
+}
+
+func deleteEvicted(vec *prometheus.MetricVec) func(label, value string) {
+	return func(label, value string) {
+		vec.DeletePartialMatch(prometheus.Labels{label: value})
+	}
+}
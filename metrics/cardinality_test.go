@@ -0,0 +1,64 @@
+package inframetrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCounterVecCardinality(t *testing.T) {
+	counter := NewCounterVec(prometheus.CounterOpts{
+		Name: "test_requests_total",
+		Help: "test",
+	}, []string{"queue", "status"}, WithLabelLimit("queue", 2))
+
+	for _, queue := range []string{"a", "b", "c", "d", "a"} {
+		counter.WithLabelValues(queue, "ok").Inc()
+	}
+	counter.With(prometheus.Labels{"queue": "e", "status": "ok"}).Inc()
+
+	if n := testutil.CollectAndCount(counter); n != 3 {
+		t.Fatalf("expected 3 series, got %d", n)
+	}
+	if v := testutil.ToFloat64(counter.CounterVec.WithLabelValues(OverflowLabelValue, "ok")); v != 3 {
+		t.Fatalf("expected 3 capped observations, got %v", v)
+	}
+	if v := testutil.ToFloat64(counter.WithLabelValues("a", "ok")); v != 2 {
+		t.Fatalf("expected 2 observations of a, got %v", v)
+	}
+}
+
+func TestGuardEviction(t *testing.T) {
+	counter := NewCounterVec(prometheus.CounterOpts{
+		Name: "test_evicted_total",
+		Help: "test",
+	}, []string{"host"}, WithLabelLimit("host", 1), WithLabelTTL(time.Millisecond))
+
+	counter.WithLabelValues("a").Inc()
+	if counter.DeleteLabelValues("b") {
+		t.Fatal("unknown values must not be deleted")
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	counter.WithLabelValues("b").Inc()
+
+	if n := testutil.CollectAndCount(counter); n != 1 {
+		t.Fatalf("expected the expired value to be evicted, got %d series", n)
+	}
+	if v := testutil.ToFloat64(counter.WithLabelValues("b")); v != 1 {
+		t.Fatalf("expected 1 observation of b, got %v", v)
+	}
+
+	gauge := NewGaugeVec(prometheus.GaugeOpts{
+		Name: "test_gauge",
+		Help: "test",
+	}, []string{"queue"}, WithLabelLimit("queue", 1))
+
+	gauge.WithLabelValues("a").Set(1)
+	gauge.WithLabelValues("b").Set(2)
+	if n := testutil.CollectAndCount(gauge); n != 1 {
+		t.Fatalf("expected gauges over limits to be dropped, got %d series", n)
+	}
+}
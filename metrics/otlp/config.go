@@ -0,0 +1,64 @@
+package inframetricsotlp
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	ExporterOTLPGRPC = "otlp_grpc"
+	ExporterOTLPHTTP = "otlp_http"
+
+	defaultInterval = 30 * time.Second
+)
+
+type Config struct {
+	Enabled bool `mapstructure:"enabled"`
+
+	// Service metadata. Empty values are taken from environment, see infralog.SetGlobalFieldsFromEnvironment
+	ServiceName string `mapstructure:"service_name"`
+	Environment string `mapstructure:"environment"`
+
+	// ResourceAttributes are added to the resource of all metrics. optional
+	ResourceAttributes map[string]string `mapstructure:"resource_attributes"`
+
+	// otlp_grpc or otlp_http. optional, default: otlp_grpc
+	Exporter string            `mapstructure:"exporter"`
+	Endpoint string            `mapstructure:"endpoint"` // host:port, optional
+	Insecure bool              `mapstructure:"insecure"`
+	Headers  map[string]string `mapstructure:"headers"` // optional
+	Timeout  time.Duration     `mapstructure:"timeout"` // optional, export timeout
+
+	// Metrics are collected and exported once per interval. optional, default: 30s
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if !c.Enabled {
+		return nil
+	}
+
+	switch c.Exporter {
+	case "", ExporterOTLPGRPC, ExporterOTLPHTTP:
+	default:
+		return errors.Errorf("unknown exporter: \"%s\"", c.Exporter)
+	}
+
+	if c.Timeout < 0 || c.Interval < 0 {
+		return errors.New("timeout and interval must be greater or equal to zero")
+	}
+
+	return nil
+}
+
+func (c *Config) GetInterval() time.Duration {
+	if c.Interval == 0 {
+		return defaultInterval
+	}
+	return c.Interval
+}
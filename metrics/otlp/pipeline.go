@@ -0,0 +1,221 @@
+package inframetricsotlp
+
+import (
+	"context"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	infraversion "github.com/pushwoosh/infra/version"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+type Option interface {
+	apply(p *Pipeline)
+}
+
+type optionGatherer struct {
+	gatherer prometheus.Gatherer
+}
+
+func (opt optionGatherer) apply(p *Pipeline) {
+	p.gatherer = opt.gatherer
+}
+
+// WithGatherer sets prometheus metrics to export, default: prometheus.DefaultGatherer with all infra metrics
+func WithGatherer(gatherer prometheus.Gatherer) Option {
+	return optionGatherer{gatherer: gatherer}
+}
+
+// Pipeline periodically exports metrics registered for Prometheus to an OpenTelemetry collector with OTLP,
+// so infra packages are instrumented once for both backends:
+//
+//	pipeline, err := inframetricsotlp.NewPipeline(cfg.OTLP)
+//	app.Add("otlp", pipeline)
+//
+// The meter provider of the pipeline is installed as the OpenTelemetry global, so instruments created
+// with otel.Meter are exported too. Stop exports the last values. Pipeline does nothing if it's disabled.
+type Pipeline struct {
+	cfg      *Config
+	gatherer prometheus.Gatherer
+
+	mu       sync.Mutex
+	provider *sdkmetric.MeterProvider
+}
+
+var (
+	_ infraoperator.Starter = (*Pipeline)(nil)
+	_ infraoperator.Stopper = (*Pipeline)(nil)
+)
+
+func NewPipeline(cfg *Config, opts ...Option) (*Pipeline, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	p := &Pipeline{
+		cfg:      cfg,
+		gatherer: prometheus.DefaultGatherer,
+	}
+
+	for _, opt := range opts {
+		opt.apply(p)
+	}
+
+	return p, nil
+}
+
+// Start creates the exporter and starts exporting in background
+func (p *Pipeline) Start(ctx context.Context) error {
+	if !p.cfg.Enabled {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.provider != nil {
+		return errors.New("pipeline is already started")
+	}
+
+	res, err := newResource(ctx, p.cfg)
+	if err != nil {
+		return err
+	}
+
+	exporter, err := newExporter(ctx, p.cfg)
+	if err != nil {
+		return err
+	}
+
+	readerOpts := []sdkmetric.PeriodicReaderOption{
+		sdkmetric.WithInterval(p.cfg.GetInterval()),
+		sdkmetric.WithProducer(&producer{gatherer: p.gatherer, start: time.Now()}),
+	}
+	if p.cfg.Timeout > 0 {
+		readerOpts = append(readerOpts, sdkmetric.WithTimeout(p.cfg.Timeout))
+	}
+
+	p.provider = sdkmetric.NewMeterProvider(
+		sdkmetric.WithResource(res),
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter, readerOpts...)),
+	)
+	otel.SetMeterProvider(p.provider)
+
+	return nil
+}
+
+// Stop exports the last values and shuts down the exporter
+func (p *Pipeline) Stop(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.provider == nil {
+		return nil
+	}
+
+	err := p.provider.Shutdown(ctx)
+	p.provider = nil
+
+	return errors.Wrap(err, "meter provider shutdown")
+}
+
+func newExporter(ctx context.Context, cfg *Config) (sdkmetric.Exporter, error) {
+	switch cfg.Exporter {
+	case ExporterOTLPHTTP:
+		var opts []otlpmetrichttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlpmetrichttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlpmetrichttp.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlpmetrichttp.WithHeaders(cfg.Headers))
+		}
+		if cfg.Timeout > 0 {
+			opts = append(opts, otlpmetrichttp.WithTimeout(cfg.Timeout))
+		}
+
+		exporter, err := otlpmetrichttp.New(ctx, opts...)
+		if err != nil {
+			return nil, errors.Wrap(err, "otlpmetrichttp.New")
+		}
+		return exporter, nil
+	default:
+		var opts []otlpmetricgrpc.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlpmetricgrpc.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlpmetricgrpc.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlpmetricgrpc.WithHeaders(cfg.Headers))
+		}
+		if cfg.Timeout > 0 {
+			opts = append(opts, otlpmetricgrpc.WithTimeout(cfg.Timeout))
+		}
+
+		exporter, err := otlpmetricgrpc.New(ctx, opts...)
+		if err != nil {
+			return nil, errors.Wrap(err, "otlpmetricgrpc.New")
+		}
+		return exporter, nil
+	}
+}
+
+func newResource(ctx context.Context, cfg *Config) (*resource.Resource, error) {
+	info := infraversion.Get()
+
+	service := cfg.ServiceName
+	if service == "" {
+		service = os.Getenv(infralog.EnvServiceName)
+	}
+	if service == "" && info.Path != "" {
+		service = path.Base(info.Path)
+	}
+
+	attrs := []attribute.KeyValue{
+		semconv.ServiceName(service),
+	}
+	if info.Version != "" {
+		attrs = append(attrs, semconv.ServiceVersion(info.Version))
+	}
+	env := cfg.Environment
+	if env == "" {
+		env = os.Getenv(infralog.EnvEnvironment)
+	}
+	if env != "" {
+		attrs = append(attrs, semconv.DeploymentEnvironment(env))
+	}
+	if instance := os.Getenv(infralog.EnvInstance); instance != "" {
+		attrs = append(attrs, semconv.ServiceInstanceID(instance))
+	}
+	for k, v := range cfg.ResourceAttributes {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+		resource.WithAttributes(attrs...),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "resource.New")
+	}
+
+	return res, nil
+}
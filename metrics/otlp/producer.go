@@ -0,0 +1,136 @@
+package inframetricsotlp
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	infratracing "github.com/pushwoosh/infra/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// producer converts metrics gathered from prometheus to OpenTelemetry metrics with cumulative temporality:
+// counters are monotonic sums, gauges are gauges and histograms are explicit bucket histograms.
+// Summaries are skipped, their quantiles can't be aggregated by a collector.
+type producer struct {
+	gatherer prometheus.Gatherer
+	start    time.Time
+}
+
+var _ sdkmetric.Producer = (*producer)(nil)
+
+func (p *producer) Produce(context.Context) ([]metricdata.ScopeMetrics, error) {
+	families, err := p.gatherer.Gather()
+	if err != nil && len(families) == 0 {
+		return nil, errors.Wrap(err, "unable to gather metrics")
+	}
+
+	now := time.Now()
+	metrics := make([]metricdata.Metrics, 0, len(families))
+	for _, family := range families {
+		data := p.convert(family, now)
+		if data == nil {
+			continue
+		}
+		metrics = append(metrics, metricdata.Metrics{
+			Name:        family.GetName(),
+			Description: family.GetHelp(),
+			Data:        data,
+		})
+	}
+
+	scope := metricdata.ScopeMetrics{
+		Scope:   instrumentation.Scope{Name: infratracing.InstrumentationPrefix + "metrics/otlp"},
+		Metrics: metrics,
+	}
+
+	// a partial gather is exported, the error is reported anyway
+	return []metricdata.ScopeMetrics{scope}, errors.Wrap(err, "unable to gather some metrics")
+}
+
+func (p *producer) convert(family *dto.MetricFamily, now time.Time) metricdata.Aggregation {
+	switch family.GetType() {
+	case dto.MetricType_COUNTER:
+		points := make([]metricdata.DataPoint[float64], 0, len(family.GetMetric()))
+		for _, m := range family.GetMetric() {
+			points = append(points, p.point(m, m.GetCounter().GetValue(), now))
+		}
+		return metricdata.Sum[float64]{
+			DataPoints:  points,
+			Temporality: metricdata.CumulativeTemporality,
+			IsMonotonic: true,
+		}
+	case dto.MetricType_GAUGE, dto.MetricType_UNTYPED:
+		points := make([]metricdata.DataPoint[float64], 0, len(family.GetMetric()))
+		for _, m := range family.GetMetric() {
+			value := m.GetGauge().GetValue()
+			if family.GetType() == dto.MetricType_UNTYPED {
+				value = m.GetUntyped().GetValue()
+			}
+			points = append(points, p.point(m, value, now))
+		}
+		return metricdata.Gauge[float64]{DataPoints: points}
+	case dto.MetricType_HISTOGRAM:
+		points := make([]metricdata.HistogramDataPoint[float64], 0, len(family.GetMetric()))
+		for _, m := range family.GetMetric() {
+			points = append(points, p.histogramPoint(m, now))
+		}
+		return metricdata.Histogram[float64]{
+			DataPoints:  points,
+			Temporality: metricdata.CumulativeTemporality,
+		}
+	default:
+		return nil
+	}
+}
+
+func (p *producer) point(m *dto.Metric, value float64, now time.Time) metricdata.DataPoint[float64] {
+	return metricdata.DataPoint[float64]{
+		Attributes: attributes(m),
+		StartTime:  p.start,
+		Time:       now,
+		Value:      value,
+	}
+}
+
+// histogramPoint converts cumulative prometheus buckets to per bucket counts, +Inf bucket is the last count
+func (p *producer) histogramPoint(m *dto.Metric, now time.Time) metricdata.HistogramDataPoint[float64] {
+	h := m.GetHistogram()
+
+	bounds := make([]float64, 0, len(h.GetBucket()))
+	counts := make([]uint64, 0, len(h.GetBucket())+1)
+	var prev uint64
+	for _, b := range h.GetBucket() {
+		if math.IsInf(b.GetUpperBound(), 1) {
+			continue
+		}
+		bounds = append(bounds, b.GetUpperBound())
+		counts = append(counts, b.GetCumulativeCount()-prev)
+		prev = b.GetCumulativeCount()
+	}
+	counts = append(counts, h.GetSampleCount()-prev)
+
+	return metricdata.HistogramDataPoint[float64]{
+		Attributes:   attributes(m),
+		StartTime:    p.start,
+		Time:         now,
+		Count:        h.GetSampleCount(),
+		Bounds:       bounds,
+		BucketCounts: counts,
+		Sum:          h.GetSampleSum(),
+	}
+}
+
+func attributes(m *dto.Metric) attribute.Set {
+	kvs := make([]attribute.KeyValue, 0, len(m.GetLabel()))
+	for _, l := range m.GetLabel() {
+		kvs = append(kvs, attribute.String(l.GetName(), l.GetValue()))
+	}
+	return attribute.NewSet(kvs...)
+}
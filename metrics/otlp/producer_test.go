@@ -0,0 +1,71 @@
+package inframetricsotlp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestProducer(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "requests_total", Help: "requests"}, []string{"status"})
+	inflight := prometheus.NewGauge(prometheus.GaugeOpts{Name: "inflight"})
+	duration := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "duration_seconds", Buckets: []float64{0.1, 1}})
+	summary := prometheus.NewSummary(prometheus.SummaryOpts{Name: "size_bytes"})
+	registry.MustRegister(requests, inflight, duration, summary)
+
+	requests.WithLabelValues("ok").Add(3)
+	inflight.Set(2)
+	duration.Observe(0.05)
+	duration.Observe(0.5)
+	duration.Observe(5)
+	summary.Observe(1)
+
+	reader := sdkmetric.NewManualReader(sdkmetric.WithProducer(&producer{gatherer: registry, start: time.Now()}))
+	_ = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+
+	metrics := make(map[string]metricdata.Metrics)
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		metrics[m.Name] = m
+	}
+	require.Len(t, metrics, 3, "summary is skipped")
+
+	sum := metrics["requests_total"].Data.(metricdata.Sum[float64])
+	require.Equal(t, "requests", metrics["requests_total"].Description)
+	require.True(t, sum.IsMonotonic)
+	require.Equal(t, metricdata.CumulativeTemporality, sum.Temporality)
+	require.Equal(t, 3.0, sum.DataPoints[0].Value)
+	status, ok := sum.DataPoints[0].Attributes.Value(attribute.Key("status"))
+	require.True(t, ok)
+	require.Equal(t, "ok", status.AsString())
+
+	gauge := metrics["inflight"].Data.(metricdata.Gauge[float64])
+	require.Equal(t, 2.0, gauge.DataPoints[0].Value)
+
+	histogram := metrics["duration_seconds"].Data.(metricdata.Histogram[float64])
+	point := histogram.DataPoints[0]
+	require.Equal(t, uint64(3), point.Count)
+	require.Equal(t, []float64{0.1, 1}, point.Bounds)
+	require.Equal(t, []uint64{1, 1, 1}, point.BucketCounts)
+	require.InDelta(t, 5.55, point.Sum, 1e-9)
+}
+
+func TestPipelineDisabled(t *testing.T) {
+	p, err := NewPipeline(&Config{})
+	require.NoError(t, err)
+	require.NoError(t, p.Start(context.Background()))
+	require.NoError(t, p.Stop(context.Background()))
+
+	_, err = NewPipeline(&Config{Enabled: true, Exporter: "zipkin"})
+	require.Error(t, err)
+}
@@ -0,0 +1,112 @@
+package inframetricsremotewrite
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultInterval             = 15 * time.Second
+	defaultTimeout              = 10 * time.Second
+	defaultMaxSamplesPerRequest = 5000
+	defaultMaxRetries           = 3
+)
+
+type Config struct {
+	Enabled bool `mapstructure:"enabled"`
+
+	// URL of the remote-write endpoint, e.g. http://victoria-metrics:8428/api/v1/write
+	URL string `mapstructure:"url"`
+
+	// Basic auth or a bearer token, values may be secret references, see infraconfig.ResolveSecret. optional
+	Username    string `mapstructure:"username"`
+	Password    string `mapstructure:"password"`
+	BearerToken string `mapstructure:"bearer_token"`
+
+	// Headers of requests, e.g. a tenant header of a multi-tenant storage. optional
+	Headers map[string]string `mapstructure:"headers"`
+
+	// Labels added to all series, e.g. site or instance of an edge deployment. optional
+	ExternalLabels map[string]string `mapstructure:"external_labels"`
+
+	// Labels dropped from all series to keep cardinality low. optional
+	DropLabels []string `mapstructure:"drop_labels"`
+
+	// Metrics are gathered and pushed once per interval. optional, default: 15s
+	Interval time.Duration `mapstructure:"interval"`
+
+	// Timeout of a single request. optional, default: 10s
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// Series are split into requests of at most that many samples. optional, default: 5000
+	MaxSamplesPerRequest int `mapstructure:"max_samples_per_request"`
+
+	// A failed request is retried that many times before its samples are dropped. optional, default: 3
+	MaxRetries *int `mapstructure:"max_retries"`
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if !c.Enabled {
+		return nil
+	}
+
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return errors.Wrap(err, "invalid url")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.Errorf("url should be http or https, got %q", c.URL)
+	}
+
+	if c.BearerToken != "" && (c.Username != "" || c.Password != "") {
+		return errors.New("bearer_token and basic auth are mutually exclusive")
+	}
+
+	if c.Interval < 0 || c.Timeout < 0 {
+		return errors.New("interval and timeout should not be negative")
+	}
+
+	if c.MaxSamplesPerRequest < 0 {
+		return errors.New("max_samples_per_request should not be negative")
+	}
+
+	if c.MaxRetries != nil && *c.MaxRetries < 0 {
+		return errors.New("max_retries should not be negative")
+	}
+
+	return nil
+}
+
+func (c *Config) GetInterval() time.Duration {
+	if c.Interval == 0 {
+		return defaultInterval
+	}
+	return c.Interval
+}
+
+func (c *Config) GetTimeout() time.Duration {
+	if c.Timeout == 0 {
+		return defaultTimeout
+	}
+	return c.Timeout
+}
+
+func (c *Config) GetMaxSamplesPerRequest() int {
+	if c.MaxSamplesPerRequest == 0 {
+		return defaultMaxSamplesPerRequest
+	}
+	return c.MaxSamplesPerRequest
+}
+
+func (c *Config) GetMaxRetries() int {
+	if c.MaxRetries == nil {
+		return defaultMaxRetries
+	}
+	return *c.MaxRetries
+}
@@ -0,0 +1,144 @@
+package inframetricsremotewrite
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+type label struct {
+	name  string
+	value string
+}
+
+type series struct {
+	labels []label
+	value  float64
+}
+
+// seriesOf converts a metric family to series of the remote-write data model:
+// histograms and summaries are split into _bucket, _sum and _count series
+func (s *Sink) seriesOf(family *dto.MetricFamily) []*series {
+	name := family.GetName()
+
+	var result []*series
+	add := func(name string, pairs []*dto.LabelPair, value float64, extra ...label) {
+		result = append(result, &series{labels: s.labels(name, pairs, extra), value: value})
+	}
+
+	for _, m := range family.GetMetric() {
+		pairs := m.GetLabel()
+
+		switch family.GetType() {
+		case dto.MetricType_COUNTER:
+			add(name, pairs, m.GetCounter().GetValue())
+		case dto.MetricType_GAUGE:
+			add(name, pairs, m.GetGauge().GetValue())
+		case dto.MetricType_UNTYPED:
+			add(name, pairs, m.GetUntyped().GetValue())
+		case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+			h := m.GetHistogram()
+			hasInf := false
+			for _, b := range h.GetBucket() {
+				if math.IsInf(b.GetUpperBound(), +1) {
+					hasInf = true
+				}
+				add(name+"_bucket", pairs, float64(b.GetCumulativeCount()), label{"le", formatFloat(b.GetUpperBound())})
+			}
+			if !hasInf {
+				add(name+"_bucket", pairs, float64(h.GetSampleCount()), label{"le", "+Inf"})
+			}
+			add(name+"_sum", pairs, h.GetSampleSum())
+			add(name+"_count", pairs, float64(h.GetSampleCount()))
+		case dto.MetricType_SUMMARY:
+			summary := m.GetSummary()
+			for _, q := range summary.GetQuantile() {
+				add(name, pairs, q.GetValue(), label{"quantile", formatFloat(q.GetQuantile())})
+			}
+			add(name+"_sum", pairs, summary.GetSampleSum())
+			add(name+"_count", pairs, float64(summary.GetSampleCount()))
+		}
+	}
+
+	return result
+}
+
+// labels returns sorted labels of a series. Labels of the metric win over external ones
+func (s *Sink) labels(name string, pairs []*dto.LabelPair, extra []label) []label {
+	labels := make([]label, 0, len(pairs)+len(extra)+len(s.cfg.ExternalLabels)+1)
+	labels = append(labels, label{"__name__", name})
+	labels = append(labels, extra...)
+
+	seen := func(name string) bool {
+		return slices.ContainsFunc(labels, func(l label) bool { return l.name == name })
+	}
+
+	for _, pair := range pairs {
+		if !slices.Contains(s.cfg.DropLabels, pair.GetName()) {
+			labels = append(labels, label{pair.GetName(), pair.GetValue()})
+		}
+	}
+	for name, value := range s.cfg.ExternalLabels {
+		if !seen(name) {
+			labels = append(labels, label{name, value})
+		}
+	}
+
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].name < labels[j].name
+	})
+
+	return labels
+}
+
+// encodeWriteRequest encodes prometheus.WriteRequest protobuf message, all samples have the same timestamp:
+//
+//	message WriteRequest { repeated TimeSeries timeseries = 1; }
+//	message TimeSeries { repeated Label labels = 1; repeated Sample samples = 2; }
+//	message Label { string name = 1; string value = 2; }
+//	message Sample { double value = 1; int64 timestamp = 2; }
+func encodeWriteRequest(batch []*series, timestamp int64) []byte {
+	var request, ts, buf []byte
+	for _, s := range batch {
+		ts = ts[:0]
+		for _, l := range s.labels {
+			buf = buf[:0]
+			buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+			buf = protowire.AppendString(buf, l.name)
+			buf = protowire.AppendTag(buf, 2, protowire.BytesType)
+			buf = protowire.AppendString(buf, l.value)
+
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, buf)
+		}
+
+		buf = buf[:0]
+		buf = protowire.AppendTag(buf, 1, protowire.Fixed64Type)
+		buf = protowire.AppendFixed64(buf, math.Float64bits(s.value))
+		buf = protowire.AppendTag(buf, 2, protowire.VarintType)
+		buf = protowire.AppendVarint(buf, uint64(timestamp))
+
+		ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+		ts = protowire.AppendBytes(ts, buf)
+
+		request = protowire.AppendTag(request, 1, protowire.BytesType)
+		request = protowire.AppendBytes(request, ts)
+	}
+
+	return request
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, +1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+}
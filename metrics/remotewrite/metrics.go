@@ -0,0 +1,40 @@
+package inframetricsremotewrite
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	SamplesCounter  *prometheus.CounterVec
+	RequestsCounter *prometheus.CounterVec
+	RequestDuration prometheus.Histogram
+}
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.SamplesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "remote_write_samples_total",
+			Help: "The total number of samples pushed with remote write by result: sent or dropped",
+		}, []string{"result"})
+
+		metrics.RequestsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "remote_write_requests_total",
+			Help: "The total number of remote write requests by response status",
+		}, []string{"status"})
+
+		metrics.RequestDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "remote_write_request_duration_seconds",
+			Help:    "The remote write request duration",
+			Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		})
+
+		prometheus.MustRegister(
+			metrics.SamplesCounter,
+			metrics.RequestsCounter,
+			metrics.RequestDuration,
+		)
+	})
+}
@@ -0,0 +1,246 @@
+package inframetricsremotewrite
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	infraconfig "github.com/pushwoosh/infra/config"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	infraretry "github.com/pushwoosh/infra/retry"
+	"go.uber.org/zap"
+)
+
+type Option interface {
+	apply(s *Sink)
+}
+
+type optionGatherer struct {
+	gatherer prometheus.Gatherer
+}
+
+func (opt optionGatherer) apply(s *Sink) {
+	s.gatherer = opt.gatherer
+}
+
+// WithGatherer sets metrics to push, default: prometheus.DefaultGatherer with all infra metrics
+func WithGatherer(gatherer prometheus.Gatherer) Option {
+	return optionGatherer{gatherer: gatherer}
+}
+
+// Sink pushes metrics registered for Prometheus with the remote-write protocol to VictoriaMetrics,
+// Prometheus or any compatible storage. It's meant for edge deployments that can't be scraped:
+//
+//	sink, err := inframetricsremotewrite.NewSink(cfg.RemoteWrite)
+//	app.Add("remote write", sink)
+//
+// All series are pushed once per interval in snappy compressed batches. A failed batch is retried
+// on network errors, 429 and 5xx responses, then its samples are dropped and counted
+// in remote_write_samples_total{result="dropped"}. Sink does nothing if it's disabled.
+type Sink struct {
+	cfg      *Config
+	gatherer prometheus.Gatherer
+	client   *http.Client
+	retrier  *infraretry.Retrier
+	auth     func(r *http.Request)
+
+	flushMu sync.Mutex
+
+	runMu  sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+var (
+	_ infraoperator.Starter = (*Sink)(nil)
+	_ infraoperator.Stopper = (*Sink)(nil)
+)
+
+func NewSink(cfg *Config, opts ...Option) (*Sink, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	initMetrics()
+
+	s := &Sink{
+		cfg:      cfg,
+		gatherer: prometheus.DefaultGatherer,
+		client:   &http.Client{Timeout: cfg.GetTimeout()},
+		retrier:  infraretry.New("remote_write", infraretry.WithMaxAttempts(cfg.GetMaxRetries()+1)),
+		auth:     func(*http.Request) {},
+	}
+
+	for _, opt := range opts {
+		opt.apply(s)
+	}
+
+	if err := s.resolveAuth(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Start starts pushing metrics in background
+func (s *Sink) Start(_ context.Context) error {
+	if !s.cfg.Enabled {
+		return nil
+	}
+
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	if s.cancel != nil {
+		return errors.New("sink is already started")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+
+	go s.run(ctx)
+
+	return nil
+}
+
+func (s *Sink) run(ctx context.Context) {
+	defer close(s.done)
+
+	for {
+		if err := infraretry.Sleep(ctx, s.cfg.GetInterval()); err != nil {
+			return
+		}
+
+		if err := s.Flush(ctx); err != nil && ctx.Err() == nil {
+			infralog.Error("unable to push metrics with remote write", zap.Error(err))
+		}
+	}
+}
+
+// Stop stops the background loop and pushes metrics the last time
+func (s *Sink) Stop(ctx context.Context) error {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	if s.cancel == nil {
+		return nil
+	}
+
+	s.cancel()
+	<-s.done
+	s.cancel = nil
+
+	return s.Flush(ctx)
+}
+
+// Flush pushes current values of all series right now
+func (s *Sink) Flush(ctx context.Context) error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	families, err := s.gatherer.Gather()
+	if err != nil && len(families) == 0 {
+		return errors.Wrap(err, "unable to gather metrics")
+	}
+
+	var all []*series
+	for _, family := range families {
+		all = append(all, s.seriesOf(family)...)
+	}
+
+	timestamp := time.Now().UnixMilli()
+	size := s.cfg.GetMaxSamplesPerRequest()
+	var pushErr error
+	for start := 0; start < len(all); start += size {
+		batch := all[start:min(start+size, len(all))]
+		if batchErr := s.push(ctx, encodeWriteRequest(batch, timestamp)); batchErr != nil {
+			metrics.SamplesCounter.WithLabelValues("dropped").Add(float64(len(batch)))
+			if pushErr == nil {
+				pushErr = batchErr
+			}
+			continue
+		}
+		metrics.SamplesCounter.WithLabelValues("sent").Add(float64(len(batch)))
+	}
+
+	if pushErr != nil {
+		return pushErr
+	}
+
+	// a partial gather is pushed, the error is reported anyway
+	return errors.Wrap(err, "unable to gather some metrics")
+}
+
+func (s *Sink) push(ctx context.Context, request []byte) error {
+	body := snappy.Encode(nil, request)
+
+	return s.retrier.Do(ctx, func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.URL, bytes.NewReader(body))
+		if err != nil {
+			return infraretry.Permanent(err)
+		}
+		req.Header.Set("Content-Type", "application/x-protobuf")
+		req.Header.Set("Content-Encoding", "snappy")
+		req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+		for k, v := range s.cfg.Headers {
+			req.Header.Set(k, v)
+		}
+		s.auth(req)
+
+		start := time.Now()
+		resp, err := s.client.Do(req)
+		metrics.RequestDuration.Observe(time.Since(start).Seconds())
+		if err != nil {
+			metrics.RequestsCounter.WithLabelValues("error").Inc()
+			return errors.Wrap(err, "unable to send remote write request")
+		}
+		defer resp.Body.Close()
+
+		metrics.RequestsCounter.WithLabelValues(strconv.Itoa(resp.StatusCode)).Inc()
+		if resp.StatusCode/100 == 2 {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			return nil
+		}
+
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		err = errors.Errorf("remote write responded %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			return err
+		}
+		return infraretry.Permanent(err)
+	})
+}
+
+func (s *Sink) resolveAuth() error {
+	if s.cfg.BearerToken != "" {
+		token, err := infraconfig.ResolveSecret(s.cfg.BearerToken)
+		if err != nil {
+			return errors.Wrap(err, "bearer_token")
+		}
+		s.auth = func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+token) }
+		return nil
+	}
+
+	if s.cfg.Username != "" || s.cfg.Password != "" {
+		username, err := infraconfig.ResolveSecret(s.cfg.Username)
+		if err != nil {
+			return errors.Wrap(err, "username")
+		}
+		password, err := infraconfig.ResolveSecret(s.cfg.Password)
+		if err != nil {
+			return errors.Wrap(err, "password")
+		}
+		s.auth = func(r *http.Request) { r.SetBasicAuth(username, password) }
+	}
+
+	return nil
+}
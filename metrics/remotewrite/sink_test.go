@@ -0,0 +1,110 @@
+package inframetricsremotewrite
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// timeseries returns label sets of series in an encoded WriteRequest as name=value strings
+func timeseries(t *testing.T, request []byte) []string {
+	t.Helper()
+
+	var result []string
+	for len(request) > 0 {
+		_, _, n := protowire.ConsumeTag(request)
+		ts, m := protowire.ConsumeBytes(request[n:])
+		request = request[n+m:]
+
+		var labels []string
+		for len(ts) > 0 {
+			num, _, n := protowire.ConsumeTag(ts)
+			field, m := protowire.ConsumeBytes(ts[n:])
+			ts = ts[n+m:]
+			if num != 1 {
+				continue
+			}
+
+			_, _, n = protowire.ConsumeTag(field)
+			name, m := protowire.ConsumeString(field[n:])
+			field = field[n+m:]
+			_, _, n = protowire.ConsumeTag(field)
+			value, _ := protowire.ConsumeString(field[n:])
+			labels = append(labels, name+"="+value)
+		}
+		result = append(result, strings.Join(labels, ","))
+	}
+
+	return result
+}
+
+func TestSink(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests [][]string
+		status   = http.StatusNoContent
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		decoded, err := snappy.Decode(nil, body)
+		if err != nil || r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, timeseries(t, decoded))
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	registry := prometheus.NewRegistry()
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "jobs_total"}, []string{"queue", "pod"})
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "latency_seconds", Buckets: []float64{1}})
+	registry.MustRegister(counter, histogram)
+	counter.WithLabelValues("push", "pod-1").Add(3)
+	histogram.Observe(0.5)
+
+	sink, err := NewSink(&Config{
+		Enabled:              true,
+		URL:                  srv.URL,
+		BearerToken:          "secret",
+		ExternalLabels:       map[string]string{"site": "edge-1", "queue": "ignored"},
+		DropLabels:           []string{"pod"},
+		MaxSamplesPerRequest: 2,
+	}, WithGatherer(registry))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = sink.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 5 series: 2 buckets, sum and count of the histogram and the counter
+	if len(requests) != 3 {
+		t.Fatalf("expected 3 requests, got %v", requests)
+	}
+	if requests[0][0] != "__name__=jobs_total,queue=push,site=edge-1" {
+		t.Fatalf("unexpected labels %s", requests[0][0])
+	}
+	if requests[0][1] != "__name__=latency_seconds_bucket,le=1,queue=ignored,site=edge-1" {
+		t.Fatalf("unexpected labels %s", requests[0][1])
+	}
+
+	// client errors are not retried
+	status = http.StatusBadRequest
+	requests = nil
+	if err = sink.Flush(context.Background()); err == nil || len(requests) != 3 {
+		t.Fatalf("expected error without retries, got %v after %d requests", err, len(requests))
+	}
+}
@@ -0,0 +1,99 @@
+package inframetrics
+
+import (
+	"regexp"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	infraversion "github.com/pushwoosh/infra/version"
+)
+
+// DefaultNamespace is a prefix of all runtime metrics, e.g. infra_go_goroutines
+const DefaultNamespace = "infra"
+
+// heapMetrics are heap classes of runtime/metrics, the rest of memory classes are covered by memstats
+var heapMetrics = collectors.GoRuntimeMetricsRule{Matcher: regexp.MustCompile(`^/memory/classes/heap/.*`)}
+
+type Option interface {
+	apply(o *options)
+}
+
+type options struct {
+	namespace  string
+	registerer prometheus.Registerer
+}
+
+type optionNamespace string
+
+func (opt optionNamespace) apply(o *options) {
+	o.namespace = string(opt)
+}
+
+// WithNamespace sets a metric name prefix instead of DefaultNamespace
+func WithNamespace(namespace string) Option {
+	return optionNamespace(namespace)
+}
+
+type optionRegisterer struct {
+	registerer prometheus.Registerer
+}
+
+func (opt optionRegisterer) apply(o *options) {
+	o.registerer = opt.registerer
+}
+
+// WithRegisterer sets a registerer instead of prometheus.DefaultRegisterer
+func WithRegisterer(registerer prometheus.Registerer) Option {
+	return optionRegisterer{registerer: registerer}
+}
+
+// RegisterRuntime registers a curated set of runtime and process metrics, so dashboards are the same
+// for all services using infra:
+//
+//	<namespace>_go_goroutines, <namespace>_go_memstats_*     - goroutines and memory stats
+//	<namespace>_go_gc_*                                      - GC cycles, pauses and heap goals
+//	<namespace>_go_sched_latencies_seconds                   - time goroutines wait for a thread
+//	<namespace>_go_memory_classes_heap_*                     - heap usage by class
+//	<namespace>_process_*                                    - CPU, RSS, open fds and start time
+//	<namespace>_build_info                                   - version, commit and build date, see infraversion
+//
+// Metrics are prefixed with the namespace, so they don't clash with go_* and process_* metrics
+// of the default registry.
+func RegisterRuntime(opts ...Option) error {
+	o := &options{
+		namespace:  DefaultNamespace,
+		registerer: prometheus.DefaultRegisterer,
+	}
+	for _, opt := range opts {
+		opt.apply(o)
+	}
+
+	registerer := o.registerer
+	if o.namespace != "" {
+		registerer = prometheus.WrapRegistererWithPrefix(o.namespace+"_", registerer)
+	}
+
+	cs := []prometheus.Collector{
+		collectors.NewGoCollector(
+			collectors.WithGoCollectorRuntimeMetrics(
+				collectors.MetricsGC,
+				collectors.MetricsScheduler,
+				heapMetrics,
+			),
+		),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		infraversion.Collector(),
+	}
+
+	for _, c := range cs {
+		if err := registerer.Register(c); err != nil {
+			if errors.As(err, &prometheus.AlreadyRegisteredError{}) {
+				continue
+			}
+			return errors.Wrap(err, "unable to register runtime metrics")
+		}
+	}
+
+	return nil
+}
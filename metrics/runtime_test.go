@@ -0,0 +1,41 @@
+package inframetrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestRegisterRuntime(t *testing.T) {
+	registry := prometheus.NewRegistry()
+
+	if err := RegisterRuntime(WithRegisterer(registry)); err != nil {
+		t.Fatal(err)
+	}
+	// registering twice is not an error
+	if err := RegisterRuntime(WithRegisterer(registry)); err != nil {
+		t.Fatal(err)
+	}
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := make(map[string]bool, len(families))
+	for _, f := range families {
+		names[f.GetName()] = true
+	}
+
+	for _, name := range []string{
+		"infra_go_goroutines",
+		"infra_go_sched_latencies_seconds",
+		"infra_go_gc_duration_seconds",
+		"infra_process_start_time_seconds",
+		"infra_build_info",
+	} {
+		if !names[name] {
+			t.Errorf("metric %s is not registered", name)
+		}
+	}
+}
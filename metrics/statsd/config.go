@@ -0,0 +1,121 @@
+package inframetricsstatsd
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultAddress       = "127.0.0.1:8125"
+	defaultNetwork       = "udp"
+	defaultInterval      = 10 * time.Second
+	defaultMaxPacketSize = 1432
+
+	// TagFormatDatadog appends tags as DogStatsD does: name:1|c|#key:value
+	TagFormatDatadog = "datadog"
+	// TagFormatInflux appends tags to the name: name,key=value:1|c
+	TagFormatInflux = "influx"
+	// TagFormatGraphite appends tags to the name: name;key=value:1|c
+	TagFormatGraphite = "graphite"
+	// TagFormatNone drops tags for plain StatsD, series with different labels are summed by the server
+	TagFormatNone = "none"
+)
+
+type Config struct {
+	Enabled bool `mapstructure:"enabled"`
+
+	// Agent address "host:port" or a unix socket path. optional, default: 127.0.0.1:8125
+	Address string `mapstructure:"address"`
+
+	// udp or unixgram. optional, default: udp
+	Network string `mapstructure:"network"`
+
+	// Prefix of metric names, e.g. "billing.". optional
+	Prefix string `mapstructure:"prefix"`
+
+	// datadog, influx, graphite or none. optional, default: datadog
+	TagFormat string `mapstructure:"tag_format"`
+
+	// Constant tags of all metrics, e.g. env or service. optional
+	Tags map[string]string `mapstructure:"tags"`
+
+	// Prometheus label names mapped to tag names, e.g. connection: db. optional
+	TagMapping map[string]string `mapstructure:"tag_mapping"`
+
+	// Labels not sent as tags to keep cardinality low. optional
+	DropLabels []string `mapstructure:"drop_labels"`
+
+	// Metrics are aggregated and sent once per interval. optional, default: 10s
+	Interval time.Duration `mapstructure:"interval"`
+
+	// Max size of one packet, metrics are split into several packets. optional, default: 1432
+	MaxPacketSize int `mapstructure:"max_packet_size"`
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if !c.Enabled {
+		return nil
+	}
+
+	switch c.Network {
+	case "", "udp", "unixgram":
+	default:
+		return errors.Errorf("unknown network %s", c.Network)
+	}
+
+	switch c.TagFormat {
+	case "", TagFormatDatadog, TagFormatInflux, TagFormatGraphite, TagFormatNone:
+	default:
+		return errors.Errorf("unknown tag_format %s", c.TagFormat)
+	}
+
+	if c.Interval < 0 {
+		return errors.New("interval should not be negative")
+	}
+
+	if c.MaxPacketSize < 0 {
+		return errors.New("max_packet_size should not be negative")
+	}
+
+	return nil
+}
+
+func (c *Config) GetAddress() string {
+	if c.Address == "" {
+		return defaultAddress
+	}
+	return c.Address
+}
+
+func (c *Config) GetNetwork() string {
+	if c.Network == "" {
+		return defaultNetwork
+	}
+	return c.Network
+}
+
+func (c *Config) GetTagFormat() string {
+	if c.TagFormat == "" {
+		return TagFormatDatadog
+	}
+	return c.TagFormat
+}
+
+func (c *Config) GetInterval() time.Duration {
+	if c.Interval == 0 {
+		return defaultInterval
+	}
+	return c.Interval
+}
+
+func (c *Config) GetMaxPacketSize() int {
+	if c.MaxPacketSize == 0 {
+		return defaultMaxPacketSize
+	}
+	return c.MaxPacketSize
+}
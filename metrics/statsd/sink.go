@@ -0,0 +1,381 @@
+package inframetricsstatsd
+
+import (
+	"context"
+	"math"
+	"net"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	infraretry "github.com/pushwoosh/infra/retry"
+	"go.uber.org/zap"
+)
+
+type Option interface {
+	apply(s *Sink)
+}
+
+type optionGatherer struct {
+	gatherer prometheus.Gatherer
+}
+
+func (opt optionGatherer) apply(s *Sink) {
+	s.gatherer = opt.gatherer
+}
+
+// WithGatherer sets metrics to send, default: prometheus.DefaultGatherer with all infra metrics
+func WithGatherer(gatherer prometheus.Gatherer) Option {
+	return optionGatherer{gatherer: gatherer}
+}
+
+// Sink sends metrics registered for Prometheus to a StatsD or DogStatsD agent, so infra packages
+// are instrumented once for both backends:
+//
+//	sink, err := inframetricsstatsd.NewSink(cfg.StatsD)
+//	app.Add("statsd", sink)
+//
+// Metrics are aggregated on the client and sent once per interval:
+//
+//	counter   - increment since the previous flush or Start, name:5|c
+//	gauge     - current value, name:5|g
+//	histogram - increments of count and sum, name.count:5|c and name.sum:0.25|c
+//	summary   - increments of count and sum, quantiles as gauges tagged with quantile
+//
+// Series without changes are not sent. Sink does nothing if it's disabled.
+type Sink struct {
+	cfg      *Config
+	gatherer prometheus.Gatherer
+	tags     string
+
+	flushMu sync.Mutex
+	conn    net.Conn
+	last    map[string]float64
+
+	runMu  sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+var (
+	_ infraoperator.Starter = (*Sink)(nil)
+	_ infraoperator.Stopper = (*Sink)(nil)
+)
+
+func NewSink(cfg *Config, opts ...Option) (*Sink, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	s := &Sink{
+		cfg:      cfg,
+		gatherer: prometheus.DefaultGatherer,
+		last:     make(map[string]float64),
+	}
+
+	for _, opt := range opts {
+		opt.apply(s)
+	}
+
+	return s, nil
+}
+
+// Start connects to the agent and starts sending metrics in background
+func (s *Sink) Start(_ context.Context) error {
+	if !s.cfg.Enabled {
+		return nil
+	}
+
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	if s.cancel != nil {
+		return errors.New("sink is already started")
+	}
+
+	conn, err := net.Dial(s.cfg.GetNetwork(), s.cfg.GetAddress())
+	if err != nil {
+		return errors.Wrap(err, "unable to connect to statsd")
+	}
+
+	s.flushMu.Lock()
+	s.conn = conn
+	s.seed()
+	s.flushMu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+
+	go s.run(ctx)
+
+	return nil
+}
+
+func (s *Sink) run(ctx context.Context) {
+	defer close(s.done)
+
+	for {
+		if err := infraretry.Sleep(ctx, s.cfg.GetInterval()); err != nil {
+			return
+		}
+
+		if err := s.Flush(); err != nil {
+			infralog.Error("unable to send metrics to statsd", zap.Error(err))
+		}
+	}
+}
+
+// Stop stops the background loop, sends the last changes and closes the connection
+func (s *Sink) Stop(_ context.Context) error {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	if s.cancel == nil {
+		return nil
+	}
+
+	s.cancel()
+	<-s.done
+	s.cancel = nil
+
+	err := s.Flush()
+
+	s.flushMu.Lock()
+	_ = s.conn.Close()
+	s.conn = nil
+	s.flushMu.Unlock()
+
+	return err
+}
+
+// Flush sends metrics changed since the previous flush right now
+func (s *Sink) Flush() error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	if s.conn == nil {
+		return errors.New("sink is not started")
+	}
+
+	families, err := s.gatherer.Gather()
+	if err != nil && len(families) == 0 {
+		return errors.Wrap(err, "unable to gather metrics")
+	}
+
+	w := &packetWriter{conn: s.conn, max: s.cfg.GetMaxPacketSize()}
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			s.write(w, family, m)
+		}
+	}
+
+	if flushErr := w.flush(); flushErr != nil {
+		return flushErr
+	}
+
+	// a partial gather is sent, the error is reported anyway
+	return errors.Wrap(err, "unable to gather some metrics")
+}
+
+func (s *Sink) write(w *packetWriter, family *dto.MetricFamily, m *dto.Metric) {
+	name := family.GetName()
+	labels := m.GetLabel()
+
+	switch family.GetType() {
+	case dto.MetricType_COUNTER:
+		s.counter(w, name, labels, m.GetCounter().GetValue())
+	case dto.MetricType_GAUGE:
+		s.gauge(w, name, labels, m.GetGauge().GetValue())
+	case dto.MetricType_UNTYPED:
+		s.gauge(w, name, labels, m.GetUntyped().GetValue())
+	case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+		h := m.GetHistogram()
+		s.counter(w, name+".count", labels, float64(h.GetSampleCount()))
+		s.counter(w, name+".sum", labels, h.GetSampleSum())
+	case dto.MetricType_SUMMARY:
+		summary := m.GetSummary()
+		s.counter(w, name+".count", labels, float64(summary.GetSampleCount()))
+		s.counter(w, name+".sum", labels, summary.GetSampleSum())
+		for _, q := range summary.GetQuantile() {
+			quantile := &dto.LabelPair{
+				Name:  proto("quantile"),
+				Value: proto(strconv.FormatFloat(q.GetQuantile(), 'f', -1, 64)),
+			}
+			s.gauge(w, name, append(slices.Clone(labels), quantile), q.GetValue())
+		}
+	}
+}
+
+// seed records current values of counters without sending them, so the first flush sends increments
+// since Start instead of totals counted before it. flushMu must be held
+func (s *Sink) seed() {
+	families, _ := s.gatherer.Gather()
+	for _, family := range families {
+		name := family.GetName()
+		for _, m := range family.GetMetric() {
+			labels := m.GetLabel()
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				s.last[seriesKey(name, labels)] = m.GetCounter().GetValue()
+			case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+				s.last[seriesKey(name+".count", labels)] = float64(m.GetHistogram().GetSampleCount())
+				s.last[seriesKey(name+".sum", labels)] = m.GetHistogram().GetSampleSum()
+			case dto.MetricType_SUMMARY:
+				s.last[seriesKey(name+".count", labels)] = float64(m.GetSummary().GetSampleCount())
+				s.last[seriesKey(name+".sum", labels)] = m.GetSummary().GetSampleSum()
+			}
+		}
+	}
+}
+
+// counter sends the increment since the previous flush. A counter reset is sent as the new value
+func (s *Sink) counter(w *packetWriter, name string, labels []*dto.LabelPair, value float64) {
+	key := seriesKey(name, labels)
+	delta := value - s.last[key]
+	if delta < 0 {
+		delta = value
+	}
+	s.last[key] = value
+
+	if delta == 0 || math.IsNaN(delta) {
+		return
+	}
+
+	w.write(s.line(name, labels, delta, "c"))
+}
+
+func (s *Sink) gauge(w *packetWriter, name string, labels []*dto.LabelPair, value float64) {
+	key := seriesKey(name, labels)
+	last, ok := s.last[key]
+	s.last[key] = value
+
+	if (ok && last == value) || math.IsNaN(value) {
+		return
+	}
+
+	// a signed gauge value is an increment in statsd, negative values are set from zero
+	if value < 0 {
+		w.write(s.line(name, labels, 0, "g"))
+	}
+	w.write(s.line(name, labels, value, "g"))
+}
+
+func (s *Sink) line(name string, labels []*dto.LabelPair, value float64, typ string) string {
+	tags := s.formatTags(labels)
+	name = s.cfg.Prefix + name
+	v := strconv.FormatFloat(value, 'f', -1, 64)
+
+	switch s.cfg.GetTagFormat() {
+	case TagFormatDatadog:
+		if tags == "" {
+			return name + ":" + v + "|" + typ
+		}
+		return name + ":" + v + "|" + typ + "|#" + tags
+	case TagFormatInflux, TagFormatGraphite:
+		if tags == "" {
+			return name + ":" + v + "|" + typ
+		}
+		sep := ","
+		if s.cfg.GetTagFormat() == TagFormatGraphite {
+			sep = ";"
+		}
+		return name + sep + tags + ":" + v + "|" + typ
+	default:
+		return name + ":" + v + "|" + typ
+	}
+}
+
+func (s *Sink) formatTags(labels []*dto.LabelPair) string {
+	format := s.cfg.GetTagFormat()
+	if format == TagFormatNone {
+		return ""
+	}
+
+	kv, sep := ":", ","
+	switch format {
+	case TagFormatInflux:
+		kv = "="
+	case TagFormatGraphite:
+		kv, sep = "=", ";"
+	}
+
+	tags := make([]string, 0, len(labels)+len(s.cfg.Tags))
+	for name, value := range s.cfg.Tags {
+		tags = append(tags, sanitize(name)+kv+sanitize(value))
+	}
+	for _, l := range labels {
+		name := l.GetName()
+		if slices.Contains(s.cfg.DropLabels, name) {
+			continue
+		}
+		if mapped, ok := s.cfg.TagMapping[name]; ok {
+			name = mapped
+		}
+		tags = append(tags, sanitize(name)+kv+sanitize(l.GetValue()))
+	}
+	sort.Strings(tags)
+
+	return strings.Join(tags, sep)
+}
+
+var sanitizer = strings.NewReplacer("|", "_", ",", "_", "#", "_", ":", "_", "=", "_", ";", "_", " ", "_", "\n", "_")
+
+func sanitize(s string) string {
+	return sanitizer.Replace(s)
+}
+
+func seriesKey(name string, labels []*dto.LabelPair) string {
+	var b strings.Builder
+	b.WriteString(name)
+	for _, l := range labels {
+		b.WriteByte(0xff)
+		b.WriteString(l.GetName())
+		b.WriteByte(0xfe)
+		b.WriteString(l.GetValue())
+	}
+	return b.String()
+}
+
+func proto(s string) *string {
+	return &s
+}
+
+// packetWriter joins lines into packets not larger than max
+type packetWriter struct {
+	conn net.Conn
+	max  int
+	buf  []byte
+	err  error
+}
+
+func (w *packetWriter) write(line string) {
+	if len(w.buf) > 0 && len(w.buf)+1+len(line) > w.max {
+		w.send()
+	}
+	if len(w.buf) > 0 {
+		w.buf = append(w.buf, '\n')
+	}
+	w.buf = append(w.buf, line...)
+}
+
+func (w *packetWriter) send() {
+	if _, err := w.conn.Write(w.buf); err != nil && w.err == nil {
+		w.err = errors.Wrap(err, "unable to send metrics")
+	}
+	w.buf = w.buf[:0]
+}
+
+func (w *packetWriter) flush() error {
+	if len(w.buf) > 0 {
+		w.send()
+	}
+	return w.err
+}
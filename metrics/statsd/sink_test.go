@@ -0,0 +1,126 @@
+package inframetricsstatsd
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func readLines(t *testing.T, conn net.PacketConn) []string {
+	t.Helper()
+
+	var lines []string
+	buf := make([]byte, 65536)
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			break
+		}
+		lines = append(lines, strings.Split(string(buf[:n]), "\n")...)
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+func TestSink(t *testing.T) {
+	agent, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer agent.Close()
+
+	registry := prometheus.NewRegistry()
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "requests_total"}, []string{"connection", "status"})
+	inflight := prometheus.NewGauge(prometheus.GaugeOpts{Name: "inflight"})
+	duration := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "duration_seconds"})
+	registry.MustRegister(requests, inflight, duration)
+
+	s, err := NewSink(&Config{
+		Enabled:       true,
+		Address:       agent.LocalAddr().String(),
+		Prefix:        "svc.",
+		Tags:          map[string]string{"env": "test"},
+		TagMapping:    map[string]string{"connection": "db"},
+		DropLabels:    []string{"status"},
+		Interval:      time.Hour,
+		MaxPacketSize: 64,
+	}, WithGatherer(registry))
+	require.NoError(t, err)
+	require.NoError(t, s.Start(context.Background()))
+
+	requests.WithLabelValues("main", "ok").Add(3)
+	inflight.Set(-2)
+	duration.Observe(0.5)
+
+	require.NoError(t, s.Flush())
+	require.Equal(t, []string{
+		"svc.duration_seconds.count:1|c|#env:test",
+		"svc.duration_seconds.sum:0.5|c|#env:test",
+		"svc.inflight:-2|g|#env:test",
+		"svc.inflight:0|g|#env:test",
+		"svc.requests_total:3|c|#db:main,env:test",
+	}, readLines(t, agent))
+
+	// only changes are sent, counters as increments
+	requests.WithLabelValues("main", "ok").Add(2)
+	require.NoError(t, s.Stop(context.Background()))
+	require.Equal(t, []string{
+		"svc.requests_total:2|c|#db:main,env:test",
+	}, readLines(t, agent))
+}
+
+func TestSinkSeed(t *testing.T) {
+	agent, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer agent.Close()
+
+	registry := prometheus.NewRegistry()
+	requests := prometheus.NewCounter(prometheus.CounterOpts{Name: "requests_total"})
+	duration := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "duration_seconds"})
+	registry.MustRegister(requests, duration)
+
+	// counted before Start
+	requests.Add(100)
+	duration.Observe(1)
+
+	s, err := NewSink(&Config{Enabled: true, Address: agent.LocalAddr().String(), Interval: time.Hour}, WithGatherer(registry))
+	require.NoError(t, err)
+	require.NoError(t, s.Start(context.Background()))
+
+	require.NoError(t, s.Flush())
+	require.Empty(t, readLines(t, agent))
+
+	requests.Add(2)
+	require.NoError(t, s.Stop(context.Background()))
+	require.Equal(t, []string{"requests_total:2|c"}, readLines(t, agent))
+}
+
+func TestLineFormats(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "queue_size"}, []string{"queue"})
+	registry.MustRegister(gauge)
+	gauge.WithLabelValues("a,b").Set(1)
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	labels := families[0].GetMetric()[0].GetLabel()
+
+	for format, expected := range map[string]string{
+		TagFormatDatadog:  "queue_size:1|g|#queue:a_b",
+		TagFormatInflux:   "queue_size,queue=a_b:1|g",
+		TagFormatGraphite: "queue_size;queue=a_b:1|g",
+		TagFormatNone:     "queue_size:1|g",
+	} {
+		s, err := NewSink(&Config{Enabled: true, TagFormat: format})
+		require.NoError(t, err)
+		require.Equal(t, expected, s.line("queue_size", labels, 1, "g"), format)
+	}
+
+	_, err = NewSink(&Config{Enabled: true, TagFormat: "xml"})
+	require.Error(t, err)
+}
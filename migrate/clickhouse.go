@@ -0,0 +1,67 @@
+package inframigrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ClickHouseDriver executes statements of a script one by one, a failed migration may be applied partially,
+// so statements should be safe to repeat, e.g. CREATE TABLE IF NOT EXISTS.
+// The table keeps every up and down as a row, the state of a version is the latest row, so no mutations are needed.
+// ClickHouse has no locks: set a locker with WithLocker if migrations run on startup of several instances.
+type ClickHouseDriver struct {
+	db *sql.DB
+}
+
+var _ Driver = (*ClickHouseDriver)(nil)
+
+func NewClickHouseDriver(db *sql.DB) *ClickHouseDriver {
+	return &ClickHouseDriver{db: db}
+}
+
+func (d *ClickHouseDriver) Name() string {
+	return "clickhouse"
+}
+
+func (d *ClickHouseDriver) Lock(_ context.Context, _ string) (func(ctx context.Context) error, error) {
+	return func(context.Context) error { return nil }, nil
+}
+
+func (d *ClickHouseDriver) Init(ctx context.Context, table string) error {
+	_, err := d.db.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	version    Int64,
+	name       String,
+	applied    UInt8,
+	applied_at DateTime64(6) DEFAULT now64(6)
+) ENGINE = MergeTree ORDER BY (version, applied_at)`, table))
+	return errors.Wrap(err, "unable to create migrations table")
+}
+
+func (d *ClickHouseDriver) Applied(ctx context.Context, table string) (map[int64]time.Time, error) {
+	return queryApplied(ctx, d.db, fmt.Sprintf(`SELECT version, max(applied_at) FROM %s
+		GROUP BY version HAVING argMax(applied, applied_at) = 1`, table))
+}
+
+func (d *ClickHouseDriver) Apply(ctx context.Context, table string, m *Migration, up bool) error {
+	script := m.Up
+	if !up {
+		script = m.Down
+	}
+	for _, stmt := range splitStatements(script) {
+		if _, err := d.db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+
+	var applied uint8
+	if up {
+		applied = 1
+	}
+	_, err := d.db.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (version, name, applied) VALUES (?, ?, ?)", table), m.Version, m.Name, applied)
+
+	return errors.Wrap(err, "unable to record migration")
+}
@@ -0,0 +1,72 @@
+package inframigrate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const usage = `usage: migrate <command>
+  up          apply all pending migrations
+  down [n]    roll back n last migrations, default: 1
+  status      show migrations state`
+
+// Run executes a migration command given by command line arguments, so a service can run migrations by itself:
+//
+//	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+//		if err := inframigrate.Run(ctx, m, os.Args[2:], os.Stdout); err != nil {
+//			log.Fatal(err)
+//		}
+//		return
+//	}
+func Run(ctx context.Context, m *Migrator, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	switch args[0] {
+	case "up":
+		count, err := m.Up(ctx)
+		_, _ = fmt.Fprintf(out, "applied %d migrations\n", count)
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return errors.Errorf("invalid number of migrations: %s", args[1])
+			}
+		}
+
+		count, err := m.Down(ctx, steps)
+		_, _ = fmt.Fprintf(out, "rolled back %d migrations\n", count)
+		return err
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.Applied {
+				appliedAt = s.AppliedAt.Format(time.RFC3339)
+			}
+			name := s.Name
+			if s.Missing {
+				name = "(missing)"
+			}
+			_, _ = fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, name, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return errors.Errorf("unknown command %q\n%s", args[0], usage)
+	}
+}
@@ -0,0 +1,57 @@
+package inframigrate
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultDir         = "."
+	defaultTable       = "schema_migrations"
+	defaultLockTimeout = 5 * time.Minute
+)
+
+type Config struct {
+	// Directory of migration files in the FS. optional, default: "."
+	Dir string `mapstructure:"dir"`
+
+	// Table of applied migrations. optional, default: schema_migrations
+	Table string `mapstructure:"table"`
+
+	// How long to wait for migrations running on another instance. optional, default: 5m
+	LockTimeout time.Duration `mapstructure:"lock_timeout"`
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if c.LockTimeout < 0 {
+		return errors.New("lock_timeout should not be negative")
+	}
+
+	return nil
+}
+
+func (c *Config) GetDir() string {
+	if c.Dir == "" {
+		return defaultDir
+	}
+	return c.Dir
+}
+
+func (c *Config) GetTable() string {
+	if c.Table == "" {
+		return defaultTable
+	}
+	return c.Table
+}
+
+func (c *Config) GetLockTimeout() time.Duration {
+	if c.LockTimeout == 0 {
+		return defaultLockTimeout
+	}
+	return c.LockTimeout
+}
@@ -0,0 +1,23 @@
+package inframigrate
+
+import (
+	"context"
+	"time"
+)
+
+// Driver applies migrations to a database and keeps the list of applied ones in table
+type Driver interface {
+	Name() string
+
+	// Lock blocks until no other instance runs migrations of the table
+	Lock(ctx context.Context, table string) (unlock func(ctx context.Context) error, err error)
+
+	// Init creates the table of applied migrations if it doesn't exist
+	Init(ctx context.Context, table string) error
+
+	// Applied returns applied versions with the time they were applied
+	Applied(ctx context.Context, table string) (map[int64]time.Time, error)
+
+	// Apply runs the up or down script of the migration and records the result in the table
+	Apply(ctx context.Context, table string, m *Migration, up bool) error
+}
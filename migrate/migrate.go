@@ -0,0 +1,271 @@
+package inframigrate
+
+import (
+	"context"
+	"io/fs"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	infralock "github.com/pushwoosh/infra/lock"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	"go.uber.org/zap"
+)
+
+const lockTTL = time.Minute
+
+var ErrLockLost = errors.New("migrations lock is lost")
+
+// Status is a migration state
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+
+	// Missing is true for applied versions without migration files
+	Missing bool
+}
+
+type Option interface {
+	apply(m *Migrator)
+}
+
+type optionLocker struct {
+	locker infralock.Locker
+}
+
+func (opt optionLocker) apply(m *Migrator) {
+	m.locker = opt.locker
+}
+
+// WithLocker locks migrations with the locker instead of the database lock, e.g. for ClickHouse
+func WithLocker(locker infralock.Locker) Option {
+	return optionLocker{locker: locker}
+}
+
+// Migrator applies migrations from a file system, usually embedded:
+//
+//	//go:embed migrations/*.sql
+//	var migrations embed.FS
+//
+//	m, err := inframigrate.New(cfg, inframigrate.NewPostgresDriver(db), migrations)
+//	app.Add("migrate", m, infraapp.WithStartTimeout(10*time.Minute)) // runs Up on start
+//
+// Files are named <version>_<name>.up.sql and <version>_<name>.down.sql, e.g. 20240101120000_create_users.up.sql.
+// Pending migrations are applied in version order, including versions lower than the last applied one.
+// A script starting with a NoTransactionMarker line runs outside of a transaction where the driver supports it.
+// Only one instance runs migrations at a time, others wait for the lock and find nothing to apply.
+type Migrator struct {
+	cfg        *Config
+	driver     Driver
+	migrations []*Migration
+	locker     infralock.Locker
+}
+
+var _ infraoperator.Starter = (*Migrator)(nil)
+
+func New(cfg *Config, driver Driver, fsys fs.FS, opts ...Option) (*Migrator, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	migrations, err := load(fsys, cfg.GetDir())
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Migrator{
+		cfg:        cfg,
+		driver:     driver,
+		migrations: migrations,
+	}
+
+	for _, opt := range opts {
+		opt.apply(m)
+	}
+
+	return m, nil
+}
+
+// Start applies pending migrations
+func (m *Migrator) Start(ctx context.Context) error {
+	_, err := m.Up(ctx)
+	return err
+}
+
+// Up applies all pending migrations. Returns the number of applied migrations.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	count := 0
+	err := m.locked(ctx, func(lost <-chan struct{}, applied map[int64]time.Time) error {
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			if err := m.apply(ctx, lost, migration, true); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+
+	return count, err
+}
+
+// Down rolls back the last steps applied migrations in reverse version order
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	count := 0
+	err := m.locked(ctx, func(lost <-chan struct{}, applied map[int64]time.Time) error {
+		for _, status := range m.status(applied, true) {
+			if count == steps {
+				break
+			}
+			if !status.Applied {
+				continue
+			}
+			if status.Missing {
+				return errors.Errorf("migration %d is applied, but not found", status.Version)
+			}
+
+			migration := m.find(status.Version)
+			if migration.Down == "" {
+				return errors.Errorf("migration %d_%s has no down script", migration.Version, migration.Name)
+			}
+
+			if err := m.apply(ctx, lost, migration, false); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+
+	return count, err
+}
+
+// Status returns states of all known and applied migrations ordered by version
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	table := m.cfg.GetTable()
+
+	if err := m.driver.Init(ctx, table); err != nil {
+		return nil, err
+	}
+
+	applied, err := m.driver.Applied(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.status(applied, false), nil
+}
+
+func (m *Migrator) status(applied map[int64]time.Time, reverse bool) []Status {
+	res := make([]Status, 0, len(m.migrations))
+	known := make(map[int64]struct{}, len(m.migrations))
+	for _, migration := range m.migrations {
+		appliedAt, ok := applied[migration.Version]
+		res = append(res, Status{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			Applied:   ok,
+			AppliedAt: appliedAt,
+		})
+		known[migration.Version] = struct{}{}
+	}
+
+	for version, appliedAt := range applied {
+		if _, ok := known[version]; !ok {
+			res = append(res, Status{Version: version, Applied: true, AppliedAt: appliedAt, Missing: true})
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if reverse {
+			return res[i].Version > res[j].Version
+		}
+		return res[i].Version < res[j].Version
+	})
+
+	return res
+}
+
+func (m *Migrator) find(version int64) *Migration {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) apply(ctx context.Context, lost <-chan struct{}, migration *Migration, up bool) error {
+	select {
+	case <-lost:
+		return ErrLockLost
+	default:
+	}
+
+	direction := "up"
+	if !up {
+		direction = "down"
+	}
+
+	start := time.Now()
+	if err := m.driver.Apply(ctx, m.cfg.GetTable(), migration, up); err != nil {
+		return errors.Wrapf(err, "migration %d_%s %s failed", migration.Version, migration.Name, direction)
+	}
+
+	infralog.Info("migration applied",
+		zap.String("driver", m.driver.Name()),
+		zap.Int64("version", migration.Version),
+		zap.String("name", migration.Name),
+		zap.String("direction", direction),
+		zap.Duration("duration", time.Since(start)),
+	)
+
+	return nil
+}
+
+// locked runs fn holding the migrations lock
+func (m *Migrator) locked(ctx context.Context, fn func(lost <-chan struct{}, applied map[int64]time.Time) error) error {
+	table := m.cfg.GetTable()
+
+	lockCtx, cancel := context.WithTimeout(ctx, m.cfg.GetLockTimeout())
+	defer cancel()
+
+	var (
+		unlock func(ctx context.Context) error
+		lost   <-chan struct{}
+	)
+	if m.locker != nil {
+		lock, err := m.locker.Acquire(lockCtx, "inframigrate:"+table, lockTTL)
+		if err != nil {
+			return errors.Wrap(err, "unable to lock migrations")
+		}
+		unlock, lost = lock.Release, lock.Lost()
+	} else {
+		var err error
+		if unlock, err = m.driver.Lock(lockCtx, table); err != nil {
+			return errors.Wrap(err, "unable to lock migrations")
+		}
+	}
+	defer func() {
+		if err := unlock(context.WithoutCancel(ctx)); err != nil {
+			infralog.ErrorCtx(ctx, "unable to unlock migrations", zap.Error(err))
+		}
+	}()
+
+	if err := m.driver.Init(ctx, table); err != nil {
+		return err
+	}
+
+	applied, err := m.driver.Applied(ctx, table)
+	if err != nil {
+		return err
+	}
+
+	return fn(lost, applied)
+}
@@ -0,0 +1,145 @@
+package inframigrate
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDriver struct {
+	applied map[int64]time.Time
+	scripts []string
+	fail    int64
+}
+
+func (d *fakeDriver) Name() string { return "fake" }
+
+func (d *fakeDriver) Lock(context.Context, string) (func(context.Context) error, error) {
+	return func(context.Context) error { return nil }, nil
+}
+
+func (d *fakeDriver) Init(context.Context, string) error {
+	if d.applied == nil {
+		d.applied = make(map[int64]time.Time)
+	}
+	return nil
+}
+
+func (d *fakeDriver) Applied(context.Context, string) (map[int64]time.Time, error) {
+	res := make(map[int64]time.Time, len(d.applied))
+	for k, v := range d.applied {
+		res[k] = v
+	}
+	return res, nil
+}
+
+func (d *fakeDriver) Apply(_ context.Context, _ string, m *Migration, up bool) error {
+	if m.Version == d.fail {
+		return errors.New("boom")
+	}
+
+	if up {
+		d.scripts = append(d.scripts, m.Up)
+		d.applied[m.Version] = time.Now()
+	} else {
+		d.scripts = append(d.scripts, m.Down)
+		delete(d.applied, m.Version)
+	}
+	return nil
+}
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"migrations/2_add_email.up.sql":      {Data: []byte("up 2")},
+		"migrations/2_add_email.down.sql":    {Data: []byte("down 2")},
+		"migrations/1_create_users.up.sql":   {Data: []byte("up 1")},
+		"migrations/1_create_users.down.sql": {Data: []byte("down 1")},
+		"migrations/3_seed.up.sql":           {Data: []byte("up 3")},
+		"migrations/README.md":               {Data: []byte("ignored")},
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	driver := &fakeDriver{}
+
+	m, err := New(&Config{Dir: "migrations"}, driver, testFS())
+	require.NoError(t, err)
+
+	count, err := m.Up(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, count)
+	require.Equal(t, []string{"up 1", "up 2", "up 3"}, driver.scripts)
+
+	count, err = m.Up(ctx)
+	require.NoError(t, err)
+	require.Zero(t, count)
+
+	// 3 has no down script
+	_, err = m.Down(ctx, 1)
+	require.ErrorContains(t, err, "no down script")
+
+	delete(driver.applied, 3)
+	driver.scripts = nil
+
+	count, err = m.Down(ctx, 5)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+	require.Equal(t, []string{"down 2", "down 1"}, driver.scripts)
+
+	driver.fail = 2
+	count, err = m.Up(ctx)
+	require.ErrorContains(t, err, "migration 2_add_email up failed")
+	require.Equal(t, 1, count)
+
+	driver.applied[10] = time.Now()
+	statuses, err := m.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 4)
+	require.True(t, statuses[0].Applied)
+	require.False(t, statuses[1].Applied)
+	require.True(t, statuses[3].Missing)
+
+	out := &bytes.Buffer{}
+	require.NoError(t, Run(ctx, m, []string{"status"}, out))
+	require.Contains(t, out.String(), "add_email")
+	require.Contains(t, out.String(), "pending")
+	require.Error(t, Run(ctx, m, []string{"down", "x"}, out))
+}
+
+func TestLoadErrors(t *testing.T) {
+	_, err := New(&Config{}, &fakeDriver{}, fstest.MapFS{
+		"1_a.up.sql": {Data: []byte("up")},
+		"1_b.up.sql": {Data: []byte("up")},
+	})
+	require.ErrorContains(t, err, "different names")
+
+	_, err = New(&Config{}, &fakeDriver{}, fstest.MapFS{
+		"1_a.down.sql": {Data: []byte("down")},
+	})
+	require.ErrorContains(t, err, "no up script")
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `CREATE TABLE a (s String DEFAULT 'x;y'); -- comment; here
+/* block; comment */ INSERT INTO a VALUES ('it\'s;');
+ALTER TABLE a ADD COLUMN "b;c" Int32;
+`
+	stmts := splitStatements(script)
+	require.Len(t, stmts, 3)
+	require.Equal(t, "CREATE TABLE a (s String DEFAULT 'x;y')", stmts[0])
+	require.Equal(t, `INSERT INTO a VALUES ('it\'s;')`, strings.TrimSpace(stmts[1]))
+	require.Equal(t, `ALTER TABLE a ADD COLUMN "b;c" Int32`, stmts[2])
+}
+
+func TestIsNoTransaction(t *testing.T) {
+	require.True(t, isNoTransaction("\n"+NoTransactionMarker+"\nCREATE INDEX CONCURRENTLY i ON a (b);"))
+	require.False(t, isNoTransaction("CREATE INDEX i ON a (b);\n"+NoTransactionMarker))
+	require.False(t, isNoTransaction(""))
+}
@@ -0,0 +1,86 @@
+package inframigrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// MySQLDriver executes statements of a script one by one, DDL statements are committed implicitly by mysql,
+// so a failed migration may be applied partially and its statements should be safe to repeat.
+// Migrations are locked with GET_LOCK.
+type MySQLDriver struct {
+	db *sql.DB
+}
+
+var _ Driver = (*MySQLDriver)(nil)
+
+func NewMySQLDriver(db *sql.DB) *MySQLDriver {
+	return &MySQLDriver{db: db}
+}
+
+func (d *MySQLDriver) Name() string {
+	return "mysql"
+}
+
+func (d *MySQLDriver) Lock(ctx context.Context, table string) (func(ctx context.Context) error, error) {
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "db.Conn")
+	}
+
+	// negative timeout waits forever, ctx cancellation kills the query
+	var acquired sql.NullInt64
+	if err = conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, -1)", table).Scan(&acquired); err != nil {
+		_ = conn.Close()
+		return nil, errors.Wrap(err, "GET_LOCK")
+	}
+	if acquired.Int64 != 1 {
+		_ = conn.Close()
+		return nil, errors.New("unable to get migrations lock")
+	}
+
+	return func(ctx context.Context) error {
+		defer conn.Close()
+
+		_, err := conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", table)
+		return errors.Wrap(err, "RELEASE_LOCK")
+	}, nil
+}
+
+func (d *MySQLDriver) Init(ctx context.Context, table string) error {
+	_, err := d.db.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	version    BIGINT NOT NULL PRIMARY KEY,
+	name       VARCHAR(255) NOT NULL,
+	applied_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+)`, table))
+	return errors.Wrap(err, "unable to create migrations table")
+}
+
+func (d *MySQLDriver) Applied(ctx context.Context, table string) (map[int64]time.Time, error) {
+	return queryApplied(ctx, d.db, fmt.Sprintf("SELECT version, applied_at FROM %s", table))
+}
+
+func (d *MySQLDriver) Apply(ctx context.Context, table string, m *Migration, up bool) error {
+	script := m.Up
+	if !up {
+		script = m.Down
+	}
+	for _, stmt := range splitStatements(script) {
+		if _, err := d.db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if up {
+		_, err = d.db.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (version, name) VALUES (?, ?)", table), m.Version, m.Name)
+	} else {
+		_, err = d.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE version = ?", table), m.Version)
+	}
+
+	return errors.Wrap(err, "unable to record migration")
+}
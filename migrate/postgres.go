@@ -0,0 +1,123 @@
+package inframigrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// PostgresDriver runs every migration with its record in one transaction, so a failed migration leaves nothing behind.
+// Scripts starting with NoTransactionMarker, e.g. with CREATE INDEX CONCURRENTLY, run statement by statement
+// outside of a transaction: a failed one leaves the previous statements applied, so they should be idempotent.
+// Such scripts are split by semicolons, dollar-quoted bodies are not supported there.
+// Migrations are locked with a session level advisory lock.
+type PostgresDriver struct {
+	db *sql.DB
+}
+
+var _ Driver = (*PostgresDriver)(nil)
+
+func NewPostgresDriver(db *sql.DB) *PostgresDriver {
+	return &PostgresDriver{db: db}
+}
+
+func (d *PostgresDriver) Name() string {
+	return "postgres"
+}
+
+func (d *PostgresDriver) Lock(ctx context.Context, table string) (func(ctx context.Context) error, error) {
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "db.Conn")
+	}
+
+	if _, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock(hashtextextended($1, 0))", table); err != nil {
+		_ = conn.Close()
+		return nil, errors.Wrap(err, "pg_advisory_lock")
+	}
+
+	return func(ctx context.Context) error {
+		defer conn.Close()
+
+		_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock(hashtextextended($1, 0))", table)
+		return errors.Wrap(err, "pg_advisory_unlock")
+	}, nil
+}
+
+func (d *PostgresDriver) Init(ctx context.Context, table string) error {
+	_, err := d.db.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	version    BIGINT PRIMARY KEY,
+	name       TEXT NOT NULL,
+	applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+)`, table))
+	return errors.Wrap(err, "unable to create migrations table")
+}
+
+func (d *PostgresDriver) Applied(ctx context.Context, table string) (map[int64]time.Time, error) {
+	return queryApplied(ctx, d.db, fmt.Sprintf("SELECT version, applied_at FROM %s", table))
+}
+
+func (d *PostgresDriver) Apply(ctx context.Context, table string, m *Migration, up bool) error {
+	script := m.Up
+	if !up {
+		script = m.Down
+	}
+
+	noTx := isNoTransaction(script)
+	if noTx {
+		// CREATE INDEX CONCURRENTLY waits for open transactions, so the record transaction begins afterwards
+		for _, stmt := range splitStatements(script) {
+			if _, err := d.db.ExecContext(ctx, stmt); err != nil {
+				return err
+			}
+		}
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "unable to begin transaction")
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if !noTx {
+		if _, err = tx.ExecContext(ctx, script); err != nil {
+			return err
+		}
+	}
+
+	if up {
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (version, name) VALUES ($1, $2)", table), m.Version, m.Name)
+	} else {
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE version = $1", table), m.Version)
+	}
+	if err != nil {
+		return errors.Wrap(err, "unable to record migration")
+	}
+
+	return errors.Wrap(tx.Commit(), "unable to commit migration")
+}
+
+func queryApplied(ctx context.Context, db *sql.DB, query string) (map[int64]time.Time, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query applied migrations")
+	}
+	defer rows.Close()
+
+	res := make(map[int64]time.Time)
+	for rows.Next() {
+		var (
+			version   int64
+			appliedAt time.Time
+		)
+		if err = rows.Scan(&version, &appliedAt); err != nil {
+			return nil, errors.Wrap(err, "unable to scan applied migration")
+		}
+		res[version] = appliedAt
+	}
+
+	return res, errors.Wrap(rows.Err(), "unable to query applied migrations")
+}
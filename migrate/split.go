@@ -0,0 +1,62 @@
+package inframigrate
+
+import (
+	"strings"
+)
+
+// splitStatements splits a script into statements by semicolons outside of quotes and comments,
+// for databases executing one statement per call
+func splitStatements(script string) []string {
+	var (
+		res   []string
+		cur   strings.Builder
+		quote byte
+	)
+
+	flush := func() {
+		if stmt := strings.TrimSpace(cur.String()); stmt != "" {
+			res = append(res, stmt)
+		}
+		cur.Reset()
+	}
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+
+		switch {
+		case quote != 0:
+			cur.WriteByte(c)
+			if c == '\\' && i+1 < len(script) {
+				i++
+				cur.WriteByte(script[i])
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			cur.WriteByte(c)
+		case c == '-' && strings.HasPrefix(script[i:], "--"):
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				i = len(script)
+			} else {
+				i += end
+				cur.WriteByte('\n')
+			}
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				i = len(script)
+			} else {
+				i += end + 3
+			}
+		case c == ';':
+			flush()
+		default:
+			cur.WriteByte(c)
+		}
+	}
+	flush()
+
+	return res
+}
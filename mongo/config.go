@@ -1,9 +1,11 @@
 package mongo
 
 import (
+	"strconv"
 	"time"
 
 	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 )
 
 type ConnectionsConfig map[string]*ConnectionConfig
@@ -15,6 +17,36 @@ type ConnectionConfig struct {
 
 	// Query log config
 	QueryLog *QueryLoggingConfig `mapstructure:"query_log"`
+
+	// Default read preference. optional, URI or driver default is used if empty
+	ReadPreference ConnectionReadPreference `mapstructure:"read_preference"`
+
+	// Default read concern level: "local", "available", "majority", "linearizable" or "snapshot". optional
+	ReadConcern string `mapstructure:"read_concern"`
+
+	// Default write concern. optional
+	WriteConcern *WriteConcernConfig `mapstructure:"write_concern"`
+
+	// Connection pool size limits. optional, URI or driver defaults are used if zero
+	MaxPoolSize uint64 `mapstructure:"max_pool_size"`
+	MinPoolSize uint64 `mapstructure:"min_pool_size"`
+
+	// How long to wait for a suitable server for an operation. optional, driver default is 30s
+	ServerSelectionTimeout time.Duration `mapstructure:"server_selection_timeout"`
+
+	// Whether to create OpenTelemetry spans for commands
+	Tracing bool `mapstructure:"tracing"`
+}
+
+type WriteConcernConfig struct {
+	// W is a number of acknowledging nodes or "majority"
+	W string `mapstructure:"w"`
+
+	// Whether to wait for the write to be written to the on-disk journal
+	Journal *bool `mapstructure:"journal"`
+
+	// Time limit for the write concern. optional
+	WTimeout time.Duration `mapstructure:"wtimeout"`
 }
 
 type QueryLoggingConfig struct {
@@ -55,6 +87,20 @@ func (c *ConnectionConfig) Validate() error {
 		return errors.New("uri is empty")
 	}
 
+	if c.ReadPreference != "" && ReadPreferenceFromString(c.ReadPreference) == nil {
+		return errors.Errorf("unknown read preference: %s", c.ReadPreference)
+	}
+
+	switch c.ReadConcern {
+	case "", "local", "available", "majority", "linearizable", "snapshot":
+	default:
+		return errors.Errorf("unknown read concern: %s", c.ReadConcern)
+	}
+
+	if c.MinPoolSize > 0 && c.MaxPoolSize > 0 && c.MinPoolSize > c.MaxPoolSize {
+		return errors.New("min_pool_size should be less than or equal to max_pool_size")
+	}
+
 	return nil
 }
 
@@ -69,3 +115,18 @@ func (c *QueryLoggingConfig) Validate() error {
 
 	return nil
 }
+
+func (c *WriteConcernConfig) writeConcern() *writeconcern.WriteConcern {
+	wc := &writeconcern.WriteConcern{
+		Journal:  c.Journal,
+		WTimeout: c.WTimeout,
+	}
+
+	if n, err := strconv.Atoi(c.W); err == nil {
+		wc.W = n
+	} else if c.W != "" {
+		wc.W = c.W
+	}
+
+	return wc
+}
@@ -0,0 +1,42 @@
+package mongo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectionConfig(t *testing.T) {
+	var cfg *ConnectionConfig
+	require.Error(t, cfg.Validate())
+
+	queryLog := &QueryLoggingConfig{}
+	cfg = &ConnectionConfig{URI: "mongodb://localhost:27017/app", QueryLog: queryLog}
+	require.NoError(t, cfg.Validate())
+
+	for name, invalid := range map[string]*ConnectionConfig{
+		"query_log":       {URI: "mongodb://localhost"},
+		"slow threshold":  {URI: "mongodb://localhost", QueryLog: &QueryLoggingConfig{Slow: true}},
+		"uri":             {QueryLog: queryLog},
+		"read preference": {URI: "mongodb://localhost", QueryLog: queryLog, ReadPreference: "any"},
+		"read concern":    {URI: "mongodb://localhost", QueryLog: queryLog, ReadConcern: "strong"},
+		"pool size":       {URI: "mongodb://localhost", QueryLog: queryLog, MinPoolSize: 10, MaxPoolSize: 5},
+	} {
+		require.Error(t, invalid.Validate(), name)
+	}
+
+	connections := ConnectionsConfig{"main": cfg, "events": {}}
+	require.ErrorContains(t, connections.Validate(), "events")
+}
+
+func TestWriteConcern(t *testing.T) {
+	journal := true
+	wc := (&WriteConcernConfig{W: "2", Journal: &journal, WTimeout: time.Second}).writeConcern()
+	require.Equal(t, 2, wc.W)
+	require.True(t, *wc.Journal)
+	require.Equal(t, time.Second, wc.WTimeout)
+
+	require.Equal(t, "majority", (&WriteConcernConfig{W: "majority"}).writeConcern().W)
+	require.Nil(t, (&WriteConcernConfig{}).writeConcern().W)
+}
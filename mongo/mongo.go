@@ -7,8 +7,10 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	infraoperator "github.com/pushwoosh/infra/operator"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
@@ -30,6 +32,11 @@ type Container struct {
 	pool map[string]*mongo.Client
 }
 
+var (
+	_ infraoperator.Stopper = (*Container)(nil)
+	_ infraoperator.Checker = (*Container)(nil)
+)
+
 func NewContainer() *Container {
 	return &Container{
 		mu:   &sync.RWMutex{},
@@ -51,6 +58,30 @@ func (cont *Container) Connect(appName string, name string, cfg *ConnectionConfi
 
 	opts.SetHosts(addresses)
 
+	if cfg.ReadPreference != "" {
+		opts.SetReadPreference(ReadPreferenceFromString(cfg.ReadPreference))
+	}
+
+	if cfg.ReadConcern != "" {
+		opts.SetReadConcern(&readconcern.ReadConcern{Level: cfg.ReadConcern})
+	}
+
+	if cfg.WriteConcern != nil {
+		opts.SetWriteConcern(cfg.WriteConcern.writeConcern())
+	}
+
+	if cfg.MaxPoolSize > 0 {
+		opts.SetMaxPoolSize(cfg.MaxPoolSize)
+	}
+
+	if cfg.MinPoolSize > 0 {
+		opts.SetMinPoolSize(cfg.MinPoolSize)
+	}
+
+	if cfg.ServerSelectionTimeout > 0 {
+		opts.SetServerSelectionTimeout(cfg.ServerSelectionTimeout)
+	}
+
 	timeout := time.Second * 10
 	if opts.ConnectTimeout != nil {
 		timeout = *opts.ConnectTimeout
@@ -62,8 +93,11 @@ func (cont *Container) Connect(appName string, name string, cfg *ConnectionConfi
 	mb := &monitorBuilder{}
 	initMetricsMonitor(mb, appName)
 	initLoggingMonitor(mb, cfg.QueryLog)
+	initTracingMonitor(mb, cfg.Tracing)
 
 	opts.SetMonitor(mb.Build())
+	opts.SetPoolMonitor(newPoolMonitor(name))
+	opts.SetServerMonitor(newServerMonitor(name))
 
 	// connect and ping
 	client, err := mongo.Connect(ctx, opts)
@@ -73,14 +107,24 @@ func (cont *Container) Connect(appName string, name string, cfg *ConnectionConfi
 
 	err = client.Ping(ctx, opts.ReadPreference)
 	if err != nil {
+		_ = client.Disconnect(ctx)
 		return errors.Wrap(err, "ping")
 	}
 
+	// replace existing connection with the same name, so Get never returns a missing client
 	cont.mu.Lock()
-	defer cont.mu.Unlock()
-
+	old := cont.pool[name]
 	cont.pool[name] = client
 	cont.cfg[name] = *cfg
+	cont.mu.Unlock()
+
+	// pool metrics are shared by connections with the same name and are kept for the new client
+	if old != nil {
+		disconnectCtx, disconnectCancel := context.WithTimeout(context.Background(), timeout)
+		defer disconnectCancel()
+
+		_ = old.Disconnect(disconnectCtx)
+	}
 
 	return nil
 }
@@ -93,6 +137,69 @@ func (cont *Container) Get(name string) *mongo.Client {
 	return cont.pool[name]
 }
 
+// Remove disconnects named connection and removes it from the container.
+// Disconnect waits for in-use connections to be returned to the pool
+func (cont *Container) Remove(name string) {
+	_ = cont.remove(context.Background(), name)
+}
+
+func (cont *Container) remove(ctx context.Context, name string) error {
+	cont.mu.Lock()
+	client := cont.pool[name]
+	delete(cont.pool, name)
+	delete(cont.cfg, name)
+	cont.mu.Unlock()
+
+	if client == nil {
+		return nil
+	}
+
+	err := client.Disconnect(ctx)
+	deletePoolMetrics(name)
+
+	return errors.Wrap(err, name)
+}
+
+// Check pings all connections in the container with their read preferences
+func (cont *Container) Check(ctx context.Context) error {
+	cont.mu.RLock()
+	defer cont.mu.RUnlock()
+
+	for name, client := range cont.pool {
+		cfg := cont.cfg[name]
+		if err := client.Ping(ctx, ReadPreferenceFromString(cfg.ReadPreference)); err != nil {
+			return errors.Wrap(err, name)
+		}
+	}
+
+	return nil
+}
+
+// Stop disconnects all connections in the container.
+// In-use connections are closed forcibly when ctx is done
+func (cont *Container) Stop(ctx context.Context) error {
+	cont.mu.RLock()
+	names := make([]string, 0, len(cont.pool))
+	for name := range cont.pool {
+		names = append(names, name)
+	}
+	cont.mu.RUnlock()
+
+	var firstErr error
+	for _, name := range names {
+		if err := cont.remove(ctx, name); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// Close disconnects all connections in the container
+func (cont *Container) Close() {
+	_ = cont.Stop(context.Background())
+}
+
 func ReadPreferenceFromString(src ConnectionReadPreference) *readpref.ReadPref {
 	switch src {
 	case ReadPreferencePrimary:
@@ -0,0 +1,104 @@
+package mongo
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	infralog "github.com/pushwoosh/infra/log"
+	inframetrics "github.com/pushwoosh/infra/metrics"
+	"go.mongodb.org/mongo-driver/event"
+	"go.uber.org/zap"
+)
+
+var poolMetrics struct {
+	ConnectionsGauge       *inframetrics.GaugeVec
+	InUseGauge             *inframetrics.GaugeVec
+	CheckOutFailedCounter  *inframetrics.CounterVec
+	PoolClearedCounter     *inframetrics.CounterVec
+	HeartbeatFailedCounter *inframetrics.CounterVec
+}
+var poolMetricsOnce sync.Once
+
+func initPoolMetrics() {
+	poolMetricsOnce.Do(func() {
+		poolMetrics.ConnectionsGauge = inframetrics.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "mongo_pool_connections",
+			Help: "The number of open connections in the pool",
+		}, []string{"connection", "address"})
+
+		poolMetrics.InUseGauge = inframetrics.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "mongo_pool_connections_in_use",
+			Help: "The number of connections checked out from the pool",
+		}, []string{"connection", "address"})
+
+		poolMetrics.CheckOutFailedCounter = inframetrics.NewCounterVec(prometheus.CounterOpts{
+			Name: "mongo_pool_checkout_failed_counter",
+			Help: "The total number of failed connection check outs",
+		}, []string{"connection", "address", "reason"})
+
+		poolMetrics.PoolClearedCounter = inframetrics.NewCounterVec(prometheus.CounterOpts{
+			Name: "mongo_pool_cleared_counter",
+			Help: "The total number of pool clears caused by server errors",
+		}, []string{"connection", "address"})
+
+		poolMetrics.HeartbeatFailedCounter = inframetrics.NewCounterVec(prometheus.CounterOpts{
+			Name: "mongo_heartbeat_failed_counter",
+			Help: "The total number of failed server heartbeats",
+		}, []string{"connection"})
+
+		prometheus.MustRegister(
+			poolMetrics.ConnectionsGauge,
+			poolMetrics.InUseGauge,
+			poolMetrics.CheckOutFailedCounter,
+			poolMetrics.PoolClearedCounter,
+			poolMetrics.HeartbeatFailedCounter,
+		)
+	})
+}
+
+func newPoolMonitor(name string) *event.PoolMonitor {
+	initPoolMetrics()
+
+	return &event.PoolMonitor{
+		Event: func(e *event.PoolEvent) {
+			switch e.Type {
+			case event.ConnectionCreated:
+				poolMetrics.ConnectionsGauge.WithLabelValues(name, e.Address).Inc()
+			case event.ConnectionClosed:
+				poolMetrics.ConnectionsGauge.WithLabelValues(name, e.Address).Dec()
+			case event.GetSucceeded:
+				poolMetrics.InUseGauge.WithLabelValues(name, e.Address).Inc()
+			case event.ConnectionReturned:
+				poolMetrics.InUseGauge.WithLabelValues(name, e.Address).Dec()
+			case event.GetFailed:
+				poolMetrics.CheckOutFailedCounter.WithLabelValues(name, e.Address, e.Reason).Inc()
+			case event.PoolCleared:
+				poolMetrics.PoolClearedCounter.WithLabelValues(name, e.Address).Inc()
+			}
+		},
+	}
+}
+
+func newServerMonitor(name string) *event.ServerMonitor {
+	initPoolMetrics()
+
+	return &event.ServerMonitor{
+		ServerHeartbeatFailed: func(e *event.ServerHeartbeatFailedEvent) {
+			poolMetrics.HeartbeatFailedCounter.WithLabelValues(name).Inc()
+			// failures are counted, the driver retries heartbeats every few seconds
+			infralog.Debug("mongo heartbeat failed",
+				zap.String("connection", name),
+				zap.String("server", e.ConnectionID),
+				zap.Error(e.Failure),
+			)
+		},
+	}
+}
+
+func deletePoolMetrics(name string) {
+	initPoolMetrics()
+
+	labels := prometheus.Labels{"connection": name}
+	poolMetrics.ConnectionsGauge.DeletePartialMatch(labels)
+	poolMetrics.InUseGauge.DeletePartialMatch(labels)
+}
@@ -0,0 +1,47 @@
+package mongo
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+func TestPoolMonitor(t *testing.T) {
+	const address = "mongo-1:27017"
+	monitor := newPoolMonitor("pool_test")
+	t.Cleanup(func() { deletePoolMetrics("pool_test") })
+
+	for _, typ := range []string{
+		event.ConnectionCreated,
+		event.ConnectionCreated,
+		event.GetSucceeded,
+		event.GetSucceeded,
+		event.ConnectionReturned,
+		event.ConnectionClosed,
+		event.PoolCleared,
+	} {
+		monitor.Event(&event.PoolEvent{Type: typ, Address: address})
+	}
+	monitor.Event(&event.PoolEvent{Type: event.GetFailed, Address: address, Reason: event.ReasonTimedOut})
+
+	require.Equal(t, 1.0, testutil.ToFloat64(poolMetrics.ConnectionsGauge.WithLabelValues("pool_test", address)))
+	require.Equal(t, 1.0, testutil.ToFloat64(poolMetrics.InUseGauge.WithLabelValues("pool_test", address)))
+	require.Equal(t, 1.0, testutil.ToFloat64(poolMetrics.PoolClearedCounter.WithLabelValues("pool_test", address)))
+	require.Equal(t, 1.0, testutil.ToFloat64(poolMetrics.CheckOutFailedCounter.WithLabelValues("pool_test", address, event.ReasonTimedOut)))
+
+	// gauges of a removed connection are not exported anymore
+	deletePoolMetrics("pool_test")
+	require.Equal(t, 0, testutil.CollectAndCount(poolMetrics.ConnectionsGauge, "mongo_pool_connections"))
+}
+
+func TestServerMonitor(t *testing.T) {
+	monitor := newServerMonitor("heartbeat_test")
+	counter := poolMetrics.HeartbeatFailedCounter.WithLabelValues("heartbeat_test")
+	before := testutil.ToFloat64(counter)
+
+	monitor.ServerHeartbeatFailed(&event.ServerHeartbeatFailedEvent{ConnectionID: "mongo-1:27017"})
+
+	require.Equal(t, 1.0, testutil.ToFloat64(counter)-before)
+}
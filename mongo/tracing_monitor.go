@@ -0,0 +1,29 @@
+package mongo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
+)
+
+// initTracingMonitor creates OpenTelemetry span for every command with the global tracer provider
+func initTracingMonitor(b *monitorBuilder, enabled bool) {
+	if !enabled {
+		return
+	}
+
+	m := otelmongo.NewMonitor()
+
+	b.Add(
+		func(ctx context.Context, startedEvent *event.CommandStartedEvent) {
+			m.Started(ctx, startedEvent)
+		},
+		func(ctx context.Context, _ *event.CommandStartedEvent, succeededEvent *event.CommandSucceededEvent) {
+			m.Succeeded(ctx, succeededEvent)
+		},
+		func(ctx context.Context, _ *event.CommandStartedEvent, failedEvent *event.CommandFailedEvent) {
+			m.Failed(ctx, failedEvent)
+		},
+	)
+}
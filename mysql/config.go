@@ -0,0 +1,124 @@
+package inframysql
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type ConnectionsConfig map[string]*ConnectionConfig
+
+type ConnectionConfig struct {
+	// Database address. "host:port"
+	Address string `mapstructure:"address"`
+
+	// Database credentials
+	Credentials Credentials `mapstructure:"credentials"`
+
+	// Maximum number of physical connections
+	MaxConnections int `mapstructure:"max_connections"`
+
+	// Maximum number of idle connections
+	MaxIdleConnections int `mapstructure:"max_idle_connections"`
+
+	// Maximum connection lifetime. Connections that active more than that period will be closed
+	MaxConnectionLifetime time.Duration `mapstructure:"max_connection_lifetime"`
+
+	// Connection idle time. Connections that idle more than that period will be closed
+	MaxConnectionIdleTime time.Duration `mapstructure:"max_connection_idle_time"`
+
+	// Dial, read and write timeouts. Zero means driver defaults
+	DialTimeout  time.Duration `mapstructure:"dial_timeout"`
+	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
+	WriteTimeout time.Duration `mapstructure:"write_timeout"`
+
+	// TLS options. TLS is disabled if empty
+	TLS *TLSConfig `mapstructure:"tls"`
+}
+
+type Credentials struct {
+	Database string `mapstructure:"database"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
+
+type TLSConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+
+	// PEM encoded CA certificate file. System pool is used if empty
+	CAFile string `mapstructure:"ca_file"`
+
+	// PEM encoded client certificate and key files for mutual TLS
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+
+	// Server name used to verify the hostname. Default is the host from the address
+	ServerName string `mapstructure:"server_name"`
+
+	// Disables server certificate verification
+	InsecureSkipVerify bool `mapstructure:"insecure_skip_verify"`
+}
+
+func (c *ConnectionsConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	for name, conf := range *c {
+		if err := conf.Validate(); err != nil {
+			return errors.Wrap(err, name)
+		}
+	}
+
+	return nil
+}
+
+func (c *ConnectionConfig) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if c.Address == "" {
+		return errors.New("address is mandatory")
+	}
+
+	if err := c.Credentials.Validate(); err != nil {
+		return errors.Wrap(err, "credentials")
+	}
+
+	if c.TLS != nil {
+		if err := c.TLS.Validate(); err != nil {
+			return errors.Wrap(err, "tls")
+		}
+	}
+
+	return nil
+}
+
+func (c *Credentials) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if c.Database == "" {
+		return errors.New("database name is mandatory")
+	}
+
+	if c.Username == "" {
+		return errors.New("username is mandatory")
+	}
+
+	return nil
+}
+
+func (c *TLSConfig) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return errors.New("cert_file and key_file must be set together")
+	}
+
+	return nil
+}
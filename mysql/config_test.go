@@ -0,0 +1,34 @@
+package inframysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectionConfig(t *testing.T) {
+	var cfg *ConnectionConfig
+	require.Error(t, cfg.Validate())
+
+	cfg = &ConnectionConfig{}
+	require.ErrorContains(t, cfg.Validate(), "address")
+
+	cfg = &ConnectionConfig{Address: "localhost:3306", Credentials: Credentials{Username: "app"}}
+	require.ErrorContains(t, cfg.Validate(), "database")
+
+	cfg = &ConnectionConfig{Address: "localhost:3306", Credentials: Credentials{Database: "app"}}
+	require.ErrorContains(t, cfg.Validate(), "username")
+
+	cfg = &ConnectionConfig{
+		Address:     "localhost:3306",
+		Credentials: Credentials{Database: "app", Username: "app"},
+		TLS:         &TLSConfig{Enabled: true, CertFile: "client.pem"},
+	}
+	require.ErrorContains(t, cfg.Validate(), "tls")
+
+	cfg.TLS.KeyFile = "client.key"
+	require.NoError(t, cfg.Validate())
+
+	connections := ConnectionsConfig{"main": cfg, "replica": &ConnectionConfig{}}
+	require.ErrorContains(t, connections.Validate(), "replica")
+}
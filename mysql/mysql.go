@@ -0,0 +1,214 @@
+package inframysql
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/dlmiddlecote/sqlstats"
+	"github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	infraoperator "github.com/pushwoosh/infra/operator"
+)
+
+// Container is a simple container for holding named mysql connections.
+type Container struct {
+	mu         *sync.RWMutex
+	cfg        map[string]ConnectionConfig
+	conns      map[string]*sql.DB
+	collectors map[string]*sqlstats.StatsCollector
+}
+
+var (
+	_ infraoperator.Stopper = (*Container)(nil)
+	_ infraoperator.Checker = (*Container)(nil)
+)
+
+func NewContainer() *Container {
+	return &Container{
+		mu:         &sync.RWMutex{},
+		cfg:        make(map[string]ConnectionConfig),
+		conns:      make(map[string]*sql.DB),
+		collectors: make(map[string]*sqlstats.StatsCollector),
+	}
+}
+
+// Connect creates a new named mysql connection
+func (cont *Container) Connect(name string, cfg *ConnectionConfig) error {
+	driverCfg, err := cfg.driverConfig(name)
+	if err != nil {
+		return err
+	}
+
+	connector, err := mysql.NewConnector(driverCfg)
+	if err != nil {
+		return errors.Wrap(err, "mysql.NewConnector")
+	}
+
+	conn := sql.OpenDB(connector)
+	if err = conn.Ping(); err != nil {
+		_ = conn.Close()
+		return errors.Wrapf(err, "conn.Ping")
+	}
+
+	conn.SetMaxOpenConns(cfg.MaxConnections)
+	conn.SetMaxIdleConns(cfg.MaxIdleConnections)
+	conn.SetConnMaxIdleTime(cfg.MaxConnectionIdleTime)
+	conn.SetConnMaxLifetime(cfg.MaxConnectionLifetime)
+
+	// replace existing connection with the same name
+	cont.Remove(name)
+
+	collector := sqlstats.NewStatsCollector(name, conn)
+	prometheus.MustRegister(collector)
+
+	cont.mu.Lock()
+	defer cont.mu.Unlock()
+
+	cont.conns[name] = conn
+	cont.cfg[name] = *cfg
+	cont.collectors[name] = collector
+
+	return nil
+}
+
+// Get gets connection from a container
+func (cont *Container) Get(name string) *sql.DB {
+	cont.mu.RLock()
+	defer cont.mu.RUnlock()
+
+	return cont.conns[name]
+}
+
+// GetCollector gets metrics collector from a container
+func (cont *Container) GetCollector(name string) *sqlstats.StatsCollector {
+	cont.mu.RLock()
+	defer cont.mu.RUnlock()
+
+	return cont.collectors[name]
+}
+
+// Remove closes named connection and removes it from the container
+func (cont *Container) Remove(name string) {
+	cont.mu.Lock()
+	conn := cont.conns[name]
+	collector := cont.collectors[name]
+	delete(cont.conns, name)
+	delete(cont.cfg, name)
+	delete(cont.collectors, name)
+	cont.mu.Unlock()
+
+	if collector != nil {
+		prometheus.Unregister(collector)
+	}
+
+	if conn != nil {
+		_ = conn.Close()
+	}
+}
+
+// Check pings all connections in the container
+func (cont *Container) Check(ctx context.Context) error {
+	cont.mu.RLock()
+	defer cont.mu.RUnlock()
+
+	for name, conn := range cont.conns {
+		if err := conn.PingContext(ctx); err != nil {
+			return errors.Wrap(err, name)
+		}
+	}
+
+	return nil
+}
+
+// Stop closes all connections in the container
+func (cont *Container) Stop(_ context.Context) error {
+	cont.Close()
+	return nil
+}
+
+// Close closes all connections in the container.
+// database/sql waits for queries in progress to finish.
+func (cont *Container) Close() {
+	cont.mu.RLock()
+	names := make([]string, 0, len(cont.conns))
+	for name := range cont.conns {
+		names = append(names, name)
+	}
+	cont.mu.RUnlock()
+
+	for _, name := range names {
+		cont.Remove(name)
+	}
+}
+
+func (c *ConnectionConfig) driverConfig(name string) (*mysql.Config, error) {
+	ret := mysql.NewConfig()
+	ret.Net = "tcp"
+	ret.Addr = c.Address
+	ret.User = c.Credentials.Username
+	ret.Passwd = c.Credentials.Password
+	ret.DBName = c.Credentials.Database
+	ret.ParseTime = true
+	ret.Timeout = c.DialTimeout
+	ret.ReadTimeout = c.ReadTimeout
+	ret.WriteTimeout = c.WriteTimeout
+
+	if c.TLS != nil && c.TLS.Enabled {
+		tlsCfg, err := c.TLS.tlsConfig(c.Address)
+		if err != nil {
+			return nil, errors.Wrap(err, "tls")
+		}
+
+		// driver looks up TLS configs by name
+		tlsName := "infra-" + name
+		if err = mysql.RegisterTLSConfig(tlsName, tlsCfg); err != nil {
+			return nil, errors.Wrap(err, "mysql.RegisterTLSConfig")
+		}
+		ret.TLSConfig = tlsName
+	}
+
+	return ret, nil
+}
+
+func (c *TLSConfig) tlsConfig(address string) (*tls.Config, error) {
+	ret := &tls.Config{
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify, //nolint:gosec
+	}
+
+	if ret.ServerName == "" {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, errors.Wrap(err, "net.SplitHostPort")
+		}
+		ret.ServerName = host
+	}
+
+	if c.CAFile != "" {
+		ca, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read CA file")
+		}
+
+		ret.RootCAs = x509.NewCertPool()
+		if !ret.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, errors.New("no certificates found in CA file")
+		}
+	}
+
+	if c.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to load client certificate")
+		}
+		ret.Certificates = []tls.Certificate{cert}
+	}
+
+	return ret, nil
+}
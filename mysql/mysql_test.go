@@ -0,0 +1,83 @@
+package inframysql
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDriverConfig(t *testing.T) {
+	cfg := &ConnectionConfig{
+		Address:      "db.local:3306",
+		Credentials:  Credentials{Database: "app", Username: "user", Password: "secret"},
+		DialTimeout:  time.Second,
+		ReadTimeout:  time.Second * 2,
+		WriteTimeout: time.Second * 3,
+	}
+
+	driverCfg, err := cfg.driverConfig("main")
+	require.NoError(t, err)
+	require.Equal(t, "tcp", driverCfg.Net)
+	require.Equal(t, "db.local:3306", driverCfg.Addr)
+	require.Equal(t, "user", driverCfg.User)
+	require.Equal(t, "secret", driverCfg.Passwd)
+	require.Equal(t, "app", driverCfg.DBName)
+	require.True(t, driverCfg.ParseTime)
+	require.Equal(t, time.Second, driverCfg.Timeout)
+	require.Equal(t, time.Second*2, driverCfg.ReadTimeout)
+	require.Equal(t, time.Second*3, driverCfg.WriteTimeout)
+	require.Empty(t, driverCfg.TLSConfig)
+
+	// disabled TLS is not registered
+	cfg.TLS = &TLSConfig{}
+	driverCfg, err = cfg.driverConfig("main")
+	require.NoError(t, err)
+	require.Empty(t, driverCfg.TLSConfig)
+
+	cfg.TLS.Enabled = true
+	driverCfg, err = cfg.driverConfig("main")
+	require.NoError(t, err)
+	require.Equal(t, "infra-main", driverCfg.TLSConfig)
+}
+
+func TestTLSConfig(t *testing.T) {
+	cfg := &TLSConfig{Enabled: true}
+
+	tlsCfg, err := cfg.tlsConfig("db.local:3306")
+	require.NoError(t, err)
+	require.Equal(t, "db.local", tlsCfg.ServerName)
+	require.Nil(t, tlsCfg.RootCAs)
+
+	cfg.ServerName = "mysql.internal"
+	tlsCfg, err = cfg.tlsConfig("db.local:3306")
+	require.NoError(t, err)
+	require.Equal(t, "mysql.internal", tlsCfg.ServerName)
+
+	_, err = (&TLSConfig{}).tlsConfig("db.local")
+	require.Error(t, err)
+
+	_, err = (&TLSConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")}).tlsConfig("db.local:3306")
+	require.ErrorContains(t, err, "CA file")
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, []byte("not a certificate"), 0o600))
+	_, err = (&TLSConfig{CAFile: caFile}).tlsConfig("db.local:3306")
+	require.ErrorContains(t, err, "no certificates")
+
+	_, err = (&TLSConfig{CertFile: "missing.pem", KeyFile: "missing.key"}).tlsConfig("db.local:3306")
+	require.ErrorContains(t, err, "client certificate")
+}
+
+func TestContainerEmpty(t *testing.T) {
+	cont := NewContainer()
+	require.Nil(t, cont.Get("main"))
+	require.Nil(t, cont.GetCollector("main"))
+	require.NoError(t, cont.Check(context.Background()))
+
+	cont.Remove("main")
+	require.NoError(t, cont.Stop(context.Background()))
+}
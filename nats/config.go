@@ -1,6 +1,8 @@
 package infranats
 
 import (
+	"time"
+
 	"github.com/pkg/errors"
 )
 
@@ -9,6 +11,46 @@ type ConnectionsConfig map[string]*ConnectionConfig
 type ConnectionConfig struct {
 	// NATS Address
 	Address string `mapstructure:"address"`
+
+	// Credentials. Optional
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+
+	// Maximum number of reconnect attempts. Default: -1 (reconnect forever)
+	MaxReconnects *int `mapstructure:"max_reconnects"`
+
+	// Delay between reconnect attempts. Default: nats.DefaultReconnectWait
+	ReconnectWait time.Duration `mapstructure:"reconnect_wait"`
+}
+
+type AckPolicy string
+
+const (
+	AckPolicyExplicit AckPolicy = "explicit"
+	AckPolicyAll      AckPolicy = "all"
+	AckPolicyNone     AckPolicy = "none"
+)
+
+type ConsumerConfig struct {
+	ConnectionName string
+	Subject        string
+	Queue          string // optional, queue group name
+
+	// JetStream options. Core NATS subscription is used if JetStream is nil
+	JetStream *JetStreamConsumerConfig // optional
+}
+
+type JetStreamConsumerConfig struct {
+	Stream        string        // optional, stream is looked up by subject if empty
+	Durable       string        // optional, ephemeral consumer is created if empty
+	Pull          bool          // optional, use pull consumer instead of push
+	AckPolicy     AckPolicy     // optional, default AckPolicyExplicit
+	AckWait       time.Duration // optional
+	MaxDeliver    int           // optional
+	MaxAckPending int           // optional
+	BatchSize     int           // optional, pull batch size
+	FetchTimeout  time.Duration // optional, pull fetch wait time
+	DeliverNew    bool          // optional, deliver only messages published after consumer creation
 }
 
 func (c *ConnectionsConfig) Validate() error {
@@ -36,3 +78,52 @@ func (c *ConnectionConfig) Validate() error {
 
 	return nil
 }
+
+func (c *ConnectionConfig) GetMaxReconnects() int {
+	if c.MaxReconnects == nil {
+		return -1
+	}
+
+	return *c.MaxReconnects
+}
+
+func (c *ConsumerConfig) Validate() error {
+	if c == nil {
+		return errors.New("empty consumer config")
+	}
+
+	if c.Subject == "" {
+		return errors.New("subject is mandatory")
+	}
+
+	if c.JetStream == nil {
+		return nil
+	}
+
+	js := c.JetStream
+	if js.AckPolicy == "" {
+		js.AckPolicy = AckPolicyExplicit
+	}
+
+	if js.AckPolicy != AckPolicyExplicit && js.AckPolicy != AckPolicyAll && js.AckPolicy != AckPolicyNone {
+		return errors.Errorf("invalid ack policy \"%s\"", js.AckPolicy)
+	}
+
+	if js.Pull && js.Durable == "" {
+		return errors.New("pull consumer requires durable name")
+	}
+
+	if js.Pull && c.Queue != "" {
+		return errors.New("queue groups are not supported by pull consumers")
+	}
+
+	if js.BatchSize <= 0 {
+		js.BatchSize = defaultBatchSize
+	}
+
+	if js.FetchTimeout <= 0 {
+		js.FetchTimeout = defaultFetchTimeout
+	}
+
+	return nil
+}
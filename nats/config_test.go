@@ -0,0 +1,55 @@
+package infranats
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectionConfig(t *testing.T) {
+	var cfg *ConnectionConfig
+	require.Error(t, cfg.Validate())
+
+	cfg = &ConnectionConfig{}
+	require.ErrorContains(t, cfg.Validate(), "address")
+
+	cfg = &ConnectionConfig{Address: "nats://localhost:4222"}
+	require.NoError(t, cfg.Validate())
+	require.Equal(t, -1, cfg.GetMaxReconnects())
+
+	maxReconnects := 10
+	cfg.MaxReconnects = &maxReconnects
+	require.Equal(t, 10, cfg.GetMaxReconnects())
+
+	connections := ConnectionsConfig{"main": cfg, "events": {}}
+	require.ErrorContains(t, connections.Validate(), "events")
+}
+
+func TestConsumerConfig(t *testing.T) {
+	var cfg *ConsumerConfig
+	require.Error(t, cfg.Validate())
+
+	cfg = &ConsumerConfig{}
+	require.ErrorContains(t, cfg.Validate(), "subject")
+
+	cfg = &ConsumerConfig{Subject: "events", Queue: "workers"}
+	require.NoError(t, cfg.Validate())
+
+	cfg.JetStream = &JetStreamConsumerConfig{}
+	require.NoError(t, cfg.Validate())
+	require.Equal(t, AckPolicyExplicit, cfg.JetStream.AckPolicy)
+	require.Equal(t, defaultBatchSize, cfg.JetStream.BatchSize)
+	require.Equal(t, defaultFetchTimeout, cfg.JetStream.FetchTimeout)
+
+	cfg.JetStream.AckPolicy = "manual"
+	require.ErrorContains(t, cfg.Validate(), "ack policy")
+
+	cfg.JetStream = &JetStreamConsumerConfig{Pull: true}
+	require.ErrorContains(t, cfg.Validate(), "durable")
+
+	cfg.JetStream.Durable = "sender"
+	require.ErrorContains(t, cfg.Validate(), "queue groups")
+
+	cfg.Queue = ""
+	require.NoError(t, cfg.Validate())
+}
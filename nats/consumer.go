@@ -0,0 +1,249 @@
+package infranats
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	inframessaging "github.com/pushwoosh/infra/messaging"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultBatchSize    = 16
+	defaultFetchTimeout = 5 * time.Second
+	drainCheckInterval  = 10 * time.Millisecond
+)
+
+// Handler processes a consumed message.
+// Message is acknowledged if handler returns nil and negatively acknowledged otherwise,
+// unless handler has already called Ack or Nack itself.
+type Handler = inframessaging.Handler[*Message]
+
+// Consumer passes messages of a core NATS or JetStream subscription to a handler
+type Consumer struct {
+	conn *nats.Conn
+	cfg  *ConsumerConfig
+
+	mu       sync.Mutex
+	sub      *nats.Subscription
+	cancel   context.CancelFunc
+	isClosed bool
+
+	// wgMu orders wg.Add of callbacks with wg.Wait of Close, it's separate from mu held by Close while draining
+	wgMu    sync.Mutex
+	wg      sync.WaitGroup
+	stopped bool
+}
+
+// CreateConsumer creates a new consumer by a connection name. Call Consume to start processing messages.
+func (cont *Container) CreateConsumer(consumerCfg *ConsumerConfig) (*Consumer, error) {
+	if err := consumerCfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	conn := cont.Get(consumerCfg.ConnectionName)
+	if conn == nil {
+		return nil, errors.Errorf("invalid connection name: %s", consumerCfg.ConnectionName)
+	}
+
+	return &Consumer{
+		conn: conn,
+		cfg:  consumerCfg,
+	}, nil
+}
+
+// Consume subscribes to the subject and starts processing messages in background
+func (c *Consumer) Consume(handler Handler) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.isClosed {
+		return errors.New("nats consumer is closed")
+	}
+	if c.sub != nil {
+		return errors.New("nats consumer is already started")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var err error
+	switch {
+	case c.cfg.JetStream == nil:
+		c.sub, err = c.conn.QueueSubscribe(c.cfg.Subject, c.cfg.Queue, c.callback(ctx, handler, false))
+	case c.cfg.JetStream.Pull:
+		err = c.startPull(ctx, handler)
+	default:
+		err = c.startPush(ctx, handler)
+	}
+
+	if err != nil {
+		cancel()
+		c.sub = nil
+		return errors.Wrap(err, "unable to subscribe")
+	}
+
+	c.cancel = cancel
+	return nil
+}
+
+// Close stops receiving new messages and waits for messages in progress
+func (c *Consumer) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.isClosed {
+		return nil
+	}
+	c.isClosed = true
+
+	var err error
+	if c.sub != nil {
+		// durable consumers must survive restarts, so subscription is drained instead of deleted
+		err = c.sub.Drain()
+
+		// drain is asynchronous: wait until all pending messages are delivered to the handler
+		for err == nil && c.sub.IsValid() {
+			time.Sleep(drainCheckInterval)
+		}
+	}
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	c.wgMu.Lock()
+	c.stopped = true
+	c.wgMu.Unlock()
+
+	c.wg.Wait()
+	return err
+}
+
+func (c *Consumer) jsSubOpts() []nats.SubOpt {
+	js := c.cfg.JetStream
+	opts := []nats.SubOpt{nats.ManualAck()}
+
+	if js.Stream != "" {
+		opts = append(opts, nats.BindStream(js.Stream))
+	}
+	if js.Durable != "" {
+		opts = append(opts, nats.Durable(js.Durable))
+	}
+
+	switch js.AckPolicy {
+	case AckPolicyExplicit:
+		opts = append(opts, nats.AckExplicit())
+	case AckPolicyAll:
+		opts = append(opts, nats.AckAll())
+	case AckPolicyNone:
+		opts = append(opts, nats.AckNone())
+	}
+
+	if js.AckWait > 0 {
+		opts = append(opts, nats.AckWait(js.AckWait))
+	}
+	if js.MaxDeliver > 0 {
+		opts = append(opts, nats.MaxDeliver(js.MaxDeliver))
+	}
+	if js.MaxAckPending > 0 {
+		opts = append(opts, nats.MaxAckPending(js.MaxAckPending))
+	}
+	if js.DeliverNew {
+		opts = append(opts, nats.DeliverNew())
+	}
+
+	return opts
+}
+
+func (c *Consumer) startPush(ctx context.Context, handler Handler) error {
+	js, err := c.conn.JetStream()
+	if err != nil {
+		return errors.Wrap(err, "JetStream")
+	}
+
+	needsAck := c.cfg.JetStream.AckPolicy != AckPolicyNone
+	c.sub, err = js.QueueSubscribe(c.cfg.Subject, c.cfg.Queue, c.callback(ctx, handler, needsAck), c.jsSubOpts()...)
+	return err
+}
+
+func (c *Consumer) startPull(ctx context.Context, handler Handler) error {
+	js, err := c.conn.JetStream()
+	if err != nil {
+		return errors.Wrap(err, "JetStream")
+	}
+
+	c.sub, err = js.PullSubscribe(c.cfg.Subject, c.cfg.JetStream.Durable, c.jsSubOpts()...)
+	if err != nil {
+		return err
+	}
+
+	sub := c.sub
+	needsAck := c.cfg.JetStream.AckPolicy != AckPolicyNone
+	callback := c.callback(ctx, handler, needsAck)
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		for ctx.Err() == nil {
+			fetchCtx, cancel := context.WithTimeout(ctx, c.cfg.JetStream.FetchTimeout)
+			msgs, err := sub.Fetch(c.cfg.JetStream.BatchSize, nats.Context(fetchCtx))
+			cancel()
+
+			if err != nil {
+				if ctx.Err() != nil || errors.Is(err, nats.ErrBadSubscription) || errors.Is(err, nats.ErrConnectionClosed) {
+					return
+				}
+				if !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, nats.ErrTimeout) {
+					infralog.Error("nats consumer: fetch", zap.String("subject", c.cfg.Subject), zap.Error(err))
+					time.Sleep(time.Second) // time to wait to not make infinite "for" loop
+				}
+				continue
+			}
+
+			for _, msg := range msgs {
+				callback(msg)
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (c *Consumer) callback(ctx context.Context, handler Handler, needsAck bool) nats.MsgHandler {
+	return func(natsMsg *nats.Msg) {
+		msg := newMessage(natsMsg, needsAck)
+
+		c.wgMu.Lock()
+		if c.stopped {
+			c.wgMu.Unlock()
+			// JetStream redelivers the message, core NATS messages are lost like after unsubscribe
+			_ = msg.Nack()
+			return
+		}
+		c.wg.Add(1)
+		c.wgMu.Unlock()
+		defer c.wg.Done()
+
+		start := time.Now()
+		err := handler(ctx, msg)
+		metrics.HandleDurationHistogram.WithLabelValues(c.cfg.Subject).Observe(time.Since(start).Seconds())
+
+		if err == nil {
+			metrics.ConsumedMessagesCounter.WithLabelValues(c.cfg.Subject, "success").Inc()
+			if ackErr := msg.Ack(); ackErr != nil {
+				infralog.Error("nats consumer: ack", zap.String("subject", natsMsg.Subject), zap.Error(ackErr))
+			}
+			return
+		}
+
+		metrics.ConsumedMessagesCounter.WithLabelValues(c.cfg.Subject, "error").Inc()
+		infralog.Error("nats consumer: handle message", zap.String("subject", natsMsg.Subject), zap.Error(err))
+		if nackErr := msg.Nack(); nackErr != nil {
+			infralog.Error("nats consumer: nack", zap.String("subject", natsMsg.Subject), zap.Error(nackErr))
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package infranats
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsumerCallback(t *testing.T) {
+	initMetrics()
+
+	c := &Consumer{cfg: &ConsumerConfig{Subject: "events"}}
+
+	var handled []string
+	callback := c.callback(context.Background(), func(_ context.Context, msg *Message) error {
+		handled = append(handled, string(msg.Body()))
+		if string(msg.Body()) == "bad" {
+			return errors.New("invalid message")
+		}
+		return nil
+	}, false)
+
+	callback(&nats.Msg{Subject: "events", Data: []byte("good")})
+	callback(&nats.Msg{Subject: "events", Data: []byte("bad")})
+	require.Equal(t, []string{"good", "bad"}, handled)
+
+	// messages received while closing are not handled
+	c.stopped = true
+	callback(&nats.Msg{Subject: "events", Data: []byte("late")})
+	require.Equal(t, []string{"good", "bad"}, handled)
+}
+
+func TestJetStreamSubOpts(t *testing.T) {
+	c := &Consumer{cfg: &ConsumerConfig{Subject: "events", JetStream: &JetStreamConsumerConfig{AckPolicy: AckPolicyExplicit}}}
+	require.Len(t, c.jsSubOpts(), 2)
+
+	c.cfg.JetStream = &JetStreamConsumerConfig{
+		Stream:        "EVENTS",
+		Durable:       "sender",
+		AckPolicy:     AckPolicyAll,
+		AckWait:       time.Second,
+		MaxDeliver:    3,
+		MaxAckPending: 100,
+		DeliverNew:    true,
+	}
+	require.Len(t, c.jsSubOpts(), 8)
+}
+
+func TestCreateConsumer(t *testing.T) {
+	cont := NewContainer()
+
+	_, err := cont.CreateConsumer(&ConsumerConfig{})
+	require.ErrorContains(t, err, "subject")
+
+	_, err = cont.CreateConsumer(&ConsumerConfig{ConnectionName: "missing", Subject: "events"})
+	require.ErrorContains(t, err, "missing")
+}
@@ -0,0 +1,75 @@
+package infranats
+
+import (
+	"sync/atomic"
+
+	"github.com/nats-io/nats.go"
+)
+
+type Message struct {
+	msg       *nats.Msg
+	needsAck  bool
+	once      atomic.Bool
+	delivered uint64
+}
+
+// Ack acknowledges JetStream message. Does nothing for core NATS messages.
+func (m *Message) Ack() error {
+	if !m.needsAck || m.once.Swap(true) {
+		return nil
+	}
+
+	return m.msg.Ack()
+}
+
+// Nack asks JetStream to redeliver the message. Does nothing for core NATS messages.
+func (m *Message) Nack() error {
+	if !m.needsAck || m.once.Swap(true) {
+		return nil
+	}
+
+	return m.msg.Nak()
+}
+
+// InProgress resets JetStream redelivery timer for the message
+func (m *Message) InProgress() error {
+	if !m.needsAck {
+		return nil
+	}
+
+	return m.msg.InProgress()
+}
+
+func (m *Message) IsRedelivered() bool {
+	return m.delivered > 1
+}
+
+func (m *Message) Subject() string {
+	return m.msg.Subject
+}
+
+func (m *Message) Header(key string) string {
+	return m.msg.Header.Get(key)
+}
+
+func (m *Message) Body() []byte {
+	return m.msg.Data
+}
+
+// Raw returns underlying nats message
+func (m *Message) Raw() *nats.Msg {
+	return m.msg
+}
+
+func newMessage(msg *nats.Msg, needsAck bool) *Message {
+	ret := &Message{
+		msg:      msg,
+		needsAck: needsAck,
+	}
+
+	if meta, err := msg.Metadata(); err == nil {
+		ret.delivered = meta.NumDelivered
+	}
+
+	return ret
+}
@@ -0,0 +1,46 @@
+package infranats
+
+import (
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessage(t *testing.T) {
+	raw := &nats.Msg{
+		Subject: "events",
+		Header:  nats.Header{"Type": []string{"event"}},
+		Data:    []byte("body"),
+	}
+
+	msg := newMessage(raw, false)
+	require.Equal(t, "events", msg.Subject())
+	require.Equal(t, "event", msg.Header("Type"))
+	require.Equal(t, []byte("body"), msg.Body())
+	require.Same(t, raw, msg.Raw())
+	require.False(t, msg.IsRedelivered())
+
+	// core NATS messages are not acknowledged
+	require.NoError(t, msg.Ack())
+	require.NoError(t, msg.Nack())
+	require.NoError(t, msg.InProgress())
+}
+
+func TestMessageAckOnce(t *testing.T) {
+	msg := newMessage(&nats.Msg{Subject: "events"}, true)
+
+	require.ErrorIs(t, msg.Ack(), nats.ErrMsgNotBound)
+	require.NoError(t, msg.Nack(), "only the first acknowledgement is sent")
+	require.NoError(t, msg.Ack())
+}
+
+func TestMessageRedelivered(t *testing.T) {
+	raw := &nats.Msg{
+		Subject: "events",
+		Reply:   "$JS.ACK.stream.consumer.2.10.5.1700000000000000000.0",
+		Sub:     &nats.Subscription{},
+	}
+
+	require.True(t, newMessage(raw, true).IsRedelivered())
+}
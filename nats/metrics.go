@@ -0,0 +1,41 @@
+package infranats
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	inframetrics "github.com/pushwoosh/infra/metrics"
+)
+
+var metrics struct {
+	ConsumedMessagesCounter *inframetrics.CounterVec
+	HandleDurationHistogram *inframetrics.HistogramVec
+	ConnectionEventsCounter *inframetrics.CounterVec
+}
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.ConsumedMessagesCounter = inframetrics.NewCounterVec(prometheus.CounterOpts{
+			Name: "nats_consumer_messages_counter",
+			Help: "The total number of handled messages",
+		}, []string{"subject", "status"})
+
+		metrics.HandleDurationHistogram = inframetrics.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "nats_consumer_handle_duration",
+			Help:    "The message handler duration",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"subject"})
+
+		metrics.ConnectionEventsCounter = inframetrics.NewCounterVec(prometheus.CounterOpts{
+			Name: "nats_connection_events_counter",
+			Help: "The total number of connection events",
+		}, []string{"connection", "event"})
+
+		prometheus.MustRegister(
+			metrics.ConsumedMessagesCounter,
+			metrics.HandleDurationHistogram,
+			metrics.ConnectionEventsCounter,
+		)
+	})
+}
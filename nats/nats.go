@@ -26,12 +26,22 @@ func NewContainer() *Container {
 	}
 }
 
-func connErrHandler(conn *nats.Conn, err error) {
-	if err == nil {
-		return
+func connErrHandler(name string) nats.ConnErrHandler {
+	return func(conn *nats.Conn, err error) {
+		metrics.ConnectionEventsCounter.WithLabelValues(name, "disconnect").Inc()
+		if err == nil {
+			return
+		}
+
+		infralog.Error("nats connection error", zap.String("connection", name), zap.Error(err))
 	}
+}
 
-	infralog.Error("nats connection error", zap.Error(err))
+func reconnectHandler(name string) nats.ConnHandler {
+	return func(conn *nats.Conn) {
+		metrics.ConnectionEventsCounter.WithLabelValues(name, "reconnect").Inc()
+		infralog.Info("nats reconnected", zap.String("connection", name), zap.String("url", conn.ConnectedUrl()))
+	}
 }
 
 func errHandler(conn *nats.Conn, sub *nats.Subscription, err error) {
@@ -44,12 +54,29 @@ func errHandler(conn *nats.Conn, sub *nats.Subscription, err error) {
 
 // Connect creates a new named NATS connection
 func (cont *Container) Connect(name string, cfg *ConnectionConfig) error {
+	initMetrics()
 	cont.wg.Add(1)
 
-	conn, err := nats.Connect(cfg.Address, nats.DisconnectErrHandler(connErrHandler), nats.ErrorHandler(errHandler),
+	opts := []nats.Option{
+		nats.Name(name),
+		nats.MaxReconnects(cfg.GetMaxReconnects()),
+		nats.DisconnectErrHandler(connErrHandler(name)),
+		nats.ReconnectHandler(reconnectHandler(name)),
+		nats.ErrorHandler(errHandler),
 		nats.ClosedHandler(func(conn *nats.Conn) {
 			cont.wg.Done()
-		}))
+		}),
+	}
+
+	if cfg.ReconnectWait > 0 {
+		opts = append(opts, nats.ReconnectWait(cfg.ReconnectWait))
+	}
+
+	if cfg.Username != "" {
+		opts = append(opts, nats.UserInfo(cfg.Username, cfg.Password))
+	}
+
+	conn, err := nats.Connect(cfg.Address, opts...)
 	if err != nil {
 		cont.wg.Done()
 		return err
@@ -1,42 +1,33 @@
 package netretry
 
 import (
+	"context"
 	"net"
 	"syscall"
 	"time"
 
 	"github.com/pkg/errors"
+	infraretry "github.com/pushwoosh/infra/retry"
 )
 
 const MaxSleep = 5 * time.Second
 const MaxRetries = 100
 
-func ExecWithRetry(fn func() error) error {
-	sleep := 50 * time.Millisecond
-	try := 0
-	var lastErr error
-	for {
-		try++
-		err := fn()
-		if err == nil {
-			return nil
-		}
-
-		if !isErrorRetryable(err) {
-			return err
-		}
-
-		lastErr = err
-		if try > MaxRetries {
-			return errors.Wrapf(lastErr, "retried %d times", MaxRetries)
-		}
+var retrier = infraretry.New("netretry",
+	infraretry.WithBackoff(infraretry.Exponential{Initial: 50 * time.Millisecond, Max: MaxSleep}),
+	infraretry.WithMaxAttempts(MaxRetries+1),
+	infraretry.WithRetryable(isErrorRetryable),
+)
 
-		time.Sleep(sleep)
-		sleep *= 2
-		if sleep > MaxSleep {
-			sleep = MaxSleep
-		}
+func ExecWithRetry(fn func() error) error {
+	err := retrier.Do(context.Background(), func(context.Context) error {
+		return fn()
+	})
+	if err != nil && isErrorRetryable(err) {
+		return errors.Wrapf(err, "retried %d times", MaxRetries)
 	}
+
+	return err
 }
 
 func isErrorRetryable(err error) bool {
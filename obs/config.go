@@ -0,0 +1,75 @@
+package infraobs
+
+import (
+	"github.com/pkg/errors"
+	infradebug "github.com/pushwoosh/infra/debug"
+	inframetricsotlp "github.com/pushwoosh/infra/metrics/otlp"
+	inframetricsremotewrite "github.com/pushwoosh/infra/metrics/remotewrite"
+	inframetricsstatsd "github.com/pushwoosh/infra/metrics/statsd"
+)
+
+const DefaultListenAddress = ":8080"
+
+// Config is an observability server configuration
+type Config struct {
+	// Address the server will be bound to
+	Listen string `mapstructure:"listen"`
+
+	// PprofEnabled enables /debug/pprof endpoints
+	PprofEnabled bool `mapstructure:"pprof_enabled"`
+
+	// Debug serves handlers of infradebug.Default registry on /debug/infra/. optional
+	Debug *infradebug.Config `mapstructure:"debug"`
+
+	// StatsD sends metrics to a StatsD or DogStatsD agent in addition to /metrics. optional
+	StatsD *inframetricsstatsd.Config `mapstructure:"statsd"`
+
+	// OTLP exports metrics to an OpenTelemetry collector in addition to /metrics. optional
+	OTLP *inframetricsotlp.Config `mapstructure:"otlp"`
+
+	// RemoteWrite pushes metrics to VictoriaMetrics or Prometheus in addition to /metrics. optional
+	RemoteWrite *inframetricsremotewrite.Config `mapstructure:"remote_write"`
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Listen:       DefaultListenAddress,
+		PprofEnabled: true,
+	}
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if c.Listen == "" {
+		return errors.New("empty listen address")
+	}
+
+	if c.Debug != nil {
+		if err := c.Debug.Validate(); err != nil {
+			return errors.Wrap(err, "debug")
+		}
+	}
+
+	if c.StatsD != nil {
+		if err := c.StatsD.Validate(); err != nil {
+			return errors.Wrap(err, "statsd")
+		}
+	}
+
+	if c.OTLP != nil {
+		if err := c.OTLP.Validate(); err != nil {
+			return errors.Wrap(err, "otlp")
+		}
+	}
+
+	if c.RemoteWrite != nil {
+		if err := c.RemoteWrite.Validate(); err != nil {
+			return errors.Wrap(err, "remote_write")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package infraobs
+
+import (
+	"context"
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	infradebug "github.com/pushwoosh/infra/debug"
+	infrahealth "github.com/pushwoosh/infra/health"
+	infrahttp "github.com/pushwoosh/infra/http"
+	inframetricsotlp "github.com/pushwoosh/infra/metrics/otlp"
+	inframetricsremotewrite "github.com/pushwoosh/infra/metrics/remotewrite"
+	inframetricsstatsd "github.com/pushwoosh/infra/metrics/statsd"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	infraversion "github.com/pushwoosh/infra/version"
+)
+
+// Server is an admin http server that serves on a dedicated port:
+//
+//	/metrics       - prometheus metrics
+//	/healthz       - liveness probe
+//	/readyz        - readiness probe
+//	/version       - build information, see infraversion.Handler
+//	/buildinfo     - alias of /version
+//	/debug/pprof/  - pprof, if enabled
+//	/debug/infra/  - debug handlers of subsystems, if enabled, see infradebug
+//
+// Metrics are sent to other backends configured in Config as well: StatsD and OTLP.
+type Server struct {
+	cfg    *Config
+	health *infrahealth.Registry
+
+	mux   *http.ServeMux
+	srv   *infrahttp.Server
+	sinks []infraoperator.Stopper
+}
+
+var (
+	_ infraoperator.Starter = (*Server)(nil)
+	_ infraoperator.Stopper = (*Server)(nil)
+)
+
+// NewServer creates a new observability server. health may be nil, then probes always succeed.
+func NewServer(cfg *Config, health *infrahealth.Registry) *Server {
+	if health == nil {
+		health = infrahealth.NewRegistry()
+	}
+
+	s := &Server{
+		cfg:    cfg,
+		health: health,
+		mux:    http.NewServeMux(),
+	}
+
+	s.mux.Handle("/metrics", promhttp.Handler())
+	s.mux.Handle("/healthz", health.LivenessHandler())
+	s.mux.Handle("/readyz", health.ReadinessHandler())
+	s.mux.Handle("/version", infraversion.Handler())
+	s.mux.Handle("/buildinfo", infraversion.Handler())
+
+	if cfg.PprofEnabled {
+		s.mux.HandleFunc("/debug/pprof/", pprof.Index)
+		s.mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		s.mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		s.mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		s.mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+
+	// pprof profiles may take much longer than default write timeout
+	httpCfg := infrahttp.DefaultConfig()
+	httpCfg.Listen = cfg.Listen
+	httpCfg.WriteTimeout = 0
+
+	s.srv = infrahttp.NewServer(httpCfg, s.mux, infrahttp.WithName("obs"), infrahttp.WithoutMetrics())
+
+	return s
+}
+
+// Handle registers additional admin handler
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// Health returns health checks registry used by the server
+func (s *Server) Health() *infrahealth.Registry {
+	return s.health
+}
+
+// Start starts serving and sending metrics to configured backends in background
+func (s *Server) Start(ctx context.Context) error {
+	if s.cfg.Debug != nil && s.cfg.Debug.Enabled {
+		handler, err := infradebug.Default().Handler(s.cfg.Debug)
+		if err != nil {
+			return errors.Wrap(err, "debug")
+		}
+		s.mux.Handle(infradebug.Prefix, handler)
+	}
+
+	if s.cfg.StatsD != nil && s.cfg.StatsD.Enabled {
+		sink, err := inframetricsstatsd.NewSink(s.cfg.StatsD)
+		if err != nil {
+			return errors.Wrap(err, "statsd")
+		}
+		if err = sink.Start(ctx); err != nil {
+			return err
+		}
+		s.sinks = append(s.sinks, sink)
+	}
+
+	if s.cfg.OTLP != nil && s.cfg.OTLP.Enabled {
+		pipeline, err := inframetricsotlp.NewPipeline(s.cfg.OTLP)
+		if err != nil {
+			_ = s.stopSinks(ctx)
+			return errors.Wrap(err, "otlp")
+		}
+		if err = pipeline.Start(ctx); err != nil {
+			_ = s.stopSinks(ctx)
+			return err
+		}
+		s.sinks = append(s.sinks, pipeline)
+	}
+
+	if s.cfg.RemoteWrite != nil && s.cfg.RemoteWrite.Enabled {
+		sink, err := inframetricsremotewrite.NewSink(s.cfg.RemoteWrite)
+		if err != nil {
+			_ = s.stopSinks(ctx)
+			return errors.Wrap(err, "remote_write")
+		}
+		if err = sink.Start(ctx); err != nil {
+			_ = s.stopSinks(ctx)
+			return err
+		}
+		s.sinks = append(s.sinks, sink)
+	}
+
+	if err := s.srv.Start(ctx); err != nil {
+		_ = s.stopSinks(ctx)
+		return err
+	}
+
+	return nil
+}
+
+// Stop marks the service as not ready, stops the server and flushes metrics to configured backends
+func (s *Server) Stop(ctx context.Context) error {
+	s.health.SetReady(false)
+	err := s.srv.Stop(ctx)
+
+	if sinkErr := s.stopSinks(ctx); err == nil {
+		err = sinkErr
+	}
+
+	return err
+}
+
+func (s *Server) stopSinks(ctx context.Context) error {
+	var firstErr error
+	for _, sink := range s.sinks {
+		if err := sink.Stop(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	s.sinks = nil
+	return firstErr
+}
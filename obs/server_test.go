@@ -0,0 +1,88 @@
+package infraobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	infrahealth "github.com/pushwoosh/infra/health"
+)
+
+func TestConfig(t *testing.T) {
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg *Config
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected error for nil config")
+	}
+	if err := (&Config{}).Validate(); err == nil {
+		t.Fatal("expected error for empty listen address")
+	}
+}
+
+func TestServerHandlers(t *testing.T) {
+	health := infrahealth.NewRegistry()
+	s := NewServer(DefaultConfig(), health)
+	s.Handle("/custom", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "custom")
+	}))
+
+	if s.Health() != health {
+		t.Fatal("expected the passed registry")
+	}
+
+	get := func(s *Server, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	for _, path := range []string{"/metrics", "/healthz", "/readyz", "/version", "/buildinfo", "/debug/pprof/", "/custom"} {
+		if rec := get(s, path); rec.Code != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d", path, rec.Code)
+		}
+	}
+
+	var version map[string]any
+	if err := json.Unmarshal(get(s, "/buildinfo").Body.Bytes(), &version); err != nil {
+		t.Fatalf("expected build info json: %v", err)
+	}
+
+	health.Register("db", func(context.Context) error { return errors.New("connection refused") })
+	if rec := get(s, "/readyz"); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected failed readiness, got %d", rec.Code)
+	}
+	if rec := get(s, "/healthz"); rec.Code != http.StatusOK {
+		t.Fatalf("expected dependencies not failing liveness, got %d", rec.Code)
+	}
+
+	cfg := DefaultConfig()
+	cfg.PprofEnabled = false
+	if rec := get(NewServer(cfg, nil), "/debug/pprof/"); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected pprof disabled, got %d", rec.Code)
+	}
+}
+
+func TestServerStop(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Listen = "127.0.0.1:0"
+	s := NewServer(cfg, nil)
+
+	ctx := context.Background()
+	if err := s.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Health().Check(ctx); err == nil {
+		t.Fatal("expected the service not ready after stop")
+	}
+}
@@ -0,0 +1,71 @@
+package infraoutbox
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type RelayConfig struct {
+	// How often the table is polled when there are no pending events
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+
+	// Max number of events claimed at once
+	BatchSize int `mapstructure:"batch_size"`
+
+	// Published events older than that are deleted. optional, cleanup is disabled if zero
+	Retention time.Duration `mapstructure:"retention"`
+
+	// How often published events are deleted. optional, default: 1h
+	CleanupInterval time.Duration `mapstructure:"cleanup_interval"`
+
+	// Events rejected by the broker are parked after that many failed attempts, so they don't block the next events.
+	// Attempts failed for other reasons like an unavailable broker are counted too. optional, default: 10
+	MaxAttempts int `mapstructure:"max_attempts"`
+}
+
+const defaultMaxAttempts = 10
+
+func DefaultRelayConfig() *RelayConfig {
+	return &RelayConfig{
+		PollInterval:    time.Second,
+		BatchSize:       100,
+		Retention:       7 * 24 * time.Hour,
+		CleanupInterval: time.Hour,
+	}
+}
+
+func (c *RelayConfig) Validate() error {
+	if c == nil {
+		return errors.New("empty relay config")
+	}
+
+	if c.PollInterval <= 0 {
+		return errors.New("poll_interval should be greater than zero")
+	}
+
+	if c.BatchSize <= 0 {
+		return errors.New("batch_size should be greater than zero")
+	}
+
+	if c.Retention < 0 {
+		return errors.New("retention should be greater than or equal to zero")
+	}
+
+	if c.MaxAttempts < 0 {
+		return errors.New("max_attempts should be greater than or equal to zero")
+	}
+
+	if c.CleanupInterval == 0 {
+		c.CleanupInterval = time.Hour
+	}
+
+	return nil
+}
+
+func (c *RelayConfig) GetMaxAttempts() int {
+	if c.MaxAttempts == 0 {
+		return defaultMaxAttempts
+	}
+	return c.MaxAttempts
+}
@@ -0,0 +1,61 @@
+package infraoutbox
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	PublishedCounter *prometheus.CounterVec
+	FailedCounter    *prometheus.CounterVec
+	ParkedCounter    *prometheus.CounterVec
+	LagGauge         *prometheus.GaugeVec
+	BatchDuration    *prometheus.HistogramVec
+	CleanedUpCounter *prometheus.CounterVec
+}
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.PublishedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "outbox_published_total",
+			Help: "The total number of published outbox events",
+		}, []string{"relay"})
+
+		metrics.FailedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "outbox_publish_failed_total",
+			Help: "The total number of failed outbox event publish attempts",
+		}, []string{"relay"})
+
+		metrics.ParkedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "outbox_parked_total",
+			Help: "The total number of outbox events parked after being rejected by the broker",
+		}, []string{"relay"})
+
+		metrics.LagGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "outbox_lag_seconds",
+			Help: "Age of the oldest unpublished event seen by the relay",
+		}, []string{"relay"})
+
+		metrics.BatchDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "outbox_batch_duration",
+			Help:    "The outbox batch processing duration",
+			Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		}, []string{"relay"})
+
+		metrics.CleanedUpCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "outbox_cleaned_up_total",
+			Help: "The total number of deleted published events",
+		}, []string{"relay"})
+
+		prometheus.MustRegister(
+			metrics.PublishedCounter,
+			metrics.FailedCounter,
+			metrics.ParkedCounter,
+			metrics.LagGauge,
+			metrics.BatchDuration,
+			metrics.CleanedUpCounter,
+		)
+	})
+}
@@ -0,0 +1,82 @@
+package infraoutbox
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// ErrRejected is returned by publishers when the broker rejects an event, e.g. it can't be routed.
+// Rejected events are parked after RelayConfig.MaxAttempts, other errors are retried forever
+var ErrRejected = errors.New("event is rejected by broker")
+
+// Event is a message stored in the outbox table within a business transaction
+// and published to the broker by Relay afterwards
+type Event struct {
+	// ID is a unique event id. It's generated by Write if empty and sent as message id,
+	// so consumers can deduplicate redelivered events
+	ID         string
+	Exchange   string
+	RoutingKey string
+	Body       []byte
+	Headers    map[string]string
+	CreatedAt  time.Time
+
+	// Attempts is the number of failed publish attempts
+	Attempts int
+}
+
+// Execer is implemented by *sql.DB, *sql.Tx and *sql.Conn
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// Storage stores events. Implementations must let only one relay claim events at a time,
+// so events are published in creation order even if several relays are running
+type Storage interface {
+	// Write inserts events using exec, which is normally a business transaction
+	Write(ctx context.Context, exec Execer, events ...*Event) error
+
+	// Claim returns up to limit unpublished and not parked events in creation order.
+	// Other relays get empty batches until the batch is finished
+	Claim(ctx context.Context, limit int) (Batch, error)
+
+	// Cleanup deletes events published before the time and returns the number of deleted events
+	Cleanup(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Batch is a set of claimed events
+type Batch interface {
+	Events() []*Event
+
+	// MarkPublished marks an event as published
+	MarkPublished(ctx context.Context, id string) error
+
+	// MarkFailed records a failed publish attempt
+	MarkFailed(ctx context.Context, id string, err error) error
+
+	// MarkParked records a failed publish attempt and excludes the event from relaying.
+	// Parked events stay in the storage to be inspected and published again manually
+	MarkParked(ctx context.Context, id string, err error) error
+
+	// Finish saves marks and releases the claim
+	Finish(ctx context.Context) error
+
+	// Abort releases the claim without saving marks
+	Abort(ctx context.Context) error
+}
+
+func prepareEvents(events []*Event) {
+	now := time.Now()
+	for _, e := range events {
+		if e.ID == "" {
+			e.ID = uuid.NewString()
+		}
+		if e.CreatedAt.IsZero() {
+			e.CreatedAt = now
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package infraoutbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// batchTimeout limits the transaction of a batch. It isn't canceled with the relay context,
+// so marks of already published events are committed when the relay stops
+const batchTimeout = 5 * time.Minute
+
+// PostgresStorage keeps events in a postgres table.
+// A batch holds a transaction level advisory lock of the table, so only one relay claims events at a time
+// and the creation order is kept. Other relays get empty batches until the lock is released.
+type PostgresStorage struct {
+	db    *sql.DB
+	table string
+}
+
+var _ Storage = (*PostgresStorage)(nil)
+
+func NewPostgresStorage(db *sql.DB, table string) *PostgresStorage {
+	return &PostgresStorage{db: db, table: table}
+}
+
+// Schema returns DDL of the outbox table
+func (s *PostgresStorage) Schema() string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s (
+	id           UUID PRIMARY KEY,
+	exchange     TEXT NOT NULL,
+	routing_key  TEXT NOT NULL,
+	body         BYTEA NOT NULL,
+	headers      JSONB NOT NULL DEFAULT '{}',
+	created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+	published_at TIMESTAMPTZ,
+	parked_at    TIMESTAMPTZ,
+	attempts     INT NOT NULL DEFAULT 0,
+	last_error   TEXT
+);
+ALTER TABLE %[1]s ADD COLUMN IF NOT EXISTS parked_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS %[1]s_unpublished_idx ON %[1]s (created_at) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS %[1]s_published_idx ON %[1]s (published_at) WHERE published_at IS NOT NULL;`, s.table)
+}
+
+// Migrate creates the outbox table if it doesn't exist
+func (s *PostgresStorage) Migrate(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, s.Schema())
+	return errors.Wrap(err, "unable to create outbox table")
+}
+
+func (s *PostgresStorage) Write(ctx context.Context, exec Execer, events ...*Event) error {
+	prepareEvents(events)
+
+	query := fmt.Sprintf(`INSERT INTO %s (id, exchange, routing_key, body, headers, created_at) VALUES ($1, $2, $3, $4, $5, $6)`, s.table)
+	for _, e := range events {
+		headers, err := json.Marshal(e.Headers)
+		if err != nil {
+			return errors.Wrap(err, "unable to encode headers")
+		}
+		if e.Headers == nil {
+			headers = []byte("{}")
+		}
+
+		if _, err = exec.ExecContext(ctx, query, e.ID, e.Exchange, e.RoutingKey, e.Body, string(headers), e.CreatedAt); err != nil {
+			return errors.Wrap(err, "unable to write outbox event")
+		}
+	}
+
+	return nil
+}
+
+func (s *PostgresStorage) Claim(ctx context.Context, limit int) (Batch, error) {
+	txCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), batchTimeout)
+	tx, err := s.db.BeginTx(txCtx, nil)
+	if err != nil {
+		cancel()
+		return nil, errors.Wrap(err, "unable to begin transaction")
+	}
+	batch := &postgresBatch{tx: tx, cancel: cancel, table: s.table}
+
+	// the lock is released with the transaction, so a crashed relay doesn't hold it
+	var locked bool
+	if err = tx.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock(hashtext($1))`, "outbox:"+s.table).Scan(&locked); err != nil {
+		_ = batch.Abort(ctx)
+		return nil, errors.Wrap(err, "unable to lock outbox")
+	}
+
+	if !locked {
+		return batch, nil
+	}
+
+	query := fmt.Sprintf(`SELECT id, exchange, routing_key, body, headers, created_at, attempts FROM %s
+		WHERE published_at IS NULL AND parked_at IS NULL ORDER BY created_at LIMIT $1 FOR UPDATE`, s.table)
+
+	rows, err := tx.QueryContext(ctx, query, limit)
+	if err != nil {
+		_ = batch.Abort(ctx)
+		return nil, errors.Wrap(err, "unable to claim outbox events")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			e       Event
+			headers []byte
+		)
+		if err = rows.Scan(&e.ID, &e.Exchange, &e.RoutingKey, &e.Body, &headers, &e.CreatedAt, &e.Attempts); err != nil {
+			_ = batch.Abort(ctx)
+			return nil, errors.Wrap(err, "unable to scan outbox event")
+		}
+		if err = json.Unmarshal(headers, &e.Headers); err != nil {
+			_ = batch.Abort(ctx)
+			return nil, errors.Wrapf(err, "unable to decode headers of event %s", e.ID)
+		}
+		batch.events = append(batch.events, &e)
+	}
+
+	if err = rows.Err(); err != nil {
+		_ = batch.Abort(ctx)
+		return nil, errors.Wrap(err, "unable to claim outbox events")
+	}
+
+	return batch, nil
+}
+
+func (s *PostgresStorage) Cleanup(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE published_at < $1`, s.table), before)
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to cleanup outbox")
+	}
+
+	return res.RowsAffected()
+}
+
+type postgresBatch struct {
+	tx     *sql.Tx
+	cancel context.CancelFunc
+	table  string
+	events []*Event
+}
+
+func (b *postgresBatch) Events() []*Event {
+	return b.events
+}
+
+func (b *postgresBatch) MarkPublished(ctx context.Context, id string) error {
+	_, err := b.tx.ExecContext(ctx, fmt.Sprintf(`UPDATE %s SET published_at = now() WHERE id = $1`, b.table), id)
+	return errors.Wrap(err, "unable to mark event as published")
+}
+
+func (b *postgresBatch) MarkFailed(ctx context.Context, id string, cause error) error {
+	_, err := b.tx.ExecContext(ctx, fmt.Sprintf(`UPDATE %s SET attempts = attempts + 1, last_error = $2 WHERE id = $1`, b.table), id, cause.Error())
+	return errors.Wrap(err, "unable to mark event as failed")
+}
+
+func (b *postgresBatch) MarkParked(ctx context.Context, id string, cause error) error {
+	_, err := b.tx.ExecContext(ctx, fmt.Sprintf(`UPDATE %s SET attempts = attempts + 1, last_error = $2, parked_at = now() WHERE id = $1`, b.table), id, cause.Error())
+	return errors.Wrap(err, "unable to mark event as parked")
+}
+
+func (b *postgresBatch) Finish(_ context.Context) error {
+	defer b.cancel()
+	return errors.Wrap(b.tx.Commit(), "unable to commit outbox batch")
+}
+
+func (b *postgresBatch) Abort(_ context.Context) error {
+	defer b.cancel()
+	return b.tx.Rollback()
+}
@@ -0,0 +1,236 @@
+package infraoutbox
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	infrarabbit "github.com/pushwoosh/infra/rabbit"
+	infraretry "github.com/pushwoosh/infra/retry"
+	"go.uber.org/zap"
+)
+
+// Publisher publishes a single event. Publish must return only after the broker accepted the event
+type Publisher interface {
+	Publish(ctx context.Context, event *Event) error
+}
+
+type rabbitPublisher struct {
+	producer *infrarabbit.Producer
+}
+
+// RabbitPublisher publishes events with a producer. The producer must be created with Confirm enabled:
+// events are published as mandatory, unroutable and nacked events fail with ErrRejected
+func RabbitPublisher(producer *infrarabbit.Producer) Publisher {
+	return &rabbitPublisher{producer: producer}
+}
+
+func (p *rabbitPublisher) Publish(ctx context.Context, event *Event) error {
+	headers := make(map[string]interface{}, len(event.Headers))
+	for k, v := range event.Headers {
+		headers[k] = v
+	}
+
+	err := p.producer.Produce(ctx, &infrarabbit.ProducerMessage{
+		Body:       event.Body,
+		Exchange:   event.Exchange,
+		RoutingKey: event.RoutingKey,
+		MessageID:  event.ID,
+		Headers:    headers,
+		Mandatory:  true,
+	})
+	if errors.Is(err, infrarabbit.ErrUnroutable) || errors.Is(err, infrarabbit.ErrNacked) {
+		return errors.Wrap(ErrRejected, err.Error())
+	}
+
+	return err
+}
+
+// Relay polls the outbox storage and publishes events in creation order.
+// Event is marked as published only after the broker confirmed it, so events are delivered at least once:
+// an event is published again if the relay crashes between publishing and marking.
+// Consumers should deduplicate events by message id.
+// Several relays of a storage may run for availability, only one of them publishes at a time.
+// An event rejected by the broker MaxAttempts times is parked and the next events are published.
+type Relay struct {
+	name      string
+	storage   Storage
+	publisher Publisher
+	cfg       *RelayConfig
+
+	runMu  sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+var (
+	_ infraoperator.Starter = (*Relay)(nil)
+	_ infraoperator.Stopper = (*Relay)(nil)
+)
+
+func NewRelay(name string, storage Storage, publisher Publisher, cfg *RelayConfig) (*Relay, error) {
+	if cfg == nil {
+		cfg = DefaultRelayConfig()
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	initMetrics()
+
+	return &Relay{
+		name:      name,
+		storage:   storage,
+		publisher: publisher,
+		cfg:       cfg,
+	}, nil
+}
+
+// Start starts relaying in background
+func (r *Relay) Start(_ context.Context) error {
+	r.runMu.Lock()
+	defer r.runMu.Unlock()
+
+	if r.cancel != nil {
+		return errors.New("relay is already started")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.done = make(chan struct{})
+
+	go r.run(ctx)
+
+	return nil
+}
+
+// Stop stops relaying and waits for the current batch
+func (r *Relay) Stop(_ context.Context) error {
+	r.runMu.Lock()
+	defer r.runMu.Unlock()
+
+	if r.cancel == nil {
+		return nil
+	}
+
+	r.cancel()
+	<-r.done
+	r.cancel = nil
+
+	return nil
+}
+
+func (r *Relay) run(ctx context.Context) {
+	defer close(r.done)
+
+	var lastCleanup time.Time
+
+	for ctx.Err() == nil {
+		if r.cfg.Retention > 0 && time.Since(lastCleanup) >= r.cfg.CleanupInterval {
+			lastCleanup = time.Now()
+			r.cleanup(ctx)
+		}
+
+		n, err := r.RelayBatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			infralog.Error("outbox relay failed", zap.String("relay", r.name), zap.Error(err))
+		}
+
+		// full batch means there are more events waiting
+		if err == nil && n == r.cfg.BatchSize {
+			continue
+		}
+
+		_ = infraretry.Sleep(ctx, r.cfg.PollInterval)
+	}
+}
+
+// RelayBatch claims and publishes a single batch and returns the number of published events.
+// Publishing stops at the first failed event to keep the order, the rest of the batch is retried later.
+// A parked event doesn't stop publishing
+func (r *Relay) RelayBatch(ctx context.Context) (int, error) {
+	start := time.Now()
+
+	batch, err := r.storage.Claim(ctx, r.cfg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	events := batch.Events()
+	if len(events) == 0 {
+		metrics.LagGauge.WithLabelValues(r.name).Set(0)
+		return 0, batch.Finish(ctx)
+	}
+
+	defer func() {
+		metrics.BatchDuration.WithLabelValues(r.name).Observe(time.Since(start).Seconds())
+	}()
+
+	metrics.LagGauge.WithLabelValues(r.name).Set(time.Since(events[0].CreatedAt).Seconds())
+
+	// marks must be saved even if relay is stopping, otherwise published events are sent again
+	markCtx := context.WithoutCancel(ctx)
+
+	var published int
+	for _, event := range events {
+		publishErr := r.publisher.Publish(ctx, event)
+		if publishErr != nil {
+			metrics.FailedCounter.WithLabelValues(r.name).Inc()
+			infralog.Error("unable to publish outbox event",
+				zap.String("relay", r.name),
+				zap.String("id", event.ID),
+				zap.Int("attempts", event.Attempts+1),
+				zap.Error(publishErr),
+			)
+
+			if errors.Is(publishErr, ErrRejected) && event.Attempts+1 >= r.cfg.GetMaxAttempts() {
+				infralog.Error("outbox event is parked",
+					zap.String("relay", r.name),
+					zap.String("id", event.ID),
+					zap.String("exchange", event.Exchange),
+					zap.String("routing_key", event.RoutingKey),
+				)
+				if err = batch.MarkParked(markCtx, event.ID, publishErr); err != nil {
+					_ = batch.Abort(markCtx)
+					return 0, err
+				}
+				metrics.ParkedCounter.WithLabelValues(r.name).Inc()
+				continue
+			}
+
+			if err = batch.MarkFailed(markCtx, event.ID, publishErr); err != nil {
+				_ = batch.Abort(markCtx)
+				return 0, err
+			}
+			break
+		}
+
+		if err = batch.MarkPublished(markCtx, event.ID); err != nil {
+			_ = batch.Abort(markCtx)
+			return 0, err
+		}
+		published++
+	}
+
+	if err = batch.Finish(markCtx); err != nil {
+		return 0, err
+	}
+
+	metrics.PublishedCounter.WithLabelValues(r.name).Add(float64(published))
+
+	return published, nil
+}
+
+func (r *Relay) cleanup(ctx context.Context) {
+	deleted, err := r.storage.Cleanup(ctx, time.Now().Add(-r.cfg.Retention))
+	if err != nil {
+		infralog.Error("outbox cleanup failed", zap.String("relay", r.name), zap.Error(err))
+		return
+	}
+
+	metrics.CleanedUpCounter.WithLabelValues(r.name).Add(float64(deleted))
+}
@@ -0,0 +1,156 @@
+package infraoutbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type memoryStorage struct {
+	events    []*Event
+	published map[string]bool
+	failed    map[string]int
+	parked    map[string]bool
+}
+
+func (s *memoryStorage) Write(_ context.Context, _ Execer, events ...*Event) error {
+	prepareEvents(events)
+	s.events = append(s.events, events...)
+	return nil
+}
+
+func (s *memoryStorage) Claim(_ context.Context, limit int) (Batch, error) {
+	b := &memoryBatch{storage: s, published: map[string]bool{}, failed: map[string]int{}, parked: map[string]bool{}}
+	for _, e := range s.events {
+		if !s.published[e.ID] && !s.parked[e.ID] && len(b.events) < limit {
+			b.events = append(b.events, e)
+		}
+	}
+	return b, nil
+}
+
+func (s *memoryStorage) Cleanup(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+type memoryBatch struct {
+	storage   *memoryStorage
+	events    []*Event
+	published map[string]bool
+	failed    map[string]int
+	parked    map[string]bool
+}
+
+func (b *memoryBatch) Events() []*Event { return b.events }
+
+func (b *memoryBatch) MarkPublished(_ context.Context, id string) error {
+	b.published[id] = true
+	return nil
+}
+
+func (b *memoryBatch) MarkFailed(_ context.Context, id string, _ error) error {
+	b.failed[id]++
+	return nil
+}
+
+func (b *memoryBatch) MarkParked(_ context.Context, id string, _ error) error {
+	b.parked[id] = true
+	return nil
+}
+
+func (b *memoryBatch) Finish(context.Context) error {
+	for id := range b.published {
+		b.storage.published[id] = true
+	}
+	for id, n := range b.failed {
+		b.storage.failed[id] += n
+	}
+	for id := range b.parked {
+		b.storage.parked[id] = true
+	}
+	for _, e := range b.events {
+		e.Attempts += b.failed[e.ID]
+	}
+	return nil
+}
+
+func (b *memoryBatch) Abort(context.Context) error { return nil }
+
+type publisherFunc func(ctx context.Context, event *Event) error
+
+func (f publisherFunc) Publish(ctx context.Context, event *Event) error { return f(ctx, event) }
+
+func TestRelayKeepsOrderOnFailure(t *testing.T) {
+	storage := &memoryStorage{published: map[string]bool{}, failed: map[string]int{}, parked: map[string]bool{}}
+	ctx := context.Background()
+	_ = storage.Write(ctx, nil, &Event{ID: "1"}, &Event{ID: "2"}, &Event{ID: "3"})
+
+	var sent []string
+	fail := true
+	publisher := publisherFunc(func(_ context.Context, event *Event) error {
+		if event.ID == "2" && fail {
+			fail = false
+			return errors.New("broker is unavailable")
+		}
+		sent = append(sent, event.ID)
+		return nil
+	})
+
+	relay, err := NewRelay("test", storage, publisher, DefaultRelayConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := relay.RelayBatch(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("expected 1 published event, got %d, %v", n, err)
+	}
+	if storage.failed["2"] != 1 {
+		t.Fatalf("expected failed attempt of event 2, got %v", storage.failed)
+	}
+
+	n, err = relay.RelayBatch(ctx)
+	if err != nil || n != 2 {
+		t.Fatalf("expected 2 published events, got %d, %v", n, err)
+	}
+
+	if len(sent) != 3 || sent[0] != "1" || sent[1] != "2" || sent[2] != "3" {
+		t.Fatalf("unexpected publish order: %v", sent)
+	}
+}
+
+func TestRelayParksRejected(t *testing.T) {
+	storage := &memoryStorage{published: map[string]bool{}, failed: map[string]int{}, parked: map[string]bool{}}
+	ctx := context.Background()
+	_ = storage.Write(ctx, nil, &Event{ID: "1"}, &Event{ID: "2"})
+
+	var sent []string
+	publisher := publisherFunc(func(_ context.Context, event *Event) error {
+		if event.ID == "1" {
+			return fmt.Errorf("no route: %w", ErrRejected)
+		}
+		sent = append(sent, event.ID)
+		return nil
+	})
+
+	cfg := DefaultRelayConfig()
+	cfg.MaxAttempts = 2
+	relay, err := NewRelay("test_parked", storage, publisher, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n, _ := relay.RelayBatch(ctx); n != 0 {
+		t.Fatalf("expected no events published before event 1 is parked, got %d", n)
+	}
+
+	n, err := relay.RelayBatch(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("expected 1 published event, got %d, %v", n, err)
+	}
+	if !storage.parked["1"] || len(sent) != 1 || sent[0] != "2" {
+		t.Fatalf("expected event 1 parked and event 2 published, got %v, %v", storage.parked, sent)
+	}
+}
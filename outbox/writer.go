@@ -0,0 +1,42 @@
+package infraoutbox
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+	infratxmanager "github.com/pushwoosh/infra/txmanager"
+)
+
+// ErrNoTransaction is returned by Writer outside of a transaction
+var ErrNoTransaction = errors.New("outbox events must be written within a transaction")
+
+// Writer writes events within the ambient transaction started by infratxmanager.SQL:
+//
+//	tm := infratxmanager.NewSQL(db)
+//	writer := infraoutbox.NewWriter(storage, db)
+//	err := tm.Do(ctx, func(ctx context.Context) error {
+//		if err := orders.Create(ctx, order); err != nil {
+//			return err
+//		}
+//		return writer.Write(ctx, event)
+//	})
+type Writer struct {
+	storage Storage
+	db      *sql.DB
+}
+
+func NewWriter(storage Storage, db *sql.DB) *Writer {
+	return &Writer{storage: storage, db: db}
+}
+
+// Write writes events with the transaction of the context. It returns ErrNoTransaction outside of a transaction,
+// events written without the business transaction may be published for changes that were rolled back.
+func (w *Writer) Write(ctx context.Context, events ...*Event) error {
+	tx := infratxmanager.SQLTx(ctx, w.db)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	return w.storage.Write(ctx, tx, events...)
+}
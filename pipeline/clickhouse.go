@@ -0,0 +1,25 @@
+package infrapipeline
+
+import (
+	"context"
+	"database/sql"
+
+	infraclickhouse "github.com/pushwoosh/infra/clickhouse"
+)
+
+// ClickHouseSink inserts batches of values with a single insert, args returns values
+// of the listed columns for a value:
+//
+//	sink := infrapipeline.ClickHouseSink(db, "INSERT INTO events (ts, user_id, name)", func(e *Event) []any {
+//		return []any{e.Time, e.UserID, e.Name}
+//	})
+func ClickHouseSink[T any](db *sql.DB, query string, args func(value T) []any) Sink[T] {
+	return SinkFunc[T](func(ctx context.Context, values []T) error {
+		rows := make([][]any, len(values))
+		for i, value := range values {
+			rows[i] = args(value)
+		}
+
+		return infraclickhouse.Insert(ctx, db, query, rows)
+	})
+}
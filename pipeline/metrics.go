@@ -0,0 +1,34 @@
+package infrapipeline
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	ItemsCounter  *prometheus.CounterVec
+	StageDuration *prometheus.HistogramVec
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.ItemsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pipeline_items_total",
+			Help: "Number of items processed by pipeline stages by result: success, skipped or error",
+		}, []string{"pipeline", "stage", "result"})
+
+		metrics.StageDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "pipeline_stage_duration_seconds",
+			Help:    "Duration of stage function and sink write calls",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"pipeline", "stage"})
+
+		prometheus.MustRegister(
+			metrics.ItemsCounter,
+			metrics.StageDuration,
+		)
+	})
+}
@@ -0,0 +1,45 @@
+package infrapipeline
+
+import (
+	"context"
+
+	infralog "github.com/pushwoosh/infra/log"
+	"go.uber.org/zap"
+)
+
+const defaultBuffer = 100
+
+type Option interface {
+	apply(p *Pipeline)
+}
+
+type optionBuffer int
+
+func (opt optionBuffer) apply(p *Pipeline) {
+	p.buffer = int(opt)
+}
+
+// WithBuffer sets the capacity of channels between stages. Default is 100
+func WithBuffer(size int) Option {
+	return optionBuffer(size)
+}
+
+type optionErrorHandler ErrorHandler
+
+func (opt optionErrorHandler) apply(p *Pipeline) {
+	p.onError = ErrorHandler(opt)
+}
+
+// WithErrorHandler sets the handler of stage and sink errors. By default errors are logged and passed
+// to the source as is
+func WithErrorHandler(fn ErrorHandler) Option {
+	return optionErrorHandler(fn)
+}
+
+func logError(name string) ErrorHandler {
+	return func(ctx context.Context, stage string, err error) error {
+		infralog.ErrorCtx(ctx, "pipeline stage failed",
+			zap.String("pipeline", name), zap.String("stage", stage), zap.Error(err))
+		return err
+	}
+}
@@ -0,0 +1,314 @@
+package infrapipeline
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	infrabatch "github.com/pushwoosh/infra/batch"
+)
+
+// ErrSkip is returned by stage functions to drop an item without an error, e.g. to filter items out
+var ErrSkip = errors.New("skip item")
+
+// EmitFunc passes a value of the source to the pipeline. It blocks while the first stage is busy.
+// done is called once the value is written by the sink, dropped or failed
+type EmitFunc[T any] func(ctx context.Context, value T, done func(err error))
+
+// Source produces values until ctx is canceled. After cancellation it should stop producing
+// and return once the emitted values are done
+type Source[T any] interface {
+	Run(ctx context.Context, emit EmitFunc[T]) error
+}
+
+// Sink writes batches of values
+type Sink[T any] interface {
+	Write(ctx context.Context, values []T) error
+}
+
+// SinkFunc is a function implementing Sink
+type SinkFunc[T any] func(ctx context.Context, values []T) error
+
+func (fn SinkFunc[T]) Write(ctx context.Context, values []T) error {
+	return fn(ctx, values)
+}
+
+// Pipeline moves values from a source through stages to a sink over bounded channels:
+//
+//	p := infrapipeline.New("events")
+//	messages := infrapipeline.From(p, infrapipeline.RabbitSource(consumer))
+//	events := infrapipeline.Map(messages, "decode", 4, decodeEvent)
+//	err := infrapipeline.To(events, infrapipeline.ClickHouseSink(db, insertQuery, eventArgs), batchCfg)
+//	...
+//	err = p.Run(ctx)
+//
+// Run drains the pipeline gracefully on ctx cancellation: the source stops producing,
+// values in flight pass all stages and the sink is flushed. Stage functions get the context
+// of the value that is not canceled on shutdown.
+type Pipeline struct {
+	name    string
+	buffer  int
+	onError ErrorHandler
+
+	mu        sync.Mutex
+	runners   []func(ctx context.Context) error
+	hasSource bool
+	open      int
+	running   bool
+}
+
+// ErrorHandler routes stage and sink errors. The returned error is passed to the done callback
+// of the source, e.g. the rabbit source requeues messages on errors and drops malformed ones
+type ErrorHandler func(ctx context.Context, stage string, err error) error
+
+type item[T any] struct {
+	ctx   context.Context
+	value T
+	done  func(err error)
+}
+
+// Stream is an output of a source or a stage. Every stream should be consumed by a stage or a sink
+type Stream[T any] struct {
+	p        *Pipeline
+	name     string
+	ch       chan item[T]
+	consumed bool
+}
+
+func New(name string, opts ...Option) *Pipeline {
+	initMetrics()
+
+	p := &Pipeline{
+		name:    name,
+		buffer:  defaultBuffer,
+		onError: logError(name),
+	}
+
+	for _, opt := range opts {
+		opt.apply(p)
+	}
+
+	return p
+}
+
+// From adds the source of the pipeline
+func From[T any](p *Pipeline, source Source[T]) *Stream[T] {
+	out := newStream[T](p, "source")
+
+	p.add(func(ctx context.Context) error {
+		defer close(out.ch)
+
+		err := source.Run(ctx, func(ctx context.Context, value T, done func(err error)) {
+			if done == nil {
+				done = func(error) {}
+			}
+			out.ch <- item[T]{ctx: context.WithoutCancel(ctx), value: value, done: done}
+		})
+		if errors.Is(err, context.Canceled) {
+			return nil
+		}
+
+		return errors.Wrap(err, "source")
+	})
+
+	p.mu.Lock()
+	p.hasSource = true
+	p.mu.Unlock()
+
+	return out
+}
+
+// Map adds a stage processing values of the stream with concurrency goroutines.
+// Values may be reordered when concurrency is greater than 1. Return ErrSkip to drop a value
+func Map[In, Out any](in *Stream[In], name string, concurrency int, fn func(ctx context.Context, value In) (Out, error)) *Stream[Out] {
+	p := in.p
+	in.consume()
+	out := newStream[Out](p, name)
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	p.add(func(context.Context) error {
+		defer close(out.ch)
+
+		var wg sync.WaitGroup
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				for it := range in.ch {
+					start := time.Now()
+					result, err := call(it.ctx, it.value, fn)
+					metrics.StageDuration.WithLabelValues(p.name, name).Observe(time.Since(start).Seconds())
+					if err != nil {
+						p.complete(it.ctx, name, it.done, err)
+						continue
+					}
+					metrics.ItemsCounter.WithLabelValues(p.name, name, "success").Inc()
+
+					out.ch <- item[Out]{ctx: it.ctx, value: result, done: it.done}
+				}
+			}()
+		}
+		wg.Wait()
+
+		return nil
+	})
+
+	return out
+}
+
+// To adds the sink consuming the stream. Values are written in batches of cfg,
+// done callbacks of values are called after their batch is written or dropped after retries
+func To[T any](in *Stream[T], sink Sink[T], cfg *infrabatch.Config) error {
+	p := in.p
+
+	flush := func(ctx context.Context, items []item[T]) error {
+		values := make([]T, len(items))
+		for i, it := range items {
+			values[i] = it.value
+		}
+
+		start := time.Now()
+		err := sink.Write(ctx, values)
+		metrics.StageDuration.WithLabelValues(p.name, "sink").Observe(time.Since(start).Seconds())
+		if err != nil {
+			return err
+		}
+
+		for _, it := range items {
+			p.complete(it.ctx, "sink", it.done, nil)
+		}
+		return nil
+	}
+
+	batcher, err := infrabatch.New(p.name+"_sink", cfg, flush,
+		infrabatch.WithOnError(func(_ context.Context, items []item[T], err error) {
+			for _, it := range items {
+				p.complete(it.ctx, "sink", it.done, err)
+			}
+		}))
+	if err != nil {
+		return errors.Wrap(err, "sink")
+	}
+
+	in.consume()
+	p.add(func(ctx context.Context) error {
+		if err := batcher.Start(ctx); err != nil {
+			return err
+		}
+
+		for it := range in.ch {
+			_ = batcher.Add(context.Background(), it)
+		}
+
+		return batcher.Stop(context.Background())
+	})
+
+	return nil
+}
+
+// Run runs the pipeline until ctx is canceled and all values in flight are done.
+// It returns the first error of the source or the sink
+func (p *Pipeline) Run(ctx context.Context) error {
+	p.mu.Lock()
+	if p.running {
+		p.mu.Unlock()
+		return errors.New("pipeline is already running")
+	}
+	if !p.hasSource {
+		p.mu.Unlock()
+		return errors.New("pipeline has no source")
+	}
+	if p.open > 0 {
+		p.mu.Unlock()
+		return errors.Errorf("%d streams are not consumed by a stage or a sink", p.open)
+	}
+	p.running = true
+	runners := p.runners
+	p.mu.Unlock()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	for _, runner := range runners {
+		wg.Add(1)
+		go func(runner func(ctx context.Context) error) {
+			defer wg.Done()
+
+			if err := runner(ctx); err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+				})
+			}
+		}(runner)
+	}
+	wg.Wait()
+
+	return firstErr
+}
+
+func (p *Pipeline) add(runner func(ctx context.Context) error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.runners = append(p.runners, runner)
+}
+
+// call runs the stage function and converts its panic into an error
+func call[In, Out any](ctx context.Context, value In, fn func(ctx context.Context, value In) (Out, error)) (result Out, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = errors.Errorf("stage panic: %v", rec)
+		}
+	}()
+
+	return fn(ctx, value)
+}
+
+// complete finishes processing of the value with the stage result
+func (p *Pipeline) complete(ctx context.Context, stage string, done func(err error), err error) {
+	switch {
+	case err == nil:
+		metrics.ItemsCounter.WithLabelValues(p.name, stage, "success").Inc()
+	case errors.Is(err, ErrSkip):
+		metrics.ItemsCounter.WithLabelValues(p.name, stage, "skipped").Inc()
+		err = nil
+	default:
+		metrics.ItemsCounter.WithLabelValues(p.name, stage, "error").Inc()
+		err = p.onError(ctx, stage, err)
+	}
+
+	done(err)
+}
+
+func newStream[T any](p *Pipeline, name string) *Stream[T] {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.open++
+
+	return &Stream[T]{
+		p:    p,
+		name: name,
+		ch:   make(chan item[T], p.buffer),
+	}
+}
+
+func (s *Stream[T]) consume() {
+	s.p.mu.Lock()
+	defer s.p.mu.Unlock()
+
+	if s.consumed {
+		panic(fmt.Sprintf("pipeline %s: stream %s is already consumed", s.p.name, s.name))
+	}
+
+	s.consumed = true
+	s.p.open--
+}
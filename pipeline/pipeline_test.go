@@ -0,0 +1,99 @@
+package infrapipeline
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	infrabatch "github.com/pushwoosh/infra/batch"
+)
+
+type sliceSource struct {
+	values []string
+
+	mu      sync.Mutex
+	results map[string]error
+	pending sync.WaitGroup
+}
+
+func (s *sliceSource) Run(ctx context.Context, emit EmitFunc[string]) error {
+	for _, value := range s.values {
+		s.pending.Add(1)
+		emit(ctx, value, func(err error) {
+			s.mu.Lock()
+			s.results[value] = err
+			s.mu.Unlock()
+			s.pending.Done()
+		})
+	}
+
+	<-ctx.Done()
+	s.pending.Wait()
+	return ctx.Err()
+}
+
+func TestPipeline(t *testing.T) {
+	source := &sliceSource{values: []string{"1", "2", "x", "4"}, results: map[string]error{}}
+
+	var (
+		mu      sync.Mutex
+		written []int
+	)
+	sink := SinkFunc[int](func(_ context.Context, values []int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		written = append(written, values...)
+		return nil
+	})
+
+	p := New("test")
+	numbers := Map(From(p, source), "parse", 2, func(_ context.Context, value string) (int, error) {
+		return strconv.Atoi(value)
+	})
+	odd := Map(numbers, "filter", 1, func(_ context.Context, value int) (int, error) {
+		if value%2 == 0 {
+			return 0, ErrSkip
+		}
+		return value, nil
+	})
+	if err := To(odd, sink, &infrabatch.Config{MaxItems: 10, MaxAge: 10 * time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	if err := p.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(written) != 1 || written[0] != 1 {
+		t.Fatalf("unexpected written values %v", written)
+	}
+
+	for value, expectErr := range map[string]bool{"1": false, "2": false, "x": true, "4": false} {
+		err, ok := source.results[value]
+		if !ok {
+			t.Fatalf("value %s is not done", value)
+		}
+		if (err != nil) != expectErr {
+			t.Fatalf("value %s: unexpected result %v", value, err)
+		}
+	}
+}
+
+func TestPipelineNotConsumed(t *testing.T) {
+	p := New("test_not_consumed")
+	Map(From(p, &sliceSource{}), "parse", 1, func(_ context.Context, value string) (string, error) {
+		return value, nil
+	})
+
+	if err := p.Run(context.Background()); err == nil {
+		t.Fatal("expected error for stream without sink")
+	}
+}
@@ -0,0 +1,95 @@
+package infrapipeline
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	infrarabbit "github.com/pushwoosh/infra/rabbit"
+	infrarequestid "github.com/pushwoosh/infra/requestid"
+	infratenancy "github.com/pushwoosh/infra/tenancy"
+	"go.uber.org/zap"
+)
+
+type rabbitSource struct {
+	consumer *infrarabbit.Consumer
+}
+
+// RabbitSource emits messages of the consumer. Messages are acked when they are written by the sink
+// or skipped, requeued on errors and dropped on errors wrapping infrarabbit.ErrMalformed.
+// On shutdown the consumer is closed after all its messages are done.
+// The value context has the request id and the tenant id from message headers
+func RabbitSource(consumer *infrarabbit.Consumer) Source[*infrarabbit.Message] {
+	return &rabbitSource{consumer: consumer}
+}
+
+func (s *rabbitSource) Run(ctx context.Context, emit EmitFunc[*infrarabbit.Message]) error {
+	var (
+		wg       sync.WaitGroup
+		closeErr error
+	)
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		select {
+		case <-ctx.Done():
+		case <-stop:
+		}
+		// the consumer channel is closed after prefetched messages are acked,
+		// so they are emitted until then
+		closeErr = s.consumer.Close()
+	}()
+
+	for msg := range s.consumer.Consume() {
+		msgCtx := infrarequestid.ExtractHeaders(context.Background(), msg.Headers())
+		msgCtx = infratenancy.ExtractHeaders(msgCtx, msg.Headers())
+
+		emit(msgCtx, msg, func(err error) {
+			switch {
+			case err == nil:
+				_ = msg.Ack()
+			case errors.Is(err, infrarabbit.ErrMalformed):
+				infralog.ErrorCtx(msgCtx, "pipeline: dropping message",
+					zap.String("routing_key", msg.RoutingKey()),
+					zap.Error(err))
+				_ = msg.Ack()
+			default:
+				_ = msg.Nack()
+			}
+		})
+	}
+
+	if ctx.Err() == nil {
+		return errors.New("rabbit consumer is closed")
+	}
+
+	wg.Wait()
+	return closeErr
+}
+
+// Producer publishes rabbit messages, e.g. infrarabbit.Producer or its encrypting and signing wrappers
+type Producer = infrarabbit.Publisher
+
+// RabbitSink publishes values encoded with encode
+func RabbitSink[T any](producer Producer, encode func(value T) (*infrarabbit.ProducerMessage, error)) Sink[T] {
+	return SinkFunc[T](func(ctx context.Context, values []T) error {
+		for _, value := range values {
+			msg, err := encode(value)
+			if err != nil {
+				return errors.Wrap(err, "unable to encode message")
+			}
+
+			if err = producer.Produce(ctx, msg); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
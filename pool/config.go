@@ -0,0 +1,26 @@
+package infrapool
+
+import "github.com/pkg/errors"
+
+type Config struct {
+	// Workers is the number of tasks executed concurrently
+	Workers int `mapstructure:"workers"`
+	// QueueSize is the number of tasks waiting for a free worker. Submit blocks when the queue is full.
+	QueueSize int `mapstructure:"queue_size"`
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if c.Workers <= 0 {
+		return errors.New("workers must be greater than zero")
+	}
+
+	if c.QueueSize < 0 {
+		return errors.New("queue size must be greater or equal to zero")
+	}
+
+	return nil
+}
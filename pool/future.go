@@ -0,0 +1,50 @@
+package infrapool
+
+import (
+	"context"
+)
+
+// Future is a result of a task submitted with Submit
+type Future[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+// Done is closed when the task is finished
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait waits for the task result
+func (f *Future[T]) Wait(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// Submit executes fn in the pool and returns a future of its result:
+//
+//	f, err := infrapool.Submit(ctx, p, func(ctx context.Context) (*User, error) { return repo.Get(ctx, id) })
+//	user, err := f.Wait(ctx)
+func Submit[T any](ctx context.Context, p *Pool, fn func(ctx context.Context) (T, error)) (*Future[T], error) {
+	f := &Future[T]{done: make(chan struct{})}
+
+	err := p.submit(ctx, func(ctx context.Context) error {
+		var err error
+		f.value, err = fn(ctx)
+		return err
+	}, func(err error) {
+		f.err = err
+		close(f.done)
+	}, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
@@ -0,0 +1,63 @@
+package infrapool
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	QueueDepthGauge       *prometheus.GaugeVec
+	BusyWorkersGauge      *prometheus.GaugeVec
+	WorkersGauge          *prometheus.GaugeVec
+	WaitDurationHistogram *prometheus.HistogramVec
+	TaskDurationHistogram *prometheus.HistogramVec
+	TaskErrorsCounter     *prometheus.CounterVec
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.QueueDepthGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "worker_pool_queue_depth",
+			Help: "Number of tasks waiting for a free worker",
+		}, []string{"pool"})
+
+		metrics.BusyWorkersGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "worker_pool_busy_workers",
+			Help: "Number of workers executing a task",
+		}, []string{"pool"})
+
+		metrics.WorkersGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "worker_pool_workers",
+			Help: "Number of pool workers",
+		}, []string{"pool"})
+
+		metrics.WaitDurationHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "worker_pool_task_wait_seconds",
+			Help:    "Time tasks spend in the queue",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"pool"})
+
+		metrics.TaskDurationHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "worker_pool_task_duration_seconds",
+			Help:    "Task execution duration",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"pool"})
+
+		metrics.TaskErrorsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "worker_pool_task_errors_total",
+			Help: "Number of failed and panicked tasks",
+		}, []string{"pool"})
+
+		prometheus.MustRegister(
+			metrics.QueueDepthGauge,
+			metrics.BusyWorkersGauge,
+			metrics.WorkersGauge,
+			metrics.WaitDurationHistogram,
+			metrics.TaskDurationHistogram,
+			metrics.TaskErrorsCounter,
+		)
+	})
+}
@@ -0,0 +1,268 @@
+package infrapool
+
+import (
+	"context"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrPoolClosed = errors.New("pool is closed")
+	ErrQueueFull  = errors.New("pool queue is full")
+	ErrPanic      = errors.New("task panic")
+)
+
+// Task is a unit of work executed by the pool.
+// ctx is the context passed to Submit.
+type Task func(ctx context.Context) error
+
+type task struct {
+	ctx      context.Context
+	fn       Task
+	done     func(err error)
+	enqueued time.Time
+}
+
+// Pool is a bounded worker pool. Panics of tasks are recovered and returned as ErrPanic.
+type Pool struct {
+	name string
+
+	queue chan *task
+	quit  chan struct{}
+	done  chan struct{}
+
+	mu        sync.RWMutex
+	workers   int
+	closed    bool
+	closeOnce sync.Once
+	workerWg  sync.WaitGroup
+
+	queueDepth  prometheus.Gauge
+	busyWorkers prometheus.Gauge
+	workerCount prometheus.Gauge
+	waitTime    prometheus.Observer
+	taskTime    prometheus.Observer
+	taskErrors  prometheus.Counter
+}
+
+var _ infraoperator.Stopper = (*Pool)(nil)
+
+// New creates a pool and starts its workers. name is used as a metrics label.
+func New(name string, cfg *Config) (*Pool, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	initMetrics()
+
+	p := &Pool{
+		name:  name,
+		queue: make(chan *task, cfg.QueueSize),
+		quit:  make(chan struct{}),
+		done:  make(chan struct{}),
+
+		queueDepth:  metrics.QueueDepthGauge.WithLabelValues(name),
+		busyWorkers: metrics.BusyWorkersGauge.WithLabelValues(name),
+		workerCount: metrics.WorkersGauge.WithLabelValues(name),
+		waitTime:    metrics.WaitDurationHistogram.WithLabelValues(name),
+		taskTime:    metrics.TaskDurationHistogram.WithLabelValues(name),
+		taskErrors:  metrics.TaskErrorsCounter.WithLabelValues(name),
+	}
+
+	p.Resize(cfg.Workers)
+
+	return p, nil
+}
+
+// Submit puts the task into the queue. Blocks while the queue is full.
+// Returned channel receives the task result.
+func (p *Pool) Submit(ctx context.Context, fn Task) (<-chan error, error) {
+	result := make(chan error, 1)
+	err := p.submit(ctx, fn, func(err error) { result <- err }, true)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// TrySubmit is like Submit, but returns ErrQueueFull instead of blocking
+func (p *Pool) TrySubmit(ctx context.Context, fn Task) (<-chan error, error) {
+	result := make(chan error, 1)
+	err := p.submit(ctx, fn, func(err error) { result <- err }, false)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Go puts the task into the queue without waiting for its result.
+// Task errors are only logged.
+func (p *Pool) Go(ctx context.Context, fn Task) error {
+	return p.submit(ctx, fn, func(err error) {
+		if err != nil {
+			infralog.ErrorCtx(ctx, "pool task error", zap.String("pool", p.name), zap.Error(err))
+		}
+	}, true)
+}
+
+func (p *Pool) submit(ctx context.Context, fn Task, done func(err error), wait bool) error {
+	t := &task{ctx: ctx, fn: fn, done: done, enqueued: time.Now()}
+
+	// queue is closed under write lock, so it's safe to send while holding read lock
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return ErrPoolClosed
+	}
+
+	if !wait {
+		select {
+		case p.queue <- t:
+			p.queueDepth.Inc()
+			return nil
+		default:
+			return ErrQueueFull
+		}
+	}
+
+	select {
+	case p.queue <- t:
+		p.queueDepth.Inc()
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.done:
+		return ErrPoolClosed
+	}
+}
+
+// Resize changes the number of workers. Extra workers exit after finishing their current task.
+func (p *Pool) Resize(workers int) {
+	if workers <= 0 {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return
+	}
+
+	for ; p.workers < workers; p.workers++ {
+		p.workerWg.Add(1)
+		go p.worker()
+	}
+
+	if p.workers > workers {
+		retire := p.workers - workers
+		p.workers = workers
+		go func() {
+			for i := 0; i < retire; i++ {
+				select {
+				case p.quit <- struct{}{}:
+				case <-p.done:
+					return
+				}
+			}
+		}()
+	}
+
+	p.workerCount.Set(float64(p.workers))
+}
+
+// Workers returns the current number of workers
+func (p *Pool) Workers() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.workers
+}
+
+// Stop stops accepting new tasks and waits until all queued tasks are executed.
+// Returns ctx error if it expires earlier, workers finish the queue in background in that case.
+func (p *Pool) Stop(ctx context.Context) error {
+	// unblock waiting submitters first, they hold read lock
+	p.closeOnce.Do(func() { close(p.done) })
+
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		p.workerWg.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		p.workerCount.Set(0)
+		return nil
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "pool drain")
+	}
+}
+
+func (p *Pool) worker() {
+	defer p.workerWg.Done()
+
+	for {
+		select {
+		case t, ok := <-p.queue:
+			if !ok {
+				return
+			}
+			p.run(t)
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+func (p *Pool) run(t *task) {
+	p.queueDepth.Dec()
+	p.busyWorkers.Inc()
+	defer p.busyWorkers.Dec()
+
+	start := time.Now()
+	p.waitTime.Observe(start.Sub(t.enqueued).Seconds())
+
+	err := p.exec(t)
+	p.taskTime.Observe(time.Since(start).Seconds())
+	if err != nil {
+		p.taskErrors.Inc()
+	}
+
+	t.done(err)
+}
+
+func (p *Pool) exec(t *task) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			infralog.ErrorCtx(t.ctx, "pool task panic",
+				zap.String("pool", p.name),
+				zap.Any("panic", rec),
+				zap.ByteString("panic_stack", debug.Stack()))
+			err = errors.Wrapf(ErrPanic, "%v", rec)
+		}
+	}()
+
+	// task was canceled while waiting in the queue
+	if err = t.ctx.Err(); err != nil {
+		return err
+	}
+
+	return t.fn(t.ctx)
+}
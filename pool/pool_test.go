@@ -0,0 +1,92 @@
+package infrapool
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	infratestleak "github.com/pushwoosh/infra/test/leak"
+)
+
+func TestMain(m *testing.M) {
+	infratestleak.Main(m)
+}
+
+func TestPool(t *testing.T) {
+	p, err := New("test", &Config{Workers: 2, QueueSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var executed atomic.Int32
+	for i := 0; i < 10; i++ {
+		if err = p.Go(context.Background(), func(ctx context.Context) error {
+			time.Sleep(time.Millisecond)
+			executed.Add(1)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := Submit(context.Background(), p, func(ctx context.Context) (int, error) { return 42, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := f.Wait(context.Background()); v != 42 || err != nil {
+		t.Errorf("unexpected future result: %d, %v", v, err)
+	}
+
+	if err = p.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if executed.Load() != 10 {
+		t.Errorf("expected all tasks to be executed before stop, got %d", executed.Load())
+	}
+
+	if _, err = p.Submit(context.Background(), func(ctx context.Context) error { return nil }); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("expected ErrPoolClosed, got %v", err)
+	}
+}
+
+func TestPool_panic(t *testing.T) {
+	p, err := New("test_panic", &Config{Workers: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = p.Stop(context.Background()) }()
+
+	result, err := p.Submit(context.Background(), func(ctx context.Context) error { panic("boom") })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = <-result; !errors.Is(err, ErrPanic) {
+		t.Errorf("expected ErrPanic, got %v", err)
+	}
+
+	// worker must survive the panic
+	result, _ = p.Submit(context.Background(), func(ctx context.Context) error { return nil })
+	if err = <-result; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestPool_Resize(t *testing.T) {
+	p, err := New("test_resize", &Config{Workers: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = p.Stop(context.Background()) }()
+
+	p.Resize(1)
+	if p.Workers() != 1 {
+		t.Errorf("expected 1 worker, got %d", p.Workers())
+	}
+
+	p.Resize(3)
+	if p.Workers() != 3 {
+		t.Errorf("expected 3 workers, got %d", p.Workers())
+	}
+}
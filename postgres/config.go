@@ -6,8 +6,16 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	infraconfig "github.com/pushwoosh/infra/config"
 )
 
+func init() {
+	// services start in dev without their dependencies running
+	infraconfig.RegisterProfileDefaults(infraconfig.ProfileDev, func(c *ConnectionConfig) {
+		c.Lazy = true
+	})
+}
+
 type ConnectionsConfig map[string]*ConnectionConfig
 
 type ConnectionConfig struct {
@@ -33,6 +41,33 @@ type ConnectionConfig struct {
 	// Extended protocol uses prepared queries.
 	// prepared queries are not compatible with PGBouncer in any modes other than session.
 	PreferSimpleProtocol bool `mapstructure:"prefer_simple_protocol"`
+
+	// Minimum number of physical connections. Used by PoolContainer only
+	MinConnections int `mapstructure:"min_connections"`
+
+	// Period between idle connections health checks. Used by PoolContainer only
+	HealthCheckPeriod time.Duration `mapstructure:"health_check_period"`
+
+	// Query log config. Used by PoolContainer only
+	QueryLog *QueryLoggingConfig `mapstructure:"query_log"`
+
+	// Whether to create OpenTelemetry spans of queries. Used by PoolContainer only
+	Tracing bool `mapstructure:"tracing"`
+
+	// Lazy connects on the first use instead of Connect, so a service starts without the database running.
+	// optional, default: true in the dev profile
+	Lazy bool `mapstructure:"lazy"`
+}
+
+type QueryLoggingConfig struct {
+	// Whether to log all queries
+	All bool `mapstructure:"all"`
+
+	// Whether to log slow queries
+	Slow bool `mapstructure:"slow"`
+
+	// Queries that were executed longer than that time will appear in slow log
+	SlowThreshold time.Duration `mapstructure:"slow_threshold"`
 }
 
 type Credentials struct {
@@ -82,6 +117,28 @@ func (c *ConnectionConfig) Validate() error {
 		return errors.Wrap(err, "credentials")
 	}
 
+	if c.MinConnections < 0 {
+		return errors.New("min_connections must be greater than or equal to zero")
+	}
+
+	if c.QueryLog != nil {
+		if err := c.QueryLog.Validate(); err != nil {
+			return errors.Wrap(err, "query_log")
+		}
+	}
+
+	return nil
+}
+
+func (c *QueryLoggingConfig) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if c.Slow && c.SlowThreshold == 0 {
+		return errors.New("slow threshold must be greater than zero")
+	}
+
 	return nil
 }
 
@@ -0,0 +1,161 @@
+package infrapostgres
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	infraoperator "github.com/pushwoosh/infra/operator"
+)
+
+// PoolContainer is a simple container for holding named pgxpool connections.
+// Unlike Container, it uses native pgx interface instead of database/sql.
+type PoolContainer struct {
+	mu         *sync.RWMutex
+	cfg        map[string]ConnectionConfig
+	pools      map[string]*pgxpool.Pool
+	collectors map[string]*poolStatsCollector
+}
+
+var (
+	_ infraoperator.Stopper = (*PoolContainer)(nil)
+	_ infraoperator.Checker = (*PoolContainer)(nil)
+)
+
+func NewPoolContainer() *PoolContainer {
+	return &PoolContainer{
+		mu:         &sync.RWMutex{},
+		cfg:        make(map[string]ConnectionConfig),
+		pools:      make(map[string]*pgxpool.Pool),
+		collectors: make(map[string]*poolStatsCollector),
+	}
+}
+
+// Connect creates a new named postgres connection pool
+func (cont *PoolContainer) Connect(name string, cfg *ConnectionConfig) error {
+	poolCfg, err := pgxpool.ParseConfig(cfg.PGXConnString())
+	if err != nil {
+		return errors.Wrap(err, "pgxpool.ParseConfig")
+	}
+
+	if cfg.MaxConnections > 0 {
+		poolCfg.MaxConns = int32(cfg.MaxConnections)
+	}
+	if cfg.MinConnections > 0 {
+		poolCfg.MinConns = int32(cfg.MinConnections)
+	}
+	if cfg.MaxConnectionLifetime > 0 {
+		poolCfg.MaxConnLifetime = cfg.MaxConnectionLifetime
+	}
+	if cfg.MaxConnectionIdleTime > 0 {
+		poolCfg.MaxConnIdleTime = cfg.MaxConnectionIdleTime
+	}
+	if cfg.HealthCheckPeriod > 0 {
+		poolCfg.HealthCheckPeriod = cfg.HealthCheckPeriod
+	}
+
+	var loggers multiLogger
+	if cfg.QueryLog != nil && (cfg.QueryLog.All || cfg.QueryLog.Slow) {
+		loggers = append(loggers, newQueryLogger(name, cfg.QueryLog))
+	}
+	if cfg.Tracing {
+		loggers = append(loggers, newQueryTracer(name, poolCfg.ConnConfig.Database))
+	}
+	if len(loggers) > 0 {
+		poolCfg.ConnConfig.Logger = loggers
+		poolCfg.ConnConfig.LogLevel = pgx.LogLevelInfo
+	}
+
+	poolCfg.LazyConnect = cfg.Lazy
+
+	pool, err := pgxpool.ConnectConfig(context.Background(), poolCfg)
+	if err != nil {
+		return errors.Wrap(err, "pgxpool.ConnectConfig")
+	}
+
+	if !cfg.Lazy {
+		if err = pool.Ping(context.Background()); err != nil {
+			pool.Close()
+			return errors.Wrap(err, "pool.Ping")
+		}
+	}
+
+	// replace existing connection with the same name
+	cont.Remove(name)
+
+	collector := newPoolStatsCollector(name, pool)
+	prometheus.MustRegister(collector)
+
+	cont.mu.Lock()
+	defer cont.mu.Unlock()
+
+	cont.pools[name] = pool
+	cont.cfg[name] = *cfg
+	cont.collectors[name] = collector
+
+	return nil
+}
+
+// Get gets connection pool from a container
+func (cont *PoolContainer) Get(name string) *pgxpool.Pool {
+	cont.mu.RLock()
+	defer cont.mu.RUnlock()
+
+	return cont.pools[name]
+}
+
+// Remove closes named connection pool and removes it from the container
+func (cont *PoolContainer) Remove(name string) {
+	cont.mu.Lock()
+	pool := cont.pools[name]
+	collector := cont.collectors[name]
+	delete(cont.pools, name)
+	delete(cont.cfg, name)
+	delete(cont.collectors, name)
+	cont.mu.Unlock()
+
+	if collector != nil {
+		prometheus.Unregister(collector)
+	}
+
+	if pool != nil {
+		pool.Close()
+	}
+}
+
+// Check pings all connection pools in the container
+func (cont *PoolContainer) Check(ctx context.Context) error {
+	cont.mu.RLock()
+	defer cont.mu.RUnlock()
+
+	for name, pool := range cont.pools {
+		if err := pool.Ping(ctx); err != nil {
+			return errors.Wrap(err, name)
+		}
+	}
+
+	return nil
+}
+
+// Stop closes all connection pools in the container
+func (cont *PoolContainer) Stop(_ context.Context) error {
+	cont.Close()
+	return nil
+}
+
+// Close closes all connection pools in the container
+func (cont *PoolContainer) Close() {
+	cont.mu.RLock()
+	names := make([]string, 0, len(cont.pools))
+	for name := range cont.pools {
+		names = append(names, name)
+	}
+	cont.mu.RUnlock()
+
+	for _, name := range names {
+		cont.Remove(name)
+	}
+}
@@ -0,0 +1,71 @@
+package infrapostgres
+
+import (
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const poolMetricsNamespace = "go_pgxpool"
+
+// poolStatsCollector exports pgxpool statistics to prometheus.
+// Metrics are labeled with the connection name in the same way as sqlstats does.
+type poolStatsCollector struct {
+	pool *pgxpool.Pool
+
+	acquireCount         *prometheus.Desc
+	acquireDuration      *prometheus.Desc
+	acquiredConns        *prometheus.Desc
+	canceledAcquireCount *prometheus.Desc
+	constructingConns    *prometheus.Desc
+	emptyAcquireCount    *prometheus.Desc
+	idleConns            *prometheus.Desc
+	maxConns             *prometheus.Desc
+	totalConns           *prometheus.Desc
+}
+
+func newPoolStatsCollector(name string, pool *pgxpool.Pool) *poolStatsCollector {
+	labels := prometheus.Labels{"db_name": name}
+	desc := func(metric, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(poolMetricsNamespace, "", metric), help, nil, labels)
+	}
+
+	return &poolStatsCollector{
+		pool: pool,
+
+		acquireCount:         desc("acquire_count_total", "The cumulative count of successful acquires from the pool."),
+		acquireDuration:      desc("acquire_duration_seconds_total", "The total duration of all successful acquires from the pool."),
+		acquiredConns:        desc("acquired_connections", "The number of currently acquired connections in the pool."),
+		canceledAcquireCount: desc("canceled_acquire_count_total", "The cumulative count of acquires from the pool that were canceled by a context."),
+		constructingConns:    desc("constructing_connections", "The number of connections with construction in progress in the pool."),
+		emptyAcquireCount:    desc("empty_acquire_count_total", "The cumulative count of successful acquires that waited for a resource to be released or constructed."),
+		idleConns:            desc("idle_connections", "The number of currently idle connections in the pool."),
+		maxConns:             desc("max_connections", "The maximum size of the pool."),
+		totalConns:           desc("total_connections", "The total number of resources currently in the pool."),
+	}
+}
+
+func (c *poolStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquireCount
+	ch <- c.acquireDuration
+	ch <- c.acquiredConns
+	ch <- c.canceledAcquireCount
+	ch <- c.constructingConns
+	ch <- c.emptyAcquireCount
+	ch <- c.idleConns
+	ch <- c.maxConns
+	ch <- c.totalConns
+}
+
+func (c *poolStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+
+	ch <- prometheus.MustNewConstMetric(c.acquireCount, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.canceledAcquireCount, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.constructingConns, prometheus.GaugeValue, float64(stat.ConstructingConns()))
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquireCount, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+}
@@ -43,9 +43,10 @@ func (cont *Container) Connect(name string, cfg *ConnectionConfig) error {
 		return errors.Wrapf(err, "sql.Open")
 	}
 
-	err = conn.Ping()
-	if err != nil {
-		return errors.Wrapf(err, "conn.Ping")
+	if !cfg.Lazy {
+		if err = conn.Ping(); err != nil {
+			return errors.Wrapf(err, "conn.Ping")
+		}
 	}
 
 	conn.SetMaxOpenConns(cfg.MaxConnections)
@@ -0,0 +1,55 @@
+package infrapostgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	infralog "github.com/pushwoosh/infra/log"
+	"go.uber.org/zap"
+)
+
+// queryLogger is a pgx logger that writes executed queries to infralog
+type queryLogger struct {
+	name string
+	cfg  *QueryLoggingConfig
+}
+
+func newQueryLogger(name string, cfg *QueryLoggingConfig) *queryLogger {
+	return &queryLogger{
+		name: name,
+		cfg:  cfg,
+	}
+}
+
+func (l *queryLogger) Log(ctx context.Context, _ pgx.LogLevel, msg string, data map[string]interface{}) {
+	duration, ok := data["time"].(time.Duration)
+	if !ok {
+		// not a query log record
+		return
+	}
+
+	if !l.cfg.All && !(l.cfg.Slow && duration > l.cfg.SlowThreshold) {
+		return
+	}
+
+	fields := []zap.Field{
+		zap.String("database", l.name),
+		zap.String("command_name", msg),
+		zap.Duration("duration", duration),
+	}
+	if query, ok := data["sql"].(string); ok {
+		fields = append(fields, zap.String("query", query))
+	}
+	if args, ok := data["args"]; ok {
+		fields = append(fields, zap.String("args", fmt.Sprint(args)))
+	}
+
+	if err, ok := data["err"].(error); ok {
+		infralog.DebugCtx(ctx, "query failed", append(fields, zap.Error(err))...)
+		return
+	}
+
+	infralog.DebugCtx(ctx, "query succeeded", fields...)
+}
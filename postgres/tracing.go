@@ -0,0 +1,61 @@
+package infrapostgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	infratracing "github.com/pushwoosh/infra/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// queryTracer is a pgx logger that creates OpenTelemetry spans of executed queries.
+// pgx v4 reports queries when they complete, so spans are started in the past by the query duration
+type queryTracer struct {
+	name     string
+	database string
+}
+
+func newQueryTracer(name, database string) *queryTracer {
+	return &queryTracer{
+		name:     name,
+		database: database,
+	}
+}
+
+func (t *queryTracer) Log(ctx context.Context, _ pgx.LogLevel, msg string, data map[string]interface{}) {
+	duration, ok := data["time"].(time.Duration)
+	if !ok {
+		// not a query log record
+		return
+	}
+
+	end := time.Now()
+	_, span := infratracing.Tracer("postgres").Start(ctx, "postgres "+msg,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(end.Add(-duration)),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.name", t.database),
+			attribute.String("db.connection", t.name),
+		))
+	if query, ok := data["sql"].(string); ok {
+		span.SetAttributes(attribute.String("db.statement", query))
+	}
+	if err, ok := data["err"].(error); ok {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End(trace.WithTimestamp(end))
+}
+
+// multiLogger passes log records to every logger, pgx accepts only one
+type multiLogger []pgx.Logger
+
+func (m multiLogger) Log(ctx context.Context, level pgx.LogLevel, msg string, data map[string]interface{}) {
+	for _, l := range m {
+		l.Log(ctx, level, msg, data)
+	}
+}
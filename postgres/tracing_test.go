@@ -0,0 +1,51 @@
+package infrapostgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+	infratesttracing "github.com/pushwoosh/infra/test/tracing"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestQueryTracer(t *testing.T) {
+	recorder := infratesttracing.Record(t)
+
+	logger := multiLogger{newQueryTracer("main", "billing")}
+	ctx := context.Background()
+
+	logger.Log(ctx, pgx.LogLevelInfo, "Query", map[string]interface{}{
+		"sql":  "SELECT 1",
+		"time": 20 * time.Millisecond,
+	})
+	logger.Log(ctx, pgx.LogLevelError, "Exec", map[string]interface{}{
+		"sql":  "UPDATE accounts",
+		"time": time.Millisecond,
+		"err":  errors.New("deadlock detected"),
+	})
+	logger.Log(ctx, pgx.LogLevelInfo, "closed connection", nil)
+
+	if spans := recorder.Ended(); len(spans) != 2 {
+		t.Fatalf("expected spans of queries only, got %d", len(spans))
+	}
+
+	query := infratesttracing.Span(t, recorder, "postgres Query")
+	if query.SpanKind() != trace.SpanKindClient || query.EndTime().Sub(query.StartTime()) != 20*time.Millisecond {
+		t.Fatalf("expected a client span started in the past by the query time, got %v of %v",
+			query.SpanKind(), query.EndTime().Sub(query.StartTime()))
+	}
+	infratesttracing.RequireAttribute(t, query, "db.system", "postgresql")
+	infratesttracing.RequireAttribute(t, query, "db.name", "billing")
+	infratesttracing.RequireAttribute(t, query, "db.connection", "main")
+	infratesttracing.RequireAttribute(t, query, "db.statement", "SELECT 1")
+
+	exec := infratesttracing.Span(t, recorder, "postgres Exec")
+	infratesttracing.RequireAttribute(t, exec, "db.statement", "UPDATE accounts")
+	if exec.Status().Code != codes.Error || exec.Status().Description != "deadlock detected" {
+		t.Fatalf("failed query must be an error span, got %v", exec.Status())
+	}
+}
@@ -0,0 +1,148 @@
+package infraprofile
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	ProfileCPU       = "cpu"
+	ProfileHeap      = "heap"
+	ProfileAllocs    = "allocs"
+	ProfileGoroutine = "goroutine"
+	ProfileMutex     = "mutex"
+	ProfileBlock     = "block"
+)
+
+const (
+	defaultInterval    = time.Minute
+	defaultCPUDuration = 10 * time.Second
+	defaultTimeout     = 30 * time.Second
+)
+
+var defaultProfiles = []string{ProfileCPU, ProfileHeap, ProfileGoroutine}
+
+type Config struct {
+	// Profiling is disabled if false, the profiler does nothing then
+	Enabled bool `mapstructure:"enabled"`
+
+	// Service name, used as application name in Pyroscope and as a key prefix in object storage
+	Service string `mapstructure:"service"`
+
+	// How often profiles are collected. optional, default: 1m
+	Interval time.Duration `mapstructure:"interval"`
+
+	// CPU profiling duration in every interval. optional, default: 10s
+	CPUDuration time.Duration `mapstructure:"cpu_duration"`
+
+	// Collected profiles: cpu, heap, allocs, goroutine, mutex, block. optional, default: cpu, heap, goroutine
+	Profiles []string `mapstructure:"profiles"`
+
+	// Mutex profile fraction and block profile rate, used only if the profiles are enabled.
+	// optional, default: 100 and 10000 (one sample per 10us blocked)
+	MutexProfileFraction int `mapstructure:"mutex_profile_fraction"`
+	BlockProfileRate     int `mapstructure:"block_profile_rate"`
+
+	// Extra labels added to service metadata labels. optional
+	Labels map[string]string `mapstructure:"labels"`
+
+	// Export timeout of a single profile. optional, default: 30s
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// Pyroscope server to push profiles to. optional
+	Pyroscope *PyroscopeConfig `mapstructure:"pyroscope"`
+
+	// Object storage to dump profiles to. optional, requires WithS3Client option
+	S3 *S3Config `mapstructure:"s3"`
+}
+
+type PyroscopeConfig struct {
+	// Server address, e.g. "http://pyroscope:4040"
+	Address string `mapstructure:"address"`
+
+	// Bearer token. optional
+	AuthToken string `mapstructure:"auth_token"`
+
+	// Basic auth credentials, e.g. for Grafana Cloud. optional
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+
+	// Tenant id sent in X-Scope-OrgID header for multi-tenant servers. optional
+	TenantID string `mapstructure:"tenant_id"`
+}
+
+type S3Config struct {
+	Bucket string `mapstructure:"bucket"`
+
+	// Key prefix. optional
+	Prefix string `mapstructure:"prefix"`
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty profile config")
+	}
+
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.Service == "" {
+		return errors.New("service is mandatory")
+	}
+
+	if c.Interval < 0 || c.CPUDuration < 0 || c.Timeout < 0 {
+		return errors.New("interval, cpu_duration and timeout should be greater than or equal to 0")
+	}
+
+	if c.GetCPUDuration() >= c.GetInterval() {
+		return errors.New("cpu_duration should be less than interval")
+	}
+
+	for _, p := range c.Profiles {
+		switch p {
+		case ProfileCPU, ProfileHeap, ProfileAllocs, ProfileGoroutine, ProfileMutex, ProfileBlock:
+		default:
+			return errors.Errorf("unknown profile %q", p)
+		}
+	}
+
+	if c.Pyroscope != nil && c.Pyroscope.Address == "" {
+		return errors.New("pyroscope: address is mandatory")
+	}
+
+	if c.S3 != nil && c.S3.Bucket == "" {
+		return errors.New("s3: bucket is mandatory")
+	}
+
+	return nil
+}
+
+func (c *Config) GetInterval() time.Duration {
+	if c.Interval == 0 {
+		return defaultInterval
+	}
+	return c.Interval
+}
+
+func (c *Config) GetCPUDuration() time.Duration {
+	if c.CPUDuration == 0 {
+		return defaultCPUDuration
+	}
+	return c.CPUDuration
+}
+
+func (c *Config) GetProfiles() []string {
+	if len(c.Profiles) == 0 {
+		return defaultProfiles
+	}
+	return c.Profiles
+}
+
+func (c *Config) GetTimeout() time.Duration {
+	if c.Timeout == 0 {
+		return defaultTimeout
+	}
+	return c.Timeout
+}
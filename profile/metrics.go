@@ -0,0 +1,47 @@
+package infraprofile
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	ExportedCounter      *prometheus.CounterVec
+	ExportDuration       *prometheus.HistogramVec
+	CollectErrorsCounter *prometheus.CounterVec
+}
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.ExportedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "profile_exported_total",
+			Help: "The total number of exported profiles",
+		}, []string{"exporter", "profile", "status"})
+
+		metrics.ExportDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "profile_export_duration",
+			Help:    "The profile export duration",
+			Buckets: []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		}, []string{"exporter"})
+
+		metrics.CollectErrorsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "profile_collect_errors_total",
+			Help: "The total number of failed profile collections",
+		}, []string{"profile"})
+
+		prometheus.MustRegister(
+			metrics.ExportedCounter,
+			metrics.ExportDuration,
+			metrics.CollectErrorsCounter,
+		)
+	})
+}
+
+func status(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
@@ -0,0 +1,300 @@
+package infraprofile
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	infraretry "github.com/pushwoosh/infra/retry"
+	infras3 "github.com/pushwoosh/infra/s3"
+	infraversion "github.com/pushwoosh/infra/version"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultMutexProfileFraction = 100
+	defaultBlockProfileRate     = 10000
+)
+
+// Profile is a collected profile in gzipped pprof format
+type Profile struct {
+	Name   string
+	Data   []byte
+	Start  time.Time
+	End    time.Time
+	Labels map[string]string
+}
+
+// Exporter sends collected profiles to a storage
+type Exporter interface {
+	Name() string
+	Export(ctx context.Context, p *Profile) error
+}
+
+type Option interface {
+	apply(p *Profiler)
+}
+
+type optionS3Client struct {
+	client *infras3.Client
+}
+
+func (opt optionS3Client) apply(p *Profiler) {
+	p.s3 = opt.client
+}
+
+// WithS3Client sets a client used to dump profiles to object storage configured in S3 section
+func WithS3Client(client *infras3.Client) Option {
+	return optionS3Client{client: client}
+}
+
+type optionExporter struct {
+	exporter Exporter
+}
+
+func (opt optionExporter) apply(p *Profiler) {
+	p.exporters = append(p.exporters, opt.exporter)
+}
+
+// WithExporter adds a custom exporter
+func WithExporter(exporter Exporter) Option {
+	return optionExporter{exporter: exporter}
+}
+
+// Profiler periodically collects profiles of the current process and exports them:
+//
+//	profiler, err := infraprofile.NewProfiler(cfg, infraprofile.WithS3Client(s3Container.Get("profiles")))
+//	app.Add(profiler)
+//	...
+//	// take profiles right now, e.g. when a consumer stalls
+//	err = profiler.Capture(ctx)
+//
+// Labels are service, version, revision and host, plus extra labels from config.
+// CPU profiling fails while another CPU profile is taken, e.g. from /debug/pprof, the cycle goes on without it.
+// Parca has no push API, it scrapes /debug/pprof endpoints of the observability server instead.
+type Profiler struct {
+	cfg       *Config
+	labels    map[string]string
+	s3        *infras3.Client
+	exporters []Exporter
+
+	// only one collection at a time
+	collectMu sync.Mutex
+
+	runMu  sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+var (
+	_ infraoperator.Starter = (*Profiler)(nil)
+	_ infraoperator.Stopper = (*Profiler)(nil)
+)
+
+func NewProfiler(cfg *Config, opts ...Option) (*Profiler, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	initMetrics()
+
+	p := &Profiler{
+		cfg:    cfg,
+		labels: serviceLabels(cfg),
+	}
+
+	for _, opt := range opts {
+		opt.apply(p)
+	}
+
+	if cfg.Pyroscope != nil {
+		p.exporters = append(p.exporters, NewPyroscopeExporter(cfg.Service, cfg.Pyroscope))
+	}
+
+	if cfg.S3 != nil {
+		if p.s3 == nil {
+			return nil, errors.New("s3 client is required to dump profiles to object storage, see WithS3Client")
+		}
+		p.exporters = append(p.exporters, NewS3Exporter(p.s3, cfg.S3.Bucket, cfg.S3.Prefix))
+	}
+
+	if cfg.Enabled && len(p.exporters) == 0 {
+		return nil, errors.New("no exporters configured")
+	}
+
+	return p, nil
+}
+
+// Start starts collecting profiles in background. It does nothing if profiling is disabled
+func (p *Profiler) Start(_ context.Context) error {
+	if !p.cfg.Enabled {
+		return nil
+	}
+
+	p.runMu.Lock()
+	defer p.runMu.Unlock()
+
+	if p.cancel != nil {
+		return errors.New("profiler is already started")
+	}
+
+	profiles := p.cfg.GetProfiles()
+	if slices.Contains(profiles, ProfileMutex) {
+		fraction := p.cfg.MutexProfileFraction
+		if fraction == 0 {
+			fraction = defaultMutexProfileFraction
+		}
+		runtime.SetMutexProfileFraction(fraction)
+	}
+	if slices.Contains(profiles, ProfileBlock) {
+		rate := p.cfg.BlockProfileRate
+		if rate == 0 {
+			rate = defaultBlockProfileRate
+		}
+		runtime.SetBlockProfileRate(rate)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.done = make(chan struct{})
+
+	go p.run(ctx)
+
+	return nil
+}
+
+// Stop stops collecting and waits for the current collection
+func (p *Profiler) Stop(_ context.Context) error {
+	p.runMu.Lock()
+	defer p.runMu.Unlock()
+
+	if p.cancel == nil {
+		return nil
+	}
+
+	p.cancel()
+	<-p.done
+	p.cancel = nil
+
+	return nil
+}
+
+func (p *Profiler) run(ctx context.Context) {
+	defer close(p.done)
+
+	for {
+		start := time.Now()
+		if err := p.Capture(ctx); err != nil && ctx.Err() == nil {
+			infralog.Error("unable to capture profiles", zap.Error(err))
+		}
+
+		if err := infraretry.Sleep(ctx, p.cfg.GetInterval()-time.Since(start)); err != nil {
+			return
+		}
+	}
+}
+
+// Capture collects configured profiles right now and exports them.
+// CPU profile takes CPUDuration, a shorter one is exported if ctx is cancelled earlier.
+// Profiles are captured even if profiling is disabled by config, as long as exporters are configured.
+func (p *Profiler) Capture(ctx context.Context) error {
+	p.collectMu.Lock()
+	defer p.collectMu.Unlock()
+
+	var firstErr error
+	for _, name := range p.cfg.GetProfiles() {
+		profile, err := p.collect(ctx, name)
+		if err != nil {
+			metrics.CollectErrorsCounter.WithLabelValues(name).Inc()
+			if firstErr == nil {
+				firstErr = errors.Wrap(err, name)
+			}
+			continue
+		}
+
+		if err = p.export(profile); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+func (p *Profiler) collect(ctx context.Context, name string) (*Profile, error) {
+	buf := &bytes.Buffer{}
+	start := time.Now()
+
+	if name == ProfileCPU {
+		if err := pprof.StartCPUProfile(buf); err != nil {
+			return nil, err
+		}
+		_ = infraretry.Sleep(ctx, p.cfg.GetCPUDuration())
+		pprof.StopCPUProfile()
+	} else {
+		profile := pprof.Lookup(name)
+		if profile == nil {
+			return nil, errors.New("profile not found")
+		}
+		if err := profile.WriteTo(buf, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Profile{
+		Name:   name,
+		Data:   buf.Bytes(),
+		Start:  start,
+		End:    time.Now(),
+		Labels: p.labels,
+	}, nil
+}
+
+// export sends the profile to all exporters. A profile is exported even if the profiler is stopping
+func (p *Profiler) export(profile *Profile) error {
+	var firstErr error
+	for _, exporter := range p.exporters {
+		ctx, cancel := context.WithTimeout(context.Background(), p.cfg.GetTimeout())
+		start := time.Now()
+		err := exporter.Export(ctx, profile)
+		cancel()
+
+		metrics.ExportedCounter.WithLabelValues(exporter.Name(), profile.Name, status(err)).Inc()
+		metrics.ExportDuration.WithLabelValues(exporter.Name()).Observe(time.Since(start).Seconds())
+
+		if err != nil && firstErr == nil {
+			firstErr = errors.Wrapf(err, "unable to export %s profile to %s", profile.Name, exporter.Name())
+		}
+	}
+	return firstErr
+}
+
+// serviceLabels returns service metadata labels: service, version, revision and host
+func serviceLabels(cfg *Config) map[string]string {
+	labels := map[string]string{"service": cfg.Service}
+
+	if host, err := os.Hostname(); err == nil {
+		labels["host"] = host
+	}
+
+	info := infraversion.Get()
+	if info.Version != "" {
+		labels["version"] = info.Version
+	}
+	if info.Commit != "" {
+		labels["revision"] = info.Commit
+	}
+
+	for k, v := range cfg.Labels {
+		labels[k] = v
+	}
+
+	return labels
+}
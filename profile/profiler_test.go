@@ -0,0 +1,78 @@
+package infraprofile
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestProfiler_Capture(t *testing.T) {
+	var mu sync.Mutex
+	var names []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("profile")
+		if err != nil {
+			t.Errorf("no profile in request: %s", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		data, _ := io.ReadAll(file)
+		if len(data) == 0 {
+			t.Error("empty profile")
+		}
+		if r.Header.Get("X-Scope-OrgID") != "tenant" {
+			t.Errorf("unexpected tenant %q", r.Header.Get("X-Scope-OrgID"))
+		}
+
+		mu.Lock()
+		names = append(names, r.URL.Query().Get("name"))
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	profiler, err := NewProfiler(&Config{
+		Enabled:     true,
+		Service:     "api",
+		CPUDuration: 50 * time.Millisecond,
+		Profiles:    []string{ProfileCPU, ProfileGoroutine},
+		Labels:      map[string]string{"host": "pod-1", "region": "eu"},
+		Pyroscope:   &PyroscopeConfig{Address: srv.URL, TenantID: "tenant"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = profiler.Capture(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(names) != 2 {
+		t.Fatalf("expected 2 profiles, got %v", names)
+	}
+	if !strings.HasPrefix(names[0], "api.cpu{host=pod-1,") || !strings.Contains(names[0], "region=eu") {
+		t.Errorf("unexpected name %q", names[0])
+	}
+}
+
+func TestNewProfiler_disabled(t *testing.T) {
+	profiler, err := NewProfiler(&Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = profiler.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err = profiler.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,119 @@
+package infraprofile
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// PyroscopeExporter pushes profiles to Pyroscope ingest API
+type PyroscopeExporter struct {
+	service string
+	cfg     *PyroscopeConfig
+	client  *http.Client
+}
+
+var _ Exporter = (*PyroscopeExporter)(nil)
+
+func NewPyroscopeExporter(service string, cfg *PyroscopeConfig) *PyroscopeExporter {
+	return &PyroscopeExporter{
+		service: service,
+		cfg:     cfg,
+		client:  &http.Client{},
+	}
+}
+
+func (e *PyroscopeExporter) Name() string {
+	return "pyroscope"
+}
+
+func (e *PyroscopeExporter) Export(ctx context.Context, p *Profile) error {
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+	part, err := form.CreateFormFile("profile", "profile.pprof")
+	if err != nil {
+		return errors.Wrap(err, "unable to create form")
+	}
+	if _, err = part.Write(p.Data); err != nil {
+		return errors.Wrap(err, "unable to write profile")
+	}
+	if err = form.Close(); err != nil {
+		return errors.Wrap(err, "unable to close form")
+	}
+
+	query := url.Values{}
+	query.Set("name", e.appName(p))
+	query.Set("from", strconv.FormatInt(p.Start.Unix(), 10))
+	query.Set("until", strconv.FormatInt(p.End.Unix(), 10))
+	query.Set("format", "pprof")
+	query.Set("spyName", "gospy")
+	if p.Name == ProfileCPU {
+		query.Set("sampleRate", "100")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		strings.TrimRight(e.cfg.Address, "/")+"/ingest?"+query.Encode(), body)
+	if err != nil {
+		return errors.Wrap(err, "unable to create request")
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	if e.cfg.AuthToken != "" {
+		req.Header.Set("Authorization", "Bearer "+e.cfg.AuthToken)
+	} else if e.cfg.Username != "" {
+		req.SetBasicAuth(e.cfg.Username, e.cfg.Password)
+	}
+	if e.cfg.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", e.cfg.TenantID)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "unable to send profile")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return errors.Errorf("pyroscope responded with %d: %s", resp.StatusCode, msg)
+	}
+
+	return nil
+}
+
+// appName formats application name with labels: service.cpu{host=a,version=b}.
+// Label "service" is reserved by Pyroscope, it's replaced by the application name
+func (e *PyroscopeExporter) appName(p *Profile) string {
+	keys := make([]string, 0, len(p.Labels))
+	for k := range p.Labels {
+		if k != "service" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	sb := strings.Builder{}
+	sb.WriteString(e.service)
+	sb.WriteString(".")
+	sb.WriteString(p.Name)
+	sb.WriteString("{")
+	for i, k := range keys {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(k)
+		sb.WriteString("=")
+		sb.WriteString(p.Labels[k])
+	}
+	sb.WriteString("}")
+
+	return sb.String()
+}
@@ -0,0 +1,42 @@
+package infraprofile
+
+import (
+	"bytes"
+	"context"
+	"path"
+
+	infras3 "github.com/pushwoosh/infra/s3"
+)
+
+// S3Exporter dumps profiles to object storage with keys like
+// <prefix>/<service>/2006-01-02/15-04-05.<host>.<profile>.pb.gz
+type S3Exporter struct {
+	client *infras3.Client
+	bucket string
+	prefix string
+}
+
+var _ Exporter = (*S3Exporter)(nil)
+
+func NewS3Exporter(client *infras3.Client, bucket, prefix string) *S3Exporter {
+	return &S3Exporter{
+		client: client,
+		bucket: bucket,
+		prefix: prefix,
+	}
+}
+
+func (e *S3Exporter) Name() string {
+	return "s3"
+}
+
+func (e *S3Exporter) Export(ctx context.Context, p *Profile) error {
+	key := path.Join(
+		e.prefix,
+		p.Labels["service"],
+		p.Start.UTC().Format("2006-01-02"),
+		p.Start.UTC().Format("15-04-05")+"."+p.Labels["host"]+"."+p.Name+".pb.gz",
+	)
+
+	return e.client.Upload(ctx, e.bucket, key, bytes.NewReader(p.Data), infras3.WithContentType("application/octet-stream"))
+}
@@ -1,12 +1,47 @@
 package infraprompushgw
 
 import (
+	"time"
+
 	"github.com/pkg/errors"
 )
 
+const (
+	defaultTimeout = 10 * time.Second
+
+	MethodPut  = "put"
+	MethodPost = "post"
+)
+
 type Config struct {
 	Enabled bool   `mapstructure:"enabled"`
 	Address string `mapstructure:"address"`
+
+	// Job label of pushed metrics. optional if the job name is passed to Publish
+	Job string `mapstructure:"job"`
+
+	// Grouping labels, e.g. instance or shard. Metrics of one group replace each other. optional
+	Grouping map[string]string `mapstructure:"grouping"`
+
+	// put replaces all metrics of the group, post replaces only metrics with the same names,
+	// aggregation gateways accumulate metrics pushed with post. optional, default: put
+	Method string `mapstructure:"method"`
+
+	// Metrics are pushed periodically while the job is running. optional, pushed only on stop if 0
+	Interval time.Duration `mapstructure:"interval"`
+
+	// optional, default: 10s
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// Basic auth. optional
+	Username string `mapstructure:"username"`
+
+	// Password or a secret reference. optional
+	Password string `mapstructure:"password"`
+
+	// Delete metrics of the group on stop instead of the final push,
+	// e.g. for long jobs that report progress only while running. optional
+	DeleteOnStop bool `mapstructure:"delete_on_stop"`
 }
 
 func (c *Config) Validate() error {
@@ -18,5 +53,22 @@ func (c *Config) Validate() error {
 		return errors.New("address is required")
 	}
 
+	switch c.Method {
+	case "", MethodPut, MethodPost:
+	default:
+		return errors.Errorf("unknown method %s", c.Method)
+	}
+
+	if c.Interval < 0 || c.Timeout < 0 {
+		return errors.New("interval and timeout should not be negative")
+	}
+
 	return nil
 }
+
+func (c *Config) GetTimeout() time.Duration {
+	if c.Timeout == 0 {
+		return defaultTimeout
+	}
+	return c.Timeout
+}
@@ -8,6 +8,7 @@ import (
 	infralog "github.com/pushwoosh/infra/log"
 )
 
+// Publish pushes metrics of the collector once. Use Pusher to push all metrics with grouping labels and a final push on stop
 func Publish(cfg *Config, collector prometheus.Collector, jobName string) {
 	if cfg == nil || !cfg.Enabled {
 		return
@@ -15,6 +16,10 @@ func Publish(cfg *Config, collector prometheus.Collector, jobName string) {
 
 	infralog.Info("infraprompushgw: publishing metrics to push gateway")
 
+	if jobName == "" {
+		jobName = cfg.Job
+	}
+
 	if jobName == "" {
 		infralog.Error("infraprompushgw: job name is empty. discarding metrics")
 		return
@@ -0,0 +1,196 @@
+package infraprompushgw
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	infraconfig "github.com/pushwoosh/infra/config"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	infraretry "github.com/pushwoosh/infra/retry"
+	"go.uber.org/zap"
+)
+
+type Option interface {
+	apply(p *Pusher)
+}
+
+type optionGatherer struct {
+	gatherer prometheus.Gatherer
+}
+
+func (opt optionGatherer) apply(p *Pusher) {
+	p.gatherer = opt.gatherer
+}
+
+// WithGatherer sets metrics to push, default: prometheus.DefaultGatherer with all infra metrics
+func WithGatherer(gatherer prometheus.Gatherer) Option {
+	return optionGatherer{gatherer: gatherer}
+}
+
+type optionHTTPClient struct {
+	client *http.Client
+}
+
+func (opt optionHTTPClient) apply(p *Pusher) {
+	p.client = opt.client
+}
+
+// WithHTTPClient sets a client used to push, e.g. an infrahttp client with retries
+func WithHTTPClient(client *http.Client) Option {
+	return optionHTTPClient{client: client}
+}
+
+// Pusher pushes metrics of short-lived jobs that live not long enough to be scraped:
+//
+//	pusher, err := infraprompushgw.NewPusher(cfg.Pushgateway)
+//	app.Add("pushgateway", pusher) // added first to be stopped last with all metrics of the job
+//	...
+//
+// Stop makes the final push, so metrics of the whole run are pushed even if the job ends before the first interval.
+// Pusher does nothing if it's disabled.
+type Pusher struct {
+	cfg      *Config
+	gatherer prometheus.Gatherer
+	client   *http.Client
+
+	runMu  sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+var (
+	_ infraoperator.Starter = (*Pusher)(nil)
+	_ infraoperator.Stopper = (*Pusher)(nil)
+)
+
+func NewPusher(cfg *Config, opts ...Option) (*Pusher, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	if cfg.Enabled && cfg.Job == "" {
+		return nil, errors.New("job is required")
+	}
+
+	p := &Pusher{
+		cfg:      cfg,
+		gatherer: prometheus.DefaultGatherer,
+		client:   &http.Client{},
+	}
+
+	for _, opt := range opts {
+		opt.apply(p)
+	}
+
+	return p, nil
+}
+
+func (p *Pusher) pusher() (*push.Pusher, error) {
+	pusher := push.New(p.cfg.Address, p.cfg.Job).
+		Gatherer(p.gatherer).
+		Client(p.client)
+
+	for name, value := range p.cfg.Grouping {
+		pusher = pusher.Grouping(name, value)
+	}
+
+	if p.cfg.Username != "" {
+		password, err := infraconfig.ResolveSecret(p.cfg.Password)
+		if err != nil {
+			return nil, errors.Wrap(err, "password")
+		}
+		pusher = pusher.BasicAuth(p.cfg.Username, password)
+	}
+
+	return pusher, nil
+}
+
+// Push pushes metrics right now
+func (p *Pusher) Push(ctx context.Context) error {
+	if !p.cfg.Enabled {
+		return nil
+	}
+
+	pusher, err := p.pusher()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.GetTimeout())
+	defer cancel()
+
+	if p.cfg.Method == MethodPost {
+		err = pusher.AddContext(ctx)
+	} else {
+		err = pusher.PushContext(ctx)
+	}
+
+	return errors.Wrap(err, "unable to push metrics")
+}
+
+// Start starts pushing metrics periodically if interval is configured
+func (p *Pusher) Start(_ context.Context) error {
+	if !p.cfg.Enabled || p.cfg.Interval == 0 {
+		return nil
+	}
+
+	p.runMu.Lock()
+	defer p.runMu.Unlock()
+
+	if p.cancel != nil {
+		return errors.New("pusher is already started")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.done = make(chan struct{})
+
+	go p.run(ctx)
+
+	return nil
+}
+
+func (p *Pusher) run(ctx context.Context) {
+	defer close(p.done)
+
+	for {
+		if err := infraretry.Sleep(ctx, p.cfg.Interval); err != nil {
+			return
+		}
+
+		if err := p.Push(ctx); err != nil && ctx.Err() == nil {
+			infralog.Error("infraprompushgw: publish metrics", zap.Error(err))
+		}
+	}
+}
+
+// Stop stops periodic pushes and makes the final push, or deletes metrics of the group with DeleteOnStop
+func (p *Pusher) Stop(ctx context.Context) error {
+	if !p.cfg.Enabled {
+		return nil
+	}
+
+	p.runMu.Lock()
+	if p.cancel != nil {
+		p.cancel()
+		<-p.done
+		p.cancel = nil
+	}
+	p.runMu.Unlock()
+
+	if !p.cfg.DeleteOnStop {
+		return p.Push(ctx)
+	}
+
+	pusher, err := p.pusher()
+	if err != nil {
+		return err
+	}
+
+	return errors.Wrap(pusher.Delete(), "unable to delete metrics")
+}
@@ -0,0 +1,76 @@
+package infraprompushgw
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+type request struct {
+	method string
+	path   string
+	body   string
+}
+
+func TestPusher(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []request
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, request{method: r.Method, path: r.URL.Path, body: string(body)})
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	registry := prometheus.NewRegistry()
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "job_processed_total"})
+	registry.MustRegister(counter)
+
+	p, err := NewPusher(&Config{
+		Enabled:  true,
+		Address:  srv.URL,
+		Job:      "billing",
+		Grouping: map[string]string{"shard": "1"},
+		Method:   MethodPost,
+		Interval: 20 * time.Millisecond,
+	}, WithGatherer(registry))
+	require.NoError(t, err)
+
+	require.NoError(t, p.Start(context.Background()))
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(requests) > 0
+	}, time.Second, 10*time.Millisecond)
+
+	counter.Add(42)
+	require.NoError(t, p.Stop(context.Background()))
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	last := requests[len(requests)-1]
+	require.Equal(t, http.MethodPost, last.method)
+	require.Equal(t, "/metrics/job/billing/shard/1", last.path)
+	require.NotEmpty(t, last.body)
+
+	// disabled pusher does nothing
+	p, err = NewPusher(&Config{})
+	require.NoError(t, err)
+	require.NoError(t, p.Start(context.Background()))
+	require.NoError(t, p.Stop(context.Background()))
+
+	_, err = NewPusher(&Config{Enabled: true, Address: srv.URL})
+	require.ErrorContains(t, err, "job")
+}
@@ -0,0 +1,112 @@
+package infraproxy
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	infraconfig "github.com/pushwoosh/infra/config"
+)
+
+type Config struct {
+	// URL of the proxy: socks5://host:1080 or http://host:3128 for HTTP CONNECT
+	URL string `mapstructure:"url"`
+
+	// Username and Password of the proxy may be secret references, see infraconfig.ResolveSecret. optional
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+
+	// NoProxy are hosts connected directly: exact names, domain suffixes like ".svc.cluster.local",
+	// IP addresses and CIDRs. optional
+	NoProxy []string `mapstructure:"no_proxy"`
+
+	// Direct disables the global proxy for a client
+	Direct bool `mapstructure:"direct"`
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if c.Direct {
+		return nil
+	}
+
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return errors.Wrap(err, "invalid url")
+	}
+
+	switch u.Scheme {
+	case "socks5", "socks5h", "http":
+	default:
+		return errors.Errorf("unsupported proxy scheme %q", u.Scheme)
+	}
+
+	if u.Host == "" {
+		return errors.New("proxy host is mandatory")
+	}
+
+	for _, host := range c.NoProxy {
+		if strings.Contains(host, "/") {
+			if _, _, err = net.ParseCIDR(host); err != nil {
+				return errors.Wrapf(err, "no_proxy %q", host)
+			}
+		}
+	}
+
+	return nil
+}
+
+// proxyURL returns the proxy URL with resolved credentials
+func (c *Config) proxyURL() (*url.URL, error) {
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid url")
+	}
+
+	if c.Username == "" {
+		return u, nil
+	}
+
+	username, err := infraconfig.ResolveSecret(c.Username)
+	if err != nil {
+		return nil, errors.Wrap(err, "username")
+	}
+	password, err := infraconfig.ResolveSecret(c.Password)
+	if err != nil {
+		return nil, errors.Wrap(err, "password")
+	}
+	u.User = url.UserPassword(username, password)
+
+	return u, nil
+}
+
+// bypass reports if the host is connected directly
+func (c *Config) bypass(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	ip := net.ParseIP(host)
+
+	for _, rule := range c.NoProxy {
+		rule = strings.ToLower(rule)
+
+		switch {
+		case rule == "*":
+			return true
+		case strings.Contains(rule, "/"):
+			if _, cidr, err := net.ParseCIDR(rule); err == nil && ip != nil && cidr.Contains(ip) {
+				return true
+			}
+		case strings.HasPrefix(rule, "."):
+			if strings.HasSuffix(host, rule) || host == rule[1:] {
+				return true
+			}
+		case host == rule:
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,166 @@
+package infraproxy
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/proxy"
+)
+
+// DialFunc dials an address, it's the signature of net.Dialer.DialContext
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+var global atomic.Pointer[Config]
+
+// SetGlobal sets the proxy of all infra clients without their own proxy config,
+// nil disables it. The config should be valid
+func SetGlobal(cfg *Config) {
+	global.Store(cfg)
+}
+
+// ForClient returns the proxy config of a client: its own config or the global one.
+// Returns nil if the client connects directly
+func ForClient(cfg *Config) *Config {
+	if cfg == nil {
+		cfg = global.Load()
+	}
+	if cfg == nil || cfg.Direct {
+		return nil
+	}
+	return cfg
+}
+
+// ProxyFunc returns a proxy function for http.Transport. Both http and socks5 proxies are supported by the transport
+func (c *Config) ProxyFunc() (func(req *http.Request) (*url.URL, error), error) {
+	u, err := c.proxyURL()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(req *http.Request) (*url.URL, error) {
+		if c.bypass(req.URL.Hostname()) {
+			return nil, nil
+		}
+		return u, nil
+	}, nil
+}
+
+// Dialer returns a dial function connecting through the proxy, hosts of NoProxy are dialed directly.
+// forward dials the proxy and direct hosts, a default dialer is used if it's nil
+func (c *Config) Dialer(forward DialFunc) (DialFunc, error) {
+	if forward == nil {
+		forward = (&net.Dialer{}).DialContext
+	}
+
+	u, err := c.proxyURL()
+	if err != nil {
+		return nil, err
+	}
+
+	var viaProxy DialFunc
+	switch u.Scheme {
+	case "http":
+		viaProxy = connectDialer(u, forward)
+	default:
+		d, err := proxy.FromURL(u, forwardDialer(forward))
+		if err != nil {
+			return nil, errors.Wrap(err, "socks5")
+		}
+		viaProxy = d.(proxy.ContextDialer).DialContext
+	}
+
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+
+		if c.bypass(host) {
+			return forward(ctx, network, address)
+		}
+
+		conn, err := viaProxy(ctx, network, address)
+		if err != nil {
+			return nil, errors.Wrapf(err, "proxy %s", u.Host)
+		}
+		return conn, nil
+	}, nil
+}
+
+// connectDialer tunnels connections through an http proxy with CONNECT requests
+func connectDialer(u *url.URL, forward DialFunc) DialFunc {
+	return func(ctx context.Context, _, address string) (net.Conn, error) {
+		conn, err := forward(ctx, "tcp", u.Host)
+		if err != nil {
+			return nil, err
+		}
+
+		if deadline, ok := ctx.Deadline(); ok {
+			_ = conn.SetDeadline(deadline)
+			defer conn.SetDeadline(time.Time{})
+		}
+
+		req := &http.Request{
+			Method: http.MethodConnect,
+			URL:    &url.URL{Opaque: address},
+			Host:   address,
+			Header: make(http.Header),
+		}
+		if u.User != nil {
+			password, _ := u.User.Password()
+			credentials := base64.StdEncoding.EncodeToString([]byte(u.User.Username() + ":" + password))
+			req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+		}
+
+		if err = req.Write(conn); err != nil {
+			_ = conn.Close()
+			return nil, errors.Wrap(err, "write CONNECT")
+		}
+
+		br := bufio.NewReader(conn)
+		resp, err := http.ReadResponse(br, req)
+		if err != nil {
+			_ = conn.Close()
+			return nil, errors.Wrap(err, "read CONNECT response")
+		}
+		_ = resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			_ = conn.Close()
+			return nil, errors.Errorf("CONNECT %s: %s", address, resp.Status)
+		}
+
+		if br.Buffered() > 0 {
+			// the tunneled server may have started talking already
+			return &bufferedConn{Conn: conn, r: br}, nil
+		}
+		return conn, nil
+	}
+}
+
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+// forwardDialer adapts DialFunc to proxy.Dialer and proxy.ContextDialer
+type forwardDialer DialFunc
+
+func (d forwardDialer) Dial(network, address string) (net.Conn, error) {
+	return d(context.Background(), network, address)
+}
+
+func (d forwardDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return d(ctx, network, address)
+}
@@ -0,0 +1,114 @@
+package infraproxy
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+// listen accepts connections in background and serves them with handle
+func listen(t *testing.T, handle func(conn net.Conn)) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = l.Close()
+	})
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go handle(conn)
+		}
+	}()
+
+	return l.Addr().String()
+}
+
+func TestDialer(t *testing.T) {
+	target := listen(t, func(conn net.Conn) {
+		defer conn.Close()
+		_, _ = io.WriteString(conn, "hello")
+	})
+
+	var tunnels atomic.Int32
+	proxyAddr := listen(t, func(conn net.Conn) {
+		defer conn.Close()
+
+		req, err := http.ReadRequest(bufio.NewReader(conn))
+		if err != nil || req.Method != http.MethodConnect || req.Header.Get("Proxy-Authorization") == "" {
+			_, _ = io.WriteString(conn, "HTTP/1.1 407 Proxy Authentication Required\r\n\r\n")
+			return
+		}
+
+		upstream, err := net.Dial("tcp", req.Host)
+		if err != nil {
+			_, _ = io.WriteString(conn, "HTTP/1.1 502 Bad Gateway\r\n\r\n")
+			return
+		}
+		defer upstream.Close()
+
+		tunnels.Add(1)
+		_, _ = io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+		_, _ = io.Copy(conn, upstream)
+	})
+
+	cfg := &Config{URL: "http://" + proxyAddr, Username: "user", Password: "secret"}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(cfg *Config) string {
+		dial, err := cfg.Dialer(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		conn, err := dial(context.Background(), "tcp", target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		b, _ := io.ReadAll(conn)
+		return string(b)
+	}
+
+	if s := read(cfg); s != "hello" || tunnels.Load() != 1 {
+		t.Fatalf("expected tunneled connection, got %q and %d tunnels", s, tunnels.Load())
+	}
+
+	cfg.NoProxy = []string{"127.0.0.0/8"}
+	if s := read(cfg); s != "hello" || tunnels.Load() != 1 {
+		t.Fatalf("expected direct connection, got %q and %d tunnels", s, tunnels.Load())
+	}
+}
+
+func TestForClient(t *testing.T) {
+	global := &Config{URL: "socks5://proxy:1080"}
+	SetGlobal(global)
+	defer SetGlobal(nil)
+
+	if ForClient(nil) != global {
+		t.Fatal("expected the global proxy")
+	}
+	if ForClient(&Config{Direct: true}) != nil {
+		t.Fatal("expected direct connection")
+	}
+
+	own := &Config{URL: "http://proxy:3128", NoProxy: []string{".svc.cluster.local"}}
+	if ForClient(own) != own {
+		t.Fatal("expected the client proxy")
+	}
+	if !own.bypass("billing.default.svc.cluster.local") || own.bypass("example.com") {
+		t.Fatal("unexpected no_proxy match")
+	}
+}
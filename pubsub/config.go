@@ -0,0 +1,91 @@
+package infrapubsub
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type ConnectionsConfig map[string]*ConnectionConfig
+
+type ConnectionConfig struct {
+	// Google Cloud project ID
+	ProjectID string `mapstructure:"project_id"`
+
+	// Service account credentials file. Application default credentials are used if empty
+	CredentialsFile string `mapstructure:"credentials_file"`
+
+	// Custom endpoint, e.g. emulator address. Optional
+	Endpoint string `mapstructure:"endpoint"`
+}
+
+type SubscriberConfig struct {
+	ConnectionName string
+	Subscription   string
+	Topic          string        // optional, subscription is created for the topic if it does not exist
+	AckDeadline    time.Duration // optional, used for created subscriptions
+	EnableOrdering bool          // optional, used for created subscriptions
+
+	// flow control settings, optional
+	MaxOutstandingMessages int
+	MaxOutstandingBytes    int
+	NumGoroutines          int
+}
+
+type PublisherConfig struct {
+	ConnectionName string
+	Topic          string
+	EnableOrdering bool          // optional, required to publish messages with ordering keys
+	CountThreshold int           // optional, publish a batch when it has this many messages
+	DelayThreshold time.Duration // optional, publish a non-empty batch after this delay
+}
+
+func (c *ConnectionsConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	for name, conf := range *c {
+		if err := conf.Validate(); err != nil {
+			return errors.Wrap(err, name)
+		}
+	}
+
+	return nil
+}
+
+func (c *ConnectionConfig) Validate() error {
+	if c == nil {
+		return errors.New("empty connection config")
+	}
+
+	if c.ProjectID == "" {
+		return errors.New("project_id is mandatory")
+	}
+
+	return nil
+}
+
+func (c *SubscriberConfig) Validate() error {
+	if c == nil {
+		return errors.New("config is required")
+	}
+
+	if c.Subscription == "" {
+		return errors.New("subscription is mandatory")
+	}
+
+	return nil
+}
+
+func (c *PublisherConfig) Validate() error {
+	if c == nil {
+		return errors.New("config is required")
+	}
+
+	if c.Topic == "" {
+		return errors.New("topic is mandatory")
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package infrapubsub
+
+import (
+	"context"
+	"sync"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/pkg/errors"
+	"google.golang.org/api/option"
+)
+
+// Container is a simple container for holding named Pub/Sub clients.
+type Container struct {
+	mu   *sync.RWMutex
+	cfg  map[string]*ConnectionConfig
+	pool map[string]*pubsub.Client
+}
+
+func NewContainer() *Container {
+	return &Container{
+		mu:   &sync.RWMutex{},
+		cfg:  make(map[string]*ConnectionConfig),
+		pool: make(map[string]*pubsub.Client),
+	}
+}
+
+// Connect creates a new named Pub/Sub client
+func (cont *Container) Connect(name string, cfg *ConnectionConfig) error {
+	var opts []option.ClientOption
+	if cfg.CredentialsFile != "" {
+		opts = append(opts, option.WithCredentialsFile(cfg.CredentialsFile))
+	}
+	if cfg.Endpoint != "" {
+		opts = append(opts, option.WithEndpoint(cfg.Endpoint))
+	}
+
+	client, err := pubsub.NewClient(context.Background(), cfg.ProjectID, opts...)
+	if err != nil {
+		return errors.Wrap(err, "pubsub.NewClient")
+	}
+
+	cont.mu.Lock()
+	defer cont.mu.Unlock()
+
+	cont.pool[name] = client
+	cont.cfg[name] = cfg
+
+	return nil
+}
+
+// Get gets client from a container
+func (cont *Container) Get(name string) *pubsub.Client {
+	cont.mu.RLock()
+	defer cont.mu.RUnlock()
+
+	return cont.pool[name]
+}
+
+// Close closes all clients in the container
+func (cont *Container) Close() error {
+	cont.mu.Lock()
+	defer cont.mu.Unlock()
+
+	var lastErr error
+	for name, client := range cont.pool {
+		if err := client.Close(); err != nil {
+			lastErr = errors.Wrap(err, name)
+		}
+		delete(cont.pool, name)
+		delete(cont.cfg, name)
+	}
+
+	return lastErr
+}
+
+// CreateSubscriber creates a new subscriber by a connection name.
+// If topic is set in config and subscription does not exist, it's created.
+func (cont *Container) CreateSubscriber(subscriberCfg *SubscriberConfig) (*Subscriber, error) {
+	if err := subscriberCfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	client := cont.Get(subscriberCfg.ConnectionName)
+	if client == nil {
+		return nil, errors.Errorf("invalid connection name: %s", subscriberCfg.ConnectionName)
+	}
+
+	sub, err := ensureSubscription(context.Background(), client, subscriberCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	sub.ReceiveSettings.MaxOutstandingMessages = subscriberCfg.MaxOutstandingMessages
+	sub.ReceiveSettings.MaxOutstandingBytes = subscriberCfg.MaxOutstandingBytes
+	sub.ReceiveSettings.NumGoroutines = subscriberCfg.NumGoroutines
+
+	initMetrics()
+
+	return &Subscriber{
+		cfg: subscriberCfg,
+		sub: sub,
+	}, nil
+}
+
+// CreatePublisher creates a new topic publisher by a connection name
+func (cont *Container) CreatePublisher(publisherCfg *PublisherConfig) (*Publisher, error) {
+	if err := publisherCfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	client := cont.Get(publisherCfg.ConnectionName)
+	if client == nil {
+		return nil, errors.Errorf("invalid connection name: %s", publisherCfg.ConnectionName)
+	}
+
+	topic := client.Topic(publisherCfg.Topic)
+	topic.EnableMessageOrdering = publisherCfg.EnableOrdering
+	if publisherCfg.CountThreshold > 0 {
+		topic.PublishSettings.CountThreshold = publisherCfg.CountThreshold
+	}
+	if publisherCfg.DelayThreshold > 0 {
+		topic.PublishSettings.DelayThreshold = publisherCfg.DelayThreshold
+	}
+
+	initMetrics()
+
+	return &Publisher{
+		cfg:   publisherCfg,
+		topic: topic,
+	}, nil
+}
+
+func ensureSubscription(ctx context.Context, client *pubsub.Client, cfg *SubscriberConfig) (*pubsub.Subscription, error) {
+	sub := client.Subscription(cfg.Subscription)
+	if cfg.Topic == "" {
+		return sub, nil
+	}
+
+	exists, err := sub.Exists(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to check subscription existence")
+	}
+	if exists {
+		return sub, nil
+	}
+
+	sub, err = client.CreateSubscription(ctx, cfg.Subscription, pubsub.SubscriptionConfig{
+		Topic:                 client.Topic(cfg.Topic),
+		AckDeadline:           cfg.AckDeadline,
+		EnableMessageOrdering: cfg.EnableOrdering,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create subscription")
+	}
+
+	return sub, nil
+}
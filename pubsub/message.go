@@ -0,0 +1,58 @@
+package infrapubsub
+
+import (
+	"sync/atomic"
+
+	"cloud.google.com/go/pubsub"
+)
+
+type Message struct {
+	msg  *pubsub.Message
+	once atomic.Bool
+}
+
+func (m *Message) Ack() error {
+	if m.once.Swap(true) {
+		return nil
+	}
+
+	m.msg.Ack()
+	return nil
+}
+
+func (m *Message) Nack() error {
+	if m.once.Swap(true) {
+		return nil
+	}
+
+	m.msg.Nack()
+	return nil
+}
+
+// IsRedelivered is known only for subscriptions with dead letter policy
+func (m *Message) IsRedelivered() bool {
+	return m.msg.DeliveryAttempt != nil && *m.msg.DeliveryAttempt > 1
+}
+
+func (m *Message) Body() []byte {
+	return m.msg.Data
+}
+
+func (m *Message) Attribute(key string) string {
+	return m.msg.Attributes[key]
+}
+
+func (m *Message) OrderingKey() string {
+	return m.msg.OrderingKey
+}
+
+// Raw returns underlying pubsub message
+func (m *Message) Raw() *pubsub.Message {
+	return m.msg
+}
+
+type PublisherMessage struct {
+	Body        []byte
+	Attributes  map[string]string
+	OrderingKey string // optional, requires publisher with enabled ordering
+}
@@ -0,0 +1,41 @@
+package infrapubsub
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	inframetrics "github.com/pushwoosh/infra/metrics"
+)
+
+var metrics struct {
+	ConsumedMessagesCounter  *inframetrics.CounterVec
+	HandleDurationHistogram  *inframetrics.HistogramVec
+	PublishedMessagesCounter *inframetrics.CounterVec
+}
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.ConsumedMessagesCounter = inframetrics.NewCounterVec(prometheus.CounterOpts{
+			Name: "pubsub_subscriber_messages_counter",
+			Help: "The total number of handled messages",
+		}, []string{"subscription", "status"})
+
+		metrics.HandleDurationHistogram = inframetrics.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "pubsub_subscriber_handle_duration",
+			Help:    "The message handler duration",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"subscription"})
+
+		metrics.PublishedMessagesCounter = inframetrics.NewCounterVec(prometheus.CounterOpts{
+			Name: "pubsub_publisher_messages_counter",
+			Help: "The total number of published messages",
+		}, []string{"topic", "status"})
+
+		prometheus.MustRegister(
+			metrics.ConsumedMessagesCounter,
+			metrics.HandleDurationHistogram,
+			metrics.PublishedMessagesCounter,
+		)
+	})
+}
@@ -0,0 +1,46 @@
+package infrapubsub
+
+import (
+	"context"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/pkg/errors"
+)
+
+type Publisher struct {
+	cfg   *PublisherConfig
+	topic *pubsub.Topic
+}
+
+// Publish sends a message and waits for the server to acknowledge it. Returns server-assigned message ID.
+func (p *Publisher) Publish(ctx context.Context, msg *PublisherMessage) (string, error) {
+	if msg == nil {
+		return "", errors.New("message is nil")
+	}
+
+	result := p.topic.Publish(ctx, &pubsub.Message{
+		Data:        msg.Body,
+		Attributes:  msg.Attributes,
+		OrderingKey: msg.OrderingKey,
+	})
+
+	id, err := result.Get(ctx)
+	if err != nil {
+		metrics.PublishedMessagesCounter.WithLabelValues(p.cfg.Topic, "error").Inc()
+
+		// publishing for the ordering key is paused after an error until it's resumed explicitly
+		if msg.OrderingKey != "" {
+			p.topic.ResumePublish(msg.OrderingKey)
+		}
+
+		return "", errors.Wrap(err, "unable to publish message")
+	}
+
+	metrics.PublishedMessagesCounter.WithLabelValues(p.cfg.Topic, "success").Inc()
+	return id, nil
+}
+
+// Close sends all pending messages and stops publisher goroutines
+func (p *Publisher) Close() {
+	p.topic.Stop()
+}
@@ -0,0 +1,94 @@
+package infrapubsub
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	inframessaging "github.com/pushwoosh/infra/messaging"
+	"go.uber.org/zap"
+)
+
+// Handler processes a received message.
+// Message is acknowledged if handler returns nil and negatively acknowledged otherwise,
+// unless handler has already called Ack or Nack itself.
+type Handler = inframessaging.Handler[*Message]
+
+// Middleware wraps a handler with additional behavior
+type Middleware = inframessaging.Middleware[*Message]
+
+type Subscriber struct {
+	cfg *SubscriberConfig
+	sub *pubsub.Subscription
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Consume starts receiving messages in background
+func (s *Subscriber) Consume(handler Handler, middlewares ...Middleware) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel != nil {
+		return errors.New("subscriber is already started")
+	}
+
+	handler = inframessaging.Chain(handler, middlewares...)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+
+		// Receive retries retryable errors itself, so returned error is permanent
+		if err := s.sub.Receive(ctx, func(ctx context.Context, m *pubsub.Message) {
+			s.handle(ctx, m, handler)
+		}); err != nil {
+			infralog.Error("pubsub subscriber: receive", zap.String("subscription", s.cfg.Subscription), zap.Error(err))
+		}
+	}()
+
+	return nil
+}
+
+// Close stops receiving messages and waits for handlers in progress
+func (s *Subscriber) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel == nil {
+		return nil
+	}
+
+	s.cancel()
+	<-s.done
+	return nil
+}
+
+func (s *Subscriber) handle(ctx context.Context, m *pubsub.Message, handler Handler) {
+	msg := &Message{msg: m}
+
+	start := time.Now()
+	err := handler(ctx, msg)
+	metrics.HandleDurationHistogram.WithLabelValues(s.cfg.Subscription).Observe(time.Since(start).Seconds())
+
+	if err == nil {
+		metrics.ConsumedMessagesCounter.WithLabelValues(s.cfg.Subscription, "success").Inc()
+		_ = msg.Ack()
+		return
+	}
+
+	metrics.ConsumedMessagesCounter.WithLabelValues(s.cfg.Subscription, "error").Inc()
+	infralog.Error("pubsub subscriber: handle message",
+		zap.String("subscription", s.cfg.Subscription),
+		zap.String("message_id", m.ID),
+		zap.Error(err))
+	_ = msg.Nack()
+}
@@ -0,0 +1,232 @@
+package infrarabbitbench
+
+import (
+	"context"
+	"encoding/binary"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	infrarabbit "github.com/pushwoosh/infra/rabbit"
+	infraretry "github.com/pushwoosh/infra/retry"
+	"go.uber.org/zap"
+)
+
+const drainCheckInterval = 100 * time.Millisecond
+
+// Run publishes synthetic messages at the configured rate and consumes them with infrarabbit consumer,
+// measuring throughput, end-to-end latency and acks. Use it to pick prefetch count and concurrency
+// of a consumer before production:
+//
+//	report, err := infrarabbitbench.Run(ctx, rabbitContainer, &infrarabbitbench.Config{
+//		ConnectionName: "default",
+//		Exchange:       "bench",
+//		RoutingKey:     "bench",
+//		Queue:          "bench",
+//		Rate:           5000,
+//		PrefetchCount:  64,
+//		Concurrency:    8,
+//		HandlerDelay:   time.Millisecond,
+//	})
+//	_ = report.Print(os.Stdout)
+//
+// Messages of other runs left in the queue are acked and ignored. Latencies of all acked messages
+// are kept in memory until the end of the run.
+func Run(ctx context.Context, cont *infrarabbit.Container, cfg *Config) (*Report, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	producer, err := cont.CreateProducer(&infrarabbit.ProducerConfig{
+		ConnectionName: cfg.ConnectionName,
+		Bindings: []*infrarabbit.BindConfig{{
+			Exchange:   cfg.Exchange,
+			RoutingKey: cfg.RoutingKey,
+			Queue:      cfg.Queue,
+		}},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create producer")
+	}
+	defer func() {
+		_ = producer.Close()
+	}()
+
+	consumer, err := cont.CreateConsumer(&infrarabbit.ConsumerConfig{
+		ConnectionName: cfg.ConnectionName,
+		Queue:          cfg.Queue,
+		PrefetchCount:  cfg.PrefetchCount,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create consumer")
+	}
+
+	r := &run{
+		cfg: cfg,
+		id:  rand.Uint64(),
+	}
+
+	start := time.Now()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < cfg.GetConcurrency(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.consume(consumer.Consume())
+		}()
+	}
+
+	r.publish(ctx, producer)
+	publishDuration := time.Since(start)
+
+	r.drain(ctx)
+	totalDuration := time.Since(start)
+
+	// closing waits for messages in progress and closes the channel, handlers exit then
+	if err = consumer.Close(); err != nil {
+		infralog.Error("unable to close bench consumer", zap.Error(err))
+	}
+	wg.Wait()
+
+	return r.report(publishDuration, totalDuration), nil
+}
+
+// run is a state of a benchmark run
+type run struct {
+	cfg *Config
+	id  uint64
+	seq atomic.Uint64
+
+	published     atomic.Int64
+	publishErrors atomic.Int64
+	deliveries    atomic.Int64
+	acked         atomic.Int64
+	nacked        atomic.Int64
+	redelivered   atomic.Int64
+
+	mu        sync.Mutex
+	latencies []time.Duration
+}
+
+func (r *run) publish(ctx context.Context, producer *infrarabbit.Producer) {
+	ctx, cancel := context.WithTimeout(ctx, r.cfg.GetDuration())
+	defer cancel()
+
+	publishers := r.cfg.GetPublishers()
+	interval := time.Duration(float64(time.Second) * float64(publishers) / float64(r.cfg.GetRate()))
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < publishers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// sends are scheduled from the start, so a publisher catches up after a slow publish
+			start := time.Now()
+			for n := 0; ; n++ {
+				if err := infraretry.Sleep(ctx, time.Until(start.Add(time.Duration(n)*interval))); err != nil {
+					return
+				}
+
+				err := producer.Produce(ctx, &infrarabbit.ProducerMessage{
+					Body:       r.body(),
+					Exchange:   r.cfg.Exchange,
+					RoutingKey: r.cfg.RoutingKey,
+				})
+				if ctx.Err() != nil {
+					return
+				}
+				if err != nil {
+					r.publishErrors.Add(1)
+					continue
+				}
+				r.published.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// body is the run id, a sequence number and publish time padded to the message size
+func (r *run) body() []byte {
+	body := make([]byte, r.cfg.GetMessageSize())
+	binary.BigEndian.PutUint64(body[0:8], r.id)
+	binary.BigEndian.PutUint64(body[8:16], r.seq.Add(1))
+	binary.BigEndian.PutUint64(body[16:24], uint64(time.Now().UnixNano()))
+	return body
+}
+
+func (r *run) consume(messages chan *infrarabbit.Message) {
+	for msg := range messages {
+		body := msg.Body()
+		if len(body) < headerSize || binary.BigEndian.Uint64(body[0:8]) != r.id {
+			_ = msg.Ack()
+			continue
+		}
+
+		r.deliveries.Add(1)
+		if msg.IsRedelivered() {
+			r.redelivered.Add(1)
+		}
+
+		if r.cfg.HandlerDelay > 0 {
+			time.Sleep(r.cfg.HandlerDelay)
+		}
+
+		if r.cfg.NackRate > 0 && rand.Float64() < r.cfg.NackRate {
+			if msg.Nack() == nil {
+				r.nacked.Add(1)
+			}
+			continue
+		}
+
+		if msg.Ack() != nil {
+			continue
+		}
+
+		latency := time.Since(time.Unix(0, int64(binary.BigEndian.Uint64(body[16:24]))))
+		r.acked.Add(1)
+
+		r.mu.Lock()
+		r.latencies = append(r.latencies, latency)
+		r.mu.Unlock()
+	}
+}
+
+// drain waits until all published messages are acked or the drain timeout passes
+func (r *run) drain(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, r.cfg.GetDrainTimeout())
+	defer cancel()
+
+	for r.acked.Load() < r.published.Load() {
+		if err := infraretry.Sleep(ctx, drainCheckInterval); err != nil {
+			return
+		}
+	}
+}
+
+func (r *run) report(publishDuration, totalDuration time.Duration) *Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := &Report{
+		Published:       r.published.Load(),
+		PublishErrors:   r.publishErrors.Load(),
+		Deliveries:      r.deliveries.Load(),
+		Acked:           r.acked.Load(),
+		Nacked:          r.nacked.Load(),
+		Redelivered:     r.redelivered.Load(),
+		PublishDuration: publishDuration,
+		TotalDuration:   totalDuration,
+		Latency:         latencyOf(r.latencies),
+	}
+	if lost := report.Published - report.Acked; lost > 0 {
+		report.Lost = lost
+	}
+
+	return report
+}
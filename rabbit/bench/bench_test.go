@@ -0,0 +1,39 @@
+package infrarabbitbench
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestReport(t *testing.T) {
+	samples := make([]time.Duration, 0, 100)
+	for i := 100; i > 0; i-- {
+		samples = append(samples, time.Duration(i)*time.Millisecond)
+	}
+
+	latency := latencyOf(samples)
+	if latency.Min != time.Millisecond || latency.Max != 100*time.Millisecond {
+		t.Fatalf("unexpected min/max: %s/%s", latency.Min, latency.Max)
+	}
+	if latency.P50 != 50*time.Millisecond || latency.P99 != 99*time.Millisecond {
+		t.Fatalf("unexpected percentiles: p50 %s, p99 %s", latency.P50, latency.P99)
+	}
+
+	report := &Report{Published: 100, Deliveries: 110, Acked: 100, TotalDuration: 2 * time.Second, Latency: latency}
+	if report.AckRate() != 50 {
+		t.Fatalf("expected 50 msg/s, got %f", report.AckRate())
+	}
+
+	buf := &bytes.Buffer{}
+	if err := report.Print(buf); err != nil || buf.Len() == 0 {
+		t.Fatalf("unable to print report: %v", err)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	cfg := &Config{ConnectionName: "default", Exchange: "bench", Queue: "bench", MessageSize: 8}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("message size smaller than header must be rejected")
+	}
+}
@@ -0,0 +1,125 @@
+package infrarabbitbench
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultRate         = 1000
+	defaultMessageSize  = 1024
+	defaultDuration     = 30 * time.Second
+	defaultPublishers   = 1
+	defaultConcurrency  = 1
+	defaultDrainTimeout = 30 * time.Second
+
+	// run id, sequence number and publish time
+	headerSize = 24
+)
+
+type Config struct {
+	ConnectionName string
+
+	// Messages are published to Exchange with RoutingKey, the exchange is bound to Queue before the run
+	Exchange   string
+	RoutingKey string
+	Queue      string
+
+	// Messages per second of all publishers. optional, default: 1000
+	Rate int
+
+	// Body size in bytes, at least 24. optional, default: 1024
+	MessageSize int
+
+	// How long messages are published. optional, default: 30s
+	Duration time.Duration
+
+	// Number of concurrent publishers. optional, default: 1
+	Publishers int
+
+	// Consumer prefetch count. optional
+	PrefetchCount int
+
+	// Number of goroutines handling consumed messages. optional, default: 1
+	Concurrency int
+
+	// Simulated processing time of a message. optional
+	HandlerDelay time.Duration
+
+	// Fraction of deliveries nacked and requeued, from 0 to 1. optional
+	NackRate float64
+
+	// How long to wait for the queue to be drained after publishing. optional, default: 30s
+	DrainTimeout time.Duration
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if c.ConnectionName == "" {
+		return errors.New("connection name is mandatory")
+	}
+
+	if c.Exchange == "" || c.Queue == "" {
+		return errors.New("exchange and queue are mandatory")
+	}
+
+	if c.Rate < 0 || c.Publishers < 0 || c.Concurrency < 0 || c.PrefetchCount < 0 {
+		return errors.New("rate, publishers, concurrency and prefetch count should not be negative")
+	}
+
+	if c.MessageSize != 0 && c.MessageSize < headerSize {
+		return errors.Errorf("message size should be at least %d bytes", headerSize)
+	}
+
+	if c.NackRate < 0 || c.NackRate >= 1 {
+		return errors.New("nack rate should be between 0 and 1, excluding 1")
+	}
+
+	return nil
+}
+
+func (c *Config) GetRate() int {
+	if c.Rate == 0 {
+		return defaultRate
+	}
+	return c.Rate
+}
+
+func (c *Config) GetMessageSize() int {
+	if c.MessageSize == 0 {
+		return defaultMessageSize
+	}
+	return c.MessageSize
+}
+
+func (c *Config) GetDuration() time.Duration {
+	if c.Duration == 0 {
+		return defaultDuration
+	}
+	return c.Duration
+}
+
+func (c *Config) GetPublishers() int {
+	if c.Publishers == 0 {
+		return defaultPublishers
+	}
+	return c.Publishers
+}
+
+func (c *Config) GetConcurrency() int {
+	if c.Concurrency == 0 {
+		return defaultConcurrency
+	}
+	return c.Concurrency
+}
+
+func (c *Config) GetDrainTimeout() time.Duration {
+	if c.DrainTimeout == 0 {
+		return defaultDrainTimeout
+	}
+	return c.DrainTimeout
+}
@@ -0,0 +1,111 @@
+package infrarabbitbench
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+	"time"
+)
+
+// Report is the result of a benchmark run
+type Report struct {
+	// Published is the number of messages published without errors
+	Published     int64
+	PublishErrors int64
+
+	// Deliveries counts every delivery including redeliveries of nacked messages
+	Deliveries  int64
+	Acked       int64
+	Nacked      int64
+	Redelivered int64
+
+	// Lost is the number of published messages not acked within the drain timeout
+	Lost int64
+
+	PublishDuration time.Duration
+	TotalDuration   time.Duration
+
+	// End-to-end latency from publish to delivery of acked messages
+	Latency Latency
+}
+
+// Latency is a latency distribution
+type Latency struct {
+	Min, P50, P90, P99, Max, Mean time.Duration
+}
+
+// PublishRate is published messages per second
+func (r *Report) PublishRate() float64 {
+	return rate(r.Published, r.PublishDuration)
+}
+
+// AckRate is acked messages per second over the whole run, the consumer throughput
+func (r *Report) AckRate() float64 {
+	return rate(r.Acked, r.TotalDuration)
+}
+
+// AckRatio is the fraction of deliveries acked
+func (r *Report) AckRatio() float64 {
+	if r.Deliveries == 0 {
+		return 0
+	}
+	return float64(r.Acked) / float64(r.Deliveries)
+}
+
+// Print writes the report as a table
+func (r *Report) Print(out io.Writer) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "published\t%d\t%.1f msg/s\n", r.Published, r.PublishRate())
+	_, _ = fmt.Fprintf(w, "publish errors\t%d\t\n", r.PublishErrors)
+	_, _ = fmt.Fprintf(w, "acked\t%d\t%.1f msg/s\n", r.Acked, r.AckRate())
+	_, _ = fmt.Fprintf(w, "nacked\t%d\t\n", r.Nacked)
+	_, _ = fmt.Fprintf(w, "redelivered\t%d\t\n", r.Redelivered)
+	_, _ = fmt.Fprintf(w, "ack ratio\t%.4f\t\n", r.AckRatio())
+	_, _ = fmt.Fprintf(w, "lost\t%d\t\n", r.Lost)
+	_, _ = fmt.Fprintf(w, "latency\tmin %s\tp50 %s\tp90 %s\tp99 %s\tmax %s\tmean %s\n",
+		r.Latency.Min, r.Latency.P50, r.Latency.P90, r.Latency.P99, r.Latency.Max, r.Latency.Mean)
+	_, _ = fmt.Fprintf(w, "duration\t%s\t\n", r.TotalDuration)
+	return w.Flush()
+}
+
+func rate(count int64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(count) / d.Seconds()
+}
+
+// latencyOf calculates the distribution, samples are sorted in place
+func latencyOf(samples []time.Duration) Latency {
+	if len(samples) == 0 {
+		return Latency{}
+	}
+
+	slices.Sort(samples)
+
+	var sum time.Duration
+	for _, s := range samples {
+		sum += s
+	}
+
+	return Latency{
+		Min:  samples[0],
+		P50:  percentile(samples, 0.5),
+		P90:  percentile(samples, 0.9),
+		P99:  percentile(samples, 0.99),
+		Max:  samples[len(samples)-1],
+		Mean: sum / time.Duration(len(samples)),
+	}
+}
+
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	i := int(float64(len(sorted))*p+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
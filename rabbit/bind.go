@@ -48,7 +48,7 @@ func NewBinder(config *ConnectionConfig) (*binder, error) {
 		return nil, errors.Wrap(err, "unable to create URL for binder")
 	}
 
-	conn, err := amqp.Dial(url)
+	conn, err := dial(config, url, amqp.Config{Heartbeat: defaultHeartbeat, Locale: defaultLocale})
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to connect to RabbitMQ")
 	}
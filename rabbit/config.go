@@ -4,17 +4,52 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	infraconfig "github.com/pushwoosh/infra/config"
+	infradns "github.com/pushwoosh/infra/dns"
+	infraproxy "github.com/pushwoosh/infra/proxy"
+	infratls "github.com/pushwoosh/infra/tls"
 )
 
-const PriorityProperty = "x-max-priority"
+const (
+	PriorityProperty           = "x-max-priority"
+	DeadLetterExchangeProperty = "x-dead-letter-exchange"
+	DeliveryCountHeader        = "x-delivery-count"
+)
+
+func init() {
+	// services start in dev without their dependencies running
+	infraconfig.RegisterProfileDefaults(infraconfig.ProfileDev, func(c *ConnectionConfig) {
+		c.Lazy = true
+	})
+}
 
 type ConnectionsConfig map[string]*ConnectionConfig
 
 type ConnectionConfig struct {
-	Address  string `mapstructure:"address"`
+	Address string `mapstructure:"address"`
+
+	// Username and Password may be secret references, see infraconfig.ResolveSecret
 	Username string `mapstructure:"username"`
 	Password string `mapstructure:"password"`
-	Vhost    string `mapstructure:"vhost"`
+
+	Vhost string `mapstructure:"vhost"`
+
+	// TLS connects with amqps, certificates are reloaded on change. optional
+	TLS *infratls.Config `mapstructure:"tls"`
+
+	// Proxy of the connection. optional, default: the global proxy
+	Proxy *infraproxy.Config `mapstructure:"proxy"`
+
+	// Resolver resolves the host instead of the system resolver, see WithResolver. optional
+	Resolver *infradns.Resolver `mapstructure:"-"`
+
+	// Dialer connects instead of dialing Address, e.g. infradiscovery.Dialer picking one of multiple hosts.
+	// Address is still used as the TLS server name and the host of metrics. optional
+	Dialer infradns.DialFunc `mapstructure:"-"`
+
+	// Lazy makes producers connect on the first Produce instead of CreateProducer,
+	// so a service starts without RabbitMQ running. optional, default: true in the dev profile
+	Lazy bool `mapstructure:"lazy"`
 }
 
 type ConsumerMetrics struct {
@@ -30,11 +65,22 @@ type ConsumerConfig struct {
 	PrefetchCount  int              // optional
 	Tag            string           // optional
 	Metrics        *ConsumerMetrics // optional
+
+	// DeadLetterExchange is declared as x-dead-letter-exchange of the queue,
+	// messages rejected by Message.Reject or over MaxRedeliveries are routed there. optional
+	DeadLetterExchange string
+
+	// MaxRedeliveries makes Message.Nack reject a message without requeueing once it was redelivered that many times,
+	// so a poison message doesn't loop forever. Deliveries are counted by quorum queues in x-delivery-count header,
+	// classic queues only flag redelivered messages, so such a message counts as redelivered once.
+	// optional, default: 0, messages are always requeued
+	MaxRedeliveries int
 }
 
 type ProducerConfig struct {
 	ConnectionName string
 	Bindings       []*BindConfig
+	Confirm        bool // optional, wait for broker confirmation of every message
 }
 
 func (c *ConnectionsConfig) Validate() error {
@@ -60,5 +106,17 @@ func (c *ConnectionConfig) Validate() error {
 		return errors.New("address is mandatory")
 	}
 
+	if c.TLS != nil {
+		if err := c.TLS.Validate(); err != nil {
+			return errors.Wrap(err, "tls")
+		}
+	}
+
+	if c.Proxy != nil {
+		if err := c.Proxy.Validate(); err != nil {
+			return errors.Wrap(err, "proxy")
+		}
+	}
+
 	return nil
 }
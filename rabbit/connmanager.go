@@ -43,7 +43,7 @@ func (cp *connManager) Get(cfg *ConnectionConfig, tag string) (*amqp.Connection,
 	}
 	amqpProps.SetClientConnectionName(tag)
 
-	conn, err := amqp.DialConfig(amqpURL, amqp.Config{
+	conn, err := dial(cfg, amqpURL, amqp.Config{
 		Properties: amqpProps,
 	})
 	if err != nil {
@@ -60,6 +60,7 @@ func (cp *connManager) CreateConsumerChannel(
 	queue string,
 	queuePriority uint8,
 	prefetchCount int,
+	deadLetterExchange string,
 ) (*amqp.Channel, <-chan amqp.Delivery, error) {
 	channel, err := conn.Channel()
 	if err != nil {
@@ -79,6 +80,9 @@ func (cp *connManager) CreateConsumerChannel(
 	if queuePriority > 0 {
 		args[PriorityProperty] = int(queuePriority)
 	}
+	if deadLetterExchange != "" {
+		args[DeadLetterExchangeProperty] = deadLetterExchange
+	}
 	_, err = channel.QueueDeclare(
 		queue, // name of the queue
 		false, // durable
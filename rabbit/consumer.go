@@ -1,12 +1,15 @@
 package infrarabbit
 
 import (
+	"context"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
+	infraclock "github.com/pushwoosh/infra/clock"
 	infralog "github.com/pushwoosh/infra/log"
+	infraretry "github.com/pushwoosh/infra/retry"
 	amqp "github.com/rabbitmq/amqp091-go"
 	"go.uber.org/zap"
 )
@@ -17,6 +20,11 @@ const (
 	defaultUser          = "guest"
 	defaultPassword      = "guest"
 
+	// amqp.Dial defaults
+	defaultHeartbeat         = 10 * time.Second
+	defaultLocale            = "en_US"
+	defaultConnectionTimeout = 30 * time.Second
+
 	connCloseChanSize             = 8096
 	metricsIntervalCheckDefault   = time.Hour * 24 * 365
 	heartbeatIntervalCheck        = time.Second
@@ -25,14 +33,24 @@ const (
 
 var connectionsManager = newConnManager()
 
+var reconnectBackoff = infraretry.Exponential{
+	Initial: time.Second,
+	Max:     30 * time.Second,
+	Jitter:  0.2,
+}
+
 type Consumer struct {
 	connCfg         *ConnectionConfig
 	cfg             *ConsumerConfig
+	clock           infraclock.Clock
 	ch              chan *Message
 	mu              sync.Mutex
 	closed          chan bool
 	isClosed        bool
 	itemsInProgress sync.WaitGroup
+
+	paused       atomic.Bool
+	pauseChanged chan struct{}
 }
 
 func (c *Consumer) start() {
@@ -44,20 +62,24 @@ func (c *Consumer) start() {
 		metricsInterval = cfg.Metrics.CheckInterval
 	}
 
-	metricsTicker := time.NewTicker(metricsInterval)
+	metricsTicker := c.clock.NewTicker(metricsInterval)
 	defer metricsTicker.Stop()
 
-	heartbeatTicker := time.NewTicker(heartbeatIntervalCheck)
+	heartbeatTicker := c.clock.NewTicker(heartbeatIntervalCheck)
 	defer heartbeatTicker.Stop()
 
 	var channel *amqp.Channel
 	var deliveries <-chan amqp.Delivery
 
+	// failed connection attempts in a row
+	failedAttempts := 0
+
 reconnectLoop:
 	for !c.isClosed {
 		conn, isNewConn, err := connectionsManager.Get(c.connCfg, cfg.Tag)
 		if err != nil {
-			time.Sleep(time.Second) // time to wait to not make infinite "for" loop
+			failedAttempts++
+			_ = c.clock.Sleep(context.Background(), reconnectBackoff.Delay(failedAttempts)) // time to wait to not make infinite "for" loop
 			continue
 		}
 
@@ -66,12 +88,15 @@ reconnectLoop:
 			cfg.Tag,
 			cfg.Queue,
 			cfg.QueuePriority,
-			cfg.PrefetchCount)
+			cfg.PrefetchCount,
+			cfg.DeadLetterExchange)
 		if err != nil {
 			connectionsManager.CloseConnection(conn)
-			time.Sleep(time.Second) // time to wait to not make infinite "for" loop
+			failedAttempts++
+			_ = c.clock.Sleep(context.Background(), reconnectBackoff.Delay(failedAttempts)) // time to wait to not make infinite "for" loop
 			continue
 		}
+		failedAttempts = 0
 
 		channelClose := channel.NotifyClose(make(chan *amqp.Error, connCloseChanSize))
 		var connClose chan *amqp.Error
@@ -79,7 +104,7 @@ reconnectLoop:
 			connClose = conn.NotifyClose(make(chan *amqp.Error, connCloseChanSize))
 		}
 
-		lastTimeConnectionUsed := time.Now()
+		lastTimeConnectionUsed := c.clock.Now()
 		isNeedRecreateChannel := atomic.Bool{}
 
 		var callback = func(err error) {
@@ -90,7 +115,15 @@ reconnectLoop:
 		}
 
 		for !c.isClosed {
+			// a paused consumer keeps its channel but stops taking deliveries
+			msgs := deliveries
+			if c.paused.Load() {
+				msgs = nil
+			}
+
 			select {
+			case <-c.pauseChanged:
+				continue
 			case closeErr, isOpen := <-connClose:
 				if closeErr != nil || !isOpen {
 					go readAllErrors(connClose)
@@ -103,25 +136,26 @@ reconnectLoop:
 					connectionsManager.CloseConsumerChannel(channel)
 					continue reconnectLoop
 				}
-			case <-heartbeatTicker.C:
-				if time.Since(lastTimeConnectionUsed) > heartbeatReconnectionInterval || isNeedRecreateChannel.Load() {
+			case <-heartbeatTicker.C():
+				if c.clock.Now().Sub(lastTimeConnectionUsed) > heartbeatReconnectionInterval || isNeedRecreateChannel.Load() {
 					connectionsManager.CloseConsumerChannel(channel)
 					continue reconnectLoop
 				}
-			case <-metricsTicker.C:
+			case <-metricsTicker.C():
 				go collectMetrics(cfg, channel, host, cfg.Queue)
-			case msg, isOpen := <-deliveries:
+			case msg, isOpen := <-msgs:
 				if !isOpen {
 					connectionsManager.CloseConsumerChannel(channel)
 					continue reconnectLoop
 				}
-				lastTimeConnectionUsed = time.Now()
+				lastTimeConnectionUsed = c.clock.Now()
 				c.itemsInProgress.Add(1)
 				c.ch <- &Message{
-					msg:      &msg,
-					host:     host,
-					queue:    cfg.Queue,
-					callback: callback,
+					msg:             &msg,
+					host:            host,
+					queue:           cfg.Queue,
+					maxRedeliveries: cfg.MaxRedeliveries,
+					callback:        callback,
 				}
 			}
 		}
@@ -136,6 +170,33 @@ func (c *Consumer) Consume() chan *Message {
 	return c.ch
 }
 
+// Pause stops passing messages to Consume channel until Resume. Messages in progress are not affected.
+// Messages prefetched by the channel stay unacked while the consumer is paused.
+func (c *Consumer) Pause() {
+	if !c.paused.Swap(true) {
+		c.notifyPauseChanged()
+	}
+}
+
+// Resume continues passing messages after Pause
+func (c *Consumer) Resume() {
+	if c.paused.Swap(false) {
+		c.notifyPauseChanged()
+	}
+}
+
+// IsPaused returns true if the consumer is paused
+func (c *Consumer) IsPaused() bool {
+	return c.paused.Load()
+}
+
+func (c *Consumer) notifyPauseChanged() {
+	select {
+	case c.pauseChanged <- struct{}{}:
+	default:
+	}
+}
+
 func (c *Consumer) Close() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -4,24 +4,73 @@ import (
 	"sync"
 
 	"github.com/pkg/errors"
+	infraclock "github.com/pushwoosh/infra/clock"
+	infradns "github.com/pushwoosh/infra/dns"
+	amqp "github.com/rabbitmq/amqp091-go"
 )
 
 // Container is a simple container for holding named rabbit connections.
 type Container struct {
-	mu  *sync.RWMutex
-	cfg map[string]*ConnectionConfig
+	mu       *sync.RWMutex
+	cfg      map[string]*ConnectionConfig
+	clock    infraclock.Clock
+	resolver *infradns.Resolver
 }
 
-func NewContainer() *Container {
-	return &Container{
-		mu:  &sync.RWMutex{},
-		cfg: make(map[string]*ConnectionConfig),
+type ContainerOption interface {
+	apply(cont *Container)
+}
+
+type optionClock struct {
+	clock infraclock.Clock
+}
+
+func (opt optionClock) apply(cont *Container) {
+	cont.clock = opt.clock
+}
+
+// WithClock sets a clock of consumers and producers created by the container, e.g. a fake one in tests
+func WithClock(clock infraclock.Clock) ContainerOption {
+	return optionClock{clock: clock}
+}
+
+type optionResolver struct {
+	resolver *infradns.Resolver
+}
+
+func (opt optionResolver) apply(cont *Container) {
+	cont.resolver = opt.resolver
+}
+
+// WithResolver resolves hosts of connections added to the container with the caching resolver,
+// unless a connection config has its own Resolver
+func WithResolver(resolver *infradns.Resolver) ContainerOption {
+	return optionResolver{resolver: resolver}
+}
+
+func NewContainer(opts ...ContainerOption) *Container {
+	cont := &Container{
+		mu:    &sync.RWMutex{},
+		cfg:   make(map[string]*ConnectionConfig),
+		clock: infraclock.Real,
+	}
+
+	for _, opt := range opts {
+		opt.apply(cont)
 	}
+
+	return cont
 }
 
 // AddConnection adds a named connection to a container.
 // It's possible to create consumer or producer on created connection later using CreateProducer ot CreateConsumer.
 func (cont *Container) AddConnection(name string, cfg *ConnectionConfig) error {
+	if cont.resolver != nil && cfg.Resolver == nil {
+		cfgCopy := *cfg
+		cfgCopy.Resolver = cont.resolver
+		cfg = &cfgCopy
+	}
+
 	cont.mu.Lock()
 	cont.cfg[name] = cfg
 	cont.mu.Unlock()
@@ -48,10 +97,12 @@ func (cont *Container) CreateConsumer(consumerCfg *ConsumerConfig) (*Consumer, e
 	}
 
 	consumer := &Consumer{
-		connCfg: cfg,
-		cfg:     consumerCfg,
-		ch:      make(chan *Message),
-		closed:  make(chan bool),
+		connCfg:      cfg,
+		cfg:          consumerCfg,
+		clock:        cont.clock,
+		ch:           make(chan *Message),
+		closed:       make(chan bool),
+		pauseChanged: make(chan struct{}, 1),
 	}
 
 	go consumer.start()
@@ -75,6 +126,7 @@ func (cont *Container) CreateProducer(producerCfg *ProducerConfig) (*Producer, e
 	p := &Producer{
 		connCfg: cfg,
 		cfg:     producerCfg,
+		clock:   cont.clock,
 	}
 
 	if err := p.start(); err != nil {
@@ -83,3 +135,28 @@ func (cont *Container) CreateProducer(producerCfg *ProducerConfig) (*Producer, e
 
 	return p, nil
 }
+
+// Dial opens a dedicated AMQP connection by a connection name.
+// It's meant for operations not covered by consumers and producers, e.g. queue inspection.
+// The caller is responsible for closing the connection.
+func (cont *Container) Dial(connectionName string) (*amqp.Connection, error) {
+	cont.mu.RLock()
+	cfg, ok := cont.cfg[connectionName]
+	cont.mu.RUnlock()
+
+	if !ok {
+		return nil, errors.Errorf("invalid connection name: %s", connectionName)
+	}
+
+	url, err := createAMQPURL(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create URL")
+	}
+
+	conn, err := dial(cfg, url, amqp.Config{Heartbeat: defaultHeartbeat, Locale: defaultLocale})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to connect to RabbitMQ")
+	}
+
+	return conn, nil
+}
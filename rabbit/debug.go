@@ -0,0 +1,90 @@
+package infrarabbit
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	infradebug "github.com/pushwoosh/infra/debug"
+)
+
+type debugConnection struct {
+	Name     string `json:"name"`
+	Address  string `json:"address"`
+	Vhost    string `json:"vhost"`
+	Username string `json:"username"`
+	TLS      bool   `json:"tls"`
+}
+
+type debugAMQPConnection struct {
+	URL        string `json:"url"`
+	Closed     bool   `json:"closed"`
+	LocalAddr  string `json:"local_addr"`
+	RemoteAddr string `json:"remote_addr"`
+}
+
+type debugState struct {
+	Connections []*debugConnection `json:"connections"`
+
+	// consumer connections shared by all containers
+	AMQPConnections []*debugAMQPConnection `json:"amqp_connections"`
+}
+
+// DebugHandler dumps connections of the container and open consumer connections,
+// register it with infradebug.Register. Passwords are not shown.
+func (cont *Container) DebugHandler() http.Handler {
+	return infradebug.JSON(func(*http.Request) (interface{}, error) {
+		state := &debugState{
+			AMQPConnections: connectionsManager.dump(),
+		}
+
+		cont.mu.RLock()
+		for name, cfg := range cont.cfg {
+			state.Connections = append(state.Connections, &debugConnection{
+				Name:     name,
+				Address:  cfg.Address,
+				Vhost:    cfg.Vhost,
+				Username: cfg.Username,
+				TLS:      cfg.TLS != nil,
+			})
+		}
+		cont.mu.RUnlock()
+
+		slices.SortFunc(state.Connections, func(a, b *debugConnection) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+
+		return state, nil
+	})
+}
+
+func (cp *connManager) dump() []*debugAMQPConnection {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	conns := make([]*debugAMQPConnection, 0, len(cp.connections))
+	for conn, amqpURL := range cp.connections {
+		c := &debugAMQPConnection{
+			URL:    redactURL(amqpURL),
+			Closed: conn.IsClosed(),
+		}
+		if addr := conn.LocalAddr(); addr != nil {
+			c.LocalAddr = addr.String()
+		}
+		if addr := conn.RemoteAddr(); addr != nil {
+			c.RemoteAddr = addr.String()
+		}
+		conns = append(conns, c)
+	}
+
+	return conns
+}
+
+func redactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return ""
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"time"
+
+	infrarabbit "github.com/pushwoosh/infra/rabbit"
+	infrarabbitbench "github.com/pushwoosh/infra/rabbit/bench"
+)
+
+func main() {
+	cfg := &infrarabbitbench.Config{ConnectionName: "bench"}
+	address := flag.String("address", "127.0.0.1:5672", "RabbitMQ address")
+	flag.StringVar(&cfg.Exchange, "exchange", "bench", "exchange")
+	flag.StringVar(&cfg.RoutingKey, "routing-key", "bench", "routing key")
+	flag.StringVar(&cfg.Queue, "queue", "bench", "queue")
+	flag.IntVar(&cfg.Rate, "rate", 1000, "messages per second")
+	flag.IntVar(&cfg.MessageSize, "size", 1024, "message size in bytes")
+	flag.DurationVar(&cfg.Duration, "duration", 30*time.Second, "publishing duration")
+	flag.IntVar(&cfg.Publishers, "publishers", 1, "number of publishers")
+	flag.IntVar(&cfg.PrefetchCount, "prefetch", 16, "consumer prefetch count")
+	flag.IntVar(&cfg.Concurrency, "concurrency", 1, "number of message handlers")
+	flag.DurationVar(&cfg.HandlerDelay, "handler-delay", 0, "simulated processing time")
+	flag.Float64Var(&cfg.NackRate, "nack-rate", 0, "fraction of nacked deliveries")
+	flag.Parse()
+
+	container := infrarabbit.NewContainer()
+	err := container.AddConnection("bench", &infrarabbit.ConnectionConfig{
+		Address:  *address,
+		Username: "guest",
+		Password: "guest",
+		Vhost:    "/",
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	report, err := infrarabbitbench.Run(context.Background(), container, cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	_ = report.Print(os.Stdout)
+}
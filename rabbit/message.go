@@ -3,15 +3,18 @@ package infrarabbit
 import (
 	"sync/atomic"
 
+	infralog "github.com/pushwoosh/infra/log"
 	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
 )
 
 type Message struct {
-	msg      *amqp.Delivery
-	host     string
-	queue    string
-	callback func(error)
-	once     atomic.Bool
+	msg             *amqp.Delivery
+	host            string
+	queue           string
+	maxRedeliveries int
+	callback        func(error)
+	once            atomic.Bool
 }
 
 func (m *Message) Ack() error {
@@ -28,12 +31,32 @@ func (m *Message) Ack() error {
 	return nil
 }
 
+// Nack requeues the message. It's rejected like with Reject instead once it was redelivered
+// ConsumerConfig.MaxRedeliveries times
 func (m *Message) Nack() error {
+	requeue := m.maxRedeliveries <= 0 || m.Redeliveries() < m.maxRedeliveries
+	if !requeue {
+		infralog.Warn("rabbit: rejecting message over redelivery limit",
+			zap.String("queue", m.queue),
+			zap.String("routing_key", m.RoutingKey()),
+			zap.Int("redeliveries", m.Redeliveries()))
+	}
+
+	return m.nack(requeue)
+}
+
+// Reject removes the message from the queue without processing,
+// it's routed to the dead-letter exchange of the queue if there is one
+func (m *Message) Reject() error {
+	return m.nack(false)
+}
+
+func (m *Message) nack(requeue bool) error {
 	if m.once.Swap(true) {
 		return nil
 	}
 
-	if err := m.msg.Nack(false, true); err != nil {
+	if err := m.msg.Nack(false, requeue); err != nil {
 		m.callback(err)
 		return err
 	}
@@ -46,6 +69,64 @@ func (m *Message) IsRedelivered() bool {
 	return m.msg.Redelivered
 }
 
+// Redeliveries returns the number of previous deliveries of the message.
+// Only quorum queues count them, a redelivered message of other queues returns 1
+func (m *Message) Redeliveries() int {
+	switch count := m.msg.Headers[DeliveryCountHeader].(type) {
+	case int64:
+		return int(count)
+	case int32:
+		return int(count)
+	case int:
+		return count
+	}
+
+	if m.msg.Redelivered {
+		return 1
+	}
+	return 0
+}
+
 func (m *Message) Body() []byte {
 	return m.msg.Body
 }
+
+// MessageID returns message id set by the publisher. It's empty if not set
+func (m *Message) MessageID() string {
+	return m.msg.MessageId
+}
+
+// CorrelationID returns correlation id set by the publisher. It's empty if not set
+func (m *Message) CorrelationID() string {
+	return m.msg.CorrelationId
+}
+
+// Headers returns message headers
+func (m *Message) Headers() map[string]interface{} {
+	return m.msg.Headers
+}
+
+// Exchange returns the exchange the message was published to
+func (m *Message) Exchange() string {
+	return m.msg.Exchange
+}
+
+// RoutingKey returns the routing key the message was published with
+func (m *Message) RoutingKey() string {
+	return m.msg.RoutingKey
+}
+
+// Type returns message type property. It's empty if not set
+func (m *Message) Type() string {
+	return m.msg.Type
+}
+
+// Priority returns message priority property
+func (m *Message) Priority() uint8 {
+	return m.msg.Priority
+}
+
+// SetBody replaces the message body. It's used by middlewares transforming payloads before the handler
+func (m *Message) SetBody(body []byte) {
+	m.msg.Body = body
+}
@@ -0,0 +1,79 @@
+package infrarabbitmonitor
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type Config struct {
+	// Queue is a name of the monitored queue, it's used as a metrics label
+	Queue string `mapstructure:"queue"`
+
+	// Interval of queue length sampling. optional, default: 10s
+	Interval time.Duration `mapstructure:"interval"`
+
+	// Window is a period rates and latency are calculated over. optional, default: 1m
+	Window time.Duration `mapstructure:"window"`
+
+	// The consumer is degraded when the queue grows faster than that, messages per second,
+	// and it's longer than MinQueueLength. optional, default: 0, any growth
+	MaxGrowthRate float64 `mapstructure:"max_growth_rate"`
+
+	// Queue growth is ignored while the queue is shorter. optional, default: 1000
+	MinQueueLength int `mapstructure:"min_queue_length"`
+
+	// The consumer is degraded when the queue is longer. optional
+	MaxQueueLength int `mapstructure:"max_queue_length"`
+
+	// The consumer is degraded when the average handler latency is higher. optional
+	MaxLatency time.Duration `mapstructure:"max_latency"`
+
+	// The consumer is degraded when the queue can't be drained in that time at the current rates. optional
+	MaxDrainTime time.Duration `mapstructure:"max_drain_time"`
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if c.Queue == "" {
+		return errors.New("queue is mandatory")
+	}
+
+	if c.Interval < 0 || c.Window < 0 || c.MaxLatency < 0 || c.MaxDrainTime < 0 {
+		return errors.New("durations should be greater than or equal to 0")
+	}
+
+	if c.GetWindow() < 2*c.GetInterval() {
+		return errors.New("window should be at least two intervals")
+	}
+
+	if c.MaxGrowthRate < 0 || c.MinQueueLength < 0 || c.MaxQueueLength < 0 {
+		return errors.New("thresholds should be greater than or equal to 0")
+	}
+
+	return nil
+}
+
+func (c *Config) GetInterval() time.Duration {
+	if c.Interval == 0 {
+		return 10 * time.Second
+	}
+	return c.Interval
+}
+
+func (c *Config) GetWindow() time.Duration {
+	if c.Window == 0 {
+		return time.Minute
+	}
+	return c.Window
+}
+
+func (c *Config) GetMinQueueLength() int {
+	if c.MinQueueLength == 0 {
+		return 1000
+	}
+	return c.MinQueueLength
+}
@@ -0,0 +1,41 @@
+package infrarabbitmonitor
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	inframetrics "github.com/pushwoosh/infra/metrics"
+)
+
+var metrics struct {
+	DegradedGauge   *inframetrics.GaugeVec
+	GrowthRateGauge *inframetrics.GaugeVec
+	AckRateGauge    *inframetrics.GaugeVec
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.DegradedGauge = inframetrics.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "rabbit_consumer_degraded",
+			Help: "1 if the consumer of the queue is falling behind, 0 otherwise",
+		}, []string{"queue"})
+
+		metrics.GrowthRateGauge = inframetrics.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "rabbit_consumer_queue_growth_rate",
+			Help: "Queue length change per second over the monitor window",
+		}, []string{"queue"})
+
+		metrics.AckRateGauge = inframetrics.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "rabbit_consumer_ack_rate",
+			Help: "Messages acked by the consumer per second over the monitor window",
+		}, []string{"queue"})
+
+		prometheus.MustRegister(
+			metrics.DegradedGauge,
+			metrics.GrowthRateGauge,
+			metrics.AckRateGauge,
+		)
+	})
+}
@@ -0,0 +1,333 @@
+package infrarabbitmonitor
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	infraclock "github.com/pushwoosh/infra/clock"
+	infradebug "github.com/pushwoosh/infra/debug"
+	infralog "github.com/pushwoosh/infra/log"
+	infrarabbit "github.com/pushwoosh/infra/rabbit"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+const (
+	StateHealthy  = "healthy"
+	StateDegraded = "degraded"
+)
+
+// Reasons of degradation
+const (
+	ReasonQueueGrowth = "queue_growth"
+	ReasonQueueLength = "queue_length"
+	ReasonLatency     = "latency"
+	ReasonDrainTime   = "drain_time"
+)
+
+// Status is a consumer state calculated over the monitor window
+type Status struct {
+	Queue   string    `json:"queue"`
+	State   string    `json:"state"`
+	Reasons []string  `json:"reasons,omitempty"`
+	Since   time.Time `json:"since"`
+
+	QueueLength int `json:"queue_length"`
+
+	// GrowthRate is a queue length change per second, negative when the queue is draining
+	GrowthRate float64 `json:"growth_rate"`
+
+	// AckRate is a number of acked messages per second, IncomingRate is an estimated publish rate
+	AckRate      float64 `json:"ack_rate"`
+	IncomingRate float64 `json:"incoming_rate"`
+
+	AvgLatency time.Duration `json:"avg_latency"`
+
+	// DrainTime is an estimated time to drain the queue at current rates, -1 if it's not draining
+	DrainTime time.Duration `json:"drain_time"`
+}
+
+// LengthFunc returns the number of ready messages in the queue
+type LengthFunc func(ctx context.Context) (int, error)
+
+// QueueLength inspects the queue with a dedicated connection of the container. The connection and its channel
+// are kept between polls and dialed again after an error. They are closed when ctx of the poll is done,
+// i.e. when Run of the monitor returns
+func QueueLength(cont *infrarabbit.Container, connection, queue string) LengthFunc {
+	var (
+		mu   sync.Mutex
+		conn *amqp.Connection
+		ch   *amqp.Channel
+		stop func() bool
+	)
+
+	// reset closes the connection, mu must be held
+	reset := func() {
+		if conn == nil {
+			return
+		}
+		stop()
+		_ = conn.Close()
+		conn, ch = nil, nil
+	}
+
+	return func(ctx context.Context) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if conn == nil || conn.IsClosed() {
+			reset()
+
+			c, err := cont.Dial(connection)
+			if err != nil {
+				return 0, err
+			}
+
+			channel, err := c.Channel()
+			if err != nil {
+				_ = c.Close()
+				return 0, errors.Wrap(err, "unable to create rabbitmq channel")
+			}
+
+			conn, ch = c, channel
+			stop = context.AfterFunc(ctx, func() {
+				mu.Lock()
+				defer mu.Unlock()
+				reset()
+			})
+		}
+
+		q, err := ch.QueueDeclarePassive(queue, false, false, false, false, nil)
+		if err != nil {
+			// a failed declaration closes the channel
+			reset()
+			return 0, errors.Wrapf(err, "unable to inspect queue %s", queue)
+		}
+
+		return q.Messages, nil
+	}
+}
+
+type sample struct {
+	at         time.Time
+	length     int
+	acks       int64
+	handled    int64
+	latencySum int64
+}
+
+// Monitor detects a consumer falling behind by queue growth, ack rate and handler latency.
+// Handler stats are collected by Middleware, queue length is sampled every interval:
+//
+//	monitor, err := infrarabbitmonitor.New(cfg, infrarabbitmonitor.QueueLength(cont, "main", "events"),
+//		infrarabbitmonitor.WithOnDegraded(func(ctx context.Context, status infrarabbitmonitor.Status) {
+//			scaler.ScaleUp(ctx)
+//		}))
+//	router.Use(monitor.Middleware())
+//	app.Add("consumer_monitor", monitor)
+type Monitor struct {
+	cfg    *Config
+	length LengthFunc
+	clock  infraclock.Clock
+
+	onDegraded  func(ctx context.Context, status Status)
+	onRecovered func(ctx context.Context, status Status)
+
+	acks       atomic.Int64
+	handled    atomic.Int64
+	latencySum atomic.Int64
+
+	mu      sync.RWMutex
+	samples []sample
+	status  Status
+}
+
+func New(cfg *Config, length LengthFunc, opts ...Option) (*Monitor, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	initMetrics()
+
+	m := &Monitor{
+		cfg:    cfg,
+		length: length,
+		clock:  infraclock.Real,
+	}
+
+	for _, opt := range opts {
+		opt.apply(m)
+	}
+
+	m.status = Status{Queue: cfg.Queue, State: StateHealthy, Since: m.clock.Now(), DrainTime: -1}
+	metrics.DegradedGauge.WithLabelValues(cfg.Queue).Set(0)
+
+	return m, nil
+}
+
+// Middleware collects handler latency and acks. Successful and malformed messages are counted as acked
+func (m *Monitor) Middleware() infrarabbit.Middleware {
+	return func(next infrarabbit.HandlerFunc) infrarabbit.HandlerFunc {
+		return func(ctx context.Context, msg *infrarabbit.Message) error {
+			start := m.clock.Now()
+			err := next(ctx, msg)
+
+			m.latencySum.Add(int64(m.clock.Now().Sub(start)))
+			m.handled.Add(1)
+			if err == nil || errors.Is(err, infrarabbit.ErrMalformed) {
+				m.acks.Add(1)
+			}
+
+			return err
+		}
+	}
+}
+
+// Run samples the queue until ctx is canceled
+func (m *Monitor) Run(ctx context.Context) error {
+	ticker := m.clock.NewTicker(m.cfg.GetInterval())
+	defer ticker.Stop()
+
+	m.sample(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C():
+			m.sample(ctx)
+		}
+	}
+}
+
+// Status returns the current consumer status
+func (m *Monitor) Status() Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.status
+}
+
+// DebugHandler shows the current status, register it with infradebug.Register
+func (m *Monitor) DebugHandler() http.Handler {
+	return infradebug.JSON(func(*http.Request) (interface{}, error) {
+		return m.Status(), nil
+	})
+}
+
+func (m *Monitor) sample(ctx context.Context) {
+	length, err := m.length(ctx)
+	if err != nil {
+		infralog.WarnCtx(ctx, "rabbit monitor: unable to get queue length",
+			zap.String("queue", m.cfg.Queue), zap.Error(err))
+		return
+	}
+
+	s := sample{
+		at:         m.clock.Now(),
+		length:     length,
+		acks:       m.acks.Load(),
+		handled:    m.handled.Load(),
+		latencySum: m.latencySum.Load(),
+	}
+
+	m.mu.Lock()
+	m.samples = append(m.samples, s)
+	size := int(m.cfg.GetWindow()/m.cfg.GetInterval()) + 1
+	if len(m.samples) > size {
+		m.samples = m.samples[len(m.samples)-size:]
+	}
+	if len(m.samples) < size {
+		m.mu.Unlock()
+		return
+	}
+
+	prev := m.status
+	m.status = m.evaluate(m.samples[0], s)
+	if m.status.State == prev.State {
+		m.status.Since = prev.Since
+	}
+	status := m.status
+	m.mu.Unlock()
+
+	metrics.GrowthRateGauge.WithLabelValues(m.cfg.Queue).Set(status.GrowthRate)
+	metrics.AckRateGauge.WithLabelValues(m.cfg.Queue).Set(status.AckRate)
+
+	if status.State == prev.State {
+		return
+	}
+
+	if status.State == StateDegraded {
+		metrics.DegradedGauge.WithLabelValues(m.cfg.Queue).Set(1)
+		infralog.WarnCtx(ctx, "rabbit monitor: consumer is falling behind",
+			zap.String("queue", m.cfg.Queue),
+			zap.Strings("reasons", status.Reasons),
+			zap.Int("queue_length", status.QueueLength),
+			zap.Float64("growth_rate", status.GrowthRate),
+			zap.Float64("ack_rate", status.AckRate))
+		if m.onDegraded != nil {
+			m.onDegraded(ctx, status)
+		}
+		return
+	}
+
+	metrics.DegradedGauge.WithLabelValues(m.cfg.Queue).Set(0)
+	infralog.InfoCtx(ctx, "rabbit monitor: consumer recovered", zap.String("queue", m.cfg.Queue))
+	if m.onRecovered != nil {
+		m.onRecovered(ctx, status)
+	}
+}
+
+func (m *Monitor) evaluate(first, last sample) Status {
+	elapsed := last.at.Sub(first.at).Seconds()
+
+	status := Status{
+		Queue:       m.cfg.Queue,
+		State:       StateHealthy,
+		Since:       last.at,
+		QueueLength: last.length,
+		GrowthRate:  float64(last.length-first.length) / elapsed,
+		AckRate:     float64(last.acks-first.acks) / elapsed,
+		DrainTime:   -1,
+	}
+	status.IncomingRate = math.Max(0, status.GrowthRate+status.AckRate)
+
+	if handled := last.handled - first.handled; handled > 0 {
+		status.AvgLatency = time.Duration((last.latencySum - first.latencySum) / handled)
+	}
+
+	switch {
+	case last.length == 0:
+		status.DrainTime = 0
+	case status.GrowthRate < 0:
+		status.DrainTime = time.Duration(float64(last.length) / -status.GrowthRate * float64(time.Second))
+	}
+
+	backlog := last.length >= m.cfg.GetMinQueueLength()
+
+	if backlog && status.GrowthRate > m.cfg.MaxGrowthRate {
+		status.Reasons = append(status.Reasons, ReasonQueueGrowth)
+	}
+
+	if m.cfg.MaxQueueLength > 0 && last.length > m.cfg.MaxQueueLength {
+		status.Reasons = append(status.Reasons, ReasonQueueLength)
+	}
+
+	if m.cfg.MaxLatency > 0 && status.AvgLatency > m.cfg.MaxLatency {
+		status.Reasons = append(status.Reasons, ReasonLatency)
+	}
+
+	if backlog && m.cfg.MaxDrainTime > 0 && (status.DrainTime < 0 || status.DrainTime > m.cfg.MaxDrainTime) {
+		status.Reasons = append(status.Reasons, ReasonDrainTime)
+	}
+
+	if len(status.Reasons) > 0 {
+		status.State = StateDegraded
+	}
+
+	return status
+}
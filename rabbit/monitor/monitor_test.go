@@ -0,0 +1,59 @@
+package infrarabbitmonitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	infraclock "github.com/pushwoosh/infra/clock"
+)
+
+func TestMonitor(t *testing.T) {
+	ctx := context.Background()
+	clock := infraclock.NewFake(time.Now())
+
+	length := 0
+	var degraded, recovered int
+
+	m, err := New(&Config{Queue: "test", Interval: 10 * time.Second, Window: 20 * time.Second, MinQueueLength: 100},
+		func(context.Context) (int, error) { return length, nil },
+		WithClock(clock),
+		WithOnDegraded(func(context.Context, Status) { degraded++ }),
+		WithOnRecovered(func(context.Context, Status) { recovered++ }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the queue grows by 10 messages per second while 5 are acked
+	for _, l := range []int{100, 200, 300} {
+		length = l
+		m.acks.Add(50)
+		m.sample(ctx)
+		clock.Advance(10 * time.Second)
+	}
+
+	status := m.Status()
+	if status.State != StateDegraded || status.Reasons[0] != ReasonQueueGrowth || degraded != 1 {
+		t.Fatalf("expected degraded status, got %+v", status)
+	}
+	if status.GrowthRate != 10 || status.AckRate != 5 || status.IncomingRate != 15 {
+		t.Fatalf("unexpected rates %+v", status)
+	}
+
+	// the consumer is scaled up and drains the queue
+	for _, l := range []int{200, 100} {
+		length = l
+		m.acks.Add(250)
+		m.sample(ctx)
+		clock.Advance(10 * time.Second)
+	}
+
+	status = m.Status()
+	if status.State != StateHealthy || recovered != 1 {
+		t.Fatalf("expected healthy status, got %+v", status)
+	}
+	if status.DrainTime != 10*time.Second {
+		t.Fatalf("expected drain time 10s, got %s", status.DrainTime)
+	}
+}
@@ -0,0 +1,47 @@
+package infrarabbitmonitor
+
+import (
+	"context"
+
+	infraclock "github.com/pushwoosh/infra/clock"
+)
+
+type Option interface {
+	apply(m *Monitor)
+}
+
+type optionOnDegraded func(ctx context.Context, status Status)
+
+func (opt optionOnDegraded) apply(m *Monitor) {
+	m.onDegraded = opt
+}
+
+// WithOnDegraded sets a callback called when the consumer starts falling behind,
+// e.g. to scale consumers up or to page. It's called from the monitor goroutine
+func WithOnDegraded(fn func(ctx context.Context, status Status)) Option {
+	return optionOnDegraded(fn)
+}
+
+type optionOnRecovered func(ctx context.Context, status Status)
+
+func (opt optionOnRecovered) apply(m *Monitor) {
+	m.onRecovered = opt
+}
+
+// WithOnRecovered sets a callback called when the degraded consumer catches up
+func WithOnRecovered(fn func(ctx context.Context, status Status)) Option {
+	return optionOnRecovered(fn)
+}
+
+type optionClock struct {
+	clock infraclock.Clock
+}
+
+func (opt optionClock) apply(m *Monitor) {
+	m.clock = opt.clock
+}
+
+// WithClock sets a clock of sampling, e.g. a fake one in tests
+func WithClock(clock infraclock.Clock) Option {
+	return optionClock{clock: clock}
+}
@@ -8,6 +8,9 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	infraclock "github.com/pushwoosh/infra/clock"
+	infrarequestid "github.com/pushwoosh/infra/requestid"
+	infratenancy "github.com/pushwoosh/infra/tenancy"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
@@ -19,32 +22,58 @@ const (
 	intervalToCheckIsConnectionClosed = 200 * time.Millisecond
 )
 
+// ErrNacked is returned by Produce when the broker rejects a message published with confirms,
+// e.g. by a queue overflowing with reject-publish. It isn't retried, the broker is available.
+var ErrNacked = errors.New("message was nacked by broker")
+
+// ErrUnroutable is returned by Produce when the broker returns a mandatory message that can't be routed to any queue.
+// It isn't retried, the broker is available.
+var ErrUnroutable = errors.New("message was returned by broker as unroutable")
+
+// Publisher publishes messages. It's implemented by Producer and its wrappers, e.g. infraspool.Producer
+// or infrasigning.Producer, so wrappers can be composed:
+//
+//	publisher := infrabreaker.WrapProducer(b, infrasigning.WrapProducer(signer, rabbitProducer))
+type Publisher interface {
+	Produce(ctx context.Context, msg *ProducerMessage) error
+}
+
+var _ Publisher = (*Producer)(nil)
+
 type Producer struct {
 	connCfg                      *ConnectionConfig
 	cfg                          *ProducerConfig
+	clock                        infraclock.Clock
 	producerAMQPChannel          *amqp.Channel
 	producerAMQPConnection       *amqp.Connection
 	producerAMQPConnectionErrors chan *amqp.Error
 	producerAMQPChannelErrors    chan *amqp.Error
+	producerAMQPReturns          chan amqp.Return
 	isNeedReconnect              bool
 	isLocked                     sync.Mutex
 	isClosed                     bool
 }
 
 type ProducerMessage struct {
-	Body       []byte
-	Exchange   string
-	RoutingKey string
-	Priority   uint8
+	Body          []byte
+	Exchange      string
+	RoutingKey    string
+	Priority      uint8
+	MessageID     string                 // optional
+	CorrelationID string                 // optional
+	Headers       map[string]interface{} // optional
+	Mandatory     bool                   // optional, Produce fails with ErrUnroutable if no queue gets the message. Requires Confirm
 }
 
 func (p *Producer) start() error {
-	if err := p.reconnect(); err != nil {
+	if p.connCfg.Lazy {
+		p.isNeedReconnect = true
+	} else if err := p.reconnect(); err != nil {
 		return errors.Wrap(err, "unable to create initial connection to RabbitMQ")
 	}
 
 	go func() {
-		ticker := time.NewTicker(intervalToCheckIsConnectionClosed)
+		ticker := p.clock.NewTicker(intervalToCheckIsConnectionClosed)
 		defer ticker.Stop()
 
 		for {
@@ -55,14 +84,14 @@ func (p *Producer) start() error {
 			case ev, isOpen := <-p.producerAMQPChannelErrors:
 				if ev != nil || !isOpen {
 					p.isNeedReconnect = true
-					time.Sleep(intervalToCheckIsNeedReconnect)
+					_ = p.clock.Sleep(context.Background(), intervalToCheckIsNeedReconnect)
 				}
 			case ev, isOpen := <-p.producerAMQPConnectionErrors:
 				if ev != nil || !isOpen {
 					p.isNeedReconnect = true
-					time.Sleep(intervalToCheckIsNeedReconnect)
+					_ = p.clock.Sleep(context.Background(), intervalToCheckIsNeedReconnect)
 				}
-			case <-ticker.C:
+			case <-ticker.C():
 				continue
 			}
 		}
@@ -71,23 +100,29 @@ func (p *Producer) start() error {
 	return nil
 }
 
-func (p *Producer) Produce(pCtx context.Context, msg *ProducerMessage) error {
+func (p *Producer) Produce(pCtx context.Context, msg *ProducerMessage) (err error) {
 	p.isLocked.Lock()
 	defer p.isLocked.Unlock()
 
 	if msg == nil {
 		return errors.New("message is nil")
 	}
+	if msg.Mandatory && !p.cfg.Confirm {
+		return errors.New("mandatory messages require a producer with confirm")
+	}
 	if p.isClosed {
 		return errors.New("AMQP producer is closed")
 	}
+
+	pCtx, span := startPublishSpan(pCtx, msg)
+	defer func() { endSpan(span, err) }()
+
 	if p.isNeedReconnect {
 		if reconnectErr := p.reconnect(); reconnectErr != nil {
 			return errors.Wrap(reconnectErr, "isNeedReconnect is true: unable to reconnect in Produce()")
 		}
 	}
 
-	var err error
 	countOfConnectionRetry := 0
 	lastErrors := make([]string, 0)
 
@@ -98,25 +133,20 @@ func (p *Producer) Produce(pCtx context.Context, msg *ProducerMessage) error {
 		defer cancel()
 
 		if p.producerAMQPChannel != nil {
-			if err = p.producerAMQPChannel.PublishWithContext(
-				ctx,
-				msg.Exchange,
-				msg.RoutingKey,
-				false,
-				false,
-				amqp.Publishing{
-					Body:      msg.Body,
-					Priority:  msg.Priority,
-					Timestamp: time.Now(),
-				},
-			); err == nil {
+			if err = p.publish(ctx, msg); err == nil {
 				return nil
+			} else if errors.Is(err, ErrNacked) || errors.Is(err, ErrUnroutable) {
+				// the broker is available, it rejected the message
+				return err
 			} else {
 				lastErrors = append(lastErrors, err.Error())
 			}
 		}
 
-		time.Sleep(retryProducerTimeout)
+		if sleepErr := p.clock.Sleep(pCtx, retryProducerTimeout); sleepErr != nil {
+			lastErrors = append(lastErrors, sleepErr.Error())
+			break
+		}
 		if reconnectErr := p.reconnect(); reconnectErr != nil {
 			lastErrors = append(lastErrors, fmt.Sprintf("unable to reconnect in Produce(), next try: %d, %s",
 				countOfConnectionRetry,
@@ -127,6 +157,59 @@ func (p *Producer) Produce(pCtx context.Context, msg *ProducerMessage) error {
 	return errors.Errorf("unable to produce AMQP message: %s", strings.Join(lastErrors, ": "))
 }
 
+func (p *Producer) publish(ctx context.Context, msg *ProducerMessage) error {
+	publishing := amqp.Publishing{
+		Body:          msg.Body,
+		Priority:      msg.Priority,
+		Timestamp:     p.clock.Now(),
+		MessageId:     msg.MessageID,
+		CorrelationId: msg.CorrelationID,
+		Headers:       infratenancy.InjectHeaders(ctx, infrarequestid.InjectHeaders(ctx, injectTrace(ctx, msg.Headers))),
+	}
+
+	if !p.cfg.Confirm {
+		return p.producerAMQPChannel.PublishWithContext(ctx, msg.Exchange, msg.RoutingKey, false, false, publishing)
+	}
+
+	// returns of previous messages whose confirmation wasn't awaited
+	p.drainReturns()
+
+	confirmation, err := p.producerAMQPChannel.PublishWithDeferredConfirmWithContext(
+		ctx, msg.Exchange, msg.RoutingKey, msg.Mandatory, false, publishing)
+	if err != nil {
+		return err
+	}
+
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return ErrNacked
+	}
+
+	// the broker sends basic.return before basic.ack, so the return is already received
+	if msg.Mandatory {
+		select {
+		case ret := <-p.producerAMQPReturns:
+			return errors.Wrapf(ErrUnroutable, "%d %s", ret.ReplyCode, ret.ReplyText)
+		default:
+		}
+	}
+
+	return nil
+}
+
+func (p *Producer) drainReturns() {
+	for {
+		select {
+		case <-p.producerAMQPReturns:
+		default:
+			return
+		}
+	}
+}
+
 func (p *Producer) reconnect() error {
 	if p.producerAMQPConnection != nil {
 		_ = p.producerAMQPConnection.Close()
@@ -153,7 +236,7 @@ func (p *Producer) reconnect() error {
 		return errors.Wrap(err, "unable to create URL for producer")
 	}
 
-	conn, err := amqp.Dial(url)
+	conn, err := dial(p.connCfg, url, amqp.Config{Heartbeat: defaultHeartbeat, Locale: defaultLocale})
 	if err != nil {
 		return errors.Wrap(err, "unable to connect to RabbitMQ")
 	}
@@ -164,6 +247,16 @@ func (p *Producer) reconnect() error {
 		return errors.Wrap(err, "unable to get channel in connection to RabbitMQ")
 	}
 
+	if p.cfg.Confirm {
+		if err = ch.Confirm(false); err != nil {
+			_ = conn.Close()
+			return errors.Wrap(err, "unable to put channel into confirm mode")
+		}
+
+		// returns are read by publish, the buffer keeps the channel reading when nobody waits for them
+		p.producerAMQPReturns = ch.NotifyReturn(make(chan amqp.Return, 16))
+	}
+
 	producerAMQPChannelErrors := make(chan *amqp.Error)
 	ch.NotifyClose(producerAMQPChannelErrors)
 	p.producerAMQPChannelErrors = producerAMQPChannelErrors
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+const (
+	contextPackage     = protogen.GoImportPath("context")
+	rabbitPackage      = protogen.GoImportPath("github.com/pushwoosh/infra/rabbit")
+	rabbitprotoPackage = protogen.GoImportPath("github.com/pushwoosh/infra/rabbit/proto")
+
+	directivePrefix = "rabbit:"
+)
+
+func generateFile(plugin *protogen.Plugin, file *protogen.File) error {
+	g := plugin.NewGeneratedFile(file.GeneratedFilenamePrefix+"_rabbit.pb.go", file.GoImportPath)
+
+	g.P("// Code generated by protoc-gen-go-rabbit. DO NOT EDIT.")
+	g.P("// source: ", file.Desc.Path())
+	g.P()
+	g.P("package ", file.GoPackageName)
+	g.P()
+
+	for _, service := range file.Services {
+		if err := generateService(g, service); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func generateService(g *protogen.GeneratedFile, service *protogen.Service) error {
+	name := service.GoName
+
+	exchange := directive(service.Comments.Leading, "exchange")
+	if exchange == "" {
+		exchange = snakeCase(name, "_")
+	}
+
+	routingKeys := make([]string, len(service.Methods))
+	for i, method := range service.Methods {
+		if method.Desc.IsStreamingClient() || method.Desc.IsStreamingServer() {
+			return fmt.Errorf("%s.%s: streaming methods are not supported", name, method.GoName)
+		}
+
+		routingKeys[i] = directive(method.Comments.Leading, "routing_key")
+		if routingKeys[i] == "" {
+			routingKeys[i] = snakeCase(method.GoName, ".")
+		}
+		if strings.ContainsAny(routingKeys[i], "*#") {
+			return fmt.Errorf("%s.%s: routing key %q should not contain wildcards", name, method.GoName, routingKeys[i])
+		}
+	}
+
+	g.P("const (")
+	g.P("// ", name, "Exchange is the exchange of ", name, " messages")
+	g.P(name, "Exchange = ", fmt.Sprintf("%q", exchange))
+	for i, method := range service.Methods {
+		g.P("// ", routingKeyConst(service, method), " is the routing key of ", method.GoName, " messages")
+		g.P(routingKeyConst(service, method), " = ", fmt.Sprintf("%q", routingKeys[i]))
+	}
+	g.P(")")
+	g.P()
+
+	ctx := g.QualifiedGoIdent(contextPackage.Ident("Context"))
+	message := g.QualifiedGoIdent(rabbitPackage.Ident("Message"))
+
+	g.P("// ", name, "Publisher publishes ", name, " messages")
+	g.P("type ", name, "Publisher struct {")
+	g.P("publisher *", rabbitprotoPackage.Ident("Publisher"))
+	g.P("}")
+	g.P()
+	g.P("func New", name, "Publisher(producer ", rabbitprotoPackage.Ident("Producer"), ", opts ...", rabbitprotoPackage.Ident("Option"), ") *", name, "Publisher {")
+	g.P("return &", name, "Publisher{publisher: ", rabbitprotoPackage.Ident("NewPublisher"), "(producer, opts...)}")
+	g.P("}")
+	g.P()
+	for _, method := range service.Methods {
+		comment(g, "Publish"+method.GoName, "publishes "+method.GoName+" message", method.Comments.Leading)
+		g.P("func (p *", name, "Publisher) Publish", method.GoName, "(ctx ", ctx, ", msg *", method.Input.GoIdent, ") error {")
+		g.P("return p.publisher.Publish(ctx, ", name, "Exchange, ", routingKeyConst(service, method), ", msg)")
+		g.P("}")
+		g.P()
+	}
+
+	g.P("// ", name, "Handler handles ", name, " messages. The message is acked on nil error and requeued on any error")
+	g.P("// except infrarabbit.ErrMalformed, see infrarabbit.HandlerFunc")
+	g.P("type ", name, "Handler interface {")
+	for _, method := range service.Methods {
+		g.P("Handle", method.GoName, "(ctx ", ctx, ", msg *", message, ", payload *", method.Input.GoIdent, ") error")
+	}
+	g.P("}")
+	g.P()
+	g.P("// Register", name, "Handler routes ", name, " messages of the router to the handler by routing key.")
+	g.P("// Undecodable messages are dropped as malformed")
+	g.P("func Register", name, "Handler(router *", rabbitPackage.Ident("Router"), ", handler ", name, "Handler) {")
+	for _, method := range service.Methods {
+		g.P("router.Handle(", routingKeyConst(service, method), ", ", rabbitprotoPackage.Ident("Handler"), "(handler.Handle", method.GoName, "))")
+	}
+	g.P("}")
+	g.P()
+
+	return nil
+}
+
+func routingKeyConst(service *protogen.Service, method *protogen.Method) string {
+	return service.GoName + method.GoName + "RoutingKey"
+}
+
+// comment writes the leading comment of a method without directives or a default one
+func comment(g *protogen.GeneratedFile, name, fallback string, leading protogen.Comments) {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(string(leading)), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, directivePrefix) {
+			lines = append(lines, line)
+		}
+	}
+
+	if len(lines) == 0 {
+		g.P("// ", name, " ", fallback)
+		return
+	}
+	for _, line := range lines {
+		g.P("// ", line)
+	}
+}
+
+// directive returns the value of "rabbit:<name> <value>" line of the comment
+func directive(leading protogen.Comments, name string) string {
+	for _, line := range strings.Split(string(leading), "\n") {
+		value, ok := strings.CutPrefix(strings.TrimSpace(line), directivePrefix+name+" ")
+		if ok {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+func snakeCase(name, sep string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteString(sep)
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
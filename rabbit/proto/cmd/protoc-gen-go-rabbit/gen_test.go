@@ -0,0 +1,72 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+func TestGenerate(t *testing.T) {
+	message := func(name string) *descriptorpb.DescriptorProto {
+		return &descriptorpb.DescriptorProto{Name: proto.String(name)}
+	}
+	method := func(name, input string) *descriptorpb.MethodDescriptorProto {
+		return &descriptorpb.MethodDescriptorProto{Name: proto.String(name), InputType: proto.String(input), OutputType: proto.String(".users.Empty")}
+	}
+
+	file := &descriptorpb.FileDescriptorProto{
+		Name:        proto.String("users.proto"),
+		Package:     proto.String("users"),
+		Syntax:      proto.String("proto3"),
+		Options:     &descriptorpb.FileOptions{GoPackage: proto.String("example.com/userspb")},
+		MessageType: []*descriptorpb.DescriptorProto{message("UserCreated"), message("UserDeleted"), message("Empty")},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name:   proto.String("UserEvents"),
+			Method: []*descriptorpb.MethodDescriptorProto{method("UserCreated", ".users.UserCreated"), method("UserDeleted", ".users.UserDeleted")},
+		}},
+		SourceCodeInfo: &descriptorpb.SourceCodeInfo{Location: []*descriptorpb.SourceCodeInfo_Location{
+			{Path: []int32{6, 0}, Span: []int32{0, 0, 0}, LeadingComments: proto.String(" rabbit:exchange users\n")},
+			{Path: []int32{6, 0, 2, 0}, Span: []int32{1, 0, 0}, LeadingComments: proto.String(" Sent after signup.\n rabbit:routing_key user.signed_up\n")},
+		}},
+	}
+
+	plugin, err := protogen.Options{}.New(&pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{"users.proto"},
+		ProtoFile:      []*descriptorpb.FileDescriptorProto{file},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = generateFile(plugin, plugin.Files[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := plugin.Response()
+	if resp.Error != nil || len(resp.File) != 1 || resp.File[0].GetName() != "example.com/userspb/users_rabbit.pb.go" {
+		t.Fatalf("unexpected response %v", resp)
+	}
+
+	content := resp.File[0].GetContent()
+	if _, err = parser.ParseFile(token.NewFileSet(), "users_rabbit.pb.go", content, 0); err != nil {
+		t.Fatalf("generated invalid code: %v\n%s", err, content)
+	}
+
+	for _, expected := range []string{
+		`UserEventsExchange = "users"`,
+		`UserEventsUserCreatedRoutingKey = "user.signed_up"`,
+		`UserEventsUserDeletedRoutingKey = "user.deleted"`,
+		"// Sent after signup.\nfunc (p *UserEventsPublisher) PublishUserCreated(ctx context.Context, msg *UserCreated) error {",
+		"HandleUserDeleted(ctx context.Context, msg *rabbit.Message, payload *UserDeleted) error",
+		"router.Handle(UserEventsUserCreatedRoutingKey, proto.Handler(handler.HandleUserCreated))",
+	} {
+		if !strings.Contains(content, expected) {
+			t.Fatalf("expected %q in generated code:\n%s", expected, content)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+// protoc-gen-go-rabbit generates typed RabbitMQ publishers and handlers on top of infrarabbit
+// from protobuf services. Every rpc of a service is a message published to the service exchange,
+// its input type is the body, the output type is ignored, use google.protobuf.Empty:
+//
+//	// rabbit:exchange users
+//	service Users {
+//	  // rabbit:routing_key user.created
+//	  rpc UserCreated(UserCreated) returns (google.protobuf.Empty);
+//	  rpc UserDeleted(UserDeleted) returns (google.protobuf.Empty);
+//	}
+//
+// The exchange is the snake case service name and routing keys are method names in dotted snake case
+// (user.deleted) unless set with comment directives. The plugin is run by protoc or buf next to protoc-gen-go:
+//
+//	//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-rabbit_out=. --go-rabbit_opt=paths=source_relative users.proto
+//
+// For every service it generates exchange and routing key constants, <Service>Publisher with Publish<Method>
+// methods, <Service>Handler interface and Register<Service>Handler routing messages of an infrarabbit.Router
+// to the handler.
+package main
+
+import (
+	"flag"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+func main() {
+	var flags flag.FlagSet
+
+	protogen.Options{ParamFunc: flags.Set}.Run(func(plugin *protogen.Plugin) error {
+		plugin.SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
+
+		for _, file := range plugin.Files {
+			if !file.Generate || len(file.Services) == 0 {
+				continue
+			}
+			if err := generateFile(plugin, file); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
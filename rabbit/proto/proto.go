@@ -0,0 +1,132 @@
+package infrarabbitproto
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	infrarabbit "github.com/pushwoosh/infra/rabbit"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// HeaderContentType is a message header with the encoding of the body
+	HeaderContentType = "x-content-type"
+	// HeaderMessageType is a message header with the full protobuf name of the body message
+	HeaderMessageType = "x-message-type"
+
+	ContentTypeProtobuf = "application/x-protobuf"
+	ContentTypeJSON     = "application/json"
+)
+
+// Producer publishes messages. It's implemented by infrarabbit.Producer and its wrappers,
+// e.g. infraspool.Producer or infraschema.Producer
+type Producer = infrarabbit.Publisher
+
+type Option interface {
+	apply(p *Publisher)
+}
+
+type optionContentType string
+
+func (opt optionContentType) apply(p *Publisher) {
+	p.contentType = string(opt)
+}
+
+// WithJSON publishes messages encoded with protojson, e.g. for consumers without generated code.
+// optional, default: protobuf binary encoding
+func WithJSON() Option {
+	return optionContentType(ContentTypeJSON)
+}
+
+// Publisher encodes protobuf messages and publishes them with the content and message type headers.
+// It's used by generated publishers, see protoc-gen-go-rabbit
+type Publisher struct {
+	producer    Producer
+	contentType string
+}
+
+func NewPublisher(producer Producer, opts ...Option) *Publisher {
+	p := &Publisher{
+		producer:    producer,
+		contentType: ContentTypeProtobuf,
+	}
+
+	for _, opt := range opts {
+		opt.apply(p)
+	}
+
+	return p
+}
+
+// Publish encodes the message and publishes it to the exchange with the routing key
+func (p *Publisher) Publish(ctx context.Context, exchange, routingKey string, msg proto.Message) error {
+	body, err := Marshal(p.contentType, msg)
+	if err != nil {
+		return err
+	}
+
+	return p.producer.Produce(ctx, &infrarabbit.ProducerMessage{
+		Body:       body,
+		Exchange:   exchange,
+		RoutingKey: routingKey,
+		Headers: map[string]interface{}{
+			HeaderContentType: p.contentType,
+			HeaderMessageType: string(msg.ProtoReflect().Descriptor().FullName()),
+		},
+	})
+}
+
+// Handler decodes message bodies into P and passes them to fn. Messages with another message type header
+// or undecodable bodies are infrarabbit.ErrMalformed. Bodies without the content type header are protobuf
+func Handler[T any, P interface {
+	*T
+	proto.Message
+}](fn func(ctx context.Context, msg *infrarabbit.Message, payload P) error) infrarabbit.HandlerFunc {
+	return func(ctx context.Context, msg *infrarabbit.Message) error {
+		payload := P(new(T))
+		name := string(payload.ProtoReflect().Descriptor().FullName())
+
+		if msgType, _ := msg.Headers()[HeaderMessageType].(string); msgType != "" && msgType != name {
+			return errors.Wrapf(infrarabbit.ErrMalformed, "expected %s message, got %s", name, msgType)
+		}
+
+		contentType, _ := msg.Headers()[HeaderContentType].(string)
+		if err := Unmarshal(contentType, msg.Body(), payload); err != nil {
+			return errors.Wrapf(infrarabbit.ErrMalformed, "unable to decode %s: %s", name, err)
+		}
+
+		return fn(ctx, msg, payload)
+	}
+}
+
+// Marshal encodes the message with the content type
+func Marshal(contentType string, msg proto.Message) ([]byte, error) {
+	var (
+		body []byte
+		err  error
+	)
+	switch contentType {
+	case ContentTypeProtobuf:
+		body, err = proto.Marshal(msg)
+	case ContentTypeJSON:
+		body, err = protojson.Marshal(msg)
+	default:
+		return nil, errors.Errorf("unsupported content type %q", contentType)
+	}
+
+	return body, errors.Wrapf(err, "unable to encode %s", msg.ProtoReflect().Descriptor().FullName())
+}
+
+// Unmarshal decodes the body with the content type, an empty content type is protobuf.
+// Unknown JSON fields are ignored, so consumers are compatible with newer schemas
+func Unmarshal(contentType string, body []byte, msg proto.Message) error {
+	switch contentType {
+	case "", ContentTypeProtobuf:
+		return proto.Unmarshal(body, msg)
+	case ContentTypeJSON:
+		return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(body, msg)
+	default:
+		return errors.Errorf("unsupported content type %q", contentType)
+	}
+}
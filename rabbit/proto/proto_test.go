@@ -0,0 +1,39 @@
+package infrarabbitproto
+
+import (
+	"context"
+	"testing"
+
+	infrarabbit "github.com/pushwoosh/infra/rabbit"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type producerFunc func(ctx context.Context, msg *infrarabbit.ProducerMessage) error
+
+func (f producerFunc) Produce(ctx context.Context, msg *infrarabbit.ProducerMessage) error {
+	return f(ctx, msg)
+}
+
+func TestPublisher(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithJSON()}} {
+		var published *infrarabbit.ProducerMessage
+		p := NewPublisher(producerFunc(func(_ context.Context, msg *infrarabbit.ProducerMessage) error {
+			published = msg
+			return nil
+		}), opts...)
+
+		if err := p.Publish(context.Background(), "users", "user.created", wrapperspb.String("user-1")); err != nil {
+			t.Fatal(err)
+		}
+		if published.Exchange != "users" || published.RoutingKey != "user.created" ||
+			published.Headers[HeaderMessageType] != "google.protobuf.StringValue" {
+			t.Fatalf("unexpected message %+v", published)
+		}
+
+		var decoded wrapperspb.StringValue
+		contentType, _ := published.Headers[HeaderContentType].(string)
+		if err := Unmarshal(contentType, published.Body, &decoded); err != nil || decoded.GetValue() != "user-1" {
+			t.Fatalf("unexpected %s body %q: %v", contentType, published.Body, err)
+		}
+	}
+}
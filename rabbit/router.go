@@ -0,0 +1,252 @@
+package infrarabbit
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	inframessaging "github.com/pushwoosh/infra/messaging"
+	infrarecovery "github.com/pushwoosh/infra/recovery"
+	infrarequestid "github.com/pushwoosh/infra/requestid"
+	infratenancy "github.com/pushwoosh/infra/tenancy"
+	"go.uber.org/zap"
+)
+
+// ErrMalformed marks messages that can't be processed at all, they are rejected instead of requeueing:
+// routed to the dead-letter exchange of the queue if there is one, dropped otherwise
+var ErrMalformed = errors.New("malformed message")
+
+// HandlerFunc processes a message routed by Router.
+// The message is acked on nil error and requeued on any error except ErrMalformed,
+// see ConsumerConfig.MaxRedeliveries to limit requeues of poison messages.
+// Handler panics are recovered, logged, reported to the error tracker and treated as errors.
+type HandlerFunc = inframessaging.Handler[*Message]
+
+// Middleware wraps a handler, e.g. with logging or idempotency checks
+type Middleware = inframessaging.Middleware[*Message]
+
+// JSON decodes message body into T and passes it to fn. Decoding errors are ErrMalformed
+func JSON[T any](fn func(ctx context.Context, msg *Message, payload T) error) HandlerFunc {
+	return func(ctx context.Context, msg *Message) error {
+		var payload T
+		if err := json.Unmarshal(msg.Body(), &payload); err != nil {
+			return errors.Wrapf(ErrMalformed, "unable to decode %s: %s", msg.RoutingKey(), err)
+		}
+		return fn(ctx, msg, payload)
+	}
+}
+
+type route struct {
+	pattern []string
+	msgType string
+	handler HandlerFunc
+}
+
+// Router dispatches messages of one or more consumers to handlers by message type or routing key:
+//
+//	router := infrarabbit.NewRouter()
+//	router.Use(loggingMiddleware)
+//	router.HandleType("user.created", infrarabbit.JSON(onUserCreated))
+//	router.Handle("orders.*.paid", onOrderPaid)
+//	router.Handle("orders.#", onOrderEvent)
+//	router.Consume(consumer1, consumer2)
+//	...
+//	router.Close()
+//
+// Routing key patterns use topic exchange syntax: "*" matches exactly one word, "#" matches zero or more words.
+// Type routes are checked before routing key routes, routes of the same kind are checked in registration order.
+type Router struct {
+	mu          sync.RWMutex
+	typeRoutes  []route
+	keyRoutes   []route
+	middlewares []Middleware
+	notFound    HandlerFunc
+
+	consumersMu sync.Mutex
+	consumers   []*Consumer
+	wg          sync.WaitGroup
+}
+
+func NewRouter() *Router {
+	return &Router{
+		notFound: func(_ context.Context, msg *Message) error {
+			return errors.Wrapf(ErrMalformed, "no route for routing key %s", msg.RoutingKey())
+		},
+	}
+}
+
+// Use appends middlewares. Middlewares are applied to all routes in order: the first one is the outermost
+func (r *Router) Use(middlewares ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.middlewares = append(r.middlewares, middlewares...)
+}
+
+// Handle registers a handler for a routing key pattern
+func (r *Router) Handle(pattern string, handler HandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.keyRoutes = append(r.keyRoutes, route{pattern: splitWords(pattern), handler: handler})
+}
+
+// HandleType registers a handler for a message type property
+func (r *Router) HandleType(msgType string, handler HandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.typeRoutes = append(r.typeRoutes, route{msgType: msgType, handler: handler})
+}
+
+// NotFound sets a handler for unrouted messages. By default they are logged and rejected
+func (r *Router) NotFound(handler HandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.notFound = handler
+}
+
+// Consume starts dispatching messages of consumers in background
+func (r *Router) Consume(consumers ...*Consumer) {
+	r.consumersMu.Lock()
+	defer r.consumersMu.Unlock()
+
+	for _, consumer := range consumers {
+		r.consumers = append(r.consumers, consumer)
+
+		r.wg.Add(1)
+		go func(consumer *Consumer) {
+			defer r.wg.Done()
+
+			for msg := range consumer.Consume() {
+				r.Dispatch(context.Background(), msg)
+			}
+		}(consumer)
+	}
+}
+
+// Close closes all consumers and waits for messages in progress
+func (r *Router) Close() error {
+	r.consumersMu.Lock()
+	consumers := r.consumers
+	r.consumers = nil
+	r.consumersMu.Unlock()
+
+	var firstErr error
+	for _, consumer := range consumers {
+		if err := consumer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	r.wg.Wait()
+
+	return firstErr
+}
+
+// Dispatch routes a single message and acks or requeues it according to the handler result.
+// The handler context has the request id from message headers or a new one, the tenant id from headers
+// and a consumer span continuing the trace of the producer.
+func (r *Router) Dispatch(ctx context.Context, msg *Message) {
+	ctx = infrarequestid.ExtractHeaders(ctx, msg.Headers())
+	ctx = infratenancy.ExtractHeaders(ctx, msg.Headers())
+	ctx = extractTrace(ctx, msg.Headers())
+	handler, name := r.match(msg)
+
+	ctx, span := startProcessSpan(ctx, msg, name)
+	err := r.call(ctx, handler, name, msg)
+	endSpan(span, err)
+
+	switch {
+	case err == nil:
+		_ = msg.Ack()
+	case errors.Is(err, ErrMalformed):
+		infralog.ErrorCtx(ctx, "rabbit router: rejecting message",
+			zap.String("queue", msg.queue),
+			zap.String("routing_key", msg.RoutingKey()),
+			zap.String("route", name),
+			zap.Error(err))
+		_ = msg.Reject()
+	default:
+		infralog.ErrorCtx(ctx, "rabbit router: handle message",
+			zap.String("queue", msg.queue),
+			zap.String("routing_key", msg.RoutingKey()),
+			zap.String("route", name),
+			zap.Error(err))
+		_ = msg.Nack()
+	}
+}
+
+// call runs the handler and converts its panic into an error, so the message is requeued
+func (r *Router) call(ctx context.Context, handler HandlerFunc, name string, msg *Message) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			infrarecovery.Report(ctx, "rabbit.router", rec,
+				zap.String("queue", msg.queue),
+				zap.String("routing_key", msg.RoutingKey()),
+				zap.String("route", name))
+			err = errors.Errorf("handler panic: %v", rec)
+		}
+	}()
+
+	return handler(ctx, msg)
+}
+
+// match returns the wrapped handler and the route name
+func (r *Router) match(msg *Message) (HandlerFunc, string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	handler, name := r.notFound, "not_found"
+
+	found := false
+	if msgType := msg.Type(); msgType != "" {
+		for _, rt := range r.typeRoutes {
+			if rt.msgType == msgType {
+				handler, name, found = rt.handler, "type:"+rt.msgType, true
+				break
+			}
+		}
+	}
+
+	if !found {
+		key := splitWords(msg.RoutingKey())
+		for _, rt := range r.keyRoutes {
+			if matchWords(rt.pattern, key) {
+				handler, name = rt.handler, strings.Join(rt.pattern, ".")
+				break
+			}
+		}
+	}
+
+	return inframessaging.Chain(handler, r.middlewares...), name
+}
+
+// matchWords matches routing key words against topic exchange pattern words
+func matchWords(pattern, key []string) bool {
+	if len(pattern) == 0 {
+		return len(key) == 0
+	}
+
+	switch pattern[0] {
+	case "#":
+		for i := 0; i <= len(key); i++ {
+			if matchWords(pattern[1:], key[i:]) {
+				return true
+			}
+		}
+		return false
+	case "*":
+		return len(key) > 0 && matchWords(pattern[1:], key[1:])
+	default:
+		return len(key) > 0 && pattern[0] == key[0] && matchWords(pattern[1:], key[1:])
+	}
+}
+
+func splitWords(s string) []string {
+	return strings.Split(s, ".")
+}
@@ -0,0 +1,109 @@
+package infrarabbit
+
+import (
+	"context"
+	"testing"
+
+	infratesttracing "github.com/pushwoosh/infra/test/tracing"
+	infratracing "github.com/pushwoosh/infra/tracing"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func TestMatchWords(t *testing.T) {
+	tests := []struct {
+		pattern string
+		key     string
+		match   bool
+	}{
+		{"orders.created", "orders.created", true},
+		{"orders.created", "orders.paid", false},
+		{"orders.*", "orders.created", true},
+		{"orders.*", "orders.created.v2", false},
+		{"orders.*.paid", "orders.eu.paid", true},
+		{"orders.#", "orders", true},
+		{"orders.#", "orders.eu.paid", true},
+		{"#.paid", "orders.eu.paid", true},
+		{"#.paid", "orders.eu.created", false},
+		{"#", "anything.at.all", true},
+	}
+
+	for _, tt := range tests {
+		if got := matchWords(splitWords(tt.pattern), splitWords(tt.key)); got != tt.match {
+			t.Errorf("%s vs %s: expected %v, got %v", tt.pattern, tt.key, tt.match, got)
+		}
+	}
+}
+
+type testAcknowledger struct {
+	amqp.Acknowledger
+
+	acked    bool
+	requeued bool
+}
+
+func (a *testAcknowledger) Ack(uint64, bool) error {
+	a.acked = true
+	return nil
+}
+
+func (a *testAcknowledger) Nack(_ uint64, _ bool, requeue bool) error {
+	a.requeued = requeue
+	return nil
+}
+
+func TestMessageNackRedeliveryLimit(t *testing.T) {
+	tests := []struct {
+		headers     amqp.Table
+		redelivered bool
+		requeued    bool
+	}{
+		{nil, false, true},
+		{nil, true, true},
+		{amqp.Table{DeliveryCountHeader: int64(1)}, true, true},
+		{amqp.Table{DeliveryCountHeader: int64(2)}, true, false},
+	}
+
+	for i, tt := range tests {
+		ack := &testAcknowledger{}
+		msg := &Message{
+			msg:             &amqp.Delivery{Acknowledger: ack, Headers: tt.headers, Redelivered: tt.redelivered},
+			maxRedeliveries: 2,
+			callback:        func(error) {},
+		}
+
+		if err := msg.Nack(); err != nil {
+			t.Fatal(err)
+		}
+		if ack.requeued != tt.requeued {
+			t.Errorf("case %d: expected requeue %v, got %v", i, tt.requeued, ack.requeued)
+		}
+	}
+}
+
+func TestDispatchTracing(t *testing.T) {
+	recorder := infratesttracing.Record(t)
+
+	ctx, parent := infratracing.Tracer("test").Start(context.Background(), "produce")
+	headers := injectTrace(ctx, nil)
+	parent.End()
+
+	router := NewRouter()
+	router.Handle("orders.*", func(context.Context, *Message) error { return nil })
+
+	ack := &testAcknowledger{}
+	router.Dispatch(context.Background(), &Message{
+		msg:      &amqp.Delivery{Acknowledger: ack, Headers: headers, RoutingKey: "orders.created"},
+		queue:    "orders",
+		callback: func(error) {},
+	})
+	if !ack.acked {
+		t.Fatal("expected the message acked")
+	}
+
+	span := infratesttracing.Span(t, recorder, "rabbit process orders")
+	if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Fatal("expected the consumer span to continue the producer trace")
+	}
+	infratesttracing.RequireAttribute(t, span, "messaging.rabbitmq.destination.routing_key", "orders.created")
+	infratesttracing.RequireAttribute(t, span, "messaging.rabbitmq.route", "orders.*")
+}
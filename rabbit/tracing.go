@@ -0,0 +1,87 @@
+package infrarabbit
+
+import (
+	"context"
+	"maps"
+
+	infratracing "github.com/pushwoosh/infra/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// headersCarrier adapts AMQP headers to the OpenTelemetry propagator
+type headersCarrier map[string]interface{}
+
+func (c headersCarrier) Get(key string) string {
+	switch v := c[key].(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	return ""
+}
+
+func (c headersCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+func (c headersCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// injectTrace returns a copy of headers with the trace context of ctx
+func injectTrace(ctx context.Context, headers map[string]interface{}) map[string]interface{} {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return headers
+	}
+
+	headers = maps.Clone(headers)
+	if headers == nil {
+		headers = make(map[string]interface{}, 2)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, headersCarrier(headers))
+	return headers
+}
+
+// extractTrace returns ctx with the trace context of headers
+func extractTrace(ctx context.Context, headers map[string]interface{}) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, headersCarrier(headers))
+}
+
+func startPublishSpan(ctx context.Context, msg *ProducerMessage) (context.Context, trace.Span) {
+	return infratracing.Tracer("rabbit").Start(ctx, "rabbit publish "+msg.Exchange,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "rabbitmq"),
+			attribute.String("messaging.operation", "publish"),
+			attribute.String("messaging.destination.name", msg.Exchange),
+			attribute.String("messaging.rabbitmq.destination.routing_key", msg.RoutingKey),
+		))
+}
+
+func startProcessSpan(ctx context.Context, msg *Message, route string) (context.Context, trace.Span) {
+	return infratracing.Tracer("rabbit").Start(ctx, "rabbit process "+msg.queue,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "rabbitmq"),
+			attribute.String("messaging.operation", "process"),
+			attribute.String("messaging.source.name", msg.queue),
+			attribute.String("messaging.rabbitmq.destination.routing_key", msg.RoutingKey()),
+			attribute.String("messaging.rabbitmq.route", route),
+		))
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
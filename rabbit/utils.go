@@ -1,11 +1,17 @@
 package infrarabbit
 
 import (
-	"fmt"
+	"context"
+	"net"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
+	infraconfig "github.com/pushwoosh/infra/config"
+	infraproxy "github.com/pushwoosh/infra/proxy"
+	amqp "github.com/rabbitmq/amqp091-go"
 )
 
 func createAMQPURL(cfg *ConnectionConfig) (string, error) {
@@ -19,12 +25,18 @@ func createAMQPURL(cfg *ConnectionConfig) (string, error) {
 
 	username := defaultUser
 	if cfg.Username != "" {
-		username = cfg.Username
+		var err error
+		if username, err = infraconfig.ResolveSecret(cfg.Username); err != nil {
+			return "", errors.Wrap(err, "username")
+		}
 	}
 
 	password := defaultPassword
 	if cfg.Password != "" {
-		password = cfg.Password
+		var err error
+		if password, err = infraconfig.ResolveSecret(cfg.Password); err != nil {
+			return "", errors.Wrap(err, "password")
+		}
 	}
 
 	vhost := defaultVHost
@@ -32,13 +44,71 @@ func createAMQPURL(cfg *ConnectionConfig) (string, error) {
 		vhost = cfg.Vhost
 	}
 
-	return fmt.Sprintf(
-		"amqp://%s:%s@%s:%d%s",
-		username,
-		password,
-		host,
-		port,
-		vhost), nil
+	scheme := "amqp"
+	if cfg.TLS != nil {
+		scheme = "amqps"
+	}
+
+	// credentials and vhost are escaped, they may contain any characters like "@" or "/"
+	u := url.URL{
+		Scheme: scheme,
+		User:   url.UserPassword(username, password),
+		Host:   net.JoinHostPort(host, strconv.Itoa(port)),
+		Path:   vhost,
+	}
+
+	return u.String(), nil
+}
+
+// dial connects to the url of cfg with its TLS config
+func dial(cfg *ConnectionConfig, url string, amqpCfg amqp.Config) (*amqp.Connection, error) {
+	if cfg.TLS != nil {
+		loader := cfg.TLS.Loader()
+		if err := loader.Reload(); err != nil {
+			return nil, errors.Wrap(err, "tls")
+		}
+		host, _ := getHostPort(cfg.Address)
+		amqpCfg.TLSClientConfig = loader.ClientConfigForHost(host)
+	}
+
+	dialer := &net.Dialer{Timeout: defaultConnectionTimeout}
+	dialContext := dialer.DialContext
+	switch {
+	case cfg.Dialer != nil:
+		dialContext = cfg.Dialer
+	case cfg.Resolver != nil:
+		dialContext = cfg.Resolver.DialContext(dialer)
+	}
+
+	proxy := infraproxy.ForClient(cfg.Proxy)
+	if proxy != nil {
+		proxyDial, err := proxy.Dialer(dialContext)
+		if err != nil {
+			return nil, errors.Wrap(err, "proxy")
+		}
+		dialContext = proxyDial
+	}
+
+	if cfg.Dialer != nil || cfg.Resolver != nil || proxy != nil {
+		amqpCfg.Dial = func(network, addr string) (net.Conn, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), defaultConnectionTimeout)
+			defer cancel()
+
+			conn, err := dialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+
+			// the same deadline of TLS and AMQP handshakes as amqp.DefaultDial sets, it's cleared after the handshake
+			if err = conn.SetDeadline(time.Now().Add(defaultConnectionTimeout)); err != nil {
+				_ = conn.Close()
+				return nil, err
+			}
+			return conn, nil
+		}
+	}
+
+	return amqp.DialConfig(url, amqpCfg)
 }
 
 func getHostPort(address string) (string, int) {
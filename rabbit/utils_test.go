@@ -0,0 +1,32 @@
+package infrarabbit
+
+import (
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func TestCreateAMQPURL(t *testing.T) {
+	cfg := &ConnectionConfig{
+		Address:  "rabbit:5672",
+		Username: "user@eu",
+		Password: "p@ss:w/rd #1%",
+		Vhost:    "/events/eu",
+	}
+
+	u, err := createAMQPURL(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uri, err := amqp.ParseURI(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uri.Username != cfg.Username || uri.Password != cfg.Password {
+		t.Fatalf("unexpected credentials %q, %q", uri.Username, uri.Password)
+	}
+	if uri.Host != "rabbit" || uri.Port != 5672 || uri.Vhost != "events/eu" {
+		t.Fatalf("unexpected address %s:%d%s", uri.Host, uri.Port, uri.Vhost)
+	}
+}
@@ -0,0 +1,266 @@
+package infraratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	infrahttp "github.com/pushwoosh/infra/http"
+	infralog "github.com/pushwoosh/infra/log"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Rule limits requests of one kind of clients, e.g. per API key or per IP.
+// Keys of different rules don't collide, so rules may share a limiter.
+type Rule struct {
+	// Name is used in metrics and keys, e.g. "api_key", "ip" or "tenant"
+	Name    string
+	Limiter Limiter
+
+	// Limit is reported in RateLimit-Limit header. optional
+	Limit Limit
+
+	// Key functions for http and grpc, the rule doesn't apply to a transport without one
+	HTTPKey HTTPKeyFunc
+	GRPCKey GRPCKeyFunc
+}
+
+// APIMiddleware applies rules to every request. A request is rejected with 429 if any rule is exceeded.
+// Responses have RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers of the most restrictive rule,
+// rejected ones have Retry-After. Requests are allowed if a limiter fails.
+//
+//	infrahttp.WithMiddlewares(infraratelimit.APIMiddleware(
+//		infraratelimit.Rule{Name: "api_key", Limiter: perKey, Limit: keyLimit, HTTPKey: infraratelimit.APIKey("X-API-Key")},
+//		infraratelimit.Rule{Name: "ip", Limiter: perIP, Limit: ipLimit, HTTPKey: infraratelimit.ClientIP(false)},
+//	))
+func APIMiddleware(rules ...Rule) infrahttp.Middleware {
+	initMetrics()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var decision *apiDecision
+			for i := range rules {
+				if rules[i].HTTPKey == nil {
+					continue
+				}
+
+				d := applyRule(r.Context(), &rules[i], rules[i].HTTPKey(r), "http")
+				if d == nil {
+					continue
+				}
+				if decision == nil || d.restrictive(decision) {
+					decision = d
+				}
+				if !d.res.Allowed {
+					break
+				}
+			}
+
+			if decision == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			decision.setHeaders(w.Header())
+			if !decision.res.Allowed {
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// UnaryServerAPIInterceptor applies rules to every call. A call exceeding any rule gets ResourceExhausted status
+// with RetryInfo details and retry-after header. Calls are allowed if a limiter fails.
+func UnaryServerAPIInterceptor(rules ...Rule) grpc.UnaryServerInterceptor {
+	initMetrics()
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := applyGRPCRules(ctx, rules, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerAPIInterceptor is UnaryServerAPIInterceptor for streams
+func StreamServerAPIInterceptor(rules ...Rule) grpc.StreamServerInterceptor {
+	initMetrics()
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := applyGRPCRules(ss.Context(), rules, info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func applyGRPCRules(ctx context.Context, rules []Rule, fullMethod string) error {
+	for i := range rules {
+		if rules[i].GRPCKey == nil {
+			continue
+		}
+
+		d := applyRule(ctx, &rules[i], rules[i].GRPCKey(ctx, fullMethod), "grpc")
+		if d == nil || d.res.Allowed {
+			continue
+		}
+
+		retryAfter := d.retryAfterSeconds()
+		_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(retryAfter)))
+
+		st := status.Newf(codes.ResourceExhausted, "rate limit %s exceeded, retry after %ds", d.rule.Name, retryAfter)
+		if withDetails, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(d.res.RetryAfter)}); err == nil {
+			st = withDetails
+		}
+		return st.Err()
+	}
+
+	return nil
+}
+
+type apiDecision struct {
+	rule *Rule
+	res  Result
+}
+
+// applyRule returns nil if the key is empty or the limiter fails
+func applyRule(ctx context.Context, rule *Rule, key, transport string) *apiDecision {
+	if key == "" {
+		return nil
+	}
+
+	res, err := Allow(ctx, rule.Limiter, rule.Name+":"+key)
+	if err != nil {
+		infralog.ErrorCtx(ctx, "rate limiter error", zap.String("rule", rule.Name), zap.Error(err))
+		return nil
+	}
+
+	if !res.Allowed {
+		metrics.RejectedCounter.WithLabelValues(transport, rule.Name).Inc()
+	}
+
+	return &apiDecision{rule: rule, res: res}
+}
+
+// restrictive reports whether d should be reported instead of other
+func (d *apiDecision) restrictive(other *apiDecision) bool {
+	if d.res.Allowed != other.res.Allowed {
+		return !d.res.Allowed
+	}
+	return d.res.Remaining < other.res.Remaining
+}
+
+func (d *apiDecision) retryAfterSeconds() int {
+	return int(math.Ceil(d.res.RetryAfter.Seconds()))
+}
+
+func (d *apiDecision) setHeaders(h http.Header) {
+	remaining := strconv.Itoa(d.res.Remaining)
+	h.Set("RateLimit-Remaining", remaining)
+	h.Set("X-RateLimit-Remaining", remaining)
+
+	if d.rule.Limit.Rate > 0 {
+		limit := strconv.Itoa(d.rule.Limit.burst())
+		h.Set("RateLimit-Limit", limit)
+		h.Set("X-RateLimit-Limit", limit)
+
+		// time until the bucket is full again
+		reset := time.Duration(d.rule.Limit.burst()-d.res.Remaining) * d.rule.Limit.interval()
+		h.Set("RateLimit-Reset", strconv.Itoa(int(math.Ceil(reset.Seconds()))))
+	}
+
+	if !d.res.Allowed {
+		h.Set("Retry-After", strconv.Itoa(d.retryAfterSeconds()))
+	}
+}
+
+// APIKey limits by an API key header. The key is hashed, so it isn't stored in a limiter backend.
+func APIKey(header string) HTTPKeyFunc {
+	return func(r *http.Request) string {
+		return hashKey(r.Header.Get(header))
+	}
+}
+
+// ClientIP limits by the client address. If trustForwarded is true the first address of X-Forwarded-For
+// is used, only enable it behind a proxy that overwrites the header.
+func ClientIP(trustForwarded bool) HTTPKeyFunc {
+	return func(r *http.Request) string {
+		if trustForwarded {
+			if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+				first, _, _ := strings.Cut(forwarded, ",")
+				return strings.TrimSpace(first)
+			}
+		}
+
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			return r.RemoteAddr
+		}
+		return host
+	}
+}
+
+// ContextKey limits by a value of the request context, e.g. tenant id set by an auth middleware
+func ContextKey(fn func(ctx context.Context) string) HTTPKeyFunc {
+	return func(r *http.Request) string {
+		return fn(r.Context())
+	}
+}
+
+// GRPCAPIKey limits by an API key in metadata. The key is hashed, so it isn't stored in a limiter backend.
+func GRPCAPIKey(name string) GRPCKeyFunc {
+	return func(ctx context.Context, _ string) string {
+		if values := metadata.ValueFromIncomingContext(ctx, name); len(values) > 0 {
+			return hashKey(values[0])
+		}
+		return ""
+	}
+}
+
+// GRPCClientIP limits by the peer address
+func GRPCClientIP() GRPCKeyFunc {
+	return func(ctx context.Context, _ string) string {
+		p, ok := peer.FromContext(ctx)
+		if !ok || p.Addr == nil {
+			return ""
+		}
+
+		host, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			return p.Addr.String()
+		}
+		return host
+	}
+}
+
+// GRPCContextKey limits by a value of the call context, e.g. tenant id set by an auth interceptor
+func GRPCContextKey(fn func(ctx context.Context) string) GRPCKeyFunc {
+	return func(ctx context.Context, _ string) string {
+		return fn(ctx)
+	}
+}
+
+func hashKey(key string) string {
+	if key == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,54 @@
+package infraratelimit
+
+import (
+	"context"
+
+	infralog "github.com/pushwoosh/infra/log"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCKeyFunc returns a limiter key for a call, e.g. tenant id from metadata.
+// Calls with empty key are not limited.
+type GRPCKeyFunc func(ctx context.Context, fullMethod string) string
+
+// UnaryServerInterceptor rejects calls exceeding the limit with ResourceExhausted status.
+// Calls are allowed if the limiter fails.
+func UnaryServerInterceptor(l Limiter, keyFunc GRPCKeyFunc) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := allowCall(ctx, l, keyFunc(ctx, info.FullMethod)); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor rejects streams exceeding the limit with ResourceExhausted status
+func StreamServerInterceptor(l Limiter, keyFunc GRPCKeyFunc) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := allowCall(ss.Context(), l, keyFunc(ss.Context(), info.FullMethod)); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func allowCall(ctx context.Context, l Limiter, key string) error {
+	if key == "" {
+		return nil
+	}
+
+	res, err := Allow(ctx, l, key)
+	if err != nil {
+		infralog.ErrorCtx(ctx, "rate limiter error", zap.String("key", key), zap.Error(err))
+		return nil
+	}
+
+	if !res.Allowed {
+		return status.Errorf(codes.ResourceExhausted, "rate limit exceeded, retry after %s", res.RetryAfter)
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package infraratelimit
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	infrahttp "github.com/pushwoosh/infra/http"
+	infralog "github.com/pushwoosh/infra/log"
+	"go.uber.org/zap"
+)
+
+// HTTPKeyFunc returns a limiter key for a request, e.g. tenant id from a header.
+// Requests with empty key are not limited.
+type HTTPKeyFunc func(r *http.Request) string
+
+// HTTPMiddleware responds with 429 Too Many Requests and Retry-After header when the limit is exceeded.
+// Requests are allowed if the limiter fails.
+func HTTPMiddleware(l Limiter, keyFunc HTTPKeyFunc) infrahttp.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := keyFunc(r)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			res, err := Allow(r.Context(), l, key)
+			if err != nil {
+				infralog.ErrorCtx(r.Context(), "rate limiter error", zap.String("key", key), zap.Error(err))
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			if !res.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(res.RetryAfter.Seconds()))))
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package infraratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	infraconcurrency "github.com/pushwoosh/infra/concurrency"
+)
+
+// Limit is a number of events allowed per period
+type Limit struct {
+	Rate int           `mapstructure:"rate"`
+	Per  time.Duration `mapstructure:"per"`
+	// Burst is a token bucket capacity. optional, Rate is used by default. Ignored by sliding window limiters
+	Burst int `mapstructure:"burst"`
+}
+
+func (l Limit) Validate() error {
+	if l.Rate <= 0 || l.Per <= 0 {
+		return errors.New("rate and per should be greater than zero")
+	}
+
+	// redis limiters count time in microseconds, a zero interval would divide by zero in the scripts
+	if l.interval() < time.Microsecond {
+		return errors.New("rate should be at most one event per microsecond")
+	}
+
+	if l.Burst < 0 {
+		return errors.New("burst should be greater than or equal to 0")
+	}
+
+	return nil
+}
+
+func (l Limit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Rate
+}
+
+// interval is a time to produce one token
+func (l Limit) interval() time.Duration {
+	return l.Per / time.Duration(l.Rate)
+}
+
+// Result is a limiter decision
+type Result struct {
+	Allowed bool
+	// Remaining is the number of events still allowed now
+	Remaining int
+	// RetryAfter is a time to wait before the events are allowed. Zero if allowed
+	RetryAfter time.Duration
+}
+
+// Limiter limits event rate per key, e.g. per tenant
+type Limiter interface {
+	// AllowN reports whether n events for the key may happen now and takes them if so
+	AllowN(ctx context.Context, key string, n int) (Result, error)
+}
+
+// Allow reports whether an event for the key may happen now
+func Allow(ctx context.Context, l Limiter, key string) (Result, error) {
+	return l.AllowN(ctx, key, 1)
+}
+
+// waiters queues Wait calls per limiter and key
+var waiters = sync.OnceValue(func() *infraconcurrency.KeyedMutex {
+	return infraconcurrency.NewKeyedMutex("ratelimit_wait")
+})
+
+// Wait blocks until an event for the key is allowed or ctx is done.
+// Waiters of a key are served one by one in order, so they don't poll the limiter at once after every RetryAfter
+func Wait(ctx context.Context, l Limiter, key string) error {
+	unlock, err := waiters().Lock(ctx, fmt.Sprintf("%p:%s", l, key))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for {
+		res, err := l.AllowN(ctx, key, 1)
+		if err != nil {
+			return err
+		}
+		if res.Allowed {
+			return nil
+		}
+
+		timer := time.NewTimer(res.RetryAfter)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
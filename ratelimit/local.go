@@ -0,0 +1,171 @@
+package infraratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// idle keys are removed from local limiters after this number of calls
+const cleanupEvery = 10000
+
+// TokenBucket is an in-process token bucket limiter.
+// A bucket holds up to Burst tokens and is refilled with Rate tokens per Per.
+type TokenBucket struct {
+	limit Limit
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	calls   int
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+var _ Limiter = (*TokenBucket)(nil)
+
+func NewTokenBucket(limit Limit) (*TokenBucket, error) {
+	if err := limit.Validate(); err != nil {
+		return nil, err
+	}
+
+	initMetrics()
+
+	return &TokenBucket{
+		limit:   limit,
+		buckets: make(map[string]*bucket),
+	}, nil
+}
+
+func (tb *TokenBucket) AllowN(_ context.Context, key string, n int) (Result, error) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	now := time.Now()
+	burst := float64(tb.limit.burst())
+	perToken := float64(tb.limit.interval())
+
+	tb.cleanup(now, burst, perToken)
+
+	b, ok := tb.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		tb.buckets[key] = b
+	}
+
+	b.tokens = min(burst, b.tokens+float64(now.Sub(b.last))/perToken)
+	b.last = now
+
+	if b.tokens < float64(n) {
+		return observe("token_bucket", Result{
+			RetryAfter: time.Duration((float64(n) - b.tokens) * perToken),
+		}), nil
+	}
+
+	b.tokens -= float64(n)
+	return observe("token_bucket", Result{Allowed: true, Remaining: int(b.tokens)}), nil
+}
+
+// cleanup removes full buckets, they are equal to absent ones
+func (tb *TokenBucket) cleanup(now time.Time, burst, perToken float64) {
+	tb.calls++
+	if tb.calls < cleanupEvery {
+		return
+	}
+	tb.calls = 0
+
+	for key, b := range tb.buckets {
+		if b.tokens+float64(now.Sub(b.last))/perToken >= burst {
+			delete(tb.buckets, key)
+		}
+	}
+}
+
+// SlidingWindow is an in-process sliding window limiter.
+// It approximates the number of events in the last Per with counters of the current and previous windows.
+type SlidingWindow struct {
+	limit Limit
+
+	mu      sync.Mutex
+	windows map[string]*window
+	calls   int
+}
+
+type window struct {
+	start    time.Time
+	current  int
+	previous int
+}
+
+var _ Limiter = (*SlidingWindow)(nil)
+
+func NewSlidingWindow(limit Limit) (*SlidingWindow, error) {
+	if err := limit.Validate(); err != nil {
+		return nil, err
+	}
+
+	initMetrics()
+
+	return &SlidingWindow{
+		limit:   limit,
+		windows: make(map[string]*window),
+	}, nil
+}
+
+func (sw *SlidingWindow) AllowN(_ context.Context, key string, n int) (Result, error) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	now := time.Now()
+	per := sw.limit.Per
+	start := now.Truncate(per)
+
+	sw.cleanup(start)
+
+	w, ok := sw.windows[key]
+	if !ok {
+		w = &window{start: start}
+		sw.windows[key] = w
+	}
+
+	switch {
+	case w.start.Equal(start):
+	case w.start.Add(per).Equal(start):
+		w.previous, w.current, w.start = w.current, 0, start
+	default:
+		w.previous, w.current, w.start = 0, 0, start
+	}
+
+	elapsed := float64(now.Sub(start)) / float64(per)
+	count := float64(w.previous)*(1-elapsed) + float64(w.current)
+
+	if count+float64(n) > float64(sw.limit.Rate) {
+		retryAfter := start.Add(per).Sub(now)
+		if w.previous > 0 && float64(w.current+n) <= float64(sw.limit.Rate) {
+			// wait until enough of the previous window slides out
+			excess := count + float64(n) - float64(sw.limit.Rate)
+			retryAfter = time.Duration(excess / float64(w.previous) * float64(per))
+		}
+		return observe("sliding_window", Result{RetryAfter: retryAfter}), nil
+	}
+
+	w.current += n
+	return observe("sliding_window", Result{Allowed: true, Remaining: sw.limit.Rate - int(count) - n}), nil
+}
+
+// cleanup removes windows older than the previous one
+func (sw *SlidingWindow) cleanup(start time.Time) {
+	sw.calls++
+	if sw.calls < cleanupEvery {
+		return
+	}
+	sw.calls = 0
+
+	for key, w := range sw.windows {
+		if w.start.Add(sw.limit.Per).Before(start) {
+			delete(sw.windows, key)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package infraratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLimitValidate(t *testing.T) {
+	if err := (Limit{Rate: 2000, Per: time.Millisecond}).Validate(); err == nil {
+		t.Fatal("expected error for a sub-microsecond interval")
+	}
+	if err := (Limit{Rate: 1000, Per: time.Millisecond}).Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	l, err := NewTokenBucket(Limit{Rate: 10, Per: 100 * time.Millisecond, Burst: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if res, _ := Allow(ctx, l, "a"); !res.Allowed {
+			t.Fatalf("event %d must be allowed within burst", i)
+		}
+	}
+
+	res, _ := Allow(ctx, l, "a")
+	if res.Allowed || res.RetryAfter <= 0 || res.RetryAfter > 10*time.Millisecond {
+		t.Fatalf("unexpected result after burst: %+v", res)
+	}
+
+	if res, _ = Allow(ctx, l, "b"); !res.Allowed {
+		t.Fatal("keys must be limited independently")
+	}
+
+	if err = Wait(ctx, l, "a"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	l, err := NewSlidingWindow(Limit{Rate: 3, Per: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if res, _ := l.AllowN(ctx, "a", 3); !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	if res, _ := Allow(ctx, l, "a"); res.Allowed || res.RetryAfter <= 0 {
+		t.Fatalf("event over limit must be rejected: %+v", res)
+	}
+}
+
+func TestAPIMiddleware(t *testing.T) {
+	limit := Limit{Rate: 2, Per: time.Hour}
+	l, err := NewSlidingWindow(limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := APIMiddleware(
+		Rule{Name: "api_key", Limiter: l, Limit: limit, HTTPKey: APIKey("X-API-Key")},
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	do := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-API-Key", key)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i := 0; i < 2; i++ {
+		if rec := do("a"); rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Limit") != "2" {
+			t.Fatalf("request %d must be allowed, got %d %v", i, rec.Code, rec.Header())
+		}
+	}
+
+	rec := do("a")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" || rec.Header().Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("request must be rejected, got %d %v", rec.Code, rec.Header())
+	}
+
+	if rec = do("b"); rec.Code != http.StatusOK {
+		t.Fatal("api keys must be limited independently")
+	}
+	if rec = do(""); rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Limit") != "" {
+		t.Fatal("requests without a key must not be limited")
+	}
+}
+
+// closedLimiter never allows events and counts calls
+type closedLimiter struct {
+	calls atomic.Int64
+}
+
+func (l *closedLimiter) AllowN(context.Context, string, int) (Result, error) {
+	l.calls.Add(1)
+	return Result{RetryAfter: time.Hour}, nil
+}
+
+func TestWaitQueued(t *testing.T) {
+	l := &closedLimiter{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- Wait(ctx, l, "a") }()
+
+	for l.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// the second waiter of the key is queued behind the first one, it doesn't poll the limiter
+	queuedCtx, queuedCancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer queuedCancel()
+	if err := Wait(queuedCtx, l, "a"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if calls := l.calls.Load(); calls != 1 {
+		t.Fatalf("expected 1 limiter call, got %d", calls)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+}
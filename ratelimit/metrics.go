@@ -0,0 +1,43 @@
+package infraratelimit
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	DecisionsCounter *prometheus.CounterVec
+	RejectedCounter  *prometheus.CounterVec
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.DecisionsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "rate_limiter_decisions_total",
+			Help: "Number of rate limiter decisions by limiter type and result: allowed or limited",
+		}, []string{"limiter", "result"})
+
+		metrics.RejectedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "rate_limit_rejected_requests_total",
+			Help: "Number of API requests rejected by rate limit rules by transport: http or grpc",
+		}, []string{"transport", "rule"})
+
+		prometheus.MustRegister(
+			metrics.DecisionsCounter,
+			metrics.RejectedCounter,
+		)
+	})
+}
+
+func observe(limiter string, res Result) Result {
+	result := "limited"
+	if res.Allowed {
+		result = "allowed"
+	}
+	metrics.DecisionsCounter.WithLabelValues(limiter, result).Inc()
+
+	return res
+}
@@ -0,0 +1,54 @@
+package infraratelimit
+
+import (
+	"context"
+
+	infralog "github.com/pushwoosh/infra/log"
+	infrarabbit "github.com/pushwoosh/infra/rabbit"
+	"go.uber.org/zap"
+)
+
+// RabbitKeyFunc returns a limiter key for a message, e.g. tenant id from the body
+type RabbitKeyFunc func(msg *infrarabbit.Message) string
+
+// ThrottleRabbit passes messages from a consumer channel waiting for the limiter.
+// Messages with empty key are passed immediately. After ctx is done, messages are requeued
+// until the consumer is closed. Returned channel is closed when in is closed or ctx is done:
+//
+//	for msg := range infraratelimit.ThrottleRabbit(ctx, limiter, tenantFromMessage, consumer.Consume()) {
+//		...
+//	}
+func ThrottleRabbit(ctx context.Context, l Limiter, keyFunc RabbitKeyFunc, in chan *infrarabbit.Message) <-chan *infrarabbit.Message {
+	out := make(chan *infrarabbit.Message)
+
+	go func() {
+		defer func() {
+			close(out)
+			// requeue the rest, so the consumer is not blocked on close
+			for msg := range in {
+				_ = msg.Nack()
+			}
+		}()
+
+		for msg := range in {
+			if key := keyFunc(msg); key != "" {
+				if err := Wait(ctx, l, key); err != nil {
+					if ctx.Err() != nil {
+						_ = msg.Nack()
+						return
+					}
+					infralog.Error("rate limiter error", zap.String("key", key), zap.Error(err))
+				}
+			}
+
+			select {
+			case out <- msg:
+			case <-ctx.Done():
+				_ = msg.Nack()
+				return
+			}
+		}
+	}()
+
+	return out
+}
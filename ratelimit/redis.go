@@ -0,0 +1,157 @@
+package infraratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// KEYS[1] - bucket key
+// ARGV[1] - capacity, ARGV[2] - microseconds per token, ARGV[3] - n
+// returns {allowed, remaining, retry after in microseconds}
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local per_token = tonumber(ARGV[2])
+local n = tonumber(ARGV[3])
+
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil then
+	tokens = capacity
+	ts = now
+end
+
+tokens = math.min(capacity, tokens + (now - ts) / per_token)
+
+local allowed = 0
+local retry_after = 0
+if tokens >= n then
+	tokens = tokens - n
+	allowed = 1
+else
+	retry_after = math.ceil((n - tokens) * per_token)
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil(capacity * per_token / 1000) + 1000)
+
+return {allowed, math.floor(tokens), retry_after}`)
+
+// KEYS[1] - key prefix
+// ARGV[1] - rate, ARGV[2] - window in microseconds, ARGV[3] - n
+// returns {allowed, remaining, retry after in microseconds}
+var slidingWindowScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local per = tonumber(ARGV[2])
+local n = tonumber(ARGV[3])
+
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+local start = now - now % per
+
+local current_key = KEYS[1] .. ":" .. start
+local previous_key = KEYS[1] .. ":" .. (start - per)
+local current = tonumber(redis.call("GET", current_key) or "0")
+local previous = tonumber(redis.call("GET", previous_key) or "0")
+
+local elapsed = (now - start) / per
+local count = previous * (1 - elapsed) + current
+
+if count + n > rate then
+	local retry_after = start + per - now
+	if previous > 0 and current + n <= rate then
+		retry_after = math.ceil((count + n - rate) / previous * per)
+	end
+	return {0, 0, retry_after}
+end
+
+redis.call("INCRBY", current_key, n)
+redis.call("PEXPIRE", current_key, math.ceil(per * 2 / 1000))
+
+return {1, math.floor(rate - count - n), 0}`)
+
+// RedisTokenBucket is a token bucket limiter shared by all replicas using the same redis
+type RedisTokenBucket struct {
+	client redis.UniversalClient
+	prefix string
+	limit  Limit
+}
+
+var _ Limiter = (*RedisTokenBucket)(nil)
+
+// NewRedisTokenBucket creates a limiter storing buckets in keys "<prefix>:<key>"
+func NewRedisTokenBucket(client redis.UniversalClient, prefix string, limit Limit) (*RedisTokenBucket, error) {
+	if err := limit.Validate(); err != nil {
+		return nil, err
+	}
+
+	initMetrics()
+
+	return &RedisTokenBucket{
+		client: client,
+		prefix: prefix,
+		limit:  limit,
+	}, nil
+}
+
+func (tb *RedisTokenBucket) AllowN(ctx context.Context, key string, n int) (Result, error) {
+	res, err := tokenBucketScript.Run(ctx, tb.client, []string{tb.prefix + ":" + key},
+		tb.limit.burst(), tb.limit.interval().Microseconds(), n).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+
+	return observe("redis_token_bucket", parseResult(res)), nil
+}
+
+// RedisSlidingWindow is a sliding window limiter shared by all replicas using the same redis
+type RedisSlidingWindow struct {
+	client redis.UniversalClient
+	prefix string
+	limit  Limit
+}
+
+var _ Limiter = (*RedisSlidingWindow)(nil)
+
+// NewRedisSlidingWindow creates a limiter storing window counters in keys "<prefix>:<key>:<window start>"
+func NewRedisSlidingWindow(client redis.UniversalClient, prefix string, limit Limit) (*RedisSlidingWindow, error) {
+	if err := limit.Validate(); err != nil {
+		return nil, err
+	}
+
+	initMetrics()
+
+	return &RedisSlidingWindow{
+		client: client,
+		prefix: prefix,
+		limit:  limit,
+	}, nil
+}
+
+func (sw *RedisSlidingWindow) AllowN(ctx context.Context, key string, n int) (Result, error) {
+	// all keys of the script have the same hash tag, so it works in redis cluster
+	res, err := slidingWindowScript.Run(ctx, sw.client, []string{"{" + sw.prefix + ":" + key + "}"},
+		sw.limit.Rate, sw.limit.Per.Microseconds(), n).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+
+	return observe("redis_sliding_window", parseResult(res)), nil
+}
+
+func parseResult(res []int64) Result {
+	if len(res) != 3 {
+		return Result{}
+	}
+
+	return Result{
+		Allowed:    res[0] == 1,
+		Remaining:  int(res[1]),
+		RetryAfter: time.Duration(res[2]) * time.Microsecond,
+	}
+}
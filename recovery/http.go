@@ -0,0 +1,81 @@
+package infrarecovery
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// HTTP returns an http middleware that reports handler panics and responds with 500.
+// If the handler has already started the response, the connection is aborted instead,
+// so the client doesn't take a truncated response as a complete one
+func HTTP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hw := &headerWriter{ResponseWriter: w}
+
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+
+			// http.ErrAbortHandler is used to abort a response and must not be suppressed
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			Report(r.Context(), "http", rec,
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path))
+
+			if hw.started {
+				panic(http.ErrAbortHandler)
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+		}()
+
+		next.ServeHTTP(hw, r)
+	})
+}
+
+// headerWriter remembers if the response has been started
+type headerWriter struct {
+	http.ResponseWriter
+	started bool
+}
+
+func (w *headerWriter) WriteHeader(status int) {
+	// informational headers are followed by the final one
+	if status >= http.StatusOK {
+		w.started = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *headerWriter) Write(b []byte) (int, error) {
+	w.started = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *headerWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.started = true
+		f.Flush()
+	}
+}
+
+func (w *headerWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	w.started = true
+	return h.Hijack()
+}
+
+func (w *headerWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,23 @@
+package infrarecovery
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	PanicsCounter *prometheus.CounterVec
+}
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.PanicsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "panics_recovered_total",
+			Help: "The total number of recovered panics",
+		}, []string{"component"})
+
+		prometheus.MustRegister(metrics.PanicsCounter)
+	})
+}
@@ -0,0 +1,84 @@
+package infrarecovery
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	infraerrors "github.com/pushwoosh/infra/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	"go.uber.org/zap"
+)
+
+// PanicError is a panic converted to an error
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it's an error
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Report logs a recovered panic with its stack, counts it in metrics and reports it to the error tracker.
+// component is a metric label, e.g. "http" or "worker.sync". It must be called from the deferred function
+// that recovered the panic to have the panicking stack:
+//
+//	defer func() {
+//		if rec := recover(); rec != nil {
+//			infrarecovery.Report(ctx, "worker", rec)
+//		}
+//	}()
+func Report(ctx context.Context, component string, rec interface{}, fields ...zap.Field) {
+	initMetrics()
+
+	metrics.PanicsCounter.WithLabelValues(component).Inc()
+
+	infralog.ErrorCtx(ctx, "panic recovered", append([]zap.Field{
+		zap.String("component", component),
+		zap.Any("panic", rec),
+		zap.ByteString("panic_stack", debug.Stack()),
+	}, fields...)...)
+
+	infraerrors.CapturePanic(ctx, rec, append([]zap.Field{zap.String("component", component)}, fields...)...)
+}
+
+// Go runs fn in a new goroutine. A panic is reported and stops only this goroutine instead of the process
+func Go(ctx context.Context, component string, fn func(ctx context.Context)) {
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				Report(ctx, component, rec)
+			}
+		}()
+
+		fn(ctx)
+	}()
+}
+
+// Do calls fn and converts its panic into *PanicError after reporting it
+func Do(ctx context.Context, component string, fn func(ctx context.Context) error) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			Report(ctx, component, rec)
+			err = &PanicError{Value: rec, Stack: debug.Stack()}
+		}
+	}()
+
+	return fn(ctx)
+}
+
+// Handler wraps a message handler of any broker, so its panics are reported and returned as *PanicError
+func Handler[T any](component string, fn func(ctx context.Context, msg T) error) func(ctx context.Context, msg T) error {
+	return func(ctx context.Context, msg T) error {
+		return Do(ctx, component, func(ctx context.Context) error {
+			return fn(ctx, msg)
+		})
+	}
+}
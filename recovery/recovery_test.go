@@ -0,0 +1,68 @@
+package infrarecovery
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDo(t *testing.T) {
+	cause := errors.New("boom")
+
+	err := Do(context.Background(), "test", func(context.Context) error {
+		panic(cause)
+	})
+
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) || len(panicErr.Stack) == 0 {
+		t.Fatalf("expected panic error, got %v", err)
+	}
+	if !errors.Is(err, cause) {
+		t.Errorf("expected panic value to be unwrapped")
+	}
+
+	if err = Do(context.Background(), "test", func(context.Context) error { return nil }); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestGo(t *testing.T) {
+	done := make(chan struct{})
+
+	Go(context.Background(), "test", func(context.Context) {
+		defer close(done)
+		panic("boom")
+	})
+
+	<-done
+}
+
+func TestHTTP(t *testing.T) {
+	handler := HTTP(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", rec.Code)
+	}
+}
+
+func TestHTTPStartedResponse(t *testing.T) {
+	handler := HTTP(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		panic("boom")
+	}))
+
+	defer func() {
+		if rec := recover(); rec != http.ErrAbortHandler {
+			t.Errorf("expected http.ErrAbortHandler, got %v", rec)
+		}
+	}()
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+}
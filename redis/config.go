@@ -4,24 +4,74 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	infraconfig "github.com/pushwoosh/infra/config"
 )
 
+func init() {
+	// services start in dev without their dependencies running
+	infraconfig.RegisterProfileDefaults(infraconfig.ProfileDev, func(c *ConnectionConfig) {
+		c.Lazy = true
+	})
+}
+
 type ConnectionsConfig map[string]*ConnectionConfig
 
 type ConnectionConfig struct {
 	// Whether to use cluster client or single node client
 	ClusterMode bool `mapstructure:"cluster_mode"`
 
+	// Sentinel master name. If set, sentinel failover client is used
+	// and Address is treated as a list of sentinel addresses
+	MasterName string `mapstructure:"master_name"`
+
 	// Database Address.
 	// if ClusterMode == false then "host:port" is expected
-	// if ClusterMode == true  then "host:port[,host:port]" is expected
+	// if ClusterMode == true or MasterName is set then "host:port[,host:port]" is expected
 	Address string `mapstructure:"address"`
 
+	// Credentials. Optional
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+
+	// Sentinel credentials. Optional
+	SentinelUsername string `mapstructure:"sentinel_username"`
+	SentinelPassword string `mapstructure:"sentinel_password"`
+
+	// Database number. Not supported in cluster mode
+	DB int `mapstructure:"db"`
+
 	// Read and write timeouts. Default values using getters: -1 (no timeout)
 	ReadTimeout  *time.Duration `mapstructure:"read_timeout"`
 	WriteTimeout *time.Duration `mapstructure:"write_timeout"`
+
+	// Dial timeout. Default: DefaultDialTimeout
+	DialTimeout time.Duration `mapstructure:"dial_timeout"`
+
+	// Maximum number of socket connections. Default: 10 connections per every available CPU
+	PoolSize int `mapstructure:"pool_size"`
+
+	// Minimum number of idle connections
+	MinIdleConnections int `mapstructure:"min_idle_connections"`
+
+	// Time client waits for connection if all connections are busy. Default: ReadTimeout + 1 second
+	PoolTimeout time.Duration `mapstructure:"pool_timeout"`
+
+	// Connection idle time. Connections that idle more than that period will be closed
+	MaxConnectionIdleTime time.Duration `mapstructure:"max_connection_idle_time"`
+
+	// Commands that were executed longer than that time are logged. Zero disables slow log
+	SlowLogThreshold time.Duration `mapstructure:"slow_log_threshold"`
+
+	// Whether to create OpenTelemetry spans of commands
+	Tracing bool `mapstructure:"tracing"`
+
+	// Lazy connects on the first use instead of Connect, so a service starts without redis running.
+	// optional, default: true in the dev profile
+	Lazy bool `mapstructure:"lazy"`
 }
 
+const DefaultDialTimeout = 5 * time.Second
+
 func (c *ConnectionsConfig) Validate() error {
 	if c == nil {
 		return nil
@@ -52,6 +102,14 @@ func (c *ConnectionConfig) GetWriteTimeout() time.Duration {
 	return *c.WriteTimeout
 }
 
+func (c *ConnectionConfig) GetDialTimeout() time.Duration {
+	if c.DialTimeout <= 0 {
+		return DefaultDialTimeout
+	}
+
+	return c.DialTimeout
+}
+
 func (c *ConnectionConfig) Validate() error {
 	if c == nil {
 		return errors.New("empty connection config")
@@ -61,5 +119,17 @@ func (c *ConnectionConfig) Validate() error {
 		return errors.New("address is mandatory")
 	}
 
+	if c.ClusterMode && c.MasterName != "" {
+		return errors.New("cluster_mode and master_name are mutually exclusive")
+	}
+
+	if c.ClusterMode && c.DB != 0 {
+		return errors.New("db is not supported in cluster mode")
+	}
+
+	if c.PoolSize < 0 {
+		return errors.New("pool_size must be greater than or equal to zero")
+	}
+
 	return nil
 }
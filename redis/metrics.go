@@ -0,0 +1,151 @@
+package infraredis
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	infralog "github.com/pushwoosh/infra/log"
+	inframetrics "github.com/pushwoosh/infra/metrics"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+var metrics struct {
+	CommandDurationHistogram *inframetrics.HistogramVec
+	DialErrorCounter         *inframetrics.CounterVec
+}
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.CommandDurationHistogram = inframetrics.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "redis_command_duration",
+			Help:    "The redis command duration",
+			Buckets: []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+		}, []string{"connection", "command", "status"})
+
+		metrics.DialErrorCounter = inframetrics.NewCounterVec(prometheus.CounterOpts{
+			Name: "redis_dial_error_counter",
+			Help: "The total number of failed connection attempts",
+		}, []string{"connection"})
+
+		prometheus.MustRegister(
+			metrics.CommandDurationHistogram,
+			metrics.DialErrorCounter,
+		)
+	})
+}
+
+// metricsHook measures commands duration and logs slow commands
+type metricsHook struct {
+	name          string
+	slowThreshold time.Duration
+}
+
+var _ redis.Hook = (*metricsHook)(nil)
+
+func newMetricsHook(name string, slowThreshold time.Duration) *metricsHook {
+	initMetrics()
+
+	return &metricsHook{
+		name:          name,
+		slowThreshold: slowThreshold,
+	}
+}
+
+func (h *metricsHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := next(ctx, network, addr)
+		if err != nil {
+			metrics.DialErrorCounter.WithLabelValues(h.name).Inc()
+		}
+		return conn, err
+	}
+}
+
+func (h *metricsHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		h.observe(ctx, cmd.Name(), time.Since(start), err)
+		return err
+	}
+}
+
+func (h *metricsHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		h.observe(ctx, "pipeline", time.Since(start), err)
+		return err
+	}
+}
+
+func (h *metricsHook) observe(ctx context.Context, command string, duration time.Duration, err error) {
+	status := "success"
+	if err != nil && err != redis.Nil {
+		status = "error"
+	}
+
+	metrics.CommandDurationHistogram.WithLabelValues(h.name, command, status).Observe(duration.Seconds())
+
+	if h.slowThreshold > 0 && duration > h.slowThreshold {
+		infralog.WarnCtx(ctx, "redis slow command",
+			zap.String("connection", h.name),
+			zap.String("command", command),
+			zap.Duration("duration", duration))
+	}
+}
+
+// poolStatsCollector exports connection pool statistics to prometheus
+type poolStatsCollector struct {
+	client redis.UniversalClient
+
+	hits       *prometheus.Desc
+	misses     *prometheus.Desc
+	timeouts   *prometheus.Desc
+	totalConns *prometheus.Desc
+	idleConns  *prometheus.Desc
+	staleConns *prometheus.Desc
+}
+
+func newPoolStatsCollector(name string, client redis.UniversalClient) *poolStatsCollector {
+	labels := prometheus.Labels{"connection": name}
+	desc := func(metric, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName("redis", "pool", metric), help, nil, labels)
+	}
+
+	return &poolStatsCollector{
+		client: client,
+
+		hits:       desc("hits_total", "The number of times free connection was found in the pool."),
+		misses:     desc("misses_total", "The number of times free connection was not found in the pool."),
+		timeouts:   desc("timeouts_total", "The number of times a wait timeout occurred."),
+		totalConns: desc("total_connections", "The number of total connections in the pool."),
+		idleConns:  desc("idle_connections", "The number of idle connections in the pool."),
+		staleConns: desc("stale_connections_total", "The number of stale connections removed from the pool."),
+	}
+}
+
+func (c *poolStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.timeouts
+	ch <- c.totalConns
+	ch <- c.idleConns
+	ch <- c.staleConns
+}
+
+func (c *poolStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.client.PoolStats()
+
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.timeouts, prometheus.CounterValue, float64(stats.Timeouts))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stats.TotalConns))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stats.IdleConns))
+	ch <- prometheus.MustNewConstMetric(c.staleConns, prometheus.CounterValue, float64(stats.StaleConns))
+}
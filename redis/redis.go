@@ -6,21 +6,30 @@ import (
 	"sync"
 
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	infraoperator "github.com/pushwoosh/infra/operator"
 	"github.com/redis/go-redis/v9"
 )
 
 // Container is a simple container for holding named redis connections.
 type Container struct {
-	mu   *sync.RWMutex
-	cfg  map[string]ConnectionConfig
-	pool map[string]redis.UniversalClient
+	mu         *sync.RWMutex
+	cfg        map[string]ConnectionConfig
+	pool       map[string]redis.UniversalClient
+	collectors map[string]*poolStatsCollector
 }
 
+var (
+	_ infraoperator.Stopper = (*Container)(nil)
+	_ infraoperator.Checker = (*Container)(nil)
+)
+
 func NewContainer() *Container {
 	return &Container{
-		mu:   &sync.RWMutex{},
-		cfg:  make(map[string]ConnectionConfig),
-		pool: make(map[string]redis.UniversalClient),
+		mu:         &sync.RWMutex{},
+		cfg:        make(map[string]ConnectionConfig),
+		pool:       make(map[string]redis.UniversalClient),
+		collectors: make(map[string]*poolStatsCollector),
 	}
 }
 
@@ -31,29 +40,60 @@ func (cont *Container) Connect(name string, cfg *ConnectionConfig) error {
 		conn redis.UniversalClient
 	)
 
+	opts.ClientName = name
+	opts.Username = cfg.Username
+	opts.Password = cfg.Password
+	opts.SentinelUsername = cfg.SentinelUsername
+	opts.SentinelPassword = cfg.SentinelPassword
+	opts.DB = cfg.DB
+	opts.DialTimeout = cfg.GetDialTimeout()
 	opts.ReadTimeout = cfg.GetReadTimeout()
 	opts.WriteTimeout = cfg.GetWriteTimeout()
+	opts.PoolSize = cfg.PoolSize
+	opts.MinIdleConns = cfg.MinIdleConnections
+	opts.PoolTimeout = cfg.PoolTimeout
+	opts.ConnMaxIdleTime = cfg.MaxConnectionIdleTime
 
 	// cannot use redis.NewUniversalClient here because it determines whether
 	// client should be clustered or not by checking connection nodes count.
 	// We use dedicated ClusterMode flag instead.
-	if cfg.ClusterMode {
+	switch {
+	case cfg.ClusterMode:
 		opts.Addrs = strings.Split(cfg.Address, ",")
 		conn = redis.NewClusterClient(opts.Cluster())
-	} else {
+	case cfg.MasterName != "":
+		opts.Addrs = strings.Split(cfg.Address, ",")
+		opts.MasterName = cfg.MasterName
+		conn = redis.NewFailoverClient(opts.Failover())
+	default:
 		opts.Addrs = []string{cfg.Address}
 		conn = redis.NewClient(opts.Simple())
 	}
 
-	if err := conn.Ping(context.Background()).Err(); err != nil {
-		return errors.Wrap(err, "cannot create redis connection")
+	conn.AddHook(newMetricsHook(name, cfg.SlowLogThreshold))
+	if cfg.Tracing {
+		conn.AddHook(newTracingHook(name))
+	}
+
+	if !cfg.Lazy {
+		if err := conn.Ping(context.Background()).Err(); err != nil {
+			_ = conn.Close()
+			return errors.Wrap(err, "cannot create redis connection")
+		}
 	}
 
+	// replace existing connection with the same name
+	cont.Remove(name)
+
+	collector := newPoolStatsCollector(name, conn)
+	prometheus.MustRegister(collector)
+
 	cont.mu.Lock()
 	defer cont.mu.Unlock()
 
 	cont.pool[name] = conn
 	cont.cfg[name] = *cfg
+	cont.collectors[name] = collector
 
 	return nil
 }
@@ -65,3 +105,56 @@ func (cont *Container) Get(name string) redis.UniversalClient {
 
 	return cont.pool[name]
 }
+
+// Remove closes named connection and removes it from the container
+func (cont *Container) Remove(name string) {
+	cont.mu.Lock()
+	conn := cont.pool[name]
+	collector := cont.collectors[name]
+	delete(cont.pool, name)
+	delete(cont.cfg, name)
+	delete(cont.collectors, name)
+	cont.mu.Unlock()
+
+	if collector != nil {
+		prometheus.Unregister(collector)
+	}
+
+	if conn != nil {
+		_ = conn.Close()
+	}
+}
+
+// Check pings all connections in the container
+func (cont *Container) Check(ctx context.Context) error {
+	cont.mu.RLock()
+	defer cont.mu.RUnlock()
+
+	for name, conn := range cont.pool {
+		if err := conn.Ping(ctx).Err(); err != nil {
+			return errors.Wrap(err, name)
+		}
+	}
+
+	return nil
+}
+
+// Stop closes all connections in the container
+func (cont *Container) Stop(_ context.Context) error {
+	cont.Close()
+	return nil
+}
+
+// Close closes all connections in the container
+func (cont *Container) Close() {
+	cont.mu.RLock()
+	names := make([]string, 0, len(cont.pool))
+	for name := range cont.pool {
+		names = append(names, name)
+	}
+	cont.mu.RUnlock()
+
+	for _, name := range names {
+		cont.Remove(name)
+	}
+}
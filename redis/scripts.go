@@ -0,0 +1,35 @@
+package infraredis
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var deleteIfEqualScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+var setIfEqualScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+	return 1
+end
+return 0`)
+
+// DeleteIfEqual deletes the key only if it holds value, e.g. releases a lock only if it's still held by the owner.
+// Returns true if the key was deleted
+func DeleteIfEqual(ctx context.Context, client redis.Scripter, key, value string) (bool, error) {
+	n, err := deleteIfEqualScript.Run(ctx, client, []string{key}, value).Int()
+	return n == 1, err
+}
+
+// SetIfEqual replaces the value of the key only if it holds expected, e.g. updates a lock only if it's still held
+// by the owner. Returns true if the key was set
+func SetIfEqual(ctx context.Context, client redis.Scripter, key, expected, value string, ttl time.Duration) (bool, error) {
+	n, err := setIfEqualScript.Run(ctx, client, []string{key}, expected, value, ttl.Milliseconds()).Int()
+	return n == 1, err
+}
@@ -0,0 +1,44 @@
+package infraredis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	infratesttracing "github.com/pushwoosh/infra/test/tracing"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/codes"
+)
+
+func TestTracingHook(t *testing.T) {
+	recorder := infratesttracing.Record(t)
+
+	hook := newTracingHook("cache")
+	ctx := context.Background()
+
+	get := hook.ProcessHook(func(context.Context, redis.Cmder) error { return redis.Nil })
+	_ = get(ctx, redis.NewStringCmd(ctx, "get", "key"))
+
+	pipeline := hook.ProcessPipelineHook(func(context.Context, []redis.Cmder) error { return errors.New("connection reset") })
+	_ = pipeline(ctx, []redis.Cmder{redis.NewStatusCmd(ctx, "set", "key", "value"), redis.NewIntCmd(ctx, "incr", "n")})
+
+	span := infratesttracing.Span(t, recorder, "redis get")
+	infratesttracing.RequireAttribute(t, span, "db.system", "redis")
+	infratesttracing.RequireAttribute(t, span, "db.operation", "get")
+	infratesttracing.RequireAttribute(t, span, "db.connection", "cache")
+	for _, kv := range span.Attributes() {
+		if kv.Value.AsString() == "key" {
+			t.Fatalf("command arguments must not be recorded, got %s", kv.Key)
+		}
+	}
+	if span.Status().Code == codes.Error {
+		t.Fatalf("missing key must not be an error, got %v", span.Status())
+	}
+
+	span = infratesttracing.Span(t, recorder, "redis pipeline")
+	infratesttracing.RequireAttribute(t, span, "db.operation", "pipeline")
+	infratesttracing.RequireAttribute(t, span, "db.redis.num_cmd", int64(2))
+	if span.Status().Code != codes.Error {
+		t.Fatalf("failed pipeline must be an error span, got %v", span.Status())
+	}
+}
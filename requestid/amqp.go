@@ -0,0 +1,42 @@
+package infrarequestid
+
+import (
+	"context"
+	"maps"
+)
+
+// InjectHeaders returns AMQP message headers with the request id of the context.
+// headers are copied, not modified. infra rabbit producer calls it on every publish.
+func InjectHeaders(ctx context.Context, headers map[string]interface{}) map[string]interface{} {
+	id := FromContext(ctx)
+	if id == "" {
+		return headers
+	}
+
+	if _, ok := headers[AMQPHeader]; ok {
+		return headers
+	}
+
+	headers = maps.Clone(headers)
+	if headers == nil {
+		headers = make(map[string]interface{}, 1)
+	}
+	headers[AMQPHeader] = id
+
+	return headers
+}
+
+// ExtractHeaders returns the context with the request id of AMQP message headers.
+// A new id is generated if headers have none. infra rabbit router calls it for every dispatched message.
+func ExtractHeaders(ctx context.Context, headers map[string]interface{}) context.Context {
+	var incoming string
+	switch v := headers[AMQPHeader].(type) {
+	case string:
+		incoming = v
+	case []byte:
+		incoming = string(v)
+	}
+
+	ctx, _ = ensure(ctx, incoming)
+	return ctx
+}
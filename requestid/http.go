@@ -0,0 +1,44 @@
+package infrarequestid
+
+import (
+	"net/http"
+)
+
+// HTTP takes the request id from X-Request-ID header or generates a new one, stores it in the request context
+// and returns it in X-Request-ID response header
+func HTTP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, id := ensure(r.Context(), r.Header.Get(Header))
+		w.Header().Set(Header, id)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Transport sets X-Request-ID header of outgoing requests from the request context
+//
+//	client.Transport = infrarequestid.Transport(client.Transport)
+func Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &transport{next: next}
+}
+
+type transport struct {
+	next http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	id := FromContext(req.Context())
+	if id == "" || req.Header.Get(Header) != "" {
+		return t.next.RoundTrip(req)
+	}
+
+	// RoundTrip must not modify the request
+	req = req.Clone(req.Context())
+	req.Header.Set(Header, id)
+
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,65 @@
+package infrarequestid
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	infralog "github.com/pushwoosh/infra/log"
+	"go.uber.org/zap"
+)
+
+const (
+	// Header is the HTTP header with the request id
+	Header = "X-Request-ID"
+
+	// MetadataKey is the gRPC metadata key with the request id
+	MetadataKey = "x-request-id"
+
+	// AMQPHeader is the AMQP message header with the request id
+	AMQPHeader = "x-request-id"
+
+	// maxLength limits incoming ids, longer ones are replaced with generated ids
+	maxLength = 128
+)
+
+type ctxKeyType string
+
+const ctxKey ctxKeyType = "request_id"
+
+// New generates a new request id
+func New() string {
+	return uuid.NewString()
+}
+
+// NewContext stores the request id in the context and adds request_id field to logs written with the context
+func NewContext(ctx context.Context, id string) context.Context {
+	ctx = context.WithValue(ctx, ctxKey, id)
+	return infralog.WithField(ctx, zap.String("request_id", id))
+}
+
+// FromContext returns the request id of the context. It's empty if not set
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey).(string)
+	return id
+}
+
+// ensure returns the context with the incoming request id or with a new one if incoming is empty or invalid
+func ensure(ctx context.Context, incoming string) (context.Context, string) {
+	if !valid(incoming) {
+		incoming = New()
+	}
+	return NewContext(ctx, incoming), incoming
+}
+
+// valid accepts printable ASCII ids only, so clients can't break log lines or headers
+func valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,82 @@
+package infrarequestid
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTP(t *testing.T) {
+	var got string
+	handler := HTTP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = FromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(Header, "abc-123")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got != "abc-123" || rec.Header().Get(Header) != "abc-123" {
+		t.Fatalf("incoming request id must be kept, got %q, response %q", got, rec.Header().Get(Header))
+	}
+
+	for _, incoming := range []string{"", "bad id\n"} {
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(Header, incoming)
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if got == "" || got == incoming || rec.Header().Get(Header) != got {
+			t.Fatalf("request id must be generated for %q, got %q", incoming, got)
+		}
+	}
+}
+
+func TestTransport(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(Header)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: Transport(nil)}
+
+	req, _ := http.NewRequestWithContext(NewContext(context.Background(), "abc-123"), http.MethodGet, srv.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	if got != "abc-123" {
+		t.Fatalf("expected request id to be sent, got %q", got)
+	}
+	if req.Header.Get(Header) != "" {
+		t.Fatal("original request must not be modified")
+	}
+}
+
+func TestAMQPHeaders(t *testing.T) {
+	headers := map[string]interface{}{"foo": "bar"}
+
+	injected := InjectHeaders(NewContext(context.Background(), "abc-123"), headers)
+	if injected[AMQPHeader] != "abc-123" || injected["foo"] != "bar" {
+		t.Fatalf("unexpected headers %v", injected)
+	}
+	if _, ok := headers[AMQPHeader]; ok {
+		t.Fatal("original headers must not be modified")
+	}
+
+	if InjectHeaders(context.Background(), nil) != nil {
+		t.Fatal("headers must stay empty without request id")
+	}
+
+	if id := FromContext(ExtractHeaders(context.Background(), injected)); id != "abc-123" {
+		t.Fatalf("expected request id from headers, got %q", id)
+	}
+	if id := FromContext(ExtractHeaders(context.Background(), nil)); id == "" {
+		t.Fatal("request id must be generated for messages without it")
+	}
+}
@@ -0,0 +1,64 @@
+package infraretry
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff returns a delay after the attempt-th failed attempt, attempts start with 1
+type Backoff interface {
+	Delay(attempt int) time.Duration
+}
+
+// Exponential backoff grows delay by Multiplier after every attempt up to Max.
+// Jitter is a fraction of the delay randomly added or subtracted.
+type Exponential struct {
+	Initial    time.Duration `mapstructure:"initial"`
+	Max        time.Duration `mapstructure:"max"`
+	Multiplier float64       `mapstructure:"multiplier"` // optional, 2 by default
+	Jitter     float64       `mapstructure:"jitter"`     // optional
+}
+
+// DefaultExponential is 100ms, 200ms, 400ms ... 10s with 20% jitter
+var DefaultExponential = Exponential{
+	Initial:    100 * time.Millisecond,
+	Max:        10 * time.Second,
+	Multiplier: 2,
+	Jitter:     0.2,
+}
+
+func (e Exponential) Delay(attempt int) time.Duration {
+	multiplier := e.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+
+	// math.Pow overflows to +Inf after enough attempts, converting it to time.Duration is undefined
+	limit := float64(math.MaxInt64)
+	if e.Max > 0 {
+		limit = float64(e.Max)
+	}
+
+	delay := float64(e.Initial) * math.Pow(multiplier, float64(attempt-1))
+	if delay > limit || math.IsNaN(delay) {
+		delay = limit
+	}
+
+	if e.Jitter > 0 {
+		// nolint:gosec
+		delay += delay * e.Jitter * (rand.Float64()*2 - 1)
+	}
+
+	if delay >= float64(math.MaxInt64) {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(delay)
+}
+
+// Constant backoff always waits the same time
+type Constant time.Duration
+
+func (c Constant) Delay(int) time.Duration {
+	return time.Duration(c)
+}
@@ -0,0 +1,40 @@
+package infraretry
+
+import "sync"
+
+// Budget limits retries shared by many calls, so a failing dependency doesn't get multiplied load.
+// It is a token bucket: every failed attempt takes a token, every success returns ratio tokens.
+// Retries are allowed while the bucket is more than half full.
+//
+//	budget := infraretry.NewBudget(100, 0.1) // retries stop when failures exceed ~10% of successes
+type Budget struct {
+	mu     sync.Mutex
+	max    float64
+	tokens float64
+	ratio  float64
+}
+
+func NewBudget(maxTokens, ratio float64) *Budget {
+	return &Budget{
+		max:    maxTokens,
+		tokens: maxTokens,
+		ratio:  ratio,
+	}
+}
+
+// success returns tokens to the bucket
+func (b *Budget) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = min(b.max, b.tokens+b.ratio)
+}
+
+// failure takes a token and reports whether a retry is allowed
+func (b *Budget) failure() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = max(0, b.tokens-1)
+	return b.tokens > b.max/2
+}
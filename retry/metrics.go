@@ -0,0 +1,33 @@
+package infraretry
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	CallsCounter   *prometheus.CounterVec
+	RetriesCounter *prometheus.CounterVec
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.CallsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "retry_calls_total",
+			Help: "Number of retried operation calls by result: success, failed, exhausted, budget or canceled",
+		}, []string{"operation", "result"})
+
+		metrics.RetriesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "retry_retries_total",
+			Help: "Number of retry attempts",
+		}, []string{"operation"})
+
+		prometheus.MustRegister(
+			metrics.CallsCounter,
+			metrics.RetriesCounter,
+		)
+	})
+}
@@ -0,0 +1,77 @@
+package infraretry
+
+import "time"
+
+type Option interface {
+	apply(r *Retrier)
+}
+
+type optionBackoff struct {
+	backoff Backoff
+}
+
+func (opt optionBackoff) apply(r *Retrier) {
+	r.backoff = opt.backoff
+}
+
+// WithBackoff sets delays between attempts. DefaultExponential is used by default
+func WithBackoff(backoff Backoff) Option {
+	return optionBackoff{backoff: backoff}
+}
+
+type optionMaxAttempts int
+
+func (opt optionMaxAttempts) apply(r *Retrier) {
+	r.maxAttempts = int(opt)
+}
+
+// WithMaxAttempts limits the number of attempts including the first one. Set 0 to retry until context is done
+func WithMaxAttempts(attempts int) Option {
+	return optionMaxAttempts(attempts)
+}
+
+type optionMaxElapsed time.Duration
+
+func (opt optionMaxElapsed) apply(r *Retrier) {
+	r.maxElapsed = time.Duration(opt)
+}
+
+// WithMaxElapsed stops retrying when the next attempt would start later than d after the first one
+func WithMaxElapsed(d time.Duration) Option {
+	return optionMaxElapsed(d)
+}
+
+type optionRetryable func(err error) bool
+
+func (opt optionRetryable) apply(r *Retrier) {
+	r.retryable = opt
+}
+
+// WithRetryable sets error classifier. By default all errors except Permanent are retried
+func WithRetryable(retryable func(err error) bool) Option {
+	return optionRetryable(retryable)
+}
+
+type optionBudget struct {
+	budget *Budget
+}
+
+func (opt optionBudget) apply(r *Retrier) {
+	r.budget = opt.budget
+}
+
+// WithBudget limits retries with a budget, usually shared by all calls to a dependency
+func WithBudget(budget *Budget) Option {
+	return optionBudget{budget: budget}
+}
+
+type optionOnRetry func(attempt int, err error, delay time.Duration)
+
+func (opt optionOnRetry) apply(r *Retrier) {
+	r.onRetry = opt
+}
+
+// WithOnRetry sets a hook called before waiting for the next attempt
+func WithOnRetry(fn func(attempt int, err error, delay time.Duration)) Option {
+	return optionOnRetry(fn)
+}
@@ -0,0 +1,150 @@
+package infraretry
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const defaultMaxAttempts = 5
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent wraps err, so it's not retried
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent checks if err is wrapped with Permanent
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// Retrier repeats failed operations according to its policy:
+//
+//	retrier := infraretry.New("billing.charge",
+//		infraretry.WithMaxAttempts(3),
+//		infraretry.WithRetryable(isTemporary),
+//	)
+//	err := retrier.Do(ctx, func(ctx context.Context) error {
+//		return billing.Charge(ctx, req)
+//	})
+//
+// Retrier is safe for concurrent use, operation name is used in metrics.
+type Retrier struct {
+	operation   string
+	backoff     Backoff
+	maxAttempts int
+	maxElapsed  time.Duration
+	retryable   func(err error) bool
+	budget      *Budget
+	onRetry     func(attempt int, err error, delay time.Duration)
+}
+
+func New(operation string, opts ...Option) *Retrier {
+	initMetrics()
+
+	r := &Retrier{
+		operation:   operation,
+		backoff:     DefaultExponential,
+		maxAttempts: defaultMaxAttempts,
+	}
+
+	for _, opt := range opts {
+		opt.apply(r)
+	}
+
+	return r
+}
+
+// Do calls fn until it succeeds, returns a non-retryable error or the policy is exhausted.
+// The last error is returned.
+func (r *Retrier) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	start := time.Now()
+
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			if r.budget != nil {
+				r.budget.success()
+			}
+			metrics.CallsCounter.WithLabelValues(r.operation, "success").Inc()
+			return nil
+		}
+
+		if IsPermanent(err) || (r.retryable != nil && !r.retryable(err)) {
+			metrics.CallsCounter.WithLabelValues(r.operation, "failed").Inc()
+			return err
+		}
+
+		if ctx.Err() != nil {
+			metrics.CallsCounter.WithLabelValues(r.operation, "canceled").Inc()
+			return err
+		}
+
+		delay := r.backoff.Delay(attempt)
+		if (r.maxAttempts > 0 && attempt >= r.maxAttempts) ||
+			(r.maxElapsed > 0 && time.Since(start)+delay > r.maxElapsed) {
+			metrics.CallsCounter.WithLabelValues(r.operation, "exhausted").Inc()
+			return err
+		}
+
+		if r.budget != nil && !r.budget.failure() {
+			metrics.CallsCounter.WithLabelValues(r.operation, "budget").Inc()
+			return err
+		}
+
+		if r.onRetry != nil {
+			r.onRetry(attempt, err, delay)
+		}
+		metrics.RetriesCounter.WithLabelValues(r.operation).Inc()
+
+		if Sleep(ctx, delay) != nil {
+			metrics.CallsCounter.WithLabelValues(r.operation, "canceled").Inc()
+			return err
+		}
+	}
+}
+
+// Do calls fn returning a value with retries, see Retrier.Do
+func Do[T any](ctx context.Context, r *Retrier, fn func(ctx context.Context) (T, error)) (T, error) {
+	var res T
+	err := r.Do(ctx, func(ctx context.Context) error {
+		var err error
+		res, err = fn(ctx)
+		return err
+	})
+	return res, err
+}
+
+// Sleep waits for d or until ctx is done
+func Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,77 @@
+package infraretry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestRetrier(t *testing.T) {
+	ctx := context.Background()
+	failure := errors.New("failure")
+
+	calls := 0
+	r := New("test", WithBackoff(Constant(time.Millisecond)), WithMaxAttempts(3))
+	err := r.Do(ctx, func(context.Context) error {
+		calls++
+		return failure
+	})
+	if !errors.Is(err, failure) || calls != 3 {
+		t.Fatalf("expected 3 failed calls, got %d: %v", calls, err)
+	}
+
+	calls = 0
+	res, err := Do(ctx, r, func(context.Context) (int, error) {
+		calls++
+		if calls < 2 {
+			return 0, failure
+		}
+		return 42, nil
+	})
+	if err != nil || res != 42 {
+		t.Fatalf("unexpected result: %d, %v", res, err)
+	}
+
+	calls = 0
+	err = r.Do(ctx, func(context.Context) error {
+		calls++
+		return Permanent(failure)
+	})
+	if !errors.Is(err, failure) || calls != 1 {
+		t.Fatalf("permanent error must not be retried, got %d calls", calls)
+	}
+}
+
+func TestBudget(t *testing.T) {
+	budget := NewBudget(4, 1)
+	r := New("test_budget", WithBackoff(Constant(0)), WithMaxAttempts(0), WithBudget(budget))
+
+	calls := 0
+	_ = r.Do(context.Background(), func(context.Context) error {
+		calls++
+		return errors.New("failure")
+	})
+	// tokens: 4 -> 3 (retry) -> 2 (stop)
+	if calls != 2 {
+		t.Fatalf("expected budget to stop after 2 calls, got %d", calls)
+	}
+}
+
+func TestExponential(t *testing.T) {
+	b := Exponential{Initial: 100 * time.Millisecond, Max: time.Second}
+	for attempt, expected := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		if d := b.Delay(attempt + 1); d != expected*time.Millisecond {
+			t.Errorf("attempt %d: expected %s, got %s", attempt+1, expected*time.Millisecond, d)
+		}
+	}
+
+	// math.Pow overflows without Max
+	if d := (Exponential{Initial: time.Second, Jitter: 0.2}).Delay(2000); d <= 0 {
+		t.Errorf("expected positive delay of overflowing attempt, got %s", d)
+	}
+	if d := b.Delay(2000); d != time.Second {
+		t.Errorf("expected max delay of overflowing attempt, got %s", d)
+	}
+}
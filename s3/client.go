@@ -0,0 +1,331 @@
+package infras3
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/pkg/errors"
+)
+
+// Client is an object storage client with transfer helpers and metrics
+type Client struct {
+	name       string
+	cfg        *ConnectionConfig
+	s3         *s3.Client
+	uploader   *manager.Uploader
+	downloader *manager.Downloader
+	presigner  *s3.PresignClient
+}
+
+// S3 returns underlying S3 client
+func (c *Client) S3() *s3.Client {
+	return c.s3
+}
+
+// ProgressFunc is called with a total number of bytes transferred so far
+type ProgressFunc func(transferred int64)
+
+type TransferOption interface {
+	apply(o *transferOptions)
+}
+
+type transferOptions struct {
+	progress    ProgressFunc
+	contentType string
+}
+
+type optionProgress ProgressFunc
+
+func (opt optionProgress) apply(o *transferOptions) {
+	o.progress = ProgressFunc(opt)
+}
+
+// WithProgress sets transfer progress callback. It is called concurrently for multipart transfers
+func WithProgress(fn ProgressFunc) TransferOption {
+	return optionProgress(fn)
+}
+
+type optionContentType string
+
+func (opt optionContentType) apply(o *transferOptions) {
+	o.contentType = string(opt)
+}
+
+// WithContentType sets uploaded object content type
+func WithContentType(contentType string) TransferOption {
+	return optionContentType(contentType)
+}
+
+func newTransferOptions(opts []TransferOption) *transferOptions {
+	o := &transferOptions{}
+	for _, opt := range opts {
+		opt.apply(o)
+	}
+	return o
+}
+
+// Upload uploads an object. Large bodies are uploaded in parts concurrently
+func (c *Client) Upload(ctx context.Context, bucket, key string, body io.Reader, opts ...TransferOption) (err error) {
+	defer observe(c.name, bucket, "upload", time.Now(), &err)
+
+	o := newTransferOptions(opts)
+
+	var counter interface {
+		io.Reader
+		transferred() int64
+	}
+	if seekable, ok := body.(readerAtSeeker); ok {
+		counter = &progressReaderAtSeeker{reader: seekable, progress: o.progress}
+	} else {
+		counter = &progressReader{reader: body, progress: o.progress}
+	}
+
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   counter,
+	}
+	if o.contentType != "" {
+		input.ContentType = aws.String(o.contentType)
+	}
+
+	_, err = c.uploader.Upload(ctx, input)
+	metrics.TransferredBytesCounter.WithLabelValues(c.name, bucket, "upload").Add(float64(counter.transferred()))
+
+	return errors.Wrapf(err, "unable to upload %s/%s", bucket, key)
+}
+
+// Download downloads an object into w. Large objects are downloaded in parts concurrently
+func (c *Client) Download(ctx context.Context, bucket, key string, w io.WriterAt, opts ...TransferOption) (n int64, err error) {
+	defer observe(c.name, bucket, "download", time.Now(), &err)
+
+	o := newTransferOptions(opts)
+
+	n, err = c.downloader.Download(ctx, &progressWriter{writer: w, progress: o.progress}, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	metrics.TransferredBytesCounter.WithLabelValues(c.name, bucket, "download").Add(float64(n))
+
+	return n, errors.Wrapf(err, "unable to download %s/%s", bucket, key)
+}
+
+// Get returns object body stream, it must be closed by the caller
+func (c *Client) Get(ctx context.Context, bucket, key string) (body io.ReadCloser, err error) {
+	defer observe(c.name, bucket, "get", time.Now(), &err)
+
+	out, err := c.s3.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get %s/%s", bucket, key)
+	}
+
+	return out.Body, nil
+}
+
+// Exists checks if an object exists
+func (c *Client) Exists(ctx context.Context, bucket, key string) (exists bool, err error) {
+	defer observe(c.name, bucket, "head", time.Now(), &err)
+
+	_, err = c.s3.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err == nil {
+		return true, nil
+	}
+
+	if isNotFound(err) {
+		return false, nil
+	}
+
+	return false, errors.Wrapf(err, "unable to check %s/%s", bucket, key)
+}
+
+// Delete deletes an object
+func (c *Client) Delete(ctx context.Context, bucket, key string) (err error) {
+	defer observe(c.name, bucket, "delete", time.Now(), &err)
+
+	_, err = c.s3.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+
+	return errors.Wrapf(err, "unable to delete %s/%s", bucket, key)
+}
+
+// PresignGet returns a URL to download an object without credentials
+func (c *Client) PresignGet(ctx context.Context, bucket, key string, expires time.Duration) (string, error) {
+	req, err := c.presigner.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(expires))
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to presign %s/%s", bucket, key)
+	}
+
+	return req.URL, nil
+}
+
+// PresignPut returns a URL to upload an object with PUT request without credentials
+func (c *Client) PresignPut(ctx context.Context, bucket, key string, expires time.Duration) (string, error) {
+	req, err := c.presigner.PresignPutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(expires))
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to presign %s/%s", bucket, key)
+	}
+
+	return req.URL, nil
+}
+
+func isNotFound(err error) bool {
+	var notFound *types.NotFound
+	if errors.As(err, &notFound) {
+		return true
+	}
+
+	var noSuchKey *types.NoSuchKey
+	if errors.As(err, &noSuchKey) {
+		return true
+	}
+
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == http.StatusText(http.StatusNotFound)
+}
+
+type progressReader struct {
+	reader   io.Reader
+	progress ProgressFunc
+	total    atomic.Int64
+}
+
+func (r *progressReader) transferred() int64 {
+	return r.total.Load()
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		total := r.total.Add(int64(n))
+		if r.progress != nil {
+			r.progress(total)
+		}
+	}
+	return n, err
+}
+
+type readerAtSeeker interface {
+	io.ReadSeeker
+	io.ReaderAt
+}
+
+// progressReaderAtSeeker passes through seeking of the body, so the uploader reads parts concurrently
+// without buffering them. The body may be read again after a seek, e.g. to compute a checksum,
+// so every byte range is counted once
+type progressReaderAtSeeker struct {
+	reader   readerAtSeeker
+	progress ProgressFunc
+
+	mu     sync.Mutex
+	pos    int64
+	ranges [][2]int64 // sorted disjoint ranges read so far
+	total  int64
+}
+
+func (r *progressReaderAtSeeker) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+
+	r.mu.Lock()
+	off := r.pos
+	r.pos += int64(n)
+	r.mu.Unlock()
+
+	r.add(off, n)
+	return n, err
+}
+
+func (r *progressReaderAtSeeker) ReadAt(p []byte, off int64) (int, error) {
+	n, err := r.reader.ReadAt(p, off)
+	r.add(off, n)
+	return n, err
+}
+
+func (r *progressReaderAtSeeker) Seek(offset int64, whence int) (int64, error) {
+	pos, err := r.reader.Seek(offset, whence)
+	if err == nil {
+		r.mu.Lock()
+		r.pos = pos
+		r.mu.Unlock()
+	}
+	return pos, err
+}
+
+func (r *progressReaderAtSeeker) transferred() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.total
+}
+
+// add merges [off, off+n) into the ranges read so far and reports the progress if new bytes were read
+func (r *progressReaderAtSeeker) add(off int64, n int) {
+	if n <= 0 {
+		return
+	}
+
+	r.mu.Lock()
+	start, end := off, off+int64(n)
+	merged := make([][2]int64, 0, len(r.ranges)+1)
+	covered := int64(0)
+	for _, rg := range r.ranges {
+		if rg[1] < start || rg[0] > end {
+			merged = append(merged, rg)
+			continue
+		}
+		covered += rg[1] - rg[0]
+		start, end = min(start, rg[0]), max(end, rg[1])
+	}
+	merged = append(merged, [2]int64{start, end})
+	sort.Slice(merged, func(i, j int) bool { return merged[i][0] < merged[j][0] })
+	r.ranges = merged
+
+	added := end - start - covered
+	r.total += added
+	total := r.total
+	r.mu.Unlock()
+
+	if added > 0 && r.progress != nil {
+		r.progress(total)
+	}
+}
+
+type progressWriter struct {
+	writer      io.WriterAt
+	progress    ProgressFunc
+	transferred atomic.Int64
+}
+
+func (w *progressWriter) WriteAt(p []byte, off int64) (int, error) {
+	n, err := w.writer.WriteAt(p, off)
+	if n > 0 {
+		total := w.transferred.Add(int64(n))
+		if w.progress != nil {
+			w.progress(total)
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,113 @@
+package infras3
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	cont := NewContainer()
+	err := cont.Connect("test", &ConnectionConfig{
+		Provider:        ProviderMinIO,
+		Endpoint:        srv.URL,
+		AccessKeyID:     "key",
+		SecretAccessKey: "secret",
+		MaxAttempts:     1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cont.Get("test")
+}
+
+func TestClientExists(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/bucket/found" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	ok, err := client.Exists(context.Background(), "bucket", "found")
+	if err != nil || !ok {
+		t.Fatalf("expected existing object, got %v, %v", ok, err)
+	}
+
+	ok, err = client.Exists(context.Background(), "bucket", "missing")
+	if err != nil || ok {
+		t.Fatalf("expected missing object, got %v, %v", ok, err)
+	}
+}
+
+func TestClientUploadProgress(t *testing.T) {
+	var received []byte
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		buf := &bytes.Buffer{}
+		_, _ = buf.ReadFrom(r.Body)
+		received = buf.Bytes()
+		w.WriteHeader(http.StatusOK)
+	})
+
+	var transferred int64
+	body := strings.Repeat("a", 1024)
+	err := client.Upload(context.Background(), "bucket", "key", strings.NewReader(body),
+		WithProgress(func(n int64) { transferred = n }),
+		WithContentType("text/plain"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if transferred != int64(len(body)) {
+		t.Fatalf("expected %d bytes transferred, got %d", len(body), transferred)
+	}
+	if !bytes.Contains(received, []byte(body)) {
+		t.Fatal("body was not uploaded")
+	}
+}
+
+func TestProgressReaderAtSeeker(t *testing.T) {
+	var reported int64
+	r := &progressReaderAtSeeker{
+		reader:   strings.NewReader(strings.Repeat("a", 100)),
+		progress: func(n int64) { reported = n },
+	}
+
+	buf := make([]byte, 10)
+	_, _ = r.ReadAt(buf, 50)
+	_, _ = r.ReadAt(buf, 55)
+	_, _ = r.Read(buf)
+	_, _ = r.Seek(0, io.SeekStart)
+	_, _ = r.Read(buf)
+
+	if r.transferred() != 25 || reported != 25 {
+		t.Fatalf("expected 25 bytes transferred, got %d, reported %d", r.transferred(), reported)
+	}
+}
+
+func TestClientPresign(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("presign must not send requests")
+	})
+
+	url, err := client.PresignGet(context.Background(), "bucket", "key", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(url, "/bucket/key") || !strings.Contains(url, "X-Amz-Expires=60") {
+		t.Fatalf("unexpected presigned url: %s", url)
+	}
+}
@@ -0,0 +1,104 @@
+package infras3
+
+import (
+	"github.com/pkg/errors"
+)
+
+const (
+	ProviderAWS   = "aws"
+	ProviderMinIO = "minio"
+	ProviderGCS   = "gcs"
+
+	gcsEndpoint = "https://storage.googleapis.com"
+)
+
+type ConnectionsConfig map[string]*ConnectionConfig
+
+type ConnectionConfig struct {
+	// Provider is "aws" (default), "minio" or "gcs" (S3 interoperability API with HMAC keys)
+	Provider string `mapstructure:"provider"`
+
+	// Region. optional for minio and gcs
+	Region string `mapstructure:"region"`
+
+	// Custom endpoint. Mandatory for minio, optional for aws and gcs
+	Endpoint string `mapstructure:"endpoint"`
+
+	// Static credentials. Default AWS credentials chain is used if empty
+	AccessKeyID     string `mapstructure:"access_key_id"`
+	SecretAccessKey string `mapstructure:"secret_access_key"`
+
+	// UsePathStyle addresses buckets as endpoint/bucket instead of bucket.endpoint. Always enabled for minio
+	UsePathStyle bool `mapstructure:"use_path_style"`
+
+	// MaxAttempts is the max number of attempts of a request, including retries. optional
+	MaxAttempts int `mapstructure:"max_attempts"`
+
+	// PartSize is a multipart upload and download part size in bytes. optional, 5MB by default
+	PartSize int64 `mapstructure:"part_size"`
+
+	// Concurrency is the number of parts transferred in parallel. optional, 5 by default
+	Concurrency int `mapstructure:"concurrency"`
+
+	// HealthBucket is checked with HeadBucket request in Check. optional, connection is not checked if empty
+	HealthBucket string `mapstructure:"health_bucket"`
+}
+
+func (c *ConnectionsConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	for name, conf := range *c {
+		if err := conf.Validate(); err != nil {
+			return errors.Wrap(err, name)
+		}
+	}
+
+	return nil
+}
+
+func (c *ConnectionConfig) Validate() error {
+	if c == nil {
+		return errors.New("empty connection config")
+	}
+
+	switch c.Provider {
+	case "", ProviderAWS:
+		if c.Region == "" {
+			return errors.New("region is mandatory")
+		}
+	case ProviderMinIO:
+		if c.Endpoint == "" {
+			return errors.New("endpoint is mandatory for minio")
+		}
+	case ProviderGCS:
+	default:
+		return errors.Errorf("unknown provider: %s", c.Provider)
+	}
+
+	if (c.AccessKeyID == "") != (c.SecretAccessKey == "") {
+		return errors.New("access_key_id and secret_access_key must be set together")
+	}
+
+	if c.MaxAttempts < 0 || c.PartSize < 0 || c.Concurrency < 0 {
+		return errors.New("max_attempts, part_size and concurrency should be greater than or equal to 0")
+	}
+
+	return nil
+}
+
+func (c *ConnectionConfig) region() string {
+	if c.Region != "" {
+		return c.Region
+	}
+	// region is required by the signer, but ignored by minio and gcs
+	return "us-east-1"
+}
+
+func (c *ConnectionConfig) endpoint() string {
+	if c.Endpoint == "" && c.Provider == ProviderGCS {
+		return gcsEndpoint
+	}
+	return c.Endpoint
+}
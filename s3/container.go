@@ -0,0 +1,144 @@
+package infras3
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/pkg/errors"
+	infraoperator "github.com/pushwoosh/infra/operator"
+)
+
+// Container is a simple container for holding named object storage clients
+type Container struct {
+	mu      *sync.RWMutex
+	cfg     map[string]*ConnectionConfig
+	clients map[string]*Client
+}
+
+var (
+	_ infraoperator.Stopper = (*Container)(nil)
+	_ infraoperator.Checker = (*Container)(nil)
+)
+
+func NewContainer() *Container {
+	return &Container{
+		mu:      &sync.RWMutex{},
+		cfg:     make(map[string]*ConnectionConfig),
+		clients: make(map[string]*Client),
+	}
+}
+
+// Connect creates a new named client
+func (cont *Container) Connect(name string, cfg *ConnectionConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	opts := []func(*awsconfig.LoadOptions) error{
+		awsconfig.WithRegion(cfg.region()),
+	}
+
+	if cfg.AccessKeyID != "" {
+		opts = append(opts, awsconfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, "")))
+	}
+
+	if cfg.MaxAttempts > 0 {
+		opts = append(opts, awsconfig.WithRetryMaxAttempts(cfg.MaxAttempts))
+	}
+
+	awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), opts...)
+	if err != nil {
+		return errors.Wrap(err, "unable to load AWS config")
+	}
+
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if endpoint := cfg.endpoint(); endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+		o.UsePathStyle = cfg.UsePathStyle || cfg.Provider == ProviderMinIO
+	})
+
+	initMetrics()
+
+	cont.mu.Lock()
+	defer cont.mu.Unlock()
+
+	cont.cfg[name] = cfg
+	cont.clients[name] = &Client{
+		name: name,
+		cfg:  cfg,
+		s3:   client,
+		uploader: manager.NewUploader(client, func(u *manager.Uploader) {
+			if cfg.PartSize > 0 {
+				u.PartSize = cfg.PartSize
+			}
+			if cfg.Concurrency > 0 {
+				u.Concurrency = cfg.Concurrency
+			}
+		}),
+		downloader: manager.NewDownloader(client, func(d *manager.Downloader) {
+			if cfg.PartSize > 0 {
+				d.PartSize = cfg.PartSize
+			}
+			if cfg.Concurrency > 0 {
+				d.Concurrency = cfg.Concurrency
+			}
+		}),
+		presigner: s3.NewPresignClient(client),
+	}
+
+	return nil
+}
+
+// Get gets client from a container
+func (cont *Container) Get(name string) *Client {
+	cont.mu.RLock()
+	defer cont.mu.RUnlock()
+
+	return cont.clients[name]
+}
+
+// Remove removes named client from the container
+func (cont *Container) Remove(name string) {
+	cont.mu.Lock()
+	defer cont.mu.Unlock()
+
+	delete(cont.clients, name)
+	delete(cont.cfg, name)
+}
+
+// Check checks health buckets of all clients in the container
+func (cont *Container) Check(ctx context.Context) error {
+	cont.mu.RLock()
+	defer cont.mu.RUnlock()
+
+	for name, client := range cont.clients {
+		bucket := cont.cfg[name].HealthBucket
+		if bucket == "" {
+			continue
+		}
+
+		if _, err := client.s3.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(bucket)}); err != nil {
+			return errors.Wrap(err, name)
+		}
+	}
+
+	return nil
+}
+
+// Stop removes all clients from the container
+func (cont *Container) Stop(_ context.Context) error {
+	cont.mu.Lock()
+	defer cont.mu.Unlock()
+
+	cont.clients = make(map[string]*Client)
+	cont.cfg = make(map[string]*ConnectionConfig)
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package infras3
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	RequestsCounter          *prometheus.CounterVec
+	RequestDurationHistogram *prometheus.HistogramVec
+	TransferredBytesCounter  *prometheus.CounterVec
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.RequestsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "s3_requests_total",
+			Help: "Number of object storage operations by bucket, operation and status",
+		}, []string{"connection", "bucket", "operation", "status"})
+
+		metrics.RequestDurationHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "s3_request_duration_seconds",
+			Help:    "Object storage operation duration",
+			Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 300},
+		}, []string{"connection", "bucket", "operation"})
+
+		metrics.TransferredBytesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "s3_transferred_bytes_total",
+			Help: "Number of uploaded and downloaded bytes",
+		}, []string{"connection", "bucket", "direction"})
+
+		prometheus.MustRegister(
+			metrics.RequestsCounter,
+			metrics.RequestDurationHistogram,
+			metrics.TransferredBytesCounter,
+		)
+	})
+}
+
+func observe(connection, bucket, operation string, start time.Time, err *error) {
+	status := "success"
+	if *err != nil {
+		status = "error"
+	}
+
+	metrics.RequestsCounter.WithLabelValues(connection, bucket, operation, status).Inc()
+	metrics.RequestDurationHistogram.WithLabelValues(connection, bucket, operation).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,244 @@
+package infraschema
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const contentType = "application/vnd.schemaregistry.v1+json"
+
+// Client is a Confluent Schema Registry REST client
+type Client struct {
+	cfg  *Config
+	http *http.Client
+
+	mu     sync.RWMutex
+	byID   map[int]*Schema
+	latest map[string]latestEntry
+}
+
+type latestEntry struct {
+	schema    *Schema
+	expiresAt time.Time
+}
+
+var _ Registry = (*Client)(nil)
+
+func NewClient(cfg *Config) (*Client, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		cfg:    cfg,
+		http:   &http.Client{Timeout: cfg.GetTimeout()},
+		byID:   make(map[int]*Schema),
+		latest: make(map[string]latestEntry),
+	}, nil
+}
+
+// Error is a non-successful response of the registry
+type Error struct {
+	StatusCode int
+	Code       int    `json:"error_code"`
+	Message    string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("schema registry: status %d: %d %s", e.StatusCode, e.Code, e.Message)
+}
+
+type schemaRequest struct {
+	Schema     string `json:"schema"`
+	SchemaType string `json:"schemaType,omitempty"`
+}
+
+type schemaResponse struct {
+	ID         int    `json:"id"`
+	Subject    string `json:"subject"`
+	Version    int    `json:"version"`
+	Schema     string `json:"schema"`
+	SchemaType string `json:"schemaType"`
+}
+
+func (r *schemaResponse) toSchema() *Schema {
+	format := r.SchemaType
+	if format == "" {
+		format = FormatAvro
+	}
+
+	return &Schema{
+		ID:         r.ID,
+		Subject:    r.Subject,
+		Version:    r.Version,
+		Format:     format,
+		Definition: r.Schema,
+	}
+}
+
+func (c *Client) Register(ctx context.Context, subject, format, definition string) (*Schema, error) {
+	if err := validateFormat(format); err != nil {
+		return nil, err
+	}
+
+	req := newSchemaRequest(format, definition)
+	path := "/subjects/" + url.PathEscape(subject) + "/versions"
+	if err := c.do(ctx, http.MethodPost, path, req, nil); err != nil {
+		return nil, err
+	}
+
+	// registration returns id only, lookup returns the version as well
+	var resp schemaResponse
+	if err := c.do(ctx, http.MethodPost, "/subjects/"+url.PathEscape(subject), req, &resp); err != nil {
+		return nil, err
+	}
+
+	schema := resp.toSchema()
+	c.mu.Lock()
+	c.byID[schema.ID] = schema
+	delete(c.latest, subject)
+	c.mu.Unlock()
+
+	return schema, nil
+}
+
+func (c *Client) CheckCompatibility(ctx context.Context, subject, format, definition string) error {
+	if err := validateFormat(format); err != nil {
+		return err
+	}
+
+	var resp struct {
+		IsCompatible bool `json:"is_compatible"`
+	}
+	path := "/compatibility/subjects/" + url.PathEscape(subject) + "/versions/latest"
+	err := c.do(ctx, http.MethodPost, path, newSchemaRequest(format, definition), &resp)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		// the first version of a subject is always compatible
+		return nil
+	case err != nil:
+		return err
+	case !resp.IsCompatible:
+		return ErrIncompatible
+	}
+
+	return nil
+}
+
+func (c *Client) Latest(ctx context.Context, subject string) (*Schema, error) {
+	c.mu.RLock()
+	entry, ok := c.latest[subject]
+	c.mu.RUnlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.schema, nil
+	}
+
+	var resp schemaResponse
+	if err := c.do(ctx, http.MethodGet, "/subjects/"+url.PathEscape(subject)+"/versions/latest", nil, &resp); err != nil {
+		return nil, err
+	}
+
+	schema := resp.toSchema()
+	c.mu.Lock()
+	c.byID[schema.ID] = schema
+	c.latest[subject] = latestEntry{schema: schema, expiresAt: time.Now().Add(c.cfg.GetLatestTTL())}
+	c.mu.Unlock()
+
+	return schema, nil
+}
+
+func (c *Client) ByID(ctx context.Context, id int) (*Schema, error) {
+	c.mu.RLock()
+	schema, ok := c.byID[id]
+	c.mu.RUnlock()
+	if ok {
+		return schema, nil
+	}
+
+	var resp schemaResponse
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/schemas/ids/%d", id), nil, &resp); err != nil {
+		return nil, err
+	}
+	resp.ID = id
+
+	schema = resp.toSchema()
+	c.mu.Lock()
+	c.byID[id] = schema
+	c.mu.Unlock()
+
+	return schema, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return errors.Wrap(err, "unable to encode request body")
+		}
+		payload = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.cfg.URL, "/")+path, payload)
+	if err != nil {
+		return errors.Wrap(err, "unable to create request")
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", contentType)
+	if c.cfg.Username != "" {
+		req.SetBasicAuth(c.cfg.Username, c.cfg.Password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "schema registry request failed")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return decodeError(resp)
+	}
+
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	return errors.Wrap(json.NewDecoder(resp.Body).Decode(out), "unable to decode response")
+}
+
+func decodeError(resp *http.Response) error {
+	e := &Error{StatusCode: resp.StatusCode}
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(e)
+
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return errors.Wrap(ErrNotFound, e.Error())
+	case http.StatusConflict:
+		return errors.Wrap(ErrIncompatible, e.Error())
+	}
+
+	return e
+}
+
+func newSchemaRequest(format, definition string) *schemaRequest {
+	req := &schemaRequest{Schema: definition}
+	// AVRO is the default type and is omitted for compatibility with older registries
+	if format != FormatAvro {
+		req.SchemaType = format
+	}
+	return req
+}
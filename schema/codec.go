@@ -0,0 +1,254 @@
+package infraschema
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"github.com/hamba/avro/v2"
+	"github.com/pkg/errors"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec encodes values with the latest schema of a subject and decodes payloads by schema id:
+//
+//	codec := infraschema.NewCodec(registry)
+//	body, schema, err := codec.Encode(ctx, "push.sent", event)
+//	...
+//	err = codec.Decode(ctx, schema.ID, body, &event)
+//
+// JSON payloads are validated against JSON Schema, Avro payloads are encoded with the schema.
+// Protobuf definitions are stored as .proto sources, so payloads are checked by message name only
+type Codec struct {
+	registry Registry
+
+	mu     sync.RWMutex
+	parsed map[int]*parsedSchema
+}
+
+type parsedSchema struct {
+	*Schema
+	json *jsonschema.Schema
+	avro avro.Schema
+}
+
+func NewCodec(registry Registry) *Codec {
+	initMetrics()
+
+	return &Codec{
+		registry: registry,
+		parsed:   make(map[int]*parsedSchema),
+	}
+}
+
+// Encode encodes the value with the latest schema of the subject.
+// Protobuf schemas require proto.Message values
+func (c *Codec) Encode(ctx context.Context, subject string, v any) ([]byte, *Schema, error) {
+	latest, err := c.registry.Latest(ctx, subject)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "subject %s", subject)
+	}
+
+	schema, err := c.schema(latest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := schema.encode(v)
+	observe(schema.Format, "encode", err)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return data, schema.Schema, nil
+}
+
+// ValidateLatest validates the payload against the latest schema of the subject and returns the schema
+func (c *Codec) ValidateLatest(ctx context.Context, subject string, data []byte) (*Schema, error) {
+	latest, err := c.registry.Latest(ctx, subject)
+	if err != nil {
+		return nil, errors.Wrapf(err, "subject %s", subject)
+	}
+
+	schema, err := c.schema(latest)
+	if err != nil {
+		return nil, err
+	}
+
+	err = schema.validate(data)
+	observe(schema.Format, "validate", err)
+
+	return schema.Schema, err
+}
+
+// Validate validates the payload against the schema
+func (c *Codec) Validate(ctx context.Context, id int, data []byte) error {
+	schema, err := c.byID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = schema.validate(data)
+	observe(schema.Format, "validate", err)
+
+	return err
+}
+
+// Decode decodes the payload written with the schema into v
+func (c *Codec) Decode(ctx context.Context, id int, data []byte, v any) error {
+	schema, err := c.byID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = schema.decode(data, v)
+	observe(schema.Format, "decode", err)
+
+	return err
+}
+
+func (c *Codec) byID(ctx context.Context, id int) (*parsedSchema, error) {
+	c.mu.RLock()
+	schema, ok := c.parsed[id]
+	c.mu.RUnlock()
+	if ok {
+		return schema, nil
+	}
+
+	s, err := c.registry.ByID(ctx, id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "schema %d", id)
+	}
+
+	return c.schema(s)
+}
+
+func (c *Codec) schema(s *Schema) (*parsedSchema, error) {
+	c.mu.RLock()
+	schema, ok := c.parsed[s.ID]
+	c.mu.RUnlock()
+	if ok {
+		return schema, nil
+	}
+
+	schema, err := parse(s)
+	if err != nil {
+		return nil, errors.Wrapf(err, "schema %d", s.ID)
+	}
+
+	c.mu.Lock()
+	c.parsed[s.ID] = schema
+	c.mu.Unlock()
+
+	return schema, nil
+}
+
+func parse(s *Schema) (*parsedSchema, error) {
+	schema := &parsedSchema{Schema: s}
+
+	var err error
+	switch s.Format {
+	case FormatJSON:
+		schema.json, err = jsonschema.CompileString(strconv.Itoa(s.ID)+".json", s.Definition)
+		err = errors.Wrap(err, "invalid json schema")
+	case FormatAvro:
+		schema.avro, err = avro.ParseWithCache(s.Definition, "", &avro.SchemaCache{})
+		err = errors.Wrap(err, "invalid avro schema")
+	case FormatProtobuf:
+	default:
+		err = validateFormat(s.Format)
+	}
+
+	return schema, err
+}
+
+func (s *parsedSchema) encode(v any) ([]byte, error) {
+	switch s.Format {
+	case FormatJSON:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to encode payload")
+		}
+		return data, s.validate(data)
+	case FormatAvro:
+		data, err := avro.Marshal(s.avro, v)
+		if err != nil {
+			return nil, errors.Wrap(ErrInvalidPayload, err.Error())
+		}
+		return data, nil
+	default:
+		msg, err := s.protoMessage(v)
+		if err != nil {
+			return nil, err
+		}
+		return proto.Marshal(msg)
+	}
+}
+
+func (s *parsedSchema) validate(data []byte) error {
+	switch s.Format {
+	case FormatJSON:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+
+		var v any
+		if err := decoder.Decode(&v); err != nil {
+			return errors.Wrap(ErrInvalidPayload, err.Error())
+		}
+		if err := s.json.Validate(v); err != nil {
+			return errors.Wrap(ErrInvalidPayload, err.Error())
+		}
+	case FormatAvro:
+		var v any
+		if err := avro.Unmarshal(s.avro, data, &v); err != nil {
+			return errors.Wrap(ErrInvalidPayload, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (s *parsedSchema) decode(data []byte, v any) error {
+	switch s.Format {
+	case FormatJSON:
+		if err := s.validate(data); err != nil {
+			return err
+		}
+		return errors.Wrap(json.Unmarshal(data, v), "unable to decode payload")
+	case FormatAvro:
+		if err := avro.Unmarshal(s.avro, data, v); err != nil {
+			return errors.Wrap(ErrInvalidPayload, err.Error())
+		}
+		return nil
+	default:
+		msg, err := s.protoMessage(v)
+		if err != nil {
+			return err
+		}
+		if err = proto.Unmarshal(data, msg); err != nil {
+			return errors.Wrap(ErrInvalidPayload, err.Error())
+		}
+		return nil
+	}
+}
+
+// protoMessage checks that the message is declared in the .proto definition
+func (s *parsedSchema) protoMessage(v any) (proto.Message, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, errors.Errorf("protobuf schema requires proto.Message, got %T", v)
+	}
+
+	name := string(msg.ProtoReflect().Descriptor().Name())
+	declared := regexp.MustCompile(fmt.Sprintf(`\bmessage\s+%s\s*\{`, regexp.QuoteMeta(name)))
+	if !declared.MatchString(s.Definition) {
+		return nil, errors.Wrapf(ErrInvalidPayload, "message %s is not declared in schema %d", name, s.ID)
+	}
+
+	return msg, nil
+}
@@ -0,0 +1,84 @@
+package infraschema
+
+import (
+	"encoding/json"
+	"slices"
+
+	"github.com/hamba/avro/v2"
+	"github.com/pkg/errors"
+)
+
+// checkCompatibility checks that the next version reads payloads written with the previous one.
+// Avro uses the schema resolution rules, JSON Schema is checked by top level properties:
+// new required properties and changed property types are incompatible.
+// Protobuf definitions are not checked, field numbers compatibility is left to the registry server
+func checkCompatibility(prev, next *Schema) error {
+	if prev.Format != next.Format {
+		return errors.Wrapf(ErrIncompatible, "format changed from %s to %s", prev.Format, next.Format)
+	}
+
+	switch next.Format {
+	case FormatAvro:
+		return checkAvro(prev.Definition, next.Definition)
+	case FormatJSON:
+		return checkJSON(prev.Definition, next.Definition)
+	}
+
+	return nil
+}
+
+func checkAvro(prev, next string) error {
+	writer, err := avro.ParseWithCache(prev, "", &avro.SchemaCache{})
+	if err != nil {
+		return errors.Wrap(err, "invalid avro schema")
+	}
+
+	reader, err := avro.ParseWithCache(next, "", &avro.SchemaCache{})
+	if err != nil {
+		return errors.Wrap(err, "invalid avro schema")
+	}
+
+	if err = avro.NewSchemaCompatibility().Compatible(reader, writer); err != nil {
+		return errors.Wrap(ErrIncompatible, err.Error())
+	}
+
+	return nil
+}
+
+type jsonSchemaObject struct {
+	Required   []string `json:"required"`
+	Properties map[string]struct {
+		Type any `json:"type"`
+	} `json:"properties"`
+}
+
+func checkJSON(prev, next string) error {
+	var p, n jsonSchemaObject
+	if err := json.Unmarshal([]byte(prev), &p); err != nil {
+		return errors.Wrap(err, "invalid json schema")
+	}
+	if err := json.Unmarshal([]byte(next), &n); err != nil {
+		return errors.Wrap(err, "invalid json schema")
+	}
+
+	for _, name := range n.Required {
+		if !slices.Contains(p.Required, name) {
+			return errors.Wrapf(ErrIncompatible, "property %q became required", name)
+		}
+	}
+
+	for name, property := range n.Properties {
+		prevProperty, ok := p.Properties[name]
+		if !ok {
+			continue
+		}
+
+		prevType, _ := json.Marshal(prevProperty.Type)
+		nextType, _ := json.Marshal(property.Type)
+		if string(prevType) != string(nextType) {
+			return errors.Wrapf(ErrIncompatible, "type of property %q changed from %s to %s", name, prevType, nextType)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package infraschema
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type Config struct {
+	// URL of Confluent compatible schema registry, e.g. "http://schema-registry:8081"
+	URL string `mapstructure:"url"`
+
+	// Basic auth credentials. optional
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+
+	// Request timeout. optional, default: 10s
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// How long the latest version of a subject is cached. Schemas by id are cached forever. optional, default: 1m
+	LatestTTL time.Duration `mapstructure:"latest_ttl"`
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if c.URL == "" {
+		return errors.New("url is mandatory")
+	}
+
+	if c.Timeout < 0 {
+		return errors.New("timeout should be greater than or equal to 0")
+	}
+
+	if c.LatestTTL < 0 {
+		return errors.New("latest_ttl should be greater than or equal to 0")
+	}
+
+	return nil
+}
+
+func (c *Config) GetTimeout() time.Duration {
+	if c.Timeout == 0 {
+		return 10 * time.Second
+	}
+	return c.Timeout
+}
+
+func (c *Config) GetLatestTTL() time.Duration {
+	if c.LatestTTL == 0 {
+		return time.Minute
+	}
+	return c.LatestTTL
+}
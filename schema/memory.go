@@ -0,0 +1,97 @@
+package infraschema
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryRegistry is an in-process registry for tests and local development.
+// Compatibility checks are backward: the new version should read payloads written with the latest one
+type MemoryRegistry struct {
+	mu       sync.RWMutex
+	schemas  []*Schema
+	subjects map[string][]*Schema
+}
+
+var _ Registry = (*MemoryRegistry)(nil)
+
+func NewMemoryRegistry() *MemoryRegistry {
+	return &MemoryRegistry{
+		subjects: make(map[string][]*Schema),
+	}
+}
+
+func (r *MemoryRegistry) Register(_ context.Context, subject, format, definition string) (*Schema, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	versions := r.subjects[subject]
+	for _, schema := range versions {
+		if schema.Format == format && schema.Definition == definition {
+			return schema, nil
+		}
+	}
+
+	if err := r.check(subject, format, definition); err != nil {
+		return nil, err
+	}
+
+	schema := &Schema{
+		ID:         len(r.schemas) + 1,
+		Subject:    subject,
+		Version:    len(versions) + 1,
+		Format:     format,
+		Definition: definition,
+	}
+	r.schemas = append(r.schemas, schema)
+	r.subjects[subject] = append(versions, schema)
+
+	return schema, nil
+}
+
+func (r *MemoryRegistry) CheckCompatibility(_ context.Context, subject, format, definition string) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.check(subject, format, definition)
+}
+
+func (r *MemoryRegistry) Latest(_ context.Context, subject string) (*Schema, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions := r.subjects[subject]
+	if len(versions) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return versions[len(versions)-1], nil
+}
+
+func (r *MemoryRegistry) ByID(_ context.Context, id int) (*Schema, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if id <= 0 || id > len(r.schemas) {
+		return nil, ErrNotFound
+	}
+
+	return r.schemas[id-1], nil
+}
+
+func (r *MemoryRegistry) check(subject, format, definition string) error {
+	if err := validateFormat(format); err != nil {
+		return err
+	}
+
+	if _, err := parse(&Schema{Format: format, Definition: definition}); err != nil {
+		return err
+	}
+
+	versions := r.subjects[subject]
+	if len(versions) == 0 {
+		return nil
+	}
+
+	return checkCompatibility(versions[len(versions)-1], &Schema{Format: format, Definition: definition})
+}
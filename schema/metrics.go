@@ -0,0 +1,34 @@
+package infraschema
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	OperationsCounter *prometheus.CounterVec
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.OperationsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "schema_operations_total",
+			Help: "Number of payload encodings, validations and decodings by format and result: success or error",
+		}, []string{"format", "operation", "result"})
+
+		prometheus.MustRegister(
+			metrics.OperationsCounter,
+		)
+	})
+}
+
+func observe(format, operation string, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	metrics.OperationsCounter.WithLabelValues(format, operation, result).Inc()
+}
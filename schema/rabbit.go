@@ -0,0 +1,118 @@
+package infraschema
+
+import (
+	"context"
+	"maps"
+
+	"github.com/pkg/errors"
+	infrarabbit "github.com/pushwoosh/infra/rabbit"
+)
+
+// HeaderSchemaID is a message header with the id of the schema the body is written with
+const HeaderSchemaID = "x-schema-id"
+
+// Producer validates bodies of published messages against the latest schema of the subject
+// and sets the schema id header
+type Producer struct {
+	publisher infrarabbit.Publisher
+	codec     *Codec
+	subject   string
+}
+
+var _ infrarabbit.Publisher = (*Producer)(nil)
+
+func WrapProducer(c *Codec, p infrarabbit.Publisher, subject string) *Producer {
+	return &Producer{
+		publisher: p,
+		codec:     c,
+		subject:   subject,
+	}
+}
+
+// Produce validates the body and publishes the message
+func (p *Producer) Produce(ctx context.Context, msg *infrarabbit.ProducerMessage) error {
+	schema, err := p.codec.ValidateLatest(ctx, p.subject, msg.Body)
+	if err != nil {
+		return err
+	}
+
+	return p.publisher.Produce(ctx, withSchemaID(msg, schema.ID))
+}
+
+// ProduceValue encodes the value with the latest schema of the subject into the body and publishes the message
+func (p *Producer) ProduceValue(ctx context.Context, msg *infrarabbit.ProducerMessage, v any) error {
+	body, schema, err := p.codec.Encode(ctx, p.subject, v)
+	if err != nil {
+		return err
+	}
+
+	encoded := withSchemaID(msg, schema.ID)
+	encoded.Body = body
+
+	return p.publisher.Produce(ctx, encoded)
+}
+
+// Middleware validates bodies of messages with the schema id header. Messages not matching their schema
+// are dropped by the router as malformed, messages without the header are passed as is:
+//
+//	router.Use(infraschema.Middleware(codec))
+func Middleware(c *Codec) infrarabbit.Middleware {
+	return func(next infrarabbit.HandlerFunc) infrarabbit.HandlerFunc {
+		return func(ctx context.Context, msg *infrarabbit.Message) error {
+			id, ok := SchemaID(msg)
+			if !ok {
+				return next(ctx, msg)
+			}
+
+			if err := c.Validate(ctx, id, msg.Body()); err != nil {
+				return malformed(err)
+			}
+
+			return next(ctx, msg)
+		}
+	}
+}
+
+// Decode decodes the message body with the schema from the header into v
+func Decode(ctx context.Context, c *Codec, msg *infrarabbit.Message, v any) error {
+	id, ok := SchemaID(msg)
+	if !ok {
+		return errors.Wrap(infrarabbit.ErrMalformed, "no schema id header")
+	}
+
+	return malformed(c.Decode(ctx, id, msg.Body(), v))
+}
+
+// SchemaID returns the schema id from the message header
+func SchemaID(msg *infrarabbit.Message) (int, bool) {
+	switch id := msg.Headers()[HeaderSchemaID].(type) {
+	case int32:
+		return int(id), true
+	case int64:
+		return int(id), true
+	case int:
+		return id, true
+	}
+
+	return 0, false
+}
+
+// malformed marks payload and unknown schema errors so the router drops the message,
+// registry availability errors are returned as is to requeue it
+func malformed(err error) error {
+	if errors.Is(err, ErrInvalidPayload) || errors.Is(err, ErrNotFound) {
+		return errors.Wrapf(infrarabbit.ErrMalformed, "schema: %s", err)
+	}
+	return err
+}
+
+func withSchemaID(msg *infrarabbit.ProducerMessage, id int) *infrarabbit.ProducerMessage {
+	out := *msg
+	out.Headers = maps.Clone(msg.Headers)
+	if out.Headers == nil {
+		out.Headers = make(map[string]interface{}, 1)
+	}
+	out.Headers[HeaderSchemaID] = int32(id)
+
+	return &out
+}
@@ -0,0 +1,61 @@
+package infraschema
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// Formats match schemaType values of Confluent Schema Registry
+const (
+	FormatAvro     = "AVRO"
+	FormatJSON     = "JSON"
+	FormatProtobuf = "PROTOBUF"
+)
+
+var (
+	// ErrNotFound is returned for unknown subjects and schema ids
+	ErrNotFound = errors.New("schema not found")
+
+	// ErrIncompatible is returned when a new schema version breaks consumers of the previous one
+	ErrIncompatible = errors.New("schema is incompatible with the latest version")
+
+	// ErrInvalidPayload is returned for payloads not matching their schema
+	ErrInvalidPayload = errors.New("payload does not match schema")
+)
+
+// Schema is a registered version of a subject. Schemas are immutable, a schema id always resolves
+// to the same definition
+type Schema struct {
+	ID         int
+	Subject    string
+	Version    int
+	Format     string
+	Definition string
+}
+
+// Registry stores schema versions by subject. Subjects are usually named after the message type,
+// e.g. "push.sent"
+type Registry interface {
+	// Register adds a new version of the subject. It returns the existing schema if the definition
+	// is already registered and ErrIncompatible if the definition breaks the latest version
+	Register(ctx context.Context, subject, format, definition string) (*Schema, error)
+
+	// CheckCompatibility checks the definition against the latest version of the subject without registering it
+	CheckCompatibility(ctx context.Context, subject, format, definition string) error
+
+	// Latest returns the latest version of the subject
+	Latest(ctx context.Context, subject string) (*Schema, error)
+
+	// ByID returns the schema by id
+	ByID(ctx context.Context, id int) (*Schema, error)
+}
+
+func validateFormat(format string) error {
+	switch format {
+	case FormatAvro, FormatJSON, FormatProtobuf:
+		return nil
+	default:
+		return errors.Errorf("unknown schema format %q", format)
+	}
+}
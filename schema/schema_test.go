@@ -0,0 +1,95 @@
+package infraschema
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+const pushSentV1 = `{
+	"type": "object",
+	"properties": {"user_id": {"type": "integer"}, "text": {"type": "string"}},
+	"required": ["user_id"]
+}`
+
+type pushSent struct {
+	UserID int    `json:"user_id" avro:"user_id"`
+	Text   string `json:"text" avro:"text"`
+}
+
+func TestJSONSchema(t *testing.T) {
+	ctx := context.Background()
+	registry := NewMemoryRegistry()
+	codec := NewCodec(registry)
+
+	schema, err := registry.Register(ctx, "push.sent", FormatJSON, pushSentV1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, encodedWith, err := codec.Encode(ctx, "push.sent", &pushSent{UserID: 1, Text: "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encodedWith.ID != schema.ID {
+		t.Fatalf("expected schema %d, got %d", schema.ID, encodedWith.ID)
+	}
+
+	var decoded pushSent
+	if err = codec.Decode(ctx, schema.ID, body, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.UserID != 1 || decoded.Text != "hi" {
+		t.Fatalf("unexpected payload %+v", decoded)
+	}
+
+	if err = codec.Validate(ctx, schema.ID, []byte(`{"text":"no user"}`)); !errors.Is(err, ErrInvalidPayload) {
+		t.Fatalf("expected ErrInvalidPayload, got %v", err)
+	}
+
+	_, err = registry.Register(ctx, "push.sent", FormatJSON, `{
+		"type": "object",
+		"properties": {"user_id": {"type": "integer"}, "text": {"type": "string"}},
+		"required": ["user_id", "text"]
+	}`)
+	if !errors.Is(err, ErrIncompatible) {
+		t.Fatalf("expected ErrIncompatible, got %v", err)
+	}
+}
+
+func TestAvroCompatibility(t *testing.T) {
+	ctx := context.Background()
+	registry := NewMemoryRegistry()
+	codec := NewCodec(registry)
+
+	v1, err := registry.Register(ctx, "push.sent.avro", FormatAvro,
+		`{"type":"record","name":"PushSent","fields":[{"name":"user_id","type":"int"}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, _, err := codec.Encode(ctx, "push.sent.avro", &pushSent{UserID: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = registry.Register(ctx, "push.sent.avro", FormatAvro,
+		`{"type":"record","name":"PushSent","fields":[{"name":"user_id","type":"int"},{"name":"text","type":"string"}]}`)
+	if !errors.Is(err, ErrIncompatible) {
+		t.Fatalf("field without default should be incompatible, got %v", err)
+	}
+
+	if _, err = registry.Register(ctx, "push.sent.avro", FormatAvro,
+		`{"type":"record","name":"PushSent","fields":[{"name":"user_id","type":"int"},{"name":"text","type":"string","default":""}]}`); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded pushSent
+	if err = codec.Decode(ctx, v1.ID, body, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.UserID != 7 {
+		t.Fatalf("unexpected payload %+v", decoded)
+	}
+}
@@ -0,0 +1,380 @@
+package infrasecrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	vault "github.com/hashicorp/vault/api"
+	"github.com/pkg/errors"
+	infraconfig "github.com/pushwoosh/infra/config"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	infraretry "github.com/pushwoosh/infra/retry"
+	"go.uber.org/zap"
+)
+
+// renewBackoff delays login and renewal attempts after failures
+var renewBackoff = infraretry.Exponential{
+	Initial:    time.Second,
+	Max:        time.Minute,
+	Multiplier: 2,
+	Jitter:     0.2,
+}
+
+// Client is a vault client that keeps its token renewed and caches secrets:
+//
+//	secrets, err := infrasecrets.NewClient(ctx, cfg.Vault)
+//	op.AddService(ctx, secrets)
+//	infraconfig.RegisterSecretResolver("vault", secrets.Resolver())
+//	err = infraconfig.Load(path, appCfg)
+//
+// Config values like "${vault:secret/data/billing#api_key}" are then resolved from vault,
+// by Load and by clients resolving credentials on connect, see infraconfig.ResolveSecret.
+type Client struct {
+	api *vault.Client
+	cfg *Config
+
+	mu    sync.Mutex
+	auth  *vault.Secret
+	cache map[string]*cacheEntry
+
+	runMu  sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+type cacheEntry struct {
+	data    map[string]interface{}
+	expires time.Time
+}
+
+var (
+	_ infraoperator.Starter = (*Client)(nil)
+	_ infraoperator.Stopper = (*Client)(nil)
+	_ infraoperator.Checker = (*Client)(nil)
+)
+
+// NewClient creates a vault client and logs in
+func NewClient(ctx context.Context, cfg *Config) (*Client, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	vaultCfg := vault.DefaultConfig()
+	if vaultCfg.Error != nil {
+		return nil, errors.Wrap(vaultCfg.Error, "vault.DefaultConfig")
+	}
+	if cfg.Address != "" {
+		vaultCfg.Address = cfg.Address
+	}
+
+	api, err := vault.NewClient(vaultCfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "vault.NewClient")
+	}
+	if cfg.Namespace != "" {
+		api.SetNamespace(cfg.Namespace)
+	}
+
+	initMetrics()
+
+	c := &Client{
+		api:   api,
+		cfg:   cfg,
+		cache: make(map[string]*cacheEntry),
+	}
+
+	if err = c.login(ctx); err != nil {
+		return nil, errors.Wrap(err, "unable to login to vault")
+	}
+
+	return c, nil
+}
+
+// API returns underlying vault client
+func (c *Client) API() *vault.Client {
+	return c.api
+}
+
+func (c *Client) login(ctx context.Context) error {
+	var (
+		path string
+		data map[string]interface{}
+	)
+
+	switch c.cfg.Auth.Method {
+	case AuthToken:
+		if c.cfg.Auth.Token != "" {
+			c.api.SetToken(c.cfg.Auth.Token)
+		}
+		if c.api.Token() == "" {
+			return errors.New("vault token is not set")
+		}
+
+		secret, err := c.api.Auth().Token().LookupSelfWithContext(ctx)
+		if err != nil {
+			return errors.Wrap(err, "token lookup")
+		}
+
+		// lookup response has token properties in data, the lifetime watcher renews tokens of auth only
+		ttl, _ := secret.TokenTTL()
+		renewable, _ := secret.TokenIsRenewable()
+		c.setAuth(&vault.Secret{Auth: &vault.SecretAuth{
+			ClientToken:   c.api.Token(),
+			Renewable:     renewable,
+			LeaseDuration: int(ttl.Seconds()),
+		}})
+		return nil
+	case AuthAppRole:
+		path = "auth/" + c.cfg.Auth.mountPath() + "/login"
+		data = map[string]interface{}{
+			"role_id":   c.cfg.Auth.RoleID,
+			"secret_id": c.cfg.Auth.SecretID,
+		}
+	case AuthKubernetes:
+		jwt, err := os.ReadFile(c.cfg.Auth.tokenPath())
+		if err != nil {
+			return errors.Wrap(err, "unable to read service account token")
+		}
+
+		path = "auth/" + c.cfg.Auth.mountPath() + "/login"
+		data = map[string]interface{}{
+			"role": c.cfg.Auth.Role,
+			"jwt":  strings.TrimSpace(string(jwt)),
+		}
+	}
+
+	secret, err := c.api.Logical().WriteWithContext(ctx, path, data)
+	if err != nil {
+		return err
+	}
+	if secret == nil || secret.Auth == nil {
+		return errors.New("empty login response")
+	}
+
+	c.api.SetToken(secret.Auth.ClientToken)
+	c.setAuth(secret)
+
+	return nil
+}
+
+func (c *Client) setAuth(secret *vault.Secret) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.auth = secret
+}
+
+// Read reads a secret. Data of KV version 2 secrets is unwrapped, so both
+// "secret/billing" (KV v1) and "secret/data/billing" (KV v2) return secret keys.
+func (c *Client) Read(ctx context.Context, path string) (map[string]interface{}, error) {
+	if c.cfg.CacheTTL > 0 {
+		c.mu.Lock()
+		entry, ok := c.cache[path]
+		c.mu.Unlock()
+
+		if ok && time.Now().Before(entry.expires) {
+			metrics.ReadsCounter.WithLabelValues("cached").Inc()
+			return entry.data, nil
+		}
+	}
+
+	secret, err := c.api.Logical().ReadWithContext(ctx, path)
+	if err != nil {
+		metrics.ReadsCounter.WithLabelValues("error").Inc()
+		return nil, errors.Wrapf(err, "unable to read secret %s", path)
+	}
+	if secret == nil || secret.Data == nil {
+		metrics.ReadsCounter.WithLabelValues("error").Inc()
+		return nil, errors.Errorf("secret %s not found", path)
+	}
+	metrics.ReadsCounter.WithLabelValues("success").Inc()
+
+	data := secret.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok = data["metadata"]; ok {
+			data = nested
+		}
+	}
+
+	if c.cfg.CacheTTL > 0 {
+		c.mu.Lock()
+		c.cache[path] = &cacheEntry{data: data, expires: time.Now().Add(c.cfg.CacheTTL)}
+		c.mu.Unlock()
+	}
+
+	return data, nil
+}
+
+// ReadString reads a single string key of a secret
+func (c *Client) ReadString(ctx context.Context, path, key string) (string, error) {
+	data, err := c.Read(ctx, path)
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := data[key]
+	if !ok {
+		return "", errors.Errorf("secret %s has no key %s", path, key)
+	}
+
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprint(value), nil
+}
+
+// Resolver returns config secret resolver for references of form "path#key"
+func (c *Client) Resolver() infraconfig.SecretResolver {
+	return func(ref string) (string, error) {
+		path, key, ok := strings.Cut(ref, "#")
+		if !ok {
+			return "", errors.Errorf("invalid vault reference %q, expected path#key", ref)
+		}
+
+		return c.ReadString(context.Background(), path, key)
+	}
+}
+
+// Start starts token renewal in background
+func (c *Client) Start(_ context.Context) error {
+	c.runMu.Lock()
+	defer c.runMu.Unlock()
+
+	if c.cancel != nil {
+		return errors.New("vault client is already started")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	c.done = make(chan struct{})
+
+	go c.renewToken(ctx)
+
+	return nil
+}
+
+// Stop stops token renewal
+func (c *Client) Stop(_ context.Context) error {
+	c.runMu.Lock()
+	defer c.runMu.Unlock()
+
+	if c.cancel == nil {
+		return nil
+	}
+
+	c.cancel()
+	<-c.done
+	c.cancel = nil
+
+	return nil
+}
+
+// Check checks the token is valid
+func (c *Client) Check(ctx context.Context) error {
+	_, err := c.api.Auth().Token().LookupSelfWithContext(ctx)
+	return err
+}
+
+func (c *Client) renewToken(ctx context.Context) {
+	defer close(c.done)
+
+	failures := 0
+	for {
+		c.mu.Lock()
+		auth := c.auth
+		c.mu.Unlock()
+
+		renewable, _ := auth.TokenIsRenewable()
+		ttl, _ := auth.TokenTTL()
+
+		switch {
+		case renewable:
+			err := c.watchLease(ctx, auth, "token")
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				failures++
+				infralog.Error("vault token renewal failed", zap.Error(err))
+				break
+			}
+			failures = 0
+
+			if c.cfg.Auth.Method == AuthToken {
+				// max TTL of a static token is reached, it can't be obtained again
+				infralog.Warn("vault token can't be renewed anymore")
+				<-ctx.Done()
+				return
+			}
+		case ttl == 0 || c.cfg.Auth.Method == AuthToken:
+			// nothing to do with a static token or a token without expiration
+			<-ctx.Done()
+			return
+		default:
+			// login again before a non-renewable token expires
+			if !sleep(ctx, ttl*2/3) {
+				return
+			}
+		}
+
+		if failures > 0 && !sleep(ctx, renewBackoff.Delay(failures)) {
+			return
+		}
+
+		for {
+			err := c.login(ctx)
+			if err == nil {
+				break
+			}
+
+			failures++
+			infralog.Error("vault login failed", zap.Error(err))
+			if !sleep(ctx, renewBackoff.Delay(failures)) {
+				return
+			}
+		}
+	}
+}
+
+// sleep waits for d, it returns false if ctx is done
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// watchLease renews token or lease of the secret until it can't be renewed anymore
+func (c *Client) watchLease(ctx context.Context, secret *vault.Secret, kind string) error {
+	watcher, err := c.api.NewLifetimeWatcher(&vault.LifetimeWatcherInput{Secret: secret})
+	if err != nil {
+		return errors.Wrap(err, "NewLifetimeWatcher")
+	}
+
+	go watcher.Start()
+	defer watcher.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err = <-watcher.DoneCh():
+			if err != nil {
+				metrics.RenewalsCounter.WithLabelValues(kind, "error").Inc()
+			}
+			return err
+		case <-watcher.RenewCh():
+			metrics.RenewalsCounter.WithLabelValues(kind, "success").Inc()
+		}
+	}
+}
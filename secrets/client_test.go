@@ -0,0 +1,94 @@
+package infrasecrets
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientRead(t *testing.T) {
+	var reads atomic.Int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/auth/token/lookup-self", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"data": {"id": "test", "renewable": false}}`))
+	})
+	mux.HandleFunc("/v1/secret/data/billing", func(w http.ResponseWriter, _ *http.Request) {
+		reads.Add(1)
+		_, _ = w.Write([]byte(`{"data": {"data": {"api_key": "s3cr3t"}, "metadata": {"version": 1}}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := NewClient(context.Background(), &Config{
+		Address:  server.URL,
+		Auth:     AuthConfig{Method: AuthToken, Token: "root"},
+		CacheTTL: time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resolve := client.Resolver()
+	for i := 0; i < 2; i++ {
+		value, err := resolve("secret/data/billing#api_key")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value != "s3cr3t" {
+			t.Fatalf("unexpected value: %s", value)
+		}
+	}
+
+	if reads.Load() != 1 {
+		t.Errorf("expected cached read, got %d requests", reads.Load())
+	}
+
+	if _, err = resolve("secret/data/billing#missing"); err == nil {
+		t.Error("expected error for missing key")
+	}
+}
+
+func TestClientRenewToken(t *testing.T) {
+	var lookups, renewals atomic.Int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/auth/token/lookup-self", func(w http.ResponseWriter, _ *http.Request) {
+		lookups.Add(1)
+		_, _ = w.Write([]byte(`{"data": {"id": "test", "renewable": true, "ttl": 60}}`))
+	})
+	mux.HandleFunc("/v1/auth/token/renew-self", func(w http.ResponseWriter, _ *http.Request) {
+		renewals.Add(1)
+		_, _ = w.Write([]byte(`{"auth": {"client_token": "root", "renewable": true, "lease_duration": 60}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := NewClient(context.Background(), &Config{
+		Address: server.URL,
+		Auth:    AuthConfig{Method: AuthToken, Token: "root"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = client.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = client.Stop(context.Background()) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for renewals.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if renewals.Load() == 0 {
+		t.Fatal("token is not renewed")
+	}
+	if lookups.Load() != 1 {
+		t.Errorf("expected a single lookup, got %d", lookups.Load())
+	}
+}
@@ -0,0 +1,90 @@
+package infrasecrets
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	AuthToken      = "token"
+	AuthAppRole    = "approle"
+	AuthKubernetes = "kubernetes"
+
+	DefaultKubernetesTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+)
+
+type Config struct {
+	// Vault address. optional, VAULT_ADDR environment variable is used by default
+	Address string `mapstructure:"address"`
+
+	// Vault enterprise namespace. optional
+	Namespace string `mapstructure:"namespace"`
+
+	// Auth method config
+	Auth AuthConfig `mapstructure:"auth"`
+
+	// CacheTTL is the time secrets are cached for. Set 0 to disable caching
+	CacheTTL time.Duration `mapstructure:"cache_ttl"`
+}
+
+type AuthConfig struct {
+	// Method is one of "token", "approle" or "kubernetes"
+	Method string `mapstructure:"method"`
+
+	// MountPath is a path the auth method is mounted at. optional, method name is used by default
+	MountPath string `mapstructure:"mount_path"`
+
+	// Token is a vault token for "token" method. optional, VAULT_TOKEN environment variable is used by default
+	Token string `mapstructure:"token"`
+
+	// RoleID and SecretID are "approle" method credentials
+	RoleID   string `mapstructure:"role_id"`
+	SecretID string `mapstructure:"secret_id"`
+
+	// Role is a vault role for "kubernetes" method
+	Role string `mapstructure:"role"`
+
+	// TokenPath is a path to service account token for "kubernetes" method. optional
+	TokenPath string `mapstructure:"token_path"`
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if c.CacheTTL < 0 {
+		return errors.New("cache_ttl should be greater than or equal to 0")
+	}
+
+	switch c.Auth.Method {
+	case AuthToken:
+	case AuthAppRole:
+		if c.Auth.RoleID == "" || c.Auth.SecretID == "" {
+			return errors.New("role_id and secret_id are mandatory for approle auth")
+		}
+	case AuthKubernetes:
+		if c.Auth.Role == "" {
+			return errors.New("role is mandatory for kubernetes auth")
+		}
+	default:
+		return errors.Errorf("unknown auth method: %s", c.Auth.Method)
+	}
+
+	return nil
+}
+
+func (c *AuthConfig) mountPath() string {
+	if c.MountPath != "" {
+		return c.MountPath
+	}
+	return c.Method
+}
+
+func (c *AuthConfig) tokenPath() string {
+	if c.TokenPath != "" {
+		return c.TokenPath
+	}
+	return DefaultKubernetesTokenPath
+}
@@ -0,0 +1,176 @@
+package infrasecrets
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	vault "github.com/hashicorp/vault/api"
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	"go.uber.org/zap"
+)
+
+const rotateRetryDelay = 5 * time.Second
+
+// DatabaseCredentials are dynamic credentials issued by vault database secrets engine
+type DatabaseCredentials struct {
+	Username      string
+	Password      string
+	LeaseID       string
+	LeaseDuration time.Duration
+}
+
+// DatabaseCredentials issues new credentials for a role of database secrets engine mounted at mount
+func (c *Client) DatabaseCredentials(ctx context.Context, mount, role string) (*DatabaseCredentials, error) {
+	creds, _, err := c.databaseCredentials(ctx, mount, role)
+	return creds, err
+}
+
+func (c *Client) databaseCredentials(ctx context.Context, mount, role string) (*DatabaseCredentials, *vault.Secret, error) {
+	secret, err := c.api.Logical().ReadWithContext(ctx, mount+"/creds/"+role)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "unable to issue %s credentials", role)
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil, errors.Errorf("empty %s credentials", role)
+	}
+
+	username, _ := secret.Data["username"].(string)
+	password, _ := secret.Data["password"].(string)
+
+	return &DatabaseCredentials{
+		Username:      username,
+		Password:      password,
+		LeaseID:       secret.LeaseID,
+		LeaseDuration: time.Duration(secret.LeaseDuration) * time.Second,
+	}, secret, nil
+}
+
+// RotateFunc applies new credentials, e.g. reconnects a database container with them
+type RotateFunc func(creds *DatabaseCredentials) error
+
+// CredentialsRotator keeps dynamic database credentials lease renewed and issues new credentials
+// when the lease reaches its max TTL:
+//
+//	rotator := secrets.NewCredentialsRotator("database", "billing-rw", func(creds *infrasecrets.DatabaseCredentials) error {
+//		cfg.Credentials.Username, cfg.Credentials.Password = creds.Username, creds.Password
+//		return pgContainer.Connect("billing", cfg)
+//	})
+//	op.AddService(ctx, rotator)
+type CredentialsRotator struct {
+	client *Client
+	mount  string
+	role   string
+	rotate RotateFunc
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+var (
+	_ infraoperator.Starter = (*CredentialsRotator)(nil)
+	_ infraoperator.Stopper = (*CredentialsRotator)(nil)
+)
+
+func (c *Client) NewCredentialsRotator(mount, role string, rotate RotateFunc) *CredentialsRotator {
+	return &CredentialsRotator{
+		client: c,
+		mount:  mount,
+		role:   role,
+		rotate: rotate,
+	}
+}
+
+// Start issues credentials, applies them and starts renewal in background
+func (r *CredentialsRotator) Start(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cancel != nil {
+		return errors.New("rotator is already started")
+	}
+
+	lease, err := r.issue(ctx)
+	if err != nil {
+		return err
+	}
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.done = make(chan struct{})
+
+	go r.run(runCtx, lease)
+
+	return nil
+}
+
+// Stop stops renewal. Issued credentials are not revoked, they expire with the lease.
+func (r *CredentialsRotator) Stop(_ context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cancel == nil {
+		return nil
+	}
+
+	r.cancel()
+	<-r.done
+	r.cancel = nil
+
+	return nil
+}
+
+// issue issues and applies new credentials, returns their lease
+func (r *CredentialsRotator) issue(ctx context.Context) (*vault.Secret, error) {
+	creds, lease, err := r.client.databaseCredentials(ctx, r.mount, r.role)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = r.rotate(creds); err != nil {
+		return nil, errors.Wrap(err, "unable to apply credentials")
+	}
+
+	infralog.Info("database credentials rotated",
+		zap.String("role", r.role),
+		zap.String("username", creds.Username),
+		zap.Duration("lease_duration", creds.LeaseDuration))
+
+	return lease, nil
+}
+
+func (r *CredentialsRotator) run(ctx context.Context, lease *vault.Secret) {
+	defer close(r.done)
+
+	for {
+		if lease.LeaseDuration <= 0 {
+			// credentials don't expire
+			<-ctx.Done()
+			return
+		}
+
+		err := r.client.watchLease(ctx, lease, "database")
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			infralog.Error("database credentials renewal failed", zap.String("role", r.role), zap.Error(err))
+		}
+
+		for {
+			if lease, err = r.issue(ctx); err == nil {
+				break
+			}
+
+			infralog.Error("unable to rotate database credentials", zap.String("role", r.role), zap.Error(err))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(rotateRetryDelay):
+			}
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package infrasecrets
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	ReadsCounter    *prometheus.CounterVec
+	RenewalsCounter *prometheus.CounterVec
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.ReadsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "vault_secret_reads_total",
+			Help: "Number of secret reads by result: cached, success or error",
+		}, []string{"result"})
+
+		metrics.RenewalsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "vault_lease_renewals_total",
+			Help: "Number of lease renewals by kind (token or database) and result",
+		}, []string{"kind", "result"})
+
+		prometheus.MustRegister(
+			metrics.ReadsCounter,
+			metrics.RenewalsCounter,
+		)
+	})
+}
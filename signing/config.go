@@ -0,0 +1,105 @@
+package infrasigning
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	AlgorithmHMACSHA256 = "hmac-sha256"
+	AlgorithmEd25519    = "ed25519"
+)
+
+const (
+	// PolicyReject drops unsigned messages and messages with invalid signatures
+	PolicyReject = "reject"
+
+	// PolicyAllowUnsigned passes unsigned messages and drops messages with invalid signatures.
+	// It's used while producers are migrated to signing
+	PolicyAllowUnsigned = "allow_unsigned"
+
+	// PolicyLog verifies signatures and logs failures without dropping messages
+	PolicyLog = "log"
+)
+
+type Config struct {
+	// optional, default: hmac-sha256
+	Algorithm string `mapstructure:"algorithm"`
+
+	// CurrentKey is an id of the key signing published messages. optional for consumers that only verify
+	CurrentKey string `mapstructure:"current_key"`
+
+	// Keys are base64 encoded HMAC secrets or ed25519 private key seeds by id. Old keys are kept to verify
+	// messages signed before rotation. Values may be secret references, see infraconfig.ResolveSecret
+	Keys map[string]string `mapstructure:"keys"`
+
+	// PublicKeys are base64 encoded ed25519 public keys by id for consumers that don't hold private keys
+	PublicKeys map[string]string `mapstructure:"public_keys"`
+
+	// optional, default: reject
+	Policy string `mapstructure:"policy"`
+
+	// MaxSkew is a maximum difference between the timestamp of a consumed message and the local clock,
+	// older messages are rejected as replayed. optional, default: 5m
+	MaxSkew time.Duration `mapstructure:"max_skew"`
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	switch c.GetAlgorithm() {
+	case AlgorithmHMACSHA256:
+		if len(c.PublicKeys) > 0 {
+			return errors.New("public_keys are supported by ed25519 only")
+		}
+	case AlgorithmEd25519:
+	default:
+		return errors.Errorf("unknown algorithm %q", c.Algorithm)
+	}
+
+	switch c.GetPolicy() {
+	case PolicyReject, PolicyAllowUnsigned, PolicyLog:
+	default:
+		return errors.Errorf("unknown policy %q", c.Policy)
+	}
+
+	if c.MaxSkew < 0 {
+		return errors.New("max_skew should be positive")
+	}
+
+	if len(c.Keys) == 0 && len(c.PublicKeys) == 0 {
+		return errors.New("keys are mandatory")
+	}
+
+	if c.CurrentKey != "" {
+		if _, ok := c.Keys[c.CurrentKey]; !ok {
+			return errors.Errorf("current key %q is not in keys", c.CurrentKey)
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) GetAlgorithm() string {
+	if c.Algorithm == "" {
+		return AlgorithmHMACSHA256
+	}
+	return c.Algorithm
+}
+
+func (c *Config) GetPolicy() string {
+	if c.Policy == "" {
+		return PolicyReject
+	}
+	return c.Policy
+}
+
+func (c *Config) GetMaxSkew() time.Duration {
+	if c.MaxSkew == 0 {
+		return time.Minute * 5
+	}
+	return c.MaxSkew
+}
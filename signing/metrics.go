@@ -0,0 +1,42 @@
+package infrasigning
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	VerificationsCounter *prometheus.CounterVec
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.VerificationsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "signing_verifications_total",
+			Help: "Number of verified messages by result: valid, unsigned, unknown_key, expired or invalid",
+		}, []string{"result"})
+
+		prometheus.MustRegister(
+			metrics.VerificationsCounter,
+		)
+	})
+}
+
+func verificationResult(err error) string {
+	switch {
+	case err == nil:
+		return "valid"
+	case errors.Is(err, ErrUnsigned):
+		return "unsigned"
+	case errors.Is(err, ErrUnknownKey):
+		return "unknown_key"
+	case errors.Is(err, ErrExpired):
+		return "expired"
+	default:
+		return "invalid"
+	}
+}
@@ -0,0 +1,22 @@
+package infrasigning
+
+import (
+	infraclock "github.com/pushwoosh/infra/clock"
+)
+
+type Option interface {
+	apply(s *Signer)
+}
+
+type optionClock struct {
+	clock infraclock.Clock
+}
+
+func (opt optionClock) apply(s *Signer) {
+	s.clock = opt.clock
+}
+
+// WithClock sets a clock of message timestamps, e.g. a fake one in tests
+func WithClock(clock infraclock.Clock) Option {
+	return optionClock{clock: clock}
+}
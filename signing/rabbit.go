@@ -0,0 +1,118 @@
+package infrasigning
+
+import (
+	"context"
+	"encoding/base64"
+	"maps"
+
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	infrarabbit "github.com/pushwoosh/infra/rabbit"
+	"go.uber.org/zap"
+)
+
+const (
+	// HeaderKeyID is a message header with the id of the signing key
+	HeaderKeyID = "x-signature-key-id"
+
+	// HeaderSignature is a message header with the base64 encoded signature of the routing key,
+	// the message id, the timestamp and the body
+	HeaderSignature = "x-signature"
+
+	// HeaderTimestamp is a message header with the signing time, unix seconds
+	HeaderTimestamp = "x-signature-timestamp"
+)
+
+// Producer signs published messages, the key id, the timestamp and the signature are sent in headers
+type Producer struct {
+	publisher infrarabbit.Publisher
+	signer    *Signer
+}
+
+var _ infrarabbit.Publisher = (*Producer)(nil)
+
+func WrapProducer(s *Signer, p infrarabbit.Publisher) *Producer {
+	return &Producer{
+		publisher: p,
+		signer:    s,
+	}
+}
+
+func (p *Producer) Produce(ctx context.Context, msg *infrarabbit.ProducerMessage) error {
+	timestamp := p.signer.clock.Now().Unix()
+	keyID, signature, err := p.signer.SignMessage(msg.RoutingKey, msg.MessageID, timestamp, msg.Body)
+	if err != nil {
+		return errors.Wrap(err, "unable to sign message")
+	}
+
+	signed := *msg
+	signed.Headers = maps.Clone(msg.Headers)
+	if signed.Headers == nil {
+		signed.Headers = make(map[string]interface{}, 3)
+	}
+	signed.Headers[HeaderKeyID] = keyID
+	signed.Headers[HeaderTimestamp] = timestamp
+	signed.Headers[HeaderSignature] = base64.StdEncoding.EncodeToString(signature)
+
+	return p.publisher.Produce(ctx, &signed)
+}
+
+// Middleware verifies signatures of consumed messages. Depending on the policy, rejected messages
+// are dropped by the router as malformed:
+//
+//	router.Use(infrasigning.Middleware(signer))
+func Middleware(s *Signer) infrarabbit.Middleware {
+	return func(next infrarabbit.HandlerFunc) infrarabbit.HandlerFunc {
+		return func(ctx context.Context, msg *infrarabbit.Message) error {
+			err := verifyMessage(s, msg)
+			metrics.VerificationsCounter.WithLabelValues(verificationResult(err)).Inc()
+
+			switch {
+			case err == nil:
+			case s.policy == PolicyLog:
+				infralog.WarnCtx(ctx, "signing: message verification failed",
+					zap.String("routing_key", msg.RoutingKey()),
+					zap.Error(err))
+			case s.policy == PolicyAllowUnsigned && errors.Is(err, ErrUnsigned):
+			default:
+				return errors.Wrapf(infrarabbit.ErrMalformed, "signature verification: %s", err)
+			}
+
+			return next(ctx, msg)
+		}
+	}
+}
+
+func verifyMessage(s *Signer, msg *infrarabbit.Message) error {
+	return verifyHeaders(s, msg.RoutingKey(), msg.MessageID(), msg.Headers(), msg.Body())
+}
+
+func verifyHeaders(s *Signer, routingKey, messageID string, headers map[string]interface{}, body []byte) error {
+	keyID, _ := headers[HeaderKeyID].(string)
+	encoded, _ := headers[HeaderSignature].(string)
+	timestamp, ok := headerTimestamp(headers[HeaderTimestamp])
+	if keyID == "" || encoded == "" || !ok {
+		return ErrUnsigned
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	return s.VerifyMessage(keyID, routingKey, messageID, timestamp, body, signature)
+}
+
+// headerTimestamp accepts integer types a header may be decoded to
+func headerTimestamp(v interface{}) (int64, bool) {
+	switch ts := v.(type) {
+	case int64:
+		return ts, true
+	case int32:
+		return int64(ts), true
+	case int:
+		return int64(ts), true
+	default:
+		return 0, false
+	}
+}
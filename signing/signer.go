@@ -0,0 +1,191 @@
+package infrasigning
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	infraclock "github.com/pushwoosh/infra/clock"
+	infraconfig "github.com/pushwoosh/infra/config"
+)
+
+var (
+	// ErrUnsigned is returned for messages without signature
+	ErrUnsigned = errors.New("message is not signed")
+
+	// ErrUnknownKey is returned for signatures made with a key that is not configured
+	ErrUnknownKey = errors.New("unknown signing key")
+
+	// ErrInvalidSignature is returned for tampered messages or signatures made with another key
+	ErrInvalidSignature = errors.New("invalid signature")
+
+	// ErrExpired is returned for messages with a timestamp outside of the allowed skew, e.g. replayed ones
+	ErrExpired = errors.New("message timestamp is outside of allowed skew")
+)
+
+// Signer signs and verifies message bodies with HMAC-SHA256 or ed25519 keys
+type Signer struct {
+	algorithm string
+	policy    string
+	current   string
+	maxSkew   time.Duration
+	clock     infraclock.Clock
+
+	secrets     map[string][]byte
+	privateKeys map[string]ed25519.PrivateKey
+	publicKeys  map[string]ed25519.PublicKey
+}
+
+func New(cfg *Config, opts ...Option) (*Signer, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	initMetrics()
+
+	s := &Signer{
+		algorithm:   cfg.GetAlgorithm(),
+		policy:      cfg.GetPolicy(),
+		current:     cfg.CurrentKey,
+		maxSkew:     cfg.GetMaxSkew(),
+		clock:       infraclock.Real,
+		secrets:     make(map[string][]byte),
+		privateKeys: make(map[string]ed25519.PrivateKey),
+		publicKeys:  make(map[string]ed25519.PublicKey),
+	}
+
+	for id, ref := range cfg.Keys {
+		key, err := decodeKey(ref)
+		if err != nil {
+			return nil, errors.Wrapf(err, "key %s", id)
+		}
+
+		if s.algorithm == AlgorithmHMACSHA256 {
+			s.secrets[id] = key
+			continue
+		}
+
+		if len(key) != ed25519.SeedSize {
+			return nil, errors.Errorf("key %s: ed25519 seed should be %d bytes", id, ed25519.SeedSize)
+		}
+		private := ed25519.NewKeyFromSeed(key)
+		s.privateKeys[id] = private
+		s.publicKeys[id] = private.Public().(ed25519.PublicKey)
+	}
+
+	for id, ref := range cfg.PublicKeys {
+		key, err := decodeKey(ref)
+		if err != nil {
+			return nil, errors.Wrapf(err, "public key %s", id)
+		}
+		if len(key) != ed25519.PublicKeySize {
+			return nil, errors.Errorf("public key %s: should be %d bytes", id, ed25519.PublicKeySize)
+		}
+		s.publicKeys[id] = key
+	}
+
+	for _, opt := range opts {
+		opt.apply(s)
+	}
+
+	return s, nil
+}
+
+// Sign returns the id of the current key and the signature of the body
+func (s *Signer) Sign(body []byte) (string, []byte, error) {
+	if s.current == "" {
+		return "", nil, errors.New("current_key is not configured")
+	}
+
+	if s.algorithm == AlgorithmHMACSHA256 {
+		return s.current, mac(s.secrets[s.current], body), nil
+	}
+
+	return s.current, ed25519.Sign(s.privateKeys[s.current], body), nil
+}
+
+// Verify checks the signature of the body made with the key keyID
+func (s *Signer) Verify(keyID string, body, signature []byte) error {
+	if keyID == "" || len(signature) == 0 {
+		return ErrUnsigned
+	}
+
+	if s.algorithm == AlgorithmHMACSHA256 {
+		secret, ok := s.secrets[keyID]
+		if !ok {
+			return errors.Wrap(ErrUnknownKey, keyID)
+		}
+		if !hmac.Equal(mac(secret, body), signature) {
+			return ErrInvalidSignature
+		}
+		return nil
+	}
+
+	public, ok := s.publicKeys[keyID]
+	if !ok {
+		return errors.Wrap(ErrUnknownKey, keyID)
+	}
+	if !ed25519.Verify(public, body, signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// SignMessage returns the id of the current key and the signature of the canonical form of the message
+// published at timestamp, unix seconds
+func (s *Signer) SignMessage(routingKey, messageID string, timestamp int64, body []byte) (string, []byte, error) {
+	return s.Sign(canonical(routingKey, messageID, timestamp, body))
+}
+
+// VerifyMessage checks the signature of the message made with the key keyID and rejects messages
+// with timestamp outside of the allowed skew
+func (s *Signer) VerifyMessage(keyID, routingKey, messageID string, timestamp int64, body, signature []byte) error {
+	if err := s.Verify(keyID, canonical(routingKey, messageID, timestamp, body), signature); err != nil {
+		return err
+	}
+
+	skew := s.clock.Now().Sub(time.Unix(timestamp, 0))
+	if skew > s.maxSkew || skew < -s.maxSkew {
+		return errors.Wrapf(ErrExpired, "skew %s", skew)
+	}
+	return nil
+}
+
+// canonical joins signed fields of a message, the body goes last so fields can't be shifted into it
+func canonical(routingKey, messageID string, timestamp int64, body []byte) []byte {
+	buf := make([]byte, 0, len(routingKey)+len(messageID)+len(body)+24)
+	buf = append(buf, routingKey...)
+	buf = append(buf, '\n')
+	buf = append(buf, messageID...)
+	buf = append(buf, '\n')
+	buf = strconv.AppendInt(buf, timestamp, 10)
+	buf = append(buf, '\n')
+	return append(buf, body...)
+}
+
+func mac(secret, body []byte) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write(body)
+	return h.Sum(nil)
+}
+
+func decodeKey(ref string) ([]byte, error) {
+	encoded, err := infraconfig.ResolveSecret(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid base64")
+	}
+	if len(key) == 0 {
+		return nil, errors.New("empty key")
+	}
+
+	return key, nil
+}
@@ -0,0 +1,137 @@
+package infrasigning
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	infraclock "github.com/pushwoosh/infra/clock"
+)
+
+func TestHMAC(t *testing.T) {
+	s, err := New(&Config{
+		CurrentKey: "v2",
+		Keys: map[string]string{
+			"v1": base64.StdEncoding.EncodeToString([]byte("old-secret")),
+			"v2": base64.StdEncoding.EncodeToString([]byte("new-secret")),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := []byte(`{"user_id":1}`)
+	keyID, signature, err := s.Sign(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyID != "v2" {
+		t.Fatalf("expected v2, got %s", keyID)
+	}
+
+	if err = s.Verify(keyID, body, signature); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Verify(keyID, []byte(`{"user_id":2}`), signature); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected ErrInvalidSignature, got %v", err)
+	}
+	if err = s.Verify("v1", body, signature); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected ErrInvalidSignature, got %v", err)
+	}
+	if err = s.Verify("v3", body, signature); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected ErrUnknownKey, got %v", err)
+	}
+	if err = s.Verify("", body, nil); !errors.Is(err, ErrUnsigned) {
+		t.Fatalf("expected ErrUnsigned, got %v", err)
+	}
+}
+
+func TestEd25519PublicKeys(t *testing.T) {
+	seed := make([]byte, ed25519.SeedSize)
+	public := ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey)
+
+	signer, err := New(&Config{
+		Algorithm:  AlgorithmEd25519,
+		CurrentKey: "cluster-a",
+		Keys:       map[string]string{"cluster-a": base64.StdEncoding.EncodeToString(seed)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier, err := New(&Config{
+		Algorithm:  AlgorithmEd25519,
+		PublicKeys: map[string]string{"cluster-a": base64.StdEncoding.EncodeToString(public)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := []byte("payload")
+	keyID, signature, err := signer.Sign(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = verifier.Verify(keyID, body, signature); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = verifier.Sign(body); err == nil {
+		t.Fatal("verifier without current key should not sign")
+	}
+}
+
+func TestVerifyHeaders(t *testing.T) {
+	clock := infraclock.NewFake(time.Unix(1700000000, 0))
+	s, err := New(&Config{
+		CurrentKey: "v1",
+		Keys:       map[string]string{"v1": base64.StdEncoding.EncodeToString([]byte("secret"))},
+		MaxSkew:    time.Minute,
+	}, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := []byte("payload")
+	timestamp := clock.Now().Unix()
+	keyID, signature, err := s.SignMessage("user.created", "id-1", timestamp, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	headers := map[string]interface{}{
+		HeaderKeyID:     keyID,
+		HeaderSignature: base64.StdEncoding.EncodeToString(signature),
+		HeaderTimestamp: timestamp,
+	}
+
+	if err = verifyHeaders(s, "user.created", "id-1", headers, body); err != nil {
+		t.Fatal(err)
+	}
+	if err = verifyHeaders(s, "user.deleted", "id-1", headers, body); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected ErrInvalidSignature for another routing key, got %v", err)
+	}
+	if err = verifyHeaders(s, "user.created", "id-2", headers, body); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected ErrInvalidSignature for another message id, got %v", err)
+	}
+
+	forged := map[string]interface{}{
+		HeaderKeyID:     headers[HeaderKeyID],
+		HeaderSignature: headers[HeaderSignature],
+		HeaderTimestamp: timestamp + 30,
+	}
+	if err = verifyHeaders(s, "user.created", "id-1", forged, body); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected ErrInvalidSignature for another timestamp, got %v", err)
+	}
+
+	delete(forged, HeaderTimestamp)
+	if err = verifyHeaders(s, "user.created", "id-1", forged, body); !errors.Is(err, ErrUnsigned) {
+		t.Fatalf("expected ErrUnsigned without timestamp, got %v", err)
+	}
+
+	clock.Advance(time.Minute * 2)
+	if err = verifyHeaders(s, "user.created", "id-1", headers, body); !errors.Is(err, ErrExpired) {
+		t.Fatalf("expected ErrExpired, got %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package infraspool
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultSegmentSize    = 64 << 20
+	DefaultMaxBytes       = 1 << 30
+	DefaultPublishTimeout = 5 * time.Second
+	DefaultMaxRejections  = 3
+)
+
+type Config struct {
+	// Dir is a directory of segment files. It must not be shared by several queues
+	Dir string `mapstructure:"dir"`
+
+	// Max size of a segment file in bytes. optional, default: 64MiB
+	SegmentSize int64 `mapstructure:"segment_size"`
+
+	// Max size of records not acked yet in bytes, Append fails with ErrFull when it's reached. optional, default: 1GiB
+	MaxBytes int64 `mapstructure:"max_bytes"`
+
+	// Interval of fsync of appended records. optional, default: 0, every record is synced before Append returns
+	SyncInterval time.Duration `mapstructure:"sync_interval"`
+
+	// Timeout of a publish to the broker, the message is spooled when it's exceeded. optional, default: 5s
+	PublishTimeout time.Duration `mapstructure:"publish_timeout"`
+
+	// Number of times the broker may reject a spooled message before it's dropped, so it doesn't block the replay.
+	// optional, default: 3
+	MaxRejections int `mapstructure:"max_rejections"`
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if c.Dir == "" {
+		return errors.New("dir is mandatory")
+	}
+
+	if c.SegmentSize < 0 || c.MaxBytes < 0 {
+		return errors.New("sizes should be greater than or equal to 0")
+	}
+
+	if c.MaxRejections < 0 {
+		return errors.New("max_rejections should be greater than or equal to 0")
+	}
+
+	if c.SyncInterval < 0 || c.PublishTimeout < 0 {
+		return errors.New("intervals should be greater than or equal to 0")
+	}
+
+	return nil
+}
+
+func (c *Config) GetSegmentSize() int64 {
+	if c.SegmentSize <= 0 {
+		return DefaultSegmentSize
+	}
+	return c.SegmentSize
+}
+
+func (c *Config) GetMaxBytes() int64 {
+	if c.MaxBytes <= 0 {
+		return DefaultMaxBytes
+	}
+	return c.MaxBytes
+}
+
+func (c *Config) GetPublishTimeout() time.Duration {
+	if c.PublishTimeout <= 0 {
+		return DefaultPublishTimeout
+	}
+	return c.PublishTimeout
+}
+
+func (c *Config) GetMaxRejections() int {
+	if c.MaxRejections <= 0 {
+		return DefaultMaxRejections
+	}
+	return c.MaxRejections
+}
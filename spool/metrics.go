@@ -0,0 +1,68 @@
+package infraspool
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	RecordsGauge         *prometheus.GaugeVec
+	BytesGauge           *prometheus.GaugeVec
+	AppendedCounter      *prometheus.CounterVec
+	ReplayedCounter      *prometheus.CounterVec
+	CorruptedCounter     *prometheus.CounterVec
+	RejectedCounter      *prometheus.CounterVec
+	PublishErrorsCounter *prometheus.CounterVec
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.RecordsGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "spool_records",
+			Help: "Number of records in the spool not acked yet",
+		}, []string{"spool"})
+
+		metrics.BytesGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "spool_bytes",
+			Help: "Size of records in the spool not acked yet",
+		}, []string{"spool"})
+
+		metrics.AppendedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "spool_appended_records_total",
+			Help: "Number of records appended to the spool",
+		}, []string{"spool"})
+
+		metrics.ReplayedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "spool_replayed_records_total",
+			Help: "Number of spooled records handled by the replay",
+		}, []string{"spool"})
+
+		metrics.CorruptedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "spool_corrupted_records_total",
+			Help: "Number of records dropped because of checksum mismatch or truncation",
+		}, []string{"spool"})
+
+		metrics.RejectedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "spool_rejected_records_total",
+			Help: "Number of spooled messages dropped because the broker rejected them",
+		}, []string{"spool"})
+
+		metrics.PublishErrorsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "spool_publish_errors_total",
+			Help: "Number of failed publishes that made a message spooled or delayed the replay",
+		}, []string{"spool"})
+
+		prometheus.MustRegister(
+			metrics.RecordsGauge,
+			metrics.BytesGauge,
+			metrics.AppendedCounter,
+			metrics.ReplayedCounter,
+			metrics.CorruptedCounter,
+			metrics.RejectedCounter,
+			metrics.PublishErrorsCounter,
+		)
+	})
+}
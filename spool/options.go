@@ -0,0 +1,22 @@
+package infraspool
+
+import (
+	infraclock "github.com/pushwoosh/infra/clock"
+)
+
+type Option interface {
+	apply(p *Producer)
+}
+
+type optionClock struct {
+	clock infraclock.Clock
+}
+
+func (opt optionClock) apply(p *Producer) {
+	p.clock = opt.clock
+}
+
+// WithClock sets a clock of replay backoff, e.g. a fake one in tests
+func WithClock(clock infraclock.Clock) Option {
+	return optionClock{clock: clock}
+}
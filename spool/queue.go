@@ -0,0 +1,525 @@
+package infraspool
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	"go.uber.org/zap"
+)
+
+const (
+	segmentExt = ".seg"
+	cursorFile = "cursor"
+
+	// record header: payload length and CRC32 (Castagnoli) of the payload
+	headerSize = 8
+)
+
+var (
+	ErrEmpty  = errors.New("spool is empty")
+	ErrFull   = errors.New("spool is full")
+	ErrClosed = errors.New("spool is closed")
+
+	// ErrCorrupted is returned by Peek for a record that can't be read, Ack skips it
+	ErrCorrupted = errors.New("corrupted record")
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+type segment struct {
+	id   uint64
+	path string
+	size int64
+}
+
+// Queue is a persistent FIFO of records stored in append-only segment files.
+// Every record has a checksum, a torn or corrupted tail left by a crash is truncated on Open.
+// The read position is persisted on Ack, records are delivered at least once:
+// a record peeked but not acked before a crash is peeked again after Open.
+// Exhausted segments are deleted.
+type Queue struct {
+	name string
+	cfg  *Config
+
+	mu       sync.Mutex
+	segments []*segment
+	writer   *os.File
+	reader   *os.File
+	cursor   *os.File
+	readOff  int64
+	peeked   int64
+	peekedN  int
+	records  int
+	bytes    int64
+	dirty    bool
+	closed   bool
+	stopSync chan struct{}
+	syncDone chan struct{}
+	appended chan struct{}
+}
+
+// Open opens the queue in cfg.Dir creating the directory if needed. name is used as a metrics label
+func Open(name string, cfg *Config) (*Queue, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	initMetrics()
+
+	if err := os.MkdirAll(cfg.Dir, 0o750); err != nil {
+		return nil, errors.Wrap(err, "unable to create spool dir")
+	}
+
+	q := &Queue{
+		name:     name,
+		cfg:      cfg,
+		stopSync: make(chan struct{}),
+		syncDone: make(chan struct{}),
+		appended: make(chan struct{}, 1),
+	}
+
+	if err := q.load(); err != nil {
+		q.closeFiles()
+		return nil, err
+	}
+	q.updateGauges()
+
+	if cfg.SyncInterval > 0 {
+		go q.syncLoop()
+	} else {
+		close(q.syncDone)
+	}
+
+	return q, nil
+}
+
+// load reads the cursor, removes consumed segments and validates records of the rest
+func (q *Queue) load() error {
+	var err error
+	q.cursor, err = os.OpenFile(filepath.Join(q.cfg.Dir, cursorFile), os.O_RDWR|os.O_CREATE, 0o640)
+	if err != nil {
+		return errors.Wrap(err, "unable to open cursor")
+	}
+
+	var cursorSeg uint64
+	buf := make([]byte, 16)
+	if _, err = q.cursor.ReadAt(buf, 0); err == nil {
+		cursorSeg = binary.BigEndian.Uint64(buf[:8])
+		q.readOff = int64(binary.BigEndian.Uint64(buf[8:]))
+	} else if err != io.EOF {
+		return errors.Wrap(err, "unable to read cursor")
+	}
+
+	entries, err := os.ReadDir(q.cfg.Dir)
+	if err != nil {
+		return errors.Wrap(err, "unable to read spool dir")
+	}
+
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), segmentExt)
+		if !ok {
+			continue
+		}
+		segID, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		path := filepath.Join(q.cfg.Dir, e.Name())
+		if segID < cursorSeg {
+			if err = os.Remove(path); err != nil {
+				return errors.Wrap(err, "unable to remove consumed segment")
+			}
+			continue
+		}
+		q.segments = append(q.segments, &segment{id: segID, path: path})
+	}
+	sort.Slice(q.segments, func(i, j int) bool {
+		return q.segments[i].id < q.segments[j].id
+	})
+
+	if len(q.segments) == 0 || q.segments[0].id != cursorSeg {
+		// the cursor segment is gone, reading starts from the beginning of the first one
+		q.readOff = 0
+	}
+
+	for i, seg := range q.segments {
+		from := int64(0)
+		if i == 0 {
+			from = q.readOff
+		}
+		if err = q.scan(seg, from); err != nil {
+			return err
+		}
+	}
+
+	if len(q.segments) == 0 {
+		if err = q.addSegment(max(cursorSeg, 1)); err != nil {
+			return err
+		}
+	}
+
+	if q.writer == nil {
+		last := q.segments[len(q.segments)-1]
+		q.writer, err = os.OpenFile(last.path, os.O_WRONLY|os.O_APPEND, 0o640)
+		if err != nil {
+			return errors.Wrap(err, "unable to open segment")
+		}
+	}
+
+	return nil
+}
+
+// scan validates records of the segment starting from offset, counts them and truncates the segment
+// at the first invalid record
+func (q *Queue) scan(seg *segment, from int64) error {
+	f, err := os.OpenFile(seg.path, os.O_RDWR, 0o640)
+	if err != nil {
+		return errors.Wrap(err, "unable to open segment")
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return errors.Wrap(err, "unable to stat segment")
+	}
+	seg.size = info.Size()
+
+	off := from
+	for off < seg.size {
+		_, n, err := readRecord(f, off, seg.size)
+		if err != nil {
+			infralog.Warn("spool: truncating segment at invalid record",
+				zap.String("spool", q.name),
+				zap.String("segment", seg.path),
+				zap.Int64("offset", off),
+				zap.Error(err))
+			metrics.CorruptedCounter.WithLabelValues(q.name).Inc()
+
+			if err = f.Truncate(off); err != nil {
+				return errors.Wrap(err, "unable to truncate segment")
+			}
+			seg.size = off
+			break
+		}
+
+		off += n
+		q.records++
+		q.bytes += n
+	}
+
+	return nil
+}
+
+func (q *Queue) addSegment(id uint64) error {
+	path := filepath.Join(q.cfg.Dir, fmt.Sprintf("%020d%s", id, segmentExt))
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o640)
+	if err != nil {
+		return errors.Wrap(err, "unable to create segment")
+	}
+	if len(q.segments) > 0 {
+		// the previous segment writer is replaced
+		_ = q.writer.Sync()
+		_ = q.writer.Close()
+	}
+	q.writer = f
+	q.segments = append(q.segments, &segment{id: id, path: path})
+
+	return nil
+}
+
+// Append writes the record to the end of the queue
+func (q *Queue) Append(payload []byte) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return ErrClosed
+	}
+
+	size := int64(headerSize + len(payload))
+	if q.bytes+size > q.cfg.GetMaxBytes() {
+		return ErrFull
+	}
+
+	last := q.segments[len(q.segments)-1]
+	if last.size > 0 && last.size+size > q.cfg.GetSegmentSize() {
+		if err := q.addSegment(last.id + 1); err != nil {
+			return err
+		}
+		last = q.segments[len(q.segments)-1]
+	}
+
+	buf := make([]byte, size)
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.Checksum(payload, crcTable))
+	copy(buf[headerSize:], payload)
+
+	if _, err := q.writer.Write(buf); err != nil {
+		// drop a partial write, otherwise the next records would follow garbage
+		_ = q.writer.Truncate(last.size)
+		return errors.Wrap(err, "unable to write record")
+	}
+	last.size += size
+
+	if q.cfg.SyncInterval == 0 {
+		if err := q.writer.Sync(); err != nil {
+			return errors.Wrap(err, "unable to sync segment")
+		}
+	} else {
+		q.dirty = true
+	}
+
+	q.records++
+	q.bytes += size
+	metrics.AppendedCounter.WithLabelValues(q.name).Inc()
+	q.updateGauges()
+
+	select {
+	case q.appended <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Peek returns the first record without removing it. Returns ErrEmpty if there are no records
+func (q *Queue) Peek() ([]byte, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return nil, ErrClosed
+	}
+	if q.records == 0 {
+		return nil, ErrEmpty
+	}
+
+	for q.readOff >= q.segments[0].size {
+		if err := q.dropFirstSegment(); err != nil {
+			return nil, err
+		}
+	}
+
+	if q.reader == nil {
+		f, err := os.Open(q.segments[0].path)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to open segment")
+		}
+		q.reader = f
+	}
+
+	payload, n, err := readRecord(q.reader, q.readOff, q.segments[0].size)
+	if errors.Is(err, ErrCorrupted) {
+		if n == 0 {
+			// the record size is unknown, the rest of the segment is skipped
+			n = q.segments[0].size - q.readOff
+		}
+		q.peeked, q.peekedN = n, 1
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	q.peeked, q.peekedN = n, 1
+
+	return payload, nil
+}
+
+// PeekBatch returns up to n first records of the first segment without removing them, Ack removes all of them.
+// A corrupted record ends the batch, it's returned by the next PeekBatch as ErrCorrupted like by Peek
+func (q *Queue) PeekBatch(n int) ([][]byte, error) {
+	payload, err := q.Peek()
+	if err != nil {
+		return nil, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	batch := [][]byte{payload}
+	for len(batch) < n && q.readOff+q.peeked < q.segments[0].size {
+		payload, size, err := readRecord(q.reader, q.readOff+q.peeked, q.segments[0].size)
+		if err != nil {
+			break
+		}
+		batch = append(batch, payload)
+		q.peeked += size
+		q.peekedN++
+	}
+
+	return batch, nil
+}
+
+// Ack removes the records returned by the last Peek or PeekBatch
+func (q *Queue) Ack() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return ErrClosed
+	}
+	if q.peeked == 0 {
+		return errors.New("no peeked record")
+	}
+
+	q.readOff += q.peeked
+	q.records -= q.peekedN
+	q.bytes -= q.peeked
+	q.peeked, q.peekedN = 0, 0
+	if len(q.segments) == 1 && q.readOff >= q.segments[0].size {
+		// counters may be off after skipping the rest of a corrupted segment
+		q.records, q.bytes = 0, 0
+	}
+	q.updateGauges()
+
+	if q.readOff >= q.segments[0].size && len(q.segments) > 1 {
+		return q.dropFirstSegment()
+	}
+	return q.saveCursor()
+}
+
+// dropFirstSegment removes the exhausted first segment, the last one is kept for appends
+func (q *Queue) dropFirstSegment() error {
+	if len(q.segments) == 1 {
+		return errors.New("read offset is beyond the last segment")
+	}
+
+	if q.reader != nil {
+		_ = q.reader.Close()
+		q.reader = nil
+	}
+
+	seg := q.segments[0]
+	q.segments = q.segments[1:]
+	q.readOff = 0
+
+	if err := q.saveCursor(); err != nil {
+		return err
+	}
+	if err := os.Remove(seg.path); err != nil {
+		return errors.Wrap(err, "unable to remove segment")
+	}
+	return nil
+}
+
+// saveCursor persists the read position. It isn't synced: after a crash a few records may be delivered again
+func (q *Queue) saveCursor() error {
+	buf := make([]byte, 16)
+	binary.BigEndian.PutUint64(buf[:8], q.segments[0].id)
+	binary.BigEndian.PutUint64(buf[8:], uint64(q.readOff))
+
+	if _, err := q.cursor.WriteAt(buf, 0); err != nil {
+		return errors.Wrap(err, "unable to save cursor")
+	}
+	return nil
+}
+
+// Len returns the number of records not acked yet
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.records
+}
+
+// Close syncs appended records and closes files
+func (q *Queue) Close() error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil
+	}
+	q.closed = true
+	q.mu.Unlock()
+
+	close(q.stopSync)
+	<-q.syncDone
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	err := q.writer.Sync()
+	q.closeFiles()
+
+	return err
+}
+
+func (q *Queue) closeFiles() {
+	for _, f := range []*os.File{q.writer, q.reader, q.cursor} {
+		if f != nil {
+			_ = f.Close()
+		}
+	}
+}
+
+func (q *Queue) syncLoop() {
+	defer close(q.syncDone)
+
+	ticker := time.NewTicker(q.cfg.SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-q.stopSync:
+			return
+		case <-ticker.C:
+		}
+
+		q.mu.Lock()
+		if q.dirty {
+			if err := q.writer.Sync(); err != nil {
+				infralog.ErrorCtx(context.Background(), "spool: sync segment", zap.String("spool", q.name), zap.Error(err))
+			}
+			q.dirty = false
+		}
+		q.mu.Unlock()
+	}
+}
+
+func (q *Queue) updateGauges() {
+	metrics.RecordsGauge.WithLabelValues(q.name).Set(float64(q.records))
+	metrics.BytesGauge.WithLabelValues(q.name).Set(float64(q.bytes))
+}
+
+// readRecord reads the record at offset and returns its payload and full size, size is the segment size.
+// Invalid records return ErrCorrupted with the full size if the header is valid.
+func readRecord(f *os.File, off, size int64) ([]byte, int64, error) {
+	if off+headerSize > size {
+		return nil, 0, errors.Wrap(ErrCorrupted, "truncated record header")
+	}
+
+	header := make([]byte, headerSize)
+	if _, err := f.ReadAt(header, off); err != nil {
+		return nil, 0, errors.Wrap(err, "unable to read record header")
+	}
+
+	length := binary.BigEndian.Uint32(header[0:4])
+	if off+headerSize+int64(length) > size {
+		return nil, 0, errors.Wrap(ErrCorrupted, "truncated record")
+	}
+
+	payload := make([]byte, length)
+	if _, err := f.ReadAt(payload, off+headerSize); err != nil {
+		return nil, 0, errors.Wrap(err, "unable to read record")
+	}
+
+	if crc32.Checksum(payload, crcTable) != binary.BigEndian.Uint32(header[4:8]) {
+		return nil, int64(headerSize + length), errors.Wrap(ErrCorrupted, "record checksum mismatch")
+	}
+
+	return payload, int64(headerSize + length), nil
+}
@@ -0,0 +1,231 @@
+package infraspool
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	infraclock "github.com/pushwoosh/infra/clock"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	infrarabbit "github.com/pushwoosh/infra/rabbit"
+	infrarequestid "github.com/pushwoosh/infra/requestid"
+	infraretry "github.com/pushwoosh/infra/retry"
+	infratenancy "github.com/pushwoosh/infra/tenancy"
+	"go.uber.org/zap"
+)
+
+var replayBackoff = infraretry.Exponential{
+	Initial: time.Second,
+	Max:     30 * time.Second,
+	Jitter:  0.2,
+}
+
+func init() {
+	// header values besides basic types, see amqp.Table
+	gob.Register(time.Time{})
+	gob.Register(map[string]interface{}{})
+	gob.Register([]interface{}{})
+}
+
+// Producer publishes messages to the broker and spools them to disk when the broker is unreachable
+// or the publish times out. Spooled messages are replayed in order in background once the broker is back,
+// new messages are spooled while there are spooled ones, so the publish order is kept:
+//
+//	producer, err := infraspool.WrapProducer(rabbitProducer, &infraspool.Config{Dir: "/var/spool/events"})
+//	app.Add("events producer", producer)
+//	err = producer.Produce(ctx, msg) // fails only if the message can't be spooled
+//
+// Concurrent publishes are not serialized, so their relative order is not defined.
+// Request id and tenant headers are saved with spooled messages. Records that can't be read and messages
+// rejected by the broker MaxRejections times are dropped, so they don't block the replay.
+type Producer struct {
+	publisher infrarabbit.Publisher
+	queue     *Queue
+	cfg       *Config
+	clock     infraclock.Clock
+
+	mu       sync.Mutex
+	started  bool
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+var _ infrarabbit.Publisher = (*Producer)(nil)
+
+var (
+	_ infraoperator.Starter = (*Producer)(nil)
+	_ infraoperator.Stopper = (*Producer)(nil)
+)
+
+// WrapProducer opens the spool in cfg.Dir, messages left by the previous run are replayed after Start
+func WrapProducer(p infrarabbit.Publisher, cfg *Config, opts ...Option) (*Producer, error) {
+	queue, err := Open("rabbit", cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	sp := &Producer{
+		publisher: p,
+		queue:     queue,
+		cfg:       cfg,
+		clock:     infraclock.Real,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt.apply(sp)
+	}
+
+	return sp, nil
+}
+
+// Produce publishes the message or spools it if the broker fails.
+// Returns an error if ctx is canceled before the publish, the broker rejects the message or the spool is full
+func (p *Producer) Produce(ctx context.Context, msg *infrarabbit.ProducerMessage) error {
+	if msg == nil {
+		return errors.New("message is nil")
+	}
+
+	p.mu.Lock()
+	direct := p.queue.Len() == 0
+	p.mu.Unlock()
+
+	if direct {
+		err := p.publishWithTimeout(ctx, msg)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil || errors.Is(err, infrarabbit.ErrNacked) {
+			return err
+		}
+
+		metrics.PublishErrorsCounter.WithLabelValues(p.queue.name).Inc()
+		infralog.WarnCtx(ctx, "spool: publish failed, spooling messages", zap.Error(err))
+	}
+
+	// headers of ctx are saved, replay has no ctx of the original call
+	spooled := *msg
+	spooled.Headers = infratenancy.InjectHeaders(ctx, infrarequestid.InjectHeaders(ctx, msg.Headers))
+
+	payload, err := encodeMessage(&spooled)
+	if err != nil {
+		return err
+	}
+	if err = p.queue.Append(payload); err != nil {
+		return errors.Wrap(err, "unable to spool message")
+	}
+
+	return nil
+}
+
+// Start replays spooled messages in background
+func (p *Producer) Start(_ context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.started {
+		p.started = true
+		go p.replay()
+	}
+	return nil
+}
+
+// Stop stops the replay and closes the spool. Messages not replayed yet stay on disk for the next run
+func (p *Producer) Stop(ctx context.Context) error {
+	p.mu.Lock()
+	started := p.started
+	p.mu.Unlock()
+
+	if started {
+		p.stopOnce.Do(func() { close(p.stop) })
+
+		select {
+		case <-p.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return p.queue.Close()
+}
+
+// Spooled returns the number of messages waiting for the replay
+func (p *Producer) Spooled() int {
+	return p.queue.Len()
+}
+
+func (p *Producer) replay() {
+	defer close(p.done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-p.stop:
+			cancel()
+		case <-p.done:
+		}
+	}()
+
+	rejections := 0
+	p.queue.Replay(ctx, 1, replayBackoff, p.clock, func(ctx context.Context, payloads [][]byte) error {
+		msg, err := decodeMessage(payloads[0])
+		if err != nil {
+			infralog.ErrorCtx(ctx, "spool: dropping undecodable message", zap.Error(err))
+			metrics.CorruptedCounter.WithLabelValues(p.queue.name).Inc()
+			return nil
+		}
+
+		if err = p.publishWithTimeout(ctx, msg); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+
+			metrics.PublishErrorsCounter.WithLabelValues(p.queue.name).Inc()
+			if errors.Is(err, infrarabbit.ErrNacked) {
+				if rejections++; rejections >= p.cfg.GetMaxRejections() {
+					infralog.ErrorCtx(ctx, "spool: dropping message rejected by broker",
+						zap.String("exchange", msg.Exchange),
+						zap.String("routing_key", msg.RoutingKey),
+						zap.Int("rejections", rejections))
+					metrics.RejectedCounter.WithLabelValues(p.queue.name).Inc()
+					rejections = 0
+					return nil
+				}
+			}
+			return err
+		}
+
+		rejections = 0
+		return nil
+	})
+}
+
+func (p *Producer) publishWithTimeout(ctx context.Context, msg *infrarabbit.ProducerMessage) error {
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.GetPublishTimeout())
+	defer cancel()
+
+	return p.publisher.Produce(ctx, msg)
+}
+
+func encodeMessage(msg *infrarabbit.ProducerMessage) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(msg); err != nil {
+		return nil, errors.Wrap(err, "unable to encode message")
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeMessage(payload []byte) (*infrarabbit.ProducerMessage, error) {
+	msg := &infrarabbit.ProducerMessage{}
+	if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(msg); err != nil {
+		return nil, errors.Wrap(err, "unable to decode message")
+	}
+	return msg, nil
+}
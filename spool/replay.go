@@ -0,0 +1,70 @@
+package infraspool
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	infraclock "github.com/pushwoosh/infra/clock"
+	infralog "github.com/pushwoosh/infra/log"
+	infraretry "github.com/pushwoosh/infra/retry"
+	"go.uber.org/zap"
+)
+
+// ReplayFunc handles records peeked from the queue, they are acked when it returns nil
+// and handled again after a backoff delay otherwise
+type ReplayFunc func(ctx context.Context, payloads [][]byte) error
+
+// Replay passes records to handle in order, up to batch records at once, until ctx is done or the queue is closed.
+// It waits for Append when the queue is empty. Corrupted records are dropped, so they don't block the replay
+func (q *Queue) Replay(ctx context.Context, batch int, backoff infraretry.Backoff, clock infraclock.Clock, handle ReplayFunc) {
+	failures := 0
+	for ctx.Err() == nil {
+		payloads, err := q.PeekBatch(batch)
+		if errors.Is(err, ErrEmpty) {
+			select {
+			case <-q.appended:
+			case <-ctx.Done():
+			}
+			continue
+		}
+		if errors.Is(err, ErrClosed) {
+			return
+		}
+		if errors.Is(err, ErrCorrupted) {
+			infralog.ErrorCtx(ctx, "spool: dropping corrupted record", zap.String("spool", q.name), zap.Error(err))
+			metrics.CorruptedCounter.WithLabelValues(q.name).Inc()
+			q.ack(ctx)
+			continue
+		}
+		if err == nil {
+			err = handle(ctx, payloads)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			failures++
+			infralog.WarnCtx(ctx, "spool: replay failed",
+				zap.String("spool", q.name),
+				zap.Int("spooled", q.Len()),
+				zap.Int("failures", failures),
+				zap.Error(err))
+			_ = clock.Sleep(ctx, backoff.Delay(failures))
+			continue
+		}
+
+		if failures > 0 {
+			infralog.InfoCtx(ctx, "spool: replay resumed", zap.String("spool", q.name), zap.Int("spooled", q.Len()))
+		}
+		failures = 0
+		metrics.ReplayedCounter.WithLabelValues(q.name).Add(float64(len(payloads)))
+		q.ack(ctx)
+	}
+}
+
+func (q *Queue) ack(ctx context.Context) {
+	if err := q.Ack(); err != nil {
+		infralog.ErrorCtx(ctx, "spool: ack records", zap.String("spool", q.name), zap.Error(err))
+	}
+}
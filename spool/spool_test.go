@@ -0,0 +1,299 @@
+package infraspool
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	infraclock "github.com/pushwoosh/infra/clock"
+	infrarabbit "github.com/pushwoosh/infra/rabbit"
+	infraretry "github.com/pushwoosh/infra/retry"
+)
+
+func TestQueue(t *testing.T) {
+	cfg := &Config{Dir: t.TempDir(), SegmentSize: 64}
+
+	q, err := Open("test", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if err = q.Append([]byte(fmt.Sprintf("record %d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := 0; i < 4; i++ {
+		if _, err = q.Peek(); err != nil {
+			t.Fatal(err)
+		}
+		if err = q.Ack(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = q.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a torn record left by a crash is truncated on open
+	segments, _ := filepath.Glob(filepath.Join(cfg.Dir, "*"+segmentExt))
+	f, err := os.OpenFile(segments[len(segments)-1], os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.Write([]byte{0, 0, 0, 100, 1, 2})
+	_ = f.Close()
+
+	q, err = Open("test", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+
+	if q.Len() != 6 {
+		t.Fatalf("expected 6 records after reopen, got %d", q.Len())
+	}
+
+	payload, err := q.Peek()
+	if err != nil || string(payload) != "record 4" {
+		t.Fatalf("expected the first record not acked, got %q, %v", payload, err)
+	}
+
+	for q.Len() > 0 {
+		if _, err = q.Peek(); err != nil {
+			t.Fatal(err)
+		}
+		if err = q.Ack(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = q.Peek(); !errors.Is(err, ErrEmpty) {
+		t.Fatalf("expected ErrEmpty, got %v", err)
+	}
+
+	if err = q.Append(make([]byte, DefaultMaxBytes)); !errors.Is(err, ErrFull) {
+		t.Fatalf("expected ErrFull, got %v", err)
+	}
+}
+
+type publisherFunc func(ctx context.Context, msg *infrarabbit.ProducerMessage) error
+
+func (f publisherFunc) Produce(ctx context.Context, msg *infrarabbit.ProducerMessage) error {
+	return f(ctx, msg)
+}
+
+func TestQueuePeekBatch(t *testing.T) {
+	q, err := Open("test_batch", &Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+
+	for i := 0; i < 5; i++ {
+		if err = q.Append([]byte(fmt.Sprintf("record %d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	batch, err := q.PeekBatch(3)
+	if err != nil || len(batch) != 3 || string(batch[2]) != "record 2" {
+		t.Fatalf("unexpected batch %q, %v", batch, err)
+	}
+	if err = q.Ack(); err != nil {
+		t.Fatal(err)
+	}
+	if q.Len() != 2 {
+		t.Fatalf("expected 2 records after ack, got %d", q.Len())
+	}
+
+	batch, err = q.PeekBatch(10)
+	if err != nil || len(batch) != 2 || string(batch[0]) != "record 3" {
+		t.Fatalf("unexpected batch %q, %v", batch, err)
+	}
+}
+
+func TestQueueReplay(t *testing.T) {
+	q, err := Open("test_replay", &Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+
+	for i := 0; i < 3; i++ {
+		if err = q.Append([]byte(fmt.Sprintf("record %d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	failed := false
+	batches := make(chan string, 10)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		q.Replay(ctx, 2, infraretry.Constant(0), infraclock.Real, func(_ context.Context, payloads [][]byte) error {
+			if !failed {
+				failed = true
+				return errors.New("unavailable")
+			}
+			batches <- fmt.Sprintf("%q", payloads)
+			return nil
+		})
+	}()
+
+	for _, expected := range []string{`["record 0" "record 1"]`, `["record 2"]`, `["record 3"]`} {
+		if expected == `["record 3"]` {
+			// a record appended to the empty queue wakes the replay up
+			if err = q.Append([]byte("record 3")); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		select {
+		case batch := <-batches:
+			if batch != expected {
+				t.Fatalf("expected batch %s, got %s", expected, batch)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("batch %s wasn't replayed", expected)
+		}
+	}
+
+	cancel()
+	<-done
+
+	if q.Len() != 0 {
+		t.Fatalf("expected acked records, got %d", q.Len())
+	}
+}
+
+func TestProducer(t *testing.T) {
+	var mu sync.Mutex
+	var published []string
+	brokerDown := true
+	publisher := publisherFunc(func(_ context.Context, msg *infrarabbit.ProducerMessage) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if brokerDown {
+			return errors.New("connection refused")
+		}
+		published = append(published, string(msg.Body))
+		return nil
+	})
+
+	p, err := WrapProducer(publisher, &Config{Dir: t.TempDir(), PublishTimeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if err = p.Produce(ctx, &infrarabbit.ProducerMessage{Body: []byte(fmt.Sprint(i))}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if p.Spooled() != 3 {
+		t.Fatalf("expected 3 spooled messages, got %d", p.Spooled())
+	}
+
+	mu.Lock()
+	brokerDown = false
+	mu.Unlock()
+
+	if err = p.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer p.Stop(ctx)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for p.Spooled() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err = p.Produce(ctx, &infrarabbit.ProducerMessage{Body: []byte("3")}); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if fmt.Sprint(published) != "[0 1 2 3]" {
+		t.Fatalf("expected messages in order, got %v", published)
+	}
+}
+
+func TestProducerSkipsPoison(t *testing.T) {
+	var mu sync.Mutex
+	var published []string
+	brokerDown := true
+	publisher := publisherFunc(func(_ context.Context, msg *infrarabbit.ProducerMessage) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case brokerDown:
+			return errors.New("connection refused")
+		case string(msg.Body) == "rejected":
+			return infrarabbit.ErrNacked
+		}
+		published = append(published, string(msg.Body))
+		return nil
+	})
+
+	cfg := &Config{Dir: t.TempDir(), PublishTimeout: time.Second, MaxRejections: 2}
+	clock := infraclock.NewFake(time.Now())
+	p, err := WrapProducer(publisher, cfg, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for _, body := range []string{"corrupted", "rejected", "ok"} {
+		if err = p.Produce(ctx, &infrarabbit.ProducerMessage{Body: []byte(body)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// flip a payload byte of the first record
+	segments, _ := filepath.Glob(filepath.Join(cfg.Dir, "*"+segmentExt))
+	f, err := os.OpenFile(segments[0], os.O_RDWR, 0o640)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteAt([]byte{0xff}, headerSize+4)
+	_ = f.Close()
+
+	mu.Lock()
+	brokerDown = false
+	mu.Unlock()
+
+	if err = p.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for p.Spooled() > 0 && time.Now().Before(deadline) {
+		clock.Advance(time.Minute)
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	if fmt.Sprint(published) != "[ok]" {
+		t.Fatalf("expected poison messages to be dropped, got %v", published)
+	}
+	mu.Unlock()
+
+	if err = p.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,269 @@
+package infrasystem
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pushwoosh/infra/log"
+	"github.com/pushwoosh/infra/operator"
+	"go.uber.org/zap"
+)
+
+// Phase is a shutdown phase. Phases are run one after another in ascending order,
+// hooks of the same phase are run concurrently.
+type Phase int
+
+const (
+	// PhaseStopIntake stops accepting new work: http servers, consumers, readiness
+	PhaseStopIntake Phase = iota
+	// PhaseDrain waits for the work in progress: worker pools, buffers, producers flush
+	PhaseDrain
+	// PhaseClose closes resources: database pools, broker connections
+	PhaseClose
+)
+
+var phaseNames = map[Phase]string{
+	PhaseStopIntake: "stop_intake",
+	PhaseDrain:      "drain",
+	PhaseClose:      "close",
+}
+
+func (p Phase) String() string {
+	return phaseNames[p]
+}
+
+const defaultPhaseTimeout = 20 * time.Second
+
+// Coordinator listens for termination signals and runs shutdown hooks in phases.
+// Its Context is canceled as soon as shutdown begins:
+//
+//	c := infrasystem.NewCoordinator()
+//	c.AddServer("http", httpServer)
+//	c.AddConsumer("events", rabbitConsumer)
+//	c.AddProducer("events", rabbitProducer)
+//	c.AddContainer("postgres", pgContainer)
+//	c.AddFunc(infrasystem.PhaseDrain, "workers", pool.Stop)
+//	go worker.Run(c.Context())
+//	if err := c.Wait(); err != nil { ... } // err lists hooks that failed or timed out
+type Coordinator struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu            sync.Mutex
+	hooks         map[Phase][]shutdownHook
+	phaseTimeouts map[Phase]time.Duration
+	signals       []os.Signal
+
+	shutdown     chan struct{}
+	shutdownOnce sync.Once
+}
+
+// Shutdowner is a server that stops gracefully, e.g. *http.Server
+type Shutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
+type shutdownHook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// NewCoordinator creates coordinator that reacts on SIGTERM and SIGINT.
+// Every phase has 20 seconds timeout by default.
+func NewCoordinator(opts ...CoordinatorOption) *Coordinator {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	c := &Coordinator{
+		ctx:    ctx,
+		cancel: cancel,
+		hooks:  make(map[Phase][]shutdownHook),
+		phaseTimeouts: map[Phase]time.Duration{
+			PhaseStopIntake: defaultPhaseTimeout,
+			PhaseDrain:      defaultPhaseTimeout,
+			PhaseClose:      defaultPhaseTimeout,
+		},
+		signals:  []os.Signal{syscall.SIGTERM, syscall.SIGINT},
+		shutdown: make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt.apply(c)
+	}
+
+	return c
+}
+
+// Context returns root context that is canceled when shutdown begins
+func (c *Coordinator) Context() context.Context {
+	return c.ctx
+}
+
+// AddFunc registers a shutdown hook
+func (c *Coordinator) AddFunc(phase Phase, name string, fn func(ctx context.Context) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.hooks[phase] = append(c.hooks[phase], shutdownHook{name: name, fn: fn})
+}
+
+// AddStopper registers a service stop as a shutdown hook
+func (c *Coordinator) AddStopper(phase Phase, name string, stopper infraoperator.Stopper) {
+	c.AddFunc(phase, name, stopper.Stop)
+}
+
+// AddCloser registers Close of a consumer, producer or a container as a shutdown hook
+func (c *Coordinator) AddCloser(phase Phase, name string, closer interface{ Close() error }) {
+	c.AddFunc(phase, name, func(_ context.Context) error {
+		return closer.Close()
+	})
+}
+
+// AddServer registers Shutdown of a server, e.g. *http.Server, in PhaseStopIntake
+func (c *Coordinator) AddServer(name string, server Shutdowner) {
+	c.AddFunc(PhaseStopIntake, name, server.Shutdown)
+}
+
+// AddConsumer registers Close of a consumer, e.g. *infrarabbit.Consumer, in PhaseStopIntake
+func (c *Coordinator) AddConsumer(name string, consumer interface{ Close() error }) {
+	c.AddCloser(PhaseStopIntake, name, consumer)
+}
+
+// AddProducer registers Close of a producer, e.g. *infrarabbit.Producer, in PhaseDrain,
+// so it's closed after consumers stopped handing it messages
+func (c *Coordinator) AddProducer(name string, producer interface{ Close() error }) {
+	c.AddCloser(PhaseDrain, name, producer)
+}
+
+// AddContainer registers Close of a connection container, e.g. *infraredis.Container, in PhaseClose
+func (c *Coordinator) AddContainer(name string, container interface{ Close() }) {
+	c.AddFunc(PhaseClose, name, func(_ context.Context) error {
+		container.Close()
+		return nil
+	})
+}
+
+// Shutdown begins shutdown without a signal. It's safe to call it several times.
+func (c *Coordinator) Shutdown() {
+	c.shutdownOnce.Do(func() {
+		c.cancel()
+		close(c.shutdown)
+	})
+}
+
+// Wait blocks until a signal is received or Shutdown is called and runs all phases.
+// Returns error describing hooks that failed or timed out.
+func (c *Coordinator) Wait() error {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, c.signals...)
+	defer signal.Stop(interrupt)
+
+	select {
+	case si := <-interrupt:
+		infralog.Info("Received signal", zap.String("signal", si.String()))
+		c.Shutdown()
+	case <-c.shutdown:
+	}
+
+	infralog.Info("Shutting down")
+
+	var failures []string
+	for _, phase := range []Phase{PhaseStopIntake, PhaseDrain, PhaseClose} {
+		failures = append(failures, c.runPhase(phase)...)
+	}
+
+	if len(failures) > 0 {
+		return errors.Errorf("shutdown failed: %s", strings.Join(failures, "; "))
+	}
+
+	infralog.Info("Shutdown completed")
+	return nil
+}
+
+// runPhase runs phase hooks concurrently and returns descriptions of failed hooks
+func (c *Coordinator) runPhase(phase Phase) []string {
+	c.mu.Lock()
+	hooks := append([]shutdownHook{}, c.hooks[phase]...)
+	timeout := c.phaseTimeouts[phase]
+	c.mu.Unlock()
+
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	infralog.Info("Shutdown phase", zap.Stringer("phase", phase), zap.Int("hooks", len(hooks)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	type result struct {
+		idx int
+		err error
+	}
+
+	results := make(chan result, len(hooks))
+	for i, h := range hooks {
+		go func(i int, h shutdownHook) {
+			results <- result{idx: i, err: h.fn(ctx)}
+		}(i, h)
+	}
+
+	var failures []string
+	done := make([]bool, len(hooks))
+	for range hooks {
+		select {
+		case r := <-results:
+			done[r.idx] = true
+			if r.err != nil {
+				name := hooks[r.idx].name
+				infralog.Error("Shutdown hook error", zap.Stringer("phase", phase), zap.String("hook", name), zap.Error(r.err))
+				failures = append(failures, phase.String()+"/"+name+": "+r.err.Error())
+			}
+		case <-ctx.Done():
+			// hooks that didn't return are abandoned
+			for i, h := range hooks {
+				if !done[i] {
+					infralog.Error("Shutdown hook timed out", zap.Stringer("phase", phase), zap.String("hook", h.name), zap.Duration("timeout", timeout))
+					failures = append(failures, phase.String()+"/"+h.name+": timed out")
+				}
+			}
+			return failures
+		}
+	}
+
+	return failures
+}
+
+type CoordinatorOption interface {
+	apply(c *Coordinator)
+}
+
+type optionWithPhaseTimeout struct {
+	phase   Phase
+	timeout time.Duration
+}
+
+func (o optionWithPhaseTimeout) apply(c *Coordinator) {
+	c.phaseTimeouts[o.phase] = o.timeout
+}
+
+// WithPhaseTimeout sets deadline of a shutdown phase
+func WithPhaseTimeout(phase Phase, timeout time.Duration) CoordinatorOption {
+	return optionWithPhaseTimeout{phase: phase, timeout: timeout}
+}
+
+type optionWithSignals []os.Signal
+
+func (o optionWithSignals) apply(c *Coordinator) {
+	c.signals = o
+}
+
+// WithSignals overrides signals that begin shutdown
+func WithSignals(signals ...os.Signal) CoordinatorOption {
+	return optionWithSignals(signals)
+}
@@ -0,0 +1,152 @@
+package infrasystem
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type testContainer struct {
+	close func()
+}
+
+func (c testContainer) Close() { c.close() }
+
+func TestCoordinatorPhaseOrder(t *testing.T) {
+	c := NewCoordinator()
+
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, name)
+	}
+
+	c.AddContainer("container", testContainer{close: func() { record("container") }})
+	c.AddFunc(PhaseDrain, "drain", func(context.Context) error {
+		record("drain")
+		return nil
+	})
+	c.AddStopper(PhaseStopIntake, "intake", stopperFunc(func(context.Context) error {
+		record("intake")
+		return nil
+	}))
+
+	c.Shutdown()
+	if err := c.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(order, ",") != "intake,drain,container" {
+		t.Fatalf("unexpected order: %v", order)
+	}
+	if c.Context().Err() == nil {
+		t.Fatal("context is not canceled")
+	}
+}
+
+func TestCoordinatorTimeout(t *testing.T) {
+	c := NewCoordinator(WithPhaseTimeout(PhaseDrain, 10*time.Millisecond))
+
+	release := make(chan struct{})
+	defer close(release)
+	c.AddFunc(PhaseDrain, "stuck", func(context.Context) error {
+		<-release
+		return nil
+	})
+	c.AddFunc(PhaseDrain, "fast", func(context.Context) error { return nil })
+
+	closed := false
+	c.AddContainer("container", testContainer{close: func() { closed = true }})
+
+	c.Shutdown()
+	err := c.Wait()
+	if err == nil || !strings.Contains(err.Error(), "drain/stuck: timed out") || strings.Contains(err.Error(), "fast") {
+		t.Fatalf("expected timeout of the stuck hook, got %v", err)
+	}
+	if !closed {
+		t.Fatal("phases after the timed out one were not run")
+	}
+}
+
+func TestCoordinatorErrors(t *testing.T) {
+	c := NewCoordinator()
+
+	// hooks of the same phase run concurrently: each waits for the other one
+	first, second := make(chan struct{}), make(chan struct{})
+	c.AddConsumer("events", closerFunc(func() error {
+		close(first)
+		<-second
+		return errors.New("channel closed")
+	}))
+	c.AddServer("http", shutdownerFunc(func(context.Context) error {
+		close(second)
+		<-first
+		return nil
+	}))
+
+	produced := false
+	c.AddProducer("events", closerFunc(func() error {
+		produced = true
+		return nil
+	}))
+
+	c.Shutdown()
+	c.Shutdown()
+	err := c.Wait()
+	if err == nil || err.Error() != "shutdown failed: stop_intake/events: channel closed" {
+		t.Fatalf("expected the consumer error, got %v", err)
+	}
+	if !produced {
+		t.Fatal("phases after the failed one were not run")
+	}
+}
+
+func TestCoordinatorSignal(t *testing.T) {
+	// keep the signal from terminating the test before Wait subscribes to it
+	ignored := make(chan os.Signal, 1)
+	signal.Notify(ignored, syscall.SIGUSR1)
+	defer signal.Stop(ignored)
+
+	c := NewCoordinator(WithSignals(syscall.SIGUSR1))
+
+	done := make(chan error)
+	go func() { done <- c.Wait() }()
+
+	for {
+		if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Context().Err() == nil {
+				t.Fatal("context is not canceled")
+			}
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
+
+type shutdownerFunc func(ctx context.Context) error
+
+func (f shutdownerFunc) Shutdown(ctx context.Context) error { return f(ctx) }
+
+type stopperFunc func(ctx context.Context) error
+
+func (f stopperFunc) Stop(ctx context.Context) error { return f(ctx) }
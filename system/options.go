@@ -38,3 +38,26 @@ func (o optionWithShutdownCallback) apply(s *Signals) {
 func WithShutdownCallback(fn func(ctx context.Context)) Option {
 	return optionWithShutdownCallback(fn)
 }
+
+type optionWithFatalShutdown struct{}
+
+func (o optionWithFatalShutdown) apply(s *Signals) {
+	s.fatalShutdown = true
+}
+
+// WithFatalShutdown makes Fatal log entries run the shutdown callback and stop
+// all operator services before the process exits.
+func WithFatalShutdown() Option {
+	return optionWithFatalShutdown{}
+}
+
+type optionWithReloadCallback func()
+
+func (o optionWithReloadCallback) apply(s *Signals) {
+	s.reloadCallback = o
+}
+
+// WithReloadCallback sets a function that is called by Reload handler (SIGHUP by default)
+func WithReloadCallback(fn func()) Option {
+	return optionWithReloadCallback(fn)
+}
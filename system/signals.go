@@ -27,8 +27,12 @@ type Signals struct {
 	shutdownGracePeriod time.Duration
 
 	shutdownCallback func(ctx context.Context)
+	reloadCallback   func()
+	fatalShutdown    bool
 
-	op *infraoperator.Operator
+	op         *infraoperator.Operator
+	stopOnce   sync.Once
+	stopErrors map[interface{}]error
 }
 
 // NewDefaultSignals creates signal controller with the following signals handlers:
@@ -69,6 +73,14 @@ func NewSignals(op *infraoperator.Operator, opts ...Option) *Signals {
 		opts[i].apply(ret)
 	}
 
+	if ret.fatalShutdown {
+		infralog.OnFatal(func(ctx context.Context) {
+			for _, err := range ret.stop(ctx) {
+				infralog.Error("Shutdown error", zap.Error(err))
+			}
+		})
+	}
+
 	return ret
 }
 
@@ -165,9 +177,16 @@ func (s *Signals) Wait() {
 	}
 }
 
-// Reload is a signal handler that reloads configuration
+// Reload is a signal handler that reloads configuration.
+// Does nothing unless reload callback is set with WithReloadCallback.
 func (s *Signals) Reload() {
-	panic("not implemented")
+	if s.reloadCallback == nil {
+		infralog.Warn("Reload is not configured")
+		return
+	}
+
+	infralog.Info("Reloading configuration")
+	s.reloadCallback()
 }
 
 // Shutdown is a signal handler that initializes system shutdown
@@ -186,10 +205,7 @@ func (s *Signals) Shutdown() {
 
 	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
 
-	infralog.Info("Waiting for service to finish tasks")
-	s.shutdownCallback(ctx)
-
-	stopResults := s.op.StopAll(ctx)
+	stopResults := s.stop(ctx)
 	cancel()
 
 	infralog.Info("StopAll")
@@ -201,5 +217,22 @@ func (s *Signals) Shutdown() {
 		return
 	}
 
-	s.done <- struct{}{}
+	// Wait may already be signaled by an earlier shutdown
+	select {
+	case s.done <- struct{}{}:
+	default:
+	}
+}
+
+// stop waits for the service to finish its tasks and stops all services.
+// Services are stopped once, e.g. a signal during a fatal shutdown waits for it and gets its errors
+func (s *Signals) stop(ctx context.Context) map[interface{}]error {
+	s.stopOnce.Do(func() {
+		infralog.Info("Waiting for service to finish tasks")
+		s.shutdownCallback(ctx)
+
+		s.stopErrors = s.op.StopAll(ctx)
+	})
+
+	return s.stopErrors
 }
@@ -0,0 +1,28 @@
+package infrasystem
+
+import (
+	"context"
+	"testing"
+
+	infraoperator "github.com/pushwoosh/infra/operator"
+)
+
+func TestSignalsStopOnce(t *testing.T) {
+	op := &infraoperator.Operator{}
+
+	stops := 0
+	if err := op.AddService(context.Background(), stopperFunc(func(context.Context) error {
+		stops++
+		return nil
+	})); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewSignals(op, WithShutdownGracePeriod(0))
+	s.Shutdown()
+	s.Shutdown()
+
+	if stops != 1 {
+		t.Fatalf("expected services to be stopped once, got %d", stops)
+	}
+}
@@ -0,0 +1,149 @@
+package infratarantool
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	infraconfig "github.com/pushwoosh/infra/config"
+	infralog "github.com/pushwoosh/infra/log"
+	"github.com/tarantool/go-tarantool/v2"
+	"go.uber.org/zap"
+)
+
+// Client is a tarantool connection with reconnects and metrics
+type Client struct {
+	name   string
+	cfg    *ConnectionConfig
+	conn   *tarantool.Connection
+	events chan tarantool.ConnEvent
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// NewClient connects to tarantool. Use Container to hold named clients
+func NewClient(name string, cfg *ConnectionConfig) (*Client, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	password, err := infraconfig.ResolveSecret(cfg.Password)
+	if err != nil {
+		return nil, errors.Wrap(err, "password")
+	}
+
+	initMetrics()
+
+	c := &Client{
+		name:   name,
+		cfg:    cfg,
+		events: make(chan tarantool.ConnEvent, 16),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go c.watch()
+
+	dialer := tarantool.NetDialer{
+		Address:  cfg.Address,
+		User:     cfg.User,
+		Password: password,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.GetConnectTimeout())
+	defer cancel()
+
+	c.conn, err = tarantool.Connect(ctx, dialer, tarantool.Opts{
+		Timeout:       cfg.GetTimeout(),
+		Reconnect:     cfg.GetReconnect(),
+		MaxReconnects: cfg.MaxReconnects,
+		Notify:        c.events,
+	})
+	if err != nil {
+		close(c.stop)
+		<-c.done
+		return nil, errors.Wrap(err, "cannot connect to tarantool")
+	}
+
+	metrics.ConnectedGauge.WithLabelValues(name).Set(1)
+
+	return c, nil
+}
+
+// watch counts connection events until the client is closed.
+// Events are dropped by the driver if the channel is full, so the gauge is reset on close
+func (c *Client) watch() {
+	defer close(c.done)
+	defer metrics.ConnectedGauge.WithLabelValues(c.name).Set(0)
+
+	for {
+		select {
+		case event := <-c.events:
+			metrics.EventsCounter.WithLabelValues(c.name, eventName(event.Kind)).Inc()
+
+			switch event.Kind {
+			case tarantool.Connected:
+				metrics.ConnectedGauge.WithLabelValues(c.name).Set(1)
+			case tarantool.Disconnected, tarantool.Closed:
+				metrics.ConnectedGauge.WithLabelValues(c.name).Set(0)
+				infralog.Warn("tarantool connection is broken", zap.String("connection", c.name))
+			}
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// Conn returns underlying connection
+func (c *Client) Conn() *tarantool.Connection {
+	return c.conn
+}
+
+// Do sends a request and decodes its response into result, e.g. a pointer to a slice of structs.
+// command is a metric label. Set ctx of the request to limit its lifetime beyond the configured timeout
+func (c *Client) Do(command string, req tarantool.Request, result interface{}) (err error) {
+	defer func(start time.Time) { c.observe(command, start, err) }(time.Now())
+
+	fut := c.conn.Do(req)
+	if result == nil {
+		_, err = fut.Get()
+		return err
+	}
+	return fut.GetTyped(result)
+}
+
+// Call calls a stored function. Its return values are decoded into result as an array,
+// so a function returning one table is decoded into a pointer to a slice of one struct
+func (c *Client) Call(ctx context.Context, function string, args []interface{}, result interface{}) error {
+	if args == nil {
+		args = []interface{}{}
+	}
+	return c.Do("call", tarantool.NewCallRequest(function).Args(args).Context(ctx), result)
+}
+
+// Select selects tuples equal to key by the index and decodes them into result, a pointer to a slice.
+// limit 0 means no limit
+func (c *Client) Select(ctx context.Context, space, index string, key []interface{}, limit uint32, result interface{}) error {
+	if limit == 0 {
+		limit = ^uint32(0)
+	}
+	req := tarantool.NewSelectRequest(space).
+		Index(index).
+		Key(key).
+		Limit(limit).
+		Iterator(tarantool.IterEq).
+		Context(ctx)
+	return c.Do("select", req, result)
+}
+
+// Ping checks the connection
+func (c *Client) Ping(ctx context.Context) error {
+	return c.Do("ping", tarantool.NewPingRequest().Context(ctx), nil)
+}
+
+// Close waits for in-flight requests and closes the connection
+func (c *Client) Close() error {
+	err := c.conn.CloseGraceful()
+	close(c.stop)
+	<-c.done
+	return err
+}
@@ -0,0 +1,89 @@
+package infratarantool
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultTimeout        = time.Second
+	defaultConnectTimeout = 5 * time.Second
+	defaultReconnect      = time.Second
+)
+
+type ConnectionsConfig map[string]*ConnectionConfig
+
+type ConnectionConfig struct {
+	// Address "host:port" or a unix socket path
+	Address string `mapstructure:"address"`
+
+	// optional, guest if empty
+	User string `mapstructure:"user"`
+
+	// Password or a secret reference, e.g. "${vault:secret/tarantool#password}". optional
+	Password string `mapstructure:"password"`
+
+	// Request timeout. optional, default: 1s
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// Timeout of the first connection. optional, default: 5s
+	ConnectTimeout time.Duration `mapstructure:"connect_timeout"`
+
+	// Pause between reconnect attempts. optional, default: 1s
+	Reconnect time.Duration `mapstructure:"reconnect"`
+
+	// Reconnect attempts after which the connection is closed for good. optional, default: unlimited
+	MaxReconnects uint `mapstructure:"max_reconnects"`
+}
+
+func (c *ConnectionsConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	for name, conf := range *c {
+		if err := conf.Validate(); err != nil {
+			return errors.Wrap(err, name)
+		}
+	}
+
+	return nil
+}
+
+func (c *ConnectionConfig) Validate() error {
+	if c == nil {
+		return errors.New("empty connection config")
+	}
+
+	if c.Address == "" {
+		return errors.New("address is mandatory")
+	}
+
+	if c.Timeout < 0 || c.ConnectTimeout < 0 || c.Reconnect < 0 {
+		return errors.New("timeouts should be greater than or equal to 0")
+	}
+
+	return nil
+}
+
+func (c *ConnectionConfig) GetTimeout() time.Duration {
+	if c.Timeout == 0 {
+		return defaultTimeout
+	}
+	return c.Timeout
+}
+
+func (c *ConnectionConfig) GetConnectTimeout() time.Duration {
+	if c.ConnectTimeout == 0 {
+		return defaultConnectTimeout
+	}
+	return c.ConnectTimeout
+}
+
+func (c *ConnectionConfig) GetReconnect() time.Duration {
+	if c.Reconnect == 0 {
+		return defaultReconnect
+	}
+	return c.Reconnect
+}
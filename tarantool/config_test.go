@@ -0,0 +1,33 @@
+package infratarantool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectionConfig(t *testing.T) {
+	var cfg *ConnectionConfig
+	require.Error(t, cfg.Validate())
+
+	cfg = &ConnectionConfig{}
+	require.Error(t, cfg.Validate())
+
+	cfg = &ConnectionConfig{Address: "localhost:3301", Timeout: -time.Second}
+	require.Error(t, cfg.Validate())
+
+	cfg = &ConnectionConfig{Address: "localhost:3301"}
+	require.NoError(t, cfg.Validate())
+	require.Equal(t, time.Second, cfg.GetTimeout())
+	require.Equal(t, 5*time.Second, cfg.GetConnectTimeout())
+	require.Equal(t, time.Second, cfg.GetReconnect())
+
+	conns := ConnectionsConfig{"main": cfg, "broken": {}}
+	require.ErrorContains(t, conns.Validate(), "broken")
+}
+
+func TestClientUnreachable(t *testing.T) {
+	_, err := NewClient("test", &ConnectionConfig{Address: "127.0.0.1:1", ConnectTimeout: 100 * time.Millisecond})
+	require.ErrorContains(t, err, "cannot connect to tarantool")
+}
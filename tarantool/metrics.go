@@ -0,0 +1,76 @@
+package infratarantool
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tarantool/go-tarantool/v2"
+)
+
+var metrics struct {
+	RequestsCounter          *prometheus.CounterVec
+	CommandDurationHistogram *prometheus.HistogramVec
+	ConnectedGauge           *prometheus.GaugeVec
+	EventsCounter            *prometheus.CounterVec
+}
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.RequestsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "tarantool_requests_total",
+			Help: "The total number of tarantool requests by result: success or error",
+		}, []string{"connection", "command", "result"})
+
+		metrics.CommandDurationHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "tarantool_command_duration",
+			Help:    "The tarantool request duration",
+			Buckets: []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1},
+		}, []string{"connection", "command"})
+
+		metrics.ConnectedGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "tarantool_connected",
+			Help: "1 if the connection is established, 0 if it's broken",
+		}, []string{"connection"})
+
+		metrics.EventsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "tarantool_connection_events_total",
+			Help: "The total number of connection events: connected, disconnected, reconnect_failed, shutdown or closed",
+		}, []string{"connection", "event"})
+
+		prometheus.MustRegister(
+			metrics.RequestsCounter,
+			metrics.CommandDurationHistogram,
+			metrics.ConnectedGauge,
+			metrics.EventsCounter,
+		)
+	})
+}
+
+func (c *Client) observe(command string, start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+
+	metrics.RequestsCounter.WithLabelValues(c.name, command, result).Inc()
+	metrics.CommandDurationHistogram.WithLabelValues(c.name, command).Observe(time.Since(start).Seconds())
+}
+
+func eventName(kind tarantool.ConnEventKind) string {
+	switch kind {
+	case tarantool.Connected:
+		return "connected"
+	case tarantool.Disconnected:
+		return "disconnected"
+	case tarantool.ReconnectFailed:
+		return "reconnect_failed"
+	case tarantool.Shutdown:
+		return "shutdown"
+	case tarantool.Closed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,40 @@
+package infratenancy
+
+import (
+	"context"
+	"maps"
+)
+
+// InjectHeaders returns AMQP message headers with the tenant id of the context.
+// headers are copied, not modified. infra rabbit producer calls it on every publish.
+func InjectHeaders(ctx context.Context, headers map[string]interface{}) map[string]interface{} {
+	tenant := FromContext(ctx)
+	if tenant == "" {
+		return headers
+	}
+
+	if _, ok := headers[AMQPHeader]; ok {
+		return headers
+	}
+
+	headers = maps.Clone(headers)
+	if headers == nil {
+		headers = make(map[string]interface{}, 1)
+	}
+	headers[AMQPHeader] = tenant
+
+	return headers
+}
+
+// ExtractHeaders returns the context with the tenant id of AMQP message headers.
+// infra rabbit router calls it for every dispatched message.
+func ExtractHeaders(ctx context.Context, headers map[string]interface{}) context.Context {
+	switch v := headers[AMQPHeader].(type) {
+	case string:
+		return fromIncoming(ctx, v)
+	case []byte:
+		return fromIncoming(ctx, string(v))
+	}
+
+	return ctx
+}
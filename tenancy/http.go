@@ -0,0 +1,46 @@
+package infratenancy
+
+import (
+	"net/http"
+)
+
+// HTTP takes the tenant id from X-Tenant-ID header and stores it in the request context.
+// The header is trusted, so it's only for internal services. Services resolving the tenant
+// from the authenticated principal call NewContext themselves, e.g. with infraauth.HTTPTenant.
+func HTTP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if incoming := r.Header.Get(Header); incoming != "" {
+			r = r.WithContext(fromIncoming(r.Context(), incoming))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Transport sets X-Tenant-ID header of outgoing requests from the request context
+//
+//	client.Transport = infratenancy.Transport(client.Transport)
+func Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &transport{next: next}
+}
+
+type transport struct {
+	next http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tenant := FromContext(req.Context())
+	if tenant == "" || req.Header.Get(Header) != "" {
+		return t.next.RoundTrip(req)
+	}
+
+	// RoundTrip must not modify the request
+	req = req.Clone(req.Context())
+	req.Header.Set(Header, tenant)
+
+	return t.next.RoundTrip(req)
+}
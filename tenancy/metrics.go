@@ -0,0 +1,71 @@
+package infratenancy
+
+import (
+	"context"
+	"sync"
+
+	inframetrics "github.com/pushwoosh/infra/metrics"
+)
+
+const (
+	// MetricLabel is a label name for per-tenant metrics
+	MetricLabel = "tenant"
+
+	// labelNone is a label value for calls without a tenant
+	labelNone = "none"
+
+	defaultMaxMetricTenants = 1000
+)
+
+var metricTenants = struct {
+	mu    sync.RWMutex
+	max   int
+	guard *inframetrics.Guard
+}{
+	max: defaultMaxMetricTenants,
+}
+
+// SetMaxMetricTenants limits the number of distinct tenant label values, 1000 by default
+func SetMaxMetricTenants(n int) {
+	metricTenants.mu.Lock()
+	defer metricTenants.mu.Unlock()
+
+	metricTenants.max = n
+	metricTenants.guard = nil
+}
+
+// MetricLabelValue returns the tenant label value of the context for per-tenant metrics:
+//
+//	requests := prometheus.NewCounterVec(opts, []string{"method", infratenancy.MetricLabel})
+//	requests.WithLabelValues(method, infratenancy.MetricLabelValue(ctx)).Inc()
+//
+// It's "none" without a tenant. Tenants seen after the limit of SetMaxMetricTenants are "other",
+// so a flood of tenants can't blow up metrics cardinality, see inframetrics.Guard.
+func MetricLabelValue(ctx context.Context) string {
+	tenant := FromContext(ctx)
+	if tenant == "" {
+		return labelNone
+	}
+
+	return metricGuard().Values([]string{tenant})[0]
+}
+
+func metricGuard() *inframetrics.Guard {
+	metricTenants.mu.RLock()
+	guard := metricTenants.guard
+	metricTenants.mu.RUnlock()
+	if guard != nil {
+		return guard
+	}
+
+	metricTenants.mu.Lock()
+	defer metricTenants.mu.Unlock()
+
+	if metricTenants.guard == nil {
+		// series of tenants are not deleted, so known tenants are never evicted
+		metricTenants.guard = inframetrics.NewGuard("tenancy", []string{MetricLabel},
+			inframetrics.WithLabelLimit(MetricLabel, metricTenants.max),
+			inframetrics.WithLabelTTL(0))
+	}
+	return metricTenants.guard
+}
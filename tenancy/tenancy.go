@@ -0,0 +1,76 @@
+package infratenancy
+
+import (
+	"context"
+
+	infraerrors "github.com/pushwoosh/infra/errors"
+	infraflags "github.com/pushwoosh/infra/flags"
+	infralog "github.com/pushwoosh/infra/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	// Header is the HTTP header with the tenant id
+	Header = "X-Tenant-ID"
+
+	// MetadataKey is the gRPC metadata key with the tenant id
+	MetadataKey = "x-tenant-id"
+
+	// AMQPHeader is the AMQP message header with the tenant id
+	AMQPHeader = "x-tenant-id"
+
+	// LogField and AttributeKey label logs and spans
+	LogField     = "tenant"
+	AttributeKey = attribute.Key("tenant")
+
+	// maxLength limits incoming ids, longer ones are ignored
+	maxLength = 128
+)
+
+type ctxKeyType string
+
+const ctxKey ctxKeyType = "tenant"
+
+// NewContext stores the tenant id in the context. Logs written with the context get tenant field,
+// errors reported with it get the tenant, feature flags are evaluated for it, and the current span
+// and spans started with the context get tenant attribute, see SpanProcessor.
+// infra http and grpc clients and rabbit producers send it to the next service.
+func NewContext(ctx context.Context, tenant string) context.Context {
+	ctx = context.WithValue(ctx, ctxKey, tenant)
+	ctx = infralog.WithField(ctx, zap.String(LogField, tenant))
+	ctx = infraerrors.WithTenant(ctx, tenant)
+	ctx = infraflags.WithTenant(ctx, tenant)
+
+	trace.SpanFromContext(ctx).SetAttributes(AttributeKey.String(tenant))
+
+	return ctx
+}
+
+// FromContext returns the tenant id of the context. It's empty if not set
+func FromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(ctxKey).(string)
+	return tenant
+}
+
+// fromIncoming returns the context with the incoming tenant id if it's valid
+func fromIncoming(ctx context.Context, incoming string) context.Context {
+	if !valid(incoming) || incoming == FromContext(ctx) {
+		return ctx
+	}
+	return NewContext(ctx, incoming)
+}
+
+// valid accepts printable ASCII ids only, so clients can't break log lines or headers
+func valid(tenant string) bool {
+	if tenant == "" || len(tenant) > maxLength {
+		return false
+	}
+	for i := 0; i < len(tenant); i++ {
+		if tenant[i] < 0x21 || tenant[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package infratenancy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	inframetrics "github.com/pushwoosh/infra/metrics"
+)
+
+func TestHTTP(t *testing.T) {
+	var got string
+	handler := HTTP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = FromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(Header, "ABCDE-12345")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if got != "ABCDE-12345" {
+		t.Fatalf("expected tenant from header, got %q", got)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(Header, "bad tenant\n")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if got != "" {
+		t.Fatalf("invalid tenant must be ignored, got %q", got)
+	}
+}
+
+func TestHeaders(t *testing.T) {
+	ctx := NewContext(context.Background(), "ABCDE-12345")
+
+	headers := map[string]interface{}{"x-type": "user.created"}
+	injected := InjectHeaders(ctx, headers)
+	if _, ok := headers[AMQPHeader]; ok {
+		t.Fatal("headers must not be modified")
+	}
+
+	if got := FromContext(ExtractHeaders(context.Background(), injected)); got != "ABCDE-12345" {
+		t.Fatalf("expected tenant from headers, got %q", got)
+	}
+}
+
+func TestMetricLabelValue(t *testing.T) {
+	SetMaxMetricTenants(1)
+	defer SetMaxMetricTenants(defaultMaxMetricTenants)
+
+	if v := MetricLabelValue(context.Background()); v != labelNone {
+		t.Fatalf("expected %q without tenant, got %q", labelNone, v)
+	}
+	if v := MetricLabelValue(NewContext(context.Background(), "first")); v != "first" {
+		t.Fatalf("expected tenant label, got %q", v)
+	}
+	if v := MetricLabelValue(NewContext(context.Background(), "second")); v != inframetrics.OverflowLabelValue {
+		t.Fatalf("expected %q over the limit, got %q", inframetrics.OverflowLabelValue, v)
+	}
+}
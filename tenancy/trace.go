@@ -0,0 +1,27 @@
+package infratenancy
+
+import (
+	"context"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// SpanProcessor sets tenant attribute of spans started with a tenant context.
+// infratracing registers it in the tracer provider.
+func SpanProcessor() sdktrace.SpanProcessor {
+	return spanProcessor{}
+}
+
+type spanProcessor struct{}
+
+func (spanProcessor) OnStart(ctx context.Context, span sdktrace.ReadWriteSpan) {
+	if tenant := FromContext(ctx); tenant != "" {
+		span.SetAttributes(AttributeKey.String(tenant))
+	}
+}
+
+func (spanProcessor) OnEnd(sdktrace.ReadOnlySpan) {}
+
+func (spanProcessor) Shutdown(context.Context) error { return nil }
+
+func (spanProcessor) ForceFlush(context.Context) error { return nil }
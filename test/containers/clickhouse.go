@@ -0,0 +1,68 @@
+//go:build integration
+
+package infratestcontainers
+
+import (
+	"context"
+	"testing"
+
+	infraclickhouse "github.com/pushwoosh/infra/clickhouse"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+const (
+	clickHouseImage    = "clickhouse/clickhouse-server:23.8-alpine"
+	clickHouseDatabase = "test"
+	clickHouseUser     = "test"
+	clickHousePassword = "test"
+)
+
+// StartClickHouse starts ClickHouse with the "test" database, user and password.
+// The address is the native protocol port.
+func StartClickHouse(ctx context.Context, opts ...Option) (*Container, *infraclickhouse.ConnectionConfig, error) {
+	container, err := start(ctx, testcontainers.ContainerRequest{
+		Image:        clickHouseImage,
+		ExposedPorts: []string{"9000/tcp", "8123/tcp"},
+		Env: map[string]string{
+			"CLICKHOUSE_DB":       clickHouseDatabase,
+			"CLICKHOUSE_USER":     clickHouseUser,
+			"CLICKHOUSE_PASSWORD": clickHousePassword,
+		},
+	}, opts,
+		wait.ForHTTP("/ping").WithPort("8123/tcp"),
+		wait.ForListeningPort("9000/tcp"),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	address, err := container.Address(ctx, "9000/tcp")
+	if err != nil {
+		_ = container.Terminate(context.Background())
+		return nil, nil, err
+	}
+
+	return container, &infraclickhouse.ConnectionConfig{
+		Address: address,
+		Credentials: infraclickhouse.Credentials{
+			Database: clickHouseDatabase,
+			Username: clickHouseUser,
+			Password: clickHousePassword,
+		},
+	}, nil
+}
+
+// ClickHouse starts ClickHouse for the test and terminates it on cleanup.
+// The test is skipped in short mode or without docker.
+func ClickHouse(t testing.TB, opts ...Option) *infraclickhouse.ConnectionConfig {
+	t.Helper()
+
+	var cfg *infraclickhouse.ConnectionConfig
+	setup(t, "clickhouse", func(ctx context.Context) (c *Container, err error) {
+		c, cfg, err = StartClickHouse(ctx, opts...)
+		return c, err
+	})
+
+	return cfg
+}
@@ -0,0 +1,166 @@
+//go:build integration
+
+// Package infratestcontainers starts dependencies in docker for integration tests.
+// It is built with the integration tag only, so docker clients are not compiled into regular builds and tests:
+//
+//	go test -tags integration ./...
+package infratestcontainers
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/pkg/errors"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+const defaultStartupTimeout = 2 * time.Minute
+
+// Container is a started docker container of a dependency
+type Container struct {
+	container testcontainers.Container
+	host      string
+}
+
+// Address returns "host:port" of the container port mapped to the host, e.g. Address("5672/tcp")
+func (c *Container) Address(ctx context.Context, port string) (string, error) {
+	mapped, err := c.container.MappedPort(ctx, nat.Port(port))
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to get mapped port %s", port)
+	}
+
+	return net.JoinHostPort(c.host, mapped.Port()), nil
+}
+
+// Terminate stops and removes the container
+func (c *Container) Terminate(ctx context.Context) error {
+	return c.container.Terminate(ctx)
+}
+
+type Option interface {
+	apply(o *options)
+}
+
+type options struct {
+	image          string
+	env            map[string]string
+	startupTimeout time.Duration
+}
+
+type optionImage string
+
+func (opt optionImage) apply(o *options) {
+	o.image = string(opt)
+}
+
+// WithImage overrides the default image of the dependency, e.g. "rabbitmq:3.12-management"
+func WithImage(image string) Option {
+	return optionImage(image)
+}
+
+type optionEnv struct {
+	key, value string
+}
+
+func (opt optionEnv) apply(o *options) {
+	o.env[opt.key] = opt.value
+}
+
+// WithEnv sets an environment variable of the container
+func WithEnv(key, value string) Option {
+	return optionEnv{key: key, value: value}
+}
+
+type optionStartupTimeout time.Duration
+
+func (opt optionStartupTimeout) apply(o *options) {
+	o.startupTimeout = time.Duration(opt)
+}
+
+// WithStartupTimeout sets how long to wait for readiness of the container. optional, default: 2m
+func WithStartupTimeout(timeout time.Duration) Option {
+	return optionStartupTimeout(timeout)
+}
+
+// start runs the container and waits for its readiness. Options override defaults of the dependency
+func start(ctx context.Context, req testcontainers.ContainerRequest, opts []Option, waitFor ...wait.Strategy) (*Container, error) {
+	o := &options{
+		image:          req.Image,
+		env:            make(map[string]string),
+		startupTimeout: defaultStartupTimeout,
+	}
+	for k, v := range req.Env {
+		o.env[k] = v
+	}
+	for _, opt := range opts {
+		opt.apply(o)
+	}
+
+	req.Image = o.image
+	req.Env = o.env
+	req.WaitingFor = wait.ForAll(waitFor...).WithDeadline(o.startupTimeout)
+
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		if container != nil {
+			_ = container.Terminate(context.Background())
+		}
+		return nil, errors.Wrapf(err, "unable to start %s", req.Image)
+	}
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		_ = container.Terminate(context.Background())
+		return nil, errors.Wrapf(err, "unable to get host of %s", req.Image)
+	}
+
+	return &Container{container: container, host: host}, nil
+}
+
+// SkipIfNoDocker skips the test if docker is not available, e.g. in unit test runs on CI without docker
+func SkipIfNoDocker(t testing.TB) {
+	t.Helper()
+
+	provider, err := testcontainers.ProviderDocker.GetProvider()
+	if err != nil {
+		t.Skipf("docker is not available: %s", err)
+	}
+	defer func() {
+		_ = provider.Close()
+	}()
+
+	if err = provider.Health(context.Background()); err != nil {
+		t.Skipf("docker is not available: %s", err)
+	}
+}
+
+// setup starts a container for the test and terminates it on cleanup.
+// The test is skipped in short mode or without docker.
+func setup(t testing.TB, name string, startFn func(ctx context.Context) (*Container, error)) *Container {
+	t.Helper()
+
+	if testing.Short() {
+		t.Skipf("%s integration test is skipped in short mode", name)
+	}
+	SkipIfNoDocker(t)
+
+	container, err := startFn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := container.Terminate(context.Background()); err != nil {
+			t.Errorf("unable to terminate %s: %s", name, err)
+		}
+	})
+
+	return container
+}
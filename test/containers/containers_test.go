@@ -0,0 +1,25 @@
+//go:build integration
+
+package infratestcontainers
+
+import (
+	"context"
+	"testing"
+
+	infraredis "github.com/pushwoosh/infra/redis"
+)
+
+func TestRedis(t *testing.T) {
+	cfg := Redis(t)
+
+	cont := infraredis.NewContainer()
+	defer cont.Close()
+
+	if err := cont.Connect("test", cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cont.Check(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
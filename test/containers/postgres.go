@@ -0,0 +1,68 @@
+//go:build integration
+
+package infratestcontainers
+
+import (
+	"context"
+	"testing"
+
+	infrapostgres "github.com/pushwoosh/infra/postgres"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+const (
+	postgresImage    = "postgres:16-alpine"
+	postgresDatabase = "test"
+	postgresUser     = "test"
+	postgresPassword = "test"
+)
+
+// StartPostgres starts Postgres with the "test" database, user and password
+func StartPostgres(ctx context.Context, opts ...Option) (*Container, *infrapostgres.ConnectionConfig, error) {
+	container, err := start(ctx, testcontainers.ContainerRequest{
+		Image:        postgresImage,
+		ExposedPorts: []string{"5432/tcp"},
+		Env: map[string]string{
+			"POSTGRES_DB":       postgresDatabase,
+			"POSTGRES_USER":     postgresUser,
+			"POSTGRES_PASSWORD": postgresPassword,
+		},
+	}, opts,
+		// the server restarts once after init scripts
+		wait.ForLog("database system is ready to accept connections").WithOccurrence(2),
+		wait.ForListeningPort("5432/tcp"),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	address, err := container.Address(ctx, "5432/tcp")
+	if err != nil {
+		_ = container.Terminate(context.Background())
+		return nil, nil, err
+	}
+
+	return container, &infrapostgres.ConnectionConfig{
+		Address: address,
+		Credentials: infrapostgres.Credentials{
+			Database: postgresDatabase,
+			Username: postgresUser,
+			Password: postgresPassword,
+		},
+	}, nil
+}
+
+// Postgres starts Postgres for the test and terminates it on cleanup.
+// The test is skipped in short mode or without docker.
+func Postgres(t testing.TB, opts ...Option) *infrapostgres.ConnectionConfig {
+	t.Helper()
+
+	var cfg *infrapostgres.ConnectionConfig
+	setup(t, "postgres", func(ctx context.Context) (c *Container, err error) {
+		c, cfg, err = StartPostgres(ctx, opts...)
+		return c, err
+	})
+
+	return cfg
+}
@@ -0,0 +1,59 @@
+//go:build integration
+
+package infratestcontainers
+
+import (
+	"context"
+	"testing"
+
+	infrarabbit "github.com/pushwoosh/infra/rabbit"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+const rabbitMQImage = "rabbitmq:3.12-management-alpine"
+
+// StartRabbitMQ starts RabbitMQ with default credentials and vhost, e.g. in TestMain of a service:
+//
+//	container, cfg, err := infratestcontainers.StartRabbitMQ(ctx)
+//	defer container.Terminate(ctx)
+//	rabbitContainer.Connect("default", cfg)
+func StartRabbitMQ(ctx context.Context, opts ...Option) (*Container, *infrarabbit.ConnectionConfig, error) {
+	container, err := start(ctx, testcontainers.ContainerRequest{
+		Image:        rabbitMQImage,
+		ExposedPorts: []string{"5672/tcp", "15672/tcp"},
+	}, opts,
+		wait.ForLog("Server startup complete"),
+		wait.ForListeningPort("5672/tcp"),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	address, err := container.Address(ctx, "5672/tcp")
+	if err != nil {
+		_ = container.Terminate(context.Background())
+		return nil, nil, err
+	}
+
+	return container, &infrarabbit.ConnectionConfig{
+		Address:  address,
+		Username: "guest",
+		Password: "guest",
+		Vhost:    "/",
+	}, nil
+}
+
+// RabbitMQ starts RabbitMQ for the test and terminates it on cleanup.
+// The test is skipped in short mode or without docker.
+func RabbitMQ(t testing.TB, opts ...Option) *infrarabbit.ConnectionConfig {
+	t.Helper()
+
+	var cfg *infrarabbit.ConnectionConfig
+	setup(t, "rabbitmq", func(ctx context.Context) (c *Container, err error) {
+		c, cfg, err = StartRabbitMQ(ctx, opts...)
+		return c, err
+	})
+
+	return cfg
+}
@@ -0,0 +1,52 @@
+//go:build integration
+
+package infratestcontainers
+
+import (
+	"context"
+	"testing"
+
+	infraredis "github.com/pushwoosh/infra/redis"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+const redisImage = "redis:7-alpine"
+
+// StartRedis starts a single node Redis without authentication
+func StartRedis(ctx context.Context, opts ...Option) (*Container, *infraredis.ConnectionConfig, error) {
+	container, err := start(ctx, testcontainers.ContainerRequest{
+		Image:        redisImage,
+		ExposedPorts: []string{"6379/tcp"},
+	}, opts,
+		wait.ForLog("Ready to accept connections"),
+		wait.ForListeningPort("6379/tcp"),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	address, err := container.Address(ctx, "6379/tcp")
+	if err != nil {
+		_ = container.Terminate(context.Background())
+		return nil, nil, err
+	}
+
+	return container, &infraredis.ConnectionConfig{
+		Address: address,
+	}, nil
+}
+
+// Redis starts Redis for the test and terminates it on cleanup.
+// The test is skipped in short mode or without docker.
+func Redis(t testing.TB, opts ...Option) *infraredis.ConnectionConfig {
+	t.Helper()
+
+	var cfg *infraredis.ConnectionConfig
+	setup(t, "redis", func(ctx context.Context) (c *Container, err error) {
+		c, cfg, err = StartRedis(ctx, opts...)
+		return c, err
+	})
+
+	return cfg
+}
@@ -0,0 +1,90 @@
+package infratestleak
+
+import (
+	"testing"
+
+	"go.uber.org/goleak"
+)
+
+// infraBackground are process wide goroutines of infra packages and their dependencies. They live
+// longer than a test by design: shared rabbit connections and detached close calls.
+var infraBackground = []string{
+	"github.com/pushwoosh/infra/rabbit.(*connManager).CloseConnection.func1",
+	"github.com/pushwoosh/infra/rabbit.(*connManager).CloseConsumerChannel.func1",
+	"github.com/pushwoosh/infra/rabbit.readAllErrors",
+	"github.com/pushwoosh/infra/rabbit.collectMetrics",
+	"github.com/rabbitmq/amqp091-go.(*Connection).heartbeater",
+	"github.com/rabbitmq/amqp091-go.(*Connection).reader",
+	"go.opencensus.io/stats/view.(*worker).start",
+	"go.opentelemetry.io/otel/sdk/trace.(*batchSpanProcessor).processQueue",
+	"go.opentelemetry.io/otel/sdk/metric.(*PeriodicReader).run",
+}
+
+type Option interface {
+	apply(o *options)
+}
+
+type options struct {
+	ignore []string
+}
+
+type optionIgnore []string
+
+func (opt optionIgnore) apply(o *options) {
+	o.ignore = append(o.ignore, opt...)
+}
+
+// Ignore skips goroutines having any of the functions in their stacks,
+// e.g. "github.com/pushwoosh/sender/cache.(*Cache).janitor"
+func Ignore(functions ...string) Option {
+	return optionIgnore(functions)
+}
+
+// Check fails the test if goroutines started during the test are still running when it ends and about a second after,
+// e.g. consumers or pools that were not closed. Goroutines running before Check are ignored,
+// as well as known infra background goroutines:
+//
+//	func TestConsumer(t *testing.T) {
+//		infratestleak.Check(t)
+//		...
+//	}
+//
+// Check must not be used in parallel tests, goroutines of other tests are reported as leaks.
+func Check(t testing.TB, opts ...Option) {
+	t.Helper()
+
+	goleakOpts := goleakOptions(opts, goleak.IgnoreCurrent())
+
+	t.Cleanup(func() {
+		if err := goleak.Find(goleakOpts...); err != nil {
+			t.Errorf("goroutine leak: %s", err)
+		}
+	})
+}
+
+// Main runs tests of a package and fails if goroutines are left running after all tests,
+// except known infra background goroutines:
+//
+//	func TestMain(m *testing.M) {
+//		infratestleak.Main(m)
+//	}
+func Main(m *testing.M, opts ...Option) {
+	goleak.VerifyTestMain(m, goleakOptions(opts)...)
+}
+
+func goleakOptions(opts []Option, extra ...goleak.Option) []goleak.Option {
+	o := &options{}
+	for _, opt := range opts {
+		opt.apply(o)
+	}
+
+	res := extra
+	for _, fn := range infraBackground {
+		res = append(res, goleak.IgnoreAnyFunction(fn))
+	}
+	for _, fn := range o.ignore {
+		res = append(res, goleak.IgnoreAnyFunction(fn))
+	}
+
+	return res
+}
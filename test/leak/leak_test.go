@@ -0,0 +1,50 @@
+package infratestleak
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+)
+
+func TestFind(t *testing.T) {
+	opts := goleakOptions(nil, goleak.IgnoreCurrent())
+
+	stop := make(chan struct{})
+	go func() {
+		<-stop
+	}()
+
+	if err := goleak.Find(opts...); err == nil {
+		t.Fatal("running goroutine must be reported")
+	}
+
+	close(stop)
+	if err := goleak.Find(opts...); err != nil {
+		t.Fatalf("finished goroutine must not be reported: %s", err)
+	}
+
+	ignored := make(chan struct{})
+	defer close(ignored)
+	go waitIgnored(ignored)
+
+	opts = goleakOptions([]Option{Ignore("github.com/pushwoosh/infra/test/leak.waitIgnored")}, goleak.IgnoreCurrent())
+	if err := goleak.Find(opts...); err != nil {
+		t.Fatalf("ignored goroutine must not be reported: %s", err)
+	}
+}
+
+func waitIgnored(ch chan struct{}) {
+	<-ch
+}
+
+func TestCheck(t *testing.T) {
+	Check(t)
+
+	done := make(chan struct{})
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(done)
+	}()
+	<-done
+}
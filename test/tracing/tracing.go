@@ -0,0 +1,67 @@
+// Package infratesttracing records spans of infra packages in tests:
+//
+//	recorder := infratesttracing.Record(t)
+//	_ = client.Get(ctx, "key")
+//	span := infratesttracing.Span(t, recorder, "redis get")
+//	infratesttracing.RequireAttribute(t, span, "db.system", "redis")
+package infratesttracing
+
+import (
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// Record installs a global tracer provider recording spans and the W3C trace context propagator until the test ends.
+// Tests using it must not run in parallel, the provider is process wide
+func Record(t testing.TB) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+
+	return recorder
+}
+
+// Span returns the first ended span with the name, the test fails if there is none
+func Span(t testing.TB, recorder *tracetest.SpanRecorder, name string) sdktrace.ReadOnlySpan {
+	t.Helper()
+
+	var names []string
+	for _, span := range recorder.Ended() {
+		if span.Name() == name {
+			return span
+		}
+		names = append(names, span.Name())
+	}
+
+	t.Fatalf("span %q is not recorded, got %q", name, names)
+	return nil
+}
+
+// RequireAttribute fails the test if the span has no attribute key with the value.
+// Integer attributes are int64, as attribute.Value.AsInterface returns them
+func RequireAttribute(t testing.TB, span sdktrace.ReadOnlySpan, key string, value any) {
+	t.Helper()
+
+	for _, kv := range span.Attributes() {
+		if string(kv.Key) != key {
+			continue
+		}
+		if kv.Value.AsInterface() != value {
+			t.Fatalf("span %q: expected %s=%v, got %v", span.Name(), key, value, kv.Value.AsInterface())
+		}
+		return
+	}
+
+	t.Fatalf("span %q has no attribute %s", span.Name(), key)
+}
@@ -0,0 +1,163 @@
+package infratls
+
+import (
+	"crypto/tls"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	infraconfig "github.com/pushwoosh/infra/config"
+)
+
+const defaultReloadInterval = time.Minute
+
+func init() {
+	// certificates of servers are always verified in production
+	infraconfig.RegisterProfileCheck(infraconfig.ProfileProd, func(c *Config) error {
+		if c.InsecureSkipVerify {
+			return errors.New("tls: insecure_skip_verify is not allowed")
+		}
+		return nil
+	})
+}
+
+// Config is a TLS config shared by servers and clients. TLS is disabled if the config is empty.
+// Certificates are read from files or from values, values may be secret references,
+// see infraconfig.ResolveSecret.
+type Config struct {
+	// Name used in metrics and logs. optional, default: cert file name
+	Name string `mapstructure:"name"`
+
+	// PEM encoded certificate and key: server certificate of servers, client certificate of clients
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+	Cert     string `mapstructure:"cert"`
+	Key      string `mapstructure:"key"`
+
+	// PEM encoded CA certificates. Clients use the system pool if empty
+	CAFile string `mapstructure:"ca_file"`
+	CA     string `mapstructure:"ca"`
+
+	// Server name used by clients to verify the hostname. optional, default: the host clients connect to,
+	// mandatory for hosts dialed by IP addresses unless the client passes the address to ClientConfigForHost
+	ServerName string `mapstructure:"server_name"`
+
+	// Disables server certificate verification of clients
+	InsecureSkipVerify bool `mapstructure:"insecure_skip_verify"`
+
+	// RequireClientCert makes servers require client certificates issued by CA, see mutual TLS below. optional
+	RequireClientCert bool `mapstructure:"require_client_cert"`
+
+	// Mutual TLS checks of peers: client certificates by servers and server certificates by clients.
+	// Peer must have one of the allowed SANs: DNS names, IPs, emails or URIs, "*.example.com" matches one label. optional
+	AllowedSANs []string `mapstructure:"allowed_sans"`
+	// Peer must have one of the allowed SPIFFE IDs, "spiffe://cluster.local/ns/billing/*" matches the prefix. optional
+	AllowedSPIFFEIDs []string `mapstructure:"allowed_spiffe_ids"`
+	// Peer chain must contain one of CA certificates with these hex encoded SHA-256 fingerprints. optional
+	PinnedCAs []string `mapstructure:"pinned_cas"`
+
+	// Minimal TLS version: "1.2" or "1.3". optional, default: 1.2
+	MinVersion string `mapstructure:"min_version"`
+
+	// How often certificates are checked for changes. optional, default: 1m
+	ReloadInterval time.Duration `mapstructure:"reload_interval"`
+
+	loaderOnce sync.Once
+	loader     *Loader
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if c.CertFile != "" && c.Cert != "" {
+		return errors.New("cert_file and cert are mutually exclusive")
+	}
+	if c.KeyFile != "" && c.Key != "" {
+		return errors.New("key_file and key are mutually exclusive")
+	}
+	if c.CAFile != "" && c.CA != "" {
+		return errors.New("ca_file and ca are mutually exclusive")
+	}
+
+	if c.hasCert() != (c.KeyFile != "" || c.Key != "") {
+		return errors.New("certificate and key must be set together")
+	}
+
+	if c.RequireClientCert && !c.hasCA() {
+		return errors.New("ca is mandatory to verify client certificates")
+	}
+
+	for _, id := range c.AllowedSPIFFEIDs {
+		if !strings.HasPrefix(id, spiffeScheme) {
+			return errors.Errorf("invalid spiffe id %q", id)
+		}
+	}
+
+	for _, pin := range c.PinnedCAs {
+		if _, err := parseFingerprint(pin); err != nil {
+			return errors.Wrapf(err, "invalid pinned ca %q", pin)
+		}
+	}
+
+	if _, err := c.minVersion(); err != nil {
+		return err
+	}
+
+	if c.ReloadInterval < 0 {
+		return errors.New("reload_interval should not be negative")
+	}
+
+	return nil
+}
+
+// Loader returns the loader of the config. The same loader is returned for every call,
+// so servers and clients sharing a config share reloading too.
+func (c *Config) Loader() *Loader {
+	c.loaderOnce.Do(func() {
+		c.loader = NewLoader(c)
+	})
+	return c.loader
+}
+
+func (c *Config) GetName() string {
+	switch {
+	case c.Name != "":
+		return c.Name
+	case c.CertFile != "":
+		return filepath.Base(c.CertFile)
+	case c.CAFile != "":
+		return filepath.Base(c.CAFile)
+	default:
+		return "default"
+	}
+}
+
+func (c *Config) GetReloadInterval() time.Duration {
+	if c.ReloadInterval == 0 {
+		return defaultReloadInterval
+	}
+	return c.ReloadInterval
+}
+
+func (c *Config) hasCert() bool {
+	return c.CertFile != "" || c.Cert != ""
+}
+
+func (c *Config) hasCA() bool {
+	return c.CAFile != "" || c.CA != ""
+}
+
+func (c *Config) minVersion() (uint16, error) {
+	switch c.MinVersion {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, errors.Errorf("unsupported min_version %q", c.MinVersion)
+	}
+}
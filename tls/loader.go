@@ -0,0 +1,263 @@
+package infratls
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	infraconfig "github.com/pushwoosh/infra/config"
+	infralog "github.com/pushwoosh/infra/log"
+	"go.uber.org/zap"
+)
+
+// Loader keeps the certificate and CA pool of a config and swaps them atomically when they change:
+//
+//	loader := cfg.TLS.Loader()
+//	if err := loader.Reload(); err != nil { ... } // fail fast on startup
+//	server.TLSConfig = loader.ServerConfig()
+//
+// Changes are checked at most once per reload interval during handshakes, so nothing runs in background.
+// A certificate that fails to load is logged and the current one is kept.
+type Loader struct {
+	cfg *Config
+
+	current  atomic.Pointer[material]
+	checked  atomic.Int64 // unix nanoseconds of the last check
+	reloadMu sync.Mutex
+
+	verifiersMu sync.RWMutex
+	verifiers   []PeerVerifier
+}
+
+type material struct {
+	hash [sha256.Size]byte
+	cert *tls.Certificate
+	pool *x509.CertPool
+}
+
+// NewLoader creates a loader, certificates are loaded on the first Reload or handshake.
+// Use Config.Loader to share a loader of the config.
+func NewLoader(cfg *Config) *Loader {
+	initMetrics()
+
+	return &Loader{cfg: cfg}
+}
+
+// Reload reads certificates and swaps them if they have changed
+func (l *Loader) Reload() error {
+	l.reloadMu.Lock()
+	defer l.reloadMu.Unlock()
+
+	return l.reload()
+}
+
+func (l *Loader) reload() error {
+	l.checked.Store(time.Now().UnixNano())
+
+	err := l.load()
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	metrics.ReloadsCounter.WithLabelValues(l.cfg.GetName(), result).Inc()
+
+	return err
+}
+
+func (l *Loader) load() error {
+	certPEM, err := read(l.cfg.CertFile, l.cfg.Cert)
+	if err != nil {
+		return errors.Wrap(err, "unable to read certificate")
+	}
+	keyPEM, err := read(l.cfg.KeyFile, l.cfg.Key)
+	if err != nil {
+		return errors.Wrap(err, "unable to read key")
+	}
+	caPEM, err := read(l.cfg.CAFile, l.cfg.CA)
+	if err != nil {
+		return errors.Wrap(err, "unable to read CA")
+	}
+
+	hash := sha256.Sum256(bytes.Join([][]byte{certPEM, keyPEM, caPEM}, []byte{0}))
+	if cur := l.current.Load(); cur != nil && cur.hash == hash {
+		return nil
+	}
+
+	m := &material{hash: hash}
+
+	if len(certPEM) > 0 {
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return errors.Wrap(err, "invalid certificate")
+		}
+		if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return errors.Wrap(err, "invalid certificate")
+		}
+		m.cert = &cert
+
+		metrics.ExpiryGauge.WithLabelValues(l.cfg.GetName()).Set(float64(cert.Leaf.NotAfter.Unix()))
+	}
+
+	if len(caPEM) > 0 {
+		m.pool = x509.NewCertPool()
+		if !m.pool.AppendCertsFromPEM(caPEM) {
+			return errors.New("no certificates found in CA")
+		}
+	}
+
+	if l.current.Swap(m) != nil {
+		infralog.Info("tls certificates reloaded", zap.String("name", l.cfg.GetName()))
+	}
+
+	return nil
+}
+
+// maybeReload reloads certificates if the reload interval has passed. Concurrent handshakes don't wait for it.
+func (l *Loader) maybeReload() *material {
+	if time.Since(time.Unix(0, l.checked.Load())) < l.cfg.GetReloadInterval() && l.current.Load() != nil {
+		return l.current.Load()
+	}
+
+	if l.current.Load() == nil {
+		l.reloadMu.Lock()
+	} else if !l.reloadMu.TryLock() {
+		return l.current.Load()
+	}
+	defer l.reloadMu.Unlock()
+
+	if err := l.reload(); err != nil {
+		infralog.Error("unable to reload tls certificates", zap.String("name", l.cfg.GetName()), zap.Error(err))
+	}
+
+	return l.current.Load()
+}
+
+// Certificate returns the current certificate, nil if it's not configured or not loaded
+func (l *Loader) Certificate() *tls.Certificate {
+	if m := l.maybeReload(); m != nil {
+		return m.cert
+	}
+	return nil
+}
+
+// CAPool returns the current CA pool, nil if it's not configured or not loaded
+func (l *Loader) CAPool() *x509.CertPool {
+	if m := l.maybeReload(); m != nil {
+		return m.pool
+	}
+	return nil
+}
+
+// ServerConfig returns a server config serving the current certificate.
+// Client certificates are required and verified with the current CA pool and peer checks if RequireClientCert is set.
+func (l *Loader) ServerConfig() *tls.Config {
+	minVersion, _ := l.cfg.minVersion()
+
+	ret := &tls.Config{
+		MinVersion: minVersion,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			cert := l.Certificate()
+			if cert == nil {
+				return nil, errors.New("tls certificate is not loaded")
+			}
+			return cert, nil
+		},
+	}
+
+	if l.cfg.RequireClientCert {
+		// ClientCAs can't be swapped, the chain is verified with the current pool instead
+		ret.ClientAuth = tls.RequireAnyClientCert
+		ret.VerifyConnection = l.verifyClient
+	}
+
+	return ret
+}
+
+// ClientConfig returns a client config presenting the current certificate, if any,
+// and verifying servers with the current CA pool and peer checks.
+// Server certificates are verified for ServerName or, if it's empty, for the SNI host name the connection is dialed with.
+// Connections dialed by an IP address fail without ServerName, use ClientConfigForHost for them.
+func (l *Loader) ClientConfig() *tls.Config {
+	return l.ClientConfigForHost("")
+}
+
+// ClientConfigForHost returns a client config verifying server certificates for the host, a DNS name or an IP address,
+// unless ServerName is configured
+func (l *Loader) ClientConfigForHost(host string) *tls.Config {
+	minVersion, _ := l.cfg.minVersion()
+
+	serverName := l.cfg.ServerName
+	if serverName == "" {
+		serverName = strings.Trim(host, "[]")
+	}
+
+	ret := &tls.Config{
+		MinVersion:         minVersion,
+		ServerName:         serverName,
+		InsecureSkipVerify: l.cfg.InsecureSkipVerify, //nolint:gosec
+	}
+
+	if l.cfg.hasCert() {
+		ret.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			if cert := l.Certificate(); cert != nil {
+				return cert, nil
+			}
+			// no certificate is sent, the server decides
+			return &tls.Certificate{}, nil
+		}
+	}
+
+	if l.cfg.hasCA() && !l.cfg.InsecureSkipVerify {
+		// RootCAs can't be swapped, the chain is verified with the current pool instead
+		ret.InsecureSkipVerify = true //nolint:gosec
+	}
+	ret.VerifyConnection = func(cs tls.ConnectionState) error {
+		return l.verifyServer(cs, serverName)
+	}
+
+	return ret
+}
+
+func verifyChain(certs []*x509.Certificate, roots *x509.CertPool, dnsName string, usage x509.ExtKeyUsage) ([][]*x509.Certificate, error) {
+	if len(certs) == 0 {
+		return nil, errors.New("no peer certificates")
+	}
+	if roots == nil {
+		return nil, errors.New("CA is not loaded")
+	}
+
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		DNSName:       dnsName,
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{usage},
+	}
+	for _, cert := range certs[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+
+	return certs[0].Verify(opts)
+}
+
+// read returns content of the file or the resolved value
+func read(file, value string) ([]byte, error) {
+	if file != "" {
+		return os.ReadFile(file)
+	}
+	if value == "" {
+		return nil, nil
+	}
+
+	resolved, err := infraconfig.ResolveSecret(value)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(resolved), nil
+}
@@ -0,0 +1,258 @@
+package infratls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns PEM encoded certificate and key for localhost with optional URI SANs
+func (ca *testCA) issue(t *testing.T, serial int64, uris ...string) ([]byte, []byte) {
+	return ca.issueFor(t, serial, "localhost", uris...)
+}
+
+// issueFor returns PEM encoded certificate and key for a DNS name or an IP address with optional URI SANs
+func (ca *testCA) issueFor(t *testing.T, serial int64, host string, uris ...string) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		tmpl.IPAddresses = []net.IP{ip}
+	} else {
+		tmpl.DNSNames = []string{host}
+	}
+	for _, uri := range uris {
+		u, err := url.Parse(uri)
+		require.NoError(t, err)
+		tmpl.URIs = append(tmpl.URIs, u)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// handshake connects client to server, the server writes one byte after a successful handshake,
+// so rejections of client certificates are returned too
+func handshake(t *testing.T, server, client *tls.Config) (*x509.Certificate, error) {
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", server)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = conn.Write([]byte{1})
+	}()
+
+	conn, err := tls.Dial("tcp", listener.Addr().String(), client)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err = conn.Read(make([]byte, 1)); err != nil {
+		return nil, err
+	}
+	return conn.ConnectionState().PeerCertificates[0], nil
+}
+
+func TestLoader(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+
+	certPEM, keyPEM := ca.issue(t, 2)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tls.crt"), certPEM, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tls.key"), keyPEM, 0o600))
+
+	serverCfg := &Config{
+		CertFile: filepath.Join(dir, "tls.crt"),
+		KeyFile:  filepath.Join(dir, "tls.key"),
+	}
+	require.NoError(t, serverCfg.Validate())
+	server := serverCfg.Loader()
+	require.Same(t, server, serverCfg.Loader())
+	require.NoError(t, server.Reload())
+
+	clientCfg := &Config{CA: string(ca.pem), ServerName: "localhost"}
+	require.NoError(t, clientCfg.Validate())
+	client := clientCfg.Loader()
+
+	peer, err := handshake(t, server.ServerConfig(), client.ClientConfig())
+	require.NoError(t, err)
+	require.Equal(t, int64(2), peer.SerialNumber.Int64())
+
+	// rotated certificate is served after reload
+	certPEM, keyPEM = ca.issue(t, 3)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tls.crt"), certPEM, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tls.key"), keyPEM, 0o600))
+	require.NoError(t, server.Reload())
+
+	peer, err = handshake(t, server.ServerConfig(), client.ClientConfig())
+	require.NoError(t, err)
+	require.Equal(t, int64(3), peer.SerialNumber.Int64())
+
+	// broken certificate is rejected, the current one is kept
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tls.crt"), []byte("garbage"), 0o600))
+	require.Error(t, server.Reload())
+	require.Equal(t, int64(3), server.Certificate().Leaf.SerialNumber.Int64())
+
+	// unknown CA is not trusted
+	other := (&Config{CA: string(newTestCA(t).pem), ServerName: "localhost"}).Loader()
+	_, err = handshake(t, server.ServerConfig(), other.ClientConfig())
+	require.Error(t, err)
+}
+
+func TestClientConfig_serverName(t *testing.T) {
+	ca := newTestCA(t)
+
+	serve := func(host string) *tls.Config {
+		certPEM, keyPEM := ca.issueFor(t, 2, host)
+		server := (&Config{Cert: string(certPEM), Key: string(keyPEM)}).Loader()
+		require.NoError(t, server.Reload())
+		return server.ServerConfig()
+	}
+	client := func(serverName string) *Loader {
+		loader := (&Config{CA: string(ca.pem), ServerName: serverName}).Loader()
+		require.NoError(t, loader.Reload())
+		return loader
+	}
+
+	// a valid certificate of another host is rejected
+	_, err := handshake(t, serve("other.example.com"), client("localhost").ClientConfig())
+	require.Error(t, err)
+
+	// dialing an IP address without a server name fails closed
+	_, err = handshake(t, serve("localhost"), client("").ClientConfig())
+	require.ErrorContains(t, err, "server name is unknown")
+
+	// the dial host is matched with IP SANs
+	_, err = handshake(t, serve("localhost"), client("").ClientConfigForHost("127.0.0.1"))
+	require.Error(t, err)
+	_, err = handshake(t, serve("127.0.0.1"), client("").ClientConfigForHost("127.0.0.1"))
+	require.NoError(t, err)
+
+	// the configured server name takes precedence over the dial host
+	_, err = handshake(t, serve("127.0.0.1"), client("localhost").ClientConfigForHost("127.0.0.1"))
+	require.Error(t, err)
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca := newTestCA(t)
+	serverCert, serverKey := ca.issue(t, 2)
+
+	server := (&Config{
+		Cert:              string(serverCert),
+		Key:               string(serverKey),
+		CA:                string(ca.pem),
+		RequireClientCert: true,
+		AllowedSPIFFEIDs:  []string{"spiffe://cluster.local/ns/billing/*"},
+	}).Loader()
+	require.NoError(t, server.Reload())
+
+	client := func(uri string, pins ...string) *Loader {
+		cert, key := ca.issue(t, 3, uri)
+		return (&Config{
+			Cert:        string(cert),
+			Key:         string(key),
+			CA:          string(ca.pem),
+			ServerName:  "localhost",
+			AllowedSANs: []string{"*.svc", "localhost"},
+			PinnedCAs:   pins,
+		}).Loader()
+	}
+
+	_, err := handshake(t, server.ServerConfig(), client("spiffe://cluster.local/ns/billing/sa/api").ClientConfig())
+	require.NoError(t, err)
+
+	_, err = handshake(t, server.ServerConfig(), client("spiffe://cluster.local/ns/push/sa/api").ClientConfig())
+	require.Error(t, err)
+
+	fingerprint := sha256.Sum256(ca.cert.Raw)
+	_, err = handshake(t, server.ServerConfig(), client("spiffe://cluster.local/ns/billing/sa/api", hex.EncodeToString(fingerprint[:])).ClientConfig())
+	require.NoError(t, err)
+
+	_, err = handshake(t, server.ServerConfig(), client("spiffe://cluster.local/ns/billing/sa/api", strings.Repeat("00", 32)).ClientConfig())
+	require.ErrorContains(t, err, "pinned CA")
+
+	var seen *Peer
+	server.AddVerifier(func(peer *Peer) error {
+		seen = peer
+		return nil
+	})
+	_, err = handshake(t, server.ServerConfig(), client("spiffe://cluster.local/ns/billing/sa/worker").ClientConfig())
+	require.NoError(t, err)
+	require.Equal(t, "spiffe://cluster.local/ns/billing/sa/worker", seen.SPIFFEID)
+
+	require.True(t, matchSAN("*.svc", "api.svc"))
+	require.False(t, matchSAN("*.svc", "a.b.svc"))
+}
+
+func TestConfigValidate(t *testing.T) {
+	require.Error(t, (&Config{CertFile: "a"}).Validate())
+	require.Error(t, (&Config{CertFile: "a", Cert: "b", KeyFile: "c"}).Validate())
+	require.Error(t, (&Config{MinVersion: "1.1"}).Validate())
+	require.Error(t, (&Config{RequireClientCert: true}).Validate())
+	require.Error(t, (&Config{AllowedSPIFFEIDs: []string{"billing"}}).Validate())
+	require.Error(t, (&Config{PinnedCAs: []string{"abc"}}).Validate())
+	require.NoError(t, (&Config{Cert: "${env:CERT}", Key: "${env:KEY}", MinVersion: "1.3"}).Validate())
+}
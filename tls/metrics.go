@@ -0,0 +1,41 @@
+package infratls
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	ReloadsCounter *prometheus.CounterVec
+	ExpiryGauge    *prometheus.GaugeVec
+
+	PeerRejectedCounter *prometheus.CounterVec
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.ReloadsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "tls_reloads_total",
+			Help: "Number of certificate reloads by result: success or error",
+		}, []string{"name", "result"})
+
+		metrics.ExpiryGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "tls_certificate_expiry_timestamp_seconds",
+			Help: "Expiration time of the loaded certificate",
+		}, []string{"name"})
+
+		metrics.PeerRejectedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "tls_peer_rejected_total",
+			Help: "Number of rejected peer certificates by reason: chain, san, spiffe_id, pin or verifier",
+		}, []string{"name", "reason"})
+
+		prometheus.MustRegister(
+			metrics.ReloadsCounter,
+			metrics.ExpiryGauge,
+			metrics.PeerRejectedCounter,
+		)
+	})
+}
@@ -0,0 +1,219 @@
+package infratls
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+const spiffeScheme = "spiffe://"
+
+// Peer is the authenticated remote side of a connection
+type Peer struct {
+	Cert *x509.Certificate
+
+	// SPIFFEID is the spiffe URI SAN of the certificate, empty if there is none
+	SPIFFEID string
+}
+
+func newPeer(cert *x509.Certificate) *Peer {
+	p := &Peer{Cert: cert}
+	for _, uri := range cert.URIs {
+		if uri.Scheme == "spiffe" {
+			p.SPIFFEID = uri.String()
+			break
+		}
+	}
+	return p
+}
+
+// PeerVerifier is an additional check of a peer, e.g. a lookup of the SPIFFE ID in a registry.
+// Returned error fails the handshake.
+type PeerVerifier func(peer *Peer) error
+
+// AddVerifier adds a check that runs after the checks of the config
+func (l *Loader) AddVerifier(fn PeerVerifier) {
+	l.verifiersMu.Lock()
+	defer l.verifiersMu.Unlock()
+
+	l.verifiers = append(l.verifiers, fn)
+}
+
+// PeerFromHTTP returns the client of a request served with mutual TLS, nil if the client sent no certificate
+func PeerFromHTTP(r *http.Request) *Peer {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return nil
+	}
+	return newPeer(r.TLS.PeerCertificates[0])
+}
+
+// PeerFromGRPC returns the client of a call served with mutual TLS, nil if the client sent no certificate
+func PeerFromGRPC(ctx context.Context) *Peer {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.PeerCertificates) == 0 {
+		return nil
+	}
+	return newPeer(info.State.PeerCertificates[0])
+}
+
+// verifyClient verifies the client chain with the current CA pool and checks the client
+func (l *Loader) verifyClient(cs tls.ConnectionState) error {
+	chains, err := verifyChain(cs.PeerCertificates, l.CAPool(), "", x509.ExtKeyUsageClientAuth)
+	if err != nil {
+		return l.reject("chain", err)
+	}
+	return l.verifyPeer(cs.PeerCertificates[0], chains)
+}
+
+// verifyServer verifies the server chain with the current CA pool, if CA is configured, and checks the server.
+// The certificate must be issued for serverName or, if it's empty, for the SNI host name, an IP address is matched with IP SANs.
+func (l *Loader) verifyServer(cs tls.ConnectionState, serverName string) error {
+	chains := cs.VerifiedChains
+	if l.cfg.hasCA() && !l.cfg.InsecureSkipVerify {
+		if serverName == "" {
+			serverName = cs.ServerName
+		}
+		if serverName == "" {
+			return l.reject("chain", errors.New("unable to verify server certificate: server name is unknown, set server_name"))
+		}
+
+		var err error
+		if chains, err = verifyChain(cs.PeerCertificates, l.CAPool(), serverName, x509.ExtKeyUsageServerAuth); err != nil {
+			return l.reject("chain", err)
+		}
+	}
+	if len(cs.PeerCertificates) == 0 {
+		return l.reject("chain", errors.New("no peer certificates"))
+	}
+	if len(chains) == 0 {
+		chains = [][]*x509.Certificate{cs.PeerCertificates}
+	}
+
+	return l.verifyPeer(cs.PeerCertificates[0], chains)
+}
+
+func (l *Loader) verifyPeer(cert *x509.Certificate, chains [][]*x509.Certificate) error {
+	p := newPeer(cert)
+
+	if len(l.cfg.AllowedSANs) > 0 && !matchSANs(cert, l.cfg.AllowedSANs) {
+		return l.reject("san", errors.New("peer certificate SAN is not allowed"))
+	}
+
+	if len(l.cfg.AllowedSPIFFEIDs) > 0 && !matchSPIFFEID(p.SPIFFEID, l.cfg.AllowedSPIFFEIDs) {
+		return l.reject("spiffe_id", errors.Errorf("peer spiffe id %q is not allowed", p.SPIFFEID))
+	}
+
+	if len(l.cfg.PinnedCAs) > 0 && !matchPins(chains, l.cfg.PinnedCAs) {
+		return l.reject("pin", errors.New("peer certificate is not issued by a pinned CA"))
+	}
+
+	l.verifiersMu.RLock()
+	verifiers := l.verifiers
+	l.verifiersMu.RUnlock()
+
+	for _, fn := range verifiers {
+		if err := fn(p); err != nil {
+			return l.reject("verifier", err)
+		}
+	}
+
+	return nil
+}
+
+func (l *Loader) reject(reason string, err error) error {
+	metrics.PeerRejectedCounter.WithLabelValues(l.cfg.GetName(), reason).Inc()
+	return err
+}
+
+func matchSANs(cert *x509.Certificate, allowed []string) bool {
+	sans := make([]string, 0, len(cert.DNSNames)+len(cert.IPAddresses)+len(cert.EmailAddresses)+len(cert.URIs))
+	sans = append(sans, cert.DNSNames...)
+	sans = append(sans, cert.EmailAddresses...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		sans = append(sans, uri.String())
+	}
+
+	for _, pattern := range allowed {
+		for _, san := range sans {
+			if matchSAN(pattern, san) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchSAN matches a SAN exactly or by a "*.domain" pattern covering one label
+func matchSAN(pattern, san string) bool {
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		label, rest, found := strings.Cut(san, ".")
+		return found && label != "" && strings.EqualFold(rest, suffix)
+	}
+	return strings.EqualFold(pattern, san)
+}
+
+func matchSPIFFEID(id string, allowed []string) bool {
+	if id == "" {
+		return false
+	}
+
+	for _, pattern := range allowed {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(id, prefix) {
+				return true
+			}
+		} else if id == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+func matchPins(chains [][]*x509.Certificate, pins []string) bool {
+	pinned := make(map[[sha256.Size]byte]struct{}, len(pins))
+	for _, pin := range pins {
+		fingerprint, _ := parseFingerprint(pin)
+		pinned[fingerprint] = struct{}{}
+	}
+
+	for _, chain := range chains {
+		for _, cert := range chain {
+			if _, ok := pinned[sha256.Sum256(cert.Raw)]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseFingerprint parses a hex encoded SHA-256 fingerprint, colons are allowed: "AB:CD:..."
+func parseFingerprint(s string) ([sha256.Size]byte, error) {
+	var res [sha256.Size]byte
+
+	b, err := hex.DecodeString(strings.ReplaceAll(s, ":", ""))
+	if err != nil {
+		return res, err
+	}
+	if len(b) != sha256.Size {
+		return res, errors.New("fingerprint must be 32 bytes")
+	}
+
+	copy(res[:], b)
+	return res, nil
+}
@@ -0,0 +1,85 @@
+package infratracing
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	ExporterOTLPGRPC = "otlp_grpc"
+	ExporterOTLPHTTP = "otlp_http"
+	// ExporterJaeger sends spans to Jaeger collector with OTLP/gRPC. Jaeger accepts OTLP natively since v1.35.
+	ExporterJaeger = "jaeger"
+	// ExporterNone disables exporting, spans are still created and propagated
+	ExporterNone = "none"
+
+	SamplerAlwaysOn  = "always_on"
+	SamplerAlwaysOff = "always_off"
+	SamplerRatio     = "ratio"
+)
+
+// Config is a tracing configuration
+type Config struct {
+	// Service metadata. Empty values are taken from environment, see infralog.SetGlobalFieldsFromEnvironment
+	ServiceName    string `mapstructure:"service_name"`
+	ServiceVersion string `mapstructure:"service_version"`
+	Environment    string `mapstructure:"environment"`
+
+	// ResourceAttributes are added to every span's resource. optional
+	ResourceAttributes map[string]string `mapstructure:"resource_attributes"`
+
+	Exporter string            `mapstructure:"exporter"`
+	Endpoint string            `mapstructure:"endpoint"` // host:port, optional for OTLP
+	Insecure bool              `mapstructure:"insecure"`
+	Headers  map[string]string `mapstructure:"headers"` // optional
+	Timeout  time.Duration     `mapstructure:"timeout"` // optional, export timeout
+
+	// Sampler is applied to root spans, child spans follow parent's decision
+	Sampler     string  `mapstructure:"sampler"`
+	SampleRatio float64 `mapstructure:"sample_ratio"` // used by "ratio" sampler
+
+	BatchTimeout       time.Duration `mapstructure:"batch_timeout"`         // optional
+	MaxExportBatchSize int           `mapstructure:"max_export_batch_size"` // optional
+	MaxQueueSize       int           `mapstructure:"max_queue_size"`        // optional
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Exporter:    ExporterOTLPGRPC,
+		Sampler:     SamplerRatio,
+		SampleRatio: 0.1,
+	}
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	switch c.Exporter {
+	case ExporterOTLPGRPC, ExporterOTLPHTTP, ExporterJaeger, ExporterNone:
+	default:
+		return errors.Errorf("unknown exporter: \"%s\"", c.Exporter)
+	}
+
+	switch c.Sampler {
+	case SamplerAlwaysOn, SamplerAlwaysOff:
+	case SamplerRatio:
+		if c.SampleRatio < 0 || c.SampleRatio > 1 {
+			return errors.New("sample ratio must be in range [0, 1]")
+		}
+	default:
+		return errors.Errorf("unknown sampler: \"%s\"", c.Sampler)
+	}
+
+	if c.Timeout < 0 || c.BatchTimeout < 0 {
+		return errors.New("timeouts must be greater or equal to zero")
+	}
+
+	if c.MaxExportBatchSize < 0 || c.MaxQueueSize < 0 {
+		return errors.New("batch and queue sizes must be greater or equal to zero")
+	}
+
+	return nil
+}
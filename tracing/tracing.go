@@ -0,0 +1,208 @@
+package infratracing
+
+import (
+	"context"
+	"os"
+	"path"
+
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	infratenancy "github.com/pushwoosh/infra/tenancy"
+	infraversion "github.com/pushwoosh/infra/version"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// InstrumentationPrefix is a prefix of tracer names used by infra packages
+const InstrumentationPrefix = "github.com/pushwoosh/infra/"
+
+// Provider is a configured tracer provider. Stop flushes pending spans.
+type Provider struct {
+	tp *sdktrace.TracerProvider
+}
+
+var _ infraoperator.Stopper = (*Provider)(nil)
+
+// Setup creates a tracer provider and installs it together with W3C trace context
+// and baggage propagators as OpenTelemetry globals.
+// Add returned provider to an operator, so spans are flushed on shutdown.
+func Setup(ctx context.Context, cfg *Config) (*Provider, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	res, err := newResource(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(newSampler(cfg)),
+		sdktrace.WithSpanProcessor(infratenancy.SpanProcessor()),
+	}
+
+	if cfg.Exporter != ExporterNone {
+		exporter, err := newExporter(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		var batchOpts []sdktrace.BatchSpanProcessorOption
+		if cfg.BatchTimeout > 0 {
+			batchOpts = append(batchOpts, sdktrace.WithBatchTimeout(cfg.BatchTimeout))
+		}
+		if cfg.MaxExportBatchSize > 0 {
+			batchOpts = append(batchOpts, sdktrace.WithMaxExportBatchSize(cfg.MaxExportBatchSize))
+		}
+		if cfg.MaxQueueSize > 0 {
+			batchOpts = append(batchOpts, sdktrace.WithMaxQueueSize(cfg.MaxQueueSize))
+		}
+
+		opts = append(opts, sdktrace.WithBatcher(exporter, batchOpts...))
+	}
+
+	tp := sdktrace.NewTracerProvider(opts...)
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		infralog.Warn("opentelemetry error", zap.Error(err))
+	}))
+
+	return &Provider{tp: tp}, nil
+}
+
+// Stop flushes pending spans and shuts down the exporter
+func (p *Provider) Stop(ctx context.Context) error {
+	if err := p.tp.Shutdown(ctx); err != nil {
+		return errors.Wrap(err, "tracer provider shutdown")
+	}
+	return nil
+}
+
+// Tracer returns a tracer of an infra component, e.g. Tracer("rabbit").
+// It uses the global provider, so it's safe to call before Setup.
+func Tracer(component string) trace.Tracer {
+	return otel.Tracer(InstrumentationPrefix + component)
+}
+
+func newExporter(ctx context.Context, cfg *Config) (sdktrace.SpanExporter, error) {
+	switch cfg.Exporter {
+	case ExporterOTLPHTTP:
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
+		}
+		if cfg.Timeout > 0 {
+			opts = append(opts, otlptracehttp.WithTimeout(cfg.Timeout))
+		}
+
+		exporter, err := otlptracehttp.New(ctx, opts...)
+		if err != nil {
+			return nil, errors.Wrap(err, "otlptracehttp.New")
+		}
+		return exporter, nil
+	default:
+		var opts []otlptracegrpc.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlptracegrpc.WithHeaders(cfg.Headers))
+		}
+		if cfg.Timeout > 0 {
+			opts = append(opts, otlptracegrpc.WithTimeout(cfg.Timeout))
+		}
+
+		exporter, err := otlptracegrpc.New(ctx, opts...)
+		if err != nil {
+			return nil, errors.Wrap(err, "otlptracegrpc.New")
+		}
+		return exporter, nil
+	}
+}
+
+func newSampler(cfg *Config) sdktrace.Sampler {
+	switch cfg.Sampler {
+	case SamplerAlwaysOn:
+		return sdktrace.ParentBased(sdktrace.AlwaysSample())
+	case SamplerAlwaysOff:
+		return sdktrace.ParentBased(sdktrace.NeverSample())
+	default:
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))
+	}
+}
+
+func newResource(ctx context.Context, cfg *Config) (*resource.Resource, error) {
+	service := firstNonEmpty(cfg.ServiceName, os.Getenv(infralog.EnvServiceName))
+	info := infraversion.Get()
+	if service == "" && info.Path != "" {
+		service = path.Base(info.Path)
+	}
+	version := firstNonEmpty(cfg.ServiceVersion, os.Getenv(infralog.EnvServiceVersion), info.Version)
+
+	attrs := []attribute.KeyValue{
+		semconv.ServiceName(service),
+	}
+	if version != "" {
+		attrs = append(attrs, semconv.ServiceVersion(version))
+	}
+	if info.Commit != "" {
+		attrs = append(attrs, attribute.String("build.commit", info.Commit))
+	}
+	if info.BuildDate != "" {
+		attrs = append(attrs, attribute.String("build.date", info.BuildDate))
+	}
+	if env := firstNonEmpty(cfg.Environment, os.Getenv(infralog.EnvEnvironment)); env != "" {
+		attrs = append(attrs, semconv.DeploymentEnvironment(env))
+	}
+	if instance := os.Getenv(infralog.EnvInstance); instance != "" {
+		attrs = append(attrs, semconv.ServiceInstanceID(instance))
+	}
+	for k, v := range cfg.ResourceAttributes {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+		resource.WithAttributes(attrs...),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "resource.New")
+	}
+
+	return res, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
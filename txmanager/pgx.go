@@ -0,0 +1,97 @@
+package infratxmanager
+
+import (
+	"context"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/pkg/errors"
+)
+
+// PGXExecutor is implemented by *pgxpool.Pool and pgx.Tx
+type PGXExecutor interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+type pgxTxKey struct {
+	pool *pgxpool.Pool
+}
+
+// PGX is TxManager of a pgx pool, e.g. of postgres pool container
+type PGX struct {
+	pool *pgxpool.Pool
+}
+
+var _ TxManager = (*PGX)(nil)
+
+func NewPGX(pool *pgxpool.Pool) *PGX {
+	return &PGX{pool: pool}
+}
+
+func (m *PGX) Do(ctx context.Context, fn func(ctx context.Context) error, opts ...Option) error {
+	if PGXTx(ctx, m.pool) != nil {
+		return fn(ctx)
+	}
+
+	o := newOptions(opts)
+	txOpts := pgx.TxOptions{
+		IsoLevel: pgxIsolation(o.isolation),
+	}
+	if o.readOnly {
+		txOpts.AccessMode = pgx.ReadOnly
+	}
+
+	return run(ctx, o, func(ctx context.Context) error {
+		tx, err := m.pool.BeginTx(ctx, txOpts)
+		if err != nil {
+			return errors.Wrap(err, "unable to begin transaction")
+		}
+
+		// rolled back on errors and panics of fn, the panic goes on
+		committed := false
+		defer func() {
+			if !committed {
+				_ = tx.Rollback(context.WithoutCancel(ctx))
+			}
+		}()
+
+		if err = fn(context.WithValue(ctx, pgxTxKey{pool: m.pool}, tx)); err != nil {
+			return err
+		}
+
+		committed = true
+		return errors.Wrap(tx.Commit(ctx), "unable to commit transaction")
+	})
+}
+
+// PGXTx returns the transaction of pool started by PGX.Do for the context, or nil
+func PGXTx(ctx context.Context, pool *pgxpool.Pool) pgx.Tx {
+	tx, _ := ctx.Value(pgxTxKey{pool: pool}).(pgx.Tx)
+	return tx
+}
+
+// PGXFromContext returns the transaction of pool for the context, or pool itself outside transactions.
+// Repositories use it to join the ambient transaction.
+func PGXFromContext(ctx context.Context, pool *pgxpool.Pool) PGXExecutor {
+	if tx := PGXTx(ctx, pool); tx != nil {
+		return tx
+	}
+	return pool
+}
+
+func pgxIsolation(level Isolation) pgx.TxIsoLevel {
+	switch level {
+	case ReadUncommitted:
+		return pgx.ReadUncommitted
+	case ReadCommitted:
+		return pgx.ReadCommitted
+	case RepeatableRead:
+		return pgx.RepeatableRead
+	case Serializable:
+		return pgx.Serializable
+	}
+	return ""
+}
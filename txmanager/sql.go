@@ -0,0 +1,93 @@
+package infratxmanager
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+// Executor is implemented by *sql.DB and *sql.Tx
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type sqlTxKey struct {
+	db *sql.DB
+}
+
+// SQL is TxManager of a database/sql pool, e.g. of postgres or mysql containers
+type SQL struct {
+	db *sql.DB
+}
+
+var _ TxManager = (*SQL)(nil)
+
+func NewSQL(db *sql.DB) *SQL {
+	return &SQL{db: db}
+}
+
+func (m *SQL) Do(ctx context.Context, fn func(ctx context.Context) error, opts ...Option) error {
+	if SQLTx(ctx, m.db) != nil {
+		return fn(ctx)
+	}
+
+	o := newOptions(opts)
+	txOpts := &sql.TxOptions{
+		Isolation: sqlIsolation(o.isolation),
+		ReadOnly:  o.readOnly,
+	}
+
+	return run(ctx, o, func(ctx context.Context) error {
+		tx, err := m.db.BeginTx(ctx, txOpts)
+		if err != nil {
+			return errors.Wrap(err, "unable to begin transaction")
+		}
+
+		// rolled back on errors and panics of fn, the panic goes on
+		committed := false
+		defer func() {
+			if !committed {
+				_ = tx.Rollback()
+			}
+		}()
+
+		if err = fn(context.WithValue(ctx, sqlTxKey{db: m.db}, tx)); err != nil {
+			return err
+		}
+
+		committed = true
+		return errors.Wrap(tx.Commit(), "unable to commit transaction")
+	})
+}
+
+// SQLTx returns the transaction of db started by SQL.Do for the context, or nil
+func SQLTx(ctx context.Context, db *sql.DB) *sql.Tx {
+	tx, _ := ctx.Value(sqlTxKey{db: db}).(*sql.Tx)
+	return tx
+}
+
+// SQLFromContext returns the transaction of db for the context, or db itself outside transactions.
+// Repositories use it to join the ambient transaction.
+func SQLFromContext(ctx context.Context, db *sql.DB) Executor {
+	if tx := SQLTx(ctx, db); tx != nil {
+		return tx
+	}
+	return db
+}
+
+func sqlIsolation(level Isolation) sql.IsolationLevel {
+	switch level {
+	case ReadUncommitted:
+		return sql.LevelReadUncommitted
+	case ReadCommitted:
+		return sql.LevelReadCommitted
+	case RepeatableRead:
+		return sql.LevelRepeatableRead
+	case Serializable:
+		return sql.LevelSerializable
+	}
+	return sql.LevelDefault
+}
@@ -0,0 +1,129 @@
+package infratxmanager
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+	infraretry "github.com/pushwoosh/infra/retry"
+)
+
+// TxManager runs functions in transactions. Repositories join the transaction of the context,
+// so a use case spanning several repositories commits or rolls back as a whole:
+//
+//	err := tm.Do(ctx, func(ctx context.Context) error {
+//		if err := orders.Create(ctx, order); err != nil {
+//			return err
+//		}
+//		return outbox.Write(ctx, infratxmanager.SQLFromContext(ctx, db), event)
+//	}, infratxmanager.WithIsolation(infratxmanager.Serializable), infratxmanager.WithRetries(3))
+type TxManager interface {
+	// Do runs fn in a new transaction, or in the transaction of ctx if there is one.
+	// The transaction is committed if fn returns nil and rolled back otherwise.
+	// Options of nested calls are ignored, the outer transaction is not committed by them.
+	Do(ctx context.Context, fn func(ctx context.Context) error, opts ...Option) error
+}
+
+type Isolation int
+
+const (
+	IsolationDefault Isolation = iota
+	ReadUncommitted
+	ReadCommitted
+	RepeatableRead
+	Serializable
+)
+
+type Option interface {
+	apply(o *options)
+}
+
+type options struct {
+	isolation Isolation
+	readOnly  bool
+	retries   int
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt.apply(o)
+	}
+	return o
+}
+
+type optionIsolation Isolation
+
+func (opt optionIsolation) apply(o *options) {
+	o.isolation = Isolation(opt)
+}
+
+// WithIsolation sets the isolation level. By default it's the database default
+func WithIsolation(level Isolation) Option {
+	return optionIsolation(level)
+}
+
+type optionReadOnly struct{}
+
+func (opt optionReadOnly) apply(o *options) {
+	o.readOnly = true
+}
+
+// ReadOnly starts a read only transaction
+func ReadOnly() Option {
+	return optionReadOnly{}
+}
+
+type optionRetries int
+
+func (opt optionRetries) apply(o *options) {
+	o.retries = int(opt)
+}
+
+// WithRetries reruns the whole transaction up to n times on serialization failures and deadlocks.
+// fn must be safe to rerun, e.g. have no side effects outside the database.
+func WithRetries(n int) Option {
+	return optionRetries(n)
+}
+
+var retryBackoff = infraretry.Exponential{
+	Initial: 10 * time.Millisecond,
+	Max:     time.Second,
+	Jitter:  0.2,
+}
+
+// run calls attempt once or with retries on serialization failures
+func run(ctx context.Context, o *options, attempt func(ctx context.Context) error) error {
+	if o.retries <= 0 {
+		return attempt(ctx)
+	}
+
+	return infraretry.New("txmanager",
+		infraretry.WithMaxAttempts(o.retries+1),
+		infraretry.WithBackoff(retryBackoff),
+		infraretry.WithRetryable(IsSerializationFailure),
+	).Do(ctx, attempt)
+}
+
+// IsSerializationFailure returns true for errors of transactions that may succeed if rerun:
+// serialization failures and deadlocks of postgres, deadlocks and lock wait timeouts of mysql
+func IsSerializationFailure(err error) bool {
+	var pgErr interface{ SQLState() string }
+	if errors.As(err, &pgErr) {
+		switch pgErr.SQLState() {
+		case "40001", "40P01":
+			return true
+		}
+	}
+
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) {
+		switch myErr.Number {
+		case 1205, 1213:
+			return true
+		}
+	}
+
+	return false
+}
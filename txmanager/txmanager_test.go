@@ -0,0 +1,124 @@
+package infratxmanager
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+type serializationError struct{}
+
+func (serializationError) Error() string    { return "could not serialize access" }
+func (serializationError) SQLState() string { return "40001" }
+
+// fakeDriver counts transactions. Exec fails with a serialization error while failures are left
+type fakeDriver struct {
+	begins, commits, rollbacks atomic.Int32
+	failures                   atomic.Int32
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) { return &fakeConn{d: d}, nil }
+
+type fakeConn struct {
+	d *fakeDriver
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *fakeConn) Close() error                        { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	c.d.begins.Add(1)
+	return &fakeTx{d: c.d}, nil
+}
+
+func (c *fakeConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	if c.d.failures.Add(-1) >= 0 {
+		return nil, serializationError{}
+	}
+	return driver.RowsAffected(1), nil
+}
+
+type fakeTx struct {
+	d *fakeDriver
+}
+
+func (tx *fakeTx) Commit() error   { tx.d.commits.Add(1); return nil }
+func (tx *fakeTx) Rollback() error { tx.d.rollbacks.Add(1); return nil }
+
+func TestSQL(t *testing.T) {
+	d := &fakeDriver{}
+	sql.Register("txmanager_fake", d)
+
+	db, err := sql.Open("txmanager_fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	tm := NewSQL(db)
+	ctx := context.Background()
+
+	if SQLFromContext(ctx, db) != db {
+		t.Fatal("db must be used outside transactions")
+	}
+
+	err = tm.Do(ctx, func(ctx context.Context) error {
+		tx := SQLTx(ctx, db)
+		if tx == nil || SQLFromContext(ctx, db) != tx {
+			t.Fatal("transaction must be in the context")
+		}
+
+		// nested call joins the transaction
+		return tm.Do(ctx, func(ctx context.Context) error {
+			if SQLTx(ctx, db) != tx {
+				t.Fatal("nested call must join the transaction")
+			}
+			_, err := SQLFromContext(ctx, db).ExecContext(ctx, "UPDATE")
+			return err
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.begins.Load() != 1 || d.commits.Load() != 1 {
+		t.Fatalf("expected one committed transaction, got %d begins, %d commits", d.begins.Load(), d.commits.Load())
+	}
+
+	d.failures.Store(2)
+	err = tm.Do(ctx, func(ctx context.Context) error {
+		_, err := SQLFromContext(ctx, db).ExecContext(ctx, "UPDATE")
+		return err
+	}, WithIsolation(Serializable), WithRetries(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.rollbacks.Load() != 2 || d.commits.Load() != 2 {
+		t.Fatalf("expected 2 rollbacks and a commit, got %d rollbacks, %d commits", d.rollbacks.Load(), d.commits.Load())
+	}
+
+	failed := errors.New("failed")
+	if err = tm.Do(ctx, func(context.Context) error { return failed }, WithRetries(3)); err != failed {
+		t.Fatalf("expected fn error, got %v", err)
+	}
+	if d.rollbacks.Load() != 3 {
+		t.Fatal("other errors must not be retried")
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("panic must be propagated")
+			}
+		}()
+		_ = tm.Do(ctx, func(context.Context) error { panic("boom") })
+	}()
+	if d.rollbacks.Load() != 4 {
+		t.Fatal("transaction must be rolled back on panic")
+	}
+}
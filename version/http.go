@@ -0,0 +1,20 @@
+package infraversion
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Handler serves build information as JSON. Dependencies are included with ?deps=1
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res := Get()
+		if r.URL.Query().Get("deps") == "" {
+			res.Deps = nil
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(res)
+	})
+}
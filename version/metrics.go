@@ -0,0 +1,32 @@
+package infraversion
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector returns a build_info gauge with build information labels and value 1:
+//
+//	build_info{path="github.com/pushwoosh/sender",version="v1.2.3",commit="6f1c...",build_date="...",dirty="false",go_version="go1.23.3"} 1
+//
+// It's registered by inframetrics.RegisterRuntime.
+func Collector() prometheus.Collector {
+	info := Get()
+
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "build_info",
+		Help: "Build information of the binary",
+		ConstLabels: prometheus.Labels{
+			"path":       info.Path,
+			"version":    info.Version,
+			"commit":     info.Commit,
+			"build_date": info.BuildDate,
+			"dirty":      strconv.FormatBool(info.Dirty),
+			"go_version": info.GoVersion,
+		},
+	})
+	gauge.Set(1)
+
+	return gauge
+}
@@ -0,0 +1,94 @@
+package infraversion
+
+import (
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// Build metadata set with linker flags, they take precedence over build info of the binary:
+//
+//	go build -ldflags "-X github.com/pushwoosh/infra/version.Version=v1.2.3 -X github.com/pushwoosh/infra/version.Commit=$(git rev-parse HEAD)"
+var (
+	Version   string
+	Commit    string
+	BuildDate string
+)
+
+// Info is build information of the running binary
+type Info struct {
+	// Path is the main module path, e.g. github.com/pushwoosh/sender
+	Path      string `json:"path"`
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+
+	// Dirty is true if the binary was built with uncommitted changes
+	Dirty     bool   `json:"dirty"`
+	GoVersion string `json:"go_version"`
+
+	// Deps are module dependencies of the binary
+	Deps []Module `json:"deps,omitempty"`
+}
+
+type Module struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+}
+
+var (
+	infoOnce sync.Once
+	info     Info
+)
+
+// Get returns build information: linker flags first, then runtime/debug.ReadBuildInfo.
+// Version is empty for binaries built without a module version or linker flags.
+func Get() Info {
+	infoOnce.Do(func() {
+		info = read()
+	})
+	return info
+}
+
+func read() Info {
+	res := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+	}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return res
+	}
+
+	res.Path = bi.Main.Path
+	if res.Version == "" && bi.Main.Version != "(devel)" {
+		res.Version = bi.Main.Version
+	}
+
+	for _, setting := range bi.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if res.Commit == "" {
+				res.Commit = setting.Value
+			}
+		case "vcs.time":
+			if res.BuildDate == "" {
+				res.BuildDate = setting.Value
+			}
+		case "vcs.modified":
+			res.Dirty = setting.Value == "true"
+		}
+	}
+
+	for _, dep := range bi.Deps {
+		if dep.Replace != nil {
+			dep = dep.Replace
+		}
+		res.Deps = append(res.Deps, Module{Path: dep.Path, Version: dep.Version})
+	}
+
+	return res
+}
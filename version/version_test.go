@@ -0,0 +1,35 @@
+package infraversion
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	Version, Commit = "v1.2.3", "abcdef"
+	defer func() { Version, Commit = "", "" }()
+
+	info := read()
+	if info.Version != "v1.2.3" || info.Commit != "abcdef" {
+		t.Fatalf("linker flags must take precedence, got %+v", info)
+	}
+	if info.GoVersion != runtime.Version() {
+		t.Fatalf("unexpected go version %s", info.GoVersion)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+
+	var info Info
+	if err := json.NewDecoder(rec.Body).Decode(&info); err != nil {
+		t.Fatal(err)
+	}
+	if info.GoVersion == "" || info.Deps != nil {
+		t.Fatalf("unexpected response %+v", info)
+	}
+}
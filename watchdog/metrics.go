@@ -0,0 +1,40 @@
+package infrawatchdog
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	StalledGauge  *prometheus.GaugeVec
+	LastBeatGauge *prometheus.GaugeVec
+	StallsCounter *prometheus.CounterVec
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.StalledGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "watchdog_component_stalled",
+			Help: "1 if the component missed its heartbeat, 0 otherwise",
+		}, []string{"component"})
+
+		metrics.LastBeatGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "watchdog_last_beat_timestamp_seconds",
+			Help: "Unix time of the last heartbeat of the component",
+		}, []string{"component"})
+
+		metrics.StallsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "watchdog_stalls_total",
+			Help: "Number of times the component missed its heartbeat",
+		}, []string{"component"})
+
+		prometheus.MustRegister(
+			metrics.StalledGauge,
+			metrics.LastBeatGauge,
+			metrics.StallsCounter,
+		)
+	})
+}
@@ -0,0 +1,75 @@
+package infrawatchdog
+
+import (
+	"context"
+	"time"
+
+	infraclock "github.com/pushwoosh/infra/clock"
+)
+
+type Option interface {
+	apply(w *Watchdog)
+}
+
+type optionClock struct {
+	clock infraclock.Clock
+}
+
+func (opt optionClock) apply(w *Watchdog) {
+	w.clock = opt.clock
+}
+
+// WithClock sets a clock of heartbeats and checks, e.g. a fake one in tests
+func WithClock(clock infraclock.Clock) Option {
+	return optionClock{clock: clock}
+}
+
+type optionInterval time.Duration
+
+func (opt optionInterval) apply(w *Watchdog) {
+	w.interval = time.Duration(opt)
+}
+
+// WithInterval sets an interval of heartbeat checks. optional, default: 1s
+func WithInterval(interval time.Duration) Option {
+	return optionInterval(interval)
+}
+
+// ComponentOption configures a registered component
+type ComponentOption interface {
+	apply(c *component)
+}
+
+type optionOnStall func(ctx context.Context, status Status)
+
+func (opt optionOnStall) apply(c *component) {
+	c.onStall = opt
+}
+
+// WithOnStall sets a callback called when the component misses its heartbeat, e.g. to restart it.
+// It's called from the watchdog goroutine once per stall
+func WithOnStall(fn func(ctx context.Context, status Status)) ComponentOption {
+	return optionOnStall(fn)
+}
+
+type optionOnRecover func(ctx context.Context, status Status)
+
+func (opt optionOnRecover) apply(c *component) {
+	c.onRecover = opt
+}
+
+// WithOnRecover sets a callback called when a stalled component beats again
+func WithOnRecover(fn func(ctx context.Context, status Status)) ComponentOption {
+	return optionOnRecover(fn)
+}
+
+type optionFailReadiness struct{}
+
+func (opt optionFailReadiness) apply(c *component) {
+	c.failReadiness = true
+}
+
+// FailReadiness makes Check fail while the component is stalled
+func FailReadiness() ComponentOption {
+	return optionFailReadiness{}
+}
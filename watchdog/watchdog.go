@@ -0,0 +1,229 @@
+package infrawatchdog
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	infraclock "github.com/pushwoosh/infra/clock"
+	infradebug "github.com/pushwoosh/infra/debug"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	"go.uber.org/zap"
+)
+
+const defaultInterval = time.Second
+
+// Status is a heartbeat state of a component
+type Status struct {
+	Component string        `json:"component"`
+	Timeout   time.Duration `json:"timeout"`
+	LastBeat  time.Time     `json:"last_beat"`
+	Stalled   bool          `json:"stalled"`
+}
+
+type component struct {
+	name          string
+	timeout       time.Duration
+	lastBeat      atomic.Int64
+	stalled       atomic.Bool
+	failReadiness bool
+	onStall       func(ctx context.Context, status Status)
+	onRecover     func(ctx context.Context, status Status)
+}
+
+// Heartbeat is a handle of a registered component
+type Heartbeat struct {
+	w *Watchdog
+	c *component
+}
+
+// Beat reports the component is alive. It's cheap enough to be called on every loop iteration
+func (h *Heartbeat) Beat() {
+	h.c.lastBeat.Store(h.w.clock.Now().UnixNano())
+}
+
+// Unregister stops watching the component, e.g. when its loop exits normally
+func (h *Heartbeat) Unregister() {
+	h.w.mu.Lock()
+	defer h.w.mu.Unlock()
+
+	if h.w.components[h.c.name] == h.c {
+		delete(h.w.components, h.c.name)
+		metrics.StalledGauge.DeleteLabelValues(h.c.name)
+		metrics.LastBeatGauge.DeleteLabelValues(h.c.name)
+	}
+}
+
+// Watchdog detects stalled background components. Long-running loops register and beat periodically,
+// a component that doesn't beat within its timeout is stalled: it's logged, counted in metrics
+// and its callbacks are called:
+//
+//	wd := infrawatchdog.New()
+//	app.Add("watchdog", wd)
+//	healthRegistry.Register("watchdog", wd.Check)
+//
+//	hb := wd.Register("outbox relay", time.Minute, infrawatchdog.FailReadiness())
+//	defer hb.Unregister()
+//	for {
+//		hb.Beat()
+//		...
+//	}
+type Watchdog struct {
+	clock    infraclock.Clock
+	interval time.Duration
+
+	mu         sync.Mutex
+	components map[string]*component
+}
+
+var _ infraoperator.Checker = (*Watchdog)(nil)
+
+func New(opts ...Option) *Watchdog {
+	initMetrics()
+
+	w := &Watchdog{
+		clock:      infraclock.Real,
+		interval:   defaultInterval,
+		components: make(map[string]*component),
+	}
+
+	for _, opt := range opts {
+		opt.apply(w)
+	}
+
+	return w
+}
+
+// Register starts watching the component. The registration counts as the first beat.
+// A component registered again with the same name replaces the previous one
+func (w *Watchdog) Register(name string, timeout time.Duration, opts ...ComponentOption) *Heartbeat {
+	c := &component{
+		name:    name,
+		timeout: timeout,
+	}
+	for _, opt := range opts {
+		opt.apply(c)
+	}
+	c.lastBeat.Store(w.clock.Now().UnixNano())
+
+	w.mu.Lock()
+	w.components[name] = c
+	w.mu.Unlock()
+
+	metrics.StalledGauge.WithLabelValues(name).Set(0)
+
+	return &Heartbeat{w: w, c: c}
+}
+
+// Run checks heartbeats until ctx is canceled
+func (w *Watchdog) Run(ctx context.Context) error {
+	ticker := w.clock.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C():
+			w.check(ctx)
+		}
+	}
+}
+
+// Check returns an error if a component registered with FailReadiness is stalled.
+// It's a health check for the readiness probe
+func (w *Watchdog) Check(context.Context) error {
+	w.mu.Lock()
+	var stalled []string
+	for _, c := range w.components {
+		if c.failReadiness && c.stalled.Load() {
+			stalled = append(stalled, c.name)
+		}
+	}
+	w.mu.Unlock()
+
+	if len(stalled) > 0 {
+		sort.Strings(stalled)
+		return errors.Errorf("stalled components: %s", strings.Join(stalled, ", "))
+	}
+	return nil
+}
+
+// Status returns states of all components sorted by name
+func (w *Watchdog) Status() []Status {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	statuses := make([]Status, 0, len(w.components))
+	for _, c := range w.components {
+		statuses = append(statuses, c.status())
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Component < statuses[j].Component
+	})
+
+	return statuses
+}
+
+// DebugHandler shows states of components, register it with infradebug.Register
+func (w *Watchdog) DebugHandler() http.Handler {
+	return infradebug.JSON(func(*http.Request) (interface{}, error) {
+		return w.Status(), nil
+	})
+}
+
+func (w *Watchdog) check(ctx context.Context) {
+	w.mu.Lock()
+	components := make([]*component, 0, len(w.components))
+	for _, c := range w.components {
+		components = append(components, c)
+	}
+	w.mu.Unlock()
+
+	now := w.clock.Now()
+	for _, c := range components {
+		lastBeat := time.Unix(0, c.lastBeat.Load())
+		metrics.LastBeatGauge.WithLabelValues(c.name).Set(float64(lastBeat.Unix()))
+
+		stalled := now.Sub(lastBeat) > c.timeout
+		if stalled == c.stalled.Load() {
+			continue
+		}
+		c.stalled.Store(stalled)
+		status := c.status()
+
+		if stalled {
+			infralog.ErrorCtx(ctx, "watchdog: component stalled",
+				zap.String("component", c.name),
+				zap.Duration("timeout", c.timeout),
+				zap.Time("last_beat", lastBeat))
+			metrics.StalledGauge.WithLabelValues(c.name).Set(1)
+			metrics.StallsCounter.WithLabelValues(c.name).Inc()
+			if c.onStall != nil {
+				c.onStall(ctx, status)
+			}
+			continue
+		}
+
+		infralog.InfoCtx(ctx, "watchdog: component recovered", zap.String("component", c.name))
+		metrics.StalledGauge.WithLabelValues(c.name).Set(0)
+		if c.onRecover != nil {
+			c.onRecover(ctx, status)
+		}
+	}
+}
+
+func (c *component) status() Status {
+	return Status{
+		Component: c.name,
+		Timeout:   c.timeout,
+		LastBeat:  time.Unix(0, c.lastBeat.Load()),
+		Stalled:   c.stalled.Load(),
+	}
+}
@@ -0,0 +1,60 @@
+package infrawatchdog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	infraclock "github.com/pushwoosh/infra/clock"
+)
+
+func TestWatchdog(t *testing.T) {
+	ctx := context.Background()
+	clock := infraclock.NewFake(time.Now())
+	w := New(WithClock(clock))
+
+	var stalls, recoveries int
+	hb := w.Register("relay", time.Minute,
+		FailReadiness(),
+		WithOnStall(func(context.Context, Status) { stalls++ }),
+		WithOnRecover(func(context.Context, Status) { recoveries++ }))
+	w.Register("cleanup", time.Minute)
+
+	clock.Advance(30 * time.Second)
+	hb.Beat()
+	clock.Advance(45 * time.Second)
+	w.check(ctx)
+
+	// cleanup is stalled but doesn't fail readiness
+	if err := w.Check(ctx); err != nil {
+		t.Fatalf("unexpected readiness error %v", err)
+	}
+
+	clock.Advance(time.Minute)
+	w.check(ctx)
+	w.check(ctx)
+
+	if err := w.Check(ctx); err == nil || err.Error() != "stalled components: relay" {
+		t.Fatalf("expected relay to fail readiness, got %v", err)
+	}
+	if stalls != 1 {
+		t.Fatalf("expected one stall callback, got %d", stalls)
+	}
+
+	hb.Beat()
+	w.check(ctx)
+
+	if err := w.Check(ctx); err != nil || recoveries != 1 {
+		t.Fatalf("expected recovery, got %v and %d recoveries", err, recoveries)
+	}
+
+	statuses := w.Status()
+	if len(statuses) != 2 || statuses[0].Component != "cleanup" || !statuses[0].Stalled {
+		t.Fatalf("unexpected statuses %+v", statuses)
+	}
+
+	hb.Unregister()
+	if len(w.Status()) != 1 {
+		t.Fatal("expected relay to be unregistered")
+	}
+}
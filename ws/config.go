@@ -0,0 +1,78 @@
+package infraws
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultSendQueueSize  = 256
+	defaultWriteTimeout   = 10 * time.Second
+	defaultPingInterval   = 30 * time.Second
+	defaultMaxMessageSize = 1 << 20
+)
+
+type Config struct {
+	// Messages queued for sending to one connection. optional, default: 256
+	SendQueueSize int `mapstructure:"send_queue_size"`
+
+	// DropOnFull drops messages to a connection with a full queue instead of closing it. optional
+	DropOnFull bool `mapstructure:"drop_on_full"`
+
+	// Timeout of writing one message. optional, default: 10s
+	WriteTimeout time.Duration `mapstructure:"write_timeout"`
+
+	// How often pings are sent, a connection without pongs for two intervals is closed. optional, default: 30s
+	PingInterval time.Duration `mapstructure:"ping_interval"`
+
+	// Max size of an incoming message in bytes. optional, default: 1MB
+	MaxMessageSize int64 `mapstructure:"max_message_size"`
+
+	// Allowed Origin header values, "*" allows any. optional, same origin only if empty
+	AllowedOrigins []string `mapstructure:"allowed_origins"`
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("empty config")
+	}
+
+	if c.SendQueueSize < 0 || c.MaxMessageSize < 0 {
+		return errors.New("sizes should not be negative")
+	}
+
+	if c.WriteTimeout < 0 || c.PingInterval < 0 {
+		return errors.New("intervals should not be negative")
+	}
+
+	return nil
+}
+
+func (c *Config) GetSendQueueSize() int {
+	if c.SendQueueSize == 0 {
+		return defaultSendQueueSize
+	}
+	return c.SendQueueSize
+}
+
+func (c *Config) GetWriteTimeout() time.Duration {
+	if c.WriteTimeout == 0 {
+		return defaultWriteTimeout
+	}
+	return c.WriteTimeout
+}
+
+func (c *Config) GetPingInterval() time.Duration {
+	if c.PingInterval == 0 {
+		return defaultPingInterval
+	}
+	return c.PingInterval
+}
+
+func (c *Config) GetMaxMessageSize() int64 {
+	if c.MaxMessageSize == 0 {
+		return defaultMaxMessageSize
+	}
+	return c.MaxMessageSize
+}
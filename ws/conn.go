@@ -0,0 +1,286 @@
+package infraws
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrClosed    = errors.New("connection is closed")
+	ErrQueueFull = errors.New("send queue is full")
+)
+
+type MessageType int
+
+const (
+	TextMessage   = MessageType(websocket.TextMessage)
+	BinaryMessage = MessageType(websocket.BinaryMessage)
+)
+
+type Message struct {
+	Type MessageType
+	Data []byte
+}
+
+const (
+	reasonClient   = "client"
+	reasonServer   = "server"
+	reasonError    = "error"
+	reasonSlow     = "slow"
+	reasonShutdown = "shutdown"
+)
+
+// Conn is an open websocket connection. Send is safe for concurrent use
+type Conn struct {
+	id      string
+	server  *Server
+	ws      *websocket.Conn
+	request *http.Request
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	send      chan Message
+	writeDone chan struct{}
+
+	closeOnce   sync.Once
+	closed      chan struct{}
+	closeReason string
+	closeCode   int
+	closeText   string
+}
+
+func newConn(s *Server, ws *websocket.Conn, r *http.Request) *Conn {
+	// the request context is cancelled after the hijack, values like auth claims are kept
+	ctx, cancel := context.WithCancel(context.WithoutCancel(r.Context()))
+
+	id := uuid.NewString()
+	ctx = infralog.WithField(ctx, zap.String("ws_conn_id", id))
+
+	return &Conn{
+		id:        id,
+		server:    s,
+		ws:        ws,
+		request:   r,
+		ctx:       ctx,
+		cancel:    cancel,
+		send:      make(chan Message, s.cfg.GetSendQueueSize()),
+		writeDone: make(chan struct{}),
+		closed:    make(chan struct{}),
+	}
+}
+
+// ID is a random unique id of the connection
+func (c *Conn) ID() string {
+	return c.id
+}
+
+// Request is the upgraded http request, its body must not be read
+func (c *Conn) Request() *http.Request {
+	return c.request
+}
+
+// Context carries values of the upgraded request and is cancelled when the connection is closed
+func (c *Conn) Context() context.Context {
+	return c.ctx
+}
+
+// SetContext replaces the connection context, e.g. to attach values in the OnConnect hook
+func (c *Conn) SetContext(ctx context.Context) {
+	c.ctx = ctx
+}
+
+// Send queues the message. If the queue is full the message is dropped with DropOnFull,
+// otherwise the slow connection is closed. Both return ErrQueueFull
+func (c *Conn) Send(typ MessageType, data []byte) error {
+	select {
+	case <-c.closed:
+		return ErrClosed
+	default:
+	}
+
+	select {
+	case c.send <- Message{Type: typ, Data: data}:
+		return nil
+	case <-c.closed:
+		return ErrClosed
+	default:
+	}
+
+	if c.server.cfg.DropOnFull {
+		metrics.DroppedCounter.WithLabelValues(c.server.name).Inc()
+		return ErrQueueFull
+	}
+
+	c.close(reasonSlow, websocket.ClosePolicyViolation, "too slow")
+	return ErrQueueFull
+}
+
+// SendText queues a text message
+func (c *Conn) SendText(text string) error {
+	return c.Send(TextMessage, []byte(text))
+}
+
+// SendJSON queues v encoded to json as a text message
+func (c *Conn) SendJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrap(err, "unable to encode message")
+	}
+	return c.Send(TextMessage, data)
+}
+
+// Close sends queued messages and a normal close frame, the connection is closed when the peer answers it
+func (c *Conn) Close() {
+	c.close(reasonServer, websocket.CloseNormalClosure, "")
+}
+
+// Done is closed when the connection is closed
+func (c *Conn) Done() <-chan struct{} {
+	return c.closed
+}
+
+func (c *Conn) shutdown() {
+	c.close(reasonShutdown, websocket.CloseGoingAway, "server is stopping")
+}
+
+// close marks the connection as closed, the write loop sends the close frame unless code is 0
+func (c *Conn) close(reason string, code int, text string) {
+	c.closeOnce.Do(func() {
+		c.closeReason = reason
+		c.closeCode = code
+		c.closeText = text
+		close(c.closed)
+		c.cancel()
+	})
+}
+
+func (c *Conn) reason() string {
+	<-c.closed
+	return c.closeReason
+}
+
+func (c *Conn) closeWith(code int, text string) {
+	deadline := time.Now().Add(c.server.cfg.GetWriteTimeout())
+	_ = c.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), deadline)
+}
+
+func (c *Conn) readLoop() {
+	defer c.ws.Close()
+
+	cfg := c.server.cfg
+	pongTimeout := 2 * cfg.GetPingInterval()
+
+	c.ws.SetReadLimit(cfg.GetMaxMessageSize())
+	_ = c.ws.SetReadDeadline(time.Now().Add(pongTimeout))
+	c.ws.SetPongHandler(func(string) error {
+		select {
+		case <-c.closed:
+			// the deadline of the close answer is kept
+			return nil
+		default:
+			return c.ws.SetReadDeadline(time.Now().Add(pongTimeout))
+		}
+	})
+
+	for {
+		typ, data, err := c.ws.ReadMessage()
+		if err != nil {
+			select {
+			case <-c.closed:
+				// the peer has answered the close frame or hasn't in time
+			default:
+				if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseNoStatusReceived) {
+					c.close(reasonClient, 0, "")
+				} else {
+					infralog.DebugCtx(c.ctx, "websocket read failed", zap.Error(err))
+					c.close(reasonError, 0, "")
+				}
+			}
+			break
+		}
+
+		select {
+		case <-c.closed:
+			// messages are drained until the close answer
+			continue
+		default:
+		}
+
+		metrics.MessagesCounter.WithLabelValues(c.server.name, "in").Inc()
+		c.server.handler(c.ctx, c, Message{Type: MessageType(typ), Data: data})
+	}
+
+	<-c.writeDone
+}
+
+func (c *Conn) writeLoop() {
+	defer close(c.writeDone)
+
+	cfg := c.server.cfg
+	ticker := time.NewTicker(cfg.GetPingInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case msg := <-c.send:
+			if err := c.write(msg); err != nil {
+				c.fail()
+				return
+			}
+		case <-ticker.C:
+			deadline := time.Now().Add(cfg.GetWriteTimeout())
+			if err := c.ws.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+				c.fail()
+				return
+			}
+		case <-c.closed:
+			if c.closeCode == 0 {
+				return
+			}
+			c.flush()
+			c.closeWith(c.closeCode, c.closeText)
+			// the read loop waits for the close answer until the deadline
+			_ = c.ws.SetReadDeadline(time.Now().Add(cfg.GetWriteTimeout()))
+			return
+		}
+	}
+}
+
+func (c *Conn) write(msg Message) error {
+	_ = c.ws.SetWriteDeadline(time.Now().Add(c.server.cfg.GetWriteTimeout()))
+	if err := c.ws.WriteMessage(int(msg.Type), msg.Data); err != nil {
+		return err
+	}
+	metrics.MessagesCounter.WithLabelValues(c.server.name, "out").Inc()
+	return nil
+}
+
+// fail closes the socket after a write error, it unblocks the read loop
+func (c *Conn) fail() {
+	c.close(reasonError, 0, "")
+	_ = c.ws.Close()
+}
+
+// flush writes messages queued before a graceful close
+func (c *Conn) flush() {
+	for {
+		select {
+		case msg := <-c.send:
+			if err := c.write(msg); err != nil {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
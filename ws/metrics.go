@@ -0,0 +1,47 @@
+package infraws
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics struct {
+	ConnectionsGauge  *prometheus.GaugeVec
+	MessagesCounter   *prometheus.CounterVec
+	DroppedCounter    *prometheus.CounterVec
+	DisconnectCounter *prometheus.CounterVec
+}
+
+var metricsOnce sync.Once
+
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.ConnectionsGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "websocket_connections",
+			Help: "Number of open websocket connections",
+		}, []string{"server"})
+
+		metrics.MessagesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "websocket_messages_total",
+			Help: "Number of websocket messages by direction: in or out",
+		}, []string{"server", "direction"})
+
+		metrics.DroppedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "websocket_dropped_messages_total",
+			Help: "Number of outgoing messages dropped because of a full send queue",
+		}, []string{"server"})
+
+		metrics.DisconnectCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "websocket_disconnects_total",
+			Help: "Number of closed connections by reason: client, server, error, slow or shutdown",
+		}, []string{"server", "reason"})
+
+		prometheus.MustRegister(
+			metrics.ConnectionsGauge,
+			metrics.MessagesCounter,
+			metrics.DroppedCounter,
+			metrics.DisconnectCounter,
+		)
+	})
+}
@@ -0,0 +1,265 @@
+package infraws
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	infralog "github.com/pushwoosh/infra/log"
+	infraoperator "github.com/pushwoosh/infra/operator"
+	"go.uber.org/zap"
+)
+
+// Handler handles an incoming message of a connection. Messages of one connection are handled sequentially
+type Handler func(ctx context.Context, c *Conn, msg Message)
+
+type Option interface {
+	apply(s *Server)
+}
+
+type optionName struct {
+	name string
+}
+
+func (opt optionName) apply(s *Server) {
+	s.name = opt.name
+}
+
+// WithName sets the server label of metrics, default: "default"
+func WithName(name string) Option {
+	return optionName{name: name}
+}
+
+type optionOnConnect struct {
+	fn func(c *Conn) error
+}
+
+func (opt optionOnConnect) apply(s *Server) {
+	s.onConnect = opt.fn
+}
+
+// WithOnConnect sets a hook called after the upgrade before reading messages, an error closes the connection
+func WithOnConnect(fn func(c *Conn) error) Option {
+	return optionOnConnect{fn: fn}
+}
+
+type optionOnClose struct {
+	fn func(c *Conn)
+}
+
+func (opt optionOnClose) apply(s *Server) {
+	s.onClose = opt.fn
+}
+
+// WithOnClose sets a hook called after the connection is closed and removed from the registry
+func WithOnClose(fn func(c *Conn)) Option {
+	return optionOnClose{fn: fn}
+}
+
+// Server upgrades http requests to websocket connections and keeps a registry of open connections.
+// It's a http.Handler, so it's mounted on an infrahttp server and shares its middlewares, e.g. auth:
+//
+//	ws := infraws.NewServer(cfg, func(ctx context.Context, c *infraws.Conn, msg infraws.Message) {
+//		...
+//	})
+//	mux.Handle("/ws", ws)
+//	app.Add("http", httpServer)
+//	app.Add("ws", ws) // stopped before the http server
+//	...
+//	ws.Broadcast(infraws.TextMessage, data)
+//
+// Hijacked connections are not closed by http.Server.Shutdown, Stop closes them with a going away frame.
+type Server struct {
+	cfg      *Config
+	name     string
+	handler  Handler
+	upgrader websocket.Upgrader
+
+	onConnect func(c *Conn) error
+	onClose   func(c *Conn)
+
+	mu      sync.RWMutex
+	conns   map[*Conn]struct{}
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+var (
+	_ http.Handler          = (*Server)(nil)
+	_ infraoperator.Stopper = (*Server)(nil)
+)
+
+func NewServer(cfg *Config, handler Handler, opts ...Option) (*Server, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	if handler == nil {
+		return nil, errors.New("handler is mandatory")
+	}
+
+	initMetrics()
+
+	s := &Server{
+		cfg:     cfg,
+		name:    "default",
+		handler: handler,
+		conns:   make(map[*Conn]struct{}),
+	}
+
+	for _, opt := range opts {
+		opt.apply(s)
+	}
+
+	s.upgrader = websocket.Upgrader{
+		HandshakeTimeout: cfg.GetWriteTimeout(),
+	}
+	if len(cfg.AllowedOrigins) > 0 {
+		s.upgrader.CheckOrigin = s.checkOrigin
+	}
+
+	return s, nil
+}
+
+func (s *Server) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || slices.Contains(s.cfg.AllowedOrigins, "*") {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	return slices.Contains(s.cfg.AllowedOrigins, origin) || slices.Contains(s.cfg.AllowedOrigins, u.Host)
+}
+
+// ServeHTTP upgrades the request and serves the connection until it's closed
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		http.Error(w, "server is stopping", http.StatusServiceUnavailable)
+		return
+	}
+	s.wg.Add(1)
+	s.mu.Unlock()
+	defer s.wg.Done()
+
+	ws, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// upgrader has already responded with an error
+		infralog.Debug("unable to upgrade websocket connection", zap.Error(err))
+		return
+	}
+
+	c := newConn(s, ws, r)
+
+	if s.onConnect != nil {
+		if err = s.onConnect(c); err != nil {
+			c.cancel()
+			c.closeWith(websocket.ClosePolicyViolation, err.Error())
+			_ = ws.Close()
+			return
+		}
+	}
+
+	s.add(c)
+	defer s.remove(c)
+
+	go c.writeLoop()
+	c.readLoop()
+}
+
+func (s *Server) add(c *Conn) {
+	s.mu.Lock()
+	s.conns[c] = struct{}{}
+	s.mu.Unlock()
+
+	metrics.ConnectionsGauge.WithLabelValues(s.name).Inc()
+}
+
+func (s *Server) remove(c *Conn) {
+	s.mu.Lock()
+	delete(s.conns, c)
+	s.mu.Unlock()
+
+	metrics.ConnectionsGauge.WithLabelValues(s.name).Dec()
+	metrics.DisconnectCounter.WithLabelValues(s.name, c.reason()).Inc()
+
+	if s.onClose != nil {
+		s.onClose(c)
+	}
+}
+
+// Len returns the number of open connections
+func (s *Server) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.conns)
+}
+
+// Conns returns a snapshot of open connections
+func (s *Server) Conns() []*Conn {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	conns := make([]*Conn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	return conns
+}
+
+// Broadcast queues the message to all open connections and returns the number of connections it was queued to
+func (s *Server) Broadcast(typ MessageType, data []byte) int {
+	return s.BroadcastFunc(nil, typ, data)
+}
+
+// BroadcastFunc queues the message to open connections matching the filter, nil filter matches all
+func (s *Server) BroadcastFunc(filter func(c *Conn) bool, typ MessageType, data []byte) int {
+	n := 0
+	for _, c := range s.Conns() {
+		if filter != nil && !filter(c) {
+			continue
+		}
+		if err := c.Send(typ, data); err == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Stop rejects new connections, closes open ones with a going away frame and waits until they are done
+func (s *Server) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	s.stopped = true
+	s.mu.Unlock()
+
+	for _, c := range s.Conns() {
+		c.shutdown()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		// peers which haven't answered the close frame are cut off
+		for _, c := range s.Conns() {
+			_ = c.ws.Close()
+		}
+		return errors.Wrap(ctx.Err(), "unable to close websocket connections in time")
+	}
+}
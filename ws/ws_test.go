@@ -0,0 +1,107 @@
+package infraws
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	infrahttp "github.com/pushwoosh/infra/http"
+	"github.com/stretchr/testify/require"
+)
+
+func dial(t *testing.T, srv *httptest.Server) *websocket.Conn {
+	t.Helper()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ws.Close() })
+
+	return ws
+}
+
+func TestServer(t *testing.T) {
+	closed := make(chan string, 2)
+	s, err := NewServer(&Config{}, func(ctx context.Context, c *Conn, msg Message) {
+		require.NoError(t, c.Send(msg.Type, msg.Data))
+	}, WithName("test"), WithOnClose(func(c *Conn) {
+		closed <- c.ID()
+	}))
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(infrahttp.NewServer(&infrahttp.Config{}, s).Handler())
+	defer srv.Close()
+
+	first := dial(t, srv)
+	second := dial(t, srv)
+	require.Eventually(t, func() bool { return s.Len() == 2 }, time.Second, 10*time.Millisecond)
+
+	// echo
+	require.NoError(t, first.WriteMessage(websocket.TextMessage, []byte("ping")))
+	typ, data, err := first.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, websocket.TextMessage, typ)
+	require.Equal(t, "ping", string(data))
+
+	require.Equal(t, 2, s.Broadcast(BinaryMessage, []byte("all")))
+	for _, ws := range []*websocket.Conn{first, second} {
+		typ, data, err = ws.ReadMessage()
+		require.NoError(t, err)
+		require.Equal(t, websocket.BinaryMessage, typ)
+		require.Equal(t, "all", string(data))
+	}
+
+	// client close
+	require.NoError(t, second.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")))
+	<-closed
+	require.Equal(t, 1, s.Len())
+
+	// graceful shutdown
+	stopped := make(chan error)
+	go func() {
+		stopped <- s.Stop(context.Background())
+	}()
+
+	_, _, err = first.ReadMessage()
+	require.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), err)
+	require.NoError(t, <-stopped)
+	require.Equal(t, 0, s.Len())
+
+	_, _, err = websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	require.ErrorIs(t, err, websocket.ErrBadHandshake)
+}
+
+func TestSlowConnection(t *testing.T) {
+	connected := make(chan *Conn, 1)
+	s, err := NewServer(&Config{SendQueueSize: 1}, func(context.Context, *Conn, Message) {}, WithOnConnect(func(c *Conn) error {
+		connected <- c
+		return nil
+	}))
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	dial(t, srv)
+	c := <-connected
+
+	// the client never reads, sooner or later the queue is full
+	payload := make([]byte, 64<<10)
+	for i := 0; ; i++ {
+		require.Less(t, i, 10000)
+		if err = c.Send(BinaryMessage, payload); err != nil {
+			break
+		}
+	}
+	require.ErrorIs(t, err, ErrQueueFull)
+	require.ErrorIs(t, c.Send(BinaryMessage, payload), ErrClosed)
+
+	select {
+	case <-c.Done():
+	case <-time.After(time.Second):
+		t.Fatal("slow connection is not closed")
+	}
+	require.Equal(t, reasonSlow, c.reason())
+}